@@ -12378,31 +12378,6 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/payment/callback/wechat": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "支付"
-                ],
-                "summary": "微信支付回调",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        },
         "/api/v1/payment/refund": {
             "post": {
                 "security": [
@@ -14559,70 +14534,6 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/venue/nearby": {
-            "get": {
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "场地"
-                ],
-                "summary": "获取附近场地",
-                "parameters": [
-                    {
-                        "type": "number",
-                        "description": "经度",
-                        "name": "longitude",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "number",
-                        "description": "纬度",
-                        "name": "latitude",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "number",
-                        "default": 5,
-                        "description": "搜索半径(公里)",
-                        "name": "radius",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 20,
-                        "description": "返回数量",
-                        "name": "limit",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/response.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/device.VenueListItem"
-                                            }
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            }
-        },
         "/api/v1/venue/search": {
             "get": {
                 "produces": [
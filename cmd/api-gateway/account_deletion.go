@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	userService "github.com/dumeirei/smart-locker-backend/internal/service/user"
+)
+
+// accountDeletionCheckInterval 账号注销检查间隔，每天执行一次
+const accountDeletionCheckInterval = time.Hour
+
+// startAccountDeletion 每日执行冷静期已结束的账号注销申请，ctx 取消后退出
+func startAccountDeletion(ctx context.Context, userSvc *userService.UserService, logger *zap.Logger) {
+	go func() {
+		ticker := time.NewTicker(accountDeletionCheckInterval)
+		defer ticker.Stop()
+
+		var lastDate string
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				today := time.Now().Format("2006-01-02")
+				if today == lastDate {
+					continue
+				}
+
+				completed, err := userSvc.ProcessDueDeletions(ctx)
+				if err != nil {
+					logger.Error("执行账号注销失败", zap.String("date", today), zap.Int("completed", completed), zap.Error(err))
+					continue
+				}
+				lastDate = today
+				logger.Info("账号注销执行完成", zap.String("date", today), zap.Int("completed", completed))
+			}
+		}
+	}()
+}
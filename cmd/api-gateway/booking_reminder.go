@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/config"
+	hotelService "github.com/dumeirei/smart-locker-backend/internal/service/hotel"
+	"github.com/dumeirei/smart-locker-backend/pkg/push"
+)
+
+// bookingReminderInterval 预订入住提醒检查间隔
+const bookingReminderInterval = 5 * time.Minute
+
+// startBookingReminders 定期向即将入住的预订推送入住提醒，未配置推送服务时不启动，ctx 取消后退出
+func startBookingReminders(ctx context.Context, cfg *config.Config, db *gorm.DB, logger *zap.Logger) {
+	if cfg.Push.FCMServerKey == "" {
+		logger.Info("未配置推送服务，预订入住提醒未启动")
+		return
+	}
+	job := hotelService.NewNotificationJob(db, push.NewFCMNotifier(&push.FCMConfig{ServerKey: cfg.Push.FCMServerKey}))
+
+	go func() {
+		ticker := time.NewTicker(bookingReminderInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sent, err := job.SendBookingReminders(ctx)
+				if err != nil {
+					logger.Error("预订入住提醒发送失败", zap.Int("sent", sent), zap.Error(err))
+				} else if sent > 0 {
+					logger.Info("已发送预订入住提醒", zap.Int("sent", sent))
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/config"
+	deviceService "github.com/dumeirei/smart-locker-backend/internal/service/device"
+	"github.com/dumeirei/smart-locker-backend/pkg/mqtt"
+)
+
+// defaultMQTTPort MQTT Broker 默认端口
+const defaultMQTTPort = 1883
+
+// newDeviceCommandClient 创建设备指令下发客户端
+// 调试模式或未配置 Broker 时使用 Mock，否则连接 MQTT Broker 并在 ctx 取消时断开
+func newDeviceCommandClient(ctx context.Context, cfg *config.Config, logger *zap.Logger) deviceService.DeviceCommandClient {
+	if cfg.IsDebug() || cfg.MQTT.Broker == "" {
+		return deviceService.NewMockDeviceCommandClient() // 开发环境使用 Mock
+	}
+
+	host, port := parseMQTTBroker(cfg.MQTT.Broker)
+	client := mqtt.NewClient(&mqtt.Config{
+		Broker:        host,
+		Port:          port,
+		ClientID:      cfg.MQTT.ClientIDPrefix + "api-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		Username:      cfg.MQTT.Username,
+		Password:      cfg.MQTT.Password,
+		CleanSession:  true,
+		QoS:           cfg.MQTT.QoS,
+		KeepAlive:     cfg.MQTT.KeepAlive,
+		AutoReconnect: cfg.MQTT.AutoReconnect,
+	})
+	if err := client.Connect(); err != nil {
+		// 连接失败时开锁指令会下发失败并回滚预订状态，不影响服务启动
+		logger.Error("Failed to connect MQTT broker", zap.String("broker", cfg.MQTT.Broker), zap.Error(err))
+	}
+
+	go func() {
+		<-ctx.Done()
+		client.Disconnect()
+	}()
+
+	return deviceService.NewMQTTDeviceCommandClient(client)
+}
+
+// parseMQTTBroker 解析 Broker 地址（如 tcp://localhost:1883），返回主机和端口
+func parseMQTTBroker(broker string) (string, int) {
+	u, err := url.Parse(broker)
+	if err != nil || u.Host == "" {
+		return broker, defaultMQTTPort
+	}
+	port := defaultMQTTPort
+	if p, err := strconv.Atoi(u.Port()); err == nil {
+		port = p
+	}
+	return u.Hostname(), port
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	financeService "github.com/dumeirei/smart-locker-backend/internal/service/finance"
+)
+
+// financeSummaryCheckInterval 财务日汇总检查间隔，跨天后汇总前一日
+const financeSummaryCheckInterval = time.Hour
+
+// startFinanceDailySummary 启动时及每日跨天后汇总财务日汇总，ctx 取消后退出
+func startFinanceDailySummary(ctx context.Context, statisticsSvc *financeService.StatisticsService, logger *zap.Logger) {
+	go func() {
+		ticker := time.NewTicker(financeSummaryCheckInterval)
+		defer ticker.Stop()
+
+		var lastDate string
+		for {
+			if today := time.Now().Format("2006-01-02"); today != lastDate {
+				days, err := statisticsSvc.AggregateDailySummaries(ctx, time.Now())
+				if err != nil {
+					logger.Error("财务日汇总失败", zap.Error(err))
+				} else {
+					lastDate = today
+					logger.Info("财务日汇总完成", zap.Int("days", days))
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
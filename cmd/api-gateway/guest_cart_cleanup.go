@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	mallService "github.com/dumeirei/smart-locker-backend/internal/service/mall"
+)
+
+// guestCartCleanupInterval 过期游客购物车清理间隔
+const guestCartCleanupInterval = time.Hour
+
+// startGuestCartCleanup 定期清理超过保留期未修改的游客购物车，ctx 取消后退出
+func startGuestCartCleanup(ctx context.Context, cartSvc *mallService.CartService, logger *zap.Logger) {
+	go func() {
+		ticker := time.NewTicker(guestCartCleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				deleted, err := cartSvc.CleanupExpiredGuestCarts(ctx)
+				if err != nil {
+					logger.Error("清理过期游客购物车失败", zap.Error(err))
+					continue
+				}
+				if deleted > 0 {
+					logger.Info("清理过期游客购物车完成", zap.Int64("deleted_items", deleted))
+				}
+			}
+		}
+	}()
+}
@@ -26,6 +26,7 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // 内置时区数据，酒店/场地时区换算不依赖系统 zoneinfo
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -86,8 +87,12 @@ func main() {
 	// 创建 Gin 引擎
 	engine := gin.New()
 
+	// 后台任务上下文，服务关闭时取消
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
 	// 设置路由
-	setupRouter(engine, cfg, log, db, redisClient)
+	shutdownRouter := setupRouter(bgCtx, engine, cfg, log, db, redisClient)
 
 	// 创建 HTTP 服务器
 	srv := &http.Server{
@@ -114,6 +119,9 @@ func main() {
 
 	log.Info("Shutting down server...")
 
+	// 停止后台任务
+	stopBackground()
+
 	// 创建超时上下文用于优雅关闭
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -123,6 +131,11 @@ func main() {
 		log.Error("Server forced to shutdown", zap.Error(err))
 	}
 
+	// 写入缓冲区中剩余的操作审计日志，须在关闭数据库连接前完成
+	if err := shutdownRouter(ctx); err != nil {
+		log.Error("Failed to flush audit logs", zap.Error(err))
+	}
+
 	// 关闭数据库连接
 	sqlDB, _ := db.DB()
 	if sqlDB != nil {
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/config"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	orderService "github.com/dumeirei/smart-locker-backend/internal/service/order"
+)
+
+// defaultOrderExpiryInterval 未配置检查间隔时的超时订单检查间隔
+const defaultOrderExpiryInterval = time.Minute
+
+// startOrderPaymentExpiry 定期取消超时未支付的订单，ctx 取消后退出
+// handlers 按订单类型释放订单占用的资源，酒店预订由超时服务直接释放；租借订单使用租借支付超时配置
+func startOrderPaymentExpiry(ctx context.Context, cfg *config.Config, db *gorm.DB,
+	handlers map[string]orderService.ExpiredOrderHandler, logger *zap.Logger) {
+	expirySvc := orderService.NewOrderExpiryService(db)
+	expirySvc.SetPaymentTTL(cfg.Business.Order.PaymentTTLMinutes)
+	expirySvc.SetOrderTypePaymentTTL(models.OrderTypeRental, cfg.Business.Rental.RentalPaymentTimeoutMinutes)
+	for orderType, handler := range handlers {
+		expirySvc.SetExpiredOrderHandler(orderType, handler)
+	}
+
+	interval := time.Duration(cfg.Business.Order.ExpiryCheckInterval) * time.Minute
+	if interval <= 0 {
+		interval = defaultOrderExpiryInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				expired, err := expirySvc.ProcessExpiredOrders(ctx)
+				if err != nil {
+					logger.Error("超时未支付订单取消失败", zap.Int("expired", expired), zap.Error(err))
+				} else if expired > 0 {
+					logger.Info("已取消超时未支付订单", zap.Int("expired", expired))
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	rentalService "github.com/dumeirei/smart-locker-backend/internal/service/rental"
+)
+
+// preauthSettlementRetryInterval 押金预授权渠道结算重试间隔
+const preauthSettlementRetryInterval = time.Minute
+
+// startPreauthSettlementRetry 定期重试提交渠道调用失败的押金预授权扣款及解冻，ctx 取消后退出
+// 未配置预授权服务时每次检查直接返回
+func startPreauthSettlementRetry(ctx context.Context, rentalSvc *rentalService.RentalService, logger *zap.Logger) {
+	go func() {
+		ticker := time.NewTicker(preauthSettlementRetryInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				completed, err := rentalSvc.CompletePendingPreauth(ctx)
+				if err != nil {
+					logger.Error("押金预授权结算重试失败", zap.Int("completed", completed), zap.Error(err))
+				} else if completed > 0 {
+					logger.Info("已完成押金预授权结算重试", zap.Int("completed", completed))
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	financeService "github.com/dumeirei/smart-locker-backend/internal/service/finance"
+)
+
+// reconciliationCheckInterval 每日对账检查间隔，跨天后对前一日进行对账
+const reconciliationCheckInterval = time.Hour
+
+// startDailyReconciliation 每日对前一天的支付、订单与钱包流水进行对账，ctx 取消后退出
+func startDailyReconciliation(ctx context.Context, reconciliationSvc *financeService.ReconciliationService, logger *zap.Logger) {
+	go func() {
+		ticker := time.NewTicker(reconciliationCheckInterval)
+		defer ticker.Stop()
+
+		var lastDate string
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				yesterday := time.Now().AddDate(0, 0, -1)
+				if yesterday.Format("2006-01-02") == lastDate {
+					continue
+				}
+
+				result, err := reconciliationSvc.RunDaily(ctx, yesterday)
+				if err != nil {
+					logger.Error("每日对账失败", zap.String("date", yesterday.Format("2006-01-02")), zap.Error(err))
+					continue
+				}
+				lastDate = result.Date
+				if len(result.Issues) > 0 {
+					logger.Warn("每日对账发现差异", zap.String("date", result.Date), zap.Int("issues", len(result.Issues)))
+				} else {
+					logger.Info("每日对账完成", zap.String("date", result.Date),
+						zap.Int("payments", result.PaymentsChecked), zap.Int("orders", result.OrdersChecked))
+				}
+			}
+		}
+	}()
+}
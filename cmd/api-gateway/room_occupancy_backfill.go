@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// startRoomOccupancyBackfill 启动时为位图表上线前已存在的预订回填房间占用位图
+// 只处理没有任何位图记录的房间，已回填后再次启动不会重复重建
+func startRoomOccupancyBackfill(ctx context.Context, occupancyRepo *repository.RoomOccupancyRepository, logger *zap.Logger) {
+	go func() {
+		rebuilt, err := occupancyRepo.RebuildMissing(ctx)
+		if err != nil {
+			logger.Error("回填房间占用位图失败", zap.Int("rebuilt", rebuilt), zap.Error(err))
+			return
+		}
+		if rebuilt > 0 {
+			logger.Info("已回填房间占用位图", zap.Int("rebuilt", rebuilt))
+		}
+	}()
+}
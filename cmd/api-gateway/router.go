@@ -2,6 +2,8 @@
 package main
 
 import (
+	"context"
+	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,6 +13,7 @@ import (
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
+	"github.com/dumeirei/smart-locker-backend/internal/common/cache"
 	"github.com/dumeirei/smart-locker-backend/internal/common/config"
 	"github.com/dumeirei/smart-locker-backend/internal/common/crypto"
 	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
@@ -23,22 +26,26 @@ import (
 	hotelHandler "github.com/dumeirei/smart-locker-backend/internal/handler/hotel"
 	mallHandler "github.com/dumeirei/smart-locker-backend/internal/handler/mall"
 	marketingHandler "github.com/dumeirei/smart-locker-backend/internal/handler/marketing"
+	merchantHandler "github.com/dumeirei/smart-locker-backend/internal/handler/merchant"
 	orderHandler "github.com/dumeirei/smart-locker-backend/internal/handler/order"
 	paymentHandler "github.com/dumeirei/smart-locker-backend/internal/handler/payment"
 	rentalHandler "github.com/dumeirei/smart-locker-backend/internal/handler/rental"
 	uploadHandler "github.com/dumeirei/smart-locker-backend/internal/handler/upload"
 	userHandler "github.com/dumeirei/smart-locker-backend/internal/handler/user"
 	userMiddleware "github.com/dumeirei/smart-locker-backend/internal/middleware"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 	adminService "github.com/dumeirei/smart-locker-backend/internal/service/admin"
 	authService "github.com/dumeirei/smart-locker-backend/internal/service/auth"
 	contentService "github.com/dumeirei/smart-locker-backend/internal/service/content"
 	deviceService "github.com/dumeirei/smart-locker-backend/internal/service/device"
 	distributionService "github.com/dumeirei/smart-locker-backend/internal/service/distribution"
+	eventService "github.com/dumeirei/smart-locker-backend/internal/service/event"
 	financeService "github.com/dumeirei/smart-locker-backend/internal/service/finance"
 	hotelService "github.com/dumeirei/smart-locker-backend/internal/service/hotel"
 	mallService "github.com/dumeirei/smart-locker-backend/internal/service/mall"
 	marketingService "github.com/dumeirei/smart-locker-backend/internal/service/marketing"
+	merchantService "github.com/dumeirei/smart-locker-backend/internal/service/merchant"
 	orderService "github.com/dumeirei/smart-locker-backend/internal/service/order"
 	paymentService "github.com/dumeirei/smart-locker-backend/internal/service/payment"
 	rentalService "github.com/dumeirei/smart-locker-backend/internal/service/rental"
@@ -50,13 +57,16 @@ import (
 )
 
 // setupRouter 设置路由
+// ctx 用于控制后台任务的生命周期，服务关闭时取消；
+// 返回的关闭函数在 HTTP 服务器关闭后调用，写入缓冲区中剩余的操作审计日志
 func setupRouter(
+	ctx context.Context,
 	r *gin.Engine,
 	cfg *config.Config,
 	logger *zap.Logger,
 	db *gorm.DB,
 	redisClient *redis.Client,
-) {
+) func(context.Context) error {
 	// 创建 JWT 管理器
 	jwtManager := jwt.NewManager(&jwt.Config{
 		Secret:            cfg.JWT.Secret,
@@ -65,6 +75,14 @@ func setupRouter(
 		Issuer:            cfg.JWT.Issuer,
 	})
 
+	// 令牌吊销：记录签发的令牌，支持刷新令牌轮换、退出登录与强制下线
+	tokenStore := authService.NewTokenStore(db, redisClient)
+	jwtManager.SetRevocationStore(tokenStore)
+	startTokenRevocationSync(ctx, jwtManager, tokenStore, logger)
+
+	// 回填位图表上线前已存在预订的房间占用位图
+	startRoomOccupancyBackfill(ctx, repository.NewRoomOccupancyRepository(db), logger)
+
 	// 初始化仓储
 	userRepo := repository.NewUserRepository(db)
 	deviceRepo := repository.NewDeviceRepository(db)
@@ -83,6 +101,7 @@ func setupRouter(
 	hotelRepo := repository.NewHotelRepository(db)
 	roomRepo := repository.NewRoomRepository(db)
 	roomTimeSlotRepo := repository.NewRoomTimeSlotRepository(db)
+	roomPriceOverrideRepo := repository.NewRoomPriceOverrideRepository(db)
 	bookingRepo := repository.NewBookingRepository(db)
 
 	// 分销相关仓储
@@ -93,7 +112,9 @@ func setupRouter(
 	// 营销相关仓储
 	couponRepo := repository.NewCouponRepository(db)
 	userCouponRepo := repository.NewUserCouponRepository(db)
+	couponGrantBatchRepo := repository.NewCouponGrantBatchRepository(db)
 	campaignRepo := repository.NewCampaignRepository(db)
+	campaignProductRepo := repository.NewCampaignProductRepository(db)
 
 	// 内容相关仓储
 	bannerRepo := repository.NewBannerRepository(db)
@@ -102,9 +123,13 @@ func setupRouter(
 	memberLevelRepo := repository.NewMemberLevelRepository(db)
 	memberPackageRepo := repository.NewMemberPackageRepository(db)
 
+	// 幂等键仓储
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+
 	// 初始化外部服务客户端
 	smsClient := sms.NewMockSender() // 开发环境使用 Mock，生产环境使用阿里云
 	wechatPayClient, _ := wechatpay.NewClient(&wechatpay.Config{})
+	deviceCommandClient := newDeviceCommandClient(ctx, cfg, logger)
 
 	// 初始化 OSS 上传器
 	var ossUploader oss.Uploader
@@ -122,6 +147,9 @@ func setupRouter(
 		ossUploader = oss.NewMockUploader() // 开发环境使用 Mock
 	}
 
+	// 初始化 AES 加密器（用于敏感数据加密）
+	aesEncryptor, _ := crypto.NewAES(cfg.Crypto.AESKey)
+
 	// 初始化服务
 	codeService := authService.NewCodeService(redisClient, smsClient, &authService.CodeServiceConfig{
 		CodeLength: 6,
@@ -132,57 +160,175 @@ func setupRouter(
 	wechatSvc := authService.NewWechatService(&authService.WechatConfig{}, db, userRepo, jwtManager)
 
 	userSvc := userService.NewUserService(db, userRepo)
-	walletSvc := userService.NewWalletService(db, userRepo)
+	// 注销完成后吊销用户全部登录会话
+	userSvc.SetTokenRevoker(jwtManager)
+	startAccountDeletion(ctx, userSvc, logger)
+	walletSvc := userService.NewWalletService(db, userRepo, wechatPayClient)
 	uploadSvc := uploadService.NewUploadService(ossUploader, userRepo)
 
 	// 会员相关服务
 	pointsSvc := userService.NewPointsService(db, userRepo, memberLevelRepo)
+	pointsSvc.SetPointsRate(cfg.Business.Member.PointsRate)
+	// 订单完成后按实付金额发放消费积分、为邀请链上的分销商生成佣金，退款时撤销
+	pointsHook := orderService.NewPointsHook(db, pointsSvc)
+	commissionSvc := distributionService.NewCommissionService(commissionRepo, distributorRepo, userRepo, db)
+	orderEvents := orderService.NewCompositeOrderEventHandler(pointsHook, orderService.NewOrderCompleteHook(commissionSvc))
 	memberLevelSvc := userService.NewMemberLevelService(db, userRepo, memberLevelRepo)
 	memberPackageSvc := userService.NewMemberPackageService(db, userRepo, memberPackageRepo, memberLevelRepo, orderRepo, pointsSvc)
 
 	deviceSvc := deviceService.NewDeviceService(db, deviceRepo, venueRepo)
+	deviceSvc.SetStatusPublisher(deviceService.NewRedisStatusPublisher(redisClient))
+	// 超过 3 个心跳间隔未上报心跳的设备自动标记为离线
+	offlineDetectionJob := deviceService.NewOfflineDetectionJob(deviceSvc, deviceRepo, time.Duration(cfg.Device.HeartbeatInterval)*time.Second, deviceService.DefaultOfflineDetectionPoll, logger)
+	offlineDetectionJob.Start(ctx)
 	venueSvc := deviceService.NewVenueService(db, venueRepo, deviceRepo)
 
-	rentalSvc := rentalService.NewRentalService(db, rentalRepo, deviceRepo, deviceSvc, walletSvc, nil)
-	paymentSvc := paymentService.NewPaymentService(db, paymentRepo, refundRepo, rentalRepo, wechatPayClient)
+	idempotencySvc := paymentService.NewIdempotencyService(idempotencyRepo)
+	deviceLocker := cache.NewLocker(redisClient)
+	// 租借、预订状态实时推送：事件经 Redis 频道扇出到所有实例
+	statusBus := eventService.NewStatusBus(redisClient, logger)
+	if err := statusBus.Start(ctx); err != nil {
+		logger.Warn("订阅状态事件频道失败，租借状态实时推送不可用", zap.Error(err))
+	}
+	rentalSvc := rentalService.NewRentalService(db, rentalRepo, deviceRepo, deviceSvc, walletSvc, nil, idempotencySvc, deviceLocker)
+	rentalSvc.SetOrderEventHandler(orderEvents)
+	rentalSvc.SetStatusEventPublisher(statusBus)
+	rentalSvc.SetMaxConcurrentRentals(cfg.Business.Rental.MaxConcurrentRentals)
+	rentalSvc.SetRentalLimitStore(rentalService.NewRentalLimitStore(redisClient))
+	memberLevelCache := repository.NewCachedMemberLevelRepository(memberLevelRepo, redisClient)
+	pricingCache := repository.NewCachedRentalPricingRepository(deviceRepo, redisClient)
+	rentalSvc.SetMemberLevelCache(memberLevelCache)
+	rentalSvc.SetPricingCache(pricingCache)
+	pricingScheduleRepo := repository.NewPricingScheduleRepository(db)
+	rentalSvc.SetPricingScheduleRepository(pricingScheduleRepo)
+	rentalSvc.SetPointsService(pointsSvc)
+	startPreauthSettlementRetry(ctx, rentalSvc, logger)
+	subscriptionSvc := rentalService.NewSubscriptionService(db, rentalSvc)
+	startSubscriptionRenewal(ctx, subscriptionSvc, logger)
+	paymentSvc := paymentService.NewPaymentService(db, paymentRepo, refundRepo, rentalRepo, wechatPayClient, idempotencySvc)
 
 	// 商城服务
 	productSvc := mallService.NewProductService(db, productRepo, categoryRepo, productSkuRepo)
 	cartSvc := mallService.NewCartService(db, cartRepo, productRepo, productSkuRepo)
-	mallOrderSvc := mallService.NewMallOrderService(db, orderRepo, cartRepo, productRepo, productSkuRepo, productSvc)
+	startGuestCartCleanup(ctx, cartSvc, logger)
+	mallOrderSvc := mallService.NewMallOrderService(db, orderRepo, cartRepo, productRepo, productSkuRepo, productSvc, refundRepo, paymentRepo)
+	mallOrderSvc.SetOrderEventHandler(orderEvents)
+	mallOrderSvc.SetPointsService(pointsSvc)
+	mallOrderSvc.SetCommissionRecalculator(commissionSvc)
+	orderNoteSvc := orderService.NewOrderNoteService(db, repository.NewOrderNoteRepository(db))
+	mallOrderSvc.SetOrderNoteService(orderNoteSvc)
+	rentalSvc.SetOrderNoteService(orderNoteSvc)
 	reviewSvc := mallService.NewReviewService(db, reviewRepo, orderRepo)
 	searchSvc := mallService.NewSearchService(db, productRepo)
+	// PostgreSQL 使用全文检索，其他数据库使用倒排索引；商品管理维护索引使用同一后端
+	productSearchBackend := mallService.NewSearchBackendForDialect(db, productRepo)
+	searchSvc.SetSearchBackend(productSearchBackend)
 
 	// 退款服务
 	refundSvc := orderService.NewRefundService(db, refundRepo, orderRepo, paymentRepo)
+	// 管理员发起的退款：余额支付退回钱包，微信支付走渠道退款，退款后按剩余实付金额重算佣金
+	refundSvc.SetWalletRefunder(walletSvc)
+	if wechatPayClient != nil {
+		refundSvc.SetRefundGateway(wechatPayClient)
+	}
+	refundSvc.SetCommissionRecalculator(commissionSvc)
 
 	// 酒店服务
 	hotelCodeSvc := hotelService.NewCodeService()
+	roomPricing := hotelService.NewPricingResolver(roomPriceOverrideRepo)
 	hotelSvc := hotelService.NewHotelService(db, hotelRepo, roomRepo, roomTimeSlotRepo)
-	bookingSvc := hotelService.NewBookingService(db, bookingRepo, roomRepo, hotelRepo, orderRepo, roomTimeSlotRepo, hotelCodeSvc, deviceSvc, nil)
+	hotelSvc.SetPricingResolver(roomPricing)
+	bookingSvc := hotelService.NewBookingService(db, bookingRepo, roomRepo, hotelRepo, orderRepo, roomTimeSlotRepo, hotelCodeSvc, deviceSvc, deviceCommandClient)
+	bookingSvc.SetPricingResolver(roomPricing)
+	bookingSvc.SetStatusEventPublisher(statusBus)
+	bookingSvc.SetUnlockAttemptGuard(hotelService.NewUnlockAttemptGuard(db, redisClient, hotelService.DefaultMaxUnlockAttempts, logger))
+	bookingSvc.SetWalletService(walletSvc)
+	bookingSvc.SetOrderEventHandler(orderEvents)
+	bookingSvc.SetPointsService(pointsSvc)
+	bookingSvc.SetOrderNoteService(orderNoteSvc)
+	// 入住前 30 分钟推送入住提醒
+	startBookingReminders(ctx, cfg, db, logger)
+
+	// 支付通知服务（按订单类型分发支付成功事件）
+	paymentCallbackSvc := paymentService.NewPaymentCallbackService(db, newWechatNotifyVerifier(cfg, logger),
+		map[string]paymentService.PaymentSuccessHandler{
+			models.OrderTypeRental: rentalSvc,
+			models.OrderTypeMall:   mallOrderSvc,
+			models.OrderTypeHotel:  bookingSvc,
+		}, walletSvc)
+
+	// 待支付订单超时取消（按订单类型释放设备槽位、商品库存等资源）
+	startOrderPaymentExpiry(ctx, cfg, db, map[string]orderService.ExpiredOrderHandler{
+		models.OrderTypeRental: rentalSvc,
+		models.OrderTypeMall:   mallOrderSvc,
+		models.OrderTypeHotel:  bookingSvc,
+	}, logger)
 
 	// 分销服务
 	distributorSvc := distributionService.NewDistributorService(distributorRepo, userRepo, db)
-	commissionSvc := distributionService.NewCommissionService(commissionRepo, distributorRepo, userRepo, db)
 	inviteSvc := distributionService.NewInviteService(distributorRepo, "") // BaseURL 在 InviteService 中有默认值
 	withdrawSvc := distributionService.NewWithdrawService(withdrawalRepo, distributorRepo, userRepo, db)
 
 	// 营销服务
 	couponSvc := marketingService.NewCouponService(db, couponRepo, userCouponRepo)
 	userCouponSvc := marketingService.NewUserCouponService(db, couponRepo, userCouponRepo)
-	campaignSvc := marketingService.NewCampaignService(campaignRepo)
+	campaignSvc := marketingService.NewCampaignService(db, campaignRepo)
+	flashSaleSvc := marketingService.NewFlashSaleService(campaignRepo, campaignProductRepo)
+	flashSaleSvc.SetRedis(redisClient)
+	campaignSvc.SetFlashSaleService(flashSaleSvc)
+	giftCampaignSvc := marketingService.NewGiftCampaignService(db, campaignRepo)
+
+	// 商城下单通过优惠计算器按会员等级折扣打折并匹配满赠活动
+	discountCalc := orderService.NewDiscountCalculator(couponSvc, campaignSvc)
+	discountCalc.SetMemberDiscountService(orderService.NewMemberDiscountService(db, userRepo, memberLevelRepo))
+	discountCalc.SetGiftCampaignService(giftCampaignSvc)
+	mallOrderSvc.SetDiscountCalculator(discountCalc)
+	// 秒杀商品按秒杀价下单并预占秒杀库存，订单取消或超时未支付时释放
+	mallOrderSvc.SetCampaignService(campaignSvc)
 
 	// 内容服务
 	bannerSvc := contentService.NewBannerService(bannerRepo)
 
+	// 商户入驻申请（资料文件仅允许本存储及配置的域名）
+	merchantApplicationRepo := repository.NewMerchantApplicationRepository(db)
+	merchantApplicationSvc := merchantService.NewMerchantApplicationService(db, merchantApplicationRepo, aesEncryptor,
+		append([]string{oss.URLHost(ossUploader)}, cfg.OSS.AllowedHosts...))
+	// 商户门户登录（入驻通过的用户换取商户令牌）
+	merchantPortalAuthSvc := merchantService.NewMerchantPortalAuthService(merchantApplicationRepo, repository.NewMerchantRepository(db), jwtManager)
+
 	// 初始化处理器
 	authH := authHandler.NewHandler(authSvc, wechatSvc, codeService)
+	authH.SetCartService(cartSvc)
 	userH := userHandler.NewHandler(userSvc, walletSvc)
 	uploadH := uploadHandler.NewHandler(uploadSvc)
 	memberH := userHandler.NewMemberHandler(memberLevelSvc, memberPackageSvc, pointsSvc)
+	merchantApplicationH := userHandler.NewMerchantApplicationHandler(merchantApplicationSvc)
+	merchantAuthH := merchantHandler.NewAuthHandler(merchantPortalAuthSvc)
 	deviceH := deviceHandler.NewHandler(deviceSvc, venueSvc)
 	rentalH := rentalHandler.NewHandler(rentalSvc)
+	if fontPath := cfg.Business.Invoice.FontPath; fontPath != "" {
+		if _, err := os.Stat(fontPath); err != nil {
+			logger.Warn("发票字体文件不可用，发票和收据将无法生成", zap.String("font_path", fontPath), zap.Error(err))
+		}
+	}
+	invoiceSvc := rentalService.NewInvoiceService(db, rentalRepo, ossUploader, rentalService.InvoiceConfig{
+		CompanyName: cfg.Business.Invoice.CompanyName,
+		LogoPath:    cfg.Business.Invoice.LogoPath,
+		FontPath:    cfg.Business.Invoice.FontPath,
+		TaxRate:     cfg.Business.Invoice.TaxRate,
+		StorageDir:  cfg.Business.Invoice.StorageDir,
+	})
+	invoiceSvc.SetCache(redisClient)
+	rentalH.SetInvoiceService(invoiceSvc)
+	rentalH.SetReceiptService(rentalService.NewReceiptService(db, rentalRepo, ossUploader, rentalService.ReceiptConfig{
+		CompanyName: cfg.Business.Invoice.CompanyName,
+		FontPath:    cfg.Business.Invoice.FontPath,
+		StorageDir:  cfg.Business.Invoice.ReceiptStorageDir,
+	}))
+	rentalH.SetSubscriptionService(subscriptionSvc)
+	rentalEventsH := rentalHandler.NewEventsHandler(rentalSvc, statusBus)
 	paymentH := paymentHandler.NewHandler(paymentSvc)
+	paymentNotifyH := paymentHandler.NewNotifyHandler(paymentCallbackSvc)
 
 	// 商城处理器
 	mallProductH := mallHandler.NewProductHandler(productSvc, searchSvc)
@@ -192,6 +338,7 @@ func setupRouter(
 
 	// 退款处理器
 	refundH := orderHandler.NewRefundHandler(refundSvc)
+	orderNoteH := orderHandler.NewNoteHandler(orderNoteSvc)
 
 	// 酒店处理器
 	hotelH := hotelHandler.NewHandler(hotelSvc)
@@ -202,7 +349,8 @@ func setupRouter(
 
 	// 营销处理器
 	couponH := marketingHandler.NewCouponHandler(couponSvc, userCouponSvc)
-	_ = campaignSvc // 活动服务将来用于扩展
+	campaignH := marketingHandler.NewCampaignHandler(flashSaleSvc)
+	campaignH.SetCampaignService(campaignSvc)
 
 	// 内容处理器
 	bannerH := contentHandler.NewBannerHandler(bannerSvc)
@@ -268,10 +416,27 @@ func setupRouter(
 			public.GET("/search/suggestions", mallProductH.GetSearchSuggestions)
 			public.GET("/products/:id/reviews", reviewH.GetProductReviews)
 			public.GET("/products/:id/review-stats", reviewH.GetProductReviewStats)
+
+			// 营销活动公开接口
+			public.GET("/campaigns/:id/flash-sale/status", campaignH.GetFlashSaleStatus)
+			public.GET("/campaigns/:id/products", campaignH.ListFlashSaleProducts)
 		}
 
 		// 支付回调（需要验签，不需要认证）
-		paymentH.RegisterCallbackRoutes(v1)
+		paymentNotifyH.RegisterRoutes(v1)
+
+		// 购物车（登录用户或携带 X-Cart-Token 的游客）
+		cart := v1.Group("/cart")
+		cart.Use(userMiddleware.UserOrGuestCartAuth(jwtManager))
+		{
+			cart.GET("", cartH.GetCart)
+			cart.POST("", cartH.AddItem)
+			cart.PUT("/:id", cartH.UpdateItem)
+			cart.DELETE("/:id", cartH.RemoveItem)
+			cart.DELETE("", cartH.ClearCart)
+			cart.PUT("/select-all", cartH.SelectAll)
+			cart.GET("/count", cartH.GetCartCount)
+		}
 
 		// 用户端接口（需要用户认证）
 		user := v1.Group("")
@@ -290,8 +455,16 @@ func setupRouter(
 			// 会员路由
 			memberH.RegisterRoutes(user)
 
+			// 商户入驻申请及商户门户登录
+			merchantApplicationH.RegisterRoutes(user)
+			merchantAuthH.RegisterRoutes(user)
+
+			// 创建订单类接口的幂等保护（客户端携带 Idempotency-Key 时生效）
+			idempotent := userMiddleware.IdempotencyMiddleware(redisClient, paymentService.DefaultIdempotencyTTL)
+
 			// 租借路由
-			rentalH.RegisterRoutes(user)
+			rentalH.RegisterRoutes(user, idempotent)
+			rentalEventsH.RegisterRoutes(user)
 
 			// 支付路由（带限流保护）
 			payment := user.Group("/payment")
@@ -307,22 +480,16 @@ func setupRouter(
 			user.PUT("/addresses/:id", placeholderHandler("更新地址"))
 			user.DELETE("/addresses/:id", placeholderHandler("删除地址"))
 
-			// 购物车
-			user.GET("/cart", cartH.GetCart)
-			user.POST("/cart", cartH.AddItem)
-			user.PUT("/cart/:id", cartH.UpdateItem)
-			user.DELETE("/cart/:id", cartH.RemoveItem)
-			user.DELETE("/cart", cartH.ClearCart)
-			user.PUT("/cart/select-all", cartH.SelectAll)
-			user.GET("/cart/count", cartH.GetCartCount)
-
 			// 商城订单
 			user.GET("/orders", mallOrderH.GetOrders)
-			user.POST("/orders", mallOrderH.CreateOrder)
-			user.POST("/orders/from-cart", mallOrderH.CreateOrderFromCart)
+			user.POST("/orders", idempotent, mallOrderH.CreateOrder)
+			user.POST("/orders/from-cart", idempotent, mallOrderH.CreateOrderFromCart)
 			user.GET("/orders/:id", mallOrderH.GetOrderDetail)
 			user.POST("/orders/:id/cancel", mallOrderH.CancelOrder)
 			user.POST("/orders/:id/confirm", mallOrderH.ConfirmReceive)
+			user.POST("/orders/:id/refund", mallOrderH.RequestRefund)
+			user.GET("/orders/:id/notes", orderNoteH.ListNotes)
+			user.POST("/orders/:id/notes", orderNoteH.AddNote)
 
 			// 退款
 			user.GET("/refunds", refundH.GetRefunds)
@@ -341,7 +508,7 @@ func setupRouter(
 				// 可领取的优惠券
 				marketing.GET("/coupons", couponH.GetCouponList)
 				marketing.GET("/coupons/:id", couponH.GetCouponDetail)
-				marketing.POST("/coupons/:id/receive", couponH.ReceiveCoupon)
+				marketing.POST("/coupons/:id/receive", userMiddleware.CouponReceiveRateLimit(redisClient), couponH.ReceiveCoupon)
 
 				// 用户优惠券
 				marketing.GET("/user-coupons", couponH.GetUserCoupons)
@@ -349,6 +516,7 @@ func setupRouter(
 				marketing.GET("/user-coupons/for-order", couponH.GetAvailableCouponsForOrder)
 				marketing.GET("/user-coupons/count", couponH.GetCouponCountByStatus)
 				marketing.GET("/user-coupons/:id", couponH.GetUserCouponDetail)
+				marketing.POST("/user-coupons/:id/transfer", couponH.TransferCoupon)
 			}
 
 			// 酒店/预订相关
@@ -361,12 +529,15 @@ func setupRouter(
 			user.GET("/rooms/hot", hotelH.GetHotRooms)
 			user.GET("/rooms/:id", hotelH.GetRoomDetail)
 			user.GET("/rooms/:id/availability", hotelH.CheckRoomAvailability)
+			user.GET("/rooms/:id/calendar", hotelH.GetRoomCalendar)
 			user.GET("/rooms/:id/time-slots", hotelH.GetRoomTimeSlots)
-			user.POST("/bookings", bookingH.CreateBooking)
+			user.POST("/bookings", idempotent, bookingH.CreateBooking)
 			user.GET("/bookings", bookingH.GetMyBookings)
 			user.GET("/bookings/:id", bookingH.GetBookingDetail)
 			user.GET("/bookings/no/:booking_no", bookingH.GetBookingByNo)
 			user.POST("/bookings/:id/cancel", bookingH.CancelBooking)
+			user.POST("/bookings/:id/refund", bookingH.RequestRefund)
+			user.PUT("/bookings/:id/reschedule", bookingH.RescheduleBooking)
 			user.POST("/bookings/unlock", bookingH.UnlockByCode)
 
 			// 分销相关
@@ -374,6 +545,7 @@ func setupRouter(
 			{
 				distribution.GET("/check", distributionH.CheckStatus)
 				distribution.POST("/apply", distributionH.Apply)
+				distribution.POST("/bind", distributionH.BindInviter)
 				distribution.GET("/info", distributionH.GetInfo)
 				distribution.GET("/dashboard", distributionH.GetDashboard)
 				distribution.GET("/team/stats", distributionH.GetTeamStats)
@@ -394,16 +566,38 @@ func setupRouter(
 			user.GET("/feedbacks", placeholderHandler("获取我的反馈"))
 		}
 
-		// 设备接口（需要设备认证，后续实现）
+		// 设备接口（设备签名认证）
 		device := v1.Group("/device")
+		device.Use(userMiddleware.DeviceAuth(cfg.Device.Secret, userMiddleware.DefaultDeviceAuthMaxSkew))
 		{
-			device.POST("/heartbeat", placeholderHandler("设备心跳"))
+			device.POST("/heartbeat", deviceH.Heartbeat)
 			device.POST("/status", placeholderHandler("上报状态"))
 			device.POST("/event", placeholderHandler("上报事件"))
 		}
 	}
 
+	// 商户开放接口密钥（管理端签发，商户门户签名认证）
+	merchantAPIKeySvc := merchantService.NewMerchantAPIKeyService(repository.NewMerchantAPIKeyRepository(db), repository.NewMerchantRepository(db), aesEncryptor)
+
+	// 商户自助门户开放接口（HMAC 签名认证，仅返回当前商户的数据）
+	merchantAPI := r.Group("/api/merchant/v1")
+	merchantAPI.Use(userMiddleware.MerchantAPIAuth(merchantAPIKeySvc, redisClient, userMiddleware.DefaultMerchantAPIMaxSkew))
+	{
+		merchantPortalH := merchantHandler.NewPortalHandler(merchantService.NewMerchantPortalService(db))
+		merchantPortalH.RegisterRoutes(merchantAPI)
+	}
+
+	// 商户门户接口（商户 JWT 认证，仅返回当前商户的数据）
+	merchantPortal := r.Group("/api/merchant")
+	merchantPortal.Use(userMiddleware.MerchantAuth(jwtManager))
+	{
+		merchantSettlementH := merchantHandler.NewSettlementHandler(financeService.NewMerchantSettlementService(repository.NewSettlementRepository(db)))
+		merchantSettlementH.RegisterRoutes(merchantPortal)
+	}
+
 	// 管理后台 API
+	operationLogRepo := repository.NewOperationLogRepository(db)
+	operationLogWriter := middleware.NewOperationLogWriter(operationLogRepo, cfg.AuditLog.BufferSize, cfg.AuditLog.BatchSize, cfg.AuditLog.FlushIntervalDuration())
 	admin := r.Group("/api/admin")
 	{
 		// 初始化管理员相关仓储
@@ -411,52 +605,124 @@ func setupRouter(
 		roleRepo := repository.NewRoleRepository(db)
 		permissionRepo := repository.NewPermissionRepository(db)
 		merchantRepo := repository.NewMerchantRepository(db)
+		deviceSlotRepo := repository.NewDeviceSlotRepository(db)
 		deviceLogRepo := repository.NewDeviceLogRepository(db)
 		deviceMaintenanceRepo := repository.NewDeviceMaintenanceRepository(db)
 		deviceAlertRepo := repository.NewDeviceAlertRepository(db)
-		operationLogRepo := repository.NewOperationLogRepository(db)
-
-		// 初始化 AES 加密器（用于敏感数据加密）
-		aesEncryptor, _ := crypto.NewAES(cfg.Crypto.AESKey)
 
 		// 初始化管理员服务
 		adminAuthSvc := adminService.NewAdminAuthService(adminRepo, jwtManager)
-		_ = adminService.NewPermissionService(roleRepo, permissionRepo, adminRepo)
-		deviceAdminSvc := adminService.NewDeviceAdminService(deviceRepo, deviceLogRepo, deviceMaintenanceRepo, venueRepo, nil)
+		permissionSvc := adminService.NewPermissionService(roleRepo, permissionRepo, adminRepo)
+		deviceAdminSvc := adminService.NewDeviceAdminService(deviceRepo, deviceSlotRepo, deviceLogRepo, deviceMaintenanceRepo, venueRepo, nil)
+		adminNotificationSvc := contentService.NewNotificationService(repository.NewNotificationRepository(db))
+		deviceAdminSvc.SetAdminNotifier(adminNotificationSvc)
 		venueAdminSvc := adminService.NewVenueAdminService(venueRepo, merchantRepo, deviceRepo)
+		venueAdminSvc.SetPricingCache(pricingCache)
+		venueAdminSvc.SetPricingScheduleRepository(pricingScheduleRepo)
 		merchantAdminSvc := adminService.NewMerchantAdminService(merchantRepo, aesEncryptor)
 		_ = adminService.NewDeviceAlertService(deviceRepo, deviceLogRepo, deviceAlertRepo) // 告警服务（后续集成使用）
 		productAdminSvc := adminService.NewProductAdminService(db, categoryRepo, productRepo, productSkuRepo)
+		productAdminSvc.SetSearchIndexer(productSearchBackend)
 		hotelAdminSvc := adminService.NewHotelAdminService(db, hotelRepo, roomRepo, bookingRepo, roomTimeSlotRepo)
 		distributionAdminSvc := adminService.NewDistributionAdminService(distributorRepo, commissionRepo, withdrawalRepo, db)
 		marketingAdminSvc := adminService.NewMarketingAdminService(db, couponRepo, campaignRepo)
 		memberAdminSvc := adminService.NewMemberAdminService(db, memberLevelRepo, memberPackageRepo, userRepo)
+		memberAdminSvc.SetMemberLevelCache(memberLevelCache)
+		rentalAdminSvc := adminService.NewRentalAdminService(db)
+		walletAdminSvc := adminService.NewWalletAdminService(userRepo, walletSvc)
+		userAdminSvc := adminService.NewUserAdminService(db, userRepo)
+		userAdminSvc.SetTokenRevoker(jwtManager)
+		couponGrantSvc := marketingService.NewCouponGrantService(db, couponSvc, couponGrantBatchRepo)
 
 		// 初始化管理员处理器
 		adminAuthH := adminHandler.NewAuthHandler(adminAuthSvc)
+		roleAdminH := adminHandler.NewRoleHandler(permissionSvc)
+		operationLogAdminH := adminHandler.NewOperationLogHandler(adminService.NewOperationLogService(operationLogRepo), permissionSvc)
+		adminNotificationH := adminHandler.NewAdminNotificationHandler(adminNotificationSvc)
 		deviceAdminH := adminHandler.NewDeviceHandler(deviceAdminSvc)
+		deviceAdminH.SetImportService(adminService.NewDeviceImportService(deviceAdminSvc))
 		venueAdminH := adminHandler.NewVenueHandler(venueAdminSvc)
 		merchantAdminH := adminHandler.NewMerchantHandler(merchantAdminSvc)
+		merchantAPIKeyAdminH := adminHandler.NewMerchantAPIKeyHandler(merchantAPIKeySvc)
+		merchantApplicationAdminH := adminHandler.NewMerchantApplicationHandler(merchantApplicationSvc)
 		productAdminH := adminHandler.NewProductHandler(productAdminSvc)
 		hotelAdminH := adminHandler.NewHotelHandler(hotelAdminSvc)
 		bookingVerifyH := adminHandler.NewBookingVerifyHandler(bookingSvc)
 		distributionAdminH := adminHandler.NewDistributionHandler(distributionAdminSvc)
-		marketingAdminH := adminHandler.NewMarketingHandler(marketingAdminSvc)
+		marketingAdminH := adminHandler.NewMarketingHandler(marketingAdminSvc, couponSvc, couponGrantSvc)
 		memberAdminH := adminHandler.NewMemberHandler(memberAdminSvc)
+		rentalAdminH := adminHandler.NewRentalHandler(rentalAdminSvc, rentalSvc, permissionSvc)
+		walletAdminH := adminHandler.NewWalletHandler(walletAdminSvc, permissionSvc)
+		userAdminH := adminHandler.NewUserHandler(userAdminSvc)
+		mallRefundAdminH := adminHandler.NewMallRefundHandler(mallOrderSvc)
+		orderRefundAdminH := adminHandler.NewOrderRefundHandler(refundSvc, permissionSvc)
+		orderNoteAdminH := adminHandler.NewOrderNoteHandler(orderNoteSvc)
+
+		// 设备状态实时推送：订阅 Redis 设备状态频道并分发给已连接的管理后台
+		deviceStatusHub := deviceService.NewStatusHub(redisClient, logger)
+		if err := deviceStatusHub.Start(ctx); err != nil {
+			logger.Warn("订阅设备状态频道失败，设备状态实时推送不可用", zap.Error(err))
+		}
+		deviceStatusWSH := adminHandler.NewDeviceStatusWSHandler(deviceStatusHub, cfg.CORS.AllowedOrigins)
 
 		// 财务相关仓储和服务
 		settlementRepo := repository.NewSettlementRepository(db)
 		transactionRepo := repository.NewTransactionRepository(db)
 
-		settlementSvc := financeService.NewSettlementService(db, settlementRepo, orderRepo, merchantRepo, commissionRepo, distributorRepo)
+		settlementRetryQueue := financeService.NewSettlementRetryQueue(redisClient, logger)
+		settlementSvc := financeService.NewSettlementService(db, settlementRepo, orderRepo, merchantRepo, commissionRepo, distributorRepo, settlementRetryQueue)
 		statisticsSvc := financeService.NewStatisticsService(db, settlementRepo, transactionRepo, orderRepo, paymentRepo, commissionRepo, withdrawalRepo)
+		startFinanceDailySummary(ctx, statisticsSvc, logger)
+		refundSvc.SetFinanceOverviewInvalidator(statisticsSvc)
 		withdrawalAuditSvc := financeService.NewWithdrawalAuditService(db, withdrawalRepo, distributorRepo)
+		withdrawalAuditSvc.SetLogger(logger)
+		startWithdrawalAutoApprove(ctx, cfg, withdrawalAuditSvc, logger)
 		exportSvc := financeService.NewExportService(db, settlementRepo, transactionRepo, orderRepo, withdrawalRepo)
+		reconciliationSvc := financeService.NewReconciliationService(db)
+		startDailyReconciliation(ctx, reconciliationSvc, logger)
+
+		// 结算失败自动重试
+		settlementRetryJob := financeService.NewRetrySettlementJob(settlementSvc, settlementRetryQueue, financeService.DefaultSettlementRetryPoll, logger)
+		settlementRetryJob.Start(ctx)
 
 		financeAdminH := adminHandler.NewFinanceHandler(settlementSvc, statisticsSvc, withdrawalAuditSvc, exportSvc)
+		financeAdminH.SetDashboardService(financeService.NewFinanceDashboardService(db))
+		financeAdminH.SetReconciliationService(reconciliationSvc)
+		financeAdminH.SetPermissionChecker(permissionSvc)
+		deviceStatsSvc := financeService.NewDeviceStatisticsService(db)
+		venueUtilizationAdminH := adminHandler.NewVenueUtilizationHandler(deviceStatsSvc, exportSvc)
 
 		// 操作日志中间件
 		operationLogger := middleware.NewOperationLogger(operationLogRepo)
+		operationLogger.SetWriter(operationLogWriter)
+		operationLogger.SetRedactFields(cfg.AuditLog.RedactFields)
+		// 按操作对象类型读取变更前的数据表记录，记录为操作日志的 before_data
+		for targetType, table := range map[string]string{
+			"admin":              "admins",
+			"article":            "articles",
+			"banner":             "banners",
+			"campaign":           "campaigns",
+			"category":           "categories",
+			"coupon":             "coupons",
+			"device":             "devices",
+			"device_maintenance": "device_maintenances",
+			"distributor":        "distributors",
+			"feedback":           "user_feedbacks",
+			"hotel":              "hotels",
+			"member_level":       "member_levels",
+			"member_package":     "member_packages",
+			"merchant":           "merchants",
+			"order":              "orders",
+			"product":            "products",
+			"role":               "roles",
+			"room":               "rooms",
+			"settlement":         "settlements",
+			"user":               "users",
+			"venue":              "venues",
+			"withdrawal":         "withdrawals",
+		} {
+			operationLogger.SetBeforeDataLoader(targetType, middleware.NewTableBeforeDataLoader(db, table))
+		}
 
 		// 管理员认证路由（公开，带限流保护）
 		adminAuthGroup := admin.Group("/auth")
@@ -475,12 +741,31 @@ func setupRouter(
 
 			// 设备管理
 			deviceAdminH.RegisterRoutes(adminAuth)
+			deviceStatusWSH.RegisterRoutes(adminAuth)
 
 			// 场地管理
 			venueAdminH.RegisterRoutes(adminAuth)
+			venueUtilizationAdminH.RegisterRoutes(adminAuth)
 
 			// 商户管理
 			merchantAdminH.RegisterRoutes(adminAuth)
+			merchantAPIKeyAdminH.RegisterRoutes(adminAuth)
+			merchantApplicationAdminH.RegisterRoutes(adminAuth)
+
+			// 租借管理
+			rentalAdminH.RegisterRoutes(adminAuth)
+
+			// 商城订单退款审批
+			mallRefundAdminH.RegisterRoutes(adminAuth)
+
+			// 订单退款（支持部分退款）
+			orderRefundAdminH.RegisterRoutes(adminAuth)
+
+			// 订单备注时间线
+			orderNoteAdminH.RegisterRoutes(adminAuth)
+
+			// 用户钱包调整与流水审计
+			walletAdminH.RegisterRoutes(adminAuth)
 
 			// 以下为尚未实现的接口占位
 
@@ -488,24 +773,28 @@ func setupRouter(
 			adminAuth.GET("/users", placeholderHandler("获取用户列表"))
 			adminAuth.GET("/users/:id", placeholderHandler("获取用户详情"))
 			adminAuth.PUT("/users/:id/status", placeholderHandler("更新用户状态"))
+			adminAuth.POST("/users/:id/force-logout", userAdminH.ForceLogout)
 
 			// 订单管理
 			adminAuth.GET("/orders", placeholderHandler("获取订单列表"))
 			adminAuth.GET("/orders/:id", placeholderHandler("获取订单详情"))
-			adminAuth.POST("/orders/:id/refund", placeholderHandler("发起退款"))
 
 			// 租借管理
-			adminAuth.GET("/rentals", placeholderHandler("获取租借列表"))
 			adminAuth.GET("/rentals/:id", placeholderHandler("获取租借详情"))
 
 			// 商品管理
 			adminAuth.GET("/products", productAdminH.GetProducts)
 			adminAuth.POST("/products", productAdminH.CreateProduct)
+			adminAuth.POST("/products/reindex", productAdminH.ReindexProducts)
 			adminAuth.GET("/products/:id", productAdminH.GetProductDetail)
 			adminAuth.PUT("/products/:id", productAdminH.UpdateProduct)
 			adminAuth.DELETE("/products/:id", productAdminH.DeleteProduct)
+			adminAuth.POST("/products/:id/restore", productAdminH.RestoreProduct)
 			adminAuth.PUT("/products/:id/status", productAdminH.UpdateProductStatus)
 
+			// 评价管理
+			adminAuth.POST("/reviews/:id/reply", reviewH.ReplyReview)
+
 			// 分类管理
 			adminAuth.GET("/categories", productAdminH.GetCategories)
 			adminAuth.POST("/categories", productAdminH.CreateCategory)
@@ -528,6 +817,9 @@ func setupRouter(
 				marketingAdmin.PUT("/coupons/:id", marketingAdminH.UpdateCoupon)
 				marketingAdmin.PUT("/coupons/:id/status", marketingAdminH.UpdateCouponStatus)
 				marketingAdmin.DELETE("/coupons/:id", marketingAdminH.DeleteCoupon)
+				marketingAdmin.POST("/coupons/:id/bulk-distribute", marketingAdminH.BulkDistributeCoupon)
+				marketingAdmin.POST("/coupons/:id/grants", marketingAdminH.GrantCoupon)
+				marketingAdmin.GET("/coupons/:id/grants", marketingAdminH.GetCouponGrants)
 
 				// 活动管理
 				marketingAdmin.GET("/campaigns", marketingAdminH.GetCampaignList)
@@ -541,6 +833,11 @@ func setupRouter(
 			// 会员管理
 			memberAdminH.RegisterRoutes(adminAuth)
 
+			// 资金相关操作权限：提现审核、结算处理、财务导出
+			requireWithdrawalApprove := userMiddleware.RequirePermission(permissionSvc, models.PermissionCodeWithdrawalApprove)
+			requireSettlementProcess := userMiddleware.RequirePermission(permissionSvc, models.PermissionCodeSettlementProcess)
+			requireFinanceExport := userMiddleware.RequirePermission(permissionSvc, models.PermissionCodeFinanceExport)
+
 			// 分销管理
 			distAdmin := adminAuth.Group("/distribution")
 			{
@@ -553,7 +850,7 @@ func setupRouter(
 				distAdmin.GET("/withdrawals", distributionAdminH.ListWithdrawals)
 				distAdmin.GET("/withdrawals/pending", distributionAdminH.GetPendingWithdrawals)
 				distAdmin.GET("/withdrawals/:id", distributionAdminH.GetWithdrawal)
-				distAdmin.POST("/withdrawals/:id/handle", distributionAdminH.HandleWithdrawal)
+				distAdmin.POST("/withdrawals/:id/handle", requireWithdrawalApprove, distributionAdminH.HandleWithdrawal)
 			}
 
 			// 财务管理
@@ -561,35 +858,57 @@ func setupRouter(
 			{
 				// 概览和统计
 				finance.GET("/overview", financeAdminH.GetOverview)
+				finance.GET("/dashboard", financeAdminH.GetDashboard)
 				finance.GET("/revenue/statistics", financeAdminH.GetRevenueStatistics)
 				finance.GET("/revenue/daily", financeAdminH.GetDailyRevenueReport)
+				finance.GET("/revenue/trend", financeAdminH.GetRevenueTrend)
 				finance.GET("/revenue/by-type", financeAdminH.GetOrderRevenueByType)
 				finance.GET("/transactions/statistics", financeAdminH.GetTransactionStatistics)
+				finance.GET("/statistics/top-devices", financeAdminH.GetTopDevicesByRevenue)
 
 				// 结算管理
 				finance.GET("/settlements", financeAdminH.ListSettlements)
 				finance.POST("/settlements", financeAdminH.CreateSettlement)
 				finance.GET("/settlements/summary", financeAdminH.GetSettlementSummary)
+				finance.GET("/settlements/preview", financeAdminH.PreviewSettlement)
+				finance.GET("/settlements/dead-letter", financeAdminH.ListDeadLetterSettlements)
 				finance.POST("/settlements/generate", financeAdminH.GenerateSettlements)
+				finance.POST("/settlements/generate-all", financeAdminH.GenerateAllSettlements)
 				finance.GET("/settlements/:id", financeAdminH.GetSettlement)
-				finance.POST("/settlements/:id/process", financeAdminH.ProcessSettlement)
+				finance.GET("/settlements/:id/items", financeAdminH.ListSettlementItems)
+				finance.POST("/settlements/:id/process", requireSettlementProcess, financeAdminH.ProcessSettlement)
+				finance.POST("/settlements/:id/submit", financeAdminH.SubmitSettlement)
+				finance.POST("/settlements/:id/approve", requireSettlementProcess, financeAdminH.ApproveSettlement)
+				finance.POST("/settlements/:id/reject", requireSettlementProcess, financeAdminH.RejectSettlement)
 
 				// 提现管理
 				finance.GET("/withdrawals", financeAdminH.ListWithdrawals)
 				finance.GET("/withdrawals/summary", financeAdminH.GetWithdrawalSummary)
-				finance.POST("/withdrawals/batch", financeAdminH.BatchHandleWithdrawals)
+				finance.POST("/withdrawals/batch", requireWithdrawalApprove, financeAdminH.BatchHandleWithdrawals)
 				finance.GET("/withdrawals/:id", financeAdminH.GetWithdrawal)
-				finance.POST("/withdrawals/:id/handle", financeAdminH.HandleWithdrawal)
+				finance.POST("/withdrawals/:id/handle", requireWithdrawalApprove, financeAdminH.HandleWithdrawal)
+				finance.GET("/withdrawals/:id/logs", financeAdminH.GetWithdrawalLogs)
+				finance.GET("/withdrawal-policies", financeAdminH.ListWithdrawalPolicies)
+				finance.PUT("/withdrawal-policies/:type", requireWithdrawalApprove, financeAdminH.UpdateWithdrawalPolicy)
 
 				// 报表
 				finance.GET("/reports/merchant-settlement", financeAdminH.GetMerchantSettlementReport)
 
 				// 导出
-				finance.GET("/export/settlements", financeAdminH.ExportSettlements)
-				finance.GET("/export/withdrawals", financeAdminH.ExportWithdrawals)
-				finance.GET("/export/daily-revenue", financeAdminH.ExportDailyRevenue)
-				finance.GET("/export/merchant-settlement", financeAdminH.ExportMerchantSettlement)
-				finance.GET("/export/transactions", financeAdminH.ExportTransactions)
+				finance.GET("/export/settlements", requireFinanceExport, financeAdminH.ExportSettlements)
+				finance.GET("/export/withdrawals", requireFinanceExport, financeAdminH.ExportWithdrawals)
+				finance.GET("/export/daily-revenue", requireFinanceExport, financeAdminH.ExportDailyRevenue)
+				finance.GET("/export/merchant-settlement", requireFinanceExport, financeAdminH.ExportMerchantSettlement)
+				finance.GET("/export/transactions", requireFinanceExport, financeAdminH.ExportTransactions)
+
+				// 对账
+				finance.GET("/reconciliation", financeAdminH.ListReconciliationIssues)
+			}
+
+			// 运营分析
+			analytics := adminAuth.Group("/analytics")
+			{
+				analytics.GET("/churned-users", financeAdminH.GetChurnedUsers)
 			}
 
 			// 系统管理
@@ -598,13 +917,14 @@ func setupRouter(
 			adminAuth.PUT("/admins/:id", placeholderHandler("更新管理员"))
 			adminAuth.DELETE("/admins/:id", placeholderHandler("删除管理员"))
 
-			adminAuth.GET("/roles", placeholderHandler("获取角色列表"))
+			// 角色权限管理
+			roleAdminH.RegisterRoutes(adminAuth)
+			operationLogAdminH.RegisterRoutes(adminAuth)
+			adminNotificationH.RegisterRoutes(adminAuth)
 			adminAuth.POST("/roles", placeholderHandler("添加角色"))
 			adminAuth.PUT("/roles/:id", placeholderHandler("更新角色"))
 			adminAuth.DELETE("/roles/:id", placeholderHandler("删除角色"))
 
-			adminAuth.GET("/permissions", placeholderHandler("获取权限列表"))
-
 			adminAuth.GET("/configs", placeholderHandler("获取系统配置"))
 			adminAuth.PUT("/configs", placeholderHandler("更新系统配置"))
 
@@ -621,7 +941,6 @@ func setupRouter(
 			adminAuth.GET("/feedbacks", placeholderHandler("获取反馈列表"))
 			adminAuth.PUT("/feedbacks/:id/reply", placeholderHandler("回复反馈"))
 
-			adminAuth.GET("/logs/operation", placeholderHandler("获取操作日志"))
 			adminAuth.GET("/logs/device", placeholderHandler("获取设备日志"))
 		}
 	}
@@ -633,6 +952,8 @@ func setupRouter(
 			"message": "接口不存在",
 		})
 	})
+
+	return operationLogWriter.Close
 }
 
 // placeholderHandler 占位处理器（待实现的接口）
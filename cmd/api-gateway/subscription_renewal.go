@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	rentalService "github.com/dumeirei/smart-locker-backend/internal/service/rental"
+)
+
+// subscriptionRenewalCheckInterval 订阅续订检查间隔，每天续订一次
+const subscriptionRenewalCheckInterval = time.Hour
+
+// startSubscriptionRenewal 每日续订即将到期的租借订阅，ctx 取消后退出
+// 续订窗口为周期结束前 24 小时，每日执行一次即可在周期结束前完成续订
+func startSubscriptionRenewal(ctx context.Context, subscriptionSvc *rentalService.SubscriptionService, logger *zap.Logger) {
+	go func() {
+		ticker := time.NewTicker(subscriptionRenewalCheckInterval)
+		defer ticker.Stop()
+
+		var lastDate string
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				today := time.Now().Format("2006-01-02")
+				if today == lastDate {
+					continue
+				}
+
+				result, err := subscriptionSvc.RenewSubscriptions(ctx)
+				if err != nil {
+					logger.Error("租借订阅续订失败", zap.String("date", today), zap.Error(err))
+					continue
+				}
+				lastDate = today
+				logger.Info("租借订阅续订完成", zap.String("date", today),
+					zap.Int("renewed", result.Renewed), zap.Int("paused", result.Paused), zap.Int("cancelled", result.Cancelled))
+			}
+		}
+	}()
+}
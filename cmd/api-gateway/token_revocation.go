@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
+	authService "github.com/dumeirei/smart-locker-backend/internal/service/auth"
+)
+
+// tokenPurgeInterval 过期令牌记录的清理间隔
+const tokenPurgeInterval = time.Hour
+
+// startTokenRevocationSync 定期同步用户吊销标记并清理过期的令牌记录，ctx 取消后退出
+func startTokenRevocationSync(ctx context.Context, jwtManager *jwt.Manager, store *authService.TokenStore, logger *zap.Logger) {
+	if err := jwtManager.SyncRevocationMarkers(ctx); err != nil {
+		logger.Warn("同步令牌吊销标记失败", zap.Error(err))
+	}
+
+	go func() {
+		syncTicker := time.NewTicker(jwt.DefaultRevocationSyncInterval)
+		defer syncTicker.Stop()
+		purgeTicker := time.NewTicker(tokenPurgeInterval)
+		defer purgeTicker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-syncTicker.C:
+				if err := jwtManager.SyncRevocationMarkers(ctx); err != nil {
+					logger.Warn("同步令牌吊销标记失败", zap.Error(err))
+				}
+			case <-purgeTicker.C:
+				purged, err := store.DeleteExpired(ctx, time.Now())
+				if err != nil {
+					logger.Error("清理过期令牌记录失败", zap.Error(err))
+				} else if purged > 0 {
+					logger.Info("已清理过期令牌记录", zap.Int64("purged", purged))
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"crypto/rsa"
+
+	"go.uber.org/zap"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/config"
+	"github.com/dumeirei/smart-locker-backend/pkg/wechatpay"
+)
+
+// newWechatNotifyVerifier 创建微信支付通知验签解密器
+// 未配置或加载平台证书失败时不信任任何证书，所有支付通知都会被拒绝
+func newWechatNotifyVerifier(cfg *config.Config, logger *zap.Logger) *wechatpay.NotifyVerifier {
+	platformKeys := make(map[string]*rsa.PublicKey)
+	if cfg.WeChat.PlatformCertPath != "" {
+		serial, publicKey, err := wechatpay.LoadPlatformCertificate(cfg.WeChat.PlatformCertPath)
+		if err != nil {
+			logger.Error("Failed to load wechatpay platform certificate",
+				zap.String("path", cfg.WeChat.PlatformCertPath), zap.Error(err))
+		} else {
+			platformKeys[serial] = publicKey
+		}
+	}
+	return wechatpay.NewNotifyVerifier(cfg.WeChat.APIv3Key, platformKeys)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/config"
+	financeService "github.com/dumeirei/smart-locker-backend/internal/service/finance"
+)
+
+// withdrawalAutoApproveInterval 小额提现自动审核间隔
+const withdrawalAutoApproveInterval = time.Hour
+
+// startWithdrawalAutoApprove 每小时自动审核通过小额提现，未配置阈值时不启动，ctx 取消后退出
+func startWithdrawalAutoApprove(ctx context.Context, cfg *config.Config, auditSvc *financeService.WithdrawalAuditService, logger *zap.Logger) {
+	threshold := cfg.Business.Distribution.AutoApproveWithdrawThreshold
+	if threshold <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(withdrawalAutoApproveInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				approved, err := auditSvc.AutoApproveSmallWithdrawals(ctx, threshold)
+				if err != nil {
+					logger.Error("小额提现自动审核失败", zap.Float64("threshold", threshold), zap.Error(err))
+				} else if approved > 0 {
+					logger.Info("小额提现自动审核完成", zap.Int("approved", approved), zap.Float64("threshold", threshold))
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// recordFailureScript 累加失败次数，窗口内达到上限时写入锁定键并清零计数
+// KEYS[1] 失败计数键，KEYS[2] 锁定键；ARGV[1] 上限，ARGV[2] 计数窗口及锁定时长(毫秒)
+// 返回 {失败次数, 是否已锁定}
+var recordFailureScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if n >= tonumber(ARGV[1]) then
+	redis.call("SET", KEYS[2], "1", "PX", ARGV[2])
+	redis.call("DEL", KEYS[1])
+	return {n, 1}
+end
+return {n, 0}
+`)
+
+// AttemptLimiter 基于 Redis 的失败尝试限制
+// 计数窗口内失败达到上限后锁定 lockout 时长，成功后可调用 Reset 清零计数
+type AttemptLimiter struct {
+	client      redis.Cmdable
+	prefix      string
+	maxAttempts int
+	lockout     time.Duration
+}
+
+// NewAttemptLimiter 创建失败尝试限制，lockout 同时作为失败计数窗口
+func NewAttemptLimiter(client redis.Cmdable, prefix string, maxAttempts int, lockout time.Duration) *AttemptLimiter {
+	return &AttemptLimiter{
+		client:      client,
+		prefix:      prefix,
+		maxAttempts: maxAttempts,
+		lockout:     lockout,
+	}
+}
+
+// LockedFor 返回 key 剩余的锁定时长，未锁定时返回 0
+func (l *AttemptLimiter) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := l.client.PTTL(ctx, l.lockKey(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// RecordFailure 记录一次失败，返回窗口内的失败次数以及是否因此被锁定
+func (l *AttemptLimiter) RecordFailure(ctx context.Context, key string) (int64, bool, error) {
+	res, err := recordFailureScript.Run(ctx, l.client, []string{l.failKey(key), l.lockKey(key)},
+		l.maxAttempts, l.lockout.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, false, err
+	}
+	return res[0], res[1] == 1, nil
+}
+
+// Reset 清零失败计数，不解除已生效的锁定
+func (l *AttemptLimiter) Reset(ctx context.Context, key string) error {
+	return l.client.Del(ctx, l.failKey(key)).Err()
+}
+
+func (l *AttemptLimiter) failKey(key string) string {
+	return l.prefix + "fail:" + key
+}
+
+func (l *AttemptLimiter) lockKey(key string) string {
+	return l.prefix + "lock:" + key
+}
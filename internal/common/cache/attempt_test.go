@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttemptLimiter(t *testing.T) {
+	s := setupMiniRedis(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	limiter := NewAttemptLimiter(client, "attempt:", 3, time.Minute)
+	ctx := context.Background()
+
+	t.Run("达到上限后锁定", func(t *testing.T) {
+		for i := 1; i < 3; i++ {
+			n, locked, err := limiter.RecordFailure(ctx, "a")
+			require.NoError(t, err)
+			assert.Equal(t, int64(i), n)
+			assert.False(t, locked)
+		}
+
+		_, locked, err := limiter.RecordFailure(ctx, "a")
+		require.NoError(t, err)
+		assert.True(t, locked)
+
+		ttl, err := limiter.LockedFor(ctx, "a")
+		require.NoError(t, err)
+		assert.True(t, ttl > 0 && ttl <= time.Minute)
+
+		// 锁定过期后解除
+		s.FastForward(time.Minute)
+		ttl, err = limiter.LockedFor(ctx, "a")
+		require.NoError(t, err)
+		assert.Zero(t, ttl)
+	})
+
+	t.Run("重置后重新计数", func(t *testing.T) {
+		_, _, err := limiter.RecordFailure(ctx, "b")
+		require.NoError(t, err)
+		_, _, err = limiter.RecordFailure(ctx, "b")
+		require.NoError(t, err)
+		require.NoError(t, limiter.Reset(ctx, "b"))
+
+		n, locked, err := limiter.RecordFailure(ctx, "b")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		assert.False(t, locked)
+	})
+
+	t.Run("计数窗口过期后重新计数", func(t *testing.T) {
+		_, _, err := limiter.RecordFailure(ctx, "c")
+		require.NoError(t, err)
+		_, _, err = limiter.RecordFailure(ctx, "c")
+		require.NoError(t, err)
+
+		s.FastForward(time.Minute)
+		n, _, err := limiter.RecordFailure(ctx, "c")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+	})
+}
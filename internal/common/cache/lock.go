@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrLockNotAcquired 重试耗尽仍未获取到锁
+var ErrLockNotAcquired = errors.New("cache: lock not acquired")
+
+// 分布式锁默认参数
+const (
+	DefaultLockTTL         = 5 * time.Second       // 锁过期时间
+	DefaultLockMaxAttempts = 3                     // 最大尝试次数
+	DefaultLockBackoff     = 50 * time.Millisecond // 首次重试等待时间，之后按指数翻倍
+)
+
+// releaseScript 仅当锁仍由当前持有者持有时才删除，避免误删他人的锁
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Locker 基于 Redis SET NX 的分布式锁
+type Locker struct {
+	client      redis.Cmdable
+	ttl         time.Duration
+	maxAttempts int
+	backoff     time.Duration
+}
+
+// NewLocker 创建分布式锁
+func NewLocker(client redis.Cmdable) *Locker {
+	return &Locker{
+		client:      client,
+		ttl:         DefaultLockTTL,
+		maxAttempts: DefaultLockMaxAttempts,
+		backoff:     DefaultLockBackoff,
+	}
+}
+
+// Lock 已持有的锁
+type Lock struct {
+	client redis.Cmdable
+	key    string
+	token  string
+}
+
+// Acquire 获取锁，失败时按指数退避重试
+// 重试耗尽仍未获取到锁时返回 ErrLockNotAcquired
+func (l *Locker) Acquire(ctx context.Context, key string) (*Lock, error) {
+	token, err := newLockToken()
+	if err != nil {
+		return nil, err
+	}
+
+	wait := l.backoff
+	for attempt := 1; ; attempt++ {
+		ok, err := l.client.SetNX(ctx, key, token, l.ttl).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &Lock{client: l.client, key: key, token: token}, nil
+		}
+		if attempt >= l.maxAttempts {
+			return nil, ErrLockNotAcquired
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		wait *= 2
+	}
+}
+
+// Release 释放锁
+func (l *Lock) Release(ctx context.Context) error {
+	return releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Err()
+}
+
+// newLockToken 生成锁持有者标识
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestLocker(t *testing.T) (*Locker, *redis.Client) {
+	s := setupMiniRedis(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	locker := NewLocker(client)
+	locker.backoff = time.Millisecond
+	return locker, client
+}
+
+func TestLocker_AcquireAndRelease(t *testing.T) {
+	locker, client := setupTestLocker(t)
+	ctx := context.Background()
+
+	lock, err := locker.Acquire(ctx, "lock:test")
+	require.NoError(t, err)
+
+	ttl, err := client.TTL(ctx, "lock:test").Result()
+	require.NoError(t, err)
+	assert.True(t, ttl > 0 && ttl <= DefaultLockTTL)
+
+	t.Run("锁被持有时重试耗尽返回 ErrLockNotAcquired", func(t *testing.T) {
+		_, err := locker.Acquire(ctx, "lock:test")
+		assert.ErrorIs(t, err, ErrLockNotAcquired)
+	})
+
+	t.Run("释放后可再次获取", func(t *testing.T) {
+		require.NoError(t, lock.Release(ctx))
+
+		lock2, err := locker.Acquire(ctx, "lock:test")
+		require.NoError(t, err)
+		require.NoError(t, lock2.Release(ctx))
+	})
+}
+
+func TestLocker_ReleaseDoesNotDeleteOthersLock(t *testing.T) {
+	locker, client := setupTestLocker(t)
+	ctx := context.Background()
+
+	lock, err := locker.Acquire(ctx, "lock:test")
+	require.NoError(t, err)
+
+	// 模拟锁过期后被其他持有者获取
+	require.NoError(t, client.Set(ctx, "lock:test", "other", DefaultLockTTL).Err())
+
+	require.NoError(t, lock.Release(ctx))
+	val, err := client.Get(ctx, "lock:test").Result()
+	require.NoError(t, err)
+	assert.Equal(t, "other", val)
+}
+
+func TestLocker_RetryUntilReleased(t *testing.T) {
+	locker, _ := setupTestLocker(t)
+	locker.backoff = 20 * time.Millisecond
+	ctx := context.Background()
+
+	lock, err := locker.Acquire(ctx, "lock:test")
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = lock.Release(ctx)
+	}()
+
+	lock2, err := locker.Acquire(ctx, "lock:test")
+	require.NoError(t, err)
+	require.NoError(t, lock2.Release(ctx))
+}
+
+func TestLocker_ContextCanceled(t *testing.T) {
+	locker, _ := setupTestLocker(t)
+	locker.backoff = time.Second
+
+	_, err := locker.Acquire(context.Background(), "lock:test")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = locker.Acquire(ctx, "lock:test")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
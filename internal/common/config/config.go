@@ -21,13 +21,16 @@ type Config struct {
 	Database    DatabaseConfig    `mapstructure:"database"`
 	Redis       RedisConfig       `mapstructure:"redis"`
 	MQTT        MQTTConfig        `mapstructure:"mqtt"`
+	Device      DeviceConfig      `mapstructure:"device"`
 	JWT         JWTConfig         `mapstructure:"jwt"`
 	Crypto      CryptoConfig      `mapstructure:"crypto"`
 	SMS         SMSConfig         `mapstructure:"sms"`
+	Push        PushConfig        `mapstructure:"push"`
 	WeChat      WeChatConfig      `mapstructure:"wechat"`
 	Alipay      AlipayConfig      `mapstructure:"alipay"`
 	OSS         OSSConfig         `mapstructure:"oss"`
 	Logger      LoggerConfig      `mapstructure:"logger"`
+	AuditLog    AuditLogConfig    `mapstructure:"audit_log"`
 	Metrics     MetricsConfig     `mapstructure:"metrics"`
 	Tracing     TracingConfig     `mapstructure:"tracing"`
 	RateLimit   RateLimitConfig   `mapstructure:"ratelimit"`
@@ -102,6 +105,12 @@ type MQTTConfig struct {
 	TopicPrefix    string `mapstructure:"topic_prefix"`
 }
 
+// DeviceConfig 设备配置
+type DeviceConfig struct {
+	HeartbeatInterval int    `mapstructure:"heartbeat_interval"` // 心跳上报间隔(秒)，超过 3 个间隔未上报视为离线
+	Secret            string `mapstructure:"secret"`             // 设备主密钥，用于派生各设备的接口签名密钥，为空时拒绝设备接口请求
+}
+
 // JWTConfig JWT配置
 type JWTConfig struct {
 	Secret             string `mapstructure:"secret"`
@@ -140,13 +149,14 @@ type SMSConfig struct {
 
 // WeChatConfig 微信配置
 type WeChatConfig struct {
-	AppID          string `mapstructure:"app_id"`
-	AppSecret      string `mapstructure:"app_secret"`
-	MchID          string `mapstructure:"mch_id"`
-	APIv3Key       string `mapstructure:"api_v3_key"`
-	SerialNo       string `mapstructure:"serial_no"`
-	PrivateKeyPath string `mapstructure:"private_key_path"`
-	NotifyURL      string `mapstructure:"notify_url"`
+	AppID            string `mapstructure:"app_id"`
+	AppSecret        string `mapstructure:"app_secret"`
+	MchID            string `mapstructure:"mch_id"`
+	APIv3Key         string `mapstructure:"api_v3_key"`
+	SerialNo         string `mapstructure:"serial_no"`
+	PrivateKeyPath   string `mapstructure:"private_key_path"`
+	NotifyURL        string `mapstructure:"notify_url"`
+	PlatformCertPath string `mapstructure:"platform_cert_path"` // 平台证书路径，用于验证支付通知签名
 }
 
 // AlipayConfig 支付宝配置
@@ -158,15 +168,21 @@ type AlipayConfig struct {
 	NotifyURL           string `mapstructure:"notify_url"`
 }
 
+// PushConfig 移动端推送配置
+type PushConfig struct {
+	FCMServerKey string `mapstructure:"fcm_server_key"` // 未配置时不发送入住提醒
+}
+
 // OSSConfig 对象存储配置
 type OSSConfig struct {
-	Provider        string `mapstructure:"provider"`
-	Endpoint        string `mapstructure:"endpoint"`
-	AccessKeyID     string `mapstructure:"access_key_id"`
-	AccessKeySecret string `mapstructure:"access_key_secret"`
-	Bucket          string `mapstructure:"bucket"`
-	CustomDomain    string `mapstructure:"custom_domain"`
-	UploadDir       string `mapstructure:"upload_dir"`
+	Provider        string   `mapstructure:"provider"`
+	Endpoint        string   `mapstructure:"endpoint"`
+	AccessKeyID     string   `mapstructure:"access_key_id"`
+	AccessKeySecret string   `mapstructure:"access_key_secret"`
+	Bucket          string   `mapstructure:"bucket"`
+	CustomDomain    string   `mapstructure:"custom_domain"`
+	UploadDir       string   `mapstructure:"upload_dir"`
+	AllowedHosts    []string `mapstructure:"allowed_hosts"` // 额外允许的文件域名，存储自身的域名始终允许
 }
 
 // LoggerConfig 日志配置
@@ -182,6 +198,19 @@ type LoggerConfig struct {
 	Caller     bool   `mapstructure:"caller"`
 }
 
+// AuditLogConfig 管理端操作审计日志配置
+type AuditLogConfig struct {
+	BufferSize    int      `mapstructure:"buffer_size"`    // 异步写入缓冲区大小，缓冲区满时丢弃日志
+	BatchSize     int      `mapstructure:"batch_size"`     // 单次批量写入条数
+	FlushInterval int      `mapstructure:"flush_interval"` // 批量写入间隔（毫秒）
+	RedactFields  []string `mapstructure:"redact_fields"`  // 额外需要脱敏的请求体字段
+}
+
+// FlushIntervalDuration 返回批量写入间隔
+func (a *AuditLogConfig) FlushIntervalDuration() time.Duration {
+	return time.Duration(a.FlushInterval) * time.Millisecond
+}
+
 // MetricsConfig 监控配置
 type MetricsConfig struct {
 	Enabled bool   `mapstructure:"enabled"`
@@ -217,23 +246,34 @@ type CORSConfig struct {
 // BusinessConfig 业务配置
 type BusinessConfig struct {
 	Rental       RentalConfig       `mapstructure:"rental"`
+	Order        OrderConfig        `mapstructure:"order"`
 	Distribution DistributionConfig `mapstructure:"distribution"`
 	Member       MemberConfig       `mapstructure:"member"`
+	Invoice      InvoiceConfig      `mapstructure:"invoice"`
 }
 
 // RentalConfig 租借配置
 type RentalConfig struct {
-	DefaultDeposit       float64 `mapstructure:"default_deposit"`
-	AutoPurchaseHours    int     `mapstructure:"auto_purchase_hours"`
-	TimeoutCheckInterval int     `mapstructure:"timeout_check_interval"`
+	DefaultDeposit              float64 `mapstructure:"default_deposit"`
+	AutoPurchaseHours           int     `mapstructure:"auto_purchase_hours"`
+	TimeoutCheckInterval        int     `mapstructure:"timeout_check_interval"`
+	RentalPaymentTimeoutMinutes int     `mapstructure:"payment_timeout_minutes"`
+	MaxConcurrentRentals        int     `mapstructure:"max_concurrent_rentals"` // 每用户同时进行中租借数上限，0 表示不限制
+}
+
+// OrderConfig 订单配置
+type OrderConfig struct {
+	PaymentTTLMinutes   int `mapstructure:"payment_ttl_minutes"`
+	ExpiryCheckInterval int `mapstructure:"expiry_check_interval"`
 }
 
 // DistributionConfig 分销配置
 type DistributionConfig struct {
-	Level1Rate        float64 `mapstructure:"level1_rate"`
-	Level2Rate        float64 `mapstructure:"level2_rate"`
-	MaxLevel          int     `mapstructure:"max_level"`
-	MinWithdrawAmount float64 `mapstructure:"min_withdraw_amount"`
+	Level1Rate                   float64 `mapstructure:"level1_rate"`
+	Level2Rate                   float64 `mapstructure:"level2_rate"`
+	MaxLevel                     int     `mapstructure:"max_level"`
+	MinWithdrawAmount            float64 `mapstructure:"min_withdraw_amount"`
+	AutoApproveWithdrawThreshold float64 `mapstructure:"auto_approve_withdraw_threshold"` // 实际到账金额不超过该值的待审核提现自动审核通过，不大于0时关闭
 }
 
 // MemberConfig 会员配置
@@ -242,6 +282,17 @@ type MemberConfig struct {
 	PointsToMoney int `mapstructure:"points_to_money"`
 }
 
+// InvoiceConfig 租借发票配置
+type InvoiceConfig struct {
+	CompanyName string  `mapstructure:"company_name"`
+	LogoPath    string  `mapstructure:"logo_path"`   // 公司 Logo 图片路径（png/jpg），为空时不显示
+	FontPath    string  `mapstructure:"font_path"`   // UTF-8 字体文件路径（ttf），显示中文时必须配置
+	TaxRate     float64 `mapstructure:"tax_rate"`    // 税率，金额按含税价计算
+	StorageDir  string  `mapstructure:"storage_dir"` // 发票在对象存储中的目录
+
+	ReceiptStorageDir string `mapstructure:"receipt_storage_dir"` // 收据在对象存储中的目录，收据与发票共用开票公司名称和字体
+}
+
 // Load 加载配置文件
 func Load(configPath string) (*Config, error) {
 	var err error
@@ -342,6 +393,9 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("mqtt.retained", false)
 	v.SetDefault("mqtt.topic_prefix", "smart-locker/")
 
+	// Device defaults
+	v.SetDefault("device.heartbeat_interval", 60)
+
 	// JWT defaults
 	v.SetDefault("jwt.secret", "your-super-secret-key-change-in-production")
 	v.SetDefault("jwt.access_token_expire", 168)
@@ -368,6 +422,11 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("logger.compress", true)
 	v.SetDefault("logger.caller", true)
 
+	// AuditLog defaults
+	v.SetDefault("audit_log.buffer_size", 1000)
+	v.SetDefault("audit_log.batch_size", 100)
+	v.SetDefault("audit_log.flush_interval", 1000)
+
 	// Metrics defaults
 	v.SetDefault("metrics.enabled", true)
 	v.SetDefault("metrics.port", 9100)
@@ -395,12 +454,20 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("business.rental.default_deposit", 99.00)
 	v.SetDefault("business.rental.auto_purchase_hours", 24)
 	v.SetDefault("business.rental.timeout_check_interval", 5)
+	v.SetDefault("business.rental.payment_timeout_minutes", 15)
+	v.SetDefault("business.rental.max_concurrent_rentals", 1)
+	v.SetDefault("business.order.payment_ttl_minutes", 15)
+	v.SetDefault("business.order.expiry_check_interval", 1)
 	v.SetDefault("business.distribution.level1_rate", 0.10)
 	v.SetDefault("business.distribution.level2_rate", 0.05)
 	v.SetDefault("business.distribution.max_level", 2)
 	v.SetDefault("business.distribution.min_withdraw_amount", 100.00)
+	v.SetDefault("business.distribution.auto_approve_withdraw_threshold", 0)
 	v.SetDefault("business.member.points_rate", 1)
 	v.SetDefault("business.member.points_to_money", 100)
+	v.SetDefault("business.invoice.tax_rate", 0.06)
+	v.SetDefault("business.invoice.storage_dir", "invoices")
+	v.SetDefault("business.invoice.receipt_storage_dir", "receipts")
 }
 
 // IsDebug 是否为调试模式
@@ -256,12 +256,18 @@ func TestBusinessConfig_Defaults(t *testing.T) {
 	assert.Equal(t, 99.00, cfg.Business.Rental.DefaultDeposit)
 	assert.Equal(t, 24, cfg.Business.Rental.AutoPurchaseHours)
 	assert.Equal(t, 5, cfg.Business.Rental.TimeoutCheckInterval)
+	assert.Equal(t, 15, cfg.Business.Rental.RentalPaymentTimeoutMinutes)
+
+	// 验证订单配置默认值
+	assert.Equal(t, 15, cfg.Business.Order.PaymentTTLMinutes)
+	assert.Equal(t, 1, cfg.Business.Order.ExpiryCheckInterval)
 
 	// 验证分销配置默认值
 	assert.Equal(t, 0.10, cfg.Business.Distribution.Level1Rate)
 	assert.Equal(t, 0.05, cfg.Business.Distribution.Level2Rate)
 	assert.Equal(t, 2, cfg.Business.Distribution.MaxLevel)
 	assert.Equal(t, 100.00, cfg.Business.Distribution.MinWithdrawAmount)
+	assert.Zero(t, cfg.Business.Distribution.AutoApproveWithdrawThreshold)
 
 	// 验证会员配置默认值
 	assert.Equal(t, 1, cfg.Business.Member.PointsRate)
@@ -354,6 +360,17 @@ func TestMQTTConfig_Defaults(t *testing.T) {
 	assert.Equal(t, "smart-locker/", cfg.MQTT.TopicPrefix)
 }
 
+// ==================== 设备配置测试 ====================
+
+func TestDeviceConfig_Defaults(t *testing.T) {
+
+	cfg := Get()
+
+	// 验证设备配置默认值
+	assert.Equal(t, 60, cfg.Device.HeartbeatInterval)
+	assert.Empty(t, cfg.Device.Secret)
+}
+
 // ==================== SMS 配置测试 ====================
 
 func TestSMSConfig_Defaults(t *testing.T) {
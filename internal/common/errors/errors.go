@@ -73,6 +73,7 @@ var (
 	ErrRateLimitExceed  = New(1008, "请求过于频繁")
 	ErrOperationFailed  = New(1009, "操作失败")
 	ErrResourceNotFound = New(1010, "资源不存在")
+	ErrIdempotencyConflict = New(1011, "幂等键已被用于不同的请求")
 )
 
 // 认证错误码 (2000-2999)
@@ -90,6 +91,7 @@ var (
 	ErrSmsCodeExpired   = New(2010, "短信验证码已过期")
 	ErrSmsSendFail      = New(2011, "短信发送失败")
 	ErrSmsSendTooFast   = New(2012, "短信发送过于频繁")
+	ErrAccountDeleted   = New(2013, "账号已注销")
 )
 
 // 用户错误码 (3000-3999)
@@ -102,6 +104,15 @@ var (
 	ErrRealNameFailed    = New(3005, "实名认证失败")
 	ErrBalanceInsufficient = New(3006, "余额不足")
 	ErrWithdrawFailed    = New(3007, "提现失败")
+	ErrPointsInsufficient = New(3008, "积分不足")
+	ErrWalletConflict    = New(3009, "钱包更新冲突，请稍后重试")
+	ErrDeletionBlocked   = New(3010, "账号存在未完成的业务，暂不能注销")
+	ErrDeletionNotFound  = New(3011, "没有待处理的注销申请")
+	ErrDeletionRequested = New(3012, "已提交注销申请")
+	ErrWithdrawBelowMinimum        = New(3013, "提现金额低于最低限额")
+	ErrWithdrawDailyCountExceeded  = New(3014, "今日提现次数已达上限")
+	ErrWithdrawDailyAmountExceeded = New(3015, "今日提现金额已达上限")
+	ErrWithdrawPolicyNotFound      = New(3016, "提现规则不存在")
 )
 
 // 设备错误码 (4000-4999)
@@ -120,6 +131,9 @@ var (
 	ErrVenueDisabled     = New(4011, "场地已禁用")
 	ErrPricingNotFound   = New(4012, "定价方案不存在")
 	ErrVenueHasDevices   = New(4013, "场地下有设备，无法删除")
+	ErrDeviceInUse       = New(4014, "设备正在使用中")
+	ErrPricingNotApplicable = New(4015, "定价方案不适用于该设备")
+	ErrPricingScheduleNotFound = New(4016, "分时定价不存在")
 )
 
 // 订单错误码 (5000-5999)
@@ -134,6 +148,8 @@ var (
 	ErrProductNotFound   = New(5007, "商品不存在")
 	ErrProductOffShelf   = New(5008, "商品已下架")
 	ErrStockInsufficient = New(5009, "库存不足")
+	ErrProductDeleted    = New(5010, "商品已删除")
+	ErrCartTokenInvalid  = New(5011, "购物车令牌无效或已失效")
 )
 
 // 支付错误码 (6000-6999)
@@ -146,6 +162,7 @@ var (
 	ErrRefundAmountExceed  = New(6005, "退款金额超限")
 	ErrPaymentMethodError  = New(6006, "支付方式错误")
 	ErrPaymentCallbackError = New(6007, "支付回调错误")
+	ErrRefundQuantityExceed = New(6008, "退款数量超出可退数量")
 )
 
 // 租借错误码 (7000-7999)
@@ -157,6 +174,12 @@ var (
 	ErrRentalReturned    = New(7004, "已归还")
 	ErrRentalOverdue     = New(7005, "租借超时")
 	ErrDepositNotPaid    = New(7006, "押金未支付")
+	ErrMaxRentalsExceeded = New(7007, "进行中的租借数量已达上限")
+	ErrTransferCodeInvalid = New(7008, "转让码无效")
+	ErrTransferExpired     = New(7009, "转让码已过期")
+	ErrRentalTransferred   = New(7010, "租借已转让过，不能再次转让")
+	ErrSubscriptionNotFound    = New(7011, "订阅不存在")
+	ErrSubscriptionStatusError = New(7012, "订阅状态异常")
 )
 
 // 酒店错误码 (8000-8499)
@@ -186,6 +209,8 @@ var (
 	ErrUnlockCodeExpired    = New(8512, "开锁码已过期")
 	ErrBookingNotVerified   = New(8513, "预订未核销")
 	ErrBookingTimeNotArrived = New(8514, "未到入住时间")
+	ErrBookingCancelDeadline = New(8515, "已过入住时间，无法取消预订")
+	ErrUnlockLockedOut       = New(8516, "开锁码错误次数过多，请15分钟后再试")
 )
 
 // 营销错误码 (9000-9999)
@@ -198,6 +223,8 @@ var (
 	ErrCouponNotEnough    = New(9005, "优惠券已领完")
 	ErrCampaignNotFound   = New(9006, "活动不存在")
 	ErrCampaignExpired    = New(9007, "活动已结束")
+	ErrFlashSaleSoldOut   = New(9008, "秒杀库存不足")
+	ErrFlashSaleLimitExceeded = New(9009, "已达到秒杀每人限购数量")
 )
 
 // 财务错误码 (10000-10999)
@@ -210,6 +237,14 @@ var (
 	ErrWithdrawalStatus   = New(10005, "提现状态异常")
 	ErrInsufficientBalance = New(10006, "可提现余额不足")
 	ErrExportFailed       = New(10007, "导出失败")
+	ErrSettlementInconsistent = New(10008, "结算明细与结算汇总不一致")
+	ErrUnsupportedExportFormat = New(10009, "不支持的导出格式")
+	ErrMerchantAPIKeyNotFound  = New(10010, "API 密钥不存在")
+	ErrMerchantAPIKeyRevoked   = New(10011, "API 密钥已吊销")
+	ErrMerchantApplicationNotFound = New(10012, "商户入驻申请不存在")
+	ErrMerchantApplicationExists   = New(10013, "已存在商户入驻申请")
+	ErrMerchantApplicationStatus   = New(10014, "商户入驻申请状态不允许该操作")
+	ErrSettlementStatus            = New(10015, "结算状态不允许该操作")
 )
 
 // IsAppError 判断是否为应用错误
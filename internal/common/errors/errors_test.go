@@ -154,6 +154,7 @@ func TestUserErrors(t *testing.T) {
 		{"ErrPhoneExists", ErrPhoneExists, 3002},
 		{"ErrPhoneInvalid", ErrPhoneInvalid, 3003},
 		{"ErrBalanceInsufficient", ErrBalanceInsufficient, 3006},
+		{"ErrWalletConflict", ErrWalletConflict, 3009},
 	}
 
 	for _, tt := range tests {
@@ -176,6 +177,7 @@ func TestDeviceErrors(t *testing.T) {
 		{"ErrDeviceDisabled", ErrDeviceDisabled, 4003},
 		{"ErrSlotNotAvailable", ErrSlotNotAvailable, 4006},
 		{"ErrUnlockFailed", ErrUnlockFailed, 4007},
+		{"ErrDeviceInUse", ErrDeviceInUse, 4014},
 	}
 
 	for _, tt := range tests {
@@ -198,6 +200,7 @@ func TestOrderErrors(t *testing.T) {
 		{"ErrOrderCancelled", ErrOrderCancelled, 5003},
 		{"ErrProductNotFound", ErrProductNotFound, 5007},
 		{"ErrStockInsufficient", ErrStockInsufficient, 5009},
+		{"ErrProductDeleted", ErrProductDeleted, 5010},
 	}
 
 	for _, tt := range tests {
@@ -240,6 +243,7 @@ func TestRentalErrors(t *testing.T) {
 		{"ErrRentalExpired", ErrRentalExpired, 7002},
 		{"ErrRentalInProgress", ErrRentalInProgress, 7003},
 		{"ErrDepositNotPaid", ErrDepositNotPaid, 7006},
+		{"ErrMaxRentalsExceeded", ErrMaxRentalsExceeded, 7007},
 	}
 
 	for _, tt := range tests {
@@ -284,6 +288,8 @@ func TestMarketingErrors(t *testing.T) {
 		{"ErrCouponUsed", ErrCouponUsed, 9002},
 		{"ErrCouponNotApplicable", ErrCouponNotApplicable, 9003},
 		{"ErrCampaignNotFound", ErrCampaignNotFound, 9006},
+		{"ErrFlashSaleSoldOut", ErrFlashSaleSoldOut, 9008},
+		{"ErrFlashSaleLimitExceeded", ErrFlashSaleLimitExceeded, 9009},
 	}
 
 	for _, tt := range tests {
@@ -305,6 +311,9 @@ func TestFinanceErrors(t *testing.T) {
 		{"ErrMerchantNotFound", ErrMerchantNotFound, 10002},
 		{"ErrWithdrawalNotFound", ErrWithdrawalNotFound, 10004},
 		{"ErrInsufficientBalance", ErrInsufficientBalance, 10006},
+		{"ErrMerchantAPIKeyNotFound", ErrMerchantAPIKeyNotFound, 10010},
+		{"ErrMerchantAPIKeyRevoked", ErrMerchantAPIKeyRevoked, 10011},
+		{"ErrSettlementStatus", ErrSettlementStatus, 10015},
 	}
 
 	for _, tt := range tests {
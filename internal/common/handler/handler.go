@@ -36,10 +36,13 @@ const (
 // 如果 err 不为 nil，发送错误响应并返回 true（表示已处理错误，调用方应该 return）
 //
 // HTTP 状态码映射规则：
-//   - 1002, 1010, 3000, 4000, 4010, 5000, 5007, 6000, 6003, 8000, 8010, 8020, 8500, 9000, 9006, 10000, 10002, 10004 -> 404 Not Found
-//   - 1001, 1003, 1008, 1009, 4001-4013, 5001-5009, 6001-6007, 7000-7006, 8001-8514, 9001-9007, 10001, 10003, 10005-10007 -> 400 Bad Request
+//   - 1002, 1010, 3000, 3011, 3016, 4000, 4010, 5000, 5007, 6000, 6003, 7011, 8000, 8010, 8020, 8500, 9000, 9006, 10000, 10002, 10004 -> 404 Not Found
+//   - 1001, 1003, 1008, 1009, 3010, 3012-3015, 4001-4014(除4002), 5001-5009, 5011, 6001-6008, 7000-7006, 7008-7010, 7012, 8001-8514, 9001-9007, 10001, 10003, 10005-10007, 10009 -> 400 Bad Request
 //   - 2000-2003 -> 401 Unauthorized
-//   - 2004-2006 -> 403 Forbidden
+//   - 2004-2006, 2013 -> 403 Forbidden
+//   - 1011 -> 409 Conflict
+//   - 8516 -> 429 Too Many Requests
+//   - 4002 -> 503 Service Unavailable
 //   - 其他 -> 500 Internal Server Error
 //
 // 使用示例:
@@ -71,6 +74,8 @@ func mapErrorCodeToHTTPStatus(code int) int {
 		1002:  true, // ErrNotFound
 		1010:  true, // ErrResourceNotFound
 		3000:  true, // ErrUserNotFound
+		3011:  true, // ErrDeletionNotFound
+		3016:  true, // ErrWithdrawPolicyNotFound
 		4000:  true, // ErrDeviceNotFound
 		4010:  true, // ErrVenueNotFound
 		5000:  true, // ErrOrderNotFound
@@ -78,6 +83,7 @@ func mapErrorCodeToHTTPStatus(code int) int {
 		6000:  true, // ErrPaymentNotFound
 		6003:  true, // ErrRefundNotFound
 		7000:  true, // ErrRentalNotFound
+		7011:  true, // ErrSubscriptionNotFound
 		8000:  true, // ErrHotelNotFound
 		8010:  true, // ErrRoomNotFound
 		8020:  true, // ErrTimeSlotNotFound
@@ -87,6 +93,7 @@ func mapErrorCodeToHTTPStatus(code int) int {
 		10000: true, // ErrSettlementNotFound
 		10002: true, // ErrMerchantNotFound
 		10004: true, // ErrWithdrawalNotFound
+		10010: true, // ErrMerchantAPIKeyNotFound
 	}
 	if notFoundCodes[code] {
 		return 404
@@ -97,11 +104,26 @@ func mapErrorCodeToHTTPStatus(code int) int {
 		return 401
 	}
 
-	// 403 Forbidden - 权限错误
-	if code >= 2004 && code <= 2006 {
+	// 403 Forbidden - 权限错误、账号已注销
+	if (code >= 2004 && code <= 2006) || code == 2013 {
 		return 403
 	}
 
+	// 409 Conflict - 幂等键冲突
+	if code == 1011 {
+		return 409
+	}
+
+	// 429 Too Many Requests - 开锁码错误次数过多，设备开锁已锁定
+	if code == 8516 {
+		return 429
+	}
+
+	// 503 Service Unavailable - 设备繁忙（获取设备锁超时），客户端可稍后重试
+	if code == 4002 {
+		return 503
+	}
+
 	// 400 Bad Request - 业务规则错误（包括参数错误、状态错误、资源已存在等）
 	// 通用错误 (1000-1999)
 	if code == 1001 || code == 1003 || code == 1008 || code == 1009 {
@@ -115,22 +137,42 @@ func mapErrorCodeToHTTPStatus(code int) int {
 	if code >= 3001 && code <= 3007 {
 		return 400
 	}
-	// 设备相关业务错误 (4001-4013，排除 4000, 4010)
-	if code >= 4001 && code <= 4013 {
+	// 账号注销相关业务错误 (3010, 3012，排除 3011)
+	if code == 3010 || code == 3012 {
+		return 400
+	}
+	// 提现规则限制 (3013-3015)
+	if code >= 3013 && code <= 3015 {
+		return 400
+	}
+	// 设备相关业务错误 (4001-4015，排除 4000, 4002, 4010)
+	if code >= 4001 && code <= 4015 {
 		return 400
 	}
 	// 订单相关业务错误 (5001-5009，排除 5000, 5007)
 	if code >= 5001 && code <= 5009 && code != 5007 {
 		return 400
 	}
-	// 支付相关业务错误 (6001-6007，排除 6000, 6003)
-	if code >= 6001 && code <= 6007 && code != 6003 {
+	// 游客购物车令牌无效 (5011)
+	if code == 5011 {
+		return 400
+	}
+	// 支付相关业务错误 (6001-6008，排除 6000, 6003)
+	if code >= 6001 && code <= 6008 && code != 6003 {
 		return 400
 	}
 	// 租借相关业务错误 (7001-7006，7000 是 not found)
 	if code >= 7001 && code <= 7006 {
 		return 400
 	}
+	// 租借转让相关业务错误 (7008-7010)
+	if code >= 7008 && code <= 7010 {
+		return 400
+	}
+	// 租借订阅相关业务错误 (7012，7011 是 not found)
+	if code == 7012 {
+		return 400
+	}
 	// 酒店相关业务错误 (8001-8022，排除 8000, 8010, 8020)
 	if code >= 8001 && code <= 8022 && code != 8010 && code != 8020 {
 		return 400
@@ -139,12 +181,12 @@ func mapErrorCodeToHTTPStatus(code int) int {
 	if code >= 8501 && code <= 8514 {
 		return 400
 	}
-	// 营销相关业务错误 (9001-9007，排除 9000, 9006)
-	if code >= 9001 && code <= 9007 && code != 9006 {
+	// 营销相关业务错误 (9001-9009，排除 9000, 9006)
+	if code >= 9001 && code <= 9009 && code != 9006 {
 		return 400
 	}
-	// 财务相关业务错误 (10001, 10003, 10005-10007)
-	if code == 10001 || code == 10003 || (code >= 10005 && code <= 10007) {
+	// 财务相关业务错误 (10001, 10003, 10005-10007, 10009, 10015)
+	if code == 10001 || code == 10003 || (code >= 10005 && code <= 10007) || code == 10009 || code == 10015 {
 		return 400
 	}
 
@@ -177,6 +219,25 @@ func HandleErrorWithMessage(c *gin.Context, err error, message string) bool {
 	return true
 }
 
+// HandleErrorWithData 处理错误并在响应中附带数据（如业务校验未通过的明细）
+// HTTP 状态码映射规则与 HandleError 相同
+func HandleErrorWithData(c *gin.Context, err error, data interface{}) bool {
+	if err == nil {
+		return false
+	}
+	if appErr, ok := err.(*errors.AppError); ok {
+		httpStatus := mapErrorCodeToHTTPStatus(appErr.Code)
+		c.JSON(httpStatus, response.Response{
+			Code:    appErr.Code,
+			Message: appErr.Message,
+			Data:    data,
+		})
+		return true
+	}
+	response.InternalError(c, err.Error())
+	return true
+}
+
 // MustSucceed 便捷封装：如果有错误则返回错误响应，否则返回成功响应
 // 适用于简单的「调用服务 -> 返回结果」场景
 //
@@ -214,6 +275,14 @@ func MustSucceedPage(c *gin.Context, err error, list interface{}, total int64, p
 	response.SuccessPage(c, list, total, page, pageSize)
 }
 
+// MustSucceedCursor 便捷封装：游标分页响应版本
+func MustSucceedCursor(c *gin.Context, err error, list interface{}, nextCursor string) {
+	if HandleError(c, err) {
+		return
+	}
+	response.SuccessCursor(c, list, nextCursor)
+}
+
 // ============================================================================
 // Phase 2: 用户认证检查
 // ============================================================================
@@ -248,6 +317,14 @@ func RequireAdminID(c *gin.Context) (int64, bool) {
 	return adminID, true
 }
 
+// GetTokenID 获取当前请求令牌的 JTI，未登录时返回空字符串
+func GetTokenID(c *gin.Context) string {
+	if claims := middleware.GetClaims(c); claims != nil {
+		return claims.ID
+	}
+	return ""
+}
+
 // GetOptionalUserID 获取当前用户ID（可选）
 // 如果未登录返回0，不会发送错误响应
 // 适用于认证可选的接口（如商品列表可以不登录访问，但登录后可显示个性化内容）
@@ -379,6 +456,17 @@ func ParseDateTime(s string) (time.Time, error) {
 	return time.Time{}, errors.ErrInvalidParams.WithMessage("时间格式错误")
 }
 
+// ParseDateTimeInLocation 按指定时区解析日期时间字符串
+// 未携带时区偏移的时间按 loc 的当地时间解释，携带偏移的时间保持原偏移
+func ParseDateTimeInLocation(s string, loc *time.Location) (time.Time, error) {
+	for _, format := range dateTimeFormats {
+		if t, err := time.ParseInLocation(format, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.ErrInvalidParams.WithMessage("时间格式错误")
+}
+
 // ParseQueryDate 从查询参数解析日期
 // 返回 (nil, true) 如果参数为空
 // 返回 (nil, false) 如果解析失败（已发送400响应）
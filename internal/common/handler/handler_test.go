@@ -85,6 +85,71 @@ func TestHandleError_AppError(t *testing.T) {
 	assert.Equal(t, "参数错误", resp.Message)
 }
 
+func TestHandleError_IdempotencyConflict(t *testing.T) {
+	c, w := createTestContext()
+
+	handled := HandleError(c, errors.ErrIdempotencyConflict)
+
+	assert.True(t, handled)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	resp := parseResponse(w)
+	assert.Equal(t, errors.ErrIdempotencyConflict.Code, resp.Code)
+}
+
+func TestHandleError_DeviceBusy(t *testing.T) {
+	c, w := createTestContext()
+
+	handled := HandleError(c, errors.ErrDeviceBusy)
+
+	assert.True(t, handled)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	resp := parseResponse(w)
+	assert.Equal(t, errors.ErrDeviceBusy.Code, resp.Code)
+}
+
+func TestHandleError_PricingNotApplicable(t *testing.T) {
+	c, w := createTestContext()
+
+	handled := HandleError(c, errors.ErrPricingNotApplicable)
+
+	assert.True(t, handled)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	resp := parseResponse(w)
+	assert.Equal(t, errors.ErrPricingNotApplicable.Code, resp.Code)
+}
+
+func TestHandleError_UnlockLockedOut(t *testing.T) {
+	c, w := createTestContext()
+
+	handled := HandleError(c, errors.ErrUnlockLockedOut)
+
+	assert.True(t, handled)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	resp := parseResponse(w)
+	assert.Equal(t, errors.ErrUnlockLockedOut.Code, resp.Code)
+}
+
+func TestHandleError_AccountDeleted(t *testing.T) {
+	c, w := createTestContext()
+
+	handled := HandleError(c, errors.ErrAccountDeleted)
+
+	assert.True(t, handled)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestHandleErrorWithData_DeletionBlocked(t *testing.T) {
+	c, w := createTestContext()
+
+	handled := HandleErrorWithData(c, errors.ErrDeletionBlocked, map[string]string{"type": "frozen"})
+
+	assert.True(t, handled)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	resp := parseResponse(w)
+	assert.Equal(t, errors.ErrDeletionBlocked.Code, resp.Code)
+	assert.Equal(t, map[string]interface{}{"type": "frozen"}, resp.Data)
+}
+
 func TestHandleError_GenericError(t *testing.T) {
 	c, w := createTestContext()
 	err := assert.AnError
@@ -328,6 +393,23 @@ func TestParseDateTime_MultipleFormats(t *testing.T) {
 	}
 }
 
+func TestParseDateTimeInLocation(t *testing.T) {
+	loc := time.FixedZone("UTC-5", -5*3600)
+
+	local, err := ParseDateTimeInLocation("2024-01-15 14:00", loc)
+	require.NoError(t, err)
+	assert.Equal(t, 14, local.Hour())
+	assert.Equal(t, time.Date(2024, 1, 15, 19, 0, 0, 0, time.UTC), local.UTC())
+
+	// 携带偏移的时间保持原偏移
+	withOffset, err := ParseDateTimeInLocation("2024-01-15T14:00:00+08:00", loc)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 15, 6, 0, 0, 0, time.UTC), withOffset.UTC())
+
+	_, err = ParseDateTimeInLocation("invalid", loc)
+	assert.Error(t, err)
+}
+
 func TestParseQueryDate_Empty(t *testing.T) {
 	c, _ := createTestContextWithQuery("")
 
@@ -14,8 +14,12 @@ import (
 // Claims 自定义 JWT 声明
 type Claims struct {
 	UserID   int64  `json:"user_id"`
-	UserType string `json:"user_type"` // user, admin
+	UserType string `json:"user_type"` // user, admin, merchant
 	Role     string `json:"role,omitempty"`
+	// TokenType 令牌类型（access/refresh），旧版本签发的令牌为空
+	TokenType string `json:"token_type,omitempty"`
+	// FamilyID 令牌族ID，同一登录会话中轮换产生的令牌共享同一个令牌族
+	FamilyID string `json:"fid,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -30,6 +34,10 @@ type Config struct {
 // Manager JWT 管理器
 type Manager struct {
 	config *Config
+
+	// 令牌吊销（可选），未设置时令牌无状态，只能等待自然过期
+	store   RevocationStore
+	markers *revocationMarkers
 }
 
 // TokenPair 令牌对
@@ -45,58 +53,70 @@ var (
 	ErrTokenExpired   = errors.New("token expired")
 	ErrTokenMalformed = errors.New("token malformed")
 	ErrTokenNotActive = errors.New("token not active yet")
+	ErrTokenRevoked   = errors.New("token revoked")
+	ErrTokenReused    = errors.New("refresh token reused")
 )
 
 // NewManager 创建 JWT 管理器
 func NewManager(config *Config) *Manager {
 	return &Manager{
-		config: config,
+		config:  config,
+		markers: newRevocationMarkers(),
 	}
 }
 
-// GenerateTokenPair 生成令牌对
+// GenerateTokenPair 生成令牌对（不记录到吊销存储）
 func (m *Manager) GenerateTokenPair(userID int64, userType, role string) (*TokenPair, error) {
+	pair, _, err := m.generateTokenPair(userID, userType, role, newTokenID())
+	return pair, err
+}
+
+// generateTokenPair 在指定令牌族下生成令牌对，同时返回两个令牌的记录
+func (m *Manager) generateTokenPair(userID int64, userType, role, familyID string) (*TokenPair, []*TokenRecord, error) {
 	now := time.Now()
 	accessExpireAt := now.Add(m.config.AccessExpireTime)
 	refreshExpireAt := now.Add(m.config.RefreshExpireTime)
 
 	// 生成访问令牌
-	accessToken, err := m.generateToken(userID, userType, role, accessExpireAt)
+	accessToken, accessID, err := m.generateToken(userID, userType, role, TokenTypeAccess, familyID, accessExpireAt)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// 生成刷新令牌
-	refreshToken, err := m.generateToken(userID, userType, role, refreshExpireAt)
+	refreshToken, refreshID, err := m.generateToken(userID, userType, role, TokenTypeRefresh, familyID, refreshExpireAt)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
+	records := []*TokenRecord{
+		{JTI: accessID, FamilyID: familyID, UserID: userID, UserType: userType, TokenType: TokenTypeAccess, ExpiresAt: accessExpireAt},
+		{JTI: refreshID, FamilyID: familyID, UserID: userID, UserType: userType, TokenType: TokenTypeRefresh, ExpiresAt: refreshExpireAt},
+	}
 	return &TokenPair{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 		ExpiresAt:    accessExpireAt.Unix(),
-	}, nil
+	}, records, nil
 }
 
 // GenerateAccessToken 生成访问令牌
 func (m *Manager) GenerateAccessToken(userID int64, userType, role string) (string, int64, error) {
 	expireAt := time.Now().Add(m.config.AccessExpireTime)
-	token, err := m.generateToken(userID, userType, role, expireAt)
+	token, _, err := m.generateToken(userID, userType, role, TokenTypeAccess, "", expireAt)
 	return token, expireAt.Unix(), err
 }
 
-// generateToken 生成令牌
-func (m *Manager) generateToken(userID int64, userType, role string, expireAt time.Time) (string, error) {
-	tokenID, err := randomTokenID()
-	if err != nil {
-		tokenID = fmt.Sprintf("fallback-%d", time.Now().UnixNano())
-	}
+// generateToken 生成令牌，返回令牌及其 JTI
+func (m *Manager) generateToken(userID int64, userType, role, tokenType, familyID string, expireAt time.Time) (string, string, error) {
+	tokenID := newTokenID()
 
 	claims := &Claims{
-		UserID:   userID,
-		UserType: userType,
-		Role:     role,
+		UserID:    userID,
+		UserType:  userType,
+		Role:      role,
+		TokenType: tokenType,
+		FamilyID:  familyID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        tokenID,
 			Issuer:    m.config.Issuer,
@@ -108,7 +128,17 @@ func (m *Manager) generateToken(userID int64, userType, role string, expireAt ti
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(m.config.Secret))
+	signed, err := token.SignedString([]byte(m.config.Secret))
+	return signed, tokenID, err
+}
+
+// newTokenID 生成随机令牌ID
+func newTokenID() string {
+	tokenID, err := randomTokenID()
+	if err != nil {
+		tokenID = fmt.Sprintf("fallback-%d", time.Now().UnixNano())
+	}
+	return tokenID
 }
 
 func randomTokenID() (string, error) {
@@ -148,12 +178,16 @@ func (m *Manager) ParseToken(tokenString string) (*Claims, error) {
 	return nil, ErrTokenInvalid
 }
 
-// RefreshToken 刷新令牌
+// RefreshToken 刷新令牌（无状态，不做轮换与重用检测）
+// 访问令牌不能用于刷新
 func (m *Manager) RefreshToken(refreshTokenString string) (*TokenPair, error) {
 	claims, err := m.ParseToken(refreshTokenString)
 	if err != nil {
 		return nil, err
 	}
+	if claims.TokenType == TokenTypeAccess {
+		return nil, ErrTokenInvalid
+	}
 
 	return m.GenerateTokenPair(claims.UserID, claims.UserType, claims.Role)
 }
@@ -178,6 +212,13 @@ func (m *Manager) GetUserIDFromToken(tokenString string) (int64, error) {
 
 // UserType 用户类型常量
 const (
-	UserTypeUser  = "user"
-	UserTypeAdmin = "admin"
+	UserTypeUser     = "user"
+	UserTypeAdmin    = "admin"
+	UserTypeMerchant = "merchant" // 商户门户，UserID 为商户 ID
+)
+
+// TokenType 令牌类型常量
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
 )
@@ -2,6 +2,7 @@
 package jwt
 
 import (
+	"context"
 	"strings"
 	"testing"
 	"time"
@@ -238,6 +239,47 @@ func TestManager_RefreshToken_Success(t *testing.T) {
 	assert.Equal(t, role, claims.Role)
 }
 
+func TestManager_RefreshToken_RejectsAccessToken(t *testing.T) {
+	manager := setupTestManager()
+
+	pair, err := manager.GenerateTokenPair(12345, UserTypeUser, "")
+	require.NoError(t, err)
+
+	accessClaims, err := manager.ParseToken(pair.AccessToken)
+	require.NoError(t, err)
+	refreshClaims, err := manager.ParseToken(pair.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, TokenTypeAccess, accessClaims.TokenType)
+	assert.Equal(t, TokenTypeRefresh, refreshClaims.TokenType)
+	assert.NotEmpty(t, accessClaims.FamilyID)
+	assert.Equal(t, accessClaims.FamilyID, refreshClaims.FamilyID)
+
+	newPair, err := manager.RefreshToken(pair.AccessToken)
+	assert.Equal(t, ErrTokenInvalid, err)
+	assert.Nil(t, newPair)
+}
+
+func TestManager_RevocationWithoutStore(t *testing.T) {
+	manager := setupTestManager()
+	ctx := context.Background()
+
+	pair, err := manager.IssueTokenPair(ctx, 12345, UserTypeUser, "")
+	require.NoError(t, err)
+	claims, err := manager.ParseToken(pair.AccessToken)
+	require.NoError(t, err)
+
+	// 未设置吊销存储时吊销为空操作，刷新退化为无状态刷新
+	require.NoError(t, manager.Revoke(ctx, claims.ID))
+	revoked, err := manager.IsRevoked(ctx, claims)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	_, err = manager.RotateRefreshToken(ctx, pair.RefreshToken)
+	assert.NoError(t, err)
+	_, err = manager.RotateRefreshToken(ctx, pair.RefreshToken)
+	assert.NoError(t, err)
+}
+
 func TestManager_RefreshToken_InvalidToken(t *testing.T) {
 	manager := setupTestManager()
 
@@ -0,0 +1,262 @@
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultRevocationSyncInterval 默认的用户吊销标记同步间隔
+const DefaultRevocationSyncInterval = 10 * time.Second
+
+// TokenRecord 已签发令牌的记录
+type TokenRecord struct {
+	JTI       string
+	FamilyID  string
+	UserID    int64
+	UserType  string
+	TokenType string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+// RevocationStore 令牌吊销存储
+type RevocationStore interface {
+	// SaveTokens 记录签发的令牌
+	SaveTokens(ctx context.Context, records []*TokenRecord) error
+	// GetToken 获取令牌记录，不存在时返回 nil
+	GetToken(ctx context.Context, jti string) (*TokenRecord, error)
+	// ConsumeRefreshToken 原子地将未使用且未吊销的刷新令牌标记为已使用，返回是否标记成功
+	ConsumeRefreshToken(ctx context.Context, jti string) (bool, error)
+	// RevokeFamily 吊销令牌族中的全部令牌
+	RevokeFamily(ctx context.Context, familyID string) error
+	// RevokeToken 吊销单个令牌
+	RevokeToken(ctx context.Context, jti string) error
+	// IsRevoked 查询令牌是否已被吊销
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	// ListActiveTokens 获取用户未过期且未吊销的令牌
+	ListActiveTokens(ctx context.Context, userType string, userID int64) ([]*TokenRecord, error)
+	// MarkUserRevoked 记录用户最近一次吊销令牌的时间
+	MarkUserRevoked(ctx context.Context, userType string, userID int64, at time.Time) error
+	// LoadRevocationMarkers 加载 since 之后的用户吊销标记，键为 UserKey
+	LoadRevocationMarkers(ctx context.Context, since time.Time) (map[string]time.Time, error)
+}
+
+// UserKey 用户吊销标记的键
+func UserKey(userType string, userID int64) string {
+	return fmt.Sprintf("%s:%d", userType, userID)
+}
+
+// SetRevocationStore 设置令牌吊销存储
+// 设置后签发的令牌会被记录，刷新令牌使用即轮换，并支持吊销
+func (m *Manager) SetRevocationStore(store RevocationStore) {
+	m.store = store
+}
+
+// IssueTokenPair 签发新登录会话的令牌对，并记录到吊销存储
+func (m *Manager) IssueTokenPair(ctx context.Context, userID int64, userType, role string) (*TokenPair, error) {
+	pair, records, err := m.generateTokenPair(userID, userType, role, newTokenID())
+	if err != nil {
+		return nil, err
+	}
+	if m.store != nil {
+		if err := m.store.SaveTokens(ctx, records); err != nil {
+			return nil, err
+		}
+	}
+	return pair, nil
+}
+
+// RotateRefreshToken 使用刷新令牌换取新的令牌对
+// 旧刷新令牌立即失效；已轮换的刷新令牌被再次使用时视为泄露，吊销整个令牌族并返回 ErrTokenReused。
+// 未设置吊销存储时退化为无状态刷新
+func (m *Manager) RotateRefreshToken(ctx context.Context, refreshTokenString string) (*TokenPair, error) {
+	if m.store == nil {
+		return m.RefreshToken(refreshTokenString)
+	}
+
+	claims, err := m.ParseToken(refreshTokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != TokenTypeRefresh {
+		return nil, ErrTokenInvalid
+	}
+
+	record, err := m.store.GetToken(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil || record.TokenType != TokenTypeRefresh {
+		return nil, ErrTokenInvalid
+	}
+	if record.RevokedAt != nil {
+		return nil, ErrTokenRevoked
+	}
+
+	consumed, err := m.store.ConsumeRefreshToken(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		if err := m.revokeFamily(ctx, record); err != nil {
+			return nil, err
+		}
+		return nil, ErrTokenReused
+	}
+
+	pair, records, err := m.generateTokenPair(claims.UserID, claims.UserType, claims.Role, record.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.store.SaveTokens(ctx, records); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Revoke 吊销令牌及其所属令牌族（同一登录会话的访问令牌和刷新令牌）
+// 未设置吊销存储或令牌未被记录（如旧版本签发）时为空操作
+func (m *Manager) Revoke(ctx context.Context, jti string) error {
+	if m.store == nil {
+		return nil
+	}
+
+	record, err := m.store.GetToken(ctx, jti)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return nil
+	}
+	if record.FamilyID == "" {
+		if err := m.store.RevokeToken(ctx, jti); err != nil {
+			return err
+		}
+		return m.markUserRevoked(ctx, record.UserType, record.UserID)
+	}
+	return m.revokeFamily(ctx, record)
+}
+
+// RevokeUser 吊销用户全部未过期的令牌（强制下线），返回吊销的会话数
+func (m *Manager) RevokeUser(ctx context.Context, userType string, userID int64) (int, error) {
+	if m.store == nil {
+		return 0, nil
+	}
+
+	records, err := m.store.ListActiveTokens(ctx, userType, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	families := make(map[string]bool)
+	for _, record := range records {
+		if record.FamilyID != "" {
+			if families[record.FamilyID] {
+				continue
+			}
+			families[record.FamilyID] = true
+		}
+		if err := m.Revoke(ctx, record.JTI); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// IsRevoked 判断访问令牌是否已被吊销
+// 仅当用户存在晚于令牌签发时间的吊销标记时才查询黑名单，其余请求不访问存储
+func (m *Manager) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	if m.store == nil {
+		return false, nil
+	}
+
+	revokedAt, ok := m.markers.get(UserKey(claims.UserType, claims.UserID))
+	if !ok {
+		return false, nil
+	}
+	if claims.IssuedAt != nil && claims.IssuedAt.Time.After(revokedAt) {
+		return false, nil
+	}
+	return m.store.IsRevoked(ctx, claims.ID)
+}
+
+// SyncRevocationMarkers 从吊销存储同步用户吊销标记
+// 其他实例吊销的令牌在下次同步后生效，同步间隔即多实例部署下吊销生效的最大延迟
+func (m *Manager) SyncRevocationMarkers(ctx context.Context) error {
+	if m.store == nil {
+		return nil
+	}
+
+	// 访问令牌过期后标记不再有意义
+	since := time.Now().Add(-m.config.AccessExpireTime)
+	loaded, err := m.store.LoadRevocationMarkers(ctx, since)
+	if err != nil {
+		return err
+	}
+	m.markers.merge(loaded, since)
+	return nil
+}
+
+// revokeFamily 吊销令牌族并标记用户
+func (m *Manager) revokeFamily(ctx context.Context, record *TokenRecord) error {
+	if err := m.store.RevokeFamily(ctx, record.FamilyID); err != nil {
+		return err
+	}
+	return m.markUserRevoked(ctx, record.UserType, record.UserID)
+}
+
+// markUserRevoked 记录用户吊销标记，本实例立即生效
+func (m *Manager) markUserRevoked(ctx context.Context, userType string, userID int64) error {
+	now := time.Now()
+	if err := m.store.MarkUserRevoked(ctx, userType, userID, now); err != nil {
+		return err
+	}
+	m.markers.set(UserKey(userType, userID), now)
+	return nil
+}
+
+// revocationMarkers 本地缓存的用户吊销标记
+type revocationMarkers struct {
+	mu    sync.RWMutex
+	items map[string]time.Time
+}
+
+func newRevocationMarkers() *revocationMarkers {
+	return &revocationMarkers{items: make(map[string]time.Time)}
+}
+
+func (r *revocationMarkers) get(key string) (time.Time, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	at, ok := r.items[key]
+	return at, ok
+}
+
+func (r *revocationMarkers) set(key string, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if current, ok := r.items[key]; !ok || at.After(current) {
+		r.items[key] = at
+	}
+}
+
+// merge 合并存储中的标记，丢弃 since 之前的标记
+func (r *revocationMarkers) merge(loaded map[string]time.Time, since time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, at := range loaded {
+		if current, ok := r.items[key]; !ok || at.After(current) {
+			r.items[key] = at
+		}
+	}
+	for key, at := range r.items {
+		if at.Before(since) {
+			delete(r.items, key)
+		}
+	}
+}
@@ -25,6 +25,7 @@ type Metrics struct {
 	activeUsers         prometheus.Gauge
 	ordersTotal         *prometheus.CounterVec
 	paymentsTotal       *prometheus.CounterVec
+	auditLogsDropped    prometheus.Counter
 }
 
 var defaultMetrics *Metrics
@@ -131,6 +132,13 @@ func Init(namespace string) *Metrics {
 			},
 			[]string{"method", "status"},
 		),
+		auditLogsDropped: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "admin_audit_logs_dropped_total",
+				Help:      "Total number of admin audit logs dropped because the write buffer was full",
+			},
+		),
 	}
 
 	defaultMetrics = m
@@ -221,6 +229,11 @@ func (m *Metrics) RecordPayment(method, status string) {
 	m.paymentsTotal.WithLabelValues(method, status).Inc()
 }
 
+// RecordAuditLogDropped 记录因写入缓冲区已满而丢弃的审计日志
+func (m *Metrics) RecordAuditLogDropped() {
+	m.auditLogsDropped.Inc()
+}
+
 // RecordHTTPRequest 手动记录 HTTP 请求（用于非中间件场景）
 func RecordHTTPRequest(method, path, status string, duration time.Duration) {
 	m := GetMetrics()
@@ -242,3 +255,8 @@ func RecordCacheHitGlobal(cache string) {
 func RecordCacheMissGlobal(cache string) {
 	GetMetrics().RecordCacheMiss(cache)
 }
+
+// RecordAuditLogDroppedGlobal 全局记录丢弃的审计日志
+func RecordAuditLogDroppedGlobal() {
+	GetMetrics().RecordAuditLogDropped()
+}
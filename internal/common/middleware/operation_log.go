@@ -10,19 +10,83 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 )
 
+// defaultSensitiveFields 默认脱敏的请求体字段，字段名包含其中任一项即脱敏
+var defaultSensitiveFields = []string{
+	"password", "old_password", "new_password", "confirm_password",
+	"token", "access_token", "refresh_token",
+	"secret", "api_key", "api_secret",
+	"bank_account", "bank_holder", "id_card", "account_info",
+}
+
 // OperationLogger 操作日志中间件
 type OperationLogger struct {
-	repo *repository.OperationLogRepository
+	repo            *repository.OperationLogRepository
+	writer          *OperationLogWriter
+	sensitiveFields []string
+	beforeLoaders   map[string]BeforeDataLoader
+}
+
+// BeforeDataLoader 按操作对象 ID 加载变更前的数据
+type BeforeDataLoader func(ctx context.Context, targetID int64) (interface{}, error)
+
+// beforeDataKey 变更前数据在请求上下文中的键
+const beforeDataKey = "operation_log_before_data"
+
+// NewTableBeforeDataLoader 创建按主键读取数据表整行的变更前数据加载器
+func NewTableBeforeDataLoader(db *gorm.DB, table string) BeforeDataLoader {
+	return func(ctx context.Context, targetID int64) (interface{}, error) {
+		row := map[string]interface{}{}
+		if err := db.WithContext(ctx).Table(table).Where("id = ?", targetID).Take(&row).Error; err != nil {
+			return nil, err
+		}
+		// jsonb 等列读出为字节，转为字符串后再序列化
+		for key, value := range row {
+			if b, ok := value.([]byte); ok {
+				row[key] = string(b)
+			}
+		}
+		return row, nil
+	}
+}
+
+// SetBeforeData 由 handler 写入操作对象变更前的数据，覆盖中间件按加载器读取的数据
+func SetBeforeData(c *gin.Context, data interface{}) {
+	c.Set(beforeDataKey, data)
 }
 
 // NewOperationLogger 创建操作日志中间件
 func NewOperationLogger(repo *repository.OperationLogRepository) *OperationLogger {
-	return &OperationLogger{repo: repo}
+	return &OperationLogger{repo: repo, sensitiveFields: defaultSensitiveFields}
+}
+
+// SetWriter 设置异步批量写入器，未设置时每条日志单独异步写库
+func (l *OperationLogger) SetWriter(writer *OperationLogWriter) {
+	l.writer = writer
+}
+
+// SetRedactFields 追加需要脱敏的请求体字段，默认字段始终脱敏
+func (l *OperationLogger) SetRedactFields(fields []string) {
+	sensitiveFields := append([]string{}, defaultSensitiveFields...)
+	for _, field := range fields {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			sensitiveFields = append(sensitiveFields, field)
+		}
+	}
+	l.sensitiveFields = sensitiveFields
+}
+
+// SetBeforeDataLoader 设置操作对象类型的变更前数据加载器，写操作执行前按路径 ID 加载并记录为 before_data
+func (l *OperationLogger) SetBeforeDataLoader(targetType string, loader BeforeDataLoader) {
+	if l.beforeLoaders == nil {
+		l.beforeLoaders = make(map[string]BeforeDataLoader)
+	}
+	l.beforeLoaders[targetType] = loader
 }
 
 // OperationConfig 操作配置
@@ -52,6 +116,11 @@ var moduleActionMap = map[string]OperationConfig{
 		Action:     "update_status",
 		TargetType: "device",
 	},
+	"PUT /admin/devices/:id/maintenance": {
+		Module:     "device",
+		Action:     "set_maintenance_mode",
+		TargetType: "device",
+	},
 	"DELETE /admin/devices/:id": {
 		Module:     "device",
 		Action:     "delete",
@@ -197,6 +266,11 @@ var moduleActionMap = map[string]OperationConfig{
 		Action:     "delete_room",
 		TargetType: "room",
 	},
+	"POST /admin/rooms/:id/occupancy/rebuild": {
+		Module:     "hotel",
+		Action:     "rebuild_room_occupancy",
+		TargetType: "room",
+	},
 
 	// 营销管理 - 优惠券
 	"POST /admin/marketing/coupons": {
@@ -379,6 +453,11 @@ var moduleActionMap = map[string]OperationConfig{
 		Action:     "delete_role",
 		TargetType: "role",
 	},
+	"PUT /admin/roles/:id/permissions": {
+		Module:     "system",
+		Action:     "set_role_permissions",
+		TargetType: "role",
+	},
 
 	// 系统管理 - 配置
 	"PUT /admin/configs": {
@@ -429,6 +508,7 @@ var moduleActionMap = map[string]OperationConfig{
 }
 
 // Log 操作日志中间件处理函数
+// 处理完成后记录管理员的写操作，失败的请求同样记录，便于审计被拒绝的操作
 func (l *OperationLogger) Log() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 只记录写操作
@@ -444,11 +524,19 @@ func (l *OperationLogger) Log() gin.HandlerFunc {
 			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
 		}
 
+		// 执行处理前加载变更前数据
+		config := l.routeConfig(c)
+		l.loadBeforeData(c, config)
+
 		// 执行处理
+		start := time.Now()
 		c.Next()
 
-		// 记录日志（异步）
-		go l.logOperation(c, requestBody)
+		// 请求结束后 gin.Context 会被复用，日志内容须在返回前读取，只有写库异步执行
+		if log := l.buildLog(c, requestBody, config); log != nil {
+			l.setRequestInfo(log, c, start)
+			l.dispatch(log)
+		}
 	}
 }
 
@@ -459,13 +547,8 @@ func (l *OperationLogger) shouldLog(c *gin.Context) bool {
 	return method == "POST" || method == "PUT" || method == "DELETE" || method == "PATCH"
 }
 
-// logOperation 记录操作日志
-func (l *OperationLogger) logOperation(c *gin.Context, requestBody []byte) {
-	if l.repo == nil {
-		return
-	}
-
-	// 获取路由配置
+// routeConfig 获取路由的操作配置，未配置的路由按路径推断
+func (l *OperationLogger) routeConfig(c *gin.Context) OperationConfig {
 	path := c.FullPath()
 	routeKey := c.Request.Method + " " + path
 	config, ok := moduleActionMap[routeKey]
@@ -478,11 +561,62 @@ func (l *OperationLogger) logOperation(c *gin.Context, requestBody []byte) {
 		// 尝试获取通用配置
 		config = l.getDefaultConfig(c)
 	}
+	return config
+}
+
+// loadBeforeData 在 handler 执行前读取操作对象变更前的数据，优先使用配置的 GetBeforeData，其次按对象类型的加载器读取
+func (l *OperationLogger) loadBeforeData(c *gin.Context, config OperationConfig) {
+	if l.repo == nil {
+		return
+	}
+	if config.GetBeforeData != nil {
+		if data := config.GetBeforeData(c.Request.Context(), c); data != nil {
+			c.Set(beforeDataKey, data)
+		}
+		return
+	}
+
+	loader, ok := l.beforeLoaders[config.TargetType]
+	if !ok {
+		return
+	}
+	// 路径 ID 属于上级资源时（如 /hotels/:id/rooms 创建房间）不加载
+	if config.GetTargetID == nil && inferTargetType(c.FullPath()) != config.TargetType {
+		return
+	}
+	targetID := l.resolveTargetID(c, config)
+	if targetID == nil {
+		return
+	}
+	// 对象不存在或读取失败时不记录变更前数据，不影响请求
+	if data, err := loader(c.Request.Context(), *targetID); err == nil {
+		c.Set(beforeDataKey, data)
+	}
+}
+
+// beforeData 读取变更前数据并过滤敏感字段
+func (l *OperationLogger) beforeData(c *gin.Context) models.JSON {
+	data, ok := c.Get(beforeDataKey)
+	if !ok || data == nil {
+		return nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil
+	}
+	return l.requestData(raw)
+}
+
+// buildLog 根据路由配置构建操作日志，无需记录时返回 nil
+func (l *OperationLogger) buildLog(c *gin.Context, requestBody []byte, config OperationConfig) *models.OperationLog {
+	if l.repo == nil {
+		return nil
+	}
 
 	// 获取管理员 ID
 	adminID, ok := l.getAdminID(c)
 	if !ok {
-		return
+		return nil
 	}
 
 	// 构建日志记录
@@ -507,19 +641,47 @@ func (l *OperationLogger) logOperation(c *gin.Context, requestBody []byte) {
 		}
 	}
 
-	// 设置请求数据
-	if len(requestBody) > 0 {
-		var data interface{}
-		if err := json.Unmarshal(requestBody, &data); err == nil {
-			// 过滤敏感字段
-			filteredData := l.filterSensitiveData(data)
-			if mapData, ok := filteredData.(map[string]interface{}); ok {
-				log.AfterData = mapData
-			}
-		}
+	// 设置变更前数据和请求数据
+	log.BeforeData = l.beforeData(c)
+	log.AfterData = l.requestData(requestBody)
+	return log
+}
+
+// requestData 解析 JSON 对象并过滤敏感字段，非 JSON 对象时返回 nil
+func (l *OperationLogger) requestData(requestBody []byte) models.JSON {
+	if len(requestBody) == 0 {
+		return nil
+	}
+	var data interface{}
+	if err := json.Unmarshal(requestBody, &data); err != nil {
+		return nil
+	}
+	// 过滤敏感字段
+	if mapData, ok := l.filterSensitiveData(data).(map[string]interface{}); ok {
+		return mapData
+	}
+	return nil
+}
+
+// setRequestInfo 记录请求方法、路由模板、响应状态码和耗时
+func (l *OperationLogger) setRequestInfo(log *models.OperationLog, c *gin.Context, start time.Time) {
+	log.Method = c.Request.Method
+	log.Route = c.FullPath()
+	log.StatusCode = c.Writer.Status()
+	log.LatencyMs = time.Since(start).Milliseconds()
+}
+
+// dispatch 提交日志写库，设置了写入器时进入缓冲区批量写入，缓冲区满时丢弃，不阻塞请求
+func (l *OperationLogger) dispatch(log *models.OperationLog) {
+	if l.writer != nil {
+		l.writer.Enqueue(log)
+		return
 	}
+	go l.save(log)
+}
 
-	// 保存日志
+// save 保存操作日志
+func (l *OperationLogger) save(log *models.OperationLog) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	_ = l.repo.Create(ctx, log)
@@ -612,9 +774,48 @@ func (l *OperationLogger) getDefaultConfig(c *gin.Context) OperationConfig {
 	}
 
 	return OperationConfig{
-		Module: module,
-		Action: action,
+		Module:     module,
+		Action:     action,
+		TargetType: inferTargetType(path),
+	}
+}
+
+// inferTargetType 从路由路径推断操作对象类型：取 :id 参数前的资源名，无 :id 参数时取最后一段资源名，
+// 并转为单数，如 /api/admin/hotels/:id/rooms 为 hotel，/api/admin/banners 为 banner
+func inferTargetType(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	resource := ""
+	for i, segment := range segments {
+		if segment == "" || strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			continue
+		}
+		if i+1 < len(segments) && segments[i+1] == ":id" {
+			resource = segment
+			break
+		}
+		if segment != "api" && segment != "admin" {
+			resource = segment
+		}
+	}
+
+	resource = strings.ReplaceAll(resource, "-", "_")
+	switch {
+	case strings.HasSuffix(resource, "ies"):
+		return strings.TrimSuffix(resource, "ies") + "y"
+	case strings.HasSuffix(resource, "ses"):
+		return strings.TrimSuffix(resource, "es")
+	case strings.HasSuffix(resource, "s") && !strings.HasSuffix(resource, "ss") && !strings.HasSuffix(resource, "us"):
+		return strings.TrimSuffix(resource, "s")
 	}
+	return resource
+}
+
+// resolveTargetID 获取目标 ID，优先使用配置的 GetTargetID
+func (l *OperationLogger) resolveTargetID(c *gin.Context, config OperationConfig) *int64 {
+	if config.GetTargetID != nil {
+		return config.GetTargetID(c)
+	}
+	return l.getTargetID(c)
 }
 
 // getTargetID 从路径参数获取目标 ID
@@ -634,20 +835,13 @@ func (l *OperationLogger) getTargetID(c *gin.Context) *int64 {
 
 // filterSensitiveData 过滤敏感数据
 func (l *OperationLogger) filterSensitiveData(data interface{}) interface{} {
-	sensitiveFields := []string{
-		"password", "old_password", "new_password", "confirm_password",
-		"token", "access_token", "refresh_token",
-		"secret", "api_key", "api_secret",
-		"bank_account", "bank_holder", "id_card",
-	}
-
 	switch v := data.(type) {
 	case map[string]interface{}:
 		result := make(map[string]interface{})
 		for key, value := range v {
 			lowerKey := strings.ToLower(key)
 			isSensitive := false
-			for _, sf := range sensitiveFields {
+			for _, sf := range l.sensitiveFields {
 				if strings.Contains(lowerKey, sf) {
 					isSensitive = true
 					break
@@ -681,29 +875,36 @@ func (l *OperationLogger) LogWithConfig(config OperationConfig) gin.HandlerFunc
 			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
 		}
 
+		// 执行处理前加载变更前数据
+		l.loadBeforeData(c, config)
+
 		// 执行处理
+		start := time.Now()
 		c.Next()
 
 		// 记录日志
-		go l.logOperationWithConfig(c, requestBody, config)
+		if log := l.buildLogWithConfig(c, requestBody, config); log != nil {
+			l.setRequestInfo(log, c, start)
+			l.dispatch(log)
+		}
 	}
 }
 
-// logOperationWithConfig 使用自定义配置记录操作日志
-func (l *OperationLogger) logOperationWithConfig(c *gin.Context, requestBody []byte, config OperationConfig) {
+// buildLogWithConfig 使用自定义配置构建操作日志，无需记录时返回 nil
+func (l *OperationLogger) buildLogWithConfig(c *gin.Context, requestBody []byte, config OperationConfig) *models.OperationLog {
 	if l.repo == nil {
-		return
+		return nil
 	}
 
 	// 获取管理员 ID
-	adminID, exists := c.Get("admin_id")
-	if !exists {
-		return
+	adminID, ok := l.getAdminID(c)
+	if !ok {
+		return nil
 	}
 
 	// 构建日志记录
 	log := &models.OperationLog{
-		AdminID: adminID.(int64),
+		AdminID: adminID,
 		Module:  config.Module,
 		Action:  config.Action,
 		IP:      c.ClientIP(),
@@ -719,25 +920,10 @@ func (l *OperationLogger) logOperationWithConfig(c *gin.Context, requestBody []b
 	if config.TargetType != "" {
 		log.TargetType = &config.TargetType
 	}
-	if config.GetTargetID != nil {
-		log.TargetID = config.GetTargetID(c)
-	} else if targetID := l.getTargetID(c); targetID != nil {
-		log.TargetID = targetID
-	}
-
-	// 设置请求数据
-	if len(requestBody) > 0 {
-		var data interface{}
-		if err := json.Unmarshal(requestBody, &data); err == nil {
-			filteredData := l.filterSensitiveData(data)
-			if mapData, ok := filteredData.(map[string]interface{}); ok {
-				log.AfterData = mapData
-			}
-		}
-	}
+	log.TargetID = l.resolveTargetID(c, config)
 
-	// 保存日志
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	_ = l.repo.Create(ctx, log)
+	// 设置变更前数据和请求数据
+	log.BeforeData = l.beforeData(c)
+	log.AfterData = l.requestData(requestBody)
+	return log
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -127,3 +128,188 @@ func TestOperationLogger_LogsAdminWriteOperations_WithActionMapping(t *testing.T
 	assert.Equal(t, int64(1), log4.AdminID)
 }
 
+
+// setupOperationLogRouter 创建带操作日志中间件的管理端路由，模拟 AdminAuth 设置的管理员身份
+func setupOperationLogRouter(t *testing.T, db *gorm.DB) (*gin.Engine, *gin.RouterGroup) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	admin := r.Group("/api/admin")
+	admin.Use(func(c *gin.Context) {
+		c.Set("user_id", int64(7))
+		c.Set("user_type", "admin")
+		c.Next()
+	})
+	admin.Use(NewOperationLogger(repository.NewOperationLogRepository(db)).Log())
+	return r, admin
+}
+
+func TestOperationLogger_LogsEveryMappedAdminRoute(t *testing.T) {
+	db := setupOperationLogTestDB(t)
+	r, admin := setupOperationLogRouter(t, db)
+
+	// 路由参数统一替换为 :id，避免同一位置参数名不同导致 gin 注册冲突
+	paramPattern := regexp.MustCompile(`:[a-z_]+`)
+	registered := make(map[string]bool)
+	for key := range moduleActionMap {
+		method, path, _ := strings.Cut(key, " ")
+		route := paramPattern.ReplaceAllString(strings.TrimPrefix(path, "/admin"), ":id")
+		if registered[method+" "+route] {
+			continue
+		}
+		registered[method+" "+route] = true
+		admin.Handle(method, route, func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"code": 0}) })
+	}
+
+	for key, config := range moduleActionMap {
+		method, path, _ := strings.Cut(key, " ")
+		url := "/api" + paramPattern.ReplaceAllString(path, "42")
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(`{"name":"x","password":"secret"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "10.0.0.1:52000"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, key)
+
+		log := waitForOperationLog(t, db, "module = ? AND action = ?", config.Module, config.Action)
+		assert.Equal(t, int64(7), log.AdminID, key)
+		assert.Equal(t, "10.0.0.1", log.IP, key)
+		if config.TargetType != "" {
+			require.NotNil(t, log.TargetType, key)
+			assert.Equal(t, config.TargetType, *log.TargetType, key)
+		}
+		assert.Equal(t, "***", log.AfterData["password"], key)
+		require.NoError(t, db.Where("1 = 1").Delete(&models.OperationLog{}).Error)
+	}
+}
+
+func TestOperationLogger_LogsFailedRequestsWithStatus(t *testing.T) {
+	db := setupOperationLogTestDB(t)
+	r, admin := setupOperationLogRouter(t, db)
+	admin.PUT("/devices/:id/status", func(c *gin.Context) { c.JSON(http.StatusBadRequest, gin.H{"code": 1001}) })
+	admin.DELETE("/devices/:id", func(c *gin.Context) { c.JSON(http.StatusForbidden, gin.H{"code": 1003}) })
+
+	req, _ := http.NewRequest("PUT", "/api/admin/devices/1/status", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	req2, _ := http.NewRequest("DELETE", "/api/admin/devices/1", nil)
+	w2 := httptest.NewRecorder()
+	r.ServeHTTP(w2, req2)
+	require.Equal(t, http.StatusForbidden, w2.Code)
+
+	// 被拒绝的操作同样记录，并保留响应状态码
+	log := waitForOperationLog(t, db, "action = ?", "update_status")
+	assert.Equal(t, http.StatusBadRequest, log.StatusCode)
+	log = waitForOperationLog(t, db, "action = ?", "delete")
+	assert.Equal(t, http.StatusForbidden, log.StatusCode)
+}
+
+func TestOperationLogger_RecordsBeforeData(t *testing.T) {
+	db := setupOperationLogTestDB(t)
+	gin.SetMode(gin.TestMode)
+	require.NoError(t, db.Create(&models.Admin{ID: 3, Username: "ops", PasswordHash: "hash", Name: "旧名称", RoleID: 1}).Error)
+
+	logger := NewOperationLogger(repository.NewOperationLogRepository(db))
+	logger.SetBeforeDataLoader("admin", NewTableBeforeDataLoader(db, "admins"))
+
+	r := gin.New()
+	admin := r.Group("/api/admin")
+	admin.Use(func(c *gin.Context) {
+		c.Set("user_id", int64(7))
+		c.Set("user_type", "admin")
+		c.Next()
+	})
+	admin.Use(logger.Log())
+	admin.PUT("/admins/:id", func(c *gin.Context) {
+		db.Model(&models.Admin{}).Where("id = ?", c.Param("id")).Update("name", "新名称")
+		c.JSON(http.StatusOK, gin.H{"code": 0})
+	})
+	admin.DELETE("/admins/:id", func(c *gin.Context) {
+		SetBeforeData(c, gin.H{"status": 1})
+		c.JSON(http.StatusOK, gin.H{"code": 0})
+	})
+
+	t.Run("按加载器读取变更前数据", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/api/admin/admins/3", strings.NewReader(`{"name":"新名称"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		log := waitForOperationLog(t, db, "action = ?", "update_admin")
+		require.NotNil(t, log.BeforeData)
+		assert.Equal(t, "旧名称", log.BeforeData["name"])
+		assert.Equal(t, "***", log.BeforeData["password_hash"])
+		assert.Equal(t, "新名称", log.AfterData["name"])
+	})
+
+	t.Run("handler 写入的变更前数据优先", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", "/api/admin/admins/3", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		log := waitForOperationLog(t, db, "action = ?", "delete_admin")
+		assert.Equal(t, map[string]interface{}{"status": float64(1)}, map[string]interface{}(log.BeforeData))
+	})
+
+	t.Run("路径 ID 属于上级资源时不加载", func(t *testing.T) {
+		logger.SetBeforeDataLoader("room", NewTableBeforeDataLoader(db, "admins"))
+		admin.POST("/hotels/:id/rooms", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"code": 0}) })
+
+		req, _ := http.NewRequest("POST", "/api/admin/hotels/3/rooms", strings.NewReader(`{"room_no":"101"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		log := waitForOperationLog(t, db, "action = ?", "create_room")
+		assert.Nil(t, log.BeforeData)
+	})
+
+	t.Run("对象不存在时不记录变更前数据", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/api/admin/admins/99", strings.NewReader(`{"name":"x"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		log := waitForOperationLog(t, db, "action = ? AND target_id = ?", "update_admin", 99)
+		assert.Nil(t, log.BeforeData)
+	})
+}
+
+func TestOperationLogger_UnmappedRouteInfersTarget(t *testing.T) {
+	db := setupOperationLogTestDB(t)
+	r, admin := setupOperationLogRouter(t, db)
+	admin.POST("/categories/:id/sort", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"code": 0}) })
+
+	req, _ := http.NewRequest("POST", "/api/admin/categories/9/sort", nil)
+	req.Header.Set("User-Agent", "admin-console")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	log := waitForOperationLog(t, db, "module = ?", "category")
+	assert.Equal(t, "create", log.Action)
+	require.NotNil(t, log.TargetType)
+	assert.Equal(t, "category", *log.TargetType)
+	require.NotNil(t, log.TargetID)
+	assert.Equal(t, int64(9), *log.TargetID)
+	require.NotNil(t, log.UserAgent)
+	assert.Equal(t, "admin-console", *log.UserAgent)
+}
+
+func TestInferTargetType(t *testing.T) {
+	tests := map[string]string{
+		"/api/admin/hotels/:id/rooms":      "hotel",
+		"/api/admin/banners":               "banner",
+		"/api/admin/categories":            "category",
+		"/api/admin/addresses/:id":         "address",
+		"/api/admin/merchant-api-keys/:id": "merchant_api_key",
+		"/api/admin/devices/:id/status":    "device",
+	}
+	for path, want := range tests {
+		assert.Equal(t, want, inferTargetType(path), path)
+	}
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/metrics"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+const (
+	defaultOperationLogBufferSize    = 1000
+	defaultOperationLogBatchSize     = 100
+	defaultOperationLogFlushInterval = time.Second
+)
+
+// OperationLogWriter 操作日志异步批量写入器
+// 日志先进入缓冲通道，由后台协程按条数或间隔批量写库；缓冲区满时直接丢弃并计数，不阻塞请求
+type OperationLogWriter struct {
+	repo          *repository.OperationLogRepository
+	logs          chan *models.OperationLog
+	batchSize     int
+	flushInterval time.Duration
+	dropped       atomic.Int64
+	done          chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewOperationLogWriter 创建操作日志写入器并启动后台写入协程，参数不大于 0 时使用默认值
+func NewOperationLogWriter(repo *repository.OperationLogRepository, bufferSize, batchSize int, flushInterval time.Duration) *OperationLogWriter {
+	if bufferSize <= 0 {
+		bufferSize = defaultOperationLogBufferSize
+	}
+	if batchSize <= 0 {
+		batchSize = defaultOperationLogBatchSize
+	}
+	if flushInterval <= 0 {
+		flushInterval = defaultOperationLogFlushInterval
+	}
+
+	w := &OperationLogWriter{
+		repo:          repo,
+		logs:          make(chan *models.OperationLog, bufferSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		done:          make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Enqueue 提交一条操作日志，缓冲区已满或写入器已关闭时丢弃并返回 false
+func (w *OperationLogWriter) Enqueue(log *models.OperationLog) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if !w.closed {
+		select {
+		case w.logs <- log:
+			return true
+		default:
+		}
+	}
+
+	w.dropped.Add(1)
+	metrics.RecordAuditLogDroppedGlobal()
+	return false
+}
+
+// Dropped 返回已丢弃的日志条数
+func (w *OperationLogWriter) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// Close 停止接收日志，并等待缓冲区中剩余日志写入完成或 ctx 结束
+func (w *OperationLogWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.logs)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run 后台写入循环，通道关闭后写入剩余日志并退出
+func (w *OperationLogWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*models.OperationLog, 0, w.batchSize)
+	for {
+		select {
+		case log, ok := <-w.logs:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+			batch = append(batch, log)
+			if len(batch) >= w.batchSize {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// flush 批量写入日志，写入失败时丢弃本批日志并计数
+func (w *OperationLogWriter) flush(batch []*models.OperationLog) {
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.repo.CreateBatch(ctx, batch); err != nil {
+		w.dropped.Add(int64(len(batch)))
+		for range batch {
+			metrics.RecordAuditLogDroppedGlobal()
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+func TestOperationLogWriter_CloseFlushesBufferedLogs(t *testing.T) {
+	db := setupOperationLogTestDB(t)
+	// 写入间隔足够长，日志只能在 Close 时写入
+	writer := NewOperationLogWriter(repository.NewOperationLogRepository(db), 10, 100, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		require.True(t, writer.Enqueue(&models.OperationLog{AdminID: 1, Module: "device", Action: "update", IP: "10.0.0.1"}))
+	}
+
+	var count int64
+	db.Model(&models.OperationLog{}).Count(&count)
+	assert.Zero(t, count)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, writer.Close(ctx))
+
+	db.Model(&models.OperationLog{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+	assert.Zero(t, writer.Dropped())
+
+	// 关闭后提交的日志直接丢弃
+	assert.False(t, writer.Enqueue(&models.OperationLog{AdminID: 1}))
+	assert.Equal(t, int64(1), writer.Dropped())
+	require.NoError(t, writer.Close(ctx))
+}
+
+func TestOperationLogWriter_DropsWhenBufferFull(t *testing.T) {
+	// 不启动写入协程，模拟数据库写入缓慢导致缓冲区积压
+	writer := &OperationLogWriter{logs: make(chan *models.OperationLog, 2)}
+
+	assert.True(t, writer.Enqueue(&models.OperationLog{AdminID: 1}))
+	assert.True(t, writer.Enqueue(&models.OperationLog{AdminID: 2}))
+	assert.False(t, writer.Enqueue(&models.OperationLog{AdminID: 3}))
+	assert.False(t, writer.Enqueue(&models.OperationLog{AdminID: 4}))
+	assert.Equal(t, int64(2), writer.Dropped())
+	assert.Len(t, writer.logs, 2)
+}
+
+func TestOperationLogger_WriterRecordsRequestAndRedactsBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupOperationLogTestDB(t)
+	repo := repository.NewOperationLogRepository(db)
+	writer := NewOperationLogWriter(repo, 10, 100, time.Hour)
+
+	op := NewOperationLogger(repo)
+	op.SetWriter(writer)
+	op.SetRedactFields([]string{" ID_Number "})
+
+	r := gin.New()
+	admin := r.Group("/api/admin")
+	admin.Use(func(c *gin.Context) {
+		c.Set("user_id", int64(5))
+		c.Set("user_type", "admin")
+		c.Next()
+	})
+	admin.Use(op.Log())
+	admin.PUT("/merchants/:id", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"code": 0}) })
+
+	body := `{"name":"商户A","password":"p","account_info":{"bank":"ICBC","no":"6222"},"contact":{"id_number":"110101"}}`
+	req, _ := http.NewRequest("PUT", "/api/admin/merchants/8", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, writer.Close(ctx))
+
+	var log models.OperationLog
+	require.NoError(t, db.First(&log).Error)
+	assert.Equal(t, int64(5), log.AdminID)
+	assert.Equal(t, "merchant", log.Module)
+	assert.Equal(t, "update", log.Action)
+	assert.Equal(t, "PUT", log.Method)
+	assert.Equal(t, "/api/admin/merchants/:id", log.Route)
+	assert.Equal(t, http.StatusOK, log.StatusCode)
+	assert.GreaterOrEqual(t, log.LatencyMs, int64(0))
+
+	assert.Equal(t, "商户A", log.AfterData["name"])
+	assert.Equal(t, "***", log.AfterData["password"])
+	assert.Equal(t, "***", log.AfterData["account_info"])
+	contact, ok := log.AfterData["contact"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "***", contact["id_number"])
+}
@@ -0,0 +1,175 @@
+// Package receipt 提供收据、发票等单据的PDF渲染功能
+// 业务方组装 Receipt 数据，渲染只负责版式，收据、发票及租借、酒店预订等不同业务共用同一版式
+package receipt
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const fontFamily = "receipt"
+
+//go:embed receipt.tmpl
+var layout string
+
+var layoutTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{
+	// clean 去除会破坏版式的分隔符与换行
+	"clean": strings.NewReplacer("|", "/", "\r", " ", "\n", " ").Replace,
+	"money": func(amount float64) string { return fmt.Sprintf("%.2f", amount) },
+	"time": func(t any) string {
+		switch v := t.(type) {
+		case time.Time:
+			return v.Format("2006-01-02 15:04")
+		case *time.Time:
+			if v != nil {
+				return v.Format("2006-01-02 15:04")
+			}
+		}
+		return ""
+	},
+}).Parse(layout))
+
+// Detail 收据信息行
+type Detail struct {
+	Label string
+	Value string
+}
+
+// Item 收据费用明细
+type Item struct {
+	Label  string
+	Amount float64 // 金额（元），负数表示退还或抵扣
+}
+
+// Receipt 收据内容
+type Receipt struct {
+	Issuer        string // 开具方名称
+	Title         string
+	NoLabel       string // 单号标签，为空时为 Receipt No.
+	ReceiptNo     string
+	OrderNo       string
+	IssuedAt      time.Time
+	Details       []Detail // 业务信息，如设备、场地、时长
+	Items         []Item   // 费用明细
+	Total         float64  // 合计金额（元）
+	PaymentMethod string
+	PaidAt        *time.Time
+	Notes         []string // 附加说明
+}
+
+// Renderer 收据PDF渲染器
+type Renderer struct {
+	fontPath string
+	logoPath string
+
+	fontMu   sync.Mutex
+	fontData []byte // 已加载的字体文件内容，加载成功后复用
+}
+
+// NewRenderer 创建收据渲染器，fontPath 为 UTF-8 字体文件路径（ttf），为空时使用内置字体，无法显示中文
+func NewRenderer(fontPath string) *Renderer {
+	return &Renderer{fontPath: fontPath}
+}
+
+// SetLogo 设置页眉 Logo 图片路径（png/jpg），为空时不显示
+func (r *Renderer) SetLogo(logoPath string) {
+	r.logoPath = logoPath
+}
+
+// loadFont 读取字体文件，成功后缓存内容，失败时下次渲染重新读取
+func (r *Renderer) loadFont() ([]byte, error) {
+	r.fontMu.Lock()
+	defer r.fontMu.Unlock()
+
+	if r.fontData == nil {
+		data, err := os.ReadFile(r.fontPath)
+		if err != nil {
+			return nil, fmt.Errorf("load font %s: %w", r.fontPath, err)
+		}
+		r.fontData = data
+	}
+	return r.fontData, nil
+}
+
+// Render 按内置版式渲染收据PDF，相同内容得到相同的文件
+func (r *Renderer) Render(receipt *Receipt) ([]byte, error) {
+	var body bytes.Buffer
+	if err := layoutTemplate.Execute(&body, receipt); err != nil {
+		return nil, err
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetCreationDate(receipt.IssuedAt)
+	pdf.SetModificationDate(receipt.IssuedAt)
+	pdf.SetTitle(receipt.Title+" "+receipt.ReceiptNo, true)
+
+	// 未配置 UTF-8 字体时使用内置字体，文本需转换为 cp1252 编码
+	family := "Helvetica"
+	text := pdf.UnicodeTranslatorFromDescriptor("")
+	if r.fontPath != "" {
+		font, err := r.loadFont()
+		if err != nil {
+			return nil, err
+		}
+		pdf.AddUTF8FontFromBytes(fontFamily, "", font)
+		if err := pdf.Error(); err != nil {
+			return nil, fmt.Errorf("load font %s: %w", r.fontPath, err)
+		}
+		family = fontFamily
+		text = func(str string) string { return str }
+	}
+
+	pdf.AddPage()
+	if r.logoPath != "" {
+		pdf.ImageOptions(r.logoPath, 15, 12, 30, 0, false, fpdf.ImageOptions{ReadDpi: true}, 0, "")
+	}
+	for _, line := range strings.Split(body.String(), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# "):
+			pdf.SetFont(family, "", 18)
+			pdf.CellFormat(0, 12, text(line[2:]), "", 1, "R", false, 0, "")
+		case strings.HasPrefix(line, "> "):
+			pdf.SetFont(family, "", 14)
+			pdf.CellFormat(0, 10, text(line[2:]), "", 1, "R", false, 0, "")
+		case line == "---":
+			pdf.Ln(3)
+			x, y := pdf.GetXY()
+			width, _ := pdf.GetPageSize()
+			left, _, right, _ := pdf.GetMargins()
+			pdf.Line(x, y, width-right, y)
+			pdf.SetX(left)
+			pdf.Ln(3)
+		case strings.HasPrefix(line, "- "), strings.HasPrefix(line, "= "):
+			label, amount, _ := strings.Cut(line[2:], "|")
+			size := 10.0
+			if line[0] == '=' {
+				size = 11
+			}
+			pdf.SetFont(family, "", size)
+			pdf.CellFormat(130, 8, text(label), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(0, 8, amount, "1", 1, "R", false, 0, "")
+		case strings.Contains(line, "|"):
+			label, value, _ := strings.Cut(line, "|")
+			pdf.SetFont(family, "", 10)
+			pdf.CellFormat(45, 7, text(label), "", 0, "L", false, 0, "")
+			pdf.CellFormat(0, 7, text(value), "", 1, "L", false, 0, "")
+		case line != "":
+			pdf.SetFont(family, "", 9)
+			pdf.MultiCell(0, 6, text(line), "", "L", false)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,80 @@
+package receipt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReceipt() *Receipt {
+	paidAt := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	return &Receipt{
+		Issuer:    "Smart Locker Co., Ltd.",
+		Title:     "RECEIPT",
+		ReceiptNo: "RCT001",
+		OrderNo:   "R001",
+		IssuedAt:  time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+		Details:   []Detail{{Label: "Device", Value: "Locker|A\nB"}},
+		Items:     []Item{{Label: "Rental fee", Amount: 29.7}, {Label: "Discount", Amount: -2.97}},
+		Total:     26.73,
+		PaidAt:    &paidAt,
+		Notes:     []string{"Thank you."},
+	}
+}
+
+func TestRenderer_Render(t *testing.T) {
+	renderer := NewRenderer("")
+
+	data, err := renderer.Render(testReceipt())
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(data, []byte("%PDF")))
+
+	// 相同内容渲染结果一致
+	again, err := renderer.Render(testReceipt())
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+}
+
+func TestLayoutTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, layoutTemplate.Execute(&buf, testReceipt()))
+
+	assert.Equal(t, "# Smart Locker Co., Ltd.\n"+
+		"> RECEIPT\n"+
+		"---\n"+
+		"Receipt No.|RCT001\n"+
+		"Order No.|R001\n"+
+		"Issue Date|2026-10-17 12:00\n"+
+		"Device|Locker/A B\n"+
+		"---\n"+
+		"- Rental fee|29.70\n"+
+		"- Discount|-2.97\n"+
+		"= Total|26.73\n"+
+		"Paid At|2026-10-17 09:00\n"+
+		"Thank you.\n", buf.String())
+}
+
+func TestLayoutTemplate_NoLabel(t *testing.T) {
+	invoice := testReceipt()
+	invoice.Title = "INVOICE"
+	invoice.NoLabel = "Invoice No."
+
+	var buf bytes.Buffer
+	require.NoError(t, layoutTemplate.Execute(&buf, invoice))
+	assert.Contains(t, buf.String(), "> INVOICE\n---\nInvoice No.|RCT001\n")
+}
+
+func TestRenderer_Render_FontError(t *testing.T) {
+	_, err := NewRenderer(filepath.Join(t.TempDir(), "missing.ttf")).Render(testReceipt())
+	require.Error(t, err)
+
+	invalid := filepath.Join(t.TempDir(), "invalid.ttf")
+	require.NoError(t, os.WriteFile(invalid, []byte("not a font"), 0o600))
+	_, err = NewRenderer(invalid).Render(testReceipt())
+	require.Error(t, err)
+}
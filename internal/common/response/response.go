@@ -30,6 +30,13 @@ type ListData struct {
 	PageSize int         `json:"page_size"`
 }
 
+// CursorData 游标分页数据结构，next_cursor 为空表示没有更多数据
+type CursorData struct {
+	List       interface{} `json:"list"`
+	NextCursor string      `json:"next_cursor"`
+	HasMore    bool        `json:"has_more"`
+}
+
 // Success 成功响应
 func Success(c *gin.Context, data interface{}) {
 	c.JSON(http.StatusOK, Response{
@@ -62,6 +69,19 @@ func SuccessPage(c *gin.Context, list interface{}, total int64, page, pageSize i
 	})
 }
 
+// SuccessCursor 游标分页成功响应
+func SuccessCursor(c *gin.Context, list interface{}, nextCursor string) {
+	c.JSON(http.StatusOK, Response{
+		Code:    0,
+		Message: "success",
+		Data: CursorData{
+			List:       list,
+			NextCursor: nextCursor,
+			HasMore:    nextCursor != "",
+		},
+	})
+}
+
 // SuccessWithPage 分页成功响应（别名）
 func SuccessWithPage(c *gin.Context, list interface{}, total int64, page, pageSize int) {
 	SuccessPage(c, list, total, page, pageSize)
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_EncodeDecode(t *testing.T) {
+	createdAt := time.Date(2025, 3, 1, 12, 30, 45, 123456789, time.UTC)
+	encoded := EncodeCursor(&Cursor{ID: 42, CreatedAt: createdAt})
+
+	decoded, err := DecodeCursor(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), decoded.ID)
+	assert.True(t, createdAt.Equal(decoded.CreatedAt))
+}
+
+func TestCursor_DecodeEmpty(t *testing.T) {
+	decoded, err := DecodeCursor("")
+	require.NoError(t, err)
+	assert.Nil(t, decoded)
+}
+
+func TestCursor_DecodeInvalid(t *testing.T) {
+	invalid := []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("no-separator")),
+		base64.RawURLEncoding.EncodeToString([]byte("abc:1")),
+		base64.RawURLEncoding.EncodeToString([]byte("1700000000:abc")),
+		base64.RawURLEncoding.EncodeToString([]byte("1700000000:0")),
+	}
+	for _, s := range invalid {
+		_, err := DecodeCursor(s)
+		assert.ErrorIs(t, err, ErrInvalidCursor, s)
+	}
+}
@@ -0,0 +1,109 @@
+// Package admin 管理端 HTTP Handler
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	contentService "github.com/dumeirei/smart-locker-backend/internal/service/content"
+)
+
+// AdminNotificationHandler 管理员通知处理器（如设备进入维护模式通知）
+type AdminNotificationHandler struct {
+	notificationService *contentService.NotificationService
+}
+
+// NewAdminNotificationHandler 创建管理员通知处理器
+func NewAdminNotificationHandler(notificationSvc *contentService.NotificationService) *AdminNotificationHandler {
+	return &AdminNotificationHandler{notificationService: notificationSvc}
+}
+
+// AdminUnreadCountResponse 管理员未读通知数量响应
+type AdminUnreadCountResponse struct {
+	Count int64 `json:"count"`
+}
+
+// List 获取当前管理员的通知列表
+// @Summary 获取管理员通知列表
+// @Tags 管理-系统管理
+// @Produce json
+// @Security Bearer
+// @Param is_read query bool false "是否已读"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=response.ListData{list=[]models.AdminNotification}}
+// @Router /api/admin/notifications [get]
+func (h *AdminNotificationHandler) List(c *gin.Context) {
+	adminID, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	var req contentService.AdminNotificationListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	notifications, total, err := h.notificationService.ListAdminNotifications(c.Request.Context(), adminID, &req)
+	handler.MustSucceedPage(c, err, notifications, total, req.Page, req.PageSize)
+}
+
+// UnreadCount 获取当前管理员的未读通知数量
+// @Summary 获取管理员未读通知数量
+// @Tags 管理-系统管理
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response{data=AdminUnreadCountResponse}
+// @Router /api/admin/notifications/unread-count [get]
+func (h *AdminNotificationHandler) UnreadCount(c *gin.Context) {
+	adminID, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.notificationService.GetAdminUnreadCount(c.Request.Context(), adminID)
+	handler.MustSucceed(c, err, AdminUnreadCountResponse{Count: count})
+}
+
+// MarkAsRead 标记通知为已读
+// @Summary 标记管理员通知为已读
+// @Tags 管理-系统管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "通知ID"
+// @Success 200 {object} response.Response
+// @Router /api/admin/notifications/{id}/read [post]
+func (h *AdminNotificationHandler) MarkAsRead(c *gin.Context) {
+	adminID, notificationID, ok := handler.RequireAdminAndParseID(c, "通知")
+	if !ok {
+		return
+	}
+
+	handler.MustSucceed(c, h.notificationService.MarkAdminNotificationAsRead(c.Request.Context(), notificationID, adminID), nil)
+}
+
+// MarkAllAsRead 标记当前管理员的所有通知为已读
+// @Summary 标记管理员所有通知为已读
+// @Tags 管理-系统管理
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response
+// @Router /api/admin/notifications/read-all [post]
+func (h *AdminNotificationHandler) MarkAllAsRead(c *gin.Context) {
+	adminID, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	handler.MustSucceed(c, h.notificationService.MarkAllAdminNotificationsAsRead(c.Request.Context(), adminID), nil)
+}
+
+// RegisterRoutes 注册路由，管理员只能查看和处理发给自己的通知，不需要额外权限
+func (h *AdminNotificationHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/notifications", h.List)
+	r.GET("/notifications/unread-count", h.UnreadCount)
+	r.POST("/notifications/read-all", h.MarkAllAsRead)
+	r.POST("/notifications/:id/read", h.MarkAsRead)
+}
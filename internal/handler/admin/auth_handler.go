@@ -179,8 +179,14 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 // @Success 200 {object} response.Response
 // @Router /admin/auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
-	// 当前设计：依赖 JWT 自然过期机制，无需 token 黑名单
-	// 如需立即吊销 token，可使用 Redis 实现黑名单机制
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	if err := h.adminAuthService.Logout(c.Request.Context(), handler.GetTokenID(c)); err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
 	response.Success(c, nil)
 }
 
@@ -2,10 +2,13 @@
 package admin
 
 import (
+	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
 	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
 	"github.com/dumeirei/smart-locker-backend/internal/common/response"
 	adminService "github.com/dumeirei/smart-locker-backend/internal/service/admin"
@@ -14,6 +17,7 @@ import (
 // DeviceHandler 设备管理处理器
 type DeviceHandler struct {
 	deviceService *adminService.DeviceAdminService
+	importService *adminService.DeviceImportService
 }
 
 // NewDeviceHandler 创建设备管理处理器
@@ -115,6 +119,74 @@ func (h *DeviceHandler) UpdateStatus(c *gin.Context) {
 	handler.MustSucceed(c, err, nil)
 }
 
+// DeviceMaintenanceModeRequest 设置维护模式请求
+type DeviceMaintenanceModeRequest struct {
+	Enabled            *bool      `json:"enabled" binding:"required"`
+	Reason             string     `json:"reason" binding:"max=255"`
+	EstimatedRestoreAt *time.Time `json:"estimated_restore_at"`
+}
+
+// SetMaintenanceMode 设置设备维护模式
+// @Summary 设置设备维护模式
+// @Description 开启后设备暂停新的租借（进行中的租借不受影响）并通知所有管理员，开启时须填写维护原因
+// @Tags 设备管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "设备ID"
+// @Param request body DeviceMaintenanceModeRequest true "请求参数"
+// @Success 200 {object} response.Response
+// @Router /admin/devices/{id}/maintenance [put]
+func (h *DeviceHandler) SetMaintenanceMode(c *gin.Context) {
+	adminID, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := handler.ParseID(c, "设备")
+	if !ok {
+		return
+	}
+
+	var req DeviceMaintenanceModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	var err error
+	if *req.Enabled {
+		err = h.deviceService.SetMaintenanceMode(c.Request.Context(), id, adminID, req.Reason, req.EstimatedRestoreAt)
+	} else {
+		err = h.deviceService.ClearMaintenanceMode(c.Request.Context(), id, adminID)
+	}
+	handler.MustSucceed(c, err, nil)
+}
+
+// Delete 删除设备
+// @Summary 删除设备
+// @Description 软删除设备，使用中的设备不能删除；删除后设备编号可重新使用
+// @Tags 设备管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "设备ID"
+// @Success 200 {object} response.Response
+// @Router /admin/devices/{id} [delete]
+func (h *DeviceHandler) Delete(c *gin.Context) {
+	adminID, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := handler.ParseID(c, "设备")
+	if !ok {
+		return
+	}
+
+	err := h.deviceService.DeleteDevice(c.Request.Context(), id, adminID)
+	handler.MustSucceed(c, err, nil)
+}
+
 // Get 获取设备详情
 // @Summary 获取设备详情
 // @Tags 设备管理
@@ -233,6 +305,98 @@ func (h *DeviceHandler) RemoteLock(c *gin.Context) {
 	handler.MustSucceed(c, err, nil)
 }
 
+// ListSlots 获取设备格口列表
+// @Summary 获取设备格口列表
+// @Tags 设备管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "设备ID"
+// @Success 200 {object} response.Response{data=[]models.DeviceSlot}
+// @Router /admin/devices/{id}/slots [get]
+func (h *DeviceHandler) ListSlots(c *gin.Context) {
+	id, ok := handler.ParseID(c, "设备")
+	if !ok {
+		return
+	}
+
+	slots, err := h.deviceService.ListDeviceSlots(c.Request.Context(), id)
+	handler.MustSucceed(c, err, slots)
+}
+
+// ListPricings 获取设备专属定价列表
+// @Summary 获取设备专属定价列表
+// @Tags 设备管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "设备ID"
+// @Success 200 {object} response.Response{data=[]models.RentalPricing}
+// @Router /admin/devices/{id}/pricings [get]
+func (h *DeviceHandler) ListPricings(c *gin.Context) {
+	id, ok := handler.ParseID(c, "设备")
+	if !ok {
+		return
+	}
+
+	pricings, err := h.deviceService.ListDevicePricings(c.Request.Context(), id)
+	handler.MustSucceed(c, err, pricings)
+}
+
+// CreatePricing 创建设备专属定价
+// @Summary 创建设备专属定价
+// @Description 设备专属定价优先于同时长的场地及全局定价，修改和停用使用 PUT /admin/pricings/{id}
+// @Tags 设备管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "设备ID"
+// @Param request body adminService.PricingRequest true "请求参数"
+// @Success 200 {object} response.Response{data=models.RentalPricing}
+// @Router /admin/devices/{id}/pricings [post]
+func (h *DeviceHandler) CreatePricing(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	id, ok := handler.ParseID(c, "设备")
+	if !ok {
+		return
+	}
+
+	var req adminService.PricingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	pricing, err := h.deviceService.CreateDevicePricing(c.Request.Context(), id, &req)
+	handler.MustSucceed(c, err, pricing)
+}
+
+// ReleaseSlot 手动释放卡住的格口
+// @Summary 手动释放格口
+// @Description 格口关联的租借已结束但格口仍被占用时，将其置为空闲并恢复设备可用槽位
+// @Tags 设备管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "设备ID"
+// @Param slot_no path int true "格口编号"
+// @Success 200 {object} response.Response
+// @Router /admin/devices/{id}/slots/{slot_no}/release [post]
+func (h *DeviceHandler) ReleaseSlot(c *gin.Context) {
+	adminID, id, ok := handler.RequireAdminAndParseID(c, "设备")
+	if !ok {
+		return
+	}
+
+	slotNo, ok := handler.ParseParamID(c, "slot_no", "格口")
+	if !ok {
+		return
+	}
+
+	err := h.deviceService.ReleaseDeviceSlot(c.Request.Context(), id, int(slotNo), adminID)
+	handler.MustSucceed(c, err, nil)
+}
+
 // GetLogs 获取设备日志
 // @Summary 获取设备日志
 // @Tags 设备管理
@@ -386,6 +550,104 @@ func (h *DeviceHandler) GetStatistics(c *gin.Context) {
 	handler.MustSucceed(c, err, stats)
 }
 
+// maxDeviceImportFileSize 设备导入文件大小上限
+const maxDeviceImportFileSize = 2 << 20
+
+// Import 从 CSV 批量导入场地设备
+// @Summary 批量导入设备
+// @Description CSV 列为 device_no,name,type,slot_count,network_type,product_name；默认仅创建有效行并返回逐行错误，strict=true 时存在无效行则不创建任何设备
+// @Tags 设备管理
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param id path int true "场地ID"
+// @Param file formData file true "CSV 文件"
+// @Param strict formData bool false "严格模式"
+// @Success 200 {object} response.Response{data=adminService.DeviceImportReport}
+// @Router /admin/venues/{id}/devices/import [post]
+func (h *DeviceHandler) Import(c *gin.Context) {
+	adminID, venueID, ok := handler.RequireAdminAndParseID(c, "场地")
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "请选择要导入的文件")
+		return
+	}
+	if file.Size > maxDeviceImportFileSize {
+		response.BadRequest(c, "导入文件不能超过2MB")
+		return
+	}
+
+	strict := false
+	if v := c.DefaultPostForm("strict", c.Query("strict")); v != "" {
+		strict, err = strconv.ParseBool(v)
+		if err != nil {
+			response.BadRequest(c, "strict 参数错误")
+			return
+		}
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		response.BadRequest(c, "读取导入文件失败")
+		return
+	}
+	defer f.Close()
+
+	report, err := h.deviceService.ImportDevices(c.Request.Context(), venueID, f, strict, adminID)
+	if appErr, ok := err.(*errors.AppError); ok && report != nil {
+		// 严格模式下被拒绝时仍返回逐行错误报告
+		c.JSON(http.StatusBadRequest, response.Response{Code: appErr.Code, Message: appErr.Message, Data: report})
+		return
+	}
+	handler.MustSucceed(c, err, report)
+}
+
+// SetImportService 设置跨场地设备批量导入服务，设置后注册 POST /devices/import
+func (h *DeviceHandler) SetImportService(importSvc *adminService.DeviceImportService) {
+	h.importService = importSvc
+}
+
+// BatchImport 从 CSV 批量导入多个场地的设备
+// @Summary 跨场地批量导入设备
+// @Description CSV 列为 device_no,name,type,venue_id,slot_count,network_type，可选 product_name（为空时使用设备名称）；无效行在报告中列出，不影响有效行的导入
+// @Tags 设备管理
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param file formData file true "CSV 文件"
+// @Success 200 {object} response.Response{data=adminService.ImportReport}
+// @Router /admin/devices/import [post]
+func (h *DeviceHandler) BatchImport(c *gin.Context) {
+	adminID, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "请选择要导入的文件")
+		return
+	}
+	if file.Size > maxDeviceImportFileSize {
+		response.BadRequest(c, "导入文件不能超过2MB")
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		response.BadRequest(c, "读取导入文件失败")
+		return
+	}
+	defer f.Close()
+
+	report, err := h.importService.Import(c.Request.Context(), f, adminID)
+	handler.MustSucceed(c, err, report)
+}
+
 // RegisterRoutes 注册路由
 func (h *DeviceHandler) RegisterRoutes(r *gin.RouterGroup) {
 	devices := r.Group("/devices")
@@ -395,14 +657,26 @@ func (h *DeviceHandler) RegisterRoutes(r *gin.RouterGroup) {
 		devices.GET("/statistics", h.GetStatistics)
 		devices.GET("/:id", h.Get)
 		devices.PUT("/:id", h.Update)
+		devices.DELETE("/:id", h.Delete)
 		devices.PUT("/:id/status", h.UpdateStatus)
+		devices.PUT("/:id/maintenance", h.SetMaintenanceMode)
 		devices.POST("/:id/unlock", h.RemoteUnlock)
 		devices.POST("/:id/lock", h.RemoteLock)
 		devices.GET("/:id/logs", h.GetLogs)
+		devices.GET("/:id/slots", h.ListSlots)
+		devices.POST("/:id/slots/:slot_no/release", h.ReleaseSlot)
+		devices.GET("/:id/pricings", h.ListPricings)
+		devices.POST("/:id/pricings", h.CreatePricing)
 
 		// 维护记录
 		devices.POST("/maintenance", h.CreateMaintenance)
 		devices.GET("/maintenance", h.ListMaintenance)
 		devices.POST("/maintenance/:id/complete", h.CompleteMaintenance)
+
+		if h.importService != nil {
+			devices.POST("/import", h.BatchImport)
+		}
 	}
+
+	r.POST("/venues/:id/devices/import", h.Import)
 }
@@ -0,0 +1,134 @@
+// Package admin 提供管理员相关的 HTTP Handler
+package admin
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	deviceService "github.com/dumeirei/smart-locker-backend/internal/service/device"
+)
+
+const (
+	deviceStatusWriteWait  = 10 * time.Second              // 单次写入超时
+	deviceStatusPongWait   = 60 * time.Second              // 等待客户端 pong 的超时
+	deviceStatusPingPeriod = deviceStatusPongWait * 9 / 10 // 发送 ping 的间隔，需小于 pong 超时
+)
+
+// DeviceStatusWSHandler 设备状态实时推送处理器
+type DeviceStatusWSHandler struct {
+	hub      *deviceService.StatusHub
+	upgrader websocket.Upgrader
+}
+
+// NewDeviceStatusWSHandler 创建设备状态实时推送处理器
+// allowedOrigins 为允许建立连接的管理后台来源（通常取 cors.allowed_origins），"*" 不生效，
+// 未列出的跨域来源无法握手；同源请求和不带 Origin 的非浏览器客户端不受限制
+func NewDeviceStatusWSHandler(hub *deviceService.StatusHub, allowedOrigins []string) *DeviceStatusWSHandler {
+	origins := make(map[string]struct{}, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		if origin != "*" {
+			origins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = struct{}{}
+		}
+	}
+
+	return &DeviceStatusWSHandler{
+		hub: hub,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin: func(r *http.Request) bool {
+				return checkDeviceStatusOrigin(r, origins)
+			},
+		},
+	}
+}
+
+// checkDeviceStatusOrigin 校验 WebSocket 握手请求的 Origin
+func checkDeviceStatusOrigin(r *http.Request, allowed map[string]struct{}) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if _, ok := allowed[strings.ToLower(origin)]; ok {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// StreamDeviceStatus 推送设备状态变更
+// @Summary 设备状态实时推送
+// @Description 建立 WebSocket 连接后持续推送设备上下线及租借状态变更事件（JSON：device_id、event_type、old_status、new_status、timestamp）。浏览器无法设置请求头时可通过 token 查询参数认证
+// @Tags 设备管理
+// @Security Bearer
+// @Param token query string false "访问令牌"
+// @Success 101 {object} deviceService.StatusChangeEvent
+// @Router /admin/ws/devices [get]
+func (h *DeviceStatusWSHandler) StreamDeviceStatus(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	// 先注册再升级，保证握手完成后发生的变更都能推送给客户端
+	events, unregister := h.hub.Register()
+	defer unregister()
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// 升级失败时 Upgrader 已写入错误响应
+		return
+	}
+	defer conn.Close()
+
+	// 读取客户端消息以处理 pong 和关闭帧，连接断开时通知写循环退出
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		_ = conn.SetReadDeadline(time.Now().Add(deviceStatusPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(deviceStatusPongWait))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(deviceStatusPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-c.Request.Context().Done():
+			return
+		case payload := <-events:
+			_ = conn.SetWriteDeadline(time.Now().Add(deviceStatusWriteWait))
+			if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(deviceStatusWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// RegisterRoutes 注册路由
+func (h *DeviceStatusWSHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/ws/devices", h.StreamDeviceStatus)
+}
@@ -9,6 +9,8 @@ import (
 
 	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
 	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	"github.com/dumeirei/smart-locker-backend/internal/middleware"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
 	financeService "github.com/dumeirei/smart-locker-backend/internal/service/finance"
 )
 
@@ -18,6 +20,9 @@ type FinanceHandler struct {
 	statisticsService *financeService.StatisticsService
 	withdrawalService *financeService.WithdrawalAuditService
 	exportService     *financeService.ExportService
+	dashboardService  *financeService.FinanceDashboardService
+	reconciliation    *financeService.ReconciliationService
+	permissionChecker middleware.PermissionChecker
 }
 
 // NewFinanceHandler 创建财务管理处理器
@@ -35,6 +40,29 @@ func NewFinanceHandler(
 	}
 }
 
+// SetDashboardService 设置财务仪表盘服务，用于收入趋势统计
+func (h *FinanceHandler) SetDashboardService(dashboardSvc *financeService.FinanceDashboardService) {
+	h.dashboardService = dashboardSvc
+}
+
+// SetReconciliationService 设置对账服务，用于查询对账差异
+func (h *FinanceHandler) SetReconciliationService(reconciliationSvc *financeService.ReconciliationService) {
+	h.reconciliation = reconciliationSvc
+}
+
+// SetPermissionChecker 设置权限检查器，用于判断导出时是否可查看完整收款账户
+func (h *FinanceHandler) SetPermissionChecker(checker middleware.PermissionChecker) {
+	h.permissionChecker = checker
+}
+
+// canExportFullAccountInfo 当前管理员是否拥有导出完整收款账户和手机号的权限
+func (h *FinanceHandler) canExportFullAccountInfo(c *gin.Context) bool {
+	if h.permissionChecker == nil {
+		return false
+	}
+	return h.permissionChecker.HasPermission(middleware.GetRole(c), models.PermissionCodeFinanceExportFull)
+}
+
 // GetOverview 获取财务概览
 // @Summary 获取财务概览
 // @Tags 管理-财务
@@ -127,6 +155,111 @@ func (h *FinanceHandler) GetDailyRevenueReport(c *gin.Context) {
 	handler.MustSucceed(c, err, report)
 }
 
+// GetRevenueTrend 获取收入趋势
+// @Summary 获取收入趋势
+// @Description 按日、ISO 周或自然月统计日期范围内的收入趋势，范围边缘不完整的周、月只统计范围内的部分；compare=true 时同时返回紧邻的等长上一周期及增长率
+// @Tags 管理-财务
+// @Produce json
+// @Security Bearer
+// @Param start_date query string true "开始日期 YYYY-MM-DD"
+// @Param end_date query string true "结束日期 YYYY-MM-DD"
+// @Param granularity query string false "统计粒度 day/week/month" default(day)
+// @Param compare query bool false "是否对比上一周期"
+// @Success 200 {object} response.Response{data=financeService.RevenueTrendReport}
+// @Router /api/v1/admin/finance/revenue/trend [get]
+func (h *FinanceHandler) GetRevenueTrend(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	startDate, endDate, ok := handler.ParseRequiredQueryDateRange(c)
+	if !ok {
+		return
+	}
+
+	compare := false
+	if compareStr := c.Query("compare"); compareStr != "" {
+		parsed, err := strconv.ParseBool(compareStr)
+		if err != nil {
+			response.BadRequest(c, "无效的对比参数")
+			return
+		}
+		compare = parsed
+	}
+
+	report, err := h.dashboardService.GetRevenueTrendReport(c.Request.Context(), &financeService.RevenueTrendRequest{
+		Granularity: c.DefaultQuery("granularity", financeService.TrendGranularityDay),
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Compare:     compare,
+	})
+	handler.MustSucceed(c, err, report)
+}
+
+// GetDashboard 获取财务仪表盘聚合数据
+// @Summary 获取财务仪表盘聚合数据
+// @Description 一次返回财务概览、收入趋势、结算统计、待处理提现和退款统计；子项查询失败时返回其余数据，失败信息在 errors 字段中
+// @Tags 管理-财务
+// @Produce json
+// @Security Bearer
+// @Param days query int false "收入趋势天数" default(7)
+// @Param limit query int false "待处理提现数量" default(10)
+// @Param start_date query string false "退款统计开始日期 YYYY-MM-DD"
+// @Param end_date query string false "退款统计结束日期 YYYY-MM-DD"
+// @Success 200 {object} response.Response{data=financeService.DashboardData}
+// @Router /api/v1/admin/finance/dashboard [get]
+func (h *FinanceHandler) GetDashboard(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "7"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	startDate, endDate, ok := handler.ParseQueryDateRange(c)
+	if !ok {
+		return
+	}
+
+	data := h.dashboardService.GetDashboard(c.Request.Context(), &financeService.DashboardRequest{
+		TrendDays:       days,
+		WithdrawalLimit: limit,
+		RefundStartDate: startDate,
+		RefundEndDate:   endDate,
+	})
+	response.Success(c, data)
+}
+
+// ListReconciliationIssues 获取对账差异
+// @Summary 获取对账差异
+// @Description 返回指定日期每日对账发现的差异：支付无对应订单、订单未支付、金额不一致、缺少支付记录、重复支付、钱包流水余额不连续
+// @Tags 管理-财务
+// @Produce json
+// @Security Bearer
+// @Param date query string true "对账日期 YYYY-MM-DD"
+// @Param type query string false "差异类型: order_missing/order_not_paid/amount_mismatch/payment_missing/duplicate_payment/wallet_chain_break"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=response.PageData}
+// @Router /api/v1/admin/finance/reconciliation [get]
+func (h *FinanceHandler) ListReconciliationIssues(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	date, ok := handler.ParseQueryDate(c, "date", "无效的对账日期格式")
+	if !ok {
+		return
+	}
+	if date == nil {
+		response.BadRequest(c, "请指定对账日期")
+		return
+	}
+
+	p := handler.BindAdminPagination(c)
+	issues, total, err := h.reconciliation.ListIssues(c.Request.Context(), *date, c.Query("type"), p.GetOffset(), p.GetLimit())
+	handler.MustSucceedPage(c, err, issues, total, p.Page, p.PageSize)
+}
+
 // GetOrderRevenueByType 按订单类型获取收入统计
 // @Summary 按订单类型获取收入统计
 // @Tags 管理-财务
@@ -165,8 +298,90 @@ func (h *FinanceHandler) GetOrderRevenueByType(c *gin.Context) {
 	handler.MustSucceed(c, err, result)
 }
 
+// GetTopDevicesByRevenue 获取设备收入排行
+// @Summary 获取设备收入排行
+// @Tags 管理-财务
+// @Produce json
+// @Security Bearer
+// @Param start query string true "开始日期 YYYY-MM-DD"
+// @Param end query string true "结束日期 YYYY-MM-DD"
+// @Param limit query int false "返回数量" default(10)
+// @Success 200 {object} response.Response{data=[]financeService.DeviceRevenueItem}
+// @Router /api/v1/admin/finance/statistics/top-devices [get]
+func (h *FinanceHandler) GetTopDevicesByRevenue(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	startDateStr := c.Query("start")
+	endDateStr := c.Query("end")
+
+	if startDateStr == "" || endDateStr == "" {
+		response.BadRequest(c, "请指定开始和结束日期")
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", startDateStr)
+	if err != nil {
+		response.BadRequest(c, "无效的开始日期格式")
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", endDateStr)
+	if err != nil {
+		response.BadRequest(c, "无效的结束日期格式")
+		return
+	}
+	endDate = endDate.Add(24*time.Hour - time.Second)
+
+	limit := financeService.DefaultTopDevicesLimit
+	if s := c.Query("limit"); s != "" {
+		l, err := strconv.Atoi(s)
+		if err != nil || l <= 0 {
+			response.BadRequest(c, "无效的返回数量")
+			return
+		}
+		limit = l
+	}
+
+	items, err := h.statisticsService.GetTopDevicesByRevenue(c.Request.Context(), startDate, endDate, limit)
+	handler.MustSucceed(c, err, items)
+}
+
+// GetChurnedUsers 获取流失用户列表
+// @Summary 获取流失用户列表
+// @Description 至少完成过一次租借、且最近 inactive_days 天内未再租借的用户，用于营销召回
+// @Tags 管理-运营分析
+// @Produce json
+// @Security Bearer
+// @Param inactive_days query int false "未活跃天数" default(30)
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量" default(50)
+// @Success 200 {object} response.Response{data=response.PageData}
+// @Router /api/v1/admin/analytics/churned-users [get]
+func (h *FinanceHandler) GetChurnedUsers(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	inactiveDays := financeService.DefaultChurnInactiveDays
+	if s := c.Query("inactive_days"); s != "" {
+		days, err := strconv.Atoi(s)
+		if err != nil || days <= 0 {
+			response.BadRequest(c, "无效的未活跃天数")
+			return
+		}
+		inactiveDays = days
+	}
+	p := handler.BindPaginationWithDefaults(c, 1, 50)
+
+	users, total, err := h.statisticsService.GetChurnedUsers(c.Request.Context(), inactiveDays, p.Page, p.PageSize)
+	handler.MustSucceedPage(c, err, users, total, p.Page, p.PageSize)
+}
+
 // ListSettlements 获取结算列表
 // @Summary 获取结算列表
+// @Description period_start_from/period_start_to 返回结算周期与该区间有重叠的记录；min_amount/max_amount 按实际结算金额过滤；
+// @Description 排序字段不在白名单内时按 created_at 排序，默认降序
 // @Tags 管理-财务
 // @Produce json
 // @Security Bearer
@@ -177,6 +392,12 @@ func (h *FinanceHandler) GetOrderRevenueByType(c *gin.Context) {
 // @Param status query string false "状态: pending/processing/completed/failed"
 // @Param period_start query string false "周期开始日期 YYYY-MM-DD"
 // @Param period_end query string false "周期结束日期 YYYY-MM-DD"
+// @Param period_start_from query string false "重叠区间开始日期 YYYY-MM-DD"
+// @Param period_start_to query string false "重叠区间结束日期 YYYY-MM-DD"
+// @Param min_amount query number false "实际结算金额下限"
+// @Param max_amount query number false "实际结算金额上限"
+// @Param sort_by query string false "排序字段: created_at/period_start/total_amount/actual_amount" default(created_at)
+// @Param sort_order query string false "排序方向: asc/desc" default(desc)
 // @Success 200 {object} response.Response{data=response.PageData}
 // @Router /api/v1/admin/finance/settlements [get]
 func (h *FinanceHandler) ListSettlements(c *gin.Context) {
@@ -195,23 +416,48 @@ func (h *FinanceHandler) ListSettlements(c *gin.Context) {
 	}
 
 	req := &financeService.SettlementListRequest{
-		Type:        c.Query("type"),
-		Status:      c.Query("status"),
-		PeriodStart: c.Query("period_start"),
-		PeriodEnd:   c.Query("period_end"),
-		Page:        page,
-		PageSize:    pageSize,
+		Type:            c.Query("type"),
+		Status:          c.Query("status"),
+		PeriodStart:     c.Query("period_start"),
+		PeriodEnd:       c.Query("period_end"),
+		PeriodStartFrom: c.Query("period_start_from"),
+		PeriodStartTo:   c.Query("period_start_to"),
+		SortBy:          c.DefaultQuery("sort_by", "created_at"),
+		SortOrder:       c.DefaultQuery("sort_order", "desc"),
+		Page:            page,
+		PageSize:        pageSize,
 	}
 
 	if targetIDStr := c.Query("target_id"); targetIDStr != "" {
 		targetID, _ := strconv.ParseInt(targetIDStr, 10, 64)
 		req.TargetID = &targetID
 	}
+	var ok bool
+	if req.MinAmount, ok = parseQueryAmount(c, "min_amount"); !ok {
+		return
+	}
+	if req.MaxAmount, ok = parseQueryAmount(c, "max_amount"); !ok {
+		return
+	}
 
 	settlements, total, err := h.settlementService.ListSettlements(c.Request.Context(), req)
 	handler.MustSucceedPage(c, err, settlements, total, page, pageSize)
 }
 
+// parseQueryAmount 解析查询参数中的非负金额，未传时返回 nil，格式错误时写入错误响应
+func parseQueryAmount(c *gin.Context, param string) (*float64, bool) {
+	s := c.Query(param)
+	if s == "" {
+		return nil, true
+	}
+	amount, err := strconv.ParseFloat(s, 64)
+	if err != nil || amount < 0 {
+		response.BadRequest(c, "无效的金额")
+		return nil, false
+	}
+	return &amount, true
+}
+
 // GetSettlement 获取结算详情
 // @Summary 获取结算详情
 // @Tags 管理-财务
@@ -235,6 +481,88 @@ func (h *FinanceHandler) GetSettlement(c *gin.Context) {
 	handler.MustSucceed(c, err, detail)
 }
 
+// ListSettlementItems 获取结算明细
+// @Summary 获取结算明细（计入结算的订单列表）
+// @Tags 管理-财务
+// @Produce json
+// @Security Bearer
+// @Param id path int true "结算ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=response.PageData}
+// @Router /api/v1/admin/finance/settlements/{id}/items [get]
+func (h *FinanceHandler) ListSettlementItems(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	items, total, err := h.settlementService.ListSettlementItems(c.Request.Context(), id, page, pageSize)
+	handler.MustSucceedPage(c, err, items, total, page, pageSize)
+}
+
+// PreviewSettlement 预览结算金额
+// @Summary 预览结算金额
+// @Description 按创建结算相同的规则计算结算金额和计入的订单，不生成结算记录
+// @Tags 管理-财务
+// @Produce json
+// @Security Bearer
+// @Param type query string true "类型: merchant/distributor"
+// @Param target_id query int true "目标ID"
+// @Param period_start query string true "周期开始日期 YYYY-MM-DD"
+// @Param period_end query string true "周期结束日期 YYYY-MM-DD"
+// @Success 200 {object} response.Response{data=financeService.SettlementPreview}
+// @Router /api/v1/admin/finance/settlements/preview [get]
+func (h *FinanceHandler) PreviewSettlement(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	settlementType := c.Query("type")
+	if settlementType != models.SettlementTypeMerchant && settlementType != models.SettlementTypeDistributor {
+		response.BadRequest(c, "无效的结算类型")
+		return
+	}
+	targetID, err := strconv.ParseInt(c.Query("target_id"), 10, 64)
+	if err != nil || targetID <= 0 {
+		response.BadRequest(c, "无效的目标ID")
+		return
+	}
+	periodStart, err := time.Parse("2006-01-02", c.Query("period_start"))
+	if err != nil {
+		response.BadRequest(c, "无效的周期开始日期")
+		return
+	}
+	periodEnd, err := time.Parse("2006-01-02", c.Query("period_end"))
+	if err != nil {
+		response.BadRequest(c, "无效的周期结束日期")
+		return
+	}
+
+	preview, err := h.settlementService.PreviewSettlement(c.Request.Context(), &financeService.CreateSettlementRequest{
+		Type:        settlementType,
+		TargetID:    targetID,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+	})
+	handler.MustSucceed(c, err, preview)
+}
+
 // CreateSettlementRequest 创建结算请求
 type CreateSettlementRequest struct {
 	Type        string `json:"type" binding:"required,oneof=merchant distributor"`
@@ -293,6 +621,7 @@ func (h *FinanceHandler) CreateSettlement(c *gin.Context) {
 
 // ProcessSettlement 处理结算
 // @Summary 处理结算
+// @Description 只能处理审核通过的结算
 // @Tags 管理-财务
 // @Produce json
 // @Security Bearer
@@ -300,23 +629,82 @@ func (h *FinanceHandler) CreateSettlement(c *gin.Context) {
 // @Success 200 {object} response.Response
 // @Router /api/v1/admin/finance/settlements/{id}/process [post]
 func (h *FinanceHandler) ProcessSettlement(c *gin.Context) {
-	operatorID, ok := handler.RequireAdminID(c)
+	operatorID, id, ok := handler.RequireAdminAndParseID(c, "结算")
 	if !ok {
 		return
 	}
 
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
-	if err != nil {
-		response.BadRequest(c, "无效的ID")
+	err := h.settlementService.ProcessSettlement(c.Request.Context(), id, operatorID)
+	handler.MustSucceed(c, err, nil)
+}
+
+// SubmitSettlement 提交结算审核
+// @Summary 提交结算审核
+// @Tags 管理-财务
+// @Produce json
+// @Security Bearer
+// @Param id path int true "结算ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/admin/finance/settlements/{id}/submit [post]
+func (h *FinanceHandler) SubmitSettlement(c *gin.Context) {
+	operatorID, id, ok := handler.RequireAdminAndParseID(c, "结算")
+	if !ok {
 		return
 	}
 
-	if err := h.settlementService.ProcessSettlement(c.Request.Context(), id, operatorID); err != nil {
-		response.InternalError(c, err.Error())
+	err := h.settlementService.SubmitForReview(c.Request.Context(), id, operatorID)
+	handler.MustSucceed(c, err, nil)
+}
+
+// ApproveSettlement 审核通过结算
+// @Summary 审核通过结算
+// @Description 审核通过后完成入账，制单人不能审核自己创建的结算
+// @Tags 管理-财务
+// @Produce json
+// @Security Bearer
+// @Param id path int true "结算ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/admin/finance/settlements/{id}/approve [post]
+func (h *FinanceHandler) ApproveSettlement(c *gin.Context) {
+	reviewerID, id, ok := handler.RequireAdminAndParseID(c, "结算")
+	if !ok {
 		return
 	}
 
-	response.Success(c, nil)
+	err := h.settlementService.ApproveSettlement(c.Request.Context(), id, reviewerID)
+	handler.MustSucceed(c, err, nil)
+}
+
+// RejectSettlementRequest 驳回结算请求
+type RejectSettlementRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// RejectSettlement 驳回结算
+// @Summary 驳回结算
+// @Description 驳回后释放结算锁定的佣金，可为同一周期重新生成结算
+// @Tags 管理-财务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "结算ID"
+// @Param request body RejectSettlementRequest true "请求参数"
+// @Success 200 {object} response.Response
+// @Router /api/v1/admin/finance/settlements/{id}/reject [post]
+func (h *FinanceHandler) RejectSettlement(c *gin.Context) {
+	reviewerID, id, ok := handler.RequireAdminAndParseID(c, "结算")
+	if !ok {
+		return
+	}
+
+	var req RejectSettlementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请填写驳回原因")
+		return
+	}
+
+	err := h.settlementService.RejectSettlement(c.Request.Context(), id, reviewerID, req.Reason)
+	handler.MustSucceed(c, err, nil)
 }
 
 // GenerateSettlementsRequest 生成结算请求
@@ -368,6 +756,49 @@ func (h *FinanceHandler) GenerateSettlements(c *gin.Context) {
 	handler.MustSucceed(c, err, settlements)
 }
 
+// GenerateAllSettlementsRequest 一键生成结算请求
+type GenerateAllSettlementsRequest struct {
+	PeriodStart string `json:"period_start" binding:"required"`
+	PeriodEnd   string `json:"period_end" binding:"required"`
+}
+
+// GenerateAllSettlements 一键生成商户和分销商结算记录
+// @Summary 一键生成商户和分销商结算记录
+// @Description 单个结算对象失败不会中断整体生成，失败原因在 errors 中按结算对象返回
+// @Tags 管理-财务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body GenerateAllSettlementsRequest true "请求参数"
+// @Success 200 {object} response.Response{data=financeService.GenerateAllResult}
+// @Router /api/v1/admin/finance/settlements/generate-all [post]
+func (h *FinanceHandler) GenerateAllSettlements(c *gin.Context) {
+	adminID, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	var req GenerateAllSettlementsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	periodStart, err := time.Parse("2006-01-02", req.PeriodStart)
+	if err != nil {
+		response.BadRequest(c, "无效的周期开始日期")
+		return
+	}
+	periodEnd, err := time.Parse("2006-01-02", req.PeriodEnd)
+	if err != nil {
+		response.BadRequest(c, "无效的周期结束日期")
+		return
+	}
+
+	result, err := h.settlementService.GenerateAllSettlements(c.Request.Context(), periodStart, periodEnd, adminID)
+	handler.MustSucceed(c, err, result)
+}
+
 // GetSettlementSummary 获取结算汇总
 // @Summary 获取结算汇总
 // @Tags 管理-财务
@@ -400,6 +831,23 @@ func (h *FinanceHandler) GetSettlementSummary(c *gin.Context) {
 	handler.MustSucceed(c, err, summary)
 }
 
+// ListDeadLetterSettlements 获取死信结算列表
+// @Summary 获取死信结算列表
+// @Description 自动重试次数耗尽仍处理失败的结算，需人工介入
+// @Tags 管理-财务
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response{data=[]financeService.SettlementDeadLetter}
+// @Router /api/v1/admin/finance/settlements/dead-letter [get]
+func (h *FinanceHandler) ListDeadLetterSettlements(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	list, err := h.settlementService.ListDeadLetterSettlements(c.Request.Context())
+	handler.MustSucceed(c, err, list)
+}
+
 // ListWithdrawals 获取提现列表
 // @Summary 获取提现列表
 // @Tags 管理-财务
@@ -470,6 +918,29 @@ func (h *FinanceHandler) GetWithdrawal(c *gin.Context) {
 	handler.MustSucceed(c, err, withdrawal)
 }
 
+// GetWithdrawalLogs 获取提现审核日志
+// @Summary 获取提现审核日志
+// @Tags 管理-财务
+// @Produce json
+// @Security Bearer
+// @Param id path int true "提现ID"
+// @Success 200 {object} response.Response{data=[]models.WithdrawalAuditLog}
+// @Router /api/v1/admin/finance/withdrawals/{id}/logs [get]
+func (h *FinanceHandler) GetWithdrawalLogs(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return
+	}
+
+	logs, err := h.withdrawalService.GetAuditLogs(c.Request.Context(), id)
+	handler.MustSucceed(c, err, logs)
+}
+
 // WithdrawalActionRequest 提现操作请求
 type WithdrawalActionRequest struct {
 	Action string `json:"action" binding:"required,oneof=approve reject process complete"`
@@ -522,6 +993,49 @@ func (h *FinanceHandler) HandleWithdrawal(c *gin.Context) {
 	handler.MustSucceed(c, err, nil)
 }
 
+// ListWithdrawalPolicies 获取提现规则
+// @Summary 获取提现规则
+// @Tags 管理-财务
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response{data=[]models.WithdrawalPolicy}
+// @Router /api/v1/admin/finance/withdrawal-policies [get]
+func (h *FinanceHandler) ListWithdrawalPolicies(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	policies, err := h.withdrawalService.ListWithdrawalPolicies(c.Request.Context())
+	handler.MustSucceed(c, err, policies)
+}
+
+// UpdateWithdrawalPolicy 修改提现规则
+// @Summary 修改提现规则
+// @Description 修改后仅对新的提现申请生效，已创建的提现保留申请时的规则快照
+// @Tags 管理-财务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param type path string true "提现类型: wallet/commission"
+// @Param request body financeService.UpdateWithdrawalPolicyRequest true "请求参数"
+// @Success 200 {object} response.Response{data=models.WithdrawalPolicy}
+// @Router /api/v1/admin/finance/withdrawal-policies/{type} [put]
+func (h *FinanceHandler) UpdateWithdrawalPolicy(c *gin.Context) {
+	adminID, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	var req financeService.UpdateWithdrawalPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	policy, err := h.withdrawalService.UpdateWithdrawalPolicy(c.Request.Context(), c.Param("type"), &req, adminID)
+	handler.MustSucceed(c, err, policy)
+}
+
 // BatchWithdrawalRequest 批量提现操作请求
 type BatchWithdrawalRequest struct {
 	IDs    []int64 `json:"ids" binding:"required,min=1"`
@@ -599,23 +1113,33 @@ func (h *FinanceHandler) GetWithdrawalSummary(c *gin.Context) {
 // ExportSettlements 导出结算记录
 // @Summary 导出结算记录
 // @Tags 管理-财务
-// @Produce text/csv
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Security Bearer
 // @Param type query string false "类型: merchant/distributor"
 // @Param target_id query int false "目标ID"
 // @Param status query string false "状态"
 // @Param period_start query string false "周期开始日期"
 // @Param period_end query string false "周期结束日期"
-// @Success 200 {file} file "CSV文件"
+// @Param format query string false "导出格式: csv/xlsx，设置密码时默认 xlsx" default(csv)
+// @Param password query string false "导出密码，设置时导出为加密 XLSX"
+// @Success 200 {file} file "CSV/XLSX文件"
 // @Router /api/v1/admin/finance/export/settlements [get]
 func (h *FinanceHandler) ExportSettlements(c *gin.Context) {
 	if _, ok := handler.RequireAdminID(c); !ok {
 		return
 	}
 
+	password := c.Query("password")
+	format, err := financeService.ExportFormat(c.Query("format")).NormalizeProtected(password)
+	if handler.HandleError(c, err) {
+		return
+	}
+
 	req := &financeService.ExportSettlementsRequest{
-		Type:   c.Query("type"),
-		Status: c.Query("status"),
+		Type:     c.Query("type"),
+		Status:   c.Query("status"),
+		Format:   format,
+		Password: password,
 	}
 
 	if targetIDStr := c.Query("target_id"); targetIDStr != "" {
@@ -636,33 +1160,43 @@ func (h *FinanceHandler) ExportSettlements(c *gin.Context) {
 		return
 	}
 
-	c.Header("Content-Type", "text/csv; charset=utf-8")
-	c.Header("Content-Disposition", "attachment; filename="+filename)
-	c.Data(200, "text/csv", data)
+	writeExportFile(c, req.Format, filename, data)
 }
 
 // ExportWithdrawals 导出提现记录
 // @Summary 导出提现记录
 // @Tags 管理-财务
-// @Produce text/csv
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Security Bearer
 // @Param user_id query int false "用户ID"
 // @Param type query string false "类型"
 // @Param status query string false "状态"
 // @Param start_date query string false "开始日期"
 // @Param end_date query string false "结束日期"
-// @Success 200 {file} file "CSV文件"
+// @Description 手机号和收款账户默认仅保留后4位，拥有 finance:export:full 权限时导出完整信息
+// @Param format query string false "导出格式: csv/xlsx，设置密码时默认 xlsx" default(csv)
+// @Param password query string false "导出密码，设置时导出为加密 XLSX"
+// @Success 200 {file} file "CSV/XLSX文件"
 // @Router /api/v1/admin/finance/export/withdrawals [get]
 func (h *FinanceHandler) ExportWithdrawals(c *gin.Context) {
 	if _, ok := handler.RequireAdminID(c); !ok {
 		return
 	}
 
+	password := c.Query("password")
+	format, err := financeService.ExportFormat(c.Query("format")).NormalizeProtected(password)
+	if handler.HandleError(c, err) {
+		return
+	}
+
 	req := &financeService.ExportWithdrawalsRequest{
-		Type:      c.Query("type"),
-		Status:    c.Query("status"),
-		StartDate: c.Query("start_date"),
-		EndDate:   c.Query("end_date"),
+		Type:            c.Query("type"),
+		Status:          c.Query("status"),
+		StartDate:       c.Query("start_date"),
+		EndDate:         c.Query("end_date"),
+		Format:          format,
+		Password:        password,
+		FullAccountInfo: h.canExportFullAccountInfo(c),
 	}
 
 	if userIDStr := c.Query("user_id"); userIDStr != "" {
@@ -675,19 +1209,18 @@ func (h *FinanceHandler) ExportWithdrawals(c *gin.Context) {
 		return
 	}
 
-	c.Header("Content-Type", "text/csv; charset=utf-8")
-	c.Header("Content-Disposition", "attachment; filename="+filename)
-	c.Data(200, "text/csv", data)
+	writeExportFile(c, req.Format, filename, data)
 }
 
 // ExportDailyRevenue 导出每日收入报表
 // @Summary 导出每日收入报表
 // @Tags 管理-财务
-// @Produce text/csv
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Security Bearer
 // @Param start_date query string true "开始日期 YYYY-MM-DD"
 // @Param end_date query string true "结束日期 YYYY-MM-DD"
-// @Success 200 {file} file "CSV文件"
+// @Param format query string false "导出格式: csv/xlsx" default(csv)
+// @Success 200 {file} file "CSV/XLSX文件"
 // @Router /api/v1/admin/finance/export/daily-revenue [get]
 func (h *FinanceHandler) ExportDailyRevenue(c *gin.Context) {
 	if _, ok := handler.RequireAdminID(c); !ok {
@@ -713,14 +1246,17 @@ func (h *FinanceHandler) ExportDailyRevenue(c *gin.Context) {
 		return
 	}
 
-	data, filename, err := h.exportService.ExportDailyRevenue(c.Request.Context(), startDate, endDate)
+	req := &financeService.ExportDailyRevenueRequest{
+		StartDate: startDate,
+		EndDate:   endDate,
+		Format:    financeService.ExportFormat(c.Query("format")),
+	}
+	data, filename, err := h.exportService.ExportDailyRevenue(c.Request.Context(), req)
 	if handler.HandleError(c, err) {
 		return
 	}
 
-	c.Header("Content-Type", "text/csv; charset=utf-8")
-	c.Header("Content-Disposition", "attachment; filename="+filename)
-	c.Data(200, "text/csv", data)
+	writeExportFile(c, req.Format, filename, data)
 }
 
 // ExportMerchantSettlement 导出商户结算报表
@@ -757,6 +1293,12 @@ func (h *FinanceHandler) ExportMerchantSettlement(c *gin.Context) {
 	c.Data(200, "text/csv", data)
 }
 
+// writeExportFile 输出导出文件，Content-Type 随导出格式切换
+func writeExportFile(c *gin.Context, format financeService.ExportFormat, filename string, data []byte) {
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(200, format.ContentType(), data)
+}
+
 // GetTransactionStatistics 获取交易统计
 // @Summary 获取交易统计
 // @Tags 管理-财务
@@ -788,14 +1330,16 @@ func (h *FinanceHandler) GetTransactionStatistics(c *gin.Context) {
 
 // ExportTransactions 导出交易记录
 // @Summary 导出交易记录
+// @Description 分批读取并流式输出，适用于大批量导出；单次导出的时间范围不超过366天，未指定开始日期时导出结束日期前366天
 // @Tags 管理-财务
-// @Produce text/csv
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Security Bearer
 // @Param user_id query int false "用户ID"
 // @Param type query string false "类型"
 // @Param start_date query string false "开始日期 YYYY-MM-DD"
 // @Param end_date query string false "结束日期 YYYY-MM-DD"
-// @Success 200 {file} file "CSV文件"
+// @Param format query string false "导出格式: csv/xlsx" default(csv)
+// @Success 200 {file} file "CSV/XLSX文件"
 // @Router /api/v1/admin/finance/export/transactions [get]
 func (h *FinanceHandler) ExportTransactions(c *gin.Context) {
 	if _, ok := handler.RequireAdminID(c); !ok {
@@ -803,7 +1347,8 @@ func (h *FinanceHandler) ExportTransactions(c *gin.Context) {
 	}
 
 	req := &financeService.ExportTransactionsRequest{
-		Type: c.Query("type"),
+		Type:   c.Query("type"),
+		Format: financeService.ExportFormat(c.Query("format")),
 	}
 
 	if userIDStr := c.Query("user_id"); userIDStr != "" {
@@ -820,14 +1365,24 @@ func (h *FinanceHandler) ExportTransactions(c *gin.Context) {
 		req.EndTime = &endOfDay
 	}
 
-	data, filename, err := h.exportService.ExportTransactions(c.Request.Context(), req)
+	format, err := req.Format.Normalize()
 	if handler.HandleError(c, err) {
 		return
 	}
 
-	c.Header("Content-Type", "text/csv; charset=utf-8")
-	c.Header("Content-Disposition", "attachment; filename="+filename)
-	c.Data(200, "text/csv", data)
+	// 分批读取并流式写入响应，数据量较大时不在内存中构建完整文件
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", "attachment; filename="+financeService.TransactionsExportFilename(time.Now(), format))
+
+	if err := h.exportService.StreamTransactions(c.Request.Context(), req, c.Writer); err != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Disposition")
+			handler.HandleError(c, err)
+			return
+		}
+		// 已开始输出文件内容，无法再返回错误响应，记录到请求日志
+		_ = c.Error(err)
+	}
 }
 
 // GetMerchantSettlementReport 获取商户结算报表
@@ -557,6 +557,55 @@ func (h *HotelHandler) SetRoomHot(c *gin.Context) {
 	handler.MustSucceed(c, h.hotelService.SetRoomHot(c.Request.Context(), id, &req), nil)
 }
 
+// BulkUpdateRoomStatus 批量修改房间状态
+// @Summary 批量修改房间状态
+// @Description 批量停用或启用房间（如整层装修），停用时取消房间尚未入住的预订并为已支付的预订发起全额退款；不存在的房间在 failed 中返回
+// @Tags 酒店管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body adminService.BulkUpdateRoomStatusRequest true "请求参数"
+// @Success 200 {object} response.Response{data=adminService.BulkUpdateRoomStatusResult}
+// @Router /admin/rooms/bulk-status [put]
+func (h *HotelHandler) BulkUpdateRoomStatus(c *gin.Context) {
+	adminID, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	var req adminService.BulkUpdateRoomStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	result, err := h.hotelService.BulkUpdateRoomStatus(c.Request.Context(), req.RoomIDs, *req.Status, adminID)
+	handler.MustSucceed(c, err, result)
+}
+
+// RebuildRoomOccupancy 重建房间占用位图
+// @Summary 重建房间占用位图
+// @Description 按房间的预订重建可用性查询使用的占用位图，用于修复位图与预订不一致
+// @Tags 酒店管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "房间ID"
+// @Success 200 {object} response.Response{data=adminService.RebuildOccupancyResult}
+// @Router /admin/rooms/{id}/occupancy/rebuild [post]
+func (h *HotelHandler) RebuildRoomOccupancy(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	id, ok := handler.ParseID(c, "房间")
+	if !ok {
+		return
+	}
+
+	result, err := h.hotelService.RebuildOccupancy(c.Request.Context(), id)
+	handler.MustSucceed(c, err, result)
+}
+
 // RegisterRoutes 注册路由
 func (h *HotelHandler) RegisterRoutes(r *gin.RouterGroup) {
 	// 酒店管理
@@ -576,9 +625,11 @@ func (h *HotelHandler) RegisterRoutes(r *gin.RouterGroup) {
 	{
 		rooms.POST("", h.CreateRoom)
 		rooms.GET("", h.ListRooms)
+		rooms.PUT("/bulk-status", h.BulkUpdateRoomStatus)
 		rooms.GET("/:id", h.GetRoom)
 		rooms.PUT("/:id", h.UpdateRoom)
 		rooms.PUT("/:id/hot", h.SetRoomHot)
+		rooms.POST("/:id/occupancy/rebuild", h.RebuildRoomOccupancy)
 		rooms.DELETE("/:id", h.DeleteRoom)
 	}
 
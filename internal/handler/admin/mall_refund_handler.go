@@ -0,0 +1,76 @@
+// Package admin 管理端 HTTP Handler
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	mallService "github.com/dumeirei/smart-locker-backend/internal/service/mall"
+)
+
+// MallRefundHandler 商城订单退款审批处理器
+type MallRefundHandler struct {
+	orderService *mallService.MallOrderService
+}
+
+// NewMallRefundHandler 创建商城订单退款审批处理器
+func NewMallRefundHandler(orderService *mallService.MallOrderService) *MallRefundHandler {
+	return &MallRefundHandler{orderService: orderService}
+}
+
+// Approve 批准退款
+// @Summary 批准商城订单部分退款
+// @Tags 管理-订单管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "退款ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/admin/refunds/{id}/approve [post]
+func (h *MallRefundHandler) Approve(c *gin.Context) {
+	adminID, refundID, ok := handler.RequireAdminAndParseID(c, "退款")
+	if !ok {
+		return
+	}
+
+	handler.MustSucceed(c, h.orderService.ApproveRefund(c.Request.Context(), adminID, refundID), nil)
+}
+
+// RejectRefundRequest 拒绝退款请求
+type RejectRefundRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// Reject 拒绝退款
+// @Summary 拒绝商城订单部分退款
+// @Tags 管理-订单管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "退款ID"
+// @Param request body RejectRefundRequest true "拒绝原因"
+// @Success 200 {object} response.Response
+// @Router /api/v1/admin/refunds/{id}/reject [post]
+func (h *MallRefundHandler) Reject(c *gin.Context) {
+	adminID, refundID, ok := handler.RequireAdminAndParseID(c, "退款")
+	if !ok {
+		return
+	}
+
+	var req RejectRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	handler.MustSucceed(c, h.orderService.RejectRefund(c.Request.Context(), adminID, refundID, req.Reason), nil)
+}
+
+// RegisterRoutes 注册路由
+func (h *MallRefundHandler) RegisterRoutes(r *gin.RouterGroup) {
+	refunds := r.Group("/refunds")
+	{
+		refunds.POST("/:id/approve", h.Approve)
+		refunds.POST("/:id/reject", h.Reject)
+	}
+}
@@ -2,24 +2,31 @@
 package admin
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
 	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
 	adminService "github.com/dumeirei/smart-locker-backend/internal/service/admin"
+	marketingService "github.com/dumeirei/smart-locker-backend/internal/service/marketing"
 )
 
 // MarketingHandler 营销管理处理器
 type MarketingHandler struct {
 	marketingService *adminService.MarketingAdminService
+	couponService    *marketingService.CouponService
+	grantService     *marketingService.CouponGrantService
 }
 
 // NewMarketingHandler 创建营销管理处理器
-func NewMarketingHandler(marketingSvc *adminService.MarketingAdminService) *MarketingHandler {
+func NewMarketingHandler(marketingSvc *adminService.MarketingAdminService, couponSvc *marketingService.CouponService, grantSvc *marketingService.CouponGrantService) *MarketingHandler {
 	return &MarketingHandler{
 		marketingService: marketingSvc,
+		couponService:    couponSvc,
+		grantService:     grantSvc,
 	}
 }
 
@@ -197,6 +204,149 @@ func (h *MarketingHandler) DeleteCoupon(c *gin.Context) {
 	handler.MustSucceedWithMessage(c, err, "删除成功", nil)
 }
 
+// BulkDistributeCouponRequest 批量发放优惠券请求
+type BulkDistributeCouponRequest struct {
+	UserIDs []int64 `json:"user_ids" binding:"required,min=1,max=10000"`
+}
+
+// BulkDistributeFailure 批量发放失败明细
+type BulkDistributeFailure struct {
+	UserID  int64  `json:"user_id"`
+	Message string `json:"message"`
+}
+
+// BulkDistributeCouponResponse 批量发放优惠券响应
+type BulkDistributeCouponResponse struct {
+	Succeeded int                      `json:"succeeded"`
+	Failed    int                      `json:"failed"`
+	Errors    []*BulkDistributeFailure `json:"errors"`
+}
+
+// BulkDistributeCoupon 批量发放优惠券
+// @Summary 批量发放优惠券
+// @Tags 管理端-营销管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "优惠券ID"
+// @Param request body BulkDistributeCouponRequest true "请求参数"
+// @Success 200 {object} response.Response{data=BulkDistributeCouponResponse}
+// @Router /api/v1/admin/marketing/coupons/{id}/bulk-distribute [post]
+func (h *MarketingHandler) BulkDistributeCoupon(c *gin.Context) {
+	adminID, couponID, ok := handler.RequireAdminAndParseID(c, "优惠券")
+	if !ok {
+		return
+	}
+
+	var req BulkDistributeCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	succeeded, failed, errs := h.couponService.BulkDistribute(c.Request.Context(), couponID, req.UserIDs, adminID)
+
+	result := &BulkDistributeCouponResponse{
+		Succeeded: succeeded,
+		Failed:    failed,
+		Errors:    make([]*BulkDistributeFailure, 0, len(errs)),
+	}
+	for _, err := range errs {
+		var userErr *marketingService.BulkDistributeError
+		if !errors.As(err, &userErr) {
+			// 优惠券本身不可发放
+			if errors.Is(err, marketingService.ErrCouponNotFound) {
+				response.NotFound(c, err.Error())
+			} else {
+				response.BadRequest(c, err.Error())
+			}
+			return
+		}
+		result.Errors = append(result.Errors, &BulkDistributeFailure{UserID: userErr.UserID, Message: userErr.Err.Error()})
+	}
+
+	response.Success(c, result)
+}
+
+// GrantCouponRequest 按分群发放优惠券请求
+type GrantCouponRequest struct {
+	BatchNo string                    `json:"batch_no" binding:"omitempty,max=64"` // 批次号，重复提交同一批次号不会重复发放；为空时自动生成
+	Segment models.CouponGrantSegment `json:"segment"`
+}
+
+// GrantCoupon 按用户分群发放优惠券
+// @Summary 按用户分群发放优惠券
+// @Description 库存不足时发放至上限，未发放人数记录在 shortfall_count 中
+// @Tags 管理端-营销管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "优惠券ID"
+// @Param request body GrantCouponRequest true "请求参数"
+// @Success 200 {object} response.Response{data=models.CouponGrantBatch}
+// @Router /api/v1/admin/marketing/coupons/{id}/grants [post]
+func (h *MarketingHandler) GrantCoupon(c *gin.Context) {
+	adminID, couponID, ok := handler.RequireAdminAndParseID(c, "优惠券")
+	if !ok {
+		return
+	}
+
+	var req GrantCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	batch, err := h.grantService.GrantToSegment(c.Request.Context(), couponID, req.Segment, req.BatchNo, adminID)
+	if err != nil {
+		respondCouponGrantError(c, err)
+		return
+	}
+	response.Success(c, batch)
+}
+
+// GetCouponGrants 获取优惠券定向发放记录
+// @Summary 获取优惠券定向发放记录
+// @Tags 管理端-营销管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "优惠券ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Success 200 {object} response.Response{data=response.PageData}
+// @Router /api/v1/admin/marketing/coupons/{id}/grants [get]
+func (h *MarketingHandler) GetCouponGrants(c *gin.Context) {
+	couponID, ok := handler.ParseID(c, "优惠券")
+	if !ok {
+		return
+	}
+
+	p := handler.BindPagination(c)
+	list, total, err := h.grantService.ListGrants(c.Request.Context(), couponID, p.Page, p.PageSize)
+	if err != nil {
+		respondCouponGrantError(c, err)
+		return
+	}
+	response.SuccessPage(c, list, total, p.Page, p.PageSize)
+}
+
+// respondCouponGrantError 将定向发放错误映射为 HTTP 响应
+func respondCouponGrantError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, marketingService.ErrCouponNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, marketingService.ErrGrantSegmentEmpty),
+		errors.Is(err, marketingService.ErrGrantSegmentInvalid),
+		errors.Is(err, marketingService.ErrGrantBatchConflict),
+		errors.Is(err, marketingService.ErrCouponNotActive),
+		errors.Is(err, marketingService.ErrCouponNotStarted),
+		errors.Is(err, marketingService.ErrCouponExpired):
+		response.BadRequest(c, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
+
 // GetCampaignList 获取活动列表
 // @Summary 获取活动列表
 // @Tags 管理端-营销管理
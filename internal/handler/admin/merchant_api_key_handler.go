@@ -0,0 +1,129 @@
+// Package admin 提供管理员相关的 HTTP Handler
+package admin
+
+import (
+	"errors"
+	"io"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	merchantService "github.com/dumeirei/smart-locker-backend/internal/service/merchant"
+)
+
+// MerchantAPIKeyHandler 商户开放接口密钥管理处理器
+type MerchantAPIKeyHandler struct {
+	apiKeyService *merchantService.MerchantAPIKeyService
+}
+
+// NewMerchantAPIKeyHandler 创建商户开放接口密钥管理处理器
+func NewMerchantAPIKeyHandler(apiKeySvc *merchantService.MerchantAPIKeyService) *MerchantAPIKeyHandler {
+	return &MerchantAPIKeyHandler{
+		apiKeyService: apiKeySvc,
+	}
+}
+
+// List 获取商户密钥列表
+// @Summary 获取商户密钥列表
+// @Tags 商户管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "商户ID"
+// @Success 200 {object} response.Response{data=[]models.MerchantAPIKey}
+// @Router /admin/merchants/{id}/api-keys [get]
+func (h *MerchantAPIKeyHandler) List(c *gin.Context) {
+	_, merchantID, ok := handler.RequireAdminAndParseID(c, "商户")
+	if !ok {
+		return
+	}
+
+	keys, err := h.apiKeyService.ListKeys(c.Request.Context(), merchantID)
+	handler.MustSucceed(c, err, keys)
+}
+
+// Issue 签发商户密钥
+// @Summary 签发商户密钥
+// @Description 签名密钥仅在签发时返回一次，请妥善保存
+// @Tags 商户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "商户ID"
+// @Param request body merchantService.IssueAPIKeyRequest false "请求参数"
+// @Success 200 {object} response.Response{data=merchantService.IssuedAPIKey}
+// @Router /admin/merchants/{id}/api-keys [post]
+func (h *MerchantAPIKeyHandler) Issue(c *gin.Context) {
+	_, merchantID, ok := handler.RequireAdminAndParseID(c, "商户")
+	if !ok {
+		return
+	}
+
+	// 请求体可省略，省略时授予全部范围
+	var req merchantService.IssueAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	issued, err := h.apiKeyService.IssueKey(c.Request.Context(), merchantID, &req)
+	handler.MustSucceed(c, err, issued)
+}
+
+// Rotate 轮换商户密钥
+// @Summary 轮换商户密钥
+// @Description 签发授权范围相同的新密钥并立即吊销旧密钥
+// @Tags 商户管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "商户ID"
+// @Param key_id path int true "密钥记录ID"
+// @Success 200 {object} response.Response{data=merchantService.IssuedAPIKey}
+// @Router /admin/merchants/{id}/api-keys/{key_id}/rotate [post]
+func (h *MerchantAPIKeyHandler) Rotate(c *gin.Context) {
+	_, merchantID, ok := handler.RequireAdminAndParseID(c, "商户")
+	if !ok {
+		return
+	}
+	keyID, ok := handler.ParseParamID(c, "key_id", "密钥")
+	if !ok {
+		return
+	}
+
+	issued, err := h.apiKeyService.RotateKey(c.Request.Context(), merchantID, keyID)
+	handler.MustSucceed(c, err, issued)
+}
+
+// Revoke 吊销商户密钥
+// @Summary 吊销商户密钥
+// @Tags 商户管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "商户ID"
+// @Param key_id path int true "密钥记录ID"
+// @Success 200 {object} response.Response
+// @Router /admin/merchants/{id}/api-keys/{key_id} [delete]
+func (h *MerchantAPIKeyHandler) Revoke(c *gin.Context) {
+	_, merchantID, ok := handler.RequireAdminAndParseID(c, "商户")
+	if !ok {
+		return
+	}
+	keyID, ok := handler.ParseParamID(c, "key_id", "密钥")
+	if !ok {
+		return
+	}
+
+	err := h.apiKeyService.RevokeKey(c.Request.Context(), merchantID, keyID)
+	handler.MustSucceed(c, err, nil)
+}
+
+// RegisterRoutes 注册路由
+func (h *MerchantAPIKeyHandler) RegisterRoutes(r *gin.RouterGroup) {
+	keys := r.Group("/merchants/:id/api-keys")
+	{
+		keys.GET("", h.List)
+		keys.POST("", h.Issue)
+		keys.POST("/:key_id/rotate", h.Rotate)
+		keys.DELETE("/:key_id", h.Revoke)
+	}
+}
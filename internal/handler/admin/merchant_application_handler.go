@@ -0,0 +1,160 @@
+package admin
+
+import (
+	"errors"
+	"io"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	merchantService "github.com/dumeirei/smart-locker-backend/internal/service/merchant"
+)
+
+// MerchantApplicationHandler 商户入驻申请审核处理器
+type MerchantApplicationHandler struct {
+	applicationService *merchantService.MerchantApplicationService
+}
+
+// NewMerchantApplicationHandler 创建商户入驻申请审核处理器
+func NewMerchantApplicationHandler(applicationSvc *merchantService.MerchantApplicationService) *MerchantApplicationHandler {
+	return &MerchantApplicationHandler{
+		applicationService: applicationSvc,
+	}
+}
+
+// List 获取入驻申请列表
+// @Summary 获取商户入驻申请列表
+// @Tags 商户管理
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param status query string false "状态" Enums(draft, submitted, under_review, approved, rejected)
+// @Param keyword query string false "商户名称或联系电话"
+// @Success 200 {object} response.Response{data=response.ListData}
+// @Router /admin/merchant-applications [get]
+func (h *MerchantApplicationHandler) List(c *gin.Context) {
+	_, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	p := handler.BindAdminPagination(c)
+	filters := &repository.MerchantApplicationListFilters{
+		Status:  c.Query("status"),
+		Keyword: c.Query("keyword"),
+	}
+
+	list, total, err := h.applicationService.List(c.Request.Context(), p.GetOffset(), p.GetLimit(), filters)
+	handler.MustSucceedPage(c, err, list, total, p.Page, p.PageSize)
+}
+
+// Get 获取入驻申请详情
+// @Summary 获取商户入驻申请详情
+// @Tags 商户管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "申请ID"
+// @Success 200 {object} response.Response{data=merchantService.MerchantApplicationInfo}
+// @Router /admin/merchant-applications/{id} [get]
+func (h *MerchantApplicationHandler) Get(c *gin.Context) {
+	_, id, ok := handler.RequireAdminAndParseID(c, "申请")
+	if !ok {
+		return
+	}
+
+	info, err := h.applicationService.Get(c.Request.Context(), id)
+	handler.MustSucceed(c, err, info)
+}
+
+// StartReview 受理入驻申请
+// @Summary 受理商户入驻申请
+// @Description 已提交的申请进入审核中
+// @Tags 商户管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "申请ID"
+// @Success 200 {object} response.Response{data=merchantService.MerchantApplicationInfo}
+// @Router /admin/merchant-applications/{id}/review [post]
+func (h *MerchantApplicationHandler) StartReview(c *gin.Context) {
+	adminID, id, ok := handler.RequireAdminAndParseID(c, "申请")
+	if !ok {
+		return
+	}
+
+	info, err := h.applicationService.StartReview(c.Request.Context(), id, adminID)
+	handler.MustSucceed(c, err, info)
+}
+
+// Approve 审核通过入驻申请
+// @Summary 审核通过商户入驻申请
+// @Description 按申请资料创建商户，重复调用返回已创建的商户
+// @Tags 商户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "申请ID"
+// @Param request body merchantService.ApproveMerchantApplicationRequest false "分成比例与结算周期"
+// @Success 200 {object} response.Response{data=models.Merchant}
+// @Router /admin/merchant-applications/{id}/approve [post]
+func (h *MerchantApplicationHandler) Approve(c *gin.Context) {
+	adminID, id, ok := handler.RequireAdminAndParseID(c, "申请")
+	if !ok {
+		return
+	}
+
+	// 请求体可省略，省略时使用默认分成比例和结算周期
+	var req merchantService.ApproveMerchantApplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	merchant, err := h.applicationService.Approve(c.Request.Context(), id, adminID, &req)
+	handler.MustSucceed(c, err, merchant)
+}
+
+// RejectMerchantApplicationRequest 驳回入驻申请请求
+type RejectMerchantApplicationRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// Reject 驳回入驻申请
+// @Summary 驳回商户入驻申请
+// @Tags 商户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "申请ID"
+// @Param request body RejectMerchantApplicationRequest true "驳回原因"
+// @Success 200 {object} response.Response{data=merchantService.MerchantApplicationInfo}
+// @Router /admin/merchant-applications/{id}/reject [post]
+func (h *MerchantApplicationHandler) Reject(c *gin.Context) {
+	adminID, id, ok := handler.RequireAdminAndParseID(c, "申请")
+	if !ok {
+		return
+	}
+
+	var req RejectMerchantApplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请填写驳回原因")
+		return
+	}
+
+	info, err := h.applicationService.Reject(c.Request.Context(), id, adminID, req.Reason)
+	handler.MustSucceed(c, err, info)
+}
+
+// RegisterRoutes 注册路由
+func (h *MerchantApplicationHandler) RegisterRoutes(r *gin.RouterGroup) {
+	applications := r.Group("/merchant-applications")
+	{
+		applications.GET("", h.List)
+		applications.GET("/:id", h.Get)
+		applications.POST("/:id/review", h.StartReview)
+		applications.POST("/:id/approve", h.Approve)
+		applications.POST("/:id/reject", h.Reject)
+	}
+}
@@ -0,0 +1,88 @@
+// Package admin 管理端 HTTP Handler
+package admin
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	"github.com/dumeirei/smart-locker-backend/internal/middleware"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	adminService "github.com/dumeirei/smart-locker-backend/internal/service/admin"
+)
+
+// OperationLogHandler 操作日志（审计日志）处理器
+type OperationLogHandler struct {
+	logService        *adminService.OperationLogService
+	permissionService *adminService.PermissionService
+}
+
+// NewOperationLogHandler 创建操作日志处理器
+func NewOperationLogHandler(logService *adminService.OperationLogService, permissionSvc *adminService.PermissionService) *OperationLogHandler {
+	return &OperationLogHandler{
+		logService:        logService,
+		permissionService: permissionSvc,
+	}
+}
+
+// List 获取操作日志列表
+// @Summary 获取管理员操作日志
+// @Description 查询管理员的写操作记录；需要操作日志查看权限
+// @Tags 管理-系统管理
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param admin_id query int false "管理员ID"
+// @Param module query string false "模块"
+// @Param action query string false "操作"
+// @Param resource_type query string false "操作对象类型，如 settlement"
+// @Param resource_id query int false "操作对象ID"
+// @Param route query string false "路由前缀，如 /api/admin/finance"
+// @Param start_date query string false "开始日期 YYYY-MM-DD"
+// @Param end_date query string false "结束日期 YYYY-MM-DD"
+// @Success 200 {object} response.Response{data=response.ListData}
+// @Router /api/admin/audit-logs [get]
+func (h *OperationLogHandler) List(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	p := handler.BindAdminPagination(c)
+
+	filters := &adminService.OperationLogListFilters{
+		Module:       c.Query("module"),
+		Action:       c.Query("action"),
+		ResourceType: c.Query("resource_type"),
+		RoutePrefix:  c.Query("route"),
+	}
+	if s := c.Query("admin_id"); s != "" {
+		if adminID, err := strconv.ParseInt(s, 10, 64); err == nil {
+			filters.AdminID = adminID
+		}
+	}
+	if s := c.Query("resource_id"); s != "" {
+		if resourceID, err := strconv.ParseInt(s, 10, 64); err == nil {
+			filters.ResourceID = resourceID
+		}
+	}
+
+	startDate, endDate, ok := handler.ParseQueryDateRange(c)
+	if !ok {
+		return
+	}
+	filters.StartDate = startDate
+	filters.EndDate = endDate
+
+	logs, total, err := h.logService.List(c.Request.Context(), p.Page, p.PageSize, filters)
+	handler.MustSucceedPage(c, err, logs, total, p.Page, p.PageSize)
+}
+
+// RegisterRoutes 注册路由
+func (h *OperationLogHandler) RegisterRoutes(r *gin.RouterGroup) {
+	requireAuditLog := middleware.RequirePermission(h.permissionService, models.PermissionCodeAuditLog)
+
+	r.GET("/audit-logs", requireAuditLog, h.List)
+	r.GET("/logs/operation", requireAuditLog, h.List)
+}
@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	orderService "github.com/dumeirei/smart-locker-backend/internal/service/order"
+)
+
+// OrderNoteHandler 订单备注管理处理器
+type OrderNoteHandler struct {
+	noteService *orderService.OrderNoteService
+}
+
+// NewOrderNoteHandler 创建订单备注管理处理器
+func NewOrderNoteHandler(noteService *orderService.OrderNoteService) *OrderNoteHandler {
+	return &OrderNoteHandler{noteService: noteService}
+}
+
+// RegisterRoutes 注册路由
+func (h *OrderNoteHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/orders/:id/notes", h.List)
+	r.POST("/orders/:id/notes", h.Add)
+}
+
+// List 获取订单备注时间线
+// @Summary 获取订单备注时间线
+// @Description 按时间倒序返回订单的全部备注，包括仅管理员可见的备注
+// @Tags 管理-订单管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "订单ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=response.ListData}
+// @Router /api/admin/orders/{id}/notes [get]
+func (h *OrderNoteHandler) List(c *gin.Context) {
+	_, orderID, ok := handler.RequireAdminAndParseID(c, "订单")
+	if !ok {
+		return
+	}
+
+	p := handler.BindAdminPagination(c)
+
+	notes, total, err := h.noteService.ListAdminNotes(c.Request.Context(), orderID, p.Page, p.PageSize)
+	handler.MustSucceedPage(c, err, notes, total, p.Page, p.PageSize)
+}
+
+// Add 添加订单备注
+// @Summary 添加订单备注
+// @Description 默认仅管理员可见，visible_to_user 为 true 时用户也可在订单时间线中看到
+// @Tags 管理-订单管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "订单ID"
+// @Param request body orderService.AddAdminOrderNoteRequest true "请求参数"
+// @Success 200 {object} response.Response{data=models.OrderNote}
+// @Router /api/admin/orders/{id}/notes [post]
+func (h *OrderNoteHandler) Add(c *gin.Context) {
+	adminID, orderID, ok := handler.RequireAdminAndParseID(c, "订单")
+	if !ok {
+		return
+	}
+
+	var req orderService.AddAdminOrderNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	note, err := h.noteService.AddAdminNote(c.Request.Context(), adminID, orderID, &req)
+	handler.MustSucceed(c, err, note)
+}
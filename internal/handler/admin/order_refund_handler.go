@@ -0,0 +1,71 @@
+// Package admin 管理端 HTTP Handler
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	"github.com/dumeirei/smart-locker-backend/internal/middleware"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	orderService "github.com/dumeirei/smart-locker-backend/internal/service/order"
+	paymentService "github.com/dumeirei/smart-locker-backend/internal/service/payment"
+)
+
+// OrderRefundHandler 订单退款处理器
+type OrderRefundHandler struct {
+	refundService     *orderService.RefundService
+	permissionChecker middleware.PermissionChecker
+}
+
+// NewOrderRefundHandler 创建订单退款处理器
+func NewOrderRefundHandler(
+	refundService *orderService.RefundService,
+	permissionChecker middleware.PermissionChecker,
+) *OrderRefundHandler {
+	return &OrderRefundHandler{
+		refundService:     refundService,
+		permissionChecker: permissionChecker,
+	}
+}
+
+// CreateOrderRefundRequest 管理员发起退款请求
+type CreateOrderRefundRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+	Reason string  `json:"reason" binding:"required,max=255"`
+}
+
+// Create 发起订单退款
+// @Summary 管理员发起订单退款（支持部分退款）
+// @Tags 管理-订单管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "订单ID"
+// @Param Idempotency-Key header string false "幂等键，相同幂等键的重复提交返回首次创建的退款"
+// @Param request body CreateOrderRefundRequest true "退款金额及原因"
+// @Success 200 {object} response.Response{data=models.Refund}
+// @Router /api/v1/admin/orders/{id}/refund [post]
+func (h *OrderRefundHandler) Create(c *gin.Context) {
+	adminID, orderID, ok := handler.RequireAdminAndParseID(c, "订单")
+	if !ok {
+		return
+	}
+
+	var req CreateOrderRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	refund, err := h.refundService.CreateAdminRefund(c.Request.Context(), orderID, req.Amount, req.Reason,
+		c.GetHeader(paymentService.IdempotencyKeyHeader), adminID)
+	handler.MustSucceed(c, err, refund)
+}
+
+// RegisterRoutes 注册路由
+func (h *OrderRefundHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/orders/:id/refund",
+		middleware.RequirePermission(h.permissionChecker, models.PermissionCodeOrderRefund),
+		h.Create)
+}
@@ -124,6 +124,7 @@ func (h *ProductHandler) DeleteCategory(c *gin.Context) {
 // @Param category_id query int false "分类ID"
 // @Param keyword query string false "关键词"
 // @Param is_on_sale query bool false "是否上架"
+// @Param include_deleted query bool false "是否包含已删除的商品"
 // @Param page query int false "页码"
 // @Param page_size query int false "每页数量"
 // @Success 200 {object} response.Response{data=[]adminService.ProductAdminInfo}
@@ -159,6 +160,7 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 // @Produce json
 // @Security Bearer
 // @Param id path int true "商品ID"
+// @Param include_deleted query bool false "是否包含已删除的商品"
 // @Success 200 {object} response.Response{data=adminService.ProductAdminInfo}
 // @Router /api/v1/admin/products/{id} [get]
 func (h *ProductHandler) GetProductDetail(c *gin.Context) {
@@ -172,7 +174,9 @@ func (h *ProductHandler) GetProductDetail(c *gin.Context) {
 		return
 	}
 
-	product, err := h.productAdminService.GetProductDetail(c.Request.Context(), id)
+	includeDeleted := c.Query("include_deleted") == "true"
+
+	product, err := h.productAdminService.GetProductDetail(c.Request.Context(), id, includeDeleted)
 	handler.MustSucceed(c, err, product)
 }
 
@@ -254,6 +258,46 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	handler.MustSucceed(c, h.productAdminService.DeleteProduct(c.Request.Context(), id), nil)
 }
 
+// RestoreProduct 恢复已删除的商品
+// @Summary 恢复已删除的商品
+// @Tags 商品管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "商品ID"
+// @Success 200 {object} response.Response{data=adminService.ProductAdminInfo}
+// @Router /api/v1/admin/products/{id}/restore [post]
+func (h *ProductHandler) RestoreProduct(c *gin.Context) {
+	_, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := handler.ParseID(c, "商品")
+	if !ok {
+		return
+	}
+
+	product, err := h.productAdminService.RestoreProduct(c.Request.Context(), id)
+	handler.MustSucceed(c, err, product)
+}
+
+// ReindexProducts 重建商品搜索索引
+// @Summary 重建商品搜索索引
+// @Tags 商品管理
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response{data=adminService.ReindexResult}
+// @Router /api/v1/admin/products/reindex [post]
+func (h *ProductHandler) ReindexProducts(c *gin.Context) {
+	_, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.productAdminService.ReindexProducts(c.Request.Context())
+	handler.MustSucceed(c, err, result)
+}
+
 // UpdateProductStatus 更新商品上架状态
 // @Summary 更新商品上架状态
 // @Tags 商品管理
@@ -0,0 +1,198 @@
+// Package admin 管理端 HTTP Handler
+package admin
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	"github.com/dumeirei/smart-locker-backend/internal/middleware"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	adminService "github.com/dumeirei/smart-locker-backend/internal/service/admin"
+	rentalService "github.com/dumeirei/smart-locker-backend/internal/service/rental"
+)
+
+// RentalHandler 租借管理处理器
+type RentalHandler struct {
+	rentalService     *adminService.RentalAdminService
+	rentalOpService   *rentalService.RentalService
+	permissionChecker middleware.PermissionChecker
+}
+
+// NewRentalHandler 创建租借管理处理器
+func NewRentalHandler(
+	rentalAdminSvc *adminService.RentalAdminService,
+	rentalSvc *rentalService.RentalService,
+	permissionChecker middleware.PermissionChecker,
+) *RentalHandler {
+	return &RentalHandler{
+		rentalService:     rentalAdminSvc,
+		rentalOpService:   rentalSvc,
+		permissionChecker: permissionChecker,
+	}
+}
+
+// ForceCompleteRequest 强制完成租借请求
+type ForceCompleteRequest struct {
+	WaiveOvertimeFee bool   `json:"waive_overtime_fee"`
+	AdminNote        string `json:"admin_note" binding:"max=255"`
+}
+
+// UpdateRentalConfigRequest 更新租借配置请求
+type UpdateRentalConfigRequest struct {
+	MaxConcurrentRentals *int `json:"max_concurrent_rentals" binding:"required,min=0"`
+}
+
+// RentalConfigResponse 租借配置
+type RentalConfigResponse struct {
+	MaxConcurrentRentals int `json:"max_concurrent_rentals"`
+}
+
+// List 获取租借列表
+// @Summary 获取租借列表
+// @Tags 管理-租借管理
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param status query string false "租借状态"
+// @Param device_id query int false "设备ID"
+// @Param venue_id query int false "场地ID"
+// @Success 200 {object} response.Response{data=response.PageData}
+// @Router /api/v1/admin/rentals [get]
+func (h *RentalHandler) List(c *gin.Context) {
+	_, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	p := handler.BindPaginationWithDefaults(c, 1, 20)
+
+	filters := &adminService.RentalListFilters{
+		Status: c.Query("status"),
+	}
+	if s := c.Query("device_id"); s != "" {
+		if deviceID, err := strconv.ParseInt(s, 10, 64); err == nil {
+			filters.DeviceID = deviceID
+		}
+	}
+	if s := c.Query("venue_id"); s != "" {
+		if venueID, err := strconv.ParseInt(s, 10, 64); err == nil {
+			filters.VenueID = venueID
+		}
+	}
+
+	rentals, total, err := h.rentalService.ListRentals(c.Request.Context(), p.Page, p.PageSize, filters)
+	handler.MustSucceedPage(c, err, rentals, total, p.Page, p.PageSize)
+}
+
+// ForceComplete 强制完成租借
+// @Summary 强制完成租借
+// @Description 用户弃用未归还等场景下由运营人员结算租借，可选择免除超时费；需要租借管理权限
+// @Tags 管理-租借管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "租借ID"
+// @Param request body ForceCompleteRequest true "强制完成参数"
+// @Success 200 {object} response.Response
+// @Router /api/v1/admin/rentals/{id}/force-complete [post]
+func (h *RentalHandler) ForceComplete(c *gin.Context) {
+	adminID, rentalID, ok := handler.RequireAdminAndParseID(c, "租借")
+	if !ok {
+		return
+	}
+
+	var req ForceCompleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	err := h.rentalOpService.ForceCompleteRental(c.Request.Context(), rentalID, adminID, req.WaiveOvertimeFee, req.AdminNote)
+	handler.MustSucceed(c, err, nil)
+}
+
+// UpdateConfig 更新租借配置
+// @Summary 更新租借配置
+// @Description 运行时修改每个用户同时进行中的租借数上限（0 表示不限制），立即对所有实例生效；需要租借管理权限
+// @Tags 管理-租借管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body UpdateRentalConfigRequest true "租借配置"
+// @Success 200 {object} response.Response{data=RentalConfigResponse}
+// @Router /api/v1/admin/config/rental [patch]
+func (h *RentalHandler) UpdateConfig(c *gin.Context) {
+	_, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateRentalConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	err := h.rentalOpService.UpdateMaxConcurrentRentals(c.Request.Context(), *req.MaxConcurrentRentals)
+	handler.MustSucceed(c, err, &RentalConfigResponse{MaxConcurrentRentals: *req.MaxConcurrentRentals})
+}
+
+// GetDeviceRentalStats 获取设备租借统计
+// @Summary 获取设备租借统计
+// @Description 统计设备在日期范围内创建的租借数量、收入、平均使用时长、平均超时费及开始使用的高峰小时
+// @Tags 管理-租借管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "设备ID"
+// @Param start query string true "开始日期 YYYY-MM-DD"
+// @Param end query string true "结束日期 YYYY-MM-DD"
+// @Success 200 {object} response.Response{data=rentalService.DeviceRentalStats}
+// @Router /api/admin/devices/{id}/rental-stats [get]
+func (h *RentalHandler) GetDeviceRentalStats(c *gin.Context) {
+	_, deviceID, ok := handler.RequireAdminAndParseID(c, "设备")
+	if !ok {
+		return
+	}
+
+	startDateStr := c.Query("start")
+	endDateStr := c.Query("end")
+	if startDateStr == "" || endDateStr == "" {
+		response.BadRequest(c, "请指定开始和结束日期")
+		return
+	}
+
+	startDate, err := handler.ParseDate(startDateStr)
+	if err != nil {
+		response.BadRequest(c, "无效的开始日期格式")
+		return
+	}
+	endDate, err := handler.ParseDate(endDateStr)
+	if err != nil {
+		response.BadRequest(c, "无效的结束日期格式")
+		return
+	}
+	endDate = endDate.Add(24*time.Hour - time.Second)
+
+	stats, err := h.rentalOpService.GetRentalStatsByDevice(c.Request.Context(), deviceID, startDate, endDate)
+	handler.MustSucceed(c, err, stats)
+}
+
+// RegisterRoutes 注册路由
+func (h *RentalHandler) RegisterRoutes(r *gin.RouterGroup) {
+	rentals := r.Group("/rentals")
+	{
+		rentals.GET("", h.List)
+		rentals.POST("/:id/force-complete",
+			middleware.RequirePermission(h.permissionChecker, models.PermissionCodeRentalManagement),
+			h.ForceComplete)
+	}
+	r.GET("/devices/:id/rental-stats", h.GetDeviceRentalStats)
+	r.PATCH("/config/rental",
+		middleware.RequirePermission(h.permissionChecker, models.PermissionCodeRentalManagement),
+		h.UpdateConfig)
+}
@@ -0,0 +1,130 @@
+// Package admin 管理端 HTTP Handler
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	"github.com/dumeirei/smart-locker-backend/internal/middleware"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	adminService "github.com/dumeirei/smart-locker-backend/internal/service/admin"
+)
+
+// RoleHandler 角色权限管理处理器
+type RoleHandler struct {
+	permissionService *adminService.PermissionService
+}
+
+// NewRoleHandler 创建角色权限管理处理器
+func NewRoleHandler(permissionSvc *adminService.PermissionService) *RoleHandler {
+	return &RoleHandler{
+		permissionService: permissionSvc,
+	}
+}
+
+// SetRolePermissionsRequest 设置角色权限请求
+type SetRolePermissionsRequest struct {
+	PermissionIDs []int64 `json:"permission_ids" binding:"required"` // 权限ID列表，为空数组时清空角色权限
+}
+
+// ListRoles 获取角色列表
+// @Summary 获取角色列表
+// @Description 返回所有角色及其权限编码；需要角色权限管理权限
+// @Tags 管理-系统管理
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response{data=[]adminService.RoleInfo}
+// @Router /api/admin/roles [get]
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	roles, err := h.permissionService.ListRolesWithPermissions(c.Request.Context())
+	handler.MustSucceed(c, err, roles)
+}
+
+// GetRole 获取角色详情
+// @Summary 获取角色详情
+// @Description 返回角色及其拥有的权限；需要角色权限管理权限
+// @Tags 管理-系统管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "角色ID"
+// @Success 200 {object} response.Response{data=models.Role}
+// @Router /api/admin/roles/{id} [get]
+func (h *RoleHandler) GetRole(c *gin.Context) {
+	_, roleID, ok := handler.RequireAdminAndParseID(c, "角色")
+	if !ok {
+		return
+	}
+
+	role, err := h.permissionService.GetRole(c.Request.Context(), roleID)
+	handler.MustSucceed(c, err, role)
+}
+
+// SetRolePermissions 设置角色权限
+// @Summary 设置角色权限
+// @Description 覆盖角色的权限列表，立即生效；需要角色权限管理权限
+// @Tags 管理-系统管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "角色ID"
+// @Param request body SetRolePermissionsRequest true "权限参数"
+// @Success 200 {object} response.Response{data=models.Role}
+// @Router /api/admin/roles/{id}/permissions [put]
+func (h *RoleHandler) SetRolePermissions(c *gin.Context) {
+	_, roleID, ok := handler.RequireAdminAndParseID(c, "角色")
+	if !ok {
+		return
+	}
+
+	var req SetRolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.permissionService.SetRolePermissions(ctx, roleID, req.PermissionIDs); handler.HandleError(c, err) {
+		return
+	}
+
+	role, err := h.permissionService.GetRole(ctx, roleID)
+	handler.MustSucceed(c, err, role)
+}
+
+// ListPermissions 获取权限列表
+// @Summary 获取权限列表
+// @Description 需要角色权限管理权限
+// @Tags 管理-系统管理
+// @Produce json
+// @Security Bearer
+// @Param type query string false "权限类型（menu/api）"
+// @Success 200 {object} response.Response{data=[]models.Permission}
+// @Router /api/admin/permissions [get]
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	filters := make(map[string]interface{})
+	if permType := c.Query("type"); permType != "" {
+		filters["type"] = permType
+	}
+
+	permissions, err := h.permissionService.ListPermissions(c.Request.Context(), filters)
+	handler.MustSucceed(c, err, permissions)
+}
+
+// RegisterRoutes 注册路由
+func (h *RoleHandler) RegisterRoutes(r *gin.RouterGroup) {
+	requireRoleManagement := middleware.RequirePermission(h.permissionService, models.PermissionCodeRoleManagement)
+
+	r.GET("/roles", requireRoleManagement, h.ListRoles)
+	r.GET("/roles/:id", requireRoleManagement, h.GetRole)
+	r.PUT("/roles/:id/permissions", requireRoleManagement, h.SetRolePermissions)
+	r.GET("/permissions", requireRoleManagement, h.ListPermissions)
+}
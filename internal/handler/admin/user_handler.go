@@ -175,6 +175,35 @@ func (h *UserHandler) Disable(c *gin.Context) {
 	handler.MustSucceed(c, h.userService.Disable(c.Request.Context(), id), nil)
 }
 
+// ForceLogoutResponse 强制下线响应
+type ForceLogoutResponse struct {
+	RevokedSessions int `json:"revoked_sessions"`
+}
+
+// ForceLogout 强制用户下线
+// @Summary 强制用户下线
+// @Description 吊销用户全部未过期的访问令牌和刷新令牌
+// @Tags 管理-用户管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "用户ID"
+// @Success 200 {object} response.Response{data=ForceLogoutResponse}
+// @Router /api/v1/admin/users/{id}/force-logout [post]
+func (h *UserHandler) ForceLogout(c *gin.Context) {
+	_, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := handler.ParseID(c, "用户")
+	if !ok {
+		return
+	}
+
+	revoked, err := h.userService.ForceLogout(c.Request.Context(), id)
+	handler.MustSucceed(c, err, &ForceLogoutResponse{RevokedSessions: revoked})
+}
+
 // AdjustPointsRequest 调整积分请求
 type AdjustPointsRequest struct {
 	Points int    `json:"points" binding:"required"` // 正数增加，负数扣减
@@ -237,6 +237,203 @@ func (h *VenueHandler) ListByMerchant(c *gin.Context) {
 	handler.MustSucceed(c, err, venues)
 }
 
+// ============ 定价管理 ============
+
+// ListPricings 获取场地定价列表
+// @Summary 获取场地定价列表
+// @Tags 场地管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "场地ID"
+// @Success 200 {object} response.Response{data=[]models.RentalPricing}
+// @Router /admin/venues/{id}/pricings [get]
+func (h *VenueHandler) ListPricings(c *gin.Context) {
+	_, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := handler.ParseID(c, "场地")
+	if !ok {
+		return
+	}
+
+	pricings, err := h.venueService.ListPricings(c.Request.Context(), id)
+	handler.MustSucceed(c, err, pricings)
+}
+
+// CreatePricing 创建场地定价
+// @Summary 创建场地定价
+// @Tags 场地管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "场地ID"
+// @Param request body adminService.PricingRequest true "请求参数"
+// @Success 200 {object} response.Response{data=models.RentalPricing}
+// @Router /admin/venues/{id}/pricings [post]
+func (h *VenueHandler) CreatePricing(c *gin.Context) {
+	_, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := handler.ParseID(c, "场地")
+	if !ok {
+		return
+	}
+
+	var req adminService.PricingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	pricing, err := h.venueService.CreatePricing(c.Request.Context(), id, &req)
+	handler.MustSucceed(c, err, pricing)
+}
+
+// UpdatePricing 更新定价
+// @Summary 更新定价
+// @Tags 场地管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "定价ID"
+// @Param request body adminService.PricingRequest true "请求参数"
+// @Success 200 {object} response.Response
+// @Router /admin/pricings/{id} [put]
+func (h *VenueHandler) UpdatePricing(c *gin.Context) {
+	_, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := handler.ParseID(c, "定价")
+	if !ok {
+		return
+	}
+
+	var req adminService.PricingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	err := h.venueService.UpdatePricing(c.Request.Context(), id, &req)
+	handler.MustSucceed(c, err, nil)
+}
+
+// ============ 分时定价管理 ============
+
+// ListPricingSchedules 获取场地分时定价列表
+// @Summary 获取场地分时定价列表
+// @Tags 场地管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "场地ID"
+// @Success 200 {object} response.Response{data=[]models.PricingSchedule}
+// @Router /admin/venues/{id}/pricing-schedules [get]
+func (h *VenueHandler) ListPricingSchedules(c *gin.Context) {
+	_, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := handler.ParseID(c, "场地")
+	if !ok {
+		return
+	}
+
+	schedules, err := h.venueService.ListPricingSchedules(c.Request.Context(), id)
+	handler.MustSucceed(c, err, schedules)
+}
+
+// CreatePricingSchedule 创建场地分时定价
+// @Summary 创建场地分时定价
+// @Tags 场地管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "场地ID"
+// @Param request body adminService.PricingScheduleRequest true "请求参数"
+// @Success 200 {object} response.Response{data=models.PricingSchedule}
+// @Router /admin/venues/{id}/pricing-schedules [post]
+func (h *VenueHandler) CreatePricingSchedule(c *gin.Context) {
+	_, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := handler.ParseID(c, "场地")
+	if !ok {
+		return
+	}
+
+	var req adminService.PricingScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	schedule, err := h.venueService.CreatePricingSchedule(c.Request.Context(), id, &req)
+	handler.MustSucceed(c, err, schedule)
+}
+
+// UpdatePricingSchedule 更新分时定价
+// @Summary 更新分时定价
+// @Tags 场地管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "分时定价ID"
+// @Param request body adminService.PricingScheduleRequest true "请求参数"
+// @Success 200 {object} response.Response
+// @Router /admin/pricing-schedules/{id} [put]
+func (h *VenueHandler) UpdatePricingSchedule(c *gin.Context) {
+	_, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := handler.ParseID(c, "分时定价")
+	if !ok {
+		return
+	}
+
+	var req adminService.PricingScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	err := h.venueService.UpdatePricingSchedule(c.Request.Context(), id, &req)
+	handler.MustSucceed(c, err, nil)
+}
+
+// DeletePricingSchedule 删除分时定价
+// @Summary 删除分时定价
+// @Tags 场地管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "分时定价ID"
+// @Success 200 {object} response.Response
+// @Router /admin/pricing-schedules/{id} [delete]
+func (h *VenueHandler) DeletePricingSchedule(c *gin.Context) {
+	_, ok := handler.RequireAdminID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := handler.ParseID(c, "分时定价")
+	if !ok {
+		return
+	}
+
+	err := h.venueService.DeletePricingSchedule(c.Request.Context(), id)
+	handler.MustSucceed(c, err, nil)
+}
+
 // ============ 路由注册 ============
 
 // RegisterRoutes 注册路由
@@ -249,5 +446,13 @@ func (h *VenueHandler) RegisterRoutes(r *gin.RouterGroup) {
 		venues.PUT("/:id", h.Update)
 		venues.PUT("/:id/status", h.UpdateStatus)
 		venues.DELETE("/:id", h.Delete)
+		venues.GET("/:id/pricings", h.ListPricings)
+		venues.POST("/:id/pricings", h.CreatePricing)
+		venues.GET("/:id/pricing-schedules", h.ListPricingSchedules)
+		venues.POST("/:id/pricing-schedules", h.CreatePricingSchedule)
 	}
+
+	r.PUT("/pricings/:id", h.UpdatePricing)
+	r.PUT("/pricing-schedules/:id", h.UpdatePricingSchedule)
+	r.DELETE("/pricing-schedules/:id", h.DeletePricingSchedule)
 }
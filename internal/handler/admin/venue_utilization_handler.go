@@ -0,0 +1,133 @@
+// Package admin 提供管理员相关的 HTTP Handler
+package admin
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	financeService "github.com/dumeirei/smart-locker-backend/internal/service/finance"
+)
+
+// VenueUtilizationHandler 场地设备使用率报表处理器
+type VenueUtilizationHandler struct {
+	deviceStatsService *financeService.DeviceStatisticsService
+	exportService      *financeService.ExportService
+}
+
+// NewVenueUtilizationHandler 创建场地设备使用率报表处理器
+func NewVenueUtilizationHandler(
+	deviceStatsSvc *financeService.DeviceStatisticsService,
+	exportSvc *financeService.ExportService,
+) *VenueUtilizationHandler {
+	return &VenueUtilizationHandler{
+		deviceStatsService: deviceStatsSvc,
+		exportService:      exportSvc,
+	}
+}
+
+// GetUtilization 获取场地设备使用率
+// @Summary 获取场地设备使用率
+// @Description 按设备统计周期内的租借时长、租借次数、收入和占用率，并汇总到场地
+// @Tags 场地管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "场地ID"
+// @Param start_date query string true "开始日期 YYYY-MM-DD"
+// @Param end_date query string true "结束日期 YYYY-MM-DD（含当天）"
+// @Success 200 {object} response.Response{data=financeService.VenueUtilization}
+// @Router /admin/venues/{id}/utilization [get]
+func (h *VenueUtilizationHandler) GetUtilization(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	id, ok := handler.ParseID(c, "场地")
+	if !ok {
+		return
+	}
+	start, end, ok := parseUtilizationRange(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.deviceStatsService.GetVenueUtilization(c.Request.Context(), id, start, end)
+	handler.MustSucceed(c, err, report)
+}
+
+// ExportUtilization 导出场地设备使用率报表
+// @Summary 导出场地设备使用率报表
+// @Tags 场地管理
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security Bearer
+// @Param id path int true "场地ID"
+// @Param start_date query string true "开始日期 YYYY-MM-DD"
+// @Param end_date query string true "结束日期 YYYY-MM-DD（含当天）"
+// @Param format query string false "导出格式: csv/xlsx" default(csv)
+// @Success 200 {file} file "CSV/XLSX文件"
+// @Router /admin/venues/{id}/utilization/export [get]
+func (h *VenueUtilizationHandler) ExportUtilization(c *gin.Context) {
+	if _, ok := handler.RequireAdminID(c); !ok {
+		return
+	}
+
+	id, ok := handler.ParseID(c, "场地")
+	if !ok {
+		return
+	}
+	start, end, ok := parseUtilizationRange(c)
+	if !ok {
+		return
+	}
+
+	req := &financeService.ExportVenueUtilizationRequest{
+		VenueID:   id,
+		StartTime: start,
+		EndTime:   end,
+		Format:    financeService.ExportFormat(c.Query("format")),
+	}
+	data, filename, err := h.exportService.ExportVenueUtilization(c.Request.Context(), req)
+	if handler.HandleError(c, err) {
+		return
+	}
+
+	writeExportFile(c, req.Format, filename, data)
+}
+
+// parseUtilizationRange 解析统计日期范围，结束日期包含当天
+func parseUtilizationRange(c *gin.Context) (time.Time, time.Time, bool) {
+	startDateStr := c.Query("start_date")
+	endDateStr := c.Query("end_date")
+	if startDateStr == "" || endDateStr == "" {
+		response.BadRequest(c, "请指定开始和结束日期")
+		return time.Time{}, time.Time{}, false
+	}
+
+	start, err := time.Parse("2006-01-02", startDateStr)
+	if err != nil {
+		response.BadRequest(c, "无效的开始日期格式")
+		return time.Time{}, time.Time{}, false
+	}
+	endDate, err := time.Parse("2006-01-02", endDateStr)
+	if err != nil {
+		response.BadRequest(c, "无效的结束日期格式")
+		return time.Time{}, time.Time{}, false
+	}
+	if endDate.Before(start) {
+		response.BadRequest(c, "结束日期不能早于开始日期")
+		return time.Time{}, time.Time{}, false
+	}
+
+	return start, endDate.Add(24 * time.Hour), true
+}
+
+// RegisterRoutes 注册路由
+func (h *VenueUtilizationHandler) RegisterRoutes(r *gin.RouterGroup) {
+	venues := r.Group("/venues")
+	{
+		venues.GET("/:id/utilization", h.GetUtilization)
+		venues.GET("/:id/utilization/export", h.ExportUtilization)
+	}
+}
@@ -0,0 +1,102 @@
+// Package admin 管理端 HTTP Handler
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	"github.com/dumeirei/smart-locker-backend/internal/middleware"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	adminService "github.com/dumeirei/smart-locker-backend/internal/service/admin"
+)
+
+// WalletHandler 用户钱包管理处理器
+type WalletHandler struct {
+	walletService     *adminService.WalletAdminService
+	permissionChecker middleware.PermissionChecker
+}
+
+// NewWalletHandler 创建用户钱包管理处理器
+func NewWalletHandler(
+	walletSvc *adminService.WalletAdminService,
+	permissionChecker middleware.PermissionChecker,
+) *WalletHandler {
+	return &WalletHandler{
+		walletService:     walletSvc,
+		permissionChecker: permissionChecker,
+	}
+}
+
+// AdjustWalletRequest 调整钱包余额请求
+type AdjustWalletRequest struct {
+	Amount    float64 `json:"amount" binding:"required,gt=0"`                          // 调整金额
+	Direction string  `json:"direction" binding:"required,oneof=increase decrease"`    // 调整方向
+	Reason    string  `json:"reason" binding:"required,max=255"`                       // 调整原因
+	Target    string  `json:"target" binding:"omitempty,oneof=balance frozen_balance"` // 调整对象，默认可用余额
+}
+
+// Adjust 调整用户钱包余额
+// @Summary 调整用户钱包余额
+// @Description 客服修正钱包可用余额或冻结余额，生成人工调整流水并记录操作管理员；需要钱包调整权限
+// @Tags 管理-用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "用户ID"
+// @Param request body AdjustWalletRequest true "调整参数"
+// @Success 200 {object} response.Response{data=userService.TransactionRecord}
+// @Router /api/v1/admin/users/{id}/wallet/adjust [post]
+func (h *WalletHandler) Adjust(c *gin.Context) {
+	adminID, userID, ok := handler.RequireAdminAndParseID(c, "用户")
+	if !ok {
+		return
+	}
+
+	var req AdjustWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	adjust := h.walletService.AdjustBalance
+	if req.Target == adminService.WalletAdjustTargetFrozen {
+		adjust = h.walletService.AdjustFrozenBalance
+	}
+	record, err := adjust(c.Request.Context(), adminID, userID, req.Amount, req.Direction, req.Reason)
+	handler.MustSucceed(c, err, record)
+}
+
+// ListTransactions 获取用户钱包流水
+// @Summary 获取用户钱包流水
+// @Tags 管理-用户管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "用户ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param type query string false "交易类型"
+// @Success 200 {object} response.Response{data=response.PageData}
+// @Router /api/v1/admin/users/{id}/wallet/transactions [get]
+func (h *WalletHandler) ListTransactions(c *gin.Context) {
+	_, userID, ok := handler.RequireAdminAndParseID(c, "用户")
+	if !ok {
+		return
+	}
+
+	p := handler.BindPaginationWithDefaults(c, 1, 20)
+
+	records, total, err := h.walletService.ListTransactions(c.Request.Context(), userID, p.GetOffset(), p.GetLimit(), c.Query("type"))
+	handler.MustSucceedPage(c, err, records, total, p.Page, p.PageSize)
+}
+
+// RegisterRoutes 注册路由
+func (h *WalletHandler) RegisterRoutes(r *gin.RouterGroup) {
+	wallet := r.Group("/users/:id/wallet")
+	{
+		wallet.GET("/transactions", h.ListTransactions)
+		wallet.POST("/adjust",
+			middleware.RequirePermission(h.permissionChecker, models.PermissionCodeWalletAdjustment),
+			h.Adjust)
+	}
+}
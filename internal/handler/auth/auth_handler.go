@@ -6,7 +6,9 @@ import (
 
 	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
 	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	"github.com/dumeirei/smart-locker-backend/internal/middleware"
 	authService "github.com/dumeirei/smart-locker-backend/internal/service/auth"
+	mallService "github.com/dumeirei/smart-locker-backend/internal/service/mall"
 )
 
 // Handler 认证处理器
@@ -14,6 +16,7 @@ type Handler struct {
 	authService   *authService.AuthService
 	wechatService *authService.WechatService
 	codeService   *authService.CodeService
+	cartService   *mallService.CartService
 }
 
 // NewHandler 创建认证处理器
@@ -29,6 +32,17 @@ func NewHandler(
 	}
 }
 
+// SetCartService 设置购物车服务，设置后登录时将请求携带的游客购物车合并到用户购物车
+func (h *Handler) SetCartService(cartSvc *mallService.CartService) {
+	h.cartService = cartSvc
+}
+
+// LoginResponse 登录响应，携带游客购物车令牌登录时附带购物车合并结果
+type LoginResponse struct {
+	*authService.LoginResponse
+	CartMerge *mallService.MergeGuestCartResult `json:"cart_merge,omitempty"`
+}
+
 // SendSmsCode 发送短信验证码
 // @Summary 发送短信验证码
 // @Tags 认证
@@ -59,7 +73,8 @@ func (h *Handler) SendSmsCode(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body authService.SmsLoginRequest true "请求参数"
-// @Success 200 {object} response.Response{data=authService.LoginResponse}
+// @Param X-Cart-Token header string false "游客购物车令牌，登录后合并到用户购物车"
+// @Success 200 {object} response.Response{data=LoginResponse}
 // @Router /auth/login/sms [post]
 func (h *Handler) SmsLogin(c *gin.Context) {
 	var req authService.SmsLoginRequest
@@ -69,7 +84,10 @@ func (h *Handler) SmsLogin(c *gin.Context) {
 	}
 
 	result, err := h.authService.SmsLogin(c.Request.Context(), &req)
-	handler.MustSucceed(c, err, result)
+	if handler.HandleError(c, err) {
+		return
+	}
+	response.Success(c, h.loginResponse(c, result))
 }
 
 // WechatLogin 微信小程序登录
@@ -78,7 +96,8 @@ func (h *Handler) SmsLogin(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body authService.WechatLoginRequest true "请求参数"
-// @Success 200 {object} response.Response{data=authService.LoginResponse}
+// @Param X-Cart-Token header string false "游客购物车令牌，登录后合并到用户购物车"
+// @Success 200 {object} response.Response{data=LoginResponse}
 // @Router /auth/login/wechat [post]
 func (h *Handler) WechatLogin(c *gin.Context) {
 	var req authService.WechatLoginRequest
@@ -88,7 +107,25 @@ func (h *Handler) WechatLogin(c *gin.Context) {
 	}
 
 	result, err := h.wechatService.WechatLogin(c.Request.Context(), &req)
-	handler.MustSucceed(c, err, result)
+	if handler.HandleError(c, err) {
+		return
+	}
+	response.Success(c, h.loginResponse(c, result))
+}
+
+// loginResponse 构造登录响应，请求携带游客购物车令牌时合并到登录用户的购物车
+// 合并失败不影响登录，游客购物车保留到下次登录时再合并
+func (h *Handler) loginResponse(c *gin.Context, result *authService.LoginResponse) *LoginResponse {
+	resp := &LoginResponse{LoginResponse: result}
+	token := middleware.GetCartToken(c)
+	if h.cartService == nil || token == "" || result.User == nil {
+		return resp
+	}
+
+	if merged, err := h.cartService.MergeGuestCart(c.Request.Context(), token, result.User.ID); err == nil {
+		resp.CartMerge = merged
+	}
+	return resp
 }
 
 // RefreshTokenRequest 刷新 Token 请求
@@ -183,9 +220,11 @@ func (h *Handler) GetCurrentUser(c *gin.Context) {
 // @Success 200 {object} response.Response
 // @Router /auth/logout [post]
 func (h *Handler) Logout(c *gin.Context) {
-	// 当前设计：依赖 JWT 自然过期机制，无需 token 黑名单
-	// 如需立即吊销 token，可使用 Redis 实现黑名单机制
-	response.Success(c, nil)
+	if _, ok := handler.RequireUserID(c); !ok {
+		return
+	}
+
+	handler.MustSucceed(c, h.authService.Logout(c.Request.Context(), handler.GetTokenID(c)), nil)
 }
 
 // RegisterRoutes 注册路由
@@ -8,6 +8,7 @@ import (
 
 	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
 	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	"github.com/dumeirei/smart-locker-backend/internal/middleware"
 	deviceService "github.com/dumeirei/smart-locker-backend/internal/service/device"
 )
 
@@ -114,45 +115,43 @@ func (h *Handler) GetVenueDevices(c *gin.Context) {
 	handler.MustSucceed(c, err, devices)
 }
 
-// ListNearbyVenues 获取附近场地列表
-// @Summary 获取附近场地
+// FindNearbyVenues 按距离分页查询附近场地
+// @Summary 按距离分页查询附近场地
 // @Tags 场地
 // @Produce json
-// @Param longitude query number true "经度"
-// @Param latitude query number true "纬度"
-// @Param radius query number false "搜索半径(公里)" default(5)
-// @Param limit query int false "返回数量" default(20)
-// @Success 200 {object} response.Response{data=[]deviceService.VenueListItem}
-// @Router /api/v1/venue/nearby [get]
-func (h *Handler) ListNearbyVenues(c *gin.Context) {
-	longitude, err := strconv.ParseFloat(c.Query("longitude"), 64)
+// @Param lat query number true "纬度"
+// @Param lon query number true "经度"
+// @Param radius_km query number false "搜索半径(公里)，最大 50" default(5)
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Success 200 {object} response.Response{data=response.PageData}
+// @Router /api/v1/venues/nearby [get]
+func (h *Handler) FindNearbyVenues(c *gin.Context) {
+	lat, err := strconv.ParseFloat(c.Query("lat"), 64)
 	if err != nil {
-		response.BadRequest(c, "无效的经度")
+		response.BadRequest(c, "无效的纬度")
 		return
 	}
 
-	latitude, err := strconv.ParseFloat(c.Query("latitude"), 64)
+	lon, err := strconv.ParseFloat(c.Query("lon"), 64)
 	if err != nil {
-		response.BadRequest(c, "无效的纬度")
+		response.BadRequest(c, "无效的经度")
 		return
 	}
 
 	radiusKm := 5.0
-	if radiusStr := c.Query("radius"); radiusStr != "" {
-		if r, err := strconv.ParseFloat(radiusStr, 64); err == nil && r > 0 {
-			radiusKm = r
+	if radiusStr := c.Query("radius_km"); radiusStr != "" {
+		radiusKm, err = strconv.ParseFloat(radiusStr, 64)
+		if err != nil {
+			response.BadRequest(c, "无效的搜索半径")
+			return
 		}
 	}
 
-	limit := 20
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
-	}
+	p := handler.BindPagination(c)
 
-	venues, err := h.venueService.ListNearbyVenues(c.Request.Context(), longitude, latitude, radiusKm, limit)
-	handler.MustSucceed(c, err, venues)
+	venues, total, err := h.venueService.FindNearby(c.Request.Context(), lat, lon, radiusKm, p.Page, p.PageSize)
+	handler.MustSucceedPage(c, err, venues, total, p.Page, p.PageSize)
 }
 
 // ListVenuesByCity 获取城市场地列表
@@ -208,6 +207,36 @@ func (h *Handler) GetCities(c *gin.Context) {
 	handler.MustSucceed(c, err, cities)
 }
 
+// HeartbeatRequest 设备心跳请求
+type HeartbeatRequest struct {
+	DeviceNo string `json:"device_no" binding:"required"` // 设备编号
+	deviceService.HeartbeatData
+}
+
+// Heartbeat 设备心跳上报
+// @Summary 设备心跳上报
+// @Description 需经设备签名认证，只能上报签名设备自身的心跳
+// @Tags 设备
+// @Accept json
+// @Produce json
+// @Param request body HeartbeatRequest true "心跳数据"
+// @Success 200 {object} response.Response
+// @Router /api/v1/device/heartbeat [post]
+func (h *Handler) Heartbeat(c *gin.Context) {
+	var req HeartbeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+	if req.DeviceNo != middleware.GetDeviceNo(c) {
+		response.Forbidden(c, "设备编号与签名设备不一致")
+		return
+	}
+
+	err := h.deviceService.UpdateDeviceHeartbeat(c.Request.Context(), req.DeviceNo, &req.HeartbeatData)
+	handler.MustSucceed(c, err, nil)
+}
+
 // RegisterRoutes 注册路由
 func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 	// 设备相关
@@ -221,11 +250,11 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 	// 场地相关
 	venue := r.Group("/venue")
 	{
-		venue.GET("/nearby", h.ListNearbyVenues)
 		venue.GET("/city", h.ListVenuesByCity)
 		venue.GET("/cities", h.GetCities)
 		venue.GET("/search", h.SearchVenues)
 		venue.GET("/:id", h.GetVenueByID)
 		venue.GET("/:id/devices", h.GetVenueDevices)
 	}
+	r.GET("/venues/nearby", h.FindNearbyVenues)
 }
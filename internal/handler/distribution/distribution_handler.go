@@ -8,6 +8,7 @@ import (
 
 	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
 	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/service/distribution"
 )
 
@@ -71,6 +72,36 @@ func (h *Handler) Apply(c *gin.Context) {
 	handler.MustSucceed(c, err, result)
 }
 
+// BindInviterRequest 绑定邀请人请求
+type BindInviterRequest struct {
+	InviteCode string `json:"invite_code" binding:"required"` // 邀请人的邀请码
+}
+
+// BindInviter 绑定邀请人
+// @Summary 绑定邀请人
+// @Tags 分销
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body BindInviterRequest true "请求参数"
+// @Success 200 {object} response.Response
+// @Router /api/v1/distribution/bind [post]
+func (h *Handler) BindInviter(c *gin.Context) {
+	userID, ok := handler.RequireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req BindInviterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	err := h.distributorService.BindInviter(c.Request.Context(), userID, req.InviteCode)
+	handler.MustSucceed(c, err, nil)
+}
+
 // GetInfo 获取分销商信息
 // @Summary 获取分销商信息
 // @Tags 分销
@@ -345,8 +376,8 @@ func (h *Handler) GetWithdrawals(c *gin.Context) {
 // @Success 200 {object} response.Response
 // @Router /api/v1/distribution/withdraw/config [get]
 func (h *Handler) GetWithdrawConfig(c *gin.Context) {
-	config := h.withdrawService.GetConfig()
-	response.Success(c, config)
+	config, err := h.withdrawService.GetConfig(c.Request.Context(), models.WithdrawalTypeCommission)
+	handler.MustSucceed(c, err, config)
 }
 
 // GetRanking 获取分销排行榜
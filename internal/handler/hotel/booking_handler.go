@@ -2,6 +2,8 @@
 package hotel
 
 import (
+	"fmt"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
@@ -49,8 +51,13 @@ func (h *BookingHandler) CreateBooking(c *gin.Context) {
 		return
 	}
 
-	// 解析入住时间
-	checkInTime, err := handler.ParseDateTime(req.CheckInTime)
+	// 按酒店当地时间解析入住时间
+	loc, err := h.bookingService.RoomLocation(c.Request.Context(), req.RoomID)
+	if err != nil {
+		handler.HandleError(c, err)
+		return
+	}
+	checkInTime, err := handler.ParseDateTimeInLocation(req.CheckInTime, loc)
 	if err != nil {
 		response.BadRequest(c, "入住时间格式错误")
 		return
@@ -153,6 +160,74 @@ func (h *BookingHandler) CancelBooking(c *gin.Context) {
 	handler.MustSucceed(c, h.bookingService.CancelBooking(c.Request.Context(), bookingID, userID), nil)
 }
 
+// RequestRefund 申请取消已支付的预订并退款
+// @Summary 申请预订退款
+// @Description 按酒店取消政策计算退款金额，已到入住时间的预订不可取消
+// @Tags 预订
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "预订ID"
+// @Param request body hotelService.RequestCancellationRequest false "请求参数"
+// @Success 200 {object} response.Response{data=hotelService.CancellationResult}
+// @Router /api/v1/bookings/{id}/refund [post]
+func (h *BookingHandler) RequestRefund(c *gin.Context) {
+	userID, bookingID, ok := handler.RequireUserAndParseID(c, "预订")
+	if !ok {
+		return
+	}
+
+	var req hotelService.RequestCancellationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "参数错误")
+			return
+		}
+	}
+
+	result, err := h.bookingService.RequestCancellation(c.Request.Context(), bookingID, userID, req.Reason)
+	handler.MustSucceed(c, err, result)
+}
+
+// RescheduleBooking 预订改期
+// @Summary 预订改期
+// @Description 修改待支付或待核销预订的入住时间，入住时长与核销码、开锁码保持不变，价格变化时补缴或退还差价
+// @Tags 预订
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "预订ID"
+// @Param request body hotelService.RescheduleBookingRequest true "请求参数"
+// @Success 200 {object} response.Response{data=hotelService.BookingInfo}
+// @Router /api/v1/bookings/{id}/reschedule [put]
+func (h *BookingHandler) RescheduleBooking(c *gin.Context) {
+	userID, bookingID, ok := handler.RequireUserAndParseID(c, "预订")
+	if !ok {
+		return
+	}
+
+	var req hotelService.RescheduleBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	// 按酒店当地时间解析新的入住时间
+	loc, err := h.bookingService.BookingLocation(c.Request.Context(), bookingID)
+	if err != nil {
+		handler.HandleError(c, err)
+		return
+	}
+	checkInTime, err := handler.ParseDateTimeInLocation(req.CheckInTime, loc)
+	if err != nil {
+		response.BadRequest(c, "入住时间格式错误")
+		return
+	}
+
+	booking, err := h.bookingService.RescheduleBooking(c.Request.Context(), bookingID, userID, checkInTime)
+	handler.MustSucceed(c, err, booking)
+}
+
 // UnlockByCode 使用开锁码开锁
 // @Summary 使用开锁码开锁
 // @Tags 预订
@@ -163,7 +238,7 @@ func (h *BookingHandler) CancelBooking(c *gin.Context) {
 // @Success 200 {object} response.Response{data=hotelService.BookingInfo}
 // @Router /api/v1/bookings/unlock [post]
 func (h *BookingHandler) UnlockByCode(c *gin.Context) {
-	_, ok := handler.RequireUserID(c)
+	userID, ok := handler.RequireUserID(c)
 	if !ok {
 		return
 	}
@@ -174,7 +249,8 @@ func (h *BookingHandler) UnlockByCode(c *gin.Context) {
 		return
 	}
 
-	booking, err := h.bookingService.UnlockByCode(c.Request.Context(), req.DeviceID, req.UnlockCode)
+	booking, err := h.bookingService.UnlockByCode(c.Request.Context(), req.DeviceID, req.UnlockCode,
+		fmt.Sprintf("user:%d ip:%s", userID, c.ClientIP()))
 	handler.MustSucceed(c, err, booking)
 }
 
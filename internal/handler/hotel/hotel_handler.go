@@ -87,6 +87,7 @@ func (h *Handler) GetRoomList(c *gin.Context) {
 // @Tags 酒店
 // @Produce json
 // @Param id path int true "房间ID"
+// @Param date query string false "入住日期(YYYY-MM-DD)，按该日期展示时段价格，默认酒店当地今天"
 // @Success 200 {object} response.Response{data=hotelService.RoomInfo}
 // @Router /api/v1/rooms/{id} [get]
 func (h *Handler) GetRoomDetail(c *gin.Context) {
@@ -95,7 +96,12 @@ func (h *Handler) GetRoomDetail(c *gin.Context) {
 		return
 	}
 
-	room, err := h.hotelService.GetRoomDetail(c.Request.Context(), roomID)
+	date, ok := handler.ParseQueryDate(c, "date", "日期格式错误")
+	if !ok {
+		return
+	}
+
+	room, err := h.hotelService.GetRoomDetailOnDate(c.Request.Context(), roomID, date)
 	handler.MustSucceed(c, err, room)
 }
 
@@ -104,8 +110,8 @@ func (h *Handler) GetRoomDetail(c *gin.Context) {
 // @Tags 酒店
 // @Produce json
 // @Param id path int true "房间ID"
-// @Param check_in query string true "入住时间"
-// @Param check_out query string true "退房时间"
+// @Param check_in query string true "入住时间（未带时区时按酒店当地时间）"
+// @Param check_out query string true "退房时间（未带时区时按酒店当地时间）"
 // @Success 200 {object} response.Response{data=bool}
 // @Router /api/v1/rooms/{id}/availability [get]
 func (h *Handler) CheckRoomAvailability(c *gin.Context) {
@@ -123,13 +129,20 @@ func (h *Handler) CheckRoomAvailability(c *gin.Context) {
 		return
 	}
 
-	checkIn, err := handler.ParseDateTime(req.CheckIn)
+	// 按酒店当地时间解析查询时间
+	loc, err := h.hotelService.RoomLocation(c.Request.Context(), roomID)
+	if err != nil {
+		handler.HandleError(c, err)
+		return
+	}
+
+	checkIn, err := handler.ParseDateTimeInLocation(req.CheckIn, loc)
 	if err != nil {
 		response.BadRequest(c, "入住时间格式错误")
 		return
 	}
 
-	checkOut, err := handler.ParseDateTime(req.CheckOut)
+	checkOut, err := handler.ParseDateTimeInLocation(req.CheckOut, loc)
 	if err != nil {
 		response.BadRequest(c, "退房时间格式错误")
 		return
@@ -139,6 +152,34 @@ func (h *Handler) CheckRoomAvailability(c *gin.Context) {
 	handler.MustSucceed(c, err, gin.H{"available": available})
 }
 
+// GetRoomCalendar 获取房间月度可用日历
+// @Summary 获取房间月度可用日历
+// @Tags 酒店
+// @Produce json
+// @Param id path int true "房间ID"
+// @Param year query int true "年份"
+// @Param month query int true "月份(1-12)"
+// @Success 200 {object} response.Response{data=hotelService.RoomCalendar}
+// @Router /api/v1/rooms/{id}/calendar [get]
+func (h *Handler) GetRoomCalendar(c *gin.Context) {
+	roomID, ok := handler.ParseID(c, "房间")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Year  int `form:"year" binding:"required,min=1"`
+		Month int `form:"month" binding:"required,min=1,max=12"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "请提供正确的年份和月份")
+		return
+	}
+
+	calendar, err := h.hotelService.GetRoomCalendar(c.Request.Context(), roomID, req.Year, req.Month)
+	handler.MustSucceed(c, err, calendar)
+}
+
 // GetCities 获取城市列表
 // @Summary 获取城市列表
 // @Tags 酒店
@@ -155,6 +196,7 @@ func (h *Handler) GetCities(c *gin.Context) {
 // @Tags 酒店
 // @Produce json
 // @Param id path int true "房间ID"
+// @Param date query string false "入住日期(YYYY-MM-DD)，返回该日期适用的价格，默认酒店当地今天"
 // @Success 200 {object} response.Response{data=[]hotelService.TimeSlotInfo}
 // @Router /api/v1/rooms/{id}/time-slots [get]
 func (h *Handler) GetRoomTimeSlots(c *gin.Context) {
@@ -163,7 +205,12 @@ func (h *Handler) GetRoomTimeSlots(c *gin.Context) {
 		return
 	}
 
-	slots, err := h.hotelService.GetTimeSlotsByRoom(c.Request.Context(), roomID)
+	date, ok := handler.ParseQueryDate(c, "date", "日期格式错误")
+	if !ok {
+		return
+	}
+
+	slots, err := h.hotelService.GetTimeSlotsByRoomOnDate(c.Request.Context(), roomID, date)
 	handler.MustSucceed(c, err, slots)
 }
 
@@ -6,6 +6,8 @@ import (
 
 	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
 	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	"github.com/dumeirei/smart-locker-backend/internal/middleware"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
 	mallService "github.com/dumeirei/smart-locker-backend/internal/service/mall"
 )
 
@@ -21,20 +23,33 @@ func NewCartHandler(cartSvc *mallService.CartService) *CartHandler {
 	}
 }
 
+// cartOwner 获取当前请求的购物车归属：已登录为用户购物车，未登录时按 X-Cart-Token 为游客购物车
+func cartOwner(c *gin.Context) (repository.CartOwner, bool) {
+	if userID := middleware.GetUserID(c); userID > 0 {
+		return repository.UserCartOwner(userID), true
+	}
+	if token := middleware.GetCartToken(c); token != "" {
+		return repository.GuestCartOwner(token), true
+	}
+	response.Unauthorized(c, "请先登录")
+	return repository.CartOwner{}, false
+}
+
 // GetCart 获取购物车
 // @Summary 获取购物车
 // @Tags 购物车
 // @Produce json
 // @Security Bearer
+// @Param X-Cart-Token header string false "游客购物车令牌（未登录时必填）"
 // @Success 200 {object} response.Response{data=mall.CartInfo}
 // @Router /api/v1/cart [get]
 func (h *CartHandler) GetCart(c *gin.Context) {
-	userID, ok := handler.RequireUserID(c)
+	owner, ok := cartOwner(c)
 	if !ok {
 		return
 	}
 
-	cart, err := h.cartService.GetCart(c.Request.Context(), userID)
+	cart, err := h.cartService.GetCart(c.Request.Context(), owner)
 	handler.MustSucceed(c, err, cart)
 }
 
@@ -44,11 +59,12 @@ func (h *CartHandler) GetCart(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Param X-Cart-Token header string false "游客购物车令牌（未登录时必填）"
 // @Param request body mall.AddCartItemRequest true "请求参数"
 // @Success 200 {object} response.Response{data=mall.CartItemInfo}
 // @Router /api/v1/cart [post]
 func (h *CartHandler) AddItem(c *gin.Context) {
-	userID, ok := handler.RequireUserID(c)
+	owner, ok := cartOwner(c)
 	if !ok {
 		return
 	}
@@ -59,7 +75,7 @@ func (h *CartHandler) AddItem(c *gin.Context) {
 		return
 	}
 
-	item, err := h.cartService.AddItem(c.Request.Context(), userID, &req)
+	item, err := h.cartService.AddItem(c.Request.Context(), owner, &req)
 	handler.MustSucceed(c, err, item)
 }
 
@@ -69,12 +85,17 @@ func (h *CartHandler) AddItem(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Param X-Cart-Token header string false "游客购物车令牌（未登录时必填）"
 // @Param id path int true "购物车项ID"
 // @Param request body mall.UpdateCartItemRequest true "请求参数"
 // @Success 200 {object} response.Response{data=mall.CartItemInfo}
 // @Router /api/v1/cart/{id} [put]
 func (h *CartHandler) UpdateItem(c *gin.Context) {
-	userID, itemID, ok := handler.RequireUserAndParseID(c, "购物车项")
+	owner, ok := cartOwner(c)
+	if !ok {
+		return
+	}
+	itemID, ok := handler.ParseID(c, "购物车项")
 	if !ok {
 		return
 	}
@@ -85,7 +106,7 @@ func (h *CartHandler) UpdateItem(c *gin.Context) {
 		return
 	}
 
-	item, err := h.cartService.UpdateItem(c.Request.Context(), userID, itemID, &req)
+	item, err := h.cartService.UpdateItem(c.Request.Context(), owner, itemID, &req)
 	handler.MustSucceed(c, err, item)
 }
 
@@ -94,16 +115,21 @@ func (h *CartHandler) UpdateItem(c *gin.Context) {
 // @Tags 购物车
 // @Produce json
 // @Security Bearer
+// @Param X-Cart-Token header string false "游客购物车令牌（未登录时必填）"
 // @Param id path int true "购物车项ID"
 // @Success 200 {object} response.Response
 // @Router /api/v1/cart/{id} [delete]
 func (h *CartHandler) RemoveItem(c *gin.Context) {
-	userID, itemID, ok := handler.RequireUserAndParseID(c, "购物车项")
+	owner, ok := cartOwner(c)
+	if !ok {
+		return
+	}
+	itemID, ok := handler.ParseID(c, "购物车项")
 	if !ok {
 		return
 	}
 
-	handler.MustSucceed(c, h.cartService.RemoveItem(c.Request.Context(), userID, itemID), nil)
+	handler.MustSucceed(c, h.cartService.RemoveItem(c.Request.Context(), owner, itemID), nil)
 }
 
 // ClearCart 清空购物车
@@ -111,15 +137,16 @@ func (h *CartHandler) RemoveItem(c *gin.Context) {
 // @Tags 购物车
 // @Produce json
 // @Security Bearer
+// @Param X-Cart-Token header string false "游客购物车令牌（未登录时必填）"
 // @Success 200 {object} response.Response
 // @Router /api/v1/cart [delete]
 func (h *CartHandler) ClearCart(c *gin.Context) {
-	userID, ok := handler.RequireUserID(c)
+	owner, ok := cartOwner(c)
 	if !ok {
 		return
 	}
 
-	handler.MustSucceed(c, h.cartService.ClearCart(c.Request.Context(), userID), nil)
+	handler.MustSucceed(c, h.cartService.ClearCart(c.Request.Context(), owner), nil)
 }
 
 // SelectAll 全选/取消全选
@@ -128,18 +155,19 @@ func (h *CartHandler) ClearCart(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Param X-Cart-Token header string false "游客购物车令牌（未登录时必填）"
 // @Param selected query bool true "是否选中"
 // @Success 200 {object} response.Response
 // @Router /api/v1/cart/select-all [put]
 func (h *CartHandler) SelectAll(c *gin.Context) {
-	userID, ok := handler.RequireUserID(c)
+	owner, ok := cartOwner(c)
 	if !ok {
 		return
 	}
 
 	selected := c.Query("selected") == "true"
 
-	handler.MustSucceed(c, h.cartService.SelectAll(c.Request.Context(), userID, selected), nil)
+	handler.MustSucceed(c, h.cartService.SelectAll(c.Request.Context(), owner, selected), nil)
 }
 
 // GetCartCount 获取购物车商品数量
@@ -147,14 +175,15 @@ func (h *CartHandler) SelectAll(c *gin.Context) {
 // @Tags 购物车
 // @Produce json
 // @Security Bearer
+// @Param X-Cart-Token header string false "游客购物车令牌（未登录时必填）"
 // @Success 200 {object} response.Response{data=int}
 // @Router /api/v1/cart/count [get]
 func (h *CartHandler) GetCartCount(c *gin.Context) {
-	userID, ok := handler.RequireUserID(c)
+	owner, ok := cartOwner(c)
 	if !ok {
 		return
 	}
 
-	count, err := h.cartService.GetCartCount(c.Request.Context(), userID)
+	count, err := h.cartService.GetCartCount(c.Request.Context(), owner)
 	handler.MustSucceed(c, err, gin.H{"count": count})
 }
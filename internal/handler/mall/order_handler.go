@@ -97,7 +97,12 @@ func (h *OrderHandler) GetOrderDetail(c *gin.Context) {
 // @Param status query string false "订单状态"
 // @Param page query int false "页码"
 // @Param page_size query int false "每页数量"
+// @Param cursor query string false "游标，传入时使用游标分页（首页传空字符串），忽略 page/page_size"
+// @Param limit query int false "游标分页每页数量" default(10)
+// @Param start_date query string false "开始日期（仅游标分页）"
+// @Param end_date query string false "结束日期（仅游标分页）"
 // @Success 200 {object} response.Response{data=[]mall.MallOrderInfo}
+// @Success 200 {object} response.Response{data=response.CursorData}
 // @Router /api/v1/orders [get]
 func (h *OrderHandler) GetOrders(c *gin.Context) {
 	userID, ok := handler.RequireUserID(c)
@@ -106,6 +111,19 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 	}
 
 	status := c.Query("status")
+
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		startDate, endDate, ok := handler.ParseQueryDateRange(c)
+		if !ok {
+			return
+		}
+
+		orders, nextCursor, err := h.orderService.GetUserOrdersByCursor(c.Request.Context(), userID, cursor,
+			handler.ParseQueryLimit(c, handler.DefaultPageSize),
+			&mallService.UserOrdersFilter{Status: status, StartDate: startDate, EndDate: endDate})
+		handler.MustSucceedCursor(c, err, orders, nextCursor)
+		return
+	}
 	p := handler.BindPagination(c)
 
 	orders, total, err := h.orderService.GetUserOrders(c.Request.Context(), userID, status, p.Page, p.PageSize)
@@ -149,3 +167,29 @@ func (h *OrderHandler) ConfirmReceive(c *gin.Context) {
 
 	handler.MustSucceed(c, h.orderService.ConfirmReceive(c.Request.Context(), userID, orderID), nil)
 }
+
+// RequestRefund 申请部分退款
+// @Summary 按商品及数量申请商城订单部分退款
+// @Tags 商城订单
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "订单ID"
+// @Param request body mall.PartialRefundRequest true "退款商品及数量"
+// @Success 200 {object} response.Response{data=mall.MallRefundInfo}
+// @Router /api/v1/orders/{id}/refund [post]
+func (h *OrderHandler) RequestRefund(c *gin.Context) {
+	userID, orderID, ok := handler.RequireUserAndParseID(c, "订单")
+	if !ok {
+		return
+	}
+
+	var req mallService.PartialRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	refund, err := h.orderService.RequestPartialRefund(c.Request.Context(), userID, orderID, req.Items)
+	handler.MustSucceed(c, err, refund)
+}
@@ -96,7 +96,7 @@ func (h *ProductHandler) GetSelectedProducts(c *gin.Context) {
 // @Param category_id query int false "分类ID"
 // @Param min_price query number false "最低价格"
 // @Param max_price query number false "最高价格"
-// @Param sort_by query string false "排序方式"
+// @Param sort_by query string false "排序方式：relevance（默认）、price_asc、price_desc、sales_desc、newest"
 // @Param page query int false "页码"
 // @Param page_size query int false "每页数量"
 // @Success 200 {object} response.Response{data=mall.SearchResult}
@@ -108,7 +108,7 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 		return
 	}
 
-	result, err := h.searchService.Search(c.Request.Context(), &req)
+	result, err := h.searchService.SearchProductsFTS(c.Request.Context(), &req)
 	handler.MustSucceed(c, err, result)
 }
 
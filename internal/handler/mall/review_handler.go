@@ -2,6 +2,8 @@
 package mall
 
 import (
+	"strconv"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
@@ -51,6 +53,7 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 // @Tags 评价
 // @Produce json
 // @Param id path int true "商品ID"
+// @Param has_images query bool false "是否有图"
 // @Param page query int false "页码"
 // @Param page_size query int false "每页数量"
 // @Success 200 {object} response.Response{data=mall.ReviewListResponse}
@@ -61,9 +64,19 @@ func (h *ReviewHandler) GetProductReviews(c *gin.Context) {
 		return
 	}
 
+	var hasImages *bool
+	if v := c.Query("has_images"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			response.BadRequest(c, "has_images 参数错误")
+			return
+		}
+		hasImages = &parsed
+	}
+
 	p := handler.BindPagination(c)
 
-	result, err := h.reviewService.GetProductReviews(c.Request.Context(), productID, p.Page, p.PageSize)
+	result, err := h.reviewService.GetProductReviews(c.Request.Context(), productID, hasImages, p.Page, p.PageSize)
 	handler.MustSucceed(c, err, result)
 }
 
@@ -121,3 +134,35 @@ func (h *ReviewHandler) DeleteReview(c *gin.Context) {
 
 	handler.MustSucceed(c, h.reviewService.DeleteReview(c.Request.Context(), userID, reviewID), nil)
 }
+
+// ReplyReviewRequest 回复评价请求
+type ReplyReviewRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// ReplyReview 管理员回复评价
+// @Summary 回复评价
+// @Tags 管理-评价
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "评价ID"
+// @Param request body ReplyReviewRequest true "请求参数"
+// @Success 200 {object} response.Response{data=mall.ReviewReplyInfo}
+// @Router /api/v1/admin/reviews/{id}/reply [post]
+func (h *ReviewHandler) ReplyReview(c *gin.Context) {
+	adminID, reviewID, ok := handler.RequireAdminAndParseID(c, "评价")
+	if !ok {
+		return
+	}
+
+	var req ReplyReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	replier := mallService.ReviewReplier{Type: mallService.ReviewReplierAdmin, ID: adminID}
+	reply, err := h.reviewService.CreateReply(c.Request.Context(), reviewID, replier, req.Content)
+	handler.MustSucceed(c, err, reply)
+}
@@ -0,0 +1,88 @@
+package marketing
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	marketingService "github.com/dumeirei/smart-locker-backend/internal/service/marketing"
+)
+
+// CampaignHandler 活动处理器
+type CampaignHandler struct {
+	flashSaleService *marketingService.FlashSaleService
+	campaignService  *marketingService.CampaignService
+}
+
+// NewCampaignHandler 创建活动处理器
+func NewCampaignHandler(flashSaleSvc *marketingService.FlashSaleService) *CampaignHandler {
+	return &CampaignHandler{
+		flashSaleService: flashSaleSvc,
+	}
+}
+
+// SetCampaignService 设置活动服务（用于秒杀商品列表）
+func (h *CampaignHandler) SetCampaignService(campaignSvc *marketingService.CampaignService) {
+	h.campaignService = campaignSvc
+}
+
+// GetFlashSaleStatus 获取秒杀活动当日剩余名额
+// @Summary 获取秒杀活动当日剩余名额
+// @Tags 营销-活动
+// @Produce json
+// @Param id path int true "活动ID"
+// @Success 200 {object} response.Response{data=marketing.FlashSaleStatus}
+// @Router /api/v1/campaigns/{id}/flash-sale/status [get]
+func (h *CampaignHandler) GetFlashSaleStatus(c *gin.Context) {
+	campaignID, ok := handler.ParseID(c, "活动")
+	if !ok {
+		return
+	}
+
+	status, err := h.flashSaleService.GetFlashSaleStatus(c.Request.Context(), campaignID)
+	if err != nil {
+		switch {
+		case errors.Is(err, marketingService.ErrCampaignNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, marketingService.ErrCampaignNotFlashSale):
+			response.BadRequest(c, err.Error())
+		default:
+			response.InternalError(c, err.Error())
+		}
+		return
+	}
+
+	response.Success(c, status)
+}
+
+// ListFlashSaleProducts 获取秒杀活动商品列表
+// @Summary 获取秒杀活动商品列表
+// @Tags 营销-活动
+// @Produce json
+// @Param id path int true "活动ID"
+// @Success 200 {object} response.Response{data=[]marketing.FlashSaleProductItem}
+// @Router /api/v1/campaigns/{id}/products [get]
+func (h *CampaignHandler) ListFlashSaleProducts(c *gin.Context) {
+	campaignID, ok := handler.ParseID(c, "活动")
+	if !ok {
+		return
+	}
+
+	items, err := h.campaignService.ListFlashSaleProducts(c.Request.Context(), campaignID)
+	if err != nil {
+		switch {
+		case errors.Is(err, marketingService.ErrCampaignNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, marketingService.ErrCampaignNotFlashSale),
+			errors.Is(err, marketingService.ErrCampaignNotActive):
+			response.BadRequest(c, err.Error())
+		default:
+			response.InternalError(c, err.Error())
+		}
+		return
+	}
+
+	response.Success(c, items)
+}
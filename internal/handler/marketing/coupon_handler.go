@@ -2,6 +2,7 @@
 package marketing
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -182,6 +183,52 @@ func (h *CouponHandler) GetUserCouponDetail(c *gin.Context) {
 	response.Success(c, coupon)
 }
 
+// TransferCouponRequest 转赠优惠券请求
+type TransferCouponRequest struct {
+	ToUserID int64 `json:"to_user_id" binding:"required,min=1"`
+}
+
+// TransferCoupon 转赠优惠券
+// @Summary 转赠优惠券
+// @Description 将未使用且未过期的优惠券转赠给其他用户，受赠用户持有数量不能超过每人限领数量
+// @Tags 营销-用户优惠券
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "用户优惠券ID"
+// @Param request body TransferCouponRequest true "转赠参数"
+// @Success 200 {object} response.Response
+// @Router /api/v1/marketing/user-coupons/{id}/transfer [post]
+func (h *CouponHandler) TransferCoupon(c *gin.Context) {
+	userID, userCouponID, ok := handler.RequireUserAndParseID(c, "用户优惠券")
+	if !ok {
+		return
+	}
+
+	var req TransferCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	err := h.userCouponService.TransferCoupon(c.Request.Context(), userID, req.ToUserID, userCouponID)
+	switch {
+	case err == nil:
+		response.SuccessWithMessage(c, "转赠成功", nil)
+	case errors.Is(err, marketingService.ErrUserCouponNotFound):
+		response.NotFound(c, "用户优惠券不存在")
+	case errors.Is(err, marketingService.ErrUserCouponUsed),
+		errors.Is(err, marketingService.ErrUserCouponExpired),
+		errors.Is(err, marketingService.ErrCouponNotFound),
+		errors.Is(err, marketingService.ErrCouponLimitExceeded),
+		errors.Is(err, marketingService.ErrCouponTransferSelf),
+		errors.Is(err, marketingService.ErrCouponTransferUserAbsent):
+		response.BadRequest(c, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
+
 // GetAvailableCoupons 获取可用优惠券列表
 // @Summary 获取可用优惠券列表
 // @Tags 营销-用户优惠券
@@ -211,6 +258,7 @@ func (h *CouponHandler) GetAvailableCoupons(c *gin.Context) {
 type GetAvailableCouponsForOrderRequest struct {
 	OrderType   string  `form:"order_type" binding:"required"` // 订单类型：rental/mall/hotel
 	OrderAmount float64 `form:"order_amount" binding:"required,gt=0"`
+	FromCart    bool    `form:"from_cart"` // 按购物车已选商品匹配限定商品/分类的优惠券
 }
 
 // GetAvailableCouponsForOrder 获取订单可用优惠券
@@ -220,6 +268,7 @@ type GetAvailableCouponsForOrderRequest struct {
 // @Security Bearer
 // @Param order_type query string true "订单类型：rental/mall/hotel"
 // @Param order_amount query number true "订单金额"
+// @Param from_cart query bool false "是否按购物车已选商品匹配限定商品的优惠券"
 // @Success 200 {object} response.Response{data=[]marketing.UserCouponItem}
 // @Router /api/v1/marketing/user-coupons/for-order [get]
 func (h *CouponHandler) GetAvailableCouponsForOrder(c *gin.Context) {
@@ -234,7 +283,17 @@ func (h *CouponHandler) GetAvailableCouponsForOrder(c *gin.Context) {
 		return
 	}
 
-	coupons, err := h.userCouponService.GetAvailableCouponsForOrder(c.Request.Context(), userID, req.OrderType, req.OrderAmount)
+	var items []*marketingService.OrderLineItem
+	if req.FromCart {
+		var err error
+		items, err = h.userCouponService.GetCartLineItems(c.Request.Context(), userID)
+		if err != nil {
+			handler.HandleError(c, err)
+			return
+		}
+	}
+
+	coupons, err := h.userCouponService.GetAvailableCouponsForOrder(c.Request.Context(), userID, req.OrderType, req.OrderAmount, items)
 	handler.MustSucceed(c, err, coupons)
 }
 
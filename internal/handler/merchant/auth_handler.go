@@ -0,0 +1,43 @@
+package merchant
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	merchantService "github.com/dumeirei/smart-locker-backend/internal/service/merchant"
+)
+
+// AuthHandler 商户门户登录处理器
+type AuthHandler struct {
+	authService *merchantService.MerchantPortalAuthService
+}
+
+// NewAuthHandler 创建商户门户登录处理器
+func NewAuthHandler(authSvc *merchantService.MerchantPortalAuthService) *AuthHandler {
+	return &AuthHandler{
+		authService: authSvc,
+	}
+}
+
+// Login 登录商户门户
+// @Summary 登录商户门户
+// @Description 入驻申请已通过的用户以用户令牌换取商户令牌，商户令牌用于访问 /api/merchant 接口，可通过 /api/v1/auth/refresh 刷新
+// @Tags 商户门户
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response{data=jwt.TokenPair}
+// @Router /api/v1/merchant/login [post]
+func (h *AuthHandler) Login(c *gin.Context) {
+	userID, ok := handler.RequireUserID(c)
+	if !ok {
+		return
+	}
+
+	pair, err := h.authService.Login(c.Request.Context(), userID)
+	handler.MustSucceed(c, err, pair)
+}
+
+// RegisterRoutes 注册路由，r 需已挂载 UserAuth 中间件
+func (h *AuthHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/merchant/login", h.Login)
+}
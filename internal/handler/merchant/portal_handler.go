@@ -0,0 +1,113 @@
+// Package merchant 提供商户自助门户开放接口的 HTTP Handler
+package merchant
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	"github.com/dumeirei/smart-locker-backend/internal/middleware"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	merchantService "github.com/dumeirei/smart-locker-backend/internal/service/merchant"
+)
+
+// PortalHandler 商户自助门户处理器
+type PortalHandler struct {
+	portalService *merchantService.MerchantPortalService
+}
+
+// NewPortalHandler 创建商户自助门户处理器
+func NewPortalHandler(portalSvc *merchantService.MerchantPortalService) *PortalHandler {
+	return &PortalHandler{
+		portalService: portalSvc,
+	}
+}
+
+// ListSettlements 获取商户结算单列表
+// @Summary 获取商户结算单列表
+// @Tags 商户开放接口
+// @Produce json
+// @Param status query string false "结算状态"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} response.Response{data=response.PageData}
+// @Router /api/merchant/v1/settlements [get]
+func (h *PortalHandler) ListSettlements(c *gin.Context) {
+	p := handler.BindPaginationWithDefaults(c, 1, 20)
+
+	settlements, total, err := h.portalService.ListSettlements(c.Request.Context(), middleware.GetMerchantID(c), p.Page, p.PageSize, c.Query("status"))
+	handler.MustSucceedPage(c, err, settlements, total, p.Page, p.PageSize)
+}
+
+// ListVenues 获取商户场地列表
+// @Summary 获取商户场地列表
+// @Tags 商户开放接口
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} response.Response{data=response.PageData}
+// @Router /api/merchant/v1/venues [get]
+func (h *PortalHandler) ListVenues(c *gin.Context) {
+	p := handler.BindPaginationWithDefaults(c, 1, 20)
+
+	venues, total, err := h.portalService.ListVenues(c.Request.Context(), middleware.GetMerchantID(c), p.Page, p.PageSize)
+	handler.MustSucceedPage(c, err, venues, total, p.Page, p.PageSize)
+}
+
+// ListDevices 获取商户设备列表
+// @Summary 获取商户设备列表
+// @Tags 商户开放接口
+// @Produce json
+// @Param venue_id query int false "场地ID"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} response.Response{data=response.PageData}
+// @Router /api/merchant/v1/devices [get]
+func (h *PortalHandler) ListDevices(c *gin.Context) {
+	venueID, ok := handler.ParseQueryID(c, "venue_id", "场地")
+	if !ok {
+		return
+	}
+	p := handler.BindPaginationWithDefaults(c, 1, 20)
+
+	filters := &merchantService.PortalDeviceFilters{}
+	if venueID != nil {
+		filters.VenueID = *venueID
+	}
+
+	devices, total, err := h.portalService.ListDevices(c.Request.Context(), middleware.GetMerchantID(c), p.Page, p.PageSize, filters)
+	handler.MustSucceedPage(c, err, devices, total, p.Page, p.PageSize)
+}
+
+// ListRentals 获取商户设备的租借记录
+// @Summary 获取商户设备的租借记录
+// @Tags 商户开放接口
+// @Produce json
+// @Param status query string false "租借状态"
+// @Param device_id query int false "设备ID"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} response.Response{data=response.PageData}
+// @Router /api/merchant/v1/rentals [get]
+func (h *PortalHandler) ListRentals(c *gin.Context) {
+	deviceID, ok := handler.ParseQueryID(c, "device_id", "设备")
+	if !ok {
+		return
+	}
+	p := handler.BindPaginationWithDefaults(c, 1, 20)
+
+	filters := &merchantService.PortalRentalFilters{Status: c.Query("status")}
+	if deviceID != nil {
+		filters.DeviceID = *deviceID
+	}
+
+	rentals, total, err := h.portalService.ListRentals(c.Request.Context(), middleware.GetMerchantID(c), p.Page, p.PageSize, filters)
+	handler.MustSucceedPage(c, err, rentals, total, p.Page, p.PageSize)
+}
+
+// RegisterRoutes 注册路由，r 需已挂载 MerchantAPIAuth 中间件
+func (h *PortalHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/settlements", middleware.RequireMerchantAPIScope(models.MerchantAPIScopeSettlements), h.ListSettlements)
+	r.GET("/venues", middleware.RequireMerchantAPIScope(models.MerchantAPIScopeVenues), h.ListVenues)
+	r.GET("/devices", middleware.RequireMerchantAPIScope(models.MerchantAPIScopeDevices), h.ListDevices)
+	r.GET("/rentals", middleware.RequireMerchantAPIScope(models.MerchantAPIScopeRentals), h.ListRentals)
+}
@@ -0,0 +1,70 @@
+package merchant
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	"github.com/dumeirei/smart-locker-backend/internal/middleware"
+	financeService "github.com/dumeirei/smart-locker-backend/internal/service/finance"
+)
+
+// SettlementHandler 商户门户结算单处理器
+type SettlementHandler struct {
+	settlementService *financeService.MerchantSettlementService
+}
+
+// NewSettlementHandler 创建商户门户结算单处理器
+func NewSettlementHandler(settlementSvc *financeService.MerchantSettlementService) *SettlementHandler {
+	return &SettlementHandler{
+		settlementService: settlementSvc,
+	}
+}
+
+// ListSettlements 获取或下载商户自己的结算单
+// @Summary 获取或下载商户结算单
+// @Description 指定 format 时下载结算单文件，每笔计入结算的订单一行；否则返回分页列表
+// @Tags 商户门户
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security Bearer
+// @Param status query string false "结算状态"
+// @Param period_start query string false "周期开始日期 YYYY-MM-DD"
+// @Param period_end query string false "周期结束日期 YYYY-MM-DD"
+// @Param format query string false "下载格式: csv/xlsx"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} response.Response{data=response.PageData}
+// @Router /api/merchant/settlements [get]
+func (h *SettlementHandler) ListSettlements(c *gin.Context) {
+	p := handler.BindPaginationWithDefaults(c, 1, 20)
+	req := &financeService.SettlementListRequest{
+		Status:      c.Query("status"),
+		PeriodStart: c.Query("period_start"),
+		PeriodEnd:   c.Query("period_end"),
+		Page:        p.Page,
+		PageSize:    p.PageSize,
+	}
+	ctx := c.Request.Context()
+	merchantID := middleware.GetMerchantID(c)
+
+	if c.Query("format") != "" {
+		format, err := financeService.ExportFormat(c.Query("format")).Normalize()
+		if handler.HandleError(c, err) {
+			return
+		}
+		data, filename, err := h.settlementService.ExportOwnSettlements(ctx, merchantID, req, format)
+		if handler.HandleError(c, err) {
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Data(200, format.ContentType(), data)
+		return
+	}
+
+	settlements, total, err := h.settlementService.GetOwnSettlements(ctx, merchantID, req)
+	handler.MustSucceedPage(c, err, settlements, total, p.Page, p.PageSize)
+}
+
+// RegisterRoutes 注册路由，r 需已挂载 MerchantAuth 中间件
+func (h *SettlementHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/settlements", h.ListSettlements)
+}
@@ -0,0 +1,70 @@
+package order
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	orderService "github.com/dumeirei/smart-locker-backend/internal/service/order"
+)
+
+// NoteHandler 订单备注处理器
+type NoteHandler struct {
+	noteService *orderService.OrderNoteService
+}
+
+// NewNoteHandler 创建订单备注处理器
+func NewNoteHandler(noteSvc *orderService.OrderNoteService) *NoteHandler {
+	return &NoteHandler{
+		noteService: noteSvc,
+	}
+}
+
+// ListNotes 获取订单备注时间线
+// @Summary 获取订单备注时间线
+// @Description 按时间倒序返回订单上用户可见的备注，包括系统自动记录的节点
+// @Tags 订单备注
+// @Produce json
+// @Security Bearer
+// @Param id path int true "订单ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=response.ListData}
+// @Router /api/v1/orders/{id}/notes [get]
+func (h *NoteHandler) ListNotes(c *gin.Context) {
+	userID, orderID, ok := handler.RequireUserAndParseID(c, "订单")
+	if !ok {
+		return
+	}
+
+	p := handler.BindPaginationWithDefaults(c, 1, 20)
+
+	notes, total, err := h.noteService.ListUserNotes(c.Request.Context(), userID, orderID, p.Page, p.PageSize)
+	handler.MustSucceedPage(c, err, notes, total, p.Page, p.PageSize)
+}
+
+// AddNote 添加订单备注
+// @Summary 添加订单备注
+// @Tags 订单备注
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "订单ID"
+// @Param request body orderService.AddUserOrderNoteRequest true "请求参数"
+// @Success 200 {object} response.Response{data=orderService.OrderNoteInfo}
+// @Router /api/v1/orders/{id}/notes [post]
+func (h *NoteHandler) AddNote(c *gin.Context) {
+	userID, orderID, ok := handler.RequireUserAndParseID(c, "订单")
+	if !ok {
+		return
+	}
+
+	var req orderService.AddUserOrderNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	note, err := h.noteService.AddUserNote(c.Request.Context(), userID, orderID, req.Content)
+	handler.MustSucceed(c, err, note)
+}
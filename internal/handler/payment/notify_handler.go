@@ -0,0 +1,64 @@
+package payment
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	paymentService "github.com/dumeirei/smart-locker-backend/internal/service/payment"
+)
+
+// NotifyHandler 第三方支付异步通知处理器
+type NotifyHandler struct {
+	callbackService *paymentService.PaymentCallbackService
+}
+
+// NewNotifyHandler 创建支付通知处理器
+func NewNotifyHandler(callbackSvc *paymentService.PaymentCallbackService) *NotifyHandler {
+	return &NotifyHandler{
+		callbackService: callbackSvc,
+	}
+}
+
+// WechatNotifyAck 微信支付通知应答
+type WechatNotifyAck struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// WechatNotify 微信支付结果通知
+// 验签、解密并处理支付结果；处理成功返回 200 及 SUCCESS 应答，失败返回 5XX 由微信重试
+// @Summary 微信支付结果通知
+// @Tags 支付
+// @Accept json
+// @Produce json
+// @Param Wechatpay-Signature header string true "平台签名"
+// @Param Wechatpay-Timestamp header string true "时间戳"
+// @Param Wechatpay-Nonce header string true "随机串"
+// @Param Wechatpay-Serial header string true "平台证书序列号"
+// @Success 200 {object} WechatNotifyAck
+// @Failure 500 {object} WechatNotifyAck
+// @Router /api/v1/payments/notify/wechat [post]
+func (h *NotifyHandler) WechatNotify(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, &WechatNotifyAck{Code: "FAIL", Message: "读取请求体失败"})
+		return
+	}
+
+	if err := h.callbackService.VerifyAndProcessWechatNotify(c.Request.Context(), body, c.Request.Header); err != nil {
+		c.JSON(http.StatusInternalServerError, &WechatNotifyAck{Code: "FAIL", Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, &WechatNotifyAck{Code: "SUCCESS", Message: "成功"})
+}
+
+// RegisterRoutes 注册通知路由（无需认证，由验签保证来源可信）
+func (h *NotifyHandler) RegisterRoutes(r *gin.RouterGroup) {
+	notify := r.Group("/payments/notify")
+	{
+		notify.POST("/wechat", h.WechatNotify)
+	}
+}
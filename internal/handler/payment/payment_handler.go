@@ -2,8 +2,6 @@
 package payment
 
 import (
-	"io"
-
 	"github.com/gin-gonic/gin"
 
 	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
@@ -30,7 +28,9 @@ func NewHandler(paymentSvc *paymentService.PaymentService) *Handler {
 // @Produce json
 // @Security Bearer
 // @Param request body paymentService.CreatePaymentRequest true "请求参数"
+// @Param Idempotency-Key header string false "幂等键，24小时内重复提交返回首次结果"
 // @Success 200 {object} response.Response{data=paymentService.CreatePaymentResponse}
+// @Failure 409 {object} response.Response "幂等键已被用于不同的请求"
 // @Router /api/v1/payment [post]
 func (h *Handler) CreatePayment(c *gin.Context) {
 	userID, ok := handler.RequireUserID(c)
@@ -44,7 +44,8 @@ func (h *Handler) CreatePayment(c *gin.Context) {
 		return
 	}
 
-	result, err := h.paymentService.CreatePayment(c.Request.Context(), userID, &req)
+	idempotencyKey := c.GetHeader(paymentService.IdempotencyKeyHeader)
+	result, err := h.paymentService.CreatePayment(c.Request.Context(), userID, &req, idempotencyKey)
 	handler.MustSucceed(c, err, result)
 }
 
@@ -96,37 +97,6 @@ func (h *Handler) CreateRefund(c *gin.Context) {
 	handler.MustSucceed(c, h.paymentService.CreateRefund(c.Request.Context(), userID, &req), nil)
 }
 
-// WechatPayCallback 微信支付回调
-// @Summary 微信支付回调
-// @Tags 支付
-// @Accept json
-// @Produce json
-// @Success 200 {object} map[string]string
-// @Router /api/v1/payment/callback/wechat [post]
-func (h *Handler) WechatPayCallback(c *gin.Context) {
-	body, err := io.ReadAll(c.Request.Body)
-	if err != nil {
-		c.JSON(500, gin.H{
-			"code":    "FAIL",
-			"message": "读取请求体失败",
-		})
-		return
-	}
-
-	if err := h.paymentService.HandlePaymentCallback(c.Request.Context(), body); err != nil {
-		c.JSON(500, gin.H{
-			"code":    "FAIL",
-			"message": err.Error(),
-		})
-		return
-	}
-
-	c.JSON(200, gin.H{
-		"code":    "SUCCESS",
-		"message": "成功",
-	})
-}
-
 // RegisterRoutes 注册路由
 func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 	payment := r.Group("/payment")
@@ -136,11 +106,3 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 		payment.POST("/refund", h.CreateRefund)
 	}
 }
-
-// RegisterCallbackRoutes 注册回调路由（无需认证）
-func (h *Handler) RegisterCallbackRoutes(r *gin.RouterGroup) {
-	callback := r.Group("/payment/callback")
-	{
-		callback.POST("/wechat", h.WechatPayCallback)
-	}
-}
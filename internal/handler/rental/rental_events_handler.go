@@ -0,0 +1,130 @@
+package rental
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	eventService "github.com/dumeirei/smart-locker-backend/internal/service/event"
+	rentalService "github.com/dumeirei/smart-locker-backend/internal/service/rental"
+)
+
+// rentalEventsHeartbeat 心跳注释的发送间隔，防止代理因连接空闲而断开
+const rentalEventsHeartbeat = 15 * time.Second
+
+// rentalTerminalStatuses 租借终态，推送后结束事件流
+var rentalTerminalStatuses = map[string]bool{
+	models.RentalStatusCompleted: true,
+	models.RentalStatusCancelled: true,
+	models.RentalStatusRefunded:  true,
+}
+
+// EventsHandler 租借状态实时推送处理器
+type EventsHandler struct {
+	rentalService *rentalService.RentalService
+	bus           *eventService.StatusBus
+}
+
+// NewEventsHandler 创建租借状态实时推送处理器
+func NewEventsHandler(rentalSvc *rentalService.RentalService, bus *eventService.StatusBus) *EventsHandler {
+	return &EventsHandler{
+		rentalService: rentalSvc,
+		bus:           bus,
+	}
+}
+
+// StreamRentalEvents 推送租借状态变更
+// @Summary 租借状态实时推送
+// @Description 以 Server-Sent Events 推送租借状态变更（event: status，data 为 JSON：subject、id、user_id、old_status、new_status、timestamp）。连接建立后先推送当前状态，每 15 秒发送心跳注释，租借进入终态（已完成、已取消、已退款）后结束
+// @Tags 租借
+// @Produce text/event-stream
+// @Security Bearer
+// @Param id path int true "租借ID"
+// @Success 200 {object} eventService.StatusEvent
+// @Router /api/v1/rental/{id}/events [get]
+func (h *EventsHandler) StreamRentalEvents(c *gin.Context) {
+	userID, rentalID, ok := handler.RequireUserAndParseID(c, "租借")
+	if !ok {
+		return
+	}
+
+	// 先订阅再查询当前状态，保证查询之后发生的变更都能推送给客户端
+	events, unsubscribe := h.bus.Subscribe(eventService.SubjectRental, rentalID)
+	defer unsubscribe()
+
+	rental, err := h.rentalService.GetRental(c.Request.Context(), userID, rentalID)
+	if err != nil {
+		handler.HandleError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	current := rental.Status
+	if err := writeStatusEvent(c, &eventService.StatusEvent{
+		Subject:   eventService.SubjectRental,
+		ID:        rentalID,
+		UserID:    userID,
+		NewStatus: current,
+		Timestamp: time.Now(),
+	}); err != nil {
+		return
+	}
+	if rentalTerminalStatuses[current] {
+		return
+	}
+
+	ticker := time.NewTicker(rentalEventsHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event := <-events:
+			// 订阅后、查询前发生的变更已体现在当前状态中
+			if event.NewStatus == current {
+				continue
+			}
+			current = event.NewStatus
+			if err := writeStatusEvent(c, event); err != nil {
+				return
+			}
+			if rentalTerminalStatuses[current] {
+				return
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeStatusEvent 写入一条 SSE 状态事件并立即刷新
+func writeStatusEvent(c *gin.Context, event *eventService.StatusEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.Writer, "event: status\ndata: %s\n\n", payload); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// RegisterRoutes 注册路由
+func (h *EventsHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/rental/:id/events", h.StreamRentalEvents)
+}
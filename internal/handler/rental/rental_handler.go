@@ -2,16 +2,22 @@
 package rental
 
 import (
+	"fmt"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
 	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	paymentService "github.com/dumeirei/smart-locker-backend/internal/service/payment"
 	rentalService "github.com/dumeirei/smart-locker-backend/internal/service/rental"
 )
 
 // Handler 租借处理器
 type Handler struct {
-	rentalService *rentalService.RentalService
+	rentalService       *rentalService.RentalService
+	invoiceService      *rentalService.InvoiceService
+	receiptService      *rentalService.ReceiptService
+	subscriptionService *rentalService.SubscriptionService
 }
 
 // NewHandler 创建租借处理器
@@ -21,6 +27,21 @@ func NewHandler(rentalSvc *rentalService.RentalService) *Handler {
 	}
 }
 
+// SetInvoiceService 设置发票服务，未设置时不提供发票下载
+func (h *Handler) SetInvoiceService(invoiceSvc *rentalService.InvoiceService) {
+	h.invoiceService = invoiceSvc
+}
+
+// SetReceiptService 设置收据服务，未设置时不提供收据下载
+func (h *Handler) SetReceiptService(receiptSvc *rentalService.ReceiptService) {
+	h.receiptService = receiptSvc
+}
+
+// SetSubscriptionService 设置租借订阅服务，未设置时不提供订阅接口
+func (h *Handler) SetSubscriptionService(subscriptionSvc *rentalService.SubscriptionService) {
+	h.subscriptionService = subscriptionSvc
+}
+
 // CreateRental 创建租借订单
 // @Summary 创建租借订单
 // @Tags 租借
@@ -29,6 +50,7 @@ func NewHandler(rentalSvc *rentalService.RentalService) *Handler {
 // @Security Bearer
 // @Param request body rentalService.CreateRentalRequest true "请求参数"
 // @Success 200 {object} response.Response{data=rentalService.RentalInfo}
+// @Failure 503 {object} response.Response "设备繁忙，请稍后重试"
 // @Router /api/v1/rental [post]
 func (h *Handler) CreateRental(c *gin.Context) {
 	userID, ok := handler.RequireUserID(c)
@@ -52,7 +74,9 @@ func (h *Handler) CreateRental(c *gin.Context) {
 // @Produce json
 // @Security Bearer
 // @Param id path int true "租借ID"
+// @Param Idempotency-Key header string false "幂等键，24小时内重复提交返回首次结果"
 // @Success 200 {object} response.Response
+// @Failure 409 {object} response.Response "幂等键已被用于不同的请求"
 // @Router /api/v1/rental/{id}/pay [post]
 func (h *Handler) PayRental(c *gin.Context) {
 	userID, rentalID, ok := handler.RequireUserAndParseID(c, "租借")
@@ -60,7 +84,47 @@ func (h *Handler) PayRental(c *gin.Context) {
 		return
 	}
 
-	handler.MustSucceed(c, h.rentalService.PayRental(c.Request.Context(), userID, rentalID), nil)
+	idempotencyKey := c.GetHeader(paymentService.IdempotencyKeyHeader)
+	handler.MustSucceed(c, h.rentalService.PayRental(c.Request.Context(), userID, rentalID, idempotencyKey), nil)
+}
+
+// ExtendRental 续租
+// @Summary 续租
+// @Description 按小时续租（additional_hours，按原定价小时单价计费）或按续租套餐续租（pricing_id），费用从余额扣除，不再收取押金
+// @Tags 租借
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "租借ID"
+// @Param request body rentalService.ExtendRentalRequest true "请求参数"
+// @Success 200 {object} response.Response{data=rentalService.RentalInfo}
+// @Router /api/v1/rental/{id}/extend [post]
+func (h *Handler) ExtendRental(c *gin.Context) {
+	userID, rentalID, ok := handler.RequireUserAndParseID(c, "租借")
+	if !ok {
+		return
+	}
+
+	var req rentalService.ExtendRentalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	var (
+		rental *rentalService.RentalInfo
+		err    error
+	)
+	switch {
+	case req.AdditionalHours > 0 && req.PricingID == 0:
+		rental, err = h.rentalService.ExtendRental(c.Request.Context(), userID, rentalID, req.AdditionalHours)
+	case req.PricingID > 0 && req.AdditionalHours == 0:
+		rental, err = h.rentalService.ExtendRentalWithPricing(c.Request.Context(), userID, rentalID, req.PricingID)
+	default:
+		response.BadRequest(c, "续租时长和续租套餐须且只能选择一项")
+		return
+	}
+	handler.MustSucceed(c, err, rental)
 }
 
 // StartRental 开始租借（取货）
@@ -140,7 +204,12 @@ func (h *Handler) GetRental(c *gin.Context) {
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(10)
 // @Param status query int false "状态筛选"
+// @Param cursor query string false "游标，传入时使用游标分页（首页传空字符串），忽略 page/page_size"
+// @Param limit query int false "游标分页每页数量" default(10)
+// @Param start_date query string false "开始日期（仅游标分页）"
+// @Param end_date query string false "结束日期（仅游标分页）"
 // @Success 200 {object} response.Response{data=response.PageData}
+// @Success 200 {object} response.Response{data=response.CursorData}
 // @Router /api/v1/rental [get]
 func (h *Handler) ListRentals(c *gin.Context) {
 	userID, ok := handler.RequireUserID(c)
@@ -148,6 +217,11 @@ func (h *Handler) ListRentals(c *gin.Context) {
 		return
 	}
 
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		h.listRentalsByCursor(c, userID, cursor)
+		return
+	}
+
 	p := handler.BindPagination(c)
 
 	var status *string
@@ -165,16 +239,208 @@ func (h *Handler) ListRentals(c *gin.Context) {
 	handler.MustSucceedPage(c, err, rentals, total, p.Page, p.PageSize)
 }
 
+// listRentalsByCursor 游标分页获取租借列表
+func (h *Handler) listRentalsByCursor(c *gin.Context, userID int64, cursor string) {
+	startDate, endDate, ok := handler.ParseQueryDateRange(c)
+	if !ok {
+		return
+	}
+
+	rentals, nextCursor, err := h.rentalService.ListRentalsByCursor(
+		c.Request.Context(),
+		userID,
+		cursor,
+		handler.ParseQueryLimit(c, handler.DefaultPageSize),
+		&rentalService.ListRentalsFilter{
+			Status:    c.Query("status"),
+			StartDate: startDate,
+			EndDate:   endDate,
+		},
+	)
+	handler.MustSucceedCursor(c, err, rentals, nextCursor)
+}
+
+// TransferRental 发起租借转让
+// @Summary 发起租借转让
+// @Description 为使用中的租借生成转让码（10分钟内有效），接收方凭转让码接受后租借及押金转到其名下；每个租借最多转让一次
+// @Tags 租借
+// @Produce json
+// @Security Bearer
+// @Param id path int true "租借ID"
+// @Success 200 {object} response.Response{data=rentalService.TransferInfo}
+// @Router /api/v1/rental/{id}/transfer [post]
+func (h *Handler) TransferRental(c *gin.Context) {
+	userID, rentalID, ok := handler.RequireUserAndParseID(c, "租借")
+	if !ok {
+		return
+	}
+
+	transfer, err := h.rentalService.InitiateTransfer(c.Request.Context(), userID, rentalID)
+	handler.MustSucceed(c, err, transfer)
+}
+
+// AcceptTransfer 接受租借转让
+// @Summary 接受租借转让
+// @Description 凭转让码接受租借，从余额冻结与原租借等额的押金，原用户冻结的押金同时退还
+// @Tags 租借
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body rentalService.AcceptTransferRequest true "请求参数"
+// @Success 200 {object} response.Response{data=rentalService.RentalInfo}
+// @Router /api/v1/rental/transfer/accept [post]
+func (h *Handler) AcceptTransfer(c *gin.Context) {
+	userID, ok := handler.RequireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req rentalService.AcceptTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	rental, err := h.rentalService.AcceptTransfer(c.Request.Context(), userID, req.Code)
+	handler.MustSucceed(c, err, rental)
+}
+
+// DownloadInvoice 下载租借发票
+// @Summary 下载租借发票
+// @Description 租借完成后下载发票PDF，包含租借信息、费用明细及税额
+// @Tags 租借
+// @Produce application/pdf
+// @Security Bearer
+// @Param id path int true "租借ID"
+// @Success 200 {file} binary
+// @Failure 400 {object} response.Response "租借尚未完成"
+// @Router /api/v1/rental/{id}/invoice [get]
+func (h *Handler) DownloadInvoice(c *gin.Context) {
+	userID, rentalID, ok := handler.RequireUserAndParseID(c, "租借")
+	if !ok {
+		return
+	}
+
+	data, err := h.invoiceService.GenerateRentalInvoice(c.Request.Context(), rentalID, userID)
+	if handler.HandleError(c, err) {
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=invoice_%d.pdf", rentalID))
+	c.Data(200, "application/pdf", data)
+}
+
+// ReceiptResponse 收据响应
+type ReceiptResponse struct {
+	URL string `json:"url"`
+}
+
+// GetReceipt 获取租借收据
+// @Summary 获取租借收据
+// @Description 已完成或已退款的租借获取收据PDF地址，首次请求时生成，之后返回同一地址
+// @Tags 租借
+// @Produce json
+// @Security Bearer
+// @Param id path int true "租借ID"
+// @Success 200 {object} response.Response{data=ReceiptResponse}
+// @Failure 400 {object} response.Response "租借尚未完成"
+// @Router /api/v1/rental/{id}/receipt [get]
+func (h *Handler) GetReceipt(c *gin.Context) {
+	userID, rentalID, ok := handler.RequireUserAndParseID(c, "租借")
+	if !ok {
+		return
+	}
+
+	url, err := h.receiptService.GenerateRentalReceipt(c.Request.Context(), userID, rentalID)
+	handler.MustSucceed(c, err, &ReceiptResponse{URL: url})
+}
+
+// CreateSubscription 创建租借订阅
+// @Summary 创建租借订阅
+// @Description 按周或按月固定费用订阅设备格口，立即从余额扣除首个周期费用；所选定价的时长须与订阅周期一致（周168小时、月720小时）
+// @Tags 租借
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body rentalService.CreateSubscriptionRequest true "请求参数"
+// @Success 200 {object} response.Response{data=rentalService.SubscriptionInfo}
+// @Router /api/v1/rental/subscriptions [post]
+func (h *Handler) CreateSubscription(c *gin.Context) {
+	userID, ok := handler.RequireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req rentalService.CreateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	subscription, err := h.subscriptionService.CreateSubscription(c.Request.Context(), userID, &req)
+	handler.MustSucceed(c, err, subscription)
+}
+
+// ListSubscriptions 获取租借订阅列表
+// @Summary 获取租借订阅列表
+// @Tags 租借
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response{data=[]rentalService.SubscriptionInfo}
+// @Router /api/v1/rental/subscriptions [get]
+func (h *Handler) ListSubscriptions(c *gin.Context) {
+	userID, ok := handler.RequireUserID(c)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := h.subscriptionService.ListSubscriptions(c.Request.Context(), userID)
+	handler.MustSucceed(c, err, subscriptions)
+}
+
+// CancelSubscription 取消租借订阅
+// @Summary 取消租借订阅
+// @Description 取消后不再续订，当前周期的租借继续有效
+// @Tags 租借
+// @Produce json
+// @Security Bearer
+// @Param id path int true "订阅ID"
+// @Success 200 {object} response.Response{data=rentalService.SubscriptionInfo}
+// @Router /api/v1/rental/subscriptions/{id}/cancel [post]
+func (h *Handler) CancelSubscription(c *gin.Context) {
+	userID, subscriptionID, ok := handler.RequireUserAndParseID(c, "订阅")
+	if !ok {
+		return
+	}
+
+	subscription, err := h.subscriptionService.CancelSubscription(c.Request.Context(), userID, subscriptionID)
+	handler.MustSucceed(c, err, subscription)
+}
+
 // RegisterRoutes 注册路由
-func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+// createMiddlewares 仅作用于创建租借接口（如幂等键中间件）
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup, createMiddlewares ...gin.HandlerFunc) {
 	rental := r.Group("/rental")
 	{
-		rental.POST("", h.CreateRental)
+		rental.POST("", append(createMiddlewares, h.CreateRental)...)
 		rental.GET("", h.ListRentals)
 		rental.GET("/:id", h.GetRental)
 		rental.POST("/:id/pay", h.PayRental)
 		rental.POST("/:id/start", h.StartRental)
+		rental.POST("/:id/extend", h.ExtendRental)
 		rental.POST("/:id/return", h.ReturnRental)
 		rental.POST("/:id/cancel", h.CancelRental)
+		rental.POST("/:id/transfer", h.TransferRental)
+		rental.POST("/transfer/accept", h.AcceptTransfer)
+		if h.invoiceService != nil {
+			rental.GET("/:id/invoice", h.DownloadInvoice)
+		}
+		if h.receiptService != nil {
+			rental.GET("/:id/receipt", h.GetReceipt)
+		}
+		if h.subscriptionService != nil {
+			rental.POST("/subscriptions", h.CreateSubscription)
+			rental.GET("/subscriptions", h.ListSubscriptions)
+			rental.POST("/subscriptions/:id/cancel", h.CancelSubscription)
+		}
 	}
 }
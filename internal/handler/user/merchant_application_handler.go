@@ -0,0 +1,116 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/handler"
+	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	merchantService "github.com/dumeirei/smart-locker-backend/internal/service/merchant"
+)
+
+// MerchantApplicationHandler 商户入驻申请处理器
+type MerchantApplicationHandler struct {
+	applicationService *merchantService.MerchantApplicationService
+}
+
+// NewMerchantApplicationHandler 创建商户入驻申请处理器
+func NewMerchantApplicationHandler(applicationService *merchantService.MerchantApplicationService) *MerchantApplicationHandler {
+	return &MerchantApplicationHandler{applicationService: applicationService}
+}
+
+// Create 创建入驻申请
+// @Summary 创建商户入驻申请
+// @Description 创建申请草稿，营业执照须先通过上传接口上传；每个用户仅能有一份申请
+// @Tags 用户-商户入驻
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body merchantService.MerchantApplicationRequest true "申请资料"
+// @Success 200 {object} response.Response{data=merchantService.MerchantApplicationInfo}
+// @Router /api/v1/merchant-application [post]
+func (h *MerchantApplicationHandler) Create(c *gin.Context) {
+	userID, ok := handler.RequireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req merchantService.MerchantApplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	info, err := h.applicationService.Create(c.Request.Context(), userID, &req)
+	handler.MustSucceed(c, err, info)
+}
+
+// Update 修改入驻申请
+// @Summary 修改商户入驻申请
+// @Description 仅草稿或已驳回的申请可修改，已驳回的申请修改后回到草稿
+// @Tags 用户-商户入驻
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body merchantService.MerchantApplicationRequest true "申请资料"
+// @Success 200 {object} response.Response{data=merchantService.MerchantApplicationInfo}
+// @Router /api/v1/merchant-application [put]
+func (h *MerchantApplicationHandler) Update(c *gin.Context) {
+	userID, ok := handler.RequireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req merchantService.MerchantApplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	info, err := h.applicationService.Update(c.Request.Context(), userID, &req)
+	handler.MustSucceed(c, err, info)
+}
+
+// Submit 提交入驻申请
+// @Summary 提交商户入驻申请
+// @Tags 用户-商户入驻
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response{data=merchantService.MerchantApplicationInfo}
+// @Router /api/v1/merchant-application/submit [post]
+func (h *MerchantApplicationHandler) Submit(c *gin.Context) {
+	userID, ok := handler.RequireUserID(c)
+	if !ok {
+		return
+	}
+
+	info, err := h.applicationService.Submit(c.Request.Context(), userID)
+	handler.MustSucceed(c, err, info)
+}
+
+// Get 获取我的入驻申请
+// @Summary 获取我的商户入驻申请
+// @Tags 用户-商户入驻
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response{data=merchantService.MerchantApplicationInfo}
+// @Router /api/v1/merchant-application [get]
+func (h *MerchantApplicationHandler) Get(c *gin.Context) {
+	userID, ok := handler.RequireUserID(c)
+	if !ok {
+		return
+	}
+
+	info, err := h.applicationService.GetMine(c.Request.Context(), userID)
+	handler.MustSucceed(c, err, info)
+}
+
+// RegisterRoutes 注册商户入驻申请路由
+func (h *MerchantApplicationHandler) RegisterRoutes(r *gin.RouterGroup) {
+	application := r.Group("/merchant-application")
+	{
+		application.POST("", h.Create)
+		application.PUT("", h.Update)
+		application.GET("", h.Get)
+		application.POST("/submit", h.Submit)
+	}
+}
@@ -114,6 +114,32 @@ func (h *Handler) GetTransactions(c *gin.Context) {
 	handler.MustSucceedPage(c, err, transactions, total, p.Page, p.PageSize)
 }
 
+// Recharge 钱包充值
+// @Summary 钱包充值
+// @Description 创建充值支付单，返回支付链接/二维码内容，支付成功后由支付回调入账
+// @Tags 用户-钱包
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body userService.RechargeRequest true "请求参数"
+// @Success 200 {object} response.Response{data=userService.RechargeResult}
+// @Router /api/v1/user/wallet/recharge [post]
+func (h *Handler) Recharge(c *gin.Context) {
+	userID, ok := handler.RequireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req userService.RechargeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	result, err := h.walletService.InitiateRecharge(c.Request.Context(), userID, req.Amount, req.Channel)
+	handler.MustSucceed(c, err, result)
+}
+
 // GetMemberLevels 获取会员等级列表
 // @Summary 获取会员等级列表
 // @Tags 用户
@@ -166,6 +192,44 @@ func (h *Handler) GetPoints(c *gin.Context) {
 	handler.MustSucceed(c, err, gin.H{"points": points})
 }
 
+// RequestDeletion 申请注销账号
+// @Summary 申请注销账号
+// @Description 提交后进入 7 天冷静期，冷静期结束后匿名化个人信息并退出全部登录；存在未完成业务时返回阻断原因
+// @Tags 用户
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response{data=userService.DeletionResult}
+// @Failure 400 {object} response.Response{data=userService.DeletionResult}
+// @Router /api/v1/user/deletion [post]
+func (h *Handler) RequestDeletion(c *gin.Context) {
+	userID, ok := handler.RequireUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.userService.RequestDeletion(c.Request.Context(), userID)
+	if handler.HandleErrorWithData(c, err, result) {
+		return
+	}
+	response.Success(c, result)
+}
+
+// CancelDeletion 撤销注销申请
+// @Summary 撤销注销申请
+// @Tags 用户
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response
+// @Router /api/v1/user/deletion [delete]
+func (h *Handler) CancelDeletion(c *gin.Context) {
+	userID, ok := handler.RequireUserID(c)
+	if !ok {
+		return
+	}
+
+	handler.MustSucceed(c, h.userService.CancelDeletion(c.Request.Context(), userID), nil)
+}
+
 // RegisterRoutes 注册路由
 func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 	user := r.Group("/user")
@@ -174,8 +238,11 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 		user.PUT("/profile", h.UpdateProfile)
 		user.GET("/wallet", h.GetWallet)
 		user.GET("/wallet/transactions", h.GetTransactions)
+		user.POST("/wallet/recharge", h.Recharge)
 		user.GET("/member-levels", h.GetMemberLevels)
 		user.POST("/real-name-verify", h.RealNameVerify)
 		user.GET("/points", h.GetPoints)
+		user.POST("/deletion", h.RequestDeletion)
+		user.DELETE("/deletion", h.CancelDeletion)
 	}
 }
@@ -45,6 +45,13 @@ func Auth(config *AuthConfig) gin.HandlerFunc {
 			return
 		}
 
+		// 刷新令牌仅用于换取新令牌，不能用于访问接口
+		if claims.TokenType == jwt.TokenTypeRefresh {
+			response.Unauthorized(c, "无效的令牌")
+			c.Abort()
+			return
+		}
+
 		// 验证用户类型
 		if config.UserType != "" && claims.UserType != config.UserType {
 			response.Forbidden(c, "无权访问")
@@ -52,11 +59,27 @@ func Auth(config *AuthConfig) gin.HandlerFunc {
 			return
 		}
 
+		// 校验令牌是否已被吊销（仅对存在吊销标记的用户访问存储）
+		revoked, err := config.JWTManager.IsRevoked(c.Request.Context(), claims)
+		if err != nil {
+			response.InternalError(c, "令牌校验失败")
+			c.Abort()
+			return
+		}
+		if revoked {
+			response.Unauthorized(c, "登录已失效，请重新登录")
+			c.Abort()
+			return
+		}
+
 		// 设置上下文
 		c.Set(ContextKeyUserID, claims.UserID)
 		c.Set(ContextKeyUserType, claims.UserType)
 		c.Set(ContextKeyRole, claims.Role)
 		c.Set(ContextKeyClaims, claims)
+		if claims.UserType == jwt.UserTypeMerchant {
+			c.Set(ContextKeyMerchantID, claims.UserID)
+		}
 
 		c.Next()
 	}
@@ -68,7 +91,11 @@ func OptionalAuth(jwtManager *jwt.Manager) gin.HandlerFunc {
 		token := extractToken(c)
 		if token != "" {
 			claims, err := jwtManager.ParseToken(token)
-			if err == nil {
+			if err == nil && claims.TokenType != jwt.TokenTypeRefresh {
+				if revoked, err := jwtManager.IsRevoked(c.Request.Context(), claims); err != nil || revoked {
+					c.Next()
+					return
+				}
 				c.Set(ContextKeyUserID, claims.UserID)
 				c.Set(ContextKeyUserType, claims.UserType)
 				c.Set(ContextKeyRole, claims.Role)
@@ -79,6 +106,27 @@ func OptionalAuth(jwtManager *jwt.Manager) gin.HandlerFunc {
 	}
 }
 
+// CartTokenHeader 游客购物车令牌请求头，令牌由客户端生成
+const CartTokenHeader = "X-Cart-Token"
+
+// UserOrGuestCartAuth 购物车认证中间件
+// 携带登录令牌时按用户认证；未携带登录令牌但携带游客购物车令牌时放行，由处理器按游客购物车处理
+func UserOrGuestCartAuth(jwtManager *jwt.Manager) gin.HandlerFunc {
+	userAuth := UserAuth(jwtManager)
+	return func(c *gin.Context) {
+		if extractToken(c) == "" && GetCartToken(c) != "" {
+			c.Next()
+			return
+		}
+		userAuth(c)
+	}
+}
+
+// GetCartToken 获取请求携带的游客购物车令牌
+func GetCartToken(c *gin.Context) string {
+	return strings.TrimSpace(c.GetHeader(CartTokenHeader))
+}
+
 // UserAuth 用户认证中间件
 func UserAuth(jwtManager *jwt.Manager) gin.HandlerFunc {
 	return Auth(&AuthConfig{
@@ -95,6 +143,15 @@ func AdminAuth(jwtManager *jwt.Manager) gin.HandlerFunc {
 	})
 }
 
+// MerchantAuth 商户门户认证中间件
+// 商户令牌的 UserID 即商户 ID，处理器通过 GetMerchantID 获取
+func MerchantAuth(jwtManager *jwt.Manager) gin.HandlerFunc {
+	return Auth(&AuthConfig{
+		JWTManager: jwtManager,
+		UserType:   jwt.UserTypeMerchant,
+	})
+}
+
 // extractToken 从请求中提取令牌
 func extractToken(c *gin.Context) string {
 	// 优先从 Authorization 头获取
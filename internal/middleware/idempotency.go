@@ -0,0 +1,138 @@
+// Package middleware 提供 HTTP 中间件
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+)
+
+// 幂等中间件相关常量
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"      // 请求头名称
+	IdempotencyReplayedHeader = "Idempotency-Replayed" // 重放响应标记头
+)
+
+// idempotencyKeyPattern 合法的幂等键：1-128 位字母、数字及 - _ . :
+var idempotencyKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.:\-]{1,128}$`)
+
+// idempotencyRecord Redis 中缓存的响应，Status 为 0 表示首个请求仍在处理中
+type idempotencyRecord struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// idempotencyWriter 记录响应体的写入器
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware 幂等键中间件
+// 请求携带 Idempotency-Key 时，使用 SET NX PX 原子占用键：首个请求正常执行并缓存状态码和响应体，
+// 有效期内相同用户、相同接口的重复请求直接返回缓存的响应；首个请求尚未完成时返回 409。
+// 未携带请求头时不做处理，格式非法时返回 422；服务端错误（5XX）及处理函数 panic 不缓存并释放键，允许客户端重试；Redis 异常时放行
+func IdempotencyMiddleware(redisClient *redis.Client, ttl time.Duration) gin.HandlerFunc {
+	pending, _ := json.Marshal(&idempotencyRecord{})
+
+	return func(c *gin.Context) {
+		key, present := c.Request.Header[IdempotencyKeyHeader]
+		if !present {
+			c.Next()
+			return
+		}
+		if len(key) != 1 || !idempotencyKeyPattern.MatchString(key[0]) {
+			c.JSON(http.StatusUnprocessableEntity, response.Response{
+				Code:    http.StatusUnprocessableEntity,
+				Message: "Idempotency-Key 格式错误",
+			})
+			c.Abort()
+			return
+		}
+
+		ctx := c.Request.Context()
+		redisKey := fmt.Sprintf("idempotency:%d:%s:%s:%s", GetUserID(c), c.Request.Method, c.Request.URL.Path, key[0])
+
+		acquired, err := redisClient.SetNX(ctx, redisKey, pending, ttl).Result()
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		if !acquired {
+			replayIdempotentResponse(c, redisClient, redisKey)
+			return
+		}
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+
+		// 未缓存响应时释放键：处理函数 panic（由外层 Recovery 处理）、未写出响应、返回服务端错误或缓存失败，
+		// 避免键在有效期内一直处于处理中，允许客户端重试
+		cached := false
+		defer func() {
+			if !cached {
+				redisClient.Del(ctx, redisKey)
+			}
+		}()
+
+		c.Next()
+
+		status := writer.Status()
+		if !writer.Written() || status >= http.StatusInternalServerError {
+			return
+		}
+
+		record, err := json.Marshal(&idempotencyRecord{
+			Status:      status,
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		})
+		if err != nil || redisClient.Set(ctx, redisKey, record, ttl).Err() != nil {
+			return
+		}
+		cached = true
+	}
+}
+
+// replayIdempotentResponse 返回已缓存的响应
+func replayIdempotentResponse(c *gin.Context, redisClient *redis.Client, redisKey string) {
+	// 读取失败（如键恰好过期或被释放）时同样按处理中返回，由客户端重试
+	var record idempotencyRecord
+	data, err := redisClient.Get(c.Request.Context(), redisKey).Bytes()
+	if err != nil || json.Unmarshal(data, &record) != nil || record.Status == 0 {
+		c.JSON(http.StatusConflict, response.Response{
+			Code:    http.StatusConflict,
+			Message: "相同请求正在处理中，请稍后重试",
+		})
+		c.Abort()
+		return
+	}
+
+	c.Header(IdempotencyReplayedHeader, "true")
+	contentType := record.ContentType
+	if contentType == "" {
+		contentType = "application/json; charset=utf-8"
+	}
+	c.Data(record.Status, contentType, record.Body)
+	c.Abort()
+}
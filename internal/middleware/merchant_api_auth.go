@@ -0,0 +1,175 @@
+// Package middleware 提供 HTTP 中间件
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// 商户开放接口签名请求头
+const (
+	MerchantAPIKeyHeader       = "X-Api-Key"
+	MerchantAPITimestampHeader = "X-Timestamp"
+	MerchantAPINonceHeader     = "X-Nonce"
+	MerchantAPISignatureHeader = "X-Signature"
+)
+
+// 商户开放接口上下文键
+const (
+	ContextKeyMerchantID       = "merchant_id"
+	ContextKeyMerchantAPIKeyID = "merchant_api_key_id"
+	ContextKeyMerchantScopes   = "merchant_api_scopes"
+)
+
+// DefaultMerchantAPIMaxSkew 请求时间戳与服务器时间允许的最大偏差
+const DefaultMerchantAPIMaxSkew = 5 * time.Minute
+
+// MerchantAPIKeyResolver 商户密钥解析器接口
+type MerchantAPIKeyResolver interface {
+	// ResolveAPIKey 根据密钥标识返回密钥及签名密钥原文，密钥不存在时返回 nil
+	ResolveAPIKey(ctx context.Context, keyID string) (*models.MerchantAPIKey, string, error)
+	// MarkAPIKeyUsed 记录密钥最近使用时间
+	MarkAPIKeyUsed(ctx context.Context, key *models.MerchantAPIKey)
+}
+
+// MerchantAPIAuth 商户开放接口签名认证中间件
+// 请求需携带 X-Api-Key、X-Timestamp(Unix 秒)、X-Nonce 和 X-Signature，签名为
+// hex(HMAC-SHA256(secret, METHOD\nURI\nTIMESTAMP\nNONCE\nhex(SHA256(body))))，URI 包含查询参数。
+// 时间戳偏差超过 maxSkew 的请求直接拒绝；签名通过后在 Redis 中以 SET NX 占用 nonce，
+// 有效期覆盖整个时间窗口，窗口内重复的 nonce 视为重放。Redis 异常时拒绝请求，避免重放保护失效
+func MerchantAPIAuth(resolver MerchantAPIKeyResolver, redisClient *redis.Client, maxSkew time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keyID := c.GetHeader(MerchantAPIKeyHeader)
+		timestamp := c.GetHeader(MerchantAPITimestampHeader)
+		nonce := c.GetHeader(MerchantAPINonceHeader)
+		signature := c.GetHeader(MerchantAPISignatureHeader)
+		if keyID == "" || timestamp == "" || nonce == "" || signature == "" {
+			response.Unauthorized(c, "缺少签名信息")
+			c.Abort()
+			return
+		}
+
+		ts, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			response.Unauthorized(c, "时间戳格式错误")
+			c.Abort()
+			return
+		}
+		if skew := time.Since(time.Unix(ts, 0)); skew > maxSkew || skew < -maxSkew {
+			response.Unauthorized(c, "请求已过期")
+			c.Abort()
+			return
+		}
+
+		ctx := c.Request.Context()
+		key, secret, err := resolver.ResolveAPIKey(ctx, keyID)
+		if err != nil {
+			response.InternalError(c, "密钥校验失败")
+			c.Abort()
+			return
+		}
+		if key == nil {
+			response.Unauthorized(c, "无效的 API 密钥")
+			c.Abort()
+			return
+		}
+		if !key.IsActive() {
+			response.Unauthorized(c, "API 密钥已吊销")
+			c.Abort()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			if body, err = io.ReadAll(c.Request.Body); err != nil {
+				response.BadRequest(c, "读取请求体失败")
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		expected := SignMerchantAPIRequest(secret, c.Request.Method, c.Request.URL.RequestURI(), timestamp, nonce, body)
+		if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+			response.Unauthorized(c, "签名错误")
+			c.Abort()
+			return
+		}
+
+		nonceKey := fmt.Sprintf("merchant_api:nonce:%s:%s", keyID, nonce)
+		acquired, err := redisClient.SetNX(ctx, nonceKey, 1, 2*maxSkew).Result()
+		if err != nil {
+			response.InternalError(c, "签名校验失败")
+			c.Abort()
+			return
+		}
+		if !acquired {
+			response.Unauthorized(c, "重复的请求")
+			c.Abort()
+			return
+		}
+
+		resolver.MarkAPIKeyUsed(ctx, key)
+
+		c.Set(ContextKeyMerchantID, key.MerchantID)
+		c.Set(ContextKeyMerchantAPIKeyID, key.KeyID)
+		c.Set(ContextKeyMerchantScopes, key.ScopeList())
+
+		c.Next()
+	}
+}
+
+// RequireMerchantAPIScope 要求商户密钥拥有指定授权范围
+func RequireMerchantAPIScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, _ := c.Get(ContextKeyMerchantScopes)
+		list, _ := scopes.([]string)
+		for _, s := range list {
+			if s == scope {
+				c.Next()
+				return
+			}
+		}
+		response.Forbidden(c, "API 密钥无权访问")
+		c.Abort()
+	}
+}
+
+// SignMerchantAPIRequest 计算商户开放接口请求签名
+func SignMerchantAPIRequest(secret, method, uri, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	payload := strings.Join([]string{
+		strings.ToUpper(method),
+		uri,
+		timestamp,
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// GetMerchantID 从上下文获取已认证的商户 ID（开放接口签名认证或商户门户令牌认证）
+func GetMerchantID(c *gin.Context) int64 {
+	merchantID, exists := c.Get(ContextKeyMerchantID)
+	if !exists {
+		return 0
+	}
+	return merchantID.(int64)
+}
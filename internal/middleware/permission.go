@@ -2,6 +2,8 @@
 package middleware
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/dumeirei/smart-locker-backend/internal/common/response"
@@ -19,7 +21,25 @@ type PermissionConfig struct {
 	Checker PermissionChecker
 }
 
-// RequirePermission 要求指定权限
+// PermissionDeniedData 权限不足时响应中返回的详情
+type PermissionDeniedData struct {
+	Role                string   `json:"role"`                 // 当前角色编码
+	RequiredPermissions []string `json:"required_permissions"` // 接口要求的权限编码
+}
+
+// abortPermissionDenied 返回 403 及缺少的权限详情并中止请求
+func abortPermissionDenied(c *gin.Context, role string, permissionCodes []string) {
+	c.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+		Code:    http.StatusForbidden,
+		Message: "权限不足",
+		Data: &PermissionDeniedData{
+			Role:                role,
+			RequiredPermissions: permissionCodes,
+		},
+	})
+}
+
+// RequirePermission 要求指定权限，超级管理员不受限制（由 checker 判断）
 func RequirePermission(checker PermissionChecker, permissionCode string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role := GetRole(c)
@@ -30,8 +50,7 @@ func RequirePermission(checker PermissionChecker, permissionCode string) gin.Han
 		}
 
 		if !checker.HasPermission(role, permissionCode) {
-			response.Forbidden(c, "权限不足")
-			c.Abort()
+			abortPermissionDenied(c, role, []string{permissionCode})
 			return
 		}
 
@@ -50,8 +69,7 @@ func RequireAnyPermission(checker PermissionChecker, permissionCodes ...string)
 		}
 
 		if !checker.HasAnyPermission(role, permissionCodes) {
-			response.Forbidden(c, "权限不足")
-			c.Abort()
+			abortPermissionDenied(c, role, permissionCodes)
 			return
 		}
 
@@ -70,8 +88,7 @@ func RequireAllPermissions(checker PermissionChecker, permissionCodes ...string)
 		}
 
 		if !checker.HasAllPermissions(role, permissionCodes) {
-			response.Forbidden(c, "权限不足")
-			c.Abort()
+			abortPermissionDenied(c, role, permissionCodes)
 			return
 		}
 
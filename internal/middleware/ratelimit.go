@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -348,3 +349,61 @@ func AdminLoginRateLimit(redisClient *redis.Client) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// 优惠券领取限流参数
+const (
+	CouponReceiveLimit  = 10          // 每用户窗口内最多领取尝试次数
+	CouponReceiveWindow = time.Minute // 滑动窗口长度
+)
+
+// slidingWindowScript 滑动窗口计数：移除窗口外的记录后未达上限则记录本次请求
+// 返回 {1, 0} 表示放行；{0, ms} 表示拒绝，ms 为最早一条记录移出窗口的剩余毫秒数
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+if redis.call("ZCARD", KEYS[1]) < limit then
+	redis.call("ZADD", KEYS[1], now, ARGV[4])
+	redis.call("PEXPIRE", KEYS[1], window)
+	return {1, 0}
+end
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+return {0, tonumber(oldest[2]) + window - now}
+`)
+
+// CouponReceiveRateLimit 优惠券领取限流中间件
+// 基于 Redis 有序集合的滑动窗口：每用户每分钟最多 10 次领取尝试，超出返回 429 并通过 Retry-After 告知需等待的秒数
+func CouponReceiveRateLimit(redisClient *redis.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var key string
+		if userID := GetUserID(c); userID > 0 {
+			key = fmt.Sprintf("rate:coupon:receive:%d", userID)
+		} else {
+			key = fmt.Sprintf("rate:coupon:receive:ip:%s", c.ClientIP())
+		}
+
+		now := time.Now()
+		res, err := slidingWindowScript.Run(c.Request.Context(), redisClient, []string{key},
+			now.UnixMilli(), CouponReceiveWindow.Milliseconds(), CouponReceiveLimit,
+			strconv.FormatInt(now.UnixNano(), 10)).Int64Slice()
+		if err != nil || len(res) != 2 {
+			// Redis 错误时放行
+			c.Next()
+			return
+		}
+
+		if res[0] == 0 {
+			retryAfter := (res[1] + 999) / 1000
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+			response.TooManyRequests(c, "领取过于频繁，请稍后再试")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -92,6 +92,19 @@ const (
 	PermissionTypeAPI  = "api"  // API
 )
 
+// PermissionCode 预置权限编码
+const (
+	PermissionCodeRentalManagement  = "rental_management"          // 租借管理（强制完成等运维操作）
+	PermissionCodeWalletAdjustment  = "wallet_adjustment"          // 钱包余额人工调整
+	PermissionCodeFinanceExportFull = "finance:export:full"        // 财务导出完整收款账户和手机号（默认脱敏）
+	PermissionCodeWithdrawalApprove = "finance:withdrawal:approve" // 提现审核（单笔及批量）
+	PermissionCodeSettlementProcess = "finance:settlement:process" // 结算处理（打款）
+	PermissionCodeFinanceExport     = "finance:export"             // 财务数据导出
+	PermissionCodeRoleManagement    = "system:role"                // 角色权限管理
+	PermissionCodeAuditLog          = "system:audit_log"           // 查看管理员操作日志
+	PermissionCodeOrderRefund       = "order:refund"               // 管理员发起订单退款（含部分退款）
+)
+
 // RolePermission 角色权限关联表
 type RolePermission struct {
 	RoleID       int64 `gorm:"primaryKey" json:"role_id"`
@@ -115,6 +128,10 @@ type OperationLog struct {
 	AfterData  JSON      `gorm:"type:jsonb" json:"after_data,omitempty"`
 	IP         string    `gorm:"type:varchar(45);not null" json:"ip"`
 	UserAgent  *string   `gorm:"type:varchar(255)" json:"user_agent,omitempty"`
+	Method     string    `gorm:"type:varchar(10);not null;default:''" json:"method"`
+	Route      string    `gorm:"type:varchar(255);not null;default:''" json:"route"` // 路由模板，如 /api/admin/devices/:id
+	StatusCode int       `gorm:"not null;default:0" json:"status_code"`
+	LatencyMs  int64     `gorm:"not null;default:0" json:"latency_ms"`
 	CreatedAt  time.Time `gorm:"autoCreateTime;index" json:"created_at"`
 
 	// 关联
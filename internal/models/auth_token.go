@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// AuthToken 已签发的 JWT 令牌记录
+// 用于刷新令牌轮换、重用检测以及吊销（退出登录、强制下线）
+type AuthToken struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	JTI       string     `gorm:"column:jti;type:varchar(64);uniqueIndex;not null" json:"jti"`
+	FamilyID  string     `gorm:"type:varchar(64);index;not null" json:"family_id"` // 令牌族ID，同一登录会话轮换产生的令牌相同
+	UserType  string     `gorm:"type:varchar(20);not null;index:idx_auth_tokens_user" json:"user_type"`
+	UserID    int64      `gorm:"not null;index:idx_auth_tokens_user" json:"user_id"`
+	TokenType string     `gorm:"type:varchar(20);not null" json:"token_type"`
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`    // 刷新令牌被轮换的时间
+	RevokedAt *time.Time `json:"revoked_at,omitempty"` // 吊销时间
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName 表名
+func (AuthToken) TableName() string {
+	return "auth_tokens"
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// CouponGrantBatch 优惠券定向发放批次
+// 按用户分群批量发放优惠券时记录一次发放任务及其结果统计
+type CouponGrantBatch struct {
+	ID             int64              `gorm:"primaryKey;autoIncrement" json:"id"`
+	BatchNo        string             `gorm:"type:varchar(64);uniqueIndex;not null" json:"batch_no"` // 批次号，重复提交同一批次号不会重复发放
+	CouponID       int64              `gorm:"index;not null" json:"coupon_id"`
+	Segment        CouponGrantSegment `gorm:"type:jsonb;not null" json:"segment"`
+	AdminID        int64              `gorm:"not null" json:"admin_id"`
+	Status         string             `gorm:"type:varchar(20);not null;default:'running'" json:"status"`
+	MatchedCount   int                `gorm:"not null;default:0" json:"matched_count"`   // 命中分群的用户数
+	GrantedCount   int                `gorm:"not null;default:0" json:"granted_count"`   // 实际发放数
+	SkippedCount   int                `gorm:"not null;default:0" json:"skipped_count"`   // 因领取上限跳过的用户数
+	ShortfallCount int                `gorm:"not null;default:0" json:"shortfall_count"` // 因库存不足未发放的用户数
+	FailedCount    int                `gorm:"not null;default:0" json:"failed_count"`    // 因系统错误未发放的用户数
+	CompletedAt    *time.Time         `json:"completed_at,omitempty"`
+	CreatedAt      time.Time          `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (CouponGrantBatch) TableName() string {
+	return "coupon_grant_batches"
+}
+
+// CouponGrantBatchStatus 发放批次状态
+const (
+	CouponGrantBatchStatusRunning   = "running"   // 发放中
+	CouponGrantBatchStatusCompleted = "completed" // 已完成
+)
+
+// CouponGrantSegment 优惠券定向发放的用户分群条件
+// 各条件之间为“且”关系，未设置的条件不参与筛选；仅正常状态的用户会被选中
+type CouponGrantSegment struct {
+	RegisteredAfter     *time.Time `json:"registered_after,omitempty"`       // 注册时间晚于
+	LastOrderWithinDays *int       `json:"last_order_within_days,omitempty"` // 最近 N 天内有已支付订单
+	MemberLevelIDs      []int64    `json:"member_level_ids,omitempty"`       // 会员等级
+	HasCompletedRental  *bool      `json:"has_completed_rental,omitempty"`   // 是否有已完成的租借
+}
+
+// IsEmpty 是否未设置任何分群条件
+func (s *CouponGrantSegment) IsEmpty() bool {
+	return s.RegisteredAfter == nil && s.LastOrderWithinDays == nil &&
+		len(s.MemberLevelIDs) == 0 && s.HasCompletedRental == nil
+}
+
+// Scan 实现 sql.Scanner 接口
+func (s *CouponGrantSegment) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*s = CouponGrantSegment{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return errors.New("invalid coupon grant segment")
+	}
+}
+
+// Value 实现 driver.Valuer 接口
+func (s CouponGrantSegment) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
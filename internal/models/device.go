@@ -2,12 +2,14 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Device 智能柜设备模型
 type Device struct {
 	ID               int64      `gorm:"primaryKey;autoIncrement" json:"id"`
-	DeviceNo         string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"device_no"`
+	DeviceNo         string     `gorm:"type:varchar(64);uniqueIndex:idx_devices_device_no_active,where:deleted_at IS NULL;not null" json:"device_no"`
 	Name             string     `gorm:"type:varchar(100);not null" json:"name"`
 	Type             string     `gorm:"type:varchar(20);not null" json:"type"`
 	Model            *string    `gorm:"type:varchar(50)" json:"model,omitempty"`
@@ -32,8 +34,12 @@ type Device struct {
 	LastOfflineAt    *time.Time `json:"last_offline_at,omitempty"`
 	InstallTime      *time.Time `json:"install_time,omitempty"`
 	Status           int8       `gorm:"type:smallint;not null;default:1" json:"status"`
+	MaintenanceMode      bool       `gorm:"not null;default:false" json:"maintenance_mode"` // 维护模式，开启后暂停新的租借
+	MaintenanceReason    string     `gorm:"type:varchar(255);not null;default:''" json:"maintenance_reason,omitempty"`
+	MaintenanceRestoreAt *time.Time `json:"maintenance_restore_at,omitempty"` // 预计恢复时间
 	CreatedAt        time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt        time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"` // 软删除，设备编号仅在未删除的设备中唯一
 
 	// 关联
 	Venue         *Venue  `gorm:"foreignKey:VenueID" json:"venue,omitempty"`
@@ -99,12 +105,14 @@ func (DeviceLog) TableName() string {
 
 // DeviceLogType 设备日志类型
 const (
-	DeviceLogTypeOnline    = "online"    // 上线
-	DeviceLogTypeOffline   = "offline"   // 离线
-	DeviceLogTypeUnlock    = "unlock"    // 开锁
-	DeviceLogTypeLock      = "lock"      // 锁定
-	DeviceLogTypeError     = "error"     // 错误
-	DeviceLogTypeHeartbeat = "heartbeat" // 心跳
+	DeviceLogTypeOnline      = "online"       // 上线
+	DeviceLogTypeOffline     = "offline"      // 离线
+	DeviceLogTypeUnlock      = "unlock"       // 开锁
+	DeviceLogTypeLock        = "lock"         // 锁定
+	DeviceLogTypeError       = "error"        // 错误
+	DeviceLogTypeHeartbeat   = "heartbeat"    // 心跳
+	DeviceLogTypeSlotRelease = "slot_release" // 槽位释放
+	DeviceLogTypeMaintenance = "maintenance"  // 维护（维护模式开关、维护记录）
 )
 
 // DeviceLogOperatorType 设备日志操作人类型
@@ -114,6 +122,30 @@ const (
 	DeviceLogOperatorSystem = "system" // 系统
 )
 
+// DeviceSlot 设备格口，记录多格口设备每个物理格口的占用情况
+type DeviceSlot struct {
+	ID              int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	DeviceID        int64     `gorm:"uniqueIndex:idx_device_slots_device_slot;not null" json:"device_id"`
+	SlotNo          int       `gorm:"uniqueIndex:idx_device_slots_device_slot;not null" json:"slot_no"`
+	Status          int8      `gorm:"type:smallint;not null;default:0" json:"status"`
+	CurrentRentalID *int64    `json:"current_rental_id,omitempty"`
+	ProductName     *string   `gorm:"type:varchar(100)" json:"product_name,omitempty"` // 格口商品名称，为空时使用设备商品名称
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (DeviceSlot) TableName() string {
+	return "device_slots"
+}
+
+// DeviceSlotStatus 格口状态
+const (
+	DeviceSlotFree     = 0 // 空闲
+	DeviceSlotReserved = 1 // 已预占（待支付/待取货）
+	DeviceSlotInUse    = 2 // 使用中
+)
+
 // DeviceMaintenance 设备维护记录
 type DeviceMaintenance struct {
 	ID           int64      `gorm:"primaryKey;autoIncrement" json:"id"`
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"math"
 	"time"
 )
 
@@ -18,6 +19,7 @@ type Distributor struct {
 	WithdrawnCommission float64    `gorm:"column:withdrawn_commission;type:decimal(12,2);not null;default:0" json:"withdrawn_commission"`
 	TeamCount           int        `gorm:"column:team_count;not null;default:0" json:"team_count"`
 	DirectCount         int        `gorm:"column:direct_count;not null;default:0" json:"direct_count"`
+	SecondLevelRate     float64    `gorm:"column:second_level_rate;type:decimal(5,4);not null;default:0" json:"second_level_rate"` // 间推佣金比例，0 表示使用全局间推比例
 	Status              int        `gorm:"column:status;type:smallint;not null;default:0" json:"status"` // 0待审核 1已通过 2已拒绝
 	ApprovedAt          *time.Time `gorm:"column:approved_at" json:"approved_at,omitempty"`
 	ApprovedBy          *int64     `gorm:"column:approved_by" json:"approved_by,omitempty"`
@@ -53,14 +55,15 @@ const (
 // 对应数据库表: commissions
 type Commission struct {
 	ID            int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
-	DistributorID int64      `gorm:"column:distributor_id;index;not null" json:"distributor_id"`
-	OrderID       int64      `gorm:"column:order_id;index;not null" json:"order_id"`
+	DistributorID int64      `gorm:"column:distributor_id;index;not null;uniqueIndex:uk_commissions_order_type_distributor,priority:3" json:"distributor_id"`
+	OrderID       int64      `gorm:"column:order_id;index;not null;uniqueIndex:uk_commissions_order_type_distributor,priority:1" json:"order_id"`
 	FromUserID    int64      `gorm:"column:from_user_id;not null" json:"from_user_id"` // 消费用户ID
-	Type          string     `gorm:"column:type;type:varchar(20);not null" json:"type"` // direct/indirect
+	Type          string     `gorm:"column:type;type:varchar(20);not null;uniqueIndex:uk_commissions_order_type_distributor,priority:2" json:"type"` // direct/indirect
 	OrderAmount   float64    `gorm:"column:order_amount;type:decimal(12,2);not null" json:"order_amount"`
 	Rate          float64    `gorm:"column:rate;type:decimal(5,4);not null" json:"rate"`
 	Amount        float64    `gorm:"column:amount;type:decimal(12,2);not null" json:"amount"`
 	Status        int        `gorm:"column:status;type:smallint;not null;default:0" json:"status"` // 0待结算 1已结算 2已失效
+	SettlementID  *int64     `gorm:"column:settlement_id;index" json:"settlement_id,omitempty"` // 生成结算单时锁定到的结算ID
 	SettledAt     *time.Time `gorm:"column:settled_at" json:"settled_at,omitempty"`
 	CreatedAt     time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
 
@@ -88,6 +91,21 @@ const (
 	CommissionStatusCancelled = 2 // 已失效
 )
 
+// DistributorCommissionConfig 分销佣金层级比例配置
+// 对应数据库表: distributor_commission_config
+type DistributorCommissionConfig struct {
+	ID        int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Level     int       `gorm:"column:level;type:smallint;uniqueIndex;not null" json:"level"` // 层级: 1直推 2间推
+	Rate      float64   `gorm:"column:rate;type:decimal(5,4);not null" json:"rate"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (DistributorCommissionConfig) TableName() string {
+	return "distributor_commission_config"
+}
+
 // Withdrawal 提现申请
 // 对应数据库表: withdrawals
 type Withdrawal struct {
@@ -104,6 +122,7 @@ type Withdrawal struct {
 	OperatorID           *int64     `gorm:"column:operator_id" json:"operator_id,omitempty"`
 	ProcessedAt          *time.Time `gorm:"column:processed_at" json:"processed_at,omitempty"`
 	RejectReason         *string    `gorm:"column:reject_reason;type:varchar(255)" json:"reject_reason,omitempty"`
+	PolicySnapshot       JSON       `gorm:"column:policy_snapshot;type:jsonb" json:"policy_snapshot,omitempty"` // 申请时适用的提现规则快照，用于审计
 	CreatedAt            time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
 	UpdatedAt            time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
 
@@ -139,6 +158,78 @@ const (
 	WithdrawalStatusRejected   = "rejected"   // 已拒绝
 )
 
+// WithdrawalPolicy 提现规则，每种提现类型一条
+// 限额为 0 表示不限制
+type WithdrawalPolicy struct {
+	ID               int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Type             string    `gorm:"column:type;type:varchar(20);uniqueIndex;not null" json:"type"` // wallet/commission
+	MinAmount        float64   `gorm:"column:min_amount;type:decimal(12,2);not null;default:0" json:"min_amount"`
+	FeeFixed         float64   `gorm:"column:fee_fixed;type:decimal(10,2);not null;default:0" json:"fee_fixed"`    // 每笔固定手续费
+	FeePercent       float64   `gorm:"column:fee_percent;type:decimal(5,4);not null;default:0" json:"fee_percent"` // 按金额收取的手续费比例
+	DailyCountLimit  int       `gorm:"column:daily_count_limit;not null;default:0" json:"daily_count_limit"`
+	DailyAmountLimit float64   `gorm:"column:daily_amount_limit;type:decimal(12,2);not null;default:0" json:"daily_amount_limit"`
+	UpdatedBy        *int64    `gorm:"column:updated_by" json:"updated_by,omitempty"`
+	CreatedAt        time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (WithdrawalPolicy) TableName() string {
+	return "withdrawal_policies"
+}
+
+// CalculateFee 计算提现手续费：固定手续费加按比例手续费，四舍五入到分
+// 先按万分之一分取整消除浮点误差，避免 1.035 这类半分金额被舍去
+func (p *WithdrawalPolicy) CalculateFee(amount float64) float64 {
+	cents := math.Round((p.FeeFixed+amount*p.FeePercent)*1000000) / 10000
+	return math.Round(cents) / 100
+}
+
+// Snapshot 提现规则快照，写入提现记录
+func (p *WithdrawalPolicy) Snapshot() JSON {
+	return JSON{
+		"policy_id":          p.ID,
+		"type":               p.Type,
+		"min_amount":         p.MinAmount,
+		"fee_fixed":          p.FeeFixed,
+		"fee_percent":        p.FeePercent,
+		"daily_count_limit":  p.DailyCountLimit,
+		"daily_amount_limit": p.DailyAmountLimit,
+		"policy_updated_at":  p.UpdatedAt,
+	}
+}
+
+// WithdrawalAuditLog 提现审核日志（只追加，记录每次状态流转）
+type WithdrawalAuditLog struct {
+	ID           int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	WithdrawalID int64     `gorm:"column:withdrawal_id;index;not null" json:"withdrawal_id"`
+	OperatorID   int64     `gorm:"column:operator_id;not null" json:"operator_id"`
+	Action       string    `gorm:"column:action;type:varchar(20);not null" json:"action"`
+	FromStatus   string    `gorm:"column:from_status;type:varchar(20);not null" json:"from_status"`
+	ToStatus     string    `gorm:"column:to_status;type:varchar(20);not null" json:"to_status"`
+	Reason       *string   `gorm:"column:reason;type:varchar(255)" json:"reason,omitempty"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+
+	// 关联
+	Operator *Admin `gorm:"foreignKey:OperatorID" json:"operator,omitempty"`
+}
+
+// TableName 表名
+func (WithdrawalAuditLog) TableName() string {
+	return "withdrawal_audit_logs"
+}
+
+// WithdrawalAuditAction 提现审核操作
+const (
+	WithdrawalAuditActionApprove  = "approve"  // 审核通过
+	WithdrawalAuditActionReject   = "reject"   // 审核拒绝
+	WithdrawalAuditActionProcess  = "process"  // 开始打款
+	WithdrawalAuditActionComplete = "complete" // 完成打款
+)
+
+// WithdrawalAuditOperatorSystem 系统自动操作时审核日志记录的操作人ID
+const WithdrawalAuditOperatorSystem int64 = 0
+
 // CommissionSetting 佣金设置
 type CommissionSetting struct {
 	ID            int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
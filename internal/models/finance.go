@@ -39,6 +39,25 @@ type FinanceOverview struct {
 	PendingSettlements int     `json:"pending_settlements"`  // 待结算数
 }
 
+// FinanceDailySummary 财务日汇总，财务概览的累计金额由已汇总日期加未汇总部分的实时增量组成
+// 各金额按入账时间归属日期：支付按支付时间、退款按退款时间、佣金和结算按结算时间
+// 参考: migrations/000052_create_finance_daily_summaries.up.sql
+type FinanceDailySummary struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	BizDate    time.Time `gorm:"column:biz_date;type:date;uniqueIndex:idx_finance_daily_summaries_date;not null" json:"biz_date"`
+	Revenue    float64   `gorm:"column:revenue;type:decimal(14,2);not null;default:0" json:"revenue"`
+	Refund     float64   `gorm:"column:refund;type:decimal(14,2);not null;default:0" json:"refund"`
+	Commission float64   `gorm:"column:commission;type:decimal(14,2);not null;default:0" json:"commission"`
+	Settlement float64   `gorm:"column:settlement;type:decimal(14,2);not null;default:0" json:"settlement"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (FinanceDailySummary) TableName() string {
+	return "finance_daily_summaries"
+}
+
 // RevenueStatistics 收入统计
 type RevenueStatistics struct {
 	Date    string  `json:"date"`
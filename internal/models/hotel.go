@@ -6,28 +6,32 @@ import (
 
 // Hotel 酒店模型
 type Hotel struct {
-	ID             int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
-	Name           string    `gorm:"column:name;type:varchar(100);not null" json:"name"`
-	StarRating     *int      `gorm:"column:star_rating;type:smallint" json:"star_rating,omitempty"`
-	Province       string    `gorm:"column:province;type:varchar(50);not null" json:"province"`
-	City           string    `gorm:"column:city;type:varchar(50);not null" json:"city"`
-	District       string    `gorm:"column:district;type:varchar(50);not null" json:"district"`
-	Address        string    `gorm:"column:address;type:varchar(255);not null" json:"address"`
-	Longitude      *float64  `gorm:"column:longitude;type:decimal(10,7)" json:"longitude,omitempty"`
-	Latitude       *float64  `gorm:"column:latitude;type:decimal(10,7)" json:"latitude,omitempty"`
-	Phone          string    `gorm:"column:phone;type:varchar(20);not null" json:"phone"`
-	Images         JSONArray `gorm:"column:images;type:jsonb" json:"images,omitempty"`
-	Facilities     JSONArray `gorm:"column:facilities;type:jsonb" json:"facilities,omitempty"`
-	Description    *string   `gorm:"column:description;type:text" json:"description,omitempty"`
-	CheckInTime    string    `gorm:"column:check_in_time;type:time;not null;default:'14:00'" json:"check_in_time"`
-	CheckOutTime   string    `gorm:"column:check_out_time;type:time;not null;default:'12:00'" json:"check_out_time"`
-	CommissionRate float64    `gorm:"column:commission_rate;type:decimal(5,4);not null;default:0.1500" json:"commission_rate"`
-	Status         int8       `gorm:"column:status;type:smallint;not null;default:1" json:"status"`
-	IsRecommended  bool       `gorm:"column:is_recommended;not null;default:false" json:"is_recommended"`
-	RecommendScore int        `gorm:"column:recommend_score;not null;default:0" json:"recommend_score"`
-	RecommendedAt  *time.Time `gorm:"column:recommended_at" json:"recommended_at,omitempty"`
-	CreatedAt      time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
-	UpdatedAt      time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+	ID                  int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Name                string     `gorm:"column:name;type:varchar(100);not null" json:"name"`
+	StarRating          *int       `gorm:"column:star_rating;type:smallint" json:"star_rating,omitempty"`
+	Province            string     `gorm:"column:province;type:varchar(50);not null" json:"province"`
+	City                string     `gorm:"column:city;type:varchar(50);not null" json:"city"`
+	District            string     `gorm:"column:district;type:varchar(50);not null" json:"district"`
+	Address             string     `gorm:"column:address;type:varchar(255);not null" json:"address"`
+	Longitude           *float64   `gorm:"column:longitude;type:decimal(10,7)" json:"longitude,omitempty"`
+	Latitude            *float64   `gorm:"column:latitude;type:decimal(10,7)" json:"latitude,omitempty"`
+	Phone               string     `gorm:"column:phone;type:varchar(20);not null" json:"phone"`
+	Images              JSONArray  `gorm:"column:images;type:jsonb" json:"images,omitempty"`
+	Facilities          JSONArray  `gorm:"column:facilities;type:jsonb" json:"facilities,omitempty"`
+	Description         *string    `gorm:"column:description;type:text" json:"description,omitempty"`
+	CheckInTime         string     `gorm:"column:check_in_time;type:time;not null;default:'14:00'" json:"check_in_time"`
+	CheckOutTime        string     `gorm:"column:check_out_time;type:time;not null;default:'12:00'" json:"check_out_time"`
+	Timezone            string     `gorm:"column:timezone;type:varchar(64);not null;default:'Asia/Shanghai'" json:"timezone"`
+	FreeCancelHours     int        `gorm:"column:free_cancel_hours;not null;default:0" json:"free_cancel_hours"`
+	CancellationFeeRate float64    `gorm:"column:cancellation_fee_rate;type:decimal(5,4);not null;default:0" json:"cancellation_fee_rate"`
+	LongUnlockCode      bool       `gorm:"column:long_unlock_code;not null;default:false" json:"long_unlock_code"` // 开启后预订生成8位开锁码
+	CommissionRate      float64    `gorm:"column:commission_rate;type:decimal(5,4);not null;default:0.1500" json:"commission_rate"`
+	Status              int8       `gorm:"column:status;type:smallint;not null;default:1" json:"status"`
+	IsRecommended       bool       `gorm:"column:is_recommended;not null;default:false" json:"is_recommended"`
+	RecommendScore      int        `gorm:"column:recommend_score;not null;default:0" json:"recommend_score"`
+	RecommendedAt       *time.Time `gorm:"column:recommended_at" json:"recommended_at,omitempty"`
+	CreatedAt           time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
 
 	// 关联
 	Rooms []Room `gorm:"foreignKey:HotelID" json:"rooms,omitempty"`
@@ -38,6 +42,9 @@ func (Hotel) TableName() string {
 	return "hotels"
 }
 
+// DefaultHotelTimezone 酒店默认时区
+const DefaultHotelTimezone = "Asia/Shanghai"
+
 // HotelStatus 酒店状态
 const (
 	HotelStatusDisabled = 0 // 下架
@@ -118,6 +125,36 @@ func (RoomTimeSlot) TableName() string {
 	return "room_time_slots"
 }
 
+// RoomPriceOverride 房间日历价格覆盖
+// 按具体日期或星期几覆盖时段价格（如周五、周六晚间及节假日加价），以入住日期匹配
+type RoomPriceOverride struct {
+	ID            int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	RoomID        int64      `gorm:"column:room_id;index;not null" json:"room_id"`
+	Date          *time.Time `gorm:"column:date;type:date" json:"date,omitempty"`                // 指定日期（节假日），优先于星期规则
+	WeekdayMask   int        `gorm:"column:weekday_mask;not null;default:0" json:"weekday_mask"` // 星期掩码，bit0=周日 ... bit6=周六
+	DurationHours *int       `gorm:"column:duration_hours" json:"duration_hours,omitempty"`      // 适用时长，为空时适用所有时段
+	Price         float64    `gorm:"column:price;type:decimal(10,2);not null" json:"price"`
+	Priority      int        `gorm:"column:priority;not null;default:0" json:"priority"`      // 优先级，数值越大越优先
+	StartDate     *time.Time `gorm:"column:start_date;type:date" json:"start_date,omitempty"` // 生效开始日期（含）
+	EndDate       *time.Time `gorm:"column:end_date;type:date" json:"end_date,omitempty"`     // 生效结束日期（含），过期后不再适用
+	IsActive      bool       `gorm:"column:is_active;not null;default:true" json:"is_active"`
+	CreatedAt     time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+
+	// 关联
+	Room *Room `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+}
+
+// TableName 表名
+func (RoomPriceOverride) TableName() string {
+	return "room_price_overrides"
+}
+
+// WeekdayBit 返回星期对应的掩码位
+func WeekdayBit(day time.Weekday) int {
+	return 1 << uint(day)
+}
+
 // Booking 预订记录模型
 type Booking struct {
 	ID               int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
@@ -139,6 +176,7 @@ type Booking struct {
 	VerifiedBy       *int64     `gorm:"column:verified_by" json:"verified_by,omitempty"`
 	UnlockedAt       *time.Time `gorm:"column:unlocked_at" json:"unlocked_at,omitempty"`
 	CompletedAt      *time.Time `gorm:"column:completed_at" json:"completed_at,omitempty"`
+	ReminderSentAt   *time.Time `gorm:"column:reminder_sent_at" json:"reminder_sent_at,omitempty"` // 入住提醒发送时间
 	CreatedAt        time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
 	UpdatedAt        time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
 
@@ -164,6 +202,43 @@ const (
 	BookingStatusInUse     = "in_use"    // 使用中
 	BookingStatusCompleted = "completed" // 已完成
 	BookingStatusCancelled = "cancelled" // 已取消
+	BookingStatusRefunding = "refunding" // 退款中
 	BookingStatusRefunded  = "refunded"  // 已退款
 	BookingStatusExpired   = "expired"   // 已过期
 )
+
+// BookingOccupyingStatuses 占用房间时段的预订状态，待支付的预订不占用时段
+var BookingOccupyingStatuses = []string{
+	BookingStatusPaid,
+	BookingStatusVerified,
+	BookingStatusInUse,
+}
+
+// RoomOccupancy 房间按日占用位图，由预订状态变更时在同一事务中维护，房间可用性查询只读此表
+// 日期与时刻均按 UTC 划分；跨零点的预订拆分到两天
+type RoomOccupancy struct {
+	RoomID    int64     `gorm:"column:room_id;primaryKey;autoIncrement:false" json:"room_id"`
+	Date      string    `gorm:"column:date;type:varchar(10);primaryKey" json:"date"`  // UTC 日期，格式 2006-01-02
+	HourMask  int32     `gorm:"column:hour_mask;not null;default:0" json:"hour_mask"` // 24 位小时掩码，bit0=00:00-01:00，该小时内任意时刻被占用即置位
+	Ranges    string    `gorm:"column:ranges;type:text;not null" json:"ranges"`       // 当日被占用的分钟区间（左闭右开），如 "630-750,900-1020"
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (RoomOccupancy) TableName() string {
+	return "room_occupancy"
+}
+
+// UnlockAttempt 设备开锁码失败尝试记录，未配置 Redis 时用于开锁限制
+type UnlockAttempt struct {
+	DeviceID      int64      `gorm:"column:device_id;primaryKey;autoIncrement:false" json:"device_id"`
+	FailedCount   int        `gorm:"column:failed_count;not null;default:0" json:"failed_count"`
+	FirstFailedAt *time.Time `gorm:"column:first_failed_at" json:"first_failed_at,omitempty"` // 本轮计数的首次失败时间
+	LockedUntil   *time.Time `gorm:"column:locked_until" json:"locked_until,omitempty"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (UnlockAttempt) TableName() string {
+	return "unlock_attempts"
+}
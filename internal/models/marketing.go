@@ -1,29 +1,33 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"time"
 )
 
 // Coupon 优惠券模型
 type Coupon struct {
-	ID              int64      `gorm:"primaryKey;autoIncrement" json:"id"`
-	Name            string     `gorm:"type:varchar(100);not null" json:"name"`
-	Type            string     `gorm:"type:varchar(20);not null" json:"type"`
-	Value           float64    `gorm:"type:decimal(10,2);not null" json:"value"`
-	MinAmount       float64    `gorm:"type:decimal(10,2);not null;default:0" json:"min_amount"`
-	MaxDiscount     *float64   `gorm:"type:decimal(10,2)" json:"max_discount,omitempty"`
-	TotalCount      int        `gorm:"not null" json:"total_count"`
-	UsedCount       int        `gorm:"not null;default:0" json:"used_count"`
-	ReceivedCount   int        `gorm:"column:issued_count;not null;default:0" json:"received_count"`
-	PerUserLimit    int        `gorm:"not null;default:1" json:"per_user_limit"`
-	ApplicableScope string     `gorm:"type:varchar(20);not null;default:'all'" json:"applicable_scope"`
-	ApplicableIDs   JSON       `gorm:"type:jsonb" json:"applicable_ids,omitempty"`
-	StartTime       time.Time  `gorm:"not null" json:"start_time"`
-	EndTime         time.Time  `gorm:"not null" json:"end_time"`
-	ValidDays       *int       `json:"valid_days,omitempty"`
-	Description     *string    `gorm:"type:varchar(255)" json:"description,omitempty"`
-	Status          int8       `gorm:"type:smallint;not null;default:1" json:"status"`
-	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	ID              int64                  `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name            string                 `gorm:"type:varchar(100);not null" json:"name"`
+	Type            string                 `gorm:"type:varchar(20);not null" json:"type"`
+	Value           float64                `gorm:"type:decimal(10,2);not null" json:"value"`
+	MinAmount       float64                `gorm:"type:decimal(10,2);not null;default:0" json:"min_amount"`
+	MaxDiscount     *float64               `gorm:"type:decimal(10,2)" json:"max_discount,omitempty"`
+	TotalCount      int                    `gorm:"not null" json:"total_count"`
+	UsedCount       int                    `gorm:"not null;default:0" json:"used_count"`
+	ReceivedCount   int                    `gorm:"column:issued_count;not null;default:0" json:"received_count"`
+	PerUserLimit    int                    `gorm:"not null;default:1" json:"per_user_limit"`
+	ApplicableScope string                 `gorm:"type:varchar(20);not null;default:'all'" json:"applicable_scope"`
+	ApplicableIDs   JSON                   `gorm:"type:jsonb" json:"applicable_ids,omitempty"`
+	ApplicableItems *CouponApplicableItems `gorm:"type:jsonb" json:"applicable_items,omitempty"` // 限定商品/分类，为空表示不限
+	StartTime       time.Time              `gorm:"not null" json:"start_time"`
+	EndTime         time.Time              `gorm:"not null" json:"end_time"`
+	ValidDays       *int                   `json:"valid_days,omitempty"`
+	Description     *string                `gorm:"type:varchar(255)" json:"description,omitempty"`
+	Status          int8                   `gorm:"type:smallint;not null;default:1" json:"status"`
+	CreatedAt       time.Time              `gorm:"autoCreateTime" json:"created_at"`
 
 	// 关联
 	UserCoupons []UserCoupon `gorm:"foreignKey:CouponID" json:"user_coupons,omitempty"`
@@ -45,8 +49,64 @@ const (
 	CouponScopeAll      = "all"      // 全场通用
 	CouponScopeCategory = "category" // 指定分类
 	CouponScopeProduct  = "product"  // 指定商品
+	CouponScopeRental   = "rental"   // 仅租借订单
+	CouponScopeMall     = "mall"     // 仅商城订单
+	CouponScopeHotel    = "hotel"    // 仅酒店预订
 )
 
+// CouponApplicableItems 优惠券限定的商品和分类
+// 订单中商品ID或分类ID任一命中的商品行计入可优惠金额
+type CouponApplicableItems struct {
+	ProductIDs  []int64 `json:"product_ids,omitempty"`
+	CategoryIDs []int64 `json:"category_ids,omitempty"`
+}
+
+// IsEmpty 是否未限定任何商品
+func (a *CouponApplicableItems) IsEmpty() bool {
+	return a == nil || (len(a.ProductIDs) == 0 && len(a.CategoryIDs) == 0)
+}
+
+// Matches 判断商品是否在限定范围内
+func (a *CouponApplicableItems) Matches(productID, categoryID int64) bool {
+	if a == nil {
+		return false
+	}
+	for _, id := range a.ProductIDs {
+		if id == productID {
+			return true
+		}
+	}
+	for _, id := range a.CategoryIDs {
+		if id == categoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// Scan 实现 sql.Scanner 接口
+func (a *CouponApplicableItems) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*a = CouponApplicableItems{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return errors.New("invalid coupon applicable items")
+	}
+}
+
+// Value 实现 driver.Valuer 接口
+func (a *CouponApplicableItems) Value() (driver.Value, error) {
+	if a.IsEmpty() {
+		return nil, nil
+	}
+	return json.Marshal(a)
+}
+
 // CouponStatus 优惠券状态
 const (
 	CouponStatusDisabled = 0 // 禁用
@@ -55,14 +115,16 @@ const (
 
 // UserCoupon 用户优惠券
 type UserCoupon struct {
-	ID         int64      `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID     int64      `gorm:"index;not null" json:"user_id"`
-	CouponID   int64      `gorm:"index;not null" json:"coupon_id"`
-	OrderID    *int64     `json:"order_id,omitempty"`
-	Status     int8       `gorm:"type:smallint;not null;default:0" json:"status"`
-	ExpiredAt  time.Time  `gorm:"not null" json:"expired_at"`
-	UsedAt     *time.Time `json:"used_at,omitempty"`
-	ReceivedAt time.Time  `gorm:"autoCreateTime" json:"received_at"`
+	ID           int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       int64      `gorm:"index;not null" json:"user_id"`
+	CouponID     int64      `gorm:"index;not null" json:"coupon_id"`
+	OrderID      *int64     `json:"order_id,omitempty"`
+	Status       int8       `gorm:"type:smallint;not null;default:0" json:"status"`
+	ExpiredAt    time.Time  `gorm:"not null" json:"expired_at"`
+	UsedAt       *time.Time `json:"used_at,omitempty"`
+	ReceivedAt   time.Time  `gorm:"autoCreateTime" json:"received_at"`
+	IssuedBy     *int64     `gorm:"index" json:"issued_by,omitempty"`      // 发放管理员ID，管理员批量发放时记录
+	GrantBatchID *int64     `gorm:"index" json:"grant_batch_id,omitempty"` // 定向发放批次ID，按分群发放时记录
 
 	// 关联
 	User   *User   `gorm:"foreignKey:UserID" json:"user,omitempty"`
@@ -82,19 +144,38 @@ const (
 	UserCouponStatusExpired = 2 // 已过期
 )
 
+// CouponTransfer 优惠券转赠记录
+// 参考: migrations/000050_create_coupon_transfers.up.sql
+type CouponTransfer struct {
+	ID           int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserCouponID int64     `gorm:"index;not null" json:"user_coupon_id"`
+	CouponID     int64     `gorm:"index;not null" json:"coupon_id"`
+	FromUserID   int64     `gorm:"index;not null" json:"from_user_id"`
+	ToUserID     int64     `gorm:"index;not null" json:"to_user_id"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName 表名
+func (CouponTransfer) TableName() string {
+	return "coupon_transfers"
+}
+
 // Campaign 活动模型
 type Campaign struct {
-	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	Name        string    `gorm:"type:varchar(100);not null" json:"name"`
-	Type        string    `gorm:"type:varchar(20);not null" json:"type"`
-	Description *string   `gorm:"type:text" json:"description,omitempty"`
-	Image       *string   `gorm:"type:varchar(255)" json:"image,omitempty"`
-	Rules       JSON      `gorm:"type:jsonb" json:"rules,omitempty"`
-	StartTime   time.Time `gorm:"not null" json:"start_time"`
-	EndTime     time.Time `gorm:"not null" json:"end_time"`
-	Status      int8      `gorm:"type:smallint;not null;default:1" json:"status"`
-	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID          int64           `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name        string          `gorm:"type:varchar(100);not null" json:"name"`
+	Type        string          `gorm:"type:varchar(20);not null" json:"type"`
+	Description *string         `gorm:"type:text" json:"description,omitempty"`
+	Image       *string         `gorm:"type:varchar(255)" json:"image,omitempty"`
+	Rules       json.RawMessage `gorm:"type:jsonb" json:"rules,omitempty"` // 满减活动为版本化的满减档位，其他类型为自定义规则
+	StartTime   time.Time       `gorm:"not null" json:"start_time"`
+	EndTime     time.Time       `gorm:"not null" json:"end_time"`
+	Status      int8            `gorm:"type:smallint;not null;default:1" json:"status"`
+	// 秒杀活动每日名额及每人每日限购次数，0 表示不限，按自然日重置
+	DailyLimit     int       `gorm:"not null;default:0" json:"daily_limit"`
+	UserDailyLimit int       `gorm:"not null;default:0" json:"user_daily_limit"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 // TableName 表名
@@ -116,6 +197,68 @@ const (
 	CampaignStatusActive   = 1 // 启用
 )
 
+// CampaignProduct 秒杀活动商品，FlashStock 为剩余秒杀库存
+type CampaignProduct struct {
+	ID           int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	CampaignID   int64     `gorm:"not null;uniqueIndex:uk_campaign_products_campaign_product" json:"campaign_id"`
+	ProductID    int64     `gorm:"not null;uniqueIndex:uk_campaign_products_campaign_product;index" json:"product_id"`
+	FlashPrice   float64   `gorm:"type:decimal(10,2);not null" json:"flash_price"`
+	FlashStock   int       `gorm:"not null;default:0" json:"flash_stock"`
+	PerUserLimit int       `gorm:"not null;default:0" json:"per_user_limit"` // 每人限购数量，0 表示不限
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// 关联
+	Campaign *Campaign `gorm:"foreignKey:CampaignID" json:"campaign,omitempty"`
+	Product  *Product  `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+// TableName 表名
+func (CampaignProduct) TableName() string {
+	return "campaign_products"
+}
+
+// FlashSaleReservation 秒杀库存预占记录，下单时预占，支付后转为已支付，订单取消或超时时释放并恢复秒杀库存
+type FlashSaleReservation struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	CampaignID int64     `gorm:"not null;index:idx_flash_sale_reservations_user" json:"campaign_id"`
+	ProductID  int64     `gorm:"not null;index:idx_flash_sale_reservations_user" json:"product_id"`
+	UserID     int64     `gorm:"not null;index:idx_flash_sale_reservations_user" json:"user_id"`
+	OrderID    *int64    `gorm:"index" json:"order_id,omitempty"` // 直接预占（未关联订单）时为空
+	Quantity   int       `gorm:"not null" json:"quantity"`
+	Status     string    `gorm:"type:varchar(20);not null" json:"status"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (FlashSaleReservation) TableName() string {
+	return "flash_sale_reservations"
+}
+
+// FlashSaleReservationStatus 秒杀库存预占状态
+const (
+	FlashSaleReservationReserved = "reserved" // 已预占（待支付）
+	FlashSaleReservationPaid     = "paid"     // 已支付
+	FlashSaleReservationReleased = "released" // 已释放
+)
+
+// GiftOrder 满赠活动赠品订单，关联触发赠品的主订单和零元赠品订单
+type GiftOrder struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	CampaignID  *int64    `gorm:"index" json:"campaign_id,omitempty"`        // 手动补发赠品时为空
+	MainOrderID int64     `gorm:"not null;uniqueIndex" json:"main_order_id"` // 每个主订单最多一份赠品
+	GiftOrderID int64     `gorm:"not null;uniqueIndex" json:"gift_order_id"`
+	ProductID   int64     `gorm:"not null" json:"product_id"`
+	Quantity    int       `gorm:"not null" json:"quantity"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName 表名
+func (GiftOrder) TableName() string {
+	return "gift_orders"
+}
+
 // MemberPackage 会员套餐
 type MemberPackage struct {
 	ID             int64    `gorm:"primaryKey;autoIncrement" json:"id"`
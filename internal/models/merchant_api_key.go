@@ -0,0 +1,72 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// MerchantAPIKey 商户开放接口密钥
+// 商户自助门户通过 KeyID + 签名密钥调用 /api/merchant/v1 接口；签名校验需要还原密钥原文，
+// 因此密钥以 AES 加密存储而非单向哈希
+type MerchantAPIKey struct {
+	ID              int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	MerchantID      int64      `gorm:"index;not null" json:"merchant_id"`
+	KeyID           string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"key_id"`
+	SecretEncrypted string     `gorm:"type:text;not null" json:"-"`
+	Scopes          string     `gorm:"type:varchar(255);not null;default:''" json:"scopes"` // 逗号分隔的授权范围
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty"`
+	Status          int8       `gorm:"type:smallint;not null;default:1" json:"status"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (MerchantAPIKey) TableName() string {
+	return "merchant_api_keys"
+}
+
+// MerchantAPIKeyStatus 商户密钥状态
+const (
+	MerchantAPIKeyStatusRevoked = 0 // 已吊销
+	MerchantAPIKeyStatusActive  = 1 // 正常
+)
+
+// MerchantAPIScope 商户开放接口授权范围
+const (
+	MerchantAPIScopeSettlements = "settlements" // 结算单
+	MerchantAPIScopeVenues      = "venues"      // 场地
+	MerchantAPIScopeDevices     = "devices"     // 设备
+	MerchantAPIScopeRentals     = "rentals"     // 租借记录
+)
+
+// MerchantAPIScopes 全部授权范围
+var MerchantAPIScopes = []string{
+	MerchantAPIScopeSettlements,
+	MerchantAPIScopeVenues,
+	MerchantAPIScopeDevices,
+	MerchantAPIScopeRentals,
+}
+
+// IsActive 密钥是否可用
+func (k *MerchantAPIKey) IsActive() bool {
+	return k.Status == MerchantAPIKeyStatusActive
+}
+
+// ScopeList 授权范围列表
+func (k *MerchantAPIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+// HasScope 是否拥有指定授权范围
+func (k *MerchantAPIKey) HasScope(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
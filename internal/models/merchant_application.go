@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// MerchantApplication 商户入驻申请
+// 用户自助填写资料提交申请，管理员审核通过后自动创建商户；每个用户仅保留一份申请，被驳回后可修改重新提交
+type MerchantApplication struct {
+	ID                   int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID               int64      `gorm:"uniqueIndex;not null" json:"user_id"`
+	MerchantName         string     `gorm:"type:varchar(100);not null" json:"merchant_name"`
+	ContactName          string     `gorm:"type:varchar(50);not null" json:"contact_name"`
+	ContactPhone         string     `gorm:"type:varchar(20);not null" json:"contact_phone"`
+	Address              *string    `gorm:"type:varchar(255)" json:"address,omitempty"`
+	BusinessLicenseURL   string     `gorm:"type:varchar(500);not null" json:"business_license_url"`
+	BankName             *string    `gorm:"type:varchar(100)" json:"bank_name,omitempty"`
+	BankAccountEncrypted *string    `gorm:"type:text" json:"-"`
+	BankHolderEncrypted  *string    `gorm:"type:text" json:"-"`
+	Status               string     `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	RejectReason         *string    `gorm:"type:varchar(255)" json:"reject_reason,omitempty"`
+	MerchantID           *int64     `gorm:"uniqueIndex" json:"merchant_id,omitempty"` // 审核通过后创建的商户
+	SubmittedAt          *time.Time `json:"submitted_at,omitempty"`
+	ReviewedBy           *int64     `json:"reviewed_by,omitempty"`
+	ReviewedAt           *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt            time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (MerchantApplication) TableName() string {
+	return "merchant_applications"
+}
+
+// MerchantApplicationStatus 商户入驻申请状态
+// 草稿 → 已提交 → 审核中 → 已通过/已驳回，已驳回的申请修改后回到草稿
+const (
+	MerchantApplicationStatusDraft       = "draft"        // 草稿
+	MerchantApplicationStatusSubmitted   = "submitted"    // 已提交
+	MerchantApplicationStatusUnderReview = "under_review" // 审核中
+	MerchantApplicationStatusApproved    = "approved"     // 已通过
+	MerchantApplicationStatusRejected    = "rejected"     // 已驳回
+)
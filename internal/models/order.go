@@ -28,6 +28,7 @@ type Order struct {
 	CompletedAt     *time.Time      `gorm:"column:completed_at" json:"completed_at,omitempty"`
 	CancelledAt     *time.Time      `gorm:"column:cancelled_at" json:"cancelled_at,omitempty"`
 	CancelReason    *string         `gorm:"column:cancel_reason;type:varchar(255)" json:"cancel_reason,omitempty"`
+	ReceiptURL      *string         `gorm:"column:receipt_url;type:varchar(500)" json:"receipt_url,omitempty"` // 收据PDF地址，首次下载时生成
 	CreatedAt       time.Time       `gorm:"column:created_at;autoCreateTime" json:"created_at"`
 	UpdatedAt       time.Time       `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
 
@@ -46,9 +47,10 @@ func (Order) TableName() string {
 
 // OrderType 订单类型
 const (
-	OrderTypeMall   = "mall"   // 商城订单
-	OrderTypeRental = "rental" // 租借订单
-	OrderTypeHotel  = "hotel"  // 酒店预订
+	OrderTypeMall     = "mall"     // 商城订单
+	OrderTypeRental   = "rental"   // 租借订单
+	OrderTypeHotel    = "hotel"    // 酒店预订
+	OrderTypeRecharge = "recharge" // 钱包充值
 )
 
 // OrderStatus 订单状态
@@ -63,6 +65,8 @@ const (
 	OrderStatusCancelled   = "cancelled"    // 已取消
 	OrderStatusRefunding   = "refunding"    // 退款中
 	OrderStatusRefunded    = "refunded"     // 已退款
+
+	OrderStatusPartialRefunded = "partial_refunded" // 部分退款
 )
 
 // OrderItem 订单项
@@ -70,6 +74,7 @@ type OrderItem struct {
 	ID           int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
 	OrderID      int64     `gorm:"column:order_id;index;not null" json:"order_id"`
 	ProductID    *int64    `gorm:"column:product_id" json:"product_id,omitempty"`
+	SkuID        *int64    `gorm:"column:sku_id" json:"sku_id,omitempty"`
 	ProductName  string    `gorm:"column:product_name;type:varchar(100);not null" json:"product_name"`
 	ProductImage *string   `gorm:"column:product_image;type:varchar(255)" json:"product_image,omitempty"`
 	SkuInfo      *string   `gorm:"column:sku_info;type:varchar(255)" json:"sku_info,omitempty"`
@@ -88,23 +93,55 @@ func (OrderItem) TableName() string {
 	return "order_items"
 }
 
+// OrderNote 订单备注时间线，记录客服、用户的跟进备注及系统在关键节点自动写入的备注
+type OrderNote struct {
+	ID            int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	OrderID       int64     `gorm:"column:order_id;index;not null" json:"order_id"`
+	AuthorType    string    `gorm:"column:author_type;type:varchar(20);not null" json:"author_type"` // user/admin/system
+	AuthorID      *int64    `gorm:"column:author_id" json:"author_id,omitempty"`                     // 系统备注为空
+	Content       string    `gorm:"column:content;type:varchar(500);not null" json:"content"`
+	VisibleToUser bool      `gorm:"column:visible_to_user;not null;default:false" json:"visible_to_user"`
+	CreatedAt     time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+// TableName 表名
+func (OrderNote) TableName() string {
+	return "order_notes"
+}
+
+// OrderNoteAuthorType 订单备注作者类型
+const (
+	OrderNoteAuthorUser   = "user"   // 用户
+	OrderNoteAuthorAdmin  = "admin"  // 管理员
+	OrderNoteAuthorSystem = "system" // 系统
+)
+
 // Rental 租借订单
 type Rental struct {
 	ID                int64      `gorm:"primaryKey;autoIncrement" json:"id"`
 	OrderID           int64      `gorm:"column:order_id;uniqueIndex;not null" json:"order_id"`
 	UserID            int64      `gorm:"column:user_id;index;not null" json:"user_id"`
 	DeviceID          int64      `gorm:"column:device_id;index;not null" json:"device_id"`
+	SlotNo            *int       `gorm:"column:slot_no" json:"slot_no,omitempty"`                                           // 分配的格口编号
+	PricingID         *int64     `gorm:"column:pricing_id;index" json:"pricing_id,omitempty"` // 租借时所选定价
 	DurationHours     int        `gorm:"column:duration_hours;not null" json:"duration_hours"`
-	RentalFee         float64    `gorm:"column:rental_fee;type:decimal(10,2);not null" json:"rental_fee"`
+	OriginalFee       float64    `gorm:"column:original_fee;type:decimal(10,2);not null;default:0" json:"original_fee"`   // 会员折扣前租金
+	HourlyPrice       float64    `gorm:"column:hourly_price;type:decimal(10,4);not null;default:0" json:"hourly_price"`   // 下单时小时单价（会员折扣前），按小时续租沿用
+	DiscountRate      float64    `gorm:"column:discount_rate;type:decimal(3,2);not null;default:1.00" json:"discount_rate"` // 会员折扣率
+	RentalFee         float64    `gorm:"column:rental_fee;type:decimal(10,2);not null" json:"rental_fee"`                   // 折后租金
 	Deposit           float64    `gorm:"column:deposit;type:decimal(10,2);not null" json:"deposit"`
 	OvertimeRate      float64    `gorm:"column:overtime_rate;type:decimal(10,2);not null" json:"overtime_rate"`
 	OvertimeFee       float64    `gorm:"column:overtime_fee;type:decimal(10,2);not null;default:0" json:"overtime_fee"`
+	GracePeriodMinutes int       `gorm:"column:grace_period_minutes;not null;default:0" json:"grace_period_minutes"`
+	DepositMethod     string     `gorm:"column:deposit_method;type:varchar(20);not null;default:'wallet_freeze'" json:"deposit_method"` // 押金方式
 	Status            string     `gorm:"column:status;type:varchar(20);not null" json:"status"`
 	UnlockedAt        *time.Time `gorm:"column:unlocked_at" json:"unlocked_at,omitempty"`
 	ExpectedReturnAt  *time.Time `gorm:"column:expected_return_at" json:"expected_return_at,omitempty"`
 	ReturnedAt        *time.Time `gorm:"column:returned_at" json:"returned_at,omitempty"`
 	IsPurchased       bool       `gorm:"column:is_purchased;not null;default:false" json:"is_purchased"`
 	PurchasedAt       *time.Time `gorm:"column:purchased_at" json:"purchased_at,omitempty"`
+	InvoicePath       *string    `gorm:"column:invoice_path;type:varchar(255)" json:"invoice_path,omitempty"` // 发票PDF在对象存储中的路径
+	SubscriptionID    *int64     `gorm:"column:subscription_id;index" json:"subscription_id,omitempty"`       // 所属订阅，按次租借为空
 	CreatedAt         time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
 	UpdatedAt         time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
 
@@ -132,23 +169,156 @@ const (
 	RentalStatusRefunded  = "refunded"   // 已退款
 )
 
+// DepositMethod 租借押金方式
+const (
+	DepositMethodWalletFreeze = "wallet_freeze" // 冻结钱包余额
+	DepositMethodPreauth      = "preauth"       // 第三方支付预授权
+)
+
+// RentalTransfer 租借转让记录，记录租借及订单归属的变更
+type RentalTransfer struct {
+	ID         int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	RentalID   int64      `gorm:"column:rental_id;index;not null" json:"rental_id"`
+	OrderID    int64      `gorm:"column:order_id;not null" json:"order_id"`
+	FromUserID int64      `gorm:"column:from_user_id;index;not null" json:"from_user_id"`
+	ToUserID   *int64     `gorm:"column:to_user_id;index" json:"to_user_id,omitempty"`
+	Code       string     `gorm:"column:code;type:varchar(16);uniqueIndex;not null" json:"code"`
+	Deposit    float64    `gorm:"column:deposit;type:decimal(10,2);not null;default:0" json:"deposit"` // 转让时由接收方冻结的押金
+	Status     string     `gorm:"column:status;type:varchar(20);not null" json:"status"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	AcceptedAt *time.Time `gorm:"column:accepted_at" json:"accepted_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+// TableName 表名
+func (RentalTransfer) TableName() string {
+	return "rental_transfers"
+}
+
+// RentalTransferStatus 租借转让状态
+const (
+	RentalTransferStatusPending   = "pending"   // 待接受
+	RentalTransferStatusAccepted  = "accepted"  // 已接受
+	RentalTransferStatusCancelled = "cancelled" // 已失效（重新发起转让）
+)
+
+// RentalSubscription 租借订阅，按周或按月固定费用连续租用同一设备的格口
+// 每个周期生成一条租借记录，续订时格口随租借一并交接，订阅期间格口不会被其他用户预占
+type RentalSubscription struct {
+	ID              int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID          int64      `gorm:"column:user_id;index;not null" json:"user_id"`
+	DeviceID        int64      `gorm:"column:device_id;index;not null" json:"device_id"`
+	PricingID       int64      `gorm:"column:pricing_id;not null" json:"pricing_id"` // 订阅时所选的周期定价
+	Period          string     `gorm:"column:period;type:varchar(20);not null" json:"period"`
+	PricePerPeriod  float64    `gorm:"column:price_per_period;type:decimal(10,2);not null" json:"price_per_period"`
+	StartDate       time.Time  `gorm:"column:start_date;not null" json:"start_date"`
+	EndDate         time.Time  `gorm:"column:end_date;index;not null" json:"end_date"` // 当前已付费周期的结束时间
+	Status          string     `gorm:"column:status;type:varchar(20);not null" json:"status"`
+	CurrentRentalID *int64     `gorm:"column:current_rental_id" json:"current_rental_id,omitempty"` // 当前周期的租借
+	PausedAt        *time.Time `gorm:"column:paused_at" json:"paused_at,omitempty"`
+	CancelledAt     *time.Time `gorm:"column:cancelled_at" json:"cancelled_at,omitempty"`
+	CreatedAt       time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (RentalSubscription) TableName() string {
+	return "rental_subscriptions"
+}
+
+// RentalSubscriptionPeriod 订阅周期
+const (
+	SubscriptionPeriodWeekly  = "weekly"  // 按周
+	SubscriptionPeriodMonthly = "monthly" // 按月
+)
+
+// RentalSubscriptionStatus 订阅状态
+const (
+	SubscriptionStatusActive    = "active"    // 生效中
+	SubscriptionStatusPaused    = "paused"    // 已暂停（续订扣款失败）
+	SubscriptionStatusCancelled = "cancelled" // 已取消
+)
+
 // RentalPricing 租借定价
 type RentalPricing struct {
-	ID            int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	VenueID       *int64    `gorm:"column:venue_id;index" json:"venue_id,omitempty"`
-	DurationHours int       `gorm:"column:duration_hours;not null" json:"duration_hours"`
-	Price         float64   `gorm:"type:decimal(10,2);not null" json:"price"`
-	Deposit       float64   `gorm:"type:decimal(10,2);not null" json:"deposit"`
-	OvertimeRate  float64   `gorm:"column:overtime_rate;type:decimal(10,2);not null" json:"overtime_rate"`
-	IsActive      bool      `gorm:"column:is_active;not null;default:true" json:"is_active"`
-	CreatedAt     time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
-	UpdatedAt     time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+	ID                 int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	VenueID            *int64    `gorm:"column:venue_id;index" json:"venue_id,omitempty"`
+	DeviceID           *int64    `gorm:"column:device_id;index" json:"device_id,omitempty"` // 设备专属定价，优先于场地及全局定价
+	DurationHours      int       `gorm:"column:duration_hours;not null" json:"duration_hours"`
+	Price              float64   `gorm:"type:decimal(10,2);not null" json:"price"`
+	Deposit            float64   `gorm:"type:decimal(10,2);not null" json:"deposit"`
+	OvertimeRate       float64   `gorm:"column:overtime_rate;type:decimal(10,2);not null" json:"overtime_rate"`
+	GracePeriodMinutes *int      `gorm:"column:grace_period_minutes" json:"grace_period_minutes,omitempty"` // 超时宽限期(分钟)，为空时使用场地默认值
+	IsActive           bool      `gorm:"column:is_active;not null;default:true" json:"is_active"`
+	CreatedAt          time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
 
 	// 关联
 	Venue *Venue `gorm:"foreignKey:VenueID" json:"venue,omitempty"`
 }
 
+// 超时宽限期范围(分钟)
+const (
+	MinGracePeriodMinutes = 0
+	MaxGracePeriodMinutes = 60
+)
+
 // TableName 表名
 func (RentalPricing) TableName() string {
 	return "rental_pricings"
 }
+
+// 定价优先级，数值越大越优先：设备专属 > 场地 > 全局
+const (
+	PricingPriorityGlobal = iota // 全局定价（未指定场地和设备）
+	PricingPriorityVenue         // 场地定价
+	PricingPriorityDevice        // 设备专属定价
+)
+
+// Priority 定价优先级
+func (p *RentalPricing) Priority() int {
+	switch {
+	case p.DeviceID != nil:
+		return PricingPriorityDevice
+	case p.VenueID != nil:
+		return PricingPriorityVenue
+	default:
+		return PricingPriorityGlobal
+	}
+}
+
+// AppliesTo 定价是否适用于指定场地下的设备
+func (p *RentalPricing) AppliesTo(deviceID, venueID int64) bool {
+	if p.DeviceID != nil {
+		return *p.DeviceID == deviceID
+	}
+	return p.VenueID == nil || *p.VenueID == venueID
+}
+
+// PricingSchedule 场地分时定价
+// 租借开始时间落在 [StartHour, EndHour) 内时租金乘以 Multiplier；DayOfWeek 为空表示每天生效
+type PricingSchedule struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	VenueID    int64     `gorm:"column:venue_id;index;not null" json:"venue_id"`
+	DayOfWeek  *int      `gorm:"column:day_of_week" json:"day_of_week,omitempty"` // 0-6，0 为周日
+	StartHour  int       `gorm:"column:start_hour;not null" json:"start_hour"`    // 0-23
+	EndHour    int       `gorm:"column:end_hour;not null" json:"end_hour"`        // 1-24，不含
+	Multiplier float64   `gorm:"type:decimal(4,2);not null;default:1" json:"multiplier"`
+	IsActive   bool      `gorm:"column:is_active;not null;default:true" json:"is_active"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (PricingSchedule) TableName() string {
+	return "pricing_schedules"
+}
+
+// Matches 分时定价是否覆盖指定时间（按该时间所在时区的星期和小时判断）
+func (s *PricingSchedule) Matches(t time.Time) bool {
+	if s.DayOfWeek != nil && *s.DayOfWeek != int(t.Weekday()) {
+		return false
+	}
+	hour := t.Hour()
+	return hour >= s.StartHour && hour < s.EndHour
+}
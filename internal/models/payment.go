@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -8,10 +9,12 @@ import (
 type Payment struct {
 	ID              int64      `gorm:"primaryKey;autoIncrement" json:"id"`
 	PaymentNo       string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"payment_no"`
+	Type            string     `gorm:"type:varchar(20);not null;default:'pay'" json:"type"` // 支付单类型：pay 普通支付，preauth 押金预授权
 	OrderID         int64      `gorm:"index;not null" json:"order_id"`
 	OrderNo         string     `gorm:"type:varchar(64);not null" json:"order_no"`
 	UserID          int64      `gorm:"index;not null" json:"user_id"`
 	Amount          float64    `gorm:"type:decimal(12,2);not null" json:"amount"`
+	CapturedAmount  float64    `gorm:"type:decimal(12,2);not null;default:0" json:"captured_amount"` // 预授权实际扣款金额
 	PaymentMethod   string     `gorm:"type:varchar(20);not null" json:"payment_method"`
 	PaymentChannel  string     `gorm:"type:varchar(20);not null" json:"payment_channel"`
 	TransactionID   *string    `gorm:"type:varchar(64)" json:"transaction_id,omitempty"`
@@ -55,6 +58,19 @@ const (
 	PaymentStatusFailed   = 2 // 支付失败
 	PaymentStatusClosed   = 3 // 已关闭
 	PaymentStatusRefunded = 4 // 已退款
+
+	// 押金预授权状态：已授权 → 扣款中/解冻中（已结算，待渠道处理）→ 已扣款（部分扣款时其余金额同时解冻）/ 已解冻
+	PaymentStatusAuthorized = 5 // 已授权（冻结）
+	PaymentStatusCaptured   = 6 // 已扣款
+	PaymentStatusReleased   = 7 // 已解冻
+	PaymentStatusCapturing  = 8 // 扣款中
+	PaymentStatusReleasing  = 9 // 解冻中
+)
+
+// PaymentType 支付单类型
+const (
+	PaymentTypePay     = "pay"     // 普通支付
+	PaymentTypePreauth = "preauth" // 押金预授权
 )
 
 // Refund 退款记录
@@ -76,6 +92,7 @@ type Refund struct {
 	CallbackData   JSON       `gorm:"type:jsonb" json:"callback_data,omitempty"`
 	OperatorID     *int64     `json:"operator_id,omitempty"`
 	OperatorType   *string    `gorm:"type:varchar(10)" json:"operator_type,omitempty"`
+	IdempotencyKey *string    `gorm:"type:varchar(128);uniqueIndex" json:"-"`
 	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 
@@ -90,6 +107,24 @@ func (Refund) TableName() string {
 	return "refunds"
 }
 
+// RefundItem 退款明细，记录商城订单部分退款涉及的订单项及数量
+// 参考: migrations/000030_add_refund_items.up.sql
+type RefundItem struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	RefundID    int64     `gorm:"column:refund_id;index;not null" json:"refund_id"`
+	OrderID     int64     `gorm:"column:order_id;index;not null" json:"order_id"`
+	OrderItemID int64     `gorm:"column:order_item_id;index;not null" json:"order_item_id"`
+	Quantity    int       `gorm:"column:quantity;not null" json:"quantity"`
+	Amount      float64   `gorm:"column:amount;type:decimal(12,2);not null" json:"amount"`
+	Reason      *string   `gorm:"column:reason;type:varchar(255)" json:"reason,omitempty"`
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+// TableName 表名
+func (RefundItem) TableName() string {
+	return "refund_items"
+}
+
 // RefundStatus 退款状态
 const (
 	RefundStatusPending   = 0 // 待处理
@@ -122,9 +157,15 @@ type Settlement struct {
 	OrderCount   int        `gorm:"column:order_count;not null" json:"order_count"`
 	Status       string     `gorm:"column:status;type:varchar(20);not null" json:"status"`
 	OperatorID   *int64     `gorm:"column:operator_id" json:"operator_id,omitempty"`
+	ReviewerID   *int64     `gorm:"column:reviewer_id" json:"reviewer_id,omitempty"`
+	ReviewedAt   *time.Time `gorm:"column:reviewed_at" json:"reviewed_at,omitempty"`
+	RejectReason *string    `gorm:"column:reject_reason;type:varchar(255)" json:"reject_reason,omitempty"`
 	SettledAt    *time.Time `gorm:"column:settled_at" json:"settled_at,omitempty"`
 	CreatedAt    time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
 
+	// LegacyPending 审核流程上线前生成的待结算记录，可不经审核直接处理入账
+	LegacyPending bool `gorm:"column:legacy_pending;not null;default:false" json:"legacy_pending"`
+
 	// 关联
 	Operator *Admin `gorm:"foreignKey:OperatorID" json:"operator,omitempty"`
 }
@@ -134,10 +175,90 @@ func (Settlement) TableName() string {
 	return "settlements"
 }
 
+// SettlementItem 结算明细，记录生成商户结算时计入的订单
+// 参考: migrations/000028_create_settlement_items.up.sql
+type SettlementItem struct {
+	ID             int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	SettlementID   int64     `gorm:"column:settlement_id;not null;uniqueIndex:uk_settlement_item_order" json:"settlement_id"`
+	OrderID        int64     `gorm:"column:order_id;not null;uniqueIndex:uk_settlement_item_order" json:"order_id"`
+	RentalID       *int64    `gorm:"column:rental_id" json:"rental_id,omitempty"`
+	OrderNo        string    `gorm:"column:order_no;type:varchar(64);not null" json:"order_no"`
+	CompletedAt    time.Time `gorm:"column:completed_at;not null" json:"completed_at"`
+	Amount         float64   `gorm:"column:amount;type:decimal(12,2);not null" json:"amount"`
+	CommissionRate float64   `gorm:"column:commission_rate;type:decimal(5,4);not null" json:"commission_rate"`
+	MerchantShare  float64   `gorm:"column:merchant_share;type:decimal(12,2);not null" json:"merchant_share"`
+	CreatedAt      time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+// TableName 表名
+func (SettlementItem) TableName() string {
+	return "settlement_items"
+}
+
+// ReconciliationIssue 对账差异记录，每日对账时按业务日期重新生成
+// 参考: migrations/000049_create_reconciliation_issues.up.sql
+type ReconciliationIssue struct {
+	ID                  int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	BizDate             time.Time `gorm:"column:biz_date;type:date;index:idx_reconciliation_issue_date;not null" json:"biz_date"`
+	Type                string    `gorm:"column:type;type:varchar(32);not null" json:"type"`
+	UserID              *int64    `gorm:"column:user_id" json:"user_id,omitempty"`
+	OrderID             *int64    `gorm:"column:order_id" json:"order_id,omitempty"`
+	OrderNo             *string   `gorm:"column:order_no;type:varchar(64)" json:"order_no,omitempty"`
+	PaymentID           *int64    `gorm:"column:payment_id" json:"payment_id,omitempty"`
+	PaymentNo           *string   `gorm:"column:payment_no;type:varchar(64)" json:"payment_no,omitempty"`
+	WalletTransactionID *int64    `gorm:"column:wallet_transaction_id" json:"wallet_transaction_id,omitempty"`
+	ExpectedAmount      float64   `gorm:"column:expected_amount;type:decimal(12,2);not null;default:0" json:"expected_amount"`
+	ActualAmount        float64   `gorm:"column:actual_amount;type:decimal(12,2);not null;default:0" json:"actual_amount"`
+	Detail              string    `gorm:"column:detail;type:varchar(255);not null" json:"detail"`
+	CreatedAt           time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+// TableName 表名
+func (ReconciliationIssue) TableName() string {
+	return "reconciliation_issues"
+}
+
+// ReconciliationIssueType 对账差异类型
+const (
+	ReconciliationIssueOrderMissing     = "order_missing"      // 支付成功但订单不存在
+	ReconciliationIssueOrderNotPaid     = "order_not_paid"     // 支付成功但订单未处于已支付及之后的状态
+	ReconciliationIssueAmountMismatch   = "amount_mismatch"    // 支付金额与订单实付金额不一致
+	ReconciliationIssuePaymentMissing   = "payment_missing"    // 订单已支付但无支付记录且余额扣款不足
+	ReconciliationIssueDuplicatePayment = "duplicate_payment"  // 订单存在多笔成功支付
+	ReconciliationIssueWalletChainBreak = "wallet_chain_break" // 钱包流水前后余额不连续
+)
+
 // SettlementStatus 结算状态
 const (
 	SettlementStatusPending    = "pending"    // 待结算
+	SettlementStatusReviewing  = "reviewing"  // 待审核
+	SettlementStatusApproved   = "approved"   // 审核通过，待入账
+	SettlementStatusRejected   = "rejected"   // 审核驳回
 	SettlementStatusProcessing = "processing" // 结算中
 	SettlementStatusCompleted  = "completed"  // 已完成
 	SettlementStatusFailed     = "failed"     // 结算失败
 )
+
+// IdempotencyKey 幂等键记录
+// 同一用户在有效期内重复提交相同幂等键时，直接返回首次请求的响应快照
+type IdempotencyKey struct {
+	ID               int64           `gorm:"primaryKey;autoIncrement" json:"id"`
+	Key              string          `gorm:"column:idempotency_key;type:varchar(128);not null;uniqueIndex:uk_idempotency_user_key" json:"key"`
+	UserID           int64           `gorm:"column:user_id;not null;uniqueIndex:uk_idempotency_user_key" json:"user_id"`
+	Scope            string          `gorm:"column:scope;type:varchar(50);not null" json:"scope"`
+	RequestHash      string          `gorm:"column:request_hash;type:varchar(64);not null" json:"request_hash"`
+	ResponseSnapshot json.RawMessage `gorm:"column:response_snapshot;type:jsonb" json:"response_snapshot,omitempty"`
+	ExpiresAt        time.Time       `gorm:"column:expires_at;index;not null" json:"expires_at"`
+	CreatedAt        time.Time       `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+// TableName 表名
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+// IdempotencyScope 幂等作用域
+const (
+	IdempotencyScopeRentalPay     = "rental_pay"     // 租借订单支付
+	IdempotencyScopePaymentCreate = "payment_create" // 创建支付（商城/酒店等）
+)
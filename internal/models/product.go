@@ -3,6 +3,8 @@ package models
 import (
 	"encoding/json"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Category 商品分类
@@ -46,6 +48,7 @@ type Product struct {
 	Sort          int              `gorm:"column:sort;not null;default:0" json:"sort"`
 	CreatedAt     time.Time        `gorm:"column:created_at;autoCreateTime" json:"created_at"`
 	UpdatedAt     time.Time        `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+	DeletedAt     gorm.DeletedAt   `gorm:"column:deleted_at;index" json:"-"` // 软删除，删除后可恢复
 
 	// 关联
 	Category *Category    `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
@@ -62,13 +65,14 @@ func (Product) TableName() string {
 type ProductSku struct {
 	ID         int64           `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
 	ProductID  int64           `gorm:"column:product_id;index;not null" json:"product_id"`
-	SkuCode    string          `gorm:"column:sku_code;type:varchar(64);uniqueIndex;not null" json:"sku_code"`
+	SkuCode    string          `gorm:"column:sku_code;type:varchar(64);uniqueIndex:idx_product_skus_sku_code_active,where:deleted_at IS NULL;not null" json:"sku_code"`
 	Attributes json.RawMessage `gorm:"column:attributes;type:jsonb;not null" json:"attributes"`
 	Price      float64         `gorm:"column:price;type:decimal(10,2);not null" json:"price"`
 	Stock      int             `gorm:"column:stock;not null;default:0" json:"stock"`
 	Image      *string         `gorm:"column:image;type:varchar(255)" json:"image,omitempty"`
 	IsActive   bool            `gorm:"column:is_active;not null;default:true" json:"is_active"`
 	CreatedAt  time.Time       `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	DeletedAt  gorm.DeletedAt  `gorm:"column:deleted_at;index" json:"-"` // 软删除，SKU 编码仅在未删除的 SKU 中唯一
 
 	// 关联
 	Product *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
@@ -79,16 +83,33 @@ func (ProductSku) TableName() string {
 	return "product_skus"
 }
 
-// CartItem 购物车项
-type CartItem struct {
+// ProductSearchTerm 商品搜索倒排索引
+// 由商品名称和描述分词生成，记录每个词项在商品中的词频
+type ProductSearchTerm struct {
 	ID        int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
-	UserID    int64     `gorm:"column:user_id;index;not null" json:"user_id"`
-	ProductID int64     `gorm:"column:product_id;not null" json:"product_id"`
-	SkuID     *int64    `gorm:"column:sku_id" json:"sku_id,omitempty"`
-	Quantity  int       `gorm:"column:quantity;not null" json:"quantity"`
-	Selected  bool      `gorm:"column:selected;not null;default:true" json:"selected"`
+	ProductID int64     `gorm:"column:product_id;uniqueIndex:uk_product_search_terms_product_term;not null" json:"product_id"`
+	Term      string    `gorm:"column:term;type:varchar(64);uniqueIndex:uk_product_search_terms_product_term;index;not null" json:"term"`
+	Frequency int       `gorm:"column:frequency;not null;default:1" json:"frequency"`
 	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (ProductSearchTerm) TableName() string {
+	return "product_search_terms"
+}
+
+// CartItem 购物车项
+// 游客购物车项的 UserID 为 0（数据库中为 NULL），以 GuestToken 区分
+type CartItem struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID     int64     `gorm:"column:user_id;index;default:null" json:"user_id"`
+	GuestToken *string   `gorm:"column:guest_token;type:varchar(64);index" json:"-"`
+	ProductID  int64     `gorm:"column:product_id;not null" json:"product_id"`
+	SkuID      *int64    `gorm:"column:sku_id" json:"sku_id,omitempty"`
+	Quantity   int       `gorm:"column:quantity;not null" json:"quantity"`
+	Selected   bool      `gorm:"column:selected;not null;default:true" json:"selected"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
 
 	// 关联
 	User    *User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
@@ -101,6 +122,18 @@ func (CartItem) TableName() string {
 	return "cart_items"
 }
 
+// GuestCartToken 已合并的游客购物车令牌
+type GuestCartToken struct {
+	Token    string    `gorm:"column:token;primaryKey;type:varchar(64)" json:"token"`
+	UserID   int64     `gorm:"column:user_id;not null" json:"user_id"`
+	MergedAt time.Time `gorm:"column:merged_at;not null;index" json:"merged_at"`
+}
+
+// TableName 表名
+func (GuestCartToken) TableName() string {
+	return "guest_cart_tokens"
+}
+
 // Review 评价模型
 type Review struct {
 	ID          int64           `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
@@ -132,3 +165,18 @@ const (
 	ReviewStatusHidden  = 0 // 隐藏
 	ReviewStatusVisible = 1 // 显示
 )
+
+// ReviewReply 评价回复
+type ReviewReply struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	ReviewID   int64     `gorm:"column:review_id;index;not null" json:"review_id"`
+	AdminID    *int64    `gorm:"column:admin_id" json:"admin_id,omitempty"`
+	MerchantID *int64    `gorm:"column:merchant_id" json:"merchant_id,omitempty"`
+	Content    string    `gorm:"column:content;type:text;not null" json:"content"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+// TableName 表名
+func (ReviewReply) TableName() string {
+	return "review_replies"
+}
@@ -64,8 +64,27 @@ const (
 	NotificationTypeSystem    = "system"    // 系统通知
 	NotificationTypeOrder     = "order"     // 订单通知
 	NotificationTypeMarketing = "marketing" // 营销通知
+	NotificationTypeDevice    = "device"    // 设备通知（管理员）
 )
 
+// AdminNotification 管理员通知
+type AdminNotification struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	AdminID   int64      `gorm:"index;not null;column:admin_id" json:"admin_id"`
+	Type      string     `gorm:"type:varchar(20);not null;column:type" json:"type"`
+	Title     string     `gorm:"type:varchar(100);not null;column:title" json:"title"`
+	Content   string     `gorm:"type:text;not null;column:content" json:"content"`
+	Link      *string    `gorm:"type:varchar(255);column:link" json:"link,omitempty"`
+	IsRead    bool       `gorm:"not null;default:false;column:is_read" json:"is_read"`
+	ReadAt    *time.Time `gorm:"column:read_at" json:"read_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+}
+
+// TableName 表名
+func (AdminNotification) TableName() string {
+	return "admin_notifications"
+}
+
 // MessageTemplate 消息模板
 type MessageTemplate struct {
 	ID        int64     `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
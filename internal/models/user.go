@@ -42,6 +42,7 @@ func (User) TableName() string {
 const (
 	UserStatusDisabled = 0 // 禁用
 	UserStatusActive   = 1 // 正常
+	UserStatusDeleted  = 2 // 已注销（个人信息已匿名化，订单等财务记录保留）
 )
 
 // Gender 性别
@@ -72,14 +73,15 @@ func (UserWallet) TableName() string {
 
 // MemberLevel 会员等级
 type MemberLevel struct {
-	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	Name      string    `gorm:"type:varchar(50);not null" json:"name"`
-	Level     int       `gorm:"uniqueIndex;not null" json:"level"`
-	MinPoints int       `gorm:"not null;default:0" json:"min_points"`
-	Discount  float64   `gorm:"type:decimal(3,2);not null;default:1.00" json:"discount"`
-	Benefits  JSON      `gorm:"type:jsonb" json:"benefits,omitempty"`
-	Icon      *string   `gorm:"type:varchar(255)" json:"icon,omitempty"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ID         int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name       string    `gorm:"type:varchar(50);not null" json:"name"`
+	Level      int       `gorm:"uniqueIndex;not null" json:"level"`
+	MinPoints  int       `gorm:"not null;default:0" json:"min_points"`
+	Discount   float64   `gorm:"type:decimal(3,2);not null;default:1.00" json:"discount"`
+	PointsRate float64   `gorm:"type:decimal(4,2);not null;default:1.00" json:"points_rate"` // 消费积分倍率
+	Benefits   JSON      `gorm:"type:jsonb" json:"benefits,omitempty"`
+	Icon       *string   `gorm:"type:varchar(255)" json:"icon,omitempty"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
 }
 
 // TableName 表名
@@ -109,6 +111,31 @@ func (Address) TableName() string {
 	return "addresses"
 }
 
+// UserDeletionRequest 用户注销申请
+// 申请后进入冷静期，冷静期内可撤销；到期后匿名化个人信息并吊销登录令牌
+type UserDeletionRequest struct {
+	ID          int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      int64      `gorm:"index;not null" json:"user_id"`
+	Status      string     `gorm:"type:varchar(20);not null" json:"status"`
+	ScheduledAt time.Time  `gorm:"not null;index" json:"scheduled_at"` // 冷静期结束、执行注销的时间
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (UserDeletionRequest) TableName() string {
+	return "user_deletion_requests"
+}
+
+// UserDeletionStatus 用户注销申请状态
+const (
+	UserDeletionStatusPending   = "pending"   // 冷静期中
+	UserDeletionStatusCancelled = "cancelled" // 已撤销
+	UserDeletionStatusCompleted = "completed" // 已注销
+)
+
 // UserFeedback 用户反馈
 type UserFeedback struct {
 	ID        int64      `gorm:"primaryKey;autoIncrement" json:"id"`
@@ -156,7 +183,9 @@ type WalletTransaction struct {
 	BalanceBefore float64   `gorm:"type:decimal(12,2);not null" json:"balance_before"`
 	BalanceAfter  float64   `gorm:"type:decimal(12,2);not null" json:"balance_after"`
 	OrderNo       *string   `gorm:"type:varchar(64);index" json:"order_no,omitempty"`
+	PaymentNo     *string   `gorm:"type:varchar(64);uniqueIndex" json:"payment_no,omitempty"`
 	Remark        *string   `gorm:"type:varchar(255)" json:"remark,omitempty"`
+	OperatorID    *int64    `gorm:"index" json:"operator_id,omitempty"` // 人工调整时记录操作管理员ID
 	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
 }
 
@@ -165,14 +194,34 @@ func (WalletTransaction) TableName() string {
 	return "wallet_transactions"
 }
 
+// UserPointsLog 用户积分变动记录
+// 参考: migrations/000031_create_user_points_logs.up.sql
+type UserPointsLog struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64     `gorm:"index;not null" json:"user_id"`
+	Type      string    `gorm:"type:varchar(32);not null" json:"type"`
+	Points    int       `gorm:"not null" json:"points"`
+	OrderNo   *string   `gorm:"type:varchar(64);index" json:"order_no,omitempty"`
+	Remark    *string   `gorm:"type:varchar(255)" json:"remark,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName 表名
+func (UserPointsLog) TableName() string {
+	return "user_points_logs"
+}
+
 // WalletTransactionType 钱包交易类型
 const (
-	WalletTxTypeRecharge      = "recharge"       // 充值
-	WalletTxTypeConsume       = "consume"        // 消费
-	WalletTxTypeRefund        = "refund"         // 退款
-	WalletTxTypeWithdraw      = "withdraw"       // 提现
-	WalletTxTypeDeposit       = "deposit"        // 押金冻结
-	WalletTxTypeReturnDeposit = "return_deposit" // 押金退还
+	WalletTxTypeRecharge           = "recharge"            // 充值
+	WalletTxTypeConsume            = "consume"             // 消费
+	WalletTxTypeRefund             = "refund"              // 退款
+	WalletTxTypeWithdraw           = "withdraw"            // 提现
+	WalletTxTypeDeposit            = "deposit"             // 押金冻结
+	WalletTxTypeReturnDeposit      = "return_deposit"      // 押金退还
+	WalletTxTypeCommissionClawback = "commission_clawback" // 佣金追回（仅记录，不变动钱包余额）
+	WalletTxTypeAdjustment         = "adjustment"          // 人工调整
+	WalletTxTypeFrozenAdjustment   = "frozen_adjustment"   // 冻结余额人工调整（不变动可用余额）
 )
 
 // JSON 自定义 JSON 类型（支持对象）
@@ -57,6 +57,8 @@ type Venue struct {
 	ContactName  *string  `gorm:"type:varchar(50)" json:"contact_name,omitempty"`
 	ContactPhone *string  `gorm:"type:varchar(20)" json:"contact_phone,omitempty"`
 	Status       int8     `gorm:"type:smallint;not null;default:1" json:"status"`
+	DefaultGracePeriodMinutes int `gorm:"column:default_grace_period_minutes;not null;default:0" json:"default_grace_period_minutes"` // 默认超时宽限期(分钟)
+	Timezone     string   `gorm:"column:timezone;type:varchar(64);not null;default:'Asia/Shanghai'" json:"timezone"`
 	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 
@@ -70,6 +72,23 @@ func (Venue) TableName() string {
 	return "venues"
 }
 
+// DefaultVenueTimezone 场地默认时区
+const DefaultVenueTimezone = "Asia/Shanghai"
+
+// Location 获取场地所在时区，未配置或无法识别时使用默认时区
+func (v *Venue) Location() *time.Location {
+	if v != nil && v.Timezone != "" {
+		if loc, err := time.LoadLocation(v.Timezone); err == nil {
+			return loc
+		}
+	}
+	loc, err := time.LoadLocation(DefaultVenueTimezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
 // VenueType 场地类型
 const (
 	VenueTypeMall      = "mall"      // 商场
@@ -345,15 +345,23 @@ func (r *BookingRepository) CountTodayBookings(ctx context.Context, hotelID int6
 
 // ExistsByRoomAndTimeRange 检查房间在指定时段是否有预订
 func (r *BookingRepository) ExistsByRoomAndTimeRange(ctx context.Context, roomID int64, checkIn, checkOut time.Time) (bool, error) {
+	return r.ExistsByRoomAndTimeRangeExcluding(ctx, roomID, checkIn, checkOut, 0)
+}
+
+// ExistsByRoomAndTimeRangeExcluding 检查房间在指定时间段是否有其他预订，excludeBookingID 为 0 时不排除
+func (r *BookingRepository) ExistsByRoomAndTimeRangeExcluding(ctx context.Context, roomID int64, checkIn, checkOut time.Time, excludeBookingID int64) (bool, error) {
 	var count int64
-	err := r.db.WithContext(ctx).Model(&models.Booking{}).
+	query := r.db.WithContext(ctx).Model(&models.Booking{}).
 		Where("room_id = ?", roomID).
 		Where("status IN ?", []string{
 			models.BookingStatusPaid,
 			models.BookingStatusVerified,
 			models.BookingStatusInUse,
 		}).
-		Where("(check_in_time < ? AND check_out_time > ?)", checkOut, checkIn).
-		Count(&count).Error
+		Where("(check_in_time < ? AND check_out_time > ?)", checkOut, checkIn)
+	if excludeBookingID > 0 {
+		query = query.Where("id <> ?", excludeBookingID)
+	}
+	err := query.Count(&count).Error
 	return count > 0, err
 }
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// CachedMemberLevelRepository 带 Redis 缓存的会员等级仓储
+// 按 ID 查询优先读取缓存，未命中时查库并缓存 10 分钟；通过本仓储更新或删除时清除缓存
+type CachedMemberLevelRepository struct {
+	*MemberLevelRepository
+	client      *redis.Client
+	invalidator CacheInvalidator
+}
+
+// NewCachedMemberLevelRepository 创建带缓存的会员等级仓储，client 为 nil 时直接查库
+func NewCachedMemberLevelRepository(repo *MemberLevelRepository, client *redis.Client) *CachedMemberLevelRepository {
+	return &CachedMemberLevelRepository{
+		MemberLevelRepository: repo,
+		client:                client,
+		invalidator:           NewRedisCacheInvalidator(client),
+	}
+}
+
+// SetInvalidator 设置缓存失效器
+func (r *CachedMemberLevelRepository) SetInvalidator(invalidator CacheInvalidator) {
+	r.invalidator = invalidator
+}
+
+// memberLevelCacheKey 会员等级缓存键
+func memberLevelCacheKey(id int64) string {
+	return fmt.Sprintf("cache:member_level:%d", id)
+}
+
+// GetByID 根据 ID 获取会员等级，优先读取缓存
+func (r *CachedMemberLevelRepository) GetByID(ctx context.Context, id int64) (*models.MemberLevel, error) {
+	key := memberLevelCacheKey(id)
+	var level models.MemberLevel
+	if getCachedEntity(ctx, r.client, key, &level) {
+		return &level, nil
+	}
+
+	cached, err := r.MemberLevelRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	setCachedEntity(ctx, r.client, key, cached)
+	return cached, nil
+}
+
+// Update 更新会员等级并清除缓存
+func (r *CachedMemberLevelRepository) Update(ctx context.Context, level *models.MemberLevel) error {
+	if err := r.MemberLevelRepository.Update(ctx, level); err != nil {
+		return err
+	}
+	return r.Invalidate(ctx, level.ID)
+}
+
+// Delete 删除会员等级并清除缓存
+func (r *CachedMemberLevelRepository) Delete(ctx context.Context, id int64) error {
+	if err := r.MemberLevelRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.Invalidate(ctx, id)
+}
+
+// Invalidate 清除会员等级缓存，用于绕过本仓储修改会员等级后的缓存失效
+func (r *CachedMemberLevelRepository) Invalidate(ctx context.Context, id int64) error {
+	return r.invalidator.Invalidate(ctx, memberLevelCacheKey(id))
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+func setupCacheTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return mr, client
+}
+
+func TestCachedMemberLevelRepository_GetByID(t *testing.T) {
+	db := setupMemberLevelTestDB(t)
+	mr, client := setupCacheTestRedis(t)
+	repo := NewCachedMemberLevelRepository(NewMemberLevelRepository(db), client)
+	ctx := context.Background()
+
+	level := &models.MemberLevel{Name: "银卡会员", Level: 2, Discount: 0.95, PointsRate: 1}
+	require.NoError(t, db.Create(level).Error)
+
+	first, err := repo.GetByID(ctx, level.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0.95, first.Discount)
+	assert.True(t, mr.Exists(memberLevelCacheKey(level.ID)))
+	assert.Equal(t, entityCacheTTL, mr.TTL(memberLevelCacheKey(level.ID)))
+
+	// 绕过仓储直接修改数据库，第二次读取仍返回缓存数据
+	require.NoError(t, db.Model(&models.MemberLevel{}).Where("id = ?", level.ID).Update("discount", 0.8).Error)
+	second, err := repo.GetByID(ctx, level.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0.95, second.Discount)
+	assert.Equal(t, "银卡会员", second.Name)
+
+	// 缓存过期后重新查库
+	mr.FastForward(entityCacheTTL)
+	third, err := repo.GetByID(ctx, level.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0.8, third.Discount)
+
+	_, err = repo.GetByID(ctx, 999)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.False(t, mr.Exists(memberLevelCacheKey(999)))
+}
+
+func TestCachedMemberLevelRepository_UpdateInvalidatesCache(t *testing.T) {
+	db := setupMemberLevelTestDB(t)
+	mr, client := setupCacheTestRedis(t)
+	repo := NewCachedMemberLevelRepository(NewMemberLevelRepository(db), client)
+	ctx := context.Background()
+
+	level := &models.MemberLevel{Name: "金卡会员", Level: 3, Discount: 0.9, PointsRate: 1}
+	require.NoError(t, db.Create(level).Error)
+
+	cached, err := repo.GetByID(ctx, level.ID)
+	require.NoError(t, err)
+
+	cached.Discount = 0.85
+	require.NoError(t, repo.Update(ctx, cached))
+	assert.False(t, mr.Exists(memberLevelCacheKey(level.ID)))
+
+	updated, err := repo.GetByID(ctx, level.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0.85, updated.Discount)
+
+	require.NoError(t, repo.Delete(ctx, level.ID))
+	assert.False(t, mr.Exists(memberLevelCacheKey(level.ID)))
+	_, err = repo.GetByID(ctx, level.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestCachedMemberLevelRepository_NoopInvalidator(t *testing.T) {
+	db := setupMemberLevelTestDB(t)
+	_, client := setupCacheTestRedis(t)
+	repo := NewCachedMemberLevelRepository(NewMemberLevelRepository(db), client)
+	repo.SetInvalidator(NoopCacheInvalidator{})
+	ctx := context.Background()
+
+	level := &models.MemberLevel{Name: "普通会员", Level: 1, Discount: 1, PointsRate: 1}
+	require.NoError(t, db.Create(level).Error)
+
+	cached, err := repo.GetByID(ctx, level.ID)
+	require.NoError(t, err)
+	cached.Name = "新会员"
+	require.NoError(t, repo.Update(ctx, cached))
+
+	// 未清除缓存，读取到更新前的数据
+	stale, err := repo.GetByID(ctx, level.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "普通会员", stale.Name)
+}
+
+func TestCachedMemberLevelRepository_WithoutRedis(t *testing.T) {
+	db := setupMemberLevelTestDB(t)
+	repo := NewCachedMemberLevelRepository(NewMemberLevelRepository(db), nil)
+	ctx := context.Background()
+
+	level := &models.MemberLevel{Name: "普通会员", Level: 1, Discount: 1, PointsRate: 1}
+	require.NoError(t, db.Create(level).Error)
+
+	require.NoError(t, db.Model(&models.MemberLevel{}).Where("id = ?", level.ID).Update("name", "新会员").Error)
+	got, err := repo.GetByID(ctx, level.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "新会员", got.Name)
+	level.Name = "会员"
+	require.NoError(t, repo.Update(ctx, level))
+}
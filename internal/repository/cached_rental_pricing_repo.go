@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// CachedRentalPricingRepository 带 Redis 缓存的租借定价仓储
+// 按 ID 查询优先读取缓存，未命中时查库并缓存 10 分钟；通过本仓储更新或删除时清除缓存
+type CachedRentalPricingRepository struct {
+	deviceRepo  *DeviceRepository
+	client      *redis.Client
+	invalidator CacheInvalidator
+}
+
+// NewCachedRentalPricingRepository 创建带缓存的租借定价仓储，client 为 nil 时直接查库
+func NewCachedRentalPricingRepository(deviceRepo *DeviceRepository, client *redis.Client) *CachedRentalPricingRepository {
+	return &CachedRentalPricingRepository{
+		deviceRepo:  deviceRepo,
+		client:      client,
+		invalidator: NewRedisCacheInvalidator(client),
+	}
+}
+
+// SetInvalidator 设置缓存失效器
+func (r *CachedRentalPricingRepository) SetInvalidator(invalidator CacheInvalidator) {
+	r.invalidator = invalidator
+}
+
+// rentalPricingCacheKey 租借定价缓存键
+func rentalPricingCacheKey(id int64) string {
+	return fmt.Sprintf("cache:rental_pricing:%d", id)
+}
+
+// GetByID 根据 ID 获取租借定价，优先读取缓存
+func (r *CachedRentalPricingRepository) GetByID(ctx context.Context, id int64) (*models.RentalPricing, error) {
+	key := rentalPricingCacheKey(id)
+	var pricing models.RentalPricing
+	if getCachedEntity(ctx, r.client, key, &pricing) {
+		return &pricing, nil
+	}
+
+	cached, err := r.deviceRepo.GetPricingByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	setCachedEntity(ctx, r.client, key, cached)
+	return cached, nil
+}
+
+// Update 更新租借定价并清除缓存
+func (r *CachedRentalPricingRepository) Update(ctx context.Context, pricing *models.RentalPricing) error {
+	if err := r.deviceRepo.UpdatePricing(ctx, pricing); err != nil {
+		return err
+	}
+	return r.Invalidate(ctx, pricing.ID)
+}
+
+// Delete 删除租借定价并清除缓存
+func (r *CachedRentalPricingRepository) Delete(ctx context.Context, id int64) error {
+	if err := r.deviceRepo.DeletePricing(ctx, id); err != nil {
+		return err
+	}
+	return r.Invalidate(ctx, id)
+}
+
+// Invalidate 清除租借定价缓存
+func (r *CachedRentalPricingRepository) Invalidate(ctx context.Context, id int64) error {
+	return r.invalidator.Invalidate(ctx, rentalPricingCacheKey(id))
+}
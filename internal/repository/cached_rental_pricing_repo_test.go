@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+func setupRentalPricingCacheTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.RentalPricing{}))
+	return db
+}
+
+func TestCachedRentalPricingRepository(t *testing.T) {
+	db := setupRentalPricingCacheTestDB(t)
+	mr, client := setupCacheTestRedis(t)
+	repo := NewCachedRentalPricingRepository(NewDeviceRepository(db), client)
+	ctx := context.Background()
+
+	pricing := &models.RentalPricing{DurationHours: 2, Price: 20, Deposit: 50, OvertimeRate: 10, IsActive: true}
+	require.NoError(t, db.Create(pricing).Error)
+	key := rentalPricingCacheKey(pricing.ID)
+
+	t.Run("第二次读取返回缓存数据", func(t *testing.T) {
+		first, err := repo.GetByID(ctx, pricing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 20.0, first.Price)
+		assert.True(t, mr.Exists(key))
+
+		require.NoError(t, db.Model(&models.RentalPricing{}).Where("id = ?", pricing.ID).Update("price", 30).Error)
+		second, err := repo.GetByID(ctx, pricing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 20.0, second.Price)
+		assert.Equal(t, 2, second.DurationHours)
+		assert.True(t, second.IsActive)
+	})
+
+	t.Run("更新后清除缓存", func(t *testing.T) {
+		current, err := repo.GetByID(ctx, pricing.ID)
+		require.NoError(t, err)
+		current.Price = 25
+		require.NoError(t, repo.Update(ctx, current))
+		assert.False(t, mr.Exists(key))
+
+		updated, err := repo.GetByID(ctx, pricing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 25.0, updated.Price)
+	})
+
+	t.Run("删除后清除缓存", func(t *testing.T) {
+		require.NoError(t, repo.Delete(ctx, pricing.ID))
+		assert.False(t, mr.Exists(key))
+		_, err := repo.GetByID(ctx, pricing.ID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
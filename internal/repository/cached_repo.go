@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// entityCacheTTL 读多写少实体（会员等级、租借定价）的缓存有效期
+const entityCacheTTL = 10 * time.Minute
+
+// CacheInvalidator 缓存失效接口，实体更新或删除后清除对应缓存键
+type CacheInvalidator interface {
+	Invalidate(ctx context.Context, keys ...string) error
+}
+
+// RedisCacheInvalidator 基于 Redis 删除缓存键的失效器
+type RedisCacheInvalidator struct {
+	client *redis.Client
+}
+
+// NewRedisCacheInvalidator 创建 Redis 缓存失效器
+func NewRedisCacheInvalidator(client *redis.Client) *RedisCacheInvalidator {
+	return &RedisCacheInvalidator{client: client}
+}
+
+// Invalidate 删除缓存键
+func (i *RedisCacheInvalidator) Invalidate(ctx context.Context, keys ...string) error {
+	if i.client == nil || len(keys) == 0 {
+		return nil
+	}
+	return i.client.Del(ctx, keys...).Err()
+}
+
+// NoopCacheInvalidator 不执行任何操作的缓存失效器，缓存仅依赖过期时间失效
+type NoopCacheInvalidator struct{}
+
+// Invalidate 不执行任何操作
+func (NoopCacheInvalidator) Invalidate(ctx context.Context, keys ...string) error {
+	return nil
+}
+
+// getCachedEntity 读取 JSON 缓存，未配置 Redis、未命中或反序列化失败时返回 false
+func getCachedEntity(ctx context.Context, client *redis.Client, key string, dest interface{}) bool {
+	if client == nil {
+		return false
+	}
+	data, err := client.Get(ctx, key).Bytes()
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, dest) == nil
+}
+
+// setCachedEntity 写入 JSON 缓存，写入失败不影响查询结果
+func setCachedEntity(ctx context.Context, client *redis.Client, key string, value interface{}) {
+	if client == nil {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	_ = client.Set(ctx, key, data, entityCacheTTL).Err()
+}
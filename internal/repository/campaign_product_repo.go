@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// CampaignProductRepository 秒杀活动商品仓储
+type CampaignProductRepository struct {
+	db *gorm.DB
+}
+
+// NewCampaignProductRepository 创建秒杀活动商品仓储
+func NewCampaignProductRepository(db *gorm.DB) *CampaignProductRepository {
+	return &CampaignProductRepository{db: db}
+}
+
+// Create 创建秒杀活动商品
+func (r *CampaignProductRepository) Create(ctx context.Context, product *models.CampaignProduct) error {
+	return r.db.WithContext(ctx).Create(product).Error
+}
+
+// GetByCampaignAndProduct 获取活动中的秒杀商品
+func (r *CampaignProductRepository) GetByCampaignAndProduct(ctx context.Context, campaignID, productID int64) (*models.CampaignProduct, error) {
+	var product models.CampaignProduct
+	err := r.db.WithContext(ctx).
+		Where("campaign_id = ? AND product_id = ?", campaignID, productID).
+		First(&product).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// ListByCampaign 获取活动的秒杀商品（包含商品信息，商品已删除时 Product 为空）
+func (r *CampaignProductRepository) ListByCampaign(ctx context.Context, campaignID int64) ([]*models.CampaignProduct, error) {
+	var products []*models.CampaignProduct
+	err := r.db.WithContext(ctx).
+		Joins("Product").
+		Where("campaign_products.campaign_id = ?", campaignID).
+		Order("campaign_products.id ASC").
+		Find(&products).Error
+	return products, err
+}
+
+// GetActiveByProduct 获取商品当前进行中的秒杀，同一商品参加多个秒杀活动时取秒杀价最低的
+func (r *CampaignProductRepository) GetActiveByProduct(ctx context.Context, productID int64, now time.Time) (*models.CampaignProduct, error) {
+	var product models.CampaignProduct
+	err := r.db.WithContext(ctx).
+		Joins("Campaign").
+		Where("campaign_products.product_id = ?", productID).
+		Where("Campaign.type = ? AND Campaign.status = ?", models.CampaignTypeFlashSale, models.CampaignStatusActive).
+		Where("Campaign.start_time <= ? AND Campaign.end_time >= ?", now, now).
+		Order("campaign_products.flash_price ASC, campaign_products.id ASC").
+		First(&product).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// DecreaseFlashStock 扣减秒杀库存，剩余库存不足时不扣减并返回 false
+func (r *CampaignProductRepository) DecreaseFlashStock(ctx context.Context, id int64, quantity int) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.CampaignProduct{}).
+		Where("id = ? AND flash_stock >= ?", id, quantity).
+		Update("flash_stock", gorm.Expr("flash_stock - ?", quantity))
+	return result.RowsAffected > 0, result.Error
+}
+
+// IncreaseFlashStock 恢复秒杀库存
+func (r *CampaignProductRepository) IncreaseFlashStock(ctx context.Context, campaignID, productID int64, quantity int) error {
+	return r.db.WithContext(ctx).Model(&models.CampaignProduct{}).
+		Where("campaign_id = ? AND product_id = ?", campaignID, productID).
+		Update("flash_stock", gorm.Expr("flash_stock + ?", quantity)).Error
+}
+
+// CreateReservation 创建秒杀库存预占记录
+func (r *CampaignProductRepository) CreateReservation(ctx context.Context, reservation *models.FlashSaleReservation) error {
+	return r.db.WithContext(ctx).Create(reservation).Error
+}
+
+// SumUserReservedQuantity 统计用户在活动中某商品未释放（待支付及已支付）的预占数量
+func (r *CampaignProductRepository) SumUserReservedQuantity(ctx context.Context, campaignID, productID, userID int64) (int, error) {
+	var total int
+	err := r.db.WithContext(ctx).Model(&models.FlashSaleReservation{}).
+		Where("campaign_id = ? AND product_id = ? AND user_id = ?", campaignID, productID, userID).
+		Where("status IN ?", []string{models.FlashSaleReservationReserved, models.FlashSaleReservationPaid}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// SumCampaignReservedQuantity 统计活动在 [since, until) 内创建的未释放预占数量
+func (r *CampaignProductRepository) SumCampaignReservedQuantity(ctx context.Context, campaignID int64, since, until time.Time) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&models.FlashSaleReservation{}).
+		Where("campaign_id = ? AND created_at >= ? AND created_at < ?", campaignID, since, until).
+		Where("status IN ?", []string{models.FlashSaleReservationReserved, models.FlashSaleReservationPaid}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// CountUserReservations 统计用户在 [since, until) 内创建的未释放预占次数
+func (r *CampaignProductRepository) CountUserReservations(ctx context.Context, campaignID, userID int64, since, until time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.FlashSaleReservation{}).
+		Where("campaign_id = ? AND user_id = ? AND created_at >= ? AND created_at < ?", campaignID, userID, since, until).
+		Where("status IN ?", []string{models.FlashSaleReservationReserved, models.FlashSaleReservationPaid}).
+		Count(&count).Error
+	return count, err
+}
+
+// ListReservedByOrder 获取订单待支付的预占记录
+func (r *CampaignProductRepository) ListReservedByOrder(ctx context.Context, orderID int64) ([]*models.FlashSaleReservation, error) {
+	var reservations []*models.FlashSaleReservation
+	err := r.db.WithContext(ctx).
+		Where("order_id = ? AND status = ?", orderID, models.FlashSaleReservationReserved).
+		Order("id ASC").
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// UpdateReservationStatus 更新预占记录状态，仅更新仍为待支付的记录，返回是否更新
+func (r *CampaignProductRepository) UpdateReservationStatus(ctx context.Context, id int64, status string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.FlashSaleReservation{}).
+		Where("id = ? AND status = ?", id, models.FlashSaleReservationReserved).
+		Update("status", status)
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkOrderReservationsPaid 订单支付后将其待支付的预占记录更新为已支付
+func (r *CampaignProductRepository) MarkOrderReservationsPaid(ctx context.Context, orderID int64) error {
+	return r.db.WithContext(ctx).Model(&models.FlashSaleReservation{}).
+		Where("order_id = ? AND status = ?", orderID, models.FlashSaleReservationReserved).
+		Update("status", models.FlashSaleReservationPaid).Error
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 )
@@ -35,6 +36,16 @@ func (r *CampaignRepository) GetByID(ctx context.Context, id int64) (*models.Cam
 	return &campaign, nil
 }
 
+// GetByIDForUpdate 根据 ID 获取活动并锁定该行，需在事务中使用
+func (r *CampaignRepository) GetByIDForUpdate(ctx context.Context, id int64) (*models.Campaign, error) {
+	var campaign models.Campaign
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&campaign, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
 // Update 更新活动
 func (r *CampaignRepository) Update(ctx context.Context, campaign *models.Campaign) error {
 	return r.db.WithContext(ctx).Save(campaign).Error
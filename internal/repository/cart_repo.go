@@ -3,6 +3,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -19,6 +20,43 @@ func NewCartRepository(db *gorm.DB) *CartRepository {
 	return &CartRepository{db: db}
 }
 
+// CartOwner 购物车归属，登录用户按 UserID，未登录游客按客户端生成的 GuestToken
+type CartOwner struct {
+	UserID     int64
+	GuestToken string
+}
+
+// UserCartOwner 登录用户的购物车
+func UserCartOwner(userID int64) CartOwner {
+	return CartOwner{UserID: userID}
+}
+
+// GuestCartOwner 游客的购物车
+func GuestCartOwner(guestToken string) CartOwner {
+	return CartOwner{GuestToken: guestToken}
+}
+
+// IsGuest 是否为游客购物车
+func (o CartOwner) IsGuest() bool {
+	return o.UserID == 0
+}
+
+// Owns 判断购物车项是否属于该购物车
+func (o CartOwner) Owns(item *models.CartItem) bool {
+	if o.IsGuest() {
+		return item.UserID == 0 && item.GuestToken != nil && *item.GuestToken == o.GuestToken
+	}
+	return item.UserID == o.UserID
+}
+
+// scope 按购物车归属过滤
+func (o CartOwner) scope(db *gorm.DB) *gorm.DB {
+	if o.IsGuest() {
+		return db.Where("guest_token = ?", o.GuestToken)
+	}
+	return db.Where("user_id = ?", o.UserID)
+}
+
 // Create 创建购物车项
 func (r *CartRepository) Create(ctx context.Context, item *models.CartItem) error {
 	return r.db.WithContext(ctx).Create(item).Error
@@ -36,8 +74,13 @@ func (r *CartRepository) GetByID(ctx context.Context, id int64) (*models.CartIte
 
 // GetByUserIDAndProductSku 根据用户ID、商品ID、SKU ID获取购物车项
 func (r *CartRepository) GetByUserIDAndProductSku(ctx context.Context, userID, productID int64, skuID *int64) (*models.CartItem, error) {
+	return r.GetByOwnerAndProductSku(ctx, UserCartOwner(userID), productID, skuID)
+}
+
+// GetByOwnerAndProductSku 根据购物车归属、商品ID、SKU ID获取购物车项
+func (r *CartRepository) GetByOwnerAndProductSku(ctx context.Context, owner CartOwner, productID int64, skuID *int64) (*models.CartItem, error) {
 	var item models.CartItem
-	query := r.db.WithContext(ctx).Where("user_id = ? AND product_id = ?", userID, productID)
+	query := r.db.WithContext(ctx).Scopes(owner.scope).Where("product_id = ?", productID)
 
 	if skuID != nil {
 		query = query.Where("sku_id = ?", *skuID)
@@ -52,9 +95,20 @@ func (r *CartRepository) GetByUserIDAndProductSku(ctx context.Context, userID, p
 	return &item, nil
 }
 
-// Update 更新购物车项
+// Update 更新购物车项，不修改购物车归属（游客购物车项的 UserID 为 0，不能写回）
 func (r *CartRepository) Update(ctx context.Context, item *models.CartItem) error {
-	return r.db.WithContext(ctx).Save(item).Error
+	return r.db.WithContext(ctx).Omit("user_id", "guest_token").Save(item).Error
+}
+
+// MoveToUser 将购物车项转移到用户购物车
+func (r *CartRepository) MoveToUser(ctx context.Context, id, userID int64, quantity int) error {
+	return r.db.WithContext(ctx).Model(&models.CartItem{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"user_id":     userID,
+			"guest_token": nil,
+			"quantity":    quantity,
+		}).Error
 }
 
 // UpdateQuantity 更新数量
@@ -73,8 +127,13 @@ func (r *CartRepository) UpdateSelected(ctx context.Context, id int64, selected
 
 // UpdateAllSelected 更新用户所有购物车项的选中状态
 func (r *CartRepository) UpdateAllSelected(ctx context.Context, userID int64, selected bool) error {
+	return r.UpdateAllSelectedByOwner(ctx, UserCartOwner(userID), selected)
+}
+
+// UpdateAllSelectedByOwner 更新购物车所有项的选中状态
+func (r *CartRepository) UpdateAllSelectedByOwner(ctx context.Context, owner CartOwner, selected bool) error {
 	return r.db.WithContext(ctx).Model(&models.CartItem{}).
-		Where("user_id = ?", userID).
+		Scopes(owner.scope).
 		Update("selected", selected).Error
 }
 
@@ -92,11 +151,29 @@ func (r *CartRepository) DeleteByIDs(ctx context.Context, userID int64, ids []in
 
 // DeleteByUserID 删除用户所有购物车项
 func (r *CartRepository) DeleteByUserID(ctx context.Context, userID int64) error {
+	return r.DeleteByOwner(ctx, UserCartOwner(userID))
+}
+
+// DeleteByOwner 删除购物车所有项
+func (r *CartRepository) DeleteByOwner(ctx context.Context, owner CartOwner) error {
 	return r.db.WithContext(ctx).
-		Where("user_id = ?", userID).
+		Scopes(owner.scope).
 		Delete(&models.CartItem{}).Error
 }
 
+// DeleteGuestCartsInactiveSince 删除最后更新时间早于 before 的游客购物车（整车删除），返回删除的购物车项数
+func (r *CartRepository) DeleteGuestCartsInactiveSince(ctx context.Context, before time.Time) (int64, error) {
+	inactive := r.db.Model(&models.CartItem{}).
+		Select("guest_token").
+		Where("guest_token IS NOT NULL").
+		Group("guest_token").
+		Having("MAX(updated_at) < ?", before)
+	result := r.db.WithContext(ctx).
+		Where("guest_token IN (?)", inactive).
+		Delete(&models.CartItem{})
+	return result.RowsAffected, result.Error
+}
+
 // DeleteSelected 删除用户选中的购物车项
 func (r *CartRepository) DeleteSelected(ctx context.Context, userID int64) error {
 	return r.db.WithContext(ctx).
@@ -104,24 +181,29 @@ func (r *CartRepository) DeleteSelected(ctx context.Context, userID int64) error
 		Delete(&models.CartItem{}).Error
 }
 
-// ListByUserID 获取用户购物车列表
+// ListByUserID 获取用户购物车列表，关联的商品及 SKU 包括已软删除的记录
 func (r *CartRepository) ListByUserID(ctx context.Context, userID int64) ([]*models.CartItem, error) {
+	return r.ListByOwner(ctx, UserCartOwner(userID))
+}
+
+// ListByOwner 获取购物车列表，关联的商品及 SKU 包括已软删除的记录
+func (r *CartRepository) ListByOwner(ctx context.Context, owner CartOwner) ([]*models.CartItem, error) {
 	var items []*models.CartItem
 	err := r.db.WithContext(ctx).
-		Preload("Product").
-		Preload("Sku").
-		Where("user_id = ?", userID).
+		Preload("Product", unscopedPreload).
+		Preload("Sku", unscopedPreload).
+		Scopes(owner.scope).
 		Order("created_at DESC").
 		Find(&items).Error
 	return items, err
 }
 
-// ListSelectedByUserID 获取用户选中的购物车项
+// ListSelectedByUserID 获取用户选中的购物车项，关联的商品及 SKU 包括已软删除的记录
 func (r *CartRepository) ListSelectedByUserID(ctx context.Context, userID int64) ([]*models.CartItem, error) {
 	var items []*models.CartItem
 	err := r.db.WithContext(ctx).
-		Preload("Product").
-		Preload("Sku").
+		Preload("Product", unscopedPreload).
+		Preload("Sku", unscopedPreload).
 		Where("user_id = ? AND selected = ?", userID, true).
 		Order("created_at DESC").
 		Find(&items).Error
@@ -139,9 +221,14 @@ func (r *CartRepository) CountByUserID(ctx context.Context, userID int64) (int64
 
 // SumQuantityByUserID 获取用户购物车商品总数量
 func (r *CartRepository) SumQuantityByUserID(ctx context.Context, userID int64) (int, error) {
+	return r.SumQuantityByOwner(ctx, UserCartOwner(userID))
+}
+
+// SumQuantityByOwner 获取购物车商品总数量
+func (r *CartRepository) SumQuantityByOwner(ctx context.Context, owner CartOwner) (int, error) {
 	var sum int
 	err := r.db.WithContext(ctx).Model(&models.CartItem{}).
-		Where("user_id = ?", userID).
+		Scopes(owner.scope).
 		Select("COALESCE(SUM(quantity), 0)").
 		Scan(&sum).Error
 	return sum, err
@@ -154,3 +241,30 @@ func (r *CartRepository) IncrementQuantity(ctx context.Context, id int64, delta
 		UpdateColumn("quantity", gorm.Expr("quantity + ?", delta)).
 		Error
 }
+
+// CreateMergedGuestToken 记录已合并的游客购物车令牌，令牌已存在时返回主键冲突错误
+func (r *CartRepository) CreateMergedGuestToken(ctx context.Context, token *models.GuestCartToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// GetMergedGuestToken 获取已合并的游客购物车令牌
+func (r *CartRepository) GetMergedGuestToken(ctx context.Context, token string) (*models.GuestCartToken, error) {
+	var merged models.GuestCartToken
+	if err := r.db.WithContext(ctx).Where("token = ?", token).First(&merged).Error; err != nil {
+		return nil, err
+	}
+	return &merged, nil
+}
+
+// DeleteMergedGuestTokensBefore 删除合并时间早于 before 的令牌记录
+func (r *CartRepository) DeleteMergedGuestTokensBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("merged_at < ?", before).
+		Delete(&models.GuestCartToken{})
+	return result.RowsAffected, result.Error
+}
+
+// unscopedPreload 预加载时包含已软删除的关联记录，由调用方判断关联是否仍有效
+func unscopedPreload(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
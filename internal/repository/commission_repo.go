@@ -277,11 +277,11 @@ func (r *CommissionRepository) GetStatsByDistributorID(ctx context.Context, dist
 	}, nil
 }
 
-// SettlePendingByTime 结算指定时间之前的待结算佣金
+// SettlePendingByTime 结算指定时间之前的待结算佣金（不含已锁定到结算单的佣金）
 func (r *CommissionRepository) SettlePendingByTime(ctx context.Context, beforeTime time.Time) (int64, error) {
 	now := time.Now()
 	result := r.db.WithContext(ctx).Model(&models.Commission{}).
-		Where("status = ? AND created_at < ?", models.CommissionStatusPending, beforeTime).
+		Where("status = ? AND created_at < ? AND settlement_id IS NULL", models.CommissionStatusPending, beforeTime).
 		Updates(map[string]interface{}{
 			"status":     models.CommissionStatusSettled,
 			"settled_at": now,
@@ -0,0 +1,54 @@
+// Package repository 提供数据访问层
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// CouponGrantBatchRepository 优惠券定向发放批次仓储
+type CouponGrantBatchRepository struct {
+	db *gorm.DB
+}
+
+// NewCouponGrantBatchRepository 创建优惠券定向发放批次仓储
+func NewCouponGrantBatchRepository(db *gorm.DB) *CouponGrantBatchRepository {
+	return &CouponGrantBatchRepository{db: db}
+}
+
+// Create 创建发放批次
+func (r *CouponGrantBatchRepository) Create(ctx context.Context, batch *models.CouponGrantBatch) error {
+	return r.db.WithContext(ctx).Create(batch).Error
+}
+
+// GetByBatchNo 根据批次号获取发放批次
+func (r *CouponGrantBatchRepository) GetByBatchNo(ctx context.Context, batchNo string) (*models.CouponGrantBatch, error) {
+	var batch models.CouponGrantBatch
+	if err := r.db.WithContext(ctx).Where("batch_no = ?", batchNo).First(&batch).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// UpdateFields 更新指定字段
+func (r *CouponGrantBatchRepository) UpdateFields(ctx context.Context, id int64, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&models.CouponGrantBatch{}).Where("id = ?", id).Updates(fields).Error
+}
+
+// ListByCoupon 分页获取优惠券的发放批次，按创建时间倒序
+func (r *CouponGrantBatchRepository) ListByCoupon(ctx context.Context, couponID int64, offset, limit int) ([]*models.CouponGrantBatch, int64, error) {
+	var batches []*models.CouponGrantBatch
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.CouponGrantBatch{}).Where("coupon_id = ?", couponID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&batches).Error; err != nil {
+		return nil, 0, err
+	}
+	return batches, total, nil
+}
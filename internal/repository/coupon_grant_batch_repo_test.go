@@ -0,0 +1,73 @@
+// Package repository 优惠券定向发放批次仓储单元测试
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+func setupCouponGrantBatchTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.AutoMigrate(&models.CouponGrantBatch{}))
+	return db
+}
+
+func TestCouponGrantBatchRepository_GetByBatchNo(t *testing.T) {
+	db := setupCouponGrantBatchTestDB(t)
+	repo := NewCouponGrantBatchRepository(db)
+	ctx := context.Background()
+
+	days := 30
+	batch := &models.CouponGrantBatch{
+		BatchNo:  "G001",
+		CouponID: 1,
+		Segment:  models.CouponGrantSegment{LastOrderWithinDays: &days, MemberLevelIDs: []int64{2, 3}},
+		AdminID:  9,
+		Status:   models.CouponGrantBatchStatusRunning,
+	}
+	require.NoError(t, repo.Create(ctx, batch))
+
+	found, err := repo.GetByBatchNo(ctx, "G001")
+	require.NoError(t, err)
+	assert.Equal(t, batch.ID, found.ID)
+	require.NotNil(t, found.Segment.LastOrderWithinDays)
+	assert.Equal(t, 30, *found.Segment.LastOrderWithinDays)
+	assert.Equal(t, []int64{2, 3}, found.Segment.MemberLevelIDs)
+
+	// 批次号唯一
+	assert.Error(t, repo.Create(ctx, &models.CouponGrantBatch{BatchNo: "G001", CouponID: 1, AdminID: 9}))
+
+	_, err = repo.GetByBatchNo(ctx, "G404")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestCouponGrantBatchRepository_ListByCoupon(t *testing.T) {
+	db := setupCouponGrantBatchTestDB(t)
+	repo := NewCouponGrantBatchRepository(db)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, repo.Create(ctx, &models.CouponGrantBatch{BatchNo: fmt.Sprintf("A%d", i), CouponID: 1, AdminID: 9}))
+	}
+	require.NoError(t, repo.Create(ctx, &models.CouponGrantBatch{BatchNo: "B0", CouponID: 2, AdminID: 9}))
+
+	batches, total, err := repo.ListByCoupon(ctx, 1, 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, batches, 2)
+	assert.Equal(t, "A2", batches[0].BatchNo)
+	assert.Equal(t, "A1", batches[1].BatchNo)
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
+)
+
+// cursorPage 按 created_at DESC, id DESC 排序并从游标位置之后取 limit 条记录
+// 游标为 nil 时从第一条开始；排序包含 id，创建时间相同的记录也不会重复或遗漏
+func cursorPage(query *gorm.DB, cursor *utils.Cursor, limit int) *gorm.DB {
+	if cursor != nil {
+		query = query.Where("(created_at < ? OR (created_at = ? AND id < ?))",
+			cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+	return query.Order("created_at DESC").Order("id DESC").Limit(limit)
+}
+
+// applyCreatedAtRange 按 filters 中的 start_date / end_date 筛选创建时间
+func applyCreatedAtRange(query *gorm.DB, filters map[string]interface{}) *gorm.DB {
+	if startDate, ok := filters["start_date"].(*time.Time); ok && startDate != nil {
+		query = query.Where("created_at >= ?", *startDate)
+	}
+	if endDate, ok := filters["end_date"].(*time.Time); ok && endDate != nil {
+		query = query.Where("created_at <= ?", *endDate)
+	}
+	return query
+}
@@ -3,6 +3,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -19,9 +20,14 @@ func NewDeviceRepository(db *gorm.DB) *DeviceRepository {
 	return &DeviceRepository{db: db}
 }
 
-// Create 创建设备
+// Create 创建设备，同时按格口数创建设备格口
 func (r *DeviceRepository) Create(ctx context.Context, device *models.Device) error {
-	return r.db.WithContext(ctx).Create(device).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(device).Error; err != nil {
+			return err
+		}
+		return createDeviceSlotsTx(tx, device)
+	})
 }
 
 // GetByID 根据 ID 获取设备
@@ -89,6 +95,11 @@ func (r *DeviceRepository) Update(ctx context.Context, device *models.Device) er
 	return r.db.WithContext(ctx).Save(device).Error
 }
 
+// Delete 软删除设备，历史租借记录仍可通过 Unscoped 查询关联的设备
+func (r *DeviceRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&models.Device{}, id).Error
+}
+
 // UpdateFields 更新指定字段
 func (r *DeviceRepository) UpdateFields(ctx context.Context, id int64, fields map[string]interface{}) error {
 	return r.db.WithContext(ctx).Model(&models.Device{}).Where("id = ?", id).Updates(fields).Error
@@ -187,6 +198,29 @@ func (r *DeviceRepository) ListAvailable(ctx context.Context, venueID int64) ([]
 	return devices, err
 }
 
+// ListHeartbeatTimeout 获取最后心跳早于指定时间的在线设备列表
+func (r *DeviceRepository) ListHeartbeatTimeout(ctx context.Context, before time.Time) ([]*models.Device, error) {
+	var devices []*models.Device
+	err := r.db.WithContext(ctx).
+		Where("online_status = ?", models.DeviceOnline).
+		Where("last_heartbeat_at < ?", before).
+		Order("id ASC").
+		Find(&devices).Error
+	return devices, err
+}
+
+// SetOfflineIfHeartbeatBefore 仅当设备仍在线且最后心跳早于 before 时标记离线，返回是否更新
+// 检测期间设备刚上报心跳时条件不成立，不会被误标记离线
+func (r *DeviceRepository) SetOfflineIfHeartbeatBefore(ctx context.Context, id int64, before, offlineAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Device{}).
+		Where("id = ? AND online_status = ? AND last_heartbeat_at < ?", id, models.DeviceOnline, before).
+		Updates(map[string]interface{}{
+			"online_status":   models.DeviceOffline,
+			"last_offline_at": offlineAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
 // ExistsByDeviceNo 检查设备编号是否存在
 func (r *DeviceRepository) ExistsByDeviceNo(ctx context.Context, deviceNo string) (bool, error) {
 	var count int64
@@ -194,6 +228,31 @@ func (r *DeviceRepository) ExistsByDeviceNo(ctx context.Context, deviceNo string
 	return count > 0, err
 }
 
+// CreateBatch 在单个事务中批量创建设备及其格口，任一设备失败则全部回滚
+func (r *DeviceRepository) CreateBatch(ctx context.Context, devices []*models.Device) error {
+	if len(devices) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(devices, 100).Error; err != nil {
+			return err
+		}
+		return createDeviceSlotsTx(tx, devices...)
+	})
+}
+
+// ListExistingDeviceNos 返回给定设备编号中已存在的编号
+func (r *DeviceRepository) ListExistingDeviceNos(ctx context.Context, deviceNos []string) ([]string, error) {
+	var existing []string
+	if len(deviceNos) == 0 {
+		return existing, nil
+	}
+	err := r.db.WithContext(ctx).Model(&models.Device{}).
+		Where("device_no IN ?", deviceNos).
+		Pluck("device_no", &existing).Error
+	return existing, err
+}
+
 // CreateLog 创建设备日志
 func (r *DeviceRepository) CreateLog(ctx context.Context, log *models.DeviceLog) error {
 	return r.db.WithContext(ctx).Create(log).Error
@@ -221,7 +280,8 @@ func (r *DeviceRepository) ListLogs(ctx context.Context, deviceID int64, offset,
 	return logs, total, nil
 }
 
-// GetPricingsByDevice 获取设备的定价列表
+// GetPricingsByDevice 获取设备的生效定价列表
+// 同一时长存在多条适用定价时按 设备专属 > 场地 > 全局 的优先级取其一
 func (r *DeviceRepository) GetPricingsByDevice(ctx context.Context, deviceID int64) ([]*models.RentalPricing, error) {
 	// 先获取设备信息得到venue_id
 	device, err := r.GetByID(ctx, deviceID)
@@ -229,9 +289,33 @@ func (r *DeviceRepository) GetPricingsByDevice(ctx context.Context, deviceID int
 		return nil, err
 	}
 
+	pricings, err := r.ListApplicablePricings(ctx, device.ID, device.VenueID)
+	if err != nil {
+		return nil, err
+	}
+
+	effective := make([]*models.RentalPricing, 0, len(pricings))
+	byDuration := make(map[int]int, len(pricings))
+	for _, p := range pricings {
+		idx, ok := byDuration[p.DurationHours]
+		if !ok {
+			byDuration[p.DurationHours] = len(effective)
+			effective = append(effective, p)
+			continue
+		}
+		if p.Priority() > effective[idx].Priority() {
+			effective[idx] = p
+		}
+	}
+	return effective, nil
+}
+
+// ListApplicablePricings 获取适用于设备的全部启用定价：设备专属定价、设备所在场地定价及全局定价
+// 按时长、ID 升序排列
+func (r *DeviceRepository) ListApplicablePricings(ctx context.Context, deviceID, venueID int64) ([]*models.RentalPricing, error) {
 	var pricings []*models.RentalPricing
-	err = r.db.WithContext(ctx).
-		Where("venue_id = ?", device.VenueID).
+	err := r.db.WithContext(ctx).
+		Where("device_id = ? OR (device_id IS NULL AND (venue_id = ? OR venue_id IS NULL))", deviceID, venueID).
 		Where("is_active = ?", true).
 		Order("duration_hours ASC, id ASC").
 		Find(&pricings).Error
@@ -248,24 +332,51 @@ func (r *DeviceRepository) GetPricingByID(ctx context.Context, id int64) (*model
 	return &pricing, nil
 }
 
-// GetDefaultPricing 获取默认定价
+// CreatePricing 创建定价
+func (r *DeviceRepository) CreatePricing(ctx context.Context, pricing *models.RentalPricing) error {
+	return r.db.WithContext(ctx).Create(pricing).Error
+}
+
+// UpdatePricing 更新定价
+func (r *DeviceRepository) UpdatePricing(ctx context.Context, pricing *models.RentalPricing) error {
+	return r.db.WithContext(ctx).Save(pricing).Error
+}
+
+// DeletePricing 删除定价
+func (r *DeviceRepository) DeletePricing(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&models.RentalPricing{}, id).Error
+}
+
+// ListPricingsByVenue 获取场地下的全部定价（含已停用）
+func (r *DeviceRepository) ListPricingsByVenue(ctx context.Context, venueID int64) ([]*models.RentalPricing, error) {
+	var pricings []*models.RentalPricing
+	err := r.db.WithContext(ctx).
+		Where("venue_id = ?", venueID).
+		Order("duration_hours ASC, id ASC").
+		Find(&pricings).Error
+	return pricings, err
+}
+
+// ListPricingsByDevice 获取设备专属定价（含已停用）
+func (r *DeviceRepository) ListPricingsByDevice(ctx context.Context, deviceID int64) ([]*models.RentalPricing, error) {
+	var pricings []*models.RentalPricing
+	err := r.db.WithContext(ctx).
+		Where("device_id = ?", deviceID).
+		Order("duration_hours ASC, id ASC").
+		Find(&pricings).Error
+	return pricings, err
+}
+
+// GetDefaultPricing 获取默认定价（时长最短的生效定价）
 func (r *DeviceRepository) GetDefaultPricing(ctx context.Context, deviceID int64) (*models.RentalPricing, error) {
-	// 先获取设备信息得到venue_id
-	device, err := r.GetByID(ctx, deviceID)
+	pricings, err := r.GetPricingsByDevice(ctx, deviceID)
 	if err != nil {
 		return nil, err
 	}
-
-	var pricing models.RentalPricing
-	err = r.db.WithContext(ctx).
-		Where("venue_id = ?", device.VenueID).
-		Where("is_active = ?", true).
-		Order("duration_hours ASC").
-		First(&pricing).Error
-	if err != nil {
-		return nil, err
+	if len(pricings) == 0 {
+		return nil, gorm.ErrRecordNotFound
 	}
-	return &pricing, nil
+	return pricings[0], nil
 }
 
 // GetForUpdate 获取设备（加锁）
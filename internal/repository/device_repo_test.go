@@ -25,6 +25,7 @@ func setupDeviceTestDB(t *testing.T) *gorm.DB {
 
 	err = db.AutoMigrate(
 		&models.Device{},
+		&models.DeviceSlot{},
 		&models.DeviceLog{},
 		&models.Venue{},
 		&models.Merchant{},
@@ -360,6 +361,35 @@ func TestDeviceRepository_ListAvailable(t *testing.T) {
 	assert.False(t, foundBusy)
 }
 
+func TestDeviceRepository_ListHeartbeatTimeout(t *testing.T) {
+	db := setupDeviceTestDB(t)
+	repo := NewDeviceRepository(db)
+	ctx := context.Background()
+
+	venue := createDeviceTestVenue(t, db)
+	now := time.Now()
+
+	// 心跳超时的在线设备
+	staleDevice := createTestDeviceForRepo(t, db, venue.ID, "DEV_STALE_1")
+	db.Model(&staleDevice).Update("last_heartbeat_at", now.Add(-10*time.Minute))
+
+	// 心跳正常的在线设备
+	freshDevice := createTestDeviceForRepo(t, db, venue.ID, "DEV_FRESH_1")
+	db.Model(&freshDevice).Update("last_heartbeat_at", now)
+
+	// 心跳超时但已离线的设备
+	offlineDevice := createTestDeviceForRepo(t, db, venue.ID, "DEV_OFFLINE_1")
+	db.Model(&offlineDevice).Updates(map[string]interface{}{
+		"last_heartbeat_at": now.Add(-10 * time.Minute),
+		"online_status":     models.DeviceOffline,
+	})
+
+	devices, err := repo.ListHeartbeatTimeout(ctx, now.Add(-3*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	assert.Equal(t, staleDevice.ID, devices[0].ID)
+}
+
 func TestDeviceRepository_ExistsByDeviceNo(t *testing.T) {
 	db := setupDeviceTestDB(t)
 	repo := NewDeviceRepository(db)
@@ -381,6 +411,52 @@ func TestDeviceRepository_ExistsByDeviceNo(t *testing.T) {
 	})
 }
 
+func TestDeviceRepository_ListExistingDeviceNos(t *testing.T) {
+	db := setupDeviceTestDB(t)
+	repo := NewDeviceRepository(db)
+	ctx := context.Background()
+
+	venue := createDeviceTestVenue(t, db)
+	createTestDeviceForRepo(t, db, venue.ID, "DEV_BATCH_A")
+	createTestDeviceForRepo(t, db, venue.ID, "DEV_BATCH_B")
+
+	existing, err := repo.ListExistingDeviceNos(ctx, []string{"DEV_BATCH_A", "DEV_BATCH_C"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DEV_BATCH_A"}, existing)
+
+	existing, err = repo.ListExistingDeviceNos(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, existing)
+}
+
+func TestDeviceRepository_CreateBatch(t *testing.T) {
+	db := setupDeviceTestDB(t)
+	repo := NewDeviceRepository(db)
+	ctx := context.Background()
+
+	venue := createDeviceTestVenue(t, db)
+	existing := createTestDeviceForRepo(t, db, venue.ID, "DEV_BATCH_DUP")
+
+	newDevice := func(deviceNo string) *models.Device {
+		return &models.Device{
+			DeviceNo: deviceNo, Name: "批量设备", Type: models.DeviceTypeStandard, VenueID: venue.ID,
+			ProductName: "充电宝", SlotCount: 1, AvailableSlots: 1, Status: models.DeviceStatusActive,
+		}
+	}
+
+	devices := []*models.Device{newDevice("DEV_BATCH_1"), newDevice("DEV_BATCH_2")}
+	require.NoError(t, repo.CreateBatch(ctx, devices))
+	assert.NotZero(t, devices[0].ID)
+	assert.NotZero(t, devices[1].ID)
+
+	// 任一设备失败时整批回滚
+	err := repo.CreateBatch(ctx, []*models.Device{newDevice("DEV_BATCH_3"), newDevice(existing.DeviceNo)})
+	assert.Error(t, err)
+	exists, err := repo.ExistsByDeviceNo(ctx, "DEV_BATCH_3")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
 func TestDeviceRepository_CreateLog(t *testing.T) {
 	db := setupDeviceTestDB(t)
 	repo := NewDeviceRepository(db)
@@ -516,6 +592,57 @@ func TestDeviceRepository_GetPricingsByDevice(t *testing.T) {
 	assert.Equal(t, 2, pricings[1].DurationHours)
 }
 
+func TestDeviceRepository_GetPricingsByDevice_Priority(t *testing.T) {
+	db := setupDeviceTestDB(t)
+	repo := NewDeviceRepository(db)
+	ctx := context.Background()
+
+	venue := createDeviceTestVenue(t, db)
+	device := createTestDeviceForRepo(t, db, venue.ID, "DEV_PRICING_PRIORITY")
+	other := createTestDeviceForRepo(t, db, venue.ID, "DEV_PRICING_OTHER")
+
+	newPricing := func(venueID, deviceID *int64, hours int, price float64) *models.RentalPricing {
+		p := &models.RentalPricing{
+			VenueID:       venueID,
+			DeviceID:      deviceID,
+			DurationHours: hours,
+			Price:         price,
+			Deposit:       50.0,
+			OvertimeRate:  5.0,
+			IsActive:      true,
+		}
+		require.NoError(t, db.Create(p).Error)
+		return p
+	}
+
+	global1 := newPricing(nil, nil, 1, 12.0)
+	global2 := newPricing(nil, nil, 2, 20.0)
+	venue1 := newPricing(&venue.ID, nil, 1, 10.0)
+	venue2 := newPricing(&venue.ID, nil, 2, 18.0)
+	device2 := newPricing(&venue.ID, &device.ID, 2, 15.0)
+	newPricing(&venue.ID, &other.ID, 3, 25.0)
+
+	pricings, err := repo.GetPricingsByDevice(ctx, device.ID)
+	require.NoError(t, err)
+	require.Len(t, pricings, 2)
+	assert.Equal(t, venue1.ID, pricings[0].ID)
+	assert.Equal(t, device2.ID, pricings[1].ID)
+
+	// 其他设备不受该设备专属定价影响
+	pricings, err = repo.GetPricingsByDevice(ctx, other.ID)
+	require.NoError(t, err)
+	require.Len(t, pricings, 3)
+	assert.Equal(t, venue2.ID, pricings[1].ID)
+
+	applicable, err := repo.ListApplicablePricings(ctx, device.ID, venue.ID)
+	require.NoError(t, err)
+	ids := make([]int64, 0, len(applicable))
+	for _, p := range applicable {
+		ids = append(ids, p.ID)
+	}
+	assert.ElementsMatch(t, []int64{global1.ID, global2.ID, venue1.ID, venue2.ID, device2.ID}, ids)
+}
+
 func TestDeviceRepository_GetDefaultPricing(t *testing.T) {
 	db := setupDeviceTestDB(t)
 	repo := NewDeviceRepository(db)
@@ -560,3 +687,66 @@ func TestDeviceRepository_UpdateHeartbeat(t *testing.T) {
 	db.First(&found, device.ID)
 	assert.Equal(t, int8(models.DeviceOnline), found.OnlineStatus)
 }
+
+func TestDeviceRepository_SoftDeleteMigration(t *testing.T) {
+	db := setupDeviceTestDB(t)
+
+	assert.True(t, db.Migrator().HasColumn(&models.Device{}, "deleted_at"))
+	assert.True(t, db.Migrator().HasIndex(&models.Device{}, "idx_devices_device_no_active"))
+	assert.True(t, db.Migrator().HasIndex(&models.Device{}, "idx_devices_deleted_at"))
+
+	venue := createDeviceTestVenue(t, db)
+	createTestDeviceForRepo(t, db, venue.ID, "DEV_UNIQ")
+
+	t.Run("未删除的设备编号唯一", func(t *testing.T) {
+		dup := &models.Device{
+			DeviceNo:    "DEV_UNIQ",
+			Name:        "重复设备",
+			VenueID:     venue.ID,
+			Type:        "locker",
+			ProductName: "测试产品",
+			QRCode:      "QR_DEV_UNIQ_DUP",
+		}
+		assert.Error(t, db.Create(dup).Error)
+	})
+}
+
+func TestDeviceRepository_Delete(t *testing.T) {
+	db := setupDeviceTestDB(t)
+	repo := NewDeviceRepository(db)
+	ctx := context.Background()
+
+	venue := createDeviceTestVenue(t, db)
+	device := createTestDeviceForRepo(t, db, venue.ID, "DEV_DEL")
+
+	require.NoError(t, repo.Delete(ctx, device.ID))
+
+	t.Run("删除后默认查询不可见", func(t *testing.T) {
+		_, err := repo.GetByID(ctx, device.ID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		exists, err := repo.ExistsByDeviceNo(ctx, "DEV_DEL")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		devices, total, err := repo.List(ctx, 0, 10, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+		assert.Empty(t, devices)
+	})
+
+	t.Run("记录保留并设置删除时间", func(t *testing.T) {
+		var deleted models.Device
+		require.NoError(t, db.Unscoped().First(&deleted, device.ID).Error)
+		assert.True(t, deleted.DeletedAt.Valid)
+	})
+
+	t.Run("删除后设备编号可重新使用", func(t *testing.T) {
+		reused := createTestDeviceForRepo(t, db, venue.ID, "DEV_DEL")
+		assert.NotEqual(t, device.ID, reused.ID)
+
+		found, err := repo.GetByDeviceNo(ctx, "DEV_DEL")
+		require.NoError(t, err)
+		assert.Equal(t, reused.ID, found.ID)
+	})
+}
@@ -0,0 +1,317 @@
+// Package repository 提供数据访问层
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// allocateSlotAttempts 预占格口时条件更新落空后的最大选取次数
+const allocateSlotAttempts = 3
+
+// 设备格口错误
+var (
+	ErrSlotsOccupied    = errors.New("device slots beyond new slot count are occupied") // 缩减格口数时超出部分仍有格口被占用
+	ErrSlotRentalActive = errors.New("device slot rental is still active")              // 格口关联的租借仍在进行中
+)
+
+// DeviceSlotRepository 设备格口仓储
+// 格口状态变更与设备可用槽位数（available_slots）在同一事务中同步更新，保证两者一致
+type DeviceSlotRepository struct {
+	db *gorm.DB
+}
+
+// NewDeviceSlotRepository 创建设备格口仓储
+func NewDeviceSlotRepository(db *gorm.DB) *DeviceSlotRepository {
+	return &DeviceSlotRepository{db: db}
+}
+
+// newDeviceSlots 构造编号 from 到 to 的空闲格口
+func newDeviceSlots(deviceID int64, from, to int) []*models.DeviceSlot {
+	slots := make([]*models.DeviceSlot, 0, to-from+1)
+	for no := from; no <= to; no++ {
+		slots = append(slots, &models.DeviceSlot{DeviceID: deviceID, SlotNo: no, Status: models.DeviceSlotFree})
+	}
+	return slots
+}
+
+// createDeviceSlotsTx 按设备格口数创建空闲格口，设备注册时与设备在同一事务中创建
+func createDeviceSlotsTx(tx *gorm.DB, devices ...*models.Device) error {
+	var slots []*models.DeviceSlot
+	for _, device := range devices {
+		slots = append(slots, newDeviceSlots(device.ID, 1, device.SlotCount)...)
+	}
+	if len(slots) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(slots, 100).Error
+}
+
+// ListByDevice 获取设备全部格口，按格口编号排序
+func (r *DeviceSlotRepository) ListByDevice(ctx context.Context, deviceID int64) ([]*models.DeviceSlot, error) {
+	var slots []*models.DeviceSlot
+	err := r.db.WithContext(ctx).Where("device_id = ?", deviceID).Order("slot_no ASC").Find(&slots).Error
+	return slots, err
+}
+
+// Ensure 确保设备已有格口记录，见 EnsureTx
+func (r *DeviceSlotRepository) Ensure(ctx context.Context, deviceID int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return r.EnsureTx(ctx, tx, deviceID)
+	})
+}
+
+// EnsureTx 确保设备已有格口记录
+// 格口库存上线前注册的设备没有格口记录，首次使用时按格口数补建：进行中的租借依次分配格口，其余为空闲，
+// 并按空闲格口数校正设备可用槽位
+func (r *DeviceSlotRepository) EnsureTx(ctx context.Context, tx *gorm.DB, deviceID int64) error {
+	exists, err := r.hasSlotsTx(ctx, tx, deviceID)
+	if err != nil || exists {
+		return err
+	}
+
+	// 锁定设备后再次检查，并发补建时只有一个事务创建格口
+	var device models.Device
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&device, deviceID).Error; err != nil {
+		return err
+	}
+	if exists, err = r.hasSlotsTx(ctx, tx, deviceID); err != nil || exists {
+		return err
+	}
+
+	var rentals []*models.Rental
+	if err := tx.WithContext(ctx).
+		Where("device_id = ? AND status IN ? AND slot_no IS NULL", deviceID, activeRentalStatuses).
+		Order("id ASC").Limit(device.SlotCount).
+		Find(&rentals).Error; err != nil {
+		return err
+	}
+
+	slots := newDeviceSlots(deviceID, 1, device.SlotCount)
+	for i, rental := range rentals {
+		rentalID := rental.ID
+		slots[i].Status = slotStatusForRental(rental.Status)
+		slots[i].CurrentRentalID = &rentalID
+		if err := tx.WithContext(ctx).Model(rental).Update("slot_no", slots[i].SlotNo).Error; err != nil {
+			return err
+		}
+	}
+	if len(slots) > 0 {
+		if err := tx.WithContext(ctx).Create(slots).Error; err != nil {
+			return err
+		}
+	}
+
+	return r.syncAvailableSlotsTx(ctx, tx, deviceID)
+}
+
+// hasSlotsTx 设备是否已有格口记录
+func (r *DeviceSlotRepository) hasSlotsTx(ctx context.Context, tx *gorm.DB, deviceID int64) (bool, error) {
+	var count int64
+	err := tx.WithContext(ctx).Model(&models.DeviceSlot{}).Where("device_id = ?", deviceID).Count(&count).Error
+	return count > 0, err
+}
+
+// slotStatusForRental 进行中租借对应的格口状态
+func slotStatusForRental(status string) int8 {
+	if status == models.RentalStatusInUse || status == models.RentalStatusOverdue {
+		return models.DeviceSlotInUse
+	}
+	return models.DeviceSlotReserved
+}
+
+// AllocateTx 为租借预占设备编号最小的空闲格口并减少设备可用槽位，返回格口编号
+// 调用前须先通过 EnsureTx 确保设备已有格口记录；没有空闲格口时返回 gorm.ErrRecordNotFound
+// 选取格口时跳过已被其他事务锁定的格口，并发租借同一设备时各自预占不同格口而不必互相等待；
+// 不支持行锁的数据库上条件更新落空时重新选取
+func (r *DeviceSlotRepository) AllocateTx(ctx context.Context, tx *gorm.DB, deviceID, rentalID int64) (int, error) {
+	for attempt := 0; attempt < allocateSlotAttempts; attempt++ {
+		var slot models.DeviceSlot
+		if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("device_id = ? AND status = ?", deviceID, models.DeviceSlotFree).
+			Order("slot_no ASC").
+			First(&slot).Error; err != nil {
+			return 0, err
+		}
+
+		// 条件更新，并发下同一格口只会被一个租借预占
+		result := tx.WithContext(ctx).Model(&models.DeviceSlot{}).
+			Where("id = ? AND status = ?", slot.ID, models.DeviceSlotFree).
+			Updates(map[string]interface{}{
+				"status":            models.DeviceSlotReserved,
+				"current_rental_id": rentalID,
+			})
+		if result.Error != nil {
+			return 0, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		result = tx.WithContext(ctx).Model(&models.Device{}).
+			Where("id = ? AND available_slots > 0", deviceID).
+			UpdateColumn("available_slots", gorm.Expr("available_slots - 1"))
+		if result.Error != nil {
+			return 0, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return 0, gorm.ErrRecordNotFound
+		}
+
+		return slot.SlotNo, nil
+	}
+	return 0, gorm.ErrRecordNotFound
+}
+
+// OccupyTx 租借开锁取货后将预占的格口标记为使用中，租借未分配格口时忽略
+func (r *DeviceSlotRepository) OccupyTx(ctx context.Context, tx *gorm.DB, rental *models.Rental) error {
+	if rental.SlotNo == nil {
+		return nil
+	}
+	return tx.WithContext(ctx).Model(&models.DeviceSlot{}).
+		Where("device_id = ? AND slot_no = ? AND current_rental_id = ?", rental.DeviceID, *rental.SlotNo, rental.ID).
+		Update("status", models.DeviceSlotInUse).Error
+}
+
+// HandOverTx 将租借占用的格口交接给同一设备的另一租借，格口状态与设备可用槽位不变
+// 用于订阅续订，格口在前后两个周期之间不会空闲；格口已不属于原租借时返回 gorm.ErrRecordNotFound
+func (r *DeviceSlotRepository) HandOverTx(ctx context.Context, tx *gorm.DB, from *models.Rental, toRentalID int64) error {
+	if from.SlotNo == nil {
+		return gorm.ErrRecordNotFound
+	}
+	result := tx.WithContext(ctx).Model(&models.DeviceSlot{}).
+		Where("device_id = ? AND slot_no = ? AND current_rental_id = ?", from.DeviceID, *from.SlotNo, from.ID).
+		Update("current_rental_id", toRentalID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ReleaseTx 释放租借占用的格口并增加设备可用槽位
+// 格口已被释放（如管理员手动释放）时不重复增加；租借未分配格口时仅增加设备可用槽位
+func (r *DeviceSlotRepository) ReleaseTx(ctx context.Context, tx *gorm.DB, rental *models.Rental) error {
+	if rental.SlotNo != nil {
+		result := tx.WithContext(ctx).Model(&models.DeviceSlot{}).
+			Where("device_id = ? AND slot_no = ? AND current_rental_id = ?", rental.DeviceID, *rental.SlotNo, rental.ID).
+			Updates(map[string]interface{}{
+				"status":            models.DeviceSlotFree,
+				"current_rental_id": nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+	}
+
+	return tx.WithContext(ctx).Model(&models.Device{}).
+		Where("id = ?", rental.DeviceID).
+		UpdateColumn("available_slots", gorm.Expr("available_slots + 1")).Error
+}
+
+// Release 手动释放卡住的格口：关联租借已结束或不存在时将格口置为空闲并增加设备可用槽位
+// 格口已空闲时不做修改并返回 false；关联租借仍在进行中时返回 ErrSlotRentalActive；格口不存在时返回 gorm.ErrRecordNotFound
+func (r *DeviceSlotRepository) Release(ctx context.Context, deviceID int64, slotNo int) (bool, error) {
+	var released bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var slot models.DeviceSlot
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("device_id = ? AND slot_no = ?", deviceID, slotNo).
+			First(&slot).Error; err != nil {
+			return err
+		}
+		if slot.Status == models.DeviceSlotFree {
+			return nil
+		}
+
+		if slot.CurrentRentalID != nil {
+			var active int64
+			if err := tx.Model(&models.Rental{}).
+				Where("id = ? AND status IN ?", *slot.CurrentRentalID, activeRentalStatuses).
+				Count(&active).Error; err != nil {
+				return err
+			}
+			if active > 0 {
+				return ErrSlotRentalActive
+			}
+		}
+
+		if err := tx.Model(&slot).Updates(map[string]interface{}{
+			"status":            models.DeviceSlotFree,
+			"current_rental_id": nil,
+		}).Error; err != nil {
+			return err
+		}
+		released = true
+		return tx.Model(&models.Device{}).
+			Where("id = ?", deviceID).
+			UpdateColumn("available_slots", gorm.Expr("available_slots + 1")).Error
+	})
+	return released, err
+}
+
+// Resize 按新的格口数增减格口并校正设备可用槽位，返回校正后的可用槽位数
+// 只删除编号超出格口数的空闲格口，存在被占用的超出格口时返回 ErrSlotsOccupied 且不做修改
+func (r *DeviceSlotRepository) Resize(ctx context.Context, deviceID int64, slotCount int) (int, error) {
+	var available int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.EnsureTx(ctx, tx, deviceID); err != nil {
+			return err
+		}
+
+		var occupied int64
+		if err := tx.Model(&models.DeviceSlot{}).
+			Where("device_id = ? AND slot_no > ? AND status <> ?", deviceID, slotCount, models.DeviceSlotFree).
+			Count(&occupied).Error; err != nil {
+			return err
+		}
+		if occupied > 0 {
+			return ErrSlotsOccupied
+		}
+
+		if err := tx.Where("device_id = ? AND slot_no > ?", deviceID, slotCount).
+			Delete(&models.DeviceSlot{}).Error; err != nil {
+			return err
+		}
+
+		var maxSlotNo int
+		if err := tx.Model(&models.DeviceSlot{}).Where("device_id = ?", deviceID).
+			Select("COALESCE(MAX(slot_no), 0)").Scan(&maxSlotNo).Error; err != nil {
+			return err
+		}
+		if maxSlotNo < slotCount {
+			if err := tx.Create(newDeviceSlots(deviceID, maxSlotNo+1, slotCount)).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := r.syncAvailableSlotsTx(ctx, tx, deviceID); err != nil {
+			return err
+		}
+		return tx.Model(&models.Device{}).Where("id = ?", deviceID).
+			Select("available_slots").Scan(&available).Error
+	})
+	return available, err
+}
+
+// syncAvailableSlotsTx 按空闲格口数更新设备可用槽位
+func (r *DeviceSlotRepository) syncAvailableSlotsTx(ctx context.Context, tx *gorm.DB, deviceID int64) error {
+	var free int64
+	if err := tx.WithContext(ctx).Model(&models.DeviceSlot{}).
+		Where("device_id = ? AND status = ?", deviceID, models.DeviceSlotFree).
+		Count(&free).Error; err != nil {
+		return err
+	}
+	return tx.WithContext(ctx).Model(&models.Device{}).Where("id = ?", deviceID).
+		UpdateColumn("available_slots", free).Error
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// isPostgres 是否为 PostgreSQL，其他方言（如测试使用的 SQLite）按 SQLite 语法处理
+func isPostgres(db *gorm.DB) bool {
+	return db.Dialector.Name() == "postgres"
+}
+
+// hourOfDayExpr 取时间列在指定时区下的小时（0-23）的 SQL 表达式
+// loc 为 nil 或 time.Local 时 PostgreSQL 按会话时区（数据库配置的 timezone）计算；
+// SQLite 不支持时区名称，按当前时刻的 UTC 偏移换算
+func hourOfDayExpr(db *gorm.DB, column string, loc *time.Location) string {
+	if isPostgres(db) {
+		if loc != nil && loc != time.Local {
+			column += " AT TIME ZONE '" + strings.ReplaceAll(loc.String(), "'", "''") + "'"
+		}
+		return "CAST(EXTRACT(HOUR FROM " + column + ") AS INTEGER)"
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	_, offset := time.Now().In(loc).Zone()
+	return "CAST(STRFTIME('%H', " + column + ", '" + strconv.Itoa(offset) + " seconds') AS INTEGER)"
+}
+
+// hoursBetweenExpr 计算两个时间列间隔小时数的 SQL 表达式
+func hoursBetweenExpr(db *gorm.DB, start, end string) string {
+	if isPostgres(db) {
+		return "EXTRACT(EPOCH FROM (" + end + " - " + start + ")) / 3600.0"
+	}
+	return "(JULIANDAY(" + end + ") - JULIANDAY(" + start + ")) * 24.0"
+}
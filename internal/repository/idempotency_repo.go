@@ -0,0 +1,65 @@
+// Package repository 提供数据访问层
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// IdempotencyRepository 幂等键仓储
+type IdempotencyRepository struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyRepository 创建幂等键仓储
+func NewIdempotencyRepository(db *gorm.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// CreateIfAbsent 在事务中插入幂等键，键已存在时不做任何修改
+// 返回 true 表示本次插入成功（首次请求）
+func (r *IdempotencyRepository) CreateIfAbsent(ctx context.Context, tx *gorm.DB, record *models.IdempotencyKey) (bool, error) {
+	result := tx.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetByUserAndKey 根据用户和幂等键获取记录
+func (r *IdempotencyRepository) GetByUserAndKey(ctx context.Context, tx *gorm.DB, userID int64, key string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	err := tx.WithContext(ctx).
+		Where("user_id = ? AND idempotency_key = ?", userID, key).
+		First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// SaveResponse 保存响应快照
+func (r *IdempotencyRepository) SaveResponse(ctx context.Context, tx *gorm.DB, id int64, snapshot json.RawMessage) error {
+	return tx.WithContext(ctx).Model(&models.IdempotencyKey{}).
+		Where("id = ?", id).
+		Update("response_snapshot", snapshot).Error
+}
+
+// Delete 删除幂等键
+func (r *IdempotencyRepository) Delete(ctx context.Context, tx *gorm.DB, id int64) error {
+	return tx.WithContext(ctx).Delete(&models.IdempotencyKey{}, id).Error
+}
+
+// DeleteExpired 清理过期的幂等键
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
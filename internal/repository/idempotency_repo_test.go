@@ -0,0 +1,101 @@
+// Package repository 幂等键仓储单元测试
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// setupIdempotencyTestDB 创建幂等键测试数据库
+func setupIdempotencyTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.AutoMigrate(&models.IdempotencyKey{}))
+	return db
+}
+
+func newTestIdempotencyKey(userID int64, key string, expiresAt time.Time) *models.IdempotencyKey {
+	return &models.IdempotencyKey{
+		Key:         key,
+		UserID:      userID,
+		Scope:       models.IdempotencyScopeRentalPay,
+		RequestHash: "hash",
+		ExpiresAt:   expiresAt,
+	}
+}
+
+func TestIdempotencyRepository_CreateIfAbsent(t *testing.T) {
+	db := setupIdempotencyTestDB(t)
+	repo := NewIdempotencyRepository(db)
+	ctx := context.Background()
+
+	created, err := repo.CreateIfAbsent(ctx, db, newTestIdempotencyKey(1, "key-1", time.Now().Add(time.Hour)))
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	t.Run("同用户同键不重复插入", func(t *testing.T) {
+		created, err := repo.CreateIfAbsent(ctx, db, newTestIdempotencyKey(1, "key-1", time.Now().Add(time.Hour)))
+		require.NoError(t, err)
+		assert.False(t, created)
+
+		var count int64
+		db.Model(&models.IdempotencyKey{}).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("不同用户可使用相同键", func(t *testing.T) {
+		created, err := repo.CreateIfAbsent(ctx, db, newTestIdempotencyKey(2, "key-1", time.Now().Add(time.Hour)))
+		require.NoError(t, err)
+		assert.True(t, created)
+	})
+}
+
+func TestIdempotencyRepository_GetAndSaveResponse(t *testing.T) {
+	db := setupIdempotencyTestDB(t)
+	repo := NewIdempotencyRepository(db)
+	ctx := context.Background()
+
+	record := newTestIdempotencyKey(1, "key-1", time.Now().Add(time.Hour))
+	_, err := repo.CreateIfAbsent(ctx, db, record)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.SaveResponse(ctx, db, record.ID, json.RawMessage(`{"payment_no":"P1"}`)))
+
+	found, err := repo.GetByUserAndKey(ctx, db, 1, "key-1")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"payment_no":"P1"}`, string(found.ResponseSnapshot))
+
+	_, err = repo.GetByUserAndKey(ctx, db, 2, "key-1")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestIdempotencyRepository_DeleteExpired(t *testing.T) {
+	db := setupIdempotencyTestDB(t)
+	repo := NewIdempotencyRepository(db)
+	ctx := context.Background()
+
+	_, err := repo.CreateIfAbsent(ctx, db, newTestIdempotencyKey(1, "expired", time.Now().Add(-time.Hour)))
+	require.NoError(t, err)
+	_, err = repo.CreateIfAbsent(ctx, db, newTestIdempotencyKey(1, "valid", time.Now().Add(time.Hour)))
+	require.NoError(t, err)
+
+	deleted, err := repo.DeleteExpired(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = repo.GetByUserAndKey(ctx, db, 1, "valid")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,76 @@
+// Package repository 提供数据访问层
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// MerchantAPIKeyRepository 商户开放接口密钥仓储
+type MerchantAPIKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewMerchantAPIKeyRepository 创建商户开放接口密钥仓储
+func NewMerchantAPIKeyRepository(db *gorm.DB) *MerchantAPIKeyRepository {
+	return &MerchantAPIKeyRepository{db: db}
+}
+
+// Create 创建密钥
+func (r *MerchantAPIKeyRepository) Create(ctx context.Context, key *models.MerchantAPIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// GetByID 根据 ID 获取密钥
+func (r *MerchantAPIKeyRepository) GetByID(ctx context.Context, id int64) (*models.MerchantAPIKey, error) {
+	var key models.MerchantAPIKey
+	if err := r.db.WithContext(ctx).First(&key, id).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByKeyID 根据密钥标识获取密钥
+func (r *MerchantAPIKeyRepository) GetByKeyID(ctx context.Context, keyID string) (*models.MerchantAPIKey, error) {
+	var key models.MerchantAPIKey
+	if err := r.db.WithContext(ctx).Where("key_id = ?", keyID).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListByMerchant 获取商户的全部密钥
+func (r *MerchantAPIKeyRepository) ListByMerchant(ctx context.Context, merchantID int64) ([]*models.MerchantAPIKey, error) {
+	var keys []*models.MerchantAPIKey
+	err := r.db.WithContext(ctx).
+		Where("merchant_id = ?", merchantID).
+		Order("id DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// UpdateFields 更新指定字段
+func (r *MerchantAPIKeyRepository) UpdateFields(ctx context.Context, id int64, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&models.MerchantAPIKey{}).Where("id = ?", id).Updates(fields).Error
+}
+
+// Revoke 吊销密钥，已吊销的密钥不受影响
+func (r *MerchantAPIKeyRepository) Revoke(ctx context.Context, id int64, revokedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.MerchantAPIKey{}).
+		Where("id = ? AND status = ?", id, models.MerchantAPIKeyStatusActive).
+		Updates(map[string]interface{}{
+			"status":     models.MerchantAPIKeyStatusRevoked,
+			"revoked_at": revokedAt,
+		}).Error
+}
+
+// TouchLastUsed 记录密钥最近使用时间
+func (r *MerchantAPIKeyRepository) TouchLastUsed(ctx context.Context, id int64, usedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.MerchantAPIKey{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", usedAt).Error
+}
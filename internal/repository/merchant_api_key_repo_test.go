@@ -0,0 +1,95 @@
+// Package repository 商户开放接口密钥仓储单元测试
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+func setupMerchantAPIKeyTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.AutoMigrate(&models.MerchantAPIKey{}))
+	return db
+}
+
+func createTestMerchantAPIKey(t *testing.T, db *gorm.DB, merchantID int64, keyID string) *models.MerchantAPIKey {
+	key := &models.MerchantAPIKey{
+		MerchantID:      merchantID,
+		KeyID:           keyID,
+		SecretEncrypted: "encrypted",
+		Scopes:          "venues,devices",
+		Status:          models.MerchantAPIKeyStatusActive,
+	}
+	require.NoError(t, db.Create(key).Error)
+	return key
+}
+
+func TestMerchantAPIKeyRepository_GetByKeyID(t *testing.T) {
+	db := setupMerchantAPIKeyTestDB(t)
+	repo := NewMerchantAPIKeyRepository(db)
+	ctx := context.Background()
+
+	key := createTestMerchantAPIKey(t, db, 1, "mk_test")
+
+	found, err := repo.GetByKeyID(ctx, "mk_test")
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, found.ID)
+	assert.Equal(t, []string{"venues", "devices"}, found.ScopeList())
+	assert.True(t, found.HasScope(models.MerchantAPIScopeDevices))
+	assert.False(t, found.HasScope(models.MerchantAPIScopeRentals))
+
+	_, err = repo.GetByKeyID(ctx, "mk_missing")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestMerchantAPIKeyRepository_ListByMerchant(t *testing.T) {
+	db := setupMerchantAPIKeyTestDB(t)
+	repo := NewMerchantAPIKeyRepository(db)
+	ctx := context.Background()
+
+	createTestMerchantAPIKey(t, db, 1, "mk_a")
+	createTestMerchantAPIKey(t, db, 1, "mk_b")
+	createTestMerchantAPIKey(t, db, 2, "mk_c")
+
+	keys, err := repo.ListByMerchant(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "mk_b", keys[0].KeyID)
+}
+
+func TestMerchantAPIKeyRepository_RevokeAndTouch(t *testing.T) {
+	db := setupMerchantAPIKeyTestDB(t)
+	repo := NewMerchantAPIKeyRepository(db)
+	ctx := context.Background()
+
+	key := createTestMerchantAPIKey(t, db, 1, "mk_test")
+	now := time.Now()
+
+	require.NoError(t, repo.TouchLastUsed(ctx, key.ID, now))
+	require.NoError(t, repo.Revoke(ctx, key.ID, now))
+
+	found, err := repo.GetByID(ctx, key.ID)
+	require.NoError(t, err)
+	assert.False(t, found.IsActive())
+	assert.NotNil(t, found.RevokedAt)
+	assert.NotNil(t, found.LastUsedAt)
+
+	// 重复吊销不覆盖吊销时间
+	require.NoError(t, repo.Revoke(ctx, key.ID, now.Add(time.Hour)))
+	again, err := repo.GetByID(ctx, key.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, *found.RevokedAt, *again.RevokedAt, time.Second)
+}
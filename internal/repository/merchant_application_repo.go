@@ -0,0 +1,81 @@
+// Package repository 提供数据访问层
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// MerchantApplicationRepository 商户入驻申请仓储
+type MerchantApplicationRepository struct {
+	db *gorm.DB
+}
+
+// NewMerchantApplicationRepository 创建商户入驻申请仓储
+func NewMerchantApplicationRepository(db *gorm.DB) *MerchantApplicationRepository {
+	return &MerchantApplicationRepository{db: db}
+}
+
+// Create 创建申请
+func (r *MerchantApplicationRepository) Create(ctx context.Context, app *models.MerchantApplication) error {
+	return r.db.WithContext(ctx).Create(app).Error
+}
+
+// GetByID 根据 ID 获取申请
+func (r *MerchantApplicationRepository) GetByID(ctx context.Context, id int64) (*models.MerchantApplication, error) {
+	var app models.MerchantApplication
+	if err := r.db.WithContext(ctx).First(&app, id).Error; err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// GetByUserID 获取用户的申请
+func (r *MerchantApplicationRepository) GetByUserID(ctx context.Context, userID int64) (*models.MerchantApplication, error) {
+	var app models.MerchantApplication
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&app).Error; err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// Update 保存申请
+func (r *MerchantApplicationRepository) Update(ctx context.Context, app *models.MerchantApplication) error {
+	return r.db.WithContext(ctx).Save(app).Error
+}
+
+// MerchantApplicationListFilters 申请列表筛选条件
+type MerchantApplicationListFilters struct {
+	Status  string
+	Keyword string // 商户名称或联系电话
+}
+
+// List 获取申请列表
+func (r *MerchantApplicationRepository) List(ctx context.Context, offset, limit int, filters *MerchantApplicationListFilters) ([]*models.MerchantApplication, int64, error) {
+	var apps []*models.MerchantApplication
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.MerchantApplication{})
+
+	if filters != nil {
+		if filters.Status != "" {
+			query = query.Where("status = ?", filters.Status)
+		}
+		if filters.Keyword != "" {
+			query = query.Where("merchant_name LIKE ? OR contact_phone LIKE ?", "%"+filters.Keyword+"%", "%"+filters.Keyword+"%")
+		}
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&apps).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return apps, total, nil
+}
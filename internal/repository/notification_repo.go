@@ -201,3 +201,80 @@ func (r *NotificationRepository) CreateUserNotification(ctx context.Context, use
 	}
 	return r.Create(ctx, notification)
 }
+
+// CreateForActiveAdmins 为所有启用状态的管理员创建通知，返回通知的管理员数
+func (r *NotificationRepository) CreateForActiveAdmins(ctx context.Context, notificationType, title, content string, link *string) (int, error) {
+	var adminIDs []int64
+	if err := r.db.WithContext(ctx).Model(&models.Admin{}).
+		Where("status = ?", models.AdminStatusActive).
+		Pluck("id", &adminIDs).Error; err != nil {
+		return 0, err
+	}
+	if len(adminIDs) == 0 {
+		return 0, nil
+	}
+
+	notifications := make([]*models.AdminNotification, len(adminIDs))
+	for i, adminID := range adminIDs {
+		notifications[i] = &models.AdminNotification{
+			AdminID: adminID,
+			Type:    notificationType,
+			Title:   title,
+			Content: content,
+			Link:    link,
+		}
+	}
+	if err := r.db.WithContext(ctx).Create(&notifications).Error; err != nil {
+		return 0, err
+	}
+	return len(notifications), nil
+}
+
+// ListAdminNotifications 获取管理员的通知列表，isRead 为 nil 时不按已读状态筛选
+func (r *NotificationRepository) ListAdminNotifications(ctx context.Context, adminID int64, offset, limit int, isRead *bool) ([]*models.AdminNotification, int64, error) {
+	var notifications []*models.AdminNotification
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.AdminNotification{}).Where("admin_id = ?", adminID)
+	if isRead != nil {
+		query = query.Where("is_read = ?", *isRead)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+// MarkAdminNotificationAsRead 标记管理员通知为已读，返回受影响行数（通知不存在或不属于该管理员时为 0）
+func (r *NotificationRepository) MarkAdminNotificationAsRead(ctx context.Context, id, adminID int64) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.AdminNotification{}).
+		Where("id = ? AND admin_id = ?", id, adminID).
+		Updates(map[string]interface{}{
+			"is_read": true,
+			"read_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// MarkAllAdminNotificationsAsRead 标记管理员的所有未读通知为已读
+func (r *NotificationRepository) MarkAllAdminNotificationsAsRead(ctx context.Context, adminID int64) error {
+	return r.db.WithContext(ctx).Model(&models.AdminNotification{}).
+		Where("admin_id = ? AND is_read = ?", adminID, false).
+		Updates(map[string]interface{}{
+			"is_read": true,
+			"read_at": time.Now(),
+		}).Error
+}
+
+// CountAdminUnread 统计管理员的未读通知数量
+func (r *NotificationRepository) CountAdminUnread(ctx context.Context, adminID int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.AdminNotification{}).
+		Where("admin_id = ? AND is_read = ?", adminID, false).
+		Count(&count).Error
+	return count, err
+}
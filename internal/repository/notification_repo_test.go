@@ -327,3 +327,36 @@ func TestNotificationRepository_CreateUserNotification(t *testing.T) {
 	assert.NotNil(t, notification.Link)
 	assert.Equal(t, link, *notification.Link)
 }
+
+func TestNotificationRepository_CreateForActiveAdmins(t *testing.T) {
+	db := setupNotificationTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Admin{}, &models.AdminNotification{}))
+	repo := NewNotificationRepository(db)
+	ctx := context.Background()
+
+	t.Run("没有管理员", func(t *testing.T) {
+		count, err := repo.CreateForActiveAdmins(ctx, models.NotificationTypeDevice, "标题", "内容", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	active := &models.Admin{Username: "ops", PasswordHash: "hash", Name: "运维", RoleID: 1, Status: models.AdminStatusActive}
+	disabled := &models.Admin{Username: "old", PasswordHash: "hash", Name: "离职", RoleID: 1, Status: models.AdminStatusActive}
+	require.NoError(t, db.Create(active).Error)
+	require.NoError(t, db.Create(disabled).Error)
+	require.NoError(t, db.Model(disabled).Update("status", models.AdminStatusDisabled).Error)
+
+	t.Run("仅通知启用的管理员", func(t *testing.T) {
+		link := "/devices/1"
+		count, err := repo.CreateForActiveAdmins(ctx, models.NotificationTypeDevice, "设备进入维护模式", "设备维护中", &link)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		var notifications []models.AdminNotification
+		require.NoError(t, db.Find(&notifications).Error)
+		require.Len(t, notifications, 1)
+		assert.Equal(t, active.ID, notifications[0].AdminID)
+		assert.Equal(t, models.NotificationTypeDevice, notifications[0].Type)
+		assert.False(t, notifications[0].IsRead)
+	})
+}
@@ -25,6 +25,14 @@ func (r *OperationLogRepository) Create(ctx context.Context, log *models.Operati
 	return r.db.WithContext(ctx).Create(log).Error
 }
 
+// CreateBatch 批量创建操作日志
+func (r *OperationLogRepository) CreateBatch(ctx context.Context, logs []*models.OperationLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(logs, 100).Error
+}
+
 // GetByID 根据 ID 获取操作日志
 func (r *OperationLogRepository) GetByID(ctx context.Context, id int64) (*models.OperationLog, error) {
 	var log models.OperationLog
@@ -58,6 +66,9 @@ func (r *OperationLogRepository) List(ctx context.Context, offset, limit int, fi
 	if targetID, ok := filters["target_id"].(int64); ok && targetID > 0 {
 		query = query.Where("target_id = ?", targetID)
 	}
+	if route, ok := filters["route_prefix"].(string); ok && route != "" {
+		query = query.Where("route LIKE ?", route+"%")
+	}
 	if ip, ok := filters["ip"].(string); ok && ip != "" {
 		query = query.Where("ip = ?", ip)
 	}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// OrderNoteRepository 订单备注仓储
+type OrderNoteRepository struct {
+	db *gorm.DB
+}
+
+// NewOrderNoteRepository 创建订单备注仓储
+func NewOrderNoteRepository(db *gorm.DB) *OrderNoteRepository {
+	return &OrderNoteRepository{db: db}
+}
+
+// Create 创建订单备注
+func (r *OrderNoteRepository) Create(ctx context.Context, note *models.OrderNote) error {
+	return r.db.WithContext(ctx).Create(note).Error
+}
+
+// ListByOrder 按时间倒序分页获取订单备注，visibleOnly 为 true 时只返回用户可见的备注
+func (r *OrderNoteRepository) ListByOrder(ctx context.Context, orderID int64, visibleOnly bool, offset, limit int) ([]*models.OrderNote, int64, error) {
+	var notes []*models.OrderNote
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.OrderNote{}).Where("order_id = ?", orderID)
+	if visibleOnly {
+		query = query.Where("visible_to_user = ?", true)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&notes).Error; err != nil {
+		return nil, 0, err
+	}
+	return notes, total, nil
+}
+
+// GetLatest 获取订单最新的一条备注，没有备注时返回 nil
+func (r *OrderNoteRepository) GetLatest(ctx context.Context, orderID int64, visibleOnly bool) (*models.OrderNote, error) {
+	var notes []*models.OrderNote
+	query := r.db.WithContext(ctx).Where("order_id = ?", orderID)
+	if visibleOnly {
+		query = query.Where("visible_to_user = ?", true)
+	}
+	if err := query.Order("id DESC").Limit(1).Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	if len(notes) == 0 {
+		return nil, nil
+	}
+	return notes[0], nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// OrderQueryBuilder 订单查询构造器
+// 统一订单查询的筛选条件，除 WithUserID 外各方法传入零值时不追加对应条件
+type OrderQueryBuilder struct {
+	db *gorm.DB
+}
+
+// NewOrderQueryBuilder 基于 db 创建订单查询构造器
+func NewOrderQueryBuilder(db *gorm.DB) *OrderQueryBuilder {
+	return &OrderQueryBuilder{db: db.Model(&models.Order{})}
+}
+
+// WithUserID 按用户筛选，用户端查询使用，始终追加条件，userID 无效时查不到任何订单
+func (b *OrderQueryBuilder) WithUserID(userID int64) *OrderQueryBuilder {
+	b.db = b.db.Where("user_id = ?", userID)
+	return b
+}
+
+// WithOptionalUserID 按用户筛选，userID <= 0 时不筛选，仅用于管理端查询
+func (b *OrderQueryBuilder) WithOptionalUserID(userID int64) *OrderQueryBuilder {
+	if userID > 0 {
+		b.db = b.db.Where("user_id = ?", userID)
+	}
+	return b
+}
+
+// WithStatus 按订单状态筛选
+func (b *OrderQueryBuilder) WithStatus(status string) *OrderQueryBuilder {
+	if status != "" {
+		b.db = b.db.Where("status = ?", status)
+	}
+	return b
+}
+
+// WithType 按订单类型筛选
+func (b *OrderQueryBuilder) WithType(orderType string) *OrderQueryBuilder {
+	if orderType != "" {
+		b.db = b.db.Where("type = ?", orderType)
+	}
+	return b
+}
+
+// WithOrderNo 按订单号精确匹配
+func (b *OrderQueryBuilder) WithOrderNo(orderNo string) *OrderQueryBuilder {
+	if orderNo != "" {
+		b.db = b.db.Where("order_no = ?", orderNo)
+	}
+	return b
+}
+
+// WithOrderNoLike 按订单号模糊匹配
+func (b *OrderQueryBuilder) WithOrderNoLike(orderNo string) *OrderQueryBuilder {
+	if orderNo != "" {
+		b.db = b.db.Where("order_no LIKE ?", "%"+orderNo+"%")
+	}
+	return b
+}
+
+// WithDateRange 按创建时间筛选，start / end 均为闭区间，为 nil 时不限制
+func (b *OrderQueryBuilder) WithDateRange(start, end *time.Time) *OrderQueryBuilder {
+	if start != nil {
+		b.db = b.db.Where("created_at >= ?", *start)
+	}
+	if end != nil {
+		b.db = b.db.Where("created_at <= ?", *end)
+	}
+	return b
+}
+
+// WithFilters 按 filters 追加筛选条件
+// 支持的键：user_id (int64)、type、status、order_no（模糊匹配）(string)、
+// start_date / end_date (time.Time 或 *time.Time)
+func (b *OrderQueryBuilder) WithFilters(filters map[string]interface{}) *OrderQueryBuilder {
+	userID, _ := filters["user_id"].(int64)
+	orderType, _ := filters["type"].(string)
+	status, _ := filters["status"].(string)
+	orderNo, _ := filters["order_no"].(string)
+
+	return b.WithOptionalUserID(userID).
+		WithType(orderType).
+		WithStatus(status).
+		WithOrderNoLike(orderNo).
+		WithDateRange(filterTime(filters, "start_date"), filterTime(filters, "end_date"))
+}
+
+// OrderByCreatedDesc 按创建时间倒序，创建时间相同时按 ID 倒序
+func (b *OrderQueryBuilder) OrderByCreatedDesc() *OrderQueryBuilder {
+	b.db = b.db.Order("created_at DESC").Order("id DESC")
+	return b
+}
+
+// Build 返回构造好的查询
+func (b *OrderQueryBuilder) Build() *gorm.DB {
+	return b.db
+}
+
+// filterTime 读取 filters 中的时间条件，兼容 time.Time 与 *time.Time
+func filterTime(filters map[string]interface{}, key string) *time.Time {
+	switch v := filters[key].(type) {
+	case time.Time:
+		return &v
+	case *time.Time:
+		return v
+	}
+	return nil
+}
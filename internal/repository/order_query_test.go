@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+func TestOrderQueryBuilder_SameSQLAsHandWritten(t *testing.T) {
+	db := setupOrderTestDB(t)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+	end := time.Date(2026, 1, 31, 23, 59, 59, 0, time.Local)
+
+	tests := []struct {
+		name        string
+		builder     func(tx *gorm.DB) *gorm.DB
+		handWritten func(tx *gorm.DB) *gorm.DB
+	}{
+		{
+			name: "用户、类型、状态",
+			builder: func(tx *gorm.DB) *gorm.DB {
+				return NewOrderQueryBuilder(tx).WithUserID(7).WithType(models.OrderTypeMall).WithStatus(models.OrderStatusPaid).Build()
+			},
+			handWritten: func(tx *gorm.DB) *gorm.DB {
+				return tx.Model(&models.Order{}).Where("user_id = ?", 7).
+					Where("type = ?", models.OrderTypeMall).
+					Where("status = ?", models.OrderStatusPaid)
+			},
+		},
+		{
+			name: "管理端全部筛选条件",
+			builder: func(tx *gorm.DB) *gorm.DB {
+				return NewOrderQueryBuilder(tx).WithFilters(map[string]interface{}{
+					"user_id":    int64(7),
+					"type":       models.OrderTypeRental,
+					"status":     models.OrderStatusCompleted,
+					"order_no":   "R2026",
+					"start_date": start,
+					"end_date":   end,
+				}).Build()
+			},
+			handWritten: func(tx *gorm.DB) *gorm.DB {
+				return tx.Model(&models.Order{}).Where("user_id = ?", int64(7)).
+					Where("type = ?", models.OrderTypeRental).
+					Where("status = ?", models.OrderStatusCompleted).
+					Where("order_no LIKE ?", "%R2026%").
+					Where("created_at >= ?", start).
+					Where("created_at <= ?", end)
+			},
+		},
+		{
+			name: "指针类型的时间范围",
+			builder: func(tx *gorm.DB) *gorm.DB {
+				return NewOrderQueryBuilder(tx).WithUserID(7).WithFilters(map[string]interface{}{
+					"start_date": &start,
+					"end_date":   (*time.Time)(nil),
+				}).Build()
+			},
+			handWritten: func(tx *gorm.DB) *gorm.DB {
+				query := tx.Model(&models.Order{}).Where("user_id = ?", 7)
+				return applyCreatedAtRange(query, map[string]interface{}{"start_date": &start})
+			},
+		},
+		{
+			name: "零值条件不追加筛选",
+			builder: func(tx *gorm.DB) *gorm.DB {
+				return NewOrderQueryBuilder(tx).WithOptionalUserID(0).WithType("").WithStatus("").WithOrderNoLike("").WithDateRange(nil, nil).Build()
+			},
+			handWritten: func(tx *gorm.DB) *gorm.DB {
+				return tx.Model(&models.Order{})
+			},
+		},
+		{
+			name: "用户端查询始终按用户筛选",
+			builder: func(tx *gorm.DB) *gorm.DB {
+				return NewOrderQueryBuilder(tx).WithUserID(0).Build()
+			},
+			handWritten: func(tx *gorm.DB) *gorm.DB {
+				return tx.Model(&models.Order{}).Where("user_id = ?", 0)
+			},
+		},
+		{
+			name: "按创建时间倒序与游标分页排序一致",
+			builder: func(tx *gorm.DB) *gorm.DB {
+				return NewOrderQueryBuilder(tx).WithUserID(7).OrderByCreatedDesc().Build().Limit(10)
+			},
+			handWritten: func(tx *gorm.DB) *gorm.DB {
+				return cursorPage(tx.Model(&models.Order{}).Where("user_id = ?", 7), nil, 10)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return tt.builder(tx).Find(&[]*models.Order{})
+			})
+			want := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return tt.handWritten(tx).Find(&[]*models.Order{})
+			})
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestOrderQueryBuilder_FilterCombinations(t *testing.T) {
+	db := setupOrderTestDB(t)
+	repo := NewOrderRepository(db)
+	ctx := context.Background()
+
+	alice := createOrderTestUser(t, db, "13800138031")
+	bob := createOrderTestUser(t, db, "13800138032")
+
+	now := time.Now()
+	day := 24 * time.Hour
+	create := func(userID int64, orderNo, orderType, status string, createdAt time.Time) {
+		order := &models.Order{
+			OrderNo:        orderNo,
+			UserID:         userID,
+			Type:           orderType,
+			OriginalAmount: 10,
+			ActualAmount:   10,
+			Status:         status,
+			CreatedAt:      createdAt,
+		}
+		require.NoError(t, db.Create(order).Error)
+	}
+	create(alice.ID, "A_MALL_PAID_OLD", models.OrderTypeMall, models.OrderStatusPaid, now.Add(-10*day))
+	create(alice.ID, "A_MALL_PAID_NEW", models.OrderTypeMall, models.OrderStatusPaid, now.Add(-1*day))
+	create(alice.ID, "A_MALL_PENDING", models.OrderTypeMall, models.OrderStatusPending, now.Add(-2*day))
+	create(alice.ID, "A_RENTAL_PAID", models.OrderTypeRental, models.OrderStatusPaid, now.Add(-3*day))
+	create(bob.ID, "B_MALL_PAID", models.OrderTypeMall, models.OrderStatusPaid, now.Add(-1*day))
+
+	orderNos := func(orders []*models.Order) []string {
+		nos := make([]string, len(orders))
+		for i, o := range orders {
+			nos[i] = o.OrderNo
+		}
+		return nos
+	}
+
+	t.Run("用户、类型、状态组合", func(t *testing.T) {
+		orders, total, err := repo.ListByUser(ctx, alice.ID, 0, 10, models.OrderTypeMall, models.OrderStatusPaid)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Equal(t, []string{"A_MALL_PAID_NEW", "A_MALL_PAID_OLD"}, orderNos(orders))
+	})
+
+	t.Run("时间范围与类型组合", func(t *testing.T) {
+		since := now.Add(-5 * day)
+		orders, total, err := repo.ListByUserID(ctx, alice.ID, 0, 10, map[string]interface{}{
+			"type":       models.OrderTypeMall,
+			"start_date": &since,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Equal(t, []string{"A_MALL_PAID_NEW", "A_MALL_PENDING"}, orderNos(orders))
+	})
+
+	t.Run("管理端跨用户组合筛选", func(t *testing.T) {
+		until := now.Add(-day / 2)
+		orders, total, err := repo.List(ctx, 0, 10, map[string]interface{}{
+			"type":       models.OrderTypeMall,
+			"status":     models.OrderStatusPaid,
+			"order_no":   "PAID",
+			"start_date": now.Add(-5 * day),
+			"end_date":   until,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.ElementsMatch(t, []string{"A_MALL_PAID_NEW", "B_MALL_PAID"}, orderNos(orders))
+	})
+
+	t.Run("游标分页沿用相同筛选", func(t *testing.T) {
+		filters := map[string]interface{}{"status": models.OrderStatusPaid}
+		first, err := repo.ListByUserIDCursor(ctx, alice.ID, nil, 2, filters)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"A_MALL_PAID_NEW", "A_RENTAL_PAID"}, orderNos(first))
+
+		last := first[len(first)-1]
+		next, err := repo.ListByUserIDCursor(ctx, alice.ID, &utils.Cursor{ID: last.ID, CreatedAt: last.CreatedAt}, 2, filters)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"A_MALL_PAID_OLD"}, orderNos(next))
+	})
+
+	t.Run("用户端查询缺少用户时不返回其他用户订单", func(t *testing.T) {
+		orders, total, err := repo.ListByUser(ctx, 0, 0, 10, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+		assert.Empty(t, orders)
+
+		orders, total, err = repo.ListByUserID(ctx, 0, 0, 10, map[string]interface{}{"user_id": bob.ID})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+		assert.Empty(t, orders)
+
+		orders, err = repo.ListByUserIDCursor(ctx, 0, nil, 10, nil)
+		require.NoError(t, err)
+		assert.Empty(t, orders)
+	})
+
+	t.Run("按状态统计", func(t *testing.T) {
+		counts, err := repo.CountByStatus(ctx, alice.ID)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int64{models.OrderStatusPaid: 3, models.OrderStatusPending: 1}, counts)
+	})
+
+	t.Run("精确订单号", func(t *testing.T) {
+		order, err := repo.GetByOrderNo(ctx, "B_MALL_PAID")
+		require.NoError(t, err)
+		assert.Equal(t, bob.ID, order.UserID)
+
+		_, err = repo.GetByOrderNo(ctx, "B_MALL_PAID_MISSING")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	"gorm.io/gorm"
 
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 )
 
@@ -20,6 +21,11 @@ func NewOrderRepository(db *gorm.DB) *OrderRepository {
 	return &OrderRepository{db: db}
 }
 
+// query 创建订单查询构造器
+func (r *OrderRepository) query(ctx context.Context) *OrderQueryBuilder {
+	return NewOrderQueryBuilder(r.db.WithContext(ctx))
+}
+
 // Create 创建订单
 func (r *OrderRepository) Create(ctx context.Context, order *models.Order) error {
 	return r.db.WithContext(ctx).Create(order).Error
@@ -51,7 +57,7 @@ func (r *OrderRepository) GetByIDWithItems(ctx context.Context, id int64) (*mode
 // GetByOrderNo 根据订单号获取订单
 func (r *OrderRepository) GetByOrderNo(ctx context.Context, orderNo string) (*models.Order, error) {
 	var order models.Order
-	err := r.db.WithContext(ctx).Where("order_no = ?", orderNo).First(&order).Error
+	err := r.query(ctx).WithOrderNo(orderNo).Build().First(&order).Error
 	if err != nil {
 		return nil, err
 	}
@@ -69,70 +75,36 @@ func (r *OrderRepository) UpdateFields(ctx context.Context, id int64, fields map
 }
 
 // UpdateStatus 更新订单状态
-func (r *OrderRepository) UpdateStatus(ctx context.Context, id int64, status int8) error {
+func (r *OrderRepository) UpdateStatus(ctx context.Context, id int64, status string) error {
 	return r.db.WithContext(ctx).Model(&models.Order{}).Where("id = ?", id).Update("status", status).Error
 }
 
 // ListByUser 获取用户订单列表
-func (r *OrderRepository) ListByUser(ctx context.Context, userID int64, offset, limit int, orderType string, status *int8) ([]*models.Order, int64, error) {
-	var orders []*models.Order
-	var total int64
-
-	query := r.db.WithContext(ctx).Model(&models.Order{}).Where("user_id = ?", userID)
-
-	if orderType != "" {
-		query = query.Where("type = ?", orderType)
-	}
-	if status != nil {
-		query = query.Where("status = ?", *status)
-	}
-
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	if err := query.Preload("Items").
-		Order("id DESC").Offset(offset).Limit(limit).
-		Find(&orders).Error; err != nil {
-		return nil, 0, err
-	}
-
-	return orders, total, nil
+func (r *OrderRepository) ListByUser(ctx context.Context, userID int64, offset, limit int, orderType, status string) ([]*models.Order, int64, error) {
+	query := r.query(ctx).WithUserID(userID).WithType(orderType).WithStatus(status)
+	return r.listPage(query, offset, limit, "Items")
 }
 
 // List 获取订单列表（管理端）
+// 支持的筛选条件见 OrderQueryBuilder.WithFilters
 func (r *OrderRepository) List(ctx context.Context, offset, limit int, filters map[string]interface{}) ([]*models.Order, int64, error) {
+	return r.listPage(r.query(ctx).WithFilters(filters), offset, limit, "User", "Items")
+}
+
+// listPage 统计总数并按创建时间倒序分页查询，preloads 为需要预加载的关联
+func (r *OrderRepository) listPage(query *OrderQueryBuilder, offset, limit int, preloads ...string) ([]*models.Order, int64, error) {
 	var orders []*models.Order
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&models.Order{})
-
-	if userID, ok := filters["user_id"].(int64); ok && userID > 0 {
-		query = query.Where("user_id = ?", userID)
-	}
-	if orderType, ok := filters["type"].(string); ok && orderType != "" {
-		query = query.Where("type = ?", orderType)
-	}
-	if status, ok := filters["status"].(int8); ok {
-		query = query.Where("status = ?", status)
-	}
-	if orderNo, ok := filters["order_no"].(string); ok && orderNo != "" {
-		query = query.Where("order_no LIKE ?", "%"+orderNo+"%")
-	}
-	if startDate, ok := filters["start_date"].(time.Time); ok {
-		query = query.Where("created_at >= ?", startDate)
-	}
-	if endDate, ok := filters["end_date"].(time.Time); ok {
-		query = query.Where("created_at <= ?", endDate)
-	}
-
-	if err := query.Count(&total).Error; err != nil {
+	if err := query.Build().Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	if err := query.Preload("User").Preload("Items").
-		Order("id DESC").Offset(offset).Limit(limit).
-		Find(&orders).Error; err != nil {
+	db := query.OrderByCreatedDesc().Build()
+	for _, preload := range preloads {
+		db = db.Preload(preload)
+	}
+	if err := db.Offset(offset).Limit(limit).Find(&orders).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -142,10 +114,10 @@ func (r *OrderRepository) List(ctx context.Context, offset, limit int, filters m
 // GetExpiredPending 获取过期的待支付订单
 func (r *OrderRepository) GetExpiredPending(ctx context.Context, limit int) ([]*models.Order, error) {
 	var orders []*models.Order
-	now := time.Now()
-	err := r.db.WithContext(ctx).
-		Where("status = ?", models.OrderStatusPending).
-		Where("expired_at < ?", now).
+	err := r.query(ctx).
+		WithStatus(models.OrderStatusPending).
+		Build().
+		Where("expired_at < ?", time.Now()).
 		Limit(limit).
 		Find(&orders).Error
 	return orders, err
@@ -171,27 +143,23 @@ func (r *OrderRepository) CreateOrderItems(ctx context.Context, items []*models.
 	return r.db.WithContext(ctx).Create(&items).Error
 }
 
-// CountByStatus 统计各状态订单数量
-func (r *OrderRepository) CountByStatus(ctx context.Context, userID int64) (map[int8]int64, error) {
+// CountByStatus 统计各状态订单数量，userID <= 0 时统计全部订单
+func (r *OrderRepository) CountByStatus(ctx context.Context, userID int64) (map[string]int64, error) {
 	type Result struct {
-		Status int8
+		Status string
 		Count  int64
 	}
 
 	var results []Result
-	query := r.db.WithContext(ctx).Model(&models.Order{}).
-		Select("status, count(*) as count")
-
-	if userID > 0 {
-		query = query.Where("user_id = ?", userID)
-	}
-
-	err := query.Group("status").Find(&results).Error
+	err := r.query(ctx).WithOptionalUserID(userID).Build().
+		Select("status, count(*) as count").
+		Group("status").
+		Find(&results).Error
 	if err != nil {
 		return nil, err
 	}
 
-	counts := make(map[int8]int64)
+	counts := make(map[string]int64)
 	for _, r := range results {
 		counts[r.Status] = r.Count
 	}
@@ -199,30 +167,23 @@ func (r *OrderRepository) CountByStatus(ctx context.Context, userID int64) (map[
 }
 
 // ListByUserID 获取用户订单列表（支持字符串过滤）
+// 支持的筛选条件见 OrderQueryBuilder.WithFilters
 func (r *OrderRepository) ListByUserID(ctx context.Context, userID int64, offset, limit int, filters map[string]interface{}) ([]*models.Order, int64, error) {
-	var orders []*models.Order
-	var total int64
-
-	query := r.db.WithContext(ctx).Model(&models.Order{}).Where("user_id = ?", userID)
-
-	if orderType, ok := filters["type"].(string); ok && orderType != "" {
-		query = query.Where("type = ?", orderType)
-	}
-	if status, ok := filters["status"].(string); ok && status != "" {
-		query = query.Where("status = ?", status)
-	}
+	query := r.query(ctx).WithUserID(userID).WithFilters(filters)
+	return r.listPage(query, offset, limit, "Items")
+}
 
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
+// ListByUserIDCursor 游标分页获取用户订单列表
+// 支持的筛选条件见 OrderQueryBuilder.WithFilters
+func (r *OrderRepository) ListByUserIDCursor(ctx context.Context, userID int64, cursor *utils.Cursor, limit int, filters map[string]interface{}) ([]*models.Order, error) {
+	var orders []*models.Order
 
-	if err := query.Preload("Items").
-		Order("id DESC").Offset(offset).Limit(limit).
-		Find(&orders).Error; err != nil {
-		return nil, 0, err
+	query := r.query(ctx).WithUserID(userID).WithFilters(filters).Build()
+	if err := cursorPage(query, cursor, limit).Preload("Items").Find(&orders).Error; err != nil {
+		return nil, err
 	}
 
-	return orders, total, nil
+	return orders, nil
 }
 
 // GetOrderItems 获取订单项列表
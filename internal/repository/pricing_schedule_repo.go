@@ -0,0 +1,64 @@
+// Package repository 提供数据访问层
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// PricingScheduleRepository 场地分时定价仓储
+type PricingScheduleRepository struct {
+	db *gorm.DB
+}
+
+// NewPricingScheduleRepository 创建场地分时定价仓储
+func NewPricingScheduleRepository(db *gorm.DB) *PricingScheduleRepository {
+	return &PricingScheduleRepository{db: db}
+}
+
+// Create 创建分时定价
+func (r *PricingScheduleRepository) Create(ctx context.Context, schedule *models.PricingSchedule) error {
+	return r.db.WithContext(ctx).Create(schedule).Error
+}
+
+// GetByID 根据 ID 获取分时定价
+func (r *PricingScheduleRepository) GetByID(ctx context.Context, id int64) (*models.PricingSchedule, error) {
+	var schedule models.PricingSchedule
+	if err := r.db.WithContext(ctx).First(&schedule, id).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// Update 更新分时定价
+func (r *PricingScheduleRepository) Update(ctx context.Context, schedule *models.PricingSchedule) error {
+	return r.db.WithContext(ctx).Save(schedule).Error
+}
+
+// Delete 删除分时定价
+func (r *PricingScheduleRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&models.PricingSchedule{}, id).Error
+}
+
+// ListByVenue 获取场地的全部分时定价
+func (r *PricingScheduleRepository) ListByVenue(ctx context.Context, venueID int64) ([]*models.PricingSchedule, error) {
+	var schedules []*models.PricingSchedule
+	err := r.db.WithContext(ctx).
+		Where("venue_id = ?", venueID).
+		Order("day_of_week, start_hour, id").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// ListActiveByVenue 获取场地启用中的分时定价
+func (r *PricingScheduleRepository) ListActiveByVenue(ctx context.Context, venueID int64) ([]*models.PricingSchedule, error) {
+	var schedules []*models.PricingSchedule
+	err := r.db.WithContext(ctx).
+		Where("venue_id = ? AND is_active = ?", venueID, true).
+		Order("id").
+		Find(&schedules).Error
+	return schedules, err
+}
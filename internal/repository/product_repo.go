@@ -56,6 +56,28 @@ func (r *ProductRepository) GetByIDWithSkus(ctx context.Context, id int64) (*mod
 	return &product, nil
 }
 
+// GetByIDWithSkusUnscoped 根据 ID 获取商品（包含 SKU），包括已软删除的商品及 SKU
+func (r *ProductRepository) GetByIDWithSkusUnscoped(ctx context.Context, id int64) (*models.Product, error) {
+	var product models.Product
+	err := r.db.WithContext(ctx).Unscoped().Preload("Skus", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Where("is_active = ?", true).Order("id ASC")
+	}).First(&product, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// GetByIDUnscoped 根据 ID 获取商品，包括已软删除的商品
+func (r *ProductRepository) GetByIDUnscoped(ctx context.Context, id int64) (*models.Product, error) {
+	var product models.Product
+	err := r.db.WithContext(ctx).Unscoped().First(&product, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
 // GetByIDFull 根据 ID 获取商品（包含分类和 SKU）
 func (r *ProductRepository) GetByIDFull(ctx context.Context, id int64) (*models.Product, error) {
 	var product models.Product
@@ -81,7 +103,7 @@ func (r *ProductRepository) UpdateFields(ctx context.Context, id int64, fields m
 	return r.db.WithContext(ctx).Model(&models.Product{}).Where("id = ?", id).Updates(fields).Error
 }
 
-// Delete 删除商品
+// Delete 软删除商品
 func (r *ProductRepository) Delete(ctx context.Context, id int64) error {
 	return r.db.WithContext(ctx).Delete(&models.Product{}, id).Error
 }
@@ -98,6 +120,8 @@ type ProductListParams struct {
 	MinPrice   *float64
 	MaxPrice   *float64
 	SortBy     string // price_asc, price_desc, sales_desc, newest
+
+	IncludeDeleted bool // 包含已软删除的商品，仅供管理后台使用
 }
 
 // List 获取商品列表
@@ -106,6 +130,9 @@ func (r *ProductRepository) List(ctx context.Context, params ProductListParams)
 	var total int64
 
 	query := r.db.WithContext(ctx).Model(&models.Product{})
+	if params.IncludeDeleted {
+		query = query.Unscoped()
+	}
 
 	// 过滤条件
 	if params.CategoryID > 0 {
@@ -260,6 +287,15 @@ func (r *ProductSkuRepository) GetByID(ctx context.Context, id int64) (*models.P
 	return &sku, nil
 }
 
+// ExistsDeleted 判断 SKU 是否已被软删除
+func (r *ProductSkuRepository) ExistsDeleted(ctx context.Context, id int64) bool {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.ProductSku{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Count(&count).Error
+	return err == nil && count > 0
+}
+
 // GetBySkuCode 根据 SKU 编码获取
 func (r *ProductSkuRepository) GetBySkuCode(ctx context.Context, skuCode string) (*models.ProductSku, error) {
 	var sku models.ProductSku
@@ -290,12 +326,12 @@ func (r *ProductSkuRepository) UpdateFields(ctx context.Context, id int64, field
 	return r.db.WithContext(ctx).Model(&models.ProductSku{}).Where("id = ?", id).Updates(fields).Error
 }
 
-// Delete 删除 SKU
+// Delete 软删除 SKU
 func (r *ProductSkuRepository) Delete(ctx context.Context, id int64) error {
 	return r.db.WithContext(ctx).Delete(&models.ProductSku{}, id).Error
 }
 
-// DeleteByProductID 根据商品 ID 删除所有 SKU
+// DeleteByProductID 根据商品 ID 软删除所有 SKU
 func (r *ProductSkuRepository) DeleteByProductID(ctx context.Context, productID int64) error {
 	return r.db.WithContext(ctx).Where("product_id = ?", productID).Delete(&models.ProductSku{}).Error
 }
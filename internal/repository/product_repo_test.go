@@ -667,3 +667,58 @@ func TestProductSkuRepository_IncreaseStock(t *testing.T) {
 	db.First(&found, sku.ID)
 	assert.Equal(t, 80, found.Stock)
 }
+
+func TestProductRepository_List_IncludeDeleted(t *testing.T) {
+	db := setupProductTestDB(t)
+	repo := NewProductRepository(db)
+	ctx := context.Background()
+
+	active := &models.Product{CategoryID: 1, Name: "在售商品", Images: testImages(), Price: 10, IsOnSale: true}
+	deleted := &models.Product{CategoryID: 1, Name: "已删除商品", Images: testImages(), Price: 20, IsOnSale: true}
+	require.NoError(t, db.Create(active).Error)
+	require.NoError(t, db.Create(deleted).Error)
+	require.NoError(t, repo.Delete(ctx, deleted.ID))
+
+	products, total, err := repo.List(ctx, ProductListParams{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, products, 1)
+	assert.Equal(t, active.ID, products[0].ID)
+
+	products, total, err = repo.List(ctx, ProductListParams{Limit: 10, IncludeDeleted: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, products, 2)
+
+	_, err = repo.GetByID(ctx, deleted.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	found, err := repo.GetByIDUnscoped(ctx, deleted.ID)
+	require.NoError(t, err)
+	assert.True(t, found.DeletedAt.Valid)
+}
+
+func TestProductSkuRepository_SkuCodeReusableAfterDelete(t *testing.T) {
+	db := setupProductTestDB(t)
+	repo := NewProductSkuRepository(db)
+	ctx := context.Background()
+
+	old := &models.ProductSku{ProductID: 1, SkuCode: "SKU001", Attributes: testAttributes(), Price: 99.99, Stock: 50, IsActive: true}
+	require.NoError(t, repo.Create(ctx, old))
+
+	// 未删除的 SKU 编码仍然唯一
+	dup := &models.ProductSku{ProductID: 2, SkuCode: "SKU001", Attributes: testAttributes(), Price: 89.99, Stock: 10, IsActive: true}
+	assert.Error(t, repo.Create(ctx, dup))
+
+	// 软删除后编码可被新 SKU 使用
+	require.NoError(t, repo.Delete(ctx, old.ID))
+	assert.True(t, repo.ExistsDeleted(ctx, old.ID))
+
+	reused := &models.ProductSku{ProductID: 2, SkuCode: "SKU001", Attributes: testAttributes(), Price: 89.99, Stock: 10, IsActive: true}
+	require.NoError(t, repo.Create(ctx, reused))
+	assert.False(t, repo.ExistsDeleted(ctx, reused.ID))
+
+	found, err := repo.GetBySkuCode(ctx, "SKU001")
+	require.NoError(t, err)
+	assert.Equal(t, reused.ID, found.ID)
+}
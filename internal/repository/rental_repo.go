@@ -6,10 +6,17 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 )
 
+// unscopedDevice 预加载设备时包含已软删除的设备，保证历史租借仍能展示设备信息
+func unscopedDevice(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
 // RentalRepository 租借仓储
 type RentalRepository struct {
 	db *gorm.DB
@@ -39,7 +46,7 @@ func (r *RentalRepository) GetByID(ctx context.Context, id int64) (*models.Renta
 func (r *RentalRepository) GetByIDWithRelations(ctx context.Context, id int64) (*models.Rental, error) {
 	var rental models.Rental
 	err := r.db.WithContext(ctx).
-		Preload("Device").
+		Preload("Device", unscopedDevice).
 		Preload("Device.Venue").
 		First(&rental, id).Error
 	if err != nil {
@@ -65,7 +72,7 @@ func (r *RentalRepository) GetByRentalNo(ctx context.Context, rentalNo string) (
 func (r *RentalRepository) GetByRentalNoWithRelations(ctx context.Context, rentalNo string) (*models.Rental, error) {
 	var rental models.Rental
 	err := r.db.WithContext(ctx).
-		Preload("Device").
+		Preload("Device", unscopedDevice).
 		Preload("Device.Venue").
 		Joins("JOIN orders ON rentals.order_id = orders.id").
 		Where("orders.order_no = ?", rentalNo).
@@ -106,7 +113,7 @@ func (r *RentalRepository) ListByUser(ctx context.Context, userID int64, offset,
 		return nil, 0, err
 	}
 
-	if err := query.Preload("Device").
+	if err := query.Preload("Device", unscopedDevice).
 		Order("id DESC").Offset(offset).Limit(limit).
 		Find(&rentals).Error; err != nil {
 		return nil, 0, err
@@ -115,6 +122,25 @@ func (r *RentalRepository) ListByUser(ctx context.Context, userID int64, offset,
 	return rentals, total, nil
 }
 
+// ListByUserCursor 游标分页获取用户的租借列表
+// 支持的筛选条件：status (string)、start_date / end_date (*time.Time)
+func (r *RentalRepository) ListByUserCursor(ctx context.Context, userID int64, cursor *utils.Cursor, limit int, filters map[string]interface{}) ([]*models.Rental, error) {
+	var rentals []*models.Rental
+
+	query := r.db.WithContext(ctx).Model(&models.Rental{}).Where("user_id = ?", userID)
+
+	if status, ok := filters["status"].(string); ok && status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query = applyCreatedAtRange(query, filters)
+
+	if err := cursorPage(query, cursor, limit).Preload("Device", unscopedDevice).Find(&rentals).Error; err != nil {
+		return nil, err
+	}
+
+	return rentals, nil
+}
+
 // ListByDevice 获取设备的租借列表
 func (r *RentalRepository) ListByDevice(ctx context.Context, deviceID int64, offset, limit int) ([]*models.Rental, int64, error) {
 	var rentals []*models.Rental
@@ -168,19 +194,28 @@ func (r *RentalRepository) GetActiveByUser(ctx context.Context, userID int64) (*
 	return &rental, nil
 }
 
+// activeRentalStatuses 进行中的租借状态
+var activeRentalStatuses = []string{
+	models.RentalStatusPending,
+	models.RentalStatusPaid,
+	models.RentalStatusInUse,
+	models.RentalStatusOverdue,
+}
+
 // HasActiveRental 检查用户是否有进行中的租借
 func (r *RentalRepository) HasActiveRental(ctx context.Context, userID int64) (bool, error) {
+	count, err := r.CountActiveRentals(ctx, userID)
+	return count > 0, err
+}
+
+// CountActiveRentals 统计用户进行中的租借数量（待支付、已支付、使用中、已超时）
+func (r *RentalRepository) CountActiveRentals(ctx context.Context, userID int64) (int64, error) {
 	var count int64
 	err := r.db.WithContext(ctx).Model(&models.Rental{}).
 		Where("user_id = ?", userID).
-		Where("status IN ?", []string{
-			models.RentalStatusPending,
-			models.RentalStatusPaid,
-			models.RentalStatusInUse,
-			models.RentalStatusOverdue,
-		}).
+		Where("status IN ?", activeRentalStatuses).
 		Count(&count).Error
-	return count > 0, err
+	return count, err
 }
 
 // List 获取租借列表（管理端）
@@ -210,7 +245,7 @@ func (r *RentalRepository) List(ctx context.Context, offset, limit int, filters
 		return nil, 0, err
 	}
 
-	if err := query.Preload("User").Preload("Device").
+	if err := query.Preload("User").Preload("Device", unscopedDevice).
 		Order("id DESC").Offset(offset).Limit(limit).
 		Find(&rentals).Error; err != nil {
 		return nil, 0, err
@@ -265,10 +300,44 @@ func (r *RentalRepository) CountByStatus(ctx context.Context) (map[string]int64,
 	return counts, nil
 }
 
+// RentalHourStats 按开始小时分组的租借统计，未开始使用的租借 Hour 为空
+type RentalHourStats struct {
+	Hour             *int
+	TotalRentals     int64
+	CompletedRentals int64
+	CancelledRentals int64
+	Revenue          float64 // 已完成租借的租金与超时费合计
+	OvertimeFee      float64 // 已完成租借的超时费合计
+	DurationHours    float64 // 已归还租借的实际使用时长合计
+	ReturnedRentals  int64   // 有实际使用时长的租借数
+}
+
+// DeviceStatsByHour 统计设备在时间范围内（按创建时间）的租借，按开始使用时 loc 时区下的小时分组
+func (r *RentalRepository) DeviceStatsByHour(ctx context.Context, deviceID int64, startDate, endDate time.Time, loc *time.Location) ([]RentalHourStats, error) {
+	hour := hourOfDayExpr(r.db, "unlocked_at", loc)
+	duration := hoursBetweenExpr(r.db, "unlocked_at", "returned_at")
+
+	var results []RentalHourStats
+	err := r.db.WithContext(ctx).Model(&models.Rental{}).
+		Select(hour+" AS hour, "+
+			"COUNT(*) AS total_rentals, "+
+			"SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS completed_rentals, "+
+			"SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS cancelled_rentals, "+
+			"COALESCE(SUM(CASE WHEN status = ? THEN rental_fee + overtime_fee ELSE 0 END), 0) AS revenue, "+
+			"COALESCE(SUM(CASE WHEN status = ? THEN overtime_fee ELSE 0 END), 0) AS overtime_fee, "+
+			"COALESCE(SUM(CASE WHEN returned_at IS NOT NULL AND unlocked_at IS NOT NULL THEN "+duration+" ELSE 0 END), 0) AS duration_hours, "+
+			"SUM(CASE WHEN returned_at IS NOT NULL AND unlocked_at IS NOT NULL THEN 1 ELSE 0 END) AS returned_rentals",
+			models.RentalStatusCompleted, models.RentalStatusCancelled, models.RentalStatusCompleted, models.RentalStatusCompleted).
+		Where("device_id = ? AND created_at >= ? AND created_at <= ?", deviceID, startDate, endDate).
+		Group(hour).
+		Scan(&results).Error
+	return results, err
+}
+
 // GetForUpdate 获取租借订单（加锁）
 func (r *RentalRepository) GetForUpdate(ctx context.Context, tx *gorm.DB, id int64) (*models.Rental, error) {
 	var rental models.Rental
-	err := tx.WithContext(ctx).Set("gorm:query_option", "FOR UPDATE").First(&rental, id).Error
+	err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&rental, id).Error
 	if err != nil {
 		return nil, err
 	}
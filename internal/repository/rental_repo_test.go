@@ -199,6 +199,40 @@ func TestRentalRepository_GetByID(t *testing.T) {
 	})
 }
 
+func TestRentalRepository_PreloadDeletedDevice(t *testing.T) {
+	db := setupRentalTestDB(t)
+	repo := NewRentalRepository(db)
+	ctx := context.Background()
+
+	user, device, pricing := createRentalTestData(t, db)
+
+	rental := &models.Rental{
+		OrderID:       1,
+		UserID:        user.ID,
+		DeviceID:      device.ID,
+		DurationHours: 1,
+		RentalFee:     pricing.Price,
+		Deposit:       pricing.Deposit,
+		OvertimeRate:  pricing.OvertimeRate,
+		Status:        models.RentalStatusCompleted,
+	}
+	require.NoError(t, db.Create(rental).Error)
+
+	// 设备软删除后，历史租借仍能获取设备信息
+	require.NoError(t, db.Delete(&models.Device{}, device.ID).Error)
+
+	found, err := repo.GetByIDWithRelations(ctx, rental.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found.Device)
+	assert.Equal(t, device.DeviceNo, found.Device.DeviceNo)
+	assert.NotNil(t, found.Device.Venue)
+
+	rentals, _, err := repo.ListByUser(ctx, user.ID, 0, 10, nil)
+	require.NoError(t, err)
+	require.Len(t, rentals, 1)
+	assert.NotNil(t, rentals[0].Device)
+}
+
 func TestRentalRepository_GetByRentalNo(t *testing.T) {
 	db := setupRentalTestDB(t)
 	repo := NewRentalRepository(db)
@@ -66,9 +66,14 @@ func (r *ReviewRepository) UpdateFields(ctx context.Context, id int64, fields ma
 	return r.db.WithContext(ctx).Model(&models.Review{}).Where("id = ?", id).Updates(fields).Error
 }
 
-// Delete 删除评价
+// Delete 删除评价及其回复
 func (r *ReviewRepository) Delete(ctx context.Context, id int64) error {
-	return r.db.WithContext(ctx).Delete(&models.Review{}, id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("review_id = ?", id).Delete(&models.ReviewReply{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Review{}, id).Error
+	})
 }
 
 // ReviewListParams 评价列表查询参数
@@ -80,6 +85,7 @@ type ReviewListParams struct {
 	OrderID   int64
 	Rating    *int16
 	Status    *int16
+	HasImages *bool
 }
 
 // List 获取评价列表
@@ -105,6 +111,13 @@ func (r *ReviewRepository) List(ctx context.Context, params ReviewListParams) ([
 	if params.Status != nil {
 		query = query.Where("status = ?", *params.Status)
 	}
+	if params.HasImages != nil {
+		if *params.HasImages {
+			query = query.Where("images IS NOT NULL")
+		} else {
+			query = query.Where("images IS NULL")
+		}
+	}
 
 	// 统计总数
 	if err := query.Count(&total).Error; err != nil {
@@ -119,14 +132,15 @@ func (r *ReviewRepository) List(ctx context.Context, params ReviewListParams) ([
 	return reviews, total, nil
 }
 
-// ListByProductID 根据商品ID获取评价列表
-func (r *ReviewRepository) ListByProductID(ctx context.Context, productID int64, offset, limit int) ([]*models.Review, int64, error) {
+// ListByProductID 根据商品ID获取评价列表，hasImages 为 nil 时不按图片筛选
+func (r *ReviewRepository) ListByProductID(ctx context.Context, productID int64, hasImages *bool, offset, limit int) ([]*models.Review, int64, error) {
 	status := int16(models.ReviewStatusVisible)
 	return r.List(ctx, ReviewListParams{
 		Offset:    offset,
 		Limit:     limit,
 		ProductID: productID,
 		Status:    &status,
+		HasImages: hasImages,
 	})
 }
 
@@ -190,3 +204,21 @@ func (r *ReviewRepository) ExistsByOrderAndProduct(ctx context.Context, orderID,
 		Count(&count).Error
 	return count > 0, err
 }
+
+// CreateReply 创建评价回复
+func (r *ReviewRepository) CreateReply(ctx context.Context, reply *models.ReviewReply) error {
+	return r.db.WithContext(ctx).Create(reply).Error
+}
+
+// ListRepliesByReviewIDs 批量获取评价回复，按创建时间升序
+func (r *ReviewRepository) ListRepliesByReviewIDs(ctx context.Context, reviewIDs []int64) ([]*models.ReviewReply, error) {
+	var replies []*models.ReviewReply
+	if len(reviewIDs) == 0 {
+		return replies, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("review_id IN ?", reviewIDs).
+		Order("created_at ASC, id ASC").
+		Find(&replies).Error
+	return replies, err
+}
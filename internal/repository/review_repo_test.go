@@ -21,7 +21,7 @@ func setupReviewTestDB(t *testing.T) *gorm.DB {
 	})
 	require.NoError(t, err)
 
-	err = db.AutoMigrate(&models.Review{}, &models.User{}, &models.Product{}, &models.Order{})
+	err = db.AutoMigrate(&models.Review{}, &models.ReviewReply{}, &models.User{}, &models.Product{}, &models.Order{})
 	require.NoError(t, err)
 
 	return db
@@ -243,7 +243,7 @@ func TestReviewRepository_ListByProductID(t *testing.T) {
 		"order_id": 3, "product_id": 1, "user_id": 3, "rating": 3, "images": images, "status": models.ReviewStatusHidden,
 	})
 
-	_, total, err := repo.ListByProductID(ctx, 1, 0, 10)
+	_, total, err := repo.ListByProductID(ctx, 1, nil, 0, 10)
 	require.NoError(t, err)
 	assert.Equal(t, int64(2), total) // 只返回可见的评价
 }
@@ -0,0 +1,338 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// 房间占用位图相关常量
+const (
+	occupancyDateLayout = "2006-01-02"
+	minutesPerHour      = 60
+)
+
+// occupancyInterval 当日被占用的分钟区间 [Start, End)
+type occupancyInterval struct {
+	Start int
+	End   int
+}
+
+// occupancyDaySpan 时间段在某一 UTC 日期内的部分
+type occupancyDaySpan struct {
+	Date     string
+	Interval occupancyInterval
+}
+
+// RoomOccupancyRepository 房间占用位图仓储
+// 位图由预订状态或时段变更时在同一事务中刷新，可用性查询只读位图，不再扫描预订表
+type RoomOccupancyRepository struct {
+	db *gorm.DB
+}
+
+// NewRoomOccupancyRepository 创建房间占用位图仓储
+func NewRoomOccupancyRepository(db *gorm.DB) *RoomOccupancyRepository {
+	return &RoomOccupancyRepository{db: db}
+}
+
+// RefreshTx 按占用房间的预订重新计算 [from, to) 涉及日期的位图
+// 预订支付、核销前取消、过期、完成或改期后调用；改期时新旧时段都需要刷新
+func (r *RoomOccupancyRepository) RefreshTx(ctx context.Context, tx *gorm.DB, roomID int64, from, to time.Time) error {
+	spans := splitOccupancyDays(from, to)
+	if len(spans) == 0 {
+		return nil
+	}
+
+	windowStart := occupancyDayStart(from)
+	windowEnd := occupancyDayStart(to.Add(-time.Nanosecond)).AddDate(0, 0, 1)
+
+	bookings, err := r.listOccupyingBookings(ctx, tx, roomID, &windowStart, &windowEnd)
+	if err != nil {
+		return err
+	}
+
+	days := make(map[string][]occupancyInterval, len(spans))
+	for _, span := range spans {
+		days[span.Date] = nil
+	}
+	addBookingSpans(days, bookings, windowStart, windowEnd)
+
+	return r.saveDaysTx(ctx, tx, roomID, days)
+}
+
+// Rebuild 按占用房间的预订重建房间的全部位图，返回写入的天数，用于修复位图与预订不一致
+func (r *RoomOccupancyRepository) Rebuild(ctx context.Context, roomID int64) (int, error) {
+	var written int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("room_id = ?", roomID).Delete(&models.RoomOccupancy{}).Error; err != nil {
+			return err
+		}
+
+		bookings, err := r.listOccupyingBookings(ctx, tx, roomID, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		days := make(map[string][]occupancyInterval)
+		for _, b := range bookings {
+			for _, span := range splitOccupancyDays(b.CheckInTime, b.CheckOutTime) {
+				days[span.Date] = append(days[span.Date], span.Interval)
+			}
+		}
+		written = len(days)
+		return r.saveDaysTx(ctx, tx, roomID, days)
+	})
+	return written, err
+}
+
+// RebuildMissing 为存在占用预订但没有任何位图记录的房间重建位图，返回重建的房间数
+// 用于位图表上线前已存在的预订回填，已有位图的房间不受影响，可重复执行
+func (r *RoomOccupancyRepository) RebuildMissing(ctx context.Context) (int, error) {
+	var roomIDs []int64
+	if err := r.db.WithContext(ctx).Model(&models.Booking{}).
+		Distinct("room_id").
+		Where("status IN ?", models.BookingOccupyingStatuses).
+		Where("check_out_time > check_in_time").
+		Where("room_id NOT IN (?)", r.db.Model(&models.RoomOccupancy{}).Select("room_id")).
+		Order("room_id").
+		Pluck("room_id", &roomIDs).Error; err != nil {
+		return 0, err
+	}
+
+	for i, roomID := range roomIDs {
+		if _, err := r.Rebuild(ctx, roomID); err != nil {
+			return i, fmt.Errorf("rebuild room %d occupancy: %w", roomID, err)
+		}
+	}
+	return len(roomIDs), nil
+}
+
+// IsAvailable 检查房间在 [from, to) 内是否未被占用
+// 先按小时掩码快速判断，掩码有交集时再按分钟区间精确判断
+func (r *RoomOccupancyRepository) IsAvailable(ctx context.Context, roomID int64, from, to time.Time) (bool, error) {
+	spans := splitOccupancyDays(from, to)
+	if len(spans) == 0 {
+		return true, nil
+	}
+
+	dates := make([]string, len(spans))
+	for i, span := range spans {
+		dates[i] = span.Date
+	}
+
+	var rows []*models.RoomOccupancy
+	if err := r.db.WithContext(ctx).
+		Where("room_id = ? AND date IN ?", roomID, dates).
+		Find(&rows).Error; err != nil {
+		return false, err
+	}
+
+	byDate := make(map[string]*models.RoomOccupancy, len(rows))
+	for _, row := range rows {
+		byDate[row.Date] = row
+	}
+
+	for _, span := range spans {
+		row, ok := byDate[span.Date]
+		if !ok || occupancyHourMask([]occupancyInterval{span.Interval})&row.HourMask == 0 {
+			continue
+		}
+		if overlapsAny(decodeOccupancyRanges(row.Ranges), span.Interval) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// OccupiedAt 返回 roomIDs 中在 at 时刻被占用的房间
+func (r *RoomOccupancyRepository) OccupiedAt(ctx context.Context, roomIDs []int64, at time.Time) (map[int64]bool, error) {
+	occupied := make(map[int64]bool)
+	if len(roomIDs) == 0 {
+		return occupied, nil
+	}
+
+	span := splitOccupancyDays(at, at.Add(time.Second))[0]
+	var rows []*models.RoomOccupancy
+	if err := r.db.WithContext(ctx).
+		Where("room_id IN ? AND date = ?", roomIDs, span.Date).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	hourBit := occupancyHourMask([]occupancyInterval{span.Interval})
+	for _, row := range rows {
+		if row.HourMask&hourBit != 0 && overlapsAny(decodeOccupancyRanges(row.Ranges), span.Interval) {
+			occupied[row.RoomID] = true
+		}
+	}
+	return occupied, nil
+}
+
+// listOccupyingBookings 获取房间占用时段的预订，windowStart / windowEnd 为 nil 时不限制时间
+func (r *RoomOccupancyRepository) listOccupyingBookings(ctx context.Context, tx *gorm.DB, roomID int64, windowStart, windowEnd *time.Time) ([]*models.Booking, error) {
+	query := tx.WithContext(ctx).Model(&models.Booking{}).
+		Select("check_in_time", "check_out_time").
+		Where("room_id = ?", roomID).
+		Where("status IN ?", models.BookingOccupyingStatuses)
+	if windowStart != nil && windowEnd != nil {
+		query = query.Where("(check_in_time < ? AND check_out_time > ?)", *windowEnd, *windowStart)
+	}
+
+	var bookings []*models.Booking
+	err := query.Find(&bookings).Error
+	return bookings, err
+}
+
+// saveDaysTx 写入各日期的位图，没有占用区间的日期删除对应记录
+func (r *RoomOccupancyRepository) saveDaysTx(ctx context.Context, tx *gorm.DB, roomID int64, days map[string][]occupancyInterval) error {
+	for date, intervals := range days {
+		if len(intervals) == 0 {
+			if err := tx.WithContext(ctx).Where("room_id = ? AND date = ?", roomID, date).
+				Delete(&models.RoomOccupancy{}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		merged := mergeOccupancyIntervals(intervals)
+		row := &models.RoomOccupancy{
+			RoomID:   roomID,
+			Date:     date,
+			HourMask: occupancyHourMask(merged),
+			Ranges:   encodeOccupancyRanges(merged),
+		}
+		if err := tx.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "room_id"}, {Name: "date"}},
+			DoUpdates: clause.AssignmentColumns([]string{"hour_mask", "ranges", "updated_at"}),
+		}).Create(row).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addBookingSpans 将预订落在 [windowStart, windowEnd) 内的部分按日期加入 days，只加入 days 中已有的日期
+func addBookingSpans(days map[string][]occupancyInterval, bookings []*models.Booking, windowStart, windowEnd time.Time) {
+	for _, b := range bookings {
+		from, to := b.CheckInTime, b.CheckOutTime
+		if from.Before(windowStart) {
+			from = windowStart
+		}
+		if to.After(windowEnd) {
+			to = windowEnd
+		}
+		for _, span := range splitOccupancyDays(from, to) {
+			if intervals, ok := days[span.Date]; ok {
+				days[span.Date] = append(intervals, span.Interval)
+			}
+		}
+	}
+}
+
+// occupancyDayStart 返回 t 所在 UTC 日期的零点
+func occupancyDayStart(t time.Time) time.Time {
+	u := t.UTC()
+	return time.Date(u.Year(), u.Month(), u.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// splitOccupancyDays 将 [from, to) 按 UTC 日期拆分为各日的分钟区间
+// 起点向下、终点向上取整到分钟，跨零点的时间段拆分到两天
+func splitOccupancyDays(from, to time.Time) []occupancyDaySpan {
+	if !to.After(from) {
+		return nil
+	}
+
+	var spans []occupancyDaySpan
+	for day := occupancyDayStart(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		start, end := from, to
+		if start.Before(day) {
+			start = day
+		}
+		if end.After(next) {
+			end = next
+		}
+		spans = append(spans, occupancyDaySpan{
+			Date: day.Format(occupancyDateLayout),
+			Interval: occupancyInterval{
+				Start: int(start.Sub(day) / time.Minute),
+				End:   int((end.Sub(day) + time.Minute - 1) / time.Minute),
+			},
+		})
+	}
+	return spans
+}
+
+// mergeOccupancyIntervals 排序并合并重叠或相邻的区间
+func mergeOccupancyIntervals(intervals []occupancyInterval) []occupancyInterval {
+	sorted := append([]occupancyInterval(nil), intervals...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	merged := make([]occupancyInterval, 0, len(sorted))
+	for _, iv := range sorted {
+		if n := len(merged); n > 0 && iv.Start <= merged[n-1].End {
+			if iv.End > merged[n-1].End {
+				merged[n-1].End = iv.End
+			}
+			continue
+		}
+		merged = append(merged, iv)
+	}
+	return merged
+}
+
+// occupancyHourMask 计算区间覆盖的小时掩码
+func occupancyHourMask(intervals []occupancyInterval) int32 {
+	var mask int32
+	for _, iv := range intervals {
+		for h := iv.Start / minutesPerHour; h*minutesPerHour < iv.End && h < 24; h++ {
+			mask |= 1 << uint(h)
+		}
+	}
+	return mask
+}
+
+// overlapsAny 判断 target 是否与任一区间重叠
+func overlapsAny(intervals []occupancyInterval, target occupancyInterval) bool {
+	for _, iv := range intervals {
+		if iv.Start < target.End && iv.End > target.Start {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeOccupancyRanges 将区间编码为 "start-end,start-end"
+func encodeOccupancyRanges(intervals []occupancyInterval) string {
+	parts := make([]string, len(intervals))
+	for i, iv := range intervals {
+		parts[i] = fmt.Sprintf("%d-%d", iv.Start, iv.End)
+	}
+	return strings.Join(parts, ",")
+}
+
+// decodeOccupancyRanges 解析 encodeOccupancyRanges 编码的区间，忽略格式错误的部分
+func decodeOccupancyRanges(ranges string) []occupancyInterval {
+	var intervals []occupancyInterval
+	for _, part := range strings.Split(ranges, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		if len(bounds) != 2 {
+			continue
+		}
+		start, err1 := strconv.Atoi(bounds[0])
+		end, err2 := strconv.Atoi(bounds[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		intervals = append(intervals, occupancyInterval{Start: start, End: end})
+	}
+	return intervals
+}
@@ -0,0 +1,268 @@
+// Package repository 房间占用位图仓储单元测试
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+func setupRoomOccupancyTest(t *testing.T) (*gorm.DB, *RoomOccupancyRepository, *models.Room) {
+	db := setupRoomTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.RoomOccupancy{}))
+
+	hotel := &models.Hotel{
+		Name: "测试酒店", Province: "广东省", City: "深圳市", District: "南山区",
+		Address: "addr1", Phone: "123",
+	}
+	require.NoError(t, db.Create(hotel).Error)
+	room := &models.Room{
+		HotelID:     hotel.ID,
+		RoomNo:      "101",
+		RoomType:    models.RoomTypeStandard,
+		HourlyPrice: 100,
+		DailyPrice:  500,
+	}
+	require.NoError(t, db.Create(room).Error)
+
+	return db, NewRoomOccupancyRepository(db), room
+}
+
+// createOccupancyBooking 创建预订并在同一事务中刷新位图
+func createOccupancyBooking(t *testing.T, db *gorm.DB, repo *RoomOccupancyRepository, room *models.Room, checkIn, checkOut time.Time, status string) *models.Booking {
+	seq := time.Now().UnixNano()
+	no := fmt.Sprintf("BK%d", seq)
+	booking := &models.Booking{
+		BookingNo:        no,
+		OrderID:          seq,
+		UserID:           1,
+		HotelID:          room.HotelID,
+		RoomID:           room.ID,
+		CheckInTime:      checkIn,
+		CheckOutTime:     checkOut,
+		DurationHours:    int(checkOut.Sub(checkIn) / time.Hour),
+		Amount:           100,
+		VerificationCode: "VC" + no,
+		UnlockCode:       "UC001",
+		QRCode:           "QR001",
+		Status:           status,
+	}
+	ctx := context.Background()
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(booking).Error; err != nil {
+			return err
+		}
+		return repo.RefreshTx(ctx, tx, room.ID, checkIn, checkOut)
+	}))
+	return booking
+}
+
+func TestRoomOccupancyRepository_IsAvailable(t *testing.T) {
+	db, repo, room := setupRoomOccupancyTest(t)
+	ctx := context.Background()
+
+	checkIn := time.Date(2030, 5, 1, 10, 30, 0, 0, time.UTC)
+	checkOut := checkIn.Add(2 * time.Hour)
+	createOccupancyBooking(t, db, repo, room, checkIn, checkOut, models.BookingStatusPaid)
+
+	var row models.RoomOccupancy
+	require.NoError(t, db.Where("room_id = ? AND date = ?", room.ID, "2030-05-01").First(&row).Error)
+	assert.Equal(t, "630-750", row.Ranges)
+	assert.Equal(t, int32(1<<10|1<<11|1<<12), row.HourMask)
+
+	t.Run("重叠时段不可用", func(t *testing.T) {
+		available, err := repo.IsAvailable(ctx, room.ID, checkIn.Add(time.Hour), checkOut.Add(time.Hour))
+		require.NoError(t, err)
+		assert.False(t, available)
+	})
+
+	t.Run("同一小时内不重叠的时段可用", func(t *testing.T) {
+		available, err := repo.IsAvailable(ctx, room.ID, checkIn.Add(-30*time.Minute), checkIn)
+		require.NoError(t, err)
+		assert.True(t, available)
+
+		available, err = repo.IsAvailable(ctx, room.ID, checkOut, checkOut.Add(15*time.Minute))
+		require.NoError(t, err)
+		assert.True(t, available)
+	})
+
+	t.Run("其他日期可用", func(t *testing.T) {
+		available, err := repo.IsAvailable(ctx, room.ID, checkIn.AddDate(0, 0, 1), checkOut.AddDate(0, 0, 1))
+		require.NoError(t, err)
+		assert.True(t, available)
+	})
+}
+
+func TestRoomOccupancyRepository_SpansMidnight(t *testing.T) {
+	db, repo, room := setupRoomOccupancyTest(t)
+	ctx := context.Background()
+
+	checkIn := time.Date(2030, 5, 1, 22, 0, 0, 0, time.UTC)
+	checkOut := checkIn.Add(4 * time.Hour)
+	createOccupancyBooking(t, db, repo, room, checkIn, checkOut, models.BookingStatusPaid)
+
+	var rows []*models.RoomOccupancy
+	require.NoError(t, db.Where("room_id = ?", room.ID).Order("date ASC").Find(&rows).Error)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "2030-05-01", rows[0].Date)
+	assert.Equal(t, "1320-1440", rows[0].Ranges)
+	assert.Equal(t, "2030-05-02", rows[1].Date)
+	assert.Equal(t, "0-120", rows[1].Ranges)
+
+	// 次日凌晨被占用，之后空闲
+	available, err := repo.IsAvailable(ctx, room.ID, time.Date(2030, 5, 2, 1, 0, 0, 0, time.UTC), time.Date(2030, 5, 2, 3, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.False(t, available)
+
+	available, err = repo.IsAvailable(ctx, room.ID, time.Date(2030, 5, 2, 2, 0, 0, 0, time.UTC), time.Date(2030, 5, 2, 4, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, available)
+
+	// 非 UTC 时区的查询按同一时刻判断
+	shanghai := time.FixedZone("CST", 8*3600)
+	available, err = repo.IsAvailable(ctx, room.ID, time.Date(2030, 5, 2, 7, 0, 0, 0, shanghai), time.Date(2030, 5, 2, 9, 0, 0, 0, shanghai))
+	require.NoError(t, err)
+	assert.False(t, available)
+}
+
+func TestRoomOccupancyRepository_CancelledBookingFreesWindow(t *testing.T) {
+	db, repo, room := setupRoomOccupancyTest(t)
+	ctx := context.Background()
+
+	checkIn := time.Date(2030, 5, 1, 10, 0, 0, 0, time.UTC)
+	checkOut := checkIn.Add(2 * time.Hour)
+	booking := createOccupancyBooking(t, db, repo, room, checkIn, checkOut, models.BookingStatusPaid)
+	// 同一天另一预订不受影响
+	createOccupancyBooking(t, db, repo, room, checkIn.Add(4*time.Hour), checkOut.Add(4*time.Hour), models.BookingStatusPaid)
+
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(booking).Update("status", models.BookingStatusCancelled).Error; err != nil {
+			return err
+		}
+		return repo.RefreshTx(ctx, tx, room.ID, checkIn, checkOut)
+	}))
+
+	available, err := repo.IsAvailable(ctx, room.ID, checkIn, checkOut)
+	require.NoError(t, err)
+	assert.True(t, available)
+
+	available, err = repo.IsAvailable(ctx, room.ID, checkIn.Add(4*time.Hour), checkOut.Add(4*time.Hour))
+	require.NoError(t, err)
+	assert.False(t, available)
+
+	var row models.RoomOccupancy
+	require.NoError(t, db.Where("room_id = ? AND date = ?", room.ID, "2030-05-01").First(&row).Error)
+	assert.Equal(t, "840-960", row.Ranges)
+}
+
+func TestRoomOccupancyRepository_NonOccupyingStatuses(t *testing.T) {
+	db, repo, room := setupRoomOccupancyTest(t)
+	ctx := context.Background()
+
+	checkIn := time.Date(2030, 5, 1, 10, 0, 0, 0, time.UTC)
+	checkOut := checkIn.Add(2 * time.Hour)
+	for _, status := range []string{models.BookingStatusPending, models.BookingStatusExpired, models.BookingStatusCancelled} {
+		createOccupancyBooking(t, db, repo, room, checkIn, checkOut, status)
+	}
+
+	available, err := repo.IsAvailable(ctx, room.ID, checkIn, checkOut)
+	require.NoError(t, err)
+	assert.True(t, available)
+
+	var count int64
+	db.Model(&models.RoomOccupancy{}).Where("room_id = ?", room.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestRoomOccupancyRepository_OccupiedAt(t *testing.T) {
+	db, repo, room := setupRoomOccupancyTest(t)
+	ctx := context.Background()
+
+	other := &models.Room{HotelID: room.HotelID, RoomNo: "102", RoomType: models.RoomTypeStandard, HourlyPrice: 100, DailyPrice: 500}
+	require.NoError(t, db.Create(other).Error)
+
+	checkIn := time.Date(2030, 5, 1, 10, 0, 0, 0, time.UTC)
+	createOccupancyBooking(t, db, repo, room, checkIn, checkIn.Add(2*time.Hour), models.BookingStatusInUse)
+
+	occupied, err := repo.OccupiedAt(ctx, []int64{room.ID, other.ID}, checkIn.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, occupied[room.ID])
+	assert.False(t, occupied[other.ID])
+
+	occupied, err = repo.OccupiedAt(ctx, []int64{room.ID, other.ID}, checkIn.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.False(t, occupied[room.ID])
+}
+
+func TestRoomOccupancyRepository_Rebuild(t *testing.T) {
+	db, repo, room := setupRoomOccupancyTest(t)
+	ctx := context.Background()
+
+	// 直接写入的预订未刷新位图
+	checkIn := time.Date(2030, 5, 1, 22, 0, 0, 0, time.UTC)
+	require.NoError(t, db.Create(&models.Booking{
+		BookingNo: "BKREBUILD", OrderID: 1, UserID: 1, HotelID: room.HotelID, RoomID: room.ID,
+		CheckInTime: checkIn, CheckOutTime: checkIn.Add(4 * time.Hour), DurationHours: 4, Amount: 100,
+		VerificationCode: "VCREBUILD", UnlockCode: "UC001", QRCode: "QR001", Status: models.BookingStatusVerified,
+	}).Error)
+	// 残留的过期位图
+	require.NoError(t, db.Create(&models.RoomOccupancy{RoomID: room.ID, Date: "2030-04-01", HourMask: 1, Ranges: "0-60"}).Error)
+
+	available, err := repo.IsAvailable(ctx, room.ID, checkIn, checkIn.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, available)
+
+	days, err := repo.Rebuild(ctx, room.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, days)
+
+	available, err = repo.IsAvailable(ctx, room.ID, checkIn, checkIn.Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, available)
+
+	var count int64
+	db.Model(&models.RoomOccupancy{}).Where("room_id = ?", room.ID).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestRoomOccupancyRepository_RebuildMissing(t *testing.T) {
+	db, repo, room := setupRoomOccupancyTest(t)
+	ctx := context.Background()
+
+	other := &models.Room{HotelID: room.HotelID, RoomNo: "102", RoomType: models.RoomTypeStandard, HourlyPrice: 100, DailyPrice: 500}
+	require.NoError(t, db.Create(other).Error)
+
+	// 位图表上线前已存在的预订
+	checkIn := time.Date(2030, 6, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, db.Create(&models.Booking{
+		BookingNo: "BKLEGACY", OrderID: 1, UserID: 1, HotelID: room.HotelID, RoomID: room.ID,
+		CheckInTime: checkIn, CheckOutTime: checkIn.Add(2 * time.Hour), DurationHours: 2, Amount: 100,
+		VerificationCode: "VCLEGACY", UnlockCode: "UC001", QRCode: "QR001", Status: models.BookingStatusPaid,
+	}).Error)
+	// 已维护位图的房间
+	createOccupancyBooking(t, db, repo, other, checkIn, checkIn.Add(time.Hour), models.BookingStatusPaid)
+
+	available, err := repo.IsAvailable(ctx, room.ID, checkIn, checkIn.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, available)
+
+	rebuilt, err := repo.RebuildMissing(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rebuilt)
+
+	available, err = repo.IsAvailable(ctx, room.ID, checkIn, checkIn.Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, available)
+
+	// 重复执行不再重建
+	rebuilt, err = repo.RebuildMissing(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, rebuilt)
+}
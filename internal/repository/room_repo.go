@@ -206,6 +206,23 @@ func (r *RoomRepository) CheckAvailability(ctx context.Context, roomID int64, ch
 	return count == 0, nil
 }
 
+// ListOccupyingBookings 获取与指定时间段重叠、占用房间的预订（排除已取消、已过期及退款的预订）
+func (r *RoomRepository) ListOccupyingBookings(ctx context.Context, roomID int64, from, to time.Time) ([]*models.Booking, error) {
+	var bookings []*models.Booking
+	err := r.db.WithContext(ctx).
+		Select("id", "room_id", "status", "check_in_time", "check_out_time").
+		Where("room_id = ?", roomID).
+		Where("status NOT IN ?", []string{
+			models.BookingStatusCancelled,
+			models.BookingStatusExpired,
+			models.BookingStatusRefunding,
+			models.BookingStatusRefunded,
+		}).
+		Where("(check_in_time < ? AND check_out_time > ?)", to, from).
+		Find(&bookings).Error
+	return bookings, err
+}
+
 // RoomTimeSlotRepository 房间时段仓储
 type RoomTimeSlotRepository struct {
 	db *gorm.DB
@@ -294,6 +311,37 @@ func (r *RoomTimeSlotRepository) DeleteByRoom(ctx context.Context, roomID int64)
 	return r.db.WithContext(ctx).Where("room_id = ?", roomID).Delete(&models.RoomTimeSlot{}).Error
 }
 
+// RoomPriceOverrideRepository 房间日历价格覆盖仓储
+type RoomPriceOverrideRepository struct {
+	db *gorm.DB
+}
+
+// NewRoomPriceOverrideRepository 创建房间日历价格覆盖仓储
+func NewRoomPriceOverrideRepository(db *gorm.DB) *RoomPriceOverrideRepository {
+	return &RoomPriceOverrideRepository{db: db}
+}
+
+// Create 创建价格覆盖
+func (r *RoomPriceOverrideRepository) Create(ctx context.Context, override *models.RoomPriceOverride) error {
+	return r.db.WithContext(ctx).Create(override).Error
+}
+
+// ListActiveByRoom 获取房间的启用价格覆盖，按优先级倒序
+func (r *RoomPriceOverrideRepository) ListActiveByRoom(ctx context.Context, roomID int64) ([]*models.RoomPriceOverride, error) {
+	var overrides []*models.RoomPriceOverride
+	err := r.db.WithContext(ctx).
+		Where("room_id = ?", roomID).
+		Where("is_active = ?", true).
+		Order("priority DESC, id ASC").
+		Find(&overrides).Error
+	return overrides, err
+}
+
+// Delete 删除价格覆盖
+func (r *RoomPriceOverrideRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&models.RoomPriceOverride{}, id).Error
+}
+
 // ListHotRooms 获取全站热门房型
 func (r *RoomRepository) ListHotRooms(ctx context.Context, limit int) ([]*models.Room, error) {
 	var rooms []*models.Room
@@ -21,7 +21,7 @@ func setupRoomTestDB(t *testing.T) *gorm.DB {
 	})
 	require.NoError(t, err)
 
-	err = db.AutoMigrate(&models.Hotel{}, &models.Room{}, &models.RoomTimeSlot{}, &models.Device{}, &models.Booking{})
+	err = db.AutoMigrate(&models.Hotel{}, &models.Room{}, &models.RoomTimeSlot{}, &models.RoomPriceOverride{}, &models.Device{}, &models.Booking{})
 	require.NoError(t, err)
 
 	return db
@@ -977,3 +977,48 @@ func TestRoomTimeSlotRepository_DeleteByRoom(t *testing.T) {
 	db.Model(&models.RoomTimeSlot{}).Where("room_id = ?", room.ID).Count(&count)
 	assert.Equal(t, int64(0), count)
 }
+
+// RoomPriceOverride repository tests
+
+func TestRoomPriceOverrideRepository_ListActiveByRoom(t *testing.T) {
+	db := setupRoomTestDB(t)
+	repo := NewRoomPriceOverrideRepository(db)
+	ctx := context.Background()
+
+	hotel := &models.Hotel{
+		Name: "测试酒店", Province: "广东省", City: "深圳市", District: "南山区",
+		Address: "addr1", Phone: "123",
+	}
+	db.Create(hotel)
+
+	room := &models.Room{
+		HotelID:     hotel.ID,
+		RoomNo:      "101",
+		RoomType:    models.RoomTypeStandard,
+		HourlyPrice: 100,
+		DailyPrice:  500,
+	}
+	db.Create(room)
+
+	holiday := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	weekend := &models.RoomPriceOverride{RoomID: room.ID, WeekdayMask: models.WeekdayBit(time.Saturday), Price: 150, IsActive: true}
+	national := &models.RoomPriceOverride{RoomID: room.ID, Date: &holiday, Price: 300, Priority: 10, IsActive: true}
+	disabled := &models.RoomPriceOverride{RoomID: room.ID, WeekdayMask: models.WeekdayBit(time.Sunday), Price: 120, IsActive: true}
+	for _, o := range []*models.RoomPriceOverride{weekend, national, disabled} {
+		require.NoError(t, repo.Create(ctx, o))
+	}
+	db.Model(disabled).Update("is_active", false)
+
+	overrides, err := repo.ListActiveByRoom(ctx, room.ID)
+	require.NoError(t, err)
+	require.Len(t, overrides, 2)
+	assert.Equal(t, national.ID, overrides[0].ID)
+	require.NotNil(t, overrides[0].Date)
+	assert.Equal(t, "2026-10-01", overrides[0].Date.Format("2006-01-02"))
+	assert.Equal(t, weekend.ID, overrides[1].ID)
+
+	require.NoError(t, repo.Delete(ctx, weekend.ID))
+	overrides, err = repo.ListActiveByRoom(ctx, room.ID)
+	require.NoError(t, err)
+	assert.Len(t, overrides, 1)
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 )
@@ -70,8 +71,35 @@ type SettlementFilter struct {
 	Type        string
 	TargetID    *int64
 	Status      string
-	PeriodStart *time.Time
-	PeriodEnd   *time.Time
+	PeriodStart *time.Time // 结算周期开始不早于该日期
+	PeriodEnd   *time.Time // 结算周期结束不晚于该日期
+	PeriodFrom  *time.Time // 结算周期与 [PeriodFrom, PeriodTo] 有重叠
+	PeriodTo    *time.Time
+	MinAmount   *float64 // 实际结算金额下限（含）
+	MaxAmount   *float64 // 实际结算金额上限（含）
+	SortBy      string   // 排序字段，仅支持 settlementSortColumns 中的字段，其他值按 created_at 排序
+	SortAsc     bool     // 是否升序，默认降序
+}
+
+// 结算列表可排序字段
+const (
+	SettlementSortCreatedAt    = "created_at"
+	SettlementSortPeriodStart  = "period_start"
+	SettlementSortTotalAmount  = "total_amount"
+	SettlementSortActualAmount = "actual_amount"
+)
+
+// settlementSortColumns 结算列表排序字段白名单
+var settlementSortColumns = map[string]bool{
+	SettlementSortCreatedAt:    true,
+	SettlementSortPeriodStart:  true,
+	SettlementSortTotalAmount:  true,
+	SettlementSortActualAmount: true,
+}
+
+// IsValidSettlementSort 是否为支持的结算列表排序字段
+func IsValidSettlementSort(sortBy string) bool {
+	return settlementSortColumns[sortBy]
 }
 
 // List 获取结算列表
@@ -97,6 +125,18 @@ func (r *SettlementRepository) List(ctx context.Context, filter *SettlementFilte
 		if filter.PeriodEnd != nil {
 			query = query.Where("period_end <= ?", *filter.PeriodEnd)
 		}
+		if filter.PeriodFrom != nil {
+			query = query.Where("period_end >= ?", *filter.PeriodFrom)
+		}
+		if filter.PeriodTo != nil {
+			query = query.Where("period_start <= ?", *filter.PeriodTo)
+		}
+		if filter.MinAmount != nil {
+			query = query.Where("actual_amount >= ?", *filter.MinAmount)
+		}
+		if filter.MaxAmount != nil {
+			query = query.Where("actual_amount <= ?", *filter.MaxAmount)
+		}
 	}
 
 	// 获取总数
@@ -106,7 +146,7 @@ func (r *SettlementRepository) List(ctx context.Context, filter *SettlementFilte
 	}
 
 	// 获取数据
-	err = query.Order("created_at DESC").
+	err = query.Clauses(settlementOrderBy(filter)).
 		Offset(offset).
 		Limit(limit).
 		Find(&settlements).Error
@@ -117,6 +157,42 @@ func (r *SettlementRepository) List(ctx context.Context, filter *SettlementFilte
 	return settlements, total, nil
 }
 
+// settlementOrderBy 结算列表排序，排序字段来自白名单，相同值按 ID 保持稳定顺序
+func settlementOrderBy(filter *SettlementFilter) clause.OrderBy {
+	column, desc := SettlementSortCreatedAt, true
+	if filter != nil {
+		if IsValidSettlementSort(filter.SortBy) {
+			column = filter.SortBy
+		}
+		desc = !filter.SortAsc
+	}
+	return clause.OrderBy{Columns: []clause.OrderByColumn{
+		{Column: clause.Column{Name: column}, Desc: desc},
+		{Column: clause.Column{Name: "id"}, Desc: desc},
+	}}
+}
+
+// ListItems 获取结算明细列表，按订单完成时间排序
+func (r *SettlementRepository) ListItems(ctx context.Context, settlementID int64, offset, limit int) ([]*models.SettlementItem, int64, error) {
+	var items []*models.SettlementItem
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.SettlementItem{}).Where("settlement_id = ?", settlementID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("completed_at ASC, id ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&items).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return items, total, nil
+}
+
 // ListByTarget 获取指定目标的结算列表
 func (r *SettlementRepository) ListByTarget(ctx context.Context, settlementType string, targetID int64, offset, limit int) ([]*models.Settlement, int64, error) {
 	filter := &SettlementFilter{
@@ -195,7 +271,7 @@ func (r *SettlementRepository) CountPending(ctx context.Context) (int64, error)
 	return count, err
 }
 
-// ExistsForPeriod 检查指定周期是否已存在结算记录
+// ExistsForPeriod 检查指定周期是否已存在结算记录（已驳回的结算除外）
 func (r *SettlementRepository) ExistsForPeriod(ctx context.Context, settlementType string, targetID int64, periodStart, periodEnd time.Time) (bool, error) {
 	var count int64
 	err := r.db.WithContext(ctx).Model(&models.Settlement{}).
@@ -203,6 +279,19 @@ func (r *SettlementRepository) ExistsForPeriod(ctx context.Context, settlementTy
 		Where("target_id = ?", targetID).
 		Where("period_start = ?", periodStart).
 		Where("period_end = ?", periodEnd).
+		Where("status <> ?", models.SettlementStatusRejected).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ExistsOverlapping 检查同一结算对象是否存在与指定周期重叠的结算记录（已失败、已驳回的结算除外）
+func (r *SettlementRepository) ExistsOverlapping(ctx context.Context, settlementType string, targetID int64, periodStart, periodEnd time.Time) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Settlement{}).
+		Where("type = ?", settlementType).
+		Where("target_id = ?", targetID).
+		Where("status NOT IN ?", []string{models.SettlementStatusFailed, models.SettlementStatusRejected}).
+		Where("period_start <= ? AND period_end >= ?", periodEnd, periodStart).
 		Count(&count).Error
 	return count > 0, err
 }
@@ -198,6 +198,51 @@ func TestSettlementRepository_List(t *testing.T) {
 	assert.Equal(t, int64(1), total)
 }
 
+func TestSettlementRepository_List_PeriodOverlapAmountAndSort(t *testing.T) {
+	db := setupSettlementTestDB(t)
+	repo := NewSettlementRepository(db)
+	ctx := context.Background()
+
+	date := func(month time.Month, day int) time.Time { return time.Date(2026, month, day, 0, 0, 0, 0, time.UTC) }
+	for _, s := range []*models.Settlement{
+		{SettlementNo: "FEB", PeriodStart: date(2, 1), PeriodEnd: date(2, 27), ActualAmount: 300, TotalAmount: 300},
+		{SettlementNo: "SPAN", PeriodStart: date(2, 28), PeriodEnd: date(3, 3), ActualAmount: 100, TotalAmount: 500},
+		{SettlementNo: "MAR", PeriodStart: date(3, 10), PeriodEnd: date(3, 16), ActualAmount: 200, TotalAmount: 200},
+		{SettlementNo: "APR", PeriodStart: date(3, 31), PeriodEnd: date(4, 6), ActualAmount: 50, TotalAmount: 50},
+	} {
+		s.Type = SettlementTypeMerchant
+		s.TargetID = 1
+		s.Status = models.SettlementStatusPending
+		require.NoError(t, db.Create(s).Error)
+	}
+	numbers := func(list []*models.Settlement) []string {
+		result := make([]string, 0, len(list))
+		for _, s := range list {
+			result = append(result, s.SettlementNo)
+		}
+		return result
+	}
+
+	// 与三月有重叠的结算，按实际金额降序
+	from, to := date(3, 1), date(3, 31)
+	list, total, err := repo.List(ctx, &SettlementFilter{PeriodFrom: &from, PeriodTo: &to, SortBy: SettlementSortActualAmount}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, []string{"MAR", "SPAN", "APR"}, numbers(list))
+
+	// 金额区间
+	minAmount, maxAmount := 100.0, 200.0
+	list, _, err = repo.List(ctx, &SettlementFilter{MinAmount: &minAmount, MaxAmount: &maxAmount, SortBy: SettlementSortPeriodStart, SortAsc: true}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SPAN", "MAR"}, numbers(list))
+
+	// 不支持的排序字段按 created_at 排序
+	list, _, err = repo.List(ctx, &SettlementFilter{SortBy: "settlement_no; DROP TABLE settlements"}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"APR", "MAR", "SPAN", "FEB"}, numbers(list))
+	assert.False(t, IsValidSettlementSort("settlement_no"))
+}
+
 func TestSettlementRepository_ListByTarget(t *testing.T) {
 	db := setupSettlementTestDB(t)
 	repo := NewSettlementRepository(db)
@@ -49,25 +49,7 @@ func (r *TransactionRepository) List(ctx context.Context, filter *TransactionFil
 	var transactions []*models.WalletTransaction
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&models.WalletTransaction{})
-
-	if filter != nil {
-		if filter.UserID != nil {
-			query = query.Where("user_id = ?", *filter.UserID)
-		}
-		if filter.Type != "" {
-			query = query.Where("type = ?", filter.Type)
-		}
-		if filter.OrderNo != "" {
-			query = query.Where("order_no = ?", filter.OrderNo)
-		}
-		if filter.StartDate != nil {
-			query = query.Where("created_at >= ?", *filter.StartDate)
-		}
-		if filter.EndDate != nil {
-			query = query.Where("created_at <= ?", *filter.EndDate)
-		}
-	}
+	query := applyTransactionFilter(r.db.WithContext(ctx).Model(&models.WalletTransaction{}), filter)
 
 	// 获取总数
 	err := query.Count(&total).Error
@@ -87,6 +69,43 @@ func (r *TransactionRepository) List(ctx context.Context, filter *TransactionFil
 	return transactions, total, nil
 }
 
+// ListBatch 按 ID 倒序分批获取交易记录，beforeID 为 0 时从最新一条开始
+// 使用主键游标而非偏移量，深度分页时性能稳定，适用于大批量导出
+func (r *TransactionRepository) ListBatch(ctx context.Context, filter *TransactionFilter, beforeID int64, limit int) ([]*models.WalletTransaction, error) {
+	var transactions []*models.WalletTransaction
+
+	query := applyTransactionFilter(r.db.WithContext(ctx).Model(&models.WalletTransaction{}), filter)
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+
+	err := query.Order("id DESC").Limit(limit).Find(&transactions).Error
+	return transactions, err
+}
+
+// applyTransactionFilter 应用交易查询过滤条件
+func applyTransactionFilter(query *gorm.DB, filter *TransactionFilter) *gorm.DB {
+	if filter == nil {
+		return query
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.OrderNo != "" {
+		query = query.Where("order_no = ?", filter.OrderNo)
+	}
+	if filter.StartDate != nil {
+		query = query.Where("created_at >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where("created_at <= ?", *filter.EndDate)
+	}
+	return query
+}
+
 // ListByUser 获取用户交易列表
 func (r *TransactionRepository) ListByUser(ctx context.Context, userID int64, offset, limit int) ([]*models.WalletTransaction, int64, error) {
 	filter := &TransactionFilter{
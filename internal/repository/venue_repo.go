@@ -132,6 +132,25 @@ func (r *VenueRepository) ListNearby(ctx context.Context, longitude, latitude fl
 	return venues, err
 }
 
+// ListInBoundingBox 获取经纬度范围内的正常场地
+// 经度范围跨越 ±180 度时 minLongitude > maxLongitude，此时按两段范围匹配
+func (r *VenueRepository) ListInBoundingBox(ctx context.Context, minLatitude, maxLatitude, minLongitude, maxLongitude float64) ([]*models.Venue, error) {
+	var venues []*models.Venue
+
+	query := r.db.WithContext(ctx).
+		Where("status = ?", models.VenueStatusActive).
+		Where("latitude IS NOT NULL AND longitude IS NOT NULL").
+		Where("latitude BETWEEN ? AND ?", minLatitude, maxLatitude)
+	if minLongitude <= maxLongitude {
+		query = query.Where("longitude BETWEEN ? AND ?", minLongitude, maxLongitude)
+	} else {
+		query = query.Where("longitude >= ? OR longitude <= ?", minLongitude, maxLongitude)
+	}
+
+	err := query.Order("id").Find(&venues).Error
+	return venues, err
+}
+
 // ListByCity 获取城市下的场地列表
 func (r *VenueRepository) ListByCity(ctx context.Context, city string, offset, limit int) ([]*models.Venue, int64, error) {
 	var venues []*models.Venue
@@ -225,3 +244,15 @@ func (r *VenueRepository) ListByMerchantSimple(ctx context.Context, merchantID i
 	err := r.db.WithContext(ctx).Where("merchant_id = ?", merchantID).Order("id DESC").Find(&venues).Error
 	return venues, err
 }
+
+// ListExistingIDs 返回给定场地 ID 中存在的 ID
+func (r *VenueRepository) ListExistingIDs(ctx context.Context, ids []int64) ([]int64, error) {
+	var existing []int64
+	if len(ids) == 0 {
+		return existing, nil
+	}
+	err := r.db.WithContext(ctx).Model(&models.Venue{}).
+		Where("id IN ?", ids).
+		Pluck("id", &existing).Error
+	return existing, err
+}
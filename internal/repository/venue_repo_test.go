@@ -379,6 +379,45 @@ func TestVenueRepository_ListNearby(t *testing.T) {
 	assert.Equal(t, 1, len(list)) // 只有附近场地1
 }
 
+func TestVenueRepository_ListInBoundingBox(t *testing.T) {
+	db := setupVenueTestDB(t)
+	repo := NewVenueRepository(db)
+	ctx := context.Background()
+
+	create := func(name string, lat, lng float64) *models.Venue {
+		venue := &models.Venue{
+			MerchantID: 1, Name: name, Type: models.VenueTypeMall,
+			Province: "广东省", City: "深圳市", District: "南山区", Address: "科技园",
+			Longitude: &lng, Latitude: &lat,
+			Status: models.VenueStatusActive,
+		}
+		require.NoError(t, db.Create(venue).Error)
+		return venue
+	}
+	inside := create("范围内", 22.5, 114.0)
+	create("纬度超出", 23.5, 114.0)
+	create("经度超出", 22.5, 115.0)
+	db.Create(&models.Venue{
+		MerchantID: 1, Name: "无经纬度场地", Type: models.VenueTypeMall,
+		Province: "广东省", City: "深圳市", District: "福田区", Address: "福华路",
+		Status: models.VenueStatusActive,
+	})
+
+	list, err := repo.ListInBoundingBox(ctx, 22.4, 22.6, 113.9, 114.1)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, inside.ID, list[0].ID)
+
+	// 跨越 ±180 度经线
+	west := create("日界线西侧", 0, -179.9)
+	east := create("日界线东侧", 0, 179.9)
+	list, err = repo.ListInBoundingBox(ctx, -1, 1, 179.5, -179.5)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, west.ID, list[0].ID)
+	assert.Equal(t, east.ID, list[1].ID)
+}
+
 func TestVenueRepository_ListByCity(t *testing.T) {
 	db := setupVenueTestDB(t)
 	repo := NewVenueRepository(db)
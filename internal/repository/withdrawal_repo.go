@@ -303,3 +303,54 @@ func (r *WithdrawalRepository) ExistsWithdrawalNo(ctx context.Context, withdrawa
 	err := r.db.WithContext(ctx).Model(&models.Withdrawal{}).Where("withdrawal_no = ?", withdrawalNo).Count(&count).Error
 	return count > 0, err
 }
+
+// CreateAuditLog 在事务中写入提现审核日志
+func (r *WithdrawalRepository) CreateAuditLog(ctx context.Context, tx *gorm.DB, log *models.WithdrawalAuditLog) error {
+	return tx.WithContext(ctx).Create(log).Error
+}
+
+// ListAuditLogs 获取提现审核日志（按时间正序）
+func (r *WithdrawalRepository) ListAuditLogs(ctx context.Context, withdrawalID int64) ([]*models.WithdrawalAuditLog, error) {
+	var logs []*models.WithdrawalAuditLog
+	err := r.db.WithContext(ctx).
+		Where("withdrawal_id = ?", withdrawalID).
+		Order("created_at ASC, id ASC").
+		Find(&logs).Error
+	return logs, err
+}
+
+// GetPolicy 获取提现类型的提现规则
+func (r *WithdrawalRepository) GetPolicy(ctx context.Context, withdrawalType string) (*models.WithdrawalPolicy, error) {
+	var policy models.WithdrawalPolicy
+	err := r.db.WithContext(ctx).Where("type = ?", withdrawalType).First(&policy).Error
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// ListPolicies 获取全部提现规则
+func (r *WithdrawalRepository) ListPolicies(ctx context.Context) ([]*models.WithdrawalPolicy, error) {
+	var policies []*models.WithdrawalPolicy
+	err := r.db.WithContext(ctx).Order("type ASC").Find(&policies).Error
+	return policies, err
+}
+
+// SavePolicy 在事务中保存提现规则，同一类型已存在时覆盖
+func (r *WithdrawalRepository) SavePolicy(ctx context.Context, tx *gorm.DB, policy *models.WithdrawalPolicy) error {
+	return tx.WithContext(ctx).Save(policy).Error
+}
+
+// DailyUsageTx 在事务中统计用户自 since 起某类型提现的次数和金额，已拒绝的提现不计入
+func (r *WithdrawalRepository) DailyUsageTx(ctx context.Context, tx *gorm.DB, userID int64, withdrawalType string, since time.Time) (int64, float64, error) {
+	var usage struct {
+		Count  int64
+		Amount float64
+	}
+	err := tx.WithContext(ctx).Model(&models.Withdrawal{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Where("user_id = ? AND type = ? AND status <> ? AND created_at >= ?",
+			userID, withdrawalType, models.WithdrawalStatusRejected, since).
+		Scan(&usage).Error
+	return usage.Count, usage.Amount, err
+}
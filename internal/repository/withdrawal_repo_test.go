@@ -4,6 +4,7 @@ package repository
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -20,7 +21,7 @@ func setupWithdrawalTestDB(t *testing.T) *gorm.DB {
 	})
 	require.NoError(t, err)
 
-	err = db.AutoMigrate(&models.Withdrawal{}, &models.User{}, &models.Admin{})
+	err = db.AutoMigrate(&models.Withdrawal{}, &models.WithdrawalPolicy{}, &models.User{}, &models.Admin{})
 	require.NoError(t, err)
 
 	return db
@@ -514,3 +515,54 @@ func TestWithdrawalRepository_ExistsWithdrawalNo(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, exists)
 }
+
+func TestWithdrawalRepository_Policy(t *testing.T) {
+	db := setupWithdrawalTestDB(t)
+	repo := NewWithdrawalRepository(db)
+	ctx := context.Background()
+
+	_, err := repo.GetPolicy(ctx, models.WithdrawalTypeWallet)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	policy := &models.WithdrawalPolicy{Type: models.WithdrawalTypeWallet, MinAmount: 10, FeePercent: 0.006, DailyCountLimit: 3}
+	require.NoError(t, repo.SavePolicy(ctx, db, policy))
+	require.NoError(t, repo.SavePolicy(ctx, db, &models.WithdrawalPolicy{Type: models.WithdrawalTypeCommission, MinAmount: 20}))
+
+	policy.MinAmount = 15
+	require.NoError(t, repo.SavePolicy(ctx, db, policy))
+
+	found, err := repo.GetPolicy(ctx, models.WithdrawalTypeWallet)
+	require.NoError(t, err)
+	assert.Equal(t, 15.0, found.MinAmount)
+
+	policies, err := repo.ListPolicies(ctx)
+	require.NoError(t, err)
+	require.Len(t, policies, 2)
+	assert.Equal(t, models.WithdrawalTypeCommission, policies[0].Type)
+}
+
+func TestWithdrawalRepository_DailyUsageTx(t *testing.T) {
+	db := setupWithdrawalTestDB(t)
+	repo := NewWithdrawalRepository(db)
+	ctx := context.Background()
+
+	create := func(no, withdrawalType, status string, amount float64) *models.Withdrawal {
+		w := &models.Withdrawal{
+			WithdrawalNo: no, UserID: 1, Type: withdrawalType, Amount: amount, ActualAmount: amount,
+			WithdrawTo: models.WithdrawToWechat, AccountInfoEncrypted: "{}", Status: status,
+		}
+		require.NoError(t, repo.Create(ctx, w))
+		return w
+	}
+	create("WDU1", models.WithdrawalTypeWallet, models.WithdrawalStatusPending, 20)
+	create("WDU2", models.WithdrawalTypeWallet, models.WithdrawalStatusSuccess, 30)
+	create("WDU3", models.WithdrawalTypeWallet, models.WithdrawalStatusRejected, 100)
+	create("WDU4", models.WithdrawalTypeCommission, models.WithdrawalStatusPending, 50)
+	yesterday := create("WDU5", models.WithdrawalTypeWallet, models.WithdrawalStatusPending, 40)
+	require.NoError(t, db.Model(yesterday).Update("created_at", time.Now().Add(-48*time.Hour)).Error)
+
+	count, amount, err := repo.DailyUsageTx(ctx, db, 1, models.WithdrawalTypeWallet, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, 50.0, amount)
+}
@@ -40,46 +40,6 @@ func NewTaskHandler(
 	}
 }
 
-// CloseExpiredRentals 关闭过期的待支付租借
-func (h *TaskHandler) CloseExpiredRentals(ctx context.Context) error {
-	expiredBefore := time.Now().Add(-30 * time.Minute) // 30分钟未支付自动关闭
-
-	rentals, err := h.rentalRepo.GetExpiredPending(ctx, expiredBefore, 100)
-	if err != nil {
-		return err
-	}
-
-	if len(rentals) == 0 {
-		return nil
-	}
-
-	log.Printf("[Task] Found %d expired rentals to close", len(rentals))
-
-	for _, rental := range rentals {
-		err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-			// 更新租借状态
-			if err := tx.Model(rental).Update("status", models.RentalStatusCancelled).Error; err != nil {
-				return err
-			}
-
-			// 恢复设备可用槽位
-			if err := tx.Model(&models.Device{}).
-				Where("id = ?", rental.DeviceID).
-				UpdateColumn("available_slots", gorm.Expr("available_slots + 1")).Error; err != nil {
-				return err
-			}
-
-			return nil
-		})
-
-		if err != nil {
-			log.Printf("[Task] Failed to close rental %d: %v", rental.ID, err)
-		}
-	}
-
-	return nil
-}
-
 // HandleOverdueRentals 处理超时未还的租借
 func (h *TaskHandler) HandleOverdueRentals(ctx context.Context) error {
 	rentals, err := h.rentalRepo.GetOverdue(ctx, 100)
@@ -169,9 +129,6 @@ func (h *TaskHandler) CompleteReturnedRentals(ctx context.Context) error {
 
 // SetupTasks 设置所有任务
 func SetupTasks(scheduler *Scheduler, handler *TaskHandler) {
-	// 每分钟检查过期租借
-	scheduler.AddTask("CloseExpiredRentals", 1*time.Minute, handler.CloseExpiredRentals)
-
 	// 每分钟检查超时租借
 	scheduler.AddTask("HandleOverdueRentals", 1*time.Minute, handler.HandleOverdueRentals)
 
@@ -89,7 +89,7 @@ func (s *AdminAuthService) Login(ctx context.Context, req *LoginRequest) (*Login
 	if admin.Role != nil {
 		roleCode = admin.Role.Code
 	}
-	tokenPair, err := s.jwtManager.GenerateTokenPair(admin.ID, jwt.UserTypeAdmin, roleCode)
+	tokenPair, err := s.jwtManager.IssueTokenPair(ctx, admin.ID, jwt.UserTypeAdmin, roleCode)
 	if err != nil {
 		return nil, err
 	}
@@ -176,8 +176,14 @@ func (s *AdminAuthService) ChangePassword(ctx context.Context, adminID int64, re
 }
 
 // RefreshToken 刷新令牌
+// 刷新令牌使用后立即失效；已使用的刷新令牌被再次提交时吊销整个登录会话
 func (s *AdminAuthService) RefreshToken(ctx context.Context, refreshToken string) (*jwt.TokenPair, error) {
-	return s.jwtManager.RefreshToken(refreshToken)
+	return s.jwtManager.RotateRefreshToken(ctx, refreshToken)
+}
+
+// Logout 退出登录，吊销当前令牌所属的登录会话
+func (s *AdminAuthService) Logout(ctx context.Context, tokenID string) error {
+	return s.jwtManager.Revoke(ctx, tokenID)
 }
 
 // toAdminInfo 转换为管理员信息
@@ -218,7 +224,10 @@ func (s *AdminAuthService) ValidateAdminToken(ctx context.Context, token string)
 		return nil, err
 	}
 
-	// 验证用户类型
+	// 验证令牌及用户类型
+	if claims.TokenType == jwt.TokenTypeRefresh {
+		return nil, jwt.ErrTokenInvalid
+	}
 	if claims.UserType != jwt.UserTypeAdmin {
 		return nil, errors.New("invalid user type")
 	}
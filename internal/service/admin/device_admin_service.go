@@ -17,15 +17,18 @@ import (
 // DeviceAdminService 设备管理服务
 type DeviceAdminService struct {
 	deviceRepo            *repository.DeviceRepository
+	deviceSlotRepo        *repository.DeviceSlotRepository
 	deviceLogRepo         *repository.DeviceLogRepository
 	deviceMaintenanceRepo *repository.DeviceMaintenanceRepository
 	venueRepo             *repository.VenueRepository
 	mqttService           *device.MQTTService
+	adminNotifier         AdminNotifier
 }
 
 // NewDeviceAdminService 创建设备管理服务
 func NewDeviceAdminService(
 	deviceRepo *repository.DeviceRepository,
+	deviceSlotRepo *repository.DeviceSlotRepository,
 	deviceLogRepo *repository.DeviceLogRepository,
 	deviceMaintenanceRepo *repository.DeviceMaintenanceRepository,
 	venueRepo *repository.VenueRepository,
@@ -33,6 +36,7 @@ func NewDeviceAdminService(
 ) *DeviceAdminService {
 	return &DeviceAdminService{
 		deviceRepo:            deviceRepo,
+		deviceSlotRepo:        deviceSlotRepo,
 		deviceLogRepo:         deviceLogRepo,
 		deviceMaintenanceRepo: deviceMaintenanceRepo,
 		venueRepo:             venueRepo,
@@ -45,10 +49,13 @@ var (
 	ErrDeviceNotFound       = commonErrors.ErrDeviceNotFound
 	ErrDeviceNoExists       = commonErrors.ErrAlreadyExists.WithMessage("设备编号已存在")
 	ErrVenueNotFound        = commonErrors.ErrVenueNotFound
-	ErrDeviceInUse          = commonErrors.ErrDeviceBusy.WithMessage("设备正在使用中")
+	ErrDeviceInUse          = commonErrors.ErrDeviceInUse
 	ErrDeviceOffline        = commonErrors.ErrDeviceOffline
 	ErrMaintenanceNotFound  = commonErrors.ErrNotFound.WithMessage("维护记录不存在")
 	ErrMaintenanceCompleted = commonErrors.ErrInvalidParams.WithMessage("维护已完成")
+	ErrDeviceSlotNotFound   = commonErrors.ErrNotFound.WithMessage("格口不存在")
+	ErrDeviceSlotInUse      = commonErrors.ErrDeviceInUse.WithMessage("格口关联的租借仍在进行中")
+	ErrDeviceSlotsOccupied  = commonErrors.ErrDeviceInUse.WithMessage("超出新格口数的格口正在使用中")
 )
 
 // DeviceInfo 设备信息
@@ -77,6 +84,10 @@ type DeviceInfo struct {
 	LastHeartbeatAt *time.Time `json:"last_heartbeat_at,omitempty"`
 	Status          int8       `json:"status"`
 	CreatedAt       time.Time  `json:"created_at"`
+
+	MaintenanceMode      bool       `json:"maintenance_mode"`
+	MaintenanceReason    string     `json:"maintenance_reason,omitempty"`
+	MaintenanceRestoreAt *time.Time `json:"maintenance_restore_at,omitempty"`
 }
 
 // CreateDeviceRequest 创建设备请求
@@ -112,6 +123,20 @@ func (s *DeviceAdminService) CreateDevice(ctx context.Context, req *CreateDevice
 		return nil, err
 	}
 
+	device := newDevice(req)
+
+	if err := s.deviceRepo.Create(ctx, device); err != nil {
+		return nil, err
+	}
+
+	// 记录日志
+	s.createDeviceLog(ctx, device.ID, models.DeviceLogTypeOnline, "设备创建", &operatorID, models.DeviceLogOperatorAdmin)
+
+	return device, nil
+}
+
+// newDevice 按创建请求构造新设备，单个创建与批量导入共用
+func newDevice(req *CreateDeviceRequest) *models.Device {
 	slotCount := req.SlotCount
 	if slotCount == 0 {
 		slotCount = 1
@@ -122,7 +147,7 @@ func (s *DeviceAdminService) CreateDevice(ctx context.Context, req *CreateDevice
 		networkType = "WiFi"
 	}
 
-	device := &models.Device{
+	return &models.Device{
 		DeviceNo:       req.DeviceNo,
 		Name:           req.Name,
 		Type:           req.Type,
@@ -139,15 +164,6 @@ func (s *DeviceAdminService) CreateDevice(ctx context.Context, req *CreateDevice
 		NetworkType:    networkType,
 		Status:         models.DeviceStatusActive,
 	}
-
-	if err := s.deviceRepo.Create(ctx, device); err != nil {
-		return nil, err
-	}
-
-	// 记录日志
-	s.createDeviceLog(ctx, device.ID, models.DeviceLogTypeOnline, "设备创建", &operatorID, models.DeviceLogOperatorAdmin)
-
-	return device, nil
 }
 
 // UpdateDeviceRequest 更新设备请求
@@ -189,9 +205,21 @@ func (s *DeviceAdminService) UpdateDevice(ctx context.Context, id int64, req *Up
 	device.VenueID = req.VenueID
 	device.ProductName = req.ProductName
 	device.ProductImage = req.ProductImage
-	device.SlotCount = req.SlotCount
 	device.NetworkType = req.NetworkType
 
+	// 格口数变化时先同步增减格口并校正可用槽位
+	if device.SlotCount != req.SlotCount {
+		available, err := s.deviceSlotRepo.Resize(ctx, device.ID, req.SlotCount)
+		if err != nil {
+			if errors.Is(err, repository.ErrSlotsOccupied) {
+				return ErrDeviceSlotsOccupied
+			}
+			return err
+		}
+		device.AvailableSlots = available
+	}
+	device.SlotCount = req.SlotCount
+
 	return s.deviceRepo.Update(ctx, device)
 }
 
@@ -231,6 +259,30 @@ func (s *DeviceAdminService) UpdateDeviceStatus(ctx context.Context, id int64, s
 	return nil
 }
 
+// DeleteDevice 删除设备（软删除）
+// 设备编号删除后可重新使用，历史租借和结算仍保留对该设备的引用
+func (s *DeviceAdminService) DeleteDevice(ctx context.Context, id int64, operatorID int64) error {
+	device, err := s.deviceRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDeviceNotFound
+		}
+		return err
+	}
+
+	if device.RentalStatus == models.DeviceRentalInUse || device.CurrentRentalID != nil {
+		return ErrDeviceInUse
+	}
+
+	if err := s.deviceRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.createDeviceLog(ctx, id, models.DeviceLogTypeError, "设备删除", &operatorID, models.DeviceLogOperatorAdmin)
+
+	return nil
+}
+
 // GetDevice 获取设备详情
 func (s *DeviceAdminService) GetDevice(ctx context.Context, id int64) (*DeviceInfo, error) {
 	device, err := s.deviceRepo.GetByIDWithVenue(ctx, id)
@@ -377,7 +429,7 @@ func (s *DeviceAdminService) CreateMaintenance(ctx context.Context, req *CreateM
 	}
 
 	// 记录日志
-	s.createDeviceLog(ctx, req.DeviceID, models.DeviceLogTypeError, "开始维护: "+req.Description, &operatorID, models.DeviceLogOperatorAdmin)
+	s.createDeviceLog(ctx, req.DeviceID, models.DeviceLogTypeMaintenance, "开始维护: "+req.Description, &operatorID, models.DeviceLogOperatorAdmin)
 
 	return maintenance, nil
 }
@@ -418,7 +470,7 @@ func (s *DeviceAdminService) CompleteMaintenance(ctx context.Context, id int64,
 	}
 
 	// 记录日志
-	s.createDeviceLog(ctx, maintenance.DeviceID, models.DeviceLogTypeError, "维护完成", &operatorID, models.DeviceLogOperatorAdmin)
+	s.createDeviceLog(ctx, maintenance.DeviceID, models.DeviceLogTypeMaintenance, "维护完成", &operatorID, models.DeviceLogOperatorAdmin)
 
 	return nil
 }
@@ -502,6 +554,10 @@ func (s *DeviceAdminService) toDeviceInfo(device *models.Device) *DeviceInfo {
 		LastHeartbeatAt: device.LastHeartbeatAt,
 		Status:          device.Status,
 		CreatedAt:       device.CreatedAt,
+
+		MaintenanceMode:      device.MaintenanceMode,
+		MaintenanceReason:    device.MaintenanceReason,
+		MaintenanceRestoreAt: device.MaintenanceRestoreAt,
 	}
 
 	if device.Venue != nil {
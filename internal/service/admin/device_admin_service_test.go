@@ -41,10 +41,12 @@ func setupDeviceAdminTestDB(t *testing.T) *gorm.DB {
 
 	err = db.AutoMigrate(
 		&models.Device{},
+		&models.DeviceSlot{},
 		&models.DeviceLog{},
 		&models.DeviceMaintenance{},
 		&models.Venue{},
 		&models.Merchant{},
+		&models.RentalPricing{},
 	)
 	require.NoError(t, err)
 
@@ -102,12 +104,13 @@ func createTestDevice(t *testing.T, db *gorm.DB, deviceNo string, venue *models.
 func setupDeviceAdminService(t *testing.T) (*DeviceAdminService, *gorm.DB, *MockMQTTService) {
 	db := setupDeviceAdminTestDB(t)
 	deviceRepo := repository.NewDeviceRepository(db)
+	deviceSlotRepo := repository.NewDeviceSlotRepository(db)
 	deviceLogRepo := repository.NewDeviceLogRepository(db)
 	deviceMaintenanceRepo := repository.NewDeviceMaintenanceRepository(db)
 	venueRepo := repository.NewVenueRepository(db)
 	mockMQTT := new(MockMQTTService)
 
-	service := NewDeviceAdminService(deviceRepo, deviceLogRepo, deviceMaintenanceRepo, venueRepo, nil)
+	service := NewDeviceAdminService(deviceRepo, deviceSlotRepo, deviceLogRepo, deviceMaintenanceRepo, venueRepo, nil)
 
 	return service, db, mockMQTT
 }
@@ -282,6 +285,50 @@ func TestDeviceAdminService_UpdateDeviceStatus_DeviceInUse(t *testing.T) {
 	assert.Equal(t, ErrDeviceInUse, err)
 }
 
+func TestDeviceAdminService_DeleteDevice_Success(t *testing.T) {
+	service, db, _ := setupDeviceAdminService(t)
+	ctx := context.Background()
+
+	venue := createTestVenue(t, db)
+	device := createTestDevice(t, db, "DEV_DELETE", venue)
+
+	err := service.DeleteDevice(ctx, device.ID, 1)
+	require.NoError(t, err)
+
+	// 删除后查询不到设备
+	_, err = service.GetDevice(ctx, device.ID)
+	assert.Equal(t, ErrDeviceNotFound, err)
+
+	// 设备编号可以重新使用
+	reused, err := service.CreateDevice(ctx, &CreateDeviceRequest{
+		DeviceNo:    "DEV_DELETE",
+		Name:        "替换设备",
+		Type:        "standard",
+		VenueID:     venue.ID,
+		ProductName: "测试产品",
+		SlotCount:   1,
+	}, 1)
+	require.NoError(t, err)
+	assert.NotEqual(t, device.ID, reused.ID)
+}
+
+func TestDeviceAdminService_DeleteDevice_DeviceInUse(t *testing.T) {
+	service, db, _ := setupDeviceAdminService(t)
+	ctx := context.Background()
+
+	venue := createTestVenue(t, db)
+	device := createTestDevice(t, db, "DEV_DELETE_INUSE", venue)
+
+	err := db.Model(device).Update("rental_status", models.DeviceRentalInUse).Error
+	require.NoError(t, err)
+
+	err = service.DeleteDevice(ctx, device.ID, 1)
+	assert.Equal(t, ErrDeviceInUse, err)
+
+	var found models.Device
+	require.NoError(t, db.First(&found, device.ID).Error)
+}
+
 func TestDeviceAdminService_GetDevice_Success(t *testing.T) {
 	service, db, _ := setupDeviceAdminService(t)
 	ctx := context.Background()
@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// deviceBatchImportColumns 跨场地导入文件必需的列
+var deviceBatchImportColumns = []string{"device_no", "name", "type", "venue_id", "slot_count", "network_type"}
+
+// deviceBatchImportOptionalColumns 跨场地导入文件可选的列，product_name 为空时使用设备名称
+var deviceBatchImportOptionalColumns = []string{"product_name"}
+
+// DeviceImportService 设备批量导入服务，导入文件的每行设备可属于不同场地
+// 行校验与创建复用场地设备导入的逻辑
+type DeviceImportService struct {
+	deviceService *DeviceAdminService
+}
+
+// NewDeviceImportService 创建设备批量导入服务
+func NewDeviceImportService(deviceService *DeviceAdminService) *DeviceImportService {
+	return &DeviceImportService{deviceService: deviceService}
+}
+
+// ImportRowFailure 导入失败的行
+type ImportRowFailure struct {
+	Row    int    `json:"row"` // 文件行号（表头为第 1 行）
+	Reason string `json:"reason"`
+}
+
+// ImportReport 批量导入结果报告
+type ImportReport struct {
+	TotalRows int                 `json:"total_rows"`
+	Succeeded int                 `json:"succeeded"`
+	Failed    []*ImportRowFailure `json:"failed"`
+}
+
+// Import 从 CSV 批量导入设备
+// 列：device_no, name, type, venue_id, slot_count, network_type，可选 product_name。
+// 逐行校验字段、文件内及与已有设备的编号重复、场地是否存在；无效行在报告中列出，不影响有效行的导入。
+// 创建的设备按 operatorID 记录设备日志
+func (s *DeviceImportService) Import(ctx context.Context, r io.Reader, operatorID int64) (*ImportReport, error) {
+	records, err := readDeviceImportCSV(r, deviceBatchImportColumns, deviceBatchImportOptionalColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{
+		TotalRows: len(records),
+		Failed:    []*ImportRowFailure{},
+	}
+	reject := func(line int, _, reason string) {
+		report.Failed = append(report.Failed, &ImportRowFailure{Row: line, Reason: reason})
+	}
+
+	devices, err := s.deviceService.validateDeviceImportRecords(ctx, records, func(fields map[string]string) (int64, string) {
+		venueID, err := strconv.ParseInt(fields["venue_id"], 10, 64)
+		if err != nil || venueID <= 0 {
+			return 0, "场地ID必须为正整数"
+		}
+		if fields["product_name"] == "" {
+			fields["product_name"] = fields["name"]
+		}
+		return venueID, ""
+	}, reject)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.deviceService.createImportedDevices(ctx, devices, operatorID); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(report.Failed, func(i, j int) bool { return report.Failed[i].Row < report.Failed[j].Row })
+	report.Succeeded = len(devices)
+	return report, nil
+}
@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+const deviceBatchImportHeader = "device_no,name,type,venue_id,slot_count,network_type\n"
+
+func setupDeviceImportService(t *testing.T) (*DeviceImportService, *gorm.DB, *models.Venue, *models.Venue) {
+	t.Helper()
+	deviceService, db, _ := setupDeviceAdminService(t)
+	first := createTestVenue(t, db)
+	second := createTestVenue(t, db)
+	createTestDevice(t, db, "BATCH-EXIST", first)
+	return NewDeviceImportService(deviceService), db, first, second
+}
+
+func TestDeviceImportService_Import(t *testing.T) {
+	svc, db, first, second := setupDeviceImportService(t)
+	ctx := context.Background()
+
+	csv := deviceBatchImportHeader +
+		fmt.Sprintf("BATCH-001,一号柜,standard,%d,10,WiFi\n", first.ID) +
+		fmt.Sprintf("BATCH-002,二号柜,mini,%d,4,\n", second.ID) +
+		fmt.Sprintf("BATCH-001,重复柜,standard,%d,10,WiFi\n", second.ID) + // 文件内重复
+		fmt.Sprintf("BATCH-EXIST,已存在柜,standard,%d,10,4G\n", first.ID) + // 与已有设备重复
+		"BATCH-003,三号柜,standard,99999,10,WiFi\n" + // 场地不存在
+		"BATCH-004,四号柜,standard,,10,WiFi\n" + // 缺少场地
+		fmt.Sprintf("BATCH-005,五号柜,premium,%d,0,Ethernet\n", first.ID)
+
+	report, err := svc.Import(ctx, strings.NewReader(csv), 9)
+	require.NoError(t, err)
+
+	assert.Equal(t, 7, report.TotalRows)
+	assert.Equal(t, 2, report.Succeeded)
+	assert.Equal(t, []*ImportRowFailure{
+		{Row: 4, Reason: "设备编号与第2行重复"},
+		{Row: 5, Reason: "设备编号已存在"},
+		{Row: 6, Reason: "场地不存在: 99999"},
+		{Row: 7, Reason: "场地ID必须为正整数"},
+		{Row: 8, Reason: "格口数必须为正整数"},
+	}, report.Failed)
+
+	var devices []*models.Device
+	require.NoError(t, db.Where("device_no LIKE ?", "BATCH-00%").Order("device_no").Find(&devices).Error)
+	require.Len(t, devices, 2)
+	assert.Equal(t, first.ID, devices[0].VenueID)
+	assert.Equal(t, "一号柜", devices[0].ProductName)
+	assert.Equal(t, second.ID, devices[1].VenueID)
+	assert.Equal(t, models.DeviceTypeMini, devices[1].Type)
+	assert.Equal(t, 4, devices[1].AvailableSlots)
+	assert.Equal(t, "WiFi", devices[1].NetworkType)
+
+	var slotCount int64
+	require.NoError(t, db.Model(&models.DeviceSlot{}).Where("device_id = ?", devices[1].ID).Count(&slotCount).Error)
+	assert.Equal(t, int64(4), slotCount)
+
+	// 导入的设备按操作管理员记录设备日志
+	var logs []*models.DeviceLog
+	require.NoError(t, db.Where("device_id IN ?", []int64{devices[0].ID, devices[1].ID}).Find(&logs).Error)
+	require.Len(t, logs, 2)
+	for _, log := range logs {
+		require.NotNil(t, log.OperatorID)
+		assert.Equal(t, int64(9), *log.OperatorID)
+		assert.Equal(t, models.DeviceLogOperatorAdmin, *log.OperatorType)
+	}
+}
+
+func TestDeviceImportService_Import_ProductName(t *testing.T) {
+	svc, db, venue, _ := setupDeviceImportService(t)
+	ctx := context.Background()
+
+	csv := "device_no,name,type,venue_id,slot_count,network_type,product_name\n" +
+		fmt.Sprintf("BATCH-PN,充电柜,standard,%d,6,4G,充电宝\n", venue.ID)
+
+	report, err := svc.Import(ctx, strings.NewReader(csv), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Succeeded)
+	assert.Empty(t, report.Failed)
+
+	var device models.Device
+	require.NoError(t, db.Where("device_no = ?", "BATCH-PN").First(&device).Error)
+	assert.Equal(t, "充电宝", device.ProductName)
+}
+
+func TestDeviceImportService_Import_InvalidFile(t *testing.T) {
+	svc, _, _, _ := setupDeviceImportService(t)
+	ctx := context.Background()
+
+	_, err := svc.Import(ctx, strings.NewReader("device_no,name,type,slot_count,network_type\nA,B,standard,1,WiFi\n"), 1)
+	require.Error(t, err)
+	assert.Equal(t, appErrors.ErrInvalidParams.Code, err.(*appErrors.AppError).Code)
+	assert.Contains(t, err.Error(), "venue_id")
+
+	_, err = svc.Import(ctx, strings.NewReader(deviceBatchImportHeader), 1)
+	require.Error(t, err)
+	assert.Equal(t, appErrors.ErrInvalidParams.Code, err.(*appErrors.AppError).Code)
+}
@@ -0,0 +1,302 @@
+// Package admin 提供管理员相关服务
+package admin
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	commonErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// MaxDeviceImportRows 单次导入的最大设备数
+const MaxDeviceImportRows = 1000
+
+// deviceImportColumns 导入文件必需的列
+var deviceImportColumns = []string{"device_no", "name", "type", "slot_count", "network_type", "product_name"}
+
+// ErrDeviceImportRejected 严格模式下存在无效行，整批导入被取消
+var ErrDeviceImportRejected = commonErrors.ErrInvalidParams.WithMessage("导入文件存在无效数据，已取消导入")
+
+// DeviceImportRowError 导入行错误
+type DeviceImportRowError struct {
+	Row      int    `json:"row"` // 文件行号（表头为第 1 行）
+	DeviceNo string `json:"device_no"`
+	Message  string `json:"message"`
+}
+
+// DeviceImportReport 设备导入结果报告
+type DeviceImportReport struct {
+	Total   int                    `json:"total"`
+	Created int                    `json:"created"`
+	Failed  int                    `json:"failed"`
+	Errors  []DeviceImportRowError `json:"errors"`
+	Devices []*models.Device       `json:"devices"`
+}
+
+// deviceImportRow 已解析的导入行
+type deviceImportRow struct {
+	line int
+	req  *CreateDeviceRequest
+}
+
+// ImportDevices 从 CSV 批量导入场地设备
+// 列：device_no, name, type, slot_count, network_type, product_name。
+// 有效行在单个事务中创建，无效行在报告中逐行列出；strict 为 true 时只要存在无效行即不创建任何设备
+func (s *DeviceAdminService) ImportDevices(ctx context.Context, venueID int64, r io.Reader, strict bool, operatorID int64) (*DeviceImportReport, error) {
+	if _, err := s.venueRepo.GetByID(ctx, venueID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVenueNotFound
+		}
+		return nil, err
+	}
+
+	records, err := readDeviceImportCSV(r, deviceImportColumns, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DeviceImportReport{
+		Total:   len(records),
+		Errors:  []DeviceImportRowError{},
+		Devices: []*models.Device{},
+	}
+	reject := func(line int, deviceNo, message string) {
+		report.Errors = append(report.Errors, DeviceImportRowError{Row: line, DeviceNo: deviceNo, Message: message})
+	}
+
+	devices, err := s.validateDeviceImportRecords(ctx, records, func(map[string]string) (int64, string) {
+		return venueID, ""
+	}, reject)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(report.Errors, func(i, j int) bool { return report.Errors[i].Row < report.Errors[j].Row })
+	report.Failed = len(report.Errors)
+
+	if strict && report.Failed > 0 {
+		return report, ErrDeviceImportRejected
+	}
+
+	if err := s.createImportedDevices(ctx, devices, operatorID); err != nil {
+		return nil, err
+	}
+
+	report.Created = len(devices)
+	report.Devices = devices
+	return report, nil
+}
+
+// validateDeviceImportRecords 校验导入行的字段、文件内及与已有设备的编号重复、场地是否存在，返回可创建的设备
+// resolveVenue 解析行所属场地并可补全字段，无效时返回错误说明；无效行通过 reject 逐行报告
+func (s *DeviceAdminService) validateDeviceImportRecords(
+	ctx context.Context,
+	records []*deviceImportRecord,
+	resolveVenue func(fields map[string]string) (int64, string),
+	reject func(line int, deviceNo, message string),
+) ([]*models.Device, error) {
+	// 逐行校验字段，并检查文件内设备编号重复
+	var rows []*deviceImportRow
+	seen := make(map[string]int)
+	venueIDs := make(map[int64]bool)
+	for _, record := range records {
+		venueID, msg := resolveVenue(record.fields)
+		if msg != "" {
+			reject(record.line, record.fields["device_no"], msg)
+			continue
+		}
+		req, msg := parseDeviceImportRecord(record.fields, venueID)
+		if msg != "" {
+			reject(record.line, record.fields["device_no"], msg)
+			continue
+		}
+		if first, ok := seen[req.DeviceNo]; ok {
+			reject(record.line, req.DeviceNo, fmt.Sprintf("设备编号与第%d行重复", first))
+			continue
+		}
+		seen[req.DeviceNo] = record.line
+		venueIDs[venueID] = true
+		rows = append(rows, &deviceImportRow{line: record.line, req: req})
+	}
+
+	// 检查与已有设备的编号冲突及场地是否存在
+	deviceNos := make([]string, 0, len(rows))
+	for _, row := range rows {
+		deviceNos = append(deviceNos, row.req.DeviceNo)
+	}
+	existingNos, err := s.deviceRepo.ListExistingDeviceNos(ctx, deviceNos)
+	if err != nil {
+		return nil, err
+	}
+	existingNoSet := make(map[string]bool, len(existingNos))
+	for _, no := range existingNos {
+		existingNoSet[no] = true
+	}
+
+	ids := make([]int64, 0, len(venueIDs))
+	for id := range venueIDs {
+		ids = append(ids, id)
+	}
+	existingVenues, err := s.venueRepo.ListExistingIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	venueSet := make(map[int64]bool, len(existingVenues))
+	for _, id := range existingVenues {
+		venueSet[id] = true
+	}
+
+	devices := make([]*models.Device, 0, len(rows))
+	for _, row := range rows {
+		switch {
+		case existingNoSet[row.req.DeviceNo]:
+			reject(row.line, row.req.DeviceNo, "设备编号已存在")
+		case !venueSet[row.req.VenueID]:
+			reject(row.line, row.req.DeviceNo, fmt.Sprintf("场地不存在: %d", row.req.VenueID))
+		default:
+			devices = append(devices, newDevice(row.req))
+		}
+	}
+	return devices, nil
+}
+
+// createImportedDevices 批量创建导入的设备，并记录操作管理员的设备日志
+func (s *DeviceAdminService) createImportedDevices(ctx context.Context, devices []*models.Device, operatorID int64) error {
+	if err := s.deviceRepo.CreateBatch(ctx, devices); err != nil {
+		return err
+	}
+	for _, device := range devices {
+		s.createDeviceLog(ctx, device.ID, models.DeviceLogTypeOnline, "设备批量导入", &operatorID, models.DeviceLogOperatorAdmin)
+	}
+	return nil
+}
+
+// deviceImportRecord CSV 数据行
+type deviceImportRecord struct {
+	line   int
+	fields map[string]string
+}
+
+// readDeviceImportCSV 读取 CSV 表头与数据行，跳过空行
+// required 为必需的列，optional 为可选的列，缺少的可选列按空值处理
+func readDeviceImportCSV(r io.Reader, required, optional []string) ([]*deviceImportRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, commonErrors.ErrInvalidParams.WithMessage("导入文件为空")
+		}
+		return nil, commonErrors.ErrInvalidParams.WithMessage("导入文件格式错误")
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			// 兼容 Excel 导出的 UTF-8 BOM
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return nil, commonErrors.ErrInvalidParams.WithMessage("导入文件缺少列: " + name)
+		}
+	}
+	names := append(append([]string{}, required...), optional...)
+
+	var records []*deviceImportRecord
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, commonErrors.ErrInvalidParams.WithMessage("导入文件格式错误")
+		}
+		line, _ := reader.FieldPos(0)
+
+		fields := make(map[string]string, len(names))
+		blank := true
+		for _, name := range names {
+			if idx, ok := columns[name]; ok && idx < len(values) {
+				fields[name] = strings.TrimSpace(values[idx])
+				if fields[name] != "" {
+					blank = false
+				}
+			}
+		}
+		if blank {
+			continue
+		}
+
+		records = append(records, &deviceImportRecord{line: line, fields: fields})
+		if len(records) > MaxDeviceImportRows {
+			return nil, commonErrors.ErrInvalidParams.WithMessage(fmt.Sprintf("单次最多导入%d台设备", MaxDeviceImportRows))
+		}
+	}
+
+	if len(records) == 0 {
+		return nil, commonErrors.ErrInvalidParams.WithMessage("导入文件没有设备数据")
+	}
+	return records, nil
+}
+
+// parseDeviceImportRecord 校验导入行并转换为创建请求，校验失败时返回错误说明
+func parseDeviceImportRecord(fields map[string]string, venueID int64) (*CreateDeviceRequest, string) {
+	req := &CreateDeviceRequest{
+		DeviceNo:    fields["device_no"],
+		Name:        fields["name"],
+		Type:        fields["type"],
+		VenueID:     venueID,
+		ProductName: fields["product_name"],
+		NetworkType: fields["network_type"],
+	}
+
+	switch {
+	case req.DeviceNo == "":
+		return nil, "设备编号不能为空"
+	case utf8.RuneCountInString(req.DeviceNo) > 64:
+		return nil, "设备编号不能超过64个字符"
+	case req.Name == "":
+		return nil, "设备名称不能为空"
+	case utf8.RuneCountInString(req.Name) > 100:
+		return nil, "设备名称不能超过100个字符"
+	case req.ProductName == "":
+		return nil, "商品名称不能为空"
+	case utf8.RuneCountInString(req.ProductName) > 100:
+		return nil, "商品名称不能超过100个字符"
+	}
+
+	switch req.Type {
+	case models.DeviceTypeStandard, models.DeviceTypeMini, models.DeviceTypePremium:
+	default:
+		return nil, "未知的设备类型: " + req.Type
+	}
+
+	switch req.NetworkType {
+	case "", "WiFi", "4G", "Ethernet":
+	default:
+		return nil, "未知的网络类型: " + req.NetworkType
+	}
+
+	slotCount, err := strconv.Atoi(fields["slot_count"])
+	if err != nil || slotCount <= 0 {
+		return nil, "格口数必须为正整数"
+	}
+	req.SlotCount = slotCount
+
+	return req, ""
+}
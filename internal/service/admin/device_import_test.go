@@ -0,0 +1,121 @@
+// Package admin 设备批量导入单元测试
+package admin
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+const deviceImportHeader = "device_no,name,type,slot_count,network_type,product_name\n"
+
+func TestDeviceAdminService_ImportDevices_PartialSuccess(t *testing.T) {
+	service, db, _ := setupDeviceAdminService(t)
+	ctx := context.Background()
+	venue := createTestVenue(t, db)
+	createTestDevice(t, db, "IMP-EXIST", venue)
+
+	csv := "\ufeff" + deviceImportHeader +
+		"IMP-001,一号柜,standard,10,WiFi,充电宝\n" +
+		"IMP-002,二号柜,mini,4,,雨伞\n" +
+		"IMP-001,重复柜,standard,10,WiFi,充电宝\n" + // 文件内重复
+		"IMP-EXIST,已存在柜,standard,10,4G,充电宝\n" + // 与已有设备重复
+		"\n" +
+		"IMP-003,三号柜,unknown,10,WiFi,充电宝\n" +
+		"IMP-004,四号柜,premium,0,Ethernet,充电宝\n" +
+		"IMP-005,五号柜,premium,8,Ethernet,充电宝\n"
+
+	report, err := service.ImportDevices(ctx, venue.ID, strings.NewReader(csv), false, 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, 7, report.Total)
+	assert.Equal(t, 3, report.Created)
+	assert.Equal(t, 4, report.Failed)
+	require.Len(t, report.Errors, 4)
+	assert.Equal(t, DeviceImportRowError{Row: 4, DeviceNo: "IMP-001", Message: "设备编号与第2行重复"}, report.Errors[0])
+	assert.Equal(t, DeviceImportRowError{Row: 5, DeviceNo: "IMP-EXIST", Message: "设备编号已存在"}, report.Errors[1])
+	assert.Equal(t, 7, report.Errors[2].Row)
+	assert.Equal(t, "IMP-003", report.Errors[2].DeviceNo)
+	assert.Equal(t, 8, report.Errors[3].Row)
+	assert.Equal(t, "格口数必须为正整数", report.Errors[3].Message)
+
+	require.Len(t, report.Devices, 3)
+	for _, device := range report.Devices {
+		assert.NotZero(t, device.ID)
+		assert.Equal(t, venue.ID, device.VenueID)
+	}
+
+	var mini models.Device
+	require.NoError(t, db.Where("device_no = ?", "IMP-002").First(&mini).Error)
+	assert.Equal(t, "二号柜", mini.Name)
+	assert.Equal(t, models.DeviceTypeMini, mini.Type)
+	assert.Equal(t, 4, mini.SlotCount)
+	assert.Equal(t, 4, mini.AvailableSlots)
+	assert.Equal(t, "WiFi", mini.NetworkType)
+	assert.Equal(t, int8(models.DeviceOffline), mini.OnlineStatus)
+
+	var existing models.Device
+	require.NoError(t, db.Where("device_no = ?", "IMP-EXIST").First(&existing).Error)
+	assert.Equal(t, "测试设备", existing.Name)
+
+	var logCount int64
+	db.Model(&models.DeviceLog{}).Where("content = ?", "设备批量导入").Count(&logCount)
+	assert.Equal(t, int64(3), logCount)
+}
+
+func TestDeviceAdminService_ImportDevices_Strict(t *testing.T) {
+	service, db, _ := setupDeviceAdminService(t)
+	ctx := context.Background()
+	venue := createTestVenue(t, db)
+
+	csv := deviceImportHeader +
+		"STRICT-001,一号柜,standard,10,WiFi,充电宝\n" +
+		"STRICT-001,二号柜,standard,10,WiFi,充电宝\n"
+
+	report, err := service.ImportDevices(ctx, venue.ID, strings.NewReader(csv), true, 1)
+	assert.Equal(t, ErrDeviceImportRejected, err)
+	require.NotNil(t, report)
+	assert.Equal(t, 0, report.Created)
+	assert.Equal(t, 1, report.Failed)
+
+	var count int64
+	db.Model(&models.Device{}).Count(&count)
+	assert.Zero(t, count)
+
+	// 全部有效时严格模式正常导入
+	report, err = service.ImportDevices(ctx, venue.ID, strings.NewReader(deviceImportHeader+"STRICT-002,二号柜,standard,10,WiFi,充电宝\n"), true, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+}
+
+func TestDeviceAdminService_ImportDevices_InvalidFile(t *testing.T) {
+	service, db, _ := setupDeviceAdminService(t)
+	ctx := context.Background()
+	venue := createTestVenue(t, db)
+
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{"空文件", ""},
+		{"缺少列", "device_no,name,type\nA,B,standard\n"},
+		{"没有数据行", deviceImportHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ImportDevices(ctx, venue.ID, strings.NewReader(tt.csv), false, 1)
+			appErr, ok := err.(*appErrors.AppError)
+			require.True(t, ok, "expected AppError, got %v", err)
+			assert.Equal(t, appErrors.ErrInvalidParams.Code, appErr.Code)
+		})
+	}
+
+	_, err := service.ImportDevices(ctx, 99999, strings.NewReader(deviceImportHeader), false, 1)
+	assert.Equal(t, ErrVenueNotFound, err)
+}
@@ -0,0 +1,91 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	commonErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// AdminNotifier 管理员通知发送者
+type AdminNotifier interface {
+	NotifyAdmins(ctx context.Context, notificationType, title, content string, link *string) error
+}
+
+// ErrMaintenanceReasonRequired 开启维护模式未填写原因
+var ErrMaintenanceReasonRequired = commonErrors.ErrInvalidParams.WithMessage("请填写维护原因")
+
+// SetAdminNotifier 设置管理员通知发送者，设备进入维护模式时通知所有管理员，未设置时不通知
+func (s *DeviceAdminService) SetAdminNotifier(notifier AdminNotifier) {
+	s.adminNotifier = notifier
+}
+
+// SetMaintenanceMode 开启设备维护模式
+// 维护模式下设备不接受新的租借，进行中的租借不受影响；已处于维护模式时更新原因及预计恢复时间
+func (s *DeviceAdminService) SetMaintenanceMode(ctx context.Context, deviceID, adminID int64, reason string, estimatedRestoreAt *time.Time) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrMaintenanceReasonRequired
+	}
+
+	device, err := s.deviceRepo.GetByID(ctx, deviceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDeviceNotFound
+		}
+		return err
+	}
+
+	if err := s.deviceRepo.UpdateFields(ctx, deviceID, map[string]interface{}{
+		"maintenance_mode":       true,
+		"maintenance_reason":     reason,
+		"maintenance_restore_at": estimatedRestoreAt,
+	}); err != nil {
+		return err
+	}
+
+	s.createDeviceLog(ctx, deviceID, models.DeviceLogTypeMaintenance, "开启维护模式: "+reason, &adminID, models.DeviceLogOperatorAdmin)
+
+	// 通知发送失败不影响维护模式设置
+	if s.adminNotifier != nil {
+		content := fmt.Sprintf("设备 %s（%s）已进入维护模式，暂停租借。原因：%s", device.DeviceNo, device.Name, reason)
+		if estimatedRestoreAt != nil {
+			content += "，预计恢复时间：" + estimatedRestoreAt.Format("2006-01-02 15:04")
+		}
+		link := fmt.Sprintf("/devices/%d", deviceID)
+		_ = s.adminNotifier.NotifyAdmins(ctx, models.NotificationTypeDevice, "设备进入维护模式", content, &link)
+	}
+
+	return nil
+}
+
+// ClearMaintenanceMode 关闭设备维护模式，恢复租借；设备未处于维护模式时不做处理
+func (s *DeviceAdminService) ClearMaintenanceMode(ctx context.Context, deviceID, adminID int64) error {
+	device, err := s.deviceRepo.GetByID(ctx, deviceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDeviceNotFound
+		}
+		return err
+	}
+	if !device.MaintenanceMode {
+		return nil
+	}
+
+	if err := s.deviceRepo.UpdateFields(ctx, deviceID, map[string]interface{}{
+		"maintenance_mode":       false,
+		"maintenance_reason":     "",
+		"maintenance_restore_at": nil,
+	}); err != nil {
+		return err
+	}
+
+	s.createDeviceLog(ctx, deviceID, models.DeviceLogTypeMaintenance, "关闭维护模式，恢复租借", &adminID, models.DeviceLogOperatorAdmin)
+	return nil
+}
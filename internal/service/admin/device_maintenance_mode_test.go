@@ -0,0 +1,113 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// adminNotification 测试记录的管理员通知
+type adminNotification struct {
+	notificationType string
+	title            string
+	content          string
+	link             *string
+}
+
+// recordingAdminNotifier 记录发送内容的管理员通知发送者
+type recordingAdminNotifier struct {
+	notifications []adminNotification
+	err           error
+}
+
+func (n *recordingAdminNotifier) NotifyAdmins(ctx context.Context, notificationType, title, content string, link *string) error {
+	n.notifications = append(n.notifications, adminNotification{notificationType, title, content, link})
+	return n.err
+}
+
+func TestDeviceAdminService_MaintenanceMode(t *testing.T) {
+	service, db, _ := setupDeviceAdminService(t)
+	ctx := context.Background()
+	notifier := &recordingAdminNotifier{}
+	service.SetAdminNotifier(notifier)
+
+	device := createSlotTestDevice(t, service, db, 1)
+	restoreAt := time.Now().Add(4 * time.Hour).Truncate(time.Second)
+
+	t.Run("开启维护模式并通知管理员", func(t *testing.T) {
+		require.NoError(t, service.SetMaintenanceMode(ctx, device.ID, 7, "柜门无法关闭", &restoreAt))
+
+		var updated models.Device
+		require.NoError(t, db.First(&updated, device.ID).Error)
+		assert.True(t, updated.MaintenanceMode)
+		assert.Equal(t, "柜门无法关闭", updated.MaintenanceReason)
+		require.NotNil(t, updated.MaintenanceRestoreAt)
+		assert.True(t, restoreAt.Equal(*updated.MaintenanceRestoreAt))
+		// 维护模式不改变设备状态
+		assert.Equal(t, int8(models.DeviceStatusActive), updated.Status)
+
+		require.Len(t, notifier.notifications, 1)
+		sent := notifier.notifications[0]
+		assert.Equal(t, models.NotificationTypeDevice, sent.notificationType)
+		assert.Contains(t, sent.content, device.DeviceNo)
+		assert.Contains(t, sent.content, "柜门无法关闭")
+		require.NotNil(t, sent.link)
+
+		info, err := service.GetDevice(ctx, device.ID)
+		require.NoError(t, err)
+		assert.True(t, info.MaintenanceMode)
+		assert.Equal(t, "柜门无法关闭", info.MaintenanceReason)
+	})
+
+	t.Run("通知失败不影响维护模式", func(t *testing.T) {
+		notifier.err = errors.New("notify failed")
+		defer func() { notifier.err = nil }()
+
+		require.NoError(t, service.SetMaintenanceMode(ctx, device.ID, 7, "更换锁芯", nil))
+
+		var updated models.Device
+		require.NoError(t, db.First(&updated, device.ID).Error)
+		assert.Equal(t, "更换锁芯", updated.MaintenanceReason)
+		assert.Nil(t, updated.MaintenanceRestoreAt)
+	})
+
+	t.Run("关闭维护模式", func(t *testing.T) {
+		require.NoError(t, service.ClearMaintenanceMode(ctx, device.ID, 7))
+
+		var updated models.Device
+		require.NoError(t, db.First(&updated, device.ID).Error)
+		assert.False(t, updated.MaintenanceMode)
+		assert.Empty(t, updated.MaintenanceReason)
+		assert.Nil(t, updated.MaintenanceRestoreAt)
+
+		var logs []models.DeviceLog
+		require.NoError(t, db.Where("device_id = ? AND operator_id = ?", device.ID, 7).Order("id").Find(&logs).Error)
+		require.Len(t, logs, 3)
+		assert.Contains(t, *logs[2].Content, "关闭维护模式")
+		for _, log := range logs {
+			assert.Equal(t, models.DeviceLogTypeMaintenance, log.Type)
+		}
+
+		// 未处于维护模式时关闭不重复记录
+		require.NoError(t, service.ClearMaintenanceMode(ctx, device.ID, 7))
+		var count int64
+		db.Model(&models.DeviceLog{}).Where("device_id = ? AND operator_id = ?", device.ID, 7).Count(&count)
+		assert.Equal(t, int64(3), count)
+	})
+
+	t.Run("开启维护模式须填写原因", func(t *testing.T) {
+		err := service.SetMaintenanceMode(ctx, device.ID, 7, "  ", nil)
+		assert.ErrorIs(t, err, ErrMaintenanceReasonRequired)
+	})
+
+	t.Run("设备不存在", func(t *testing.T) {
+		assert.ErrorIs(t, service.SetMaintenanceMode(ctx, 99999, 7, "故障", nil), ErrDeviceNotFound)
+		assert.ErrorIs(t, service.ClearMaintenanceMode(ctx, 99999, 7), ErrDeviceNotFound)
+	})
+}
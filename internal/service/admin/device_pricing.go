@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	commonErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// ErrDevicePricingExists 设备已存在相同时长的专属定价
+var ErrDevicePricingExists = commonErrors.ErrAlreadyExists.WithMessage("该设备已存在相同时长的定价")
+
+// ListDevicePricings 获取设备专属定价列表（含已停用）
+func (s *DeviceAdminService) ListDevicePricings(ctx context.Context, deviceID int64) ([]*models.RentalPricing, error) {
+	if _, err := s.deviceRepo.GetByID(ctx, deviceID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeviceNotFound
+		}
+		return nil, err
+	}
+
+	return s.deviceRepo.ListPricingsByDevice(ctx, deviceID)
+}
+
+// CreateDevicePricing 创建设备专属定价
+// 设备专属定价优先于同时长的场地及全局定价，停用后回退到场地或全局定价；修改和停用使用通用的定价更新接口
+func (s *DeviceAdminService) CreateDevicePricing(ctx context.Context, deviceID int64, req *PricingRequest) (*models.RentalPricing, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	pricings, err := s.ListDevicePricings(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pricings {
+		if p.DurationHours == req.DurationHours {
+			return nil, ErrDevicePricingExists
+		}
+	}
+
+	pricing := &models.RentalPricing{
+		DeviceID:           &deviceID,
+		DurationHours:      req.DurationHours,
+		Price:              req.Price,
+		Deposit:            req.Deposit,
+		OvertimeRate:       req.OvertimeRate,
+		GracePeriodMinutes: req.GracePeriodMinutes,
+		IsActive:           true,
+	}
+	if req.IsActive != nil {
+		pricing.IsActive = *req.IsActive
+	}
+
+	if err := s.deviceRepo.CreatePricing(ctx, pricing); err != nil {
+		return nil, err
+	}
+
+	return pricing, nil
+}
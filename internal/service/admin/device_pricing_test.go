@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+func TestDeviceAdminService_DevicePricing(t *testing.T) {
+	service, db, _ := setupDeviceAdminService(t)
+	ctx := context.Background()
+	deviceRepo := repository.NewDeviceRepository(db)
+
+	device := createSlotTestDevice(t, service, db, 1)
+	venueID := device.VenueID
+	venuePricing := &models.RentalPricing{VenueID: &venueID, DurationHours: 2, Price: 10, Deposit: 50, OvertimeRate: 5, IsActive: true}
+	require.NoError(t, db.Create(venuePricing).Error)
+
+	t.Run("创建设备专属定价", func(t *testing.T) {
+		pricing, err := service.CreateDevicePricing(ctx, device.ID, &PricingRequest{
+			DurationHours: 2,
+			Price:         15,
+			Deposit:       50,
+			OvertimeRate:  8,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, pricing.DeviceID)
+		assert.Equal(t, device.ID, *pricing.DeviceID)
+		assert.Nil(t, pricing.VenueID)
+		assert.True(t, pricing.IsActive)
+
+		list, err := service.ListDevicePricings(ctx, device.ID)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		assert.Equal(t, pricing.ID, list[0].ID)
+
+		effective, err := deviceRepo.GetPricingsByDevice(ctx, device.ID)
+		require.NoError(t, err)
+		require.Len(t, effective, 1)
+		assert.Equal(t, pricing.ID, effective[0].ID)
+
+		// 停用后回退场地定价
+		pricing.IsActive = false
+		require.NoError(t, deviceRepo.UpdatePricing(ctx, pricing))
+		effective, err = deviceRepo.GetPricingsByDevice(ctx, device.ID)
+		require.NoError(t, err)
+		require.Len(t, effective, 1)
+		assert.Equal(t, venuePricing.ID, effective[0].ID)
+	})
+
+	t.Run("同一设备相同时长的定价不能重复创建", func(t *testing.T) {
+		_, err := service.CreateDevicePricing(ctx, device.ID, &PricingRequest{DurationHours: 2, Price: 20})
+		assert.ErrorIs(t, err, ErrDevicePricingExists)
+	})
+
+	t.Run("宽限期超出范围", func(t *testing.T) {
+		grace := 90
+		_, err := service.CreateDevicePricing(ctx, device.ID, &PricingRequest{DurationHours: 3, GracePeriodMinutes: &grace})
+		assert.Error(t, err)
+	})
+
+	t.Run("设备不存在", func(t *testing.T) {
+		_, err := service.CreateDevicePricing(ctx, 99999, &PricingRequest{DurationHours: 1})
+		assert.ErrorIs(t, err, ErrDeviceNotFound)
+
+		_, err = service.ListDevicePricings(ctx, 99999)
+		assert.ErrorIs(t, err, ErrDeviceNotFound)
+	})
+}
@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// ListDeviceSlots 获取设备格口列表
+// 格口库存上线前注册的设备会先按格口数补建格口记录
+func (s *DeviceAdminService) ListDeviceSlots(ctx context.Context, deviceID int64) ([]*models.DeviceSlot, error) {
+	if _, err := s.deviceRepo.GetByID(ctx, deviceID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeviceNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.deviceSlotRepo.Ensure(ctx, deviceID); err != nil {
+		return nil, err
+	}
+	return s.deviceSlotRepo.ListByDevice(ctx, deviceID)
+}
+
+// ReleaseDeviceSlot 手动释放卡住的格口
+// 仅当格口关联的租借已结束（或无关联租借）时允许释放，进行中的租借需先强制完成；格口已空闲时不做处理
+func (s *DeviceAdminService) ReleaseDeviceSlot(ctx context.Context, deviceID int64, slotNo int, operatorID int64) error {
+	released, err := s.deviceSlotRepo.Release(ctx, deviceID, slotNo)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return ErrDeviceSlotNotFound
+		case errors.Is(err, repository.ErrSlotRentalActive):
+			return ErrDeviceSlotInUse
+		}
+		return err
+	}
+
+	if released {
+		s.createDeviceLog(ctx, deviceID, models.DeviceLogTypeSlotRelease,
+			fmt.Sprintf("管理员手动释放格口 %d", slotNo), &operatorID, models.DeviceLogOperatorAdmin)
+	}
+	return nil
+}
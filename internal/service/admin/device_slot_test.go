@@ -0,0 +1,164 @@
+package admin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// occupyTestSlot 将格口标记为被指定状态的租借占用
+func occupyTestSlot(t *testing.T, db *gorm.DB, device *models.Device, slotNo int, rentalStatus string) *models.Rental {
+	t.Helper()
+
+	rental := &models.Rental{
+		OrderID:       device.ID*100 + int64(slotNo),
+		UserID:        1,
+		DeviceID:      device.ID,
+		SlotNo:        &slotNo,
+		DurationHours: 1,
+		Status:        rentalStatus,
+	}
+	require.NoError(t, db.Create(rental).Error)
+	require.NoError(t, db.Model(&models.DeviceSlot{}).
+		Where("device_id = ? AND slot_no = ?", device.ID, slotNo).
+		Updates(map[string]interface{}{"status": models.DeviceSlotInUse, "current_rental_id": rental.ID}).Error)
+	require.NoError(t, db.Model(device).UpdateColumn("available_slots", gorm.Expr("available_slots - 1")).Error)
+	return rental
+}
+
+// createSlotTestDevice 通过管理服务注册多格口设备
+func createSlotTestDevice(t *testing.T, service *DeviceAdminService, db *gorm.DB, slotCount int) *models.Device {
+	t.Helper()
+
+	venue := createTestVenue(t, db)
+	device, err := service.CreateDevice(context.Background(), &CreateDeviceRequest{
+		DeviceNo:    "DEV_SLOT",
+		Name:        "多格口设备",
+		Type:        "standard",
+		VenueID:     venue.ID,
+		ProductName: "测试产品",
+		SlotCount:   slotCount,
+		NetworkType: "WiFi",
+	}, 1)
+	require.NoError(t, err)
+	return device
+}
+
+func TestDeviceAdminService_ListDeviceSlots(t *testing.T) {
+	service, db, _ := setupDeviceAdminService(t)
+	ctx := context.Background()
+
+	t.Run("注册设备时按格口数创建格口", func(t *testing.T) {
+		device := createSlotTestDevice(t, service, db, 3)
+
+		slots, err := service.ListDeviceSlots(ctx, device.ID)
+		require.NoError(t, err)
+		require.Len(t, slots, 3)
+		for i, slot := range slots {
+			assert.Equal(t, i+1, slot.SlotNo)
+			assert.Equal(t, int8(models.DeviceSlotFree), slot.Status)
+		}
+	})
+
+	t.Run("未建格口的存量设备补建格口", func(t *testing.T) {
+		venue := createTestVenue(t, db)
+		device := createTestDevice(t, db, "DEV_LEGACY", venue)
+
+		slots, err := service.ListDeviceSlots(ctx, device.ID)
+		require.NoError(t, err)
+		assert.Len(t, slots, device.SlotCount)
+	})
+
+	t.Run("设备不存在", func(t *testing.T) {
+		_, err := service.ListDeviceSlots(ctx, 99999)
+		assert.Equal(t, ErrDeviceNotFound, err)
+	})
+}
+
+func TestDeviceAdminService_ReleaseDeviceSlot(t *testing.T) {
+	service, db, _ := setupDeviceAdminService(t)
+	ctx := context.Background()
+	device := createSlotTestDevice(t, service, db, 3)
+
+	occupyTestSlot(t, db, device, 1, models.RentalStatusCompleted)
+	occupyTestSlot(t, db, device, 2, models.RentalStatusInUse)
+
+	// 关联租借仍在进行中，不允许释放
+	err := service.ReleaseDeviceSlot(ctx, device.ID, 2, 1)
+	assert.Equal(t, ErrDeviceSlotInUse, err)
+
+	// 租借已结束但格口未释放
+	require.NoError(t, service.ReleaseDeviceSlot(ctx, device.ID, 1, 1))
+
+	var slot models.DeviceSlot
+	require.NoError(t, db.Where("device_id = ? AND slot_no = ?", device.ID, 1).First(&slot).Error)
+	assert.Equal(t, int8(models.DeviceSlotFree), slot.Status)
+	assert.Nil(t, slot.CurrentRentalID)
+
+	var updated models.Device
+	require.NoError(t, db.First(&updated, device.ID).Error)
+	assert.Equal(t, 2, updated.AvailableSlots)
+
+	// 重复释放空闲格口不重复增加可用槽位
+	require.NoError(t, service.ReleaseDeviceSlot(ctx, device.ID, 1, 1))
+	require.NoError(t, db.First(&updated, device.ID).Error)
+	assert.Equal(t, 2, updated.AvailableSlots)
+
+	var logCount int64
+	require.NoError(t, db.Model(&models.DeviceLog{}).
+		Where("device_id = ? AND type = ?", device.ID, models.DeviceLogTypeSlotRelease).Count(&logCount).Error)
+	assert.Equal(t, int64(1), logCount)
+
+	err = service.ReleaseDeviceSlot(ctx, device.ID, 9, 1)
+	assert.Equal(t, ErrDeviceSlotNotFound, err)
+}
+
+func TestDeviceAdminService_UpdateDevice_ResizesSlots(t *testing.T) {
+	service, db, _ := setupDeviceAdminService(t)
+	ctx := context.Background()
+	device := createSlotTestDevice(t, service, db, 3)
+	occupyTestSlot(t, db, device, 3, models.RentalStatusInUse)
+
+	req := &UpdateDeviceRequest{
+		Name:        device.Name,
+		Type:        device.Type,
+		VenueID:     device.VenueID,
+		ProductName: device.ProductName,
+		NetworkType: "WiFi",
+	}
+
+	// 增加格口
+	req.SlotCount = 5
+	require.NoError(t, service.UpdateDevice(ctx, device.ID, req))
+	slots, err := service.ListDeviceSlots(ctx, device.ID)
+	require.NoError(t, err)
+	assert.Len(t, slots, 5)
+
+	var updated models.Device
+	require.NoError(t, db.First(&updated, device.ID).Error)
+	assert.Equal(t, 4, updated.AvailableSlots)
+
+	// 缩减到被占用的格口以下
+	req.SlotCount = 2
+	err = service.UpdateDevice(ctx, device.ID, req)
+	assert.Equal(t, ErrDeviceSlotsOccupied, err)
+	slots, err = service.ListDeviceSlots(ctx, device.ID)
+	require.NoError(t, err)
+	assert.Len(t, slots, 5)
+	require.NoError(t, db.First(&updated, device.ID).Error)
+	assert.Equal(t, 5, updated.SlotCount)
+
+	// 删除超出的空闲格口
+	req.SlotCount = 3
+	require.NoError(t, service.UpdateDevice(ctx, device.ID, req))
+	slots, err = service.ListDeviceSlots(ctx, device.ID)
+	require.NoError(t, err)
+	assert.Len(t, slots, 3)
+	require.NoError(t, db.First(&updated, device.ID).Error)
+	assert.Equal(t, 2, updated.AvailableSlots)
+}
@@ -242,6 +242,7 @@ func (s *DistributionAdminService) RejectWithdrawal(ctx context.Context, withdra
 				Updates(map[string]interface{}{
 					"balance":        gorm.Expr("balance + ?", withdrawal.Amount),
 					"frozen_balance": gorm.Expr("frozen_balance - ?", withdrawal.Amount),
+					"version":        gorm.Expr("version + 1"),
 				}).Error; err != nil {
 				return err
 			}
@@ -286,6 +287,7 @@ func (s *DistributionAdminService) CompleteWithdrawal(ctx context.Context, withd
 				Where("user_id = ?", withdrawal.UserID).
 				Updates(map[string]interface{}{
 					"frozen_balance":  gorm.Expr("frozen_balance - ?", withdrawal.Amount),
+					"version":         gorm.Expr("version + 1"),
 					"total_withdrawn": gorm.Expr("total_withdrawn + ?", withdrawal.ActualAmount),
 				}).Error; err != nil {
 				return err
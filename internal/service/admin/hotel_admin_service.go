@@ -4,6 +4,7 @@ package admin
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -19,6 +20,7 @@ type HotelAdminService struct {
 	roomRepo         *repository.RoomRepository
 	bookingRepo      *repository.BookingRepository
 	timeSlotRepo     *repository.RoomTimeSlotRepository
+	occupancyRepo    *repository.RoomOccupancyRepository
 }
 
 // NewHotelAdminService 创建酒店管理服务
@@ -30,11 +32,12 @@ func NewHotelAdminService(
 	timeSlotRepo *repository.RoomTimeSlotRepository,
 ) *HotelAdminService {
 	return &HotelAdminService{
-		db:           db,
-		hotelRepo:    hotelRepo,
-		roomRepo:     roomRepo,
-		bookingRepo:  bookingRepo,
-		timeSlotRepo: timeSlotRepo,
+		db:            db,
+		hotelRepo:     hotelRepo,
+		roomRepo:      roomRepo,
+		bookingRepo:   bookingRepo,
+		timeSlotRepo:  timeSlotRepo,
+		occupancyRepo: repository.NewRoomOccupancyRepository(db),
 	}
 }
 
@@ -54,6 +57,10 @@ type CreateHotelRequest struct {
 	Description    *string  `json:"description"`
 	CheckInTime    string   `json:"check_in_time"`
 	CheckOutTime   string   `json:"check_out_time"`
+	Timezone       string   `json:"timezone"` // IANA 时区名称，默认 Asia/Shanghai
+	FreeCancelHours     int     `json:"free_cancel_hours" binding:"min=0"`             // 入住前多少小时之前可免费取消
+	CancellationFeeRate float64 `json:"cancellation_fee_rate" binding:"min=0,max=1"` // 超过免费取消时限后的手续费比例
+	LongUnlockCode      bool    `json:"long_unlock_code"`                              // 是否使用 8 位开锁码
 	CommissionRate float64  `json:"commission_rate"`
 }
 
@@ -73,6 +80,10 @@ type UpdateHotelRequest struct {
 	Description    *string   `json:"description"`
 	CheckInTime    *string   `json:"check_in_time"`
 	CheckOutTime   *string   `json:"check_out_time"`
+	Timezone       *string   `json:"timezone"`
+	FreeCancelHours     *int     `json:"free_cancel_hours" binding:"omitempty,min=0"`
+	CancellationFeeRate *float64 `json:"cancellation_fee_rate" binding:"omitempty,min=0,max=1"`
+	LongUnlockCode      *bool    `json:"long_unlock_code"`
 	CommissionRate *float64  `json:"commission_rate"`
 	Status         *int8     `json:"status"`
 }
@@ -155,6 +166,24 @@ func (s *HotelAdminService) CreateHotel(ctx context.Context, req *CreateHotelReq
 		hotel.CheckOutTime = "12:00"
 	}
 
+	// 设置取消政策
+	if err := validateCancellationPolicy(req.FreeCancelHours, req.CancellationFeeRate); err != nil {
+		return nil, err
+	}
+	hotel.FreeCancelHours = req.FreeCancelHours
+	hotel.CancellationFeeRate = req.CancellationFeeRate
+	hotel.LongUnlockCode = req.LongUnlockCode
+
+	// 设置时区
+	if req.Timezone != "" {
+		if err := validateTimezone(req.Timezone); err != nil {
+			return nil, err
+		}
+		hotel.Timezone = req.Timezone
+	} else {
+		hotel.Timezone = models.DefaultHotelTimezone
+	}
+
 	// 设置图片和设施
 	if len(req.Images) > 0 {
 		hotel.Images = stringSliceToJSONArray(req.Images)
@@ -226,6 +255,24 @@ func (s *HotelAdminService) UpdateHotel(ctx context.Context, id int64, req *Upda
 	if req.CheckOutTime != nil {
 		hotel.CheckOutTime = *req.CheckOutTime
 	}
+	if req.Timezone != nil {
+		if err := validateTimezone(*req.Timezone); err != nil {
+			return nil, err
+		}
+		hotel.Timezone = *req.Timezone
+	}
+	if req.FreeCancelHours != nil {
+		hotel.FreeCancelHours = *req.FreeCancelHours
+	}
+	if req.CancellationFeeRate != nil {
+		hotel.CancellationFeeRate = *req.CancellationFeeRate
+	}
+	if err := validateCancellationPolicy(hotel.FreeCancelHours, hotel.CancellationFeeRate); err != nil {
+		return nil, err
+	}
+	if req.LongUnlockCode != nil {
+		hotel.LongUnlockCode = *req.LongUnlockCode
+	}
 	if req.CommissionRate != nil {
 		hotel.CommissionRate = *req.CommissionRate
 	}
@@ -541,3 +588,44 @@ func (s *HotelAdminService) SetRoomHot(ctx context.Context, id int64, req *SetRo
 
 	return s.roomRepo.SetHotStatus(ctx, id, req.IsHot, req.Rank)
 }
+
+// RebuildOccupancyResult 重建房间占用位图结果
+type RebuildOccupancyResult struct {
+	RoomID int64 `json:"room_id"`
+	Days   int   `json:"days"` // 写入的占用天数
+}
+
+// RebuildOccupancy 按房间的预订重建占用位图，用于修复位图与预订不一致
+func (s *HotelAdminService) RebuildOccupancy(ctx context.Context, roomID int64) (*RebuildOccupancyResult, error) {
+	if _, err := s.roomRepo.GetByID(ctx, roomID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrRoomNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	days, err := s.occupancyRepo.Rebuild(ctx, roomID)
+	if err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	return &RebuildOccupancyResult{RoomID: roomID, Days: days}, nil
+}
+
+// validateTimezone 校验酒店/场地时区是否为有效的 IANA 时区名称
+func validateTimezone(tz string) error {
+	if _, err := time.LoadLocation(tz); err != nil {
+		return errors.ErrInvalidParams.WithMessage("无效的时区: " + tz)
+	}
+	return nil
+}
+
+// validateCancellationPolicy 校验酒店取消政策
+func validateCancellationPolicy(freeCancelHours int, feeRate float64) error {
+	if freeCancelHours < 0 {
+		return errors.ErrInvalidParams.WithMessage("免费取消时限不能为负数")
+	}
+	if feeRate < 0 || feeRate > 1 {
+		return errors.ErrInvalidParams.WithMessage("取消手续费比例必须在0到1之间")
+	}
+	return nil
+}
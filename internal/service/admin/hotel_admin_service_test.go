@@ -34,6 +34,7 @@ func setupHotelAdminTestDB(t *testing.T) *gorm.DB {
 		&models.Hotel{},
 		&models.Room{},
 		&models.Booking{},
+		&models.RoomOccupancy{},
 		&models.RoomTimeSlot{},
 	))
 	return db
@@ -63,6 +64,49 @@ func TestHotelAdminService_CreateHotelAndRoom(t *testing.T) {
 	assert.Equal(t, int8(models.HotelStatusActive), hotel.Status)
 	assert.Equal(t, "14:00", hotel.CheckInTime)
 	assert.Equal(t, "12:00", hotel.CheckOutTime)
+	assert.Equal(t, models.DefaultHotelTimezone, hotel.Timezone)
+
+	t.Run("CreateHotel 无效时区", func(t *testing.T) {
+		_, err := svc.CreateHotel(ctx, &CreateHotelRequest{
+			Name:     "酒店B",
+			Province: "广东省",
+			City:     "深圳市",
+			District: "南山区",
+			Address:  "科技园",
+			Phone:    "0755-123456",
+			Timezone: "Mars/Olympus",
+		})
+		require.Error(t, err)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrInvalidParams.Code, appErr.Code)
+	})
+
+	t.Run("UpdateHotel 修改时区", func(t *testing.T) {
+		tz := "America/New_York"
+		updated, err := svc.UpdateHotel(ctx, hotel.ID, &UpdateHotelRequest{Timezone: &tz})
+		require.NoError(t, err)
+		assert.Equal(t, tz, updated.Timezone)
+
+		invalid := "Invalid/Zone"
+		_, err = svc.UpdateHotel(ctx, hotel.ID, &UpdateHotelRequest{Timezone: &invalid})
+		require.Error(t, err)
+	})
+
+	t.Run("UpdateHotel 设置取消政策", func(t *testing.T) {
+		hours, rate := 24, 0.3
+		updated, err := svc.UpdateHotel(ctx, hotel.ID, &UpdateHotelRequest{
+			FreeCancelHours:     &hours,
+			CancellationFeeRate: &rate,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 24, updated.FreeCancelHours)
+		assert.Equal(t, 0.3, updated.CancellationFeeRate)
+
+		invalid := 1.5
+		_, err = svc.UpdateHotel(ctx, hotel.ID, &UpdateHotelRequest{CancellationFeeRate: &invalid})
+		require.Error(t, err)
+	})
 
 	t.Run("CreateHotel 名称重复", func(t *testing.T) {
 		_, err := svc.CreateHotel(ctx, &CreateHotelRequest{
@@ -547,3 +591,68 @@ func TestHotelAdminService_GetBookingByID(t *testing.T) {
 	})
 }
 
+
+func TestHotelAdminService_RebuildOccupancy(t *testing.T) {
+	db := setupHotelAdminTestDB(t)
+	svc := NewHotelAdminService(
+		db,
+		repository.NewHotelRepository(db),
+		repository.NewRoomRepository(db),
+		repository.NewBookingRepository(db),
+		repository.NewRoomTimeSlotRepository(db),
+	)
+	ctx := context.Background()
+
+	hotel, _ := svc.CreateHotel(ctx, &CreateHotelRequest{
+		Name:     "位图测试酒店",
+		Province: "广东省",
+		City:     "深圳市",
+		District: "南山区",
+		Address:  "科技园",
+		Phone:    "0755-123456",
+	})
+
+	room, _ := svc.CreateRoom(ctx, &CreateRoomRequest{
+		HotelID:     hotel.ID,
+		RoomNo:      "OC01",
+		RoomType:    models.RoomTypeStandard,
+		HourlyPrice: 60,
+		DailyPrice:  288,
+	})
+
+	checkIn := time.Date(2030, 5, 1, 22, 0, 0, 0, time.UTC)
+	require.NoError(t, db.Create(&models.Booking{
+		BookingNo:        "BOC01",
+		OrderID:          1,
+		UserID:           1,
+		HotelID:          hotel.ID,
+		RoomID:           room.ID,
+		CheckInTime:      checkIn,
+		CheckOutTime:     checkIn.Add(4 * time.Hour),
+		DurationHours:    4,
+		Amount:           200,
+		VerificationCode: "OC0001",
+		UnlockCode:       "888888",
+		QRCode:           "qr_code",
+		Status:           models.BookingStatusPaid,
+	}).Error)
+
+	t.Run("RebuildOccupancy 重建位图", func(t *testing.T) {
+		result, err := svc.RebuildOccupancy(ctx, room.ID)
+		require.NoError(t, err)
+		assert.Equal(t, room.ID, result.RoomID)
+		assert.Equal(t, 2, result.Days)
+
+		available, err := repository.NewRoomOccupancyRepository(db).IsAvailable(ctx, room.ID, checkIn, checkIn.Add(time.Hour))
+		require.NoError(t, err)
+		assert.False(t, available)
+	})
+
+	t.Run("RebuildOccupancy 房间不存在", func(t *testing.T) {
+		_, err := svc.RebuildOccupancy(ctx, 99999)
+		require.Error(t, err)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrRoomNotFound.Code, appErr.Code)
+	})
+}
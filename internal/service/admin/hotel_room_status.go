@@ -0,0 +1,196 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// 批量修改房间状态的操作日志
+const (
+	roomStatusLogModule     = "hotel"
+	roomStatusLogAction     = "bulk_update_room_status"
+	roomStatusLogTargetType = "room"
+)
+
+// roomDisableCancelReason 停用房间时取消预订的原因
+const roomDisableCancelReason = "房间停用，预订已取消"
+
+// BulkUpdateRoomStatusRequest 批量修改房间状态请求
+type BulkUpdateRoomStatusRequest struct {
+	RoomIDs []int64 `json:"room_ids" binding:"required,min=1,max=200"`
+	Status  *int8   `json:"status" binding:"required,oneof=0 1"` // 0 停用 1 可用
+}
+
+// BulkUpdateRoomStatusResult 批量修改房间状态结果
+type BulkUpdateRoomStatusResult struct {
+	Updated           int     `json:"updated"`
+	Failed            []int64 `json:"failed"`             // 不存在或取消预订失败的房间，状态未修改
+	CancelledBookings int     `json:"cancelled_bookings"` // 停用时取消的待入住预订数
+}
+
+// BulkUpdateRoomStatus 批量修改房间状态（如整层装修时统一停用）
+// 不存在的房间计入 Failed，其余房间用一条更新语句修改状态并逐个记录操作日志；
+// 停用房间时取消其尚未入住的预订：待支付的直接取消，已支付的全额退款并释放房间时段。
+// 某个房间的预订取消失败时该房间计入 Failed，不影响其他房间
+func (s *HotelAdminService) BulkUpdateRoomStatus(ctx context.Context, roomIDs []int64, status int8, adminID int64) (*BulkUpdateRoomStatusResult, error) {
+	if status != models.RoomStatusActive && status != models.RoomStatusDisabled {
+		return nil, errors.ErrInvalidParams.WithMessage("房间状态只能修改为可用或停用")
+	}
+
+	ids := uniqueRoomIDs(roomIDs)
+	result := &BulkUpdateRoomStatusResult{Failed: []int64{}}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rooms []*models.Room
+		if err := tx.Where("id IN ?", ids).Find(&rooms).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		found := make(map[int64]*models.Room, len(rooms))
+		for _, room := range rooms {
+			found[room.ID] = room
+		}
+
+		var updateIDs []int64
+		for _, id := range ids {
+			room, ok := found[id]
+			if !ok {
+				result.Failed = append(result.Failed, id)
+				continue
+			}
+			if status == models.RoomStatusDisabled {
+				// 每个房间使用保存点，取消预订失败只回滚该房间
+				var cancelled int
+				if err := tx.Transaction(func(roomTx *gorm.DB) error {
+					var err error
+					cancelled, err = s.cancelUpcomingBookingsTx(ctx, roomTx, room, adminID)
+					return err
+				}); err != nil {
+					result.Failed = append(result.Failed, id)
+					continue
+				}
+				result.CancelledBookings += cancelled
+			}
+			updateIDs = append(updateIDs, id)
+		}
+		if len(updateIDs) == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&models.Room{}).Where("id IN ?", updateIDs).Update("status", status).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		logs := make([]*models.OperationLog, 0, len(updateIDs))
+		for _, id := range updateIDs {
+			targetType := roomStatusLogTargetType
+			targetID := id
+			logs = append(logs, &models.OperationLog{
+				AdminID:    adminID,
+				Module:     roomStatusLogModule,
+				Action:     roomStatusLogAction,
+				TargetType: &targetType,
+				TargetID:   &targetID,
+				BeforeData: models.JSON{"status": found[id].Status},
+				AfterData:  models.JSON{"status": status},
+			})
+		}
+		if err := tx.Create(logs).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		result.Updated = len(updateIDs)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// cancelUpcomingBookingsTx 取消房间尚未入住的预订，返回取消的预订数
+// 待支付的预订与订单直接取消；已支付的预订全额退款，预订与订单进入退款中并刷新房间占用位图
+func (s *HotelAdminService) cancelUpcomingBookingsTx(ctx context.Context, tx *gorm.DB, room *models.Room, adminID int64) (int, error) {
+	var bookings []*models.Booking
+	if err := tx.Where("room_id = ? AND status IN ?", room.ID, []string{models.BookingStatusPending, models.BookingStatusPaid}).
+		Find(&bookings).Error; err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	for _, booking := range bookings {
+		if booking.Status == models.BookingStatusPending {
+			if err := s.updateBookingAndOrderTx(tx, booking, models.BookingStatusCancelled, map[string]interface{}{
+				"status":        models.OrderStatusCancelled,
+				"cancelled_at":  now,
+				"cancel_reason": roomDisableCancelReason,
+			}); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		var payment models.Payment
+		if err := tx.Where("order_id = ? AND status = ?", booking.OrderID, models.PaymentStatusSuccess).
+			First(&payment).Error; err != nil {
+			return 0, err
+		}
+		operatorID := adminID
+		operatorType := models.RefundOperatorAdmin
+		if err := tx.Create(&models.Refund{
+			RefundNo:     utils.GenerateOrderNo("R"),
+			OrderID:      payment.OrderID,
+			OrderNo:      payment.OrderNo,
+			PaymentID:    payment.ID,
+			PaymentNo:    payment.PaymentNo,
+			UserID:       booking.UserID,
+			Amount:       booking.Amount,
+			Reason:       roomDisableCancelReason,
+			Status:       models.RefundStatusPending,
+			OperatorID:   &operatorID,
+			OperatorType: &operatorType,
+		}).Error; err != nil {
+			return 0, err
+		}
+		if err := s.updateBookingAndOrderTx(tx, booking, models.BookingStatusRefunding, map[string]interface{}{
+			"status": models.OrderStatusRefunding,
+		}); err != nil {
+			return 0, err
+		}
+		if err := s.occupancyRepo.RefreshTx(ctx, tx, room.ID, booking.CheckInTime, booking.CheckOutTime); err != nil {
+			return 0, err
+		}
+	}
+	return len(bookings), nil
+}
+
+// updateBookingAndOrderTx 条件更新预订状态并同步更新订单，预订状态已变化时返回错误
+func (s *HotelAdminService) updateBookingAndOrderTx(tx *gorm.DB, booking *models.Booking, status string, orderFields map[string]interface{}) error {
+	res := tx.Model(&models.Booking{}).
+		Where("id = ? AND status = ?", booking.ID, booking.Status).
+		Update("status", status)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.ErrBookingStatusError
+	}
+	return tx.Model(&models.Order{}).Where("id = ?", booking.OrderID).Updates(orderFields).Error
+}
+
+// uniqueRoomIDs 去除重复的房间 ID，保持原有顺序
+func uniqueRoomIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	result := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,251 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+func setupRoomStatusTest(t *testing.T) (*gorm.DB, *HotelAdminService, *models.Hotel) {
+	db := setupHotelAdminTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Order{}, &models.Payment{}, &models.Refund{}, &models.OperationLog{}))
+
+	svc := NewHotelAdminService(
+		db,
+		repository.NewHotelRepository(db),
+		repository.NewRoomRepository(db),
+		repository.NewBookingRepository(db),
+		repository.NewRoomTimeSlotRepository(db),
+	)
+	hotel, err := svc.CreateHotel(context.Background(), &CreateHotelRequest{
+		Name:     "装修酒店",
+		Province: "广东省",
+		City:     "深圳市",
+		District: "南山区",
+		Address:  "科技园",
+		Phone:    "0755-123456",
+	})
+	require.NoError(t, err)
+	return db, svc, hotel
+}
+
+func createStatusTestRoom(t *testing.T, svc *HotelAdminService, hotelID int64, roomNo string) *models.Room {
+	room, err := svc.CreateRoom(context.Background(), &CreateRoomRequest{
+		HotelID:     hotelID,
+		RoomNo:      roomNo,
+		RoomType:    models.RoomTypeStandard,
+		HourlyPrice: 60,
+		DailyPrice:  288,
+	})
+	require.NoError(t, err)
+	return room
+}
+
+// createStatusTestBooking 创建入住时长 2 小时的预订及其订单，withPayment 为 true 时创建支付成功记录
+func createStatusTestBooking(t *testing.T, db *gorm.DB, room *models.Room, status string, withPayment bool, checkIn time.Time) *models.Booking {
+	seq := time.Now().UnixNano()
+	order := &models.Order{
+		OrderNo:        fmt.Sprintf("O%d", seq),
+		UserID:         1,
+		Type:           models.OrderTypeHotel,
+		OriginalAmount: 120,
+		ActualAmount:   120,
+		Status:         models.OrderStatusPending,
+	}
+	if status == models.BookingStatusPaid {
+		order.Status = models.OrderStatusPaid
+	}
+	require.NoError(t, db.Create(order).Error)
+
+	if withPayment {
+		require.NoError(t, db.Create(&models.Payment{
+			PaymentNo:      fmt.Sprintf("P%d", seq),
+			OrderID:        order.ID,
+			OrderNo:        order.OrderNo,
+			UserID:         1,
+			Amount:         120,
+			PaymentMethod:  models.PaymentMethodWechat,
+			PaymentChannel: models.PaymentChannelMiniProgram,
+			Status:         models.PaymentStatusSuccess,
+		}).Error)
+	}
+
+	booking := &models.Booking{
+		BookingNo:        fmt.Sprintf("B%d", seq),
+		OrderID:          order.ID,
+		UserID:           1,
+		HotelID:          room.HotelID,
+		RoomID:           room.ID,
+		CheckInTime:      checkIn,
+		CheckOutTime:     checkIn.Add(2 * time.Hour),
+		DurationHours:    2,
+		Amount:           120,
+		VerificationCode: fmt.Sprintf("V%d", seq),
+		UnlockCode:       "888888",
+		QRCode:           "qr_code",
+		Status:           status,
+	}
+	require.NoError(t, db.Create(booking).Error)
+	_, err := repository.NewRoomOccupancyRepository(db).Rebuild(context.Background(), room.ID)
+	require.NoError(t, err)
+	return booking
+}
+
+func TestHotelAdminService_BulkUpdateRoomStatus_Disable(t *testing.T) {
+	db, svc, hotel := setupRoomStatusTest(t)
+	ctx := context.Background()
+	checkIn := time.Now().Add(48 * time.Hour)
+
+	room1 := createStatusTestRoom(t, svc, hotel.ID, "301")
+	room2 := createStatusTestRoom(t, svc, hotel.ID, "302")
+	other := createStatusTestRoom(t, svc, hotel.ID, "401")
+	pending := createStatusTestBooking(t, db, room1, models.BookingStatusPending, false, checkIn)
+	paid := createStatusTestBooking(t, db, room2, models.BookingStatusPaid, true, checkIn)
+	verified := createStatusTestBooking(t, db, room2, models.BookingStatusVerified, true, checkIn.Add(-24*time.Hour))
+
+	result, err := svc.BulkUpdateRoomStatus(ctx, []int64{room1.ID, room2.ID, room1.ID, 99999}, models.RoomStatusDisabled, 7)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Updated)
+	assert.Equal(t, []int64{99999}, result.Failed)
+	assert.Equal(t, 2, result.CancelledBookings)
+
+	var statuses []int8
+	db.Model(&models.Room{}).Where("id IN ?", []int64{room1.ID, room2.ID}).Pluck("status", &statuses)
+	assert.Equal(t, []int8{models.RoomStatusDisabled, models.RoomStatusDisabled}, statuses)
+	var otherRoom models.Room
+	require.NoError(t, db.First(&otherRoom, other.ID).Error)
+	assert.Equal(t, int8(models.RoomStatusActive), otherRoom.Status)
+
+	t.Run("待支付预订直接取消", func(t *testing.T) {
+		var booking models.Booking
+		require.NoError(t, db.First(&booking, pending.ID).Error)
+		assert.Equal(t, models.BookingStatusCancelled, booking.Status)
+		var order models.Order
+		require.NoError(t, db.First(&order, pending.OrderID).Error)
+		assert.Equal(t, models.OrderStatusCancelled, order.Status)
+	})
+
+	t.Run("已支付预订全额退款并释放时段", func(t *testing.T) {
+		var booking models.Booking
+		require.NoError(t, db.First(&booking, paid.ID).Error)
+		assert.Equal(t, models.BookingStatusRefunding, booking.Status)
+
+		var refund models.Refund
+		require.NoError(t, db.Where("order_id = ?", paid.OrderID).First(&refund).Error)
+		assert.Equal(t, 120.0, refund.Amount)
+		assert.Equal(t, int8(models.RefundStatusPending), refund.Status)
+		require.NotNil(t, refund.OperatorType)
+		assert.Equal(t, models.RefundOperatorAdmin, *refund.OperatorType)
+
+		available, err := svc.occupancyRepo.IsAvailable(ctx, room2.ID, paid.CheckInTime, paid.CheckOutTime)
+		require.NoError(t, err)
+		assert.True(t, available)
+	})
+
+	t.Run("已核销预订不受影响", func(t *testing.T) {
+		var booking models.Booking
+		require.NoError(t, db.First(&booking, verified.ID).Error)
+		assert.Equal(t, models.BookingStatusVerified, booking.Status)
+	})
+
+	t.Run("每个房间记录操作日志", func(t *testing.T) {
+		var logs []*models.OperationLog
+		require.NoError(t, db.Where("action = ?", roomStatusLogAction).Order("target_id ASC").Find(&logs).Error)
+		require.Len(t, logs, 2)
+		assert.Equal(t, room1.ID, *logs[0].TargetID)
+		assert.Equal(t, room2.ID, *logs[1].TargetID)
+		assert.Equal(t, int64(7), logs[0].AdminID)
+	})
+}
+
+func TestHotelAdminService_BulkUpdateRoomStatus_PartialFailure(t *testing.T) {
+	db, svc, hotel := setupRoomStatusTest(t)
+	ctx := context.Background()
+	checkIn := time.Now().Add(48 * time.Hour)
+
+	ok := createStatusTestRoom(t, svc, hotel.ID, "501")
+	broken := createStatusTestRoom(t, svc, hotel.ID, "502")
+	okBooking := createStatusTestBooking(t, db, ok, models.BookingStatusPending, false, checkIn)
+	// 已支付但缺少支付记录，无法发起退款
+	brokenPending := createStatusTestBooking(t, db, broken, models.BookingStatusPending, false, checkIn)
+	brokenPaid := createStatusTestBooking(t, db, broken, models.BookingStatusPaid, false, checkIn)
+
+	result, err := svc.BulkUpdateRoomStatus(ctx, []int64{ok.ID, broken.ID}, models.RoomStatusDisabled, 7)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Updated)
+	assert.Equal(t, []int64{broken.ID}, result.Failed)
+	assert.Equal(t, 1, result.CancelledBookings)
+
+	var brokenRoom, okRoom models.Room
+	require.NoError(t, db.First(&brokenRoom, broken.ID).Error)
+	assert.Equal(t, int8(models.RoomStatusActive), brokenRoom.Status)
+	require.NoError(t, db.First(&okRoom, ok.ID).Error)
+	assert.Equal(t, int8(models.RoomStatusDisabled), okRoom.Status)
+
+	// 失败房间的预订全部回滚
+	statusOf := func(id int64) string {
+		var booking models.Booking
+		require.NoError(t, db.First(&booking, id).Error)
+		return booking.Status
+	}
+	assert.Equal(t, models.BookingStatusPending, statusOf(brokenPending.ID))
+	assert.Equal(t, models.BookingStatusPaid, statusOf(brokenPaid.ID))
+	assert.Equal(t, models.BookingStatusCancelled, statusOf(okBooking.ID))
+
+	var logCount int64
+	db.Model(&models.OperationLog{}).Where("action = ?", roomStatusLogAction).Count(&logCount)
+	assert.Equal(t, int64(1), logCount)
+}
+
+func TestHotelAdminService_BulkUpdateRoomStatus_Enable(t *testing.T) {
+	db, svc, hotel := setupRoomStatusTest(t)
+	ctx := context.Background()
+	checkIn := time.Now().Add(48 * time.Hour)
+
+	room := createStatusTestRoom(t, svc, hotel.ID, "601")
+	require.NoError(t, db.Model(room).Update("status", models.RoomStatusDisabled).Error)
+	booking := createStatusTestBooking(t, db, room, models.BookingStatusPending, false, checkIn)
+
+	result, err := svc.BulkUpdateRoomStatus(ctx, []int64{room.ID}, models.RoomStatusActive, 7)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Updated)
+	assert.Empty(t, result.Failed)
+	assert.Zero(t, result.CancelledBookings)
+
+	var updated models.Room
+	require.NoError(t, db.First(&updated, room.ID).Error)
+	assert.Equal(t, int8(models.RoomStatusActive), updated.Status)
+
+	var b models.Booking
+	require.NoError(t, db.First(&b, booking.ID).Error)
+	assert.Equal(t, models.BookingStatusPending, b.Status)
+}
+
+func TestHotelAdminService_BulkUpdateRoomStatus_InvalidStatus(t *testing.T) {
+	_, svc, hotel := setupRoomStatusTest(t)
+	room := createStatusTestRoom(t, svc, hotel.ID, "701")
+
+	_, err := svc.BulkUpdateRoomStatus(context.Background(), []int64{room.ID}, models.RoomStatusInUse, 7)
+	require.Error(t, err)
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, appErrors.ErrInvalidParams.Code, appErr.Code)
+}
+
+func TestHotelAdminService_BulkUpdateRoomStatus_AllMissing(t *testing.T) {
+	_, svc, _ := setupRoomStatusTest(t)
+
+	result, err := svc.BulkUpdateRoomStatus(context.Background(), []int64{99998, 99999}, models.RoomStatusDisabled, 7)
+	require.NoError(t, err)
+	assert.Zero(t, result.Updated)
+	assert.Equal(t, []int64{99998, 99999}, result.Failed)
+}
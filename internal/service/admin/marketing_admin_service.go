@@ -8,8 +8,10 @@ import (
 
 	"gorm.io/gorm"
 
+	commonErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	"github.com/dumeirei/smart-locker-backend/internal/service/marketing"
 )
 
 // MarketingAdminService 营销管理服务
@@ -46,26 +48,27 @@ type AdminCouponListResponse struct {
 
 // AdminCouponItem 管理端优惠券项
 type AdminCouponItem struct {
-	ID              int64      `json:"id"`
-	Name            string     `json:"name"`
-	Type            string     `json:"type"`
-	TypeText        string     `json:"type_text"`
-	Value           float64    `json:"value"`
-	MinAmount       float64    `json:"min_amount"`
-	MaxDiscount     *float64   `json:"max_discount,omitempty"`
-	ApplicableScope string     `json:"applicable_scope"`
-	ApplicableIDs   []int64    `json:"applicable_ids,omitempty"`
-	StartTime       time.Time  `json:"start_time"`
-	EndTime         time.Time  `json:"end_time"`
-	ValidDays       *int       `json:"valid_days,omitempty"`
-	TotalCount      int        `json:"total_count"`
-	ReceivedCount   int        `json:"received_count"`
-	UsedCount       int        `json:"used_count"`
-	PerUserLimit    int        `json:"per_user_limit"`
-	Description     *string    `json:"description,omitempty"`
-	Status          int8       `json:"status"`
-	StatusText      string     `json:"status_text"`
-	CreatedAt       time.Time  `json:"created_at"`
+	ID              int64                         `json:"id"`
+	Name            string                        `json:"name"`
+	Type            string                        `json:"type"`
+	TypeText        string                        `json:"type_text"`
+	Value           float64                       `json:"value"`
+	MinAmount       float64                       `json:"min_amount"`
+	MaxDiscount     *float64                      `json:"max_discount,omitempty"`
+	ApplicableScope string                        `json:"applicable_scope"`
+	ApplicableIDs   []int64                       `json:"applicable_ids,omitempty"`
+	ApplicableItems *models.CouponApplicableItems `json:"applicable_items,omitempty"`
+	StartTime       time.Time                     `json:"start_time"`
+	EndTime         time.Time                     `json:"end_time"`
+	ValidDays       *int                          `json:"valid_days,omitempty"`
+	TotalCount      int                           `json:"total_count"`
+	ReceivedCount   int                           `json:"received_count"`
+	UsedCount       int                           `json:"used_count"`
+	PerUserLimit    int                           `json:"per_user_limit"`
+	Description     *string                       `json:"description,omitempty"`
+	Status          int8                          `json:"status"`
+	StatusText      string                        `json:"status_text"`
+	CreatedAt       time.Time                     `json:"created_at"`
 }
 
 // GetCouponList 获取优惠券列表（管理端）
@@ -109,19 +112,20 @@ func (s *MarketingAdminService) GetCouponDetail(ctx context.Context, couponID in
 
 // CreateCouponRequest 创建优惠券请求
 type CreateCouponRequest struct {
-	Name            string   `json:"name" binding:"required"`
-	Type            string   `json:"type" binding:"required,oneof=fixed percent"`
-	Value           float64  `json:"value" binding:"required,gt=0"`
-	MinAmount       float64  `json:"min_amount"`
-	MaxDiscount     *float64 `json:"max_discount"`
-	TotalCount      int      `json:"total_count" binding:"required,gt=0"`
-	PerUserLimit    int      `json:"per_user_limit" binding:"required,gt=0"`
-	ApplicableScope string   `json:"applicable_scope" binding:"required,oneof=all category product"`
-	ApplicableIDs   []int64  `json:"applicable_ids,omitempty"`
-	StartTime       string   `json:"start_time" binding:"required"`
-	EndTime         string   `json:"end_time" binding:"required"`
-	ValidDays       *int     `json:"valid_days"`
-	Description     *string  `json:"description"`
+	Name            string                        `json:"name" binding:"required"`
+	Type            string                        `json:"type" binding:"required,oneof=fixed percent"`
+	Value           float64                       `json:"value" binding:"required,gt=0"`
+	MinAmount       float64                       `json:"min_amount"`
+	MaxDiscount     *float64                      `json:"max_discount"`
+	TotalCount      int                           `json:"total_count" binding:"required,gt=0"`
+	PerUserLimit    int                           `json:"per_user_limit" binding:"required,gt=0"`
+	ApplicableScope string                        `json:"applicable_scope" binding:"required,oneof=all category product rental mall hotel"`
+	ApplicableIDs   []int64                       `json:"applicable_ids,omitempty"`
+	ApplicableItems *models.CouponApplicableItems `json:"applicable_items,omitempty"` // 限定商品/分类
+	StartTime       string                        `json:"start_time" binding:"required"`
+	EndTime         string                        `json:"end_time" binding:"required"`
+	ValidDays       *int                          `json:"valid_days"`
+	Description     *string                       `json:"description"`
 }
 
 // CreateCoupon 创建优惠券
@@ -144,6 +148,7 @@ func (s *MarketingAdminService) CreateCoupon(ctx context.Context, req *CreateCou
 		TotalCount:      req.TotalCount,
 		PerUserLimit:    req.PerUserLimit,
 		ApplicableScope: req.ApplicableScope,
+		ApplicableItems: req.ApplicableItems,
 		StartTime:       startTime,
 		EndTime:         endTime,
 		ValidDays:       req.ValidDays,
@@ -167,20 +172,21 @@ func (s *MarketingAdminService) CreateCoupon(ctx context.Context, req *CreateCou
 
 // UpdateCouponRequest 更新优惠券请求
 type UpdateCouponRequest struct {
-	Name            *string  `json:"name"`
-	Type            *string  `json:"type"`
-	Value           *float64 `json:"value"`
-	MinAmount       *float64 `json:"min_amount"`
-	MaxDiscount     *float64 `json:"max_discount"`
-	TotalCount      *int     `json:"total_count"`
-	PerUserLimit    *int     `json:"per_user_limit"`
-	ApplicableScope *string  `json:"applicable_scope"`
-	ApplicableIDs   []int64  `json:"applicable_ids"`
-	StartTime       *string  `json:"start_time"`
-	EndTime         *string  `json:"end_time"`
-	ValidDays       *int     `json:"valid_days"`
-	Description     *string  `json:"description"`
-	Status          *int8    `json:"status"`
+	Name            *string                       `json:"name"`
+	Type            *string                       `json:"type"`
+	Value           *float64                      `json:"value"`
+	MinAmount       *float64                      `json:"min_amount"`
+	MaxDiscount     *float64                      `json:"max_discount"`
+	TotalCount      *int                          `json:"total_count"`
+	PerUserLimit    *int                          `json:"per_user_limit"`
+	ApplicableScope *string                       `json:"applicable_scope"`
+	ApplicableIDs   []int64                       `json:"applicable_ids"`
+	ApplicableItems *models.CouponApplicableItems `json:"applicable_items"` // 传空对象表示取消限定
+	StartTime       *string                       `json:"start_time"`
+	EndTime         *string                       `json:"end_time"`
+	ValidDays       *int                          `json:"valid_days"`
+	Description     *string                       `json:"description"`
+	Status          *int8                         `json:"status"`
 }
 
 // UpdateCoupon 更新优惠券
@@ -215,6 +221,9 @@ func (s *MarketingAdminService) UpdateCoupon(ctx context.Context, couponID int64
 		ids, _ := json.Marshal(req.ApplicableIDs)
 		fields["applicable_ids"] = ids
 	}
+	if req.ApplicableItems != nil {
+		fields["applicable_items"] = req.ApplicableItems
+	}
 	if req.StartTime != nil {
 		startTime, err := time.ParseInLocation("2006-01-02 15:04:05", *req.StartTime, time.Local)
 		if err != nil {
@@ -288,6 +297,9 @@ type AdminCampaignItem struct {
 	StatusText  string          `json:"status_text"`
 	CreatedAt   time.Time       `json:"created_at"`
 	UpdatedAt   time.Time       `json:"updated_at"`
+
+	DailyLimit     int `json:"daily_limit"`
+	UserDailyLimit int `json:"user_daily_limit"`
 }
 
 // GetCampaignList 获取活动列表（管理端）
@@ -337,6 +349,9 @@ type CreateCampaignRequest struct {
 	Rules       json.RawMessage `json:"rules"`
 	StartTime   string          `json:"start_time" binding:"required"`
 	EndTime     string          `json:"end_time" binding:"required"`
+
+	DailyLimit     int `json:"daily_limit" binding:"min=0"`      // 秒杀每日名额，0 表示不限
+	UserDailyLimit int `json:"user_daily_limit" binding:"min=0"` // 秒杀每人每日限购次数，0 表示不限
 }
 
 // CreateCampaign 创建活动
@@ -358,14 +373,18 @@ func (s *MarketingAdminService) CreateCampaign(ctx context.Context, req *CreateC
 		StartTime:   startTime,
 		EndTime:     endTime,
 		Status:      models.CampaignStatusActive,
+
+		DailyLimit:     req.DailyLimit,
+		UserDailyLimit: req.UserDailyLimit,
 	}
 
 	// 处理规则
 	if req.Rules != nil {
-		var rulesMap models.JSON
-		if err := json.Unmarshal(req.Rules, &rulesMap); err == nil {
-			campaign.Rules = rulesMap
+		rules, err := normalizeCampaignRules(req.Type, req.Rules)
+		if err != nil {
+			return nil, err
 		}
+		campaign.Rules = rules
 	}
 
 	if err := s.campaignRepo.Create(ctx, campaign); err != nil {
@@ -385,6 +404,9 @@ type UpdateCampaignRequest struct {
 	StartTime   *string         `json:"start_time"`
 	EndTime     *string         `json:"end_time"`
 	Status      *int8           `json:"status"`
+
+	DailyLimit     *int `json:"daily_limit" binding:"omitempty,min=0"`
+	UserDailyLimit *int `json:"user_daily_limit" binding:"omitempty,min=0"`
 }
 
 // UpdateCampaign 更新活动
@@ -404,7 +426,15 @@ func (s *MarketingAdminService) UpdateCampaign(ctx context.Context, campaignID i
 		fields["image"] = *req.Image
 	}
 	if req.Rules != nil {
-		fields["rules"] = req.Rules
+		campaignType, err := s.campaignTypeForUpdate(ctx, campaignID, req.Type)
+		if err != nil {
+			return err
+		}
+		rules, err := normalizeCampaignRules(campaignType, req.Rules)
+		if err != nil {
+			return err
+		}
+		fields["rules"] = rules
 	}
 	if req.StartTime != nil {
 		startTime, err := time.ParseInLocation("2006-01-02 15:04:05", *req.StartTime, time.Local)
@@ -423,6 +453,12 @@ func (s *MarketingAdminService) UpdateCampaign(ctx context.Context, campaignID i
 	if req.Status != nil {
 		fields["status"] = *req.Status
 	}
+	if req.DailyLimit != nil {
+		fields["daily_limit"] = *req.DailyLimit
+	}
+	if req.UserDailyLimit != nil {
+		fields["user_daily_limit"] = *req.UserDailyLimit
+	}
 
 	if len(fields) == 0 {
 		return nil
@@ -431,6 +467,44 @@ func (s *MarketingAdminService) UpdateCampaign(ctx context.Context, campaignID i
 	return s.campaignRepo.UpdateFields(ctx, campaignID, fields)
 }
 
+// campaignTypeForUpdate 获取更新后的活动类型
+func (s *MarketingAdminService) campaignTypeForUpdate(ctx context.Context, campaignID int64, newType *string) (string, error) {
+	if newType != nil {
+		return *newType, nil
+	}
+	campaign, err := s.campaignRepo.GetByID(ctx, campaignID)
+	if err != nil {
+		return "", err
+	}
+	return campaign.Type, nil
+}
+
+// normalizeCampaignRules 满减活动的规则校验后统一保存为版本化的满减档位，满赠活动校验赠品规则，其他类型原样保存
+func normalizeCampaignRules(campaignType string, raw json.RawMessage) (json.RawMessage, error) {
+	if campaignType == models.CampaignTypeGift {
+		item, err := marketing.ParseGiftRules(raw)
+		if err == nil {
+			raw, err = json.Marshal(item)
+		}
+		if err != nil {
+			return nil, commonErrors.ErrInvalidParams.WithMessage(err.Error())
+		}
+		return raw, nil
+	}
+	if campaignType != models.CampaignTypeDiscount {
+		return raw, nil
+	}
+
+	rules, err := marketing.ParseDiscountRules(raw)
+	if err == nil {
+		raw, err = marketing.EncodeDiscountRules(rules)
+	}
+	if err != nil {
+		return nil, commonErrors.ErrInvalidParams.WithMessage(err.Error())
+	}
+	return raw, nil
+}
+
 // UpdateCampaignStatus 更新活动状态
 func (s *MarketingAdminService) UpdateCampaignStatus(ctx context.Context, campaignID int64, status int8) error {
 	return s.campaignRepo.UpdateStatus(ctx, campaignID, status)
@@ -451,6 +525,7 @@ func (s *MarketingAdminService) buildAdminCouponItem(c *models.Coupon) *AdminCou
 		MinAmount:       c.MinAmount,
 		MaxDiscount:     c.MaxDiscount,
 		ApplicableScope: c.ApplicableScope,
+		ApplicableItems: c.ApplicableItems,
 		StartTime:       c.StartTime,
 		EndTime:         c.EndTime,
 		ValidDays:       c.ValidDays,
@@ -514,6 +589,9 @@ func (s *MarketingAdminService) buildAdminCampaignItem(c *models.Campaign) *Admi
 		Status:      c.Status,
 		CreatedAt:   c.CreatedAt,
 		UpdatedAt:   c.UpdatedAt,
+
+		DailyLimit:     c.DailyLimit,
+		UserDailyLimit: c.UserDailyLimit,
 	}
 
 	// 设置类型文本
@@ -530,11 +608,7 @@ func (s *MarketingAdminService) buildAdminCampaignItem(c *models.Campaign) *Admi
 		item.TypeText = "活动"
 	}
 
-	// 处理规则
-	if c.Rules != nil {
-		rulesBytes, _ := json.Marshal(c.Rules)
-		item.Rules = rulesBytes
-	}
+	item.Rules = c.Rules
 
 	// 设置状态文本
 	now := time.Now()
@@ -2,6 +2,7 @@ package admin
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
@@ -13,6 +14,7 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
+	commonErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 )
@@ -214,6 +216,52 @@ func TestMarketingAdminService_CampaignCRUD(t *testing.T) {
 		assert.Equal(t, "测试活动", campaign.Name)
 	})
 
+	t.Run("创建满减活动规范化规则", func(t *testing.T) {
+		campaign, err := svc.CreateCampaign(ctx, &CreateCampaignRequest{
+			Name:      "满减活动",
+			Type:      "discount",
+			StartTime: start,
+			EndTime:   end,
+			Rules:     json.RawMessage(`{"rules":[{"min_amount":100,"discount":10},{"min_amount":200,"discount":30}]}`),
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"version":1,"tiers":[{"min_amount":100,"discount":10},{"min_amount":200,"discount":30}]}`, string(campaign.Rules))
+
+		_, err = svc.CreateCampaign(ctx, &CreateCampaignRequest{
+			Name:      "非法满减活动",
+			Type:      "discount",
+			StartTime: start,
+			EndTime:   end,
+			Rules:     json.RawMessage(`[{"min_amount":200,"discount":30},{"min_amount":100,"discount":10}]`),
+		})
+		require.Error(t, err)
+		appErr, ok := err.(*commonErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, commonErrors.ErrInvalidParams.Code, appErr.Code)
+	})
+
+	t.Run("创建秒杀活动设置每日名额", func(t *testing.T) {
+		campaign, err := svc.CreateCampaign(ctx, &CreateCampaignRequest{
+			Name:           "每日秒杀",
+			Type:           models.CampaignTypeFlashSale,
+			StartTime:      start,
+			EndTime:        end,
+			DailyLimit:     50,
+			UserDailyLimit: 1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 50, campaign.DailyLimit)
+		assert.Equal(t, 1, campaign.UserDailyLimit)
+
+		dailyLimit := 80
+		require.NoError(t, svc.UpdateCampaign(ctx, campaign.ID, &UpdateCampaignRequest{DailyLimit: &dailyLimit}))
+
+		var updated models.Campaign
+		require.NoError(t, db.First(&updated, campaign.ID).Error)
+		assert.Equal(t, 80, updated.DailyLimit)
+		assert.Equal(t, 1, updated.UserDailyLimit)
+	})
+
 	t.Run("获取活动列表", func(t *testing.T) {
 		resp, err := svc.GetCampaignList(ctx, &AdminCampaignListRequest{Page: 1, PageSize: 10})
 		require.NoError(t, err)
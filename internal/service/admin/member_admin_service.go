@@ -19,6 +19,7 @@ type MemberAdminService struct {
 	memberLevelRepo   *repository.MemberLevelRepository
 	memberPackageRepo *repository.MemberPackageRepository
 	userRepo          *repository.UserRepository
+	levelCache        *repository.CachedMemberLevelRepository
 }
 
 // NewMemberAdminService 创建会员管理服务
@@ -36,19 +37,32 @@ func NewMemberAdminService(
 	}
 }
 
+// SetMemberLevelCache 设置会员等级缓存，更新或删除会员等级后清除对应缓存
+func (s *MemberAdminService) SetMemberLevelCache(cache *repository.CachedMemberLevelRepository) {
+	s.levelCache = cache
+}
+
+// invalidateMemberLevelCache 清除会员等级缓存，清除失败时缓存最长在有效期结束后失效
+func (s *MemberAdminService) invalidateMemberLevelCache(ctx context.Context, id int64) {
+	if s.levelCache != nil {
+		_ = s.levelCache.Invalidate(ctx, id)
+	}
+}
+
 // ===================== 会员等级管理 =====================
 
 // AdminMemberLevelItem 管理端会员等级项
 type AdminMemberLevelItem struct {
-	ID          int64                  `json:"id"`
-	Name        string                 `json:"name"`
-	Level       int                    `json:"level"`
-	MinPoints   int                    `json:"min_points"`
-	Discount    float64                `json:"discount"`
-	Benefits    map[string]interface{} `json:"benefits,omitempty"`
-	Icon        *string                `json:"icon,omitempty"`
-	UserCount   int64                  `json:"user_count"`
-	CreatedAt   time.Time              `json:"created_at"`
+	ID         int64                  `json:"id"`
+	Name       string                 `json:"name"`
+	Level      int                    `json:"level"`
+	MinPoints  int                    `json:"min_points"`
+	Discount   float64                `json:"discount"`
+	PointsRate float64                `json:"points_rate"`
+	Benefits   map[string]interface{} `json:"benefits,omitempty"`
+	Icon       *string                `json:"icon,omitempty"`
+	UserCount  int64                  `json:"user_count"`
+	CreatedAt  time.Time              `json:"created_at"`
 }
 
 // GetMemberLevelList 获取会员等级列表
@@ -62,14 +76,15 @@ func (s *MemberAdminService) GetMemberLevelList(ctx context.Context) ([]*AdminMe
 	for i, level := range levels {
 		userCount, _ := s.countUsersByLevel(ctx, level.ID)
 		result[i] = &AdminMemberLevelItem{
-			ID:        level.ID,
-			Name:      level.Name,
-			Level:     level.Level,
-			MinPoints: level.MinPoints,
-			Discount:  level.Discount,
-			Icon:      level.Icon,
-			UserCount: userCount,
-			CreatedAt: level.CreatedAt,
+			ID:         level.ID,
+			Name:       level.Name,
+			Level:      level.Level,
+			MinPoints:  level.MinPoints,
+			Discount:   level.Discount,
+			PointsRate: level.PointsRate,
+			Icon:       level.Icon,
+			UserCount:  userCount,
+			CreatedAt:  level.CreatedAt,
 		}
 		if level.Benefits != nil {
 			result[i].Benefits = level.Benefits
@@ -91,14 +106,15 @@ func (s *MemberAdminService) GetMemberLevelDetail(ctx context.Context, id int64)
 
 	userCount, _ := s.countUsersByLevel(ctx, level.ID)
 	item := &AdminMemberLevelItem{
-		ID:        level.ID,
-		Name:      level.Name,
-		Level:     level.Level,
-		MinPoints: level.MinPoints,
-		Discount:  level.Discount,
-		Icon:      level.Icon,
-		UserCount: userCount,
-		CreatedAt: level.CreatedAt,
+		ID:         level.ID,
+		Name:       level.Name,
+		Level:      level.Level,
+		MinPoints:  level.MinPoints,
+		Discount:   level.Discount,
+		PointsRate: level.PointsRate,
+		Icon:       level.Icon,
+		UserCount:  userCount,
+		CreatedAt:  level.CreatedAt,
 	}
 	if level.Benefits != nil {
 		item.Benefits = level.Benefits
@@ -109,12 +125,13 @@ func (s *MemberAdminService) GetMemberLevelDetail(ctx context.Context, id int64)
 
 // CreateMemberLevelRequest 创建会员等级请求
 type CreateMemberLevelRequest struct {
-	Name      string                 `json:"name" binding:"required"`
-	Level     int                    `json:"level" binding:"required"`
-	MinPoints int                    `json:"min_points"`
-	Discount  float64                `json:"discount" binding:"required"`
-	Benefits  map[string]interface{} `json:"benefits,omitempty"`
-	Icon      *string                `json:"icon"`
+	Name       string                 `json:"name" binding:"required"`
+	Level      int                    `json:"level" binding:"required"`
+	MinPoints  int                    `json:"min_points"`
+	Discount   float64                `json:"discount" binding:"required"`
+	PointsRate *float64               `json:"points_rate" binding:"omitempty,gt=0"` // 消费积分倍率，默认1
+	Benefits   map[string]interface{} `json:"benefits,omitempty"`
+	Icon       *string                `json:"icon"`
 }
 
 // CreateMemberLevel 创建会员等级
@@ -126,11 +143,15 @@ func (s *MemberAdminService) CreateMemberLevel(ctx context.Context, req *CreateM
 	}
 
 	level := &models.MemberLevel{
-		Name:      req.Name,
-		Level:     req.Level,
-		MinPoints: req.MinPoints,
-		Discount:  req.Discount,
-		Icon:      req.Icon,
+		Name:       req.Name,
+		Level:      req.Level,
+		MinPoints:  req.MinPoints,
+		Discount:   req.Discount,
+		PointsRate: 1,
+		Icon:       req.Icon,
+	}
+	if req.PointsRate != nil {
+		level.PointsRate = *req.PointsRate
 	}
 
 	if req.Benefits != nil {
@@ -146,11 +167,12 @@ func (s *MemberAdminService) CreateMemberLevel(ctx context.Context, req *CreateM
 
 // UpdateMemberLevelRequest 更新会员等级请求
 type UpdateMemberLevelRequest struct {
-	Name      *string                `json:"name"`
-	MinPoints *int                   `json:"min_points"`
-	Discount  *float64               `json:"discount"`
-	Benefits  map[string]interface{} `json:"benefits"`
-	Icon      *string                `json:"icon"`
+	Name       *string                `json:"name"`
+	MinPoints  *int                   `json:"min_points"`
+	Discount   *float64               `json:"discount"`
+	PointsRate *float64               `json:"points_rate" binding:"omitempty,gt=0"`
+	Benefits   map[string]interface{} `json:"benefits"`
+	Icon       *string                `json:"icon"`
 }
 
 // UpdateMemberLevel 更新会员等级
@@ -172,6 +194,9 @@ func (s *MemberAdminService) UpdateMemberLevel(ctx context.Context, id int64, re
 	if req.Discount != nil {
 		level.Discount = *req.Discount
 	}
+	if req.PointsRate != nil {
+		level.PointsRate = *req.PointsRate
+	}
 	if req.Benefits != nil {
 		level.Benefits = req.Benefits
 	}
@@ -182,6 +207,7 @@ func (s *MemberAdminService) UpdateMemberLevel(ctx context.Context, id int64, re
 	if err := s.memberLevelRepo.Update(ctx, level); err != nil {
 		return errors.ErrDatabaseError.WithError(err)
 	}
+	s.invalidateMemberLevelCache(ctx, level.ID)
 
 	return nil
 }
@@ -200,6 +226,7 @@ func (s *MemberAdminService) DeleteMemberLevel(ctx context.Context, id int64) er
 	if err := s.memberLevelRepo.Delete(ctx, id); err != nil {
 		return errors.ErrDatabaseError.WithError(err)
 	}
+	s.invalidateMemberLevelCache(ctx, id)
 
 	return nil
 }
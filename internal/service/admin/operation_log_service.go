@@ -0,0 +1,60 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// OperationLogService 管理员操作日志（审计日志）服务
+// 日志由操作日志中间件在管理端写操作成功后记录，或由业务服务在事务内写入
+type OperationLogService struct {
+	logRepo *repository.OperationLogRepository
+}
+
+// NewOperationLogService 创建操作日志服务
+func NewOperationLogService(logRepo *repository.OperationLogRepository) *OperationLogService {
+	return &OperationLogService{logRepo: logRepo}
+}
+
+// OperationLogListFilters 操作日志列表筛选条件
+type OperationLogListFilters struct {
+	AdminID      int64
+	Module       string
+	Action       string
+	ResourceType string // 操作对象类型，对应 target_type
+	ResourceID   int64  // 操作对象 ID，对应 target_id
+	RoutePrefix  string // 路由前缀，如 /api/admin/finance
+	StartDate    *time.Time
+	EndDate      *time.Time
+}
+
+// List 获取操作日志列表，按时间倒序
+func (s *OperationLogService) List(ctx context.Context, page, pageSize int, filters *OperationLogListFilters) ([]*models.OperationLog, int64, error) {
+	offset := (page - 1) * pageSize
+
+	conditions := make(map[string]interface{})
+	if filters != nil {
+		conditions["admin_id"] = filters.AdminID
+		conditions["module"] = filters.Module
+		conditions["action"] = filters.Action
+		conditions["target_type"] = filters.ResourceType
+		conditions["target_id"] = filters.ResourceID
+		conditions["route_prefix"] = filters.RoutePrefix
+		if filters.StartDate != nil {
+			conditions["start_time"] = *filters.StartDate
+		}
+		if filters.EndDate != nil {
+			conditions["end_time"] = *filters.EndDate
+		}
+	}
+
+	logs, total, err := s.logRepo.List(ctx, offset, pageSize, conditions)
+	if err != nil {
+		return nil, 0, errors.ErrDatabaseError.WithError(err)
+	}
+	return logs, total, nil
+}
@@ -0,0 +1,100 @@
+package admin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+func setupOperationLogService(t *testing.T) (*OperationLogService, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Admin{}, &models.OperationLog{}))
+	return NewOperationLogService(repository.NewOperationLogRepository(db)), db
+}
+
+func TestOperationLogService_List(t *testing.T) {
+	svc, db := setupOperationLogService(t)
+	ctx := context.Background()
+
+	createLog := func(adminID int64, module, action, targetType string, targetID int64) {
+		require.NoError(t, db.Create(&models.OperationLog{
+			AdminID:    adminID,
+			Module:     module,
+			Action:     action,
+			TargetType: &targetType,
+			TargetID:   &targetID,
+			IP:         "10.0.0.1",
+		}).Error)
+	}
+	createLog(1, "finance", "process_settlement", "settlement", 10)
+	createLog(2, "finance", "process_settlement", "settlement", 11)
+	createLog(1, "device", "update", "device", 10)
+
+	t.Run("按对象类型和管理员筛选", func(t *testing.T) {
+		logs, total, err := svc.List(ctx, 1, 20, &OperationLogListFilters{ResourceType: "settlement", AdminID: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, logs, 1)
+		assert.Equal(t, int64(10), *logs[0].TargetID)
+		assert.Equal(t, "process_settlement", logs[0].Action)
+	})
+
+	t.Run("按对象筛选", func(t *testing.T) {
+		logs, total, err := svc.List(ctx, 1, 20, &OperationLogListFilters{ResourceType: "device", ResourceID: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, "device", logs[0].Module)
+	})
+
+	t.Run("无筛选条件按时间倒序分页", func(t *testing.T) {
+		logs, total, err := svc.List(ctx, 1, 2, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		require.Len(t, logs, 2)
+		assert.Greater(t, logs[0].ID, logs[1].ID)
+	})
+	t.Run("按路由前缀和日期筛选", func(t *testing.T) {
+		day := time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)
+		createRouteLog := func(route string, createdAt time.Time) {
+			require.NoError(t, db.Create(&models.OperationLog{
+				AdminID:   3,
+				Module:    "finance",
+				Action:    "update",
+				IP:        "10.0.0.1",
+				Method:    "PUT",
+				Route:     route,
+				CreatedAt: createdAt,
+			}).Error)
+		}
+		createRouteLog("/api/admin/finance/settlements/:id/process", day)
+		createRouteLog("/api/admin/finance/withdrawals/:id/handle", day.AddDate(0, 0, -2))
+		createRouteLog("/api/admin/devices/:id", day)
+
+		logs, total, err := svc.List(ctx, 1, 20, &OperationLogListFilters{RoutePrefix: "/api/admin/finance"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		for _, log := range logs {
+			assert.Contains(t, log.Route, "/api/admin/finance/")
+		}
+
+		start := day.Add(-time.Hour)
+		end := day.Add(time.Hour)
+		logs, total, err = svc.List(ctx, 1, 20, &OperationLogListFilters{RoutePrefix: "/api/admin/finance", StartDate: &start, EndDate: &end})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, logs, 1)
+		assert.Equal(t, "/api/admin/finance/settlements/:id/process", logs[0].Route)
+	})
+}
@@ -4,18 +4,36 @@ package admin
 import (
 	"context"
 	"errors"
+	"sync"
+	"time"
 
 	"gorm.io/gorm"
 
+	commonErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 )
 
+// DefaultPermissionCacheTTL 角色权限缓存的默认有效期
+// 本实例修改角色权限时立即失效，其他实例最迟在有效期后生效
+const DefaultPermissionCacheTTL = time.Minute
+
 // PermissionService 权限服务
 type PermissionService struct {
 	roleRepo       *repository.RoleRepository
 	permissionRepo *repository.PermissionRepository
 	adminRepo      *repository.AdminRepository
+
+	cacheTTL time.Duration
+	cacheMu  sync.RWMutex
+	cache    map[string]*rolePermissionCacheEntry // 角色编码 -> 权限编码集合
+	cacheGen uint64                               // 缓存失效次数，避免失效前查询的结果写回缓存
+}
+
+// rolePermissionCacheEntry 角色权限缓存项
+type rolePermissionCacheEntry struct {
+	codes     map[string]bool
+	expiresAt time.Time
 }
 
 // NewPermissionService 创建权限服务
@@ -28,18 +46,20 @@ func NewPermissionService(
 		roleRepo:       roleRepo,
 		permissionRepo: permissionRepo,
 		adminRepo:      adminRepo,
+		cacheTTL:       DefaultPermissionCacheTTL,
+		cache:          make(map[string]*rolePermissionCacheEntry),
 	}
 }
 
-// 预定义错误
+// 预定义错误 - 使用 common/errors 中的 AppError 类型
 var (
-	ErrRoleNotFound         = errors.New("角色不存在")
-	ErrRoleCodeExists       = errors.New("角色编码已存在")
-	ErrRoleIsSystem         = errors.New("系统角色不能删除或修改")
-	ErrRoleHasAdmins        = errors.New("角色下有管理员，无法删除")
-	ErrPermissionNotFound   = errors.New("权限不存在")
-	ErrPermissionCodeExists = errors.New("权限编码已存在")
-	ErrPermissionHasChildren = errors.New("权限下有子权限，无法删除")
+	ErrRoleNotFound          = commonErrors.ErrResourceNotFound.WithMessage("角色不存在")
+	ErrRoleCodeExists        = commonErrors.ErrAlreadyExists.WithMessage("角色编码已存在")
+	ErrRoleIsSystem          = commonErrors.ErrInvalidOperation.WithMessage("系统角色不能删除或修改")
+	ErrRoleHasAdmins         = commonErrors.ErrInvalidOperation.WithMessage("角色下有管理员，无法删除")
+	ErrPermissionNotFound    = commonErrors.ErrResourceNotFound.WithMessage("权限不存在")
+	ErrPermissionCodeExists  = commonErrors.ErrAlreadyExists.WithMessage("权限编码已存在")
+	ErrPermissionHasChildren = commonErrors.ErrInvalidOperation.WithMessage("权限下有子权限，无法删除")
 )
 
 // RoleInfo 角色信息
@@ -119,7 +139,11 @@ func (s *PermissionService) UpdateRole(ctx context.Context, id int64, req *Updat
 	}
 
 	// 更新权限
-	return s.roleRepo.SetPermissions(ctx, id, req.PermissionIDs)
+	if err := s.roleRepo.SetPermissions(ctx, id, req.PermissionIDs); err != nil {
+		return err
+	}
+	s.InvalidatePermissionCache()
+	return nil
 }
 
 // DeleteRole 删除角色
@@ -150,6 +174,7 @@ func (s *PermissionService) DeleteRole(ctx context.Context, id int64) error {
 	if err := s.roleRepo.SetPermissions(ctx, id, nil); err != nil {
 		return err
 	}
+	s.InvalidatePermissionCache()
 
 	return s.roleRepo.Delete(ctx, id)
 }
@@ -176,7 +201,7 @@ func (s *PermissionService) ListAllRoles(ctx context.Context) ([]*models.Role, e
 	return s.roleRepo.ListAll(ctx)
 }
 
-// SetRolePermissions 设置角色权限
+// SetRolePermissions 设置角色权限，覆盖角色原有权限并使权限缓存失效
 func (s *PermissionService) SetRolePermissions(ctx context.Context, roleID int64, permissionIDs []int64) error {
 	// 检查角色是否存在
 	_, err := s.roleRepo.GetByID(ctx, roleID)
@@ -187,7 +212,53 @@ func (s *PermissionService) SetRolePermissions(ctx context.Context, roleID int64
 		return err
 	}
 
-	return s.roleRepo.SetPermissions(ctx, roleID, permissionIDs)
+	// 检查权限是否都存在
+	if len(permissionIDs) > 0 {
+		permissions, err := s.permissionRepo.GetByIDs(ctx, permissionIDs)
+		if err != nil {
+			return err
+		}
+		found := make(map[int64]bool, len(permissions))
+		for _, p := range permissions {
+			found[p.ID] = true
+		}
+		for _, id := range permissionIDs {
+			if !found[id] {
+				return ErrPermissionNotFound
+			}
+		}
+	}
+
+	if err := s.roleRepo.SetPermissions(ctx, roleID, permissionIDs); err != nil {
+		return err
+	}
+	s.InvalidatePermissionCache()
+	return nil
+}
+
+// ListRolesWithPermissions 获取所有角色及其权限编码
+func (s *PermissionService) ListRolesWithPermissions(ctx context.Context) ([]*RoleInfo, error) {
+	roles, err := s.roleRepo.ListWithPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*RoleInfo, 0, len(roles))
+	for _, role := range roles {
+		info := &RoleInfo{
+			ID:          role.ID,
+			Code:        role.Code,
+			Name:        role.Name,
+			Description: role.Description,
+			IsSystem:    role.IsSystem,
+			Permissions: make([]string, 0, len(role.Permissions)),
+		}
+		for _, p := range role.Permissions {
+			info.Permissions = append(info.Permissions, p.Code)
+		}
+		list = append(list, info)
+	}
+	return list, nil
 }
 
 // PermissionInfo 权限信息
@@ -299,7 +370,11 @@ func (s *PermissionService) DeletePermission(ctx context.Context, id int64) erro
 		return ErrPermissionHasChildren
 	}
 
-	return s.permissionRepo.Delete(ctx, id)
+	if err := s.permissionRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.InvalidatePermissionCache()
+	return nil
 }
 
 // GetPermission 获取权限详情
@@ -374,6 +449,91 @@ func (s *PermissionService) CheckAPIPermission(ctx context.Context, adminID int6
 	return false, nil
 }
 
+// SetPermissionCacheTTL 设置角色权限缓存有效期，小于等于 0 时不缓存
+func (s *PermissionService) SetPermissionCacheTTL(ttl time.Duration) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	s.cacheTTL = ttl
+	s.cache = make(map[string]*rolePermissionCacheEntry)
+	s.cacheGen++
+}
+
+// InvalidatePermissionCache 清空角色权限缓存
+func (s *PermissionService) InvalidatePermissionCache() {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	s.cache = make(map[string]*rolePermissionCacheEntry)
+	s.cacheGen++
+}
+
+// rolePermissionCodes 获取角色拥有的权限编码集合，超级管理员返回 all = true
+// 角色不存在或查询失败时返回空集合，即按无权限处理；查询失败的结果不缓存
+func (s *PermissionService) rolePermissionCodes(roleCode string) (codes map[string]bool, all bool) {
+	if roleCode == models.RoleCodeSuperAdmin {
+		return nil, true
+	}
+
+	now := time.Now()
+	s.cacheMu.RLock()
+	entry, ok := s.cache[roleCode]
+	gen := s.cacheGen
+	s.cacheMu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.codes, false
+	}
+
+	role, err := s.roleRepo.GetByCodeWithPermissions(context.Background(), roleCode)
+	if err != nil {
+		return nil, false
+	}
+
+	codes = make(map[string]bool, len(role.Permissions))
+	for _, p := range role.Permissions {
+		codes[p.Code] = true
+	}
+
+	s.cacheMu.Lock()
+	if s.cacheTTL > 0 && s.cacheGen == gen {
+		s.cache[roleCode] = &rolePermissionCacheEntry{codes: codes, expiresAt: now.Add(s.cacheTTL)}
+	}
+	s.cacheMu.Unlock()
+	return codes, false
+}
+
+// HasPermission 检查角色是否有指定权限（实现 middleware.PermissionChecker）
+func (s *PermissionService) HasPermission(roleCode, permissionCode string) bool {
+	codes, all := s.rolePermissionCodes(roleCode)
+	return all || codes[permissionCode]
+}
+
+// HasAnyPermission 检查角色是否有任一权限
+func (s *PermissionService) HasAnyPermission(roleCode string, permissionCodes []string) bool {
+	codes, all := s.rolePermissionCodes(roleCode)
+	if all {
+		return true
+	}
+	for _, code := range permissionCodes {
+		if codes[code] {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAllPermissions 检查角色是否拥有全部权限
+func (s *PermissionService) HasAllPermissions(roleCode string, permissionCodes []string) bool {
+	codes, all := s.rolePermissionCodes(roleCode)
+	if all {
+		return true
+	}
+	for _, code := range permissionCodes {
+		if !codes[code] {
+			return false
+		}
+	}
+	return true
+}
+
 // GetAdminPermissions 获取管理员权限列表
 func (s *PermissionService) GetAdminPermissions(ctx context.Context, adminID int64) ([]string, error) {
 	admin, err := s.adminRepo.GetByIDWithRoleAndPermissions(ctx, adminID)
@@ -368,3 +368,102 @@ func TestPermissionService_CheckPermission(t *testing.T) {
 	assert.True(t, ok)
 }
 
+
+func TestPermissionService_RolePermissionChecker(t *testing.T) {
+	db := setupPermissionServiceTestDB(t)
+	svc := setupPermissionService(db)
+	ctx := context.Background()
+
+	rentalPerm := &models.Permission{Code: models.PermissionCodeRentalManagement, Name: "租借管理", Type: models.PermissionTypeAPI}
+	require.NoError(t, db.Create(rentalPerm).Error)
+	devicePerm := &models.Permission{Code: "device:read", Name: "设备查看", Type: models.PermissionTypeAPI}
+	require.NoError(t, db.Create(devicePerm).Error)
+
+	operationRole := &models.Role{Code: models.RoleCodeOperationAdmin, Name: "运营管理员", IsSystem: true}
+	require.NoError(t, db.Create(operationRole).Error)
+	require.NoError(t, repository.NewRoleRepository(db).SetPermissions(ctx, operationRole.ID, []int64{rentalPerm.ID}))
+
+	// 超级管理员无需配置权限
+	assert.True(t, svc.HasPermission(models.RoleCodeSuperAdmin, "any:perm"))
+	assert.True(t, svc.HasAllPermissions(models.RoleCodeSuperAdmin, []string{"a", "b"}))
+
+	assert.True(t, svc.HasPermission(models.RoleCodeOperationAdmin, models.PermissionCodeRentalManagement))
+	assert.False(t, svc.HasPermission(models.RoleCodeOperationAdmin, "device:read"))
+	assert.True(t, svc.HasAnyPermission(models.RoleCodeOperationAdmin, []string{"device:read", models.PermissionCodeRentalManagement}))
+	assert.False(t, svc.HasAllPermissions(models.RoleCodeOperationAdmin, []string{"device:read", models.PermissionCodeRentalManagement}))
+
+	// 不存在的角色没有任何权限
+	assert.False(t, svc.HasPermission("unknown_role", models.PermissionCodeRentalManagement))
+	assert.False(t, svc.HasAnyPermission("unknown_role", []string{models.PermissionCodeRentalManagement}))
+}
+
+func TestPermissionService_PermissionCache(t *testing.T) {
+	db := setupPermissionServiceTestDB(t)
+	svc := setupPermissionService(db)
+	ctx := context.Background()
+	roleRepo := repository.NewRoleRepository(db)
+
+	approvePerm := &models.Permission{Code: models.PermissionCodeWithdrawalApprove, Name: "提现审核", Type: models.PermissionTypeAPI}
+	require.NoError(t, db.Create(approvePerm).Error)
+	exportPerm := &models.Permission{Code: models.PermissionCodeFinanceExport, Name: "财务导出", Type: models.PermissionTypeAPI}
+	require.NoError(t, db.Create(exportPerm).Error)
+
+	financeRole := &models.Role{Code: models.RoleCodeFinanceAdmin, Name: "财务管理员", IsSystem: true}
+	require.NoError(t, db.Create(financeRole).Error)
+	require.NoError(t, roleRepo.SetPermissions(ctx, financeRole.ID, []int64{approvePerm.ID}))
+
+	assert.True(t, svc.HasPermission(models.RoleCodeFinanceAdmin, models.PermissionCodeWithdrawalApprove))
+
+	// 绕过服务直接修改数据库，缓存有效期内仍使用缓存结果
+	require.NoError(t, roleRepo.SetPermissions(ctx, financeRole.ID, nil))
+	assert.True(t, svc.HasPermission(models.RoleCodeFinanceAdmin, models.PermissionCodeWithdrawalApprove))
+
+	t.Run("通过服务修改角色权限后缓存立即失效", func(t *testing.T) {
+		require.NoError(t, svc.SetRolePermissions(ctx, financeRole.ID, []int64{exportPerm.ID}))
+		assert.False(t, svc.HasPermission(models.RoleCodeFinanceAdmin, models.PermissionCodeWithdrawalApprove))
+		assert.True(t, svc.HasPermission(models.RoleCodeFinanceAdmin, models.PermissionCodeFinanceExport))
+
+		require.NoError(t, svc.UpdateRole(ctx, financeRole.ID, &UpdateRoleRequest{Name: "财务管理员", PermissionIDs: []int64{approvePerm.ID}}))
+		assert.True(t, svc.HasPermission(models.RoleCodeFinanceAdmin, models.PermissionCodeWithdrawalApprove))
+		assert.False(t, svc.HasPermission(models.RoleCodeFinanceAdmin, models.PermissionCodeFinanceExport))
+	})
+
+	t.Run("设置不存在的权限", func(t *testing.T) {
+		err := svc.SetRolePermissions(ctx, financeRole.ID, []int64{approvePerm.ID, 99999})
+		assert.Equal(t, ErrPermissionNotFound, err)
+		assert.True(t, svc.HasPermission(models.RoleCodeFinanceAdmin, models.PermissionCodeWithdrawalApprove))
+	})
+
+	t.Run("关闭缓存后直接读取数据库", func(t *testing.T) {
+		svc.SetPermissionCacheTTL(0)
+		require.NoError(t, roleRepo.SetPermissions(ctx, financeRole.ID, []int64{exportPerm.ID}))
+		assert.False(t, svc.HasPermission(models.RoleCodeFinanceAdmin, models.PermissionCodeWithdrawalApprove))
+		assert.True(t, svc.HasPermission(models.RoleCodeFinanceAdmin, models.PermissionCodeFinanceExport))
+	})
+
+	t.Run("超级管理员不受权限限制", func(t *testing.T) {
+		assert.True(t, svc.HasPermission(models.RoleCodeSuperAdmin, models.PermissionCodeWithdrawalApprove))
+		assert.True(t, svc.HasAllPermissions(models.RoleCodeSuperAdmin, []string{models.PermissionCodeSettlementProcess, models.PermissionCodeFinanceExport}))
+	})
+}
+
+func TestPermissionService_ListRolesWithPermissions(t *testing.T) {
+	db := setupPermissionServiceTestDB(t)
+	svc := setupPermissionService(db)
+	ctx := context.Background()
+
+	perm := &models.Permission{Code: models.PermissionCodeFinanceExport, Name: "财务导出", Type: models.PermissionTypeAPI}
+	require.NoError(t, db.Create(perm).Error)
+	financeRole := &models.Role{Code: models.RoleCodeFinanceAdmin, Name: "财务管理员", IsSystem: true}
+	require.NoError(t, db.Create(financeRole).Error)
+	emptyRole := &models.Role{Code: models.RoleCodeCustomerService, Name: "客服", IsSystem: true}
+	require.NoError(t, db.Create(emptyRole).Error)
+	require.NoError(t, svc.SetRolePermissions(ctx, financeRole.ID, []int64{perm.ID}))
+
+	roles, err := svc.ListRolesWithPermissions(ctx)
+	require.NoError(t, err)
+	require.Len(t, roles, 2)
+	assert.Equal(t, models.RoleCodeFinanceAdmin, roles[0].Code)
+	assert.Equal(t, []string{models.PermissionCodeFinanceExport}, roles[0].Permissions)
+	assert.Empty(t, roles[1].Permissions)
+}
@@ -4,6 +4,8 @@ package admin
 import (
 	"context"
 	"encoding/json"
+	"log"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -14,10 +16,18 @@ import (
 
 // ProductAdminService 商品管理服务
 type ProductAdminService struct {
-	db           *gorm.DB
-	categoryRepo *repository.CategoryRepository
-	productRepo  *repository.ProductRepository
-	skuRepo      *repository.ProductSkuRepository
+	db            *gorm.DB
+	categoryRepo  *repository.CategoryRepository
+	productRepo   *repository.ProductRepository
+	skuRepo       *repository.ProductSkuRepository
+	searchIndexer productSearchIndexer
+}
+
+// productSearchIndexer 商品搜索索引维护
+type productSearchIndexer interface {
+	IndexProduct(ctx context.Context, product *models.Product) error
+	RemoveProduct(ctx context.Context, productID int64) error
+	ReindexAll(ctx context.Context) (int, error)
 }
 
 // NewProductAdminService 创建商品管理服务
@@ -35,6 +45,11 @@ func NewProductAdminService(
 	}
 }
 
+// SetSearchIndexer 设置商品搜索索引，商品创建、更新、删除后同步刷新
+func (s *ProductAdminService) SetSearchIndexer(indexer productSearchIndexer) {
+	s.searchIndexer = indexer
+}
+
 // CategoryAdminInfo 分类管理信息
 type CategoryAdminInfo struct {
 	ID        int64                `json:"id"`
@@ -181,6 +196,7 @@ type ProductAdminInfo struct {
 	Sort          int              `json:"sort"`
 	Skus          []*SkuAdminInfo  `json:"skus,omitempty"`
 	CreatedAt     string           `json:"created_at"`
+	DeletedAt     string           `json:"deleted_at,omitempty"`
 }
 
 // SkuAdminInfo SKU 管理信息
@@ -201,6 +217,8 @@ type ProductListParams struct {
 	CategoryID int64  `form:"category_id"`
 	Keyword    string `form:"keyword"`
 	IsOnSale   *bool  `form:"is_on_sale"`
+
+	IncludeDeleted bool `form:"include_deleted"` // 包含已删除的商品
 }
 
 // CreateProductRequest 创建商品请求
@@ -254,6 +272,8 @@ func (s *ProductAdminService) GetProducts(ctx context.Context, params *ProductLi
 		CategoryID: params.CategoryID,
 		Keyword:    params.Keyword,
 		IsOnSale:   params.IsOnSale,
+
+		IncludeDeleted: params.IncludeDeleted,
 	}
 
 	products, total, err := s.productRepo.List(ctx, repoParams)
@@ -269,9 +289,15 @@ func (s *ProductAdminService) GetProducts(ctx context.Context, params *ProductLi
 	return list, total, nil
 }
 
-// GetProductDetail 获取商品详情
-func (s *ProductAdminService) GetProductDetail(ctx context.Context, id int64) (*ProductAdminInfo, error) {
-	product, err := s.productRepo.GetByIDWithSkus(ctx, id)
+// GetProductDetail 获取商品详情，includeDeleted 为 true 时可查看已删除的商品
+func (s *ProductAdminService) GetProductDetail(ctx context.Context, id int64, includeDeleted bool) (*ProductAdminInfo, error) {
+	var product *models.Product
+	var err error
+	if includeDeleted {
+		product, err = s.productRepo.GetByIDWithSkusUnscoped(ctx, id)
+	} else {
+		product, err = s.productRepo.GetByIDWithSkus(ctx, id)
+	}
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, errors.ErrProductNotFound
@@ -326,6 +352,7 @@ func (s *ProductAdminService) CreateProduct(ctx context.Context, req *CreateProd
 	if err := s.productRepo.Create(ctx, product); err != nil {
 		return nil, errors.ErrDatabaseError.WithError(err)
 	}
+	s.indexProduct(ctx, product)
 
 	return s.toProductAdminInfo(product), nil
 }
@@ -384,13 +411,156 @@ func (s *ProductAdminService) UpdateProduct(ctx context.Context, id int64, req *
 	if err := s.productRepo.Update(ctx, product); err != nil {
 		return nil, errors.ErrDatabaseError.WithError(err)
 	}
+	s.indexProduct(ctx, product)
 
 	return s.toProductAdminInfo(product), nil
 }
 
-// DeleteProduct 删除商品
+// productInFlightOrderStatuses 商品删除前需要处理完毕的订单状态
+var productInFlightOrderStatuses = []string{
+	models.OrderStatusPending,
+	models.OrderStatusPaid,
+	models.OrderStatusPendingShip,
+	models.OrderStatusShipping,
+	models.OrderStatusShipped,
+	models.OrderStatusDelivered,
+	models.OrderStatusRefunding,
+}
+
+// DeleteProduct 软删除商品及其 SKU
+// 存在未完成的订单时不允许删除；购物车中的该商品保留并标记为失效，下单时拒绝
 func (s *ProductAdminService) DeleteProduct(ctx context.Context, id int64) error {
-	return s.productRepo.Delete(ctx, id)
+	if _, err := s.productRepo.GetByID(ctx, id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.ErrProductNotFound
+		}
+		return errors.ErrDatabaseError.WithError(err)
+	}
+
+	var inFlight int64
+	err := s.db.WithContext(ctx).Model(&models.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("order_items.product_id = ? AND orders.status IN ?", id, productInFlightOrderStatuses).
+		Count(&inFlight).Error
+	if err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	if inFlight > 0 {
+		return errors.ErrOperationFailed.WithMessage("该商品存在未完成的订单，无法删除")
+	}
+
+	// 商品与 SKU 使用同一删除时间，恢复时据此找回随商品一起删除的 SKU
+	deletedAt := time.Now()
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ProductSku{}).
+			Where("product_id = ?", id).
+			Update("deleted_at", deletedAt).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Product{}).
+			Where("id = ?", id).
+			Update("deleted_at", deletedAt).Error
+	})
+	if err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+
+	if s.searchIndexer != nil {
+		if err := s.searchIndexer.RemoveProduct(ctx, id); err != nil {
+			log.Printf("删除商品搜索索引失败: productID=%d, error=%v", id, err)
+		}
+	}
+	return nil
+}
+
+// RestoreProduct 恢复已删除的商品及随商品一起删除的 SKU，恢复后重新加入搜索索引
+// SKU 编码在删除期间已被其他商品使用时不允许恢复
+func (s *ProductAdminService) RestoreProduct(ctx context.Context, id int64) (*ProductAdminInfo, error) {
+	product, err := s.productRepo.GetByIDUnscoped(ctx, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrProductNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	if !product.DeletedAt.Valid {
+		return s.toProductAdminInfo(product), nil
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var skuCodes []string
+		err := tx.Unscoped().Model(&models.ProductSku{}).
+			Where("product_id = ? AND deleted_at >= ?", id, product.DeletedAt.Time).
+			Pluck("sku_code", &skuCodes).Error
+		if err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		if len(skuCodes) > 0 {
+			var conflicts int64
+			err = tx.Model(&models.ProductSku{}).
+				Where("sku_code IN ?", skuCodes).
+				Count(&conflicts).Error
+			if err != nil {
+				return errors.ErrDatabaseError.WithError(err)
+			}
+			if conflicts > 0 {
+				return errors.ErrOperationFailed.WithMessage("商品的 SKU 编码已被占用，无法恢复")
+			}
+
+			err = tx.Unscoped().Model(&models.ProductSku{}).
+				Where("product_id = ? AND deleted_at >= ?", id, product.DeletedAt.Time).
+				Update("deleted_at", nil).Error
+			if err != nil {
+				return errors.ErrDatabaseError.WithError(err)
+			}
+		}
+
+		err = tx.Unscoped().Model(&models.Product{}).
+			Where("id = ?", id).
+			Update("deleted_at", nil).Error
+		if err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	product.DeletedAt = gorm.DeletedAt{}
+	s.indexProduct(ctx, product)
+
+	return s.toProductAdminInfo(product), nil
+}
+
+// ReindexResult 重建搜索索引结果
+type ReindexResult struct {
+	Indexed int `json:"indexed"` // 已索引的商品数
+}
+
+// ReindexProducts 重建全部商品的搜索索引
+func (s *ProductAdminService) ReindexProducts(ctx context.Context) (*ReindexResult, error) {
+	if s.searchIndexer == nil {
+		return &ReindexResult{}, nil
+	}
+
+	indexed, err := s.searchIndexer.ReindexAll(ctx)
+	if err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	return &ReindexResult{Indexed: indexed}, nil
+}
+
+// indexProduct 刷新商品搜索索引
+// 索引失败不影响商品写入，可通过重建索引修复
+func (s *ProductAdminService) indexProduct(ctx context.Context, product *models.Product) {
+	if s.searchIndexer == nil {
+		return
+	}
+	if err := s.searchIndexer.IndexProduct(ctx, product); err != nil {
+		log.Printf("更新商品搜索索引失败: productID=%d, error=%v", product.ID, err)
+	}
 }
 
 // UpdateProductStatus 更新商品上架状态
@@ -449,6 +619,9 @@ func (s *ProductAdminService) toProductAdminInfo(p *models.Product) *ProductAdmi
 		CreatedAt:  p.CreatedAt.Format("2006-01-02 15:04:05"),
 	}
 
+	if p.DeletedAt.Valid {
+		info.DeletedAt = p.DeletedAt.Time.Format("2006-01-02 15:04:05")
+	}
 	if p.Subtitle != nil {
 		info.Subtitle = *p.Subtitle
 	}
@@ -33,6 +33,8 @@ func setupProductAdminTestDB(t *testing.T) *gorm.DB {
 		&models.Category{},
 		&models.Product{},
 		&models.ProductSku{},
+		&models.Order{},
+		&models.OrderItem{},
 	))
 	return db
 }
@@ -198,14 +200,14 @@ func TestProductAdminService_GetProductsAndDetail(t *testing.T) {
 	})
 
 	t.Run("GetProductDetail 获取商品详情", func(t *testing.T) {
-		detail, err := svc.GetProductDetail(ctx, product.ID)
+		detail, err := svc.GetProductDetail(ctx, product.ID, false)
 		require.NoError(t, err)
 		assert.Equal(t, product.ID, detail.ID)
 		assert.Equal(t, "商品列表测试", detail.Name)
 	})
 
 	t.Run("GetProductDetail 商品不存在", func(t *testing.T) {
-		_, err := svc.GetProductDetail(ctx, 99999)
+		_, err := svc.GetProductDetail(ctx, 99999, false)
 		assert.Error(t, err)
 	})
 }
@@ -264,3 +266,186 @@ func TestProductAdminService_UpdateAndDeleteProduct(t *testing.T) {
 	})
 }
 
+
+// recordingSearchIndexer 记录商品搜索索引维护调用
+type recordingSearchIndexer struct {
+	indexed  []string
+	removed  []int64
+	reindex  int
+	indexErr error
+}
+
+func (r *recordingSearchIndexer) IndexProduct(_ context.Context, product *models.Product) error {
+	r.indexed = append(r.indexed, product.Name)
+	return r.indexErr
+}
+
+func (r *recordingSearchIndexer) RemoveProduct(_ context.Context, productID int64) error {
+	r.removed = append(r.removed, productID)
+	return nil
+}
+
+func (r *recordingSearchIndexer) ReindexAll(_ context.Context) (int, error) {
+	r.reindex++
+	return 7, nil
+}
+
+func TestProductAdminService_SearchIndexMaintenance(t *testing.T) {
+	db := setupProductAdminTestDB(t)
+	svc := NewProductAdminService(
+		db,
+		repository.NewCategoryRepository(db),
+		repository.NewProductRepository(db),
+		repository.NewProductSkuRepository(db),
+	)
+	ctx := context.Background()
+
+	t.Run("未设置索引时重建返回 0", func(t *testing.T) {
+		result, err := svc.ReindexProducts(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.Indexed)
+	})
+
+	indexer := &recordingSearchIndexer{}
+	svc.SetSearchIndexer(indexer)
+
+	cat, err := svc.CreateCategory(ctx, &CreateCategoryRequest{Name: "索引分类"})
+	require.NoError(t, err)
+	product, err := svc.CreateProduct(ctx, &CreateProductRequest{
+		CategoryID: cat.ID,
+		Name:       "索引商品",
+		Images:     []string{"img1"},
+		Price:      10,
+		IsOnSale:   true,
+	})
+	require.NoError(t, err)
+
+	_, err = svc.UpdateProduct(ctx, product.ID, &UpdateProductRequest{Name: "索引商品改名"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"索引商品", "索引商品改名"}, indexer.indexed)
+
+	require.NoError(t, svc.DeleteProduct(ctx, product.ID))
+	assert.Equal(t, []int64{product.ID}, indexer.removed)
+
+	result, err := svc.ReindexProducts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 7, result.Indexed)
+	assert.Equal(t, 1, indexer.reindex)
+
+	t.Run("索引失败不影响商品写入", func(t *testing.T) {
+		indexer.indexErr = assert.AnError
+		created, err := svc.CreateProduct(ctx, &CreateProductRequest{
+			CategoryID: cat.ID,
+			Name:       "索引失败商品",
+			Images:     []string{"img1"},
+			Price:      10,
+		})
+		require.NoError(t, err)
+		assert.NotZero(t, created.ID)
+	})
+}
+
+func TestProductAdminService_SoftDeleteAndRestore(t *testing.T) {
+	db := setupProductAdminTestDB(t)
+	svc := NewProductAdminService(
+		db,
+		repository.NewCategoryRepository(db),
+		repository.NewProductRepository(db),
+		repository.NewProductSkuRepository(db),
+	)
+	ctx := context.Background()
+
+	cat, err := svc.CreateCategory(ctx, &CreateCategoryRequest{Name: "软删除分类"})
+	require.NoError(t, err)
+	createProduct := func(name string) *ProductAdminInfo {
+		p, err := svc.CreateProduct(ctx, &CreateProductRequest{
+			CategoryID: cat.ID,
+			Name:       name,
+			Images:     []string{"img1"},
+			Price:      10,
+			Stock:      5,
+			IsOnSale:   true,
+		})
+		require.NoError(t, err)
+		return p
+	}
+
+	t.Run("存在未完成订单时不能删除", func(t *testing.T) {
+		product := createProduct("在途订单商品")
+		order := &models.Order{OrderNo: "SD0001", UserID: 1, Type: models.OrderTypeMall, OriginalAmount: 10, ActualAmount: 10, Status: models.OrderStatusPaid}
+		require.NoError(t, db.Create(order).Error)
+		require.NoError(t, db.Create(&models.OrderItem{OrderID: order.ID, ProductID: &product.ID, ProductName: product.Name, Price: 10, Quantity: 1, Subtotal: 10}).Error)
+
+		err := svc.DeleteProduct(ctx, product.ID)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrOperationFailed.Code, appErr.Code)
+
+		// 订单完成后允许删除
+		require.NoError(t, db.Model(order).Update("status", models.OrderStatusCompleted).Error)
+		require.NoError(t, svc.DeleteProduct(ctx, product.ID))
+	})
+
+	t.Run("删除后列表默认隐藏，include_deleted 可查看", func(t *testing.T) {
+		product := createProduct("待删除商品")
+		require.NoError(t, db.Create(&models.ProductSku{ProductID: product.ID, SkuCode: "SD-SKU-1", Attributes: []byte(`{}`), Price: 10, IsActive: true}).Error)
+		require.NoError(t, svc.DeleteProduct(ctx, product.ID))
+
+		_, err := svc.GetProductDetail(ctx, product.ID, false)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrProductNotFound.Code, appErr.Code)
+
+		detail, err := svc.GetProductDetail(ctx, product.ID, true)
+		require.NoError(t, err)
+		assert.NotEmpty(t, detail.DeletedAt)
+		require.Len(t, detail.Skus, 1)
+
+		list, _, err := svc.GetProducts(ctx, &ProductListParams{Keyword: "待删除"})
+		require.NoError(t, err)
+		assert.Empty(t, list)
+
+		list, total, err := svc.GetProducts(ctx, &ProductListParams{Keyword: "待删除", IncludeDeleted: true})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, list, 1)
+		assert.NotEmpty(t, list[0].DeletedAt)
+	})
+
+	t.Run("恢复商品及一起删除的 SKU", func(t *testing.T) {
+		product := createProduct("待恢复商品")
+		sku := &models.ProductSku{ProductID: product.ID, SkuCode: "SD-SKU-2", Attributes: []byte(`{}`), Price: 10, IsActive: true}
+		require.NoError(t, db.Create(sku).Error)
+		require.NoError(t, svc.DeleteProduct(ctx, product.ID))
+
+		restored, err := svc.RestoreProduct(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Empty(t, restored.DeletedAt)
+
+		detail, err := svc.GetProductDetail(ctx, product.ID, false)
+		require.NoError(t, err)
+		require.Len(t, detail.Skus, 1)
+		assert.Equal(t, "SD-SKU-2", detail.Skus[0].SkuCode)
+
+		// 未删除的商品恢复时直接返回
+		_, err = svc.RestoreProduct(ctx, product.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("SKU 编码被占用时不能恢复", func(t *testing.T) {
+		product := createProduct("编码冲突商品")
+		require.NoError(t, db.Create(&models.ProductSku{ProductID: product.ID, SkuCode: "SD-SKU-3", Attributes: []byte(`{}`), Price: 10, IsActive: true}).Error)
+		require.NoError(t, svc.DeleteProduct(ctx, product.ID))
+
+		other := createProduct("占用编码商品")
+		require.NoError(t, db.Create(&models.ProductSku{ProductID: other.ID, SkuCode: "SD-SKU-3", Attributes: []byte(`{}`), Price: 10, IsActive: true}).Error)
+
+		_, err := svc.RestoreProduct(ctx, product.ID)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrOperationFailed.Code, appErr.Code)
+
+		_, err = svc.GetProductDetail(ctx, product.ID, false)
+		assert.Error(t, err)
+	})
+}
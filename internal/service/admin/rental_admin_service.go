@@ -0,0 +1,156 @@
+// Package admin 管理端服务
+package admin
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// RentalAdminService 租借管理服务
+type RentalAdminService struct {
+	db *gorm.DB
+}
+
+// NewRentalAdminService 创建租借管理服务
+func NewRentalAdminService(db *gorm.DB) *RentalAdminService {
+	return &RentalAdminService{db: db}
+}
+
+// RentalListFilters 租借列表筛选条件
+type RentalListFilters struct {
+	Status   string
+	DeviceID int64
+	VenueID  int64
+}
+
+// RentalListItem 租借列表项
+type RentalListItem struct {
+	ID               int64      `json:"id"`
+	OrderID          int64      `json:"order_id"`
+	Status           string     `json:"status"`
+	UserID           int64      `json:"user_id"`
+	UserPhone        string     `json:"user_phone"`
+	UserNickname     string     `json:"user_nickname"`
+	DeviceID         int64      `json:"device_id"`
+	DeviceNo         string     `json:"device_no"`
+	DeviceName       string     `json:"device_name"`
+	VenueID          int64      `json:"venue_id"`
+	VenueName        string     `json:"venue_name"`
+	MerchantID       int64      `json:"merchant_id"`
+	MerchantName     string     `json:"merchant_name"`
+	DurationHours    int        `json:"duration_hours"`
+	RentalFee        float64    `json:"rental_fee"`
+	Deposit          float64    `json:"deposit"`
+	OvertimeFee      float64    `json:"overtime_fee"`
+	UnlockedAt       *time.Time `json:"unlocked_at,omitempty"`
+	ExpectedReturnAt *time.Time `json:"expected_return_at,omitempty"`
+	ReturnedAt       *time.Time `json:"returned_at,omitempty"`
+	UsedMinutes      int64      `json:"used_minutes"` // 已使用时长(分钟)
+	IsOvertime       bool       `json:"is_overtime"`  // 是否已超时(超过预计归还时间及宽限期)
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// ListRentals 获取租借列表（关联用户、设备、场地及商户信息）
+func (s *RentalAdminService) ListRentals(ctx context.Context, page, pageSize int, filters *RentalListFilters) ([]*RentalListItem, int64, error) {
+	offset := (page - 1) * pageSize
+
+	query := s.db.WithContext(ctx).Model(&models.Rental{})
+
+	if filters != nil {
+		if filters.Status != "" {
+			query = query.Where("rentals.status = ?", filters.Status)
+		}
+		if filters.DeviceID > 0 {
+			query = query.Where("rentals.device_id = ?", filters.DeviceID)
+		}
+		if filters.VenueID > 0 {
+			query = query.Joins("JOIN devices ON devices.id = rentals.device_id").
+				Where("devices.venue_id = ?", filters.VenueID)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rentals []*models.Rental
+	// 设备可能已被软删除，历史租借仍需展示设备信息
+	if err := query.Preload("User").
+		Preload("Device", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Device.Venue.Merchant").
+		Order("rentals.id DESC").Offset(offset).Limit(pageSize).
+		Find(&rentals).Error; err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	results := make([]*RentalListItem, len(rentals))
+	for i, rental := range rentals {
+		results[i] = toRentalListItem(rental, now)
+	}
+
+	return results, total, nil
+}
+
+// toRentalListItem 转换为租借列表项
+// 使用中/超时未还的租借以 now 计算已使用时长和超时状态，已归还的以归还时间计算
+func toRentalListItem(rental *models.Rental, now time.Time) *RentalListItem {
+	item := &RentalListItem{
+		ID:               rental.ID,
+		OrderID:          rental.OrderID,
+		Status:           rental.Status,
+		UserID:           rental.UserID,
+		DeviceID:         rental.DeviceID,
+		DurationHours:    rental.DurationHours,
+		RentalFee:        rental.RentalFee,
+		Deposit:          rental.Deposit,
+		OvertimeFee:      rental.OvertimeFee,
+		UnlockedAt:       rental.UnlockedAt,
+		ExpectedReturnAt: rental.ExpectedReturnAt,
+		ReturnedAt:       rental.ReturnedAt,
+		CreatedAt:        rental.CreatedAt,
+	}
+
+	if rental.User != nil {
+		item.UserNickname = rental.User.Nickname
+		if rental.User.Phone != nil {
+			item.UserPhone = *rental.User.Phone
+		}
+	}
+
+	if rental.Device != nil {
+		item.DeviceNo = rental.Device.DeviceNo
+		item.DeviceName = rental.Device.Name
+		item.VenueID = rental.Device.VenueID
+		if rental.Device.Venue != nil {
+			item.VenueName = rental.Device.Venue.Name
+			item.MerchantID = rental.Device.Venue.MerchantID
+			if rental.Device.Venue.Merchant != nil {
+				item.MerchantName = rental.Device.Venue.Merchant.Name
+			}
+		}
+	}
+
+	end := now
+	if rental.ReturnedAt != nil {
+		end = *rental.ReturnedAt
+	} else if rental.Status != models.RentalStatusInUse && rental.Status != models.RentalStatusOverdue {
+		// 未开始使用或已结束但无归还时间的租借不计算时长和超时
+		return item
+	}
+
+	if rental.UnlockedAt != nil && end.After(*rental.UnlockedAt) {
+		item.UsedMinutes = int64(end.Sub(*rental.UnlockedAt) / time.Minute)
+	}
+	if rental.ExpectedReturnAt != nil {
+		deadline := rental.ExpectedReturnAt.Add(time.Duration(rental.GracePeriodMinutes) * time.Minute)
+		item.IsOvertime = end.After(deadline)
+	}
+
+	return item
+}
@@ -0,0 +1,190 @@
+// Package admin 租借管理服务单元测试
+package admin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// rentalAdminFixture 租借管理测试数据
+type rentalAdminFixture struct {
+	user     *models.User
+	merchant *models.Merchant
+	venueA   *models.Venue
+	venueB   *models.Venue
+	deviceA  *models.Device
+	deviceB  *models.Device
+}
+
+// setupRentalAdminService 创建测试用的 RentalAdminService 及基础数据
+func setupRentalAdminService(t *testing.T) (*RentalAdminService, *gorm.DB, *rentalAdminFixture) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Merchant{}, &models.Venue{}, &models.Device{}, &models.Rental{}))
+
+	phone := "13800138000"
+	f := &rentalAdminFixture{}
+	f.user = &models.User{Phone: &phone, Nickname: "租借用户", Status: models.UserStatusActive}
+	require.NoError(t, db.Create(f.user).Error)
+
+	f.merchant = &models.Merchant{Name: "测试商户", ContactName: "C", ContactPhone: "138", CommissionRate: 0.2, SettlementType: models.SettlementTypeMonthly, Status: models.MerchantStatusActive}
+	require.NoError(t, db.Create(f.merchant).Error)
+
+	f.venueA = &models.Venue{MerchantID: f.merchant.ID, Name: "场地A", Type: models.VenueTypeMall, Province: "广东省", City: "深圳市", District: "南山区", Address: "A"}
+	f.venueB = &models.Venue{MerchantID: f.merchant.ID, Name: "场地B", Type: models.VenueTypeMall, Province: "广东省", City: "深圳市", District: "福田区", Address: "B"}
+	require.NoError(t, db.Create(f.venueA).Error)
+	require.NoError(t, db.Create(f.venueB).Error)
+
+	f.deviceA = &models.Device{DeviceNo: "RA-001", Name: "设备A", Type: models.DeviceTypeStandard, VenueID: f.venueA.ID, QRCode: "/qr/ra-001", ProductName: "商品", Status: models.DeviceStatusActive}
+	f.deviceB = &models.Device{DeviceNo: "RA-002", Name: "设备B", Type: models.DeviceTypeStandard, VenueID: f.venueB.ID, QRCode: "/qr/ra-002", ProductName: "商品", Status: models.DeviceStatusActive}
+	require.NoError(t, db.Create(f.deviceA).Error)
+	require.NoError(t, db.Create(f.deviceB).Error)
+
+	return NewRentalAdminService(db), db, f
+}
+
+// createTestRentalForAdmin 创建测试租借，unlockedAgo 为开锁距今时长
+func createTestRentalForAdmin(t *testing.T, db *gorm.DB, orderID, userID, deviceID int64, status string, unlockedAgo time.Duration, hours int) *models.Rental {
+	t.Helper()
+
+	unlockedAt := time.Now().Add(-unlockedAgo)
+	expectedReturnAt := unlockedAt.Add(time.Duration(hours) * time.Hour)
+	rental := &models.Rental{
+		OrderID:            orderID,
+		UserID:             userID,
+		DeviceID:           deviceID,
+		DurationHours:      hours,
+		RentalFee:          10,
+		Deposit:            50,
+		OvertimeRate:       5,
+		GracePeriodMinutes: 10,
+		Status:             status,
+		UnlockedAt:         &unlockedAt,
+		ExpectedReturnAt:   &expectedReturnAt,
+	}
+	require.NoError(t, db.Create(rental).Error)
+	return rental
+}
+
+func TestRentalAdminService_ListRentals_JoinsRelations(t *testing.T) {
+	svc, db, f := setupRentalAdminService(t)
+	ctx := context.Background()
+
+	rental := createTestRentalForAdmin(t, db, 1, f.user.ID, f.deviceA.ID, models.RentalStatusInUse, 30*time.Minute, 1)
+
+	list, total, err := svc.ListRentals(ctx, 1, 20, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, list, 1)
+
+	item := list[0]
+	assert.Equal(t, rental.ID, item.ID)
+	assert.Equal(t, "13800138000", item.UserPhone)
+	assert.Equal(t, "租借用户", item.UserNickname)
+	assert.Equal(t, "RA-001", item.DeviceNo)
+	assert.Equal(t, "设备A", item.DeviceName)
+	assert.Equal(t, f.venueA.ID, item.VenueID)
+	assert.Equal(t, "场地A", item.VenueName)
+	assert.Equal(t, f.merchant.ID, item.MerchantID)
+	assert.Equal(t, "测试商户", item.MerchantName)
+	assert.InDelta(t, 30, item.UsedMinutes, 1)
+	require.NotNil(t, item.ExpectedReturnAt)
+	assert.False(t, item.IsOvertime)
+}
+
+func TestRentalAdminService_ListRentals_Filters(t *testing.T) {
+	svc, db, f := setupRentalAdminService(t)
+	ctx := context.Background()
+
+	createTestRentalForAdmin(t, db, 1, f.user.ID, f.deviceA.ID, models.RentalStatusInUse, time.Hour, 2)
+	createTestRentalForAdmin(t, db, 2, f.user.ID, f.deviceB.ID, models.RentalStatusInUse, time.Hour, 2)
+	createTestRentalForAdmin(t, db, 3, f.user.ID, f.deviceA.ID, models.RentalStatusCompleted, 5*time.Hour, 2)
+
+	t.Run("按状态筛选", func(t *testing.T) {
+		list, total, err := svc.ListRentals(ctx, 1, 20, &RentalListFilters{Status: models.RentalStatusInUse})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, list, 2)
+	})
+
+	t.Run("按设备筛选", func(t *testing.T) {
+		list, total, err := svc.ListRentals(ctx, 1, 20, &RentalListFilters{DeviceID: f.deviceA.ID})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		for _, item := range list {
+			assert.Equal(t, f.deviceA.ID, item.DeviceID)
+		}
+	})
+
+	t.Run("按场地筛选", func(t *testing.T) {
+		list, total, err := svc.ListRentals(ctx, 1, 20, &RentalListFilters{Status: models.RentalStatusInUse, VenueID: f.venueB.ID})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, list, 1)
+		assert.Equal(t, "场地B", list[0].VenueName)
+	})
+
+	t.Run("分页", func(t *testing.T) {
+		list, total, err := svc.ListRentals(ctx, 2, 2, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		assert.Len(t, list, 1)
+	})
+}
+
+func TestRentalAdminService_ListRentals_Overtime(t *testing.T) {
+	svc, db, f := setupRentalAdminService(t)
+	ctx := context.Background()
+
+	// 1 小时租借，开锁 65 分钟：仍在 10 分钟宽限期内
+	inGrace := createTestRentalForAdmin(t, db, 1, f.user.ID, f.deviceA.ID, models.RentalStatusInUse, 65*time.Minute, 1)
+	// 1 小时租借，开锁 2 小时：已超时
+	overtime := createTestRentalForAdmin(t, db, 2, f.user.ID, f.deviceA.ID, models.RentalStatusOverdue, 2*time.Hour, 1)
+	// 未开锁的租借不计算时长
+	pending := &models.Rental{OrderID: 3, UserID: f.user.ID, DeviceID: f.deviceA.ID, DurationHours: 1, RentalFee: 10, Deposit: 50, OvertimeRate: 5, Status: models.RentalStatusPaid}
+	require.NoError(t, db.Create(pending).Error)
+
+	list, _, err := svc.ListRentals(ctx, 1, 20, nil)
+	require.NoError(t, err)
+
+	items := make(map[int64]*RentalListItem, len(list))
+	for _, item := range list {
+		items[item.ID] = item
+	}
+
+	assert.False(t, items[inGrace.ID].IsOvertime)
+	assert.True(t, items[overtime.ID].IsOvertime)
+	assert.InDelta(t, 120, items[overtime.ID].UsedMinutes, 1)
+	assert.False(t, items[pending.ID].IsOvertime)
+	assert.Zero(t, items[pending.ID].UsedMinutes)
+}
+
+func TestToRentalListItem_ReturnedUsesReturnTime(t *testing.T) {
+	now := time.Now()
+	unlockedAt := now.Add(-3 * time.Hour)
+	expectedReturnAt := unlockedAt.Add(time.Hour)
+	returnedAt := unlockedAt.Add(50 * time.Minute)
+
+	item := toRentalListItem(&models.Rental{
+		Status:           models.RentalStatusReturned,
+		UnlockedAt:       &unlockedAt,
+		ExpectedReturnAt: &expectedReturnAt,
+		ReturnedAt:       &returnedAt,
+	}, now)
+
+	assert.Equal(t, int64(50), item.UsedMinutes)
+	assert.False(t, item.IsOvertime)
+}
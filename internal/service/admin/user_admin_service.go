@@ -3,18 +3,28 @@ package admin
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
 
+	commonErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 )
 
 // UserAdminService 用户管理服务
 type UserAdminService struct {
-	userRepo *repository.UserRepository
-	db       *gorm.DB
+	userRepo     *repository.UserRepository
+	db           *gorm.DB
+	tokenRevoker UserTokenRevoker
+}
+
+// UserTokenRevoker 用户令牌吊销器
+type UserTokenRevoker interface {
+	// RevokeUser 吊销用户全部未过期的令牌，返回吊销的会话数
+	RevokeUser(ctx context.Context, userType string, userID int64) (int, error)
 }
 
 // NewUserAdminService 创建用户管理服务
@@ -25,6 +35,11 @@ func NewUserAdminService(db *gorm.DB, userRepo *repository.UserRepository) *User
 	}
 }
 
+// SetTokenRevoker 设置令牌吊销器，用于强制用户下线
+func (s *UserAdminService) SetTokenRevoker(revoker UserTokenRevoker) {
+	s.tokenRevoker = revoker
+}
+
 // UserListFilters 用户列表筛选条件
 type UserListFilters struct {
 	Phone         string
@@ -167,6 +182,25 @@ func (s *UserAdminService) Disable(ctx context.Context, id int64) error {
 	return s.UpdateStatus(ctx, id, models.UserStatusDisabled)
 }
 
+// ForceLogout 强制用户下线，吊销用户全部未过期的令牌，返回吊销的会话数
+func (s *UserAdminService) ForceLogout(ctx context.Context, id int64) (int, error) {
+	if _, err := s.userRepo.GetByID(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, commonErrors.ErrUserNotFound
+		}
+		return 0, commonErrors.ErrDatabaseError.WithError(err)
+	}
+	if s.tokenRevoker == nil {
+		return 0, commonErrors.ErrOperationFailed.WithMessage("未启用令牌吊销")
+	}
+
+	revoked, err := s.tokenRevoker.RevokeUser(ctx, jwt.UserTypeUser, id)
+	if err != nil {
+		return 0, commonErrors.ErrInternalError.WithError(err)
+	}
+	return revoked, nil
+}
+
 // AdjustPoints 调整积分
 func (s *UserAdminService) AdjustPoints(ctx context.Context, id int64, points int, remark string) error {
 	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
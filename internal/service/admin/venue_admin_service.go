@@ -17,6 +17,8 @@ type VenueAdminService struct {
 	venueRepo    *repository.VenueRepository
 	merchantRepo *repository.MerchantRepository
 	deviceRepo   *repository.DeviceRepository
+	pricingCache *repository.CachedRentalPricingRepository
+	scheduleRepo *repository.PricingScheduleRepository
 }
 
 // NewVenueAdminService 创建场地管理服务
@@ -32,48 +34,73 @@ func NewVenueAdminService(
 	}
 }
 
+// SetPricingCache 设置租借定价缓存，更新定价后清除对应缓存
+func (s *VenueAdminService) SetPricingCache(cache *repository.CachedRentalPricingRepository) {
+	s.pricingCache = cache
+}
+
+// SetPricingScheduleRepository 设置场地分时定价仓储，未设置时不支持分时定价管理
+func (s *VenueAdminService) SetPricingScheduleRepository(repo *repository.PricingScheduleRepository) {
+	s.scheduleRepo = repo
+}
+
 // 预定义错误（使用 common/errors 包的 AppError）
 var (
 	venueNotFoundErr   = commonErrors.ErrVenueNotFound
 	venueHasDevicesErr = commonErrors.ErrVenueHasDevices
+	pricingNotFoundErr = commonErrors.ErrPricingNotFound
 )
 
 // VenueInfo 场地信息
 type VenueInfo struct {
-	ID           int64   `json:"id"`
-	MerchantID   int64   `json:"merchant_id"`
-	MerchantName string  `json:"merchant_name,omitempty"`
-	Name         string  `json:"name"`
-	Type         string  `json:"type"`
-	Province     string  `json:"province"`
-	City         string  `json:"city"`
-	District     string  `json:"district"`
-	Address      string  `json:"address"`
-	Longitude    float64 `json:"longitude,omitempty"`
-	Latitude     float64 `json:"latitude,omitempty"`
-	ContactName  *string `json:"contact_name,omitempty"`
-	ContactPhone *string `json:"contact_phone,omitempty"`
-	DeviceCount  int64   `json:"device_count"`
-	Status       int8    `json:"status"`
+	ID                        int64   `json:"id"`
+	MerchantID                int64   `json:"merchant_id"`
+	MerchantName              string  `json:"merchant_name,omitempty"`
+	Name                      string  `json:"name"`
+	Type                      string  `json:"type"`
+	Province                  string  `json:"province"`
+	City                      string  `json:"city"`
+	District                  string  `json:"district"`
+	Address                   string  `json:"address"`
+	Longitude                 float64 `json:"longitude,omitempty"`
+	Latitude                  float64 `json:"latitude,omitempty"`
+	ContactName               *string `json:"contact_name,omitempty"`
+	ContactPhone              *string `json:"contact_phone,omitempty"`
+	DeviceCount               int64   `json:"device_count"`
+	Status                    int8    `json:"status"`
+	DefaultGracePeriodMinutes int     `json:"default_grace_period_minutes"`
+	Timezone                  string  `json:"timezone"`
 }
 
 // CreateVenueRequest 创建场地请求
 type CreateVenueRequest struct {
-	MerchantID   int64    `json:"merchant_id" binding:"required"`
-	Name         string   `json:"name" binding:"required,max=100"`
-	Type         string   `json:"type" binding:"required,oneof=mall hotel community office other"`
-	Province     string   `json:"province" binding:"required,max=50"`
-	City         string   `json:"city" binding:"required,max=50"`
-	District     string   `json:"district" binding:"required,max=50"`
-	Address      string   `json:"address" binding:"required,max=255"`
-	Longitude    *float64 `json:"longitude"`
-	Latitude     *float64 `json:"latitude"`
-	ContactName  *string  `json:"contact_name"`
-	ContactPhone *string  `json:"contact_phone"`
+	MerchantID                int64    `json:"merchant_id" binding:"required"`
+	Name                      string   `json:"name" binding:"required,max=100"`
+	Type                      string   `json:"type" binding:"required,oneof=mall hotel community office other"`
+	Province                  string   `json:"province" binding:"required,max=50"`
+	City                      string   `json:"city" binding:"required,max=50"`
+	District                  string   `json:"district" binding:"required,max=50"`
+	Address                   string   `json:"address" binding:"required,max=255"`
+	Longitude                 *float64 `json:"longitude"`
+	Latitude                  *float64 `json:"latitude"`
+	ContactName               *string  `json:"contact_name"`
+	ContactPhone              *string  `json:"contact_phone"`
+	DefaultGracePeriodMinutes int      `json:"default_grace_period_minutes" binding:"min=0,max=60"`
+	Timezone                  string   `json:"timezone"` // IANA 时区名称，默认 Asia/Shanghai
 }
 
 // CreateVenue 创建场地
 func (s *VenueAdminService) CreateVenue(ctx context.Context, req *CreateVenueRequest) (*models.Venue, error) {
+	if err := validateGracePeriod(req.DefaultGracePeriodMinutes); err != nil {
+		return nil, err
+	}
+	if req.Timezone == "" {
+		req.Timezone = models.DefaultVenueTimezone
+	}
+	if err := validateTimezone(req.Timezone); err != nil {
+		return nil, err
+	}
+
 	// 检查商户是否存在
 	_, err := s.merchantRepo.GetByID(ctx, req.MerchantID)
 	if err != nil {
@@ -84,18 +111,20 @@ func (s *VenueAdminService) CreateVenue(ctx context.Context, req *CreateVenueReq
 	}
 
 	venue := &models.Venue{
-		MerchantID:   req.MerchantID,
-		Name:         req.Name,
-		Type:         req.Type,
-		Province:     req.Province,
-		City:         req.City,
-		District:     req.District,
-		Address:      req.Address,
-		Longitude:    req.Longitude,
-		Latitude:     req.Latitude,
-		ContactName:  req.ContactName,
-		ContactPhone: req.ContactPhone,
-		Status:       models.VenueStatusActive,
+		MerchantID:                req.MerchantID,
+		Name:                      req.Name,
+		Type:                      req.Type,
+		Province:                  req.Province,
+		City:                      req.City,
+		District:                  req.District,
+		Address:                   req.Address,
+		Longitude:                 req.Longitude,
+		Latitude:                  req.Latitude,
+		ContactName:               req.ContactName,
+		ContactPhone:              req.ContactPhone,
+		Status:                    models.VenueStatusActive,
+		DefaultGracePeriodMinutes: req.DefaultGracePeriodMinutes,
+		Timezone:                  req.Timezone,
 	}
 
 	if err := s.venueRepo.Create(ctx, venue); err != nil {
@@ -107,21 +136,32 @@ func (s *VenueAdminService) CreateVenue(ctx context.Context, req *CreateVenueReq
 
 // UpdateVenueRequest 更新场地请求
 type UpdateVenueRequest struct {
-	MerchantID   int64    `json:"merchant_id" binding:"required"`
-	Name         string   `json:"name" binding:"required,max=100"`
-	Type         string   `json:"type" binding:"required,oneof=mall hotel community office other"`
-	Province     string   `json:"province" binding:"required,max=50"`
-	City         string   `json:"city" binding:"required,max=50"`
-	District     string   `json:"district" binding:"required,max=50"`
-	Address      string   `json:"address" binding:"required,max=255"`
-	Longitude    *float64 `json:"longitude"`
-	Latitude     *float64 `json:"latitude"`
-	ContactName  *string  `json:"contact_name"`
-	ContactPhone *string  `json:"contact_phone"`
+	MerchantID                int64    `json:"merchant_id" binding:"required"`
+	Name                      string   `json:"name" binding:"required,max=100"`
+	Type                      string   `json:"type" binding:"required,oneof=mall hotel community office other"`
+	Province                  string   `json:"province" binding:"required,max=50"`
+	City                      string   `json:"city" binding:"required,max=50"`
+	District                  string   `json:"district" binding:"required,max=50"`
+	Address                   string   `json:"address" binding:"required,max=255"`
+	Longitude                 *float64 `json:"longitude"`
+	Latitude                  *float64 `json:"latitude"`
+	ContactName               *string  `json:"contact_name"`
+	ContactPhone              *string  `json:"contact_phone"`
+	DefaultGracePeriodMinutes int      `json:"default_grace_period_minutes" binding:"min=0,max=60"`
+	Timezone                  string   `json:"timezone"` // IANA 时区名称，默认 Asia/Shanghai
 }
 
 // UpdateVenue 更新场地
 func (s *VenueAdminService) UpdateVenue(ctx context.Context, id int64, req *UpdateVenueRequest) error {
+	if err := validateGracePeriod(req.DefaultGracePeriodMinutes); err != nil {
+		return err
+	}
+	if req.Timezone != "" {
+		if err := validateTimezone(req.Timezone); err != nil {
+			return err
+		}
+	}
+
 	venue, err := s.venueRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -152,6 +192,10 @@ func (s *VenueAdminService) UpdateVenue(ctx context.Context, id int64, req *Upda
 	venue.Latitude = req.Latitude
 	venue.ContactName = req.ContactName
 	venue.ContactPhone = req.ContactPhone
+	venue.DefaultGracePeriodMinutes = req.DefaultGracePeriodMinutes
+	if req.Timezone != "" {
+		venue.Timezone = req.Timezone
+	}
 
 	return s.venueRepo.Update(ctx, venue)
 }
@@ -229,21 +273,256 @@ func (s *VenueAdminService) ListVenuesByMerchant(ctx context.Context, merchantID
 	return s.venueRepo.ListByMerchantSimple(ctx, merchantID)
 }
 
+// ============ 定价管理 ============
+
+// PricingRequest 创建/更新定价请求
+type PricingRequest struct {
+	DurationHours      int     `json:"duration_hours" binding:"required,min=1"`
+	Price              float64 `json:"price" binding:"min=0"`
+	Deposit            float64 `json:"deposit" binding:"min=0"`
+	OvertimeRate       float64 `json:"overtime_rate" binding:"min=0"`
+	GracePeriodMinutes *int    `json:"grace_period_minutes" binding:"omitempty,min=0,max=60"` // 为空时使用场地默认值
+	IsActive           *bool   `json:"is_active"`
+}
+
+// validate 校验定价参数
+func (req *PricingRequest) validate() error {
+	if req.GracePeriodMinutes == nil {
+		return nil
+	}
+	return validateGracePeriod(*req.GracePeriodMinutes)
+}
+
+// validateGracePeriod 校验超时宽限期范围
+func validateGracePeriod(minutes int) error {
+	if minutes < models.MinGracePeriodMinutes || minutes > models.MaxGracePeriodMinutes {
+		return commonErrors.ErrInvalidParams.WithMessage("超时宽限期需在0-60分钟之间")
+	}
+	return nil
+}
+
+// CreatePricing 创建场地定价
+func (s *VenueAdminService) CreatePricing(ctx context.Context, venueID int64, req *PricingRequest) (*models.RentalPricing, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.venueRepo.GetByID(ctx, venueID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, venueNotFoundErr
+		}
+		return nil, err
+	}
+
+	pricing := &models.RentalPricing{
+		VenueID:            &venueID,
+		DurationHours:      req.DurationHours,
+		Price:              req.Price,
+		Deposit:            req.Deposit,
+		OvertimeRate:       req.OvertimeRate,
+		GracePeriodMinutes: req.GracePeriodMinutes,
+		IsActive:           true,
+	}
+	if req.IsActive != nil {
+		pricing.IsActive = *req.IsActive
+	}
+
+	if err := s.deviceRepo.CreatePricing(ctx, pricing); err != nil {
+		return nil, err
+	}
+
+	return pricing, nil
+}
+
+// UpdatePricing 更新定价
+func (s *VenueAdminService) UpdatePricing(ctx context.Context, id int64, req *PricingRequest) error {
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	pricing, err := s.deviceRepo.GetPricingByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return pricingNotFoundErr
+		}
+		return err
+	}
+
+	pricing.DurationHours = req.DurationHours
+	pricing.Price = req.Price
+	pricing.Deposit = req.Deposit
+	pricing.OvertimeRate = req.OvertimeRate
+	pricing.GracePeriodMinutes = req.GracePeriodMinutes
+	if req.IsActive != nil {
+		pricing.IsActive = *req.IsActive
+	}
+
+	if err := s.deviceRepo.UpdatePricing(ctx, pricing); err != nil {
+		return err
+	}
+	if s.pricingCache != nil {
+		// 清除失败时缓存最长在有效期结束后失效
+		_ = s.pricingCache.Invalidate(ctx, pricing.ID)
+	}
+	return nil
+}
+
+// ListPricings 获取场地定价列表
+func (s *VenueAdminService) ListPricings(ctx context.Context, venueID int64) ([]*models.RentalPricing, error) {
+	if _, err := s.venueRepo.GetByID(ctx, venueID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, venueNotFoundErr
+		}
+		return nil, err
+	}
+
+	return s.deviceRepo.ListPricingsByVenue(ctx, venueID)
+}
+
+// ============ 分时定价管理 ============
+
+// PricingScheduleRequest 创建/更新分时定价请求
+type PricingScheduleRequest struct {
+	DayOfWeek  *int    `json:"day_of_week" binding:"omitempty,min=0,max=6"` // 0-6，0 为周日，为空表示每天
+	StartHour  int     `json:"start_hour" binding:"min=0,max=23"`
+	EndHour    int     `json:"end_hour" binding:"required,min=1,max=24"`
+	Multiplier float64 `json:"multiplier" binding:"required,gt=0"`
+	IsActive   *bool   `json:"is_active"`
+}
+
+// validate 校验分时定价参数，与 pricing_schedules 表约束一致
+func (req *PricingScheduleRequest) validate() error {
+	if req.DayOfWeek != nil && (*req.DayOfWeek < 0 || *req.DayOfWeek > 6) {
+		return commonErrors.ErrInvalidParams.WithMessage("星期需在0-6之间")
+	}
+	if req.StartHour < 0 || req.EndHour > 24 || req.StartHour >= req.EndHour {
+		return commonErrors.ErrInvalidParams.WithMessage("时段需满足0≤开始小时<结束小时≤24")
+	}
+	if req.Multiplier <= 0 || req.Multiplier >= 100 {
+		return commonErrors.ErrInvalidParams.WithMessage("租金倍率需大于0且小于100")
+	}
+	return nil
+}
+
+// requireScheduleRepo 检查是否已配置分时定价仓储
+func (s *VenueAdminService) requireScheduleRepo() error {
+	if s.scheduleRepo == nil {
+		return commonErrors.ErrOperationFailed.WithMessage("分时定价未配置")
+	}
+	return nil
+}
+
+// CreatePricingSchedule 创建场地分时定价
+func (s *VenueAdminService) CreatePricingSchedule(ctx context.Context, venueID int64, req *PricingScheduleRequest) (*models.PricingSchedule, error) {
+	if err := s.requireScheduleRepo(); err != nil {
+		return nil, err
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.venueRepo.GetByID(ctx, venueID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, venueNotFoundErr
+		}
+		return nil, err
+	}
+
+	schedule := &models.PricingSchedule{
+		VenueID:    venueID,
+		DayOfWeek:  req.DayOfWeek,
+		StartHour:  req.StartHour,
+		EndHour:    req.EndHour,
+		Multiplier: req.Multiplier,
+		IsActive:   true,
+	}
+	if req.IsActive != nil {
+		schedule.IsActive = *req.IsActive
+	}
+
+	if err := s.scheduleRepo.Create(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// UpdatePricingSchedule 更新分时定价
+func (s *VenueAdminService) UpdatePricingSchedule(ctx context.Context, id int64, req *PricingScheduleRequest) error {
+	if err := s.requireScheduleRepo(); err != nil {
+		return err
+	}
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	schedule, err := s.scheduleRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return commonErrors.ErrPricingScheduleNotFound
+		}
+		return err
+	}
+
+	schedule.DayOfWeek = req.DayOfWeek
+	schedule.StartHour = req.StartHour
+	schedule.EndHour = req.EndHour
+	schedule.Multiplier = req.Multiplier
+	if req.IsActive != nil {
+		schedule.IsActive = *req.IsActive
+	}
+
+	return s.scheduleRepo.Update(ctx, schedule)
+}
+
+// DeletePricingSchedule 删除分时定价
+func (s *VenueAdminService) DeletePricingSchedule(ctx context.Context, id int64) error {
+	if err := s.requireScheduleRepo(); err != nil {
+		return err
+	}
+
+	if _, err := s.scheduleRepo.GetByID(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return commonErrors.ErrPricingScheduleNotFound
+		}
+		return err
+	}
+
+	return s.scheduleRepo.Delete(ctx, id)
+}
+
+// ListPricingSchedules 获取场地分时定价列表
+func (s *VenueAdminService) ListPricingSchedules(ctx context.Context, venueID int64) ([]*models.PricingSchedule, error) {
+	if err := s.requireScheduleRepo(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.venueRepo.GetByID(ctx, venueID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, venueNotFoundErr
+		}
+		return nil, err
+	}
+
+	return s.scheduleRepo.ListByVenue(ctx, venueID)
+}
+
 // toVenueInfo 转换为场地信息
 func (s *VenueAdminService) toVenueInfo(venue *models.Venue, deviceCount int64) *VenueInfo {
 	info := &VenueInfo{
-		ID:           venue.ID,
-		MerchantID:   venue.MerchantID,
-		Name:         venue.Name,
-		Type:         venue.Type,
-		Province:     venue.Province,
-		City:         venue.City,
-		District:     venue.District,
-		Address:      venue.Address,
-		ContactName:  venue.ContactName,
-		ContactPhone: venue.ContactPhone,
-		DeviceCount:  deviceCount,
-		Status:       venue.Status,
+		ID:                        venue.ID,
+		MerchantID:                venue.MerchantID,
+		Name:                      venue.Name,
+		Type:                      venue.Type,
+		Province:                  venue.Province,
+		City:                      venue.City,
+		District:                  venue.District,
+		Address:                   venue.Address,
+		ContactName:               venue.ContactName,
+		ContactPhone:              venue.ContactPhone,
+		DeviceCount:               deviceCount,
+		Status:                    venue.Status,
+		DefaultGracePeriodMinutes: venue.DefaultGracePeriodMinutes,
+		Timezone:                  venue.Timezone,
 	}
 
 	if venue.Longitude != nil {
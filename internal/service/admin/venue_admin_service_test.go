@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -13,6 +14,7 @@ import (
 	"gorm.io/gorm/logger"
 
 	commonErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 )
@@ -29,7 +31,7 @@ func setupVenueAdminTestDB(t *testing.T) *gorm.DB {
 	sqlDB.SetMaxOpenConns(1)
 	sqlDB.SetMaxIdleConns(1)
 
-	require.NoError(t, db.AutoMigrate(&models.Merchant{}, &models.Venue{}, &models.Device{}))
+	require.NoError(t, db.AutoMigrate(&models.Merchant{}, &models.Venue{}, &models.Device{}, &models.RentalPricing{}, &models.PricingSchedule{}))
 	return db
 }
 
@@ -160,3 +162,225 @@ func TestVenueAdminService_AdditionalOperations(t *testing.T) {
 	})
 }
 
+
+func TestVenueAdminService_Pricing(t *testing.T) {
+	db := setupVenueAdminTestDB(t)
+	svc := NewVenueAdminService(
+		repository.NewVenueRepository(db),
+		repository.NewMerchantRepository(db),
+		repository.NewDeviceRepository(db),
+	)
+	ctx := context.Background()
+
+	merchant := &models.Merchant{Name: "M3", ContactName: "C", ContactPhone: "138", CommissionRate: 0.2, SettlementType: models.SettlementTypeMonthly, Status: models.MerchantStatusActive}
+	require.NoError(t, db.Create(merchant).Error)
+
+	venue, err := svc.CreateVenue(ctx, &CreateVenueRequest{
+		MerchantID: merchant.ID,
+		Name:       "定价场地",
+		Type:       models.VenueTypeMall,
+		Province:   "广东省",
+		City:       "深圳市",
+		District:   "南山区",
+		Address:    "科技园",
+	})
+	require.NoError(t, err)
+
+	t.Run("CreatePricing 未配置宽限期时沿用场地默认值", func(t *testing.T) {
+		pricing, err := svc.CreatePricing(ctx, venue.ID, &PricingRequest{DurationHours: 1, Price: 10, Deposit: 50, OvertimeRate: 1.5})
+		require.NoError(t, err)
+		assert.Nil(t, pricing.GracePeriodMinutes)
+		assert.True(t, pricing.IsActive)
+	})
+
+	t.Run("CreatePricing 宽限期上限60分钟", func(t *testing.T) {
+		pricing, err := svc.CreatePricing(ctx, venue.ID, &PricingRequest{DurationHours: 2, Price: 18, Deposit: 50, OvertimeRate: 1.5, GracePeriodMinutes: utils.IntPtr(60)})
+		require.NoError(t, err)
+		require.NotNil(t, pricing.GracePeriodMinutes)
+		assert.Equal(t, 60, *pricing.GracePeriodMinutes)
+	})
+
+	t.Run("CreatePricing 宽限期超出范围", func(t *testing.T) {
+		_, err := svc.CreatePricing(ctx, venue.ID, &PricingRequest{DurationHours: 1, GracePeriodMinutes: utils.IntPtr(61)})
+		require.Error(t, err)
+		appErr, ok := err.(*commonErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, commonErrors.ErrInvalidParams.Code, appErr.Code)
+
+		_, err = svc.CreatePricing(ctx, venue.ID, &PricingRequest{DurationHours: 1, GracePeriodMinutes: utils.IntPtr(-1)})
+		require.Error(t, err)
+	})
+
+	t.Run("CreatePricing 场地不存在", func(t *testing.T) {
+		_, err := svc.CreatePricing(ctx, 99999, &PricingRequest{DurationHours: 1})
+		assert.Equal(t, commonErrors.ErrVenueNotFound, err)
+	})
+
+	t.Run("UpdatePricing 更新宽限期", func(t *testing.T) {
+		pricing, err := svc.CreatePricing(ctx, venue.ID, &PricingRequest{DurationHours: 3, Price: 25, Deposit: 50, OvertimeRate: 1.5})
+		require.NoError(t, err)
+
+		err = svc.UpdatePricing(ctx, pricing.ID, &PricingRequest{DurationHours: 3, Price: 25, Deposit: 50, OvertimeRate: 1.5, GracePeriodMinutes: utils.IntPtr(30)})
+		require.NoError(t, err)
+
+		var updated models.RentalPricing
+		require.NoError(t, db.First(&updated, pricing.ID).Error)
+		require.NotNil(t, updated.GracePeriodMinutes)
+		assert.Equal(t, 30, *updated.GracePeriodMinutes)
+
+		err = svc.UpdatePricing(ctx, pricing.ID, &PricingRequest{DurationHours: 3, GracePeriodMinutes: utils.IntPtr(90)})
+		require.Error(t, err)
+	})
+
+	t.Run("UpdatePricing 定价不存在", func(t *testing.T) {
+		err := svc.UpdatePricing(ctx, 99999, &PricingRequest{DurationHours: 1})
+		assert.Equal(t, commonErrors.ErrPricingNotFound, err)
+	})
+
+	t.Run("ListPricings 获取场地定价列表", func(t *testing.T) {
+		list, err := svc.ListPricings(ctx, venue.ID)
+		require.NoError(t, err)
+		assert.Len(t, list, 3)
+	})
+}
+
+func TestVenueAdminService_DefaultGracePeriod(t *testing.T) {
+	db := setupVenueAdminTestDB(t)
+	svc := NewVenueAdminService(
+		repository.NewVenueRepository(db),
+		repository.NewMerchantRepository(db),
+		repository.NewDeviceRepository(db),
+	)
+	ctx := context.Background()
+
+	merchant := &models.Merchant{Name: "M4", ContactName: "C", ContactPhone: "138", CommissionRate: 0.2, SettlementType: models.SettlementTypeMonthly, Status: models.MerchantStatusActive}
+	require.NoError(t, db.Create(merchant).Error)
+
+	req := &CreateVenueRequest{
+		MerchantID:                merchant.ID,
+		Name:                      "宽限期场地",
+		Type:                      models.VenueTypeMall,
+		Province:                  "广东省",
+		City:                      "深圳市",
+		District:                  "南山区",
+		Address:                   "科技园",
+		DefaultGracePeriodMinutes: 10,
+	}
+	venue, err := svc.CreateVenue(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 10, venue.DefaultGracePeriodMinutes)
+
+	t.Run("CreateVenue 默认宽限期超出范围", func(t *testing.T) {
+		invalid := *req
+		invalid.DefaultGracePeriodMinutes = 61
+		_, err := svc.CreateVenue(ctx, &invalid)
+		require.Error(t, err)
+	})
+
+	t.Run("UpdateVenue 更新默认宽限期", func(t *testing.T) {
+		err := svc.UpdateVenue(ctx, venue.ID, &UpdateVenueRequest{
+			MerchantID:                merchant.ID,
+			Name:                      venue.Name,
+			Type:                      venue.Type,
+			Province:                  venue.Province,
+			City:                      venue.City,
+			District:                  venue.District,
+			Address:                   venue.Address,
+			DefaultGracePeriodMinutes: 5,
+		})
+		require.NoError(t, err)
+
+		info, err := svc.GetVenue(ctx, venue.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 5, info.DefaultGracePeriodMinutes)
+	})
+}
+
+func TestVenueAdminService_PricingSchedule(t *testing.T) {
+	db := setupVenueAdminTestDB(t)
+	svc := NewVenueAdminService(
+		repository.NewVenueRepository(db),
+		repository.NewMerchantRepository(db),
+		repository.NewDeviceRepository(db),
+	)
+	svc.SetPricingScheduleRepository(repository.NewPricingScheduleRepository(db))
+	ctx := context.Background()
+
+	merchant := &models.Merchant{Name: "M5", ContactName: "C", ContactPhone: "138", CommissionRate: 0.2, SettlementType: models.SettlementTypeMonthly, Status: models.MerchantStatusActive}
+	require.NoError(t, db.Create(merchant).Error)
+
+	venue, err := svc.CreateVenue(ctx, &CreateVenueRequest{
+		MerchantID: merchant.ID,
+		Name:       "分时场地",
+		Type:       models.VenueTypeMall,
+		Province:   "广东省",
+		City:       "深圳市",
+		District:   "南山区",
+		Address:    "科技园",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultVenueTimezone, venue.Timezone)
+
+	t.Run("CreateVenue 无效时区", func(t *testing.T) {
+		_, err := svc.CreateVenue(ctx, &CreateVenueRequest{
+			MerchantID: merchant.ID,
+			Name:       "场地",
+			Type:       models.VenueTypeMall,
+			Province:   "广东省",
+			City:       "深圳市",
+			District:   "南山区",
+			Address:    "科技园",
+			Timezone:   "Mars/Olympus",
+		})
+		appErr, ok := err.(*commonErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, commonErrors.ErrInvalidParams.Code, appErr.Code)
+	})
+
+	saturday := int(time.Saturday)
+	schedule, err := svc.CreatePricingSchedule(ctx, venue.ID, &PricingScheduleRequest{DayOfWeek: &saturday, StartHour: 10, EndHour: 22, Multiplier: 1.5})
+	require.NoError(t, err)
+	assert.True(t, schedule.IsActive)
+
+	t.Run("CreatePricingSchedule 参数校验", func(t *testing.T) {
+		for _, req := range []*PricingScheduleRequest{
+			{StartHour: 10, EndHour: 10, Multiplier: 1.2},
+			{StartHour: 0, EndHour: 25, Multiplier: 1.2},
+			{StartHour: 0, EndHour: 24, Multiplier: 0},
+		} {
+			_, err := svc.CreatePricingSchedule(ctx, venue.ID, req)
+			appErr, ok := err.(*commonErrors.AppError)
+			require.True(t, ok)
+			assert.Equal(t, commonErrors.ErrInvalidParams.Code, appErr.Code)
+		}
+
+		_, err := svc.CreatePricingSchedule(ctx, 99999, &PricingScheduleRequest{StartHour: 0, EndHour: 6, Multiplier: 0.8})
+		assert.Equal(t, commonErrors.ErrVenueNotFound, err)
+	})
+
+	t.Run("UpdatePricingSchedule", func(t *testing.T) {
+		inactive := false
+		require.NoError(t, svc.UpdatePricingSchedule(ctx, schedule.ID, &PricingScheduleRequest{StartHour: 18, EndHour: 22, Multiplier: 1.3, IsActive: &inactive}))
+
+		schedules, err := svc.ListPricingSchedules(ctx, venue.ID)
+		require.NoError(t, err)
+		require.Len(t, schedules, 1)
+		assert.Nil(t, schedules[0].DayOfWeek)
+		assert.Equal(t, 18, schedules[0].StartHour)
+		assert.Equal(t, 1.3, schedules[0].Multiplier)
+		assert.False(t, schedules[0].IsActive)
+
+		err = svc.UpdatePricingSchedule(ctx, 99999, &PricingScheduleRequest{StartHour: 0, EndHour: 6, Multiplier: 0.8})
+		assert.Equal(t, commonErrors.ErrPricingScheduleNotFound, err)
+	})
+
+	t.Run("DeletePricingSchedule", func(t *testing.T) {
+		require.NoError(t, svc.DeletePricingSchedule(ctx, schedule.ID))
+
+		schedules, err := svc.ListPricingSchedules(ctx, venue.ID)
+		require.NoError(t, err)
+		assert.Empty(t, schedules)
+
+		assert.Equal(t, commonErrors.ErrPricingScheduleNotFound, svc.DeletePricingSchedule(ctx, schedule.ID))
+	})
+}
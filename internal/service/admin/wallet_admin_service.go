@@ -0,0 +1,102 @@
+// Package admin 管理端服务
+package admin
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	userService "github.com/dumeirei/smart-locker-backend/internal/service/user"
+)
+
+// 钱包调整方向
+const (
+	WalletAdjustIncrease = "increase" // 增加余额
+	WalletAdjustDecrease = "decrease" // 扣减余额
+)
+
+// 钱包调整对象
+const (
+	WalletAdjustTargetBalance = "balance"        // 可用余额
+	WalletAdjustTargetFrozen  = "frozen_balance" // 冻结余额
+)
+
+// WalletAdminService 钱包管理服务
+// 客服修正钱包余额需通过本服务调整，保证每次调整都有流水和操作人可追溯
+type WalletAdminService struct {
+	userRepo  *repository.UserRepository
+	walletSvc *userService.WalletService
+}
+
+// NewWalletAdminService 创建钱包管理服务
+func NewWalletAdminService(userRepo *repository.UserRepository, walletSvc *userService.WalletService) *WalletAdminService {
+	return &WalletAdminService{
+		userRepo:  userRepo,
+		walletSvc: walletSvc,
+	}
+}
+
+// AdjustBalance 人工调整用户可用余额
+// amount 为调整金额（正数），direction 为调整方向，reason 为必填的调整原因
+func (s *WalletAdminService) AdjustBalance(ctx context.Context, adminID, userID int64, amount float64, direction, reason string) (*userService.TransactionRecord, error) {
+	amount, reason, err := s.prepareAdjustment(ctx, userID, amount, direction, reason)
+	if err != nil {
+		return nil, err
+	}
+	return s.walletSvc.Adjust(ctx, userID, amount, adminID, reason)
+}
+
+// AdjustFrozenBalance 人工调整用户冻结余额，参数同 AdjustBalance
+func (s *WalletAdminService) AdjustFrozenBalance(ctx context.Context, adminID, userID int64, amount float64, direction, reason string) (*userService.TransactionRecord, error) {
+	amount, reason, err := s.prepareAdjustment(ctx, userID, amount, direction, reason)
+	if err != nil {
+		return nil, err
+	}
+	return s.walletSvc.AdjustFrozen(ctx, userID, amount, adminID, reason)
+}
+
+// prepareAdjustment 校验调整参数和用户，返回带方向的调整金额及去除首尾空白的原因
+func (s *WalletAdminService) prepareAdjustment(ctx context.Context, userID int64, amount float64, direction, reason string) (float64, string, error) {
+	if amount <= 0 {
+		return 0, "", errors.ErrInvalidParams.WithMessage("调整金额必须大于0")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return 0, "", errors.ErrInvalidParams.WithMessage("调整原因不能为空")
+	}
+
+	switch direction {
+	case WalletAdjustIncrease:
+	case WalletAdjustDecrease:
+		amount = -amount
+	default:
+		return 0, "", errors.ErrInvalidParams.WithMessage("无效的调整方向")
+	}
+
+	if err := s.ensureUserExists(ctx, userID); err != nil {
+		return 0, "", err
+	}
+	return amount, reason, nil
+}
+
+// ListTransactions 获取用户钱包流水，用于审计余额变动
+func (s *WalletAdminService) ListTransactions(ctx context.Context, userID int64, offset, limit int, txType string) ([]*userService.TransactionRecord, int64, error) {
+	if err := s.ensureUserExists(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+	return s.walletSvc.GetTransactions(ctx, userID, offset, limit, txType)
+}
+
+// ensureUserExists 校验用户存在
+func (s *WalletAdminService) ensureUserExists(ctx context.Context, userID int64) error {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.ErrUserNotFound
+		}
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+// Package admin 钱包管理服务单元测试
+package admin
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	userService "github.com/dumeirei/smart-locker-backend/internal/service/user"
+)
+
+// setupWalletAdminService 创建测试用的 WalletAdminService
+// 使用基于文件的 SQLite 数据库，允许多个连接并发访问
+func setupWalletAdminService(t *testing.T) (*WalletAdminService, *userService.WalletService, *gorm.DB) {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "wallet_admin.db") + "?_journal_mode=WAL&_busy_timeout=10000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(20)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.UserWallet{}, &models.WalletTransaction{}))
+
+	userRepo := repository.NewUserRepository(db)
+	walletSvc := userService.NewWalletService(db, userRepo, nil)
+	return NewWalletAdminService(userRepo, walletSvc), walletSvc, db
+}
+
+// createWalletAdminTestUser 创建带钱包的测试用户
+func createWalletAdminTestUser(t *testing.T, db *gorm.DB, phone string, balance float64) *models.User {
+	t.Helper()
+
+	user := &models.User{Phone: &phone, Nickname: "钱包用户", Status: models.UserStatusActive}
+	require.NoError(t, db.Create(user).Error)
+	require.NoError(t, db.Create(&models.UserWallet{UserID: user.ID, Balance: balance}).Error)
+	return user
+}
+
+func assertAppErrorCode(t *testing.T, err error, code int) {
+	t.Helper()
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok, "expected AppError, got %v", err)
+	assert.Equal(t, code, appErr.Code)
+}
+
+func TestWalletAdminService_AdjustBalance_Validation(t *testing.T) {
+	svc, _, db := setupWalletAdminService(t)
+	ctx := context.Background()
+	user := createWalletAdminTestUser(t, db, "13800138200", 100.0)
+
+	_, err := svc.AdjustBalance(ctx, 1, user.ID, 0, WalletAdjustIncrease, "补偿")
+	assertAppErrorCode(t, err, appErrors.ErrInvalidParams.Code)
+
+	_, err = svc.AdjustBalance(ctx, 1, user.ID, 10, WalletAdjustIncrease, "   ")
+	assertAppErrorCode(t, err, appErrors.ErrInvalidParams.Code)
+
+	_, err = svc.AdjustBalance(ctx, 1, user.ID, 10, "sideways", "补偿")
+	assertAppErrorCode(t, err, appErrors.ErrInvalidParams.Code)
+
+	_, err = svc.AdjustBalance(ctx, 1, 99999, 10, WalletAdjustIncrease, "补偿")
+	assertAppErrorCode(t, err, appErrors.ErrUserNotFound.Code)
+
+	var count int64
+	db.Model(&models.WalletTransaction{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestWalletAdminService_AdjustBalance_RejectsNegativeBalance(t *testing.T) {
+	svc, _, db := setupWalletAdminService(t)
+	ctx := context.Background()
+	user := createWalletAdminTestUser(t, db, "13800138201", 100.0)
+
+	_, err := svc.AdjustBalance(ctx, 1, user.ID, 100.01, WalletAdjustDecrease, "误充值扣回")
+	assertAppErrorCode(t, err, appErrors.ErrOperationFailed.Code)
+
+	var wallet models.UserWallet
+	require.NoError(t, db.Where("user_id = ?", user.ID).First(&wallet).Error)
+	assert.Equal(t, 100.0, wallet.Balance)
+
+	var count int64
+	db.Model(&models.WalletTransaction{}).Where("user_id = ?", user.ID).Count(&count)
+	assert.Zero(t, count)
+
+	// 扣减至恰好为 0 允许
+	record, err := svc.AdjustBalance(ctx, 1, user.ID, 100, WalletAdjustDecrease, "误充值扣回")
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, record.BalanceAfter)
+}
+
+func TestWalletAdminService_AdjustFrozenBalance(t *testing.T) {
+	svc, _, db := setupWalletAdminService(t)
+	ctx := context.Background()
+	user := createWalletAdminTestUser(t, db, "13800138205", 100.0)
+	require.NoError(t, db.Model(&models.UserWallet{}).Where("user_id = ?", user.ID).Update("frozen_balance", 30).Error)
+
+	// 扣减后冻结余额为负时拒绝，可用余额充足也不允许
+	_, err := svc.AdjustFrozenBalance(ctx, 1, user.ID, 30.01, WalletAdjustDecrease, "押金重复冻结")
+	assertAppErrorCode(t, err, appErrors.ErrOperationFailed.Code)
+
+	_, err = svc.AdjustFrozenBalance(ctx, 1, user.ID, 10, WalletAdjustIncrease, "  ")
+	assertAppErrorCode(t, err, appErrors.ErrInvalidParams.Code)
+
+	record, err := svc.AdjustFrozenBalance(ctx, 1, user.ID, 30, WalletAdjustDecrease, "押金重复冻结")
+	require.NoError(t, err)
+	assert.Equal(t, models.WalletTxTypeFrozenAdjustment, record.Type)
+	assert.Equal(t, -30.0, record.Amount)
+	// 可用余额不变
+	assert.Equal(t, 100.0, record.BalanceBefore)
+	assert.Equal(t, 100.0, record.BalanceAfter)
+	require.NotNil(t, record.OperatorID)
+	assert.Equal(t, int64(1), *record.OperatorID)
+
+	var wallet models.UserWallet
+	require.NoError(t, db.Where("user_id = ?", user.ID).First(&wallet).Error)
+	assert.Equal(t, 100.0, wallet.Balance)
+	assert.Equal(t, 0.0, wallet.FrozenBalance)
+
+	var count int64
+	db.Model(&models.WalletTransaction{}).Where("user_id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestWalletAdminService_AdjustBalance_RecordsOperator(t *testing.T) {
+	svc, _, db := setupWalletAdminService(t)
+	ctx := context.Background()
+	user := createWalletAdminTestUser(t, db, "13800138202", 50.0)
+
+	const adminID int64 = 7
+	record, err := svc.AdjustBalance(ctx, adminID, user.ID, 20, WalletAdjustIncrease, "  设备故障补偿  ")
+	require.NoError(t, err)
+	assert.Equal(t, models.WalletTxTypeAdjustment, record.Type)
+	assert.Equal(t, "人工调整", record.TypeName)
+	assert.Equal(t, 20.0, record.Amount)
+	assert.Equal(t, 50.0, record.BalanceBefore)
+	assert.Equal(t, 70.0, record.BalanceAfter)
+
+	_, err = svc.AdjustBalance(ctx, adminID+1, user.ID, 5, WalletAdjustDecrease, "重复补偿扣回")
+	require.NoError(t, err)
+
+	records, total, err := svc.ListTransactions(ctx, user.ID, 0, 10, "")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), total)
+	require.Len(t, records, 2)
+
+	// 按时间倒序
+	assert.Equal(t, -5.0, records[0].Amount)
+	assert.Equal(t, 70.0, records[0].BalanceBefore)
+	assert.Equal(t, 65.0, records[0].BalanceAfter)
+	require.NotNil(t, records[0].OperatorID)
+	assert.Equal(t, adminID+1, *records[0].OperatorID)
+	require.NotNil(t, records[0].Remark)
+	assert.Equal(t, "重复补偿扣回", *records[0].Remark)
+
+	require.NotNil(t, records[1].OperatorID)
+	assert.Equal(t, adminID, *records[1].OperatorID)
+	require.NotNil(t, records[1].Remark)
+	assert.Equal(t, "设备故障补偿", *records[1].Remark)
+
+	var wallet models.UserWallet
+	require.NoError(t, db.Where("user_id = ?", user.ID).First(&wallet).Error)
+	assert.Equal(t, 65.0, wallet.Balance)
+
+	_, _, err = svc.ListTransactions(ctx, 99999, 0, 10, "")
+	assertAppErrorCode(t, err, appErrors.ErrUserNotFound.Code)
+}
+
+func TestWalletAdminService_AdjustBalance_CreatesMissingWallet(t *testing.T) {
+	svc, _, db := setupWalletAdminService(t)
+	ctx := context.Background()
+
+	phone := "13800138203"
+	user := &models.User{Phone: &phone, Nickname: "无钱包用户", Status: models.UserStatusActive}
+	require.NoError(t, db.Create(user).Error)
+
+	record, err := svc.AdjustBalance(ctx, 1, user.ID, 8, WalletAdjustIncrease, "活动补贴")
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, record.BalanceBefore)
+	assert.Equal(t, 8.0, record.BalanceAfter)
+}
+
+func TestWalletAdminService_AdjustBalance_ConcurrentWithPayment(t *testing.T) {
+	svc, walletSvc, db := setupWalletAdminService(t)
+	ctx := context.Background()
+	user := createWalletAdminTestUser(t, db, "13800138204", 100.0)
+
+	const workers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*2)
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			errs <- walletSvc.Consume(ctx, user.ID, 5, fmt.Sprintf("PAY%02d", i))
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			_, err := svc.AdjustBalance(ctx, 1, user.ID, 5, WalletAdjustDecrease, fmt.Sprintf("扣回%02d", i))
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	var wallet models.UserWallet
+	require.NoError(t, db.Where("user_id = ?", user.ID).First(&wallet).Error)
+	assert.Equal(t, 0.0, wallet.Balance)
+	assert.Equal(t, workers*2, wallet.Version)
+
+	// 流水前后余额首尾相接，总额与余额变动一致
+	var transactions []*models.WalletTransaction
+	require.NoError(t, db.Where("user_id = ?", user.ID).Order("id ASC").Find(&transactions).Error)
+	require.Len(t, transactions, workers*2)
+	balance := 100.0
+	for _, tx := range transactions {
+		assert.Equal(t, balance, tx.BalanceBefore)
+		assert.Equal(t, tx.BalanceBefore+tx.Amount, tx.BalanceAfter)
+		balance = tx.BalanceAfter
+	}
+	assert.Equal(t, 0.0, balance)
+
+	// 余额已耗尽，继续扣减被拒绝
+	_, err := svc.AdjustBalance(ctx, 1, user.ID, 0.01, WalletAdjustDecrease, "扣回")
+	assertAppErrorCode(t, err, appErrors.ErrOperationFailed.Code)
+}
@@ -105,9 +105,12 @@ func (s *AuthService) SmsLogin(ctx context.Context, req *SmsLoginRequest) (*Logi
 	if user.Status == models.UserStatusDisabled {
 		return nil, errors.ErrAccountDisabled
 	}
+	if user.Status == models.UserStatusDeleted {
+		return nil, errors.ErrAccountDeleted
+	}
 
 	// 生成 Token
-	tokenPair, err := s.jwtManager.GenerateTokenPair(user.ID, jwt.UserTypeUser, "")
+	tokenPair, err := s.jwtManager.IssueTokenPair(ctx, user.ID, jwt.UserTypeUser, "")
 	if err != nil {
 		return nil, errors.ErrInternalError.WithError(err)
 	}
@@ -186,17 +189,42 @@ func (s *AuthService) toUserInfo(user *models.User) *UserInfo {
 }
 
 // RefreshToken 刷新 Token
+// 刷新令牌使用后立即失效；已使用的刷新令牌被再次提交时吊销整个登录会话；已注销的用户不能刷新
 func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*jwt.TokenPair, error) {
-	tokenPair, err := s.jwtManager.RefreshToken(refreshToken)
+	if claims, err := s.jwtManager.ParseToken(refreshToken); err == nil && claims.UserType == jwt.UserTypeUser {
+		user, err := s.userRepo.GetByID(ctx, claims.UserID)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return nil, errors.ErrDatabaseError.WithError(err)
+		}
+		if err == nil && user.Status == models.UserStatusDeleted {
+			return nil, errors.ErrAccountDeleted
+		}
+	}
+
+	tokenPair, err := s.jwtManager.RotateRefreshToken(ctx, refreshToken)
 	if err != nil {
-		if err == jwt.ErrTokenExpired {
+		switch err {
+		case jwt.ErrTokenExpired:
 			return nil, errors.ErrTokenExpired
+		case jwt.ErrTokenRevoked, jwt.ErrTokenReused:
+			return nil, errors.ErrTokenInvalid.WithMessage("登录已失效，请重新登录")
+		case jwt.ErrTokenInvalid, jwt.ErrTokenMalformed, jwt.ErrTokenNotActive:
+			return nil, errors.ErrTokenInvalid
+		default:
+			return nil, errors.ErrInternalError.WithError(err)
 		}
-		return nil, errors.ErrTokenInvalid
 	}
 	return tokenPair, nil
 }
 
+// Logout 退出登录，吊销当前令牌所属的登录会话
+func (s *AuthService) Logout(ctx context.Context, tokenID string) error {
+	if err := s.jwtManager.Revoke(ctx, tokenID); err != nil {
+		return errors.ErrInternalError.WithError(err)
+	}
+	return nil
+}
+
 // GetUserByID 根据 ID 获取用户
 func (s *AuthService) GetUserByID(ctx context.Context, userID int64) (*models.User, error) {
 	user, err := s.userRepo.GetByID(ctx, userID)
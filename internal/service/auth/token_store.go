@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// Redis 键
+const (
+	tokenRevokedKeyPrefix = "jwt:revoked:"      // 令牌黑名单，TTL 为令牌剩余有效期
+	tokenRevokedUsersKey  = "jwt:revoked_users" // 用户吊销标记（Hash：user_type:user_id -> UnixNano）
+)
+
+// TokenStore 令牌吊销存储
+// 数据库为权威数据源；Redis 缓存黑名单和用户吊销标记供热路径查询，Redis 不可用或未配置时回退到数据库
+type TokenStore struct {
+	db    *gorm.DB
+	redis *redis.Client
+}
+
+// NewTokenStore 创建令牌吊销存储，redisClient 可为 nil
+func NewTokenStore(db *gorm.DB, redisClient *redis.Client) *TokenStore {
+	return &TokenStore{db: db, redis: redisClient}
+}
+
+var _ jwt.RevocationStore = (*TokenStore)(nil)
+
+// SaveTokens 记录签发的令牌
+func (s *TokenStore) SaveTokens(ctx context.Context, records []*jwt.TokenRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	tokens := make([]*models.AuthToken, 0, len(records))
+	for _, r := range records {
+		tokens = append(tokens, &models.AuthToken{
+			JTI:       r.JTI,
+			FamilyID:  r.FamilyID,
+			UserType:  r.UserType,
+			UserID:    r.UserID,
+			TokenType: r.TokenType,
+			ExpiresAt: r.ExpiresAt,
+		})
+	}
+	return s.db.WithContext(ctx).Create(&tokens).Error
+}
+
+// GetToken 获取令牌记录，不存在时返回 nil
+func (s *TokenStore) GetToken(ctx context.Context, jti string) (*jwt.TokenRecord, error) {
+	var token models.AuthToken
+	if err := s.db.WithContext(ctx).Where("jti = ?", jti).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return toTokenRecord(&token), nil
+}
+
+// ConsumeRefreshToken 原子地将未使用且未吊销的刷新令牌标记为已使用
+func (s *TokenStore) ConsumeRefreshToken(ctx context.Context, jti string) (bool, error) {
+	result := s.db.WithContext(ctx).Model(&models.AuthToken{}).
+		Where("jti = ? AND token_type = ? AND used_at IS NULL AND revoked_at IS NULL", jti, jwt.TokenTypeRefresh).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RevokeFamily 吊销令牌族中的全部令牌
+func (s *TokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	return s.revoke(ctx, s.db.WithContext(ctx).Where("family_id = ?", familyID))
+}
+
+// RevokeToken 吊销单个令牌
+func (s *TokenStore) RevokeToken(ctx context.Context, jti string) error {
+	return s.revoke(ctx, s.db.WithContext(ctx).Where("jti = ?", jti))
+}
+
+// revoke 吊销满足条件的未吊销令牌，并同步到 Redis 黑名单
+func (s *TokenStore) revoke(ctx context.Context, scope *gorm.DB) error {
+	var tokens []*models.AuthToken
+	if err := scope.Where("revoked_at IS NULL").Find(&tokens).Error; err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(tokens))
+	for _, token := range tokens {
+		ids = append(ids, token.ID)
+	}
+	if err := s.db.WithContext(ctx).Model(&models.AuthToken{}).
+		Where("id IN ?", ids).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return err
+	}
+
+	if s.redis == nil {
+		return nil
+	}
+	now := time.Now()
+	pipe := s.redis.Pipeline()
+	for _, token := range tokens {
+		ttl := token.ExpiresAt.Sub(now)
+		if ttl <= 0 {
+			continue
+		}
+		pipe.Set(ctx, tokenRevokedKeyPrefix+token.JTI, 1, ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// IsRevoked 查询令牌是否已被吊销，优先查询 Redis 黑名单
+func (s *TokenStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	if s.redis != nil {
+		n, err := s.redis.Exists(ctx, tokenRevokedKeyPrefix+jti).Result()
+		if err == nil {
+			return n > 0, nil
+		}
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.AuthToken{}).
+		Where("jti = ? AND revoked_at IS NOT NULL", jti).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ListActiveTokens 获取用户未过期且未吊销的令牌
+func (s *TokenStore) ListActiveTokens(ctx context.Context, userType string, userID int64) ([]*jwt.TokenRecord, error) {
+	var tokens []*models.AuthToken
+	if err := s.db.WithContext(ctx).
+		Where("user_type = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", userType, userID, time.Now()).
+		Order("id").
+		Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+
+	records := make([]*jwt.TokenRecord, 0, len(tokens))
+	for _, token := range tokens {
+		records = append(records, toTokenRecord(token))
+	}
+	return records, nil
+}
+
+// MarkUserRevoked 记录用户吊销标记
+// 数据库中的吊销时间即可还原标记，因此只写入 Redis
+func (s *TokenStore) MarkUserRevoked(ctx context.Context, userType string, userID int64, at time.Time) error {
+	if s.redis == nil {
+		return nil
+	}
+	return s.redis.HSet(ctx, tokenRevokedUsersKey, jwt.UserKey(userType, userID), at.UnixNano()).Err()
+}
+
+// LoadRevocationMarkers 加载 since 之后的用户吊销标记，同时清理 Redis 中过期的标记
+func (s *TokenStore) LoadRevocationMarkers(ctx context.Context, since time.Time) (map[string]time.Time, error) {
+	if s.redis != nil {
+		values, err := s.redis.HGetAll(ctx, tokenRevokedUsersKey).Result()
+		if err == nil {
+			markers := make(map[string]time.Time, len(values))
+			var stale []string
+			for key, value := range values {
+				nanos, parseErr := strconv.ParseInt(value, 10, 64)
+				at := time.Unix(0, nanos)
+				if parseErr != nil || at.Before(since) {
+					stale = append(stale, key)
+					continue
+				}
+				markers[key] = at
+			}
+			if len(stale) > 0 {
+				s.redis.HDel(ctx, tokenRevokedUsersKey, stale...)
+			}
+			return markers, nil
+		}
+	}
+
+	var rows []struct {
+		UserType  string
+		UserID    int64
+		RevokedAt time.Time
+	}
+	if err := s.db.WithContext(ctx).Model(&models.AuthToken{}).
+		Select("user_type, user_id, revoked_at").
+		Where("revoked_at >= ?", since).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	markers := make(map[string]time.Time)
+	for _, row := range rows {
+		key := jwt.UserKey(row.UserType, row.UserID)
+		if current, ok := markers[key]; !ok || row.RevokedAt.After(current) {
+			markers[key] = row.RevokedAt
+		}
+	}
+	return markers, nil
+}
+
+// DeleteExpired 删除 before 之前已过期的令牌记录
+func (s *TokenStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.AuthToken{})
+	return result.RowsAffected, result.Error
+}
+
+// toTokenRecord 转换为令牌记录
+func toTokenRecord(token *models.AuthToken) *jwt.TokenRecord {
+	return &jwt.TokenRecord{
+		JTI:       token.JTI,
+		FamilyID:  token.FamilyID,
+		UserID:    token.UserID,
+		UserType:  token.UserType,
+		TokenType: token.TokenType,
+		ExpiresAt: token.ExpiresAt,
+		UsedAt:    token.UsedAt,
+		RevokedAt: token.RevokedAt,
+	}
+}
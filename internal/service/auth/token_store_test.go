@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+func setupTokenStoreTest(t *testing.T) (*jwt.Manager, *TokenStore, *miniredis.Miniredis, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.AuthToken{}))
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	store := NewTokenStore(db, client)
+	manager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: 24 * time.Hour,
+		Issuer:            "test",
+	})
+	manager.SetRevocationStore(store)
+	return manager, store, mr, db
+}
+
+func mustParse(t *testing.T, manager *jwt.Manager, token string) *jwt.Claims {
+	t.Helper()
+
+	claims, err := manager.ParseToken(token)
+	require.NoError(t, err)
+	return claims
+}
+
+func TestTokenStore_RotateRefreshToken_ReuseRevokesFamily(t *testing.T) {
+	manager, _, _, _ := setupTokenStoreTest(t)
+	ctx := context.Background()
+
+	first, err := manager.IssueTokenPair(ctx, 1, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+
+	second, err := manager.RotateRefreshToken(ctx, first.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, mustParse(t, manager, first.RefreshToken).FamilyID, mustParse(t, manager, second.RefreshToken).FamilyID)
+
+	// 轮换后旧访问令牌在过期前仍然有效
+	revoked, err := manager.IsRevoked(ctx, mustParse(t, manager, first.AccessToken))
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	// 再次使用已轮换的刷新令牌：视为泄露，整个令牌族失效
+	_, err = manager.RotateRefreshToken(ctx, first.RefreshToken)
+	assert.ErrorIs(t, err, jwt.ErrTokenReused)
+
+	_, err = manager.RotateRefreshToken(ctx, second.RefreshToken)
+	assert.ErrorIs(t, err, jwt.ErrTokenRevoked)
+
+	for _, token := range []string{first.AccessToken, second.AccessToken} {
+		revoked, err := manager.IsRevoked(ctx, mustParse(t, manager, token))
+		require.NoError(t, err)
+		assert.True(t, revoked)
+	}
+
+	// 其他会话不受影响
+	other, err := manager.IssueTokenPair(ctx, 1, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+	_, err = manager.RotateRefreshToken(ctx, other.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestTokenStore_RotateRefreshToken_RejectsAccessAndUnknownTokens(t *testing.T) {
+	manager, _, _, _ := setupTokenStoreTest(t)
+	ctx := context.Background()
+
+	pair, err := manager.IssueTokenPair(ctx, 1, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+	_, err = manager.RotateRefreshToken(ctx, pair.AccessToken)
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalid)
+
+	// 未记录的刷新令牌（如旧版本签发）不能用于轮换
+	untracked, err := manager.GenerateTokenPair(1, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+	_, err = manager.RotateRefreshToken(ctx, untracked.RefreshToken)
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalid)
+}
+
+func TestTokenStore_RevokeUser(t *testing.T) {
+	manager, _, _, _ := setupTokenStoreTest(t)
+	ctx := context.Background()
+
+	phone, err := manager.IssueTokenPair(ctx, 1, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+	tablet, err := manager.IssueTokenPair(ctx, 1, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+	otherUser, err := manager.IssueTokenPair(ctx, 2, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+
+	sessions, err := manager.RevokeUser(ctx, jwt.UserTypeUser, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sessions)
+
+	for _, token := range []string{phone.AccessToken, tablet.AccessToken} {
+		revoked, err := manager.IsRevoked(ctx, mustParse(t, manager, token))
+		require.NoError(t, err)
+		assert.True(t, revoked)
+	}
+	_, err = manager.RotateRefreshToken(ctx, tablet.RefreshToken)
+	assert.ErrorIs(t, err, jwt.ErrTokenRevoked)
+
+	revoked, err := manager.IsRevoked(ctx, mustParse(t, manager, otherUser.AccessToken))
+	require.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestTokenStore_IsRevoked_NoRedisRoundTripWithoutMarker(t *testing.T) {
+	manager, _, mr, _ := setupTokenStoreTest(t)
+	ctx := context.Background()
+
+	clean, err := manager.IssueTokenPair(ctx, 1, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+	revokedPair, err := manager.IssueTokenPair(ctx, 2, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+	require.NoError(t, manager.Revoke(ctx, mustParse(t, manager, revokedPair.AccessToken).ID))
+
+	cleanClaims := mustParse(t, manager, clean.AccessToken)
+	before := mr.CommandCount()
+	for i := 0; i < 10; i++ {
+		revoked, err := manager.IsRevoked(ctx, cleanClaims)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	}
+	assert.Equal(t, before, mr.CommandCount())
+
+	// 存在吊销标记的用户才查询黑名单
+	revoked, err := manager.IsRevoked(ctx, mustParse(t, manager, revokedPair.AccessToken))
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	assert.Greater(t, mr.CommandCount(), before)
+}
+
+func TestTokenStore_SyncRevocationMarkers(t *testing.T) {
+	manager, store, _, _ := setupTokenStoreTest(t)
+	ctx := context.Background()
+
+	pair, err := manager.IssueTokenPair(ctx, 1, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+	claims := mustParse(t, manager, pair.AccessToken)
+
+	// 其他实例吊销令牌：本实例在同步前不感知，同步后生效
+	peer := jwt.NewManager(&jwt.Config{Secret: "test-secret", AccessExpireTime: time.Hour, RefreshExpireTime: 24 * time.Hour})
+	peer.SetRevocationStore(store)
+	require.NoError(t, peer.Revoke(ctx, claims.ID))
+
+	revoked, err := manager.IsRevoked(ctx, claims)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	require.NoError(t, manager.SyncRevocationMarkers(ctx))
+	revoked, err = manager.IsRevoked(ctx, claims)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestTokenStore_FallsBackToDatabase(t *testing.T) {
+	manager, store, mr, _ := setupTokenStoreTest(t)
+	ctx := context.Background()
+
+	pair, err := manager.IssueTokenPair(ctx, 1, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+	claims := mustParse(t, manager, pair.AccessToken)
+	require.NoError(t, manager.Revoke(ctx, claims.ID))
+
+	mr.Close()
+
+	revoked, err := store.IsRevoked(ctx, claims.ID)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	markers, err := store.LoadRevocationMarkers(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Contains(t, markers, jwt.UserKey(jwt.UserTypeUser, 1))
+
+	revoked, err = manager.IsRevoked(ctx, claims)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestTokenStore_DeleteExpired(t *testing.T) {
+	_, store, _, db := setupTokenStoreTest(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	require.NoError(t, store.SaveTokens(ctx, []*jwt.TokenRecord{
+		{JTI: "expired", FamilyID: "f1", UserID: 1, UserType: jwt.UserTypeUser, TokenType: jwt.TokenTypeAccess, ExpiresAt: now.Add(-time.Minute)},
+		{JTI: "active", FamilyID: "f1", UserID: 1, UserType: jwt.UserTypeUser, TokenType: jwt.TokenTypeRefresh, ExpiresAt: now.Add(time.Hour)},
+	}))
+
+	purged, err := store.DeleteExpired(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	var count int64
+	require.NoError(t, db.Model(&models.AuthToken{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
@@ -91,9 +91,12 @@ func (s *WechatService) WechatLogin(ctx context.Context, req *WechatLoginRequest
 	if user.Status == models.UserStatusDisabled {
 		return nil, errors.ErrAccountDisabled
 	}
+	if user.Status == models.UserStatusDeleted {
+		return nil, errors.ErrAccountDeleted
+	}
 
 	// 生成 Token
-	tokenPair, err := s.jwtManager.GenerateTokenPair(user.ID, jwt.UserTypeUser, "")
+	tokenPair, err := s.jwtManager.IssueTokenPair(ctx, user.ID, jwt.UserTypeUser, "")
 	if err != nil {
 		return nil, errors.ErrInternalError.WithError(err)
 	}
@@ -4,6 +4,7 @@ package content
 import (
 	"context"
 
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 )
@@ -177,3 +178,51 @@ func (s *NotificationService) GetNotificationSummary(ctx context.Context, userID
 		UnreadByType: unreadByType,
 	}, nil
 }
+
+// NotifyAdmins 向所有启用状态的管理员发送通知
+func (s *NotificationService) NotifyAdmins(ctx context.Context, notificationType, title, content string, link *string) error {
+	_, err := s.notificationRepo.CreateForActiveAdmins(ctx, notificationType, title, content, link)
+	return err
+}
+
+// AdminNotificationListRequest 管理员通知列表请求
+type AdminNotificationListRequest struct {
+	IsRead   *bool `form:"is_read"`
+	Page     int   `form:"page,default=1"`
+	PageSize int   `form:"page_size,default=20"`
+}
+
+// ListAdminNotifications 获取管理员的通知列表（如设备进入维护模式）
+func (s *NotificationService) ListAdminNotifications(ctx context.Context, adminID int64, req *AdminNotificationListRequest) ([]*models.AdminNotification, int64, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	return s.notificationRepo.ListAdminNotifications(ctx, adminID, offset, req.PageSize, req.IsRead)
+}
+
+// MarkAdminNotificationAsRead 标记管理员通知为已读，通知不存在或不属于该管理员时返回 ErrResourceNotFound
+func (s *NotificationService) MarkAdminNotificationAsRead(ctx context.Context, id, adminID int64) error {
+	affected, err := s.notificationRepo.MarkAdminNotificationAsRead(ctx, id, adminID)
+	if err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	if affected == 0 {
+		return errors.ErrResourceNotFound.WithMessage("通知不存在")
+	}
+	return nil
+}
+
+// MarkAllAdminNotificationsAsRead 标记管理员的所有通知为已读
+func (s *NotificationService) MarkAllAdminNotificationsAsRead(ctx context.Context, adminID int64) error {
+	return s.notificationRepo.MarkAllAdminNotificationsAsRead(ctx, adminID)
+}
+
+// GetAdminUnreadCount 获取管理员的未读通知数量
+func (s *NotificationService) GetAdminUnreadCount(ctx context.Context, adminID int64) (int64, error) {
+	return s.notificationRepo.CountAdminUnread(ctx, adminID)
+}
@@ -11,6 +11,7 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 )
@@ -606,3 +607,60 @@ func TestNotificationService_GetNotificationSummary(t *testing.T) {
 	assert.Equal(t, int64(1), summary.UnreadByType[models.NotificationTypeSystem])
 	assert.Equal(t, int64(1), summary.UnreadByType[models.NotificationTypeMarketing])
 }
+
+func TestNotificationService_AdminNotifications(t *testing.T) {
+	service, db := setupNotificationService(t)
+	require.NoError(t, db.AutoMigrate(&models.Admin{}, &models.AdminNotification{}))
+	ctx := context.Background()
+
+	ops := &models.Admin{Username: "ops", PasswordHash: "hash", Name: "运维", RoleID: 1, Status: models.AdminStatusActive}
+	finance := &models.Admin{Username: "finance", PasswordHash: "hash", Name: "财务", RoleID: 1, Status: models.AdminStatusActive}
+	require.NoError(t, db.Create(ops).Error)
+	require.NoError(t, db.Create(finance).Error)
+
+	link := "/devices/1"
+	require.NoError(t, service.NotifyAdmins(ctx, models.NotificationTypeDevice, "设备进入维护模式", "设备 D001 维护中", &link))
+	require.NoError(t, service.NotifyAdmins(ctx, models.NotificationTypeDevice, "设备进入维护模式", "设备 D002 维护中", &link))
+
+	list, total, err := service.ListAdminNotifications(ctx, ops.ID, &AdminNotificationListRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, list, 2)
+	assert.Equal(t, "设备 D002 维护中", list[0].Content)
+
+	count, err := service.GetAdminUnreadCount(ctx, ops.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	t.Run("只能标记自己的通知", func(t *testing.T) {
+		err := service.MarkAdminNotificationAsRead(ctx, list[0].ID, finance.ID)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrResourceNotFound.Code, appErr.Code)
+
+		require.NoError(t, service.MarkAdminNotificationAsRead(ctx, list[0].ID, ops.ID))
+
+		unread := false
+		unreadList, total, err := service.ListAdminNotifications(ctx, ops.ID, &AdminNotificationListRequest{IsRead: &unread})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, unreadList, 1)
+		assert.Equal(t, list[1].ID, unreadList[0].ID)
+
+		count, err := service.GetAdminUnreadCount(ctx, finance.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("全部标记已读", func(t *testing.T) {
+		require.NoError(t, service.MarkAllAdminNotificationsAsRead(ctx, ops.ID))
+
+		count, err := service.GetAdminUnreadCount(ctx, ops.ID)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+
+		count, err = service.GetAdminUnreadCount(ctx, finance.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+}
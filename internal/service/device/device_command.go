@@ -0,0 +1,103 @@
+package device
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dumeirei/smart-locker-backend/pkg/mqtt"
+)
+
+// DeviceCommandActionUnlock 开锁指令
+const DeviceCommandActionUnlock = "unlock"
+
+// DeviceCommandClient 设备指令下发客户端
+type DeviceCommandClient interface {
+	// SendUnlockCommand 向设备下发开锁指令
+	SendUnlockCommand(ctx context.Context, deviceNo string) error
+}
+
+// DeviceCommand 设备指令载荷
+type DeviceCommand struct {
+	Action    string `json:"action"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// CommandPublisher 指令发布器（由 mqtt.Client 实现）
+type CommandPublisher interface {
+	PublishWithContext(ctx context.Context, topic string, payload interface{}) error
+}
+
+// MQTTDeviceCommandClient 基于 MQTT 的设备指令客户端
+// 指令发布到 device/{deviceNo}/command 主题
+type MQTTDeviceCommandClient struct {
+	publisher CommandPublisher
+}
+
+// NewMQTTDeviceCommandClient 创建 MQTT 设备指令客户端
+func NewMQTTDeviceCommandClient(publisher CommandPublisher) *MQTTDeviceCommandClient {
+	return &MQTTDeviceCommandClient{publisher: publisher}
+}
+
+// SendUnlockCommand 下发开锁指令
+func (c *MQTTDeviceCommandClient) SendUnlockCommand(ctx context.Context, deviceNo string) error {
+	topic := fmt.Sprintf(mqtt.TopicDeviceCommand, deviceNo)
+	payload := &DeviceCommand{
+		Action:    DeviceCommandActionUnlock,
+		Timestamp: time.Now().Unix(),
+	}
+	if err := c.publisher.PublishWithContext(ctx, topic, payload); err != nil {
+		return fmt.Errorf("send unlock command to %s: %w", deviceNo, err)
+	}
+	return nil
+}
+
+// MockDeviceCommandClient 模拟设备指令客户端（用于开发/测试）
+// 记录所有下发的指令，设置 Err 可模拟下发失败
+type MockDeviceCommandClient struct {
+	mu       sync.Mutex
+	Commands []MockDeviceCommand
+	Err      error
+}
+
+// MockDeviceCommand 模拟下发的指令
+type MockDeviceCommand struct {
+	DeviceNo string
+	Action   string
+	SentAt   time.Time
+}
+
+// NewMockDeviceCommandClient 创建模拟设备指令客户端
+func NewMockDeviceCommandClient() *MockDeviceCommandClient {
+	return &MockDeviceCommandClient{
+		Commands: make([]MockDeviceCommand, 0),
+	}
+}
+
+// SendUnlockCommand 记录开锁指令，Err 非空时返回该错误
+func (c *MockDeviceCommandClient) SendUnlockCommand(ctx context.Context, deviceNo string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Commands = append(c.Commands, MockDeviceCommand{
+		DeviceNo: deviceNo,
+		Action:   DeviceCommandActionUnlock,
+		SentAt:   time.Now(),
+	})
+	return c.Err
+}
+
+// SetError 设置模拟的下发错误，传入 nil 恢复正常
+func (c *MockDeviceCommandClient) SetError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Err = err
+}
+
+// CommandCount 已记录的指令数量
+func (c *MockDeviceCommandClient) CommandCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.Commands)
+}
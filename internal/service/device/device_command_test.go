@@ -0,0 +1,58 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePublisher 记录发布的消息
+type fakePublisher struct {
+	topic   string
+	payload interface{}
+	err     error
+}
+
+func (p *fakePublisher) PublishWithContext(ctx context.Context, topic string, payload interface{}) error {
+	p.topic = topic
+	p.payload = payload
+	return p.err
+}
+
+func TestMQTTDeviceCommandClient_SendUnlockCommand(t *testing.T) {
+	publisher := &fakePublisher{}
+	client := NewMQTTDeviceCommandClient(publisher)
+
+	err := client.SendUnlockCommand(context.Background(), "D001")
+	require.NoError(t, err)
+	assert.Equal(t, "device/D001/command", publisher.topic)
+
+	cmd, ok := publisher.payload.(*DeviceCommand)
+	require.True(t, ok)
+	assert.Equal(t, DeviceCommandActionUnlock, cmd.Action)
+	assert.NotZero(t, cmd.Timestamp)
+}
+
+func TestMQTTDeviceCommandClient_PublishError(t *testing.T) {
+	publishErr := errors.New("not connected")
+	client := NewMQTTDeviceCommandClient(&fakePublisher{err: publishErr})
+
+	err := client.SendUnlockCommand(context.Background(), "D001")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, publishErr)
+}
+
+func TestMockDeviceCommandClient(t *testing.T) {
+	client := NewMockDeviceCommandClient()
+
+	require.NoError(t, client.SendUnlockCommand(context.Background(), "D001"))
+	client.SetError(errors.New("offline"))
+	require.Error(t, client.SendUnlockCommand(context.Background(), "D002"))
+
+	require.Equal(t, 2, client.CommandCount())
+	assert.Equal(t, "D001", client.Commands[0].DeviceNo)
+	assert.Equal(t, "D002", client.Commands[1].DeviceNo)
+}
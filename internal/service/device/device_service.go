@@ -14,9 +14,10 @@ import (
 
 // DeviceService 设备服务
 type DeviceService struct {
-	db         *gorm.DB
-	deviceRepo *repository.DeviceRepository
-	venueRepo  *repository.VenueRepository
+	db              *gorm.DB
+	deviceRepo      *repository.DeviceRepository
+	venueRepo       *repository.VenueRepository
+	statusPublisher StatusPublisher
 }
 
 // NewDeviceService 创建设备服务
@@ -32,6 +33,26 @@ func NewDeviceService(
 	}
 }
 
+// SetStatusPublisher 设置设备状态变更事件发布者，未设置时不发布事件
+func (s *DeviceService) SetStatusPublisher(publisher StatusPublisher) {
+	s.statusPublisher = publisher
+}
+
+// PublishStatusChange 发布设备状态变更事件，状态未变化或未设置发布者时忽略
+// 发布失败不影响业务流程
+func (s *DeviceService) PublishStatusChange(ctx context.Context, deviceID int64, eventType string, oldStatus, newStatus int8) {
+	if s.statusPublisher == nil || oldStatus == newStatus {
+		return
+	}
+	_ = s.statusPublisher.PublishStatusChange(ctx, &StatusChangeEvent{
+		DeviceID:  deviceID,
+		EventType: eventType,
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+		Timestamp: time.Now(),
+	})
+}
+
 // DeviceInfo 设备信息（用户端）
 type DeviceInfo struct {
 	ID             int64        `json:"id"`
@@ -151,6 +172,10 @@ func (s *DeviceService) CheckDeviceAvailable(ctx context.Context, deviceID int64
 		return errors.ErrDeviceDisabled
 	}
 
+	if device.MaintenanceMode {
+		return errors.ErrDeviceMaintenance
+	}
+
 	if device.OnlineStatus != models.DeviceOnline {
 		return errors.ErrDeviceOffline
 	}
@@ -185,10 +210,13 @@ func (s *DeviceService) GetPricing(ctx context.Context, pricingID int64) (*Prici
 	}, nil
 }
 
-// GetDevicePricings 获取设备的定价列表
+// GetDevicePricings 获取设备的生效定价列表，同一时长按 设备专属 > 场地 > 全局 的优先级取其一
 func (s *DeviceService) GetDevicePricings(ctx context.Context, deviceID int64) ([]PricingInfo, error) {
 	pricings, err := s.deviceRepo.GetPricingsByDevice(ctx, deviceID)
 	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrDeviceNotFound
+		}
 		return nil, errors.ErrDatabaseError.WithError(err)
 	}
 
@@ -290,7 +318,12 @@ func (s *DeviceService) UpdateDeviceHeartbeat(ctx context.Context, deviceNo stri
 		})
 	}
 
-	return s.deviceRepo.UpdateHeartbeat(ctx, device.ID, fields)
+	if err := s.deviceRepo.UpdateHeartbeat(ctx, device.ID, fields); err != nil {
+		return err
+	}
+
+	s.PublishStatusChange(ctx, device.ID, StatusEventOnline, device.OnlineStatus, models.DeviceOnline)
+	return nil
 }
 
 // HeartbeatData 心跳数据
@@ -305,6 +338,14 @@ type HeartbeatData struct {
 
 // SetDeviceOffline 设置设备离线
 func (s *DeviceService) SetDeviceOffline(ctx context.Context, deviceID int64) error {
+	device, err := s.deviceRepo.GetByID(ctx, deviceID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.ErrDeviceNotFound
+		}
+		return errors.ErrDatabaseError.WithError(err)
+	}
+
 	now := time.Now()
 	fields := map[string]interface{}{
 		"online_status":   models.DeviceOffline,
@@ -322,9 +363,32 @@ func (s *DeviceService) SetDeviceOffline(ctx context.Context, deviceID int64) er
 		OperatorType: stringPtr(models.DeviceLogOperatorSystem),
 	})
 
+	s.PublishStatusChange(ctx, deviceID, StatusEventOffline, device.OnlineStatus, models.DeviceOffline)
 	return nil
 }
 
+// SetDeviceOfflineIfStale 心跳超时时设置设备离线，返回是否标记离线
+// 仅当设备最后心跳仍早于 before 时更新，检测期间已恢复心跳的设备保持在线
+func (s *DeviceService) SetDeviceOfflineIfStale(ctx context.Context, deviceID int64, before time.Time) (bool, error) {
+	updated, err := s.deviceRepo.SetOfflineIfHeartbeatBefore(ctx, deviceID, before, time.Now())
+	if err != nil {
+		return false, errors.ErrDatabaseError.WithError(err)
+	}
+	if !updated {
+		return false, nil
+	}
+
+	// 记录离线日志
+	_ = s.deviceRepo.CreateLog(ctx, &models.DeviceLog{
+		DeviceID:     deviceID,
+		Type:         models.DeviceLogTypeOffline,
+		OperatorType: stringPtr(models.DeviceLogOperatorSystem),
+	})
+
+	s.PublishStatusChange(ctx, deviceID, StatusEventOffline, models.DeviceOnline, models.DeviceOffline)
+	return true, nil
+}
+
 // toDeviceInfo 转换为设备信息
 func (s *DeviceService) toDeviceInfo(device *models.Device, pricings []*models.RentalPricing) *DeviceInfo {
 	info := &DeviceInfo{
@@ -12,6 +12,7 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 )
@@ -248,6 +249,13 @@ func TestDeviceService_CheckDeviceAvailable(t *testing.T) {
 		db.Model(&models.Device{}).Where("id = ?", device.ID).Update("status", models.DeviceStatusActive)
 	})
 
+	t.Run("设备维护模式", func(t *testing.T) {
+		db.Model(&models.Device{}).Where("id = ?", device.ID).Update("maintenance_mode", true)
+		err := svc.CheckDeviceAvailable(context.Background(), device.ID)
+		assert.ErrorIs(t, err, appErrors.ErrDeviceMaintenance)
+		db.Model(&models.Device{}).Where("id = ?", device.ID).Update("maintenance_mode", false)
+	})
+
 	t.Run("设备离线", func(t *testing.T) {
 		db.Model(&models.Device{}).Where("id = ?", device.ID).Update("online_status", models.DeviceOffline)
 		err := svc.CheckDeviceAvailable(context.Background(), device.ID)
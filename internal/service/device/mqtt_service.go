@@ -62,11 +62,14 @@ func (s *MQTTService) OnStatus(ctx context.Context, deviceNo string, payload *mq
 		return err
 	}
 
+	// 可用槽位由格口库存在租借事务中维护，不采用设备上报值，避免覆盖并发租借的扣减
 	fields := map[string]interface{}{
-		"online_status":   payload.OnlineStatus,
-		"lock_status":     payload.LockStatus,
-		"rental_status":   payload.RentalStatus,
-		"available_slots": payload.AvailableSlots,
+		"online_status": payload.OnlineStatus,
+		"lock_status":   payload.LockStatus,
+		"rental_status": payload.RentalStatus,
+	}
+	if payload.AvailableSlots != device.AvailableSlots {
+		log.Printf("[MQTTService] Device %s reported available slots %d, inventory has %d", deviceNo, payload.AvailableSlots, device.AvailableSlots)
 	}
 
 	if err := s.deviceRepo.UpdateFields(ctx, device.ID, fields); err != nil {
@@ -74,6 +77,15 @@ func (s *MQTTService) OnStatus(ctx context.Context, deviceNo string, payload *mq
 		return err
 	}
 
+	if s.deviceService != nil {
+		onlineEvent := StatusEventOffline
+		if payload.OnlineStatus == models.DeviceOnline {
+			onlineEvent = StatusEventOnline
+		}
+		s.deviceService.PublishStatusChange(ctx, device.ID, onlineEvent, device.OnlineStatus, payload.OnlineStatus)
+		s.deviceService.PublishStatusChange(ctx, device.ID, StatusEventRentalStatus, device.RentalStatus, payload.RentalStatus)
+	}
+
 	return nil
 }
 
@@ -81,7 +81,8 @@ func TestMQTTService_OnStatus_UpdatesDeviceFields(t *testing.T) {
 	require.NoError(t, db.First(&updated, device.ID).Error)
 	assert.Equal(t, int8(models.DeviceUnlocked), updated.LockStatus)
 	assert.Equal(t, int8(models.DeviceRentalInUse), updated.RentalStatus)
-	assert.Equal(t, 7, updated.AvailableSlots)
+	// 设备上报的可用槽位不覆盖格口库存维护的值
+	assert.Equal(t, 10, updated.AvailableSlots)
 }
 
 func TestMQTTService_OnEvent_Unlocked_CreatesLogAndUpdatesLockStatus(t *testing.T) {
@@ -0,0 +1,105 @@
+package device
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// 离线检测相关常量
+const (
+	DefaultHeartbeatInterval    = 60 * time.Second // 默认心跳上报间隔
+	DefaultOfflineDetectionPoll = 30 * time.Second // 默认检测间隔
+	offlineHeartbeatMisses      = 3                // 连续未上报心跳次数，超过后视为离线
+)
+
+// OfflineDetectionJob 设备离线检测任务
+// 定期将超过 3 个心跳间隔未上报心跳的在线设备标记为离线
+type OfflineDetectionJob struct {
+	deviceSvc         *DeviceService
+	deviceRepo        *repository.DeviceRepository
+	heartbeatInterval time.Duration
+	interval          time.Duration
+	logger            *zap.Logger
+	wg                sync.WaitGroup
+}
+
+// NewOfflineDetectionJob 创建设备离线检测任务
+func NewOfflineDetectionJob(deviceSvc *DeviceService, deviceRepo *repository.DeviceRepository, heartbeatInterval, interval time.Duration, logger *zap.Logger) *OfflineDetectionJob {
+	if heartbeatInterval <= 0 {
+		heartbeatInterval = DefaultHeartbeatInterval
+	}
+	if interval <= 0 {
+		interval = DefaultOfflineDetectionPoll
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &OfflineDetectionJob{
+		deviceSvc:         deviceSvc,
+		deviceRepo:        deviceRepo,
+		heartbeatInterval: heartbeatInterval,
+		interval:          interval,
+		logger:            logger,
+	}
+}
+
+// Start 启动后台检测，ctx 取消后退出
+func (j *OfflineDetectionJob) Start(ctx context.Context) {
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := j.RunOnce(ctx, time.Now()); err != nil {
+					j.logger.Error("设备离线检测任务执行失败", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// Wait 等待后台检测退出
+func (j *OfflineDetectionJob) Wait() {
+	j.wg.Wait()
+}
+
+// RunOnce 将心跳超时的设备标记为离线，返回标记的设备数
+func (j *OfflineDetectionJob) RunOnce(ctx context.Context, now time.Time) (int, error) {
+	deadline := now.Add(-offlineHeartbeatMisses * j.heartbeatInterval)
+	devices, err := j.deviceRepo.ListHeartbeatTimeout(ctx, deadline)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, d := range devices {
+		// 单台设备失败不影响其他设备，下一轮检测会重试；查询后刚恢复心跳的设备不会被标记离线
+		offline, err := j.deviceSvc.SetDeviceOfflineIfStale(ctx, d.ID, deadline)
+		if err != nil {
+			j.logger.Warn("设备标记离线失败", zap.Int64("device_id", d.ID), zap.Error(err))
+			continue
+		}
+		if !offline {
+			continue
+		}
+		j.logger.Info("设备心跳超时，已标记离线",
+			zap.Int64("device_id", d.ID),
+			zap.String("device_no", d.DeviceNo),
+			zap.Timep("last_heartbeat_at", d.LastHeartbeatAt),
+		)
+		count++
+	}
+	return count, nil
+}
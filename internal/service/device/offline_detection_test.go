@@ -0,0 +1,154 @@
+package device
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+func TestOfflineDetectionJob_RunOnce_HeartbeatThenSilence(t *testing.T) {
+	db := setupDeviceServiceTestDB(t)
+	ctx := context.Background()
+	deviceRepo := repository.NewDeviceRepository(db)
+	deviceSvc := NewDeviceService(db, deviceRepo, repository.NewVenueRepository(db))
+
+	core, logs := observer.New(zap.InfoLevel)
+	job := NewOfflineDetectionJob(deviceSvc, deviceRepo, 10*time.Second, 0, zap.New(core))
+
+	_, device := seedMerchantVenueDevice(t, db, "D_HEARTBEAT_001", models.DeviceOffline)
+
+	// 设备持续上报心跳
+	battery := 80
+	temperature := 25.5
+	for i := 0; i < 3; i++ {
+		require.NoError(t, deviceSvc.UpdateDeviceHeartbeat(ctx, device.DeviceNo, &HeartbeatData{
+			BatteryLevel: &battery,
+			Temperature:  &temperature,
+		}))
+	}
+
+	var updated models.Device
+	require.NoError(t, db.First(&updated, device.ID).Error)
+	assert.Equal(t, int8(models.DeviceOnline), updated.OnlineStatus)
+	require.NotNil(t, updated.LastHeartbeatAt)
+	require.NotNil(t, updated.BatteryLevel)
+	assert.Equal(t, battery, *updated.BatteryLevel)
+	lastHeartbeat := *updated.LastHeartbeatAt
+
+	// 静默未超过 3 个心跳间隔，保持在线
+	offline, err := job.RunOnce(ctx, lastHeartbeat.Add(30*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 0, offline)
+	require.NoError(t, db.First(&updated, device.ID).Error)
+	assert.Equal(t, int8(models.DeviceOnline), updated.OnlineStatus)
+
+	// 静默超过 3 个心跳间隔，标记离线
+	offline, err = job.RunOnce(ctx, lastHeartbeat.Add(31*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, offline)
+	require.NoError(t, db.First(&updated, device.ID).Error)
+	assert.Equal(t, int8(models.DeviceOffline), updated.OnlineStatus)
+	assert.NotNil(t, updated.LastOfflineAt)
+
+	var logCount int64
+	db.Model(&models.DeviceLog{}).Where("device_id = ? AND type = ?", device.ID, models.DeviceLogTypeOffline).Count(&logCount)
+	assert.Equal(t, int64(1), logCount)
+	assert.Equal(t, 1, logs.FilterMessage("设备心跳超时，已标记离线").Len())
+
+	// 已离线设备不会被重复处理
+	offline, err = job.RunOnce(ctx, lastHeartbeat.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 0, offline)
+
+	// 恢复心跳后重新上线
+	require.NoError(t, deviceSvc.UpdateDeviceHeartbeat(ctx, device.DeviceNo, &HeartbeatData{}))
+	require.NoError(t, db.First(&updated, device.ID).Error)
+	assert.Equal(t, int8(models.DeviceOnline), updated.OnlineStatus)
+}
+
+func TestOfflineDetectionJob_RunOnce_OnlyTimedOutDevices(t *testing.T) {
+	db := setupDeviceServiceTestDB(t)
+	ctx := context.Background()
+	deviceRepo := repository.NewDeviceRepository(db)
+	deviceSvc := NewDeviceService(db, deviceRepo, repository.NewVenueRepository(db))
+	job := NewOfflineDetectionJob(deviceSvc, deviceRepo, 0, 0, nil)
+
+	now := time.Now()
+	_, stale := seedMerchantVenueDevice(t, db, "D_STALE", models.DeviceOnline)
+	_, fresh := seedMerchantVenueDevice(t, db, "D_FRESH", models.DeviceOnline)
+	_, neverReported := seedMerchantVenueDevice(t, db, "D_NEVER", models.DeviceOnline)
+	require.NoError(t, db.Model(stale).Update("last_heartbeat_at", now.Add(-3*DefaultHeartbeatInterval-time.Second)).Error)
+	require.NoError(t, db.Model(fresh).Update("last_heartbeat_at", now.Add(-DefaultHeartbeatInterval)).Error)
+
+	offline, err := job.RunOnce(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, offline)
+
+	statuses := map[int64]int8{}
+	for _, id := range []int64{stale.ID, fresh.ID, neverReported.ID} {
+		var d models.Device
+		require.NoError(t, db.First(&d, id).Error)
+		statuses[id] = d.OnlineStatus
+	}
+	assert.Equal(t, int8(models.DeviceOffline), statuses[stale.ID])
+	assert.Equal(t, int8(models.DeviceOnline), statuses[fresh.ID])
+	assert.Equal(t, int8(models.DeviceOnline), statuses[neverReported.ID])
+}
+
+func TestDeviceService_SetDeviceOfflineIfStale_HeartbeatDuringSweep(t *testing.T) {
+	db := setupDeviceServiceTestDB(t)
+	ctx := context.Background()
+	deviceRepo := repository.NewDeviceRepository(db)
+	deviceSvc := NewDeviceService(db, deviceRepo, repository.NewVenueRepository(db))
+
+	now := time.Now()
+	_, device := seedMerchantVenueDevice(t, db, "D_RACE", models.DeviceOnline)
+	require.NoError(t, db.Model(device).Update("last_heartbeat_at", now.Add(-time.Hour)).Error)
+
+	// 检测任务查出超时设备后、标记离线前设备恢复心跳
+	deadline := now.Add(-3 * DefaultHeartbeatInterval)
+	stale, err := deviceRepo.ListHeartbeatTimeout(ctx, deadline)
+	require.NoError(t, err)
+	require.Len(t, stale, 1)
+	require.NoError(t, deviceSvc.UpdateDeviceHeartbeat(ctx, device.DeviceNo, &HeartbeatData{}))
+
+	offline, err := deviceSvc.SetDeviceOfflineIfStale(ctx, device.ID, deadline)
+	require.NoError(t, err)
+	assert.False(t, offline)
+
+	var updated models.Device
+	require.NoError(t, db.First(&updated, device.ID).Error)
+	assert.Equal(t, int8(models.DeviceOnline), updated.OnlineStatus)
+	var logCount int64
+	db.Model(&models.DeviceLog{}).Where("device_id = ? AND type = ?", device.ID, models.DeviceLogTypeOffline).Count(&logCount)
+	assert.Zero(t, logCount)
+}
+
+func TestOfflineDetectionJob_StartStopsOnCancel(t *testing.T) {
+	db := setupDeviceServiceTestDB(t)
+	deviceRepo := repository.NewDeviceRepository(db)
+	deviceSvc := NewDeviceService(db, deviceRepo, repository.NewVenueRepository(db))
+
+	_, device := seedMerchantVenueDevice(t, db, "D_TICKER", models.DeviceOnline)
+	require.NoError(t, db.Model(device).Update("last_heartbeat_at", time.Now().Add(-time.Minute)).Error)
+
+	job := NewOfflineDetectionJob(deviceSvc, deviceRepo, time.Second, 10*time.Millisecond, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	job.Start(ctx)
+
+	assert.Eventually(t, func() bool {
+		var d models.Device
+		return db.First(&d, device.ID).Error == nil && d.OnlineStatus == models.DeviceOffline
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	job.Wait()
+}
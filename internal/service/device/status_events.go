@@ -0,0 +1,147 @@
+// Package device 设备状态变更事件的发布与订阅
+package device
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// DeviceStatusChannel 设备状态变更事件的 Redis 频道
+const DeviceStatusChannel = "device:status:changes"
+
+// 设备状态变更事件类型
+const (
+	StatusEventOnline       = "online"        // 设备上线
+	StatusEventOffline      = "offline"       // 设备离线
+	StatusEventRentalStatus = "rental_status" // 租借状态变更
+)
+
+// statusClientBuffer 每个订阅者的事件缓冲数，缓冲已满时丢弃新事件
+const statusClientBuffer = 64
+
+// StatusChangeEvent 设备状态变更事件
+// online/offline 事件的状态为在线状态，rental_status 事件的状态为设备租借状态
+type StatusChangeEvent struct {
+	DeviceID  int64     `json:"device_id"`
+	EventType string    `json:"event_type"`
+	OldStatus int8      `json:"old_status"`
+	NewStatus int8      `json:"new_status"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// StatusPublisher 设备状态变更事件发布者
+type StatusPublisher interface {
+	PublishStatusChange(ctx context.Context, event *StatusChangeEvent) error
+}
+
+// RedisStatusPublisher 通过 Redis 频道发布设备状态变更事件
+type RedisStatusPublisher struct {
+	client redis.Cmdable
+}
+
+// NewRedisStatusPublisher 创建 Redis 设备状态发布者
+func NewRedisStatusPublisher(client redis.Cmdable) *RedisStatusPublisher {
+	return &RedisStatusPublisher{client: client}
+}
+
+// PublishStatusChange 发布设备状态变更事件
+func (p *RedisStatusPublisher) PublishStatusChange(ctx context.Context, event *StatusChangeEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return p.client.Publish(ctx, DeviceStatusChannel, payload).Err()
+}
+
+// StatusHub 订阅 Redis 设备状态频道，并将事件分发给所有已注册的订阅者
+type StatusHub struct {
+	client  *redis.Client
+	logger  *zap.Logger
+	mu      sync.RWMutex
+	clients map[chan []byte]struct{}
+	wg      sync.WaitGroup
+}
+
+// NewStatusHub 创建设备状态事件分发中心
+func NewStatusHub(client *redis.Client, logger *zap.Logger) *StatusHub {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &StatusHub{
+		client:  client,
+		logger:  logger,
+		clients: make(map[chan []byte]struct{}),
+	}
+}
+
+// Start 订阅设备状态频道并在后台分发事件，ctx 取消后退出
+// 返回时订阅已生效
+func (h *StatusHub) Start(ctx context.Context) error {
+	pubsub := h.client.Subscribe(ctx, DeviceStatusChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return err
+	}
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				h.broadcast([]byte(msg.Payload))
+			}
+		}
+	}()
+	return nil
+}
+
+// Wait 等待后台订阅退出
+func (h *StatusHub) Wait() {
+	h.wg.Wait()
+}
+
+// Register 注册订阅者，返回事件通道及注销函数
+func (h *StatusHub) Register() (<-chan []byte, func()) {
+	ch := make(chan []byte, statusClientBuffer)
+
+	h.mu.Lock()
+	h.clients[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.clients, ch)
+			h.mu.Unlock()
+		})
+	}
+}
+
+// broadcast 将事件分发给所有订阅者，订阅者处理过慢时丢弃该事件
+func (h *StatusHub) broadcast(payload []byte) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.clients {
+		select {
+		case ch <- payload:
+		default:
+			h.logger.Warn("设备状态订阅者缓冲已满，丢弃事件")
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package device
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	"github.com/dumeirei/smart-locker-backend/pkg/mqtt"
+)
+
+// recordingPublisher 记录发布的设备状态事件
+type recordingPublisher struct {
+	events []*StatusChangeEvent
+}
+
+func (p *recordingPublisher) PublishStatusChange(_ context.Context, event *StatusChangeEvent) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func setupStatusTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+		mr.Close()
+	})
+	return client
+}
+
+// receiveStatusEvent 从订阅通道读取一个事件，超时则失败
+func receiveStatusEvent(t *testing.T, events <-chan []byte) *StatusChangeEvent {
+	t.Helper()
+
+	select {
+	case payload := <-events:
+		var event StatusChangeEvent
+		require.NoError(t, json.Unmarshal(payload, &event))
+		return &event
+	case <-time.After(2 * time.Second):
+		t.Fatal("未收到设备状态事件")
+		return nil
+	}
+}
+
+func TestStatusHub_BroadcastsPublishedEvents(t *testing.T) {
+	client := setupStatusTestRedis(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewStatusHub(client, nil)
+	require.NoError(t, hub.Start(ctx))
+
+	first, unregisterFirst := hub.Register()
+	defer unregisterFirst()
+	second, unregisterSecond := hub.Register()
+
+	publisher := NewRedisStatusPublisher(client)
+	require.NoError(t, publisher.PublishStatusChange(ctx, &StatusChangeEvent{
+		DeviceID:  7,
+		EventType: StatusEventOffline,
+		OldStatus: models.DeviceOnline,
+		NewStatus: models.DeviceOffline,
+		Timestamp: time.Now(),
+	}))
+
+	for _, events := range []<-chan []byte{first, second} {
+		event := receiveStatusEvent(t, events)
+		assert.Equal(t, int64(7), event.DeviceID)
+		assert.Equal(t, StatusEventOffline, event.EventType)
+		assert.Equal(t, int8(models.DeviceOnline), event.OldStatus)
+		assert.Equal(t, int8(models.DeviceOffline), event.NewStatus)
+	}
+
+	// 注销后不再接收事件
+	unregisterSecond()
+	require.NoError(t, publisher.PublishStatusChange(ctx, &StatusChangeEvent{DeviceID: 8, EventType: StatusEventOnline}))
+	assert.Equal(t, int64(8), receiveStatusEvent(t, first).DeviceID)
+	assert.Empty(t, second)
+
+	cancel()
+	hub.Wait()
+}
+
+func TestDeviceService_PublishesStatusChanges(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("离线设备心跳发布上线事件", func(t *testing.T) {
+		db := setupDeviceServiceTestDB(t)
+		svc := NewDeviceService(db, repository.NewDeviceRepository(db), repository.NewVenueRepository(db))
+		publisher := &recordingPublisher{}
+		svc.SetStatusPublisher(publisher)
+		_, device := seedMerchantVenueDevice(t, db, "DEV_EVT_1", models.DeviceOffline)
+
+		require.NoError(t, svc.UpdateDeviceHeartbeat(ctx, device.DeviceNo, &HeartbeatData{}))
+
+		require.Len(t, publisher.events, 1)
+		assert.Equal(t, device.ID, publisher.events[0].DeviceID)
+		assert.Equal(t, StatusEventOnline, publisher.events[0].EventType)
+		assert.Equal(t, int8(models.DeviceOffline), publisher.events[0].OldStatus)
+		assert.Equal(t, int8(models.DeviceOnline), publisher.events[0].NewStatus)
+		assert.False(t, publisher.events[0].Timestamp.IsZero())
+	})
+
+	t.Run("在线设备心跳不发布事件", func(t *testing.T) {
+		db := setupDeviceServiceTestDB(t)
+		svc := NewDeviceService(db, repository.NewDeviceRepository(db), repository.NewVenueRepository(db))
+		publisher := &recordingPublisher{}
+		svc.SetStatusPublisher(publisher)
+		_, device := seedMerchantVenueDevice(t, db, "DEV_EVT_2", models.DeviceOnline)
+
+		require.NoError(t, svc.UpdateDeviceHeartbeat(ctx, device.DeviceNo, &HeartbeatData{}))
+		assert.Empty(t, publisher.events)
+	})
+
+	t.Run("设置离线发布离线事件", func(t *testing.T) {
+		db := setupDeviceServiceTestDB(t)
+		svc := NewDeviceService(db, repository.NewDeviceRepository(db), repository.NewVenueRepository(db))
+		publisher := &recordingPublisher{}
+		svc.SetStatusPublisher(publisher)
+		_, device := seedMerchantVenueDevice(t, db, "DEV_EVT_3", models.DeviceOnline)
+
+		require.NoError(t, svc.SetDeviceOffline(ctx, device.ID))
+
+		require.Len(t, publisher.events, 1)
+		assert.Equal(t, StatusEventOffline, publisher.events[0].EventType)
+		assert.Equal(t, int8(models.DeviceOnline), publisher.events[0].OldStatus)
+		assert.Equal(t, int8(models.DeviceOffline), publisher.events[0].NewStatus)
+	})
+
+	t.Run("MQTT 状态上报发布租借状态变更", func(t *testing.T) {
+		db := setupDeviceServiceTestDB(t)
+		deviceRepo := repository.NewDeviceRepository(db)
+		svc := NewDeviceService(db, deviceRepo, repository.NewVenueRepository(db))
+		publisher := &recordingPublisher{}
+		svc.SetStatusPublisher(publisher)
+		_, device := seedMerchantVenueDevice(t, db, "DEV_EVT_4", models.DeviceOnline)
+
+		mqttSvc := NewMQTTService(deviceRepo, svc, nil)
+		require.NoError(t, mqttSvc.OnStatus(ctx, device.DeviceNo, &mqtt.StatusPayload{
+			OnlineStatus:   models.DeviceOnline,
+			RentalStatus:   models.DeviceRentalInUse,
+			AvailableSlots: 0,
+		}))
+
+		require.Len(t, publisher.events, 1)
+		assert.Equal(t, StatusEventRentalStatus, publisher.events[0].EventType)
+		assert.Equal(t, int8(models.DeviceRentalFree), publisher.events[0].OldStatus)
+		assert.Equal(t, int8(models.DeviceRentalInUse), publisher.events[0].NewStatus)
+	})
+}
@@ -4,6 +4,7 @@ package device
 import (
 	"context"
 	"math"
+	"sort"
 
 	"gorm.io/gorm"
 
@@ -103,47 +104,62 @@ func (s *VenueService) GetVenueByID(ctx context.Context, venueID int64) (*VenueD
 	}, nil
 }
 
-// ListNearbyVenues 获取附近场地列表
-func (s *VenueService) ListNearbyVenues(ctx context.Context, longitude, latitude float64, radiusKm float64, limit int) ([]*VenueListItem, error) {
-	if limit <= 0 {
-		limit = 20
+// MaxNearbyRadiusKm 附近场地搜索的最大半径（公里）
+const MaxNearbyRadiusKm = 50.0
+
+// VenueWithDistance 带距离的场地
+type VenueWithDistance struct {
+	models.Venue
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// FindNearby 按距离由近到远分页查询半径范围内的正常场地
+// 先按经纬度范围在数据库中粗筛，再用 Haversine 公式计算精确距离，不依赖数据库的三角函数支持
+func (s *VenueService) FindNearby(ctx context.Context, lat, lon, radiusKm float64, page, pageSize int) ([]*VenueWithDistance, int64, error) {
+	if lat < -90 || lat > 90 {
+		return nil, 0, errors.ErrInvalidParams.WithMessage("无效的纬度")
+	}
+	if lon < -180 || lon > 180 {
+		return nil, 0, errors.ErrInvalidParams.WithMessage("无效的经度")
+	}
+	if radiusKm <= 0 || radiusKm > MaxNearbyRadiusKm {
+		return nil, 0, errors.ErrInvalidParams.WithMessage("搜索半径需大于 0 且不超过 50 公里")
 	}
-	if radiusKm <= 0 {
-		radiusKm = 5.0 // 默认 5 公里
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
 	}
 
-	venues, err := s.venueRepo.ListNearby(ctx, longitude, latitude, radiusKm, limit)
+	minLat, maxLat, minLon, maxLon := boundingBox(lat, lon, radiusKm)
+	venues, err := s.venueRepo.ListInBoundingBox(ctx, minLat, maxLat, minLon, maxLon)
 	if err != nil {
-		return nil, errors.ErrDatabaseError.WithError(err)
+		return nil, 0, errors.ErrDatabaseError.WithError(err)
 	}
 
-	result := make([]*VenueListItem, 0, len(venues))
+	nearby := make([]*VenueWithDistance, 0, len(venues))
 	for _, v := range venues {
-		// 获取可用设备数量
-		availableCount, _ := s.venueRepo.GetAvailableDeviceCount(ctx, v.ID)
-
-		item := &VenueListItem{
-			ID:                   v.ID,
-			Name:                 v.Name,
-			Type:                 v.Type,
-			City:                 v.City,
-			District:             v.District,
-			Address:              v.Address,
-			Longitude:            v.Longitude,
-			Latitude:             v.Latitude,
-			AvailableDeviceCount: availableCount,
+		distance := calculateDistance(lat, lon, *v.Latitude, *v.Longitude)
+		if distance > radiusKm {
+			continue
 		}
-
-		// 计算距离
-		if v.Longitude != nil && v.Latitude != nil {
-			distance := calculateDistance(latitude, longitude, *v.Latitude, *v.Longitude)
-			item.Distance = &distance
-		}
-
-		result = append(result, item)
+		nearby = append(nearby, &VenueWithDistance{Venue: *v, DistanceKm: distance})
 	}
-
-	return result, nil
+	sort.SliceStable(nearby, func(i, j int) bool {
+		return nearby[i].DistanceKm < nearby[j].DistanceKm
+	})
+
+	total := int64(len(nearby))
+	offset := (page - 1) * pageSize
+	if offset >= len(nearby) {
+		return []*VenueWithDistance{}, total, nil
+	}
+	end := offset + pageSize
+	if end > len(nearby) {
+		end = len(nearby)
+	}
+	return nearby[offset:end], total, nil
 }
 
 // ListVenuesByCity 获取城市下的场地列表
@@ -235,3 +251,31 @@ func calculateDistance(lat1, lon1, lat2, lon2 float64) float64 {
 
 	return earthRadius * c
 }
+
+// boundingBox 计算以 (lat, lon) 为圆心、radiusKm 为半径的圆的经纬度外接范围
+// 经度范围跨越 ±180 度时返回的 minLon > maxLon
+func boundingBox(lat, lon, radiusKm float64) (minLat, maxLat, minLon, maxLon float64) {
+	const earthRadius = 6371.0 // 地球半径（公里）
+
+	angular := radiusKm / earthRadius
+	latDelta := angular * 180 / math.Pi
+	minLat = math.Max(lat-latDelta, -90)
+	maxLat = math.Min(lat+latDelta, 90)
+
+	// 范围包含极点时经度不受限制
+	ratio := math.Sin(angular) / math.Cos(lat*math.Pi/180)
+	if minLat == -90 || maxLat == 90 || ratio >= 1 {
+		return minLat, maxLat, -180, 180
+	}
+
+	lonDelta := math.Asin(ratio) * 180 / math.Pi
+	minLon = lon - lonDelta
+	maxLon = lon + lonDelta
+	if minLon < -180 {
+		minLon += 360
+	}
+	if maxLon > 180 {
+		maxLon -= 360
+	}
+	return minLat, maxLat, minLon, maxLon
+}
@@ -131,9 +131,109 @@ func TestVenueService_GetVenueByID(t *testing.T) {
 	})
 }
 
-func TestVenueService_ListNearbyVenues(t *testing.T) {
-	// Skip: SQLite doesn't support acos/sin/cos math functions used in distance calculation
-	t.Skip("Skipping due to SQLite not supporting acos function")
+// createVenueAt 创建指定坐标的场地
+func createVenueAt(t *testing.T, db *gorm.DB, merchantID int64, name string, lat, lng float64, status int8) *models.Venue {
+	t.Helper()
+
+	venue := &models.Venue{
+		MerchantID: merchantID,
+		Name:       name,
+		Type:       models.VenueTypeMall,
+		Province:   "广东省",
+		City:       "深圳市",
+		District:   "南山区",
+		Address:    "科技园路1号",
+		Longitude:  &lng,
+		Latitude:   &lat,
+		Status:     models.VenueStatusActive,
+	}
+	require.NoError(t, db.Create(venue).Error)
+	// status 列默认值为 1，零值需单独更新
+	require.NoError(t, db.Model(venue).Update("status", status).Error)
+	return venue
+}
+
+func TestVenueService_FindNearby(t *testing.T) {
+	db := setupVenueServiceTestDB(t)
+	svc := NewVenueService(db, repository.NewVenueRepository(db), repository.NewDeviceRepository(db))
+	ctx := context.Background()
+
+	merchant := createVenueTestMerchant(db)
+	const lat, lon = 22.5, 114.0
+	// 纬度每 0.01 度约 1.112 公里
+	far := createVenueAt(t, db, merchant.ID, "3.3公里", lat+0.03, lon, models.VenueStatusActive)
+	near := createVenueAt(t, db, merchant.ID, "1.1公里", lat-0.01, lon, models.VenueStatusActive)
+	east := createVenueAt(t, db, merchant.ID, "2.1公里", lat, lon+0.02, models.VenueStatusActive)
+	createVenueAt(t, db, merchant.ID, "11公里", lat+0.1, lon, models.VenueStatusActive)
+	createVenueAt(t, db, merchant.ID, "已禁用", lat+0.001, lon, models.VenueStatusDisabled)
+	require.NoError(t, db.Create(&models.Venue{
+		MerchantID: merchant.ID, Name: "无经纬度", Type: models.VenueTypeMall,
+		Province: "广东省", City: "深圳市", District: "南山区", Address: "科技园路2号",
+		Status: models.VenueStatusActive,
+	}).Error)
+
+	t.Run("按距离升序", func(t *testing.T) {
+		venues, total, err := svc.FindNearby(ctx, lat, lon, 5, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		require.Len(t, venues, 3)
+		assert.Equal(t, near.ID, venues[0].ID)
+		assert.InDelta(t, 1.112, venues[0].DistanceKm, 0.01)
+		assert.Equal(t, east.ID, venues[1].ID)
+		assert.InDelta(t, 2.055, venues[1].DistanceKm, 0.01)
+		assert.Equal(t, far.ID, venues[2].ID)
+		assert.InDelta(t, 3.336, venues[2].DistanceKm, 0.01)
+	})
+
+	t.Run("分页", func(t *testing.T) {
+		venues, total, err := svc.FindNearby(ctx, lat, lon, 5, 2, 2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		require.Len(t, venues, 1)
+		assert.Equal(t, far.ID, venues[0].ID)
+
+		venues, total, err = svc.FindNearby(ctx, lat, lon, 5, 3, 2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		assert.Empty(t, venues)
+	})
+
+	t.Run("半径边界", func(t *testing.T) {
+		venues, total, err := svc.FindNearby(ctx, lat, lon, 2, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, venues, 1)
+		assert.Equal(t, near.ID, venues[0].ID)
+	})
+
+	t.Run("参数校验", func(t *testing.T) {
+		_, _, err := svc.FindNearby(ctx, 91, lon, 5, 1, 10)
+		assert.Error(t, err)
+		_, _, err = svc.FindNearby(ctx, lat, -181, 5, 1, 10)
+		assert.Error(t, err)
+		_, _, err = svc.FindNearby(ctx, lat, lon, 0, 1, 10)
+		assert.Error(t, err)
+		_, _, err = svc.FindNearby(ctx, lat, lon, MaxNearbyRadiusKm+1, 1, 10)
+		assert.Error(t, err)
+	})
+}
+
+func TestVenueService_FindNearby_Antimeridian(t *testing.T) {
+	db := setupVenueServiceTestDB(t)
+	svc := NewVenueService(db, repository.NewVenueRepository(db), repository.NewDeviceRepository(db))
+	ctx := context.Background()
+
+	merchant := createVenueTestMerchant(db)
+	west := createVenueAt(t, db, merchant.ID, "日界线西侧", 0, -179.99, models.VenueStatusActive)
+	east := createVenueAt(t, db, merchant.ID, "日界线东侧", 0, 179.995, models.VenueStatusActive)
+	createVenueAt(t, db, merchant.ID, "远处", 0, 179.5, models.VenueStatusActive)
+
+	venues, total, err := svc.FindNearby(ctx, 0, 179.999, 5, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, venues, 2)
+	assert.Equal(t, east.ID, venues[0].ID)
+	assert.Equal(t, west.ID, venues[1].ID)
 }
 
 func TestVenueService_ListVenuesByCity(t *testing.T) {
@@ -4,9 +4,14 @@ package distribution
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
@@ -48,7 +53,7 @@ func NewCommissionService(
 	}
 }
 
-// SetRates 设置佣金比例
+// SetRates 设置默认佣金比例，distributor_commission_config 中已配置的层级以配置为准
 func (s *CommissionService) SetRates(directRate, indirectRate float64, settleDelay int) {
 	s.directRate = directRate
 	s.indirectRate = indirectRate
@@ -99,8 +104,13 @@ func (s *CommissionService) Calculate(ctx context.Context, req *CalculateRequest
 			return nil
 		}
 
+		directRate, indirectRate, err := s.levelRates(ctx, tx)
+		if err != nil {
+			return err
+		}
+
 		// 计算直推佣金
-		directAmount := req.OrderAmount * s.directRate
+		directAmount := req.OrderAmount * directRate
 		if directAmount > 0 {
 			directCommission := &models.Commission{
 				DistributorID: directDistributor.ID,
@@ -108,7 +118,7 @@ func (s *CommissionService) Calculate(ctx context.Context, req *CalculateRequest
 				FromUserID:    req.UserID,
 				Type:          models.CommissionTypeDirect,
 				OrderAmount:   req.OrderAmount,
-				Rate:          s.directRate,
+				Rate:          directRate,
 				Amount:        directAmount,
 				Status:        models.CommissionStatusPending,
 			}
@@ -121,8 +131,14 @@ func (s *CommissionService) Calculate(ctx context.Context, req *CalculateRequest
 		if directDistributor.ParentID != nil {
 			indirectDistributor, err := s.findDistributorByID(ctx, tx, *directDistributor.ParentID)
 			if err == nil && indirectDistributor != nil {
+				// 上级分销商单独设置了间推比例时以其为准
+				rate := indirectRate
+				if indirectDistributor.SecondLevelRate > 0 {
+					rate = indirectDistributor.SecondLevelRate
+				}
+
 				// 计算间推佣金
-				indirectAmount := req.OrderAmount * s.indirectRate
+				indirectAmount := req.OrderAmount * rate
 				if indirectAmount > 0 {
 					indirectCommission := &models.Commission{
 						DistributorID: indirectDistributor.ID,
@@ -130,7 +146,7 @@ func (s *CommissionService) Calculate(ctx context.Context, req *CalculateRequest
 						FromUserID:    req.UserID,
 						Type:          models.CommissionTypeIndirect,
 						OrderAmount:   req.OrderAmount,
-						Rate:          s.indirectRate,
+						Rate:          rate,
 						Amount:        indirectAmount,
 						Status:        models.CommissionStatusPending,
 					}
@@ -158,6 +174,72 @@ func (s *CommissionService) Calculate(ctx context.Context, req *CalculateRequest
 	return response, nil
 }
 
+// levelRates 读取直推、间推佣金比例，未在 distributor_commission_config 中配置的层级使用默认比例
+func (s *CommissionService) levelRates(ctx context.Context, tx *gorm.DB) (directRate, indirectRate float64, err error) {
+	var configs []*models.DistributorCommissionConfig
+	if err := tx.WithContext(ctx).Find(&configs).Error; err != nil {
+		return 0, 0, err
+	}
+
+	directRate, indirectRate = s.directRate, s.indirectRate
+	for _, config := range configs {
+		switch config.Level {
+		case models.DistributorLevelDirect:
+			directRate = config.Rate
+		case models.DistributorLevelIndirect:
+			indirectRate = config.Rate
+		}
+	}
+	return directRate, indirectRate, nil
+}
+
+// OnOrderCompleted 订单完成后按邀请链生成佣金
+// 直推佣金归属消费用户的邀请人，间推佣金归属邀请人的上级分销商；
+// 订单未完成、实付金额为 0 或已生成过佣金时不处理
+func (s *CommissionService) OnOrderCompleted(ctx context.Context, orderID int64) (*CalculateResponse, error) {
+	var order models.Order
+	if err := s.db.WithContext(ctx).First(&order, orderID).Error; err != nil {
+		return nil, err
+	}
+	if order.Status != models.OrderStatusCompleted || order.ActualAmount <= 0 {
+		return &CalculateResponse{TotalAmount: 0}, nil
+	}
+
+	existing, err := s.commissionRepo.GetByOrderID(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return &CalculateResponse{TotalAmount: 0}, nil
+	}
+
+	response, err := s.Calculate(ctx, &CalculateRequest{
+		OrderID:     order.ID,
+		UserID:      order.UserID,
+		OrderAmount: order.ActualAmount,
+	})
+	if err != nil {
+		// 并发处理同一订单时由唯一索引兜底，后提交的一方视为已生成过佣金
+		if isDuplicateKeyError(err) {
+			return &CalculateResponse{TotalAmount: 0}, nil
+		}
+		return nil, err
+	}
+	return response, nil
+}
+
+// isDuplicateKeyError 判断是否为唯一索引冲突错误（PostgreSQL 23505 / SQLite UNIQUE 约束）
+func isDuplicateKeyError(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
 // findDistributorByUserID 根据用户ID查找已审核通过的分销商
 func (s *CommissionService) findDistributorByUserID(ctx context.Context, tx *gorm.DB, userID int64) (*models.Distributor, error) {
 	var distributor models.Distributor
@@ -183,26 +265,30 @@ func (s *CommissionService) findDistributorByID(ctx context.Context, tx *gorm.DB
 }
 
 // Settle 结算佣金（将待结算佣金转为可提现）
+// 在事务中锁定佣金记录后读取金额，避免与部分退款重算佣金并发时按旧金额入账
 func (s *CommissionService) Settle(ctx context.Context, commissionID int64) error {
-	commission, err := s.commissionRepo.GetByID(ctx, commissionID)
-	if err != nil {
-		return err
-	}
-
-	if commission.Status != models.CommissionStatusPending {
-		return errors.New("该佣金已处理")
-	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var commission models.Commission
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&commission, commissionID).Error; err != nil {
+			return err
+		}
+		if commission.Status != models.CommissionStatusPending {
+			return errors.New("该佣金已处理")
+		}
 
-	return s.db.Transaction(func(tx *gorm.DB) error {
-		// 更新佣金状态
+		// 更新佣金状态（条件更新，不支持行锁的数据库上同样防止重复结算）
 		now := time.Now()
-		if err := tx.Model(&models.Commission{}).
-			Where("id = ?", commissionID).
+		res := tx.Model(&models.Commission{}).
+			Where("id = ? AND status = ? AND amount = ?", commissionID, models.CommissionStatusPending, commission.Amount).
 			Updates(map[string]interface{}{
 				"status":     models.CommissionStatusSettled,
 				"settled_at": now,
-			}).Error; err != nil {
-			return err
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("该佣金已处理")
 		}
 
 		// 增加分销商的可用佣金
@@ -224,10 +310,10 @@ func (s *CommissionService) SettlePendingCommissions(ctx context.Context) (int64
 	// 计算结算截止时间
 	settleTime := time.Now().AddDate(0, 0, -s.settleDelay)
 
-	// 获取需要结算的佣金
+	// 获取需要结算的佣金（已锁定到结算单的佣金由结算单处理）
 	var commissions []*models.Commission
 	if err := s.db.WithContext(ctx).
-		Where("status = ? AND created_at < ?", models.CommissionStatusPending, settleTime).
+		Where("status = ? AND created_at < ? AND settlement_id IS NULL", models.CommissionStatusPending, settleTime).
 		Find(&commissions).Error; err != nil {
 		return 0, err
 	}
@@ -248,18 +334,19 @@ func (s *CommissionService) SettlePendingCommissions(ctx context.Context) (int64
 
 // CancelByOrderID 取消订单相关的佣金（退款时调用）
 func (s *CommissionService) CancelByOrderID(ctx context.Context, orderID int64) error {
-	// 获取订单相关的佣金记录
-	commissions, err := s.commissionRepo.GetByOrderID(ctx, orderID)
-	if err != nil {
-		return err
-	}
-
-	if len(commissions) == 0 {
-		return nil
-	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 在事务中锁定订单相关的佣金记录
+		var commissions []*models.Commission
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ?", orderID).
+			Find(&commissions).Error; err != nil {
+			return err
+		}
 
-	return s.db.Transaction(func(tx *gorm.DB) error {
 		for _, commission := range commissions {
+			if commission.Status == models.CommissionStatusCancelled {
+				continue
+			}
 			if commission.Status == models.CommissionStatusSettled {
 				// 已结算的佣金，需要从分销商账户扣除
 				if err := tx.Model(&models.Distributor{}).
@@ -283,6 +370,109 @@ func (s *CommissionService) CancelByOrderID(ctx context.Context, orderID int64)
 	})
 }
 
+// RecalculateCommission 订单实付金额因部分退款变化时，按原佣金比例重新计算订单相关佣金
+// 待结算佣金直接调整金额；已结算佣金的差额从分销商可用佣金中扣回，可用佣金不足时最多扣至 0，
+// 已提现部分无法扣回，记录一条负数的佣金追回流水供对账，只影响之后的提现
+func (s *CommissionService) RecalculateCommission(ctx context.Context, orderID int64, newActualAmount float64) error {
+	if newActualAmount < 0 {
+		return errors.New("订单金额无效")
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 在事务中锁定订单佣金后再读取，避免与佣金结算并发时按过期的状态或金额处理
+		var commissions []*models.Commission
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ?", orderID).
+			Find(&commissions).Error; err != nil {
+			return err
+		}
+
+		for _, commission := range commissions {
+			if commission.Status == models.CommissionStatusCancelled {
+				continue
+			}
+			if newActualAmount > commission.OrderAmount {
+				return errors.New("重新计算的订单金额不能大于原订单金额")
+			}
+
+			newAmount := math.Round(newActualAmount*commission.Rate*100) / 100
+			delta := math.Round((commission.Amount-newAmount)*100) / 100
+
+			updates := map[string]interface{}{
+				"order_amount": newActualAmount,
+				"amount":       newAmount,
+			}
+			if newAmount == 0 && commission.Status == models.CommissionStatusPending {
+				updates["status"] = models.CommissionStatusCancelled
+			}
+			res := tx.Model(&models.Commission{}).
+				Where("id = ? AND status = ? AND amount = ?", commission.ID, commission.Status, commission.Amount).
+				Updates(updates)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return errors.New("佣金状态已变更，请重试")
+			}
+
+			if commission.Status == models.CommissionStatusSettled && delta > 0 {
+				if err := s.clawbackSettled(ctx, tx, commission, delta); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// clawbackSettled 扣回已结算佣金的差额
+func (s *CommissionService) clawbackSettled(ctx context.Context, tx *gorm.DB, commission *models.Commission, delta float64) error {
+	var distributor models.Distributor
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&distributor, commission.DistributorID).Error; err != nil {
+		return err
+	}
+
+	// 条件更新保证可用佣金不被扣成负数，期间被并发提现时放弃本次扣回
+	deduct := math.Min(delta, distributor.AvailableCommission)
+	res := tx.Model(&models.Distributor{}).
+		Where("id = ? AND available_commission >= ?", distributor.ID, deduct).
+		Updates(map[string]interface{}{
+			"total_commission":     gorm.Expr("total_commission - ?", delta),
+			"available_commission": gorm.Expr("available_commission - ?", deduct),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("分销商可用佣金已变更，请重试")
+	}
+
+	shortfall := math.Round((delta-deduct)*100) / 100
+	if shortfall <= 0 {
+		return nil
+	}
+
+	var balance float64
+	var wallet models.UserWallet
+	err := tx.WithContext(ctx).Where("user_id = ?", distributor.UserID).First(&wallet).Error
+	if err == nil {
+		balance = wallet.Balance
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	remark := fmt.Sprintf("订单退款佣金追回（佣金ID %d，已提现部分）", commission.ID)
+	return tx.Create(&models.WalletTransaction{
+		UserID:        distributor.UserID,
+		Type:          models.WalletTxTypeCommissionClawback,
+		Amount:        -shortfall,
+		BalanceBefore: balance,
+		BalanceAfter:  balance,
+		Remark:        &remark,
+	}).Error
+}
+
 // GetByDistributorID 获取分销商的佣金记录
 func (s *CommissionService) GetByDistributorID(ctx context.Context, distributorID int64, offset, limit int) ([]*models.Commission, int64, error) {
 	return s.commissionRepo.GetByDistributorID(ctx, distributorID, offset, limit)
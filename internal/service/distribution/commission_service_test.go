@@ -37,6 +37,8 @@ func setupCommissionTestDB(t *testing.T) *gorm.DB {
 		&models.Order{},
 		&models.Distributor{},
 		&models.Commission{},
+		&models.DistributorCommissionConfig{},
+		&models.WalletTransaction{},
 	)
 	require.NoError(t, err)
 
@@ -291,6 +293,53 @@ func TestCommissionService_Calculate(t *testing.T) {
 		assert.Len(t, commissions, 2)
 	})
 
+	t.Run("上级分销商设置间推比例_按其比例计算间推佣金", func(t *testing.T) {
+		db := setupCommissionTestDB(t)
+		commissionRepo := repository.NewCommissionRepository(db)
+		distributorRepo := repository.NewDistributorRepository(db)
+		userRepo := repository.NewUserRepository(db)
+		svc := NewCommissionService(commissionRepo, distributorRepo, userRepo, db)
+		ctx := context.Background()
+
+		// 两级分销链：上级分销商单独设置 3% 间推比例
+		parentUser := createTestUser(db, nil)
+		parentDistributor := createTestDistributor(db, parentUser.ID, nil, models.DistributorStatusApproved)
+		require.NoError(t, db.Model(parentDistributor).Update("second_level_rate", 0.03).Error)
+
+		childUser := createTestUser(db, &parentUser.ID)
+		childDistributor := createTestDistributor(db, childUser.ID, &parentDistributor.ID, models.DistributorStatusApproved)
+
+		buyer := createTestUser(db, &childUser.ID)
+		order := createTestOrder(db, buyer.ID, 200.0)
+
+		resp, err := svc.Calculate(ctx, &CalculateRequest{
+			OrderID:     order.ID,
+			UserID:      buyer.ID,
+			OrderAmount: order.ActualAmount,
+		})
+		require.NoError(t, err)
+
+		// 直推佣金 200 * 10% = 20，间推佣金 200 * 3% = 6
+		require.NotNil(t, resp.DirectCommission)
+		assert.Equal(t, childDistributor.ID, resp.DirectCommission.DistributorID)
+		assert.InDelta(t, 20.0, resp.DirectCommission.Amount, 0.001)
+		require.NotNil(t, resp.IndirectCommission)
+		assert.Equal(t, parentDistributor.ID, resp.IndirectCommission.DistributorID)
+		assert.Equal(t, 0.03, resp.IndirectCommission.Rate)
+		assert.InDelta(t, 6.0, resp.IndirectCommission.Amount, 0.001)
+		assert.InDelta(t, 26.0, resp.TotalAmount, 0.001)
+
+		var saved []*models.Commission
+		require.NoError(t, db.Where("order_id = ?", order.ID).Order("id ASC").Find(&saved).Error)
+		require.Len(t, saved, 2)
+		assert.Equal(t, models.CommissionTypeDirect, saved[0].Type)
+		assert.Equal(t, childDistributor.ID, saved[0].DistributorID)
+		assert.InDelta(t, 20.0, saved[0].Amount, 0.001)
+		assert.Equal(t, models.CommissionTypeIndirect, saved[1].Type)
+		assert.Equal(t, parentDistributor.ID, saved[1].DistributorID)
+		assert.InDelta(t, 6.0, saved[1].Amount, 0.001)
+	})
+
 	t.Run("订单金额无效_返回错误", func(t *testing.T) {
 		db := setupCommissionTestDB(t)
 		commissionRepo := repository.NewCommissionRepository(db)
@@ -697,3 +746,274 @@ func TestCommissionService_SettlePendingCommissions(t *testing.T) {
 	db.First(&updated, commission.ID)
 	assert.Equal(t, models.CommissionStatusSettled, updated.Status)
 }
+
+func TestCommissionService_RecalculateCommission(t *testing.T) {
+	// setup 创建已结算直推佣金（订单 100 元，佣金 10 元）和待结算间推佣金（5 元）
+	setup := func(t *testing.T, available, withdrawn float64) (*gorm.DB, *CommissionService, *models.Distributor, *models.Commission, *models.Commission) {
+		db := setupCommissionTestDB(t)
+		svc := NewCommissionService(repository.NewCommissionRepository(db), repository.NewDistributorRepository(db), repository.NewUserRepository(db), db)
+
+		referrer := createTestUser(db, nil)
+		distributor := &models.Distributor{
+			UserID:              referrer.ID,
+			Level:               models.DistributorLevelDirect,
+			InviteCode:          "INV_RECALC",
+			TotalCommission:     available + withdrawn,
+			AvailableCommission: available,
+			WithdrawnCommission: withdrawn,
+			Status:              models.DistributorStatusApproved,
+		}
+		require.NoError(t, db.Create(distributor).Error)
+
+		now := time.Now()
+		settled := &models.Commission{
+			DistributorID: distributor.ID,
+			OrderID:       1,
+			FromUserID:    2,
+			Type:          models.CommissionTypeDirect,
+			OrderAmount:   100.0,
+			Rate:          DefaultDirectRate,
+			Amount:        10.0,
+			Status:        models.CommissionStatusSettled,
+			SettledAt:     &now,
+		}
+		require.NoError(t, db.Create(settled).Error)
+
+		pending := &models.Commission{
+			DistributorID: distributor.ID + 100,
+			OrderID:       1,
+			FromUserID:    2,
+			Type:          models.CommissionTypeIndirect,
+			OrderAmount:   100.0,
+			Rate:          DefaultIndirectRate,
+			Amount:        5.0,
+			Status:        models.CommissionStatusPending,
+		}
+		require.NoError(t, db.Create(pending).Error)
+
+		return db, svc, distributor, settled, pending
+	}
+
+	t.Run("部分退款_按比例扣减可用佣金", func(t *testing.T) {
+		db, svc, distributor, settled, pending := setup(t, 50.0, 0)
+
+		require.NoError(t, svc.RecalculateCommission(context.Background(), 1, 60.0))
+
+		var updated models.Commission
+		db.First(&updated, settled.ID)
+		assert.Equal(t, 6.0, updated.Amount)
+		assert.Equal(t, 60.0, updated.OrderAmount)
+		assert.Equal(t, models.CommissionStatusSettled, updated.Status)
+
+		var updatedPending models.Commission
+		db.First(&updatedPending, pending.ID)
+		assert.Equal(t, 3.0, updatedPending.Amount)
+		assert.Equal(t, models.CommissionStatusPending, updatedPending.Status)
+
+		var updatedDistributor models.Distributor
+		db.First(&updatedDistributor, distributor.ID)
+		assert.Equal(t, 46.0, updatedDistributor.TotalCommission)
+		assert.Equal(t, 46.0, updatedDistributor.AvailableCommission)
+
+		var count int64
+		db.Model(&models.WalletTransaction{}).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("全额退款_待结算佣金失效", func(t *testing.T) {
+		db, svc, distributor, settled, pending := setup(t, 50.0, 0)
+
+		require.NoError(t, svc.RecalculateCommission(context.Background(), 1, 0))
+
+		var updated models.Commission
+		db.First(&updated, settled.ID)
+		assert.Equal(t, 0.0, updated.Amount)
+
+		var updatedPending models.Commission
+		db.First(&updatedPending, pending.ID)
+		assert.Equal(t, 0.0, updatedPending.Amount)
+		assert.Equal(t, models.CommissionStatusCancelled, updatedPending.Status)
+
+		var updatedDistributor models.Distributor
+		db.First(&updatedDistributor, distributor.ID)
+		assert.Equal(t, 40.0, updatedDistributor.TotalCommission)
+		assert.Equal(t, 40.0, updatedDistributor.AvailableCommission)
+	})
+
+	t.Run("佣金已提现_可用佣金扣至0并记录追回流水", func(t *testing.T) {
+		db, svc, distributor, _, _ := setup(t, 3.0, 10.0)
+		require.NoError(t, db.Create(&models.UserWallet{UserID: distributor.UserID, Balance: 20.0}).Error)
+
+		require.NoError(t, svc.RecalculateCommission(context.Background(), 1, 0))
+
+		var updatedDistributor models.Distributor
+		db.First(&updatedDistributor, distributor.ID)
+		assert.Equal(t, 0.0, updatedDistributor.AvailableCommission)
+		assert.Equal(t, 3.0, updatedDistributor.TotalCommission)
+		assert.Equal(t, 10.0, updatedDistributor.WithdrawnCommission)
+
+		var tx models.WalletTransaction
+		require.NoError(t, db.Where("user_id = ?", distributor.UserID).First(&tx).Error)
+		assert.Equal(t, models.WalletTxTypeCommissionClawback, tx.Type)
+		assert.Equal(t, -7.0, tx.Amount)
+		assert.Equal(t, 20.0, tx.BalanceBefore)
+		assert.Equal(t, 20.0, tx.BalanceAfter)
+
+		// 钱包余额不受影响
+		var wallet models.UserWallet
+		db.Where("user_id = ?", distributor.UserID).First(&wallet)
+		assert.Equal(t, 20.0, wallet.Balance)
+	})
+
+	t.Run("已失效佣金不处理", func(t *testing.T) {
+		db, svc, distributor, settled, _ := setup(t, 50.0, 0)
+		db.Model(&models.Commission{}).Where("id = ?", settled.ID).Update("status", models.CommissionStatusCancelled)
+
+		require.NoError(t, svc.RecalculateCommission(context.Background(), 1, 50.0))
+
+		var updated models.Commission
+		db.First(&updated, settled.ID)
+		assert.Equal(t, 10.0, updated.Amount)
+
+		var updatedDistributor models.Distributor
+		db.First(&updatedDistributor, distributor.ID)
+		assert.Equal(t, 50.0, updatedDistributor.AvailableCommission)
+	})
+
+	t.Run("金额无效", func(t *testing.T) {
+		_, svc, _, _, _ := setup(t, 50.0, 0)
+
+		assert.Error(t, svc.RecalculateCommission(context.Background(), 1, -1))
+		assert.Error(t, svc.RecalculateCommission(context.Background(), 1, 120.0))
+	})
+}
+
+func TestCommissionService_OnOrderCompleted(t *testing.T) {
+	ctx := context.Background()
+
+	newService := func(db *gorm.DB) *CommissionService {
+		return NewCommissionService(
+			repository.NewCommissionRepository(db),
+			repository.NewDistributorRepository(db),
+			repository.NewUserRepository(db),
+			db,
+		)
+	}
+
+	t.Run("两级邀请链按层级配置比例生成佣金", func(t *testing.T) {
+		db := setupCommissionTestDB(t)
+		svc := newService(db)
+		require.NoError(t, db.Create(&[]models.DistributorCommissionConfig{
+			{Level: models.DistributorLevelDirect, Rate: 0.2},
+			{Level: models.DistributorLevelIndirect, Rate: 0.08},
+		}).Error)
+
+		grandUser := createTestUser(db, nil)
+		grand := createTestDistributor(db, grandUser.ID, nil, models.DistributorStatusApproved)
+		parentUser := createTestUser(db, &grandUser.ID)
+		parent := createTestDistributor(db, parentUser.ID, &grand.ID, models.DistributorStatusApproved)
+		buyer := createTestUser(db, &parentUser.ID)
+		order := createTestOrder(db, buyer.ID, 100.0)
+
+		resp, err := svc.OnOrderCompleted(ctx, order.ID)
+		require.NoError(t, err)
+		require.NotNil(t, resp.DirectCommission)
+		require.NotNil(t, resp.IndirectCommission)
+		assert.Equal(t, parent.ID, resp.DirectCommission.DistributorID)
+		assert.InDelta(t, 0.2, resp.DirectCommission.Rate, 0.0001)
+		assert.InDelta(t, 20.0, resp.DirectCommission.Amount, 0.001)
+		assert.Equal(t, grand.ID, resp.IndirectCommission.DistributorID)
+		assert.InDelta(t, 0.08, resp.IndirectCommission.Rate, 0.0001)
+		assert.InDelta(t, 8.0, resp.IndirectCommission.Amount, 0.001)
+
+		// 重复触发不会重复生成
+		_, err = svc.OnOrderCompleted(ctx, order.ID)
+		require.NoError(t, err)
+		var count int64
+		db.Model(&models.Commission{}).Where("order_id = ?", order.ID).Count(&count)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("未配置层级使用默认比例", func(t *testing.T) {
+		db := setupCommissionTestDB(t)
+		svc := newService(db)
+
+		parentUser := createTestUser(db, nil)
+		createTestDistributor(db, parentUser.ID, nil, models.DistributorStatusApproved)
+		buyer := createTestUser(db, &parentUser.ID)
+		order := createTestOrder(db, buyer.ID, 100.0)
+
+		resp, err := svc.OnOrderCompleted(ctx, order.ID)
+		require.NoError(t, err)
+		require.NotNil(t, resp.DirectCommission)
+		assert.InDelta(t, 0.1, resp.DirectCommission.Rate, 0.0001)
+		assert.Nil(t, resp.IndirectCommission)
+	})
+
+	t.Run("并发生成触发唯一索引冲突视为已处理", func(t *testing.T) {
+		db := setupCommissionTestDB(t)
+		svc := newService(db)
+
+		parentUser := createTestUser(db, nil)
+		parent := createTestDistributor(db, parentUser.ID, nil, models.DistributorStatusApproved)
+		buyer := createTestUser(db, &parentUser.ID)
+		order := createTestOrder(db, buyer.ID, 100.0)
+
+		// 模拟并发请求：已通过重复检查后，另一请求抢先写入了同一订单的直推佣金
+		inserted := false
+		require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:concurrent_commission", func(tx *gorm.DB) {
+			if inserted || tx.Statement.Table != "users" {
+				return
+			}
+			inserted = true
+			tx.Session(&gorm.Session{NewDB: true}).Exec(
+				"INSERT INTO commissions (distributor_id, order_id, from_user_id, type, order_amount, rate, amount, status, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				parent.ID, order.ID, buyer.ID, models.CommissionTypeDirect, 100.0, 0.1, 10.0, models.CommissionStatusPending, time.Now(),
+			)
+		}))
+
+		resp, err := svc.OnOrderCompleted(ctx, order.ID)
+		require.NoError(t, err)
+		assert.True(t, inserted)
+		assert.Equal(t, 0.0, resp.TotalAmount)
+
+		var count int64
+		db.Model(&models.Commission{}).Where("order_id = ?", order.ID).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("用户无邀请人_不生成佣金", func(t *testing.T) {
+		db := setupCommissionTestDB(t)
+		svc := newService(db)
+
+		buyer := createTestUser(db, nil)
+		order := createTestOrder(db, buyer.ID, 100.0)
+
+		resp, err := svc.OnOrderCompleted(ctx, order.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, resp.TotalAmount)
+
+		var count int64
+		db.Model(&models.Commission{}).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("订单未完成_不生成佣金", func(t *testing.T) {
+		db := setupCommissionTestDB(t)
+		svc := newService(db)
+
+		parentUser := createTestUser(db, nil)
+		createTestDistributor(db, parentUser.ID, nil, models.DistributorStatusApproved)
+		buyer := createTestUser(db, &parentUser.ID)
+		order := createTestOrder(db, buyer.ID, 100.0)
+		db.Model(order).Update("status", models.OrderStatusPaid)
+
+		resp, err := svc.OnOrderCompleted(ctx, order.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, resp.TotalAmount)
+
+		var count int64
+		db.Model(&models.Commission{}).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+}
@@ -129,6 +129,63 @@ func (s *DistributorService) Apply(ctx context.Context, req *ApplyRequest) (*App
 	}, nil
 }
 
+// BindInviter 通过分销商邀请码为用户绑定邀请人
+// 邀请人须为已审核通过的分销商；不能绑定自己或自己下级的邀请码，已绑定邀请人的用户不能重新绑定
+func (s *DistributorService) BindInviter(ctx context.Context, userID int64, inviteCode string) error {
+	inviteCode = strings.TrimSpace(inviteCode)
+	if inviteCode == "" {
+		return errors.New("邀请码不能为空")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("用户不存在")
+		}
+		return err
+	}
+	if user.ReferrerID != nil {
+		return errors.New("已绑定邀请人，不能重复绑定")
+	}
+
+	inviter, err := s.distributorRepo.GetByInviteCode(ctx, inviteCode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("邀请码无效")
+		}
+		return err
+	}
+	if inviter.UserID == userID {
+		return errors.New("不能绑定自己的邀请码")
+	}
+	if inviter.Status != models.DistributorStatusApproved {
+		return errors.New("邀请人尚未通过审核")
+	}
+
+	// 邀请人的上级是当前用户时，绑定后会形成循环
+	if inviter.ParentID != nil {
+		parent, err := s.distributorRepo.GetByID(ctx, *inviter.ParentID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if parent != nil && parent.UserID == userID {
+			return errors.New("不能绑定自己下级的邀请码")
+		}
+	}
+
+	// 条件更新防止并发重复绑定
+	result := s.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND referrer_id IS NULL", userID).
+		Update("referrer_id", inviter.UserID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("已绑定邀请人，不能重复绑定")
+	}
+	return nil
+}
+
 // generateInviteCode 生成唯一邀请码
 func (s *DistributorService) generateInviteCode(ctx context.Context) (string, error) {
 	for i := 0; i < 10; i++ {
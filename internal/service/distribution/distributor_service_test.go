@@ -861,3 +861,101 @@ func TestDistributorService_GetTopDistributors(t *testing.T) {
 		assert.GreaterOrEqual(t, topList[0].TotalCommission, topList[1].TotalCommission)
 	}
 }
+
+func TestDistributorService_BindInviter(t *testing.T) {
+	ctx := context.Background()
+
+	newService := func(db *gorm.DB) *DistributorService {
+		return NewDistributorService(repository.NewDistributorRepository(db), repository.NewUserRepository(db), db)
+	}
+
+	t.Run("绑定已通过分销商的邀请码", func(t *testing.T) {
+		db := setupDistributorTestDB(t)
+		svc := newService(db)
+
+		inviterUser := createDistributorTestUser(db, nil)
+		inviter := createTestDistributor(db, inviterUser.ID, nil, models.DistributorStatusApproved)
+		user := createDistributorTestUser(db, nil)
+
+		require.NoError(t, svc.BindInviter(ctx, user.ID, " "+inviter.InviteCode+" "))
+
+		var updated models.User
+		require.NoError(t, db.First(&updated, user.ID).Error)
+		require.NotNil(t, updated.ReferrerID)
+		assert.Equal(t, inviterUser.ID, *updated.ReferrerID)
+	})
+
+	t.Run("不能重复绑定", func(t *testing.T) {
+		db := setupDistributorTestDB(t)
+		svc := newService(db)
+
+		firstUser := createDistributorTestUser(db, nil)
+		first := createTestDistributor(db, firstUser.ID, nil, models.DistributorStatusApproved)
+		secondUser := createDistributorTestUser(db, nil)
+		second := createTestDistributor(db, secondUser.ID, nil, models.DistributorStatusApproved)
+		user := createDistributorTestUser(db, nil)
+
+		require.NoError(t, svc.BindInviter(ctx, user.ID, first.InviteCode))
+		err := svc.BindInviter(ctx, user.ID, second.InviteCode)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "不能重复绑定")
+
+		var updated models.User
+		require.NoError(t, db.First(&updated, user.ID).Error)
+		assert.Equal(t, firstUser.ID, *updated.ReferrerID)
+	})
+
+	t.Run("不能绑定自己的邀请码", func(t *testing.T) {
+		db := setupDistributorTestDB(t)
+		svc := newService(db)
+
+		user := createDistributorTestUser(db, nil)
+		distributor := createTestDistributor(db, user.ID, nil, models.DistributorStatusApproved)
+
+		err := svc.BindInviter(ctx, user.ID, distributor.InviteCode)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "不能绑定自己的邀请码")
+	})
+
+	t.Run("不能绑定自己下级的邀请码", func(t *testing.T) {
+		db := setupDistributorTestDB(t)
+		svc := newService(db)
+
+		user := createDistributorTestUser(db, nil)
+		parent := createTestDistributor(db, user.ID, nil, models.DistributorStatusApproved)
+		childUser := createDistributorTestUser(db, &user.ID)
+		child := createTestDistributor(db, childUser.ID, &parent.ID, models.DistributorStatusApproved)
+
+		err := svc.BindInviter(ctx, user.ID, child.InviteCode)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "下级")
+	})
+
+	t.Run("邀请人未通过审核", func(t *testing.T) {
+		db := setupDistributorTestDB(t)
+		svc := newService(db)
+
+		inviterUser := createDistributorTestUser(db, nil)
+		inviter := createTestDistributor(db, inviterUser.ID, nil, models.DistributorStatusPending)
+		user := createDistributorTestUser(db, nil)
+
+		err := svc.BindInviter(ctx, user.ID, inviter.InviteCode)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "尚未通过审核")
+
+		var updated models.User
+		require.NoError(t, db.First(&updated, user.ID).Error)
+		assert.Nil(t, updated.ReferrerID)
+	})
+
+	t.Run("邀请码无效", func(t *testing.T) {
+		db := setupDistributorTestDB(t)
+		svc := newService(db)
+
+		user := createDistributorTestUser(db, nil)
+
+		err := svc.BindInviter(ctx, user.ID, "NOTEXIST")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "邀请码无效")
+	})
+}
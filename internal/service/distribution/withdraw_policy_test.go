@@ -0,0 +1,153 @@
+package distribution
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+func setupWithdrawPolicyTest(t *testing.T, policies ...*models.WithdrawalPolicy) (*gorm.DB, *WithdrawService) {
+	db := setupWithdrawTestDB(t)
+	withdrawalRepo := repository.NewWithdrawalRepository(db)
+	for _, policy := range policies {
+		require.NoError(t, withdrawalRepo.SavePolicy(context.Background(), db, policy))
+	}
+	svc := NewWithdrawService(withdrawalRepo, repository.NewDistributorRepository(db), repository.NewUserRepository(db), db)
+	return db, svc
+}
+
+func applyWithdraw(svc *WithdrawService, userID int64, withdrawalType string, amount float64) (*WithdrawResponse, error) {
+	return svc.Apply(context.Background(), &WithdrawRequest{
+		UserID:      userID,
+		Type:        withdrawalType,
+		Amount:      amount,
+		WithdrawTo:  models.WithdrawToWechat,
+		AccountInfo: `{}`,
+	})
+}
+
+func assertAppErrorCode(t *testing.T, expected *appErrors.AppError, err error) {
+	t.Helper()
+	require.Error(t, err)
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok, "unexpected error: %v", err)
+	assert.Equal(t, expected.Code, appErr.Code)
+}
+
+func TestWithdrawService_Apply_FeeRounding(t *testing.T) {
+	_, svc := setupWithdrawPolicyTest(t, &models.WithdrawalPolicy{
+		Type:       models.WithdrawalTypeCommission,
+		MinAmount:  1,
+		FeeFixed:   1,
+		FeePercent: 0.0035,
+	})
+
+	tests := []struct {
+		amount       float64
+		fee          float64
+		actualAmount float64
+	}{
+		{33.33, 1.12, 32.21},   // 1 + 0.116655
+		{10, 1.04, 8.96},       // 1 + 0.035 四舍五入
+		{123.45, 1.43, 122.02}, // 1 + 0.432075
+	}
+	for _, tt := range tests {
+		user := createWithdrawTestUser(svc.db)
+		createWithdrawTestDistributor(svc.db, user.ID, 1000)
+
+		resp, err := applyWithdraw(svc, user.ID, models.WithdrawalTypeCommission, tt.amount)
+		require.NoError(t, err)
+		assert.Equal(t, tt.fee, resp.Fee, "amount %.2f", tt.amount)
+		assert.Equal(t, tt.actualAmount, resp.ActualAmount, "amount %.2f", tt.amount)
+		assert.Equal(t, tt.fee, resp.Withdrawal.Fee)
+		assert.Equal(t, 0.0035, resp.Withdrawal.PolicySnapshot["fee_percent"])
+	}
+}
+
+func TestWithdrawService_Apply_BelowMinimum(t *testing.T) {
+	_, svc := setupWithdrawPolicyTest(t, &models.WithdrawalPolicy{
+		Type:      models.WithdrawalTypeWallet,
+		MinAmount: 20,
+		FeeFixed:  5,
+	})
+	user := createWithdrawTestUser(svc.db)
+	createWithdrawTestWallet(svc.db, user.ID, 100)
+
+	_, err := applyWithdraw(svc, user.ID, models.WithdrawalTypeWallet, 19.99)
+	assertAppErrorCode(t, appErrors.ErrWithdrawBelowMinimum, err)
+	assert.Contains(t, err.Error(), "最低提现金额为20.00元")
+}
+
+func TestWithdrawService_Apply_FeeExceedsAmount(t *testing.T) {
+	_, svc := setupWithdrawPolicyTest(t, &models.WithdrawalPolicy{
+		Type:      models.WithdrawalTypeWallet,
+		MinAmount: 1,
+		FeeFixed:  5,
+	})
+	user := createWithdrawTestUser(svc.db)
+	createWithdrawTestWallet(svc.db, user.ID, 100)
+
+	_, err := applyWithdraw(svc, user.ID, models.WithdrawalTypeWallet, 5)
+	assertAppErrorCode(t, appErrors.ErrWithdrawBelowMinimum, err)
+}
+
+func TestWithdrawService_Apply_DailyLimitsPerType(t *testing.T) {
+	db, svc := setupWithdrawPolicyTest(t,
+		&models.WithdrawalPolicy{Type: models.WithdrawalTypeWallet, MinAmount: 1, DailyCountLimit: 1},
+		&models.WithdrawalPolicy{Type: models.WithdrawalTypeCommission, MinAmount: 1, DailyCountLimit: 2, DailyAmountLimit: 100},
+	)
+	user := createWithdrawTestUser(db)
+	createWithdrawTestWallet(db, user.ID, 500)
+	createWithdrawTestDistributor(db, user.ID, 500)
+
+	// 钱包提现次数用完不影响佣金提现
+	_, err := applyWithdraw(svc, user.ID, models.WithdrawalTypeWallet, 30)
+	require.NoError(t, err)
+	_, err = applyWithdraw(svc, user.ID, models.WithdrawalTypeWallet, 30)
+	assertAppErrorCode(t, appErrors.ErrWithdrawDailyCountExceeded, err)
+
+	_, err = applyWithdraw(svc, user.ID, models.WithdrawalTypeCommission, 60)
+	require.NoError(t, err)
+
+	// 佣金当日已提 60，再提 50 超过 100 元上限
+	_, err = applyWithdraw(svc, user.ID, models.WithdrawalTypeCommission, 50)
+	assertAppErrorCode(t, appErrors.ErrWithdrawDailyAmountExceeded, err)
+	assert.Contains(t, err.Error(), "今日还可提现40.00元")
+
+	_, err = applyWithdraw(svc, user.ID, models.WithdrawalTypeCommission, 40)
+	require.NoError(t, err)
+	_, err = applyWithdraw(svc, user.ID, models.WithdrawalTypeCommission, 1)
+	assertAppErrorCode(t, appErrors.ErrWithdrawDailyCountExceeded, err)
+
+	// 超限的申请未冻结余额
+	var wallet models.UserWallet
+	require.NoError(t, db.Where("user_id = ?", user.ID).First(&wallet).Error)
+	assert.Equal(t, 470.0, wallet.Balance)
+	assert.Equal(t, 30.0, wallet.FrozenBalance)
+	var distributor models.Distributor
+	require.NoError(t, db.Where("user_id = ?", user.ID).First(&distributor).Error)
+	assert.Equal(t, 400.0, distributor.AvailableCommission)
+	assert.Equal(t, 100.0, distributor.FrozenCommission)
+}
+
+func TestWithdrawService_Apply_RejectedNotCounted(t *testing.T) {
+	db, svc := setupWithdrawPolicyTest(t,
+		&models.WithdrawalPolicy{Type: models.WithdrawalTypeCommission, MinAmount: 1, DailyCountLimit: 1},
+	)
+	user := createWithdrawTestUser(db)
+	createWithdrawTestDistributor(db, user.ID, 500)
+
+	resp, err := applyWithdraw(svc, user.ID, models.WithdrawalTypeCommission, 30)
+	require.NoError(t, err)
+	require.NoError(t, svc.Reject(context.Background(), resp.Withdrawal.ID, 1, "信息有误"))
+
+	_, err = applyWithdraw(svc, user.ID, models.WithdrawalTypeCommission, 30)
+	require.NoError(t, err)
+}
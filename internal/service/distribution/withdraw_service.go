@@ -5,10 +5,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"gorm.io/gorm"
 
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 )
@@ -17,7 +19,7 @@ import (
 const (
 	DefaultMinWithdraw  = 10.0  // 默认最低提现金额
 	DefaultWithdrawFee  = 0.006 // 默认提现手续费比例 0.6%
-	MaxWithdrawPerDay   = 3     // 每日最大提现次数
+	MaxWithdrawPerDay   = 3     // 默认每日最大提现次数
 	MaxPendingWithdraw  = 5     // 最大待处理提现数
 )
 
@@ -48,7 +50,7 @@ func NewWithdrawService(
 	}
 }
 
-// SetConfig 设置提现配置
+// SetConfig 设置提现配置，提现类型未配置提现规则时使用
 func (s *WithdrawService) SetConfig(minWithdraw, withdrawFee float64) {
 	s.minWithdraw = minWithdraw
 	s.withdrawFee = withdrawFee
@@ -85,9 +87,18 @@ func (s *WithdrawService) Apply(ctx context.Context, req *WithdrawRequest) (*Wit
 		return nil, errors.New("无效的提现方式")
 	}
 
-	// 验证最低提现金额
-	if req.Amount < s.minWithdraw {
-		return nil, fmt.Errorf("最低提现金额为%.2f元", s.minWithdraw)
+	// 按提现规则验证最低金额并计算手续费，忽略客户端传入的手续费
+	policy, err := s.GetPolicy(ctx, req.Type)
+	if err != nil {
+		return nil, err
+	}
+	if req.Amount < policy.MinAmount {
+		return nil, appErrors.ErrWithdrawBelowMinimum.WithMessage(fmt.Sprintf("最低提现金额为%.2f元", policy.MinAmount))
+	}
+	fee := policy.CalculateFee(req.Amount)
+	actualAmount := math.Round((req.Amount-fee)*100) / 100
+	if actualAmount <= 0 {
+		return nil, appErrors.ErrWithdrawBelowMinimum.WithMessage("提现金额不足以支付手续费")
 	}
 
 	// 检查待处理提现数量
@@ -129,10 +140,6 @@ func (s *WithdrawService) Apply(ctx context.Context, req *WithdrawRequest) (*Wit
 		return nil, fmt.Errorf("可提现余额不足，当前可提现: %.2f元", availableBalance)
 	}
 
-	// 计算手续费和实际到账金额
-	fee := req.Amount * s.withdrawFee
-	actualAmount := req.Amount - fee
-
 	// 生成提现单号
 	withdrawalNo := s.generateWithdrawalNo()
 
@@ -147,6 +154,7 @@ func (s *WithdrawService) Apply(ctx context.Context, req *WithdrawRequest) (*Wit
 		WithdrawTo:           req.WithdrawTo,
 		AccountInfoEncrypted: req.AccountInfo, // 实际应该加密存储
 		Status:               models.WithdrawalStatusPending,
+		PolicySnapshot:       policy.Snapshot(),
 	}
 
 	// 使用事务处理
@@ -173,6 +181,7 @@ func (s *WithdrawService) Apply(ctx context.Context, req *WithdrawRequest) (*Wit
 				Updates(map[string]interface{}{
 					"balance":        gorm.Expr("balance - ?", req.Amount),
 					"frozen_balance": gorm.Expr("frozen_balance + ?", req.Amount),
+					"version":        gorm.Expr("version + 1"),
 				})
 			if result.Error != nil {
 				return result.Error
@@ -182,6 +191,11 @@ func (s *WithdrawService) Apply(ctx context.Context, req *WithdrawRequest) (*Wit
 			}
 		}
 
+		// 冻结余额已锁定分销商或钱包行，同一用户的并发申请在此串行统计当日限额
+		if err := s.checkDailyLimit(ctx, tx, req, policy); err != nil {
+			return err
+		}
+
 		// 创建提现记录
 		if err := tx.Create(withdrawal).Error; err != nil {
 			return err
@@ -202,6 +216,45 @@ func (s *WithdrawService) Apply(ctx context.Context, req *WithdrawRequest) (*Wit
 	}, nil
 }
 
+// GetPolicy 获取提现类型的提现规则，未配置时使用服务的默认配置
+func (s *WithdrawService) GetPolicy(ctx context.Context, withdrawalType string) (*models.WithdrawalPolicy, error) {
+	policy, err := s.withdrawalRepo.GetPolicy(ctx, withdrawalType)
+	if err == nil {
+		return policy, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &models.WithdrawalPolicy{
+		Type:            withdrawalType,
+		MinAmount:       s.minWithdraw,
+		FeePercent:      s.withdrawFee,
+		DailyCountLimit: MaxWithdrawPerDay,
+	}, nil
+}
+
+// checkDailyLimit 校验当日同类型提现的次数和金额上限
+func (s *WithdrawService) checkDailyLimit(ctx context.Context, tx *gorm.DB, req *WithdrawRequest, policy *models.WithdrawalPolicy) error {
+	if policy.DailyCountLimit <= 0 && policy.DailyAmountLimit <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	count, amount, err := s.withdrawalRepo.DailyUsageTx(ctx, tx, req.UserID, req.Type, startOfDay)
+	if err != nil {
+		return err
+	}
+	if policy.DailyCountLimit > 0 && count >= int64(policy.DailyCountLimit) {
+		return appErrors.ErrWithdrawDailyCountExceeded.WithMessage(fmt.Sprintf("每日最多提现%d次", policy.DailyCountLimit))
+	}
+	if policy.DailyAmountLimit > 0 && amount+req.Amount > policy.DailyAmountLimit {
+		return appErrors.ErrWithdrawDailyAmountExceeded.WithMessage(
+			fmt.Sprintf("每日最多提现%.2f元，今日还可提现%.2f元", policy.DailyAmountLimit, math.Max(policy.DailyAmountLimit-amount, 0)))
+	}
+	return nil
+}
+
 // generateWithdrawalNo 生成提现单号
 func (s *WithdrawService) generateWithdrawalNo() string {
 	return fmt.Sprintf("W%s%06d", time.Now().Format("20060102150405"), time.Now().Nanosecond()/1000%1000000)
@@ -263,6 +316,7 @@ func (s *WithdrawService) Reject(ctx context.Context, withdrawalID int64, operat
 				Updates(map[string]interface{}{
 					"balance":        gorm.Expr("balance + ?", withdrawal.Amount),
 					"frozen_balance": gorm.Expr("frozen_balance - ?", withdrawal.Amount),
+					"version":        gorm.Expr("version + 1"),
 				}).Error; err != nil {
 				return err
 			}
@@ -322,6 +376,7 @@ func (s *WithdrawService) Complete(ctx context.Context, withdrawalID int64) erro
 				Where("user_id = ?", withdrawal.UserID).
 				Updates(map[string]interface{}{
 					"frozen_balance":   gorm.Expr("frozen_balance - ?", withdrawal.Amount),
+					"version":          gorm.Expr("version + 1"),
 					"total_withdrawn":  gorm.Expr("total_withdrawn + ?", withdrawal.ActualAmount),
 				}).Error; err != nil {
 				return err
@@ -362,13 +417,20 @@ func (s *WithdrawService) GetStats(ctx context.Context, userID int64) (map[strin
 	return s.withdrawalRepo.GetStatsByUserID(ctx, userID)
 }
 
-// GetConfig 获取提现配置
-func (s *WithdrawService) GetConfig() map[string]interface{} {
+// GetConfig 获取提现类型当前适用的提现配置
+func (s *WithdrawService) GetConfig(ctx context.Context, withdrawalType string) (map[string]interface{}, error) {
+	policy, err := s.GetPolicy(ctx, withdrawalType)
+	if err != nil {
+		return nil, err
+	}
 	return map[string]interface{}{
-		"min_withdraw":       s.minWithdraw,
-		"withdraw_fee":       s.withdrawFee,
-		"withdraw_fee_desc":  fmt.Sprintf("%.1f%%", s.withdrawFee*100),
+		"min_withdraw":       policy.MinAmount,
+		"withdraw_fee":       policy.FeePercent,
+		"withdraw_fee_fixed": policy.FeeFixed,
+		"withdraw_fee_desc":  fmt.Sprintf("%.1f%%", policy.FeePercent*100),
+		"daily_count_limit":  policy.DailyCountLimit,
+		"daily_amount_limit": policy.DailyAmountLimit,
 		"max_pending":        MaxPendingWithdraw,
 		"support_methods":    []string{models.WithdrawToWechat, models.WithdrawToAlipay, models.WithdrawToBank},
-	}
+	}, nil
 }
@@ -36,6 +36,7 @@ func setupWithdrawTestDB(t *testing.T) *gorm.DB {
 		&models.MemberLevel{},
 		&models.Distributor{},
 		&models.Withdrawal{},
+		&models.WithdrawalPolicy{},
 		&models.Admin{},
 	)
 	require.NoError(t, err)
@@ -121,9 +122,8 @@ func TestWithdrawService_Apply(t *testing.T) {
 		assert.Equal(t, models.WithdrawalStatusPending, resp.Withdrawal.Status)
 
 		// 验证手续费计算 50 * 0.006 = 0.3
-		expectedFee := 50.0 * DefaultWithdrawFee
-		assert.Equal(t, expectedFee, resp.Fee)
-		assert.Equal(t, 50.0-expectedFee, resp.ActualAmount)
+		assert.Equal(t, 0.3, resp.Fee)
+		assert.Equal(t, 49.7, resp.ActualAmount)
 
 		// 验证分销商余额被冻结
 		var distributor models.Distributor
@@ -718,7 +718,8 @@ func TestWithdrawService_SetConfig(t *testing.T) {
 
 		svc.SetConfig(50.0, 0.01)
 
-		config := svc.GetConfig()
+		config, err := svc.GetConfig(context.Background(), models.WithdrawalTypeCommission)
+		require.NoError(t, err)
 		assert.Equal(t, 50.0, config["min_withdraw"])
 		assert.Equal(t, 0.01, config["withdraw_fee"])
 	})
@@ -732,7 +733,8 @@ func TestWithdrawService_GetConfig(t *testing.T) {
 		userRepo := repository.NewUserRepository(db)
 		svc := NewWithdrawService(withdrawalRepo, distributorRepo, userRepo, db)
 
-		config := svc.GetConfig()
+		config, err := svc.GetConfig(context.Background(), models.WithdrawalTypeCommission)
+		require.NoError(t, err)
 
 		assert.Equal(t, DefaultMinWithdraw, config["min_withdraw"])
 		assert.Equal(t, DefaultWithdrawFee, config["withdraw_fee"])
@@ -0,0 +1,166 @@
+// Package event 提供租借、预订状态变更事件的进程内分发，多实例部署时通过 Redis 频道扇出
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// StatusEventChannel 租借、预订状态变更事件的 Redis 频道
+const StatusEventChannel = "order:status:events"
+
+// 事件所属业务
+const (
+	SubjectRental  = "rental"  // 租借
+	SubjectBooking = "booking" // 酒店预订
+)
+
+// subscriberBuffer 每个订阅者的事件缓冲数，缓冲已满时丢弃新事件
+const subscriberBuffer = 16
+
+// StatusEvent 租借、预订状态变更事件
+type StatusEvent struct {
+	Subject   string    `json:"subject"`
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	OldStatus string    `json:"old_status"`
+	NewStatus string    `json:"new_status"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Publisher 状态变更事件发布者
+type Publisher interface {
+	Publish(ctx context.Context, event *StatusEvent) error
+}
+
+// subscriptionKey 订阅键，按业务及单据ID区分
+type subscriptionKey struct {
+	subject string
+	id      int64
+}
+
+// StatusBus 状态变更事件总线
+// 未配置 Redis 时事件直接在进程内分发；配置 Redis 时事件发布到 Redis 频道，
+// 由各实例订阅后分发给本实例的订阅者，保证连接到任意实例的客户端都能收到事件
+type StatusBus struct {
+	client *redis.Client
+	logger *zap.Logger
+	mu     sync.RWMutex
+	subs   map[subscriptionKey]map[chan *StatusEvent]struct{}
+	wg     sync.WaitGroup
+}
+
+// NewStatusBus 创建状态变更事件总线，client 为 nil 时仅在进程内分发
+func NewStatusBus(client *redis.Client, logger *zap.Logger) *StatusBus {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &StatusBus{
+		client: client,
+		logger: logger,
+		subs:   make(map[subscriptionKey]map[chan *StatusEvent]struct{}),
+	}
+}
+
+// Start 订阅 Redis 状态事件频道并在后台分发事件，ctx 取消后退出
+// 未配置 Redis 时直接返回；返回时订阅已生效
+func (b *StatusBus) Start(ctx context.Context) error {
+	if b.client == nil {
+		return nil
+	}
+
+	pubsub := b.client.Subscribe(ctx, StatusEventChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return err
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var event StatusEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					b.logger.Warn("解析状态变更事件失败", zap.Error(err))
+					continue
+				}
+				b.dispatch(&event)
+			}
+		}
+	}()
+	return nil
+}
+
+// Wait 等待后台订阅退出
+func (b *StatusBus) Wait() {
+	b.wg.Wait()
+}
+
+// Publish 发布状态变更事件
+func (b *StatusBus) Publish(ctx context.Context, event *StatusEvent) error {
+	if b.client == nil {
+		b.dispatch(event)
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, StatusEventChannel, payload).Err()
+}
+
+// Subscribe 订阅指定单据的状态变更事件，返回事件通道及取消订阅函数
+func (b *StatusBus) Subscribe(subject string, id int64) (<-chan *StatusEvent, func()) {
+	key := subscriptionKey{subject: subject, id: id}
+	ch := make(chan *StatusEvent, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subs[key] == nil {
+		b.subs[key] = make(map[chan *StatusEvent]struct{})
+	}
+	b.subs[key][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs[key], ch)
+			if len(b.subs[key]) == 0 {
+				delete(b.subs, key)
+			}
+			b.mu.Unlock()
+		})
+	}
+}
+
+// dispatch 将事件分发给该单据的订阅者，订阅者处理过慢时丢弃该事件
+func (b *StatusBus) dispatch(event *StatusEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subs[subscriptionKey{subject: event.Subject, id: event.ID}] {
+		select {
+		case ch <- event:
+		default:
+			b.logger.Warn("状态变更订阅者缓冲已满，丢弃事件",
+				zap.String("subject", event.Subject), zap.Int64("id", event.ID))
+		}
+	}
+}
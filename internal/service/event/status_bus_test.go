@@ -0,0 +1,87 @@
+package event
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiveEvent 从订阅通道读取一个事件，超时则失败
+func receiveEvent(t *testing.T, events <-chan *StatusEvent) *StatusEvent {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("未收到状态变更事件")
+		return nil
+	}
+}
+
+func TestStatusBus_InProcess(t *testing.T) {
+	bus := NewStatusBus(nil, nil)
+	require.NoError(t, bus.Start(context.Background()))
+
+	events, unsubscribe := bus.Subscribe(SubjectRental, 1)
+	other, unsubscribeOther := bus.Subscribe(SubjectBooking, 1)
+	defer unsubscribeOther()
+
+	require.NoError(t, bus.Publish(context.Background(), &StatusEvent{Subject: SubjectRental, ID: 1, OldStatus: "paid", NewStatus: "in_use"}))
+	require.NoError(t, bus.Publish(context.Background(), &StatusEvent{Subject: SubjectRental, ID: 2, OldStatus: "paid", NewStatus: "in_use"}))
+
+	event := receiveEvent(t, events)
+	assert.Equal(t, int64(1), event.ID)
+	assert.Equal(t, "in_use", event.NewStatus)
+	assert.Empty(t, events)
+	assert.Empty(t, other)
+
+	// 取消订阅后不再收到事件
+	unsubscribe()
+	unsubscribe()
+	require.NoError(t, bus.Publish(context.Background(), &StatusEvent{Subject: SubjectRental, ID: 1, NewStatus: "returned"}))
+	assert.Empty(t, events)
+}
+
+func TestStatusBus_RedisFanOut(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 模拟两个实例：事件由一个实例发布，两个实例的订阅者都能收到
+	newBus := func() *StatusBus {
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+		bus := NewStatusBus(client, nil)
+		require.NoError(t, bus.Start(ctx))
+		return bus
+	}
+	busA := newBus()
+	busB := newBus()
+
+	eventsA, unsubscribeA := busA.Subscribe(SubjectBooking, 7)
+	defer unsubscribeA()
+	eventsB, unsubscribeB := busB.Subscribe(SubjectBooking, 7)
+	defer unsubscribeB()
+
+	require.NoError(t, busA.Publish(ctx, &StatusEvent{Subject: SubjectBooking, ID: 7, UserID: 3, OldStatus: "verified", NewStatus: "in_use"}))
+
+	for _, events := range []<-chan *StatusEvent{eventsA, eventsB} {
+		event := receiveEvent(t, events)
+		assert.Equal(t, SubjectBooking, event.Subject)
+		assert.Equal(t, int64(3), event.UserID)
+		assert.Equal(t, "in_use", event.NewStatus)
+	}
+
+	cancel()
+	busA.Wait()
+	busB.Wait()
+}
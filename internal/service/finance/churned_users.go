@@ -0,0 +1,123 @@
+package finance
+
+import (
+	"context"
+	"time"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/crypto"
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// 流失用户统计参数
+const (
+	DefaultChurnInactiveDays = 30
+	MaxChurnInactiveDays     = 3650
+)
+
+// churnFinishedRentalStatuses 视为已完成的租借状态
+var churnFinishedRentalStatuses = []string{models.RentalStatusReturned, models.RentalStatusCompleted}
+
+// ChurnedUser 流失用户
+type ChurnedUser struct {
+	UserID       int64     `json:"user_id"`
+	Phone        string    `json:"phone"`          // 脱敏后的手机号
+	LastRentalAt time.Time `json:"last_rental_at"` // 最近一次完成租借的时间
+	RentalCount  int64     `json:"rental_count"`   // 历史完成租借次数
+	TotalSpend   float64   `json:"total_spend"`    // 历史完成租借的实付金额合计（已退款订单不计入）
+}
+
+// churnedUserRow 流失用户聚合结果
+type churnedUserRow struct {
+	UserID      int64
+	Phone       *string
+	RentalCount int64
+	TotalSpend  float64
+}
+
+// GetChurnedUsers 获取流失用户列表
+// 流失用户指至少完成过一次租借、最近一次完成租借早于 inactiveDays 天前，且此后没有发起过新租借（已取消的除外）的用户；
+// 按最近租借时间倒序，刚流失的用户排在前面
+func (s *StatisticsService) GetChurnedUsers(ctx context.Context, inactiveDays int, page, pageSize int) ([]*ChurnedUser, int64, error) {
+	if inactiveDays <= 0 || inactiveDays > MaxChurnInactiveDays {
+		return nil, 0, errors.ErrInvalidParams.WithMessage("无效的未活跃天数")
+	}
+	cutoff := time.Now().AddDate(0, 0, -inactiveDays)
+	lastRentalExpr := "MAX(COALESCE(rentals.returned_at, rentals.created_at))"
+
+	recentRenters := s.db.Model(&models.Rental{}).
+		Select("user_id").
+		Where("created_at >= ? AND status <> ?", cutoff, models.RentalStatusCancelled)
+
+	query := s.db.WithContext(ctx).Model(&models.Rental{}).
+		Select(
+			"rentals.user_id AS user_id, users.phone AS phone, COUNT(rentals.id) AS rental_count, "+
+				"COALESCE(SUM(CASE WHEN orders.status <> ? THEN orders.actual_amount ELSE 0 END), 0) AS total_spend, "+
+				lastRentalExpr+" AS last_rental_at",
+			models.OrderStatusRefunded,
+		).
+		Joins("JOIN users ON users.id = rentals.user_id").
+		Joins("LEFT JOIN orders ON orders.id = rentals.order_id").
+		Where("rentals.status IN ?", churnFinishedRentalStatuses).
+		Where("rentals.user_id NOT IN (?)", recentRenters).
+		Group("rentals.user_id, users.phone").
+		Having(lastRentalExpr+" < ?", cutoff)
+
+	var total int64
+	if err := s.db.WithContext(ctx).Table("(?) AS churned", query).Count(&total).Error; err != nil {
+		return nil, 0, errors.ErrDatabaseError.WithError(err)
+	}
+
+	var rows []churnedUserRow
+	err := s.db.WithContext(ctx).Table("(?) AS churned", query).
+		Select("user_id, phone, rental_count, total_spend").
+		Order("last_rental_at DESC, user_id ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, errors.ErrDatabaseError.WithError(err)
+	}
+	if len(rows) == 0 {
+		return []*ChurnedUser{}, total, nil
+	}
+
+	// 最近租借时间单独查询，避免聚合结果中的时间在不同数据库下类型不一致
+	userIDs := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		userIDs = append(userIDs, row.UserID)
+	}
+	var rentals []models.Rental
+	err = s.db.WithContext(ctx).Model(&models.Rental{}).
+		Select("user_id", "returned_at", "created_at").
+		Where("user_id IN ? AND status IN ?", userIDs, churnFinishedRentalStatuses).
+		Find(&rentals).Error
+	if err != nil {
+		return nil, 0, errors.ErrDatabaseError.WithError(err)
+	}
+	lastRentalAt := make(map[int64]time.Time, len(rows))
+	for _, rental := range rentals {
+		at := rental.CreatedAt
+		if rental.ReturnedAt != nil {
+			at = *rental.ReturnedAt
+		}
+		if at.After(lastRentalAt[rental.UserID]) {
+			lastRentalAt[rental.UserID] = at
+		}
+	}
+
+	users := make([]*ChurnedUser, 0, len(rows))
+	for _, row := range rows {
+		user := &ChurnedUser{
+			UserID:       row.UserID,
+			LastRentalAt: lastRentalAt[row.UserID],
+			RentalCount:  row.RentalCount,
+			TotalSpend:   roundAmount(row.TotalSpend),
+		}
+		if row.Phone != nil {
+			user.Phone = crypto.MaskPhone(*row.Phone)
+		}
+		users = append(users, user)
+	}
+	return users, total, nil
+}
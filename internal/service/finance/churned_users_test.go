@@ -0,0 +1,110 @@
+// Package finance 流失用户统计单元测试
+package finance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// createChurnRental 创建指定时间的租借记录，已完成的租借以该时间作为归还时间
+func createChurnRental(t *testing.T, db *gorm.DB, userID, deviceID int64, amount float64, orderStatus, rentalStatus string, at time.Time) {
+	t.Helper()
+
+	order := createTestOrder(t, db, userID, amount, orderStatus)
+	rental := &models.Rental{
+		OrderID:   order.ID,
+		UserID:    userID,
+		DeviceID:  deviceID,
+		Status:    rentalStatus,
+		CreatedAt: at.Add(-time.Hour),
+	}
+	if rentalStatus == models.RentalStatusCompleted || rentalStatus == models.RentalStatusReturned {
+		rental.ReturnedAt = &at
+	}
+	require.NoError(t, db.Create(rental).Error)
+}
+
+func TestStatisticsService_GetChurnedUsers(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupStatisticsService(db)
+	ctx := context.Background()
+
+	merchant := createTestMerchant(t, db, "商户")
+	venue := createTestVenue(t, db, merchant.ID, "场地")
+	device := createTestDevice(t, db, venue.ID, "CHURN-D1")
+	daysAgo := func(n int) time.Time { return time.Now().AddDate(0, 0, -n) }
+
+	// 60 天前最后一次租借，共 2 次，消费 30 元
+	longGone := createFinanceTestUser(t, db, "13800138401")
+	createChurnRental(t, db, longGone.ID, device.ID, 10, models.OrderStatusCompleted, models.RentalStatusCompleted, daysAgo(90))
+	createChurnRental(t, db, longGone.ID, device.ID, 20, models.OrderStatusCompleted, models.RentalStatusReturned, daysAgo(60))
+
+	// 40 天前最后一次租借，已退款订单不计入消费
+	recentlyGone := createFinanceTestUser(t, db, "13800138402")
+	createChurnRental(t, db, recentlyGone.ID, device.ID, 15.5, models.OrderStatusCompleted, models.RentalStatusCompleted, daysAgo(40))
+	createChurnRental(t, db, recentlyGone.ID, device.ID, 99, models.OrderStatusRefunded, models.RentalStatusCompleted, daysAgo(50))
+
+	// 近期取消的租借不算活跃
+	cancelledOnly := createFinanceTestUser(t, db, "13800138403")
+	createChurnRental(t, db, cancelledOnly.ID, device.ID, 8, models.OrderStatusCompleted, models.RentalStatusCompleted, daysAgo(70))
+	createChurnRental(t, db, cancelledOnly.ID, device.ID, 8, models.OrderStatusCancelled, models.RentalStatusCancelled, daysAgo(1))
+
+	// 10 天前仍有完成的租借
+	active := createFinanceTestUser(t, db, "13800138404")
+	createChurnRental(t, db, active.ID, device.ID, 10, models.OrderStatusCompleted, models.RentalStatusCompleted, daysAgo(10))
+
+	// 早期完成过租借，但近期有进行中的租借
+	returning := createFinanceTestUser(t, db, "13800138405")
+	createChurnRental(t, db, returning.ID, device.ID, 10, models.OrderStatusCompleted, models.RentalStatusCompleted, daysAgo(60))
+	createChurnRental(t, db, returning.ID, device.ID, 10, models.OrderStatusPaid, models.RentalStatusInUse, daysAgo(2))
+
+	// 从未完成过租借
+	neverRented := createFinanceTestUser(t, db, "13800138406")
+	createChurnRental(t, db, neverRented.ID, device.ID, 10, models.OrderStatusCancelled, models.RentalStatusCancelled, daysAgo(100))
+
+	users, total, err := svc.GetChurnedUsers(ctx, 30, 1, 50)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, users, 3)
+
+	assert.Equal(t, recentlyGone.ID, users[0].UserID)
+	assert.Equal(t, "138****8402", users[0].Phone)
+	assert.Equal(t, int64(2), users[0].RentalCount)
+	assert.Equal(t, 15.5, users[0].TotalSpend)
+	assert.WithinDuration(t, daysAgo(40), users[0].LastRentalAt, time.Minute)
+
+	assert.Equal(t, longGone.ID, users[1].UserID)
+	assert.Equal(t, 30.0, users[1].TotalSpend)
+	assert.WithinDuration(t, daysAgo(60), users[1].LastRentalAt, time.Minute)
+
+	assert.Equal(t, cancelledOnly.ID, users[2].UserID)
+
+	t.Run("分页", func(t *testing.T) {
+		page, total, err := svc.GetChurnedUsers(ctx, 30, 2, 2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		require.Len(t, page, 1)
+		assert.Equal(t, cancelledOnly.ID, page[0].UserID)
+	})
+
+	t.Run("未活跃天数更长", func(t *testing.T) {
+		users, total, err := svc.GetChurnedUsers(ctx, 45, 1, 50)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Equal(t, longGone.ID, users[0].UserID)
+	})
+
+	t.Run("无效的未活跃天数", func(t *testing.T) {
+		_, _, err := svc.GetChurnedUsers(ctx, 0, 1, 50)
+		require.Error(t, err)
+		assert.Equal(t, appErrors.ErrInvalidParams.Code, err.(*appErrors.AppError).Code)
+	})
+}
@@ -0,0 +1,106 @@
+package finance
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// dashboardTimeout 财务仪表盘聚合查询的总超时时间
+const dashboardTimeout = 3 * time.Second
+
+// 财务仪表盘数据来源，用于标识查询失败的子项
+const (
+	DashboardSourceOverview           = "overview"
+	DashboardSourceRevenueTrend       = "revenue_trend"
+	DashboardSourceSettlementStats    = "settlement_stats"
+	DashboardSourcePendingWithdrawals = "pending_withdrawals"
+	DashboardSourceRefundStats        = "refund_stats"
+)
+
+// DashboardRequest 财务仪表盘查询参数
+type DashboardRequest struct {
+	TrendDays       int        // 收入趋势天数
+	WithdrawalLimit int        // 待处理提现数量
+	RefundStartDate *time.Time // 退款统计开始日期
+	RefundEndDate   *time.Time // 退款统计结束日期
+}
+
+// DashboardError 财务仪表盘子项查询失败信息
+type DashboardError struct {
+	Source  string `json:"source"`
+	Message string `json:"message"`
+}
+
+// DashboardData 财务仪表盘聚合数据，查询失败的子项为空并记录在 Errors 中
+type DashboardData struct {
+	Overview           *FinanceOverviewData    `json:"overview"`
+	RevenueTrend       []RevenueTrend          `json:"revenue_trend"`
+	SettlementStats    []SettlementStat        `json:"settlement_stats"`
+	PendingWithdrawals []PendingWithdrawalItem `json:"pending_withdrawals"`
+	RefundStats        []RefundStat            `json:"refund_stats"`
+	Errors             []DashboardError        `json:"errors,omitempty"`
+}
+
+// GetDashboard 并发查询财务概览、收入趋势、结算统计、待处理提现和退款统计并合并返回
+// 整体超时 dashboardTimeout，单个子项失败不影响其他子项，失败信息记录在 Errors 中
+func (s *FinanceDashboardService) GetDashboard(ctx context.Context, req *DashboardRequest) *DashboardData {
+	ctx, cancel := context.WithTimeout(ctx, dashboardTimeout)
+	defer cancel()
+
+	data := &DashboardData{}
+	var mu sync.Mutex
+	var g errgroup.Group
+
+	run := func(source string, fn func() error) {
+		g.Go(func() error {
+			if err := fn(); err != nil {
+				mu.Lock()
+				data.Errors = append(data.Errors, DashboardError{Source: source, Message: err.Error()})
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+
+	run(DashboardSourceOverview, func() error {
+		overview, err := s.GetFinanceOverviewData(ctx)
+		mu.Lock()
+		data.Overview = overview
+		mu.Unlock()
+		return err
+	})
+	run(DashboardSourceRevenueTrend, func() error {
+		trend, err := s.GetRevenueTrend(ctx, req.TrendDays)
+		mu.Lock()
+		data.RevenueTrend = trend
+		mu.Unlock()
+		return err
+	})
+	run(DashboardSourceSettlementStats, func() error {
+		stats, err := s.GetSettlementStats(ctx)
+		mu.Lock()
+		data.SettlementStats = stats
+		mu.Unlock()
+		return err
+	})
+	run(DashboardSourcePendingWithdrawals, func() error {
+		withdrawals, err := s.GetPendingWithdrawals(ctx, req.WithdrawalLimit)
+		mu.Lock()
+		data.PendingWithdrawals = withdrawals
+		mu.Unlock()
+		return err
+	})
+	run(DashboardSourceRefundStats, func() error {
+		stats, err := s.GetRefundStats(ctx, req.RefundStartDate, req.RefundEndDate)
+		mu.Lock()
+		data.RefundStats = stats
+		mu.Unlock()
+		return err
+	})
+
+	_ = g.Wait()
+	return data
+}
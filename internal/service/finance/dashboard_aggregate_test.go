@@ -0,0 +1,71 @@
+package finance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// setupDashboardTestDB 创建仪表盘测试数据库，内存库限制为单连接以便并发查询共享同一数据库
+func setupDashboardTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db := setupFinanceTestDB(t)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	user := createFinanceTestUser(t, db, "13800138071")
+	payment := createTestPayment(t, db, user.ID, 100, models.PaymentStatusSuccess)
+	createTestSettlement(t, db, models.SettlementTypeMerchant, 1, 200, models.SettlementStatusPending)
+	createTestWithdrawal(t, db, user.ID, 50, models.WithdrawalStatusPending)
+	now := time.Now()
+	require.NoError(t, db.Create(&models.Refund{
+		RefundNo: "R_DASHBOARD_1", OrderID: payment.OrderID, OrderNo: payment.OrderNo,
+		PaymentID: payment.ID, PaymentNo: payment.PaymentNo, UserID: user.ID,
+		Amount: 10, Reason: "测试", Status: models.RefundStatusSuccess, RefundedAt: &now,
+	}).Error)
+	return db
+}
+
+func TestFinanceDashboardService_GetDashboard(t *testing.T) {
+	db := setupDashboardTestDB(t)
+	svc := NewFinanceDashboardService(db)
+
+	data := svc.GetDashboard(context.Background(), &DashboardRequest{TrendDays: 7, WithdrawalLimit: 10})
+
+	assert.Empty(t, data.Errors)
+	require.NotNil(t, data.Overview)
+	assert.Equal(t, 100.0, data.Overview.TotalRevenue)
+	assert.Equal(t, int64(1), data.Overview.PendingCount)
+	assert.Len(t, data.RevenueTrend, 7)
+	assert.Len(t, data.SettlementStats, 2)
+	assert.Equal(t, int64(1), data.SettlementStats[0].PendingCount)
+	require.Len(t, data.PendingWithdrawals, 1)
+	assert.Equal(t, 50.0, data.PendingWithdrawals[0].Amount)
+	require.Len(t, data.RefundStats, 1)
+	assert.Equal(t, 10.0, data.RefundStats[0].Amount)
+}
+
+func TestFinanceDashboardService_GetDashboard_PartialFailure(t *testing.T) {
+	db := setupDashboardTestDB(t)
+	svc := NewFinanceDashboardService(db)
+	require.NoError(t, db.Migrator().DropTable(&models.Withdrawal{}))
+
+	data := svc.GetDashboard(context.Background(), &DashboardRequest{})
+
+	require.Len(t, data.Errors, 1)
+	assert.Equal(t, DashboardSourcePendingWithdrawals, data.Errors[0].Source)
+	assert.NotEmpty(t, data.Errors[0].Message)
+	assert.Nil(t, data.PendingWithdrawals)
+	assert.NotNil(t, data.Overview)
+	assert.Len(t, data.RevenueTrend, 7)
+	assert.Len(t, data.SettlementStats, 2)
+	assert.Len(t, data.RefundStats, 1)
+}
@@ -181,8 +181,12 @@ func (s *FinanceDashboardService) GetFinanceOverviewData(ctx context.Context) (*
 }
 
 // RevenueTrend 收入趋势数据
+// Date 为统计周期标识：按日为 2006-01-02，按周为 ISO 周 2006-W01，按月为 2006-01；
+// StartDate、EndDate 为周期在查询范围内的起止日期（含），范围边缘的周、月只统计范围内的部分
 type RevenueTrend struct {
 	Date         string  `json:"date"`
+	StartDate    string  `json:"start_date"`
+	EndDate      string  `json:"end_date"`
 	Revenue      float64 `json:"revenue"`
 	Refund       float64 `json:"refund"`
 	Commission   float64 `json:"commission"`
@@ -199,52 +203,16 @@ func (s *FinanceDashboardService) GetRevenueTrend(ctx context.Context, days int)
 		days = 30
 	}
 
-	trends := make([]RevenueTrend, days)
-	now := time.Now()
-
-	for i := days - 1; i >= 0; i-- {
-		date := now.Add(-time.Duration(i) * 24 * time.Hour)
-		startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-		endOfDay := startOfDay.Add(24 * time.Hour)
-
-		trend := RevenueTrend{
-			Date: startOfDay.Format("2006-01-02"),
-		}
-
-		// 当日收入
-		s.db.WithContext(ctx).Model(&models.Payment{}).
-			Where("status = ? AND pay_time >= ? AND pay_time < ?",
-				models.PaymentStatusSuccess, startOfDay, endOfDay).
-			Select("COALESCE(SUM(amount), 0)").
-			Row().Scan(&trend.Revenue)
-
-		// 当日退款
-		s.db.WithContext(ctx).Model(&models.Refund{}).
-			Where("status = ? AND created_at >= ? AND created_at < ?",
-				models.RefundStatusSuccess, startOfDay, endOfDay).
-			Select("COALESCE(SUM(amount), 0)").
-			Row().Scan(&trend.Refund)
-
-		// 当日佣金支出
-		s.db.WithContext(ctx).Model(&models.Commission{}).
-			Where("status = ? AND settled_at >= ? AND settled_at < ?",
-				models.CommissionStatusSettled, startOfDay, endOfDay).
-			Select("COALESCE(SUM(amount), 0)").
-			Row().Scan(&trend.Commission)
-
-		// 当日订单数
-		s.db.WithContext(ctx).Model(&models.Order{}).
-			Where("status NOT IN ? AND created_at >= ? AND created_at < ?",
-				[]string{models.OrderStatusPending, models.OrderStatusCancelled}, startOfDay, endOfDay).
-			Count(&trend.OrderCount)
-
-		// 净收入
-		trend.NetRevenue = trend.Revenue - trend.Refund - trend.Commission
-
-		trends[days-1-i] = trend
+	today := time.Now()
+	report, err := s.GetRevenueTrendReport(ctx, &RevenueTrendRequest{
+		Granularity: TrendGranularityDay,
+		StartDate:   today.AddDate(0, 0, -(days - 1)),
+		EndDate:     today,
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	return trends, nil
+	return report.Current, nil
 }
 
 // PaymentChannelSummary 支付渠道汇总
@@ -0,0 +1,288 @@
+// Package finance 提供财务管理服务
+package finance
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// DeviceStatisticsService 设备使用统计服务
+type DeviceStatisticsService struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+// NewDeviceStatisticsService 创建设备使用统计服务
+func NewDeviceStatisticsService(db *gorm.DB) *DeviceStatisticsService {
+	return &DeviceStatisticsService{
+		db:  db,
+		now: time.Now,
+	}
+}
+
+// DeviceUtilization 单台设备的使用率统计
+type DeviceUtilization struct {
+	DeviceID       int64   `json:"device_id"`
+	DeviceNo       string  `json:"device_no"`
+	DeviceName     string  `json:"device_name"`
+	SlotCount      int     `json:"slot_count"`
+	AvailableHours float64 `json:"available_hours"` // 统计周期内可用时长（时长 × 格口数）
+	RentalHours    float64 `json:"rental_hours"`    // 统计周期内租借占用时长
+	RentalCount    int     `json:"rental_count"`
+	Revenue        float64 `json:"revenue"`
+	OccupancyRate  float64 `json:"occupancy_rate"` // 占用率 = 租借时长 / 可用时长
+}
+
+// VenueUtilization 场地设备使用率报表
+type VenueUtilization struct {
+	VenueID        int64                `json:"venue_id"`
+	VenueName      string               `json:"venue_name"`
+	StartTime      time.Time            `json:"start_time"`
+	EndTime        time.Time            `json:"end_time"`
+	DeviceCount    int                  `json:"device_count"`
+	AvailableHours float64              `json:"available_hours"`
+	RentalHours    float64              `json:"rental_hours"`
+	RentalCount    int                  `json:"rental_count"`
+	Revenue        float64              `json:"revenue"`
+	OccupancyRate  float64              `json:"occupancy_rate"`
+	Devices        []*DeviceUtilization `json:"devices"`
+}
+
+// utilizationRental 参与使用率统计的租借记录
+type utilizationRental struct {
+	DeviceID         int64
+	Status           string
+	UnlockedAt       *time.Time
+	ExpectedReturnAt *time.Time
+	ReturnedAt       *time.Time
+	OrderStatus      string
+	Amount           float64
+}
+
+// GetVenueUtilization 统计场地下各设备在周期内的租借时长、租借次数、收入及占用率
+// 周期内新增的设备从创建时间开始计算可用时长，已删除的设备计算到删除时间为止；
+// 跨越周期边界的租借只计入周期内的时长，收入按该时长占租借总时长的比例分摊
+func (s *DeviceStatisticsService) GetVenueUtilization(ctx context.Context, venueID int64, start, end time.Time) (*VenueUtilization, error) {
+	if !end.After(start) {
+		return nil, errors.ErrInvalidParams.WithMessage("结束时间必须晚于开始时间")
+	}
+
+	var venue models.Venue
+	if err := s.db.WithContext(ctx).First(&venue, venueID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrVenueNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	// 包含周期内被删除的设备，其删除前的租借仍计入统计
+	var devices []*models.Device
+	err := s.db.WithContext(ctx).Unscoped().
+		Where("venue_id = ?", venueID).
+		Where("created_at < ?", end).
+		Where("deleted_at IS NULL OR deleted_at > ?", start).
+		Order("id ASC").
+		Find(&devices).Error
+	if err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	report := &VenueUtilization{
+		VenueID:   venue.ID,
+		VenueName: venue.Name,
+		StartTime: start,
+		EndTime:   end,
+		Devices:   make([]*DeviceUtilization, 0, len(devices)),
+	}
+	if len(devices) == 0 {
+		return report, nil
+	}
+
+	deviceIDs := make([]int64, len(devices))
+	for i, d := range devices {
+		deviceIDs[i] = d.ID
+	}
+
+	var rentals []*utilizationRental
+	err = s.db.WithContext(ctx).Model(&models.Rental{}).
+		Joins("JOIN orders ON orders.id = rentals.order_id").
+		Where("rentals.device_id IN ?", deviceIDs).
+		Where("rentals.unlocked_at IS NOT NULL AND rentals.unlocked_at < ?", end).
+		// 与 rentalInterval 的结束时间口径一致，排除周期开始前已结束的租借
+		Where("(rentals.returned_at IS NOT NULL AND rentals.returned_at > ?) OR "+
+			"(rentals.returned_at IS NULL AND (rentals.status IN ? OR rentals.expected_return_at > ?))",
+			start, []string{models.RentalStatusInUse, models.RentalStatusOverdue}, start).
+		Select("rentals.device_id, rentals.status, rentals.unlocked_at, rentals.expected_return_at, rentals.returned_at, " +
+			"orders.status AS order_status, orders.actual_amount AS amount").
+		Scan(&rentals).Error
+	if err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	rentalsByDevice := make(map[int64][]*utilizationRental, len(devices))
+	for _, r := range rentals {
+		rentalsByDevice[r.DeviceID] = append(rentalsByDevice[r.DeviceID], r)
+	}
+
+	now := s.now()
+	for _, device := range devices {
+		item := s.deviceUtilization(device, rentalsByDevice[device.ID], start, end, now)
+		report.Devices = append(report.Devices, item)
+		report.AvailableHours += item.AvailableHours
+		report.RentalHours += item.RentalHours
+		report.RentalCount += item.RentalCount
+		report.Revenue += item.Revenue
+	}
+
+	report.DeviceCount = len(report.Devices)
+	report.AvailableHours = roundHours(report.AvailableHours)
+	report.RentalHours = roundHours(report.RentalHours)
+	report.Revenue = roundAmount(report.Revenue)
+	report.OccupancyRate = occupancyRate(report.RentalHours, report.AvailableHours)
+	return report, nil
+}
+
+// deviceUtilization 计算单台设备在其可用时间窗口内的使用率
+func (s *DeviceStatisticsService) deviceUtilization(device *models.Device, rentals []*utilizationRental, start, end, now time.Time) *DeviceUtilization {
+	slots := device.SlotCount
+	if slots < 1 {
+		slots = 1
+	}
+
+	// 设备可用窗口：周期与设备存续时间的交集
+	windowStart, windowEnd := start, end
+	if device.CreatedAt.After(windowStart) {
+		windowStart = device.CreatedAt
+	}
+	if device.DeletedAt.Valid && device.DeletedAt.Time.Before(windowEnd) {
+		windowEnd = device.DeletedAt.Time
+	}
+
+	item := &DeviceUtilization{
+		DeviceID:   device.ID,
+		DeviceNo:   device.DeviceNo,
+		DeviceName: device.Name,
+		SlotCount:  slots,
+	}
+	if !windowEnd.After(windowStart) {
+		return item
+	}
+	item.AvailableHours = windowEnd.Sub(windowStart).Hours() * float64(slots)
+
+	intervals := make([][2]time.Time, 0, len(rentals))
+	for _, r := range rentals {
+		rentalStart, rentalEnd, ok := rentalInterval(r, now)
+		if !ok {
+			continue
+		}
+		clippedStart, clippedEnd := rentalStart, rentalEnd
+		if clippedStart.Before(windowStart) {
+			clippedStart = windowStart
+		}
+		if clippedEnd.After(windowEnd) {
+			clippedEnd = windowEnd
+		}
+		if !clippedEnd.After(clippedStart) {
+			continue
+		}
+
+		intervals = append(intervals, [2]time.Time{clippedStart, clippedEnd})
+		item.RentalCount++
+		if isRevenueOrderStatus(r.OrderStatus) {
+			// 跨周期的租借按落在窗口内的时长比例分摊收入，避免相邻周期重复计入
+			item.Revenue += r.Amount * clippedEnd.Sub(clippedStart).Seconds() / rentalEnd.Sub(rentalStart).Seconds()
+		}
+	}
+
+	item.RentalHours = roundHours(occupiedHours(intervals, slots))
+	item.AvailableHours = roundHours(item.AvailableHours)
+	item.Revenue = roundAmount(item.Revenue)
+	item.OccupancyRate = occupancyRate(item.RentalHours, item.AvailableHours)
+	return item
+}
+
+// rentalInterval 计算租借的占用区间，未归还的租借占用到当前时间
+func rentalInterval(r *utilizationRental, now time.Time) (time.Time, time.Time, bool) {
+	if r.UnlockedAt == nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	switch {
+	case r.ReturnedAt != nil:
+		return *r.UnlockedAt, *r.ReturnedAt, true
+	case r.Status == models.RentalStatusInUse || r.Status == models.RentalStatusOverdue:
+		return *r.UnlockedAt, now, true
+	case r.ExpectedReturnAt != nil:
+		return *r.UnlockedAt, *r.ExpectedReturnAt, true
+	default:
+		return time.Time{}, time.Time{}, false
+	}
+}
+
+// occupiedHours 按时间扫描累计占用时长，同一时刻的占用数不超过格口数
+func occupiedHours(intervals [][2]time.Time, slots int) float64 {
+	type event struct {
+		at    time.Time
+		delta int
+	}
+
+	events := make([]event, 0, len(intervals)*2)
+	for _, iv := range intervals {
+		events = append(events, event{at: iv[0], delta: 1}, event{at: iv[1], delta: -1})
+	}
+	// 同一时刻先处理结束再处理开始，首尾相接的租借不视为重叠
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].at.Equal(events[j].at) {
+			return events[i].delta < events[j].delta
+		}
+		return events[i].at.Before(events[j].at)
+	})
+
+	var hours float64
+	active := 0
+	for i, e := range events {
+		if i > 0 && active > 0 {
+			occupied := active
+			if occupied > slots {
+				occupied = slots
+			}
+			hours += e.at.Sub(events[i-1].at).Hours() * float64(occupied)
+		}
+		active += e.delta
+	}
+	return hours
+}
+
+// isRevenueOrderStatus 判断订单是否计入收入（已支付且未取消、未退款）
+func isRevenueOrderStatus(status string) bool {
+	switch status {
+	case models.OrderStatusPending, models.OrderStatusCancelled, models.OrderStatusRefunded:
+		return false
+	default:
+		return true
+	}
+}
+
+// occupancyRate 计算占用率，保留四位小数
+func occupancyRate(rentalHours, availableHours float64) float64 {
+	if availableHours <= 0 {
+		return 0
+	}
+	rate := rentalHours / availableHours
+	if rate > 1 {
+		rate = 1
+	}
+	return math.Round(rate*10000) / 10000
+}
+
+// roundHours 时长保留两位小数
+func roundHours(hours float64) float64 {
+	return math.Round(hours*100) / 100
+}
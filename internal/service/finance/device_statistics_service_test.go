@@ -0,0 +1,283 @@
+package finance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// createUtilizationDevice 创建指定格口数和创建时间的设备
+func createUtilizationDevice(t *testing.T, db *gorm.DB, venueID int64, deviceNo string, slots int, createdAt time.Time) *models.Device {
+	t.Helper()
+
+	device := createTestDevice(t, db, venueID, deviceNo)
+	require.NoError(t, db.Model(device).Updates(map[string]interface{}{
+		"slot_count": slots,
+		"created_at": createdAt,
+	}).Error)
+	device.SlotCount = slots
+	device.CreatedAt = createdAt
+	return device
+}
+
+// createUtilizationRental 创建指定开锁和归还时间的租借订单，returnedAt 为空表示未归还
+func createUtilizationRental(t *testing.T, db *gorm.DB, userID, deviceID int64, amount float64, orderStatus string, unlockedAt time.Time, returnedAt *time.Time) *models.Rental {
+	t.Helper()
+
+	order := createTestOrder(t, db, userID, amount, orderStatus)
+	rental := &models.Rental{
+		OrderID:    order.ID,
+		UserID:     userID,
+		DeviceID:   deviceID,
+		Status:     models.RentalStatusCompleted,
+		UnlockedAt: &unlockedAt,
+		ReturnedAt: returnedAt,
+	}
+	if returnedAt == nil {
+		rental.Status = models.RentalStatusInUse
+	}
+	require.NoError(t, db.Create(rental).Error)
+	return rental
+}
+
+func findDeviceUtilization(t *testing.T, report *VenueUtilization, deviceID int64) *DeviceUtilization {
+	t.Helper()
+
+	for _, d := range report.Devices {
+		if d.DeviceID == deviceID {
+			return d
+		}
+	}
+	require.FailNow(t, fmt.Sprintf("device %d not found in report", deviceID))
+	return nil
+}
+
+func TestDeviceStatisticsService_GetVenueUtilization(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := NewDeviceStatisticsService(db)
+	ctx := context.Background()
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	at := func(hour int) time.Time { return start.Add(time.Duration(hour) * time.Hour) }
+	ptr := func(t time.Time) *time.Time { return &t }
+	svc.now = func() time.Time { return at(20) }
+
+	merchant := createTestMerchant(t, db, "使用率商户")
+	venue := createTestVenue(t, db, merchant.ID, "使用率场地")
+	user := createFinanceTestUser(t, db, "13800190001")
+
+	// 双格口设备：三笔租借在 10:00-14:00 同时占用，超出格口数的部分不计入
+	multi := createUtilizationDevice(t, db, venue.ID, "DEV_UTIL_MULTI", 2, start.Add(-24*time.Hour))
+	createUtilizationRental(t, db, user.ID, multi.ID, 10, models.OrderStatusCompleted, at(10), ptr(at(14)))
+	createUtilizationRental(t, db, user.ID, multi.ID, 10, models.OrderStatusCompleted, at(10), ptr(at(14)))
+	createUtilizationRental(t, db, user.ID, multi.ID, 10, models.OrderStatusCompleted, at(10), ptr(at(14)))
+
+	// 单格口设备：全天被重复占用，占用率封顶 100%
+	full := createUtilizationDevice(t, db, venue.ID, "DEV_UTIL_FULL", 1, start.Add(-24*time.Hour))
+	createUtilizationRental(t, db, user.ID, full.ID, 20, models.OrderStatusCompleted, at(-2), ptr(at(26)))
+	createUtilizationRental(t, db, user.ID, full.ID, 20, models.OrderStatusCompleted, at(0), ptr(at(24)))
+
+	// 单格口设备：部分重叠的租借按并集计算，已退款订单不计收入
+	partial := createUtilizationDevice(t, db, venue.ID, "DEV_UTIL_PARTIAL", 1, start.Add(-24*time.Hour))
+	createUtilizationRental(t, db, user.ID, partial.ID, 15, models.OrderStatusCompleted, at(10), ptr(at(14)))
+	createUtilizationRental(t, db, user.ID, partial.ID, 5, models.OrderStatusRefunded, at(12), ptr(at(16)))
+
+	// 周期中途新增的设备只计算创建后的可用时长，未归还的租借占用到当前时间
+	added := createUtilizationDevice(t, db, venue.ID, "DEV_UTIL_ADDED", 1, at(12))
+	createUtilizationRental(t, db, user.ID, added.ID, 8, models.OrderStatusPaid, at(14), nil)
+
+	// 周期结束后才创建的设备不计入
+	createUtilizationDevice(t, db, venue.ID, "DEV_UTIL_LATER", 1, end.Add(time.Hour))
+
+	// 其他场地的设备不计入
+	otherVenue := createTestVenue(t, db, merchant.ID, "其他场地")
+	other := createUtilizationDevice(t, db, otherVenue.ID, "DEV_UTIL_OTHER", 1, start.Add(-24*time.Hour))
+	createUtilizationRental(t, db, user.ID, other.ID, 99, models.OrderStatusCompleted, at(1), ptr(at(2)))
+
+	report, err := svc.GetVenueUtilization(ctx, venue.ID, start, end)
+	require.NoError(t, err)
+	assert.Equal(t, venue.ID, report.VenueID)
+	assert.Equal(t, 4, report.DeviceCount)
+
+	m := findDeviceUtilization(t, report, multi.ID)
+	assert.Equal(t, 48.0, m.AvailableHours)
+	assert.Equal(t, 8.0, m.RentalHours)
+	assert.Equal(t, 3, m.RentalCount)
+	assert.Equal(t, 30.0, m.Revenue)
+	assert.InDelta(t, 8.0/48.0, m.OccupancyRate, 0.0001)
+
+	f := findDeviceUtilization(t, report, full.ID)
+	assert.Equal(t, 24.0, f.AvailableHours)
+	assert.Equal(t, 24.0, f.RentalHours)
+	assert.Equal(t, 2, f.RentalCount)
+	assert.Equal(t, 1.0, f.OccupancyRate)
+	// 跨周期的 28 小时租借只有 24 小时落在周期内，收入按比例分摊
+	assert.Equal(t, roundAmount(20+20*24.0/28.0), f.Revenue)
+
+	p := findDeviceUtilization(t, report, partial.ID)
+	assert.Equal(t, 6.0, p.RentalHours)
+	assert.Equal(t, 2, p.RentalCount)
+	assert.Equal(t, 15.0, p.Revenue)
+	assert.InDelta(t, 0.25, p.OccupancyRate, 0.0001)
+
+	a := findDeviceUtilization(t, report, added.ID)
+	assert.Equal(t, 12.0, a.AvailableHours)
+	assert.Equal(t, 6.0, a.RentalHours)
+	assert.Equal(t, 0.5, a.OccupancyRate)
+
+	for _, d := range report.Devices {
+		assert.LessOrEqual(t, d.RentalHours, d.AvailableHours, "device %d", d.DeviceID)
+		assert.LessOrEqual(t, d.OccupancyRate, 1.0, "device %d", d.DeviceID)
+	}
+
+	assert.Equal(t, 108.0, report.AvailableHours)
+	assert.Equal(t, 44.0, report.RentalHours)
+	assert.Equal(t, 8, report.RentalCount)
+	assert.Equal(t, roundAmount(30+20+20*24.0/28.0+15+8), report.Revenue)
+	assert.InDelta(t, 44.0/108.0, report.OccupancyRate, 0.0001)
+}
+
+func TestDeviceStatisticsService_GetVenueUtilization_DeletedDevice(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := NewDeviceStatisticsService(db)
+	ctx := context.Background()
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	merchant := createTestMerchant(t, db, "删除设备商户")
+	venue := createTestVenue(t, db, merchant.ID, "删除设备场地")
+	user := createFinanceTestUser(t, db, "13800190002")
+
+	device := createUtilizationDevice(t, db, venue.ID, "DEV_UTIL_DELETED", 1, start.Add(-24*time.Hour))
+	unlockedAt, returnedAt := start.Add(2*time.Hour), start.Add(5*time.Hour)
+	createUtilizationRental(t, db, user.ID, device.ID, 12, models.OrderStatusCompleted, unlockedAt, &returnedAt)
+	require.NoError(t, db.Model(device).Update("deleted_at", start.Add(6*time.Hour)).Error)
+
+	report, err := svc.GetVenueUtilization(ctx, venue.ID, start, end)
+	require.NoError(t, err)
+	require.Len(t, report.Devices, 1)
+	assert.Equal(t, 6.0, report.Devices[0].AvailableHours)
+	assert.Equal(t, 3.0, report.Devices[0].RentalHours)
+	assert.Equal(t, 0.5, report.Devices[0].OccupancyRate)
+}
+
+func TestDeviceStatisticsService_GetVenueUtilization_StraddlingRentals(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := NewDeviceStatisticsService(db)
+	ctx := context.Background()
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	at := func(hour int) time.Time { return start.Add(time.Duration(hour) * time.Hour) }
+	ptr := func(t time.Time) *time.Time { return &t }
+	svc.now = func() time.Time { return at(48) }
+
+	merchant := createTestMerchant(t, db, "跨周期商户")
+	venue := createTestVenue(t, db, merchant.ID, "跨周期场地")
+	user := createFinanceTestUser(t, db, "13800190003")
+	device := createUtilizationDevice(t, db, venue.ID, "DEV_UTIL_STRADDLE", 2, start.Add(-48*time.Hour))
+
+	// 周期开始前已归还的租借不计入
+	createUtilizationRental(t, db, user.ID, device.ID, 50, models.OrderStatusCompleted, at(-10), ptr(at(-2)))
+	// 跨越周期开始：12 小时中 6 小时在周期内
+	createUtilizationRental(t, db, user.ID, device.ID, 24, models.OrderStatusCompleted, at(-6), ptr(at(6)))
+	// 跨越周期结束：8 小时中 2 小时在周期内
+	createUtilizationRental(t, db, user.ID, device.ID, 16, models.OrderStatusCompleted, at(22), ptr(at(30)))
+	// 周期结束后才开锁的租借不计入
+	createUtilizationRental(t, db, user.ID, device.ID, 30, models.OrderStatusCompleted, at(25), ptr(at(27)))
+
+	report, err := svc.GetVenueUtilization(ctx, venue.ID, start, end)
+	require.NoError(t, err)
+	require.Len(t, report.Devices, 1)
+
+	d := report.Devices[0]
+	assert.Equal(t, 2, d.RentalCount)
+	assert.Equal(t, 8.0, d.RentalHours)
+	assert.Equal(t, 16.0, d.Revenue)
+
+	// 相邻两个周期的收入之和等于订单金额，不重复计入
+	next, err := svc.GetVenueUtilization(ctx, venue.ID, end, end.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.RentalCount)
+	assert.Equal(t, 16.0-4.0+30.0, next.Revenue)
+}
+
+func TestDeviceStatisticsService_GetVenueUtilization_Errors(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := NewDeviceStatisticsService(db)
+	ctx := context.Background()
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("场地不存在", func(t *testing.T) {
+		_, err := svc.GetVenueUtilization(ctx, 99999, start, start.Add(time.Hour))
+		assertSettlementErrorCode(t, err, appErrors.ErrVenueNotFound.Code)
+	})
+
+	t.Run("结束时间早于开始时间", func(t *testing.T) {
+		_, err := svc.GetVenueUtilization(ctx, 1, start, start.Add(-time.Hour))
+		assertSettlementErrorCode(t, err, appErrors.ErrInvalidParams.Code)
+	})
+
+	t.Run("无设备场地", func(t *testing.T) {
+		merchant := createTestMerchant(t, db, "空场地商户")
+		venue := createTestVenue(t, db, merchant.ID, "空场地")
+
+		report, err := svc.GetVenueUtilization(ctx, venue.ID, start, start.Add(24*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 0, report.DeviceCount)
+		assert.Empty(t, report.Devices)
+		assert.Equal(t, 0.0, report.OccupancyRate)
+	})
+}
+
+func TestExportService_ExportVenueUtilization(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupExportService(db)
+	ctx := context.Background()
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	merchant := createTestMerchant(t, db, "导出使用率商户")
+	venue := createTestVenue(t, db, merchant.ID, "导出使用率场地")
+	user := createFinanceTestUser(t, db, "13800190003")
+	device := createUtilizationDevice(t, db, venue.ID, "DEV_UTIL_EXPORT", 1, start.Add(-24*time.Hour))
+	unlockedAt, returnedAt := start.Add(6*time.Hour), start.Add(12*time.Hour)
+	createUtilizationRental(t, db, user.ID, device.ID, 18, models.OrderStatusCompleted, unlockedAt, &returnedAt)
+
+	data, filename, err := svc.ExportVenueUtilization(ctx, &ExportVenueUtilizationRequest{
+		VenueID:   venue.ID,
+		StartTime: start,
+		EndTime:   end,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("venue_utilization_%d_20240501_20240502.csv", venue.ID), filename)
+
+	content := string(data)
+	assert.Contains(t, content, "设备编号")
+	assert.Contains(t, content, "DEV_UTIL_EXPORT")
+	assert.Contains(t, content, "25.00%")
+
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[2], "合计")
+
+	t.Run("场地不存在", func(t *testing.T) {
+		_, _, err := svc.ExportVenueUtilization(ctx, &ExportVenueUtilizationRequest{
+			VenueID: 99999, StartTime: start, EndTime: end,
+		})
+		assertSettlementErrorCode(t, err, appErrors.ErrVenueNotFound.Code)
+	})
+}
@@ -0,0 +1,281 @@
+package finance
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+)
+
+// ExportFormat 导出文件格式
+type ExportFormat string
+
+// 导出文件格式
+const (
+	ExportFormatCSV  ExportFormat = "csv"
+	ExportFormatXLSX ExportFormat = "xlsx"
+)
+
+// Content-Type
+const (
+	csvContentType  = "text/csv; charset=utf-8"
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+const (
+	xlsxSheetName   = "Sheet1"
+	xlsxMaxColWidth = 60
+)
+
+// 加密导出密码长度范围，上限与 XLSX 文件密码上限一致
+const (
+	exportPasswordMinLength = 6
+	exportPasswordMaxLength = 255
+)
+
+// utf8BOM 添加 BOM 以支持 Excel 中文显示
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// Normalize 校验导出格式，空值默认为 CSV，不支持的格式返回 ErrUnsupportedExportFormat
+func (f ExportFormat) Normalize() (ExportFormat, error) {
+	switch f {
+	case "", ExportFormatCSV:
+		return ExportFormatCSV, nil
+	case ExportFormatXLSX:
+		return ExportFormatXLSX, nil
+	default:
+		return "", errors.ErrUnsupportedExportFormat.WithMessage(fmt.Sprintf("不支持的导出格式: %s", string(f)))
+	}
+}
+
+// NormalizeProtected 校验带密码导出的格式：未设置密码时同 Normalize；
+// 设置密码时导出为加密 XLSX（ECMA-376 标准加密，AES-128），未指定格式时默认 XLSX，CSV 无法加密返回错误
+func (f ExportFormat) NormalizeProtected(password string) (ExportFormat, error) {
+	if password == "" {
+		return f.Normalize()
+	}
+	if n := utf8.RuneCountInString(password); n < exportPasswordMinLength || n > exportPasswordMaxLength {
+		return "", errors.ErrInvalidParams.WithMessage(fmt.Sprintf("导出密码长度需在%d-%d位之间", exportPasswordMinLength, exportPasswordMaxLength))
+	}
+	switch f {
+	case "", ExportFormatXLSX:
+		return ExportFormatXLSX, nil
+	case ExportFormatCSV:
+		return "", errors.ErrUnsupportedExportFormat.WithMessage("加密导出仅支持 xlsx 格式")
+	default:
+		return "", errors.ErrUnsupportedExportFormat.WithMessage(fmt.Sprintf("不支持的导出格式: %s", string(f)))
+	}
+}
+
+// ContentType 导出文件的 Content-Type
+func (f ExportFormat) ContentType() string {
+	if f == ExportFormatXLSX {
+		return xlsxContentType
+	}
+	return csvContentType
+}
+
+// exportFilename 生成导出文件名
+func exportFilename(name string, format ExportFormat) string {
+	if format == "" {
+		format = ExportFormatCSV
+	}
+	return fmt.Sprintf("%s.%s", name, format)
+}
+
+// renderExport 按格式生成导出文件
+// 单元格值支持 string、int、int64 和 float64，金额使用 float64，CSV 中保留两位小数
+func renderExport(format ExportFormat, headers []string, rows [][]interface{}) ([]byte, error) {
+	if format == ExportFormatXLSX {
+		return renderXLSX(headers, rows, "")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := writeCSV(buf, headers, rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderProtectedExport 按格式生成导出文件，设置密码时生成加密 XLSX，格式须先经 NormalizeProtected 校验
+func renderProtectedExport(format ExportFormat, password string, headers []string, rows [][]interface{}) ([]byte, error) {
+	if password == "" {
+		return renderExport(format, headers, rows)
+	}
+	return renderXLSX(headers, rows, password)
+}
+
+// writeCSV 写入带 BOM 的 CSV
+func writeCSV(w io.Writer, headers []string, rows [][]interface{}) error {
+	if _, err := w.Write(utf8BOM); err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(headers); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writer.Write(csvRecord(row)); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvRecord 将一行单元格值格式化为 CSV 记录
+func csvRecord(row []interface{}) []string {
+	record := make([]string, len(row))
+	for i, v := range row {
+		record[i] = formatCell(v)
+	}
+	return record
+}
+
+// formatCell 单元格值的文本形式
+func formatCell(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case float64:
+		return fmt.Sprintf("%.2f", val)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// renderXLSX 生成 XLSX：表头加粗并冻结首行，列宽按内容自适应，金额列保留两位小数
+// password 非空时文件需输入密码才能打开
+func renderXLSX(headers []string, rows [][]interface{}, password string) ([]byte, error) {
+	f := excelize.NewFile(excelize.Options{Password: password})
+	defer f.Close()
+
+	sheet, err := newXLSXSheetWriter(f, headers, xlsxColumnWidths(headers, rows))
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := sheet.writeRow(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := sheet.sw.Flush(); err != nil {
+		return nil, err
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// xlsxSheetWriter 按行流式写入工作表，excelize 在数据量较大时将已写入的行暂存到临时文件
+type xlsxSheetWriter struct {
+	sw          *excelize.StreamWriter
+	amountStyle int
+	nextRow     int
+}
+
+// newXLSXSheetWriter 创建工作表写入器并写入表头：表头加粗并冻结首行，按 widths 设置列宽
+func newXLSXSheetWriter(f *excelize.File, headers []string, widths []float64) (*xlsxSheetWriter, error) {
+	headerStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, err
+	}
+	amountStyle, err := f.NewStyle(&excelize.Style{NumFmt: 2}) // 0.00
+	if err != nil {
+		return nil, err
+	}
+
+	sw, err := f.NewStreamWriter(xlsxSheetName)
+	if err != nil {
+		return nil, err
+	}
+
+	// 列宽需在写入行之前设置
+	for i, width := range widths {
+		if err := sw.SetColWidth(i+1, i+1, width); err != nil {
+			return nil, err
+		}
+	}
+	if err := sw.SetPanes(&excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return nil, err
+	}
+
+	headerRow := make([]interface{}, len(headers))
+	for i, h := range headers {
+		headerRow[i] = excelize.Cell{StyleID: headerStyle, Value: h}
+	}
+	if err := sw.SetRow("A1", headerRow); err != nil {
+		return nil, err
+	}
+	return &xlsxSheetWriter{sw: sw, amountStyle: amountStyle, nextRow: 2}, nil
+}
+
+// writeRow 写入一行数据，金额列保留两位小数
+func (w *xlsxSheetWriter) writeRow(row []interface{}) error {
+	values := make([]interface{}, len(row))
+	for i, v := range row {
+		if amount, ok := v.(float64); ok {
+			values[i] = excelize.Cell{StyleID: w.amountStyle, Value: amount}
+			continue
+		}
+		values[i] = v
+	}
+	cell, err := excelize.CoordinatesToCellName(1, w.nextRow)
+	if err != nil {
+		return err
+	}
+	w.nextRow++
+	return w.sw.SetRow(cell, values)
+}
+
+// xlsxColumnWidths 按表头和单元格内容计算列宽，中文等宽字符按两个字符宽度计算
+func xlsxColumnWidths(headers []string, rows [][]interface{}) []float64 {
+	widths := make([]float64, len(headers))
+	measure := func(i int, s string) {
+		if i >= len(widths) {
+			return
+		}
+		w := 0
+		for _, r := range s {
+			if utf8.RuneLen(r) > 1 {
+				w += 2
+			} else {
+				w++
+			}
+		}
+		if width := float64(w + 2); width > widths[i] {
+			widths[i] = width
+		}
+	}
+
+	for i, h := range headers {
+		measure(i, h)
+	}
+	for _, row := range rows {
+		for i, v := range row {
+			measure(i, formatCell(v))
+		}
+	}
+
+	for i := range widths {
+		if widths[i] > xlsxMaxColWidth {
+			widths[i] = xlsxMaxColWidth
+		}
+	}
+	return widths
+}
@@ -0,0 +1,172 @@
+package finance
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// parseCSVExport 解析 CSV 导出内容（含 BOM），返回所有行（含表头）
+func parseCSVExport(t *testing.T, data []byte) [][]string {
+	t.Helper()
+
+	require.True(t, bytes.HasPrefix(data, utf8BOM), "CSV 导出应以 BOM 开头")
+	records, err := csv.NewReader(bytes.NewReader(data[len(utf8BOM):])).ReadAll()
+	require.NoError(t, err)
+	return records
+}
+
+// parseXLSXExport 解析 XLSX 导出内容，返回所有行（含表头）并校验表头样式、冻结首行和列宽
+func parseXLSXExport(t *testing.T, data []byte) [][]string {
+	t.Helper()
+
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	defer f.Close()
+
+	rows, err := f.GetRows(xlsxSheetName)
+	require.NoError(t, err)
+	require.NotEmpty(t, rows)
+
+	// 表头加粗
+	styleID, err := f.GetCellStyle(xlsxSheetName, "A1")
+	require.NoError(t, err)
+	style, err := f.GetStyle(styleID)
+	require.NoError(t, err)
+	require.NotNil(t, style.Font)
+	assert.True(t, style.Font.Bold)
+
+	// 冻结首行
+	panes, err := f.GetPanes(xlsxSheetName)
+	require.NoError(t, err)
+	assert.True(t, panes.Freeze)
+	assert.Equal(t, 1, panes.YSplit)
+
+	// 列宽不小于表头宽度
+	width, err := f.GetColWidth(xlsxSheetName, "A")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, width, float64(len([]rune(rows[0][0]))*2))
+
+	return rows
+}
+
+func TestExportFormat_Normalize(t *testing.T) {
+	format, err := ExportFormat("").Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, ExportFormatCSV, format)
+
+	format, err = ExportFormat("xlsx").Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, ExportFormatXLSX, format)
+	assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", format.ContentType())
+	assert.Equal(t, "text/csv; charset=utf-8", ExportFormatCSV.ContentType())
+
+	_, err = ExportFormat("pdf").Normalize()
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, appErrors.ErrUnsupportedExportFormat.Code, appErr.Code)
+}
+
+func TestExportService_Formats(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupExportService(db)
+	ctx := context.Background()
+
+	merchant := createTestMerchant(t, db, "格式导出商户")
+	for i := 0; i < 3; i++ {
+		createTestSettlement(t, db, models.SettlementTypeMerchant, merchant.ID, 1000.0, models.SettlementStatusPending)
+	}
+	user := createFinanceTestUser(t, db, "13800140101")
+	for i := 0; i < 2; i++ {
+		createTestWithdrawal(t, db, user.ID, 50.0, models.WithdrawalStatusPending)
+	}
+	seedWalletTransactions(t, svc, user.ID, 4)
+
+	startDate := time.Now().AddDate(0, 0, -2).Truncate(24 * time.Hour)
+	endDate := startDate.Add(2 * 24 * time.Hour)
+
+	exports := []struct {
+		name     string
+		rows     int
+		export   func(format ExportFormat) ([]byte, string, error)
+		filename string
+	}{
+		{"结算记录", 3, func(format ExportFormat) ([]byte, string, error) {
+			return svc.ExportSettlements(ctx, &ExportSettlementsRequest{Format: format})
+		}, "settlements_"},
+		{"提现记录", 2, func(format ExportFormat) ([]byte, string, error) {
+			return svc.ExportWithdrawals(ctx, &ExportWithdrawalsRequest{Format: format})
+		}, "withdrawals_"},
+		{"交易记录", 4, func(format ExportFormat) ([]byte, string, error) {
+			return svc.ExportTransactions(ctx, &ExportTransactionsRequest{Format: format})
+		}, "transactions_"},
+		{"每日收入", 3, func(format ExportFormat) ([]byte, string, error) {
+			return svc.ExportDailyRevenue(ctx, &ExportDailyRevenueRequest{StartDate: startDate, EndDate: endDate, Format: format})
+		}, "daily_revenue_"},
+	}
+
+	for _, tt := range exports {
+		t.Run(tt.name+"_CSV", func(t *testing.T) {
+			data, filename, err := tt.export(ExportFormatCSV)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(filename, tt.filename))
+			assert.True(t, strings.HasSuffix(filename, ".csv"))
+
+			records := parseCSVExport(t, data)
+			assert.Len(t, records, tt.rows+1)
+		})
+
+		t.Run(tt.name+"_XLSX", func(t *testing.T) {
+			data, filename, err := tt.export(ExportFormatXLSX)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(filename, tt.filename))
+			assert.True(t, strings.HasSuffix(filename, ".xlsx"))
+
+			csvData, _, err := tt.export(ExportFormatCSV)
+			require.NoError(t, err)
+			csvRecords := parseCSVExport(t, csvData)
+
+			rows := parseXLSXExport(t, data)
+			require.Len(t, rows, tt.rows+1)
+			// 表头与 CSV 一致
+			assert.Equal(t, csvRecords[0], rows[0])
+		})
+
+		t.Run(tt.name+"_不支持的格式", func(t *testing.T) {
+			_, _, err := tt.export("pdf")
+			appErr, ok := err.(*appErrors.AppError)
+			require.True(t, ok)
+			assert.Equal(t, appErrors.ErrUnsupportedExportFormat.Code, appErr.Code)
+		})
+	}
+}
+
+func TestRenderXLSX_AmountCells(t *testing.T) {
+	data, err := renderExport(ExportFormatXLSX, []string{"单号", "金额", "笔数"}, [][]interface{}{
+		{"A001", 12.5, 3},
+	})
+	require.NoError(t, err)
+
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	defer f.Close()
+
+	// 金额以数值写入并显示两位小数
+	value, err := f.GetCellValue(xlsxSheetName, "B2")
+	require.NoError(t, err)
+	assert.Equal(t, "12.50", value)
+	cellType, err := f.GetCellType(xlsxSheetName, "B2")
+	require.NoError(t, err)
+	assert.NotEqual(t, excelize.CellTypeSharedString, cellType)
+	assert.NotEqual(t, excelize.CellTypeInlineString, cellType)
+}
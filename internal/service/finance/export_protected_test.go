@@ -0,0 +1,123 @@
+package finance
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+func TestExportService_ExportWithdrawals_AccountMasking(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupExportService(db)
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800138088")
+	withdrawal := createTestWithdrawal(t, db, user.ID, 100, models.WithdrawalStatusPending)
+	require.NoError(t, db.Model(withdrawal).Update("account_info_encrypted", `{"account":"6222020200112233"}`).Error)
+
+	t.Run("默认仅保留后4位", func(t *testing.T) {
+		data, _, err := svc.ExportWithdrawals(ctx, &ExportWithdrawalsRequest{})
+		require.NoError(t, err)
+
+		content := string(data)
+		assert.Contains(t, content, "****8088")
+		assert.Contains(t, content, "****2233")
+		assert.NotContains(t, content, "13800138088")
+		assert.NotContains(t, content, "6222020200112233")
+	})
+
+	t.Run("有权限时导出完整信息", func(t *testing.T) {
+		data, _, err := svc.ExportWithdrawals(ctx, &ExportWithdrawalsRequest{FullAccountInfo: true})
+		require.NoError(t, err)
+
+		content := string(data)
+		assert.Contains(t, content, "13800138088")
+		assert.Contains(t, content, "6222020200112233")
+	})
+}
+
+func TestWithdrawalAccount(t *testing.T) {
+	assert.Equal(t, "test@alipay.com", withdrawalAccount(`{"account":"test@alipay.com"}`))
+	assert.Equal(t, "oXXXX_openid", withdrawalAccount(`{"openid":"oXXXX_openid"}`))
+	assert.Equal(t, "", withdrawalAccount(`{"name":"张三"}`))
+	assert.Equal(t, "raw_account", withdrawalAccount("raw_account"))
+
+	assert.Equal(t, "", maskExportTail(""))
+	assert.Equal(t, "****", maskExportTail("1234"))
+	assert.Equal(t, "****2345", maskExportTail("12345"))
+}
+
+func TestExportService_PasswordProtectedExport(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupExportService(db)
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800138089")
+	withdrawal := createTestWithdrawal(t, db, user.ID, 100, models.WithdrawalStatusPending)
+	settlement := createTestSettlement(t, db, models.SettlementTypeMerchant, 1, 100, models.SettlementStatusPending)
+
+	const password = "compliance-2026"
+	tests := []struct {
+		name   string
+		export func(format ExportFormat, password string) ([]byte, string, error)
+		want   string
+	}{
+		{
+			name: "结算记录",
+			export: func(format ExportFormat, password string) ([]byte, string, error) {
+				return svc.ExportSettlements(ctx, &ExportSettlementsRequest{Format: format, Password: password})
+			},
+			want: settlement.SettlementNo,
+		},
+		{
+			name: "提现记录",
+			export: func(format ExportFormat, password string) ([]byte, string, error) {
+				return svc.ExportWithdrawals(ctx, &ExportWithdrawalsRequest{Format: format, Password: password})
+			},
+			want: withdrawal.WithdrawalNo,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"_加密XLSX", func(t *testing.T) {
+			data, filename, err := tt.export("", password)
+			require.NoError(t, err)
+			assert.Contains(t, filename, ".xlsx")
+			assert.NotContains(t, string(data), tt.want)
+
+			// 无密码或密码错误无法打开
+			_, err = excelize.OpenReader(bytes.NewReader(data))
+			assert.Error(t, err)
+			_, err = excelize.OpenReader(bytes.NewReader(data), excelize.Options{Password: "wrong-password"})
+			assert.Error(t, err)
+
+			f, err := excelize.OpenReader(bytes.NewReader(data), excelize.Options{Password: password})
+			require.NoError(t, err)
+			defer f.Close()
+			value, err := f.GetCellValue(xlsxSheetName, "A2")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, value)
+		})
+
+		t.Run(tt.name+"_CSV不支持加密", func(t *testing.T) {
+			_, _, err := tt.export(ExportFormatCSV, password)
+			appErr, ok := err.(*appErrors.AppError)
+			require.True(t, ok)
+			assert.Equal(t, appErrors.ErrUnsupportedExportFormat.Code, appErr.Code)
+		})
+
+		t.Run(tt.name+"_密码过短", func(t *testing.T) {
+			_, _, err := tt.export(ExportFormatXLSX, "123")
+			appErr, ok := err.(*appErrors.AppError)
+			require.True(t, ok)
+			assert.Equal(t, appErrors.ErrInvalidParams.Code, appErr.Code)
+		})
+	}
+}
@@ -5,9 +5,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"time"
 
+	"github.com/xuri/excelize/v2"
 	"gorm.io/gorm"
 
 	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
@@ -22,6 +26,7 @@ type ExportService struct {
 	transactionRepo *repository.TransactionRepository
 	orderRepo       *repository.OrderRepository
 	withdrawalRepo  *repository.WithdrawalRepository
+	deviceStats     *DeviceStatisticsService
 }
 
 // NewExportService 创建报表导出服务
@@ -38,20 +43,28 @@ func NewExportService(
 		transactionRepo: transactionRepo,
 		orderRepo:       orderRepo,
 		withdrawalRepo:  withdrawalRepo,
+		deviceStats:     NewDeviceStatisticsService(db),
 	}
 }
 
 // ExportSettlementsRequest 导出结算记录请求
 type ExportSettlementsRequest struct {
-	Type        string     `form:"type"`
-	TargetID    *int64     `form:"target_id"`
-	Status      string     `form:"status"`
-	PeriodStart *time.Time `form:"period_start"`
-	PeriodEnd   *time.Time `form:"period_end"`
+	Type        string       `form:"type"`
+	TargetID    *int64       `form:"target_id"`
+	Status      string       `form:"status"`
+	PeriodStart *time.Time   `form:"period_start"`
+	PeriodEnd   *time.Time   `form:"period_end"`
+	Format      ExportFormat `form:"format"`   // 导出格式: csv/xlsx，默认 csv
+	Password    string       `form:"password"` // 导出密码，设置时导出为加密 XLSX
 }
 
-// ExportSettlements 导出结算记录为 CSV
+// ExportSettlements 导出结算记录为 CSV 或 XLSX
 func (s *ExportService) ExportSettlements(ctx context.Context, req *ExportSettlementsRequest) ([]byte, string, error) {
+	format, err := req.Format.NormalizeProtected(req.Password)
+	if err != nil {
+		return nil, "", err
+	}
+
 	// 查询数据
 	filter := &repository.SettlementFilter{
 		Type:        req.Type,
@@ -66,140 +79,277 @@ func (s *ExportService) ExportSettlements(ctx context.Context, req *ExportSettle
 		return nil, "", errors.ErrExportFailed.WithError(err)
 	}
 
-	// 生成 CSV
-	buf := new(bytes.Buffer)
-	// 添加 BOM 以支持 Excel 中文显示
-	buf.Write([]byte{0xEF, 0xBB, 0xBF})
-
-	writer := csv.NewWriter(buf)
-
-	// 写入表头
 	headers := []string{
 		"结算单号", "类型", "目标ID", "结算周期开始", "结算周期结束",
 		"总金额", "手续费", "实际金额", "订单数", "状态", "结算时间", "创建时间",
 	}
-	if err := writer.Write(headers); err != nil {
-		return nil, "", errors.ErrExportFailed.WithError(err)
-	}
 
-	// 写入数据
+	rows := make([][]interface{}, 0, len(settlements))
 	for _, settlement := range settlements {
 		settledAt := ""
 		if settlement.SettledAt != nil {
 			settledAt = settlement.SettledAt.Format("2006-01-02 15:04:05")
 		}
 
-		row := []string{
+		rows = append(rows, []interface{}{
 			settlement.SettlementNo,
 			getSettlementTypeName(settlement.Type),
-			fmt.Sprintf("%d", settlement.TargetID),
+			settlement.TargetID,
 			settlement.PeriodStart.Format("2006-01-02"),
 			settlement.PeriodEnd.Format("2006-01-02"),
-			fmt.Sprintf("%.2f", settlement.TotalAmount),
-			fmt.Sprintf("%.2f", settlement.Fee),
-			fmt.Sprintf("%.2f", settlement.ActualAmount),
-			fmt.Sprintf("%d", settlement.OrderCount),
+			settlement.TotalAmount,
+			settlement.Fee,
+			settlement.ActualAmount,
+			settlement.OrderCount,
 			getSettlementStatusName(settlement.Status),
 			settledAt,
 			settlement.CreatedAt.Format("2006-01-02 15:04:05"),
-		}
-		if err := writer.Write(row); err != nil {
-			return nil, "", errors.ErrExportFailed.WithError(err)
-		}
+		})
 	}
 
-	writer.Flush()
-	if err := writer.Error(); err != nil {
+	data, err := renderProtectedExport(format, req.Password, headers, rows)
+	if err != nil {
 		return nil, "", errors.ErrExportFailed.WithError(err)
 	}
 
-	filename := fmt.Sprintf("settlements_%s.csv", time.Now().Format("20060102150405"))
-	return buf.Bytes(), filename, nil
+	filename := exportFilename(fmt.Sprintf("settlements_%s", time.Now().Format("20060102150405")), format)
+	return data, filename, nil
 }
 
 // ExportTransactionsRequest 导出交易记录请求
 type ExportTransactionsRequest struct {
-	UserID    *int64     `form:"user_id"`
-	Type      string     `form:"type"`
-	StartTime *time.Time `form:"start_time"`
-	EndTime   *time.Time `form:"end_time"`
+	UserID    *int64       `form:"user_id"`
+	Type      string       `form:"type"`
+	StartTime *time.Time   `form:"start_time"`
+	EndTime   *time.Time   `form:"end_time"`
+	Format    ExportFormat `form:"format"` // 导出格式: csv/xlsx，默认 csv
+}
+
+// exportBatchSize 流式导出每批读取的记录数
+const exportBatchSize = 5000
+
+// MaxTransactionExportDays 交易记录单次导出的最大时间跨度（天），未指定开始时间时从结束时间往前取该跨度
+const MaxTransactionExportDays = 366
+
+// transactionExportHeaders 交易记录导出表头
+var transactionExportHeaders = []string{
+	"用户ID", "交易类型", "金额", "交易前余额", "交易后余额", "关联订单号", "备注", "创建时间",
 }
 
-// ExportTransactions 导出交易记录为 CSV
+// transactionExportColumnWidths 交易记录 XLSX 列宽，流式写入时无法预先按内容计算
+var transactionExportColumnWidths = []float64{10, 12, 12, 12, 12, 26, 40, 21}
+
+// ExportTransactions 导出交易记录为 CSV 或 XLSX，文件在内存中构建
+// 适用于结果集较小的场景，大批量导出请使用 StreamTransactions
 func (s *ExportService) ExportTransactions(ctx context.Context, req *ExportTransactionsRequest) ([]byte, string, error) {
-	// 查询数据
+	format, err := req.Format.Normalize()
+	if err != nil {
+		return nil, "", err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := s.StreamTransactions(ctx, req, buf); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), TransactionsExportFilename(time.Now(), format), nil
+}
+
+// TransactionsExportFilename 交易记录导出文件名
+func TransactionsExportFilename(now time.Time, format ExportFormat) string {
+	return exportFilename(fmt.Sprintf("transactions_%s", now.Format("20060102150405")), format)
+}
+
+// transactionExportRange 校验导出时间范围：未指定开始时间时取结束时间（默认当前时间）前 MaxTransactionExportDays 天，
+// 跨度超过 MaxTransactionExportDays 天时返回 ErrInvalidParams
+func transactionExportRange(req *ExportTransactionsRequest, now time.Time) (*time.Time, *time.Time, error) {
+	end := now
+	if req.EndTime != nil {
+		end = *req.EndTime
+	}
+	start := end.AddDate(0, 0, -MaxTransactionExportDays)
+	if req.StartTime != nil {
+		start = *req.StartTime
+	}
+	if start.After(end) {
+		return nil, nil, errors.ErrInvalidParams.WithMessage("开始时间不能晚于结束时间")
+	}
+	if end.Sub(start) > time.Duration(MaxTransactionExportDays)*24*time.Hour {
+		return nil, nil, errors.ErrInvalidParams.WithMessage(fmt.Sprintf("单次导出的时间范围不能超过%d天", MaxTransactionExportDays))
+	}
+	return &start, req.EndTime, nil
+}
+
+// StreamTransactions 流式导出交易记录为 CSV 或 XLSX
+// 按 ID 倒序每批读取 exportBatchSize 条，内存占用与导出总量无关：CSV 每批直接写入 w，
+// w 实现 http.Flusher 时每批写入后刷新；XLSX 逐批写入工作表（大数据量时暂存到临时文件），全部写完后输出到 w。
+// 时间范围先按 transactionExportRange 校验，首批数据查询成功后才开始写入，查询失败时 w 不会收到任何数据
+func (s *ExportService) StreamTransactions(ctx context.Context, req *ExportTransactionsRequest, w io.Writer) error {
+	format, err := req.Format.Normalize()
+	if err != nil {
+		return err
+	}
+	start, end, err := transactionExportRange(req, time.Now())
+	if err != nil {
+		return err
+	}
 	filter := &repository.TransactionFilter{
 		UserID:    req.UserID,
 		Type:      req.Type,
-		StartDate: req.StartTime,
-		EndDate:   req.EndTime,
+		StartDate: start,
+		EndDate:   end,
 	}
 
-	transactions, _, err := s.transactionRepo.List(ctx, filter, 0, 50000)
+	if format == ExportFormatXLSX {
+		err = s.streamTransactionsXLSX(ctx, filter, w)
+	} else {
+		err = s.streamTransactionsCSV(ctx, filter, w)
+	}
 	if err != nil {
-		return nil, "", errors.ErrExportFailed.WithError(err)
+		if appErr, ok := err.(*errors.AppError); ok {
+			return appErr
+		}
+		return errors.ErrExportFailed.WithError(err)
 	}
+	return nil
+}
 
-	// 生成 CSV
-	buf := new(bytes.Buffer)
-	buf.Write([]byte{0xEF, 0xBB, 0xBF})
+// streamTransactionsCSV 逐批写入 CSV
+func (s *ExportService) streamTransactionsCSV(ctx context.Context, filter *repository.TransactionFilter, w io.Writer) error {
+	var writer *csv.Writer
+	flusher, _ := w.(http.Flusher)
+
+	return s.eachTransactionBatch(ctx, filter, func(transactions []*models.WalletTransaction) error {
+		// 首批数据到达后写入 BOM 和表头
+		if writer == nil {
+			if _, err := w.Write(utf8BOM); err != nil {
+				return err
+			}
+			writer = csv.NewWriter(w)
+			if err := writer.Write(transactionExportHeaders); err != nil {
+				return err
+			}
+		}
 
-	writer := csv.NewWriter(buf)
+		for _, tx := range transactions {
+			if err := writer.Write(csvRecord(transactionExportRow(tx))); err != nil {
+				return err
+			}
+		}
 
-	// 写入表头
-	headers := []string{
-		"用户ID", "交易类型", "金额", "交易前余额", "交易后余额", "关联订单号", "备注", "创建时间",
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+}
+
+// streamTransactionsXLSX 逐批写入 XLSX 工作表，全部写完后输出文件
+func (s *ExportService) streamTransactionsXLSX(ctx context.Context, filter *repository.TransactionFilter, w io.Writer) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet, err := newXLSXSheetWriter(f, transactionExportHeaders, transactionExportColumnWidths)
+	if err != nil {
+		return err
 	}
-	if err := writer.Write(headers); err != nil {
-		return nil, "", errors.ErrExportFailed.WithError(err)
+	err = s.eachTransactionBatch(ctx, filter, func(transactions []*models.WalletTransaction) error {
+		for _, tx := range transactions {
+			if err := sheet.writeRow(transactionExportRow(tx)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// 写入数据
-	for _, tx := range transactions {
-		orderNo := ""
-		if tx.OrderNo != nil {
-			orderNo = *tx.OrderNo
+	if err := sheet.sw.Flush(); err != nil {
+		return err
+	}
+	_, err = f.WriteTo(w)
+	return err
+}
+
+// eachTransactionBatch 按 ID 倒序分批读取交易记录，每批调用一次 fn
+// 无数据时以空批次调用一次 fn，便于调用方输出表头；查询失败返回 ErrExportFailed
+func (s *ExportService) eachTransactionBatch(ctx context.Context, filter *repository.TransactionFilter, fn func([]*models.WalletTransaction) error) error {
+	transactions, err := s.transactionRepo.ListBatch(ctx, filter, 0, exportBatchSize)
+	if err != nil {
+		return errors.ErrExportFailed.WithError(err)
+	}
+
+	for {
+		if err := fn(transactions); err != nil {
+			return err
 		}
-		remark := ""
-		if tx.Remark != nil {
-			remark = *tx.Remark
+		if len(transactions) < exportBatchSize {
+			return nil
 		}
 
-		row := []string{
-			fmt.Sprintf("%d", tx.UserID),
-			getTransactionTypeName(tx.Type),
-			fmt.Sprintf("%.2f", tx.Amount),
-			fmt.Sprintf("%.2f", tx.BalanceBefore),
-			fmt.Sprintf("%.2f", tx.BalanceAfter),
-			orderNo,
-			remark,
-			tx.CreatedAt.Format("2006-01-02 15:04:05"),
+		// 客户端断开时停止导出
+		if err := ctx.Err(); err != nil {
+			return errors.ErrExportFailed.WithError(err)
 		}
-		if err := writer.Write(row); err != nil {
-			return nil, "", errors.ErrExportFailed.WithError(err)
+
+		lastID := transactions[len(transactions)-1].ID
+		transactions, err = s.transactionRepo.ListBatch(ctx, filter, lastID, exportBatchSize)
+		if err != nil {
+			return errors.ErrExportFailed.WithError(err)
+		}
+		if len(transactions) == 0 {
+			return nil
 		}
 	}
+}
 
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		return nil, "", errors.ErrExportFailed.WithError(err)
+// transactionExportRow 交易记录导出行
+func transactionExportRow(tx *models.WalletTransaction) []interface{} {
+	orderNo := ""
+	if tx.OrderNo != nil {
+		orderNo = *tx.OrderNo
+	}
+	remark := ""
+	if tx.Remark != nil {
+		remark = *tx.Remark
 	}
 
-	filename := fmt.Sprintf("transactions_%s.csv", time.Now().Format("20060102150405"))
-	return buf.Bytes(), filename, nil
+	return []interface{}{
+		tx.UserID,
+		getTransactionTypeName(tx.Type),
+		tx.Amount,
+		tx.BalanceBefore,
+		tx.BalanceAfter,
+		orderNo,
+		remark,
+		tx.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
 }
 
 // ExportWithdrawalsRequest 导出提现记录请求
 type ExportWithdrawalsRequest struct {
-	UserID    *int64 `form:"user_id"`
-	Type      string `form:"type"`
-	Status    string `form:"status"`
-	StartDate string `form:"start_date"`
-	EndDate   string `form:"end_date"`
+	UserID    *int64       `form:"user_id"`
+	Type      string       `form:"type"`
+	Status    string       `form:"status"`
+	StartDate string       `form:"start_date"`
+	EndDate   string       `form:"end_date"`
+	Format    ExportFormat `form:"format"`   // 导出格式: csv/xlsx，默认 csv
+	Password  string       `form:"password"` // 导出密码，设置时导出为加密 XLSX
+	// FullAccountInfo 导出完整的收款账户和手机号，默认仅保留后4位；由调用方按 finance:export:full 权限设置
+	FullAccountInfo bool `form:"-"`
 }
 
-// ExportWithdrawals 导出提现记录为 CSV
+// ExportWithdrawals 导出提现记录为 CSV 或 XLSX
 func (s *ExportService) ExportWithdrawals(ctx context.Context, req *ExportWithdrawalsRequest) ([]byte, string, error) {
+	format, err := req.Format.NormalizeProtected(req.Password)
+	if err != nil {
+		return nil, "", err
+	}
+
 	// 构建查询条件
 	filters := make(map[string]interface{})
 	if req.UserID != nil {
@@ -228,21 +378,11 @@ func (s *ExportService) ExportWithdrawals(ctx context.Context, req *ExportWithdr
 		return nil, "", errors.ErrExportFailed.WithError(err)
 	}
 
-	// 生成 CSV
-	buf := new(bytes.Buffer)
-	buf.Write([]byte{0xEF, 0xBB, 0xBF})
-
-	writer := csv.NewWriter(buf)
-
-	// 写入表头
 	headers := []string{
-		"提现单号", "用户ID", "提现类型", "申请金额", "手续费", "实际到账", "状态", "提现方式", "拒绝原因", "申请时间", "处理时间",
-	}
-	if err := writer.Write(headers); err != nil {
-		return nil, "", errors.ErrExportFailed.WithError(err)
+		"提现单号", "用户ID", "手机号", "提现类型", "申请金额", "手续费", "实际到账", "状态", "提现方式", "收款账户", "拒绝原因", "申请时间", "处理时间",
 	}
 
-	// 写入数据
+	rows := make([][]interface{}, 0, len(withdrawals))
 	for _, w := range withdrawals {
 		processedAt := ""
 		if w.ProcessedAt != nil {
@@ -252,42 +392,86 @@ func (s *ExportService) ExportWithdrawals(ctx context.Context, req *ExportWithdr
 		if w.RejectReason != nil {
 			rejectReason = *w.RejectReason
 		}
+		phone := ""
+		if w.User != nil && w.User.Phone != nil {
+			phone = *w.User.Phone
+		}
+		account := withdrawalAccount(w.AccountInfoEncrypted)
+		if !req.FullAccountInfo {
+			phone = maskExportTail(phone)
+			account = maskExportTail(account)
+		}
 
-		row := []string{
+		rows = append(rows, []interface{}{
 			w.WithdrawalNo,
-			fmt.Sprintf("%d", w.UserID),
+			w.UserID,
+			phone,
 			getWithdrawalTypeName(w.Type),
-			fmt.Sprintf("%.2f", w.Amount),
-			fmt.Sprintf("%.2f", w.Fee),
-			fmt.Sprintf("%.2f", w.ActualAmount),
+			w.Amount,
+			w.Fee,
+			w.ActualAmount,
 			getWithdrawalStatusName(w.Status),
 			getWithdrawToName(w.WithdrawTo),
+			account,
 			rejectReason,
 			w.CreatedAt.Format("2006-01-02 15:04:05"),
 			processedAt,
-		}
-		if err := writer.Write(row); err != nil {
-			return nil, "", errors.ErrExportFailed.WithError(err)
-		}
+		})
 	}
 
-	writer.Flush()
-	if err := writer.Error(); err != nil {
+	data, err := renderProtectedExport(format, req.Password, headers, rows)
+	if err != nil {
 		return nil, "", errors.ErrExportFailed.WithError(err)
 	}
 
-	filename := fmt.Sprintf("withdrawals_%s.csv", time.Now().Format("20060102150405"))
-	return buf.Bytes(), filename, nil
+	filename := exportFilename(fmt.Sprintf("withdrawals_%s", time.Now().Format("20060102150405")), format)
+	return data, filename, nil
+}
+
+// withdrawalAccountKeys 提现账户信息中依次尝试的收款账户字段
+var withdrawalAccountKeys = []string{"account", "card_no", "bank_account", "openid"}
+
+// withdrawalAccount 从提现账户信息（JSON）中取收款账户，无法解析时返回原文
+func withdrawalAccount(accountInfo string) string {
+	var info map[string]interface{}
+	if err := json.Unmarshal([]byte(accountInfo), &info); err != nil {
+		return accountInfo
+	}
+	for _, key := range withdrawalAccountKeys {
+		if v, ok := info[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// maskExportTail 导出脱敏：仅保留后4位，不超过4位时全部隐藏
+func maskExportTail(s string) string {
+	if s == "" {
+		return ""
+	}
+	runes := []rune(s)
+	if len(runes) <= 4 {
+		return "****"
+	}
+	return "****" + string(runes[len(runes)-4:])
 }
 
 // ExportDailyRevenueRequest 导出每日收入报表请求
 type ExportDailyRevenueRequest struct {
-	StartDate time.Time `form:"start_date" binding:"required"`
-	EndDate   time.Time `form:"end_date" binding:"required"`
+	StartDate time.Time    `form:"start_date" binding:"required"`
+	EndDate   time.Time    `form:"end_date" binding:"required"`
+	Format    ExportFormat `form:"format"` // 导出格式: csv/xlsx，默认 csv
 }
 
-// ExportDailyRevenue 导出每日收入报表为 CSV
-func (s *ExportService) ExportDailyRevenue(ctx context.Context, startDate, endDate time.Time) ([]byte, string, error) {
+// ExportDailyRevenue 导出每日收入报表为 CSV 或 XLSX
+func (s *ExportService) ExportDailyRevenue(ctx context.Context, req *ExportDailyRevenueRequest) ([]byte, string, error) {
+	format, err := req.Format.Normalize()
+	if err != nil {
+		return nil, "", err
+	}
+	startDate, endDate := req.StartDate, req.EndDate
+
 	var reports []models.DailyRevenueReport
 
 	// 按日期和订单类型统计
@@ -382,51 +566,93 @@ func (s *ExportService) ExportDailyRevenue(ctx context.Context, startDate, endDa
 		current = current.Add(24 * time.Hour)
 	}
 
-	// 生成 CSV
-	buf := new(bytes.Buffer)
-	buf.Write([]byte{0xEF, 0xBB, 0xBF})
-
-	writer := csv.NewWriter(buf)
-
-	// 写入表头
 	headers := []string{
 		"日期", "租借收入", "租借订单", "酒店收入", "酒店订单", "商城收入", "商城订单",
 		"总收入", "总订单", "退款金额", "退款笔数", "净收入",
 	}
-	if err := writer.Write(headers); err != nil {
-		return nil, "", errors.ErrExportFailed.WithError(err)
-	}
 
-	// 写入数据
+	records := make([][]interface{}, 0, len(reports))
 	for _, r := range reports {
-		row := []string{
+		records = append(records, []interface{}{
 			r.Date,
-			fmt.Sprintf("%.2f", r.RentalRevenue),
-			fmt.Sprintf("%d", r.RentalOrders),
-			fmt.Sprintf("%.2f", r.HotelRevenue),
-			fmt.Sprintf("%d", r.HotelOrders),
-			fmt.Sprintf("%.2f", r.MallRevenue),
-			fmt.Sprintf("%d", r.MallOrders),
-			fmt.Sprintf("%.2f", r.TotalRevenue),
-			fmt.Sprintf("%d", r.TotalOrders),
-			fmt.Sprintf("%.2f", r.RefundAmount),
-			fmt.Sprintf("%d", r.RefundCount),
-			fmt.Sprintf("%.2f", r.NetRevenue),
-		}
-		if err := writer.Write(row); err != nil {
-			return nil, "", errors.ErrExportFailed.WithError(err)
-		}
+			r.RentalRevenue,
+			r.RentalOrders,
+			r.HotelRevenue,
+			r.HotelOrders,
+			r.MallRevenue,
+			r.MallOrders,
+			r.TotalRevenue,
+			r.TotalOrders,
+			r.RefundAmount,
+			r.RefundCount,
+			r.NetRevenue,
+		})
+	}
+
+	data, err := renderExport(format, headers, records)
+	if err != nil {
+		return nil, "", errors.ErrExportFailed.WithError(err)
 	}
 
-	writer.Flush()
-	if err := writer.Error(); err != nil {
+	filename := exportFilename(fmt.Sprintf("daily_revenue_%s_%s",
+		startDate.Format("20060102"),
+		endDate.Format("20060102")), format)
+	return data, filename, nil
+}
+
+// ExportVenueUtilizationRequest 导出场地设备使用率报表请求
+type ExportVenueUtilizationRequest struct {
+	VenueID   int64        `form:"-"`
+	StartTime time.Time    `form:"start_date" binding:"required"`
+	EndTime   time.Time    `form:"end_date" binding:"required"`
+	Format    ExportFormat `form:"format"` // 导出格式: csv/xlsx，默认 csv
+}
+
+// ExportVenueUtilization 导出场地设备使用率报表，每台设备一行，末行为场地汇总
+func (s *ExportService) ExportVenueUtilization(ctx context.Context, req *ExportVenueUtilizationRequest) ([]byte, string, error) {
+	format, err := req.Format.Normalize()
+	if err != nil {
+		return nil, "", err
+	}
+
+	report, err := s.deviceStats.GetVenueUtilization(ctx, req.VenueID, req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, "", err
+	}
+
+	headers := []string{
+		"设备ID", "设备编号", "设备名称", "格口数", "可用时长(小时)", "租借时长(小时)", "租借次数", "收入", "占用率",
+	}
+
+	rows := make([][]interface{}, 0, len(report.Devices)+1)
+	for _, d := range report.Devices {
+		rows = append(rows, []interface{}{
+			d.DeviceID,
+			d.DeviceNo,
+			d.DeviceName,
+			d.SlotCount,
+			d.AvailableHours,
+			d.RentalHours,
+			d.RentalCount,
+			d.Revenue,
+			fmt.Sprintf("%.2f%%", d.OccupancyRate*100),
+		})
+	}
+	rows = append(rows, []interface{}{
+		"", "", "合计", "", report.AvailableHours, report.RentalHours, report.RentalCount, report.Revenue,
+		fmt.Sprintf("%.2f%%", report.OccupancyRate*100),
+	})
+
+	data, err := renderExport(format, headers, rows)
+	if err != nil {
 		return nil, "", errors.ErrExportFailed.WithError(err)
 	}
 
-	filename := fmt.Sprintf("daily_revenue_%s_%s.csv",
-		startDate.Format("20060102"),
-		endDate.Format("20060102"))
-	return buf.Bytes(), filename, nil
+	filename := exportFilename(fmt.Sprintf("venue_utilization_%d_%s_%s",
+		req.VenueID,
+		req.StartTime.Format("20060102"),
+		req.EndTime.Format("20060102")), format)
+	return data, filename, nil
 }
 
 // ExportMerchantSettlementReport 导出商户结算报表
@@ -567,6 +793,8 @@ func getTransactionTypeName(t string) string {
 		return "押金"
 	case models.WalletTxTypeReturnDeposit:
 		return "退还押金"
+	case models.WalletTxTypeCommissionClawback:
+		return "佣金追回"
 	default:
 		return t
 	}
@@ -0,0 +1,173 @@
+package finance
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// flushRecorder 记录每次刷新时已写入字节数的写入器
+type flushRecorder struct {
+	bytes.Buffer
+	writes  int
+	flushes []int
+}
+
+func (w *flushRecorder) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func (w *flushRecorder) Flush() {
+	w.flushes = append(w.flushes, w.Len())
+}
+
+// seedWalletTransactions 批量创建钱包交易记录
+func seedWalletTransactions(t *testing.T, svc *ExportService, userID int64, count int) {
+	t.Helper()
+
+	const chunk = 1000
+	for i := 0; i < count; i += chunk {
+		batch := make([]*models.WalletTransaction, 0, chunk)
+		for j := i; j < i+chunk && j < count; j++ {
+			batch = append(batch, &models.WalletTransaction{
+				UserID:        userID,
+				Type:          models.WalletTxTypeRecharge,
+				Amount:        1,
+				BalanceBefore: float64(j),
+				BalanceAfter:  float64(j + 1),
+				Remark:        utils.StringPtr("批量充值"),
+			})
+		}
+		require.NoError(t, svc.db.CreateInBatches(batch, 200).Error)
+	}
+}
+
+func TestExportService_StreamTransactions_Incremental(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	svc := setupExportService(db)
+	ctx := context.Background()
+
+	const total = 50000
+	user := createFinanceTestUser(t, db, "13800140010")
+	seedWalletTransactions(t, svc, user.ID, total)
+
+	w := &flushRecorder{}
+	require.NoError(t, svc.StreamTransactions(ctx, &ExportTransactionsRequest{}, w))
+
+	// 每批写入后刷新一次，已写入数据量逐批增长，说明数据是分批输出而非一次性生成
+	require.Len(t, w.flushes, total/exportBatchSize)
+	for i := 1; i < len(w.flushes); i++ {
+		assert.Greater(t, w.flushes[i], w.flushes[i-1])
+	}
+	assert.Less(t, w.flushes[0], w.Len()/2)
+	assert.Greater(t, w.writes, len(w.flushes))
+
+	// 输出完整且不重复
+	records, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(w.Bytes(), []byte{0xEF, 0xBB, 0xBF}))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, total+1)
+	assert.Equal(t, "用户ID", records[0][0])
+	assert.Equal(t, "50000.00", records[1][4]) // 按 ID 倒序，首行为最新一条
+	assert.Equal(t, "1.00", records[total][4])
+}
+
+func TestExportService_StreamTransactions_Filter(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupExportService(db)
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800140011")
+	other := createFinanceTestUser(t, db, "13800140012")
+	seedWalletTransactions(t, svc, user.ID, 3)
+	seedWalletTransactions(t, svc, other.ID, 2)
+
+	w := &flushRecorder{}
+	require.NoError(t, svc.StreamTransactions(ctx, &ExportTransactionsRequest{UserID: &other.ID}, w))
+
+	records, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(w.Bytes(), []byte{0xEF, 0xBB, 0xBF}))).ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Len(t, w.flushes, 1)
+}
+
+func TestExportService_StreamTransactions_Empty(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupExportService(db)
+
+	w := &flushRecorder{}
+	require.NoError(t, svc.StreamTransactions(context.Background(), &ExportTransactionsRequest{}, w))
+
+	records, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(w.Bytes(), []byte{0xEF, 0xBB, 0xBF}))).ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+}
+
+func TestExportService_StreamTransactions_XLSX(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupExportService(db)
+	ctx := context.Background()
+
+	// 跨多个批次写入同一工作表
+	total := exportBatchSize*2 + 10
+	user := createFinanceTestUser(t, db, "13800140013")
+	seedWalletTransactions(t, svc, user.ID, total)
+
+	w := &flushRecorder{}
+	require.NoError(t, svc.StreamTransactions(ctx, &ExportTransactionsRequest{Format: ExportFormatXLSX}, w))
+
+	rows := parseXLSXExport(t, w.Bytes())
+	require.Len(t, rows, total+1)
+	assert.Equal(t, transactionExportHeaders, rows[0])
+	assert.Equal(t, "10010.00", rows[1][4])
+	assert.Equal(t, "1.00", rows[total][4])
+	assert.Empty(t, w.flushes)
+}
+
+func TestExportService_StreamTransactions_DateRange(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupExportService(db)
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800140014")
+	seedWalletTransactions(t, svc, user.ID, 2)
+	// 超过默认导出范围的历史记录
+	require.NoError(t, db.Model(&models.WalletTransaction{}).Where("balance_before = ?", 0).
+		Update("created_at", time.Now().AddDate(0, 0, -MaxTransactionExportDays-1)).Error)
+
+	t.Run("未指定开始时间时只导出最近的记录", func(t *testing.T) {
+		w := &flushRecorder{}
+		require.NoError(t, svc.StreamTransactions(ctx, &ExportTransactionsRequest{}, w))
+		records, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(w.Bytes(), utf8BOM))).ReadAll()
+		require.NoError(t, err)
+		assert.Len(t, records, 2)
+	})
+
+	t.Run("时间范围超出上限", func(t *testing.T) {
+		start := time.Now().AddDate(-2, 0, 0)
+		w := &flushRecorder{}
+		err := svc.StreamTransactions(ctx, &ExportTransactionsRequest{StartTime: &start}, w)
+		require.Error(t, err)
+		assert.Equal(t, appErrors.ErrInvalidParams.Code, err.(*appErrors.AppError).Code)
+		assert.Zero(t, w.Len())
+	})
+
+	t.Run("开始时间晚于结束时间", func(t *testing.T) {
+		start, end := time.Now(), time.Now().AddDate(0, 0, -1)
+		_, _, err := svc.ExportTransactions(ctx, &ExportTransactionsRequest{StartTime: &start, EndTime: &end})
+		require.Error(t, err)
+	})
+}
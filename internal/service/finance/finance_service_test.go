@@ -13,6 +13,7 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 )
@@ -31,16 +32,21 @@ func setupFinanceTestDB(t *testing.T) *gorm.DB {
 		&models.MemberLevel{},
 		&models.Payment{},
 		&models.Refund{},
+		&models.OrderNote{},
 		&models.Order{},
 		&models.Merchant{},
 		&models.Venue{},
 		&models.Device{},
 		&models.Rental{},
 		&models.Settlement{},
+		&models.SettlementItem{},
 		&models.Commission{},
 		&models.Distributor{},
 		&models.Withdrawal{},
+		&models.WithdrawalAuditLog{},
 		&models.WalletTransaction{},
+		&models.ReconciliationIssue{},
+		&models.FinanceDailySummary{},
 	))
 
 	db.Create(&models.MemberLevel{ID: 1, Name: "普通会员", Level: 1, MinPoints: 0, Discount: 1.0})
@@ -218,7 +224,7 @@ func setupSettlementService(db *gorm.DB) *SettlementService {
 	commissionRepo := repository.NewCommissionRepository(db)
 	distributorRepo := repository.NewDistributorRepository(db)
 
-	return NewSettlementService(db, settlementRepo, orderRepo, merchantRepo, commissionRepo, distributorRepo)
+	return NewSettlementService(db, settlementRepo, orderRepo, merchantRepo, commissionRepo, distributorRepo, nil)
 }
 
 // ================== StatisticsService Tests ==================
@@ -420,9 +426,9 @@ func TestSettlementService_ProcessSettlement(t *testing.T) {
 	svc := setupSettlementService(db)
 	ctx := context.Background()
 
-	t.Run("处理待结算状态记录", func(t *testing.T) {
+	t.Run("处理审核通过的记录", func(t *testing.T) {
 		merchant := createTestMerchant(t, db, "测试商户2")
-		settlement := createTestSettlement(t, db, models.SettlementTypeMerchant, merchant.ID, 1000.0, models.SettlementStatusPending)
+		settlement := createTestSettlement(t, db, models.SettlementTypeMerchant, merchant.ID, 1000.0, models.SettlementStatusApproved)
 
 		err := svc.ProcessSettlement(ctx, settlement.ID, 1)
 		require.NoError(t, err)
@@ -433,12 +439,38 @@ func TestSettlementService_ProcessSettlement(t *testing.T) {
 		assert.Equal(t, models.SettlementStatusCompleted, updated.Status)
 	})
 
-	t.Run("处理非待结算状态记录失败", func(t *testing.T) {
+	t.Run("处理非审核通过状态记录失败", func(t *testing.T) {
 		merchant := createTestMerchant(t, db, "测试商户3")
-		settlement := createTestSettlement(t, db, models.SettlementTypeMerchant, merchant.ID, 1000.0, models.SettlementStatusCompleted)
+		for _, status := range []string{models.SettlementStatusPending, models.SettlementStatusReviewing, models.SettlementStatusCompleted} {
+			settlement := createTestSettlement(t, db, models.SettlementTypeMerchant, merchant.ID, 1000.0, status)
 
-		err := svc.ProcessSettlement(ctx, settlement.ID, 1)
-		assert.Error(t, err)
+			err := svc.ProcessSettlement(ctx, settlement.ID, 1)
+			assertSettlementErrorCode(t, err, appErrors.ErrSettlementStatus.Code)
+
+			var unchanged models.Settlement
+			require.NoError(t, db.First(&unchanged, settlement.ID).Error)
+			assert.Equal(t, status, unchanged.Status)
+		}
+	})
+
+	t.Run("审核流程上线前的待结算记录直接入账", func(t *testing.T) {
+		merchant := createTestMerchant(t, db, "测试商户4")
+		settlement := createTestSettlement(t, db, models.SettlementTypeMerchant, merchant.ID, 1000.0, models.SettlementStatusPending)
+		require.NoError(t, db.Model(settlement).Update("legacy_pending", true).Error)
+
+		err := svc.ProcessSettlement(ctx, settlement.ID, 7)
+		require.NoError(t, err)
+
+		var updated models.Settlement
+		require.NoError(t, db.First(&updated, settlement.ID).Error)
+		assert.Equal(t, models.SettlementStatusCompleted, updated.Status)
+		assert.NotNil(t, updated.SettledAt)
+		require.NotNil(t, updated.OperatorID)
+		assert.Equal(t, int64(7), *updated.OperatorID)
+
+		// 重复处理不会再次入账
+		err = svc.ProcessSettlement(ctx, settlement.ID, 7)
+		assertSettlementErrorCode(t, err, appErrors.ErrSettlementStatus.Code)
 	})
 
 	t.Run("处理不存在的结算失败", func(t *testing.T) {
@@ -470,7 +502,7 @@ func TestSettlementService_ProcessDistributorSettlement(t *testing.T) {
 		Fee:          0,
 		ActualAmount: 10.0,
 		OrderCount:   1,
-		Status:       models.SettlementStatusPending,
+		Status:       models.SettlementStatusApproved,
 	}
 	require.NoError(t, db.Create(settlement).Error)
 
@@ -548,6 +580,33 @@ func TestSettlementService_GenerateMerchantSettlements(t *testing.T) {
 	assert.NotNil(t, settlements)
 }
 
+func TestSettlementService_CalculateMerchantSettlement_DeletedDevice(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupSettlementService(db)
+	ctx := context.Background()
+
+	merchant := createTestMerchant(t, db, "设备已删除商户")
+	venue := createTestVenue(t, db, merchant.ID, "测试场地")
+	device := createTestDevice(t, db, venue.ID, "DEV_REMOVED")
+
+	user := createFinanceTestUser(t, db, "13800138015")
+	order := createTestOrder(t, db, user.ID, 80.0, models.OrderStatusCompleted)
+	require.NoError(t, db.Create(&models.Rental{
+		OrderID:  order.ID,
+		UserID:   user.ID,
+		DeviceID: device.ID,
+		Status:   models.RentalStatusCompleted,
+	}).Error)
+
+	// 设备在结算前被软删除，周期内的租借收入仍应计入商户结算
+	require.NoError(t, db.Delete(&models.Device{}, device.ID).Error)
+
+	items, total, err := svc.calculateMerchantSettlement(ctx, merchant.ID, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 80.0, total)
+	assert.Len(t, items, 1)
+}
+
 func TestSettlementService_GenerateDistributorSettlements(t *testing.T) {
 	db := setupFinanceTestDB(t)
 	svc := setupSettlementService(db)
@@ -850,7 +909,7 @@ func TestExportService_ExportDailyRevenue(t *testing.T) {
 	startDate := time.Now().Add(-7 * 24 * time.Hour)
 	endDate := time.Now().Add(time.Hour)
 
-	data, filename, err := svc.ExportDailyRevenue(ctx, startDate, endDate)
+	data, filename, err := svc.ExportDailyRevenue(ctx, &ExportDailyRevenueRequest{StartDate: startDate, EndDate: endDate})
 	require.NoError(t, err)
 	assert.NotNil(t, data)
 	assert.NotEmpty(t, filename)
@@ -0,0 +1,113 @@
+package finance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// merchantSettlementExportLimit 商户结算单导出的最大结算单数
+const merchantSettlementExportLimit = 1000
+
+// merchantSettlementExportHeaders 商户结算单导出表头，每行为结算单中的一笔订单
+var merchantSettlementExportHeaders = []string{
+	"结算单号", "结算周期开始", "结算周期结束", "结算状态", "结算总金额", "手续费", "实际结算金额",
+	"订单号", "订单完成时间", "订单金额", "佣金比例", "商户应得",
+}
+
+// MerchantSettlementService 商户自助结算查询服务
+// 结算类型和目标 ID 始终取自认证的商户，忽略请求中的取值，商户只能查询自己的结算单
+type MerchantSettlementService struct {
+	settlementRepo *repository.SettlementRepository
+}
+
+// NewMerchantSettlementService 创建商户自助结算查询服务
+func NewMerchantSettlementService(settlementRepo *repository.SettlementRepository) *MerchantSettlementService {
+	return &MerchantSettlementService{settlementRepo: settlementRepo}
+}
+
+// GetOwnSettlements 获取商户自己的结算单列表
+func (s *MerchantSettlementService) GetOwnSettlements(ctx context.Context, merchantID int64, req *SettlementListRequest) ([]*models.Settlement, int64, error) {
+	filter, err := ownSettlementFilter(merchantID, req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	return s.settlementRepo.List(ctx, filter, offset, req.PageSize)
+}
+
+// ExportOwnSettlements 导出商户自己的结算单，每笔计入结算的订单一行，没有订单明细的结算单单独一行
+// 最多导出 merchantSettlementExportLimit 张结算单，忽略请求中的分页参数
+func (s *MerchantSettlementService) ExportOwnSettlements(ctx context.Context, merchantID int64, req *SettlementListRequest, format ExportFormat) ([]byte, string, error) {
+	format, err := format.Normalize()
+	if err != nil {
+		return nil, "", err
+	}
+
+	filter, err := ownSettlementFilter(merchantID, req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	settlements, _, err := s.settlementRepo.List(ctx, filter, 0, merchantSettlementExportLimit)
+	if err != nil {
+		return nil, "", errors.ErrExportFailed.WithError(err)
+	}
+
+	var rows [][]interface{}
+	for _, settlement := range settlements {
+		summary := []interface{}{
+			settlement.SettlementNo,
+			settlement.PeriodStart.Format("2006-01-02"),
+			settlement.PeriodEnd.Format("2006-01-02"),
+			getSettlementStatusName(settlement.Status),
+			settlement.TotalAmount,
+			settlement.Fee,
+			settlement.ActualAmount,
+		}
+
+		// limit 为 -1 时不限制条数，导出结算单的全部订单明细
+		items, _, err := s.settlementRepo.ListItems(ctx, settlement.ID, 0, -1)
+		if err != nil {
+			return nil, "", errors.ErrExportFailed.WithError(err)
+		}
+		if len(items) == 0 {
+			rows = append(rows, append(summary, "", "", "", "", ""))
+			continue
+		}
+		for _, item := range items {
+			row := append(append([]interface{}{}, summary...),
+				item.OrderNo,
+				item.CompletedAt.Format("2006-01-02 15:04:05"),
+				item.Amount,
+				fmt.Sprintf("%.2f%%", item.CommissionRate*100),
+				item.MerchantShare,
+			)
+			rows = append(rows, row)
+		}
+	}
+
+	data, err := renderExport(format, merchantSettlementExportHeaders, rows)
+	if err != nil {
+		return nil, "", errors.ErrExportFailed.WithError(err)
+	}
+
+	filename := exportFilename(fmt.Sprintf("merchant_%d_settlements_%s", merchantID, time.Now().Format("20060102150405")), format)
+	return data, filename, nil
+}
+
+// ownSettlementFilter 构造限定为指定商户结算单的查询条件
+func ownSettlementFilter(merchantID int64, req *SettlementListRequest) (*repository.SettlementFilter, error) {
+	filter, err := buildSettlementFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	filter.Type = models.SettlementTypeMerchant
+	filter.TargetID = &merchantID
+	return filter, nil
+}
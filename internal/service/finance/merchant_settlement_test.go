@@ -0,0 +1,100 @@
+package finance
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+func TestMerchantSettlementService_GetOwnSettlements(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := NewMerchantSettlementService(repository.NewSettlementRepository(db))
+	ctx := context.Background()
+
+	merchantA := createTestMerchant(t, db, "商户A")
+	merchantB := createTestMerchant(t, db, "商户B")
+	own := createTestSettlement(t, db, models.SettlementTypeMerchant, merchantA.ID, 100, models.SettlementStatusPending)
+	createTestSettlement(t, db, models.SettlementTypeMerchant, merchantB.ID, 200, models.SettlementStatusPending)
+	// 与商户 ID 相同的分销商结算不属于该商户
+	createTestSettlement(t, db, models.SettlementTypeDistributor, merchantA.ID, 300, models.SettlementStatusPending)
+
+	t.Run("只返回自己的结算单", func(t *testing.T) {
+		list, total, err := svc.GetOwnSettlements(ctx, merchantA.ID, &SettlementListRequest{Page: 1, PageSize: 20})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, list, 1)
+		assert.Equal(t, own.ID, list[0].ID)
+	})
+
+	t.Run("请求中的类型和目标ID被忽略", func(t *testing.T) {
+		otherID := merchantB.ID
+		list, total, err := svc.GetOwnSettlements(ctx, merchantA.ID, &SettlementListRequest{
+			Type:     models.SettlementTypeDistributor,
+			TargetID: &otherID,
+			Page:     1,
+			PageSize: 20,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, list, 1)
+		assert.Equal(t, merchantA.ID, list[0].TargetID)
+		assert.Equal(t, models.SettlementTypeMerchant, list[0].Type)
+	})
+
+	t.Run("按结算周期筛选", func(t *testing.T) {
+		future := time.Now().AddDate(0, 0, 7).Format("2006-01-02")
+		list, total, err := svc.GetOwnSettlements(ctx, merchantA.ID, &SettlementListRequest{PeriodStart: future, Page: 1, PageSize: 20})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+		assert.Empty(t, list)
+	})
+}
+
+func TestMerchantSettlementService_ExportOwnSettlements(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := NewMerchantSettlementService(repository.NewSettlementRepository(db))
+	ctx := context.Background()
+
+	merchantA := createTestMerchant(t, db, "商户A")
+	merchantB := createTestMerchant(t, db, "商户B")
+	withItems := createTestSettlement(t, db, models.SettlementTypeMerchant, merchantA.ID, 80, models.SettlementStatusCompleted)
+	empty := createTestSettlement(t, db, models.SettlementTypeMerchant, merchantA.ID, 0, models.SettlementStatusPending)
+	other := createTestSettlement(t, db, models.SettlementTypeMerchant, merchantB.ID, 50, models.SettlementStatusPending)
+
+	completedAt := time.Now().Add(-time.Hour)
+	require.NoError(t, db.Create([]*models.SettlementItem{
+		{SettlementID: withItems.ID, OrderID: 1, OrderNo: "ORD_A_1", CompletedAt: completedAt, Amount: 30, CommissionRate: 0.1, MerchantShare: 27},
+		{SettlementID: withItems.ID, OrderID: 2, OrderNo: "ORD_A_2", CompletedAt: completedAt, Amount: 50, CommissionRate: 0.1, MerchantShare: 45},
+		{SettlementID: other.ID, OrderID: 3, OrderNo: "ORD_B_1", CompletedAt: completedAt, Amount: 50, CommissionRate: 0.1, MerchantShare: 45},
+	}).Error)
+
+	data, filename, err := svc.ExportOwnSettlements(ctx, merchantA.ID, &SettlementListRequest{}, ExportFormatCSV)
+	require.NoError(t, err)
+	assert.Contains(t, filename, ".csv")
+
+	records, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4) // 表头 + 两笔订单 + 无明细的结算单
+	assert.Equal(t, merchantSettlementExportHeaders, records[0])
+
+	orderNos := map[string]string{}
+	for _, record := range records[1:] {
+		assert.NotEqual(t, other.SettlementNo, record[0])
+		orderNos[record[7]] = record[0]
+	}
+	assert.Equal(t, withItems.SettlementNo, orderNos["ORD_A_1"])
+	assert.Equal(t, withItems.SettlementNo, orderNos["ORD_A_2"])
+	assert.Equal(t, empty.SettlementNo, orderNos[""])
+	assert.NotContains(t, string(data), "ORD_B_1")
+
+	_, _, err = svc.ExportOwnSettlements(ctx, merchantA.ID, &SettlementListRequest{}, ExportFormat("pdf"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,262 @@
+package finance
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/cache"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+const (
+	// financeOverviewCacheKey 财务概览缓存键
+	financeOverviewCacheKey = "finance:overview"
+	// financeOverviewCacheTTL 财务概览缓存有效期
+	financeOverviewCacheTTL = 30 * time.Second
+	// financeSummaryRecheckDays 每次汇总时重新计算的最近已汇总天数，吸收跨天后才写入入账时间的记录
+	financeSummaryRecheckDays = 2
+)
+
+// overviewTotals 财务概览累计金额
+type overviewTotals struct {
+	Revenue    float64
+	Refund     float64
+	Commission float64
+	Settlement float64
+}
+
+// overviewTimeScope 按入账时间列限定统计范围
+type overviewTimeScope func(db *gorm.DB, timeColumn string) *gorm.DB
+
+// sumOverviewTotals 按财务概览口径汇总累计金额：支付成功金额、退款成功金额、已结算佣金、已完成结算的实际金额
+func sumOverviewTotals(db *gorm.DB, scope overviewTimeScope) (*overviewTotals, error) {
+	totals := &overviewTotals{}
+	metrics := []struct {
+		model      interface{}
+		status     interface{}
+		amount     string
+		timeColumn string
+		dest       *float64
+	}{
+		{&models.Payment{}, models.PaymentStatusSuccess, "amount", "pay_time", &totals.Revenue},
+		{&models.Refund{}, models.RefundStatusSuccess, "amount", "refunded_at", &totals.Refund},
+		{&models.Commission{}, models.CommissionStatusSettled, "amount", "settled_at", &totals.Commission},
+		{&models.Settlement{}, models.SettlementStatusCompleted, "actual_amount", "settled_at", &totals.Settlement},
+	}
+
+	for _, metric := range metrics {
+		query := db.Model(metric.model).Where("status = ?", metric.status)
+		if scope != nil {
+			query = scope(query, metric.timeColumn)
+		}
+		if err := query.Select(fmt.Sprintf("COALESCE(SUM(%s), 0)", metric.amount)).Row().Scan(metric.dest); err != nil {
+			return nil, err
+		}
+	}
+	return totals, nil
+}
+
+// overviewTotals 获取财务概览累计金额：已汇总日期读取日汇总，之后（含今日）及入账时间为空的记录实时统计
+func (s *StatisticsService) overviewTotals(ctx context.Context) (*overviewTotals, error) {
+	db := s.db.WithContext(ctx)
+
+	coveredUntil, err := s.summaryCoveredUntil(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if coveredUntil == nil {
+		return sumOverviewTotals(db, nil)
+	}
+
+	summarized := &overviewTotals{}
+	err = db.Model(&models.FinanceDailySummary{}).
+		Select("COALESCE(SUM(revenue), 0), COALESCE(SUM(refund), 0), COALESCE(SUM(commission), 0), COALESCE(SUM(settlement), 0)").
+		Row().Scan(&summarized.Revenue, &summarized.Refund, &summarized.Commission, &summarized.Settlement)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := sumOverviewTotals(db, func(query *gorm.DB, timeColumn string) *gorm.DB {
+		return query.Where(fmt.Sprintf("(%s >= ? OR %s IS NULL)", timeColumn, timeColumn), *coveredUntil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &overviewTotals{
+		Revenue:    roundAmount(summarized.Revenue + live.Revenue),
+		Refund:     roundAmount(summarized.Refund + live.Refund),
+		Commission: roundAmount(summarized.Commission + live.Commission),
+		Settlement: roundAmount(summarized.Settlement + live.Settlement),
+	}, nil
+}
+
+// summaryCoveredUntil 日汇总覆盖的截止时间（不含），即最后一个汇总日期的次日零点；尚无日汇总时返回 nil
+func (s *StatisticsService) summaryCoveredUntil(ctx context.Context) (*time.Time, error) {
+	var last models.FinanceDailySummary
+	err := s.db.WithContext(ctx).Order("biz_date DESC").First(&last).Error
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, end := reconciliationDay(last.BizDate)
+	return &end, nil
+}
+
+// AggregateDailySummaries 汇总截至 now 前一日的财务日汇总，返回本次汇总的天数
+// 从最后一个汇总日期往前 financeSummaryRecheckDays 天开始重新计算，首次汇总从最早的入账日期开始，保证汇总日期连续
+func (s *StatisticsService) AggregateDailySummaries(ctx context.Context, now time.Time) (int, error) {
+	today, _ := reconciliationDay(now)
+
+	var start time.Time
+	coveredUntil, err := s.summaryCoveredUntil(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if coveredUntil != nil {
+		start = coveredUntil.AddDate(0, 0, -financeSummaryRecheckDays)
+	} else {
+		earliest, err := s.earliestOverviewTime(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if earliest == nil {
+			start = today.AddDate(0, 0, -1)
+		} else {
+			start, _ = reconciliationDay(*earliest)
+		}
+	}
+
+	days := 0
+	for day := start; day.Before(today); day = day.AddDate(0, 0, 1) {
+		if err := s.aggregateDay(ctx, day); err != nil {
+			return days, err
+		}
+		days++
+	}
+	if days > 0 {
+		invalidateFinanceOverviewCache(ctx)
+	}
+	return days, nil
+}
+
+// InvalidateOrderOverview 订单退款完成后重新计算受影响日期的日汇总并清除财务概览缓存
+// 全额退款会将历史支付记录变更为已退款，佣金重算会调整历史已结算佣金，需按原入账日期重新汇总
+func (s *StatisticsService) InvalidateOrderOverview(ctx context.Context, orderID int64) error {
+	defer invalidateFinanceOverviewCache(ctx)
+
+	coveredUntil, err := s.summaryCoveredUntil(ctx)
+	if err != nil || coveredUntil == nil {
+		return err
+	}
+
+	var times []*time.Time
+	db := s.db.WithContext(ctx)
+	if err := db.Model(&models.Payment{}).Where("order_id = ? AND pay_time < ?", orderID, *coveredUntil).
+		Pluck("pay_time", &times).Error; err != nil {
+		return err
+	}
+	var commissionTimes []*time.Time
+	if err := db.Model(&models.Commission{}).Where("order_id = ? AND settled_at < ?", orderID, *coveredUntil).
+		Pluck("settled_at", &commissionTimes).Error; err != nil {
+		return err
+	}
+
+	refreshed := make(map[string]bool)
+	for _, t := range append(times, commissionTimes...) {
+		if t == nil {
+			continue
+		}
+		day, _ := reconciliationDay(*t)
+		key := day.Format("2006-01-02")
+		if refreshed[key] {
+			continue
+		}
+		if err := s.aggregateDay(ctx, day); err != nil {
+			return err
+		}
+		refreshed[key] = true
+	}
+	return nil
+}
+
+// aggregateDay 重新生成指定日期的日汇总
+func (s *StatisticsService) aggregateDay(ctx context.Context, day time.Time) error {
+	start, end := reconciliationDay(day)
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		totals, err := sumOverviewTotals(tx, func(query *gorm.DB, timeColumn string) *gorm.DB {
+			return query.Where(fmt.Sprintf("%s >= ? AND %s < ?", timeColumn, timeColumn), start, end)
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("biz_date >= ? AND biz_date < ?", start, end).Delete(&models.FinanceDailySummary{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.FinanceDailySummary{
+			BizDate:    start,
+			Revenue:    totals.Revenue,
+			Refund:     totals.Refund,
+			Commission: totals.Commission,
+			Settlement: totals.Settlement,
+		}).Error
+	})
+}
+
+// earliestOverviewTime 最早的入账时间，没有任何入账记录时返回 nil
+func (s *StatisticsService) earliestOverviewTime(ctx context.Context) (*time.Time, error) {
+	db := s.db.WithContext(ctx)
+	sources := []struct {
+		model      interface{}
+		timeColumn string
+	}{
+		{&models.Payment{}, "pay_time"},
+		{&models.Refund{}, "refunded_at"},
+		{&models.Commission{}, "settled_at"},
+		{&models.Settlement{}, "settled_at"},
+	}
+
+	var earliest *time.Time
+	for _, source := range sources {
+		var times []time.Time
+		if err := db.Model(source.model).Where(source.timeColumn+" IS NOT NULL").
+			Order(source.timeColumn+" ASC").Limit(1).Pluck(source.timeColumn, &times).Error; err != nil {
+			return nil, err
+		}
+		if len(times) > 0 && (earliest == nil || times[0].Before(*earliest)) {
+			earliest = &times[0]
+		}
+	}
+	return earliest, nil
+}
+
+// getCachedFinanceOverview 读取财务概览缓存，未初始化缓存或未命中时返回 false
+func getCachedFinanceOverview(ctx context.Context, overview *models.FinanceOverview) bool {
+	if cache.GetClient() == nil {
+		return false
+	}
+	return cache.Get(ctx, financeOverviewCacheKey, overview) == nil
+}
+
+// setCachedFinanceOverview 写入财务概览缓存，写入失败不影响查询结果
+func setCachedFinanceOverview(ctx context.Context, overview *models.FinanceOverview) {
+	if cache.GetClient() == nil {
+		return
+	}
+	_ = cache.Set(ctx, financeOverviewCacheKey, overview, financeOverviewCacheTTL)
+}
+
+// invalidateFinanceOverviewCache 清除财务概览缓存，在提现审核、退款完成等影响概览数据的操作后调用
+func invalidateFinanceOverviewCache(ctx context.Context) {
+	if cache.GetClient() == nil {
+		return
+	}
+	_ = cache.Delete(ctx, financeOverviewCacheKey)
+}
@@ -0,0 +1,223 @@
+package finance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/cache"
+	"github.com/dumeirei/smart-locker-backend/internal/common/config"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// bruteForceOverviewTotals 逐条读取记录重新计算财务概览累计金额
+func bruteForceOverviewTotals(t *testing.T, db *gorm.DB) *overviewTotals {
+	t.Helper()
+
+	totals := &overviewTotals{}
+	var payments []*models.Payment
+	require.NoError(t, db.Find(&payments).Error)
+	for _, p := range payments {
+		if p.Status == models.PaymentStatusSuccess {
+			totals.Revenue += p.Amount
+		}
+	}
+	var refunds []*models.Refund
+	require.NoError(t, db.Find(&refunds).Error)
+	for _, r := range refunds {
+		if r.Status == models.RefundStatusSuccess {
+			totals.Refund += r.Amount
+		}
+	}
+	var commissions []*models.Commission
+	require.NoError(t, db.Find(&commissions).Error)
+	for _, c := range commissions {
+		if c.Status == models.CommissionStatusSettled {
+			totals.Commission += c.Amount
+		}
+	}
+	var settlements []*models.Settlement
+	require.NoError(t, db.Find(&settlements).Error)
+	for _, s := range settlements {
+		if s.Status == models.SettlementStatusCompleted {
+			totals.Settlement += s.ActualAmount
+		}
+	}
+
+	totals.Revenue = roundAmount(totals.Revenue)
+	totals.Refund = roundAmount(totals.Refund)
+	totals.Commission = roundAmount(totals.Commission)
+	totals.Settlement = roundAmount(totals.Settlement)
+	return totals
+}
+
+// assertOverviewMatches 断言财务概览累计金额与逐条重新计算的结果一致
+func assertOverviewMatches(t *testing.T, svc *StatisticsService, db *gorm.DB) {
+	t.Helper()
+
+	overview, err := svc.GetFinanceOverview(context.Background())
+	require.NoError(t, err)
+	expected := bruteForceOverviewTotals(t, db)
+	assert.Equal(t, expected.Revenue, roundAmount(overview.TotalRevenue), "总收入")
+	assert.Equal(t, expected.Refund, roundAmount(overview.TotalRefund), "总退款")
+	assert.Equal(t, expected.Commission, roundAmount(overview.TotalCommission), "总佣金")
+	assert.Equal(t, expected.Settlement, roundAmount(overview.TotalSettlement), "总结算")
+}
+
+// createOverviewTestPayment 创建指定支付时间的支付记录及订单
+func createOverviewTestPayment(t *testing.T, db *gorm.DB, userID int64, amount float64, status int8, paidAt time.Time) *models.Payment {
+	t.Helper()
+
+	order := createTestOrder(t, db, userID, amount, models.OrderStatusCompleted)
+	payment := &models.Payment{
+		PaymentNo:      fmt.Sprintf("PAY%d", time.Now().UnixNano()),
+		OrderID:        order.ID,
+		OrderNo:        order.OrderNo,
+		UserID:         userID,
+		Amount:         amount,
+		PaymentMethod:  models.PaymentMethodWechat,
+		PaymentChannel: "miniapp",
+		Status:         status,
+		PaidAt:         &paidAt,
+	}
+	require.NoError(t, db.Create(payment).Error)
+	return payment
+}
+
+func TestStatisticsService_FinanceOverviewSummary(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupStatisticsService(db)
+	ctx := context.Background()
+
+	now := time.Now()
+	today, _ := reconciliationDay(now)
+	at := func(daysAgo int) time.Time { return today.AddDate(0, 0, -daysAgo).Add(10 * time.Hour) }
+
+	user := createFinanceTestUser(t, db, "13800138061")
+	distributor := createTestDistributor(t, db, user.ID)
+
+	// 三天的支付：前天、昨天、今天
+	historical := createOverviewTestPayment(t, db, user.ID, 12.34, models.PaymentStatusSuccess, at(2))
+	historical2 := createOverviewTestPayment(t, db, user.ID, 56.78, models.PaymentStatusSuccess, at(2))
+	unpaid := createOverviewTestPayment(t, db, user.ID, 99.99, models.PaymentStatusPending, at(2))
+	createOverviewTestPayment(t, db, user.ID, 10.01, models.PaymentStatusSuccess, at(1))
+	createOverviewTestPayment(t, db, user.ID, 20.02, models.PaymentStatusSuccess, at(1))
+	createOverviewTestPayment(t, db, user.ID, 33.33, models.PaymentStatusSuccess, at(0))
+
+	refundedAt := at(1)
+	require.NoError(t, db.Create(&models.Refund{
+		RefundNo: "R_OVERVIEW_1", OrderID: historical.OrderID, OrderNo: historical.OrderNo,
+		PaymentID: historical.ID, PaymentNo: historical.PaymentNo, UserID: user.ID,
+		Amount: 5.55, Reason: "测试", Status: models.RefundStatusSuccess, RefundedAt: &refundedAt,
+	}).Error)
+
+	settled := createTestCommission(t, db, distributor.ID, historical.OrderID, user.ID, 1.23, models.CommissionStatusSettled)
+	require.NoError(t, db.Model(settled).Update("settled_at", at(2)).Error)
+	// 入账时间为空的已结算佣金始终实时统计
+	createTestCommission(t, db, distributor.ID, historical2.OrderID, user.ID, 0.77, models.CommissionStatusSettled)
+	pending := createTestCommission(t, db, distributor.ID, unpaid.OrderID, user.ID, 4.56, models.CommissionStatusPending)
+
+	settlement := createTestSettlement(t, db, models.SettlementTypeMerchant, 1, 100, models.SettlementStatusCompleted)
+	require.NoError(t, db.Model(settlement).Update("settled_at", at(1)).Error)
+	createTestSettlement(t, db, models.SettlementTypeMerchant, 2, 200, models.SettlementStatusPending)
+
+	// 尚未汇总时实时统计
+	assertOverviewMatches(t, svc, db)
+
+	days, err := svc.AggregateDailySummaries(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, days)
+
+	var summaries []*models.FinanceDailySummary
+	require.NoError(t, db.Order("biz_date ASC").Find(&summaries).Error)
+	require.Len(t, summaries, 2)
+	assert.InDelta(t, 69.12, summaries[0].Revenue, 0.001)
+	assert.InDelta(t, 1.23, summaries[0].Commission, 0.001)
+	assert.InDelta(t, 30.03, summaries[1].Revenue, 0.001)
+	assert.InDelta(t, 5.55, summaries[1].Refund, 0.001)
+	assert.InDelta(t, 90, summaries[1].Settlement, 0.001)
+	assertOverviewMatches(t, svc, db)
+
+	// 重复汇总只重新计算最近的日期，结果不变
+	_, err = svc.AggregateDailySummaries(ctx, now)
+	require.NoError(t, err)
+	var count int64
+	require.NoError(t, db.Model(&models.FinanceDailySummary{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+	assertOverviewMatches(t, svc, db)
+
+	// 今日新增的支付、退款与佣金结算计入实时增量
+	createOverviewTestPayment(t, db, user.ID, 44.44, models.PaymentStatusSuccess, now)
+	require.NoError(t, db.Create(&models.Refund{
+		RefundNo: "R_OVERVIEW_2", OrderID: historical.OrderID, OrderNo: historical.OrderNo,
+		PaymentID: historical.ID, PaymentNo: historical.PaymentNo, UserID: user.ID,
+		Amount: 6.66, Reason: "测试", Status: models.RefundStatusSuccess, RefundedAt: &now,
+	}).Error)
+	require.NoError(t, db.Model(pending).Updates(map[string]interface{}{
+		"status": models.CommissionStatusSettled, "settled_at": now,
+	}).Error)
+	assertOverviewMatches(t, svc, db)
+
+	// 全额退款将历史支付变更为已退款，按订单刷新后与实时统计一致
+	require.NoError(t, db.Model(historical).Update("status", models.PaymentStatusRefunded).Error)
+	require.NoError(t, svc.InvalidateOrderOverview(ctx, historical.OrderID))
+	assertOverviewMatches(t, svc, db)
+}
+
+func TestStatisticsService_AggregateDailySummaries_NoData(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupStatisticsService(db)
+
+	days, err := svc.AggregateDailySummaries(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, days)
+	assertOverviewMatches(t, svc, db)
+}
+
+func TestStatisticsService_FinanceOverviewCache(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	var port int
+	_, err = fmt.Sscanf(mr.Port(), "%d", &port)
+	require.NoError(t, err)
+	_, err = cache.Init(&config.RedisConfig{Host: mr.Host(), Port: port})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cache.Close() })
+
+	db := setupFinanceTestDB(t)
+	svc := setupStatisticsService(db)
+	withdrawalSvc := setupWithdrawalAuditService(db)
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800138062")
+	createOverviewTestPayment(t, db, user.ID, 10, models.PaymentStatusSuccess, time.Now())
+	withdrawal := createTestWithdrawal(t, db, user.ID, 10.0, models.WithdrawalStatusPending)
+
+	overview, err := svc.GetFinanceOverview(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, overview.TotalRevenue)
+	assert.Equal(t, 1, overview.PendingWithdrawals)
+	assert.True(t, mr.Exists(financeOverviewCacheKey))
+
+	// 缓存有效期内读取缓存
+	createOverviewTestPayment(t, db, user.ID, 5, models.PaymentStatusSuccess, time.Now())
+	overview, err = svc.GetFinanceOverview(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, overview.TotalRevenue)
+
+	// 提现审核通过后清除缓存
+	require.NoError(t, withdrawalSvc.ApproveWithdrawal(ctx, withdrawal.ID, 1))
+	assert.False(t, mr.Exists(financeOverviewCacheKey))
+	overview, err = svc.GetFinanceOverview(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 15.0, overview.TotalRevenue)
+	assert.Equal(t, 0, overview.PendingWithdrawals)
+}
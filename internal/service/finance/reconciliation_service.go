@@ -0,0 +1,389 @@
+package finance
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// reconciliationAmountTolerance 金额比较容差，小于 1 分视为一致
+const reconciliationAmountTolerance = 0.005
+
+// reconciliationUserBatchSize 查询用户期初流水时每批的用户数
+const reconciliationUserBatchSize = 500
+
+// paidOrderStatuses 已支付及之后的订单状态
+var paidOrderStatuses = []string{
+	models.OrderStatusPaid,
+	models.OrderStatusPendingShip,
+	models.OrderStatusShipping,
+	models.OrderStatusShipped,
+	models.OrderStatusDelivered,
+	models.OrderStatusCompleted,
+	models.OrderStatusRefunding,
+	models.OrderStatusRefunded,
+	models.OrderStatusPartialRefunded,
+}
+
+// reconciledOrderTypes 需要核对支付来源的订单类型
+var reconciledOrderTypes = []string{
+	models.OrderTypeRental,
+	models.OrderTypeHotel,
+	models.OrderTypeMall,
+}
+
+// ReconciliationService 对账服务
+// 每日核对支付记录与订单、订单与支付来源（第三方支付或余额扣款），以及钱包流水的余额连续性
+type ReconciliationService struct {
+	db *gorm.DB
+}
+
+// NewReconciliationService 创建对账服务
+func NewReconciliationService(db *gorm.DB) *ReconciliationService {
+	return &ReconciliationService{db: db}
+}
+
+// ReconciliationResult 对账结果
+type ReconciliationResult struct {
+	Date                string                        `json:"date"`
+	PaymentsChecked     int                           `json:"payments_checked"`
+	OrdersChecked       int                           `json:"orders_checked"`
+	TransactionsChecked int                           `json:"transactions_checked"`
+	Issues              []*models.ReconciliationIssue `json:"issues"`
+}
+
+// reconciliationDay 返回日期所在自然日的起止时间
+func reconciliationDay(date time.Time) (time.Time, time.Time) {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// amountEqual 判断两个金额是否一致
+func amountEqual(a, b float64) bool {
+	return math.Abs(a-b) < reconciliationAmountTolerance
+}
+
+// RunDaily 对指定日期进行对账，并以本次结果替换该日期已有的差异记录
+// (a) 当日支付成功的记录需对应已支付及之后状态的订单，且金额与订单实付金额（扣除余额支付部分）一致；
+// (b) 当日支付的租借、酒店、商城订单需恰好有一笔成功支付，或余额扣款足额覆盖实付金额；
+// (c) 当日每个用户的钱包流水前后余额需连续
+func (s *ReconciliationService) RunDaily(ctx context.Context, date time.Time) (*ReconciliationResult, error) {
+	start, end := reconciliationDay(date)
+	db := s.db.WithContext(ctx)
+
+	// 当日支付成功的支付记录
+	var dayPayments []*models.Payment
+	if err := db.Where("status = ? AND pay_time >= ? AND pay_time < ?", models.PaymentStatusSuccess, start, end).
+		Order("id ASC").Find(&dayPayments).Error; err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	// 当日支付的订单
+	var paidOrders []*models.Order
+	if err := db.Where("type IN ? AND status IN ? AND paid_at >= ? AND paid_at < ?", reconciledOrderTypes, paidOrderStatuses, start, end).
+		Order("id ASC").Find(&paidOrders).Error; err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	orders, err := s.loadOrders(ctx, dayPayments, paidOrders)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := s.loadOrderPayments(ctx, orders)
+	if err != nil {
+		return nil, err
+	}
+	deductions, err := s.loadWalletDeductions(ctx, orders)
+	if err != nil {
+		return nil, err
+	}
+
+	bizDate := start
+	issues := make([]*models.ReconciliationIssue, 0)
+	issues = append(issues, checkPayments(bizDate, dayPayments, orders, deductions)...)
+	issues = append(issues, checkPaidOrders(bizDate, paidOrders, payments, deductions)...)
+
+	chainIssues, txCount, err := s.checkWalletChains(ctx, bizDate, start, end)
+	if err != nil {
+		return nil, err
+	}
+	issues = append(issues, chainIssues...)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("biz_date >= ? AND biz_date < ?", start, end).Delete(&models.ReconciliationIssue{}).Error; err != nil {
+			return err
+		}
+		if len(issues) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(issues, 100).Error
+	})
+	if err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	return &ReconciliationResult{
+		Date:                start.Format("2006-01-02"),
+		PaymentsChecked:     len(dayPayments),
+		OrdersChecked:       len(paidOrders),
+		TransactionsChecked: txCount,
+		Issues:              issues,
+	}, nil
+}
+
+// ListIssues 获取指定日期的对账差异，issueType 为空时返回全部类型
+func (s *ReconciliationService) ListIssues(ctx context.Context, date time.Time, issueType string, offset, limit int) ([]*models.ReconciliationIssue, int64, error) {
+	start, end := reconciliationDay(date)
+	query := s.db.WithContext(ctx).Model(&models.ReconciliationIssue{}).
+		Where("biz_date >= ? AND biz_date < ?", start, end)
+	if issueType != "" {
+		query = query.Where("type = ?", issueType)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.ErrDatabaseError.WithError(err)
+	}
+
+	var issues []*models.ReconciliationIssue
+	if err := query.Order("id ASC").Offset(offset).Limit(limit).Find(&issues).Error; err != nil {
+		return nil, 0, errors.ErrDatabaseError.WithError(err)
+	}
+	return issues, total, nil
+}
+
+// loadOrders 加载支付记录关联的订单及当日支付的订单
+func (s *ReconciliationService) loadOrders(ctx context.Context, dayPayments []*models.Payment, paidOrders []*models.Order) (map[int64]*models.Order, error) {
+	orders := make(map[int64]*models.Order, len(paidOrders))
+	for _, order := range paidOrders {
+		orders[order.ID] = order
+	}
+
+	var missing []int64
+	for _, payment := range dayPayments {
+		if _, ok := orders[payment.OrderID]; !ok {
+			missing = append(missing, payment.OrderID)
+		}
+	}
+	if len(missing) == 0 {
+		return orders, nil
+	}
+
+	var list []*models.Order
+	if err := s.db.WithContext(ctx).Where("id IN ?", missing).Find(&list).Error; err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	for _, order := range list {
+		orders[order.ID] = order
+	}
+	return orders, nil
+}
+
+// loadOrderPayments 加载订单的成功支付记录（含之后已退款的支付），按订单分组
+func (s *ReconciliationService) loadOrderPayments(ctx context.Context, orders map[int64]*models.Order) (map[int64][]*models.Payment, error) {
+	result := make(map[int64][]*models.Payment)
+	if len(orders) == 0 {
+		return result, nil
+	}
+
+	orderIDs := make([]int64, 0, len(orders))
+	for id := range orders {
+		orderIDs = append(orderIDs, id)
+	}
+
+	var payments []*models.Payment
+	if err := s.db.WithContext(ctx).
+		Where("order_id IN ? AND status IN ?", orderIDs, []int8{models.PaymentStatusSuccess, models.PaymentStatusRefunded}).
+		Order("id ASC").Find(&payments).Error; err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	for _, payment := range payments {
+		result[payment.OrderID] = append(result[payment.OrderID], payment)
+	}
+	return result, nil
+}
+
+// loadWalletDeductions 统计订单的余额扣款金额（消费与押金冻结中实际扣减可用余额的部分），按订单号分组
+func (s *ReconciliationService) loadWalletDeductions(ctx context.Context, orders map[int64]*models.Order) (map[string]float64, error) {
+	result := make(map[string]float64)
+	if len(orders) == 0 {
+		return result, nil
+	}
+
+	orderNos := make([]string, 0, len(orders))
+	for _, order := range orders {
+		orderNos = append(orderNos, order.OrderNo)
+	}
+
+	var rows []struct {
+		OrderNo string
+		Amount  float64
+	}
+	if err := s.db.WithContext(ctx).Model(&models.WalletTransaction{}).
+		Select("order_no, COALESCE(SUM(balance_before - balance_after), 0) AS amount").
+		Where("order_no IN ? AND type IN ? AND balance_before > balance_after", orderNos,
+			[]string{models.WalletTxTypeConsume, models.WalletTxTypeDeposit}).
+		Group("order_no").Scan(&rows).Error; err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	for _, row := range rows {
+		result[row.OrderNo] = row.Amount
+	}
+	return result, nil
+}
+
+// checkPayments 核对当日成功支付与订单
+func checkPayments(bizDate time.Time, dayPayments []*models.Payment, orders map[int64]*models.Order, deductions map[string]float64) []*models.ReconciliationIssue {
+	paidStatus := make(map[string]bool, len(paidOrderStatuses))
+	for _, status := range paidOrderStatuses {
+		paidStatus[status] = true
+	}
+
+	var issues []*models.ReconciliationIssue
+	for _, payment := range dayPayments {
+		issue := &models.ReconciliationIssue{
+			BizDate:      bizDate,
+			UserID:       &payment.UserID,
+			OrderID:      &payment.OrderID,
+			OrderNo:      &payment.OrderNo,
+			PaymentID:    &payment.ID,
+			PaymentNo:    &payment.PaymentNo,
+			ActualAmount: payment.Amount,
+		}
+
+		order, ok := orders[payment.OrderID]
+		switch {
+		case !ok:
+			issue.Type = models.ReconciliationIssueOrderMissing
+			issue.Detail = "支付成功但订单不存在"
+		case !paidStatus[order.Status]:
+			issue.Type = models.ReconciliationIssueOrderNotPaid
+			issue.ExpectedAmount = order.ActualAmount
+			issue.Detail = fmt.Sprintf("支付成功但订单状态为 %s", order.Status)
+		default:
+			expected := order.ActualAmount - deductions[order.OrderNo]
+			if amountEqual(payment.Amount, expected) {
+				continue
+			}
+			issue.Type = models.ReconciliationIssueAmountMismatch
+			issue.ExpectedAmount = expected
+			issue.Detail = "支付金额与订单实付金额不一致"
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// checkPaidOrders 核对当日支付订单的支付来源
+func checkPaidOrders(bizDate time.Time, paidOrders []*models.Order, payments map[int64][]*models.Payment, deductions map[string]float64) []*models.ReconciliationIssue {
+	var issues []*models.ReconciliationIssue
+	for _, order := range paidOrders {
+		issue := &models.ReconciliationIssue{
+			BizDate:        bizDate,
+			UserID:         &order.UserID,
+			OrderID:        &order.ID,
+			OrderNo:        &order.OrderNo,
+			ExpectedAmount: order.ActualAmount,
+		}
+
+		orderPayments := payments[order.ID]
+		switch len(orderPayments) {
+		case 0:
+			deducted := deductions[order.OrderNo]
+			if amountEqual(deducted, order.ActualAmount) {
+				continue
+			}
+			issue.Type = models.ReconciliationIssuePaymentMissing
+			issue.ActualAmount = deducted
+			issue.Detail = "订单已支付但无支付记录，余额扣款与实付金额不一致"
+		case 1:
+			continue
+		default:
+			var paid float64
+			for _, payment := range orderPayments {
+				paid += payment.Amount
+			}
+			issue.Type = models.ReconciliationIssueDuplicatePayment
+			issue.PaymentID = &orderPayments[1].ID
+			issue.PaymentNo = &orderPayments[1].PaymentNo
+			issue.ActualAmount = paid
+			issue.Detail = fmt.Sprintf("订单存在 %d 笔成功支付", len(orderPayments))
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// checkWalletChains 核对当日钱包流水的余额连续性，每个用户以当日之前的最后一条流水为起点
+func (s *ReconciliationService) checkWalletChains(ctx context.Context, bizDate, start, end time.Time) ([]*models.ReconciliationIssue, int, error) {
+	db := s.db.WithContext(ctx)
+
+	var transactions []*models.WalletTransaction
+	if err := db.Where("created_at >= ? AND created_at < ?", start, end).
+		Order("user_id ASC, id ASC").Find(&transactions).Error; err != nil {
+		return nil, 0, errors.ErrDatabaseError.WithError(err)
+	}
+
+	var userIDs []int64
+	for i, tx := range transactions {
+		if i == 0 || transactions[i-1].UserID != tx.UserID {
+			userIDs = append(userIDs, tx.UserID)
+		}
+	}
+	lastBefore, err := s.lastWalletTransactions(ctx, userIDs, start)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var issues []*models.ReconciliationIssue
+	var prev *models.WalletTransaction
+	for _, tx := range transactions {
+		if prev == nil || prev.UserID != tx.UserID {
+			prev = lastBefore[tx.UserID]
+		}
+
+		if prev != nil && !amountEqual(prev.BalanceAfter, tx.BalanceBefore) {
+			issues = append(issues, &models.ReconciliationIssue{
+				BizDate:             bizDate,
+				Type:                models.ReconciliationIssueWalletChainBreak,
+				UserID:              &tx.UserID,
+				OrderNo:             tx.OrderNo,
+				PaymentNo:           tx.PaymentNo,
+				WalletTransactionID: &tx.ID,
+				ExpectedAmount:      prev.BalanceAfter,
+				ActualAmount:        tx.BalanceBefore,
+				Detail:              fmt.Sprintf("流水变动前余额与上一条流水（ID %d）变动后余额不一致", prev.ID),
+			})
+		}
+		prev = tx
+	}
+	return issues, len(transactions), nil
+}
+
+// lastWalletTransactions 按用户分批查询指定时间之前的最后一条钱包流水
+func (s *ReconciliationService) lastWalletTransactions(ctx context.Context, userIDs []int64, before time.Time) (map[int64]*models.WalletTransaction, error) {
+	result := make(map[int64]*models.WalletTransaction, len(userIDs))
+	for i := 0; i < len(userIDs); i += reconciliationUserBatchSize {
+		batch := userIDs[i:min(i+reconciliationUserBatchSize, len(userIDs))]
+
+		lastIDs := s.db.WithContext(ctx).Model(&models.WalletTransaction{}).
+			Select("MAX(id)").
+			Where("user_id IN ? AND created_at < ?", batch, before).
+			Group("user_id")
+
+		var transactions []*models.WalletTransaction
+		if err := s.db.WithContext(ctx).Where("id IN (?)", lastIDs).Find(&transactions).Error; err != nil {
+			return nil, errors.ErrDatabaseError.WithError(err)
+		}
+		for _, tx := range transactions {
+			result[tx.UserID] = tx
+		}
+	}
+	return result, nil
+}
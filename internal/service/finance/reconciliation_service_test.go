@@ -0,0 +1,262 @@
+package finance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// reconciliationFixture 对账测试数据构造器
+type reconciliationFixture struct {
+	t   *testing.T
+	db  *gorm.DB
+	seq int
+}
+
+func (f *reconciliationFixture) order(userID int64, orderType, status string, amount float64, paidAt *time.Time) *models.Order {
+	f.seq++
+	order := &models.Order{
+		OrderNo:        fmt.Sprintf("RC%03d", f.seq),
+		UserID:         userID,
+		Type:           orderType,
+		OriginalAmount: amount,
+		ActualAmount:   amount,
+		Status:         status,
+		PaidAt:         paidAt,
+	}
+	require.NoError(f.t, f.db.Create(order).Error)
+	return order
+}
+
+func (f *reconciliationFixture) payment(userID, orderID int64, orderNo string, amount float64, paidAt time.Time) *models.Payment {
+	f.seq++
+	payment := &models.Payment{
+		PaymentNo:      fmt.Sprintf("RCP%03d", f.seq),
+		OrderID:        orderID,
+		OrderNo:        orderNo,
+		UserID:         userID,
+		Amount:         amount,
+		PaymentMethod:  models.PaymentMethodWechat,
+		PaymentChannel: models.PaymentChannelMiniProgram,
+		Status:         models.PaymentStatusSuccess,
+		PaidAt:         &paidAt,
+	}
+	require.NoError(f.t, f.db.Create(payment).Error)
+	return payment
+}
+
+func (f *reconciliationFixture) walletTx(userID int64, txType string, orderNo string, before, after float64, at time.Time) *models.WalletTransaction {
+	tx := &models.WalletTransaction{
+		UserID:        userID,
+		Type:          txType,
+		Amount:        after - before,
+		BalanceBefore: before,
+		BalanceAfter:  after,
+		CreatedAt:     at,
+	}
+	if orderNo != "" {
+		tx.OrderNo = &orderNo
+	}
+	require.NoError(f.t, f.db.Create(tx).Error)
+	return tx
+}
+
+// issuesByType 按差异类型分组
+func issuesByType(issues []*models.ReconciliationIssue) map[string][]*models.ReconciliationIssue {
+	result := make(map[string][]*models.ReconciliationIssue)
+	for _, issue := range issues {
+		result[issue.Type] = append(result[issue.Type], issue)
+	}
+	return result
+}
+
+func TestReconciliationService_RunDaily_DetectsEachIssueType(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := NewReconciliationService(db)
+	ctx := context.Background()
+	f := &reconciliationFixture{t: t, db: db}
+
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.Local)
+	at := day.Add(10 * time.Hour)
+	previousDay := day.Add(-2 * time.Hour)
+	userA := createFinanceTestUser(t, db, "13800138071")
+	userB := createFinanceTestUser(t, db, "13800138072")
+
+	// 正常：第三方支付的商城订单
+	mallOK := f.order(userA.ID, models.OrderTypeMall, models.OrderStatusPaid, 30, &at)
+	f.payment(userA.ID, mallOK.ID, mallOK.OrderNo, 30, at)
+
+	// 正常：余额支付的租借订单（押金冻结 + 租金消费），流水连续
+	rentalOK := f.order(userA.ID, models.OrderTypeRental, models.OrderStatusCompleted, 60, &at)
+	f.walletTx(userA.ID, models.WalletTxTypeRecharge, "", 0, 100, previousDay)
+	f.walletTx(userA.ID, models.WalletTxTypeDeposit, rentalOK.OrderNo, 100, 50, at)
+	f.walletTx(userA.ID, models.WalletTxTypeConsume, rentalOK.OrderNo, 50, 40, at)
+
+	// 支付成功但订单不存在
+	orphan := f.payment(userA.ID, 99999, "RC_MISSING", 15, at)
+
+	// 支付成功但订单未支付
+	pending := f.order(userA.ID, models.OrderTypeMall, models.OrderStatusPending, 20, nil)
+	f.payment(userA.ID, pending.ID, pending.OrderNo, 20, at)
+
+	// 支付金额与订单金额不一致
+	mismatch := f.order(userA.ID, models.OrderTypeMall, models.OrderStatusPendingShip, 50, &at)
+	f.payment(userA.ID, mismatch.ID, mismatch.OrderNo, 45, at)
+
+	// 订单已支付但无支付记录和余额扣款
+	unpaid := f.order(userA.ID, models.OrderTypeRental, models.OrderStatusPaid, 25, &at)
+
+	// 重复支付
+	duplicated := f.order(userA.ID, models.OrderTypeHotel, models.OrderStatusPaid, 80, &at)
+	f.payment(userA.ID, duplicated.ID, duplicated.OrderNo, 80, at)
+	second := f.payment(userA.ID, duplicated.ID, duplicated.OrderNo, 80, at.Add(time.Minute))
+
+	// 钱包流水不连续：前一日余额 100，当日流水变动前余额 90
+	f.walletTx(userB.ID, models.WalletTxTypeRecharge, "", 0, 100, previousDay)
+	broken := f.walletTx(userB.ID, models.WalletTxTypeAdjustment, "", 90, 95, at)
+	f.walletTx(userB.ID, models.WalletTxTypeAdjustment, "", 95, 99, at.Add(time.Minute))
+
+	// 其他日期的差异不计入
+	otherDay := day.AddDate(0, 0, 1).Add(time.Hour)
+	f.payment(userA.ID, 88888, "RC_OTHER_DAY", 10, otherDay)
+
+	result, err := svc.RunDaily(ctx, day.Add(15*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03-10", result.Date)
+	assert.Equal(t, 6, result.PaymentsChecked)
+	assert.Equal(t, 5, result.OrdersChecked)
+	assert.Equal(t, 4, result.TransactionsChecked)
+	require.Len(t, result.Issues, 6)
+
+	byType := issuesByType(result.Issues)
+
+	require.Len(t, byType[models.ReconciliationIssueOrderMissing], 1)
+	issue := byType[models.ReconciliationIssueOrderMissing][0]
+	assert.Equal(t, orphan.ID, *issue.PaymentID)
+	assert.Equal(t, 15.0, issue.ActualAmount)
+
+	require.Len(t, byType[models.ReconciliationIssueOrderNotPaid], 1)
+	assert.Equal(t, pending.ID, *byType[models.ReconciliationIssueOrderNotPaid][0].OrderID)
+
+	require.Len(t, byType[models.ReconciliationIssueAmountMismatch], 1)
+	issue = byType[models.ReconciliationIssueAmountMismatch][0]
+	assert.Equal(t, mismatch.ID, *issue.OrderID)
+	assert.Equal(t, 50.0, issue.ExpectedAmount)
+	assert.Equal(t, 45.0, issue.ActualAmount)
+
+	require.Len(t, byType[models.ReconciliationIssuePaymentMissing], 1)
+	issue = byType[models.ReconciliationIssuePaymentMissing][0]
+	assert.Equal(t, unpaid.ID, *issue.OrderID)
+	assert.Equal(t, 25.0, issue.ExpectedAmount)
+	assert.Equal(t, 0.0, issue.ActualAmount)
+
+	require.Len(t, byType[models.ReconciliationIssueDuplicatePayment], 1)
+	issue = byType[models.ReconciliationIssueDuplicatePayment][0]
+	assert.Equal(t, duplicated.ID, *issue.OrderID)
+	assert.Equal(t, second.ID, *issue.PaymentID)
+	assert.Equal(t, 160.0, issue.ActualAmount)
+
+	require.Len(t, byType[models.ReconciliationIssueWalletChainBreak], 1)
+	issue = byType[models.ReconciliationIssueWalletChainBreak][0]
+	assert.Equal(t, userB.ID, *issue.UserID)
+	assert.Equal(t, broken.ID, *issue.WalletTransactionID)
+	assert.Equal(t, 100.0, issue.ExpectedAmount)
+	assert.Equal(t, 90.0, issue.ActualAmount)
+
+	// 差异已保存，可按类型查询
+	issues, total, err := svc.ListIssues(ctx, day, "", 0, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), total)
+	assert.Len(t, issues, 6)
+
+	issues, total, err = svc.ListIssues(ctx, day, models.ReconciliationIssueWalletChainBreak, 0, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, broken.ID, *issues[0].WalletTransactionID)
+}
+
+func TestReconciliationService_RunDaily_ReplacesPreviousIssues(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := NewReconciliationService(db)
+	ctx := context.Background()
+	f := &reconciliationFixture{t: t, db: db}
+
+	day := time.Date(2026, 3, 11, 0, 0, 0, 0, time.Local)
+	at := day.Add(9 * time.Hour)
+	user := createFinanceTestUser(t, db, "13800138073")
+	unpaid := f.order(user.ID, models.OrderTypeMall, models.OrderStatusPaid, 40, &at)
+
+	result, err := svc.RunDaily(ctx, day)
+	require.NoError(t, err)
+	require.Len(t, result.Issues, 1)
+
+	// 重复执行不产生重复记录
+	_, err = svc.RunDaily(ctx, day)
+	require.NoError(t, err)
+	_, total, err := svc.ListIssues(ctx, day, "", 0, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	// 补录支付后重新对账，差异消除
+	f.payment(user.ID, unpaid.ID, unpaid.OrderNo, 40, at)
+	result, err = svc.RunDaily(ctx, day)
+	require.NoError(t, err)
+	assert.Empty(t, result.Issues)
+	_, total, err = svc.ListIssues(ctx, day, "", 0, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestReconciliationService_RunDaily_WalletChainsBatchQuery(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := NewReconciliationService(db)
+	ctx := context.Background()
+	f := &reconciliationFixture{t: t, db: db}
+
+	day := time.Date(2026, 3, 12, 0, 0, 0, 0, time.Local)
+	at := day.Add(8 * time.Hour)
+
+	// 多个用户各有前一日的期初流水，其中一个用户当日流水不连续
+	var broken *models.WalletTransaction
+	for i := 0; i < 5; i++ {
+		user := createFinanceTestUser(t, db, fmt.Sprintf("1380013808%d", i))
+		f.walletTx(user.ID, models.WalletTxTypeRecharge, "", 0, 50, day.Add(-3*time.Hour))
+		f.walletTx(user.ID, models.WalletTxTypeRecharge, "", 50, 100, day.Add(-time.Hour))
+		before := 100.0
+		if i == 3 {
+			before = 80
+		}
+		tx := f.walletTx(user.ID, models.WalletTxTypeAdjustment, "", before, before+10, at)
+		if i == 3 {
+			broken = tx
+		}
+	}
+
+	// 期初流水按批次查询，查询次数不随用户数增长：
+	// 订单余额扣款核对 1 次、当日流水 1 次、期初流水 1 次
+	var queries int
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:count_wallet_queries", func(tx *gorm.DB) {
+		if tx.Statement.Table == "wallet_transactions" {
+			queries++
+		}
+	}))
+
+	result, err := svc.RunDaily(ctx, day)
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.TransactionsChecked)
+	assert.Equal(t, 3, queries)
+
+	require.Len(t, result.Issues, 1)
+	issue := result.Issues[0]
+	assert.Equal(t, models.ReconciliationIssueWalletChainBreak, issue.Type)
+	assert.Equal(t, broken.ID, *issue.WalletTransactionID)
+	assert.Equal(t, 100.0, issue.ExpectedAmount)
+	assert.Equal(t, 80.0, issue.ActualAmount)
+}
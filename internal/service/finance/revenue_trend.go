@@ -0,0 +1,289 @@
+package finance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// 收入趋势统计粒度
+const (
+	TrendGranularityDay   = "day"   // 按日
+	TrendGranularityWeek  = "week"  // 按 ISO 周（周一至周日）
+	TrendGranularityMonth = "month" // 按自然月
+)
+
+// revenueTrendMaxBuckets 单次查询的最大统计周期数
+const revenueTrendMaxBuckets = 366
+
+// RevenueTrendRequest 收入趋势查询请求
+// StartDate、EndDate 只取日期部分，按本地时区的自然日统计（含首尾两天）
+type RevenueTrendRequest struct {
+	Granularity string
+	StartDate   time.Time
+	EndDate     time.Time
+	Compare     bool // 同时返回紧邻的等长上一周期
+}
+
+// RevenueTrendTotal 收入趋势周期合计
+type RevenueTrendTotal struct {
+	StartDate  string  `json:"start_date"`
+	EndDate    string  `json:"end_date"`
+	Revenue    float64 `json:"revenue"`
+	Refund     float64 `json:"refund"`
+	Commission float64 `json:"commission"`
+	NetRevenue float64 `json:"net_revenue"`
+	OrderCount int64   `json:"order_count"`
+}
+
+// RevenueTrendGrowth 本期相对上一周期的增长率（百分比，保留两位小数），上一周期为 0 时为 null
+type RevenueTrendGrowth struct {
+	Revenue    *float64 `json:"revenue"`
+	Refund     *float64 `json:"refund"`
+	Commission *float64 `json:"commission"`
+	NetRevenue *float64 `json:"net_revenue"`
+	OrderCount *float64 `json:"order_count"`
+}
+
+// RevenueTrendReport 收入趋势报表
+type RevenueTrendReport struct {
+	Granularity   string              `json:"granularity"`
+	Current       []RevenueTrend      `json:"current"`
+	CurrentTotal  RevenueTrendTotal   `json:"current_total"`
+	Previous      []RevenueTrend      `json:"previous,omitempty"`
+	PreviousTotal *RevenueTrendTotal  `json:"previous_total,omitempty"`
+	Growth        *RevenueTrendGrowth `json:"growth,omitempty"`
+}
+
+// trendBucket 统计周期，from 含、to 不含
+type trendBucket struct {
+	label string
+	from  time.Time
+	to    time.Time
+}
+
+// GetRevenueTrendReport 按日、周或月统计指定日期范围的收入趋势
+// 开启对比时同时统计紧邻的等长上一周期，并计算各项合计的增长率
+func (s *FinanceDashboardService) GetRevenueTrendReport(ctx context.Context, req *RevenueTrendRequest) (*RevenueTrendReport, error) {
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = TrendGranularityDay
+	}
+	switch granularity {
+	case TrendGranularityDay, TrendGranularityWeek, TrendGranularityMonth:
+	default:
+		return nil, errors.ErrInvalidParams.WithMessage("统计粒度只支持 day、week、month")
+	}
+
+	start := truncateToDate(req.StartDate)
+	end := truncateToDate(req.EndDate)
+	if end.Before(start) {
+		return nil, errors.ErrInvalidParams.WithMessage("结束日期不能早于开始日期")
+	}
+
+	buckets := revenueTrendBuckets(granularity, start, end)
+	if len(buckets) > revenueTrendMaxBuckets {
+		return nil, errors.ErrInvalidParams.WithMessage(fmt.Sprintf("统计周期数不能超过 %d 个", revenueTrendMaxBuckets))
+	}
+
+	current, err := s.revenueTrendSeries(ctx, buckets)
+	if err != nil {
+		return nil, err
+	}
+	report := &RevenueTrendReport{
+		Granularity:  granularity,
+		Current:      current,
+		CurrentTotal: sumRevenueTrend(current, start, end),
+	}
+	if !req.Compare {
+		return report, nil
+	}
+
+	// 上一周期与本期天数相同，结束于本期开始的前一天
+	days := daysBetween(start, end) + 1
+	prevEnd := start.AddDate(0, 0, -1)
+	prevStart := prevEnd.AddDate(0, 0, -(days - 1))
+	previous, err := s.revenueTrendSeries(ctx, revenueTrendBuckets(granularity, prevStart, prevEnd))
+	if err != nil {
+		return nil, err
+	}
+	previousTotal := sumRevenueTrend(previous, prevStart, prevEnd)
+	report.Previous = previous
+	report.PreviousTotal = &previousTotal
+	report.Growth = &RevenueTrendGrowth{
+		Revenue:    growthRate(report.CurrentTotal.Revenue, previousTotal.Revenue),
+		Refund:     growthRate(report.CurrentTotal.Refund, previousTotal.Refund),
+		Commission: growthRate(report.CurrentTotal.Commission, previousTotal.Commission),
+		NetRevenue: growthRate(report.CurrentTotal.NetRevenue, previousTotal.NetRevenue),
+		OrderCount: growthRate(float64(report.CurrentTotal.OrderCount), float64(previousTotal.OrderCount)),
+	}
+	return report, nil
+}
+
+// revenueTrendSeries 统计各周期的收入、退款、佣金及订单数
+// 四项指标在一条 UNION ALL 查询中按周期分组汇总，周期边界由 Go 按本地时区计算后作为参数传入
+func (s *FinanceDashboardService) revenueTrendSeries(ctx context.Context, buckets []trendBucket) ([]RevenueTrend, error) {
+	trends := make([]RevenueTrend, len(buckets))
+	for i, bucket := range buckets {
+		trends[i] = RevenueTrend{
+			Date:      bucket.label,
+			StartDate: bucket.from.Format("2006-01-02"),
+			EndDate:   bucket.to.AddDate(0, 0, -1).Format("2006-01-02"),
+		}
+	}
+	if len(buckets) == 0 {
+		return trends, nil
+	}
+
+	from, to := buckets[0].from, buckets[len(buckets)-1].to
+	var parts []string
+	var args []interface{}
+	addMetric := func(metric, aggregate, table, column, condition string, conditionArgs ...interface{}) {
+		bucketExpr, bucketArgs := trendBucketExpr(column, buckets)
+		parts = append(parts, fmt.Sprintf(
+			"SELECT '%s' AS metric, %s AS bucket, %s AS total FROM %s WHERE %s AND %s >= ? AND %s < ? GROUP BY bucket",
+			metric, bucketExpr, aggregate, table, condition, column, column))
+		args = append(args, bucketArgs...)
+		args = append(args, conditionArgs...)
+		args = append(args, from, to)
+	}
+	// 周期收入
+	addMetric("revenue", "COALESCE(SUM(amount), 0)", "payments", "pay_time", "status = ?", models.PaymentStatusSuccess)
+	// 周期退款
+	addMetric("refund", "COALESCE(SUM(amount), 0)", "refunds", "created_at", "status = ?", models.RefundStatusSuccess)
+	// 周期佣金支出
+	addMetric("commission", "COALESCE(SUM(amount), 0)", "commissions", "settled_at", "status = ?", models.CommissionStatusSettled)
+	// 周期订单数
+	addMetric("orders", "COUNT(*)", "orders", "created_at", "status NOT IN ?",
+		[]string{models.OrderStatusPending, models.OrderStatusCancelled})
+
+	rows, err := s.db.WithContext(ctx).Raw(strings.Join(parts, " UNION ALL "), args...).Rows()
+	if err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var metric string
+		var bucket int
+		var total float64
+		if err := rows.Scan(&metric, &bucket, &total); err != nil {
+			return nil, errors.ErrDatabaseError.WithError(err)
+		}
+		if bucket < 0 || bucket >= len(trends) {
+			continue
+		}
+		trend := &trends[bucket]
+		switch metric {
+		case "revenue":
+			trend.Revenue = total
+		case "refund":
+			trend.Refund = total
+		case "commission":
+			trend.Commission = total
+		case "orders":
+			trend.OrderCount = int64(total)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	// 净收入
+	for i := range trends {
+		trends[i].NetRevenue = roundAmount(trends[i].Revenue - trends[i].Refund - trends[i].Commission)
+	}
+	return trends, nil
+}
+
+// trendBucketExpr 将时间列映射为周期序号的 CASE 表达式，按周期起点从后往前匹配
+// 周期首尾相连，调用方需将时间列限定在 [首个周期起点, 末个周期终点) 内
+func trendBucketExpr(column string, buckets []trendBucket) (string, []interface{}) {
+	if len(buckets) == 1 {
+		return "0", nil
+	}
+
+	var b strings.Builder
+	args := make([]interface{}, 0, len(buckets)-1)
+	b.WriteString("CASE")
+	for i := len(buckets) - 1; i > 0; i-- {
+		fmt.Fprintf(&b, " WHEN %s >= ? THEN %d", column, i)
+		args = append(args, buckets[i].from)
+	}
+	b.WriteString(" ELSE 0 END")
+	return b.String(), args
+}
+
+// revenueTrendBuckets 将 [start, end] 按粒度切分为统计周期，首尾不完整的周、月只保留范围内的部分
+func revenueTrendBuckets(granularity string, start, end time.Time) []trendBucket {
+	var buckets []trendBucket
+	limit := end.AddDate(0, 0, 1)
+	for cur := start; cur.Before(limit); {
+		var next time.Time
+		var label string
+		switch granularity {
+		case TrendGranularityWeek:
+			// ISO 周从周一开始
+			offset := (int(cur.Weekday()) + 6) % 7
+			next = cur.AddDate(0, 0, 7-offset)
+			year, week := cur.ISOWeek()
+			label = fmt.Sprintf("%d-W%02d", year, week)
+		case TrendGranularityMonth:
+			next = time.Date(cur.Year(), cur.Month()+1, 1, 0, 0, 0, 0, cur.Location())
+			label = cur.Format("2006-01")
+		default:
+			next = cur.AddDate(0, 0, 1)
+			label = cur.Format("2006-01-02")
+		}
+		if next.After(limit) {
+			next = limit
+		}
+		buckets = append(buckets, trendBucket{label: label, from: cur, to: next})
+		cur = next
+	}
+	return buckets
+}
+
+// sumRevenueTrend 汇总各周期数据
+func sumRevenueTrend(trends []RevenueTrend, start, end time.Time) RevenueTrendTotal {
+	total := RevenueTrendTotal{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+	}
+	for _, trend := range trends {
+		total.Revenue += trend.Revenue
+		total.Refund += trend.Refund
+		total.Commission += trend.Commission
+		total.OrderCount += trend.OrderCount
+	}
+	total.Revenue = roundAmount(total.Revenue)
+	total.Refund = roundAmount(total.Refund)
+	total.Commission = roundAmount(total.Commission)
+	total.NetRevenue = roundAmount(total.Revenue - total.Refund - total.Commission)
+	return total
+}
+
+// growthRate 增长率百分比，保留两位小数；上一周期为 0 时无法计算，返回 nil
+func growthRate(current, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	rate := roundAmount((current - previous) / previous * 100)
+	return &rate
+}
+
+// truncateToDate 取本地时区的当天零点
+func truncateToDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// daysBetween 两个日期相差的自然日数，不受夏令时影响
+func daysBetween(start, end time.Time) int {
+	s := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	e := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	return int(e.Sub(s).Hours() / 24)
+}
@@ -0,0 +1,198 @@
+package finance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// localDate 本地时区指定日期的零点
+func localDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
+
+// createTestPaymentAt 创建指定支付时间的成功支付
+func createTestPaymentAt(t *testing.T, db *gorm.DB, userID int64, amount float64, paidAt time.Time) {
+	t.Helper()
+
+	payment := createTestPayment(t, db, userID, amount, models.PaymentStatusSuccess)
+	require.NoError(t, db.Model(payment).Update("pay_time", paidAt).Error)
+}
+
+// seedLeapWeekPayments 在 2024 年第 9 周（2月26日至3月3日，含闰日）及上一周写入支付数据
+func seedLeapWeekPayments(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	user := createFinanceTestUser(t, db, "13800172001")
+	noon := func(month time.Month, day int) time.Time { return localDate(2024, month, day).Add(12 * time.Hour) }
+
+	createTestPaymentAt(t, db, user.ID, 999.0, noon(time.February, 18)) // 不在任何统计范围内
+	createTestPaymentAt(t, db, user.ID, 80.0, noon(time.February, 20))
+	createTestPaymentAt(t, db, user.ID, 100.0, noon(time.February, 26))
+	createTestPaymentAt(t, db, user.ID, 50.0, noon(time.February, 29))
+	createTestPaymentAt(t, db, user.ID, 30.0, noon(time.March, 1))
+	createTestPaymentAt(t, db, user.ID, 20.0, localDate(2024, time.March, 3).Add(24*time.Hour-time.Second))
+}
+
+func trendDates(trends []RevenueTrend) []string {
+	dates := make([]string, len(trends))
+	for i, trend := range trends {
+		dates[i] = trend.Date
+	}
+	return dates
+}
+
+func trendRevenues(trends []RevenueTrend) []float64 {
+	revenues := make([]float64, len(trends))
+	for i, trend := range trends {
+		revenues[i] = trend.Revenue
+	}
+	return revenues
+}
+
+func TestFinanceDashboardService_GetRevenueTrendReport_Granularity(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := NewFinanceDashboardService(db)
+	ctx := context.Background()
+	seedLeapWeekPayments(t, db)
+
+	start := localDate(2024, time.February, 26)
+	end := localDate(2024, time.March, 3)
+
+	t.Run("按日统计包含闰日", func(t *testing.T) {
+		report, err := svc.GetRevenueTrendReport(ctx, &RevenueTrendRequest{
+			Granularity: TrendGranularityDay,
+			StartDate:   start,
+			EndDate:     end,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"2024-02-26", "2024-02-27", "2024-02-28", "2024-02-29",
+			"2024-03-01", "2024-03-02", "2024-03-03",
+		}, trendDates(report.Current))
+		assert.Equal(t, []float64{100, 0, 0, 50, 30, 0, 20}, trendRevenues(report.Current))
+		assert.Equal(t, 200.0, report.CurrentTotal.Revenue)
+		assert.Nil(t, report.Previous)
+		assert.Nil(t, report.Growth)
+	})
+
+	t.Run("按 ISO 周统计", func(t *testing.T) {
+		report, err := svc.GetRevenueTrendReport(ctx, &RevenueTrendRequest{
+			Granularity: TrendGranularityWeek,
+			StartDate:   start,
+			EndDate:     end,
+		})
+		require.NoError(t, err)
+		require.Len(t, report.Current, 1)
+		assert.Equal(t, "2024-W09", report.Current[0].Date)
+		assert.Equal(t, "2024-02-26", report.Current[0].StartDate)
+		assert.Equal(t, "2024-03-03", report.Current[0].EndDate)
+		assert.Equal(t, 200.0, report.Current[0].Revenue)
+	})
+
+	t.Run("按月统计跨月边界只统计范围内的部分", func(t *testing.T) {
+		report, err := svc.GetRevenueTrendReport(ctx, &RevenueTrendRequest{
+			Granularity: TrendGranularityMonth,
+			StartDate:   start,
+			EndDate:     end,
+		})
+		require.NoError(t, err)
+		require.Len(t, report.Current, 2)
+		assert.Equal(t, "2024-02", report.Current[0].Date)
+		assert.Equal(t, "2024-02-26", report.Current[0].StartDate)
+		assert.Equal(t, "2024-02-29", report.Current[0].EndDate)
+		assert.Equal(t, 150.0, report.Current[0].Revenue)
+		assert.Equal(t, "2024-03", report.Current[1].Date)
+		assert.Equal(t, "2024-03-01", report.Current[1].StartDate)
+		assert.Equal(t, "2024-03-03", report.Current[1].EndDate)
+		assert.Equal(t, 50.0, report.Current[1].Revenue)
+	})
+}
+
+func TestFinanceDashboardService_GetRevenueTrendReport_Compare(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := NewFinanceDashboardService(db)
+	ctx := context.Background()
+	seedLeapWeekPayments(t, db)
+
+	report, err := svc.GetRevenueTrendReport(ctx, &RevenueTrendRequest{
+		Granularity: TrendGranularityWeek,
+		StartDate:   localDate(2024, time.February, 26),
+		EndDate:     localDate(2024, time.March, 3),
+		Compare:     true,
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, report.PreviousTotal)
+	assert.Equal(t, "2024-02-19", report.PreviousTotal.StartDate)
+	assert.Equal(t, "2024-02-25", report.PreviousTotal.EndDate)
+	assert.Equal(t, 80.0, report.PreviousTotal.Revenue)
+	assert.Equal(t, []string{"2024-W08"}, trendDates(report.Previous))
+
+	require.NotNil(t, report.Growth)
+	require.NotNil(t, report.Growth.Revenue)
+	assert.Equal(t, 150.0, *report.Growth.Revenue)
+	require.NotNil(t, report.Growth.NetRevenue)
+	assert.Equal(t, 150.0, *report.Growth.NetRevenue)
+	assert.Nil(t, report.Growth.Refund, "上一周期无退款时无法计算增长率")
+	assert.Nil(t, report.Growth.OrderCount)
+
+	t.Run("跨月的等长上一周期按月切分", func(t *testing.T) {
+		report, err := svc.GetRevenueTrendReport(ctx, &RevenueTrendRequest{
+			Granularity: TrendGranularityMonth,
+			StartDate:   localDate(2024, time.February, 29),
+			EndDate:     localDate(2024, time.March, 3),
+			Compare:     true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "2024-02-25", report.PreviousTotal.StartDate)
+		assert.Equal(t, "2024-02-28", report.PreviousTotal.EndDate)
+		assert.Equal(t, []string{"2024-02"}, trendDates(report.Previous))
+		assert.Equal(t, 100.0, report.PreviousTotal.Revenue)
+		assert.Equal(t, 100.0, report.CurrentTotal.Revenue)
+		require.NotNil(t, report.Growth.Revenue)
+		assert.Equal(t, 0.0, *report.Growth.Revenue)
+	})
+}
+
+func TestFinanceDashboardService_GetRevenueTrendReport_InvalidParams(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := NewFinanceDashboardService(db)
+	ctx := context.Background()
+
+	cases := map[string]*RevenueTrendRequest{
+		"不支持的统计粒度":   {Granularity: "year", StartDate: localDate(2024, 1, 1), EndDate: localDate(2024, 1, 31)},
+		"结束日期早于开始日期": {StartDate: localDate(2024, 2, 1), EndDate: localDate(2024, 1, 31)},
+		"统计周期过多":     {Granularity: TrendGranularityDay, StartDate: localDate(2023, 1, 1), EndDate: localDate(2024, 12, 31)},
+	}
+	for name, req := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := svc.GetRevenueTrendReport(ctx, req)
+			assertSettlementErrorCode(t, err, appErrors.ErrInvalidParams.Code)
+		})
+	}
+}
+
+func TestRevenueTrendBuckets_ISOWeekEdges(t *testing.T) {
+	// 2024年12月30日所在的 ISO 周属于 2025 年第 1 周，范围首尾的周只统计范围内的天数
+	buckets := revenueTrendBuckets(TrendGranularityWeek, localDate(2024, time.December, 27), localDate(2025, time.January, 7))
+	require.Len(t, buckets, 3)
+
+	assert.Equal(t, "2024-W52", buckets[0].label)
+	assert.Equal(t, localDate(2024, time.December, 27), buckets[0].from)
+	assert.Equal(t, localDate(2024, time.December, 30), buckets[0].to)
+
+	assert.Equal(t, "2025-W01", buckets[1].label)
+	assert.Equal(t, localDate(2025, time.January, 6), buckets[1].to)
+
+	assert.Equal(t, "2025-W02", buckets[2].label)
+	assert.Equal(t, localDate(2025, time.January, 6), buckets[2].from)
+	assert.Equal(t, localDate(2025, time.January, 8), buckets[2].to)
+}
@@ -0,0 +1,69 @@
+package finance
+
+import (
+	"context"
+	"time"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// errSettlementPeriodExists 结算对象在该周期已有结算记录
+var errSettlementPeriodExists = errors.ErrDuplicateRecord.WithMessage("该周期已存在结算记录")
+
+// SettlementGenerateError 单个结算对象的生成失败信息
+// TargetID 为 0 表示该类型的结算对象列表获取失败，整类未生成
+type SettlementGenerateError struct {
+	Type     string `json:"type"`
+	TargetID int64  `json:"target_id"`
+	Code     int    `json:"code"`
+	Message  string `json:"message"`
+}
+
+// newSettlementGenerateError 创建结算生成失败信息
+func newSettlementGenerateError(settlementType string, targetID int64, err error) *SettlementGenerateError {
+	appErr := errors.GetAppError(err)
+	return &SettlementGenerateError{
+		Type:     settlementType,
+		TargetID: targetID,
+		Code:     appErr.Code,
+		Message:  appErr.Message,
+	}
+}
+
+// GenerateAllResult 一键生成结算结果
+type GenerateAllResult struct {
+	MerchantSettlements    []*models.Settlement       `json:"merchant_settlements"`
+	DistributorSettlements []*models.Settlement       `json:"distributor_settlements"`
+	Errors                 []*SettlementGenerateError `json:"errors"`
+}
+
+// GenerateAllSettlements 为所有商户和分销商生成指定周期的结算记录
+// 部分失败不会中断整体生成，失败原因按结算对象记录在 Errors 中
+func (s *SettlementService) GenerateAllSettlements(ctx context.Context, periodStart, periodEnd time.Time, adminID int64) (*GenerateAllResult, error) {
+	if periodEnd.Before(periodStart) {
+		return nil, errors.ErrInvalidParams.WithMessage("周期结束日期不能早于开始日期")
+	}
+
+	result := &GenerateAllResult{
+		MerchantSettlements:    []*models.Settlement{},
+		DistributorSettlements: []*models.Settlement{},
+		Errors:                 []*SettlementGenerateError{},
+	}
+
+	merchantSettlements, failures, err := s.generateMerchantSettlements(ctx, periodStart, periodEnd, adminID)
+	if err != nil {
+		result.Errors = append(result.Errors, newSettlementGenerateError(models.SettlementTypeMerchant, 0, err))
+	}
+	result.MerchantSettlements = append(result.MerchantSettlements, merchantSettlements...)
+	result.Errors = append(result.Errors, failures...)
+
+	distributorSettlements, failures, err := s.generateDistributorSettlements(ctx, periodStart, periodEnd, adminID)
+	if err != nil {
+		result.Errors = append(result.Errors, newSettlementGenerateError(models.SettlementTypeDistributor, 0, err))
+	}
+	result.DistributorSettlements = append(result.DistributorSettlements, distributorSettlements...)
+	result.Errors = append(result.Errors, failures...)
+
+	return result, nil
+}
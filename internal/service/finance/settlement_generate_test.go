@@ -0,0 +1,80 @@
+package finance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// TestSettlementService_GenerateAllSettlements_PartialFailure 单个商户结算失败不影响其他商户和分销商的结算
+func TestSettlementService_GenerateAllSettlements_PartialFailure(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupSettlementService(db)
+	ctx := context.Background()
+
+	periodStart := time.Now().Add(-time.Hour)
+	periodEnd := time.Now().Add(time.Hour)
+	user := createFinanceTestUser(t, db, "13800138061")
+
+	okMerchant := createTestMerchant(t, db, "正常商户")
+	okVenue := createTestVenue(t, db, okMerchant.ID, "正常场地")
+	okDevice := createTestDevice(t, db, okVenue.ID, "DEV_ALL_OK")
+	createCompletedRental(t, db, user.ID, okDevice.ID, 100.0)
+
+	// 该商户在同一周期已有结算记录
+	dupMerchant := createTestMerchant(t, db, "重复商户")
+	dupVenue := createTestVenue(t, db, dupMerchant.ID, "重复场地")
+	dupDevice := createTestDevice(t, db, dupVenue.ID, "DEV_ALL_DUP")
+	createCompletedRental(t, db, user.ID, dupDevice.ID, 50.0)
+	require.NoError(t, db.Create(&models.Settlement{
+		SettlementNo: fmt.Sprintf("ST%d", time.Now().UnixNano()),
+		Type:         models.SettlementTypeMerchant,
+		TargetID:     dupMerchant.ID,
+		PeriodStart:  periodStart,
+		PeriodEnd:    periodEnd,
+		TotalAmount:  50.0,
+		Status:       models.SettlementStatusPending,
+	}).Error)
+
+	distributor := createTestDistributor(t, db, user.ID)
+	order := createTestOrder(t, db, user.ID, 100.0, models.OrderStatusCompleted)
+	createTestCommission(t, db, distributor.ID, order.ID, user.ID, 10.0, models.CommissionStatusPending)
+
+	result, err := svc.GenerateAllSettlements(ctx, periodStart, periodEnd, 1)
+	require.NoError(t, err)
+
+	require.Len(t, result.MerchantSettlements, 1)
+	assert.Equal(t, okMerchant.ID, result.MerchantSettlements[0].TargetID)
+	assert.Equal(t, 100.0, result.MerchantSettlements[0].TotalAmount)
+
+	require.Len(t, result.DistributorSettlements, 1)
+	assert.Equal(t, distributor.ID, result.DistributorSettlements[0].TargetID)
+	assert.Equal(t, 10.0, result.DistributorSettlements[0].TotalAmount)
+	require.NotNil(t, result.DistributorSettlements[0].OperatorID)
+	assert.Equal(t, int64(1), *result.DistributorSettlements[0].OperatorID)
+
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, models.SettlementTypeMerchant, result.Errors[0].Type)
+	assert.Equal(t, dupMerchant.ID, result.Errors[0].TargetID)
+	assert.Equal(t, appErrors.ErrDuplicateRecord.Code, result.Errors[0].Code)
+
+	// 单独生成接口保持原有行为：已存在的结算静默跳过
+	settlements, err := svc.GenerateMerchantSettlements(ctx, periodStart, periodEnd, 1)
+	require.NoError(t, err)
+	assert.Empty(t, settlements)
+}
+
+func TestSettlementService_GenerateAllSettlements_InvalidPeriod(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupSettlementService(db)
+
+	_, err := svc.GenerateAllSettlements(context.Background(), time.Now(), time.Now().Add(-time.Hour), 1)
+	assertSettlementErrorCode(t, err, appErrors.ErrInvalidParams.Code)
+}
@@ -0,0 +1,159 @@
+package finance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// createCompletedRental 创建指定设备上已完成的租借订单
+func createCompletedRental(t *testing.T, db *gorm.DB, userID, deviceID int64, amount float64) *models.Order {
+	t.Helper()
+
+	order := createTestOrder(t, db, userID, amount, models.OrderStatusCompleted)
+	require.NoError(t, db.Create(&models.Rental{
+		OrderID:  order.ID,
+		UserID:   userID,
+		DeviceID: deviceID,
+		Status:   models.RentalStatusCompleted,
+	}).Error)
+	return order
+}
+
+// assertSettlementErrorCode 断言错误为指定错误码的 AppError
+func assertSettlementErrorCode(t *testing.T, err error, code int) {
+	t.Helper()
+
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok, "expected AppError, got %v", err)
+	assert.Equal(t, code, appErr.Code)
+}
+
+// TestSettlementService_GenerateMerchantSettlements_Items 生成商户结算时写入订单明细，明细金额之和等于结算总额
+func TestSettlementService_GenerateMerchantSettlements_Items(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupSettlementService(db)
+	ctx := context.Background()
+
+	merchant := createTestMerchant(t, db, "明细商户")
+	venue := createTestVenue(t, db, merchant.ID, "明细场地")
+	device := createTestDevice(t, db, venue.ID, "DEV_ITEMS")
+	user := createFinanceTestUser(t, db, "13800138031")
+
+	orders := []*models.Order{
+		createCompletedRental(t, db, user.ID, device.ID, 33.33),
+		createCompletedRental(t, db, user.ID, device.ID, 50.00),
+		createCompletedRental(t, db, user.ID, device.ID, 16.67),
+	}
+
+	settlements, err := svc.GenerateMerchantSettlements(ctx, time.Now().Add(-time.Hour), time.Now().Add(time.Hour), 1)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+	settlement := settlements[0]
+	assert.Equal(t, 100.0, settlement.TotalAmount)
+	assert.Equal(t, 3, settlement.OrderCount)
+
+	items, total, err := svc.ListSettlementItems(ctx, settlement.ID, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, items, 3)
+
+	var sum float64
+	for i, item := range items {
+		assert.Equal(t, orders[i].ID, item.OrderID)
+		assert.Equal(t, orders[i].OrderNo, item.OrderNo)
+		assert.NotNil(t, item.RentalID)
+		assert.Equal(t, merchant.CommissionRate, item.CommissionRate)
+		assert.Equal(t, merchantShare(item.Amount, merchant.CommissionRate), item.MerchantShare)
+		sum += item.Amount
+	}
+	assert.InDelta(t, settlement.TotalAmount, sum, 0.001)
+
+	// 订单时间线记录计入结算，仅管理员可见
+	var notes []*models.OrderNote
+	require.NoError(t, db.Where("author_type = ?", models.OrderNoteAuthorSystem).Order("order_id").Find(&notes).Error)
+	require.Len(t, notes, 3)
+	for i, note := range notes {
+		assert.Equal(t, orders[i].ID, note.OrderID)
+		assert.Contains(t, note.Content, settlement.SettlementNo)
+		assert.False(t, note.VisibleToUser)
+	}
+
+	// 分页
+	items, total, err = svc.ListSettlementItems(ctx, settlement.ID, 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, items, 1)
+
+	_, _, err = svc.ListSettlementItems(ctx, 99999, 1, 10)
+	assertSettlementErrorCode(t, err, appErrors.ErrSettlementNotFound.Code)
+}
+
+// TestSettlementService_ValidateSettlement 校验结算汇总与明细的一致性
+func TestSettlementService_ValidateSettlement(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupSettlementService(db)
+	ctx := context.Background()
+
+	merchant := createTestMerchant(t, db, "校验商户")
+	venue := createTestVenue(t, db, merchant.ID, "校验场地")
+	device := createTestDevice(t, db, venue.ID, "DEV_VALIDATE")
+	user := createFinanceTestUser(t, db, "13800138032")
+	createCompletedRental(t, db, user.ID, device.ID, 60.0)
+	createCompletedRental(t, db, user.ID, device.ID, 40.0)
+
+	settlement, err := svc.CreateSettlement(ctx, &CreateSettlementRequest{
+		Type:        models.SettlementTypeMerchant,
+		TargetID:    merchant.ID,
+		PeriodStart: time.Now().Add(-time.Hour),
+		PeriodEnd:   time.Now().Add(time.Hour),
+	}, 1)
+	require.NoError(t, err)
+	require.Equal(t, 100.0, settlement.TotalAmount)
+
+	t.Run("明细与汇总一致", func(t *testing.T) {
+		assert.NoError(t, svc.ValidateSettlement(ctx, settlement.ID))
+	})
+
+	var item models.SettlementItem
+	require.NoError(t, db.Where("settlement_id = ?", settlement.ID).Order("id ASC").First(&item).Error)
+
+	t.Run("篡改明细金额被检测", func(t *testing.T) {
+		require.NoError(t, db.Model(&models.SettlementItem{}).Where("id = ?", item.ID).
+			Updates(map[string]interface{}{"amount": 70.0, "merchant_share": 63.0}).Error)
+		defer db.Model(&models.SettlementItem{}).Where("id = ?", item.ID).
+			Updates(map[string]interface{}{"amount": item.Amount, "merchant_share": item.MerchantShare})
+
+		err := svc.ValidateSettlement(ctx, settlement.ID)
+		assertSettlementErrorCode(t, err, appErrors.ErrSettlementInconsistent.Code)
+	})
+
+	t.Run("篡改商户应得金额被检测", func(t *testing.T) {
+		require.NoError(t, db.Model(&models.SettlementItem{}).Where("id = ?", item.ID).
+			Update("merchant_share", item.MerchantShare+1).Error)
+		defer db.Model(&models.SettlementItem{}).Where("id = ?", item.ID).
+			Update("merchant_share", item.MerchantShare)
+
+		err := svc.ValidateSettlement(ctx, settlement.ID)
+		assertSettlementErrorCode(t, err, appErrors.ErrSettlementInconsistent.Code)
+	})
+
+	t.Run("缺失明细被检测", func(t *testing.T) {
+		require.NoError(t, db.Delete(&models.SettlementItem{}, item.ID).Error)
+
+		err := svc.ValidateSettlement(ctx, settlement.ID)
+		assertSettlementErrorCode(t, err, appErrors.ErrSettlementInconsistent.Code)
+	})
+
+	t.Run("结算不存在", func(t *testing.T) {
+		err := svc.ValidateSettlement(ctx, 99999)
+		assertSettlementErrorCode(t, err, appErrors.ErrSettlementNotFound.Code)
+	})
+}
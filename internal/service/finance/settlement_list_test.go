@@ -0,0 +1,86 @@
+package finance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+func TestSettlementService_ListSettlements_FilterAndSort(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupSettlementService(db)
+	ctx := context.Background()
+
+	date := func(month time.Month, day int) time.Time { return time.Date(2026, month, day, 0, 0, 0, 0, time.UTC) }
+	create := func(no string, targetID int64, start, end time.Time, amount float64) {
+		require.NoError(t, db.Create(&models.Settlement{
+			SettlementNo: no, Type: models.SettlementTypeMerchant, TargetID: targetID,
+			PeriodStart: start, PeriodEnd: end, TotalAmount: amount, ActualAmount: amount,
+			Status: models.SettlementStatusPending,
+		}).Error)
+	}
+	create("FEB", 1, date(2, 1), date(2, 27), 80)
+	create("SPAN", 1, date(2, 28), date(3, 3), 120)
+	create("MAR", 1, date(3, 10), date(3, 16), 60)
+	create("MAR-OTHER", 2, date(3, 10), date(3, 16), 500)
+	numbers := func(list []*models.Settlement) []string {
+		result := make([]string, 0, len(list))
+		for _, s := range list {
+			result = append(result, s.SettlementNo)
+		}
+		return result
+	}
+
+	t.Run("周期与三月重叠", func(t *testing.T) {
+		targetID := int64(1)
+		list, total, err := svc.ListSettlements(ctx, &SettlementListRequest{
+			TargetID:        &targetID,
+			PeriodStartFrom: "2026-03-01",
+			PeriodStartTo:   "2026-03-31",
+			SortBy:          "actual_amount",
+			SortOrder:       "desc",
+			Page:            1,
+			PageSize:        20,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		// 2 月 28 日至 3 月 3 日的结算跨入三月，应被包含
+		assert.Equal(t, []string{"SPAN", "MAR"}, numbers(list))
+	})
+
+	t.Run("金额区间", func(t *testing.T) {
+		minAmount, maxAmount := 70.0, 200.0
+		list, _, err := svc.ListSettlements(ctx, &SettlementListRequest{
+			MinAmount: &minAmount, MaxAmount: &maxAmount, SortBy: "period_start", SortOrder: "asc", Page: 1, PageSize: 20,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"FEB", "SPAN"}, numbers(list))
+	})
+
+	t.Run("无效排序字段回退到创建时间", func(t *testing.T) {
+		list, total, err := svc.ListSettlements(ctx, &SettlementListRequest{SortBy: "1; DROP TABLE settlements", SortOrder: "sideways", Page: 1, PageSize: 20})
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), total)
+		assert.Equal(t, []string{"MAR-OTHER", "MAR", "SPAN", "FEB"}, numbers(list))
+	})
+
+	t.Run("参数校验", func(t *testing.T) {
+		minAmount, maxAmount := 100.0, 10.0
+		for name, req := range map[string]*SettlementListRequest{
+			"日期格式错误": {PeriodStartFrom: "2026/03/01"},
+			"日期区间倒置": {PeriodStartFrom: "2026-03-31", PeriodStartTo: "2026-03-01"},
+			"金额区间倒置": {MinAmount: &minAmount, MaxAmount: &maxAmount},
+		} {
+			req.Page, req.PageSize = 1, 20
+			_, _, err := svc.ListSettlements(ctx, req)
+			require.Error(t, err, name)
+			assert.Equal(t, appErrors.ErrInvalidParams.Code, err.(*appErrors.AppError).Code, name)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package finance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// createCommissionAt 创建指定时间的待结算佣金
+func createCommissionAt(t *testing.T, db *gorm.DB, distributorID, orderID, fromUserID int64, amount float64, createdAt time.Time) *models.Commission {
+	t.Helper()
+
+	commission := &models.Commission{
+		DistributorID: distributorID,
+		OrderID:       orderID,
+		FromUserID:    fromUserID,
+		Type:          models.CommissionTypeDirect,
+		OrderAmount:   amount * 10,
+		Rate:          0.1,
+		Amount:        amount,
+		Status:        models.CommissionStatusPending,
+		CreatedAt:     createdAt,
+	}
+	require.NoError(t, db.Create(commission).Error)
+	return commission
+}
+
+// TestSettlementService_GenerateDistributorSettlements_OverlappingPeriods 重叠周期生成结算不重复计入佣金
+func TestSettlementService_GenerateDistributorSettlements_OverlappingPeriods(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupSettlementService(db)
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800170001")
+	distributor := createTestDistributor(t, db, user.ID)
+	orderID := func() int64 { return createTestOrder(t, db, user.ID, 100.0, models.OrderStatusCompleted).ID }
+
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+	c2 := createCommissionAt(t, db, distributor.ID, orderID(), user.ID, 10.0, day(2))
+	c5 := createCommissionAt(t, db, distributor.ID, orderID(), user.ID, 20.0, day(5))
+	c6 := createCommissionAt(t, db, distributor.ID, orderID(), user.ID, 30.0, day(6))
+	c9 := createCommissionAt(t, db, distributor.ID, orderID(), user.ID, 40.0, day(9))
+
+	// 第一次生成 1月1日-1月7日
+	first, err := svc.GenerateDistributorSettlements(ctx,
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 7, 23, 59, 59, 0, time.UTC), 1)
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	assert.Equal(t, 60.0, first[0].TotalAmount)
+	assert.Equal(t, 3, first[0].OrderCount)
+
+	// 第二次生成 1月5日-1月10日，5日、6日的佣金已锁定到第一张结算单
+	second, err := svc.GenerateDistributorSettlements(ctx,
+		time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 10, 23, 59, 59, 0, time.UTC), 1)
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+	assert.Equal(t, 40.0, second[0].TotalAmount)
+	assert.Equal(t, 1, second[0].OrderCount)
+
+	for _, c := range []*models.Commission{c2, c5, c6} {
+		var locked models.Commission
+		require.NoError(t, db.First(&locked, c.ID).Error)
+		require.NotNil(t, locked.SettlementID)
+		assert.Equal(t, first[0].ID, *locked.SettlementID)
+	}
+	var locked9 models.Commission
+	require.NoError(t, db.First(&locked9, c9.ID).Error)
+	require.NotNil(t, locked9.SettlementID)
+	assert.Equal(t, second[0].ID, *locked9.SettlementID)
+
+	// 两张结算单都审核通过并处理后，分销商余额只入账一次
+	require.NoError(t, db.Model(&models.Settlement{}).
+		Where("id IN ?", []int64{first[0].ID, second[0].ID}).
+		Update("status", models.SettlementStatusApproved).Error)
+	require.NoError(t, svc.ProcessSettlement(ctx, second[0].ID, 1))
+
+	var settledCount int64
+	require.NoError(t, db.Model(&models.Commission{}).
+		Where("distributor_id = ? AND status = ?", distributor.ID, models.CommissionStatusSettled).
+		Count(&settledCount).Error)
+	assert.Equal(t, int64(1), settledCount, "处理第二张结算单只能结算其锁定的佣金")
+
+	require.NoError(t, svc.ProcessSettlement(ctx, first[0].ID, 1))
+
+	var updated models.Distributor
+	require.NoError(t, db.First(&updated, distributor.ID).Error)
+	assert.Equal(t, 100.0, updated.AvailableCommission)
+
+	require.NoError(t, db.Model(&models.Commission{}).
+		Where("distributor_id = ? AND status = ?", distributor.ID, models.CommissionStatusSettled).
+		Count(&settledCount).Error)
+	assert.Equal(t, int64(4), settledCount)
+}
+
+func TestSettlementService_CreateSettlement_OverlappingPeriod(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupSettlementService(db)
+	ctx := context.Background()
+
+	merchant := createTestMerchant(t, db, "重叠周期商户")
+	day := func(d int) time.Time { return time.Date(2024, 2, d, 0, 0, 0, 0, time.UTC) }
+
+	_, err := svc.CreateSettlement(ctx, &CreateSettlementRequest{
+		Type: models.SettlementTypeMerchant, TargetID: merchant.ID, PeriodStart: day(1), PeriodEnd: day(7),
+	}, 1)
+	require.NoError(t, err)
+
+	t.Run("部分重叠的周期被拒绝", func(t *testing.T) {
+		_, err := svc.CreateSettlement(ctx, &CreateSettlementRequest{
+			Type: models.SettlementTypeMerchant, TargetID: merchant.ID, PeriodStart: day(5), PeriodEnd: day(10),
+		}, 1)
+		require.Error(t, err)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrDuplicateRecord.Code, appErr.Code)
+	})
+
+	t.Run("包含已有周期的周期被拒绝", func(t *testing.T) {
+		_, err := svc.CreateSettlement(ctx, &CreateSettlementRequest{
+			Type: models.SettlementTypeMerchant, TargetID: merchant.ID, PeriodStart: day(1), PeriodEnd: day(20),
+		}, 1)
+		require.Error(t, err)
+	})
+
+	t.Run("不重叠的周期可以创建", func(t *testing.T) {
+		_, err := svc.CreateSettlement(ctx, &CreateSettlementRequest{
+			Type: models.SettlementTypeMerchant, TargetID: merchant.ID, PeriodStart: day(8), PeriodEnd: day(14),
+		}, 1)
+		require.NoError(t, err)
+	})
+
+	t.Run("其他结算对象不受影响", func(t *testing.T) {
+		other := createTestMerchant(t, db, "其他商户")
+		_, err := svc.CreateSettlement(ctx, &CreateSettlementRequest{
+			Type: models.SettlementTypeMerchant, TargetID: other.ID, PeriodStart: day(5), PeriodEnd: day(10),
+		}, 1)
+		require.NoError(t, err)
+	})
+
+	t.Run("已失败的结算不占用周期", func(t *testing.T) {
+		failed := &models.Settlement{
+			SettlementNo: fmt.Sprintf("STF%d", time.Now().UnixNano()),
+			Type:         models.SettlementTypeMerchant,
+			TargetID:     merchant.ID,
+			PeriodStart:  day(21),
+			PeriodEnd:    day(25),
+			Status:       models.SettlementStatusFailed,
+		}
+		require.NoError(t, db.Create(failed).Error)
+
+		_, err := svc.CreateSettlement(ctx, &CreateSettlementRequest{
+			Type: models.SettlementTypeMerchant, TargetID: merchant.ID, PeriodStart: day(21), PeriodEnd: day(25),
+		}, 1)
+		require.NoError(t, err)
+	})
+}
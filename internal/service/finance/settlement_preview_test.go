@@ -0,0 +1,104 @@
+package finance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// TestSettlementService_PreviewSettlement_Merchant 商户结算预览与创建结果一致且预览不落库
+func TestSettlementService_PreviewSettlement_Merchant(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupSettlementService(db)
+	ctx := context.Background()
+
+	merchant := createTestMerchant(t, db, "预览商户")
+	venue := createTestVenue(t, db, merchant.ID, "预览场地")
+	device := createTestDevice(t, db, venue.ID, "DEV_PREVIEW")
+	user := createFinanceTestUser(t, db, "13800180001")
+
+	orders := []*models.Order{
+		createCompletedRental(t, db, user.ID, device.ID, 33.33),
+		createCompletedRental(t, db, user.ID, device.ID, 66.67),
+	}
+
+	req := &CreateSettlementRequest{
+		Type:        models.SettlementTypeMerchant,
+		TargetID:    merchant.ID,
+		PeriodStart: time.Now().Add(-time.Hour),
+		PeriodEnd:   time.Now().Add(time.Hour),
+	}
+
+	preview, err := svc.PreviewSettlement(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, preview.TotalAmount)
+	assert.Equal(t, 2, preview.OrderCount)
+	assert.Equal(t, []int64{orders[0].ID, orders[1].ID}, preview.OrderIDs)
+
+	var settlementCount, itemCount int64
+	require.NoError(t, db.Model(&models.Settlement{}).Count(&settlementCount).Error)
+	require.NoError(t, db.Model(&models.SettlementItem{}).Count(&itemCount).Error)
+	assert.Equal(t, int64(0), settlementCount)
+	assert.Equal(t, int64(0), itemCount)
+
+	settlement, err := svc.CreateSettlement(ctx, req, 1)
+	require.NoError(t, err)
+	assert.Equal(t, preview.TotalAmount, settlement.TotalAmount)
+	assert.Equal(t, preview.Fee, settlement.Fee)
+	assert.Equal(t, preview.ActualAmount, settlement.ActualAmount)
+	assert.Equal(t, preview.OrderCount, settlement.OrderCount)
+
+	// 已生成结算后同一周期不可再预览
+	_, err = svc.PreviewSettlement(ctx, req)
+	assertSettlementErrorCode(t, err, appErrors.ErrDuplicateRecord.Code)
+}
+
+// TestSettlementService_PreviewSettlement_Distributor 分销商结算预览与创建结果一致且不锁定佣金
+func TestSettlementService_PreviewSettlement_Distributor(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupSettlementService(db)
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800180002")
+	distributor := createTestDistributor(t, db, user.ID)
+	order1 := createTestOrder(t, db, user.ID, 100.0, models.OrderStatusCompleted)
+	order2 := createTestOrder(t, db, user.ID, 200.0, models.OrderStatusCompleted)
+	order3 := createTestOrder(t, db, user.ID, 400.0, models.OrderStatusCompleted)
+
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC) }
+	createCommissionAt(t, db, distributor.ID, order1.ID, user.ID, 10.0, day(2))
+	createCommissionAt(t, db, distributor.ID, order2.ID, user.ID, 20.0, day(3))
+	createCommissionAt(t, db, distributor.ID, order3.ID, user.ID, 40.0, day(20))
+
+	req := &CreateSettlementRequest{
+		Type:        models.SettlementTypeDistributor,
+		TargetID:    distributor.ID,
+		PeriodStart: day(1),
+		PeriodEnd:   day(10),
+	}
+
+	preview, err := svc.PreviewSettlement(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 30.0, preview.TotalAmount)
+	assert.Equal(t, 0.0, preview.Fee)
+	assert.Equal(t, 30.0, preview.ActualAmount)
+	assert.Equal(t, 2, preview.OrderCount)
+	assert.Equal(t, []int64{order1.ID, order2.ID}, preview.OrderIDs)
+
+	var locked int64
+	require.NoError(t, db.Model(&models.Commission{}).Where("settlement_id IS NOT NULL").Count(&locked).Error)
+	assert.Equal(t, int64(0), locked)
+
+	settlement, err := svc.CreateSettlement(ctx, req, 1)
+	require.NoError(t, err)
+	assert.Equal(t, preview.TotalAmount, settlement.TotalAmount)
+	assert.Equal(t, preview.Fee, settlement.Fee)
+	assert.Equal(t, preview.ActualAmount, settlement.ActualAmount)
+	assert.Equal(t, preview.OrderCount, settlement.OrderCount)
+}
@@ -0,0 +1,301 @@
+package finance
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+)
+
+// 结算重试相关常量
+const (
+	SettlementRetryQueueKey    = "settlement_retry_queue" // 待重试结算(有序集合，score 为下次重试时间)
+	SettlementRetryMetaKey     = "settlement_retry_meta"  // 重试元数据(哈希，field 为结算ID)
+	SettlementDeadLetterKey    = "settlement_dead_letter" // 死信结算(有序集合，score 为进入死信的时间)
+	SettlementRetryMaxFailures = 6                        // 最大失败次数，超过后进入死信
+	SettlementRetryBaseBackoff = time.Minute              // 首次重试间隔
+	SettlementRetryMaxBackoff  = 64 * time.Minute         // 最大重试间隔
+	DefaultSettlementRetryPoll = 30 * time.Second         // 默认轮询间隔
+	settlementRetryBatchSize   = 50
+)
+
+// SettlementRetryMeta 结算重试元数据
+type SettlementRetryMeta struct {
+	SettlementID int64     `json:"settlement_id"`
+	OperatorID   int64     `json:"operator_id"`
+	Failures     int       `json:"failures"`
+	LastError    string    `json:"last_error"`
+	LastFailedAt time.Time `json:"last_failed_at"`
+}
+
+// SettlementDeadLetter 死信结算
+type SettlementDeadLetter struct {
+	SettlementRetryMeta
+	DeadLetteredAt time.Time `json:"dead_lettered_at"`
+}
+
+// SettlementRetryQueue 基于 Redis 的结算重试队列
+type SettlementRetryQueue struct {
+	client redis.Cmdable
+	logger *zap.Logger
+}
+
+// NewSettlementRetryQueue 创建结算重试队列
+func NewSettlementRetryQueue(client redis.Cmdable, logger *zap.Logger) *SettlementRetryQueue {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &SettlementRetryQueue{
+		client: client,
+		logger: logger,
+	}
+}
+
+// settlementRetryBackoff 第 failures 次失败后的重试间隔：1, 2, 4 ... 64 分钟
+func settlementRetryBackoff(failures int) time.Duration {
+	backoff := SettlementRetryBaseBackoff
+	for i := 1; i < failures && backoff < SettlementRetryMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > SettlementRetryMaxBackoff {
+		backoff = SettlementRetryMaxBackoff
+	}
+	return backoff
+}
+
+// RecordFailure 记录一次处理失败
+// 未达到最大失败次数时按指数退避安排下次重试，否则移入死信队列
+func (q *SettlementRetryQueue) RecordFailure(ctx context.Context, settlementID, operatorID int64, cause error, now time.Time) (deadLettered bool, err error) {
+	meta, err := q.getMeta(ctx, settlementID)
+	if err != nil {
+		return false, err
+	}
+	if meta == nil {
+		meta = &SettlementRetryMeta{SettlementID: settlementID, OperatorID: operatorID}
+	}
+	meta.Failures++
+	meta.LastError = cause.Error()
+	meta.LastFailedAt = now
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return false, err
+	}
+	member := strconv.FormatInt(settlementID, 10)
+
+	if meta.Failures >= SettlementRetryMaxFailures {
+		pipe := q.client.TxPipeline()
+		pipe.ZRem(ctx, SettlementRetryQueueKey, member)
+		pipe.ZAdd(ctx, SettlementDeadLetterKey, redis.Z{Score: float64(now.Unix()), Member: member})
+		pipe.HSet(ctx, SettlementRetryMetaKey, member, data)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return false, err
+		}
+
+		q.logger.Error("结算重试次数耗尽，已移入死信队列",
+			zap.Int64("settlement_id", settlementID),
+			zap.Int64("operator_id", meta.OperatorID),
+			zap.Int("failures", meta.Failures),
+			zap.String("last_error", meta.LastError),
+		)
+		return true, nil
+	}
+
+	nextRetryAt := now.Add(settlementRetryBackoff(meta.Failures))
+	pipe := q.client.TxPipeline()
+	pipe.ZAdd(ctx, SettlementRetryQueueKey, redis.Z{Score: float64(nextRetryAt.Unix()), Member: member})
+	pipe.HSet(ctx, SettlementRetryMetaKey, member, data)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+
+	q.logger.Warn("结算处理失败，已安排重试",
+		zap.Int64("settlement_id", settlementID),
+		zap.Int("failures", meta.Failures),
+		zap.Time("next_retry_at", nextRetryAt),
+		zap.String("error", meta.LastError),
+	)
+	return false, nil
+}
+
+// Remove 将结算从重试队列及死信队列中移除（处理成功或无需重试时）
+func (q *SettlementRetryQueue) Remove(ctx context.Context, settlementID int64) error {
+	member := strconv.FormatInt(settlementID, 10)
+	pipe := q.client.TxPipeline()
+	pipe.ZRem(ctx, SettlementRetryQueueKey, member)
+	pipe.ZRem(ctx, SettlementDeadLetterKey, member)
+	pipe.HDel(ctx, SettlementRetryMetaKey, member)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Due 获取到期需要重试的结算
+func (q *SettlementRetryQueue) Due(ctx context.Context, now time.Time, limit int64) ([]*SettlementRetryMeta, error) {
+	members, err := q.client.ZRangeByScore(ctx, SettlementRetryQueueKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.Unix(), 10),
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	metas := make([]*SettlementRetryMeta, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		meta, err := q.getMeta(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if meta == nil {
+			meta = &SettlementRetryMeta{SettlementID: id}
+		}
+		metas = append(metas, meta)
+	}
+	return metas, nil
+}
+
+// ListDeadLetters 获取死信结算列表（按进入死信时间倒序）
+func (q *SettlementRetryQueue) ListDeadLetters(ctx context.Context) ([]*SettlementDeadLetter, error) {
+	entries, err := q.client.ZRevRangeWithScores(ctx, SettlementDeadLetterKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*SettlementDeadLetter, 0, len(entries))
+	for _, entry := range entries {
+		member, _ := entry.Member.(string)
+		id, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		meta, err := q.getMeta(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if meta == nil {
+			meta = &SettlementRetryMeta{SettlementID: id}
+		}
+		list = append(list, &SettlementDeadLetter{
+			SettlementRetryMeta: *meta,
+			DeadLetteredAt:      time.Unix(int64(entry.Score), 0),
+		})
+	}
+	return list, nil
+}
+
+// getMeta 获取重试元数据，不存在时返回 nil
+func (q *SettlementRetryQueue) getMeta(ctx context.Context, settlementID int64) (*SettlementRetryMeta, error) {
+	data, err := q.client.HGet(ctx, SettlementRetryMetaKey, strconv.FormatInt(settlementID, 10)).Bytes()
+	if err != nil {
+		if stderrors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var meta SettlementRetryMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// isTransientSettlementError 判断结算失败是否为可重试的临时错误
+// 业务错误（不存在、状态不符等）重试也无法成功，不进入重试队列
+func isTransientSettlementError(err error) bool {
+	var appErr *errors.AppError
+	if stderrors.As(err, &appErr) {
+		return appErr.Code == errors.ErrDatabaseError.Code
+	}
+	return true
+}
+
+// RetrySettlementJob 结算重试后台任务
+// 定期从重试队列中取出到期的结算重新处理
+type RetrySettlementJob struct {
+	settlementSvc *SettlementService
+	queue         *SettlementRetryQueue
+	interval      time.Duration
+	logger        *zap.Logger
+	wg            sync.WaitGroup
+}
+
+// NewRetrySettlementJob 创建结算重试任务
+func NewRetrySettlementJob(settlementSvc *SettlementService, queue *SettlementRetryQueue, interval time.Duration, logger *zap.Logger) *RetrySettlementJob {
+	if interval <= 0 {
+		interval = DefaultSettlementRetryPoll
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &RetrySettlementJob{
+		settlementSvc: settlementSvc,
+		queue:         queue,
+		interval:      interval,
+		logger:        logger,
+	}
+}
+
+// Start 启动后台轮询，ctx 取消后退出
+func (j *RetrySettlementJob) Start(ctx context.Context) {
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := j.RunOnce(ctx, time.Now()); err != nil {
+					j.logger.Error("结算重试任务执行失败", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// Wait 等待后台轮询退出
+func (j *RetrySettlementJob) Wait() {
+	j.wg.Wait()
+}
+
+// RunOnce 处理一批到期的重试
+func (j *RetrySettlementJob) RunOnce(ctx context.Context, now time.Time) error {
+	metas, err := j.queue.Due(ctx, now, settlementRetryBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, meta := range metas {
+		// ProcessSettlement 成功时会移出队列，临时失败时会重新安排重试
+		err := j.settlementSvc.ProcessSettlement(ctx, meta.SettlementID, meta.OperatorID)
+		if err == nil || isTransientSettlementError(err) {
+			continue
+		}
+
+		// 业务错误（如已被人工处理）无需再重试
+		j.logger.Info("结算无需继续重试，已移出重试队列",
+			zap.Int64("settlement_id", meta.SettlementID),
+			zap.Error(err),
+		)
+		if err := j.queue.Remove(ctx, meta.SettlementID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package finance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+func setupSettlementRetryQueue(t *testing.T) (*SettlementRetryQueue, *redis.Client) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+		mr.Close()
+	})
+
+	return NewSettlementRetryQueue(client, nil), client
+}
+
+func setupRetryingSettlementService(t *testing.T, db *gorm.DB) (*SettlementService, *SettlementRetryQueue, *redis.Client) {
+	t.Helper()
+
+	queue, client := setupSettlementRetryQueue(t)
+	svc := NewSettlementService(db,
+		repository.NewSettlementRepository(db),
+		repository.NewOrderRepository(db),
+		repository.NewMerchantRepository(db),
+		repository.NewCommissionRepository(db),
+		repository.NewDistributorRepository(db),
+		queue,
+	)
+	return svc, queue, client
+}
+
+func TestSettlementRetryBackoff(t *testing.T) {
+	expected := []time.Duration{1, 2, 4, 8, 16, 32, 64, 64}
+	for i, want := range expected {
+		assert.Equal(t, want*time.Minute, settlementRetryBackoff(i+1), "failures=%d", i+1)
+	}
+}
+
+func TestSettlementRetryQueue_RecordFailure(t *testing.T) {
+	queue, client := setupSettlementRetryQueue(t)
+	ctx := context.Background()
+	now := time.Now()
+	cause := errors.New("db timeout")
+
+	t.Run("失败后按指数退避安排重试", func(t *testing.T) {
+		deadLettered, err := queue.RecordFailure(ctx, 1, 9, cause, now)
+		require.NoError(t, err)
+		assert.False(t, deadLettered)
+
+		score, err := client.ZScore(ctx, SettlementRetryQueueKey, "1").Result()
+		require.NoError(t, err)
+		assert.Equal(t, float64(now.Add(time.Minute).Unix()), score)
+
+		due, err := queue.Due(ctx, now, 10)
+		require.NoError(t, err)
+		assert.Empty(t, due)
+
+		due, err = queue.Due(ctx, now.Add(time.Minute), 10)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		assert.Equal(t, int64(9), due[0].OperatorID)
+		assert.Equal(t, 1, due[0].Failures)
+	})
+
+	t.Run("第二次失败等待2分钟", func(t *testing.T) {
+		_, err := queue.RecordFailure(ctx, 1, 9, cause, now)
+		require.NoError(t, err)
+
+		score, err := client.ZScore(ctx, SettlementRetryQueueKey, "1").Result()
+		require.NoError(t, err)
+		assert.Equal(t, float64(now.Add(2*time.Minute).Unix()), score)
+	})
+
+	t.Run("失败6次后移入死信队列", func(t *testing.T) {
+		var deadLettered bool
+		for i := 0; i < SettlementRetryMaxFailures-2; i++ {
+			var err error
+			deadLettered, err = queue.RecordFailure(ctx, 1, 9, cause, now)
+			require.NoError(t, err)
+		}
+		assert.True(t, deadLettered)
+
+		exists, err := client.ZScore(ctx, SettlementRetryQueueKey, "1").Result()
+		assert.ErrorIs(t, err, redis.Nil, "score=%v", exists)
+
+		list, err := queue.ListDeadLetters(ctx)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		assert.Equal(t, int64(1), list[0].SettlementID)
+		assert.Equal(t, SettlementRetryMaxFailures, list[0].Failures)
+		assert.Equal(t, "db timeout", list[0].LastError)
+	})
+
+	t.Run("移除后不再出现在死信队列", func(t *testing.T) {
+		require.NoError(t, queue.Remove(ctx, 1))
+
+		list, err := queue.ListDeadLetters(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, list)
+	})
+}
+
+func TestSettlementService_ProcessSettlement_Retry(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc, queue, _ := setupRetryingSettlementService(t, db)
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800138101")
+	distributor := createTestDistributor(t, db, user.ID)
+	settlement := createTestSettlement(t, db, models.SettlementTypeDistributor, distributor.ID, 100.0, models.SettlementStatusApproved)
+
+	// 模拟更新佣金时数据库临时故障
+	require.NoError(t, db.Migrator().DropTable(&models.Commission{}))
+
+	err := svc.ProcessSettlement(ctx, settlement.ID, 1)
+	require.Error(t, err)
+
+	var approved models.Settlement
+	require.NoError(t, db.First(&approved, settlement.ID).Error)
+	assert.Equal(t, models.SettlementStatusApproved, approved.Status)
+
+	due, err := queue.Due(ctx, time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, settlement.ID, due[0].SettlementID)
+
+	// 故障恢复后由后台任务重试成功
+	require.NoError(t, db.AutoMigrate(&models.Commission{}))
+	job := NewRetrySettlementJob(svc, queue, 0, nil)
+	require.NoError(t, job.RunOnce(ctx, time.Now().Add(time.Minute)))
+
+	var completed models.Settlement
+	require.NoError(t, db.First(&completed, settlement.ID).Error)
+	assert.Equal(t, models.SettlementStatusCompleted, completed.Status)
+
+	due, err = queue.Due(ctx, time.Now().Add(time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+}
+
+func TestRetrySettlementJob_RemovesNonTransientFailures(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc, queue, _ := setupRetryingSettlementService(t, db)
+	ctx := context.Background()
+
+	merchant := createTestMerchant(t, db, "重试商户")
+	settlement := createTestSettlement(t, db, models.SettlementTypeMerchant, merchant.ID, 100.0, models.SettlementStatusCompleted)
+
+	_, err := queue.RecordFailure(ctx, settlement.ID, 1, errors.New("db timeout"), time.Now())
+	require.NoError(t, err)
+
+	// 结算已被人工处理，重试返回业务错误，应移出队列
+	job := NewRetrySettlementJob(svc, queue, 0, nil)
+	require.NoError(t, job.RunOnce(ctx, time.Now().Add(time.Minute)))
+
+	due, err := queue.Due(ctx, time.Now().Add(time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+}
+
+func TestRetrySettlementJob_StartStopsOnCancel(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc, queue, _ := setupRetryingSettlementService(t, db)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := NewRetrySettlementJob(svc, queue, 10*time.Millisecond, nil)
+	job.Start(ctx)
+
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	job.Wait()
+}
+
+func TestSettlementService_ListDeadLetterSettlements(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("未启用重试队列返回空列表", func(t *testing.T) {
+		svc := setupSettlementService(setupFinanceTestDB(t))
+		list, err := svc.ListDeadLetterSettlements(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, list)
+	})
+
+	t.Run("返回死信结算", func(t *testing.T) {
+		svc, queue, _ := setupRetryingSettlementService(t, setupFinanceTestDB(t))
+		for i := 0; i < SettlementRetryMaxFailures; i++ {
+			_, err := queue.RecordFailure(ctx, 42, 1, errors.New("db timeout"), time.Now())
+			require.NoError(t, err)
+		}
+
+		list, err := svc.ListDeadLetterSettlements(ctx)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		assert.Equal(t, int64(42), list[0].SettlementID)
+	})
+}
@@ -0,0 +1,135 @@
+package finance
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// SubmitForReview 提交结算审核，待结算记录变更为待审核，重复提交时直接返回
+func (s *SettlementService) SubmitForReview(ctx context.Context, settlementID int64, operatorID int64) error {
+	settlement, err := s.settlementRepo.GetByID(ctx, settlementID)
+	if err != nil {
+		return errors.ErrSettlementNotFound.WithError(err)
+	}
+
+	switch settlement.Status {
+	case models.SettlementStatusReviewing:
+		return nil
+	case models.SettlementStatusPending:
+	default:
+		return errors.ErrInvalidOperation.WithMessage("只能提交待结算状态的记录")
+	}
+
+	updates := map[string]interface{}{
+		"status": models.SettlementStatusReviewing,
+	}
+	// 未记录创建人的结算以提交人作为制单人，审核时不能由其本人审核通过
+	if settlement.OperatorID == nil {
+		updates["operator_id"] = operatorID
+	}
+	result := s.db.WithContext(ctx).Model(&models.Settlement{}).
+		Where("id = ? AND status = ?", settlementID, models.SettlementStatusPending).
+		Updates(updates)
+	if result.Error != nil {
+		return errors.ErrDatabaseError.WithError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrInvalidOperation.WithMessage("结算状态已变更，请刷新后重试")
+	}
+	return nil
+}
+
+// ApproveSettlement 审核通过结算并完成入账
+// 制单人不能审核通过自己创建的结算；重复审核通过时不会重复入账，已审核通过但入账失败的结算再次审核时继续入账
+func (s *SettlementService) ApproveSettlement(ctx context.Context, settlementID int64, reviewerID int64) error {
+	settlement, err := s.settlementRepo.GetByID(ctx, settlementID)
+	if err != nil {
+		return errors.ErrSettlementNotFound.WithError(err)
+	}
+
+	switch settlement.Status {
+	case models.SettlementStatusCompleted:
+		return nil
+	case models.SettlementStatusApproved:
+		return s.ProcessSettlement(ctx, settlementID, reviewerID)
+	case models.SettlementStatusReviewing:
+	default:
+		return errors.ErrInvalidOperation.WithMessage("只能审核待审核状态的记录")
+	}
+
+	if settlement.OperatorID != nil && *settlement.OperatorID == reviewerID {
+		return errors.ErrPermissionDenied.WithMessage("不能审核自己创建的结算")
+	}
+
+	now := time.Now()
+	result := s.db.WithContext(ctx).Model(&models.Settlement{}).
+		Where("id = ? AND status = ?", settlementID, models.SettlementStatusReviewing).
+		Updates(map[string]interface{}{
+			"status":      models.SettlementStatusApproved,
+			"reviewer_id": reviewerID,
+			"reviewed_at": &now,
+		})
+	if result.Error != nil {
+		return errors.ErrDatabaseError.WithError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// 并发审核时由先提交的一方完成入账
+		return nil
+	}
+
+	return s.ProcessSettlement(ctx, settlementID, reviewerID)
+}
+
+// RejectSettlement 驳回结算
+// 分销商结算释放锁定的佣金，驳回后可为同一周期重新生成结算
+func (s *SettlementService) RejectSettlement(ctx context.Context, settlementID int64, reviewerID int64, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.ErrInvalidParams.WithMessage("请填写驳回原因")
+	}
+
+	settlement, err := s.settlementRepo.GetByID(ctx, settlementID)
+	if err != nil {
+		return errors.ErrSettlementNotFound.WithError(err)
+	}
+	if settlement.Status != models.SettlementStatusReviewing {
+		return errors.ErrInvalidOperation.WithMessage("只能驳回待审核状态的记录")
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.Settlement{}).
+			Where("id = ? AND status = ?", settlementID, models.SettlementStatusReviewing).
+			Updates(map[string]interface{}{
+				"status":        models.SettlementStatusRejected,
+				"reviewer_id":   reviewerID,
+				"reviewed_at":   &now,
+				"reject_reason": reason,
+			})
+		if result.Error != nil {
+			return errors.ErrDatabaseError.WithError(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrInvalidOperation.WithMessage("结算状态已变更，请刷新后重试")
+		}
+
+		if settlement.Type != models.SettlementTypeDistributor {
+			return nil
+		}
+
+		err := tx.Model(&models.Commission{}).
+			Where("settlement_id = ?", settlementID).
+			Where("status = ?", models.CommissionStatusPending).
+			Update("settlement_id", nil).Error
+		if err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		return nil
+	})
+}
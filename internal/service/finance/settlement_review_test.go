@@ -0,0 +1,155 @@
+package finance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// createReviewingDistributorSettlement 由管理员 1 为分销商创建 1 月份结算并提交审核
+func createReviewingDistributorSettlement(t *testing.T, svc *SettlementService, distributorID int64) *models.Settlement {
+	t.Helper()
+
+	settlement, err := svc.CreateSettlement(context.Background(), &CreateSettlementRequest{
+		Type:        models.SettlementTypeDistributor,
+		TargetID:    distributorID,
+		PeriodStart: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:   time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC),
+	}, 1)
+	require.NoError(t, err)
+	require.NoError(t, svc.SubmitForReview(context.Background(), settlement.ID, 1))
+	return settlement
+}
+
+func TestSettlementService_ApproveSettlement_FourEyes(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupSettlementService(db)
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800171001")
+	distributor := createTestDistributor(t, db, user.ID)
+	order := createTestOrder(t, db, user.ID, 100.0, models.OrderStatusCompleted)
+	createCommissionAt(t, db, distributor.ID, order.ID, user.ID, 10.0, time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC))
+
+	settlement := createReviewingDistributorSettlement(t, svc, distributor.ID)
+
+	t.Run("制单人不能审核通过", func(t *testing.T) {
+		err := svc.ApproveSettlement(ctx, settlement.ID, 1)
+		assertSettlementErrorCode(t, err, appErrors.ErrPermissionDenied.Code)
+
+		var updated models.Settlement
+		require.NoError(t, db.First(&updated, settlement.ID).Error)
+		assert.Equal(t, models.SettlementStatusReviewing, updated.Status)
+
+		var unchanged models.Distributor
+		require.NoError(t, db.First(&unchanged, distributor.ID).Error)
+		assert.Equal(t, 0.0, unchanged.AvailableCommission)
+	})
+
+	t.Run("其他管理员审核通过后入账", func(t *testing.T) {
+		require.NoError(t, svc.ApproveSettlement(ctx, settlement.ID, 2))
+
+		var updated models.Settlement
+		require.NoError(t, db.First(&updated, settlement.ID).Error)
+		assert.Equal(t, models.SettlementStatusCompleted, updated.Status)
+		require.NotNil(t, updated.ReviewerID)
+		assert.Equal(t, int64(2), *updated.ReviewerID)
+		assert.NotNil(t, updated.ReviewedAt)
+		assert.NotNil(t, updated.SettledAt)
+		require.NotNil(t, updated.OperatorID)
+		assert.Equal(t, int64(1), *updated.OperatorID)
+
+		var credited models.Distributor
+		require.NoError(t, db.First(&credited, distributor.ID).Error)
+		assert.Equal(t, 10.0, credited.AvailableCommission)
+	})
+}
+
+func TestSettlementService_ApproveSettlement_Idempotent(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupSettlementService(db)
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800171002")
+	distributor := createTestDistributor(t, db, user.ID)
+	order := createTestOrder(t, db, user.ID, 100.0, models.OrderStatusCompleted)
+	createCommissionAt(t, db, distributor.ID, order.ID, user.ID, 25.0, time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC))
+
+	settlement := createReviewingDistributorSettlement(t, svc, distributor.ID)
+
+	require.NoError(t, svc.ApproveSettlement(ctx, settlement.ID, 2))
+	require.NoError(t, svc.ApproveSettlement(ctx, settlement.ID, 2))
+	require.NoError(t, svc.ApproveSettlement(ctx, settlement.ID, 3))
+
+	var credited models.Distributor
+	require.NoError(t, db.First(&credited, distributor.ID).Error)
+	assert.Equal(t, 25.0, credited.AvailableCommission, "重复审核通过只能入账一次")
+
+	t.Run("审核通过后不能驳回", func(t *testing.T) {
+		err := svc.RejectSettlement(ctx, settlement.ID, 2, "金额有误")
+		assertSettlementErrorCode(t, err, appErrors.ErrInvalidOperation.Code)
+	})
+
+	t.Run("未提交审核不能审核通过", func(t *testing.T) {
+		pending := createTestSettlement(t, db, models.SettlementTypeMerchant, 1, 100.0, models.SettlementStatusPending)
+		err := svc.ApproveSettlement(ctx, pending.ID, 2)
+		assertSettlementErrorCode(t, err, appErrors.ErrInvalidOperation.Code)
+	})
+}
+
+func TestSettlementService_RejectSettlement_UnlocksCommissions(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupSettlementService(db)
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800171003")
+	distributor := createTestDistributor(t, db, user.ID)
+	order1 := createTestOrder(t, db, user.ID, 100.0, models.OrderStatusCompleted)
+	order2 := createTestOrder(t, db, user.ID, 150.0, models.OrderStatusCompleted)
+	c1 := createCommissionAt(t, db, distributor.ID, order1.ID, user.ID, 10.0, time.Date(2024, 1, 5, 12, 0, 0, 0, time.UTC))
+	c2 := createCommissionAt(t, db, distributor.ID, order2.ID, user.ID, 15.0, time.Date(2024, 1, 20, 12, 0, 0, 0, time.UTC))
+
+	settlement := createReviewingDistributorSettlement(t, svc, distributor.ID)
+
+	t.Run("驳回原因必填", func(t *testing.T) {
+		err := svc.RejectSettlement(ctx, settlement.ID, 2, "  ")
+		assertSettlementErrorCode(t, err, appErrors.ErrInvalidParams.Code)
+	})
+
+	require.NoError(t, svc.RejectSettlement(ctx, settlement.ID, 2, "佣金金额待核对"))
+
+	var rejected models.Settlement
+	require.NoError(t, db.First(&rejected, settlement.ID).Error)
+	assert.Equal(t, models.SettlementStatusRejected, rejected.Status)
+	require.NotNil(t, rejected.RejectReason)
+	assert.Equal(t, "佣金金额待核对", *rejected.RejectReason)
+	require.NotNil(t, rejected.ReviewerID)
+	assert.Equal(t, int64(2), *rejected.ReviewerID)
+
+	for _, c := range []*models.Commission{c1, c2} {
+		var unlocked models.Commission
+		require.NoError(t, db.First(&unlocked, c.ID).Error)
+		assert.Nil(t, unlocked.SettlementID)
+		assert.Equal(t, models.CommissionStatusPending, unlocked.Status)
+	}
+
+	var distributorAfter models.Distributor
+	require.NoError(t, db.First(&distributorAfter, distributor.ID).Error)
+	assert.Equal(t, 0.0, distributorAfter.AvailableCommission)
+
+	// 驳回后可为同一周期重新生成结算
+	regenerated := createReviewingDistributorSettlement(t, svc, distributor.ID)
+	assert.NotEqual(t, settlement.ID, regenerated.ID)
+	assert.Equal(t, 25.0, regenerated.TotalAmount)
+	assert.Equal(t, 2, regenerated.OrderCount)
+
+	require.NoError(t, svc.ApproveSettlement(ctx, regenerated.ID, 2))
+	require.NoError(t, db.First(&distributorAfter, distributor.ID).Error)
+	assert.Equal(t, 25.0, distributorAfter.AvailableCommission)
+}
@@ -4,14 +4,18 @@ package finance
 import (
 	"context"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
 	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	orderService "github.com/dumeirei/smart-locker-backend/internal/service/order"
 )
 
 // SettlementService 结算服务
@@ -22,6 +26,7 @@ type SettlementService struct {
 	merchantRepo    *repository.MerchantRepository
 	commissionRepo  *repository.CommissionRepository
 	distributorRepo *repository.DistributorRepository
+	retryQueue      *SettlementRetryQueue
 }
 
 // NewSettlementService 创建结算服务
@@ -32,6 +37,7 @@ func NewSettlementService(
 	merchantRepo *repository.MerchantRepository,
 	commissionRepo *repository.CommissionRepository,
 	distributorRepo *repository.DistributorRepository,
+	retryQueue *SettlementRetryQueue,
 ) *SettlementService {
 	return &SettlementService{
 		db:              db,
@@ -40,6 +46,7 @@ func NewSettlementService(
 		merchantRepo:    merchantRepo,
 		commissionRepo:  commissionRepo,
 		distributorRepo: distributorRepo,
+		retryQueue:      retryQueue,
 	}
 }
 
@@ -51,24 +58,85 @@ type CreateSettlementRequest struct {
 	PeriodEnd   time.Time `json:"period_end" binding:"required"`
 }
 
+// SettlementPreview 结算预览，按创建结算相同的规则计算金额但不落库
+type SettlementPreview struct {
+	Type         string    `json:"type"`
+	TargetID     int64     `json:"target_id"`
+	PeriodStart  time.Time `json:"period_start"`
+	PeriodEnd    time.Time `json:"period_end"`
+	TotalAmount  float64   `json:"total_amount"`
+	Fee          float64   `json:"fee"`
+	ActualAmount float64   `json:"actual_amount"`
+	OrderCount   int       `json:"order_count"`
+	OrderIDs     []int64   `json:"order_ids"`
+
+	items          []*models.SettlementItem
+	commissionRate float64
+}
+
+// PreviewSettlement 预览结算金额，与 CreateSettlement 使用相同的计算逻辑但不写入数据库
+func (s *SettlementService) PreviewSettlement(ctx context.Context, req *CreateSettlementRequest) (*SettlementPreview, error) {
+	return s.calculateSettlement(ctx, req)
+}
+
 // CreateSettlement 创建结算记录
 func (s *SettlementService) CreateSettlement(ctx context.Context, req *CreateSettlementRequest, operatorID int64) (*models.Settlement, error) {
-	// 检查是否已存在该周期的结算记录
-	exists, err := s.settlementRepo.ExistsForPeriod(ctx, req.Type, req.TargetID, req.PeriodStart, req.PeriodEnd)
+	preview, err := s.calculateSettlement(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	settlementNo := utils.GenerateOrderNo("ST")
+	settlement := &models.Settlement{
+		SettlementNo: settlementNo,
+		Type:         req.Type,
+		TargetID:     req.TargetID,
+		PeriodStart:  req.PeriodStart,
+		PeriodEnd:    req.PeriodEnd,
+		TotalAmount:  preview.TotalAmount,
+		Fee:          preview.Fee,
+		ActualAmount: preview.ActualAmount,
+		OrderCount:   preview.OrderCount,
+		Status:       models.SettlementStatusPending,
+		OperatorID:   &operatorID,
+	}
+
+	if req.Type == models.SettlementTypeDistributor {
+		if err := s.createDistributorSettlement(ctx, settlement); err != nil {
+			return nil, err
+		}
+		return settlement, nil
+	}
+
+	if err := s.createMerchantSettlement(ctx, settlement, preview.items, preview.commissionRate); err != nil {
+		return nil, err
+	}
+
+	return settlement, nil
+}
+
+// calculateSettlement 校验结算周期并计算结算金额，供创建结算和预览结算共用
+func (s *SettlementService) calculateSettlement(ctx context.Context, req *CreateSettlementRequest) (*SettlementPreview, error) {
+	// 检查是否已存在与该周期重叠的结算记录
+	exists, err := s.settlementRepo.ExistsOverlapping(ctx, req.Type, req.TargetID, req.PeriodStart, req.PeriodEnd)
 	if err != nil {
 		return nil, errors.ErrDatabaseError.WithError(err)
 	}
 	if exists {
-		return nil, errors.ErrDuplicateRecord.WithMessage("该周期的结算记录已存在")
+		return nil, errors.ErrDuplicateRecord.WithMessage("该周期与已有结算记录重叠")
 	}
 
-	// 计算结算金额
-	var totalAmount, fee, actualAmount float64
-	var orderCount int
+	preview := &SettlementPreview{
+		Type:        req.Type,
+		TargetID:    req.TargetID,
+		PeriodStart: req.PeriodStart,
+		PeriodEnd:   req.PeriodEnd,
+		OrderIDs:    []int64{},
+	}
 
 	if req.Type == models.SettlementTypeMerchant {
 		// 商户结算 - 计算商户的订单收入
-		totalAmount, orderCount, err = s.calculateMerchantSettlement(ctx, req.TargetID, req.PeriodStart, req.PeriodEnd)
+		items, totalAmount, err := s.calculateMerchantSettlement(ctx, req.TargetID, req.PeriodStart, req.PeriodEnd)
 		if err != nil {
 			return nil, err
 		}
@@ -77,54 +145,130 @@ func (s *SettlementService) CreateSettlement(ctx context.Context, req *CreateSet
 		if err != nil {
 			return nil, errors.ErrMerchantNotFound.WithError(err)
 		}
-		fee = totalAmount * merchant.CommissionRate
-		actualAmount = totalAmount - fee
-	} else {
-		// 分销商结算 - 计算分销商的佣金
-		totalAmount, orderCount, err = s.calculateDistributorSettlement(ctx, req.TargetID, req.PeriodStart, req.PeriodEnd)
-		if err != nil {
-			return nil, err
+		preview.items = items
+		preview.commissionRate = merchant.CommissionRate
+		preview.TotalAmount = totalAmount
+		preview.Fee = totalAmount * merchant.CommissionRate
+		preview.ActualAmount = totalAmount - preview.Fee
+		preview.OrderCount = len(items)
+		for _, item := range items {
+			preview.OrderIDs = append(preview.OrderIDs, item.OrderID)
 		}
-		// 分销商提现无手续费
-		fee = 0
-		actualAmount = totalAmount
+		return preview, nil
 	}
 
-	settlementNo := utils.GenerateOrderNo("ST")
-	settlement := &models.Settlement{
-		SettlementNo: settlementNo,
-		Type:         req.Type,
-		TargetID:     req.TargetID,
-		PeriodStart:  req.PeriodStart,
-		PeriodEnd:    req.PeriodEnd,
-		TotalAmount:  totalAmount,
-		Fee:          fee,
-		ActualAmount: actualAmount,
-		OrderCount:   orderCount,
-		Status:       models.SettlementStatusPending,
-		OperatorID:   &operatorID,
-	}
+	// 分销商结算 - 计算分销商的佣金
+	totalAmount, orderIDs, err := s.calculateDistributorSettlement(ctx, req.TargetID, req.PeriodStart, req.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	// 分销商提现无手续费
+	preview.TotalAmount = totalAmount
+	preview.Fee = 0
+	preview.ActualAmount = totalAmount
+	preview.OrderCount = len(orderIDs)
+	preview.OrderIDs = append(preview.OrderIDs, orderIDs...)
+	return preview, nil
+}
 
-	if err := s.settlementRepo.Create(ctx, settlement); err != nil {
-		return nil, errors.ErrDatabaseError.WithError(err)
-	}
+// createMerchantSettlement 在同一事务中创建商户结算及其订单明细，保证明细金额之和等于结算总额
+func (s *SettlementService) createMerchantSettlement(ctx context.Context, settlement *models.Settlement, items []*models.SettlementItem, commissionRate float64) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(settlement).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		if len(items) == 0 {
+			return nil
+		}
 
-	return settlement, nil
+		for _, item := range items {
+			item.SettlementID = settlement.ID
+			item.CommissionRate = commissionRate
+			item.MerchantShare = merchantShare(item.Amount, commissionRate)
+		}
+		if err := tx.CreateInBatches(items, 100).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		// 订单时间线记录计入结算，仅管理员可见
+		notes := make([]*models.OrderNote, 0, len(items))
+		for _, item := range items {
+			notes = append(notes, orderService.NewSystemNote(item.OrderID, "已计入商户结算 "+settlement.SettlementNo, false))
+		}
+		if err := tx.CreateInBatches(notes, 100).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		return nil
+	})
 }
 
-// calculateMerchantSettlement 计算商户结算金额
-func (s *SettlementService) calculateMerchantSettlement(ctx context.Context, merchantID int64, periodStart, periodEnd time.Time) (float64, int, error) {
-	var totalAmount float64
-	var orderCount int64
+// merchantShare 商户应得金额 = 订单金额 - 平台分成，保留两位小数
+func merchantShare(amount, commissionRate float64) float64 {
+	return roundAmount(amount - amount*commissionRate)
+}
+
+// roundAmount 金额保留两位小数
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// createDistributorSettlement 创建分销商结算并锁定周期内尚未归属结算单的待结算佣金
+// 结算金额按实际锁定的佣金重新汇总，重叠周期再次生成时已锁定的佣金不会被重复计入
+func (s *SettlementService) createDistributorSettlement(ctx context.Context, settlement *models.Settlement) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(settlement).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		result := tx.Model(&models.Commission{}).
+			Where("distributor_id = ?", settlement.TargetID).
+			Where("status = ?", models.CommissionStatusPending).
+			Where("settlement_id IS NULL").
+			Where("created_at >= ? AND created_at <= ?", settlement.PeriodStart, settlement.PeriodEnd).
+			Update("settlement_id", settlement.ID)
+		if result.Error != nil {
+			return errors.ErrDatabaseError.WithError(result.Error)
+		}
 
+		var totalAmount float64
+		err := tx.Model(&models.Commission{}).
+			Where("settlement_id = ?", settlement.ID).
+			Select("COALESCE(SUM(amount), 0)").
+			Row().Scan(&totalAmount)
+		if err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		settlement.TotalAmount = totalAmount
+		settlement.Fee = 0
+		settlement.ActualAmount = totalAmount
+		settlement.OrderCount = int(result.RowsAffected)
+		err = tx.Model(&models.Settlement{}).
+			Where("id = ?", settlement.ID).
+			Updates(map[string]interface{}{
+				"total_amount":  settlement.TotalAmount,
+				"fee":           settlement.Fee,
+				"actual_amount": settlement.ActualAmount,
+				"order_count":   settlement.OrderCount,
+			}).Error
+		if err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		return nil
+	})
+}
+
+// calculateMerchantSettlement 计算商户结算金额
+// 返回周期内计入结算的租借订单明细及其金额之和，明细的分成比例和商户应得金额由调用方填充
+func (s *SettlementService) calculateMerchantSettlement(ctx context.Context, merchantID int64, periodStart, periodEnd time.Time) ([]*models.SettlementItem, float64, error) {
 	// 获取商户下所有场地
 	venues, err := s.getVenuesByMerchant(ctx, merchantID)
 	if err != nil {
-		return 0, 0, err
+		return nil, 0, err
 	}
 
 	if len(venues) == 0 {
-		return 0, 0, nil
+		return nil, 0, nil
 	}
 
 	venueIDs := make([]int64, len(venues))
@@ -132,72 +276,72 @@ func (s *SettlementService) calculateMerchantSettlement(ctx context.Context, mer
 		venueIDs[i] = v.ID
 	}
 
-	// 获取场地下所有设备
+	// 获取场地下所有设备（包含已删除的设备，其结算周期内的租借收入仍需计入）
 	var deviceIDs []int64
-	err = s.db.WithContext(ctx).Model(&models.Device{}).
+	err = s.db.WithContext(ctx).Unscoped().Model(&models.Device{}).
 		Where("venue_id IN ?", venueIDs).
 		Pluck("id", &deviceIDs).Error
 	if err != nil {
-		return 0, 0, err
+		return nil, 0, err
 	}
 
 	if len(deviceIDs) == 0 {
-		return 0, 0, nil
+		return nil, 0, nil
 	}
 
-	// 统计租借订单收入
+	// 查询周期内已完成的租借订单
+	var items []*models.SettlementItem
 	err = s.db.WithContext(ctx).Model(&models.Rental{}).
 		Joins("JOIN orders ON orders.id = rentals.order_id").
 		Where("rentals.device_id IN ?", deviceIDs).
 		Where("orders.status = ?", models.OrderStatusCompleted).
 		Where("orders.completed_at >= ? AND orders.completed_at <= ?", periodStart, periodEnd).
-		Select("COALESCE(SUM(orders.actual_amount), 0)").
-		Row().Scan(&totalAmount)
+		Select("orders.id AS order_id, rentals.id AS rental_id, orders.order_no, orders.completed_at, orders.actual_amount AS amount").
+		Order("orders.completed_at ASC, orders.id ASC").
+		Scan(&items).Error
 	if err != nil {
-		return 0, 0, err
+		return nil, 0, err
 	}
 
-	// 统计订单数
-	err = s.db.WithContext(ctx).Model(&models.Rental{}).
-		Joins("JOIN orders ON orders.id = rentals.order_id").
-		Where("rentals.device_id IN ?", deviceIDs).
-		Where("orders.status = ?", models.OrderStatusCompleted).
-		Where("orders.completed_at >= ? AND orders.completed_at <= ?", periodStart, periodEnd).
-		Count(&orderCount).Error
-	if err != nil {
-		return 0, 0, err
+	var totalAmount float64
+	for _, item := range items {
+		totalAmount += item.Amount
 	}
 
-	return totalAmount, int(orderCount), nil
+	return items, roundAmount(totalAmount), nil
 }
 
 // calculateDistributorSettlement 计算分销商结算金额
-func (s *SettlementService) calculateDistributorSettlement(ctx context.Context, distributorID int64, periodStart, periodEnd time.Time) (float64, int, error) {
+// 返回周期内尚未归属结算单的待结算佣金总额及每笔佣金对应的订单ID
+func (s *SettlementService) calculateDistributorSettlement(ctx context.Context, distributorID int64, periodStart, periodEnd time.Time) (float64, []int64, error) {
 	var totalAmount float64
-	var orderCount int64
 
-	// 统计待结算佣金
+	// 统计尚未归属结算单的待结算佣金
 	err := s.db.WithContext(ctx).Model(&models.Commission{}).
 		Where("distributor_id = ?", distributorID).
 		Where("status = ?", models.CommissionStatusPending).
+		Where("settlement_id IS NULL").
 		Where("created_at >= ? AND created_at <= ?", periodStart, periodEnd).
 		Select("COALESCE(SUM(amount), 0)").
 		Row().Scan(&totalAmount)
 	if err != nil {
-		return 0, 0, err
+		return 0, nil, err
 	}
 
-	// 统计佣金订单数
+	// 查询佣金对应的订单
+	var orderIDs []int64
 	err = s.db.WithContext(ctx).Model(&models.Commission{}).
 		Where("distributor_id = ?", distributorID).
 		Where("status = ?", models.CommissionStatusPending).
+		Where("settlement_id IS NULL").
 		Where("created_at >= ? AND created_at <= ?", periodStart, periodEnd).
-		Count(&orderCount).Error
+		Order("created_at ASC, id ASC").
+		Pluck("order_id", &orderIDs).Error
 	if err != nil {
-		return 0, 0, err
+		return 0, nil, err
 	}
 
-	return totalAmount, int(orderCount), nil
+	return totalAmount, orderIDs, nil
 }
 
 // getVenuesByMerchant 获取商户下的场地
@@ -208,48 +352,85 @@ func (s *SettlementService) getVenuesByMerchant(ctx context.Context, merchantID
 }
 
 // ProcessSettlement 处理结算
+// 只有审核通过的记录可以入账，待结算记录需先提交审核并由他人审核通过；
+// 审核流程上线前生成的待结算记录（LegacyPending）按原流程直接入账。
+// 临时错误加入重试队列
 func (s *SettlementService) ProcessSettlement(ctx context.Context, settlementID int64, operatorID int64) error {
+	err := s.processSettlement(ctx, settlementID, operatorID)
+	if s.retryQueue == nil {
+		return err
+	}
+
+	if err == nil {
+		if rmErr := s.retryQueue.Remove(ctx, settlementID); rmErr != nil {
+			s.retryQueue.logger.Warn("移除结算重试记录失败", zap.Int64("settlement_id", settlementID), zap.Error(rmErr))
+		}
+		return nil
+	}
+
+	// 临时错误加入重试队列，由 RetrySettlementJob 按指数退避重试
+	if isTransientSettlementError(err) {
+		if _, qErr := s.retryQueue.RecordFailure(ctx, settlementID, operatorID, err, time.Now()); qErr != nil {
+			s.retryQueue.logger.Error("结算加入重试队列失败", zap.Int64("settlement_id", settlementID), zap.Error(qErr))
+		}
+	}
+	return err
+}
+
+// processSettlement 执行结算处理
+func (s *SettlementService) processSettlement(ctx context.Context, settlementID int64, operatorID int64) error {
 	settlement, err := s.settlementRepo.GetByID(ctx, settlementID)
 	if err != nil {
 		return errors.ErrSettlementNotFound.WithError(err)
 	}
 
-	if settlement.Status != models.SettlementStatusPending {
-		return errors.ErrInvalidOperation.WithMessage("只能处理待结算状态的记录")
+	// 审核流程上线前生成的待结算记录没有审核环节，兼容原流程直接入账并记录处理人
+	if settlement.Status == models.SettlementStatusPending && settlement.LegacyPending {
+		return s.completeSettlement(ctx, settlement, models.SettlementStatusPending, &operatorID)
+	}
+
+	if settlement.Status != models.SettlementStatusApproved {
+		return errors.ErrSettlementStatus.WithMessage("只能处理审核通过的记录")
 	}
+	// 审核通过的结算保留创建人，审核人已在审核时记录
+	return s.completeSettlement(ctx, settlement, models.SettlementStatusApproved, nil)
+}
 
-	// 开始事务
-	tx := s.db.WithContext(ctx).Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+// completeSettlement 完成结算入账：结算状态从 fromStatus 变更为已完成，分销商结算同时结算锁定的佣金并增加可提现佣金
+// 状态以条件更新的方式变更，并发处理同一结算时只会入账一次；operatorID 非空时记录为处理人
+func (s *SettlementService) completeSettlement(ctx context.Context, settlement *models.Settlement, fromStatus string, operatorID *int64) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":     models.SettlementStatusCompleted,
+			"settled_at": &now,
+		}
+		if operatorID != nil {
+			updates["operator_id"] = *operatorID
+		}
+		result := tx.Model(&models.Settlement{}).
+			Where("id = ? AND status = ?", settlement.ID, fromStatus).
+			Updates(updates)
+		if result.Error != nil {
+			return errors.ErrDatabaseError.WithError(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrInvalidOperation.WithMessage("结算状态已变更，请刷新后重试")
 		}
-	}()
 
-	// 更新结算状态为处理中
-	err = tx.Model(&models.Settlement{}).
-		Where("id = ?", settlementID).
-		Updates(map[string]interface{}{
-			"status":      models.SettlementStatusProcessing,
-			"operator_id": operatorID,
-		}).Error
-	if err != nil {
-		tx.Rollback()
-		return errors.ErrDatabaseError.WithError(err)
-	}
+		if settlement.Type != models.SettlementTypeDistributor {
+			return nil
+		}
 
-	// 如果是分销商结算，更新佣金状态
-	if settlement.Type == models.SettlementTypeDistributor {
-		err = tx.Model(&models.Commission{}).
-			Where("distributor_id = ?", settlement.TargetID).
+		// 分销商结算：更新该结算单锁定的佣金状态
+		err := tx.Model(&models.Commission{}).
+			Where("settlement_id = ?", settlement.ID).
 			Where("status = ?", models.CommissionStatusPending).
-			Where("created_at >= ? AND created_at <= ?", settlement.PeriodStart, settlement.PeriodEnd).
 			Updates(map[string]interface{}{
 				"status":     models.CommissionStatusSettled,
-				"settled_at": time.Now(),
+				"settled_at": now,
 			}).Error
 		if err != nil {
-			tx.Rollback()
 			return errors.ErrDatabaseError.WithError(err)
 		}
 
@@ -260,25 +441,23 @@ func (s *SettlementService) ProcessSettlement(ctx context.Context, settlementID
 				"available_commission": gorm.Expr("available_commission + ?", settlement.ActualAmount),
 			}).Error
 		if err != nil {
-			tx.Rollback()
 			return errors.ErrDatabaseError.WithError(err)
 		}
+		return nil
+	})
+}
+
+// ListDeadLetterSettlements 获取重试耗尽进入死信队列的结算
+func (s *SettlementService) ListDeadLetterSettlements(ctx context.Context) ([]*SettlementDeadLetter, error) {
+	if s.retryQueue == nil {
+		return []*SettlementDeadLetter{}, nil
 	}
 
-	// 更新结算状态为已完成
-	now := time.Now()
-	err = tx.Model(&models.Settlement{}).
-		Where("id = ?", settlementID).
-		Updates(map[string]interface{}{
-			"status":     models.SettlementStatusCompleted,
-			"settled_at": &now,
-		}).Error
+	list, err := s.retryQueue.ListDeadLetters(ctx)
 	if err != nil {
-		tx.Rollback()
-		return errors.ErrDatabaseError.WithError(err)
+		return nil, errors.ErrCacheError.WithError(err)
 	}
-
-	return tx.Commit().Error
+	return list, nil
 }
 
 // GetSettlement 获取结算详情
@@ -292,21 +471,43 @@ func (s *SettlementService) GetSettlement(ctx context.Context, id int64) (*model
 
 // SettlementListRequest 结算列表请求
 type SettlementListRequest struct {
-	Type        string `form:"type"`
-	TargetID    *int64 `form:"target_id"`
-	Status      string `form:"status"`
-	PeriodStart string `form:"period_start"`
-	PeriodEnd   string `form:"period_end"`
-	Page        int    `form:"page,default=1"`
-	PageSize    int    `form:"page_size,default=20"`
+	Type            string   `form:"type"`
+	TargetID        *int64   `form:"target_id"`
+	Status          string   `form:"status"`
+	PeriodStart     string   `form:"period_start"`
+	PeriodEnd       string   `form:"period_end"`
+	PeriodStartFrom string   `form:"period_start_from"` // 与 PeriodStartTo 组成查询区间，返回结算周期与区间有重叠的记录
+	PeriodStartTo   string   `form:"period_start_to"`
+	MinAmount       *float64 `form:"min_amount"` // 实际结算金额下限
+	MaxAmount       *float64 `form:"max_amount"` // 实际结算金额上限
+	SortBy          string   `form:"sort_by"`    // created_at（默认）、period_start、total_amount、actual_amount
+	SortOrder       string   `form:"sort_order"` // asc 或 desc（默认）
+	Page            int      `form:"page,default=1"`
+	PageSize        int      `form:"page_size,default=20"`
 }
 
 // ListSettlements 获取结算列表
+// 不支持的排序字段按 created_at 排序，不支持的排序方向按降序排序
 func (s *SettlementService) ListSettlements(ctx context.Context, req *SettlementListRequest) ([]*models.Settlement, int64, error) {
+	filter, err := buildSettlementFilter(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	return s.settlementRepo.List(ctx, filter, offset, req.PageSize)
+}
+
+// buildSettlementFilter 将结算列表请求转换为仓储查询条件
+func buildSettlementFilter(req *SettlementListRequest) (*repository.SettlementFilter, error) {
 	filter := &repository.SettlementFilter{
-		Type:     req.Type,
-		TargetID: req.TargetID,
-		Status:   req.Status,
+		Type:      req.Type,
+		TargetID:  req.TargetID,
+		Status:    req.Status,
+		MinAmount: req.MinAmount,
+		MaxAmount: req.MaxAmount,
+		SortBy:    req.SortBy,
+		SortAsc:   strings.EqualFold(req.SortOrder, "asc"),
 	}
 
 	if req.PeriodStart != "" {
@@ -321,34 +522,66 @@ func (s *SettlementService) ListSettlements(ctx context.Context, req *Settlement
 			filter.PeriodEnd = &t
 		}
 	}
+	if req.PeriodStartFrom != "" {
+		t, err := time.Parse("2006-01-02", req.PeriodStartFrom)
+		if err != nil {
+			return nil, errors.ErrInvalidParams.WithMessage("无效的周期开始日期")
+		}
+		filter.PeriodFrom = &t
+	}
+	if req.PeriodStartTo != "" {
+		t, err := time.Parse("2006-01-02", req.PeriodStartTo)
+		if err != nil {
+			return nil, errors.ErrInvalidParams.WithMessage("无效的周期结束日期")
+		}
+		filter.PeriodTo = &t
+	}
+	if filter.PeriodFrom != nil && filter.PeriodTo != nil && filter.PeriodTo.Before(*filter.PeriodFrom) {
+		return nil, errors.ErrInvalidParams.WithMessage("周期结束日期不能早于开始日期")
+	}
+	if req.MinAmount != nil && req.MaxAmount != nil && *req.MaxAmount < *req.MinAmount {
+		return nil, errors.ErrInvalidParams.WithMessage("金额上限不能小于下限")
+	}
 
-	offset := (req.Page - 1) * req.PageSize
-	return s.settlementRepo.List(ctx, filter, offset, req.PageSize)
+	return filter, nil
 }
 
 // GenerateMerchantSettlements 生成商户结算记录
 func (s *SettlementService) GenerateMerchantSettlements(ctx context.Context, periodStart, periodEnd time.Time, operatorID int64) ([]*models.Settlement, error) {
+	settlements, _, err := s.generateMerchantSettlements(ctx, periodStart, periodEnd, operatorID)
+	return settlements, err
+}
+
+// generateMerchantSettlements 为所有活跃商户生成结算记录，单个商户失败不影响其他商户，失败原因按商户返回
+func (s *SettlementService) generateMerchantSettlements(ctx context.Context, periodStart, periodEnd time.Time, operatorID int64) ([]*models.Settlement, []*SettlementGenerateError, error) {
 	// 获取所有活跃商户
 	var merchants []*models.Merchant
 	err := s.db.WithContext(ctx).Where("status = ?", 1).Find(&merchants).Error
 	if err != nil {
-		return nil, errors.ErrDatabaseError.WithError(err)
+		return nil, nil, errors.ErrDatabaseError.WithError(err)
 	}
 
 	var settlements []*models.Settlement
+	var failures []*SettlementGenerateError
+	fail := func(targetID int64, err error) {
+		failures = append(failures, newSettlementGenerateError(models.SettlementTypeMerchant, targetID, err))
+	}
 	for _, merchant := range merchants {
 		// 检查是否已存在结算记录
 		exists, err := s.settlementRepo.ExistsForPeriod(ctx, models.SettlementTypeMerchant, merchant.ID, periodStart, periodEnd)
 		if err != nil {
+			fail(merchant.ID, errors.ErrDatabaseError.WithError(err))
 			continue
 		}
 		if exists {
+			fail(merchant.ID, errSettlementPeriodExists)
 			continue
 		}
 
 		// 计算结算金额
-		totalAmount, orderCount, err := s.calculateMerchantSettlement(ctx, merchant.ID, periodStart, periodEnd)
+		items, totalAmount, err := s.calculateMerchantSettlement(ctx, merchant.ID, periodStart, periodEnd)
 		if err != nil {
+			fail(merchant.ID, err)
 			continue
 		}
 		if totalAmount == 0 {
@@ -367,48 +600,63 @@ func (s *SettlementService) GenerateMerchantSettlements(ctx context.Context, per
 			TotalAmount:  totalAmount,
 			Fee:          fee,
 			ActualAmount: actualAmount,
-			OrderCount:   orderCount,
+			OrderCount:   len(items),
 			Status:       models.SettlementStatusPending,
 			OperatorID:   &operatorID,
 		}
 
-		if err := s.settlementRepo.Create(ctx, settlement); err != nil {
+		if err := s.createMerchantSettlement(ctx, settlement, items, merchant.CommissionRate); err != nil {
+			fail(merchant.ID, err)
 			continue
 		}
 
 		settlements = append(settlements, settlement)
 	}
 
-	return settlements, nil
+	return settlements, failures, nil
 }
 
 // GenerateDistributorSettlements 生成分销商结算记录
 func (s *SettlementService) GenerateDistributorSettlements(ctx context.Context, periodStart, periodEnd time.Time, operatorID int64) ([]*models.Settlement, error) {
-	// 获取所有有待结算佣金的分销商
+	settlements, _, err := s.generateDistributorSettlements(ctx, periodStart, periodEnd, operatorID)
+	return settlements, err
+}
+
+// generateDistributorSettlements 为有待结算佣金的分销商生成结算记录，单个分销商失败不影响其他分销商，失败原因按分销商返回
+func (s *SettlementService) generateDistributorSettlements(ctx context.Context, periodStart, periodEnd time.Time, operatorID int64) ([]*models.Settlement, []*SettlementGenerateError, error) {
+	// 获取所有有未锁定待结算佣金的分销商
 	var distributorIDs []int64
 	err := s.db.WithContext(ctx).Model(&models.Commission{}).
 		Where("status = ?", models.CommissionStatusPending).
+		Where("settlement_id IS NULL").
 		Where("created_at >= ? AND created_at <= ?", periodStart, periodEnd).
 		Distinct("distributor_id").
 		Pluck("distributor_id", &distributorIDs).Error
 	if err != nil {
-		return nil, errors.ErrDatabaseError.WithError(err)
+		return nil, nil, errors.ErrDatabaseError.WithError(err)
 	}
 
 	var settlements []*models.Settlement
+	var failures []*SettlementGenerateError
+	fail := func(targetID int64, err error) {
+		failures = append(failures, newSettlementGenerateError(models.SettlementTypeDistributor, targetID, err))
+	}
 	for _, distributorID := range distributorIDs {
 		// 检查是否已存在结算记录
 		exists, err := s.settlementRepo.ExistsForPeriod(ctx, models.SettlementTypeDistributor, distributorID, periodStart, periodEnd)
 		if err != nil {
+			fail(distributorID, errors.ErrDatabaseError.WithError(err))
 			continue
 		}
 		if exists {
+			fail(distributorID, errSettlementPeriodExists)
 			continue
 		}
 
 		// 计算结算金额
-		totalAmount, orderCount, err := s.calculateDistributorSettlement(ctx, distributorID, periodStart, periodEnd)
+		totalAmount, orderIDs, err := s.calculateDistributorSettlement(ctx, distributorID, periodStart, periodEnd)
 		if err != nil {
+			fail(distributorID, err)
 			continue
 		}
 		if totalAmount == 0 {
@@ -424,19 +672,20 @@ func (s *SettlementService) GenerateDistributorSettlements(ctx context.Context,
 			TotalAmount:  totalAmount,
 			Fee:          0,
 			ActualAmount: totalAmount,
-			OrderCount:   orderCount,
+			OrderCount:   len(orderIDs),
 			Status:       models.SettlementStatusPending,
 			OperatorID:   &operatorID,
 		}
 
-		if err := s.settlementRepo.Create(ctx, settlement); err != nil {
+		if err := s.createDistributorSettlement(ctx, settlement); err != nil {
+			fail(distributorID, err)
 			continue
 		}
 
 		settlements = append(settlements, settlement)
 	}
 
-	return settlements, nil
+	return settlements, failures, nil
 }
 
 // SettlementDetail 获取结算详情（包含目标名称）
@@ -478,3 +727,62 @@ func (s *SettlementService) GetSettlementDetail(ctx context.Context, id int64) (
 
 	return detail, nil
 }
+
+// ListSettlementItems 获取结算明细（计入结算的订单列表）
+func (s *SettlementService) ListSettlementItems(ctx context.Context, settlementID int64, page, pageSize int) ([]*models.SettlementItem, int64, error) {
+	if _, err := s.settlementRepo.GetByID(ctx, settlementID); err != nil {
+		return nil, 0, errors.ErrSettlementNotFound.WithError(err)
+	}
+
+	offset := (page - 1) * pageSize
+	items, total, err := s.settlementRepo.ListItems(ctx, settlementID, offset, pageSize)
+	if err != nil {
+		return nil, 0, errors.ErrDatabaseError.WithError(err)
+	}
+	return items, total, nil
+}
+
+// ValidateSettlement 校验结算汇总与明细是否一致
+// 商户结算核对订单明细的笔数、金额之和及每笔商户应得金额；分销商结算核对锁定佣金的笔数与金额之和。
+// 不一致时返回 ErrSettlementInconsistent
+func (s *SettlementService) ValidateSettlement(ctx context.Context, settlementID int64) error {
+	settlement, err := s.settlementRepo.GetByID(ctx, settlementID)
+	if err != nil {
+		return errors.ErrSettlementNotFound.WithError(err)
+	}
+
+	var count int64
+	var totalAmount float64
+	if settlement.Type == models.SettlementTypeMerchant {
+		var items []*models.SettlementItem
+		if err := s.db.WithContext(ctx).Where("settlement_id = ?", settlement.ID).Find(&items).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		for _, item := range items {
+			if roundAmount(item.MerchantShare) != merchantShare(item.Amount, item.CommissionRate) {
+				return errors.ErrSettlementInconsistent.WithMessage(
+					fmt.Sprintf("订单 %s 的商户应得金额 %.2f 与订单金额不符", item.OrderNo, item.MerchantShare))
+			}
+			totalAmount += item.Amount
+		}
+		count = int64(len(items))
+	} else {
+		err := s.db.WithContext(ctx).Model(&models.Commission{}).
+			Where("settlement_id = ?", settlement.ID).
+			Select("COUNT(*), COALESCE(SUM(amount), 0)").
+			Row().Scan(&count, &totalAmount)
+		if err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+	}
+
+	if int(count) != settlement.OrderCount {
+		return errors.ErrSettlementInconsistent.WithMessage(
+			fmt.Sprintf("明细笔数 %d 与结算订单数 %d 不一致", count, settlement.OrderCount))
+	}
+	if roundAmount(totalAmount) != roundAmount(settlement.TotalAmount) {
+		return errors.ErrSettlementInconsistent.WithMessage(
+			fmt.Sprintf("明细金额合计 %.2f 与结算总额 %.2f 不一致", totalAmount, settlement.TotalAmount))
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"gorm.io/gorm"
 
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 )
@@ -44,44 +45,21 @@ func NewStatisticsService(
 }
 
 // GetFinanceOverview 获取财务概览
+// 累计金额由财务日汇总加未汇总部分的实时增量组成，结果短时缓存，提现审核和退款完成时主动清除缓存
 func (s *StatisticsService) GetFinanceOverview(ctx context.Context) (*models.FinanceOverview, error) {
 	overview := &models.FinanceOverview{}
-
-	// 总收入 - 从成功支付记录汇总
-	err := s.db.WithContext(ctx).Model(&models.Payment{}).
-		Where("status = ?", models.PaymentStatusSuccess).
-		Select("COALESCE(SUM(amount), 0)").
-		Row().Scan(&overview.TotalRevenue)
-	if err != nil {
-		return nil, err
-	}
-
-	// 总退款
-	err = s.db.WithContext(ctx).Model(&models.Refund{}).
-		Where("status = ?", models.RefundStatusSuccess).
-		Select("COALESCE(SUM(amount), 0)").
-		Row().Scan(&overview.TotalRefund)
-	if err != nil {
-		return nil, err
-	}
-
-	// 总佣金支出 - 已结算的佣金
-	err = s.db.WithContext(ctx).Model(&models.Commission{}).
-		Where("status = ?", models.CommissionStatusSettled).
-		Select("COALESCE(SUM(amount), 0)").
-		Row().Scan(&overview.TotalCommission)
-	if err != nil {
-		return nil, err
+	if getCachedFinanceOverview(ctx, overview) {
+		return overview, nil
 	}
 
-	// 总结算金额
-	err = s.db.WithContext(ctx).Model(&models.Settlement{}).
-		Where("status = ?", models.SettlementStatusCompleted).
-		Select("COALESCE(SUM(actual_amount), 0)").
-		Row().Scan(&overview.TotalSettlement)
+	totals, err := s.overviewTotals(ctx)
 	if err != nil {
 		return nil, err
 	}
+	overview.TotalRevenue = totals.Revenue
+	overview.TotalRefund = totals.Refund
+	overview.TotalCommission = totals.Commission
+	overview.TotalSettlement = totals.Settlement
 
 	// 今日收入
 	today := time.Now().Truncate(24 * time.Hour)
@@ -124,6 +102,7 @@ func (s *StatisticsService) GetFinanceOverview(ctx context.Context) (*models.Fin
 	}
 	overview.PendingSettlements = int(pendingSettlements)
 
+	setCachedFinanceOverview(ctx, overview)
 	return overview, nil
 }
 
@@ -249,6 +228,67 @@ func (s *StatisticsService) GetOrderRevenueByType(ctx context.Context, startDate
 	return results, err
 }
 
+// 设备收入排行数量限制
+const (
+	DefaultTopDevicesLimit = 10
+	MaxTopDevicesLimit     = 100
+)
+
+// DeviceRevenueItem 设备收入排行项
+type DeviceRevenueItem struct {
+	DeviceID     int64   `json:"device_id"`
+	DeviceNo     string  `json:"device_no"`
+	DeviceName   string  `json:"device_name"`
+	VenueName    string  `json:"venue_name"`
+	MerchantName string  `json:"merchant_name"`
+	TotalRevenue float64 `json:"total_revenue"`
+	OrderCount   int     `json:"order_count"`
+}
+
+// GetTopDevicesByRevenue 获取周期内收入最高的设备
+// 按支付时间统计租借订单实付金额，待支付、已取消和已退款的订单不计入收入
+func (s *StatisticsService) GetTopDevicesByRevenue(ctx context.Context, startDate, endDate time.Time, limit int) ([]*DeviceRevenueItem, error) {
+	if endDate.Before(startDate) {
+		return nil, errors.ErrInvalidParams.WithMessage("结束时间不能早于开始时间")
+	}
+	if limit <= 0 {
+		limit = DefaultTopDevicesLimit
+	}
+	if limit > MaxTopDevicesLimit {
+		limit = MaxTopDevicesLimit
+	}
+
+	items := make([]*DeviceRevenueItem, 0, limit)
+	err := s.db.WithContext(ctx).Model(&models.Rental{}).
+		Select(
+			"devices.id AS device_id",
+			"devices.device_no AS device_no",
+			"devices.name AS device_name",
+			"COALESCE(venues.name, '') AS venue_name",
+			"COALESCE(merchants.name, '') AS merchant_name",
+			"COALESCE(SUM(orders.actual_amount), 0) AS total_revenue",
+			"COUNT(DISTINCT orders.id) AS order_count",
+		).
+		Joins("JOIN orders ON orders.id = rentals.order_id").
+		Joins("JOIN devices ON devices.id = rentals.device_id").
+		Joins("LEFT JOIN venues ON venues.id = devices.venue_id").
+		Joins("LEFT JOIN merchants ON merchants.id = venues.merchant_id").
+		Where("orders.status NOT IN ?", []string{models.OrderStatusPending, models.OrderStatusCancelled, models.OrderStatusRefunded}).
+		Where("orders.paid_at >= ? AND orders.paid_at <= ?", startDate, endDate).
+		Group("devices.id, devices.device_no, devices.name, venues.name, merchants.name").
+		Order("total_revenue DESC, devices.id ASC").
+		Limit(limit).
+		Scan(&items).Error
+	if err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	for _, item := range items {
+		item.TotalRevenue = roundAmount(item.TotalRevenue)
+	}
+	return items, nil
+}
+
 // GetDailyRevenueReport 获取每日收入报表
 func (s *StatisticsService) GetDailyRevenueReport(ctx context.Context, startDate, endDate time.Time) ([]models.DailyRevenueReport, error) {
 	var reports []models.DailyRevenueReport
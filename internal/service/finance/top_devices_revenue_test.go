@@ -0,0 +1,117 @@
+// Package finance 设备收入排行单元测试
+package finance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// createRevenueRental 创建指定支付时间的租借订单
+func createRevenueRental(t *testing.T, db *gorm.DB, userID, deviceID int64, amount float64, orderStatus string, paidAt time.Time) {
+	t.Helper()
+
+	order := createTestOrder(t, db, userID, amount, orderStatus)
+	require.NoError(t, db.Model(order).Update("paid_at", paidAt).Error)
+	require.NoError(t, db.Create(&models.Rental{
+		OrderID:  order.ID,
+		UserID:   userID,
+		DeviceID: deviceID,
+		Status:   models.RentalStatusCompleted,
+	}).Error)
+}
+
+func TestStatisticsService_GetTopDevicesByRevenue(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupStatisticsService(db)
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800138300")
+	merchantA := createTestMerchant(t, db, "商户A")
+	merchantB := createTestMerchant(t, db, "商户B")
+	venueA := createTestVenue(t, db, merchantA.ID, "场地A")
+	venueB := createTestVenue(t, db, merchantB.ID, "场地B")
+	deviceA1 := createTestDevice(t, db, venueA.ID, "TOP-A1")
+	deviceA2 := createTestDevice(t, db, venueA.ID, "TOP-A2")
+	deviceB1 := createTestDevice(t, db, venueB.ID, "TOP-B1")
+	idleDevice := createTestDevice(t, db, venueB.ID, "TOP-IDLE")
+
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 31, 23, 59, 59, 0, time.UTC)
+	inRange := start.Add(5 * 24 * time.Hour)
+
+	// A1: 2 单共 30 元
+	createRevenueRental(t, db, user.ID, deviceA1.ID, 10, models.OrderStatusCompleted, inRange)
+	createRevenueRental(t, db, user.ID, deviceA1.ID, 20, models.OrderStatusPaid, inRange)
+	// A2: 1 单 45.5 元，另有已退款、已取消、待支付订单不计入
+	createRevenueRental(t, db, user.ID, deviceA2.ID, 45.5, models.OrderStatusCompleted, inRange)
+	createRevenueRental(t, db, user.ID, deviceA2.ID, 100, models.OrderStatusRefunded, inRange)
+	createRevenueRental(t, db, user.ID, deviceA2.ID, 100, models.OrderStatusCancelled, inRange)
+	createRevenueRental(t, db, user.ID, deviceA2.ID, 100, models.OrderStatusPending, inRange)
+	// B1: 3 单共 15 元，另有周期外订单不计入
+	createRevenueRental(t, db, user.ID, deviceB1.ID, 5, models.OrderStatusCompleted, start)
+	createRevenueRental(t, db, user.ID, deviceB1.ID, 5, models.OrderStatusCompleted, inRange)
+	createRevenueRental(t, db, user.ID, deviceB1.ID, 5, models.OrderStatusCompleted, end)
+	createRevenueRental(t, db, user.ID, deviceB1.ID, 500, models.OrderStatusCompleted, start.Add(-time.Second))
+	createRevenueRental(t, db, user.ID, deviceB1.ID, 500, models.OrderStatusCompleted, end.Add(time.Second))
+
+	items, err := svc.GetTopDevicesByRevenue(ctx, start, end, 10)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+
+	assert.Equal(t, &DeviceRevenueItem{
+		DeviceID:     deviceA2.ID,
+		DeviceNo:     "TOP-A2",
+		DeviceName:   deviceA2.Name,
+		VenueName:    "场地A",
+		MerchantName: "商户A",
+		TotalRevenue: 45.5,
+		OrderCount:   1,
+	}, items[0])
+	assert.Equal(t, deviceA1.ID, items[1].DeviceID)
+	assert.Equal(t, 30.0, items[1].TotalRevenue)
+	assert.Equal(t, 2, items[1].OrderCount)
+	assert.Equal(t, deviceB1.ID, items[2].DeviceID)
+	assert.Equal(t, 15.0, items[2].TotalRevenue)
+	assert.Equal(t, 3, items[2].OrderCount)
+	assert.Equal(t, "场地B", items[2].VenueName)
+	assert.Equal(t, "商户B", items[2].MerchantName)
+
+	for _, item := range items {
+		assert.NotEqual(t, idleDevice.ID, item.DeviceID)
+	}
+
+	t.Run("限制返回数量", func(t *testing.T) {
+		top, err := svc.GetTopDevicesByRevenue(ctx, start, end, 2)
+		require.NoError(t, err)
+		require.Len(t, top, 2)
+		assert.Equal(t, deviceA2.ID, top[0].DeviceID)
+		assert.Equal(t, deviceA1.ID, top[1].DeviceID)
+	})
+
+	t.Run("未指定数量时使用默认值", func(t *testing.T) {
+		top, err := svc.GetTopDevicesByRevenue(ctx, start, end, 0)
+		require.NoError(t, err)
+		assert.Len(t, top, 3)
+	})
+
+	t.Run("周期内无订单", func(t *testing.T) {
+		top, err := svc.GetTopDevicesByRevenue(ctx, end.Add(time.Hour), end.Add(48*time.Hour), 10)
+		require.NoError(t, err)
+		assert.Empty(t, top)
+	})
+
+	t.Run("结束时间早于开始时间", func(t *testing.T) {
+		_, err := svc.GetTopDevicesByRevenue(ctx, end, start, 10)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok, "expected AppError, got %v", err)
+		assert.Equal(t, appErrors.ErrInvalidParams.Code, appErr.Code)
+	})
+}
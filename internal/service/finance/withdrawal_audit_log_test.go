@@ -0,0 +1,161 @@
+package finance
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// failUpdatesOn 注册回调，使对指定表的更新失败
+func failUpdatesOn(t *testing.T, db *gorm.DB, table string) {
+	t.Helper()
+	err := db.Callback().Update().Before("gorm:update").Register("test:fail_"+table, func(tx *gorm.DB) {
+		if tx.Statement.Table == table {
+			_ = tx.AddError(stderrors.New("injected update failure"))
+		}
+	})
+	require.NoError(t, err)
+}
+
+func countAuditLogs(t *testing.T, db *gorm.DB, withdrawalID int64) int64 {
+	t.Helper()
+	var count int64
+	require.NoError(t, db.Model(&models.WithdrawalAuditLog{}).Where("withdrawal_id = ?", withdrawalID).Count(&count).Error)
+	return count
+}
+
+func TestWithdrawalAuditService_AuditLogs_Lifecycle(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupWithdrawalAuditService(db)
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800160001")
+	withdrawal := createTestWithdrawal(t, db, user.ID, 100.0, models.WithdrawalStatusPending)
+
+	require.NoError(t, svc.ApproveWithdrawal(ctx, withdrawal.ID, 11))
+	require.NoError(t, svc.ProcessWithdrawal(ctx, withdrawal.ID, 12))
+	require.NoError(t, svc.CompleteWithdrawal(ctx, withdrawal.ID, 13))
+
+	logs, err := svc.GetAuditLogs(ctx, withdrawal.ID)
+	require.NoError(t, err)
+	require.Len(t, logs, 3)
+
+	assert.Equal(t, models.WithdrawalAuditActionApprove, logs[0].Action)
+	assert.Equal(t, int64(11), logs[0].OperatorID)
+	assert.Equal(t, models.WithdrawalStatusPending, logs[0].FromStatus)
+	assert.Equal(t, models.WithdrawalStatusApproved, logs[0].ToStatus)
+
+	assert.Equal(t, models.WithdrawalAuditActionProcess, logs[1].Action)
+	assert.Equal(t, int64(12), logs[1].OperatorID)
+	assert.Equal(t, models.WithdrawalStatusApproved, logs[1].FromStatus)
+	assert.Equal(t, models.WithdrawalStatusProcessing, logs[1].ToStatus)
+
+	assert.Equal(t, models.WithdrawalAuditActionComplete, logs[2].Action)
+	assert.Equal(t, int64(13), logs[2].OperatorID)
+	assert.Equal(t, models.WithdrawalStatusProcessing, logs[2].FromStatus)
+	assert.Equal(t, models.WithdrawalStatusSuccess, logs[2].ToStatus)
+}
+
+func TestWithdrawalAuditService_AuditLogs_Reject(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupWithdrawalAuditService(db)
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800160002")
+	withdrawal := createTestWithdrawal(t, db, user.ID, 100.0, models.WithdrawalStatusPending)
+
+	require.NoError(t, svc.RejectWithdrawal(ctx, withdrawal.ID, 21, "账户信息有误"))
+
+	logs, err := svc.GetAuditLogs(ctx, withdrawal.ID)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, models.WithdrawalAuditActionReject, logs[0].Action)
+	assert.Equal(t, models.WithdrawalStatusPending, logs[0].FromStatus)
+	assert.Equal(t, models.WithdrawalStatusRejected, logs[0].ToStatus)
+	require.NotNil(t, logs[0].Reason)
+	assert.Equal(t, "账户信息有误", *logs[0].Reason)
+
+	// 状态不允许的操作不产生日志
+	require.Error(t, svc.ApproveWithdrawal(ctx, withdrawal.ID, 21))
+	assert.Equal(t, int64(1), countAuditLogs(t, db, withdrawal.ID))
+}
+
+func TestWithdrawalAuditService_AuditLogs_Batch(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupWithdrawalAuditService(db)
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800160003")
+	w1 := createTestWithdrawal(t, db, user.ID, 100.0, models.WithdrawalStatusPending)
+	w2 := createTestWithdrawal(t, db, user.ID, 200.0, models.WithdrawalStatusSuccess)
+	w3 := createTestWithdrawal(t, db, user.ID, 300.0, models.WithdrawalStatusPending)
+
+	require.NoError(t, svc.BatchApprove(ctx, []int64{w1.ID, w2.ID, 99999, w3.ID}, 31))
+
+	assert.Equal(t, int64(1), countAuditLogs(t, db, w1.ID))
+	assert.Equal(t, int64(0), countAuditLogs(t, db, w2.ID))
+	assert.Equal(t, int64(1), countAuditLogs(t, db, w3.ID))
+
+	w4 := createTestWithdrawal(t, db, user.ID, 100.0, models.WithdrawalStatusPending)
+	require.NoError(t, svc.BatchReject(ctx, []int64{w1.ID, w4.ID}, 32, "批量拒绝"))
+
+	// w1 已审核通过，拒绝失败不追加日志
+	assert.Equal(t, int64(1), countAuditLogs(t, db, w1.ID))
+	assert.Equal(t, int64(1), countAuditLogs(t, db, w4.ID))
+}
+
+func TestWithdrawalAuditService_AuditLogs_RollbackOnStatusUpdateFailure(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupWithdrawalAuditService(db)
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800160004")
+	withdrawal := createTestWithdrawal(t, db, user.ID, 100.0, models.WithdrawalStatusPending)
+
+	failUpdatesOn(t, db, "withdrawals")
+
+	err := svc.ApproveWithdrawal(ctx, withdrawal.ID, 41)
+	require.Error(t, err)
+	assert.Equal(t, int64(0), countAuditLogs(t, db, withdrawal.ID))
+
+	var current models.Withdrawal
+	require.NoError(t, db.First(&current, withdrawal.ID).Error)
+	assert.Equal(t, models.WithdrawalStatusPending, current.Status)
+}
+
+func TestWithdrawalAuditService_AuditLogs_RollbackOnBalanceFailure(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupWithdrawalAuditService(db)
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800160005")
+	distributor := createTestDistributor(t, db, user.ID)
+	require.NoError(t, db.Model(distributor).Update("frozen_commission", 100.0).Error)
+	withdrawal := createTestWithdrawal(t, db, user.ID, 100.0, models.WithdrawalStatusPending)
+
+	// 状态更新与日志写入后退还佣金失败，整个事务回滚
+	failUpdatesOn(t, db, "distributors")
+
+	err := svc.RejectWithdrawal(ctx, withdrawal.ID, 51, "测试回滚")
+	require.Error(t, err)
+	assert.Equal(t, int64(0), countAuditLogs(t, db, withdrawal.ID))
+
+	var current models.Withdrawal
+	require.NoError(t, db.First(&current, withdrawal.ID).Error)
+	assert.Equal(t, models.WithdrawalStatusPending, current.Status)
+}
+
+func TestWithdrawalAuditService_GetAuditLogs_NotFound(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupWithdrawalAuditService(db)
+
+	_, err := svc.GetAuditLogs(context.Background(), 99999)
+	assert.ErrorIs(t, err, appErrors.ErrWithdrawalNotFound)
+}
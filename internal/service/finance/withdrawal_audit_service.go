@@ -5,6 +5,7 @@ import (
 	"context"
 	"time"
 
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
@@ -17,6 +18,7 @@ type WithdrawalAuditService struct {
 	db              *gorm.DB
 	withdrawalRepo  *repository.WithdrawalRepository
 	distributorRepo *repository.DistributorRepository
+	logger          *zap.Logger
 }
 
 // NewWithdrawalAuditService 创建提现审核服务
@@ -29,6 +31,14 @@ func NewWithdrawalAuditService(
 		db:              db,
 		withdrawalRepo:  withdrawalRepo,
 		distributorRepo: distributorRepo,
+		logger:          zap.NewNop(),
+	}
+}
+
+// SetLogger 设置日志记录器
+func (s *WithdrawalAuditService) SetLogger(logger *zap.Logger) {
+	if logger != nil {
+		s.logger = logger
 	}
 }
 
@@ -92,7 +102,70 @@ func (s *WithdrawalAuditService) ApproveWithdrawal(ctx context.Context, id int64
 		return errors.ErrWithdrawalStatus.WithMessage("只能审核待审核状态的提现申请")
 	}
 
-	return s.withdrawalRepo.Approve(ctx, id, operatorID)
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.approve(ctx, tx, withdrawal, operatorID, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	invalidateFinanceOverviewCache(ctx)
+	return nil
+}
+
+// approve 在事务中将待审核提现变更为已通过
+func (s *WithdrawalAuditService) approve(ctx context.Context, tx *gorm.DB, withdrawal *models.Withdrawal, operatorID int64, reason *string) error {
+	return s.transition(ctx, tx, withdrawal, operatorID, models.WithdrawalAuditActionApprove,
+		models.WithdrawalStatusApproved, map[string]interface{}{
+			"operator_id":  operatorID,
+			"processed_at": time.Now(),
+		}, reason)
+}
+
+// AutoApproveSmallWithdrawals 自动审核通过小额提现
+// 实际到账金额不超过 thresholdAmount 的待审核提现在同一事务中逐笔审核通过，任一失败则全部回滚；
+// 审核日志操作人记为系统，threshold 不大于0时不处理。返回审核通过的数量
+func (s *WithdrawalAuditService) AutoApproveSmallWithdrawals(ctx context.Context, thresholdAmount float64) (int, error) {
+	if thresholdAmount <= 0 {
+		return 0, nil
+	}
+
+	var approved []*models.Withdrawal
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var withdrawals []*models.Withdrawal
+		err := tx.Where("status = ? AND actual_amount <= ?", models.WithdrawalStatusPending, thresholdAmount).
+			Order("id ASC").
+			Find(&withdrawals).Error
+		if err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		reason := "小额提现自动审核通过"
+		for _, withdrawal := range withdrawals {
+			if err := s.approve(ctx, tx, withdrawal, models.WithdrawalAuditOperatorSystem, &reason); err != nil {
+				return err
+			}
+			approved = append(approved, withdrawal)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(approved) > 0 {
+		invalidateFinanceOverviewCache(ctx)
+	}
+
+	for _, withdrawal := range approved {
+		s.logger.Info("小额提现已自动审核通过",
+			zap.Int64("withdrawal_id", withdrawal.ID),
+			zap.String("withdrawal_no", withdrawal.WithdrawalNo),
+			zap.Int64("user_id", withdrawal.UserID),
+			zap.Float64("actual_amount", withdrawal.ActualAmount),
+			zap.Float64("threshold", thresholdAmount),
+		)
+	}
+	return len(approved), nil
 }
 
 // RejectWithdrawal 审核拒绝提现
@@ -114,19 +187,17 @@ func (s *WithdrawalAuditService) RejectWithdrawal(ctx context.Context, id int64,
 		}
 	}()
 
-	// 更新提现状态为已拒绝
+	// 更新提现状态为已拒绝并记录审核日志
 	now := time.Now()
-	err = tx.Model(&models.Withdrawal{}).
-		Where("id = ?", id).
-		Updates(map[string]interface{}{
-			"status":        models.WithdrawalStatusRejected,
+	err = s.transition(ctx, tx, withdrawal, operatorID, models.WithdrawalAuditActionReject,
+		models.WithdrawalStatusRejected, map[string]interface{}{
 			"operator_id":   operatorID,
 			"processed_at":  &now,
 			"reject_reason": reason,
-		}).Error
+		}, &reason)
 	if err != nil {
 		tx.Rollback()
-		return errors.ErrDatabaseError.WithError(err)
+		return err
 	}
 
 	// 退还金额到可提现余额
@@ -149,6 +220,7 @@ func (s *WithdrawalAuditService) RejectWithdrawal(ctx context.Context, id int64,
 			Updates(map[string]interface{}{
 				"balance":        gorm.Expr("balance + ?", withdrawal.Amount),
 				"frozen_balance": gorm.Expr("frozen_balance - ?", withdrawal.Amount),
+				"version":        gorm.Expr("version + 1"),
 			}).Error
 		if err != nil {
 			tx.Rollback()
@@ -156,7 +228,12 @@ func (s *WithdrawalAuditService) RejectWithdrawal(ctx context.Context, id int64,
 		}
 	}
 
-	return tx.Commit().Error
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	invalidateFinanceOverviewCache(ctx)
+	return nil
 }
 
 // ProcessWithdrawal 处理提现（打款中）
@@ -170,7 +247,10 @@ func (s *WithdrawalAuditService) ProcessWithdrawal(ctx context.Context, id int64
 		return errors.ErrWithdrawalStatus.WithMessage("只能处理已审核通过的提现申请")
 	}
 
-	return s.withdrawalRepo.MarkProcessing(ctx, id)
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.transition(ctx, tx, withdrawal, operatorID, models.WithdrawalAuditActionProcess,
+			models.WithdrawalStatusProcessing, nil, nil)
+	})
 }
 
 // CompleteWithdrawal 完成提现
@@ -192,18 +272,16 @@ func (s *WithdrawalAuditService) CompleteWithdrawal(ctx context.Context, id int6
 		}
 	}()
 
-	// 更新状态为已完成
+	// 更新状态为已完成并记录审核日志
 	now := time.Now()
-	err = tx.Model(&models.Withdrawal{}).
-		Where("id = ?", id).
-		Updates(map[string]interface{}{
-			"status":       models.WithdrawalStatusSuccess,
+	err = s.transition(ctx, tx, withdrawal, operatorID, models.WithdrawalAuditActionComplete,
+		models.WithdrawalStatusSuccess, map[string]interface{}{
 			"operator_id":  operatorID,
 			"processed_at": &now,
-		}).Error
+		}, nil)
 	if err != nil {
 		tx.Rollback()
-		return errors.ErrDatabaseError.WithError(err)
+		return err
 	}
 
 	// 扣除冻结金额
@@ -225,6 +303,7 @@ func (s *WithdrawalAuditService) CompleteWithdrawal(ctx context.Context, id int6
 			Where("user_id = ?", withdrawal.UserID).
 			Updates(map[string]interface{}{
 				"frozen_balance":  gorm.Expr("frozen_balance - ?", withdrawal.Amount),
+				"version":         gorm.Expr("version + 1"),
 				"total_withdrawn": gorm.Expr("total_withdrawn + ?", withdrawal.ActualAmount),
 			}).Error
 		if err != nil {
@@ -252,6 +331,63 @@ func (s *WithdrawalAuditService) CompleteWithdrawal(ctx context.Context, id int6
 	return tx.Commit().Error
 }
 
+// transition 在事务中变更提现状态并写入审核日志
+// 以当前状态为条件更新，防止并发操作导致重复流转
+func (s *WithdrawalAuditService) transition(
+	ctx context.Context,
+	tx *gorm.DB,
+	withdrawal *models.Withdrawal,
+	operatorID int64,
+	action string,
+	toStatus string,
+	fields map[string]interface{},
+	reason *string,
+) error {
+	updates := map[string]interface{}{"status": toStatus}
+	for k, v := range fields {
+		updates[k] = v
+	}
+
+	result := tx.WithContext(ctx).Model(&models.Withdrawal{}).
+		Where("id = ? AND status = ?", withdrawal.ID, withdrawal.Status).
+		Updates(updates)
+	if result.Error != nil {
+		return errors.ErrDatabaseError.WithError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrWithdrawalStatus.WithMessage("提现状态已变更，请刷新后重试")
+	}
+
+	log := &models.WithdrawalAuditLog{
+		WithdrawalID: withdrawal.ID,
+		OperatorID:   operatorID,
+		Action:       action,
+		FromStatus:   withdrawal.Status,
+		ToStatus:     toStatus,
+		Reason:       reason,
+	}
+	if err := s.withdrawalRepo.CreateAuditLog(ctx, tx, log); err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	return nil
+}
+
+// GetAuditLogs 获取提现审核日志（按时间正序）
+func (s *WithdrawalAuditService) GetAuditLogs(ctx context.Context, id int64) ([]*models.WithdrawalAuditLog, error) {
+	if _, err := s.withdrawalRepo.GetByID(ctx, id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrWithdrawalNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	logs, err := s.withdrawalRepo.ListAuditLogs(ctx, id)
+	if err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	return logs, nil
+}
+
 // GetPendingWithdrawalsCount 获取待审核提现数量
 func (s *WithdrawalAuditService) GetPendingWithdrawalsCount(ctx context.Context) (int64, error) {
 	return s.withdrawalRepo.CountByStatus(ctx, models.WithdrawalStatusPending)
@@ -0,0 +1,114 @@
+package finance
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+func withdrawalStatus(t *testing.T, db *gorm.DB, id int64) string {
+	t.Helper()
+	var current models.Withdrawal
+	require.NoError(t, db.First(&current, id).Error)
+	return current.Status
+}
+
+func TestWithdrawalAuditService_AutoApproveSmallWithdrawals(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupWithdrawalAuditService(db)
+	core, logs := observer.New(zapcore.InfoLevel)
+	svc.SetLogger(zap.New(core))
+	ctx := context.Background()
+
+	user := createFinanceTestUser(t, db, "13800170001")
+	small := createTestWithdrawal(t, db, user.ID, 5.0, models.WithdrawalStatusPending)
+	boundary := createTestWithdrawal(t, db, user.ID, 10.0, models.WithdrawalStatusPending)
+	large := createTestWithdrawal(t, db, user.ID, 10.01, models.WithdrawalStatusPending)
+	larger := createTestWithdrawal(t, db, user.ID, 500.0, models.WithdrawalStatusPending)
+	rejected := createTestWithdrawal(t, db, user.ID, 3.0, models.WithdrawalStatusRejected)
+
+	approved, err := svc.AutoApproveSmallWithdrawals(ctx, 10.0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, approved)
+
+	assert.Equal(t, models.WithdrawalStatusApproved, withdrawalStatus(t, db, small.ID))
+	assert.Equal(t, models.WithdrawalStatusApproved, withdrawalStatus(t, db, boundary.ID))
+	assert.Equal(t, models.WithdrawalStatusPending, withdrawalStatus(t, db, large.ID))
+	assert.Equal(t, models.WithdrawalStatusPending, withdrawalStatus(t, db, larger.ID))
+	assert.Equal(t, models.WithdrawalStatusRejected, withdrawalStatus(t, db, rejected.ID))
+
+	// 审核日志记为系统操作
+	auditLogs, err := svc.GetAuditLogs(ctx, small.ID)
+	require.NoError(t, err)
+	require.Len(t, auditLogs, 1)
+	assert.Equal(t, models.WithdrawalAuditActionApprove, auditLogs[0].Action)
+	assert.Equal(t, models.WithdrawalAuditOperatorSystem, auditLogs[0].OperatorID)
+	require.NotNil(t, auditLogs[0].Reason)
+	assert.Equal(t, int64(0), countAuditLogs(t, db, large.ID))
+	assert.Equal(t, int64(0), countAuditLogs(t, db, rejected.ID))
+
+	// 每笔自动审核输出一条日志
+	entries := logs.FilterMessage("小额提现已自动审核通过").All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, small.ID, entries[0].ContextMap()["withdrawal_id"])
+	assert.Equal(t, boundary.ID, entries[1].ContextMap()["withdrawal_id"])
+
+	// 再次执行没有待处理的小额提现
+	approved, err = svc.AutoApproveSmallWithdrawals(ctx, 10.0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, approved)
+}
+
+func TestWithdrawalAuditService_AutoApproveSmallWithdrawals_Disabled(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupWithdrawalAuditService(db)
+
+	user := createFinanceTestUser(t, db, "13800170002")
+	withdrawal := createTestWithdrawal(t, db, user.ID, 1.0, models.WithdrawalStatusPending)
+
+	approved, err := svc.AutoApproveSmallWithdrawals(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, approved)
+	assert.Equal(t, models.WithdrawalStatusPending, withdrawalStatus(t, db, withdrawal.ID))
+}
+
+func TestWithdrawalAuditService_AutoApproveSmallWithdrawals_RollbackOnFailure(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	svc := setupWithdrawalAuditService(db)
+	core, logs := observer.New(zapcore.InfoLevel)
+	svc.SetLogger(zap.New(core))
+
+	user := createFinanceTestUser(t, db, "13800170003")
+	first := createTestWithdrawal(t, db, user.ID, 2.0, models.WithdrawalStatusPending)
+	second := createTestWithdrawal(t, db, user.ID, 3.0, models.WithdrawalStatusPending)
+
+	// 第二笔审核日志写入失败，第一笔的审核也应回滚
+	created := 0
+	err := db.Callback().Create().Before("gorm:create").Register("test:fail_second_audit_log", func(tx *gorm.DB) {
+		if tx.Statement.Table == "withdrawal_audit_logs" {
+			created++
+			if created == 2 {
+				_ = tx.AddError(stderrors.New("injected audit log failure"))
+			}
+		}
+	})
+	require.NoError(t, err)
+
+	approved, err := svc.AutoApproveSmallWithdrawals(context.Background(), 10.0)
+	require.Error(t, err)
+	assert.Equal(t, 0, approved)
+
+	assert.Equal(t, models.WithdrawalStatusPending, withdrawalStatus(t, db, first.ID))
+	assert.Equal(t, models.WithdrawalStatusPending, withdrawalStatus(t, db, second.ID))
+	assert.Equal(t, int64(0), countAuditLogs(t, db, first.ID))
+	assert.Equal(t, 0, logs.Len())
+}
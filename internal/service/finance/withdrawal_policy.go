@@ -0,0 +1,96 @@
+package finance
+
+import (
+	"context"
+	stderrors "errors"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// 修改提现规则的操作日志
+const (
+	withdrawalPolicyLogModule     = "finance"
+	withdrawalPolicyLogAction     = "update_withdrawal_policy"
+	withdrawalPolicyLogTargetType = "withdrawal_policy"
+)
+
+// UpdateWithdrawalPolicyRequest 修改提现规则请求，限额为 0 表示不限制
+type UpdateWithdrawalPolicyRequest struct {
+	MinAmount        *float64 `json:"min_amount" binding:"required,gte=0"`
+	FeeFixed         *float64 `json:"fee_fixed" binding:"required,gte=0"`
+	FeePercent       *float64 `json:"fee_percent" binding:"required,gte=0,lt=1"`
+	DailyCountLimit  *int     `json:"daily_count_limit" binding:"required,gte=0"`
+	DailyAmountLimit *float64 `json:"daily_amount_limit" binding:"required,gte=0"`
+}
+
+// ListWithdrawalPolicies 获取全部提现规则
+func (s *WithdrawalAuditService) ListWithdrawalPolicies(ctx context.Context) ([]*models.WithdrawalPolicy, error) {
+	policies, err := s.withdrawalRepo.ListPolicies(ctx)
+	if err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	return policies, nil
+}
+
+// UpdateWithdrawalPolicy 修改提现类型的提现规则，不存在时创建，并记录操作日志
+// 已创建的提现记录保存了申请时的规则快照，不受修改影响
+func (s *WithdrawalAuditService) UpdateWithdrawalPolicy(ctx context.Context, withdrawalType string, req *UpdateWithdrawalPolicyRequest, adminID int64) (*models.WithdrawalPolicy, error) {
+	if withdrawalType != models.WithdrawalTypeWallet && withdrawalType != models.WithdrawalTypeCommission {
+		return nil, errors.ErrWithdrawPolicyNotFound
+	}
+	if req.MinAmount == nil || req.FeeFixed == nil || req.FeePercent == nil || req.DailyCountLimit == nil || req.DailyAmountLimit == nil {
+		return nil, errors.ErrInvalidParams
+	}
+	if *req.MinAmount < 0 || *req.FeeFixed < 0 || *req.FeePercent < 0 || *req.FeePercent >= 1 ||
+		*req.DailyCountLimit < 0 || *req.DailyAmountLimit < 0 {
+		return nil, errors.ErrInvalidParams.WithMessage("提现规则参数超出范围")
+	}
+	if *req.DailyAmountLimit > 0 && *req.DailyAmountLimit < *req.MinAmount {
+		return nil, errors.ErrInvalidParams.WithMessage("每日提现金额上限不能低于最低提现金额")
+	}
+
+	var policy models.WithdrawalPolicy
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var before models.JSON
+		if err := tx.Where("type = ?", withdrawalType).First(&policy).Error; err != nil {
+			if !stderrors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.ErrDatabaseError.WithError(err)
+			}
+			policy = models.WithdrawalPolicy{Type: withdrawalType}
+		} else {
+			before = policy.Snapshot()
+		}
+
+		policy.MinAmount = *req.MinAmount
+		policy.FeeFixed = *req.FeeFixed
+		policy.FeePercent = *req.FeePercent
+		policy.DailyCountLimit = *req.DailyCountLimit
+		policy.DailyAmountLimit = *req.DailyAmountLimit
+		policy.UpdatedBy = &adminID
+		if err := s.withdrawalRepo.SavePolicy(ctx, tx, &policy); err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		targetType := withdrawalPolicyLogTargetType
+		targetID := policy.ID
+		if err := tx.Create(&models.OperationLog{
+			AdminID:    adminID,
+			Module:     withdrawalPolicyLogModule,
+			Action:     withdrawalPolicyLogAction,
+			TargetType: &targetType,
+			TargetID:   &targetID,
+			BeforeData: before,
+			AfterData:  policy.Snapshot(),
+		}).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
@@ -0,0 +1,113 @@
+package finance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	"github.com/dumeirei/smart-locker-backend/internal/service/distribution"
+)
+
+func policyRequest(minAmount, feeFixed, feePercent float64, dailyCount int, dailyAmount float64) *UpdateWithdrawalPolicyRequest {
+	return &UpdateWithdrawalPolicyRequest{
+		MinAmount:        &minAmount,
+		FeeFixed:         &feeFixed,
+		FeePercent:       &feePercent,
+		DailyCountLimit:  &dailyCount,
+		DailyAmountLimit: &dailyAmount,
+	}
+}
+
+func TestWithdrawalAuditService_UpdateWithdrawalPolicy(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.WithdrawalPolicy{}, &models.OperationLog{}))
+	svc := setupWithdrawalAuditService(db)
+	ctx := context.Background()
+
+	policy, err := svc.UpdateWithdrawalPolicy(ctx, models.WithdrawalTypeCommission, policyRequest(20, 1, 0.01, 2, 500), 9)
+	require.NoError(t, err)
+	assert.Equal(t, 20.0, policy.MinAmount)
+	require.NotNil(t, policy.UpdatedBy)
+	assert.Equal(t, int64(9), *policy.UpdatedBy)
+
+	// 再次修改覆盖同一类型
+	_, err = svc.UpdateWithdrawalPolicy(ctx, models.WithdrawalTypeCommission, policyRequest(30, 1, 0.01, 2, 500), 9)
+	require.NoError(t, err)
+	policies, err := svc.ListWithdrawalPolicies(ctx)
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.Equal(t, 30.0, policies[0].MinAmount)
+
+	var logs []*models.OperationLog
+	require.NoError(t, db.Where("action = ?", withdrawalPolicyLogAction).Order("id ASC").Find(&logs).Error)
+	require.Len(t, logs, 2)
+	assert.Nil(t, logs[0].BeforeData)
+	assert.Equal(t, 20.0, logs[1].BeforeData["min_amount"])
+	assert.Equal(t, 30.0, logs[1].AfterData["min_amount"])
+
+	t.Run("未知提现类型", func(t *testing.T) {
+		_, err := svc.UpdateWithdrawalPolicy(ctx, "bonus", policyRequest(1, 0, 0, 0, 0), 9)
+		require.Error(t, err)
+		assert.Equal(t, appErrors.ErrWithdrawPolicyNotFound.Code, err.(*appErrors.AppError).Code)
+	})
+
+	t.Run("每日金额上限低于最低金额", func(t *testing.T) {
+		_, err := svc.UpdateWithdrawalPolicy(ctx, models.WithdrawalTypeWallet, policyRequest(50, 0, 0, 0, 20), 9)
+		require.Error(t, err)
+		assert.Equal(t, appErrors.ErrInvalidParams.Code, err.(*appErrors.AppError).Code)
+	})
+}
+
+func TestWithdrawalAuditService_PolicyChangeKeepsSnapshot(t *testing.T) {
+	db := setupFinanceTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.WithdrawalPolicy{}, &models.OperationLog{}, &models.Admin{}))
+	svc := setupWithdrawalAuditService(db)
+	ctx := context.Background()
+
+	_, err := svc.UpdateWithdrawalPolicy(ctx, models.WithdrawalTypeCommission, policyRequest(10, 0.5, 0.01, 0, 0), 9)
+	require.NoError(t, err)
+
+	user := createFinanceTestUser(t, db, "13800190001")
+	distributor := createTestDistributor(t, db, user.ID)
+	require.NoError(t, db.Model(distributor).Updates(map[string]interface{}{
+		"status":               models.DistributorStatusApproved,
+		"available_commission": 200,
+	}).Error)
+	withdrawSvc := distribution.NewWithdrawService(
+		repository.NewWithdrawalRepository(db),
+		repository.NewDistributorRepository(db),
+		repository.NewUserRepository(db),
+		db,
+	)
+	resp, err := withdrawSvc.Apply(ctx, &distribution.WithdrawRequest{
+		UserID:      user.ID,
+		Type:        models.WithdrawalTypeCommission,
+		Amount:      100,
+		WithdrawTo:  models.WithdrawToWechat,
+		AccountInfo: `{}`,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, resp.Fee)
+
+	// 规则修改后，已创建的提现保持原手续费和快照
+	_, err = svc.UpdateWithdrawalPolicy(ctx, models.WithdrawalTypeCommission, policyRequest(50, 2, 0.02, 1, 1000), 9)
+	require.NoError(t, err)
+
+	withdrawal, err := svc.GetWithdrawal(ctx, resp.Withdrawal.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, withdrawal.Fee)
+	assert.Equal(t, 98.5, withdrawal.ActualAmount)
+	assert.Equal(t, 10.0, withdrawal.PolicySnapshot["min_amount"])
+	assert.Equal(t, 0.5, withdrawal.PolicySnapshot["fee_fixed"])
+	assert.Equal(t, 0.01, withdrawal.PolicySnapshot["fee_percent"])
+
+	list, _, err := svc.ListWithdrawals(ctx, &WithdrawalListRequest{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, 0.01, list[0].PolicySnapshot["fee_percent"])
+}
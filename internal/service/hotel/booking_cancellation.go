@@ -0,0 +1,163 @@
+package hotel
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// RequestCancellationRequest 申请取消（退款）请求
+type RequestCancellationRequest struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// CancellationResult 取消结果
+type CancellationResult struct {
+	BookingID       int64   `json:"booking_id"`
+	BookingNo       string  `json:"booking_no"`
+	Status          string  `json:"status"`
+	StatusName      string  `json:"status_name"`
+	RefundNo        string  `json:"refund_no,omitempty"`
+	RefundAmount    float64 `json:"refund_amount"`
+	CancellationFee float64 `json:"cancellation_fee"`
+}
+
+// calculateCancellationRefund 按酒店取消政策计算退款金额和手续费
+// 入住前 FreeCancelHours 小时之前取消全额退款，之后按 CancellationFeeRate 收取手续费；已到入住时间不可取消
+func calculateCancellationRefund(hotel *models.Hotel, booking *models.Booking, now time.Time) (refund, fee float64, err error) {
+	if !now.Before(booking.CheckInTime) {
+		return 0, 0, errors.ErrBookingCancelDeadline
+	}
+
+	freeCancelDeadline := booking.CheckInTime.Add(-time.Duration(hotel.FreeCancelHours) * time.Hour)
+	if !now.After(freeCancelDeadline) || hotel.CancellationFeeRate <= 0 {
+		return booking.Amount, 0, nil
+	}
+
+	rate := math.Min(hotel.CancellationFeeRate, 1)
+	fee = math.Round(booking.Amount*rate*100) / 100
+	refund = math.Round((booking.Amount-fee)*100) / 100
+	return refund, fee, nil
+}
+
+// RequestCancellation 申请取消已支付的预订
+// 按酒店取消政策计算退款金额并创建退款申请，预订进入退款中状态并释放房间时段；
+// 扣除手续费后无可退金额时直接取消
+func (s *BookingService) RequestCancellation(ctx context.Context, id int64, userID int64, reason string) (*CancellationResult, error) {
+	booking, err := s.bookingRepo.GetByIDWithDetails(ctx, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrBookingNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	if booking.UserID != userID {
+		return nil, errors.ErrPermissionDenied
+	}
+
+	switch booking.Status {
+	case models.BookingStatusPaid:
+	case models.BookingStatusPending:
+		return nil, errors.ErrBookingNotPaid.WithMessage("待支付的预订请直接取消")
+	default:
+		return nil, errors.ErrBookingStatusError.WithMessage("只有待核销的预订可以申请退款")
+	}
+
+	if booking.Hotel == nil {
+		return nil, errors.ErrHotelNotFound
+	}
+
+	refundAmount, fee, err := calculateCancellationRefund(booking.Hotel, booking, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if reason == "" {
+		reason = "用户取消预订"
+	}
+
+	result := &CancellationResult{
+		BookingID:       booking.ID,
+		BookingNo:       booking.BookingNo,
+		RefundAmount:    refundAmount,
+		CancellationFee: fee,
+	}
+
+	newStatus := models.BookingStatusRefunding
+	orderStatus := models.OrderStatusRefunding
+	if refundAmount <= 0 {
+		newStatus = models.BookingStatusCancelled
+		orderStatus = models.OrderStatusCancelled
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 条件更新防止并发重复申请；离开已支付状态后房间时段即可被重新预订
+		res := tx.Model(&models.Booking{}).
+			Where("id = ? AND status = ?", booking.ID, models.BookingStatusPaid).
+			Update("status", newStatus)
+		if res.Error != nil {
+			return errors.ErrDatabaseError.WithError(res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return errors.ErrBookingStatusError.WithMessage("预订状态已变更，请刷新后重试")
+		}
+
+		if refundAmount > 0 {
+			var payment models.Payment
+			if err := tx.Where("order_id = ? AND status = ?", booking.OrderID, models.PaymentStatusSuccess).
+				First(&payment).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return errors.ErrPaymentNotFound
+				}
+				return errors.ErrDatabaseError.WithError(err)
+			}
+
+			operatorType := models.RefundOperatorUser
+			refund := &models.Refund{
+				RefundNo:     utils.GenerateOrderNo("R"),
+				OrderID:      payment.OrderID,
+				OrderNo:      payment.OrderNo,
+				PaymentID:    payment.ID,
+				PaymentNo:    payment.PaymentNo,
+				UserID:       userID,
+				Amount:       refundAmount,
+				Reason:       reason,
+				Status:       models.RefundStatusPending,
+				OperatorID:   &userID,
+				OperatorType: &operatorType,
+			}
+			if err := tx.Create(refund).Error; err != nil {
+				return errors.ErrDatabaseError.WithError(err)
+			}
+			result.RefundNo = refund.RefundNo
+		}
+
+		fields := map[string]interface{}{"status": orderStatus}
+		if orderStatus == models.OrderStatusCancelled {
+			fields["cancelled_at"] = time.Now()
+			fields["cancel_reason"] = reason
+		}
+		if err := tx.Model(&models.Order{}).Where("id = ?", booking.OrderID).Updates(fields).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		if err := s.occupancyRepo.RefreshTx(ctx, tx, booking.RoomID, booking.CheckInTime, booking.CheckOutTime); err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Status = newStatus
+	result.StatusName = s.getStatusName(newStatus)
+	return result, nil
+}
@@ -0,0 +1,196 @@
+// Package hotel 预订取消退款单元测试
+package hotel
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// createPaidBooking 创建已支付的预订及其订单、支付记录
+func createPaidBooking(t *testing.T, db *gorm.DB, user *models.User, room *models.Room, checkIn time.Time, amount float64) *models.Booking {
+	t.Helper()
+
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+	order := &models.Order{
+		OrderNo:        "O" + suffix,
+		UserID:         user.ID,
+		Type:           models.OrderTypeHotel,
+		OriginalAmount: amount,
+		ActualAmount:   amount,
+		Status:         models.OrderStatusPaid,
+	}
+	require.NoError(t, db.Create(order).Error)
+
+	payment := &models.Payment{
+		PaymentNo:      "P" + suffix,
+		OrderID:        order.ID,
+		OrderNo:        order.OrderNo,
+		UserID:         user.ID,
+		Amount:         amount,
+		PaymentMethod:  models.PaymentMethodWechat,
+		PaymentChannel: models.PaymentChannelMiniProgram,
+		Status:         models.PaymentStatusSuccess,
+	}
+	require.NoError(t, db.Create(payment).Error)
+
+	booking := &models.Booking{
+		BookingNo:        "B" + suffix,
+		OrderID:          order.ID,
+		UserID:           user.ID,
+		HotelID:          room.HotelID,
+		RoomID:           room.ID,
+		CheckInTime:      checkIn,
+		CheckOutTime:     checkIn.Add(2 * time.Hour),
+		DurationHours:    2,
+		Amount:           amount,
+		VerificationCode: "V" + suffix,
+		UnlockCode:       suffix[len(suffix)-6:],
+		QRCode:           "/qr/" + suffix,
+		Status:           models.BookingStatusPaid,
+	}
+	require.NoError(t, db.Create(booking).Error)
+	return booking
+}
+
+func TestBookingService_RequestCancellation(t *testing.T) {
+	svc := setupTestBookingService(t)
+	ctx := context.Background()
+
+	user, hotel, room, _ := createTestBookingData(t, svc.db)
+
+	// 入住前 24 小时之前免费取消，之后收取 20% 手续费
+	require.NoError(t, svc.db.Model(hotel).Updates(map[string]interface{}{
+		"free_cancel_hours":     24,
+		"cancellation_fee_rate": 0.2,
+	}).Error)
+
+	t.Run("免费取消期内全额退款", func(t *testing.T) {
+		checkIn := time.Now().Add(48 * time.Hour)
+		booking := createPaidBooking(t, svc.db, user, room, checkIn, 100)
+
+		result, err := svc.RequestCancellation(ctx, booking.ID, user.ID, "行程变更")
+		require.NoError(t, err)
+		assert.Equal(t, models.BookingStatusRefunding, result.Status)
+		assert.Equal(t, "退款中", result.StatusName)
+		assert.Equal(t, 100.0, result.RefundAmount)
+		assert.Equal(t, 0.0, result.CancellationFee)
+		assert.NotEmpty(t, result.RefundNo)
+
+		var refund models.Refund
+		require.NoError(t, svc.db.Where("refund_no = ?", result.RefundNo).First(&refund).Error)
+		assert.Equal(t, booking.OrderID, refund.OrderID)
+		assert.Equal(t, 100.0, refund.Amount)
+		assert.Equal(t, "行程变更", refund.Reason)
+		assert.Equal(t, int8(models.RefundStatusPending), refund.Status)
+
+		var order models.Order
+		require.NoError(t, svc.db.First(&order, booking.OrderID).Error)
+		assert.Equal(t, models.OrderStatusRefunding, order.Status)
+
+		// 房间时段已释放，可被重新预订
+		exists, err := repository.NewBookingRepository(svc.db).
+			ExistsByRoomAndTimeRange(ctx, room.ID, booking.CheckInTime, booking.CheckOutTime)
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("超过免费取消期部分退款", func(t *testing.T) {
+		checkIn := time.Now().Add(6 * time.Hour)
+		booking := createPaidBooking(t, svc.db, user, room, checkIn, 100)
+
+		result, err := svc.RequestCancellation(ctx, booking.ID, user.ID, "")
+		require.NoError(t, err)
+		assert.Equal(t, models.BookingStatusRefunding, result.Status)
+		assert.Equal(t, 80.0, result.RefundAmount)
+		assert.Equal(t, 20.0, result.CancellationFee)
+
+		var refund models.Refund
+		require.NoError(t, svc.db.Where("refund_no = ?", result.RefundNo).First(&refund).Error)
+		assert.Equal(t, 80.0, refund.Amount)
+		assert.Equal(t, "用户取消预订", refund.Reason)
+	})
+
+	t.Run("已过入住时间不可取消", func(t *testing.T) {
+		checkIn := time.Now().Add(-10 * time.Minute)
+		booking := createPaidBooking(t, svc.db, user, room, checkIn, 100)
+
+		_, err := svc.RequestCancellation(ctx, booking.ID, user.ID, "")
+		assert.ErrorIs(t, err, appErrors.ErrBookingCancelDeadline)
+
+		var updated models.Booking
+		require.NoError(t, svc.db.First(&updated, booking.ID).Error)
+		assert.Equal(t, models.BookingStatusPaid, updated.Status)
+	})
+
+	t.Run("重复申请失败", func(t *testing.T) {
+		booking := createPaidBooking(t, svc.db, user, room, time.Now().Add(72*time.Hour), 100)
+
+		_, err := svc.RequestCancellation(ctx, booking.ID, user.ID, "")
+		require.NoError(t, err)
+
+		_, err = svc.RequestCancellation(ctx, booking.ID, user.ID, "")
+		require.Error(t, err)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrBookingStatusError.Code, appErr.Code)
+
+		var count int64
+		svc.db.Model(&models.Refund{}).Where("order_id = ?", booking.OrderID).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("非本人预订无权取消", func(t *testing.T) {
+		booking := createPaidBooking(t, svc.db, user, room, time.Now().Add(72*time.Hour), 100)
+
+		_, err := svc.RequestCancellation(ctx, booking.ID, user.ID+1000, "")
+		assert.ErrorIs(t, err, appErrors.ErrPermissionDenied)
+	})
+
+	t.Run("待支付预订仍走直接取消", func(t *testing.T) {
+		bookingInfo, err := svc.CreateBooking(ctx, user.ID, &CreateBookingRequest{
+			RoomID:        room.ID,
+			DurationHours: 2,
+			CheckInTime:   time.Now().Add(100 * time.Hour),
+		})
+		require.NoError(t, err)
+
+		_, err = svc.RequestCancellation(ctx, bookingInfo.ID, user.ID, "")
+		require.Error(t, err)
+
+		require.NoError(t, svc.CancelBooking(ctx, bookingInfo.ID, user.ID))
+		var updated models.Booking
+		require.NoError(t, svc.db.First(&updated, bookingInfo.ID).Error)
+		assert.Equal(t, models.BookingStatusCancelled, updated.Status)
+	})
+
+	t.Run("手续费全额时直接取消不创建退款", func(t *testing.T) {
+		require.NoError(t, svc.db.Model(hotel).Update("cancellation_fee_rate", 1).Error)
+		defer svc.db.Model(hotel).Update("cancellation_fee_rate", 0.2)
+
+		booking := createPaidBooking(t, svc.db, user, room, time.Now().Add(2*time.Hour), 100)
+
+		result, err := svc.RequestCancellation(ctx, booking.ID, user.ID, "")
+		require.NoError(t, err)
+		assert.Equal(t, models.BookingStatusCancelled, result.Status)
+		assert.Equal(t, 0.0, result.RefundAmount)
+		assert.Empty(t, result.RefundNo)
+
+		var count int64
+		svc.db.Model(&models.Refund{}).Where("order_id = ?", booking.OrderID).Count(&count)
+		assert.Equal(t, int64(0), count)
+
+		var order models.Order
+		require.NoError(t, svc.db.First(&order, booking.OrderID).Error)
+		assert.Equal(t, models.OrderStatusCancelled, order.Status)
+	})
+}
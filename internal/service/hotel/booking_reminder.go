@@ -0,0 +1,99 @@
+package hotel
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// BookingReminderLead 入住提醒提前量，提醒任务每 5 分钟执行一次，
+// 查找入住时间在该提前量加一个执行间隔内的预订，保证每个预订在入住前约 30 分钟收到提醒
+const BookingReminderLead = 35 * time.Minute
+
+// bookingReminderBatch 每次执行的提醒预订数上限
+const bookingReminderBatch = 200
+
+// PushNotifier 移动端推送发送器
+type PushNotifier interface {
+	Send(ctx context.Context, userID int64, title, body string) error
+}
+
+// NotificationJob 预订入住提醒任务
+type NotificationJob struct {
+	db       *gorm.DB
+	notifier PushNotifier
+}
+
+// NewNotificationJob 创建预订入住提醒任务
+func NewNotificationJob(db *gorm.DB, notifier PushNotifier) *NotificationJob {
+	return &NotificationJob{db: db, notifier: notifier}
+}
+
+// SendBookingReminders 向入住时间在 BookingReminderLead 内、尚未提醒的已支付预订推送入住提醒，返回发送成功的预订数
+// 发送前先条件更新 reminder_sent_at 认领预订，多实例同时执行也不会重复推送；
+// 推送失败时清空 reminder_sent_at，下次执行时重试。单个预订失败不影响其他预订，全部错误合并返回
+func (j *NotificationJob) SendBookingReminders(ctx context.Context) (int, error) {
+	now := time.Now()
+	var bookings []*models.Booking
+	if err := j.db.WithContext(ctx).
+		Preload("Hotel").
+		Where("status = ? AND reminder_sent_at IS NULL AND check_in_time BETWEEN ? AND ?",
+			models.BookingStatusPaid, now, now.Add(BookingReminderLead)).
+		Order("check_in_time ASC").
+		Limit(bookingReminderBatch).
+		Find(&bookings).Error; err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, booking := range bookings {
+		claimed, err := j.claimReminder(ctx, booking.ID, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("booking %d: %w", booking.ID, err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		title, body := bookingReminderMessage(booking)
+		if err := j.notifier.Send(ctx, booking.UserID, title, body); err != nil {
+			errs = append(errs, fmt.Errorf("booking %d send: %w", booking.ID, err))
+			if err := j.db.WithContext(ctx).Model(&models.Booking{}).
+				Where("id = ?", booking.ID).
+				Update("reminder_sent_at", nil).Error; err != nil {
+				errs = append(errs, fmt.Errorf("booking %d release: %w", booking.ID, err))
+			}
+			continue
+		}
+		sent++
+	}
+	return sent, stderrors.Join(errs...)
+}
+
+// claimReminder 条件更新预订的提醒发送时间，已被其他任务认领时返回 false
+func (j *NotificationJob) claimReminder(ctx context.Context, bookingID int64, now time.Time) (bool, error) {
+	result := j.db.WithContext(ctx).Model(&models.Booking{}).
+		Where("id = ? AND status = ? AND reminder_sent_at IS NULL", bookingID, models.BookingStatusPaid).
+		Update("reminder_sent_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// bookingReminderMessage 生成入住提醒内容，入住时间按酒店时区显示
+func bookingReminderMessage(booking *models.Booking) (string, string) {
+	hotelName := "酒店"
+	if booking.Hotel != nil && booking.Hotel.Name != "" {
+		hotelName = booking.Hotel.Name
+	}
+	checkIn := booking.CheckInTime.In(hotelLocation(booking.Hotel)).Format("15:04")
+	return "入住提醒", fmt.Sprintf("您预订的%s将于 %s 开始入住，请准时到店核销入住。预订号：%s", hotelName, checkIn, booking.BookingNo)
+}
@@ -0,0 +1,108 @@
+package hotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// pushMessage 推送记录
+type pushMessage struct {
+	UserID int64
+	Title  string
+	Body   string
+}
+
+// recordingPushNotifier 记录推送内容的推送发送器，err 非空时发送失败
+type recordingPushNotifier struct {
+	messages []pushMessage
+	err      error
+}
+
+func (n *recordingPushNotifier) Send(ctx context.Context, userID int64, title, body string) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.messages = append(n.messages, pushMessage{UserID: userID, Title: title, Body: body})
+	return nil
+}
+
+func TestNotificationJob_SendBookingReminders(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	user, hotel, room, _ := createTestBookingData(t, db)
+	now := time.Now()
+
+	due30 := createPaidBooking(t, db, user, room, now.Add(30*time.Minute), 100)
+	due34 := createPaidBooking(t, db, user, room, now.Add(34*time.Minute), 100)
+	tooEarly := createPaidBooking(t, db, user, room, now.Add(40*time.Minute), 100)
+	started := createPaidBooking(t, db, user, room, now.Add(-5*time.Minute), 100)
+	pending := createPaidBooking(t, db, user, room, now.Add(30*time.Minute), 100)
+	require.NoError(t, db.Model(pending).Update("status", models.BookingStatusPending).Error)
+	reminded := createPaidBooking(t, db, user, room, now.Add(30*time.Minute), 100)
+	sentAt := now.Add(-10 * time.Minute)
+	require.NoError(t, db.Model(reminded).Update("reminder_sent_at", sentAt).Error)
+
+	notifier := &recordingPushNotifier{}
+	job := NewNotificationJob(db, notifier)
+
+	sent, err := job.SendBookingReminders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	require.Len(t, notifier.messages, 2)
+	assert.Equal(t, user.ID, notifier.messages[0].UserID)
+	assert.Equal(t, "入住提醒", notifier.messages[0].Title)
+	assert.Contains(t, notifier.messages[0].Body, hotel.Name)
+	assert.Contains(t, notifier.messages[0].Body, due30.BookingNo)
+	assert.Contains(t, notifier.messages[1].Body, due34.BookingNo)
+
+	reminderSentAt := func(id int64) *time.Time {
+		var booking models.Booking
+		require.NoError(t, db.First(&booking, id).Error)
+		return booking.ReminderSentAt
+	}
+	assert.NotNil(t, reminderSentAt(due30.ID))
+	assert.NotNil(t, reminderSentAt(due34.ID))
+	assert.Nil(t, reminderSentAt(tooEarly.ID))
+	assert.Nil(t, reminderSentAt(started.ID))
+	assert.Nil(t, reminderSentAt(pending.ID))
+
+	t.Run("重复执行不重复推送", func(t *testing.T) {
+		sent, err := job.SendBookingReminders(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, sent)
+		assert.Len(t, notifier.messages, 2)
+	})
+}
+
+func TestNotificationJob_SendBookingReminders_SendFailureRetries(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	user, _, room, _ := createTestBookingData(t, db)
+	booking := createPaidBooking(t, db, user, room, time.Now().Add(30*time.Minute), 100)
+
+	notifier := &recordingPushNotifier{err: errors.New("push unavailable")}
+	job := NewNotificationJob(db, notifier)
+
+	sent, err := job.SendBookingReminders(ctx)
+	require.Error(t, err)
+	assert.Zero(t, sent)
+
+	var failed models.Booking
+	require.NoError(t, db.First(&failed, booking.ID).Error)
+	assert.Nil(t, failed.ReminderSentAt)
+
+	// 推送恢复后下次执行补发
+	notifier.err = nil
+	sent, err = job.SendBookingReminders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	require.Len(t, notifier.messages, 1)
+	assert.Contains(t, notifier.messages[0].Body, booking.BookingNo)
+}
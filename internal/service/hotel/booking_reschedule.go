@@ -0,0 +1,219 @@
+package hotel
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	userService "github.com/dumeirei/smart-locker-backend/internal/service/user"
+)
+
+// rescheduleRefundReason 改期后价格降低时退还差价的退款原因
+const rescheduleRefundReason = "预订改期退还差价"
+
+// RescheduleBookingRequest 预订改期请求
+type RescheduleBookingRequest struct {
+	CheckInTime string `json:"check_in_time" binding:"required"`
+}
+
+// SetWalletService 设置钱包服务，用于已支付预订改期后价格上涨时从余额扣除差价；
+// 未设置时价格上涨的已支付预订不允许改期
+func (s *BookingService) SetWalletService(walletService *userService.WalletService) {
+	s.walletService = walletService
+}
+
+// BookingLocation 获取预订所属酒店的时区，用于按酒店当地时间解析改期后的入住时间
+func (s *BookingService) BookingLocation(ctx context.Context, bookingID int64) (*time.Location, error) {
+	booking, err := s.bookingRepo.GetByID(ctx, bookingID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrBookingNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	return roomLocation(ctx, s.roomRepo, booking.RoomID)
+}
+
+// RescheduleBooking 修改待支付或已支付预订的入住时间，入住时长不变，核销码、开锁码及二维码保持不变
+// 改期后按房间当前时段价格重新计价：待支付预订直接更新订单金额；已支付预订价格上涨时从余额扣除差价，
+// 价格下降时创建差价退款申请
+func (s *BookingService) RescheduleBooking(ctx context.Context, bookingID, userID int64, newCheckInTime time.Time) (*BookingInfo, error) {
+	booking, err := s.bookingRepo.GetByIDWithDetails(ctx, bookingID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrBookingNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	if booking.UserID != userID {
+		return nil, errors.ErrPermissionDenied
+	}
+	if booking.Status != models.BookingStatusPending && booking.Status != models.BookingStatusPaid {
+		return nil, errors.ErrBookingStatusError.WithMessage("只有待支付或待核销的预订可以改期")
+	}
+
+	now := time.Now()
+	if !now.Before(booking.CheckInTime) {
+		return nil, errors.ErrBookingStatusError.WithMessage("已到入住时间的预订不可改期")
+	}
+
+	room, err := s.roomRepo.GetByIDWithHotel(ctx, booking.RoomID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrRoomNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	if room.Status != int8(models.RoomStatusActive) {
+		return nil, errors.ErrRoomNotAvailable
+	}
+	if room.Hotel == nil || room.Hotel.Status != int8(models.HotelStatusActive) {
+		return nil, errors.ErrHotelNotFound
+	}
+
+	// 按酒店当地时间校验新的入住时间并计算退房时间
+	checkInTime, checkOutTime, err := resolveStayPeriod(room.Hotel, newCheckInTime, booking.DurationHours, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查新时段是否与该房间的其他预订冲突
+	exists, err := s.bookingRepo.ExistsByRoomAndTimeRangeExcluding(ctx, booking.RoomID, checkInTime, checkOutTime, booking.ID)
+	if err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	if exists {
+		return nil, errors.ErrBookingConflict
+	}
+
+	// 按当前时段价格及新入住日期重新计价
+	timeSlot, err := s.timeSlotRepo.GetByRoomAndDuration(ctx, booking.RoomID, booking.DurationHours)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrTimeSlotNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	newAmount, err := s.slotPrice(ctx, timeSlot, checkInTime)
+	if err != nil {
+		return nil, err
+	}
+	diff := math.Round((newAmount-booking.Amount)*100) / 100
+
+	if booking.Status == models.BookingStatusPaid && diff > 0 && s.walletService == nil {
+		return nil, errors.ErrOperationFailed.WithMessage("暂不支持补差价，请取消后重新预订")
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 条件更新防止与支付、核销、取消并发
+		res := tx.Model(&models.Booking{}).
+			Where("id = ? AND status = ?", booking.ID, booking.Status).
+			Updates(map[string]interface{}{
+				"check_in_time":  checkInTime,
+				"check_out_time": checkOutTime,
+				"amount":         newAmount,
+			})
+		if res.Error != nil {
+			return errors.ErrDatabaseError.WithError(res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return errors.ErrBookingStatusError.WithMessage("预订状态已变更，请刷新后重试")
+		}
+
+		// 已支付的预订释放原时段并占用新时段
+		if booking.Status == models.BookingStatusPaid {
+			if err := s.occupancyRepo.RefreshTx(ctx, tx, booking.RoomID, booking.CheckInTime, booking.CheckOutTime); err != nil {
+				return errors.ErrDatabaseError.WithError(err)
+			}
+			if err := s.occupancyRepo.RefreshTx(ctx, tx, booking.RoomID, checkInTime, checkOutTime); err != nil {
+				return errors.ErrDatabaseError.WithError(err)
+			}
+		}
+
+		if diff == 0 {
+			return nil
+		}
+
+		if booking.Status == models.BookingStatusPending {
+			// 未支付：直接按新价格更新订单金额
+			return s.updateBookingOrderAmount(tx, booking.OrderID, diff)
+		}
+
+		if diff > 0 {
+			// 已支付且价格上涨：余额补差价
+			orderNo := ""
+			if booking.Order != nil {
+				orderNo = booking.Order.OrderNo
+			}
+			if err := s.walletService.ConsumeTx(ctx, tx, userID, diff, orderNo); err != nil {
+				return err
+			}
+			return s.updateBookingOrderAmount(tx, booking.OrderID, diff)
+		}
+
+		// 已支付且价格下降：创建差价退款申请
+		return s.createRescheduleRefund(tx, booking, userID, -diff)
+	})
+	if err != nil {
+		if _, ok := err.(*errors.AppError); ok {
+			return nil, err
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	booking.CheckInTime = checkInTime
+	booking.CheckOutTime = checkOutTime
+	booking.Amount = newAmount
+	booking.Hotel = room.Hotel
+	booking.Room = room
+
+	return s.convertBookingInfo(booking, booking.Status == models.BookingStatusPaid), nil
+}
+
+// updateBookingOrderAmount 按差价调整预订订单金额
+func (s *BookingService) updateBookingOrderAmount(tx *gorm.DB, orderID int64, diff float64) error {
+	if err := tx.Model(&models.Order{}).Where("id = ?", orderID).Updates(map[string]interface{}{
+		"original_amount": gorm.Expr("original_amount + ?", diff),
+		"actual_amount":   gorm.Expr("actual_amount + ?", diff),
+	}).Error; err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	return nil
+}
+
+// createRescheduleRefund 创建改期差价退款申请
+func (s *BookingService) createRescheduleRefund(tx *gorm.DB, booking *models.Booking, userID int64, amount float64) error {
+	var payment models.Payment
+	if err := tx.Where("order_id = ? AND status = ?", booking.OrderID, models.PaymentStatusSuccess).
+		First(&payment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.ErrPaymentNotFound
+		}
+		return errors.ErrDatabaseError.WithError(err)
+	}
+
+	operatorType := models.RefundOperatorUser
+	refund := &models.Refund{
+		RefundNo:     utils.GenerateOrderNo("R"),
+		OrderID:      payment.OrderID,
+		OrderNo:      payment.OrderNo,
+		PaymentID:    payment.ID,
+		PaymentNo:    payment.PaymentNo,
+		UserID:       userID,
+		Amount:       amount,
+		Reason:       rescheduleRefundReason,
+		Status:       models.RefundStatusPending,
+		OperatorID:   &userID,
+		OperatorType: &operatorType,
+	}
+	if err := tx.Create(refund).Error; err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+// Package hotel 预订改期单元测试
+package hotel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	userService "github.com/dumeirei/smart-locker-backend/internal/service/user"
+)
+
+// assertRescheduleErrorCode 断言错误为指定错误码的 AppError
+func assertRescheduleErrorCode(t *testing.T, err error, code int) {
+	t.Helper()
+
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok, "expected AppError, got %v", err)
+	assert.Equal(t, code, appErr.Code)
+}
+
+func TestBookingService_RescheduleBooking(t *testing.T) {
+	svc := setupTestBookingService(t)
+	ctx := context.Background()
+	require.NoError(t, svc.db.AutoMigrate(&models.WalletTransaction{}))
+
+	user, hotel, room, timeSlot := createTestBookingData(t, svc.db)
+	// 按酒店当地时间构造入住时间，与服务层计算出的时段保持一致
+	base := time.Now().In(hotelLocation(hotel)).Add(48 * time.Hour).Truncate(time.Minute)
+
+	t.Run("改期成功且核销码开锁码不变", func(t *testing.T) {
+		booking := createPaidBooking(t, svc.db, user, room, base, timeSlot.Price)
+
+		// 与自身原时段重叠不视为冲突
+		newCheckIn := base.Add(time.Hour)
+		info, err := svc.RescheduleBooking(ctx, booking.ID, user.ID, newCheckIn)
+		require.NoError(t, err)
+		assert.True(t, info.CheckInTime.Equal(newCheckIn))
+		assert.True(t, info.CheckOutTime.Equal(newCheckIn.Add(2*time.Hour)))
+
+		var updated models.Booking
+		require.NoError(t, svc.db.First(&updated, booking.ID).Error)
+		assert.True(t, updated.CheckInTime.Equal(newCheckIn))
+		assert.Equal(t, booking.VerificationCode, updated.VerificationCode)
+		assert.Equal(t, booking.UnlockCode, updated.UnlockCode)
+		assert.Equal(t, booking.QRCode, updated.QRCode)
+		assert.Equal(t, models.BookingStatusPaid, updated.Status)
+
+		var refundCount int64
+		svc.db.Model(&models.Refund{}).Where("order_id = ?", booking.OrderID).Count(&refundCount)
+		assert.Equal(t, int64(0), refundCount)
+	})
+
+	t.Run("与其他预订时段冲突", func(t *testing.T) {
+		other := createPaidBooking(t, svc.db, user, room, base.Add(24*time.Hour), timeSlot.Price)
+		booking := createPaidBooking(t, svc.db, user, room, base.Add(30*time.Hour), timeSlot.Price)
+
+		_, err := svc.RescheduleBooking(ctx, booking.ID, user.ID, other.CheckInTime.Add(time.Hour))
+		assertRescheduleErrorCode(t, err, appErrors.ErrBookingConflict.Code)
+
+		var unchanged models.Booking
+		require.NoError(t, svc.db.First(&unchanged, booking.ID).Error)
+		assert.True(t, unchanged.CheckInTime.Equal(booking.CheckInTime))
+	})
+
+	t.Run("待支付预订价格变化时更新订单金额", func(t *testing.T) {
+		booking := createPaidBooking(t, svc.db, user, room, base.Add(72*time.Hour), 80)
+		require.NoError(t, svc.db.Model(booking).Update("status", models.BookingStatusPending).Error)
+
+		info, err := svc.RescheduleBooking(ctx, booking.ID, user.ID, booking.CheckInTime.Add(2*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, timeSlot.Price, info.Amount)
+
+		var order models.Order
+		require.NoError(t, svc.db.First(&order, booking.OrderID).Error)
+		assert.Equal(t, timeSlot.Price, order.OriginalAmount)
+		assert.Equal(t, timeSlot.Price, order.ActualAmount)
+	})
+
+	t.Run("已支付预订价格上涨未配置钱包时不允许改期", func(t *testing.T) {
+		booking := createPaidBooking(t, svc.db, user, room, base.Add(96*time.Hour), 80)
+
+		_, err := svc.RescheduleBooking(ctx, booking.ID, user.ID, booking.CheckInTime.Add(time.Hour))
+		assertRescheduleErrorCode(t, err, appErrors.ErrOperationFailed.Code)
+	})
+
+	t.Run("已支付预订价格上涨从余额补差价", func(t *testing.T) {
+		svc.SetWalletService(userService.NewWalletService(svc.db, repository.NewUserRepository(svc.db), nil))
+		defer svc.SetWalletService(nil)
+
+		booking := createPaidBooking(t, svc.db, user, room, base.Add(120*time.Hour), 80)
+
+		var before models.UserWallet
+		require.NoError(t, svc.db.Where("user_id = ?", user.ID).First(&before).Error)
+
+		info, err := svc.RescheduleBooking(ctx, booking.ID, user.ID, booking.CheckInTime.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, timeSlot.Price, info.Amount)
+
+		var after models.UserWallet
+		require.NoError(t, svc.db.Where("user_id = ?", user.ID).First(&after).Error)
+		assert.InDelta(t, before.Balance-20, after.Balance, 0.001)
+
+		var order models.Order
+		require.NoError(t, svc.db.First(&order, booking.OrderID).Error)
+		assert.Equal(t, timeSlot.Price, order.ActualAmount)
+	})
+
+	t.Run("已支付预订余额不足时改期失败", func(t *testing.T) {
+		svc.SetWalletService(userService.NewWalletService(svc.db, repository.NewUserRepository(svc.db), nil))
+		defer svc.SetWalletService(nil)
+		require.NoError(t, svc.db.Model(&models.UserWallet{}).Where("user_id = ?", user.ID).Update("balance", 0).Error)
+
+		booking := createPaidBooking(t, svc.db, user, room, base.Add(144*time.Hour), 80)
+
+		_, err := svc.RescheduleBooking(ctx, booking.ID, user.ID, booking.CheckInTime.Add(time.Hour))
+		assertRescheduleErrorCode(t, err, appErrors.ErrBalanceInsufficient.Code)
+
+		// 事务回滚，入住时间保持不变
+		var unchanged models.Booking
+		require.NoError(t, svc.db.First(&unchanged, booking.ID).Error)
+		assert.True(t, unchanged.CheckInTime.Equal(booking.CheckInTime))
+		assert.Equal(t, 80.0, unchanged.Amount)
+	})
+
+	t.Run("已支付预订价格下降时创建差价退款", func(t *testing.T) {
+		booking := createPaidBooking(t, svc.db, user, room, base.Add(168*time.Hour), 130)
+
+		info, err := svc.RescheduleBooking(ctx, booking.ID, user.ID, booking.CheckInTime.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, timeSlot.Price, info.Amount)
+
+		var refund models.Refund
+		require.NoError(t, svc.db.Where("order_id = ?", booking.OrderID).First(&refund).Error)
+		assert.Equal(t, 30.0, refund.Amount)
+		assert.Equal(t, int8(models.RefundStatusPending), refund.Status)
+		assert.Equal(t, rescheduleRefundReason, refund.Reason)
+	})
+
+	t.Run("非本人预订", func(t *testing.T) {
+		booking := createPaidBooking(t, svc.db, user, room, base.Add(192*time.Hour), timeSlot.Price)
+
+		_, err := svc.RescheduleBooking(ctx, booking.ID, user.ID+1000, booking.CheckInTime.Add(time.Hour))
+		assertRescheduleErrorCode(t, err, appErrors.ErrPermissionDenied.Code)
+	})
+
+	t.Run("已核销预订不可改期", func(t *testing.T) {
+		booking := createPaidBooking(t, svc.db, user, room, base.Add(216*time.Hour), timeSlot.Price)
+		require.NoError(t, svc.db.Model(booking).Update("status", models.BookingStatusVerified).Error)
+
+		_, err := svc.RescheduleBooking(ctx, booking.ID, user.ID, booking.CheckInTime.Add(time.Hour))
+		assertRescheduleErrorCode(t, err, appErrors.ErrBookingStatusError.Code)
+	})
+
+	t.Run("新入住时间不能是过去", func(t *testing.T) {
+		booking := createPaidBooking(t, svc.db, user, room, base.Add(240*time.Hour), timeSlot.Price)
+
+		_, err := svc.RescheduleBooking(ctx, booking.ID, user.ID, time.Now().Add(-24*time.Hour))
+		assertRescheduleErrorCode(t, err, appErrors.ErrInvalidParams.Code)
+	})
+
+	t.Run("预订不存在", func(t *testing.T) {
+		_, err := svc.RescheduleBooking(ctx, 99999, user.ID, base)
+		assertRescheduleErrorCode(t, err, appErrors.ErrBookingNotFound.Code)
+	})
+}
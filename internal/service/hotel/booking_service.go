@@ -4,6 +4,7 @@ package hotel
 import (
 	"context"
 	"fmt"
+	"math"
 	"time"
 
 	"gorm.io/gorm"
@@ -13,19 +14,30 @@ import (
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 	deviceService "github.com/dumeirei/smart-locker-backend/internal/service/device"
+	eventService "github.com/dumeirei/smart-locker-backend/internal/service/event"
+	orderService "github.com/dumeirei/smart-locker-backend/internal/service/order"
+	userService "github.com/dumeirei/smart-locker-backend/internal/service/user"
 )
 
 // BookingService 预订服务
 type BookingService struct {
-	db               *gorm.DB
-	bookingRepo      *repository.BookingRepository
-	roomRepo         *repository.RoomRepository
-	hotelRepo        *repository.HotelRepository
-	orderRepo        *repository.OrderRepository
-	timeSlotRepo     *repository.RoomTimeSlotRepository
-	codeService      *CodeService
-	deviceService    *deviceService.DeviceService
-	mqttService      *deviceService.MQTTService
+	db            *gorm.DB
+	bookingRepo   *repository.BookingRepository
+	roomRepo      *repository.RoomRepository
+	hotelRepo     *repository.HotelRepository
+	orderRepo     *repository.OrderRepository
+	timeSlotRepo  *repository.RoomTimeSlotRepository
+	occupancyRepo *repository.RoomOccupancyRepository
+	codeService   *CodeService
+	deviceService *deviceService.DeviceService
+	commandClient deviceService.DeviceCommandClient
+	unlockGuard   *UnlockAttemptGuard
+	walletService *userService.WalletService
+	orderEvents   orderService.OrderEventHandler
+	pricing       *PricingResolver
+	statusEvents  eventService.Publisher
+	pointsService *userService.PointsService
+	orderNotes    *orderService.OrderNoteService
 }
 
 // NewBookingService 创建预订服务
@@ -38,7 +50,7 @@ func NewBookingService(
 	timeSlotRepo *repository.RoomTimeSlotRepository,
 	codeService *CodeService,
 	deviceSvc *deviceService.DeviceService,
-	mqttSvc *deviceService.MQTTService,
+	commandClient deviceService.DeviceCommandClient,
 ) *BookingService {
 	return &BookingService{
 		db:            db,
@@ -47,39 +59,87 @@ func NewBookingService(
 		hotelRepo:     hotelRepo,
 		orderRepo:     orderRepo,
 		timeSlotRepo:  timeSlotRepo,
+		occupancyRepo: repository.NewRoomOccupancyRepository(db),
 		codeService:   codeService,
 		deviceService: deviceSvc,
-		mqttService:   mqttSvc,
+		commandClient: commandClient,
 	}
 }
 
+// SetUnlockAttemptGuard 设置开锁码尝试限制，未设置时不限制开锁码尝试次数
+func (s *BookingService) SetUnlockAttemptGuard(guard *UnlockAttemptGuard) {
+	s.unlockGuard = guard
+}
+
+// SetOrderEventHandler 设置订单事件处理器，预订完成后触发（如发放消费积分）
+func (s *BookingService) SetOrderEventHandler(handler orderService.OrderEventHandler) {
+	s.orderEvents = handler
+}
+
+// SetPricingResolver 设置时段价格解析器，未设置时按时段原价收费
+func (s *BookingService) SetPricingResolver(resolver *PricingResolver) {
+	s.pricing = resolver
+}
+
+// SetStatusEventPublisher 设置预订状态变更事件发布者，开锁后推送给客户端，未设置时不发布
+func (s *BookingService) SetStatusEventPublisher(publisher eventService.Publisher) {
+	s.statusEvents = publisher
+}
+
+// SetPointsService 设置积分服务，用于下单时积分抵扣及取消订单时返还抵扣积分
+func (s *BookingService) SetPointsService(svc *userService.PointsService) {
+	s.pointsService = svc
+}
+
+// SetOrderNoteService 设置订单备注服务，预订详情附带最新一条用户可见的备注
+func (s *BookingService) SetOrderNoteService(orderNotes *orderService.OrderNoteService) {
+	s.orderNotes = orderNotes
+}
+
+// publishBookingStatus 发布预订状态变更事件，发布失败不影响业务流程
+func (s *BookingService) publishBookingStatus(ctx context.Context, bookingID, userID int64, oldStatus, newStatus string) {
+	if s.statusEvents == nil || oldStatus == newStatus {
+		return
+	}
+	_ = s.statusEvents.Publish(ctx, &eventService.StatusEvent{
+		Subject:   eventService.SubjectBooking,
+		ID:        bookingID,
+		UserID:    userID,
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+		Timestamp: time.Now(),
+	})
+}
+
 // CreateBookingRequest 创建预订请求
 type CreateBookingRequest struct {
 	RoomID        int64     `json:"room_id" binding:"required"`
 	DurationHours int       `json:"duration_hours" binding:"required,min=1"`
 	CheckInTime   time.Time `json:"check_in_time" binding:"required"`
+	UsePoints     int       `json:"use_points" binding:"omitempty,min=0"` // 使用积分抵扣房费
 }
 
 // BookingInfo 预订信息
 type BookingInfo struct {
-	ID               int64      `json:"id"`
-	BookingNo        string     `json:"booking_no"`
-	OrderNo          string     `json:"order_no,omitempty"`
-	Status           string     `json:"status"`
-	StatusName       string     `json:"status_name"`
-	Hotel            *HotelInfo `json:"hotel,omitempty"`
-	Room             *RoomInfo  `json:"room,omitempty"`
-	CheckInTime      time.Time  `json:"check_in_time"`
-	CheckOutTime     time.Time  `json:"check_out_time"`
-	DurationHours    int        `json:"duration_hours"`
-	Amount           float64    `json:"amount"`
-	VerificationCode string     `json:"verification_code,omitempty"`
-	UnlockCode       string     `json:"unlock_code,omitempty"`
-	QRCode           string     `json:"qr_code,omitempty"`
-	VerifiedAt       *time.Time `json:"verified_at,omitempty"`
-	UnlockedAt       *time.Time `json:"unlocked_at,omitempty"`
-	CompletedAt      *time.Time `json:"completed_at,omitempty"`
-	CreatedAt        time.Time  `json:"created_at"`
+	ID               int64                       `json:"id"`
+	BookingNo        string                      `json:"booking_no"`
+	OrderNo          string                      `json:"order_no,omitempty"`
+	Status           string                      `json:"status"`
+	StatusName       string                      `json:"status_name"`
+	Hotel            *HotelInfo                  `json:"hotel,omitempty"`
+	Room             *RoomInfo                   `json:"room,omitempty"`
+	CheckInTime      time.Time                   `json:"check_in_time"`
+	CheckOutTime     time.Time                   `json:"check_out_time"`
+	DurationHours    int                         `json:"duration_hours"`
+	Amount           float64                     `json:"amount"`
+	VerificationCode string                      `json:"verification_code,omitempty"`
+	UnlockCode       string                      `json:"unlock_code,omitempty"`
+	QRCode           string                      `json:"qr_code,omitempty"`
+	VerifiedAt       *time.Time                  `json:"verified_at,omitempty"`
+	UnlockedAt       *time.Time                  `json:"unlocked_at,omitempty"`
+	CompletedAt      *time.Time                  `json:"completed_at,omitempty"`
+	CreatedAt        time.Time                   `json:"created_at"`
+	LatestNote       *orderService.OrderNoteInfo `json:"latest_note,omitempty"` // 仅预订详情返回
 }
 
 // CreateBooking 创建预订
@@ -116,13 +176,29 @@ func (s *BookingService) CreateBooking(ctx context.Context, userID int64, req *C
 		return nil, errors.ErrTimeSlotDisabled
 	}
 
-	// 3. 计算入住和退房时间
-	checkInTime := req.CheckInTime
-	checkOutTime := checkInTime.Add(time.Duration(req.DurationHours) * time.Hour)
+	// 3. 按酒店当地时间校验入住时间并计算退房时间
+	checkInTime, checkOutTime, err := resolveStayPeriod(room.Hotel, req.CheckInTime, req.DurationHours, time.Now())
+	if err != nil {
+		return nil, err
+	}
 
-	// 验证入住时间不能是过去
-	if checkInTime.Before(time.Now().Add(-5 * time.Minute)) { // 允许5分钟的误差
-		return nil, errors.ErrInvalidParams.WithMessage("入住时间不能是过去")
+	// 按入住日期锁定价格，跨零点入住不因次日价格变化而改变
+	price, err := s.slotPrice(ctx, timeSlot, checkInTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// 积分抵扣房费，积分在创建订单的事务中扣减
+	amount := price
+	if req.UsePoints > 0 {
+		if s.pointsService == nil {
+			return nil, errors.ErrOperationFailed.WithMessage("暂不支持积分抵扣")
+		}
+		pointsDiscount := userService.CalculateRedeemDiscount(req.UsePoints)
+		if pointsDiscount > price {
+			return nil, errors.ErrInvalidParams.WithMessage("抵扣金额不能超过订单应付金额")
+		}
+		amount = math.Round((price-pointsDiscount)*100) / 100
 	}
 
 	// 4. 检查房间可用性（时段冲突）
@@ -137,6 +213,9 @@ func (s *BookingService) CreateBooking(ctx context.Context, userID int64, req *C
 	// 5. 生成核销码和开锁码
 	verificationCode := s.codeService.GenerateVerificationCode()
 	unlockCode := s.codeService.GenerateUnlockCode()
+	if room.Hotel.LongUnlockCode {
+		unlockCode = s.codeService.GenerateLongUnlockCode()
+	}
 	bookingNo := utils.GenerateOrderNo("B")
 	qrCode := s.codeService.GenerateQRCodeURL(bookingNo, verificationCode)
 
@@ -147,13 +226,18 @@ func (s *BookingService) CreateBooking(ctx context.Context, userID int64, req *C
 	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 创建订单
 		orderNo := utils.GenerateOrderNo("O")
+		if req.UsePoints > 0 {
+			if _, err := s.pointsService.RedeemPointsTx(ctx, tx, userID, req.UsePoints, orderNo); err != nil {
+				return err
+			}
+		}
 		order = &models.Order{
 			OrderNo:        orderNo,
 			UserID:         userID,
 			Type:           models.OrderTypeHotel,
-			OriginalAmount: timeSlot.Price,
-			DiscountAmount: 0,
-			ActualAmount:   timeSlot.Price,
+			OriginalAmount: price,
+			DiscountAmount: math.Round((price-amount)*100) / 100,
+			ActualAmount:   amount,
 			DepositAmount:  0,
 			Status:         models.OrderStatusPending,
 		}
@@ -172,7 +256,7 @@ func (s *BookingService) CreateBooking(ctx context.Context, userID int64, req *C
 			CheckInTime:      checkInTime,
 			CheckOutTime:     checkOutTime,
 			DurationHours:    req.DurationHours,
-			Amount:           timeSlot.Price,
+			Amount:           amount,
 			VerificationCode: verificationCode,
 			UnlockCode:       unlockCode,
 			QRCode:           qrCode,
@@ -186,6 +270,9 @@ func (s *BookingService) CreateBooking(ctx context.Context, userID int64, req *C
 	})
 
 	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return nil, appErr
+		}
 		return nil, errors.ErrDatabaseError.WithError(err)
 	}
 
@@ -197,6 +284,19 @@ func (s *BookingService) CreateBooking(ctx context.Context, userID int64, req *C
 	return s.convertBookingInfo(booking, true), nil
 }
 
+// slotPrice 获取时段在入住日期（酒店当地时间）的价格
+func (s *BookingService) slotPrice(ctx context.Context, slot *models.RoomTimeSlot, checkInTime time.Time) (float64, error) {
+	if s.pricing == nil {
+		return slot.Price, nil
+	}
+	return s.pricing.Resolve(ctx, slot, checkInTime)
+}
+
+// RoomLocation 获取房间所属酒店的时区，用于按酒店当地时间解析入住时间
+func (s *BookingService) RoomLocation(ctx context.Context, roomID int64) (*time.Location, error) {
+	return roomLocation(ctx, s.roomRepo, roomID)
+}
+
 // GetBookingByID 根据ID获取预订
 func (s *BookingService) GetBookingByID(ctx context.Context, id int64, userID int64) (*BookingInfo, error) {
 	booking, err := s.bookingRepo.GetByIDWithDetails(ctx, id)
@@ -217,7 +317,7 @@ func (s *BookingService) GetBookingByID(ctx context.Context, id int64, userID in
 		booking.Status == models.BookingStatusVerified ||
 		booking.Status == models.BookingStatusInUse
 
-	return s.convertBookingInfo(booking, showCodes), nil
+	return s.withLatestNote(ctx, s.convertBookingInfo(booking, showCodes), booking.OrderID)
 }
 
 // GetBookingByNo 根据预订号获取预订
@@ -239,7 +339,20 @@ func (s *BookingService) GetBookingByNo(ctx context.Context, bookingNo string, u
 		booking.Status == models.BookingStatusVerified ||
 		booking.Status == models.BookingStatusInUse
 
-	return s.convertBookingInfo(booking, showCodes), nil
+	return s.withLatestNote(ctx, s.convertBookingInfo(booking, showCodes), booking.OrderID)
+}
+
+// withLatestNote 为预订详情附带订单最新一条用户可见的备注
+func (s *BookingService) withLatestNote(ctx context.Context, info *BookingInfo, orderID int64) (*BookingInfo, error) {
+	if s.orderNotes == nil {
+		return info, nil
+	}
+	note, err := s.orderNotes.GetLatestUserNote(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	info.LatestNote = note
+	return info, nil
 }
 
 // GetUserBookings 获取用户预订列表
@@ -288,7 +401,40 @@ func (s *BookingService) CancelBooking(ctx context.Context, id int64, userID int
 		return errors.ErrBookingStatusError.WithMessage("只有待支付的预订可以取消")
 	}
 
-	return s.bookingRepo.Cancel(ctx, id)
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Booking{}).
+			Where("id = ? AND status = ?", id, models.BookingStatusPending).
+			Update("status", models.BookingStatusCancelled)
+		if res.Error != nil {
+			return errors.ErrDatabaseError.WithError(res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return errors.ErrBookingStatusError.WithMessage("只有待支付的预订可以取消")
+		}
+		return s.returnRedeemedPointsTx(ctx, tx, booking.UserID, booking.OrderID)
+	})
+	return err
+}
+
+// ReleaseExpiredOrderTx 超时未支付的预订订单被取消时，在同一事务中返还下单时抵扣的积分
+// 待支付预订由订单超时任务取消，此处不重复处理
+func (s *BookingService) ReleaseExpiredOrderTx(ctx context.Context, tx *gorm.DB, order *models.Order) (func(), error) {
+	if s.pointsService == nil {
+		return nil, nil
+	}
+	return nil, s.pointsService.ReturnRedeemedPointsTx(ctx, tx, order.UserID, order.OrderNo)
+}
+
+// returnRedeemedPointsTx 在事务中返还预订订单抵扣的积分
+func (s *BookingService) returnRedeemedPointsTx(ctx context.Context, tx *gorm.DB, userID, orderID int64) error {
+	if s.pointsService == nil {
+		return nil
+	}
+	var order models.Order
+	if err := tx.Select("id, order_no").First(&order, orderID).Error; err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	return s.pointsService.ReturnRedeemedPointsTx(ctx, tx, userID, order.OrderNo)
 }
 
 // VerifyBooking 核销预订（酒店前台调用）
@@ -321,7 +467,7 @@ func (s *BookingService) VerifyBooking(ctx context.Context, verificationCode str
 	// 检查是否过期（超过入住时间一定时间后不能核销）
 	if time.Now().After(booking.CheckOutTime) {
 		// 自动标记为过期
-		_ = s.bookingRepo.MarkExpired(ctx, booking.ID)
+		_ = s.updateOccupyingStatus(ctx, booking, models.BookingStatusExpired)
 		return nil, errors.ErrBookingExpired
 	}
 
@@ -337,17 +483,25 @@ func (s *BookingService) VerifyBooking(ctx context.Context, verificationCode str
 }
 
 // UnlockByCode 使用开锁码开锁
-func (s *BookingService) UnlockByCode(ctx context.Context, deviceID int64, unlockCode string) (*BookingInfo, error) {
+// source 为请求来源（用户及 IP），用于记录开锁尝试日志；
+// 设置了开锁码尝试限制时，同一设备开锁码错误次数过多将被锁定，锁定期间返回 ErrUnlockLockedOut
+func (s *BookingService) UnlockByCode(ctx context.Context, deviceID int64, unlockCode string, source string) (*BookingInfo, error) {
+	if s.unlockGuard != nil {
+		if err := s.unlockGuard.Check(ctx, deviceID, source); err != nil {
+			return nil, err
+		}
+	}
+
 	// 验证开锁码格式
 	if !s.codeService.ValidateUnlockCode(unlockCode) {
-		return nil, errors.ErrUnlockCodeInvalid
+		return nil, s.unlockCodeFailed(ctx, deviceID, source)
 	}
 
 	// 根据开锁码和设备ID查找预订
 	booking, err := s.bookingRepo.GetByUnlockCode(ctx, unlockCode, deviceID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, errors.ErrUnlockCodeInvalid
+			return nil, s.unlockCodeFailed(ctx, deviceID, source)
 		}
 		return nil, errors.ErrDatabaseError.WithError(err)
 	}
@@ -373,23 +527,46 @@ func (s *BookingService) UnlockByCode(ctx context.Context, deviceID int64, unloc
 		}
 	}
 
-	// 发送开锁命令
-	if s.mqttService != nil && s.deviceService != nil && booking.DeviceID != nil {
-		// 获取设备信息
+	// 获取设备编号
+	var deviceNo string
+	if s.commandClient != nil && s.deviceService != nil && booking.DeviceID != nil {
 		device, err := s.deviceService.GetDeviceByID(ctx, *booking.DeviceID)
 		if err != nil {
 			return nil, errors.ErrUnlockFailed.WithError(err)
 		}
-		// 发送开锁命令
-		if _, err := s.mqttService.SendUnlockCommand(ctx, device.DeviceNo, nil); err != nil {
-			return nil, errors.ErrUnlockFailed.WithError(err)
+		deviceNo = device.DeviceNo
+	}
+
+	// 先更新预订状态再下发开锁指令，指令下发失败时回滚状态
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Booking{}).
+			Where("id = ? AND status = ?", booking.ID, models.BookingStatusVerified).
+			Updates(map[string]interface{}{
+				"status":      models.BookingStatusInUse,
+				"unlocked_at": time.Now(),
+			})
+		if res.Error != nil {
+			return errors.ErrDatabaseError.WithError(res.Error)
 		}
+		if res.RowsAffected == 0 {
+			return errors.ErrBookingStatusError.WithMessage("已开锁")
+		}
+
+		if deviceNo != "" {
+			if err := s.commandClient.SendUnlockCommand(ctx, deviceNo); err != nil {
+				return errors.ErrUnlockFailed.WithError(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// 更新预订状态
-	if err := s.bookingRepo.Unlock(ctx, booking.ID); err != nil {
-		return nil, errors.ErrDatabaseError.WithError(err)
+	if s.unlockGuard != nil {
+		s.unlockGuard.Succeed(ctx, deviceID, source)
 	}
+	s.publishBookingStatus(ctx, booking.ID, booking.UserID, models.BookingStatusVerified, models.BookingStatusInUse)
 
 	// 获取更新后的预订
 	booking, _ = s.bookingRepo.GetByIDWithDetails(ctx, booking.ID)
@@ -397,6 +574,16 @@ func (s *BookingService) UnlockByCode(ctx context.Context, deviceID int64, unloc
 	return s.convertBookingInfo(booking, true), nil
 }
 
+// unlockCodeFailed 记录一次开锁码错误，返回 ErrUnlockCodeInvalid，达到上限时返回 ErrUnlockLockedOut
+func (s *BookingService) unlockCodeFailed(ctx context.Context, deviceID int64, source string) error {
+	if s.unlockGuard != nil {
+		if err := s.unlockGuard.Fail(ctx, deviceID, source); err != nil {
+			return err
+		}
+	}
+	return errors.ErrUnlockCodeInvalid
+}
+
 // CompleteBooking 完成预订
 func (s *BookingService) CompleteBooking(ctx context.Context, id int64) error {
 	booking, err := s.bookingRepo.GetByID(ctx, id)
@@ -412,7 +599,53 @@ func (s *BookingService) CompleteBooking(ctx context.Context, id int64) error {
 		return errors.ErrBookingStatusError
 	}
 
-	return s.bookingRepo.Complete(ctx, id)
+	return s.completeBooking(ctx, booking)
+}
+
+// completeBooking 完成预订并同步完成预订订单，完成后触发订单完成事件
+func (s *BookingService) completeBooking(ctx context.Context, booking *models.Booking) error {
+	now := time.Now()
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 条件更新防止重复完成
+		res := tx.Model(&models.Booking{}).
+			Where("id = ? AND status IN ?", booking.ID, []string{models.BookingStatusVerified, models.BookingStatusInUse}).
+			Updates(map[string]interface{}{
+				"status":       models.BookingStatusCompleted,
+				"completed_at": now,
+			})
+		if res.Error != nil {
+			return errors.ErrDatabaseError.WithError(res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return errors.ErrBookingStatusError
+		}
+
+		if err := tx.Model(&models.Order{}).Where("id = ?", booking.OrderID).
+			Updates(map[string]interface{}{
+				"status":       models.OrderStatusCompleted,
+				"completed_at": now,
+			}).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		// 提前退房时释放剩余时段
+		if err := s.occupancyRepo.RefreshTx(ctx, tx, booking.RoomID, booking.CheckInTime, booking.CheckOutTime); err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 订单完成事件处理失败不影响预订完成
+	if s.orderEvents != nil {
+		var order models.Order
+		if err := s.db.WithContext(ctx).First(&order, booking.OrderID).Error; err == nil {
+			_ = s.orderEvents.OnOrderCompleted(ctx, &order)
+		}
+	}
+	return nil
 }
 
 // OnPaymentSuccess 支付成功回调
@@ -426,7 +659,18 @@ func (s *BookingService) OnPaymentSuccess(ctx context.Context, orderID int64) er
 		return nil // 已经处理过
 	}
 
-	return s.bookingRepo.UpdateStatus(ctx, booking.ID, models.BookingStatusPaid)
+	return s.updateOccupyingStatus(ctx, booking, models.BookingStatusPaid)
+}
+
+// updateOccupyingStatus 更新预订状态并在同一事务中刷新房间占用位图
+// 用于预订进入或离开占用房间的状态（支付成功、过期）
+func (s *BookingService) updateOccupyingStatus(ctx context.Context, booking *models.Booking, status string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Booking{}).Where("id = ?", booking.ID).Update("status", status).Error; err != nil {
+			return err
+		}
+		return s.occupancyRepo.RefreshTx(ctx, tx, booking.RoomID, booking.CheckInTime, booking.CheckOutTime)
+	})
 }
 
 // ProcessExpiredBookings 处理过期预订（定时任务调用）
@@ -438,7 +682,7 @@ func (s *BookingService) ProcessExpiredBookings(ctx context.Context) error {
 	}
 
 	for _, booking := range bookings {
-		if err := s.bookingRepo.MarkExpired(ctx, booking.ID); err != nil {
+		if err := s.updateOccupyingStatus(ctx, booking, models.BookingStatusExpired); err != nil {
 			// 记录日志但继续处理
 			fmt.Printf("标记预订过期失败: booking_id=%d, err=%v\n", booking.ID, err)
 		}
@@ -456,7 +700,7 @@ func (s *BookingService) ProcessCompletedBookings(ctx context.Context) error {
 	}
 
 	for _, booking := range bookings {
-		if err := s.bookingRepo.Complete(ctx, booking.ID); err != nil {
+		if err := s.completeBooking(ctx, booking); err != nil {
 			fmt.Printf("自动完成预订失败: booking_id=%d, err=%v\n", booking.ID, err)
 		}
 	}
@@ -523,6 +767,8 @@ func (s *BookingService) getStatusName(status string) string {
 		return "已完成"
 	case models.BookingStatusCancelled:
 		return "已取消"
+	case models.BookingStatusRefunding:
+		return "退款中"
 	case models.BookingStatusRefunded:
 		return "已退款"
 	case models.BookingStatusExpired:
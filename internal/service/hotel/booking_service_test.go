@@ -15,10 +15,12 @@ import (
 	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	orderService "github.com/dumeirei/smart-locker-backend/internal/service/order"
+	userService "github.com/dumeirei/smart-locker-backend/internal/service/user"
 )
 
 // setupTestDB 创建测试数据库
-func setupTestDB(t *testing.T) *gorm.DB {
+func setupTestDB(t testing.TB) *gorm.DB {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
@@ -37,8 +39,12 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		&models.Hotel{},
 		&models.Room{},
 		&models.RoomTimeSlot{},
+		&models.RoomPriceOverride{},
 		&models.Booking{},
+		&models.RoomOccupancy{},
 		&models.Device{},
+		&models.Payment{},
+		&models.Refund{},
 	)
 	require.NoError(t, err)
 
@@ -338,6 +344,90 @@ func TestBookingService_CreateBooking_RoomConflict(t *testing.T) {
 	})
 }
 
+func TestBookingService_CreateBooking_HotelTimezone(t *testing.T) {
+	svc := setupTestBookingService(t)
+	ctx := context.Background()
+
+	user, hotel, room, _ := createTestBookingData(t, svc.db)
+
+	// 酒店位于纽约，与服务器时区不同
+	require.NoError(t, svc.db.Model(hotel).Update("timezone", "America/New_York").Error)
+	require.NoError(t, svc.db.Create(&models.RoomTimeSlot{
+		RoomID:        room.ID,
+		DurationHours: 24,
+		Price:         288.0,
+		IsActive:      true,
+		Sort:          2,
+	}).Error)
+
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	nowLocal := time.Now().In(loc)
+
+	t.Run("恰好在酒店入住时间入住", func(t *testing.T) {
+		checkIn := time.Date(nowLocal.Year(), nowLocal.Month(), nowLocal.Day()+2, 14, 0, 0, 0, loc)
+
+		bookingInfo, err := svc.CreateBooking(ctx, user.ID, &CreateBookingRequest{
+			RoomID:        room.ID,
+			DurationHours: 24,
+			CheckInTime:   checkIn,
+		})
+		require.NoError(t, err)
+		assert.True(t, bookingInfo.CheckInTime.Equal(checkIn))
+
+		// 退房时间对齐到次日酒店退房时间
+		expectedCheckOut := time.Date(nowLocal.Year(), nowLocal.Month(), nowLocal.Day()+3, 12, 0, 0, 0, loc)
+		assert.True(t, bookingInfo.CheckOutTime.Equal(expectedCheckOut))
+	})
+
+	t.Run("早于酒店入住时间入住失败", func(t *testing.T) {
+		// 纽约当地 13:00，对应服务器时区可能已是下午之后
+		checkIn := time.Date(nowLocal.Year(), nowLocal.Month(), nowLocal.Day()+5, 13, 0, 0, 0, loc)
+
+		_, err := svc.CreateBooking(ctx, user.ID, &CreateBookingRequest{
+			RoomID:        room.ID,
+			DurationHours: 24,
+			CheckInTime:   checkIn.UTC(),
+		})
+		require.Error(t, err)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrInvalidParams.Code, appErr.Code)
+	})
+
+	t.Run("按小时预订不受入住时刻限制", func(t *testing.T) {
+		checkIn := time.Date(nowLocal.Year(), nowLocal.Month(), nowLocal.Day()+7, 9, 0, 0, 0, loc)
+
+		bookingInfo, err := svc.CreateBooking(ctx, user.ID, &CreateBookingRequest{
+			RoomID:        room.ID,
+			DurationHours: 2,
+			CheckInTime:   checkIn,
+		})
+		require.NoError(t, err)
+		assert.True(t, bookingInfo.CheckOutTime.Equal(checkIn.Add(2*time.Hour)))
+	})
+
+	t.Run("按酒店当地时间判断过去时间", func(t *testing.T) {
+		checkIn := nowLocal.Add(-1 * time.Hour)
+
+		_, err := svc.CreateBooking(ctx, user.ID, &CreateBookingRequest{
+			RoomID:        room.ID,
+			DurationHours: 2,
+			CheckInTime:   checkIn,
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("获取房间所属酒店时区", func(t *testing.T) {
+		roomLoc, err := svc.RoomLocation(ctx, room.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "America/New_York", roomLoc.String())
+
+		_, err = svc.RoomLocation(ctx, 999999)
+		assert.ErrorIs(t, err, appErrors.ErrRoomNotFound)
+	})
+}
+
 func TestBookingService_GetBookingByID(t *testing.T) {
 	svc := setupTestBookingService(t)
 	ctx := context.Background()
@@ -396,6 +486,58 @@ func TestBookingService_GetBookingByID(t *testing.T) {
 	})
 }
 
+func TestBookingService_GetBooking_LatestNote(t *testing.T) {
+	svc := setupTestBookingService(t)
+	ctx := context.Background()
+	require.NoError(t, svc.db.AutoMigrate(&models.OrderNote{}))
+	noteSvc := orderService.NewOrderNoteService(svc.db, repository.NewOrderNoteRepository(svc.db))
+	svc.SetOrderNoteService(noteSvc)
+
+	user, hotel, room, _ := createTestBookingData(t, svc.db)
+	order := &models.Order{
+		OrderNo:        "TESTNOTE001",
+		UserID:         user.ID,
+		Type:           models.OrderTypeHotel,
+		OriginalAmount: 100.0,
+		ActualAmount:   100.0,
+		Status:         models.OrderStatusPaid,
+	}
+	require.NoError(t, svc.db.Create(order).Error)
+
+	checkInTime := time.Now().Add(1 * time.Hour)
+	booking := &models.Booking{
+		BookingNo:        "B202401010099",
+		OrderID:          order.ID,
+		UserID:           user.ID,
+		HotelID:          hotel.ID,
+		RoomID:           room.ID,
+		CheckInTime:      checkInTime,
+		CheckOutTime:     checkInTime.Add(2 * time.Hour),
+		DurationHours:    2,
+		Amount:           100.0,
+		VerificationCode: "V1234567890123456799",
+		UnlockCode:       "654321",
+		Status:           models.BookingStatusPaid,
+	}
+	require.NoError(t, svc.db.Create(booking).Error)
+
+	_, err := noteSvc.AddAdminNote(ctx, 1, order.ID, &orderService.AddAdminOrderNoteRequest{Content: "已电话确认入住时间", VisibleToUser: true})
+	require.NoError(t, err)
+	// 仅管理员可见的备注不返回给用户
+	_, err = noteSvc.AddAdminNote(ctx, 1, order.ID, &orderService.AddAdminOrderNoteRequest{Content: "疑似刷单"})
+	require.NoError(t, err)
+
+	info, err := svc.GetBookingByID(ctx, booking.ID, user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, info.LatestNote)
+	assert.Equal(t, "已电话确认入住时间", info.LatestNote.Content)
+
+	info, err = svc.GetBookingByNo(ctx, booking.BookingNo, user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, info.LatestNote)
+	assert.Equal(t, "已电话确认入住时间", info.LatestNote.Content)
+}
+
 func TestBookingService_GetUserBookings(t *testing.T) {
 	svc := setupTestBookingService(t)
 	ctx := context.Background()
@@ -1141,7 +1283,7 @@ func TestBookingService_UnlockByCode(t *testing.T) {
 	}
 
 	t.Run("开锁码格式不正确", func(t *testing.T) {
-		_, err := svc.UnlockByCode(ctx, deviceID, "bad")
+		_, err := svc.UnlockByCode(ctx, deviceID, "bad", "test")
 		require.Error(t, err)
 		appErr, ok := err.(*appErrors.AppError)
 		require.True(t, ok)
@@ -1149,7 +1291,7 @@ func TestBookingService_UnlockByCode(t *testing.T) {
 	})
 
 	t.Run("找不到对应预订返回开锁码无效", func(t *testing.T) {
-		_, err := svc.UnlockByCode(ctx, deviceID, "123456")
+		_, err := svc.UnlockByCode(ctx, deviceID, "123456", "test")
 		require.Error(t, err)
 		appErr, ok := err.(*appErrors.AppError)
 		require.True(t, ok)
@@ -1161,7 +1303,7 @@ func TestBookingService_UnlockByCode(t *testing.T) {
 		checkOut := time.Now().Add(time.Hour)
 		createBooking(t, models.BookingStatusInUse, checkIn, checkOut, "111111")
 
-		_, err := svc.UnlockByCode(ctx, deviceID, "111111")
+		_, err := svc.UnlockByCode(ctx, deviceID, "111111", "test")
 		require.Error(t, err)
 		appErr, ok := err.(*appErrors.AppError)
 		require.True(t, ok)
@@ -1174,7 +1316,7 @@ func TestBookingService_UnlockByCode(t *testing.T) {
 		checkOut := time.Now().Add(3 * time.Hour)
 		createBooking(t, models.BookingStatusVerified, checkIn, checkOut, "222222")
 
-		_, err := svc.UnlockByCode(ctx, deviceID, "222222")
+		_, err := svc.UnlockByCode(ctx, deviceID, "222222", "test")
 		require.Error(t, err)
 		appErr, ok := err.(*appErrors.AppError)
 		require.True(t, ok)
@@ -1186,7 +1328,7 @@ func TestBookingService_UnlockByCode(t *testing.T) {
 		checkOut := time.Now().Add(-1 * time.Hour)
 		createBooking(t, models.BookingStatusVerified, checkIn, checkOut, "333333")
 
-		_, err := svc.UnlockByCode(ctx, deviceID, "333333")
+		_, err := svc.UnlockByCode(ctx, deviceID, "333333", "test")
 		require.Error(t, err)
 		appErr, ok := err.(*appErrors.AppError)
 		require.True(t, ok)
@@ -1201,7 +1343,7 @@ func TestBookingService_UnlockByCode(t *testing.T) {
 
 		// GetByUnlockCode 只查询 Verified 或 InUse 状态的预订
 		// 所以 Paid 状态会找不到记录，返回开锁码无效
-		_, err := svc.UnlockByCode(ctx, deviceID, "555555")
+		_, err := svc.UnlockByCode(ctx, deviceID, "555555", "test")
 		require.Error(t, err)
 		appErr, ok := err.(*appErrors.AppError)
 		require.True(t, ok)
@@ -1213,7 +1355,7 @@ func TestBookingService_UnlockByCode(t *testing.T) {
 		checkOut := time.Now().Add(time.Hour)
 		booking := createBooking(t, models.BookingStatusVerified, checkIn, checkOut, "444444")
 
-		info, err := svc.UnlockByCode(ctx, deviceID, "444444")
+		info, err := svc.UnlockByCode(ctx, deviceID, "444444", "test")
 		require.NoError(t, err)
 		require.NotNil(t, info)
 		assert.Equal(t, models.BookingStatusInUse, info.Status)
@@ -1366,7 +1508,7 @@ func TestBookingService_UnlockByCode_DBError(t *testing.T) {
 	sqlDB, _ := svc.db.DB()
 	sqlDB.Close()
 
-	_, err := svc.UnlockByCode(ctx, 1, "123456")
+	_, err := svc.UnlockByCode(ctx, 1, "123456", "test")
 	require.Error(t, err)
 }
 
@@ -1447,3 +1589,132 @@ func TestBookingService_GetBookingByID_DBError(t *testing.T) {
 	_, err := svc.GetBookingByID(ctx, 1, 1)
 	require.Error(t, err)
 }
+
+// recordingOrderEvents 记录订单完成事件的测试桩
+type recordingOrderEvents struct {
+	completed []*models.Order
+}
+
+func (r *recordingOrderEvents) OnOrderCompleted(ctx context.Context, order *models.Order) error {
+	r.completed = append(r.completed, order)
+	return nil
+}
+
+func (r *recordingOrderEvents) OnOrderRefunded(ctx context.Context, order *models.Order) error {
+	return nil
+}
+
+func TestBookingService_CompleteBooking_TriggersOrderCompleted(t *testing.T) {
+	svc := setupTestBookingService(t)
+	ctx := context.Background()
+	events := &recordingOrderEvents{}
+	svc.SetOrderEventHandler(events)
+
+	user, _, room, _ := createTestBookingData(t, svc.db)
+
+	t.Run("手动完成预订", func(t *testing.T) {
+		booking := createPaidBooking(t, svc.db, user, room, time.Now().Add(-time.Hour), 100)
+		require.NoError(t, svc.db.Model(booking).Update("status", models.BookingStatusInUse).Error)
+
+		require.NoError(t, svc.CompleteBooking(ctx, booking.ID))
+
+		var order models.Order
+		require.NoError(t, svc.db.First(&order, booking.OrderID).Error)
+		assert.Equal(t, models.OrderStatusCompleted, order.Status)
+		assert.NotNil(t, order.CompletedAt)
+
+		require.Len(t, events.completed, 1)
+		assert.Equal(t, booking.OrderID, events.completed[0].ID)
+		assert.Equal(t, 100.0, events.completed[0].ActualAmount)
+
+		// 重复完成失败且不再触发事件
+		require.Error(t, svc.CompleteBooking(ctx, booking.ID))
+		assert.Len(t, events.completed, 1)
+	})
+
+	t.Run("超过退房时间自动完成", func(t *testing.T) {
+		events.completed = nil
+		booking := createPaidBooking(t, svc.db, user, room, time.Now().Add(-3*time.Hour), 100)
+		require.NoError(t, svc.db.Model(booking).Update("status", models.BookingStatusVerified).Error)
+
+		require.NoError(t, svc.ProcessCompletedBookings(ctx))
+
+		require.Len(t, events.completed, 1)
+		assert.Equal(t, booking.OrderID, events.completed[0].ID)
+	})
+}
+
+func TestBookingService_CreateBooking_UsePoints(t *testing.T) {
+	svc := setupTestBookingService(t)
+	require.NoError(t, svc.db.AutoMigrate(&models.UserPointsLog{}))
+	svc.SetPointsService(userService.NewPointsService(svc.db, repository.NewUserRepository(svc.db), repository.NewMemberLevelRepository(svc.db)))
+	ctx := context.Background()
+
+	user, _, room, _ := createTestBookingData(t, svc.db)
+	require.NoError(t, svc.db.Model(user).Update("points", 3000).Error)
+
+	points := func() int {
+		var refreshed models.User
+		require.NoError(t, svc.db.First(&refreshed, user.ID).Error)
+		return refreshed.Points
+	}
+
+	t.Run("积分抵扣房费，取消预订返还积分", func(t *testing.T) {
+		info, err := svc.CreateBooking(ctx, user.ID, &CreateBookingRequest{
+			RoomID:        room.ID,
+			DurationHours: 2,
+			CheckInTime:   time.Now().Add(1 * time.Hour),
+			UsePoints:     2000,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 80.0, info.Amount)
+		assert.Equal(t, 1000, points())
+
+		var order models.Order
+		require.NoError(t, svc.db.Where("order_no = ?", info.OrderNo).First(&order).Error)
+		assert.Equal(t, 100.0, order.OriginalAmount)
+		assert.Equal(t, 20.0, order.DiscountAmount)
+		assert.Equal(t, 80.0, order.ActualAmount)
+
+		require.NoError(t, svc.CancelBooking(ctx, info.ID, user.ID))
+		assert.Equal(t, 3000, points())
+
+		// 已取消的预订不能重复取消
+		err = svc.CancelBooking(ctx, info.ID, user.ID)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrBookingStatusError.Code, appErr.Code)
+		assert.Equal(t, 3000, points())
+	})
+
+	t.Run("超时未支付取消返还积分", func(t *testing.T) {
+		info, err := svc.CreateBooking(ctx, user.ID, &CreateBookingRequest{
+			RoomID:        room.ID,
+			DurationHours: 2,
+			CheckInTime:   time.Now().Add(4 * time.Hour),
+			UsePoints:     500,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2500, points())
+
+		var order models.Order
+		require.NoError(t, svc.db.Where("order_no = ?", info.OrderNo).First(&order).Error)
+		require.NoError(t, svc.db.Transaction(func(tx *gorm.DB) error {
+			_, err := svc.ReleaseExpiredOrderTx(ctx, tx, &order)
+			return err
+		}))
+		assert.Equal(t, 3000, points())
+	})
+
+	t.Run("抵扣金额超过房费", func(t *testing.T) {
+		_, err := svc.CreateBooking(ctx, user.ID, &CreateBookingRequest{
+			RoomID:        room.ID,
+			DurationHours: 2,
+			CheckInTime:   time.Now().Add(8 * time.Hour),
+			UsePoints:     10001,
+		})
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrInvalidParams.Code, appErr.Code)
+	})
+}
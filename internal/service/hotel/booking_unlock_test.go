@@ -0,0 +1,141 @@
+// Package hotel 开锁指令下发单元测试
+package hotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	deviceService "github.com/dumeirei/smart-locker-backend/internal/service/device"
+)
+
+// setupUnlockTest 创建带设备指令客户端的预订服务及一个已核销的预订
+func setupUnlockTest(t *testing.T) (*testBookingService, *deviceService.MockDeviceCommandClient, *models.Device, *models.Booking) {
+	t.Helper()
+
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Venue{}, &models.RentalPricing{}))
+
+	commandClient := deviceService.NewMockDeviceCommandClient()
+	deviceSvc := deviceService.NewDeviceService(db, repository.NewDeviceRepository(db), repository.NewVenueRepository(db))
+	svc := NewBookingService(db,
+		repository.NewBookingRepository(db),
+		repository.NewRoomRepository(db),
+		repository.NewHotelRepository(db),
+		repository.NewOrderRepository(db),
+		repository.NewRoomTimeSlotRepository(db),
+		NewCodeService(),
+		deviceSvc,
+		commandClient,
+	)
+
+	user, hotel, room, _ := createTestBookingData(t, db)
+
+	device := &models.Device{
+		DeviceNo:    "HOTEL-LOCK-001",
+		Name:        "客房门锁",
+		Type:        models.DeviceTypeStandard,
+		VenueID:     1,
+		QRCode:      "/qr/hotel-lock-001",
+		ProductName: "客房",
+		Status:      models.DeviceStatusActive,
+	}
+	require.NoError(t, db.Create(device).Error)
+
+	order := &models.Order{
+		OrderNo:        "O_UNLOCK_CMD",
+		UserID:         user.ID,
+		Type:           models.OrderTypeHotel,
+		OriginalAmount: 100.0,
+		ActualAmount:   100.0,
+		Status:         models.OrderStatusPaid,
+	}
+	require.NoError(t, db.Create(order).Error)
+
+	checkIn := time.Now().Add(-time.Hour)
+	booking := &models.Booking{
+		BookingNo:        "B_UNLOCK_CMD",
+		OrderID:          order.ID,
+		UserID:           user.ID,
+		HotelID:          hotel.ID,
+		RoomID:           room.ID,
+		DeviceID:         &device.ID,
+		CheckInTime:      checkIn,
+		CheckOutTime:     checkIn.Add(3 * time.Hour),
+		DurationHours:    3,
+		Amount:           100.0,
+		VerificationCode: "V_UNLOCK_CMD",
+		UnlockCode:       "654321",
+		QRCode:           "/qr/unlock-cmd",
+		Status:           models.BookingStatusVerified,
+	}
+	require.NoError(t, db.Create(booking).Error)
+
+	return &testBookingService{BookingService: svc, db: db}, commandClient, device, booking
+}
+
+func TestBookingService_UnlockByCode_SendsUnlockCommand(t *testing.T) {
+	svc, commandClient, device, booking := setupUnlockTest(t)
+	ctx := context.Background()
+
+	info, err := svc.UnlockByCode(ctx, device.ID, booking.UnlockCode, "test")
+	require.NoError(t, err)
+	assert.Equal(t, models.BookingStatusInUse, info.Status)
+
+	require.Equal(t, 1, commandClient.CommandCount())
+	assert.Equal(t, device.DeviceNo, commandClient.Commands[0].DeviceNo)
+	assert.Equal(t, deviceService.DeviceCommandActionUnlock, commandClient.Commands[0].Action)
+
+	var updated models.Booking
+	require.NoError(t, svc.db.First(&updated, booking.ID).Error)
+	assert.Equal(t, models.BookingStatusInUse, updated.Status)
+	assert.NotNil(t, updated.UnlockedAt)
+}
+
+func TestBookingService_UnlockByCode_CommandFailureRollsBack(t *testing.T) {
+	svc, commandClient, device, booking := setupUnlockTest(t)
+	ctx := context.Background()
+
+	commandClient.SetError(errors.New("device offline"))
+
+	_, err := svc.UnlockByCode(ctx, device.ID, booking.UnlockCode, "test")
+	require.Error(t, err)
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, appErrors.ErrUnlockFailed.Code, appErr.Code)
+	assert.Equal(t, 1, commandClient.CommandCount())
+
+	// 指令下发失败，预订保持已核销状态，可重试开锁
+	var updated models.Booking
+	require.NoError(t, svc.db.First(&updated, booking.ID).Error)
+	assert.Equal(t, models.BookingStatusVerified, updated.Status)
+	assert.Nil(t, updated.UnlockedAt)
+
+	commandClient.SetError(nil)
+	info, err := svc.UnlockByCode(ctx, device.ID, booking.UnlockCode, "test")
+	require.NoError(t, err)
+	assert.Equal(t, models.BookingStatusInUse, info.Status)
+	assert.Equal(t, 2, commandClient.CommandCount())
+}
+
+func TestBookingService_UnlockByCode_DeviceDisabled(t *testing.T) {
+	svc, commandClient, device, booking := setupUnlockTest(t)
+	ctx := context.Background()
+
+	require.NoError(t, svc.db.Model(device).Update("status", models.DeviceStatusDisabled).Error)
+
+	_, err := svc.UnlockByCode(ctx, device.ID, booking.UnlockCode, "test")
+	require.Error(t, err)
+	assert.Equal(t, 0, commandClient.CommandCount())
+
+	var updated models.Booking
+	require.NoError(t, svc.db.First(&updated, booking.ID).Error)
+	assert.Equal(t, models.BookingStatusVerified, updated.Status)
+}
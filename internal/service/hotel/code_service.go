@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"math/big"
 	"time"
 )
 
@@ -27,20 +28,32 @@ func (s *CodeService) GenerateVerificationCode() string {
 	return fmt.Sprintf("V%s", hex.EncodeToString(bytes)[:19])
 }
 
+// 开锁码长度
+const (
+	UnlockCodeLength     = 6 // 默认开锁码长度
+	LongUnlockCodeLength = 8 // 酒店开启长开锁码时的长度
+)
+
 // GenerateUnlockCode 生成开锁码
 // 格式：6位数字，便于用户在设备上输入
 func (s *CodeService) GenerateUnlockCode() string {
-	bytes := make([]byte, 4)
-	if _, err := rand.Read(bytes); err != nil {
-		// 降级使用时间戳后6位
-		return fmt.Sprintf("%06d", time.Now().UnixNano()%1000000)
-	}
-	// 转换为6位数字
-	num := int(bytes[0])<<24 | int(bytes[1])<<16 | int(bytes[2])<<8 | int(bytes[3])
-	if num < 0 {
-		num = -num
+	return generateNumericCode(UnlockCodeLength)
+}
+
+// GenerateLongUnlockCode 生成8位数字开锁码，用于开启长开锁码的酒店
+func (s *CodeService) GenerateLongUnlockCode() string {
+	return generateNumericCode(LongUnlockCodeLength)
+}
+
+// generateNumericCode 生成指定位数的随机数字码
+func generateNumericCode(length int) string {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(length)), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		// 降级使用时间戳
+		n = new(big.Int).Mod(big.NewInt(time.Now().UnixNano()), limit)
 	}
-	return fmt.Sprintf("%06d", num%1000000)
+	return fmt.Sprintf("%0*d", length, n)
 }
 
 // GenerateQRCodeURL 生成核销二维码 URL
@@ -49,9 +62,9 @@ func (s *CodeService) GenerateQRCodeURL(bookingNo string, verificationCode strin
 	return fmt.Sprintf("/api/v1/hotel/verify/%s?code=%s", bookingNo, verificationCode)
 }
 
-// ValidateUnlockCode 验证开锁码格式
+// ValidateUnlockCode 验证开锁码格式，支持6位和8位数字
 func (s *CodeService) ValidateUnlockCode(code string) bool {
-	if len(code) != 6 {
+	if len(code) != UnlockCodeLength && len(code) != LongUnlockCodeLength {
 		return false
 	}
 	for _, c := range code {
@@ -67,6 +67,16 @@ func TestCodeService_GenerateQRCodeURL(t *testing.T) {
 	})
 }
 
+func TestCodeService_GenerateLongUnlockCode(t *testing.T) {
+	svc := NewCodeService()
+
+	for i := 0; i < 20; i++ {
+		code := svc.GenerateLongUnlockCode()
+		assert.Len(t, code, 8, "长开锁码应为8位")
+		assert.True(t, svc.ValidateUnlockCode(code))
+	}
+}
+
 func TestCodeService_ValidateUnlockCode(t *testing.T) {
 	svc := NewCodeService()
 
@@ -80,6 +90,9 @@ func TestCodeService_ValidateUnlockCode(t *testing.T) {
 		{"有效的6位数字（全9）", "999999", true},
 		{"太短", "12345", false},
 		{"太长", "1234567", false},
+		{"有效的8位数字", "12345678", true},
+		{"太长（9位）", "123456789", false},
+		{"包含字母的8位", "1234567a", false},
 		{"包含字母", "12345a", false},
 		{"包含空格", "12345 ", false},
 		{"空字符串", "", false},
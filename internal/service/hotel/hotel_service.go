@@ -18,6 +18,8 @@ type HotelService struct {
 	hotelRepo          *repository.HotelRepository
 	roomRepo           *repository.RoomRepository
 	roomTimeSlotRepo   *repository.RoomTimeSlotRepository
+	occupancyRepo      *repository.RoomOccupancyRepository
+	pricing            *PricingResolver
 }
 
 // NewHotelService 创建酒店服务
@@ -32,9 +34,15 @@ func NewHotelService(
 		hotelRepo:        hotelRepo,
 		roomRepo:         roomRepo,
 		roomTimeSlotRepo: roomTimeSlotRepo,
+		occupancyRepo:    repository.NewRoomOccupancyRepository(db),
 	}
 }
 
+// SetPricingResolver 设置时段价格解析器，未设置时展示时段原价
+func (s *HotelService) SetPricingResolver(resolver *PricingResolver) {
+	s.pricing = resolver
+}
+
 // HotelListRequest 酒店列表请求
 type HotelListRequest struct {
 	Page       int     `form:"page" json:"page"`
@@ -66,6 +74,9 @@ type HotelInfo struct {
 	Description    string             `json:"description"`
 	CheckInTime    string             `json:"check_in_time"`
 	CheckOutTime   string             `json:"check_out_time"`
+	Timezone       string             `json:"timezone"`
+	FreeCancelHours     int           `json:"free_cancel_hours"`
+	CancellationFeeRate float64       `json:"cancellation_fee_rate"`
 	MinPrice       float64            `json:"min_price"`
 	RoomCount      int64              `json:"room_count"`
 	Distance       float64            `json:"distance,omitempty"`
@@ -89,6 +100,7 @@ type RoomInfo struct {
 	StatusName  string             `json:"status_name"`
 	TimeSlots   []TimeSlotInfo     `json:"time_slots,omitempty"`
 	DeviceID    *int64             `json:"device_id,omitempty"`
+	AvailableNow bool              `json:"available_now"` // 当前时刻是否空闲，仅房间列表返回
 	CreatedAt   time.Time          `json:"created_at"`
 }
 
@@ -198,11 +210,30 @@ func (s *HotelService) GetRoomList(ctx context.Context, hotelID int64) ([]*RoomI
 		return nil, errors.ErrDatabaseError.WithError(err)
 	}
 
-	return s.convertRoomList(rooms), nil
+	// 按占用位图判断当前是否空闲，不扫描预订表
+	roomIDs := make([]int64, len(rooms))
+	for i, room := range rooms {
+		roomIDs[i] = room.ID
+	}
+	occupied, err := s.occupancyRepo.OccupiedAt(ctx, roomIDs, time.Now())
+	if err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	list := s.convertRoomList(rooms)
+	for _, info := range list {
+		info.AvailableNow = !occupied[info.ID]
+	}
+	return list, nil
 }
 
-// GetRoomDetail 获取房间详情
+// GetRoomDetail 获取房间详情，时段价格按酒店当地今天解析
 func (s *HotelService) GetRoomDetail(ctx context.Context, roomID int64) (*RoomInfo, error) {
+	return s.GetRoomDetailOnDate(ctx, roomID, nil)
+}
+
+// GetRoomDetailOnDate 获取房间详情，时段价格按入住日期解析；date 为空时取酒店当地今天
+func (s *HotelService) GetRoomDetailOnDate(ctx context.Context, roomID int64, date *time.Time) (*RoomInfo, error) {
 	room, err := s.roomRepo.GetByIDWithTimeSlots(ctx, roomID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -215,12 +246,21 @@ func (s *HotelService) GetRoomDetail(ctx context.Context, roomID int64) (*RoomIn
 		return nil, errors.ErrRoomNotFound
 	}
 
-	return s.convertRoomInfo(room), nil
+	info := s.convertRoomInfo(room)
+	slots := make([]*models.RoomTimeSlot, len(room.TimeSlots))
+	for i := range room.TimeSlots {
+		slots[i] = &room.TimeSlots[i]
+	}
+	if err := s.applyResolvedPrices(ctx, room.ID, slots, info.TimeSlots, pricingDate(room.Hotel, date)); err != nil {
+		return nil, err
+	}
+	return info, nil
 }
 
 // CheckRoomAvailability 检查房间可用性
+// 入住时间已过或不符合酒店入住/退房时刻（按酒店当地时间）时视为不可用
 func (s *HotelService) CheckRoomAvailability(ctx context.Context, roomID int64, checkIn, checkOut time.Time) (bool, error) {
-	room, err := s.roomRepo.GetByID(ctx, roomID)
+	room, err := s.roomRepo.GetByIDWithHotel(ctx, roomID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return false, errors.ErrRoomNotFound
@@ -232,7 +272,11 @@ func (s *HotelService) CheckRoomAvailability(ctx context.Context, roomID int64,
 		return false, nil
 	}
 
-	available, err := s.roomRepo.CheckAvailability(ctx, roomID, checkIn, checkOut)
+	if !withinStayWindow(room.Hotel, checkIn, checkOut, time.Now()) {
+		return false, nil
+	}
+
+	available, err := s.occupancyRepo.IsAvailable(ctx, roomID, checkIn, checkOut)
 	if err != nil {
 		return false, errors.ErrDatabaseError.WithError(err)
 	}
@@ -240,6 +284,11 @@ func (s *HotelService) CheckRoomAvailability(ctx context.Context, roomID int64,
 	return available, nil
 }
 
+// RoomLocation 获取房间所属酒店的时区，用于按酒店当地时间解析查询时间
+func (s *HotelService) RoomLocation(ctx context.Context, roomID int64) (*time.Location, error) {
+	return roomLocation(ctx, s.roomRepo, roomID)
+}
+
 // GetCities 获取城市列表
 func (s *HotelService) GetCities(ctx context.Context) ([]string, error) {
 	cities, err := s.hotelRepo.GetCities(ctx)
@@ -249,8 +298,13 @@ func (s *HotelService) GetCities(ctx context.Context) ([]string, error) {
 	return cities, nil
 }
 
-// GetTimeSlotsByRoom 获取房间的时段价格
+// GetTimeSlotsByRoom 获取房间的时段价格，按酒店当地今天解析
 func (s *HotelService) GetTimeSlotsByRoom(ctx context.Context, roomID int64) ([]TimeSlotInfo, error) {
+	return s.GetTimeSlotsByRoomOnDate(ctx, roomID, nil)
+}
+
+// GetTimeSlotsByRoomOnDate 获取房间在入住日期的时段价格；date 为空时取酒店当地今天
+func (s *HotelService) GetTimeSlotsByRoomOnDate(ctx context.Context, roomID int64, date *time.Time) ([]TimeSlotInfo, error) {
 	slots, err := s.roomTimeSlotRepo.ListActiveByRoom(ctx, roomID)
 	if err != nil {
 		return nil, errors.ErrDatabaseError.WithError(err)
@@ -268,9 +322,49 @@ func (s *HotelService) GetTimeSlotsByRoom(ctx context.Context, roomID int64) ([]
 		})
 	}
 
+	if s.pricing == nil || len(slots) == 0 {
+		return result, nil
+	}
+
+	var onDate time.Time
+	if date != nil {
+		onDate = *date
+	} else {
+		loc, err := s.RoomLocation(ctx, roomID)
+		if err != nil {
+			return nil, err
+		}
+		onDate = time.Now().In(loc)
+	}
+	if err := s.applyResolvedPrices(ctx, roomID, slots, result, onDate); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
+// applyResolvedPrices 将时段价格替换为入住日期适用的价格，infos 与 slots 一一对应
+func (s *HotelService) applyResolvedPrices(ctx context.Context, roomID int64, slots []*models.RoomTimeSlot, infos []TimeSlotInfo, date time.Time) error {
+	if s.pricing == nil || len(slots) == 0 {
+		return nil
+	}
+	prices, err := s.pricing.ResolveSlots(ctx, roomID, slots, date)
+	if err != nil {
+		return err
+	}
+	for i := range infos {
+		infos[i].Price = prices[i]
+	}
+	return nil
+}
+
+// pricingDate 返回用于解析价格的入住日期，未指定时取酒店当地今天
+func pricingDate(hotel *models.Hotel, date *time.Time) time.Time {
+	if date != nil {
+		return *date
+	}
+	return time.Now().In(hotelLocation(hotel))
+}
+
 // convertHotelList 转换酒店列表
 func (s *HotelService) convertHotelList(hotels []*models.Hotel) []*HotelInfo {
 	var result []*HotelInfo
@@ -296,6 +390,9 @@ func (s *HotelService) convertHotelInfo(hotel *models.Hotel) *HotelInfo {
 		Phone:        hotel.Phone,
 		CheckInTime:  hotel.CheckInTime,
 		CheckOutTime: hotel.CheckOutTime,
+		Timezone:     hotel.Timezone,
+		FreeCancelHours:     hotel.FreeCancelHours,
+		CancellationFeeRate: hotel.CancellationFeeRate,
 		CreatedAt:    hotel.CreatedAt,
 	}
 
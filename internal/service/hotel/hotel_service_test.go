@@ -21,7 +21,7 @@ type testHotelService struct {
 }
 
 // setupTestHotelService 创建测试用的 HotelService
-func setupTestHotelService(t *testing.T) *testHotelService {
+func setupTestHotelService(t testing.TB) *testHotelService {
 	db := setupTestDB(t)
 	hotelRepo := repository.NewHotelRepository(db)
 	roomRepo := repository.NewRoomRepository(db)
@@ -36,7 +36,7 @@ func setupTestHotelService(t *testing.T) *testHotelService {
 }
 
 // createTestHotelData 创建酒店测试数据
-func createTestHotelData(t *testing.T, db *gorm.DB) (hotel *models.Hotel, room *models.Room, timeSlots []*models.RoomTimeSlot) {
+func createTestHotelData(t testing.TB, db *gorm.DB) (hotel *models.Hotel, room *models.Room, timeSlots []*models.RoomTimeSlot) {
 	// 创建酒店
 	description := "测试酒店描述"
 	starRating := 4
@@ -453,6 +453,9 @@ func TestHotelService_CheckRoomAvailability(t *testing.T) {
 			Status:           models.BookingStatusPaid,
 		}
 		svc.db.Create(booking)
+		// 直接写入的已支付预订需重建占用位图
+		_, err := repository.NewRoomOccupancyRepository(svc.db).Rebuild(ctx, room.ID)
+		require.NoError(t, err)
 
 		// 查询同一时段
 		available, err := svc.CheckRoomAvailability(ctx, room.ID, checkIn, checkOut)
@@ -490,6 +493,46 @@ func TestHotelService_CheckRoomAvailability(t *testing.T) {
 	})
 }
 
+func TestHotelService_CheckRoomAvailability_HotelTimezone(t *testing.T) {
+	svc := setupTestHotelService(t)
+	ctx := context.Background()
+
+	hotel, room, _ := createTestHotelData(t, svc.db)
+	require.NoError(t, svc.db.Model(hotel).Update("timezone", "America/New_York").Error)
+
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	nowLocal := time.Now().In(loc)
+	day := func(offset, hour int) time.Time {
+		return time.Date(nowLocal.Year(), nowLocal.Month(), nowLocal.Day()+offset, hour, 0, 0, 0, loc)
+	}
+
+	t.Run("符合酒店入住退房时间的跨天入住可用", func(t *testing.T) {
+		available, err := svc.CheckRoomAvailability(ctx, room.ID, day(2, 14), day(4, 12))
+		require.NoError(t, err)
+		assert.True(t, available)
+	})
+
+	t.Run("早于酒店入住时间的跨天入住不可用", func(t *testing.T) {
+		available, err := svc.CheckRoomAvailability(ctx, room.ID, day(2, 10), day(4, 12))
+		require.NoError(t, err)
+		assert.False(t, available)
+	})
+
+	t.Run("晚于酒店退房时间的跨天入住不可用", func(t *testing.T) {
+		available, err := svc.CheckRoomAvailability(ctx, room.ID, day(2, 14), day(4, 15))
+		require.NoError(t, err)
+		assert.False(t, available)
+	})
+
+	t.Run("已过去的时间不可用", func(t *testing.T) {
+		checkIn := nowLocal.Add(-2 * time.Hour)
+		available, err := svc.CheckRoomAvailability(ctx, room.ID, checkIn, checkIn.Add(time.Hour))
+		require.NoError(t, err)
+		assert.False(t, available)
+	})
+}
+
 func TestHotelService_GetCities(t *testing.T) {
 	svc := setupTestHotelService(t)
 	ctx := context.Background()
@@ -0,0 +1,134 @@
+package hotel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// pastCheckInTolerance 入住时间早于当前时间的容忍误差
+const pastCheckInTolerance = 5 * time.Minute
+
+// hotelLocation 获取酒店所在时区，未配置或无法识别时使用默认时区
+func hotelLocation(hotel *models.Hotel) *time.Location {
+	if hotel != nil && hotel.Timezone != "" {
+		if loc, err := time.LoadLocation(hotel.Timezone); err == nil {
+			return loc
+		}
+	}
+	loc, err := time.LoadLocation(models.DefaultHotelTimezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// roomLocation 获取房间所属酒店的时区
+func roomLocation(ctx context.Context, roomRepo *repository.RoomRepository, roomID int64) (*time.Location, error) {
+	room, err := roomRepo.GetByIDWithHotel(ctx, roomID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrRoomNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	return hotelLocation(room.Hotel), nil
+}
+
+// parseClock 解析酒店入住/退房时刻（"14:00" 或 "14:00:00"），返回距当天零点的时长
+func parseClock(s string) (time.Duration, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return time.Duration(t.Hour())*time.Hour +
+				time.Duration(t.Minute())*time.Minute +
+				time.Duration(t.Second())*time.Second, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid clock time %q", s)
+}
+
+// clockOf 返回 t 在其所在时区距当天零点的时长
+func clockOf(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+}
+
+// atClock 返回 t 所在日期之后第 days 天、指定时刻的时间（t 所在时区）
+func atClock(t time.Time, days int, clock time.Duration) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day()+days, 0, 0, 0, 0, t.Location())
+	return midnight.Add(clock)
+}
+
+// isDailyBooking 是否为按天预订（时长为 24 小时的整数倍）
+func isDailyBooking(durationHours int) bool {
+	return durationHours >= 24 && durationHours%24 == 0
+}
+
+// resolveStayPeriod 按酒店当地时间校验入住时间并计算退房时间
+// 按天预订须不早于酒店入住时刻，退房时间对齐到最后一天的酒店退房时刻；
+// 按小时预订直接按时长计算退房时间
+func resolveStayPeriod(hotel *models.Hotel, checkIn time.Time, durationHours int, now time.Time) (time.Time, time.Time, error) {
+	loc := hotelLocation(hotel)
+	localCheckIn := checkIn.In(loc)
+
+	if localCheckIn.Before(now.In(loc).Add(-pastCheckInTolerance)) {
+		return time.Time{}, time.Time{}, errors.ErrInvalidParams.WithMessage("入住时间不能是过去")
+	}
+
+	if !isDailyBooking(durationHours) {
+		return localCheckIn, localCheckIn.Add(time.Duration(durationHours) * time.Hour), nil
+	}
+
+	checkInClock, err := parseClock(hotel.CheckInTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.ErrInternalError.WithError(err)
+	}
+	checkOutClock, err := parseClock(hotel.CheckOutTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.ErrInternalError.WithError(err)
+	}
+
+	if clockOf(localCheckIn) < checkInClock {
+		return time.Time{}, time.Time{}, errors.ErrInvalidParams.WithMessage(
+			fmt.Sprintf("入住时间不能早于酒店入住时间%s", hotel.CheckInTime))
+	}
+
+	checkOut := atClock(localCheckIn, durationHours/24, checkOutClock)
+	return localCheckIn, checkOut, nil
+}
+
+// withinStayWindow 判断入住/退房时间是否符合酒店入住政策（按酒店当地时间）
+// 满 24 小时的入住须不早于酒店入住时刻、不晚于酒店退房时刻
+func withinStayWindow(hotel *models.Hotel, checkIn, checkOut time.Time, now time.Time) bool {
+	loc := hotelLocation(hotel)
+	localCheckIn := checkIn.In(loc)
+	localCheckOut := checkOut.In(loc)
+
+	if !localCheckOut.After(localCheckIn) {
+		return false
+	}
+	if localCheckIn.Before(now.In(loc).Add(-pastCheckInTolerance)) {
+		return false
+	}
+	// 不足 24 小时按小时房处理，不受入住/退房时刻限制
+	if localCheckOut.Sub(localCheckIn) < 24*time.Hour {
+		return true
+	}
+
+	checkInClock, err := parseClock(hotel.CheckInTime)
+	if err != nil {
+		return false
+	}
+	checkOutClock, err := parseClock(hotel.CheckOutTime)
+	if err != nil {
+		return false
+	}
+	return clockOf(localCheckIn) >= checkInClock && clockOf(localCheckOut) <= checkOutClock
+}
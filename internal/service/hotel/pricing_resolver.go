@@ -0,0 +1,108 @@
+package hotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// PricingResolver 时段价格解析器
+// 按入住日期（酒店当地日期）匹配房间日历价格覆盖，未命中时使用时段原价。
+// 多条覆盖同时命中时按优先级、再按具体程度（指定日期优于星期规则、指定时长优于全部时长）选取
+type PricingResolver struct {
+	overrideRepo *repository.RoomPriceOverrideRepository
+}
+
+// NewPricingResolver 创建时段价格解析器
+func NewPricingResolver(overrideRepo *repository.RoomPriceOverrideRepository) *PricingResolver {
+	return &PricingResolver{overrideRepo: overrideRepo}
+}
+
+// Resolve 解析时段在入住日期的价格
+// checkInDate 取其所在时区的日期，调用方应传入酒店当地时间
+func (r *PricingResolver) Resolve(ctx context.Context, slot *models.RoomTimeSlot, checkInDate time.Time) (float64, error) {
+	prices, err := r.ResolveSlots(ctx, slot.RoomID, []*models.RoomTimeSlot{slot}, checkInDate)
+	if err != nil {
+		return 0, err
+	}
+	return prices[0], nil
+}
+
+// ResolveSlots 批量解析同一房间各时段在入住日期的价格，返回顺序与 slots 一致
+func (r *PricingResolver) ResolveSlots(ctx context.Context, roomID int64, slots []*models.RoomTimeSlot, checkInDate time.Time) ([]float64, error) {
+	prices := make([]float64, len(slots))
+	for i, slot := range slots {
+		prices[i] = slot.Price
+	}
+	if len(slots) == 0 {
+		return prices, nil
+	}
+
+	overrides, err := r.overrideRepo.ListActiveByRoom(ctx, roomID)
+	if err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	for i, slot := range slots {
+		if override := matchPriceOverride(overrides, slot.DurationHours, checkInDate); override != nil {
+			prices[i] = override.Price
+		}
+	}
+	return prices, nil
+}
+
+// matchPriceOverride 选出适用于指定时长和日期的价格覆盖，无命中时返回 nil
+func matchPriceOverride(overrides []*models.RoomPriceOverride, durationHours int, date time.Time) *models.RoomPriceOverride {
+	day := calendarDate(date)
+
+	var best *models.RoomPriceOverride
+	for _, o := range overrides {
+		if !o.IsActive {
+			continue
+		}
+		if o.DurationHours != nil && *o.DurationHours != durationHours {
+			continue
+		}
+		if o.StartDate != nil && day.Before(calendarDate(*o.StartDate)) {
+			continue
+		}
+		if o.EndDate != nil && day.After(calendarDate(*o.EndDate)) {
+			continue
+		}
+		if o.Date != nil {
+			if !calendarDate(*o.Date).Equal(day) {
+				continue
+			}
+		} else if o.WeekdayMask&models.WeekdayBit(day.Weekday()) == 0 {
+			continue
+		}
+
+		if best == nil || overridePrecedes(o, best) {
+			best = o
+		}
+	}
+	return best
+}
+
+// overridePrecedes 判断覆盖 a 是否优先于 b
+func overridePrecedes(a, b *models.RoomPriceOverride) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	if (a.Date != nil) != (b.Date != nil) {
+		return a.Date != nil
+	}
+	if (a.DurationHours != nil) != (b.DurationHours != nil) {
+		return a.DurationHours != nil
+	}
+	// 同等条件下后创建的覆盖生效
+	return a.ID > b.ID
+}
+
+// calendarDate 取 t 在其所在时区的日期，统一为 UTC 零点便于比较
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,230 @@
+// Package hotel 时段价格解析单元测试
+package hotel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// testDate 构造日期（UTC 零点）
+func testDate(year int, month time.Month, day int) *time.Time {
+	d := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &d
+}
+
+// nextWeekday 返回 from 之后至少 minDays 天的第一个指定星期几（from 所在时区零点）
+func nextWeekday(from time.Time, day time.Weekday, minDays int) time.Time {
+	d := time.Date(from.Year(), from.Month(), from.Day()+minDays, 0, 0, 0, 0, from.Location())
+	for d.Weekday() != day {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+func TestMatchPriceOverride(t *testing.T) {
+	friday := time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC) // 周五
+	weekend := models.WeekdayBit(time.Friday) | models.WeekdayBit(time.Saturday)
+	four := 4
+
+	t.Run("无命中返回nil", func(t *testing.T) {
+		overrides := []*models.RoomPriceOverride{
+			{ID: 1, WeekdayMask: models.WeekdayBit(time.Monday), Price: 120, IsActive: true},
+			{ID: 2, Date: testDate(2026, 10, 17), Price: 300, IsActive: true},
+			{ID: 3, WeekdayMask: weekend, DurationHours: &four, Price: 200, IsActive: true},
+			{ID: 4, WeekdayMask: weekend, Price: 150, IsActive: false},
+		}
+		assert.Nil(t, matchPriceOverride(overrides, 2, friday))
+	})
+
+	t.Run("优先级高者优先", func(t *testing.T) {
+		overrides := []*models.RoomPriceOverride{
+			{ID: 1, Date: testDate(2026, 10, 16), Price: 300, Priority: 0, IsActive: true},
+			{ID: 2, WeekdayMask: weekend, Price: 150, Priority: 10, IsActive: true},
+		}
+		assert.Equal(t, int64(2), matchPriceOverride(overrides, 2, friday).ID)
+	})
+
+	t.Run("同优先级指定日期优于星期规则", func(t *testing.T) {
+		overrides := []*models.RoomPriceOverride{
+			{ID: 1, WeekdayMask: weekend, Price: 150, IsActive: true},
+			{ID: 2, Date: testDate(2026, 10, 16), Price: 300, IsActive: true},
+		}
+		assert.Equal(t, int64(2), matchPriceOverride(overrides, 2, friday).ID)
+	})
+
+	t.Run("同优先级指定时长优于全部时长", func(t *testing.T) {
+		overrides := []*models.RoomPriceOverride{
+			{ID: 1, WeekdayMask: weekend, DurationHours: &four, Price: 200, IsActive: true},
+			{ID: 2, WeekdayMask: weekend, Price: 150, IsActive: true},
+		}
+		assert.Equal(t, int64(1), matchPriceOverride(overrides, 4, friday).ID)
+		assert.Equal(t, int64(2), matchPriceOverride(overrides, 2, friday).ID)
+	})
+
+	t.Run("生效期外不适用", func(t *testing.T) {
+		overrides := []*models.RoomPriceOverride{
+			{ID: 1, WeekdayMask: weekend, Price: 150, EndDate: testDate(2026, 10, 15), IsActive: true},
+			{ID: 2, WeekdayMask: weekend, Price: 180, StartDate: testDate(2026, 10, 17), IsActive: true},
+		}
+		assert.Nil(t, matchPriceOverride(overrides, 2, friday))
+
+		overrides[0].EndDate = testDate(2026, 10, 16)
+		assert.Equal(t, int64(1), matchPriceOverride(overrides, 2, friday).ID)
+	})
+
+	t.Run("按传入时间所在时区的日期匹配", func(t *testing.T) {
+		overrides := []*models.RoomPriceOverride{
+			{ID: 1, WeekdayMask: models.WeekdayBit(time.Saturday), Price: 180, IsActive: true},
+		}
+		shanghai, err := time.LoadLocation("Asia/Shanghai")
+		require.NoError(t, err)
+		// UTC 周五 22:00 为上海周六 06:00
+		assert.NotNil(t, matchPriceOverride(overrides, 2, friday.In(shanghai)))
+		assert.Nil(t, matchPriceOverride(overrides, 2, friday))
+	})
+}
+
+func TestBookingService_CreateBooking_PriceOverride(t *testing.T) {
+	svc := setupTestBookingService(t)
+	svc.SetPricingResolver(NewPricingResolver(repository.NewRoomPriceOverrideRepository(svc.db)))
+	ctx := context.Background()
+
+	user, hotel, room, timeSlot := createTestBookingData(t, svc.db)
+	loc := hotelLocation(hotel)
+
+	// 周五、周六晚加价
+	require.NoError(t, svc.db.Create(&models.RoomPriceOverride{
+		RoomID:      room.ID,
+		WeekdayMask: models.WeekdayBit(time.Friday) | models.WeekdayBit(time.Saturday),
+		Price:       160,
+		IsActive:    true,
+	}).Error)
+
+	thursday := nextWeekday(time.Now().In(loc), time.Thursday, 2)
+
+	t.Run("跨零点入住按入住日期锁定价格", func(t *testing.T) {
+		// 周四 23:00 入住 2 小时，退房在周五
+		info, err := svc.CreateBooking(ctx, user.ID, &CreateBookingRequest{
+			RoomID:        room.ID,
+			DurationHours: 2,
+			CheckInTime:   thursday.Add(23 * time.Hour),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, timeSlot.Price, info.Amount)
+		assert.Equal(t, time.Friday, info.CheckOutTime.In(loc).Weekday())
+	})
+
+	t.Run("入住日期命中覆盖价格", func(t *testing.T) {
+		// 周五 23:00 入住，跨零点至周六
+		info, err := svc.CreateBooking(ctx, user.ID, &CreateBookingRequest{
+			RoomID:        room.ID,
+			DurationHours: 2,
+			CheckInTime:   thursday.AddDate(0, 0, 1).Add(23 * time.Hour),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 160.0, info.Amount)
+
+		var order models.Order
+		require.NoError(t, svc.db.Where("order_no = ?", info.OrderNo).First(&order).Error)
+		assert.Equal(t, 160.0, order.OriginalAmount)
+		assert.Equal(t, 160.0, order.ActualAmount)
+	})
+}
+
+func TestBookingService_CreateBooking_ExpiredPriceOverride(t *testing.T) {
+	svc := setupTestBookingService(t)
+	svc.SetPricingResolver(NewPricingResolver(repository.NewRoomPriceOverrideRepository(svc.db)))
+	ctx := context.Background()
+
+	user, hotel, room, timeSlot := createTestBookingData(t, svc.db)
+	loc := hotelLocation(hotel)
+
+	checkIn := nextWeekday(time.Now().In(loc), time.Wednesday, 3).Add(15 * time.Hour)
+	lastDay := checkIn.AddDate(0, 0, -1)
+
+	// 活动价已于入住前一天结束
+	require.NoError(t, svc.db.Create(&models.RoomPriceOverride{
+		RoomID:      room.ID,
+		WeekdayMask: 0x7F,
+		Price:       60,
+		Priority:    100,
+		EndDate:     testDate(lastDay.Year(), lastDay.Month(), lastDay.Day()),
+		IsActive:    true,
+	}).Error)
+
+	info, err := svc.CreateBooking(ctx, user.ID, &CreateBookingRequest{
+		RoomID:        room.ID,
+		DurationHours: 2,
+		CheckInTime:   checkIn,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, timeSlot.Price, info.Amount)
+
+	// 生效期内的入住仍按活动价
+	info, err = svc.CreateBooking(ctx, user.ID, &CreateBookingRequest{
+		RoomID:        room.ID,
+		DurationHours: 2,
+		CheckInTime:   lastDay,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 60.0, info.Amount)
+}
+
+func TestHotelService_GetTimeSlotsByRoomOnDate(t *testing.T) {
+	svc := setupTestHotelService(t)
+	svc.SetPricingResolver(NewPricingResolver(repository.NewRoomPriceOverrideRepository(svc.db)))
+	ctx := context.Background()
+
+	_, room, _ := createTestHotelData(t, svc.db)
+
+	four := 4
+	// 2026-10-16 为周五
+	require.NoError(t, svc.db.Create(&models.RoomPriceOverride{
+		RoomID:      room.ID,
+		WeekdayMask: models.WeekdayBit(time.Friday) | models.WeekdayBit(time.Saturday),
+		Price:       150,
+		EndDate:     testDate(2026, 10, 31),
+		IsActive:    true,
+	}).Error)
+	require.NoError(t, svc.db.Create(&models.RoomPriceOverride{
+		RoomID:        room.ID,
+		WeekdayMask:   models.WeekdayBit(time.Friday) | models.WeekdayBit(time.Saturday),
+		DurationHours: &four,
+		Price:         260,
+		EndDate:       testDate(2026, 10, 31),
+		IsActive:      true,
+	}).Error)
+
+	pricesOn := func(date *time.Time) map[int]float64 {
+		slots, err := svc.GetTimeSlotsByRoomOnDate(ctx, room.ID, date)
+		require.NoError(t, err)
+		prices := make(map[int]float64)
+		for _, slot := range slots {
+			prices[slot.DurationHours] = slot.Price
+		}
+		return prices
+	}
+
+	assert.Equal(t, map[int]float64{2: 150, 4: 260, 6: 150}, pricesOn(testDate(2026, 10, 16)))
+	assert.Equal(t, map[int]float64{2: 100, 4: 180, 6: 250}, pricesOn(testDate(2026, 10, 15)))
+	// 覆盖过期后恢复原价
+	assert.Equal(t, map[int]float64{2: 100, 4: 180, 6: 250}, pricesOn(testDate(2026, 11, 6)))
+
+	info, err := svc.GetRoomDetailOnDate(ctx, room.ID, testDate(2026, 10, 17))
+	require.NoError(t, err)
+	require.Len(t, info.TimeSlots, 3)
+	for _, slot := range info.TimeSlots {
+		if slot.DurationHours == 4 {
+			assert.Equal(t, 260.0, slot.Price)
+		} else {
+			assert.Equal(t, 150.0, slot.Price)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package hotel
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// DayAvailability 房间单日可用情况
+type DayAvailability struct {
+	Date           string `json:"date"`            // 日期（YYYY-MM-DD，酒店当地时间）
+	AvailableSlots int    `json:"available_slots"` // 可预订小时数
+	TotalSlots     int    `json:"total_slots"`     // 入住窗口总小时数
+}
+
+// RoomCalendar 房间月度可用日历
+type RoomCalendar struct {
+	RoomID int64             `json:"room_id"`
+	Year   int               `json:"year"`
+	Month  int               `json:"month"`
+	Days   []DayAvailability `json:"days"`
+}
+
+// GetRoomCalendar 获取房间整月的逐日可用情况
+// 每天的入住窗口为当天酒店入住时刻至次日退房时刻（按酒店当地时间），按小时统计未被预订占用的时长；
+// 已过去的小时及房间停用时均视为不可用
+func (s *HotelService) GetRoomCalendar(ctx context.Context, roomID int64, year int, month int) (*RoomCalendar, error) {
+	if year < 1 || month < 1 || month > 12 {
+		return nil, errors.ErrInvalidParams.WithMessage("年份或月份不正确")
+	}
+
+	room, err := s.roomRepo.GetByIDWithHotel(ctx, roomID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrRoomNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	checkInClock, err := parseClock(room.Hotel.CheckInTime)
+	if err != nil {
+		return nil, errors.ErrInternalError.WithError(err)
+	}
+	checkOutClock, err := parseClock(room.Hotel.CheckOutTime)
+	if err != nil {
+		return nil, errors.ErrInternalError.WithError(err)
+	}
+
+	loc := hotelLocation(room.Hotel)
+	first := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, loc)
+	daysInMonth := first.AddDate(0, 1, -1).Day()
+
+	// 一次查询覆盖整月所有入住窗口
+	var bookings []*models.Booking
+	if room.Status == int8(models.RoomStatusActive) {
+		bookings, err = s.roomRepo.ListOccupyingBookings(ctx, roomID,
+			atClock(first, 0, checkInClock), atClock(first, daysInMonth, checkOutClock))
+		if err != nil {
+			return nil, errors.ErrDatabaseError.WithError(err)
+		}
+	}
+
+	now := time.Now()
+	calendar := &RoomCalendar{
+		RoomID: roomID,
+		Year:   year,
+		Month:  month,
+		Days:   make([]DayAvailability, 0, daysInMonth),
+	}
+	for d := 0; d < daysInMonth; d++ {
+		windowStart := atClock(first, d, checkInClock)
+		windowEnd := atClock(first, d+1, checkOutClock)
+
+		day := DayAvailability{Date: windowStart.Format("2006-01-02")}
+		for hour := windowStart; hour.Before(windowEnd); hour = hour.Add(time.Hour) {
+			day.TotalSlots++
+			if room.Status != int8(models.RoomStatusActive) || hour.Before(now) {
+				continue
+			}
+			if !hourOccupied(bookings, hour, hour.Add(time.Hour)) {
+				day.AvailableSlots++
+			}
+		}
+		calendar.Days = append(calendar.Days, day)
+	}
+
+	return calendar, nil
+}
+
+// hourOccupied 判断 [start, end) 时段是否与任一预订重叠
+func hourOccupied(bookings []*models.Booking, start, end time.Time) bool {
+	for _, b := range bookings {
+		if b.CheckInTime.Before(end) && b.CheckOutTime.After(start) {
+			return true
+		}
+	}
+	return false
+}
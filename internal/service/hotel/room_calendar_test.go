@@ -0,0 +1,116 @@
+// Package hotel 房间可用日历单元测试
+package hotel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+func TestHotelService_GetRoomCalendar(t *testing.T) {
+	svc := setupTestHotelService(t)
+	ctx := context.Background()
+
+	user := &models.User{
+		Nickname:      "测试用户",
+		MemberLevelID: 1,
+		Status:        models.UserStatusActive,
+	}
+	svc.db.Create(user)
+
+	hotel, room, _ := createTestHotelData(t, svc.db)
+
+	loc, err := time.LoadLocation(models.DefaultHotelTimezone)
+	require.NoError(t, err)
+	// 使用明年六月，避免已过去的小时影响统计
+	year := time.Now().In(loc).Year() + 1
+	at := func(day, hour int) time.Time {
+		return time.Date(year, time.June, day, hour, 0, 0, 0, loc)
+	}
+
+	createBooking := func(no string, checkIn, checkOut time.Time, status string) {
+		order := &models.Order{
+			OrderNo:        "CAL" + no,
+			UserID:         user.ID,
+			Type:           models.OrderTypeHotel,
+			OriginalAmount: 100.0,
+			ActualAmount:   100.0,
+			Status:         models.OrderStatusPaid,
+		}
+		require.NoError(t, svc.db.Create(order).Error)
+		booking := &models.Booking{
+			BookingNo:        "BCAL" + no,
+			OrderID:          order.ID,
+			UserID:           user.ID,
+			HotelID:          hotel.ID,
+			RoomID:           room.ID,
+			CheckInTime:      checkIn,
+			CheckOutTime:     checkOut,
+			DurationHours:    int(checkOut.Sub(checkIn).Hours()),
+			Amount:           100.0,
+			VerificationCode: "VCAL" + no + "XXXXXXXXXXXX",
+			UnlockCode:       "1234" + no,
+			QRCode:           "/qr/cal" + no,
+			Status:           status,
+		}
+		require.NoError(t, svc.db.Create(booking).Error)
+	}
+
+	// 10 日 16:00-19:00 已支付的小时房
+	createBooking("01", at(10, 16), at(10, 19), models.BookingStatusPaid)
+	// 20 日已取消的预订不占用房间
+	createBooking("02", at(20, 16), at(20, 19), models.BookingStatusCancelled)
+
+	t.Run("有预订的日期可用时长减少", func(t *testing.T) {
+		calendar, err := svc.GetRoomCalendar(ctx, room.ID, year, 6)
+		require.NoError(t, err)
+		require.Len(t, calendar.Days, 30)
+
+		// 入住窗口 14:00 至次日 12:00，共 22 小时
+		for _, day := range calendar.Days {
+			assert.Equal(t, 22, day.TotalSlots)
+		}
+
+		booked := calendar.Days[9]
+		assert.Equal(t, at(10, 0).Format("2006-01-02"), booked.Date)
+		assert.Equal(t, 19, booked.AvailableSlots)
+
+		assert.Equal(t, 22, calendar.Days[8].AvailableSlots)
+		assert.Equal(t, 22, calendar.Days[19].AvailableSlots)
+	})
+
+	t.Run("停用房间全部不可用", func(t *testing.T) {
+		disabledRoom := &models.Room{
+			HotelID:     hotel.ID,
+			RoomNo:      "998",
+			RoomType:    models.RoomTypeStandard,
+			MaxGuests:   2,
+			HourlyPrice: 60.0,
+			DailyPrice:  288.0,
+			Status:      models.RoomStatusActive,
+		}
+		svc.db.Create(disabledRoom)
+		svc.db.Model(disabledRoom).Update("status", models.RoomStatusDisabled)
+
+		calendar, err := svc.GetRoomCalendar(ctx, disabledRoom.ID, year, 6)
+		require.NoError(t, err)
+		for _, day := range calendar.Days {
+			assert.Equal(t, 0, day.AvailableSlots)
+		}
+	})
+
+	t.Run("月份不正确", func(t *testing.T) {
+		_, err := svc.GetRoomCalendar(ctx, room.ID, year, 13)
+		assert.Error(t, err)
+	})
+
+	t.Run("房间不存在", func(t *testing.T) {
+		_, err := svc.GetRoomCalendar(ctx, 999999, year, 6)
+		assert.Error(t, err)
+	})
+}
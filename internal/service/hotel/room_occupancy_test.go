@@ -0,0 +1,153 @@
+// Package hotel 房间占用位图单元测试
+package hotel
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// countBookingQueries 统计注册后对预订表的查询次数
+func countBookingQueries(t testing.TB, db *gorm.DB) *int {
+	var count int
+	require.NoError(t, db.Callback().Query().Before("gorm:query").Register("test:count_booking_queries", func(tx *gorm.DB) {
+		if tx.Statement.Table == (models.Booking{}).TableName() {
+			count++
+		}
+	}))
+	return &count
+}
+
+// createOccupyingBooking 直接写入占用房间的预订，调用方负责刷新位图
+func createOccupyingBooking(t testing.TB, db *gorm.DB, room *models.Room, checkIn time.Time, hours int, status string) {
+	seq := time.Now().UnixNano()
+	require.NoError(t, db.Create(&models.Booking{
+		BookingNo:        fmt.Sprintf("BOCC%d", seq),
+		OrderID:          seq,
+		UserID:           1,
+		HotelID:          room.HotelID,
+		RoomID:           room.ID,
+		CheckInTime:      checkIn,
+		CheckOutTime:     checkIn.Add(time.Duration(hours) * time.Hour),
+		DurationHours:    hours,
+		Amount:           100.0,
+		VerificationCode: fmt.Sprintf("VOCC%d", seq),
+		UnlockCode:       "123456",
+		QRCode:           "/qr/occ",
+		Status:           status,
+	}).Error)
+}
+
+func TestBookingService_PaymentRefreshesOccupancy(t *testing.T) {
+	svc := setupTestBookingService(t)
+	ctx := context.Background()
+
+	user, _, room, _ := createTestBookingData(t, svc.db)
+	hotelSvc := NewHotelService(svc.db, repository.NewHotelRepository(svc.db), repository.NewRoomRepository(svc.db), repository.NewRoomTimeSlotRepository(svc.db))
+
+	checkIn := time.Now().Add(1 * time.Hour)
+	info, err := svc.CreateBooking(ctx, user.ID, &CreateBookingRequest{RoomID: room.ID, DurationHours: 2, CheckInTime: checkIn})
+	require.NoError(t, err)
+
+	// 待支付的预订不占用房间
+	available, err := hotelSvc.CheckRoomAvailability(ctx, room.ID, info.CheckInTime, info.CheckOutTime)
+	require.NoError(t, err)
+	assert.True(t, available)
+
+	booking, err := svc.bookingRepo.GetByID(ctx, info.ID)
+	require.NoError(t, err)
+	require.NoError(t, svc.OnPaymentSuccess(ctx, booking.OrderID))
+
+	available, err = hotelSvc.CheckRoomAvailability(ctx, room.ID, info.CheckInTime, info.CheckOutTime)
+	require.NoError(t, err)
+	assert.False(t, available)
+
+	// 过期后释放时段
+	require.NoError(t, svc.updateOccupyingStatus(ctx, booking, models.BookingStatusExpired))
+	available, err = hotelSvc.CheckRoomAvailability(ctx, room.ID, info.CheckInTime, info.CheckOutTime)
+	require.NoError(t, err)
+	assert.True(t, available)
+}
+
+func TestHotelService_AvailabilityDoesNotQueryBookings(t *testing.T) {
+	svc := setupTestHotelService(t)
+	ctx := context.Background()
+
+	hotel, room, _ := createTestHotelData(t, svc.db)
+	now := time.Now()
+	createOccupyingBooking(t, svc.db, room, now.Add(-30*time.Minute), 2, models.BookingStatusInUse)
+	createOccupyingBooking(t, svc.db, room, now.Add(5*time.Hour), 2, models.BookingStatusPaid)
+	_, err := svc.occupancyRepo.Rebuild(ctx, room.ID)
+	require.NoError(t, err)
+
+	idle := &models.Room{
+		HotelID:     hotel.ID,
+		RoomNo:      "102",
+		RoomType:    models.RoomTypeStandard,
+		MaxGuests:   2,
+		HourlyPrice: 60.0,
+		DailyPrice:  288.0,
+		Status:      models.RoomStatusActive,
+	}
+	require.NoError(t, svc.db.Create(idle).Error)
+
+	queries := countBookingQueries(t, svc.db)
+
+	rooms, err := svc.GetRoomList(ctx, hotel.ID)
+	require.NoError(t, err)
+	require.Len(t, rooms, 2)
+	for _, info := range rooms {
+		assert.Equal(t, info.ID == idle.ID, info.AvailableNow, "room %d", info.ID)
+	}
+
+	available, err := svc.CheckRoomAvailability(ctx, room.ID, now.Add(6*time.Hour), now.Add(8*time.Hour))
+	require.NoError(t, err)
+	assert.False(t, available)
+
+	available, err = svc.CheckRoomAvailability(ctx, room.ID, now.Add(10*time.Hour), now.Add(12*time.Hour))
+	require.NoError(t, err)
+	assert.True(t, available)
+
+	assert.Zero(t, *queries)
+}
+
+func BenchmarkHotelService_GetRoomList(b *testing.B) {
+	svc := setupTestHotelService(b)
+	ctx := context.Background()
+
+	hotel, _, _ := createTestHotelData(b, svc.db)
+	start := time.Now().Add(-24 * time.Hour)
+	for i := 0; i < 20; i++ {
+		room := &models.Room{
+			HotelID:     hotel.ID,
+			RoomNo:      fmt.Sprintf("2%02d", i),
+			RoomType:    models.RoomTypeStandard,
+			MaxGuests:   2,
+			HourlyPrice: 60.0,
+			DailyPrice:  288.0,
+			Status:      models.RoomStatusActive,
+		}
+		require.NoError(b, svc.db.Create(room).Error)
+		// 每个房间连续多天的已支付预订
+		for j := 0; j < 50; j++ {
+			createOccupyingBooking(b, svc.db, room, start.Add(time.Duration(j*3)*time.Hour), 2, models.BookingStatusPaid)
+		}
+		_, err := svc.occupancyRepo.Rebuild(ctx, room.ID)
+		require.NoError(b, err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := svc.GetRoomList(ctx, hotel.ID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package hotel
+
+import (
+	"context"
+	stderrors "errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/cache"
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// 开锁码尝试限制参数
+const (
+	DefaultMaxUnlockAttempts = 5                // 锁定前允许的连续失败次数
+	UnlockLockoutDuration    = 15 * time.Minute // 锁定时长，同时作为失败计数窗口
+	unlockAttemptKeyPrefix   = "unlock:attempt:"
+)
+
+// unlockAttemptStore 设备开锁失败计数存储
+type unlockAttemptStore interface {
+	// lockedFor 返回设备剩余的锁定时长，未锁定时返回 0
+	lockedFor(ctx context.Context, deviceID int64) (time.Duration, error)
+	// recordFailure 记录一次失败，返回窗口内的失败次数以及是否因此被锁定
+	recordFailure(ctx context.Context, deviceID int64) (int64, bool, error)
+	// reset 清零失败计数
+	reset(ctx context.Context, deviceID int64) error
+}
+
+// UnlockAttemptGuard 开锁码尝试限制
+// 同一设备在计数窗口内开锁码错误达到上限后锁定该设备的开锁码开锁 15 分钟，开锁成功后清零计数。
+// 配置 Redis 时使用 Redis 计数，否则使用数据库 unlock_attempts 表。
+// 计数存储异常时仅记录日志并放行，避免存储故障导致所有设备无法开锁
+type UnlockAttemptGuard struct {
+	store  unlockAttemptStore
+	logger *zap.Logger
+}
+
+// NewUnlockAttemptGuard 创建开锁码尝试限制，redisClient 为 nil 时使用数据库计数
+func NewUnlockAttemptGuard(db *gorm.DB, redisClient *redis.Client, maxAttempts int, logger *zap.Logger) *UnlockAttemptGuard {
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxUnlockAttempts
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	var store unlockAttemptStore
+	if redisClient != nil {
+		store = &redisUnlockAttemptStore{
+			limiter: cache.NewAttemptLimiter(redisClient, unlockAttemptKeyPrefix, maxAttempts, UnlockLockoutDuration),
+		}
+	} else {
+		store = &dbUnlockAttemptStore{db: db, maxAttempts: maxAttempts, lockout: UnlockLockoutDuration, now: time.Now}
+	}
+
+	return &UnlockAttemptGuard{store: store, logger: logger}
+}
+
+// Check 设备处于锁定期时返回 ErrUnlockLockedOut
+func (g *UnlockAttemptGuard) Check(ctx context.Context, deviceID int64, source string) error {
+	remaining, err := g.store.lockedFor(ctx, deviceID)
+	if err != nil {
+		g.logger.Error("查询开锁锁定状态失败", zap.Int64("device_id", deviceID), zap.Error(err))
+		return nil
+	}
+	if remaining > 0 {
+		g.logger.Warn("设备开锁已锁定，拒绝开锁尝试",
+			zap.Int64("device_id", deviceID),
+			zap.String("source", source),
+			zap.Duration("remaining", remaining),
+		)
+		return errors.ErrUnlockLockedOut
+	}
+	return nil
+}
+
+// Fail 记录一次开锁码错误，达到上限时锁定设备并返回 ErrUnlockLockedOut
+func (g *UnlockAttemptGuard) Fail(ctx context.Context, deviceID int64, source string) error {
+	failures, locked, err := g.store.recordFailure(ctx, deviceID)
+	if err != nil {
+		g.logger.Error("记录开锁失败次数失败", zap.Int64("device_id", deviceID), zap.Error(err))
+		return nil
+	}
+
+	g.logger.Warn("开锁码错误",
+		zap.Int64("device_id", deviceID),
+		zap.String("source", source),
+		zap.Int64("failures", failures),
+	)
+	if locked {
+		g.logger.Warn("开锁码错误次数过多，设备开锁已锁定",
+			zap.Int64("device_id", deviceID),
+			zap.String("source", source),
+			zap.Duration("lockout", UnlockLockoutDuration),
+		)
+		return errors.ErrUnlockLockedOut
+	}
+	return nil
+}
+
+// Succeed 开锁成功，清零设备的失败计数
+func (g *UnlockAttemptGuard) Succeed(ctx context.Context, deviceID int64, source string) {
+	g.logger.Info("开锁码开锁成功", zap.Int64("device_id", deviceID), zap.String("source", source))
+	if err := g.store.reset(ctx, deviceID); err != nil {
+		g.logger.Error("清零开锁失败次数失败", zap.Int64("device_id", deviceID), zap.Error(err))
+	}
+}
+
+// redisUnlockAttemptStore 基于 Redis 的开锁失败计数
+type redisUnlockAttemptStore struct {
+	limiter *cache.AttemptLimiter
+}
+
+func (s *redisUnlockAttemptStore) lockedFor(ctx context.Context, deviceID int64) (time.Duration, error) {
+	return s.limiter.LockedFor(ctx, strconv.FormatInt(deviceID, 10))
+}
+
+func (s *redisUnlockAttemptStore) recordFailure(ctx context.Context, deviceID int64) (int64, bool, error) {
+	return s.limiter.RecordFailure(ctx, strconv.FormatInt(deviceID, 10))
+}
+
+func (s *redisUnlockAttemptStore) reset(ctx context.Context, deviceID int64) error {
+	return s.limiter.Reset(ctx, strconv.FormatInt(deviceID, 10))
+}
+
+// dbUnlockAttemptStore 基于数据库 unlock_attempts 表的开锁失败计数
+type dbUnlockAttemptStore struct {
+	db          *gorm.DB
+	maxAttempts int
+	lockout     time.Duration
+	now         func() time.Time
+}
+
+func (s *dbUnlockAttemptStore) lockedFor(ctx context.Context, deviceID int64) (time.Duration, error) {
+	var attempt models.UnlockAttempt
+	err := s.db.WithContext(ctx).Where("device_id = ?", deviceID).First(&attempt).Error
+	if err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	if attempt.LockedUntil == nil {
+		return 0, nil
+	}
+	if remaining := attempt.LockedUntil.Sub(s.now()); remaining > 0 {
+		return remaining, nil
+	}
+	return 0, nil
+}
+
+// recordFailure 在一个事务中原子地累加失败次数：先确保计数行存在，再用一条条件 UPDATE 完成窗口过期重置与计数累加，
+// 并发失败时由数据库行锁串行化，不会出现读取后覆盖写入导致的计数丢失；达到上限时以条件更新设置锁定，只有一个请求触发锁定
+func (s *dbUnlockAttemptStore) recordFailure(ctx context.Context, deviceID int64) (int64, bool, error) {
+	var failures int64
+	var locked bool
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.UnlockAttempt{DeviceID: deviceID}).Error; err != nil {
+			return err
+		}
+
+		now := s.now()
+		// 计数窗口已过期（或尚未开始）时从 1 重新计数
+		windowExpired := "first_failed_at IS NULL OR first_failed_at <= ?"
+		windowStart := now.Add(-s.lockout)
+		if err := tx.Model(&models.UnlockAttempt{}).
+			Where("device_id = ?", deviceID).
+			Updates(map[string]interface{}{
+				"failed_count":    gorm.Expr("CASE WHEN "+windowExpired+" THEN 1 ELSE failed_count + 1 END", windowStart),
+				"first_failed_at": gorm.Expr("CASE WHEN "+windowExpired+" THEN ? ELSE first_failed_at END", windowStart, now),
+			}).Error; err != nil {
+			return err
+		}
+
+		var attempt models.UnlockAttempt
+		if err := tx.Where("device_id = ?", deviceID).First(&attempt).Error; err != nil {
+			return err
+		}
+		failures = int64(attempt.FailedCount)
+		if attempt.FailedCount < s.maxAttempts {
+			return nil
+		}
+
+		res := tx.Model(&models.UnlockAttempt{}).
+			Where("device_id = ? AND failed_count >= ?", deviceID, s.maxAttempts).
+			Updates(map[string]interface{}{
+				"locked_until":    now.Add(s.lockout),
+				"failed_count":    0,
+				"first_failed_at": nil,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		locked = res.RowsAffected > 0
+		return nil
+	})
+
+	return failures, locked, err
+}
+
+func (s *dbUnlockAttemptStore) reset(ctx context.Context, deviceID int64) error {
+	return s.db.WithContext(ctx).Model(&models.UnlockAttempt{}).
+		Where("device_id = ?", deviceID).
+		Updates(map[string]interface{}{
+			"failed_count":    0,
+			"first_failed_at": nil,
+		}).Error
+}
@@ -0,0 +1,126 @@
+// Package hotel 开锁码尝试限制单元测试
+package hotel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// failUnlock 使用错误开锁码尝试开锁 n 次，返回最后一次的错误
+func failUnlock(t *testing.T, svc *testBookingService, deviceID int64, n int) error {
+	t.Helper()
+
+	var err error
+	for i := 0; i < n; i++ {
+		_, err = svc.UnlockByCode(context.Background(), deviceID, "000000", "test")
+		require.Error(t, err)
+	}
+	return err
+}
+
+func TestBookingService_UnlockByCode_RedisLockout(t *testing.T) {
+	svc, _, device, booking := setupUnlockTest(t)
+	ctx := context.Background()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	svc.SetUnlockAttemptGuard(NewUnlockAttemptGuard(svc.db, client, DefaultMaxUnlockAttempts, nil))
+
+	t.Run("连续错误达到上限后锁定", func(t *testing.T) {
+		err := failUnlock(t, svc, device.ID, DefaultMaxUnlockAttempts-1)
+		assert.ErrorIs(t, err, appErrors.ErrUnlockCodeInvalid)
+
+		err = failUnlock(t, svc, device.ID, 1)
+		assert.ErrorIs(t, err, appErrors.ErrUnlockLockedOut)
+
+		// 锁定期间正确的开锁码同样被拒绝
+		_, err = svc.UnlockByCode(ctx, device.ID, booking.UnlockCode, "test")
+		assert.ErrorIs(t, err, appErrors.ErrUnlockLockedOut)
+	})
+
+	t.Run("锁定过期后可正常开锁", func(t *testing.T) {
+		mr.FastForward(UnlockLockoutDuration)
+
+		info, err := svc.UnlockByCode(ctx, device.ID, booking.UnlockCode, "test")
+		require.NoError(t, err)
+		assert.Equal(t, models.BookingStatusInUse, info.Status)
+	})
+}
+
+func TestBookingService_UnlockByCode_DBLockout(t *testing.T) {
+	svc, _, device, booking := setupUnlockTest(t)
+	require.NoError(t, svc.db.AutoMigrate(&models.UnlockAttempt{}))
+	ctx := context.Background()
+
+	guard := NewUnlockAttemptGuard(svc.db, nil, DefaultMaxUnlockAttempts, nil)
+	store := guard.store.(*dbUnlockAttemptStore)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	svc.SetUnlockAttemptGuard(guard)
+
+	t.Run("开锁成功后清零失败计数", func(t *testing.T) {
+		err := failUnlock(t, svc, device.ID, DefaultMaxUnlockAttempts-1)
+		assert.ErrorIs(t, err, appErrors.ErrUnlockCodeInvalid)
+
+		_, err = svc.UnlockByCode(ctx, device.ID, booking.UnlockCode, "test")
+		require.NoError(t, err)
+
+		var attempt models.UnlockAttempt
+		require.NoError(t, svc.db.First(&attempt, "device_id = ?", device.ID).Error)
+		assert.Zero(t, attempt.FailedCount)
+
+		// 清零后需重新累计到上限才会锁定
+		err = failUnlock(t, svc, device.ID, DefaultMaxUnlockAttempts-1)
+		assert.ErrorIs(t, err, appErrors.ErrUnlockCodeInvalid)
+	})
+
+	t.Run("连续错误达到上限后锁定", func(t *testing.T) {
+		err := failUnlock(t, svc, device.ID, 1)
+		assert.ErrorIs(t, err, appErrors.ErrUnlockLockedOut)
+
+		now = now.Add(UnlockLockoutDuration - time.Minute)
+		err = failUnlock(t, svc, device.ID, 1)
+		assert.ErrorIs(t, err, appErrors.ErrUnlockLockedOut)
+	})
+
+	t.Run("锁定过期后解除", func(t *testing.T) {
+		now = now.Add(time.Minute)
+
+		err := failUnlock(t, svc, device.ID, 1)
+		assert.ErrorIs(t, err, appErrors.ErrUnlockCodeInvalid)
+
+		var attempt models.UnlockAttempt
+		require.NoError(t, svc.db.First(&attempt, "device_id = ?", device.ID).Error)
+		assert.Equal(t, 1, attempt.FailedCount)
+	})
+}
+
+func TestBookingService_CreateBooking_LongUnlockCode(t *testing.T) {
+	svc := setupTestBookingService(t)
+	ctx := context.Background()
+
+	user, hotel, room, _ := createTestBookingData(t, svc.db)
+	require.NoError(t, svc.db.Model(hotel).Update("long_unlock_code", true).Error)
+
+	info, err := svc.CreateBooking(ctx, user.ID, &CreateBookingRequest{
+		RoomID:        room.ID,
+		DurationHours: 2,
+		CheckInTime:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Len(t, info.UnlockCode, LongUnlockCodeLength)
+}
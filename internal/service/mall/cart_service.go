@@ -50,6 +50,7 @@ type CartItemInfo struct {
 	Selected     bool              `json:"selected"`
 	Stock        int               `json:"stock"`
 	IsOnSale     bool              `json:"is_on_sale"`
+	IsValid      bool              `json:"is_valid"` // 商品或规格已删除时为 false，无法下单
 }
 
 // CartInfo 购物车信息
@@ -74,8 +75,12 @@ type UpdateCartItemRequest struct {
 }
 
 // GetCart 获取购物车
-func (s *CartService) GetCart(ctx context.Context, userID int64) (*CartInfo, error) {
-	items, err := s.cartRepo.ListByUserID(ctx, userID)
+func (s *CartService) GetCart(ctx context.Context, owner repository.CartOwner) (*CartInfo, error) {
+	if err := s.checkOwner(ctx, owner); err != nil {
+		return nil, err
+	}
+
+	items, err := s.cartRepo.ListByOwner(ctx, owner)
 	if err != nil {
 		return nil, errors.ErrDatabaseError.WithError(err)
 	}
@@ -89,7 +94,8 @@ func (s *CartService) GetCart(ctx context.Context, userID int64) (*CartInfo, err
 		cartInfo.Items = append(cartInfo.Items, itemInfo)
 		cartInfo.TotalCount += item.Quantity
 
-		if item.Selected {
+		// 失效的购物车项不计入结算金额
+		if item.Selected && itemInfo.IsValid {
 			cartInfo.SelectedCount += item.Quantity
 			cartInfo.TotalAmount += itemInfo.Subtotal
 		}
@@ -99,7 +105,11 @@ func (s *CartService) GetCart(ctx context.Context, userID int64) (*CartInfo, err
 }
 
 // AddItem 添加商品到购物车
-func (s *CartService) AddItem(ctx context.Context, userID int64, req *AddCartItemRequest) (*CartItemInfo, error) {
+func (s *CartService) AddItem(ctx context.Context, owner repository.CartOwner, req *AddCartItemRequest) (*CartItemInfo, error) {
+	if err := s.checkOwner(ctx, owner); err != nil {
+		return nil, err
+	}
+
 	// 检查商品是否存在且上架
 	product, err := s.productRepo.GetByID(ctx, req.ProductID)
 	if err != nil {
@@ -131,7 +141,7 @@ func (s *CartService) AddItem(ctx context.Context, userID int64, req *AddCartIte
 	}
 
 	// 检查购物车是否已有该商品
-	existingItem, err := s.cartRepo.GetByUserIDAndProductSku(ctx, userID, req.ProductID, req.SkuID)
+	existingItem, err := s.cartRepo.GetByOwnerAndProductSku(ctx, owner, req.ProductID, req.SkuID)
 	if err != nil && err != gorm.ErrRecordNotFound {
 		return nil, errors.ErrDatabaseError.WithError(err)
 	}
@@ -147,12 +157,15 @@ func (s *CartService) AddItem(ctx context.Context, userID int64, req *AddCartIte
 
 	// 创建新购物车项
 	item := &models.CartItem{
-		UserID:    userID,
+		UserID:    owner.UserID,
 		ProductID: req.ProductID,
 		SkuID:     req.SkuID,
 		Quantity:  req.Quantity,
 		Selected:  true,
 	}
+	if owner.IsGuest() {
+		item.GuestToken = &owner.GuestToken
+	}
 
 	if err := s.cartRepo.Create(ctx, item); err != nil {
 		return nil, errors.ErrDatabaseError.WithError(err)
@@ -162,7 +175,11 @@ func (s *CartService) AddItem(ctx context.Context, userID int64, req *AddCartIte
 }
 
 // UpdateItem 更新购物车项
-func (s *CartService) UpdateItem(ctx context.Context, userID, itemID int64, req *UpdateCartItemRequest) (*CartItemInfo, error) {
+func (s *CartService) UpdateItem(ctx context.Context, owner repository.CartOwner, itemID int64, req *UpdateCartItemRequest) (*CartItemInfo, error) {
+	if err := s.checkOwner(ctx, owner); err != nil {
+		return nil, err
+	}
+
 	item, err := s.cartRepo.GetByID(ctx, itemID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -171,7 +188,7 @@ func (s *CartService) UpdateItem(ctx context.Context, userID, itemID int64, req
 		return nil, errors.ErrDatabaseError.WithError(err)
 	}
 
-	if item.UserID != userID {
+	if !owner.Owns(item) {
 		return nil, errors.ErrResourceNotFound
 	}
 
@@ -193,7 +210,11 @@ func (s *CartService) UpdateItem(ctx context.Context, userID, itemID int64, req
 }
 
 // RemoveItem 移除购物车项
-func (s *CartService) RemoveItem(ctx context.Context, userID, itemID int64) error {
+func (s *CartService) RemoveItem(ctx context.Context, owner repository.CartOwner, itemID int64) error {
+	if err := s.checkOwner(ctx, owner); err != nil {
+		return err
+	}
+
 	item, err := s.cartRepo.GetByID(ctx, itemID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -202,7 +223,7 @@ func (s *CartService) RemoveItem(ctx context.Context, userID, itemID int64) erro
 		return errors.ErrDatabaseError.WithError(err)
 	}
 
-	if item.UserID != userID {
+	if !owner.Owns(item) {
 		return errors.ErrResourceNotFound
 	}
 
@@ -215,8 +236,11 @@ func (s *CartService) RemoveItems(ctx context.Context, userID int64, itemIDs []i
 }
 
 // ClearCart 清空购物车
-func (s *CartService) ClearCart(ctx context.Context, userID int64) error {
-	return s.cartRepo.DeleteByUserID(ctx, userID)
+func (s *CartService) ClearCart(ctx context.Context, owner repository.CartOwner) error {
+	if err := s.checkOwner(ctx, owner); err != nil {
+		return err
+	}
+	return s.cartRepo.DeleteByOwner(ctx, owner)
 }
 
 // ClearSelected 清空选中项
@@ -225,8 +249,11 @@ func (s *CartService) ClearSelected(ctx context.Context, userID int64) error {
 }
 
 // SelectAll 全选
-func (s *CartService) SelectAll(ctx context.Context, userID int64, selected bool) error {
-	return s.cartRepo.UpdateAllSelected(ctx, userID, selected)
+func (s *CartService) SelectAll(ctx context.Context, owner repository.CartOwner, selected bool) error {
+	if err := s.checkOwner(ctx, owner); err != nil {
+		return err
+	}
+	return s.cartRepo.UpdateAllSelectedByOwner(ctx, owner, selected)
 }
 
 // GetSelectedItems 获取选中的购物车项
@@ -244,8 +271,12 @@ func (s *CartService) GetSelectedItems(ctx context.Context, userID int64) ([]*Ca
 }
 
 // GetCartCount 获取购物车商品数量
-func (s *CartService) GetCartCount(ctx context.Context, userID int64) (int, error) {
-	count, err := s.cartRepo.SumQuantityByUserID(ctx, userID)
+func (s *CartService) GetCartCount(ctx context.Context, owner repository.CartOwner) (int, error) {
+	if err := s.checkOwner(ctx, owner); err != nil {
+		return 0, err
+	}
+
+	count, err := s.cartRepo.SumQuantityByOwner(ctx, owner)
 	if err != nil {
 		return 0, errors.ErrDatabaseError.WithError(err)
 	}
@@ -268,8 +299,8 @@ func (s *CartService) getCartItemInfo(ctx context.Context, itemID int64) (*CartI
 	// 预加载关联数据
 	var fullItem models.CartItem
 	err = s.db.WithContext(ctx).
-		Preload("Product").
-		Preload("Sku").
+		Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Sku", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
 		First(&fullItem, itemID).Error
 	if err != nil {
 		// 如果加载失败，返回基本信息
@@ -297,6 +328,12 @@ func (s *CartService) toCartItemInfo(item *models.CartItem) *CartItemInfo {
 		Selected:  item.Selected,
 	}
 
+	// 商品或所选规格已删除的购物车项保留展示，但标记为失效
+	info.IsValid = item.Product != nil && !item.Product.DeletedAt.Valid
+	if item.SkuID != nil && *item.SkuID > 0 && (item.Sku == nil || item.Sku.DeletedAt.Valid) {
+		info.IsValid = false
+	}
+
 	// 商品信息
 	if item.Product != nil {
 		info.ProductName = item.Product.Name
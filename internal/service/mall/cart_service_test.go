@@ -39,6 +39,7 @@ func setupCartServiceTestDB(t *testing.T) *gorm.DB {
 		&models.Product{},
 		&models.ProductSku{},
 		&models.CartItem{},
+		&models.GuestCartToken{},
 	)
 	require.NoError(t, err)
 
@@ -110,7 +111,7 @@ func TestCartService_AddItem_NewProduct(t *testing.T) {
 	user, product, _ := seedCartTestData(t, db)
 
 	// 添加商品到购物车
-	item, err := svc.AddItem(ctx, user.ID, &AddCartItemRequest{
+	item, err := svc.AddItem(ctx, repository.UserCartOwner(user.ID), &AddCartItemRequest{
 		ProductID: product.ID,
 		Quantity:  2,
 	})
@@ -130,7 +131,7 @@ func TestCartService_AddItem_WithSku(t *testing.T) {
 
 	// 添加带 SKU 的商品
 	skuID := sku.ID
-	item, err := svc.AddItem(ctx, user.ID, &AddCartItemRequest{
+	item, err := svc.AddItem(ctx, repository.UserCartOwner(user.ID), &AddCartItemRequest{
 		ProductID: product.ID,
 		SkuID:     &skuID,
 		Quantity:  1,
@@ -148,14 +149,14 @@ func TestCartService_AddItem_UpdateExistingQuantity(t *testing.T) {
 	user, product, _ := seedCartTestData(t, db)
 
 	// 第一次添加
-	_, err := svc.AddItem(ctx, user.ID, &AddCartItemRequest{
+	_, err := svc.AddItem(ctx, repository.UserCartOwner(user.ID), &AddCartItemRequest{
 		ProductID: product.ID,
 		Quantity:  2,
 	})
 	require.NoError(t, err)
 
 	// 再次添加同一商品
-	item, err := svc.AddItem(ctx, user.ID, &AddCartItemRequest{
+	item, err := svc.AddItem(ctx, repository.UserCartOwner(user.ID), &AddCartItemRequest{
 		ProductID: product.ID,
 		Quantity:  3,
 	})
@@ -170,7 +171,7 @@ func TestCartService_AddItem_ProductNotFound(t *testing.T) {
 
 	user, _, _ := seedCartTestData(t, db)
 
-	_, err := svc.AddItem(ctx, user.ID, &AddCartItemRequest{
+	_, err := svc.AddItem(ctx, repository.UserCartOwner(user.ID), &AddCartItemRequest{
 		ProductID: 99999,
 		Quantity:  1,
 	})
@@ -201,7 +202,7 @@ func TestCartService_AddItem_ProductOffShelf(t *testing.T) {
 	// 使用 Updates 更新 IsOnSale 为 false
 	require.NoError(t, db.Model(offShelfProduct).Update("is_on_sale", false).Error)
 
-	_, err := svc.AddItem(ctx, user.ID, &AddCartItemRequest{
+	_, err := svc.AddItem(ctx, repository.UserCartOwner(user.ID), &AddCartItemRequest{
 		ProductID: offShelfProduct.ID,
 		Quantity:  1,
 	})
@@ -243,7 +244,7 @@ func TestCartService_AddItem_SkuNotBelongToProduct(t *testing.T) {
 
 	// 尝试使用不属于该商品的 SKU
 	skuID := anotherSku.ID
-	_, err := svc.AddItem(ctx, user.ID, &AddCartItemRequest{
+	_, err := svc.AddItem(ctx, repository.UserCartOwner(user.ID), &AddCartItemRequest{
 		ProductID: product.ID,
 		SkuID:     &skuID,
 		Quantity:  1,
@@ -268,7 +269,7 @@ func TestCartService_GetCart(t *testing.T) {
 		Selected:  true,
 	}).Error)
 
-	cart, err := svc.GetCart(ctx, user.ID)
+	cart, err := svc.GetCart(ctx, repository.UserCartOwner(user.ID))
 	require.NoError(t, err)
 	assert.Len(t, cart.Items, 1)
 	assert.Equal(t, 2, cart.TotalCount)
@@ -282,7 +283,7 @@ func TestCartService_GetCart_Empty(t *testing.T) {
 
 	user, _, _ := seedCartTestData(t, db)
 
-	cart, err := svc.GetCart(ctx, user.ID)
+	cart, err := svc.GetCart(ctx, repository.UserCartOwner(user.ID))
 	require.NoError(t, err)
 	assert.Empty(t, cart.Items)
 	assert.Equal(t, 0, cart.TotalCount)
@@ -304,7 +305,7 @@ func TestCartService_GetCart_CalculateSubtotal(t *testing.T) {
 	}
 	require.NoError(t, db.Create(cartItem).Error)
 
-	cart, err := svc.GetCart(ctx, user.ID)
+	cart, err := svc.GetCart(ctx, repository.UserCartOwner(user.ID))
 	require.NoError(t, err)
 	assert.Len(t, cart.Items, 1)
 	// 小计 = 价格 * 数量 = 80.0 * 3 = 240.0
@@ -329,7 +330,7 @@ func TestCartService_UpdateItem_Quantity(t *testing.T) {
 	require.NoError(t, db.Create(cartItem).Error)
 
 	// 更新数量
-	updated, err := svc.UpdateItem(ctx, user.ID, cartItem.ID, &UpdateCartItemRequest{
+	updated, err := svc.UpdateItem(ctx, repository.UserCartOwner(user.ID), cartItem.ID, &UpdateCartItemRequest{
 		Quantity: 5,
 	})
 	require.NoError(t, err)
@@ -353,7 +354,7 @@ func TestCartService_UpdateItem_Selected(t *testing.T) {
 
 	// 取消选中
 	selected := false
-	updated, err := svc.UpdateItem(ctx, user.ID, cartItem.ID, &UpdateCartItemRequest{
+	updated, err := svc.UpdateItem(ctx, repository.UserCartOwner(user.ID), cartItem.ID, &UpdateCartItemRequest{
 		Quantity: 2,
 		Selected: &selected,
 	})
@@ -388,7 +389,7 @@ func TestCartService_UpdateItem_NotOwned(t *testing.T) {
 	require.NoError(t, db.Create(cartItem).Error)
 
 	// 尝试更新不属于自己的购物车项
-	_, err := svc.UpdateItem(ctx, user.ID, cartItem.ID, &UpdateCartItemRequest{
+	_, err := svc.UpdateItem(ctx, repository.UserCartOwner(user.ID), cartItem.ID, &UpdateCartItemRequest{
 		Quantity: 5,
 	})
 	assert.Error(t, err)
@@ -411,7 +412,7 @@ func TestCartService_RemoveItem(t *testing.T) {
 	}
 	require.NoError(t, db.Create(cartItem).Error)
 
-	err := svc.RemoveItem(ctx, user.ID, cartItem.ID)
+	err := svc.RemoveItem(ctx, repository.UserCartOwner(user.ID), cartItem.ID)
 	require.NoError(t, err)
 
 	// 验证已删除
@@ -445,7 +446,7 @@ func TestCartService_RemoveItem_NotOwned(t *testing.T) {
 	}
 	require.NoError(t, db.Create(cartItem).Error)
 
-	err := svc.RemoveItem(ctx, user.ID, cartItem.ID)
+	err := svc.RemoveItem(ctx, repository.UserCartOwner(user.ID), cartItem.ID)
 	assert.Error(t, err)
 }
 
@@ -503,7 +504,7 @@ func TestCartService_ClearCart(t *testing.T) {
 		}).Error)
 	}
 
-	err := svc.ClearCart(ctx, user.ID)
+	err := svc.ClearCart(ctx, repository.UserCartOwner(user.ID))
 	require.NoError(t, err)
 
 	var count int64
@@ -555,7 +556,7 @@ func TestCartService_SelectAll(t *testing.T) {
 	require.NoError(t, db.Model(item2).Update("selected", false).Error)
 
 	// 全选
-	err := svc.SelectAll(ctx, user.ID, true)
+	err := svc.SelectAll(ctx, repository.UserCartOwner(user.ID), true)
 	require.NoError(t, err)
 
 	var items []models.CartItem
@@ -565,7 +566,7 @@ func TestCartService_SelectAll(t *testing.T) {
 	}
 
 	// 取消全选
-	err = svc.SelectAll(ctx, user.ID, false)
+	err = svc.SelectAll(ctx, repository.UserCartOwner(user.ID), false)
 	require.NoError(t, err)
 
 	db.Where("user_id = ?", user.ID).Find(&items)
@@ -612,7 +613,7 @@ func TestCartService_GetCartCount(t *testing.T) {
 	require.NoError(t, db.Create(&models.CartItem{UserID: user.ID, ProductID: product.ID, Quantity: 2, Selected: true}).Error)
 	require.NoError(t, db.Create(&models.CartItem{UserID: user.ID, ProductID: product.ID, Quantity: 3, Selected: true}).Error)
 
-	count, err := svc.GetCartCount(ctx, user.ID)
+	count, err := svc.GetCartCount(ctx, repository.UserCartOwner(user.ID))
 	require.NoError(t, err)
 	assert.Equal(t, 5, count) // 2 + 3
 }
@@ -636,7 +637,7 @@ func TestCartService_GetCart_WithSku(t *testing.T) {
 	}
 	require.NoError(t, db.Create(cartItem).Error)
 
-	cart, err := svc.GetCart(ctx, user.ID)
+	cart, err := svc.GetCart(ctx, repository.UserCartOwner(user.ID))
 	require.NoError(t, err)
 	require.Len(t, cart.Items, 1)
 	assert.NotNil(t, cart.Items[0].SkuID)
@@ -664,11 +665,11 @@ func TestCartService_GetCart_ProductDeleted(t *testing.T) {
 	require.NoError(t, db.Delete(product).Error)
 
 	// 获取购物车，应该能处理商品不存在的情况
-	cart, err := svc.GetCart(ctx, user.ID)
+	cart, err := svc.GetCart(ctx, repository.UserCartOwner(user.ID))
 	require.NoError(t, err)
-	// 商品不存在时，购物车项也应该被过滤掉或标记为无效
-	// 这取决于实现，但不应该报错
-	assert.NotNil(t, cart)
+	// 商品已删除时，购物车项保留并标记为无效
+	require.Len(t, cart.Items, 1)
+	assert.False(t, cart.Items[0].IsValid)
 }
 
 func TestCartService_GetSelectedItems_Empty(t *testing.T) {
@@ -695,7 +696,7 @@ func TestCartService_GetCartCount_Empty(t *testing.T) {
 
 	user, _, _ := seedCartTestData(t, db)
 
-	count, err := svc.GetCartCount(ctx, user.ID)
+	count, err := svc.GetCartCount(ctx, repository.UserCartOwner(user.ID))
 	require.NoError(t, err)
 	assert.Equal(t, 0, count)
 }
@@ -708,7 +709,7 @@ func TestCartService_AddItem_ZeroQuantity(t *testing.T) {
 	user, product, _ := seedCartTestData(t, db)
 
 	// 添加数量为 0 的商品 - 应该报错或被拒绝
-	_, err := svc.AddItem(ctx, user.ID, &AddCartItemRequest{
+	_, err := svc.AddItem(ctx, repository.UserCartOwner(user.ID), &AddCartItemRequest{
 		ProductID: product.ID,
 		Quantity:  0,
 	})
@@ -723,7 +724,7 @@ func TestCartService_UpdateItem_NotFound(t *testing.T) {
 
 	user, _, _ := seedCartTestData(t, db)
 
-	_, err := svc.UpdateItem(ctx, user.ID, 99999, &UpdateCartItemRequest{
+	_, err := svc.UpdateItem(ctx, repository.UserCartOwner(user.ID), 99999, &UpdateCartItemRequest{
 		Quantity: 5,
 	})
 	assert.Error(t, err)
@@ -736,7 +737,7 @@ func TestCartService_RemoveItem_NotFound(t *testing.T) {
 
 	user, _, _ := seedCartTestData(t, db)
 
-	err := svc.RemoveItem(ctx, user.ID, 99999)
+	err := svc.RemoveItem(ctx, repository.UserCartOwner(user.ID), 99999)
 	assert.Error(t, err)
 }
 
@@ -748,7 +749,7 @@ func TestCartService_AddItem_StockInsufficient(t *testing.T) {
 	user, product, _ := seedCartTestData(t, db)
 
 	// 尝试添加超过库存的数量 - product.Stock = 50
-	_, err := svc.AddItem(ctx, user.ID, &AddCartItemRequest{
+	_, err := svc.AddItem(ctx, repository.UserCartOwner(user.ID), &AddCartItemRequest{
 		ProductID: product.ID,
 		Quantity:  100, // 超过库存
 	})
@@ -871,7 +872,7 @@ func TestCartService_UpdateItem_QuantityAndSelected(t *testing.T) {
 
 	// 同时更新数量和选中状态
 	selected := false
-	updated, err := svc.UpdateItem(ctx, user.ID, cartItem.ID, &UpdateCartItemRequest{
+	updated, err := svc.UpdateItem(ctx, repository.UserCartOwner(user.ID), cartItem.ID, &UpdateCartItemRequest{
 		Quantity: 10,
 		Selected: &selected,
 	})
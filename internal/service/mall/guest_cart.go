@@ -0,0 +1,178 @@
+package mall
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+const (
+	// GuestCartTTL 游客购物车在最后一次修改后的保留时长
+	GuestCartTTL = 7 * 24 * time.Hour
+	// mergedGuestTokenRetention 已合并令牌的保留时长，期间该令牌不能再用于游客购物车
+	mergedGuestTokenRetention = 30 * 24 * time.Hour
+)
+
+// guestTokenPattern 客户端生成的购物车令牌格式
+var guestTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{16,64}$`)
+
+// CappedCartItem 合并时因库存不足被截断的购物车项
+type CappedCartItem struct {
+	ProductID         int64  `json:"product_id"`
+	SkuID             *int64 `json:"sku_id,omitempty"`
+	RequestedQuantity int    `json:"requested_quantity"` // 用户与游客购物车数量之和
+	Quantity          int    `json:"quantity"`           // 合并后的数量，为 0 表示未加入用户购物车
+	Stock             int    `json:"stock"`              // 可用库存，商品或规格已删除时为 0
+}
+
+// MergeGuestCartResult 游客购物车合并结果
+type MergeGuestCartResult struct {
+	MergedCount int               `json:"merged_count"` // 合并的游客购物车项数
+	CappedItems []*CappedCartItem `json:"capped_items"`
+}
+
+// checkOwner 校验购物车归属，游客令牌需格式合法且尚未合并到用户购物车
+func (s *CartService) checkOwner(ctx context.Context, owner repository.CartOwner) error {
+	if !owner.IsGuest() {
+		return nil
+	}
+	if !guestTokenPattern.MatchString(owner.GuestToken) {
+		return errors.ErrCartTokenInvalid
+	}
+
+	_, err := s.cartRepo.GetMergedGuestToken(ctx, owner.GuestToken)
+	if err == nil {
+		return errors.ErrCartTokenInvalid
+	}
+	if err != gorm.ErrRecordNotFound {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	return nil
+}
+
+// MergeGuestCart 将游客购物车合并到用户购物车，合并后该令牌不能再用于游客购物车
+// 同一商品规格两边都有时数量相加，合并后的数量不超过可用库存（不减少用户购物车原有数量），被截断的项在结果中返回；
+// 同一令牌重复合并到同一用户时直接返回空结果
+func (s *CartService) MergeGuestCart(ctx context.Context, guestToken string, userID int64) (*MergeGuestCartResult, error) {
+	if userID <= 0 || !guestTokenPattern.MatchString(guestToken) {
+		return nil, errors.ErrCartTokenInvalid
+	}
+	if result, done, err := s.mergedGuestCartResult(ctx, guestToken, userID); done {
+		return result, err
+	}
+
+	result := &MergeGuestCartResult{CappedItems: make([]*CappedCartItem, 0)}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		cartRepo := repository.NewCartRepository(tx)
+		if err := cartRepo.CreateMergedGuestToken(ctx, &models.GuestCartToken{
+			Token:    guestToken,
+			UserID:   userID,
+			MergedAt: time.Now(),
+		}); err != nil {
+			return err
+		}
+
+		guestItems, err := cartRepo.ListByOwner(ctx, repository.GuestCartOwner(guestToken))
+		if err != nil {
+			return err
+		}
+
+		userOwner := repository.UserCartOwner(userID)
+		for _, item := range guestItems {
+			existing, err := cartRepo.GetByOwnerAndProductSku(ctx, userOwner, item.ProductID, item.SkuID)
+			if err != nil && err != gorm.ErrRecordNotFound {
+				return err
+			}
+
+			existingQuantity := 0
+			if existing != nil {
+				existingQuantity = existing.Quantity
+			}
+			requested := existingQuantity + item.Quantity
+			stock := availableStock(item)
+			quantity := min(requested, max(stock, existingQuantity))
+			if quantity < requested {
+				result.CappedItems = append(result.CappedItems, &CappedCartItem{
+					ProductID:         item.ProductID,
+					SkuID:             item.SkuID,
+					RequestedQuantity: requested,
+					Quantity:          quantity,
+					Stock:             stock,
+				})
+			}
+
+			switch {
+			case existing != nil:
+				if err := cartRepo.UpdateQuantity(ctx, existing.ID, quantity); err != nil {
+					return err
+				}
+				err = cartRepo.Delete(ctx, item.ID)
+			case quantity > 0:
+				err = cartRepo.MoveToUser(ctx, item.ID, userID, quantity)
+			default:
+				err = cartRepo.Delete(ctx, item.ID)
+			}
+			if err != nil {
+				return err
+			}
+			result.MergedCount++
+		}
+		return nil
+	})
+	if err != nil {
+		// 并发合并同一令牌时以先提交的为准
+		if merged, done, checkErr := s.mergedGuestCartResult(ctx, guestToken, userID); done {
+			return merged, checkErr
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	return result, nil
+}
+
+// mergedGuestCartResult 检查令牌是否已合并，已合并到同一用户时返回空结果，合并到其他用户时返回令牌失效
+func (s *CartService) mergedGuestCartResult(ctx context.Context, guestToken string, userID int64) (*MergeGuestCartResult, bool, error) {
+	merged, err := s.cartRepo.GetMergedGuestToken(ctx, guestToken)
+	if err == gorm.ErrRecordNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, errors.ErrDatabaseError.WithError(err)
+	}
+	if merged.UserID != userID {
+		return nil, true, errors.ErrCartTokenInvalid
+	}
+	return &MergeGuestCartResult{CappedItems: make([]*CappedCartItem, 0)}, true, nil
+}
+
+// availableStock 购物车项的可用库存，有规格时取规格库存，商品或规格已删除时为 0
+func availableStock(item *models.CartItem) int {
+	if item.Product == nil || item.Product.DeletedAt.Valid {
+		return 0
+	}
+	if item.SkuID != nil && *item.SkuID > 0 {
+		if item.Sku == nil || item.Sku.DeletedAt.Valid {
+			return 0
+		}
+		return item.Sku.Stock
+	}
+	return item.Product.Stock
+}
+
+// CleanupExpiredGuestCarts 删除超过 GuestCartTTL 未修改的游客购物车及过期的已合并令牌记录，返回删除的购物车项数
+func (s *CartService) CleanupExpiredGuestCarts(ctx context.Context) (int64, error) {
+	now := time.Now()
+	deleted, err := s.cartRepo.DeleteGuestCartsInactiveSince(ctx, now.Add(-GuestCartTTL))
+	if err != nil {
+		return 0, errors.ErrDatabaseError.WithError(err)
+	}
+	if _, err := s.cartRepo.DeleteMergedGuestTokensBefore(ctx, now.Add(-mergedGuestTokenRetention)); err != nil {
+		return deleted, errors.ErrDatabaseError.WithError(err)
+	}
+	return deleted, nil
+}
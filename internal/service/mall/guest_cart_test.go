@@ -0,0 +1,240 @@
+package mall
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+const testGuestToken = "guest-token-0123456789abcdef"
+
+func TestCartService_GuestCart(t *testing.T) {
+	db := setupCartServiceTestDB(t)
+	svc := newCartService(db)
+	ctx := context.Background()
+	user, product, sku := seedCartTestData(t, db)
+	guest := repository.GuestCartOwner(testGuestToken)
+
+	item, err := svc.AddItem(ctx, guest, &AddCartItemRequest{ProductID: product.ID, Quantity: 2})
+	require.NoError(t, err)
+	_, err = svc.AddItem(ctx, guest, &AddCartItemRequest{ProductID: product.ID, SkuID: &sku.ID, Quantity: 1})
+	require.NoError(t, err)
+	_, err = svc.AddItem(ctx, guest, &AddCartItemRequest{ProductID: product.ID, Quantity: 1})
+	require.NoError(t, err)
+
+	cart, err := svc.GetCart(ctx, guest)
+	require.NoError(t, err)
+	assert.Len(t, cart.Items, 2)
+	assert.Equal(t, 4, cart.TotalCount)
+
+	var stored models.CartItem
+	require.NoError(t, db.First(&stored, item.ID).Error)
+	assert.Equal(t, int64(0), stored.UserID)
+	require.NotNil(t, stored.GuestToken)
+	assert.Equal(t, testGuestToken, *stored.GuestToken)
+
+	t.Run("更新不改变归属", func(t *testing.T) {
+		selected := false
+		_, err := svc.UpdateItem(ctx, guest, item.ID, &UpdateCartItemRequest{Quantity: 3, Selected: &selected})
+		require.NoError(t, err)
+
+		var updated models.CartItem
+		require.NoError(t, db.First(&updated, item.ID).Error)
+		assert.Equal(t, 3, updated.Quantity)
+		require.NotNil(t, updated.GuestToken)
+		assert.Equal(t, testGuestToken, *updated.GuestToken)
+	})
+
+	t.Run("用户与游客购物车相互隔离", func(t *testing.T) {
+		cart, err := svc.GetCart(ctx, repository.UserCartOwner(user.ID))
+		require.NoError(t, err)
+		assert.Empty(t, cart.Items)
+
+		_, err = svc.UpdateItem(ctx, repository.UserCartOwner(user.ID), item.ID, &UpdateCartItemRequest{Quantity: 1})
+		assert.Equal(t, appErrors.ErrResourceNotFound, err)
+		err = svc.RemoveItem(ctx, repository.GuestCartOwner("another-guest-token-0001"), item.ID)
+		assert.Equal(t, appErrors.ErrResourceNotFound, err)
+	})
+
+	t.Run("令牌格式不合法", func(t *testing.T) {
+		_, err := svc.GetCart(ctx, repository.GuestCartOwner("short"))
+		assert.Equal(t, appErrors.ErrCartTokenInvalid, err)
+		_, err = svc.GetCartCount(ctx, repository.GuestCartOwner("invalid token with spaces!"))
+		assert.Equal(t, appErrors.ErrCartTokenInvalid, err)
+	})
+}
+
+func TestCartService_MergeGuestCart(t *testing.T) {
+	db := setupCartServiceTestDB(t)
+	svc := newCartService(db)
+	ctx := context.Background()
+	user, product, sku := seedCartTestData(t, db)
+	guest := repository.GuestCartOwner(testGuestToken)
+	userCart := repository.UserCartOwner(user.ID)
+
+	// 同一商品两边都有，且都未超过库存
+	_, err := svc.AddItem(ctx, userCart, &AddCartItemRequest{ProductID: product.ID, Quantity: 3})
+	require.NoError(t, err)
+	_, err = svc.AddItem(ctx, guest, &AddCartItemRequest{ProductID: product.ID, Quantity: 4})
+	require.NoError(t, err)
+	// 仅游客购物车有的规格
+	guestSku, err := svc.AddItem(ctx, guest, &AddCartItemRequest{ProductID: product.ID, SkuID: &sku.ID, Quantity: 2})
+	require.NoError(t, err)
+
+	result, err := svc.MergeGuestCart(ctx, testGuestToken, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.MergedCount)
+	assert.Empty(t, result.CappedItems)
+
+	cart, err := svc.GetCart(ctx, userCart)
+	require.NoError(t, err)
+	require.Len(t, cart.Items, 2)
+	quantities := map[int64]int{}
+	for _, item := range cart.Items {
+		if item.SkuID != nil {
+			quantities[*item.SkuID] = item.Quantity
+			// 仅游客有的项直接转移到用户购物车
+			assert.Equal(t, guestSku.ID, item.ID)
+		} else {
+			quantities[0] = item.Quantity
+		}
+	}
+	assert.Equal(t, 7, quantities[0])
+	assert.Equal(t, 2, quantities[sku.ID])
+
+	var guestCount int64
+	require.NoError(t, db.Model(&models.CartItem{}).Where("guest_token IS NOT NULL").Count(&guestCount).Error)
+	assert.Equal(t, int64(0), guestCount)
+
+	t.Run("合并后令牌不可再用", func(t *testing.T) {
+		_, err := svc.GetCart(ctx, guest)
+		assert.Equal(t, appErrors.ErrCartTokenInvalid, err)
+		_, err = svc.AddItem(ctx, guest, &AddCartItemRequest{ProductID: product.ID, Quantity: 1})
+		assert.Equal(t, appErrors.ErrCartTokenInvalid, err)
+		assert.Equal(t, appErrors.ErrCartTokenInvalid, svc.ClearCart(ctx, guest))
+	})
+
+	t.Run("重复合并到同一用户", func(t *testing.T) {
+		result, err := svc.MergeGuestCart(ctx, testGuestToken, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.MergedCount)
+		assert.Empty(t, result.CappedItems)
+
+		count, err := svc.GetCartCount(ctx, userCart)
+		require.NoError(t, err)
+		assert.Equal(t, 9, count)
+	})
+
+	t.Run("合并到其他用户", func(t *testing.T) {
+		_, err := svc.MergeGuestCart(ctx, testGuestToken, user.ID+1)
+		assert.Equal(t, appErrors.ErrCartTokenInvalid, err)
+	})
+}
+
+func TestCartService_MergeGuestCart_StockCap(t *testing.T) {
+	db := setupCartServiceTestDB(t)
+	svc := newCartService(db)
+	ctx := context.Background()
+	user, product, sku := seedCartTestData(t, db)
+	guest := repository.GuestCartOwner(testGuestToken)
+	userCart := repository.UserCartOwner(user.ID)
+
+	// 商品库存 50：用户 30 + 游客 30 截断为 50
+	_, err := svc.AddItem(ctx, userCart, &AddCartItemRequest{ProductID: product.ID, Quantity: 30})
+	require.NoError(t, err)
+	_, err = svc.AddItem(ctx, guest, &AddCartItemRequest{ProductID: product.ID, Quantity: 30})
+	require.NoError(t, err)
+	// 规格库存 20：仅游客有 25 截断为 20
+	_, err = svc.AddItem(ctx, guest, &AddCartItemRequest{ProductID: product.ID, SkuID: &sku.ID, Quantity: 25})
+	require.NoError(t, err)
+	// 规格已删除：不加入用户购物车
+	deletedSku := &models.ProductSku{ProductID: product.ID, SkuCode: "BLUE-L", Attributes: []byte(`{"颜色":"蓝色"}`), Price: 90, Stock: 10, IsActive: true}
+	require.NoError(t, db.Create(deletedSku).Error)
+	_, err = svc.AddItem(ctx, guest, &AddCartItemRequest{ProductID: product.ID, SkuID: &deletedSku.ID, Quantity: 1})
+	require.NoError(t, err)
+	require.NoError(t, db.Delete(deletedSku).Error)
+
+	result, err := svc.MergeGuestCart(ctx, testGuestToken, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.MergedCount)
+	require.Len(t, result.CappedItems, 3)
+
+	capped := map[int64]*CappedCartItem{}
+	for _, item := range result.CappedItems {
+		key := int64(0)
+		if item.SkuID != nil {
+			key = *item.SkuID
+		}
+		capped[key] = item
+	}
+	assert.Equal(t, &CappedCartItem{ProductID: product.ID, RequestedQuantity: 60, Quantity: 50, Stock: 50}, capped[0])
+	assert.Equal(t, &CappedCartItem{ProductID: product.ID, SkuID: &sku.ID, RequestedQuantity: 25, Quantity: 20, Stock: 20}, capped[sku.ID])
+	assert.Equal(t, &CappedCartItem{ProductID: product.ID, SkuID: &deletedSku.ID, RequestedQuantity: 1, Quantity: 0, Stock: 0}, capped[deletedSku.ID])
+
+	cart, err := svc.GetCart(ctx, userCart)
+	require.NoError(t, err)
+	assert.Len(t, cart.Items, 2)
+	assert.Equal(t, 70, cart.TotalCount)
+
+	t.Run("不减少用户原有数量", func(t *testing.T) {
+		db := setupCartServiceTestDB(t)
+		svc := newCartService(db)
+		user, product, _ := seedCartTestData(t, db)
+
+		// 用户购物车原有数量已超过库存
+		_, err := svc.AddItem(ctx, repository.UserCartOwner(user.ID), &AddCartItemRequest{ProductID: product.ID, Quantity: 60})
+		require.NoError(t, err)
+		_, err = svc.AddItem(ctx, guest, &AddCartItemRequest{ProductID: product.ID, Quantity: 5})
+		require.NoError(t, err)
+
+		result, err := svc.MergeGuestCart(ctx, testGuestToken, user.ID)
+		require.NoError(t, err)
+		require.Len(t, result.CappedItems, 1)
+		assert.Equal(t, 65, result.CappedItems[0].RequestedQuantity)
+		assert.Equal(t, 60, result.CappedItems[0].Quantity)
+	})
+}
+
+func TestCartService_CleanupExpiredGuestCarts(t *testing.T) {
+	db := setupCartServiceTestDB(t)
+	svc := newCartService(db)
+	ctx := context.Background()
+	_, product, sku := seedCartTestData(t, db)
+
+	staleToken := "stale-guest-token-000001"
+	activeToken := "active-guest-token-00001"
+	stale := repository.GuestCartOwner(staleToken)
+	active := repository.GuestCartOwner(activeToken)
+
+	_, err := svc.AddItem(ctx, stale, &AddCartItemRequest{ProductID: product.ID, Quantity: 1})
+	require.NoError(t, err)
+	_, err = svc.AddItem(ctx, stale, &AddCartItemRequest{ProductID: product.ID, SkuID: &sku.ID, Quantity: 1})
+	require.NoError(t, err)
+	_, err = svc.AddItem(ctx, active, &AddCartItemRequest{ProductID: product.ID, Quantity: 1})
+	require.NoError(t, err)
+	oldItem, err := svc.AddItem(ctx, active, &AddCartItemRequest{ProductID: product.ID, SkuID: &sku.ID, Quantity: 1})
+	require.NoError(t, err)
+
+	expired := time.Now().Add(-GuestCartTTL - time.Hour)
+	require.NoError(t, db.Model(&models.CartItem{}).Where("guest_token = ?", staleToken).UpdateColumn("updated_at", expired).Error)
+	// 游客购物车按最后修改时间整车保留
+	require.NoError(t, db.Model(&models.CartItem{}).Where("id = ?", oldItem.ID).UpdateColumn("updated_at", expired).Error)
+
+	deleted, err := svc.CleanupExpiredGuestCarts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	count, err := svc.GetCartCount(ctx, stale)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	count, err = svc.GetCartCount(ctx, active)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,51 @@
+package mall
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// recordingOrderEvents 记录订单完成事件的测试桩
+type recordingOrderEvents struct {
+	completed []*models.Order
+}
+
+func (r *recordingOrderEvents) OnOrderCompleted(ctx context.Context, order *models.Order) error {
+	r.completed = append(r.completed, order)
+	return nil
+}
+
+func (r *recordingOrderEvents) OnOrderRefunded(ctx context.Context, order *models.Order) error {
+	return nil
+}
+
+func TestMallOrderService_ConfirmReceive_TriggersOrderCompleted(t *testing.T) {
+	svc, db := setupMallRefundTest(t)
+	ctx := context.Background()
+	events := &recordingOrderEvents{}
+	svc.SetOrderEventHandler(events)
+
+	o := createRefundTestOrder(t, db, 1)
+	require.NoError(t, db.Model(o.order).Update("status", models.OrderStatusShipped).Error)
+
+	require.NoError(t, svc.ConfirmReceive(ctx, 1, o.order.ID))
+
+	var order models.Order
+	require.NoError(t, db.First(&order, o.order.ID).Error)
+	assert.Equal(t, models.OrderStatusCompleted, order.Status)
+
+	require.Len(t, events.completed, 1)
+	assert.Equal(t, o.order.ID, events.completed[0].ID)
+	assert.Equal(t, models.OrderStatusCompleted, events.completed[0].Status)
+	assert.Equal(t, 90.0, events.completed[0].ActualAmount)
+
+	// 状态不允许时不触发事件
+	err := svc.ConfirmReceive(ctx, 1, o.order.ID)
+	require.Error(t, err)
+	assert.Len(t, events.completed, 1)
+}
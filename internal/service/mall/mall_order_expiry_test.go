@@ -0,0 +1,59 @@
+package mall
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	orderService "github.com/dumeirei/smart-locker-backend/internal/service/order"
+)
+
+func TestMallOrderService_ReleaseExpiredOrder(t *testing.T) {
+	svc, db := setupMallRefundTest(t)
+	ctx := context.Background()
+
+	o := createRefundTestOrder(t, db, 1)
+	require.NoError(t, db.Model(o.order).Updates(map[string]interface{}{
+		"status":     models.OrderStatusPending,
+		"created_at": time.Now().Add(-time.Hour),
+	}).Error)
+
+	// 满赠生成的赠品订单随主订单取消
+	giftOrder := &models.Order{OrderNo: "G" + t.Name(), UserID: 1, Type: models.OrderTypeMall, Status: models.OrderStatusPending}
+	require.NoError(t, db.Create(giftOrder).Error)
+	require.NoError(t, db.Create(&models.OrderItem{OrderID: giftOrder.ID, ProductID: &o.productB.ID, ProductName: o.productB.Name, Quantity: 1}).Error)
+	require.NoError(t, db.Create(&models.GiftOrder{MainOrderID: o.order.ID, GiftOrderID: giftOrder.ID, ProductID: o.productB.ID, Quantity: 1}).Error)
+	require.NoError(t, db.Model(giftOrder).Update("created_at", time.Now().Add(-time.Hour)).Error)
+
+	expirySvc := orderService.NewOrderExpiryService(db)
+	expirySvc.SetExpiredOrderHandler(models.OrderTypeMall, svc)
+
+	expired, err := expirySvc.ProcessExpiredOrders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	for _, id := range []int64{o.order.ID, giftOrder.ID} {
+		var order models.Order
+		require.NoError(t, db.First(&order, id).Error)
+		assert.Equal(t, models.OrderStatusCancelled, order.Status, "order %d", id)
+	}
+
+	// 主订单及赠品订单的商品库存恢复
+	var productA, productB models.Product
+	require.NoError(t, db.First(&productA, o.productA.ID).Error)
+	require.NoError(t, db.First(&productB, o.productB.ID).Error)
+	assert.Equal(t, 10, productA.Stock)
+	assert.Equal(t, 7, productB.Stock)
+
+	var userCoupon models.UserCoupon
+	require.NoError(t, db.First(&userCoupon, o.userCoupon.ID).Error)
+	assert.Equal(t, int8(models.UserCouponStatusUnused), userCoupon.Status)
+
+	// 已取消的订单不能再取消
+	err = svc.CancelOrder(ctx, 1, o.order.ID, "重复取消")
+	assert.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package mall
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	marketingService "github.com/dumeirei/smart-locker-backend/internal/service/marketing"
+)
+
+// flashSaleOrderItem 下单时按秒杀价购买的商品
+type flashSaleOrderItem struct {
+	product     *models.CampaignProduct
+	productName string
+	quantity    int
+}
+
+// flashSaleProductTx 获取商品当前进行中的秒杀，未设置活动服务或商品未参加秒杀时返回 nil
+func (s *MallOrderService) flashSaleProductTx(ctx context.Context, tx *gorm.DB, productID int64) (*models.CampaignProduct, error) {
+	if s.campaignService == nil {
+		return nil, nil
+	}
+	return s.campaignService.GetActiveFlashSaleProductTx(ctx, tx, productID)
+}
+
+// reserveFlashSaleStockTx 在下单事务中为按秒杀价购买的商品预占秒杀库存，库存不足、当日名额已抢完或超过每人限购时下单失败
+// 已成功的预占追加到 reserved，下单事务回滚时由 releaseFlashSaleSlots 归还其名额计数
+func (s *MallOrderService) reserveFlashSaleStockTx(ctx context.Context, tx *gorm.DB, order *models.Order, items []*flashSaleOrderItem, reserved *[]*models.FlashSaleReservation) error {
+	for _, item := range items {
+		reservation, err := s.campaignService.ReserveFlashSaleStockTx(ctx, tx, item.product.CampaignID, item.product.ProductID,
+			order.UserID, &order.ID, item.quantity)
+		switch {
+		case err == nil:
+			*reserved = append(*reserved, reservation)
+		case stderrors.Is(err, marketingService.ErrFlashSaleStockSoldOut):
+			return errors.ErrFlashSaleSoldOut.WithMessage(fmt.Sprintf("商品 %s 秒杀库存不足", item.productName))
+		case stderrors.Is(err, marketingService.ErrFlashSaleLimitExceeded):
+			return errors.ErrFlashSaleLimitExceeded.WithMessage(fmt.Sprintf("商品 %s 每人限购 %d 件", item.productName, item.product.PerUserLimit))
+		case stderrors.Is(err, marketingService.ErrFlashSaleSoldOut):
+			return errors.ErrFlashSaleSoldOut.WithMessage(marketingService.ErrFlashSaleSoldOut.Error())
+		case stderrors.Is(err, marketingService.ErrFlashSaleUserLimit):
+			return errors.ErrFlashSaleLimitExceeded.WithMessage(marketingService.ErrFlashSaleUserLimit.Error())
+		case stderrors.Is(err, marketingService.ErrCampaignExpired):
+			return errors.ErrCampaignExpired
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseFlashSaleSlots 下单事务回滚后归还已预占的秒杀名额计数
+func (s *MallOrderService) releaseFlashSaleSlots(ctx context.Context, reserved []*models.FlashSaleReservation) {
+	if s.campaignService == nil || len(reserved) == 0 {
+		return
+	}
+	s.campaignService.ReleaseFlashSaleSlots(ctx, reserved)
+}
+
+// confirmFlashSaleTx 订单支付后确认秒杀库存预占
+func (s *MallOrderService) confirmFlashSaleTx(ctx context.Context, tx *gorm.DB, orderID int64) error {
+	if s.campaignService == nil {
+		return nil
+	}
+	return s.campaignService.ConfirmFlashSaleReservationsTx(ctx, tx, orderID)
+}
+
+// releaseFlashSaleTx 订单取消（含超时未支付自动取消）时释放秒杀库存预占
+func (s *MallOrderService) releaseFlashSaleTx(ctx context.Context, tx *gorm.DB, orderID int64) error {
+	if s.campaignService == nil {
+		return nil
+	}
+	return s.campaignService.ReleaseFlashSaleReservationsTx(ctx, tx, orderID)
+}
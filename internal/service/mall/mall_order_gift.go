@@ -0,0 +1,54 @@
+package mall
+
+import (
+	"context"
+	stderrors "errors"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	marketingService "github.com/dumeirei/smart-locker-backend/internal/service/marketing"
+)
+
+// attachGiftOrderTx 订单实付金额达到进行中满赠活动门槛时，在下单事务中生成零元赠品订单
+// 赠品下架、库存不足或活动规则无效时不赠送，不影响主订单创建
+func (s *MallOrderService) attachGiftOrderTx(ctx context.Context, tx *gorm.DB, order *models.Order) error {
+	if s.discountCalc == nil {
+		return nil
+	}
+
+	gift, err := s.discountCalc.CheckGift(ctx, order.ActualAmount)
+	if err != nil {
+		if stderrors.Is(err, marketingService.ErrCampaignRuleInvalid) {
+			return nil
+		}
+		return err
+	}
+	if gift == nil {
+		return nil
+	}
+
+	_, err = marketingService.CreateGiftOrderTx(tx, &gift.Campaign.ID, order, gift.Item.GiftProductID, gift.Item.GiftQuantity)
+	if stderrors.Is(err, marketingService.ErrGiftProductNotFound) || stderrors.Is(err, marketingService.ErrGiftStockInsufficient) {
+		return nil
+	}
+	return err
+}
+
+// giftOrderIDs 获取主订单关联的赠品订单ID
+func giftOrderIDs(tx *gorm.DB, mainOrderID int64) ([]int64, error) {
+	var ids []int64
+	err := tx.Model(&models.GiftOrder{}).Where("main_order_id = ?", mainOrderID).Pluck("gift_order_id", &ids).Error
+	return ids, err
+}
+
+// syncGiftOrdersPaidTx 主订单支付成功后，赠品订单随之变更为待发货
+func syncGiftOrdersPaidTx(tx *gorm.DB, mainOrderID int64) error {
+	ids, err := giftOrderIDs(tx, mainOrderID)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	return tx.Model(&models.Order{}).
+		Where("id IN ? AND status IN ?", ids, []string{models.OrderStatusPending, models.OrderStatusPaid}).
+		Update("status", models.OrderStatusPendingShip).Error
+}
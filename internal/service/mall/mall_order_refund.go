@@ -0,0 +1,518 @@
+package mall
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	orderService "github.com/dumeirei/smart-locker-backend/internal/service/order"
+)
+
+// defaultPartialRefundReason 退款项均未填写原因时使用的退款原因
+const defaultPartialRefundReason = "部分退款"
+
+// refundOccupyingStatuses 占用可退数量及金额的退款状态，退款申请一旦创建即占用，避免重复提交导致超额退款
+var refundOccupyingStatuses = []int8{
+	models.RefundStatusPending,
+	models.RefundStatusApproved,
+	models.RefundStatusProcessing,
+	models.RefundStatusSuccess,
+}
+
+// refundApprovedStatuses 已审批通过的退款状态，用于判断订单是否已全部退款
+var refundApprovedStatuses = []int8{
+	models.RefundStatusApproved,
+	models.RefundStatusProcessing,
+	models.RefundStatusSuccess,
+}
+
+// PartialRefundItem 部分退款项
+type PartialRefundItem struct {
+	OrderItemID int64  `json:"order_item_id" binding:"required"`
+	Quantity    int    `json:"quantity" binding:"required,min=1"`
+	Reason      string `json:"reason" binding:"max=255"`
+}
+
+// PartialRefundRequest 部分退款请求
+type PartialRefundRequest struct {
+	Items []PartialRefundItem `json:"items" binding:"required,min=1,dive"`
+}
+
+// MallRefundInfo 商城订单退款信息
+type MallRefundInfo struct {
+	ID        int64                 `json:"id"`
+	RefundNo  string                `json:"refund_no"`
+	OrderID   int64                 `json:"order_id"`
+	OrderNo   string                `json:"order_no"`
+	Amount    float64               `json:"amount"`
+	Reason    string                `json:"reason"`
+	Status    int8                  `json:"status"`
+	Items     []*MallRefundItemInfo `json:"items"`
+	CreatedAt string                `json:"created_at"`
+}
+
+// MallRefundItemInfo 商城订单退款明细
+type MallRefundItemInfo struct {
+	OrderItemID int64   `json:"order_item_id"`
+	ProductName string  `json:"product_name"`
+	Quantity    int     `json:"quantity"`
+	Amount      float64 `json:"amount"`
+	Reason      string  `json:"reason,omitempty"`
+}
+
+// RequestPartialRefund 按订单项及数量申请商城订单部分退款
+// 退款数量不得超过订单项购买数量减去已申请退款的数量；退款金额按订单项原价占订单原价的比例分摊优惠后计算，
+// 申请退完订单剩余全部商品时退款金额为订单实付金额减去已申请退款金额，避免分摊舍入误差。
+// 库存恢复、订单状态及优惠券恢复在管理员审批通过时处理（见 ApproveRefund）
+func (s *MallOrderService) RequestPartialRefund(ctx context.Context, userID int64, orderID int64, items []PartialRefundItem) (*MallRefundInfo, error) {
+	if len(items) == 0 {
+		return nil, errors.ErrInvalidParams.WithMessage("退款商品不能为空")
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrOrderNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	if order.UserID != userID || order.Type != models.OrderTypeMall {
+		return nil, errors.ErrOrderNotFound
+	}
+	if !partialRefundAllowed(order.Status) {
+		return nil, errors.ErrOrderStatusError.WithMessage("订单状态不允许申请退款")
+	}
+
+	payment, err := s.paymentRepo.GetByOrder(ctx, orderID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrPaymentNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	var refund *models.Refund
+	var refundItems []*models.RefundItem
+	var orderItems map[int64]*models.OrderItem
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁定订单，串行化同一订单的退款申请
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(order, orderID).Error; err != nil {
+			return err
+		}
+		if !partialRefundAllowed(order.Status) {
+			return errors.ErrOrderStatusError.WithMessage("订单状态不允许申请退款")
+		}
+
+		loaded, err := loadOrderItems(tx, orderID)
+		if err != nil {
+			return err
+		}
+		orderItems = loaded
+
+		refunded, err := refundedQuantities(tx, orderID, refundOccupyingStatuses)
+		if err != nil {
+			return err
+		}
+
+		// 合并同一订单项的退款数量并校验可退数量
+		requested := make(map[int64]int, len(items))
+		reasons := make(map[int64]string, len(items))
+		var itemIDs []int64
+		for _, item := range items {
+			orderItem, ok := orderItems[item.OrderItemID]
+			if !ok {
+				return errors.ErrInvalidParams.WithMessage(fmt.Sprintf("订单项 %d 不存在", item.OrderItemID))
+			}
+			if item.Quantity <= 0 {
+				return errors.ErrInvalidParams.WithMessage("退款数量必须大于0")
+			}
+			if _, seen := requested[item.OrderItemID]; !seen {
+				itemIDs = append(itemIDs, item.OrderItemID)
+			}
+			requested[item.OrderItemID] += item.Quantity
+			if item.Reason != "" && reasons[item.OrderItemID] == "" {
+				reasons[item.OrderItemID] = item.Reason
+			}
+
+			if remaining := orderItem.Quantity - refunded[item.OrderItemID]; requested[item.OrderItemID] > remaining {
+				return errors.ErrRefundQuantityExceed.WithMessage(
+					fmt.Sprintf("商品 %s 可退数量为 %d", orderItem.ProductName, remaining))
+			}
+		}
+
+		// 计算退款金额
+		refundItems = make([]*models.RefundItem, 0, len(itemIDs))
+		var amount float64
+		for _, id := range itemIDs {
+			refundItem := &models.RefundItem{
+				OrderID:     orderID,
+				OrderItemID: id,
+				Quantity:    requested[id],
+				Amount:      allocateRefundAmount(order, orderItems[id], requested[id]),
+			}
+			if reason := reasons[id]; reason != "" {
+				refundItem.Reason = &reason
+			}
+			refundItems = append(refundItems, refundItem)
+			amount += refundItem.Amount
+		}
+
+		var alreadyRefunded float64
+		if err := tx.Model(&models.Refund{}).
+			Where("order_id = ? AND status IN ?", orderID, refundOccupyingStatuses).
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&alreadyRefunded).Error; err != nil {
+			return err
+		}
+		refundable := roundRefundAmount(order.ActualAmount - alreadyRefunded)
+
+		// 退完剩余全部商品时按剩余可退金额退款，差额计入最后一项
+		if fullyRefundedAfter(orderItems, refunded, requested) || amount > refundable {
+			last := refundItems[len(refundItems)-1]
+			last.Amount = roundRefundAmount(last.Amount + refundable - amount)
+			amount = refundable
+		}
+		amount = roundRefundAmount(amount)
+		if amount <= 0 {
+			return errors.ErrRefundAmountExceed.WithMessage("订单已无可退金额")
+		}
+
+		operatorType := models.RefundOperatorUser
+		refund = &models.Refund{
+			RefundNo:     utils.GenerateOrderNo("R"),
+			OrderID:      orderID,
+			OrderNo:      order.OrderNo,
+			PaymentID:    payment.ID,
+			PaymentNo:    payment.PaymentNo,
+			UserID:       userID,
+			Amount:       amount,
+			Reason:       joinRefundReasons(itemIDs, reasons),
+			Status:       models.RefundStatusPending,
+			OperatorID:   &userID,
+			OperatorType: &operatorType,
+		}
+		if err := tx.Create(refund).Error; err != nil {
+			return err
+		}
+
+		for _, item := range refundItems {
+			item.RefundID = refund.ID
+		}
+		return tx.Create(&refundItems).Error
+	})
+	if err != nil {
+		if _, ok := err.(*errors.AppError); ok {
+			return nil, err
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	return toMallRefundInfo(refund, refundItems, orderItems), nil
+}
+
+// ApproveRefund 批准商城订单部分退款（管理端）
+// 恢复退款商品的 SKU 及商品库存；订单商品全部退款后订单变更为已退款并恢复订单使用的优惠券，
+// 已完成的订单全部退款时触发订单退款事件（撤销佣金、扣回积分），部分退款时按剩余实付金额重算佣金
+func (s *MallOrderService) ApproveRefund(ctx context.Context, operatorID int64, refundID int64) error {
+	var order models.Order
+	var refundedAfterCompletion bool
+	var partialRemaining *float64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		refund, refundItems, err := lockPendingMallRefund(tx, refundID, "退款申请状态不允许审批")
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, refund.OrderID).Error; err != nil {
+			return err
+		}
+
+		// 仅更新仍为待处理的退款，并发审批同一退款时只有一次生效
+		result := tx.Model(&models.Refund{}).
+			Where("id = ? AND status = ?", refund.ID, models.RefundStatusPending).
+			Updates(map[string]interface{}{
+				"status":        models.RefundStatusApproved,
+				"operator_id":   operatorID,
+				"operator_type": models.RefundOperatorAdmin,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrOperationFailed.WithMessage("退款申请状态不允许审批")
+		}
+		if err := tx.Create(orderService.NewSystemNote(order.ID, orderService.RefundApprovedNote(refund.Amount), true)).Error; err != nil {
+			return err
+		}
+
+		orderItems, err := loadOrderItems(tx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		// 恢复库存
+		for _, item := range refundItems {
+			orderItem, ok := orderItems[item.OrderItemID]
+			if !ok {
+				continue
+			}
+			if orderItem.SkuID != nil {
+				if err := tx.Model(&models.ProductSku{}).Where("id = ?", *orderItem.SkuID).
+					UpdateColumn("stock", gorm.Expr("stock + ?", item.Quantity)).Error; err != nil {
+					return err
+				}
+			}
+			if orderItem.ProductID != nil {
+				if err := tx.Model(&models.Product{}).Where("id = ?", *orderItem.ProductID).
+					UpdateColumn("stock", gorm.Expr("stock + ?", item.Quantity)).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		approved, err := refundedQuantities(tx, order.ID, refundApprovedStatuses)
+		if err != nil {
+			return err
+		}
+		if !fullyRefundedAfter(orderItems, approved, nil) {
+			if order.Status != models.OrderStatusCompleted {
+				return nil
+			}
+			// 已完成订单部分退款，佣金按剩余实付金额等比例扣回
+			var refunded float64
+			if err := tx.Model(&models.Refund{}).
+				Where("order_id = ? AND status IN ?", order.ID, refundApprovedStatuses).
+				Select("COALESCE(SUM(amount), 0)").Row().Scan(&refunded); err != nil {
+				return err
+			}
+			remaining := math.Max(roundRefundAmount(order.ActualAmount-refunded), 0)
+			partialRemaining = &remaining
+			return nil
+		}
+
+		// 订单商品已全部退款
+		if err := tx.Model(&models.Order{}).Where("id = ?", order.ID).
+			Update("status", models.OrderStatusRefunded).Error; err != nil {
+			return err
+		}
+		refundedAfterCompletion = order.Status == models.OrderStatusCompleted
+		order.Status = models.OrderStatusRefunded
+		if err := orderService.CancelGiftOrdersTx(tx, order.ID, orderService.GiftOrderRefundCancelReason); err != nil {
+			return err
+		}
+		return orderService.RestoreOrderCouponTx(tx, order.ID)
+	})
+	if err != nil {
+		if _, ok := err.(*errors.AppError); ok {
+			return err
+		}
+		return errors.ErrDatabaseError.WithError(err)
+	}
+
+	// 订单退款事件处理失败（如佣金撤销）及佣金重算失败不影响退款结果
+	if refundedAfterCompletion && s.orderEvents != nil {
+		_ = s.orderEvents.OnOrderRefunded(ctx, &order)
+	}
+	if partialRemaining != nil && s.commissions != nil {
+		_ = s.commissions.RecalculateCommission(ctx, order.ID, *partialRemaining)
+	}
+	return nil
+}
+
+// RejectRefund 拒绝商城订单部分退款（管理端），释放占用的可退数量
+func (s *MallOrderService) RejectRefund(ctx context.Context, operatorID int64, refundID int64, reason string) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		refund, _, err := lockPendingMallRefund(tx, refundID, "退款申请状态不允许拒绝")
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.Refund{}).
+			Where("id = ? AND status = ?", refund.ID, models.RefundStatusPending).
+			Updates(map[string]interface{}{
+				"status":        models.RefundStatusRejected,
+				"operator_id":   operatorID,
+				"operator_type": models.RefundOperatorAdmin,
+				"rejected_at":   time.Now(),
+				"reject_reason": reason,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrOperationFailed.WithMessage("退款申请状态不允许拒绝")
+		}
+		return nil
+	})
+	if err != nil {
+		if _, ok := err.(*errors.AppError); ok {
+			return err
+		}
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	return nil
+}
+
+// partialRefundAllowed 订单状态是否允许申请部分退款
+func partialRefundAllowed(status string) bool {
+	switch status {
+	case models.OrderStatusPaid, models.OrderStatusPendingShip, models.OrderStatusShipped, models.OrderStatusCompleted:
+		return true
+	default:
+		return false
+	}
+}
+
+// lockPendingMallRefund 锁定待处理的商城订单部分退款，返回退款及其明细
+func lockPendingMallRefund(tx *gorm.DB, refundID int64, statusMessage string) (*models.Refund, []*models.RefundItem, error) {
+	var refund models.Refund
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&refund, refundID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, errors.ErrRefundNotFound
+		}
+		return nil, nil, err
+	}
+
+	var items []*models.RefundItem
+	if err := tx.Where("refund_id = ?", refundID).Order("id ASC").Find(&items).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil, errors.ErrRefundNotFound.WithMessage("商城订单退款申请不存在")
+	}
+
+	if refund.Status != models.RefundStatusPending {
+		return nil, nil, errors.ErrOperationFailed.WithMessage(statusMessage)
+	}
+	return &refund, items, nil
+}
+
+// loadOrderItems 获取订单项，按订单项 ID 索引
+func loadOrderItems(tx *gorm.DB, orderID int64) (map[int64]*models.OrderItem, error) {
+	var items []*models.OrderItem
+	if err := tx.Where("order_id = ?", orderID).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[int64]*models.OrderItem, len(items))
+	for _, item := range items {
+		result[item.ID] = item
+	}
+	return result, nil
+}
+
+// refundedQuantities 统计订单各订单项处于指定退款状态的退款数量
+func refundedQuantities(tx *gorm.DB, orderID int64, statuses []int8) (map[int64]int, error) {
+	var rows []struct {
+		OrderItemID int64
+		Quantity    int
+	}
+	err := tx.Model(&models.RefundItem{}).
+		Select("refund_items.order_item_id, COALESCE(SUM(refund_items.quantity), 0) AS quantity").
+		Joins("JOIN refunds ON refunds.id = refund_items.refund_id").
+		Where("refund_items.order_id = ? AND refunds.status IN ?", orderID, statuses).
+		Group("refund_items.order_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int64]int, len(rows))
+	for _, row := range rows {
+		result[row.OrderItemID] = row.Quantity
+	}
+	return result, nil
+}
+
+// fullyRefundedAfter 已退款数量加上本次申请数量后订单商品是否全部退款
+func fullyRefundedAfter(orderItems map[int64]*models.OrderItem, refunded, requested map[int64]int) bool {
+	if len(orderItems) == 0 {
+		return false
+	}
+	for id, item := range orderItems {
+		if refunded[id]+requested[id] < item.Quantity {
+			return false
+		}
+	}
+	return true
+}
+
+// allocateRefundAmount 计算订单项部分数量的退款金额
+// 订单优惠按订单项原价占订单原价的比例分摊
+func allocateRefundAmount(order *models.Order, item *models.OrderItem, quantity int) float64 {
+	if item.Quantity <= 0 {
+		return 0
+	}
+	lineAmount := item.Subtotal * float64(quantity) / float64(item.Quantity)
+
+	var discount float64
+	if order.DiscountAmount > 0 && order.OriginalAmount > 0 {
+		discount = order.DiscountAmount * lineAmount / order.OriginalAmount
+	}
+	return roundRefundAmount(lineAmount - discount)
+}
+
+// roundRefundAmount 退款金额保留两位小数
+func roundRefundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// joinRefundReasons 合并退款项的退款原因
+func joinRefundReasons(itemIDs []int64, reasons map[int64]string) string {
+	seen := make(map[string]bool, len(reasons))
+	var parts []string
+	for _, id := range itemIDs {
+		if reason := reasons[id]; reason != "" && !seen[reason] {
+			seen[reason] = true
+			parts = append(parts, reason)
+		}
+	}
+	if len(parts) == 0 {
+		return defaultPartialRefundReason
+	}
+
+	reason := strings.Join(parts, "；")
+	if runes := []rune(reason); len(runes) > 255 {
+		reason = string(runes[:255])
+	}
+	return reason
+}
+
+// toMallRefundInfo 转换为商城订单退款信息
+func toMallRefundInfo(refund *models.Refund, items []*models.RefundItem, orderItems map[int64]*models.OrderItem) *MallRefundInfo {
+	info := &MallRefundInfo{
+		ID:        refund.ID,
+		RefundNo:  refund.RefundNo,
+		OrderID:   refund.OrderID,
+		OrderNo:   refund.OrderNo,
+		Amount:    refund.Amount,
+		Reason:    refund.Reason,
+		Status:    refund.Status,
+		Items:     make([]*MallRefundItemInfo, len(items)),
+		CreatedAt: refund.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+
+	for i, item := range items {
+		info.Items[i] = &MallRefundItemInfo{
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+			Amount:      item.Amount,
+		}
+		if orderItem, ok := orderItems[item.OrderItemID]; ok {
+			info.Items[i].ProductName = orderItem.ProductName
+		}
+		if item.Reason != nil {
+			info.Items[i].Reason = *item.Reason
+		}
+	}
+	return info
+}
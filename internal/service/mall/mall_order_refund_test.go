@@ -0,0 +1,352 @@
+package mall
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// refundTestOrder 部分退款测试订单
+type refundTestOrder struct {
+	order      *models.Order
+	itemA      *models.OrderItem // 规格商品 2 件，单价 30
+	itemB      *models.OrderItem // 普通商品 1 件，单价 40
+	productA   *models.Product
+	productB   *models.Product
+	sku        *models.ProductSku
+	userCoupon *models.UserCoupon
+}
+
+func setupMallRefundTest(t *testing.T) (*MallOrderService, *gorm.DB) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+
+	require.NoError(t, db.AutoMigrate(
+		&models.Product{},
+		&models.ProductSku{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.GiftOrder{},
+		&models.Payment{},
+		&models.Refund{},
+		&models.OrderNote{},
+		&models.RefundItem{},
+		&models.Coupon{},
+		&models.UserCoupon{},
+	))
+
+	svc := NewMallOrderService(db, repository.NewOrderRepository(db), nil,
+		repository.NewProductRepository(db), repository.NewProductSkuRepository(db), nil,
+		repository.NewRefundRepository(db), repository.NewPaymentRepository(db))
+	return svc, db
+}
+
+// createRefundTestOrder 创建已支付的商城订单：原价 100，优惠券优惠 10，实付 90
+func createRefundTestOrder(t *testing.T, db *gorm.DB, userID int64) *refundTestOrder {
+	t.Helper()
+
+	o := &refundTestOrder{}
+	o.productA = &models.Product{CategoryID: 1, Name: "规格商品", Images: []byte(`[]`), Price: 30, Stock: 8, IsOnSale: true}
+	require.NoError(t, db.Create(o.productA).Error)
+	o.sku = &models.ProductSku{ProductID: o.productA.ID, SkuCode: "SKU-REFUND", Attributes: []byte(`{}`), Price: 30, Stock: 3, IsActive: true}
+	require.NoError(t, db.Create(o.sku).Error)
+	o.productB = &models.Product{CategoryID: 1, Name: "普通商品", Images: []byte(`[]`), Price: 40, Stock: 5, IsOnSale: true}
+	require.NoError(t, db.Create(o.productB).Error)
+
+	o.order = &models.Order{
+		OrderNo:        "M" + t.Name(),
+		UserID:         userID,
+		Type:           models.OrderTypeMall,
+		OriginalAmount: 100,
+		DiscountAmount: 10,
+		ActualAmount:   90,
+		Status:         models.OrderStatusPendingShip,
+	}
+	require.NoError(t, db.Create(o.order).Error)
+
+	o.itemA = &models.OrderItem{OrderID: o.order.ID, ProductID: &o.productA.ID, SkuID: &o.sku.ID, ProductName: o.productA.Name, Price: 30, Quantity: 2, Subtotal: 60}
+	require.NoError(t, db.Create(o.itemA).Error)
+	o.itemB = &models.OrderItem{OrderID: o.order.ID, ProductID: &o.productB.ID, ProductName: o.productB.Name, Price: 40, Quantity: 1, Subtotal: 40}
+	require.NoError(t, db.Create(o.itemB).Error)
+
+	require.NoError(t, db.Create(&models.Payment{
+		PaymentNo:      "P" + t.Name(),
+		OrderID:        o.order.ID,
+		OrderNo:        o.order.OrderNo,
+		UserID:         userID,
+		Amount:         90,
+		PaymentMethod:  "wechat",
+		PaymentChannel: "miniapp",
+		Status:         models.PaymentStatusSuccess,
+	}).Error)
+
+	coupon := &models.Coupon{Name: "满减券", Type: "fixed", Value: 10, TotalCount: 100, UsedCount: 1,
+		StartTime: time.Now().Add(-time.Hour), EndTime: time.Now().Add(24 * time.Hour)}
+	require.NoError(t, db.Create(coupon).Error)
+	usedAt := time.Now()
+	o.userCoupon = &models.UserCoupon{UserID: userID, CouponID: coupon.ID, OrderID: &o.order.ID,
+		Status: models.UserCouponStatusUsed, ExpiredAt: time.Now().Add(24 * time.Hour), UsedAt: &usedAt}
+	require.NoError(t, db.Create(o.userCoupon).Error)
+
+	return o
+}
+
+func assertRefundErrorCode(t *testing.T, err error, code int) {
+	t.Helper()
+
+	appErr, ok := err.(*errors.AppError)
+	require.True(t, ok, "expected AppError, got %v", err)
+	assert.Equal(t, code, appErr.Code)
+}
+
+func TestMallOrderService_RequestPartialRefund(t *testing.T) {
+	svc, db := setupMallRefundTest(t)
+	ctx := context.Background()
+	userID := int64(1)
+	o := createRefundTestOrder(t, db, userID)
+
+	// 退 1 件规格商品：原价 30，按 30/100 分摊优惠 3，退款 27
+	refund, err := svc.RequestPartialRefund(ctx, userID, o.order.ID, []PartialRefundItem{
+		{OrderItemID: o.itemA.ID, Quantity: 1, Reason: "尺码不合适"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 27.0, refund.Amount)
+	assert.Equal(t, "尺码不合适", refund.Reason)
+	assert.Equal(t, int8(models.RefundStatusPending), refund.Status)
+	require.Len(t, refund.Items, 1)
+	assert.Equal(t, 1, refund.Items[0].Quantity)
+	assert.Equal(t, o.productA.Name, refund.Items[0].ProductName)
+
+	t.Run("超过剩余可退数量被拒绝", func(t *testing.T) {
+		_, err := svc.RequestPartialRefund(ctx, userID, o.order.ID, []PartialRefundItem{
+			{OrderItemID: o.itemA.ID, Quantity: 2},
+		})
+		assertRefundErrorCode(t, err, errors.ErrRefundQuantityExceed.Code)
+
+		// 同一订单项拆分多行同样合并校验
+		_, err = svc.RequestPartialRefund(ctx, userID, o.order.ID, []PartialRefundItem{
+			{OrderItemID: o.itemA.ID, Quantity: 1},
+			{OrderItemID: o.itemA.ID, Quantity: 1},
+		})
+		assertRefundErrorCode(t, err, errors.ErrRefundQuantityExceed.Code)
+	})
+
+	t.Run("订单项不属于该订单", func(t *testing.T) {
+		_, err := svc.RequestPartialRefund(ctx, userID, o.order.ID, []PartialRefundItem{
+			{OrderItemID: 99999, Quantity: 1},
+		})
+		assertRefundErrorCode(t, err, errors.ErrInvalidParams.Code)
+	})
+
+	t.Run("非本人订单", func(t *testing.T) {
+		_, err := svc.RequestPartialRefund(ctx, userID+1, o.order.ID, []PartialRefundItem{
+			{OrderItemID: o.itemB.ID, Quantity: 1},
+		})
+		assertRefundErrorCode(t, err, errors.ErrOrderNotFound.Code)
+	})
+
+	t.Run("拒绝后释放可退数量", func(t *testing.T) {
+		require.NoError(t, svc.RejectRefund(ctx, 100, refund.ID, "商品已使用"))
+
+		var rejected models.Refund
+		require.NoError(t, db.First(&rejected, refund.ID).Error)
+		assert.Equal(t, int8(models.RefundStatusRejected), rejected.Status)
+
+		again, err := svc.RequestPartialRefund(ctx, userID, o.order.ID, []PartialRefundItem{
+			{OrderItemID: o.itemA.ID, Quantity: 2},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 54.0, again.Amount)
+
+		err = svc.RejectRefund(ctx, 100, refund.ID, "重复审批")
+		assertRefundErrorCode(t, err, errors.ErrOperationFailed.Code)
+	})
+}
+
+func TestMallOrderService_ApproveRefund_LastItemRefundsOrder(t *testing.T) {
+	svc, db := setupMallRefundTest(t)
+	ctx := context.Background()
+	userID := int64(1)
+	o := createRefundTestOrder(t, db, userID)
+
+	// 主订单支付后待发货的满赠赠品订单
+	gift := &models.Order{OrderNo: "GIFT_REFUND", UserID: userID, Type: models.OrderTypeMall, Status: models.OrderStatusPendingShip}
+	require.NoError(t, db.Create(gift).Error)
+	require.NoError(t, db.Create(&models.GiftOrder{MainOrderID: o.order.ID, GiftOrderID: gift.ID, ProductID: o.productB.ID, Quantity: 1}).Error)
+
+	first, err := svc.RequestPartialRefund(ctx, userID, o.order.ID, []PartialRefundItem{
+		{OrderItemID: o.itemA.ID, Quantity: 1},
+	})
+	require.NoError(t, err)
+	require.NoError(t, svc.ApproveRefund(ctx, 100, first.ID))
+
+	t.Run("部分退款审批后恢复库存，订单与优惠券不变", func(t *testing.T) {
+		var sku models.ProductSku
+		require.NoError(t, db.First(&sku, o.sku.ID).Error)
+		assert.Equal(t, o.sku.Stock+1, sku.Stock)
+		var product models.Product
+		require.NoError(t, db.First(&product, o.productA.ID).Error)
+		assert.Equal(t, o.productA.Stock+1, product.Stock)
+
+		var order models.Order
+		require.NoError(t, db.First(&order, o.order.ID).Error)
+		assert.Equal(t, models.OrderStatusPendingShip, order.Status)
+
+		var uc models.UserCoupon
+		require.NoError(t, db.First(&uc, o.userCoupon.ID).Error)
+		assert.Equal(t, int8(models.UserCouponStatusUsed), uc.Status)
+
+		var giftOrder models.Order
+		require.NoError(t, db.First(&giftOrder, gift.ID).Error)
+		assert.Equal(t, models.OrderStatusPendingShip, giftOrder.Status)
+	})
+
+	// 退剩余全部商品：退款金额为实付 90 减去已退 27
+	last, err := svc.RequestPartialRefund(ctx, userID, o.order.ID, []PartialRefundItem{
+		{OrderItemID: o.itemA.ID, Quantity: 1},
+		{OrderItemID: o.itemB.ID, Quantity: 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 63.0, last.Amount)
+	assert.Equal(t, defaultPartialRefundReason, last.Reason)
+	var itemSum float64
+	for _, item := range last.Items {
+		itemSum += item.Amount
+	}
+	assert.InDelta(t, last.Amount, itemSum, 0.001)
+
+	// 审批前订单仍未退款
+	var order models.Order
+	require.NoError(t, db.First(&order, o.order.ID).Error)
+	assert.Equal(t, models.OrderStatusPendingShip, order.Status)
+
+	require.NoError(t, svc.ApproveRefund(ctx, 100, last.ID))
+
+	t.Run("全部退款后订单变更为已退款并恢复优惠券", func(t *testing.T) {
+		var order models.Order
+		require.NoError(t, db.First(&order, o.order.ID).Error)
+		assert.Equal(t, models.OrderStatusRefunded, order.Status)
+
+		var giftOrder models.Order
+		require.NoError(t, db.First(&giftOrder, gift.ID).Error)
+		assert.Equal(t, models.OrderStatusCancelled, giftOrder.Status)
+
+		var uc models.UserCoupon
+		require.NoError(t, db.First(&uc, o.userCoupon.ID).Error)
+		assert.Equal(t, int8(models.UserCouponStatusUnused), uc.Status)
+		assert.Nil(t, uc.OrderID)
+
+		var coupon models.Coupon
+		require.NoError(t, db.First(&coupon, o.userCoupon.CouponID).Error)
+		assert.Equal(t, 0, coupon.UsedCount)
+
+		var sku models.ProductSku
+		require.NoError(t, db.First(&sku, o.sku.ID).Error)
+		assert.Equal(t, o.sku.Stock+2, sku.Stock)
+		var productA, productB models.Product
+		require.NoError(t, db.First(&productA, o.productA.ID).Error)
+		require.NoError(t, db.First(&productB, o.productB.ID).Error)
+		assert.Equal(t, o.productA.Stock+2, productA.Stock)
+		assert.Equal(t, o.productB.Stock+1, productB.Stock)
+	})
+
+	t.Run("已退款订单不可再申请", func(t *testing.T) {
+		_, err := svc.RequestPartialRefund(ctx, userID, o.order.ID, []PartialRefundItem{
+			{OrderItemID: o.itemB.ID, Quantity: 1},
+		})
+		assertRefundErrorCode(t, err, errors.ErrOrderStatusError.Code)
+	})
+
+	t.Run("重复审批被拒绝", func(t *testing.T) {
+		err := svc.ApproveRefund(ctx, 100, last.ID)
+		assertRefundErrorCode(t, err, errors.ErrOperationFailed.Code)
+
+		err = svc.ApproveRefund(ctx, 100, 99999)
+		assertRefundErrorCode(t, err, errors.ErrRefundNotFound.Code)
+	})
+}
+
+// stubCommissionRecalculator 记录佣金重算调用
+type stubCommissionRecalculator struct {
+	calls map[int64][]float64
+}
+
+func (r *stubCommissionRecalculator) RecalculateCommission(ctx context.Context, orderID int64, newActualAmount float64) error {
+	r.calls[orderID] = append(r.calls[orderID], newActualAmount)
+	return nil
+}
+
+func TestMallOrderService_ApproveRefund_RecalculatesCommission(t *testing.T) {
+	svc, db := setupMallRefundTest(t)
+	ctx := context.Background()
+	userID := int64(1)
+	recalculator := &stubCommissionRecalculator{calls: map[int64][]float64{}}
+	svc.SetCommissionRecalculator(recalculator)
+
+	completed := createRefundTestOrder(t, db, userID)
+	require.NoError(t, db.Model(completed.order).Update("status", models.OrderStatusCompleted).Error)
+
+	// 已完成订单部分退款：按剩余实付金额 90-27 重算佣金
+	first, err := svc.RequestPartialRefund(ctx, userID, completed.order.ID, []PartialRefundItem{
+		{OrderItemID: completed.itemA.ID, Quantity: 1},
+	})
+	require.NoError(t, err)
+	require.NoError(t, svc.ApproveRefund(ctx, 100, first.ID))
+	assert.Equal(t, []float64{63}, recalculator.calls[completed.order.ID])
+
+	second, err := svc.RequestPartialRefund(ctx, userID, completed.order.ID, []PartialRefundItem{
+		{OrderItemID: completed.itemB.ID, Quantity: 1},
+	})
+	require.NoError(t, err)
+	require.NoError(t, svc.ApproveRefund(ctx, 100, second.ID))
+	assert.Equal(t, []float64{63, 27}, recalculator.calls[completed.order.ID])
+}
+
+func TestMallOrderService_ApproveRefund_UncompletedOrderSkipsCommission(t *testing.T) {
+	svc, db := setupMallRefundTest(t)
+	ctx := context.Background()
+	recalculator := &stubCommissionRecalculator{calls: map[int64][]float64{}}
+	svc.SetCommissionRecalculator(recalculator)
+
+	// 未完成的订单尚未产生佣金，部分退款不重算
+	o := createRefundTestOrder(t, db, 1)
+	refund, err := svc.RequestPartialRefund(ctx, 1, o.order.ID, []PartialRefundItem{
+		{OrderItemID: o.itemA.ID, Quantity: 1},
+	})
+	require.NoError(t, err)
+	require.NoError(t, svc.ApproveRefund(ctx, 100, refund.ID))
+	assert.Empty(t, recalculator.calls)
+}
+
+func TestAllocateRefundAmount(t *testing.T) {
+	order := &models.Order{OriginalAmount: 30, DiscountAmount: 10, ActualAmount: 20}
+	item := &models.OrderItem{Price: 10, Quantity: 3, Subtotal: 30}
+
+	// 每件分摊优惠 3.333...，保留两位小数
+	assert.Equal(t, 6.67, allocateRefundAmount(order, item, 1))
+	assert.Equal(t, 20.0, allocateRefundAmount(order, item, 3))
+
+	// 无优惠时按原价退款
+	assert.Equal(t, 10.0, allocateRefundAmount(&models.Order{OriginalAmount: 30, ActualAmount: 30}, item, 1))
+}
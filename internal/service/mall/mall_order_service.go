@@ -13,6 +13,9 @@ import (
 	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	marketingService "github.com/dumeirei/smart-locker-backend/internal/service/marketing"
+	orderService "github.com/dumeirei/smart-locker-backend/internal/service/order"
+	userService "github.com/dumeirei/smart-locker-backend/internal/service/user"
 )
 
 // MallOrderService 商城订单服务
@@ -23,6 +26,20 @@ type MallOrderService struct {
 	productRepo    *repository.ProductRepository
 	skuRepo        *repository.ProductSkuRepository
 	productService *ProductService
+	refundRepo     *repository.RefundRepository
+	paymentRepo    *repository.PaymentRepository
+	orderEvents    orderService.OrderEventHandler
+	pointsService  *userService.PointsService
+	orderNotes     *orderService.OrderNoteService
+	discountCalc   *orderService.DiscountCalculator
+	commissions    commissionRecalculator
+
+	campaignService *marketingService.CampaignService
+}
+
+// commissionRecalculator 佣金重算接口
+type commissionRecalculator interface {
+	RecalculateCommission(ctx context.Context, orderID int64, newActualAmount float64) error
 }
 
 // NewMallOrderService 创建商城订单服务
@@ -33,6 +50,8 @@ func NewMallOrderService(
 	productRepo *repository.ProductRepository,
 	skuRepo *repository.ProductSkuRepository,
 	productService *ProductService,
+	refundRepo *repository.RefundRepository,
+	paymentRepo *repository.PaymentRepository,
 ) *MallOrderService {
 	return &MallOrderService{
 		db:             db,
@@ -41,9 +60,41 @@ func NewMallOrderService(
 		productRepo:    productRepo,
 		skuRepo:        skuRepo,
 		productService: productService,
+		refundRepo:     refundRepo,
+		paymentRepo:    paymentRepo,
 	}
 }
 
+// SetOrderEventHandler 设置订单事件处理器，确认收货后触发（如发放消费积分）
+func (s *MallOrderService) SetOrderEventHandler(handler orderService.OrderEventHandler) {
+	s.orderEvents = handler
+}
+
+// SetPointsService 设置积分服务，用于下单时积分抵扣及取消订单时返还抵扣积分
+func (s *MallOrderService) SetPointsService(pointsService *userService.PointsService) {
+	s.pointsService = pointsService
+}
+
+// SetOrderNoteService 设置订单备注服务，订单详情附带最新一条用户可见的备注
+func (s *MallOrderService) SetOrderNoteService(orderNotes *orderService.OrderNoteService) {
+	s.orderNotes = orderNotes
+}
+
+// SetDiscountCalculator 设置订单优惠计算器，下单时实付金额达到满赠活动门槛则生成赠品订单
+func (s *MallOrderService) SetDiscountCalculator(calculator *orderService.DiscountCalculator) {
+	s.discountCalc = calculator
+}
+
+// SetCommissionRecalculator 设置佣金重算服务，已完成订单部分退款后按剩余实付金额重算佣金
+func (s *MallOrderService) SetCommissionRecalculator(recalculator commissionRecalculator) {
+	s.commissions = recalculator
+}
+
+// SetCampaignService 设置活动服务，进行中秒杀活动的商品按秒杀价下单，并预占秒杀库存、校验每人限购
+func (s *MallOrderService) SetCampaignService(campaignService *marketingService.CampaignService) {
+	s.campaignService = campaignService
+}
+
 // OrderItemRequest 订单项请求
 type OrderItemRequest struct {
 	ProductID int64  `json:"product_id" binding:"required"`
@@ -56,6 +107,7 @@ type CreateMallOrderRequest struct {
 	Items     []OrderItemRequest `json:"items" binding:"required,min=1"`
 	AddressID int64              `json:"address_id" binding:"required"`
 	CouponID  *int64             `json:"coupon_id"`
+	UsePoints int                `json:"use_points" binding:"omitempty,min=0"` // 使用积分抵扣，每100积分抵扣1元
 	Remark    string             `json:"remark"`
 }
 
@@ -63,38 +115,41 @@ type CreateMallOrderRequest struct {
 type CreateFromCartRequest struct {
 	AddressID int64  `json:"address_id" binding:"required"`
 	CouponID  *int64 `json:"coupon_id"`
+	UsePoints int    `json:"use_points" binding:"omitempty,min=0"` // 使用积分抵扣，每100积分抵扣1元
 	Remark    string `json:"remark"`
 }
 
 // MallOrderInfo 商城订单信息
 type MallOrderInfo struct {
-	ID             int64              `json:"id"`
-	OrderNo        string             `json:"order_no"`
-	Status         string             `json:"status"`
-	StatusName     string             `json:"status_name"`
-	OriginalAmount float64            `json:"original_amount"`
-	DiscountAmount float64            `json:"discount_amount"`
-	ActualAmount   float64            `json:"actual_amount"`
-	Items          []*MallOrderItem   `json:"items"`
-	Address        *AddressSnapshot   `json:"address,omitempty"`
-	ExpressCompany string             `json:"express_company,omitempty"`
-	ExpressNo      string             `json:"express_no,omitempty"`
-	Remark         string             `json:"remark,omitempty"`
-	CreatedAt      string             `json:"created_at"`
-	PaidAt         string             `json:"paid_at,omitempty"`
-	ShippedAt      string             `json:"shipped_at,omitempty"`
-	ReceivedAt     string             `json:"received_at,omitempty"`
+	ID             int64                       `json:"id"`
+	OrderNo        string                      `json:"order_no"`
+	Status         string                      `json:"status"`
+	StatusName     string                      `json:"status_name"`
+	OriginalAmount float64                     `json:"original_amount"`
+	DiscountAmount float64                     `json:"discount_amount"`
+	ActualAmount   float64                     `json:"actual_amount"`
+	Items          []*MallOrderItem            `json:"items"`
+	Address        *AddressSnapshot            `json:"address,omitempty"`
+	ExpressCompany string                      `json:"express_company,omitempty"`
+	ExpressNo      string                      `json:"express_no,omitempty"`
+	Remark         string                      `json:"remark,omitempty"`
+	CreatedAt      string                      `json:"created_at"`
+	PaidAt         string                      `json:"paid_at,omitempty"`
+	ShippedAt      string                      `json:"shipped_at,omitempty"`
+	ReceivedAt     string                      `json:"received_at,omitempty"`
+	LatestNote     *orderService.OrderNoteInfo `json:"latest_note,omitempty"` // 仅订单详情返回
 }
 
 // MallOrderItem 订单项
 type MallOrderItem struct {
-	ProductID    int64             `json:"product_id"`
-	ProductName  string            `json:"product_name"`
-	ProductImage string            `json:"product_image"`
-	SkuInfo      string            `json:"sku_info,omitempty"`
-	Price        float64           `json:"price"`
-	Quantity     int               `json:"quantity"`
-	Subtotal     float64           `json:"subtotal"`
+	ID           int64   `json:"id"`
+	ProductID    int64   `json:"product_id"`
+	ProductName  string  `json:"product_name"`
+	ProductImage string  `json:"product_image"`
+	SkuInfo      string  `json:"sku_info,omitempty"`
+	Price        float64 `json:"price"`
+	Quantity     int     `json:"quantity"`
+	Subtotal     float64 `json:"subtotal"`
 }
 
 // AddressSnapshot 地址快照
@@ -108,21 +163,28 @@ type AddressSnapshot struct {
 }
 
 // CreateOrder 创建商城订单
+// 参加进行中秒杀活动的商品按秒杀价计算（含指定规格时），并在下单事务中预占秒杀库存
 func (s *MallOrderService) CreateOrder(ctx context.Context, userID int64, req *CreateMallOrderRequest) (*MallOrderInfo, error) {
 	var order *models.Order
 	var orderItems []*models.OrderItem
+	var flashSaleItems []*flashSaleOrderItem
+	var flashSaleReservations []*models.FlashSaleReservation
 
 	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 商品查询及库存扣减均在事务内，下单失败（如超过秒杀限购）时库存随事务回滚
+		productRepo := repository.NewProductRepository(tx)
+		skuRepo := repository.NewProductSkuRepository(tx)
+
 		// 计算订单金额
 		var originalAmount float64
 		orderItems = make([]*models.OrderItem, len(req.Items))
 
 		for i, item := range req.Items {
 			// 获取商品信息
-			product, err := s.productRepo.GetByID(ctx, item.ProductID)
+			product, err := productRepo.GetByID(ctx, item.ProductID)
 			if err != nil {
 				if err == gorm.ErrRecordNotFound {
-					return errors.ErrProductNotFound.WithMessage(fmt.Sprintf("商品 %d 不存在", item.ProductID))
+					return s.productMissingError(ctx, item.ProductID)
 				}
 				return err
 			}
@@ -132,6 +194,7 @@ func (s *MallOrderService) CreateOrder(ctx context.Context, userID int64, req *C
 			}
 
 			price := product.Price
+			var skuID *int64
 			var skuInfo string
 			var productImage string
 
@@ -145,9 +208,12 @@ func (s *MallOrderService) CreateOrder(ctx context.Context, userID int64, req *C
 
 			// 如果有 SKU
 			if item.SkuID != nil && *item.SkuID > 0 {
-				sku, err := s.skuRepo.GetByID(ctx, *item.SkuID)
+				sku, err := skuRepo.GetByID(ctx, *item.SkuID)
 				if err != nil {
 					if err == gorm.ErrRecordNotFound {
+						if skuRepo.ExistsDeleted(ctx, *item.SkuID) {
+							return errors.ErrProductDeleted.WithMessage(fmt.Sprintf("商品 %s 的规格已删除", product.Name))
+						}
 						return errors.ErrProductNotFound.WithMessage("商品规格不存在")
 					}
 					return err
@@ -160,6 +226,7 @@ func (s *MallOrderService) CreateOrder(ctx context.Context, userID int64, req *C
 				}
 
 				price = sku.Price
+				skuID = item.SkuID
 
 				// 解析 SKU 属性
 				if sku.Attributes != nil {
@@ -176,7 +243,7 @@ func (s *MallOrderService) CreateOrder(ctx context.Context, userID int64, req *C
 				}
 
 				// 扣减 SKU 库存
-				if err := s.skuRepo.DecreaseStock(ctx, *item.SkuID, item.Quantity); err != nil {
+				if err := skuRepo.DecreaseStock(ctx, *item.SkuID, item.Quantity); err != nil {
 					return errors.ErrStockInsufficient.WithMessage(fmt.Sprintf("商品 %s 库存不足", product.Name))
 				}
 			} else {
@@ -187,15 +254,26 @@ func (s *MallOrderService) CreateOrder(ctx context.Context, userID int64, req *C
 			}
 
 			// 扣减商品总库存
-			if err := s.productRepo.DecreaseStock(ctx, item.ProductID, item.Quantity); err != nil {
+			if err := productRepo.DecreaseStock(ctx, item.ProductID, item.Quantity); err != nil {
 				return errors.ErrStockInsufficient.WithMessage(fmt.Sprintf("商品 %s 库存不足", product.Name))
 			}
 
+			// 秒杀价
+			flashSale, err := s.flashSaleProductTx(ctx, tx, item.ProductID)
+			if err != nil {
+				return err
+			}
+			if flashSale != nil {
+				price = flashSale.FlashPrice
+				flashSaleItems = append(flashSaleItems, &flashSaleOrderItem{product: flashSale, productName: product.Name, quantity: item.Quantity})
+			}
+
 			subtotal := price * float64(item.Quantity)
 			originalAmount += subtotal
 
 			orderItems[i] = &models.OrderItem{
 				ProductID:    &item.ProductID,
+				SkuID:        skuID,
 				ProductName:  product.Name,
 				ProductImage: &productImage,
 				SkuInfo:      &skuInfo,
@@ -205,8 +283,35 @@ func (s *MallOrderService) CreateOrder(ctx context.Context, userID int64, req *C
 			}
 		}
 
-		// TODO: 应用优惠券
+		orderNo := utils.GenerateOrderNo("M")
+
+		// 会员等级折扣
 		discountAmount := 0.0
+		if s.discountCalc != nil {
+			memberDiscount, err := s.discountCalc.CalculateMemberDiscountAmount(ctx, userID, originalAmount)
+			if err != nil {
+				return err
+			}
+			discountAmount += memberDiscount
+		}
+
+		// TODO: 应用优惠券
+
+		// 积分抵扣
+		if req.UsePoints > 0 {
+			if s.pointsService == nil {
+				return errors.ErrOperationFailed.WithMessage("暂不支持积分抵扣")
+			}
+			if userService.CalculateRedeemDiscount(req.UsePoints) > originalAmount-discountAmount {
+				return errors.ErrInvalidParams.WithMessage("抵扣金额不能超过订单应付金额")
+			}
+			pointsDiscount, err := s.pointsService.RedeemPointsTx(ctx, tx, userID, req.UsePoints, orderNo)
+			if err != nil {
+				return err
+			}
+			discountAmount += pointsDiscount
+		}
+
 		actualAmount := originalAmount - discountAmount
 
 		// 获取地址信息（简化处理，实际应该查询数据库）
@@ -221,7 +326,7 @@ func (s *MallOrderService) CreateOrder(ctx context.Context, userID int64, req *C
 
 		// 创建订单
 		order = &models.Order{
-			OrderNo:         utils.GenerateOrderNo("M"),
+			OrderNo:         orderNo,
 			UserID:          userID,
 			Type:            models.OrderTypeMall,
 			OriginalAmount:  originalAmount,
@@ -249,16 +354,30 @@ func (s *MallOrderService) CreateOrder(ctx context.Context, userID int64, req *C
 			}
 		}
 
-		return nil
+		if err := s.reserveFlashSaleStockTx(ctx, tx, order, flashSaleItems, &flashSaleReservations); err != nil {
+			return err
+		}
+
+		return s.attachGiftOrderTx(ctx, tx, order)
 	})
 
 	if err != nil {
+		s.releaseFlashSaleSlots(ctx, flashSaleReservations)
 		return nil, err
 	}
 
 	return s.toMallOrderInfo(order, orderItems), nil
 }
 
+// productMissingError 商品查询不到时区分已删除和不存在，已删除的商品（如加入购物车后被删除）返回 ErrProductDeleted
+func (s *MallOrderService) productMissingError(ctx context.Context, productID int64) error {
+	product, err := s.productRepo.GetByIDUnscoped(ctx, productID)
+	if err == nil && product.DeletedAt.Valid {
+		return errors.ErrProductDeleted.WithMessage(fmt.Sprintf("商品 %s 已删除", product.Name))
+	}
+	return errors.ErrProductNotFound.WithMessage(fmt.Sprintf("商品 %d 不存在", productID))
+}
+
 // CreateOrderFromCart 从购物车创建订单
 func (s *MallOrderService) CreateOrderFromCart(ctx context.Context, userID int64, req *CreateFromCartRequest) (*MallOrderInfo, error) {
 	// 获取选中的购物车项
@@ -286,6 +405,7 @@ func (s *MallOrderService) CreateOrderFromCart(ctx context.Context, userID int64
 		Items:     items,
 		AddressID: req.AddressID,
 		CouponID:  req.CouponID,
+		UsePoints: req.UsePoints,
 		Remark:    req.Remark,
 	})
 
@@ -320,7 +440,13 @@ func (s *MallOrderService) GetOrderDetail(ctx context.Context, userID int64, ord
 		return nil, errors.ErrResourceNotFound
 	}
 
-	return s.toMallOrderInfo(order, order.Items), nil
+	info := s.toMallOrderInfo(order, order.Items)
+	if s.orderNotes != nil {
+		if info.LatestNote, err = s.orderNotes.GetLatestUserNote(ctx, order.ID); err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
 }
 
 // GetUserOrders 获取用户商城订单列表
@@ -356,6 +482,55 @@ func (s *MallOrderService) GetUserOrders(ctx context.Context, userID int64, stat
 	return result, total, nil
 }
 
+// UserOrdersFilter 用户订单列表筛选条件
+type UserOrdersFilter struct {
+	Status    string
+	StartDate *time.Time
+	EndDate   *time.Time
+}
+
+// GetUserOrdersByCursor 游标分页获取用户订单列表
+// cursor 为空时返回第一页；还有更多记录时返回下一页游标，否则返回空字符串
+func (s *MallOrderService) GetUserOrdersByCursor(ctx context.Context, userID int64, cursor string, limit int, filter *UserOrdersFilter) ([]*MallOrderInfo, string, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	after, err := utils.DecodeCursor(cursor)
+	if err != nil {
+		return nil, "", errors.ErrInvalidParams.WithMessage("无效的游标")
+	}
+
+	filters := map[string]interface{}{
+		"type": models.OrderTypeMall,
+	}
+	if filter != nil {
+		filters["status"] = filter.Status
+		filters["start_date"] = filter.StartDate
+		filters["end_date"] = filter.EndDate
+	}
+
+	// 多取一条用于判断是否还有下一页
+	orders, err := s.orderRepo.ListByUserIDCursor(ctx, userID, after, limit+1, filters)
+	if err != nil {
+		return nil, "", errors.ErrDatabaseError.WithError(err)
+	}
+
+	nextCursor := ""
+	if len(orders) > limit {
+		orders = orders[:limit]
+		last := orders[limit-1]
+		nextCursor = utils.EncodeCursor(&utils.Cursor{ID: last.ID, CreatedAt: last.CreatedAt})
+	}
+
+	result := make([]*MallOrderInfo, len(orders))
+	for i, o := range orders {
+		result[i] = s.toMallOrderInfo(o, o.Items)
+	}
+
+	return result, nextCursor, nil
+}
+
 // CancelOrder 取消订单
 func (s *MallOrderService) CancelOrder(ctx context.Context, userID int64, orderID int64, reason string) error {
 	order, err := s.orderRepo.GetByID(ctx, orderID)
@@ -375,28 +550,102 @@ func (s *MallOrderService) CancelOrder(ctx context.Context, userID int64, orderI
 	}
 
 	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 恢复库存
-		items, err := s.orderRepo.GetOrderItems(ctx, orderID)
-		if err != nil {
-			return err
-		}
-
-		for _, item := range items {
-			if item.ProductID != nil {
-				if err := s.productRepo.IncreaseStock(ctx, *item.ProductID, item.Quantity); err != nil {
-					return err
-				}
-			}
-		}
+		return s.cancelOrderTx(ctx, tx, order, reason)
+	})
+}
 
-		// 更新订单状态
-		now := time.Now()
-		return s.orderRepo.UpdateFields(ctx, orderID, map[string]interface{}{
+// cancelOrderTx 在事务中取消待支付订单：恢复库存及秒杀库存、取消赠品订单、返还抵扣积分
+// 以待支付为条件更新订单状态，订单已被支付或取消时返回状态错误
+func (s *MallOrderService) cancelOrderTx(ctx context.Context, tx *gorm.DB, order *models.Order, reason string) error {
+	res := tx.Model(&models.Order{}).
+		Where("id = ? AND status = ? AND paid_at IS NULL", order.ID, models.OrderStatusPending).
+		Updates(map[string]interface{}{
 			"status":        models.OrderStatusCancelled,
-			"cancelled_at":  now,
+			"cancelled_at":  time.Now(),
 			"cancel_reason": reason,
 		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.ErrOrderStatusError.WithMessage("订单状态不允许取消")
+	}
+
+	return s.releaseCancelledOrderTx(ctx, tx, order, reason)
+}
+
+// ReleaseExpiredOrderTx 超时未支付的商城订单被取消时，在同一事务中恢复库存及秒杀库存、取消赠品订单、返还抵扣积分
+func (s *MallOrderService) ReleaseExpiredOrderTx(ctx context.Context, tx *gorm.DB, order *models.Order) (func(), error) {
+	return nil, s.releaseCancelledOrderTx(ctx, tx, order, orderService.OrderExpiredCancelReason)
+}
+
+// releaseCancelledOrderTx 释放已取消订单占用的资源
+func (s *MallOrderService) releaseCancelledOrderTx(ctx context.Context, tx *gorm.DB, order *models.Order, reason string) error {
+	// 恢复库存
+	items, err := repository.NewOrderRepository(tx).GetOrderItems(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	productRepo := repository.NewProductRepository(tx)
+	for _, item := range items {
+		if item.ProductID != nil {
+			if err := productRepo.IncreaseStock(ctx, *item.ProductID, item.Quantity); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 释放秒杀库存预占
+	if err := s.releaseFlashSaleTx(ctx, tx, order.ID); err != nil {
+		return err
+	}
+
+	// 赠品订单随主订单取消
+	if err := orderService.CancelGiftOrdersTx(tx, order.ID, reason); err != nil {
+		return err
+	}
+
+	// 返还下单时抵扣的积分
+	if s.pointsService != nil {
+		return s.pointsService.ReturnRedeemedPointsTx(ctx, tx, order.UserID, order.OrderNo)
+	}
+	return nil
+}
+
+// OnPaymentSuccess 第三方支付成功回调
+// 将待支付或已支付的商城订单及其赠品订单更新为待发货；已发货等后续状态视为已处理，
+// 订单已被取消（如超时未支付自动取消）时返回状态错误，需人工退款
+func (s *MallOrderService) OnPaymentSuccess(ctx context.Context, orderID int64) error {
+	var cancelled bool
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Order{}).
+			Where("id = ? AND type = ? AND status IN ?", orderID, models.OrderTypeMall,
+				[]string{models.OrderStatusPending, models.OrderStatusPaid}).
+			Update("status", models.OrderStatusPendingShip)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			var order models.Order
+			if err := tx.Select("status").First(&order, orderID).Error; err != nil {
+				return err
+			}
+			cancelled = order.Status == models.OrderStatusCancelled
+			return nil
+		}
+		if err := s.confirmFlashSaleTx(ctx, tx, orderID); err != nil {
+			return err
+		}
+		return syncGiftOrdersPaidTx(tx, orderID)
 	})
+	if err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	if cancelled {
+		return errors.ErrOrderStatusError.WithMessage("订单已取消，支付款项需退款处理")
+	}
+	return nil
 }
 
 // ConfirmReceive 确认收货
@@ -418,11 +667,22 @@ func (s *MallOrderService) ConfirmReceive(ctx context.Context, userID int64, ord
 	}
 
 	now := time.Now()
-	return s.orderRepo.UpdateFields(ctx, orderID, map[string]interface{}{
+	if err := s.orderRepo.UpdateFields(ctx, orderID, map[string]interface{}{
 		"status":       models.OrderStatusCompleted,
 		"received_at":  now,
 		"completed_at": now,
-	})
+	}); err != nil {
+		return err
+	}
+
+	// 订单完成事件处理失败不影响确认收货
+	if s.orderEvents != nil {
+		order.Status = models.OrderStatusCompleted
+		order.ReceivedAt = &now
+		order.CompletedAt = &now
+		_ = s.orderEvents.OnOrderCompleted(ctx, order)
+	}
+	return nil
 }
 
 // toMallOrderInfo 转换为商城订单信息
@@ -469,6 +729,7 @@ func (s *MallOrderService) toMallOrderInfo(order *models.Order, items []*models.
 	info.Items = make([]*MallOrderItem, len(items))
 	for i, item := range items {
 		info.Items[i] = &MallOrderItem{
+			ID:          item.ID,
 			ProductName: item.ProductName,
 			Price:       item.Price,
 			Quantity:    item.Quantity,
@@ -507,6 +768,8 @@ func (s *MallOrderService) getStatusName(status string) string {
 		return "退款中"
 	case models.OrderStatusRefunded:
 		return "已退款"
+	case models.OrderStatusPartialRefunded:
+		return "部分退款"
 	default:
 		return status
 	}
@@ -0,0 +1,138 @@
+package mall
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+func setupMallOrderCursorTest(t *testing.T) (*MallOrderService, *gorm.DB) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+
+	require.NoError(t, db.AutoMigrate(&models.Order{}, &models.OrderItem{}))
+
+	svc := NewMallOrderService(db, repository.NewOrderRepository(db), nil, nil, nil, nil, nil, nil)
+	return svc, db
+}
+
+// createCursorTestOrder 创建指定创建时间的商城订单
+func createCursorTestOrder(t *testing.T, db *gorm.DB, userID int64, orderNo, status string, createdAt time.Time) {
+	t.Helper()
+	order := &models.Order{
+		OrderNo:        orderNo,
+		UserID:         userID,
+		Type:           models.OrderTypeMall,
+		OriginalAmount: 50,
+		ActualAmount:   50,
+		Status:         status,
+		CreatedAt:      createdAt,
+	}
+	require.NoError(t, db.Create(order).Error)
+	productID := int64(1)
+	require.NoError(t, db.Create(&models.OrderItem{
+		OrderID:     order.ID,
+		ProductID:   &productID,
+		ProductName: "商品" + orderNo,
+		Price:       50,
+		Quantity:    1,
+		Subtotal:    50,
+	}).Error)
+}
+
+func orderNosOf(orders []*MallOrderInfo) []string {
+	orderNos := make([]string, len(orders))
+	for i, o := range orders {
+		orderNos[i] = o.OrderNo
+	}
+	return orderNos
+}
+
+func TestMallOrderService_GetUserOrdersByCursor_StableWhenInserted(t *testing.T) {
+	svc, db := setupMallOrderCursorTest(t)
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour)
+	for i := 1; i <= 5; i++ {
+		createCursorTestOrder(t, db, 1, fmt.Sprintf("M%d", i), models.OrderStatusPendingShip, base.Add(time.Duration(i)*time.Minute))
+	}
+
+	page1, next, err := svc.GetUserOrdersByCursor(ctx, 1, "", 2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"M5", "M4"}, orderNosOf(page1))
+	require.NotEmpty(t, next)
+	assert.Len(t, page1[0].Items, 1)
+
+	// 翻页期间产生新订单，不影响后续页
+	createCursorTestOrder(t, db, 1, "M6", models.OrderStatusPendingShip, time.Now())
+
+	page2, next, err := svc.GetUserOrdersByCursor(ctx, 1, next, 2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"M3", "M2"}, orderNosOf(page2))
+	require.NotEmpty(t, next)
+
+	page3, next, err := svc.GetUserOrdersByCursor(ctx, 1, next, 2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"M1"}, orderNosOf(page3))
+	assert.Empty(t, next)
+}
+
+func TestMallOrderService_GetUserOrdersByCursor_Filters(t *testing.T) {
+	svc, db := setupMallOrderCursorTest(t)
+	ctx := context.Background()
+
+	base := time.Date(2025, 6, 1, 9, 0, 0, 0, time.Local)
+	createCursorTestOrder(t, db, 1, "F1", models.OrderStatusCompleted, base)
+	createCursorTestOrder(t, db, 1, "F2", models.OrderStatusCompleted, base.AddDate(0, 0, 1))
+	createCursorTestOrder(t, db, 1, "F3", models.OrderStatusPending, base.AddDate(0, 0, 2))
+	createCursorTestOrder(t, db, 1, "F4", models.OrderStatusCompleted, base.AddDate(0, 0, 3))
+	createCursorTestOrder(t, db, 1, "F5", models.OrderStatusCompleted, base.AddDate(0, 0, 4))
+	createCursorTestOrder(t, db, 2, "F6", models.OrderStatusCompleted, base.AddDate(0, 0, 2))
+
+	start := base.AddDate(0, 0, 1)
+	end := base.AddDate(0, 0, 3)
+	filter := &UserOrdersFilter{Status: models.OrderStatusCompleted, StartDate: &start, EndDate: &end}
+
+	var all []string
+	cursor := ""
+	for {
+		orders, next, err := svc.GetUserOrdersByCursor(ctx, 1, cursor, 1, filter)
+		require.NoError(t, err)
+		all = append(all, orderNosOf(orders)...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	assert.Equal(t, []string{"F4", "F2"}, all)
+}
+
+func TestMallOrderService_GetUserOrdersByCursor_InvalidCursor(t *testing.T) {
+	svc, _ := setupMallOrderCursorTest(t)
+
+	_, _, err := svc.GetUserOrdersByCursor(context.Background(), 1, "%%invalid%%", 10, nil)
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrInvalidParams.Code, appErr.Code)
+}
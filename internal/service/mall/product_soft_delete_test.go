@@ -0,0 +1,121 @@
+package mall
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	adminService "github.com/dumeirei/smart-locker-backend/internal/service/admin"
+)
+
+func setupProductSoftDeleteTest(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	// 下单事务内通过仓储读取商品，需要第二个连接
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(2)
+	sqlDB.SetMaxIdleConns(2)
+
+	require.NoError(t, db.AutoMigrate(
+		&models.User{},
+		&models.MemberLevel{},
+		&models.Category{},
+		&models.Product{},
+		&models.ProductSku{},
+		&models.ProductSearchTerm{},
+		&models.CartItem{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.GiftOrder{},
+	))
+	db.Create(&models.MemberLevel{ID: 1, Name: "普通会员", Level: 1, MinPoints: 0, Discount: 1.0})
+	return db
+}
+
+func newSoftDeleteAdminService(db *gorm.DB) *adminService.ProductAdminService {
+	return adminService.NewProductAdminService(
+		db,
+		repository.NewCategoryRepository(db),
+		repository.NewProductRepository(db),
+		repository.NewProductSkuRepository(db),
+	)
+}
+
+func TestProductSoftDelete_CartWithJustDeletedProduct(t *testing.T) {
+	db := setupProductSoftDeleteTest(t)
+	ctx := context.Background()
+	cartSvc := newCartService(db)
+	orderSvc := NewMallOrderService(db, repository.NewOrderRepository(db), repository.NewCartRepository(db),
+		repository.NewProductRepository(db), repository.NewProductSkuRepository(db), nil, nil, nil)
+
+	user, product, sku := seedCartTestData(t, db)
+	_, err := cartSvc.AddItem(ctx, repository.UserCartOwner(user.ID), &AddCartItemRequest{ProductID: product.ID, SkuID: &sku.ID, Quantity: 2})
+	require.NoError(t, err)
+
+	require.NoError(t, newSoftDeleteAdminService(db).DeleteProduct(ctx, product.ID))
+
+	// 购物车保留该商品，但标记为失效且不计入结算金额
+	cart, err := cartSvc.GetCart(ctx, repository.UserCartOwner(user.ID))
+	require.NoError(t, err)
+	require.Len(t, cart.Items, 1)
+	assert.False(t, cart.Items[0].IsValid)
+	assert.Equal(t, "测试商品", cart.Items[0].ProductName)
+	assert.Equal(t, 0, cart.SelectedCount)
+	assert.Equal(t, 0.0, cart.TotalAmount)
+
+	// 结算时拒绝已删除的商品
+	_, err = orderSvc.CreateOrderFromCart(ctx, user.ID, &CreateFromCartRequest{})
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok, "unexpected error: %v", err)
+	assert.Equal(t, appErrors.ErrProductDeleted.Code, appErr.Code)
+
+	var orders int64
+	db.Model(&models.Order{}).Count(&orders)
+	assert.Equal(t, int64(0), orders)
+}
+
+func TestProductSoftDelete_RestoreBackIntoSearch(t *testing.T) {
+	db := setupProductSoftDeleteTest(t)
+	ctx := context.Background()
+	adminSvc := newSoftDeleteAdminService(db)
+	searchSvc := NewSearchService(db, repository.NewProductRepository(db))
+
+	_, product, _ := seedCartTestData(t, db)
+	search := func() int64 {
+		result, err := searchSvc.SearchProductsFTS(ctx, &SearchRequest{Keyword: "测试商品"})
+		require.NoError(t, err)
+		return result.Total
+	}
+	require.Equal(t, int64(1), search())
+
+	require.NoError(t, adminSvc.DeleteProduct(ctx, product.ID))
+	assert.Equal(t, int64(0), search())
+
+	productSvc := NewProductService(db, repository.NewProductRepository(db), repository.NewCategoryRepository(db), repository.NewProductSkuRepository(db))
+	_, err := productSvc.GetProductDetail(ctx, product.ID)
+	assert.Error(t, err)
+
+	_, err = adminSvc.RestoreProduct(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), search())
+
+	detail, err := productSvc.GetProductDetail(ctx, product.ID)
+	require.NoError(t, err)
+	require.Len(t, detail.Skus, 1)
+}
@@ -0,0 +1,193 @@
+package mall
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// createReviewForOrder 为新订单创建评价
+func createReviewForOrder(t *testing.T, db *gorm.DB, user *models.User, product *models.Product, images []string) *models.Review {
+	t.Helper()
+
+	order := &models.Order{
+		OrderNo:        fmt.Sprintf("M%d", time.Now().UnixNano()),
+		UserID:         user.ID,
+		Type:           models.OrderTypeMall,
+		OriginalAmount: 80.0,
+		ActualAmount:   80.0,
+		Status:         models.OrderStatusCompleted,
+	}
+	require.NoError(t, db.Create(order).Error)
+
+	review := &models.Review{
+		OrderID:   order.ID,
+		ProductID: product.ID,
+		UserID:    user.ID,
+		Rating:    5,
+		Status:    int16(models.ReviewStatusVisible),
+	}
+	if len(images) > 0 {
+		review.Images, _ = json.Marshal(images)
+	}
+	require.NoError(t, db.Create(review).Error)
+	return review
+}
+
+func assertAppErrorCode(t *testing.T, err error, code int) {
+	t.Helper()
+
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok, "expected AppError, got %v", err)
+	assert.Equal(t, code, appErr.Code)
+}
+
+func TestReviewService_CreateReview_ImageValidation(t *testing.T) {
+	db := setupReviewServiceTestDB(t)
+	svc := newReviewService(db)
+	ctx := context.Background()
+
+	user, product, order := seedReviewTestData(t, db)
+	images := func(n int) []string {
+		list := make([]string, n)
+		for i := range list {
+			list[i] = fmt.Sprintf("https://cdn.example.com/review/%d.jpg", i)
+		}
+		return list
+	}
+
+	t.Run("超过6张", func(t *testing.T) {
+		_, err := svc.CreateReview(ctx, user.ID, &CreateReviewRequest{
+			OrderID: order.ID, ProductID: product.ID, Rating: 5, Images: images(MaxReviewImages + 1),
+		})
+		assertAppErrorCode(t, err, appErrors.ErrInvalidParams.Code)
+	})
+
+	t.Run("非法地址", func(t *testing.T) {
+		for _, image := range []string{"review.jpg", "ftp://example.com/a.jpg", "https://", "javascript:alert(1)"} {
+			_, err := svc.CreateReview(ctx, user.ID, &CreateReviewRequest{
+				OrderID: order.ID, ProductID: product.ID, Rating: 5, Images: []string{image},
+			})
+			assertAppErrorCode(t, err, appErrors.ErrInvalidParams.Code)
+		}
+	})
+
+	t.Run("正好6张", func(t *testing.T) {
+		review, err := svc.CreateReview(ctx, user.ID, &CreateReviewRequest{
+			OrderID: order.ID, ProductID: product.ID, Rating: 5, Images: images(MaxReviewImages),
+		})
+		require.NoError(t, err)
+		assert.Len(t, review.Images, MaxReviewImages)
+		assert.True(t, review.HasImages)
+	})
+}
+
+func TestReviewService_CreateReply(t *testing.T) {
+	db := setupReviewServiceTestDB(t)
+	svc := newReviewService(db)
+	ctx := context.Background()
+
+	user, product, _ := seedReviewTestData(t, db)
+	review := createReviewForOrder(t, db, user, product, nil)
+
+	reply, err := svc.CreateReply(ctx, review.ID, ReviewReplier{Type: ReviewReplierAdmin, ID: 7}, "  感谢您的支持  ")
+	require.NoError(t, err)
+	assert.Equal(t, ReviewReplierAdmin, reply.ReplierType)
+	assert.Equal(t, "感谢您的支持", reply.Content)
+
+	var saved models.ReviewReply
+	require.NoError(t, db.First(&saved, reply.ID).Error)
+	require.NotNil(t, saved.AdminID)
+	assert.Equal(t, int64(7), *saved.AdminID)
+	assert.Nil(t, saved.MerchantID)
+
+	_, err = svc.CreateReply(ctx, review.ID, ReviewReplier{Type: ReviewReplierAdmin, ID: 7}, "   ")
+	assertAppErrorCode(t, err, appErrors.ErrInvalidParams.Code)
+
+	_, err = svc.CreateReply(ctx, review.ID, ReviewReplier{Type: "user", ID: 7}, "回复")
+	assertAppErrorCode(t, err, appErrors.ErrInvalidParams.Code)
+}
+
+func TestReviewService_CreateReply_DeletedReview(t *testing.T) {
+	db := setupReviewServiceTestDB(t)
+	svc := newReviewService(db)
+	ctx := context.Background()
+
+	user, product, _ := seedReviewTestData(t, db)
+	review := createReviewForOrder(t, db, user, product, nil)
+	_, err := svc.CreateReply(ctx, review.ID, ReviewReplier{Type: ReviewReplierMerchant, ID: 3}, "欢迎再次光临")
+	require.NoError(t, err)
+
+	// 删除评价级联删除回复
+	require.NoError(t, svc.DeleteReview(ctx, user.ID, review.ID))
+	var count int64
+	require.NoError(t, db.Model(&models.ReviewReply{}).Where("review_id = ?", review.ID).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+
+	_, err = svc.CreateReply(ctx, review.ID, ReviewReplier{Type: ReviewReplierAdmin, ID: 7}, "回复")
+	assertAppErrorCode(t, err, appErrors.ErrResourceNotFound.Code)
+	require.NoError(t, db.Model(&models.ReviewReply{}).Where("review_id = ?", review.ID).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestReviewService_GetProductReviews_RepliesAndImages(t *testing.T) {
+	db := setupReviewServiceTestDB(t)
+	svc := newReviewService(db)
+	ctx := context.Background()
+
+	user, product, _ := seedReviewTestData(t, db)
+	withImages := createReviewForOrder(t, db, user, product, []string{"https://cdn.example.com/review/a.jpg"})
+	withoutImages := createReviewForOrder(t, db, user, product, nil)
+
+	// 回复写入顺序与创建时间不一致，列表按创建时间升序返回
+	base := time.Now().Add(-time.Hour)
+	adminID, merchantID := int64(1), int64(2)
+	require.NoError(t, db.Create(&[]*models.ReviewReply{
+		{ReviewID: withImages.ID, AdminID: &adminID, Content: "第二条", CreatedAt: base.Add(2 * time.Minute)},
+		{ReviewID: withImages.ID, MerchantID: &merchantID, Content: "第一条", CreatedAt: base.Add(time.Minute)},
+		{ReviewID: withImages.ID, AdminID: &adminID, Content: "第三条", CreatedAt: base.Add(3 * time.Minute)},
+	}).Error)
+
+	resp, err := svc.GetProductReviews(ctx, product.ID, nil, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, resp.List, 2)
+
+	byID := make(map[int64]*ReviewInfo)
+	for _, info := range resp.List {
+		byID[info.ID] = info
+	}
+	info := byID[withImages.ID]
+	require.NotNil(t, info)
+	assert.True(t, info.HasImages)
+	assert.Equal(t, []string{"https://cdn.example.com/review/a.jpg"}, info.Images)
+	require.Len(t, info.Replies, 3)
+	assert.Equal(t, "第一条", info.Replies[0].Content)
+	assert.Equal(t, ReviewReplierMerchant, info.Replies[0].ReplierType)
+	assert.Equal(t, "第二条", info.Replies[1].Content)
+	assert.Equal(t, "第三条", info.Replies[2].Content)
+	assert.False(t, byID[withoutImages.ID].HasImages)
+	assert.Empty(t, byID[withoutImages.ID].Replies)
+
+	hasImages := true
+	resp, err = svc.GetProductReviews(ctx, product.ID, &hasImages, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Total)
+	require.Len(t, resp.List, 1)
+	assert.Equal(t, withImages.ID, resp.List[0].ID)
+
+	hasImages = false
+	resp, err = svc.GetProductReviews(ctx, product.ID, &hasImages, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Total)
+	require.Len(t, resp.List, 1)
+	assert.Equal(t, withoutImages.ID, resp.List[0].ID)
+}
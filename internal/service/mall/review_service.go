@@ -4,7 +4,11 @@ package mall
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"gorm.io/gorm"
 
@@ -33,6 +37,32 @@ func NewReviewService(
 	}
 }
 
+// 评价限制
+const (
+	MaxReviewImages      = 6   // 评价最多图片数
+	MaxReviewReplyLength = 500 // 回复内容最大长度（字符）
+)
+
+// 评价回复人类型
+const (
+	ReviewReplierAdmin    = "admin"
+	ReviewReplierMerchant = "merchant"
+)
+
+// ReviewReplier 评价回复人
+type ReviewReplier struct {
+	Type string // ReviewReplierAdmin 或 ReviewReplierMerchant
+	ID   int64
+}
+
+// ReviewReplyInfo 评价回复信息
+type ReviewReplyInfo struct {
+	ID          int64  `json:"id"`
+	ReplierType string `json:"replier_type"`
+	Content     string `json:"content"`
+	CreatedAt   string `json:"created_at"`
+}
+
 // ReviewInfo 评价信息
 type ReviewInfo struct {
 	ID          int64    `json:"id"`
@@ -44,10 +74,13 @@ type ReviewInfo struct {
 	Rating      int      `json:"rating"`
 	Content     string   `json:"content"`
 	Images      []string `json:"images,omitempty"`
+	HasImages   bool     `json:"has_images"`
 	IsAnonymous bool     `json:"is_anonymous"`
 	Reply       string   `json:"reply,omitempty"`
 	RepliedAt   string   `json:"replied_at,omitempty"`
 	CreatedAt   string   `json:"created_at"`
+
+	Replies []*ReviewReplyInfo `json:"replies,omitempty"` // 按回复时间升序
 }
 
 // ReviewListResponse 评价列表响应
@@ -105,6 +138,10 @@ func (s *ReviewService) CreateReview(ctx context.Context, userID int64, req *Cre
 		return nil, errors.ErrAlreadyExists.WithMessage("该商品已评价")
 	}
 
+	if err := validateReviewImages(req.Images); err != nil {
+		return nil, err
+	}
+
 	// 创建评价
 	var imagesJSON json.RawMessage
 	if len(req.Images) > 0 {
@@ -134,8 +171,8 @@ func (s *ReviewService) CreateReview(ctx context.Context, userID int64, req *Cre
 	return s.toReviewInfo(review), nil
 }
 
-// GetProductReviews 获取商品评价列表
-func (s *ReviewService) GetProductReviews(ctx context.Context, productID int64, page, pageSize int) (*ReviewListResponse, error) {
+// GetProductReviews 获取商品评价列表，每条评价附带回复；hasImages 非 nil 时按是否有图筛选
+func (s *ReviewService) GetProductReviews(ctx context.Context, productID int64, hasImages *bool, page, pageSize int) (*ReviewListResponse, error) {
 	if page == 0 {
 		page = 1
 	}
@@ -145,7 +182,7 @@ func (s *ReviewService) GetProductReviews(ctx context.Context, productID int64,
 
 	offset := (page - 1) * pageSize
 
-	reviews, total, err := s.reviewRepo.ListByProductID(ctx, productID, offset, pageSize)
+	reviews, total, err := s.reviewRepo.ListByProductID(ctx, productID, hasImages, offset, pageSize)
 	if err != nil {
 		return nil, errors.ErrDatabaseError.WithError(err)
 	}
@@ -154,6 +191,9 @@ func (s *ReviewService) GetProductReviews(ctx context.Context, productID int64,
 	for i, r := range reviews {
 		list[i] = s.toReviewInfo(r)
 	}
+	if err := s.attachReplies(ctx, list); err != nil {
+		return nil, err
+	}
 
 	totalPages := int(total) / pageSize
 	if int(total)%pageSize > 0 {
@@ -189,6 +229,9 @@ func (s *ReviewService) GetUserReviews(ctx context.Context, userID int64, page,
 	for i, r := range reviews {
 		list[i] = s.toReviewInfo(r)
 	}
+	if err := s.attachReplies(ctx, list); err != nil {
+		return nil, err
+	}
 
 	totalPages := int(total) / pageSize
 	if int(total)%pageSize > 0 {
@@ -267,6 +310,97 @@ func (s *ReviewService) ReplyReview(ctx context.Context, reviewID int64, reply s
 	})
 }
 
+// CreateReply 回复评价，同一评价可多次回复
+func (s *ReviewService) CreateReply(ctx context.Context, reviewID int64, replier ReviewReplier, content string) (*ReviewReplyInfo, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, errors.ErrInvalidParams.WithMessage("回复内容不能为空")
+	}
+	if utf8.RuneCountInString(content) > MaxReviewReplyLength {
+		return nil, errors.ErrInvalidParams.WithMessage(fmt.Sprintf("回复内容不能超过 %d 个字符", MaxReviewReplyLength))
+	}
+
+	reply := &models.ReviewReply{
+		ReviewID: reviewID,
+		Content:  content,
+	}
+	switch replier.Type {
+	case ReviewReplierAdmin:
+		reply.AdminID = &replier.ID
+	case ReviewReplierMerchant:
+		reply.MerchantID = &replier.ID
+	default:
+		return nil, errors.ErrInvalidParams.WithMessage("无效的回复人")
+	}
+
+	// 已删除的评价不能回复
+	if _, err := s.reviewRepo.GetByID(ctx, reviewID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrResourceNotFound.WithMessage("评价不存在")
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	if err := s.reviewRepo.CreateReply(ctx, reply); err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	return toReviewReplyInfo(reply), nil
+}
+
+// attachReplies 批量加载评价回复
+func (s *ReviewService) attachReplies(ctx context.Context, list []*ReviewInfo) error {
+	if len(list) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(list))
+	byID := make(map[int64]*ReviewInfo, len(list))
+	for i, info := range list {
+		ids[i] = info.ID
+		byID[info.ID] = info
+	}
+
+	replies, err := s.reviewRepo.ListRepliesByReviewIDs(ctx, ids)
+	if err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	for _, reply := range replies {
+		if info, ok := byID[reply.ReviewID]; ok {
+			info.Replies = append(info.Replies, toReviewReplyInfo(reply))
+		}
+	}
+	return nil
+}
+
+// validateReviewImages 校验评价图片：最多 MaxReviewImages 张，且必须为 http(s) 地址
+func validateReviewImages(images []string) error {
+	if len(images) > MaxReviewImages {
+		return errors.ErrInvalidParams.WithMessage(fmt.Sprintf("评价图片最多 %d 张", MaxReviewImages))
+	}
+	for _, image := range images {
+		u, err := url.ParseRequestURI(image)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.ErrInvalidParams.WithMessage("无效的图片地址")
+		}
+	}
+	return nil
+}
+
+// toReviewReplyInfo 转换为评价回复信息
+func toReviewReplyInfo(r *models.ReviewReply) *ReviewReplyInfo {
+	replierType := ReviewReplierAdmin
+	if r.MerchantID != nil {
+		replierType = ReviewReplierMerchant
+	}
+	return &ReviewReplyInfo{
+		ID:          r.ID,
+		ReplierType: replierType,
+		Content:     r.Content,
+		CreatedAt:   r.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
 // toReviewInfo 转换为评价信息
 func (s *ReviewService) toReviewInfo(r *models.Review) *ReviewInfo {
 	info := &ReviewInfo{
@@ -293,6 +427,7 @@ func (s *ReviewService) toReviewInfo(r *models.Review) *ReviewInfo {
 	if r.Images != nil {
 		_ = json.Unmarshal(r.Images, &info.Images)
 	}
+	info.HasImages = len(info.Images) > 0
 
 	// 用户信息
 	if r.User != nil {
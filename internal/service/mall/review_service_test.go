@@ -40,6 +40,7 @@ func setupReviewServiceTestDB(t *testing.T) *gorm.DB {
 		&models.Order{},
 		&models.OrderItem{},
 		&models.Review{},
+		&models.ReviewReply{},
 	)
 	require.NoError(t, err)
 
@@ -288,7 +289,7 @@ func TestReviewService_GetProductReviews(t *testing.T) {
 		db.Create(review)
 	}
 
-	resp, err := svc.GetProductReviews(ctx, product.ID, 1, 3)
+	resp, err := svc.GetProductReviews(ctx, product.ID, nil, 1, 3)
 	require.NoError(t, err)
 	assert.Len(t, resp.List, 3)
 	assert.Equal(t, int64(5), resp.Total)
@@ -327,19 +328,19 @@ func TestReviewService_GetProductReviews_Pagination(t *testing.T) {
 	}
 
 	// 第一页
-	resp1, err := svc.GetProductReviews(ctx, product.ID, 1, 4)
+	resp1, err := svc.GetProductReviews(ctx, product.ID, nil, 1, 4)
 	require.NoError(t, err)
 	assert.Len(t, resp1.List, 4)
 	assert.Equal(t, 1, resp1.Page)
 
 	// 第二页
-	resp2, err := svc.GetProductReviews(ctx, product.ID, 2, 4)
+	resp2, err := svc.GetProductReviews(ctx, product.ID, nil, 2, 4)
 	require.NoError(t, err)
 	assert.Len(t, resp2.List, 4)
 	assert.Equal(t, 2, resp2.Page)
 
 	// 第三页
-	resp3, err := svc.GetProductReviews(ctx, product.ID, 3, 4)
+	resp3, err := svc.GetProductReviews(ctx, product.ID, nil, 3, 4)
 	require.NoError(t, err)
 	assert.Len(t, resp3.List, 2)
 	assert.Equal(t, 3, resp3.Page)
@@ -0,0 +1,163 @@
+// Package mall 提供商城服务
+package mall
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// SearchSortRelevance 按相关度排序，关键词搜索的默认排序方式
+const SearchSortRelevance = "relevance"
+
+const (
+	searchMaxTermLength    = 64  // 词项最大字节数，与 product_search_terms.term 列宽一致，超长的词项不参与检索
+	searchReindexBatchSize = 200 // 重建检索向量时每批处理的商品数
+)
+
+// ProductSearchParams 商品检索参数
+type ProductSearchParams struct {
+	Keyword    string
+	CategoryID int64
+	MinPrice   *float64
+	MaxPrice   *float64
+	SortBy     string // relevance, price_asc, price_desc, sales_desc, newest
+	Offset     int
+	Limit      int
+}
+
+// SearchBackend 商品检索后端
+// 检索只返回上架商品；商品创建、更新、删除后需同步维护索引
+type SearchBackend interface {
+	// Search 按关键词检索商品，返回当前页商品和命中总数
+	Search(ctx context.Context, params *ProductSearchParams) ([]*models.Product, int64, error)
+	// IndexProduct 建立或刷新单个商品的索引
+	IndexProduct(ctx context.Context, product *models.Product) error
+	// RemoveProduct 删除单个商品的索引
+	RemoveProduct(ctx context.Context, productID int64) error
+	// ReindexAll 重建全部商品索引，返回已索引的商品数
+	ReindexAll(ctx context.Context) (int, error)
+}
+
+// NewSearchBackendForDialect 按数据库方言选择商品检索后端
+// PostgreSQL 使用 tsvector 全文检索（依赖 products.search_vector 列及 GIN 索引），
+// 其他数据库（如 SQLite）使用 product_search_terms 倒排索引；两者在关键词未命中时均回退到 LIKE 检索
+func NewSearchBackendForDialect(db *gorm.DB, productRepo *repository.ProductRepository) SearchBackend {
+	if db.Dialector.Name() == "postgres" {
+		return NewPostgresFTSBackend(db, productRepo)
+	}
+	return NewInvertedIndexBackend(db, productRepo)
+}
+
+// SQLiteLikeBackend 基于 SQL LIKE 的商品检索，不维护索引也不支持相关度排序
+type SQLiteLikeBackend struct {
+	productRepo *repository.ProductRepository
+}
+
+// NewSQLiteLikeBackend 创建 LIKE 商品检索
+func NewSQLiteLikeBackend(productRepo *repository.ProductRepository) *SQLiteLikeBackend {
+	return &SQLiteLikeBackend{productRepo: productRepo}
+}
+
+// Search 按名称或副标题模糊匹配整个关键词
+func (b *SQLiteLikeBackend) Search(ctx context.Context, params *ProductSearchParams) ([]*models.Product, int64, error) {
+	isOnSale := true
+	sortBy := params.SortBy
+	if sortBy == SearchSortRelevance {
+		// LIKE 无法计算相关度，使用默认排序
+		sortBy = ""
+	}
+
+	return b.productRepo.List(ctx, repository.ProductListParams{
+		Offset:     params.Offset,
+		Limit:      params.Limit,
+		CategoryID: params.CategoryID,
+		Keyword:    params.Keyword,
+		IsOnSale:   &isOnSale,
+		MinPrice:   params.MinPrice,
+		MaxPrice:   params.MaxPrice,
+		SortBy:     sortBy,
+	})
+}
+
+// IndexProduct LIKE 检索直接查询商品表，无需维护索引
+func (b *SQLiteLikeBackend) IndexProduct(ctx context.Context, product *models.Product) error {
+	return nil
+}
+
+// RemoveProduct LIKE 检索直接查询商品表，无需维护索引
+func (b *SQLiteLikeBackend) RemoveProduct(ctx context.Context, productID int64) error {
+	return nil
+}
+
+// ReindexAll LIKE 检索直接查询商品表，无需维护索引
+func (b *SQLiteLikeBackend) ReindexAll(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+// tokenizeSearchText 将搜索关键词切分为去重后的词项
+func tokenizeSearchText(text string) []string {
+	seen := make(map[string]struct{})
+	var terms []string
+	for _, term := range tokenizeText(text) {
+		if _, ok := seen[term]; ok {
+			continue
+		}
+		seen[term] = struct{}{}
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// tokenizeText 分词：英文单词转小写、数字整体保留，连续汉字按二元组切分（单个汉字保留为一元词项），其余字符视为分隔符
+func tokenizeText(text string) []string {
+	var terms []string
+	emit := func(term string) {
+		if len(term) <= searchMaxTermLength {
+			terms = append(terms, term)
+		}
+	}
+
+	var word []rune
+	var han []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			emit(strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	flushHan := func() {
+		switch {
+		case len(han) == 1:
+			emit(string(han))
+		case len(han) > 1:
+			for j := 0; j+1 < len(han); j++ {
+				emit(string(han[j : j+2]))
+			}
+		}
+		han = han[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+
+	return terms
+}
@@ -0,0 +1,151 @@
+package mall
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// ftsConfig 全文检索使用的文本搜索配置
+// 中文由 tokenizeText 预先切分为二元组，数据库侧只按空白分词，不做词干化
+const ftsConfig = "simple"
+
+// ftsMatchCondition 商品检索向量匹配关键词
+const ftsMatchCondition = "search_vector @@ plainto_tsquery('" + ftsConfig + "', ?)"
+
+// ftsVectorExpr 名称词项权重 A、描述词项权重 B 的检索向量
+const ftsVectorExpr = "setweight(to_tsvector('" + ftsConfig + "', ?), 'A') || setweight(to_tsvector('" + ftsConfig + "', ?), 'B')"
+
+// PostgresFTSBackend 基于 PostgreSQL 全文检索的商品检索
+// 商品名称和描述按 tokenizeText 分词后写入 products.search_vector，检索时要求命中全部关键词词项并按 ts_rank 排序；
+// 关键词未命中任何商品时（如检索向量尚未重建）回退到 LIKE 检索
+type PostgresFTSBackend struct {
+	db       *gorm.DB
+	fallback *SQLiteLikeBackend
+}
+
+// NewPostgresFTSBackend 创建 PostgreSQL 全文检索商品检索
+func NewPostgresFTSBackend(db *gorm.DB, productRepo *repository.ProductRepository) *PostgresFTSBackend {
+	return &PostgresFTSBackend{
+		db:       db,
+		fallback: NewSQLiteLikeBackend(productRepo),
+	}
+}
+
+// Search 按检索向量检索商品
+func (b *PostgresFTSBackend) Search(ctx context.Context, params *ProductSearchParams) ([]*models.Product, int64, error) {
+	query := ftsQueryText(params.Keyword)
+	if query == "" {
+		return b.fallback.Search(ctx, params)
+	}
+
+	base := b.db.WithContext(ctx).Model(&models.Product{}).
+		Where("is_on_sale = ?", true).
+		Where(ftsMatchCondition, query)
+	if params.CategoryID > 0 {
+		base = base.Where("category_id = ?", params.CategoryID)
+	}
+	if params.MinPrice != nil {
+		base = base.Where("price >= ?", *params.MinPrice)
+	}
+	if params.MaxPrice != nil {
+		base = base.Where("price <= ?", *params.MaxPrice)
+	}
+
+	var total int64
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return b.fallback.Search(ctx, params)
+	}
+
+	var products []*models.Product
+	err := base.Clauses(ftsOrderBy(params.SortBy, query)).
+		Offset(params.Offset).
+		Limit(params.Limit).
+		Find(&products).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+// IndexProduct 刷新商品的检索向量
+func (b *PostgresFTSBackend) IndexProduct(ctx context.Context, product *models.Product) error {
+	return updateProductSearchVector(b.db.WithContext(ctx), product)
+}
+
+// RemoveProduct 清空商品的检索向量
+func (b *PostgresFTSBackend) RemoveProduct(ctx context.Context, productID int64) error {
+	return b.db.WithContext(ctx).Model(&models.Product{}).
+		Where("id = ?", productID).
+		UpdateColumn("search_vector", nil).Error
+}
+
+// ReindexAll 重建全部商品的检索向量
+func (b *PostgresFTSBackend) ReindexAll(ctx context.Context) (int, error) {
+	indexed := 0
+	err := b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var batch []*models.Product
+		return tx.Model(&models.Product{}).FindInBatches(&batch, searchReindexBatchSize, func(_ *gorm.DB, _ int) error {
+			for _, p := range batch {
+				if err := updateProductSearchVector(tx, p); err != nil {
+					return err
+				}
+			}
+			indexed += len(batch)
+			return nil
+		}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return indexed, nil
+}
+
+// updateProductSearchVector 由商品名称和描述的词项生成检索向量
+func updateProductSearchVector(db *gorm.DB, product *models.Product) error {
+	nameText, descText := productSearchDocument(product)
+	return db.Model(&models.Product{}).
+		Where("id = ?", product.ID).
+		UpdateColumn("search_vector", gorm.Expr(ftsVectorExpr, nameText, descText)).Error
+}
+
+// productSearchDocument 返回商品名称、描述分词后以空格拼接的文本，供 to_tsvector 按空白切分
+func productSearchDocument(product *models.Product) (nameText, descText string) {
+	nameText = strings.Join(tokenizeText(product.Name), " ")
+	if product.Description != nil {
+		descText = strings.Join(tokenizeText(*product.Description), " ")
+	}
+	return nameText, descText
+}
+
+// ftsQueryText 将关键词切分为去重后的词项并以空格拼接，交由 plainto_tsquery 组合为 AND 查询
+func ftsQueryText(keyword string) string {
+	return strings.Join(tokenizeSearchText(keyword), " ")
+}
+
+// ftsOrderBy 全文检索的排序子句，未指定排序方式时按相关度，排序字段相同时按相关度及商品ID倒序
+func ftsOrderBy(sortBy, query string) clause.OrderBy {
+	var prefix string
+	switch sortBy {
+	case "price_asc":
+		prefix = "price ASC, "
+	case "price_desc":
+		prefix = "price DESC, "
+	case "sales_desc":
+		prefix = "sales DESC, "
+	case "newest":
+		prefix = "created_at DESC, "
+	}
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:  prefix + "ts_rank(search_vector, plainto_tsquery('" + ftsConfig + "', ?)) DESC, id DESC",
+		Vars: []interface{}{query},
+	}}
+}
@@ -0,0 +1,96 @@
+package mall
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// openDryRunPostgres 创建不连接数据库的 PostgreSQL 方言实例，仅用于生成 SQL
+func openDryRunPostgres(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=dry_run sslmode=disable"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	return db
+}
+
+func TestNewSearchBackendForDialect(t *testing.T) {
+	sqliteDB := setupSearchBackendTestDB(t)
+	assert.IsType(t, &InvertedIndexBackend{}, NewSearchBackendForDialect(sqliteDB, repository.NewProductRepository(sqliteDB)))
+
+	pgDB := openDryRunPostgres(t)
+	assert.IsType(t, &PostgresFTSBackend{}, NewSearchBackendForDialect(pgDB, repository.NewProductRepository(pgDB)))
+}
+
+func TestFTSQueryText(t *testing.T) {
+	assert.Equal(t, "蕾丝 套装", ftsQueryText(" 蕾丝 套装 蕾丝 "))
+	assert.Equal(t, "durex 安全 全套", ftsQueryText("Durex安全套"))
+	assert.Empty(t, ftsQueryText("，。!"))
+}
+
+func TestProductSearchDocument(t *testing.T) {
+	description := "柔软亲肤"
+	nameText, descText := productSearchDocument(&models.Product{Name: "蕾丝套装", Description: &description})
+	assert.Equal(t, "蕾丝 丝套 套装", nameText)
+	assert.Equal(t, "柔软 软亲 亲肤", descText)
+
+	nameText, descText = productSearchDocument(&models.Product{Name: "Durex 12只"})
+	assert.Equal(t, "durex 12 只", nameText)
+	assert.Empty(t, descText)
+}
+
+func TestFTSOrderBy(t *testing.T) {
+	db := openDryRunPostgres(t)
+
+	render := func(sortBy string) string {
+		return db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			var products []*models.Product
+			return tx.Model(&models.Product{}).
+				Where(ftsMatchCondition, "蕾丝").
+				Clauses(ftsOrderBy(sortBy, "蕾丝")).
+				Find(&products)
+		})
+	}
+
+	sql := render(SearchSortRelevance)
+	assert.Contains(t, sql, `search_vector @@ plainto_tsquery('simple', '蕾丝')`)
+	assert.Contains(t, sql, `ORDER BY ts_rank(search_vector, plainto_tsquery('simple', '蕾丝')) DESC, id DESC`)
+
+	assert.Contains(t, render("price_asc"), "ORDER BY price ASC, ts_rank(")
+	assert.Contains(t, render("price_desc"), "ORDER BY price DESC, ts_rank(")
+	assert.Contains(t, render("sales_desc"), "ORDER BY sales DESC, ts_rank(")
+	assert.Contains(t, render("newest"), "ORDER BY created_at DESC, ts_rank(")
+}
+
+func TestSQLiteLikeBackend_NoIndexMaintenance(t *testing.T) {
+	db := setupSearchBackendTestDB(t)
+	backend := NewSQLiteLikeBackend(repository.NewProductRepository(db))
+	ctx := context.Background()
+
+	seedSearchProduct(t, db, "蕾丝套装", "", 10, true)
+	seedSearchProduct(t, db, "蕾丝内衣", "", 5, false)
+	seedSearchProduct(t, db, "按摩精油", "", 3, true)
+
+	// LIKE 检索无需维护索引
+	require.NoError(t, backend.IndexProduct(ctx, &models.Product{ID: 1, Name: "蕾丝套装"}))
+	indexed, err := backend.ReindexAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, indexed)
+
+	products, total, err := backend.Search(ctx, &ProductSearchParams{Keyword: "蕾丝", SortBy: SearchSortRelevance, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []string{"蕾丝套装"}, productNames(products))
+}
@@ -0,0 +1,248 @@
+package mall
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+const (
+	searchNameTermWeight = 2    // 名称中出现的词项按 2 倍词频计入
+	searchCoverageWeight = 10.0 // 命中词项覆盖率权重，保证命中全部关键词的商品排在前面
+	searchSalesWeight    = 0.5  // 销量权重（按 ln(1+销量) 计分）
+	searchRecencyWeight  = 1.0  // 新品权重
+	searchRecencyDays    = 30.0 // 新品得分衰减周期（天）
+)
+
+// InvertedIndexBackend 基于 product_search_terms 倒排索引的商品检索
+// 商品名称和描述分词后记录词频，检索时按命中词项覆盖率、词频、销量和新品程度综合排序；
+// 只有当前页的商品会完整加载；关键词未命中任何词项时（如索引尚未重建）回退到 LIKE 检索
+type InvertedIndexBackend struct {
+	db       *gorm.DB
+	fallback *SQLiteLikeBackend
+	now      func() time.Time
+}
+
+// NewInvertedIndexBackend 创建倒排索引商品检索
+func NewInvertedIndexBackend(db *gorm.DB, productRepo *repository.ProductRepository) *InvertedIndexBackend {
+	return &InvertedIndexBackend{
+		db:       db,
+		fallback: NewSQLiteLikeBackend(productRepo),
+		now:      time.Now,
+	}
+}
+
+// productMatch 商品命中的词项统计
+type productMatch struct {
+	matchedTerms int
+	termScore    float64
+}
+
+// Search 按倒排索引检索商品
+func (b *InvertedIndexBackend) Search(ctx context.Context, params *ProductSearchParams) ([]*models.Product, int64, error) {
+	terms := tokenizeSearchText(params.Keyword)
+	if len(terms) == 0 {
+		return b.fallback.Search(ctx, params)
+	}
+
+	var hits []*models.ProductSearchTerm
+	if err := b.db.WithContext(ctx).Where("term IN ?", terms).Find(&hits).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(hits) == 0 {
+		return b.fallback.Search(ctx, params)
+	}
+
+	matches := make(map[int64]*productMatch)
+	productIDs := make([]int64, 0, len(hits))
+	for _, hit := range hits {
+		match, ok := matches[hit.ProductID]
+		if !ok {
+			match = &productMatch{}
+			matches[hit.ProductID] = match
+			productIDs = append(productIDs, hit.ProductID)
+		}
+		match.matchedTerms++
+		match.termScore += 1 + math.Log(float64(hit.Frequency))
+	}
+
+	query := b.db.WithContext(ctx).Model(&models.Product{}).
+		Where("id IN ? AND is_on_sale = ?", productIDs, true)
+	if params.CategoryID > 0 {
+		query = query.Where("category_id = ?", params.CategoryID)
+	}
+	if params.MinPrice != nil {
+		query = query.Where("price >= ?", *params.MinPrice)
+	}
+	if params.MaxPrice != nil {
+		query = query.Where("price <= ?", *params.MaxPrice)
+	}
+
+	// 先只取排序所需的列计算相关度，排序分页后再加载当前页商品
+	var candidates []*models.Product
+	if err := query.Select("id", "price", "sales", "created_at").Find(&candidates).Error; err != nil {
+		return nil, 0, err
+	}
+
+	scores := make(map[int64]float64, len(candidates))
+	for _, p := range candidates {
+		scores[p.ID] = b.relevance(p, matches[p.ID], len(terms))
+	}
+	sortSearchProducts(candidates, scores, params.SortBy)
+
+	total := int64(len(candidates))
+	start := min(params.Offset, len(candidates))
+	end := len(candidates)
+	if params.Limit > 0 && start+params.Limit < end {
+		end = start + params.Limit
+	}
+	if start == end {
+		return []*models.Product{}, total, nil
+	}
+
+	pageIDs := make([]int64, 0, end-start)
+	for _, p := range candidates[start:end] {
+		pageIDs = append(pageIDs, p.ID)
+	}
+	var pageProducts []*models.Product
+	if err := b.db.WithContext(ctx).Where("id IN ?", pageIDs).Find(&pageProducts).Error; err != nil {
+		return nil, 0, err
+	}
+
+	byID := make(map[int64]*models.Product, len(pageProducts))
+	for _, p := range pageProducts {
+		byID[p.ID] = p
+	}
+	products := make([]*models.Product, 0, len(pageIDs))
+	for _, id := range pageIDs {
+		if p, ok := byID[id]; ok {
+			products = append(products, p)
+		}
+	}
+	return products, total, nil
+}
+
+// relevance 计算商品相关度：命中词项覆盖率为主，词频、销量和新品程度为辅
+func (b *InvertedIndexBackend) relevance(p *models.Product, match *productMatch, queryTerms int) float64 {
+	coverage := float64(match.matchedTerms) / float64(queryTerms)
+	termScore := match.termScore / float64(queryTerms)
+	sales := math.Log1p(float64(max(p.Sales, 0)))
+
+	ageDays := b.now().Sub(p.CreatedAt).Hours() / 24
+	if ageDays < 0 {
+		ageDays = 0
+	}
+	recency := 1 / (1 + ageDays/searchRecencyDays)
+
+	return coverage*searchCoverageWeight + termScore + sales*searchSalesWeight + recency*searchRecencyWeight
+}
+
+// sortSearchProducts 按排序方式排列检索结果，默认按相关度
+func sortSearchProducts(products []*models.Product, scores map[int64]float64, sortBy string) {
+	sort.SliceStable(products, func(a, b int) bool {
+		pa, pb := products[a], products[b]
+		switch sortBy {
+		case "price_asc":
+			if pa.Price != pb.Price {
+				return pa.Price < pb.Price
+			}
+		case "price_desc":
+			if pa.Price != pb.Price {
+				return pa.Price > pb.Price
+			}
+		case "sales_desc":
+			if pa.Sales != pb.Sales {
+				return pa.Sales > pb.Sales
+			}
+		case "newest":
+			if !pa.CreatedAt.Equal(pb.CreatedAt) {
+				return pa.CreatedAt.After(pb.CreatedAt)
+			}
+		}
+		if scores[pa.ID] != scores[pb.ID] {
+			return scores[pa.ID] > scores[pb.ID]
+		}
+		return pa.ID > pb.ID
+	})
+}
+
+// IndexProduct 重新生成商品的词项
+func (b *InvertedIndexBackend) IndexProduct(ctx context.Context, product *models.Product) error {
+	terms := buildProductSearchTerms(product)
+	return b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.ProductSearchTerm{}).Error; err != nil {
+			return err
+		}
+		if len(terms) == 0 {
+			return nil
+		}
+		return tx.Create(&terms).Error
+	})
+}
+
+// RemoveProduct 删除商品的词项
+func (b *InvertedIndexBackend) RemoveProduct(ctx context.Context, productID int64) error {
+	return b.db.WithContext(ctx).Where("product_id = ?", productID).Delete(&models.ProductSearchTerm{}).Error
+}
+
+// ReindexAll 清空并重建全部商品的词项
+func (b *InvertedIndexBackend) ReindexAll(ctx context.Context) (int, error) {
+	indexed := 0
+	err := b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.ProductSearchTerm{}).Error; err != nil {
+			return err
+		}
+
+		var batch []*models.Product
+		return tx.Model(&models.Product{}).FindInBatches(&batch, searchReindexBatchSize, func(_ *gorm.DB, _ int) error {
+			var terms []*models.ProductSearchTerm
+			for _, p := range batch {
+				terms = append(terms, buildProductSearchTerms(p)...)
+			}
+			indexed += len(batch)
+			if len(terms) == 0 {
+				return nil
+			}
+			return tx.CreateInBatches(&terms, searchReindexBatchSize).Error
+		}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return indexed, nil
+}
+
+// buildProductSearchTerms 由商品名称和描述生成词项及词频
+func buildProductSearchTerms(product *models.Product) []*models.ProductSearchTerm {
+	frequencies := make(map[string]int)
+	var order []string
+	add := func(text string, weight int) {
+		for _, term := range tokenizeText(text) {
+			if _, ok := frequencies[term]; !ok {
+				order = append(order, term)
+			}
+			frequencies[term] += weight
+		}
+	}
+
+	add(product.Name, searchNameTermWeight)
+	if product.Description != nil {
+		add(*product.Description, 1)
+	}
+
+	terms := make([]*models.ProductSearchTerm, 0, len(order))
+	for _, term := range order {
+		terms = append(terms, &models.ProductSearchTerm{
+			ProductID: product.ID,
+			Term:      term,
+			Frequency: frequencies[term],
+		})
+	}
+	return terms
+}
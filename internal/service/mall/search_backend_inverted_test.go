@@ -0,0 +1,137 @@
+package mall
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+func TestInvertedIndexBackend_MultiWordRelevance(t *testing.T) {
+	db := setupSearchBackendTestDB(t)
+	ctx := context.Background()
+	productRepo := repository.NewProductRepository(db)
+	backend := NewInvertedIndexBackend(db, productRepo)
+
+	seedSearchProduct(t, db, "情趣内衣套装", "蕾丝性感款", 50, true)
+	seedSearchProduct(t, db, "蕾丝内衣", "", 200, true)
+	seedSearchProduct(t, db, "套装礼盒", "", 10, true)
+	seedSearchProduct(t, db, "润滑剂", "水溶性", 500, true)
+	seedSearchProduct(t, db, "蕾丝套装（已下架）", "", 1000, false)
+
+	indexed, err := backend.ReindexAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, indexed)
+
+	params := &ProductSearchParams{Keyword: "蕾丝 套装", SortBy: SearchSortRelevance, Limit: 10}
+
+	// LIKE 整体匹配关键词，多词查询没有结果
+	likeProducts, likeTotal, err := NewSQLiteLikeBackend(productRepo).Search(ctx, params)
+	require.NoError(t, err)
+	assert.Empty(t, likeProducts)
+	assert.Equal(t, int64(0), likeTotal)
+
+	// 倒排索引：命中全部词项的商品排第一，其余按销量排序，已下架商品不返回
+	products, total, err := backend.Search(ctx, params)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, []string{"情趣内衣套装", "蕾丝内衣", "套装礼盒"}, productNames(products))
+	// 当前页商品完整加载
+	assert.NotEmpty(t, products[0].Images)
+
+	t.Run("分页", func(t *testing.T) {
+		page, total, err := backend.Search(ctx, &ProductSearchParams{Keyword: "蕾丝 套装", Offset: 1, Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		assert.Equal(t, []string{"蕾丝内衣"}, productNames(page))
+
+		beyond, _, err := backend.Search(ctx, &ProductSearchParams{Keyword: "蕾丝 套装", Offset: 10, Limit: 10})
+		require.NoError(t, err)
+		assert.Empty(t, beyond)
+	})
+
+	t.Run("指定排序方式优先于相关度", func(t *testing.T) {
+		sorted, _, err := backend.Search(ctx, &ProductSearchParams{Keyword: "蕾丝 套装", SortBy: "sales_desc", Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"蕾丝内衣", "情趣内衣套装", "套装礼盒"}, productNames(sorted))
+	})
+
+	t.Run("未命中索引时回退 LIKE", func(t *testing.T) {
+		fallback, total, err := backend.Search(ctx, &ProductSearchParams{Keyword: "润", Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, []string{"润滑剂"}, productNames(fallback))
+	})
+}
+
+func TestInvertedIndexBackend_RecencyBreaksTies(t *testing.T) {
+	db := setupSearchBackendTestDB(t)
+	ctx := context.Background()
+	backend := NewInvertedIndexBackend(db, repository.NewProductRepository(db))
+
+	older := seedSearchProduct(t, db, "蕾丝睡衣", "", 20, true)
+	newer := seedSearchProduct(t, db, "蕾丝睡裙", "", 20, true)
+	now := time.Now()
+	require.NoError(t, db.Model(older).Update("created_at", now.AddDate(0, 0, -90)).Error)
+	require.NoError(t, db.Model(newer).Update("created_at", now.AddDate(0, 0, -1)).Error)
+	_, err := backend.ReindexAll(ctx)
+	require.NoError(t, err)
+
+	// 词项命中和销量相同，较新的商品排在前面
+	products, _, err := backend.Search(ctx, &ProductSearchParams{Keyword: "蕾丝", SortBy: SearchSortRelevance, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"蕾丝睡裙", "蕾丝睡衣"}, productNames(products))
+}
+
+func TestInvertedIndexBackend_IndexAndRemove(t *testing.T) {
+	db := setupSearchBackendTestDB(t)
+	ctx := context.Background()
+	backend := NewInvertedIndexBackend(db, repository.NewProductRepository(db))
+
+	product := seedSearchProduct(t, db, "蕾丝内衣", "蕾丝花边", 0, true)
+	require.NoError(t, backend.IndexProduct(ctx, product))
+
+	var term models.ProductSearchTerm
+	require.NoError(t, db.Where("product_id = ? AND term = ?", product.ID, "蕾丝").First(&term).Error)
+	// 名称按权重计 2 次，描述计 1 次
+	assert.Equal(t, 3, term.Frequency)
+
+	// 更新后旧词项被替换
+	product.Name = "丝袜"
+	product.Description = nil
+	require.NoError(t, backend.IndexProduct(ctx, product))
+	var terms []string
+	require.NoError(t, db.Model(&models.ProductSearchTerm{}).Where("product_id = ?", product.ID).Pluck("term", &terms).Error)
+	assert.Equal(t, []string{"丝袜"}, terms)
+
+	require.NoError(t, backend.RemoveProduct(ctx, product.ID))
+	var count int64
+	require.NoError(t, db.Model(&models.ProductSearchTerm{}).Where("product_id = ?", product.ID).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestSearchService_SearchProductsFTS_InvertedIndex(t *testing.T) {
+	db := setupSearchBackendTestDB(t)
+	ctx := context.Background()
+	productRepo := repository.NewProductRepository(db)
+
+	seedSearchProduct(t, db, "蕾丝内衣", "", 200, true)
+	seedSearchProduct(t, db, "情趣内衣套装", "蕾丝性感款", 50, true)
+
+	// 非 PostgreSQL 默认使用倒排索引，重建索引后多词查询按相关度返回
+	service := NewSearchService(db, productRepo)
+	_, err := service.backend.ReindexAll(ctx)
+	require.NoError(t, err)
+
+	result, err := service.SearchProductsFTS(ctx, &SearchRequest{Keyword: "蕾丝 套装", Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Total)
+	require.Len(t, result.Products, 2)
+	assert.Equal(t, "情趣内衣套装", result.Products[0].Name)
+	assert.Equal(t, "蕾丝内衣", result.Products[1].Name)
+}
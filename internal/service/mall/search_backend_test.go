@@ -0,0 +1,138 @@
+// Package mall 商品检索后端单元测试
+package mall
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// setupSearchBackendTestDB 创建商品检索测试数据库
+func setupSearchBackendTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+
+	require.NoError(t, db.AutoMigrate(
+		&models.Category{},
+		&models.Product{},
+		&models.ProductSearchTerm{},
+	))
+	return db
+}
+
+// seedSearchProduct 创建检索测试商品
+func seedSearchProduct(t testing.TB, db *gorm.DB, name, description string, sales int, isOnSale bool) *models.Product {
+	t.Helper()
+	images, _ := json.Marshal([]string{"https://example.com/img.jpg"})
+	product := &models.Product{
+		CategoryID: 1,
+		Name:       name,
+		Images:     images,
+		Price:      99.0,
+		Stock:      10,
+		Sales:      sales,
+		Unit:       "件",
+		IsOnSale:   isOnSale,
+	}
+	if description != "" {
+		product.Description = &description
+	}
+	require.NoError(t, db.Create(product).Error)
+	// 上下架字段默认值为 true，需显式更新
+	require.NoError(t, db.Model(product).Update("is_on_sale", isOnSale).Error)
+	return product
+}
+
+func productNames(products []*models.Product) []string {
+	names := make([]string, len(products))
+	for i, p := range products {
+		names[i] = p.Name
+	}
+	return names
+}
+
+func TestTokenizeText(t *testing.T) {
+	assert.Equal(t, []string{"情趣", "趣内", "内衣", "衣套", "套装"}, tokenizeText("情趣内衣套装"))
+	assert.Equal(t, []string{"蕾丝", "套装"}, tokenizeText("蕾丝 套装"))
+	assert.Equal(t, []string{"durex", "安全", "全套", "12", "只"}, tokenizeText("Durex安全套 12只"))
+	assert.Equal(t, []string{"套"}, tokenizeText("套"))
+	assert.Empty(t, tokenizeText("  ，。!"))
+	assert.Equal(t, []string{"蕾丝", "套装"}, tokenizeSearchText("蕾丝 套装 蕾丝"))
+}
+
+func TestSearchService_SearchProductsFTS_SQLiteLike(t *testing.T) {
+	db := setupSearchBackendTestDB(t)
+	ctx := context.Background()
+
+	seedSearchProduct(t, db, "蕾丝套装", "", 10, true)
+	seedSearchProduct(t, db, "蕾丝内衣", "", 200, true)
+	seedSearchProduct(t, db, "蕾丝睡衣（已下架）", "", 5, false)
+	seedSearchProduct(t, db, "按摩精油", "", 3, true)
+
+	productRepo := repository.NewProductRepository(db)
+	service := NewSearchService(db, productRepo)
+	service.SetSearchBackend(NewSQLiteLikeBackend(productRepo))
+
+	result, err := service.SearchProductsFTS(ctx, &SearchRequest{Keyword: "蕾丝", SortBy: "sales_desc", Page: 1, PageSize: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Total)
+	assert.Equal(t, 2, result.TotalPages)
+	require.Len(t, result.Products, 1)
+	assert.Equal(t, "蕾丝内衣", result.Products[0].Name)
+
+	// LIKE 整体匹配关键词，多词查询没有结果
+	result, err = service.SearchProductsFTS(ctx, &SearchRequest{Keyword: "蕾丝 套装", Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), result.Total)
+}
+
+func benchmarkSearchBackend(b *testing.B, keyword string, newBackend func(db *gorm.DB) SearchBackend) {
+	db := setupSearchBackendTestDB(b)
+	ctx := context.Background()
+	for i := 0; i < 500; i++ {
+		seedSearchProduct(b, db, fmt.Sprintf("蕾丝内衣%d号", i), "柔软舒适套装", i, true)
+	}
+	backend := newBackend(db)
+	_, err := backend.ReindexAll(ctx)
+	require.NoError(b, err)
+
+	params := &ProductSearchParams{Keyword: keyword, SortBy: SearchSortRelevance, Limit: 20}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := backend.Search(ctx, params); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSQLiteLikeBackend_Search(b *testing.B) {
+	benchmarkSearchBackend(b, "蕾丝", func(db *gorm.DB) SearchBackend {
+		return NewSQLiteLikeBackend(repository.NewProductRepository(db))
+	})
+}
+
+func BenchmarkInvertedIndexBackend_Search(b *testing.B) {
+	benchmarkSearchBackend(b, "蕾丝 套装", func(db *gorm.DB) SearchBackend {
+		return NewInvertedIndexBackend(db, repository.NewProductRepository(db))
+	})
+}
@@ -12,13 +12,18 @@ import (
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 )
 
+// searchMaxPageSize 搜索每页最大数量，与请求参数校验一致
+const searchMaxPageSize = 100
+
 // SearchService 商品搜索服务
 type SearchService struct {
 	db          *gorm.DB
 	productRepo *repository.ProductRepository
+	backend     SearchBackend
 }
 
 // NewSearchService 创建搜索服务
+// 按数据库方言选择检索后端，见 NewSearchBackendForDialect
 func NewSearchService(
 	db *gorm.DB,
 	productRepo *repository.ProductRepository,
@@ -26,16 +31,22 @@ func NewSearchService(
 	return &SearchService{
 		db:          db,
 		productRepo: productRepo,
+		backend:     NewSearchBackendForDialect(db, productRepo),
 	}
 }
 
+// SetSearchBackend 设置商品检索后端，需与商品管理维护索引使用同一后端
+func (s *SearchService) SetSearchBackend(backend SearchBackend) {
+	s.backend = backend
+}
+
 // SearchRequest 搜索请求
 type SearchRequest struct {
 	Keyword    string   `form:"keyword" binding:"required"`
 	CategoryID int64    `form:"category_id"`
 	MinPrice   float64  `form:"min_price"`
 	MaxPrice   float64  `form:"max_price"`
-	SortBy     string   `form:"sort_by"` // price_asc, price_desc, sales_desc, newest
+	SortBy     string   `form:"sort_by"` // relevance（默认）, price_asc, price_desc, sales_desc, newest
 	Page       int      `form:"page" binding:"min=1"`
 	PageSize   int      `form:"page_size" binding:"min=1,max=100"`
 }
@@ -56,14 +67,19 @@ type SearchSuggestion struct {
 	Count   int64  `json:"count"`
 }
 
-// Search 搜索商品
-func (s *SearchService) Search(ctx context.Context, req *SearchRequest) (*SearchResult, error) {
+// SearchProductsFTS 搜索商品
+// 由检索后端执行全文检索（PostgreSQL）或倒排索引检索，未指定排序方式时按相关度排序；
+// 分页在查询中完成，每页最多 searchMaxPageSize 条
+func (s *SearchService) SearchProductsFTS(ctx context.Context, req *SearchRequest) (*SearchResult, error) {
 	if req.Page == 0 {
 		req.Page = 1
 	}
 	if req.PageSize == 0 {
 		req.PageSize = 20
 	}
+	if req.PageSize > searchMaxPageSize {
+		req.PageSize = searchMaxPageSize
+	}
 
 	// 清理关键词
 	keyword := strings.TrimSpace(req.Keyword)
@@ -71,16 +87,17 @@ func (s *SearchService) Search(ctx context.Context, req *SearchRequest) (*Search
 		return nil, errors.ErrInvalidParams.WithMessage("搜索关键词不能为空")
 	}
 
-	offset := (req.Page - 1) * req.PageSize
-	isOnSale := true
+	sortBy := req.SortBy
+	if sortBy == "" {
+		sortBy = SearchSortRelevance
+	}
 
-	params := repository.ProductListParams{
-		Offset:     offset,
-		Limit:      req.PageSize,
-		CategoryID: req.CategoryID,
+	params := &ProductSearchParams{
 		Keyword:    keyword,
-		IsOnSale:   &isOnSale,
-		SortBy:     req.SortBy,
+		CategoryID: req.CategoryID,
+		SortBy:     sortBy,
+		Offset:     (req.Page - 1) * req.PageSize,
+		Limit:      req.PageSize,
 	}
 
 	if req.MinPrice > 0 {
@@ -90,7 +107,7 @@ func (s *SearchService) Search(ctx context.Context, req *SearchRequest) (*Search
 		params.MaxPrice = &req.MaxPrice
 	}
 
-	products, total, err := s.productRepo.List(ctx, params)
+	products, total, err := s.backend.Search(ctx, params)
 	if err != nil {
 		return nil, errors.ErrDatabaseError.WithError(err)
 	}
@@ -38,6 +38,7 @@ func setupSearchServiceTestDB(t *testing.T) *gorm.DB {
 		&models.Category{},
 		&models.Product{},
 		&models.ProductSku{},
+		&models.ProductSearchTerm{},
 	)
 	require.NoError(t, err)
 	return db
@@ -170,7 +171,7 @@ func TestSearchService_Search_Success(t *testing.T) {
 			PageSize: 10,
 		}
 
-		result, err := service.Search(ctx, req)
+		result, err := service.SearchProductsFTS(ctx, req)
 		require.NoError(t, err)
 		assert.NotNil(t, result)
 		assert.Greater(t, result.Total, int64(0))
@@ -197,7 +198,7 @@ func TestSearchService_Search_Success(t *testing.T) {
 			PageSize: 10,
 		}
 
-		result, err := service.Search(ctx, req)
+		result, err := service.SearchProductsFTS(ctx, req)
 		require.NoError(t, err)
 		assert.NotNil(t, result)
 		assert.Greater(t, result.Total, int64(0))
@@ -222,7 +223,7 @@ func TestSearchService_Search_Success(t *testing.T) {
 			PageSize:   10,
 		}
 
-		result, err := service.Search(ctx, req)
+		result, err := service.SearchProductsFTS(ctx, req)
 		require.NoError(t, err)
 		assert.NotNil(t, result)
 
@@ -241,7 +242,7 @@ func TestSearchService_Search_Success(t *testing.T) {
 			PageSize: 10,
 		}
 
-		result, err := service.Search(ctx, req)
+		result, err := service.SearchProductsFTS(ctx, req)
 		require.NoError(t, err)
 		assert.NotNil(t, result)
 
@@ -260,7 +261,7 @@ func TestSearchService_Search_Success(t *testing.T) {
 			PageSize: 10,
 		}
 
-		result, err := service.Search(ctx, req)
+		result, err := service.SearchProductsFTS(ctx, req)
 		require.NoError(t, err)
 		assert.NotNil(t, result)
 
@@ -279,7 +280,7 @@ func TestSearchService_Search_Success(t *testing.T) {
 			PageSize: 2,
 		}
 
-		result, err := service.Search(ctx, req)
+		result, err := service.SearchProductsFTS(ctx, req)
 		require.NoError(t, err)
 		assert.NotNil(t, result)
 		assert.LessOrEqual(t, len(result.Products), 2)
@@ -291,11 +292,50 @@ func TestSearchService_Search_Success(t *testing.T) {
 			Keyword: "套",
 		}
 
-		result, err := service.Search(ctx, req)
+		result, err := service.SearchProductsFTS(ctx, req)
 		require.NoError(t, err)
 		assert.Equal(t, 1, result.Page)
 		assert.Equal(t, 20, result.PageSize)
 	})
+
+	t.Run("每页数量上限", func(t *testing.T) {
+		result, err := service.SearchProductsFTS(ctx, &SearchRequest{Keyword: "套", PageSize: 1000})
+		require.NoError(t, err)
+		assert.Equal(t, searchMaxPageSize, result.PageSize)
+	})
+}
+
+func TestSearchService_SearchProductsFTS_PaginatesInQuery(t *testing.T) {
+	db := setupSearchServiceTestDB(t)
+	service := newSearchService(db)
+	ctx := context.Background()
+
+	images, _ := json.Marshal([]string{"https://example.com/img.jpg"})
+	for i := 0; i < 5; i++ {
+		require.NoError(t, db.Create(&models.Product{CategoryID: 1, Name: fmt.Sprintf("分页商品%d", i), Price: 10, Stock: 10, Unit: "件", Images: images, IsOnSale: true}).Error)
+	}
+
+	// 记录商品列表查询的 SQL，确认分页下推到数据库而非在内存中截取
+	var statements []string
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+		if tx.Statement.Table == "products" {
+			statements = append(statements, tx.Statement.SQL.String())
+		}
+	}))
+
+	result, err := service.SearchProductsFTS(ctx, &SearchRequest{Keyword: "分页商品", Page: 3, PageSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), result.Total)
+	assert.Equal(t, 3, result.TotalPages)
+	assert.Len(t, result.Products, 1)
+
+	var paged bool
+	for _, sql := range statements {
+		if strings.Contains(sql, "LIMIT 2") && strings.Contains(sql, "OFFSET 4") {
+			paged = true
+		}
+	}
+	assert.True(t, paged, "分页应在 SQL 中完成: %v", statements)
 }
 
 func TestSearchService_Search_EmptyKeyword(t *testing.T) {
@@ -309,7 +349,7 @@ func TestSearchService_Search_EmptyKeyword(t *testing.T) {
 		PageSize: 10,
 	}
 
-	result, err := service.Search(ctx, req)
+	result, err := service.SearchProductsFTS(ctx, req)
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "搜索关键词不能为空")
@@ -326,7 +366,7 @@ func TestSearchService_Search_WhitespaceKeyword(t *testing.T) {
 		PageSize: 10,
 	}
 
-	result, err := service.Search(ctx, req)
+	result, err := service.SearchProductsFTS(ctx, req)
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "搜索关键词不能为空")
@@ -345,7 +385,7 @@ func TestSearchService_Search_NoResults(t *testing.T) {
 		PageSize: 10,
 	}
 
-	result, err := service.Search(ctx, req)
+	result, err := service.SearchProductsFTS(ctx, req)
 	require.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Equal(t, int64(0), result.Total)
@@ -365,7 +405,7 @@ func TestSearchService_Search_OnlyOnSaleProducts(t *testing.T) {
 		PageSize: 100,
 	}
 
-	result, err := service.Search(ctx, req)
+	result, err := service.SearchProductsFTS(ctx, req)
 	require.NoError(t, err)
 
 	// 验证所有搜索结果都是在售商品
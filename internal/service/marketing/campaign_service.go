@@ -2,26 +2,42 @@
 package marketing
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
 	"time"
 
+	"gorm.io/gorm"
+
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 )
 
 // CampaignService 活动服务
 type CampaignService struct {
-	campaignRepo *repository.CampaignRepository
+	db                  *gorm.DB
+	campaignRepo        *repository.CampaignRepository
+	campaignProductRepo *repository.CampaignProductRepository
+	flashSale           *FlashSaleService
 }
 
 // NewCampaignService 创建活动服务
-func NewCampaignService(campaignRepo *repository.CampaignRepository) *CampaignService {
+func NewCampaignService(db *gorm.DB, campaignRepo *repository.CampaignRepository) *CampaignService {
 	return &CampaignService{
-		campaignRepo: campaignRepo,
+		db:                  db,
+		campaignRepo:        campaignRepo,
+		campaignProductRepo: repository.NewCampaignProductRepository(db),
 	}
 }
 
+// SetFlashSaleService 设置秒杀服务，预占秒杀库存前先以其 Redis 计数预检当日名额
+func (s *CampaignService) SetFlashSaleService(flashSale *FlashSaleService) {
+	s.flashSale = flashSale
+}
+
 // CampaignListRequest 活动列表请求
 type CampaignListRequest struct {
 	Page     int
@@ -106,7 +122,135 @@ type DiscountRule struct {
 	Discount  float64 `json:"discount"`   // 优惠金额
 }
 
+// DiscountRulesVersion 满减规则存储格式版本
+const DiscountRulesVersion = 1
+
+// DiscountRules 满减活动规则的存储格式：{"version":1,"tiers":[{"min_amount":100,"discount":10}]}
+type DiscountRules struct {
+	Version int            `json:"version"`
+	Tiers   []DiscountRule `json:"tiers"`
+}
+
+// ValidateDiscountRules 校验满减档位：至少一档，门槛金额不为负且严格升序，优惠金额大于0
+func ValidateDiscountRules(rules []DiscountRule) error {
+	if len(rules) == 0 {
+		return fmt.Errorf("%w: 至少需要一个满减档位", ErrCampaignRuleInvalid)
+	}
+	for i, rule := range rules {
+		if rule.MinAmount < 0 {
+			return fmt.Errorf("%w: 门槛金额不能为负", ErrCampaignRuleInvalid)
+		}
+		if rule.Discount <= 0 {
+			return fmt.Errorf("%w: 优惠金额必须大于0", ErrCampaignRuleInvalid)
+		}
+		if i == 0 {
+			continue
+		}
+		if rule.MinAmount == rules[i-1].MinAmount {
+			return fmt.Errorf("%w: 满减档位重复", ErrCampaignRuleInvalid)
+		}
+		if rule.MinAmount < rules[i-1].MinAmount {
+			return fmt.Errorf("%w: 门槛金额须按升序排列", ErrCampaignRuleInvalid)
+		}
+	}
+	return nil
+}
+
+// EncodeDiscountRules 校验满减档位并编码为版本化存储格式
+func EncodeDiscountRules(rules []DiscountRule) (json.RawMessage, error) {
+	if err := ValidateDiscountRules(rules); err != nil {
+		return nil, err
+	}
+	return json.Marshal(&DiscountRules{Version: DiscountRulesVersion, Tiers: rules})
+}
+
+// ParseDiscountRules 按原顺序解析满减规则，兼容旧版本存储的 {"rules":[...]} 和直接存储的数组
+func ParseDiscountRules(raw json.RawMessage) ([]DiscountRule, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	var rules []DiscountRule
+	if raw[0] == '[' {
+		if err := json.Unmarshal(raw, &rules); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCampaignRuleInvalid, err)
+		}
+	} else {
+		var doc struct {
+			DiscountRules
+			Rules []DiscountRule `json:"rules"` // 旧格式
+		}
+		if err := json.Unmarshal(raw, &doc); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCampaignRuleInvalid, err)
+		}
+		if doc.Version > DiscountRulesVersion {
+			return nil, fmt.Errorf("%w: 不支持的规则版本 %d", ErrCampaignRuleInvalid, doc.Version)
+		}
+		rules = doc.Tiers
+		if doc.Version == 0 {
+			rules = doc.Rules
+		}
+	}
+	return rules, nil
+}
+
+// DecodeDiscountRules 解析满减规则，返回按门槛金额升序排列的档位
+// 旧数据未经校验，排序后再按档位匹配
+func DecodeDiscountRules(raw json.RawMessage) ([]DiscountRule, error) {
+	rules, err := ParseDiscountRules(raw)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].MinAmount < rules[j].MinAmount
+	})
+	return rules, nil
+}
+
+// SetDiscountRules 设置满减活动的档位
+func (s *CampaignService) SetDiscountRules(ctx context.Context, campaignID int64, rules []DiscountRule) error {
+	campaign, err := s.getCampaign(ctx, campaignID)
+	if err != nil {
+		return err
+	}
+	if campaign.Type != models.CampaignTypeDiscount {
+		return fmt.Errorf("%w: 仅满减活动可设置满减档位", ErrCampaignRuleInvalid)
+	}
+
+	raw, err := EncodeDiscountRules(rules)
+	if err != nil {
+		return err
+	}
+	return s.campaignRepo.UpdateFields(ctx, campaignID, map[string]interface{}{"rules": raw})
+}
+
+// GetDiscountRules 获取满减活动的档位（按门槛金额升序）
+func (s *CampaignService) GetDiscountRules(ctx context.Context, campaignID int64) ([]DiscountRule, error) {
+	campaign, err := s.getCampaign(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Type != models.CampaignTypeDiscount {
+		return nil, fmt.Errorf("%w: 非满减活动", ErrCampaignRuleInvalid)
+	}
+	return DecodeDiscountRules(campaign.Rules)
+}
+
+// getCampaign 获取活动，不存在时返回 ErrCampaignNotFound
+func (s *CampaignService) getCampaign(ctx context.Context, campaignID int64) (*models.Campaign, error) {
+	campaign, err := s.campaignRepo.GetByID(ctx, campaignID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCampaignNotFound
+		}
+		return nil, err
+	}
+	return campaign, nil
+}
+
 // CalculateDiscountCampaign 计算满减活动优惠
+// 取订单金额满足的最高档位（门槛金额最大者）
 func (s *CampaignService) CalculateDiscountCampaign(ctx context.Context, orderAmount float64) (float64, *models.Campaign, error) {
 	campaign, err := s.campaignRepo.GetActiveByType(ctx, models.CampaignTypeDiscount)
 	if err != nil {
@@ -114,33 +258,21 @@ func (s *CampaignService) CalculateDiscountCampaign(ctx context.Context, orderAm
 		return 0, nil, nil
 	}
 
-	// 解析规则
-	var rules []DiscountRule
-	if campaign.Rules != nil {
-		// models.JSON 是 map 结构；兼容 {"rules":[...]} 或直接存数组（若未来 JSON 类型扩展）。
-		if rawRules, ok := campaign.Rules["rules"]; ok {
-			b, err := json.Marshal(rawRules)
-			if err != nil {
-				return 0, nil, err
-			}
-			if err := json.Unmarshal(b, &rules); err != nil {
-				return 0, nil, err
-			}
-		} else if err := campaign.Rules.Unmarshal(&rules); err != nil {
-			return 0, nil, err
-		}
+	rules, err := DecodeDiscountRules(campaign.Rules)
+	if err != nil {
+		return 0, nil, err
 	}
 
-	// 计算优惠金额（取最大满减档位）
-	var maxDiscount float64
+	var discount float64
 	for _, rule := range rules {
-		if orderAmount >= rule.MinAmount && rule.Discount > maxDiscount {
-			maxDiscount = rule.Discount
+		if orderAmount < rule.MinAmount {
+			break
 		}
+		discount = rule.Discount
 	}
 
-	if maxDiscount > 0 {
-		return maxDiscount, campaign, nil
+	if discount > 0 {
+		return discount, campaign, nil
 	}
 	return 0, nil, nil
 }
@@ -187,11 +319,7 @@ func (s *CampaignService) buildCampaignItem(c *models.Campaign, now time.Time) *
 		item.IsActive = true
 	}
 
-	// 处理规则（转换为 JSON）
-	if c.Rules != nil {
-		rulesBytes, _ := json.Marshal(c.Rules)
-		item.Rules = rulesBytes
-	}
+	item.Rules = c.Rules
 
 	return item
 }
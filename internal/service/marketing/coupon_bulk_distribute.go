@@ -0,0 +1,218 @@
+package marketing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// BulkDistributeBatchSize 批量发放每批处理的用户数
+const BulkDistributeBatchSize = 100
+
+// BulkDistributeError 批量发放中单个用户的失败原因
+type BulkDistributeError struct {
+	UserID int64
+	Err    error
+}
+
+// Error 实现 error 接口
+func (e *BulkDistributeError) Error() string {
+	return fmt.Sprintf("用户%d: %v", e.UserID, e.Err)
+}
+
+// Unwrap 返回原始错误
+func (e *BulkDistributeError) Unwrap() error {
+	return e.Err
+}
+
+// BulkDistribute 管理员向指定用户批量发放优惠券
+// 按批处理用户，每批在单个事务中校验每人领取上限、原子扣减库存并批量创建用户优惠券。
+// 库存不足时仅发放剩余数量，其余用户记为失败；返回成功数、失败数及失败明细。
+// 优惠券本身不可发放（不存在、未启用、不在活动时间内）时所有用户均失败，errors 仅包含该错误
+func (s *CouponService) BulkDistribute(ctx context.Context, couponID int64, userIDs []int64, adminID int64) (succeeded, failed int, errs []error) {
+	coupon, err := s.couponRepo.GetByID(ctx, couponID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrCouponNotFound
+		}
+		return 0, len(userIDs), []error{err}
+	}
+
+	now := time.Now()
+	if err := checkCouponReceivable(coupon, now); err != nil {
+		return 0, len(userIDs), []error{err}
+	}
+	expireAt := couponExpireAt(coupon, now)
+
+	// 同一用户在列表中多次出现时按多次领取计算
+	pending := make(map[int64]int)
+	soldOut := false
+	for start := 0; start < len(userIDs); start += BulkDistributeBatchSize {
+		end := start + BulkDistributeBatchSize
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+		batch := userIDs[start:end]
+
+		if soldOut {
+			for _, userID := range batch {
+				errs = append(errs, &BulkDistributeError{UserID: userID, Err: ErrCouponSoldOut})
+			}
+			failed += len(batch)
+			continue
+		}
+
+		created, batchErrs, err := s.distributeBatch(ctx, coupon, batch, pending, expireAt, adminID, nil)
+		if err != nil {
+			// 事务失败，本批全部记为失败
+			for _, userID := range batch {
+				errs = append(errs, &BulkDistributeError{UserID: userID, Err: err})
+			}
+			failed += len(batch)
+			continue
+		}
+
+		succeeded += created
+		failed += len(batchErrs)
+		for _, e := range batchErrs {
+			errs = append(errs, e)
+			if errors.Is(e, ErrCouponSoldOut) {
+				soldOut = true
+			}
+		}
+	}
+
+	return succeeded, failed, errs
+}
+
+// distributeBatch 在单个事务中向一批用户发放优惠券
+// pending 记录本次操作中已成功发放给各用户的数量，用于跨批次校验领取上限；
+// grantBatchID 非空时记录到用户优惠券上，标识所属的定向发放批次
+func (s *CouponService) distributeBatch(ctx context.Context, coupon *models.Coupon, userIDs []int64, pending map[int64]int, expireAt time.Time, adminID int64, grantBatchID *int64) (int, []*BulkDistributeError, error) {
+	var (
+		userCoupons []*models.UserCoupon
+		batchErrs   []*BulkDistributeError
+	)
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		userCoupons = nil
+		batchErrs = nil
+
+		// 查询本批用户已持有的数量
+		var rows []struct {
+			UserID int64
+			Count  int64
+		}
+		if err := tx.Model(&models.UserCoupon{}).
+			Select("user_id, COUNT(*) AS count").
+			Where("coupon_id = ? AND user_id IN ?", coupon.ID, uniqueUserIDs(userIDs)).
+			Group("user_id").
+			Scan(&rows).Error; err != nil {
+			return err
+		}
+		held := make(map[int64]int64, len(rows))
+		for _, row := range rows {
+			held[row.UserID] = row.Count
+		}
+
+		// 校验每人领取上限
+		batchCount := make(map[int64]int)
+		var eligible []int64
+		for _, userID := range userIDs {
+			owned := held[userID] + int64(pending[userID]+batchCount[userID])
+			if owned >= int64(coupon.PerUserLimit) {
+				batchErrs = append(batchErrs, &BulkDistributeError{UserID: userID, Err: ErrCouponLimitExceeded})
+				continue
+			}
+			batchCount[userID]++
+			eligible = append(eligible, userID)
+		}
+		if len(eligible) == 0 {
+			return nil
+		}
+
+		// 扣减库存，不足时只发放剩余数量
+		reserved, err := reserveCouponStock(tx, coupon.ID, len(eligible))
+		if err != nil {
+			return err
+		}
+		for _, userID := range eligible[reserved:] {
+			batchErrs = append(batchErrs, &BulkDistributeError{UserID: userID, Err: ErrCouponSoldOut})
+		}
+		eligible = eligible[:reserved]
+		if len(eligible) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		userCoupons = make([]*models.UserCoupon, 0, len(eligible))
+		for _, userID := range eligible {
+			userCoupons = append(userCoupons, &models.UserCoupon{
+				UserID:       userID,
+				CouponID:     coupon.ID,
+				Status:       models.UserCouponStatusUnused,
+				ExpiredAt:    expireAt,
+				ReceivedAt:   now,
+				IssuedBy:     &adminID,
+				GrantBatchID: grantBatchID,
+			})
+		}
+		return tx.CreateInBatches(userCoupons, BulkDistributeBatchSize).Error
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	for _, uc := range userCoupons {
+		pending[uc.UserID]++
+	}
+	return len(userCoupons), batchErrs, nil
+}
+
+// reserveCouponStock 原子增加优惠券已发放数量，返回实际预留的数量
+// 剩余库存不足 n 时预留全部剩余库存
+func reserveCouponStock(tx *gorm.DB, couponID int64, n int) (int, error) {
+	for n > 0 {
+		result := tx.Model(&models.Coupon{}).
+			Where("id = ? AND issued_count + ? <= total_count", couponID, n).
+			UpdateColumn("issued_count", gorm.Expr("issued_count + ?", n))
+		if result.Error != nil {
+			return 0, result.Error
+		}
+		if result.RowsAffected > 0 {
+			return n, nil
+		}
+
+		// 库存不足，按当前剩余数量重试
+		var coupon models.Coupon
+		if err := tx.Select("id", "total_count", "issued_count").First(&coupon, couponID).Error; err != nil {
+			return 0, err
+		}
+		remain := coupon.TotalCount - coupon.ReceivedCount
+		if remain <= 0 {
+			return 0, nil
+		}
+		if remain < n {
+			n = remain
+		}
+	}
+	return 0, nil
+}
+
+// uniqueUserIDs 去重用户ID
+func uniqueUserIDs(userIDs []int64) []int64 {
+	seen := make(map[int64]bool, len(userIDs))
+	result := make([]int64, 0, len(userIDs))
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
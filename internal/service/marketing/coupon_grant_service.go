@@ -0,0 +1,239 @@
+package marketing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// MaxGrantOrderWithinDays 分群条件“最近 N 天内有订单”允许的最大天数
+const MaxGrantOrderWithinDays = 3650
+
+// grantFinishedRentalStatuses 视为已完成的租借状态
+var grantFinishedRentalStatuses = []string{models.RentalStatusReturned, models.RentalStatusCompleted}
+
+// CouponGrantService 优惠券定向发放服务
+// 按用户分群批量发放优惠券，无需用户主动领取
+type CouponGrantService struct {
+	db            *gorm.DB
+	couponService *CouponService
+	batchRepo     *repository.CouponGrantBatchRepository
+}
+
+// NewCouponGrantService 创建优惠券定向发放服务
+func NewCouponGrantService(db *gorm.DB, couponService *CouponService, batchRepo *repository.CouponGrantBatchRepository) *CouponGrantService {
+	return &CouponGrantService{
+		db:            db,
+		couponService: couponService,
+		batchRepo:     batchRepo,
+	}
+}
+
+// GrantToSegment 向分群内的用户发放优惠券
+// 分批解析分群用户，每批在单个事务中校验每人领取上限并原子扣减库存；库存中途耗尽时发放至上限，
+// 剩余用户计入 shortfall_count 而不是整体失败。batchNo 为空时自动生成；
+// 重复提交已完成的批次号直接返回原批次结果，未完成的批次（如进程中断）继续向尚未发放的用户发放
+func (s *CouponGrantService) GrantToSegment(ctx context.Context, couponID int64, segment models.CouponGrantSegment, batchNo string, adminID int64) (*models.CouponGrantBatch, error) {
+	if err := validateGrantSegment(&segment); err != nil {
+		return nil, err
+	}
+
+	var batch *models.CouponGrantBatch
+	if batchNo != "" {
+		existing, err := s.batchRepo.GetByBatchNo(ctx, batchNo)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if existing != nil {
+			if existing.CouponID != couponID {
+				return nil, ErrGrantBatchConflict
+			}
+			if existing.Status == models.CouponGrantBatchStatusCompleted {
+				return existing, nil
+			}
+			batch = existing
+		}
+	} else {
+		batchNo = utils.GenerateOrderNo("CG")
+	}
+
+	coupon, err := s.couponService.couponRepo.GetByID(ctx, couponID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCouponNotFound
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if err := checkCouponReceivable(coupon, now); err != nil {
+		return nil, err
+	}
+
+	if batch == nil {
+		batch = &models.CouponGrantBatch{
+			BatchNo:  batchNo,
+			CouponID: couponID,
+			Segment:  segment,
+			AdminID:  adminID,
+			Status:   models.CouponGrantBatchStatusRunning,
+		}
+		if err := s.batchRepo.Create(ctx, batch); err != nil {
+			// 并发提交同一批次号时由先创建者负责发放
+			if existing, getErr := s.batchRepo.GetByBatchNo(ctx, batchNo); getErr == nil {
+				if existing.CouponID != couponID {
+					return nil, ErrGrantBatchConflict
+				}
+				return existing, nil
+			}
+			return nil, err
+		}
+	}
+
+	// 续跑时沿用批次创建时的分群条件，已发放的用户不再重复发放
+	segment = batch.Segment
+	previouslyGranted, err := s.countGranted(ctx, batch.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	expireAt := couponExpireAt(coupon, now)
+	var matched, skipped, shortfall, failed int
+	soldOut := false
+	var lastUserID int64
+	for {
+		var userIDs []int64
+		if err := s.segmentQuery(ctx, &segment, now, batch.ID).
+			Where("users.id > ?", lastUserID).
+			Order("users.id ASC").
+			Limit(BulkDistributeBatchSize).
+			Pluck("users.id", &userIDs).Error; err != nil {
+			return nil, err
+		}
+		if len(userIDs) == 0 {
+			break
+		}
+		lastUserID = userIDs[len(userIDs)-1]
+		matched += len(userIDs)
+
+		if soldOut {
+			shortfall += len(userIDs)
+			continue
+		}
+
+		_, batchErrs, err := s.couponService.distributeBatch(ctx, coupon, userIDs, make(map[int64]int), expireAt, adminID, &batch.ID)
+		if err != nil {
+			failed += len(userIDs)
+			continue
+		}
+		for _, e := range batchErrs {
+			switch {
+			case errors.Is(e, ErrCouponSoldOut):
+				shortfall++
+				soldOut = true
+			case errors.Is(e, ErrCouponLimitExceeded):
+				skipped++
+			default:
+				failed++
+			}
+		}
+	}
+
+	granted, err := s.countGranted(ctx, batch.ID)
+	if err != nil {
+		return nil, err
+	}
+	completedAt := time.Now()
+	fields := map[string]interface{}{
+		"status":          models.CouponGrantBatchStatusCompleted,
+		"matched_count":   previouslyGranted + matched,
+		"granted_count":   granted,
+		"skipped_count":   skipped,
+		"shortfall_count": shortfall,
+		"failed_count":    failed,
+		"completed_at":    completedAt,
+	}
+	if err := s.batchRepo.UpdateFields(ctx, batch.ID, fields); err != nil {
+		return nil, err
+	}
+
+	batch.Status = models.CouponGrantBatchStatusCompleted
+	batch.MatchedCount = previouslyGranted + matched
+	batch.GrantedCount = granted
+	batch.SkippedCount = skipped
+	batch.ShortfallCount = shortfall
+	batch.FailedCount = failed
+	batch.CompletedAt = &completedAt
+	return batch, nil
+}
+
+// ListGrants 分页获取优惠券的定向发放批次
+func (s *CouponGrantService) ListGrants(ctx context.Context, couponID int64, page, pageSize int) ([]*models.CouponGrantBatch, int64, error) {
+	if _, err := s.couponService.couponRepo.GetByID(ctx, couponID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, ErrCouponNotFound
+		}
+		return nil, 0, err
+	}
+	return s.batchRepo.ListByCoupon(ctx, couponID, (page-1)*pageSize, pageSize)
+}
+
+// segmentQuery 构建分群用户查询，排除已在该批次中获得优惠券的用户
+func (s *CouponGrantService) segmentQuery(ctx context.Context, segment *models.CouponGrantSegment, now time.Time, batchID int64) *gorm.DB {
+	db := s.db.WithContext(ctx)
+	query := db.Model(&models.User{}).
+		Where("users.status = ?", models.UserStatusActive).
+		Where("users.id NOT IN (?)", db.Model(&models.UserCoupon{}).Select("user_id").Where("grant_batch_id = ?", batchID))
+
+	if segment.RegisteredAfter != nil {
+		query = query.Where("users.created_at > ?", *segment.RegisteredAfter)
+	}
+	if segment.LastOrderWithinDays != nil {
+		cutoff := now.AddDate(0, 0, -*segment.LastOrderWithinDays)
+		query = query.Where("users.id IN (?)", db.Model(&models.Order{}).Select("user_id").Where("paid_at >= ?", cutoff))
+	}
+	if len(segment.MemberLevelIDs) > 0 {
+		query = query.Where("users.member_level_id IN ?", segment.MemberLevelIDs)
+	}
+	if segment.HasCompletedRental != nil {
+		rentals := db.Model(&models.Rental{}).Select("user_id").Where("status IN ?", grantFinishedRentalStatuses)
+		if *segment.HasCompletedRental {
+			query = query.Where("users.id IN (?)", rentals)
+		} else {
+			query = query.Where("users.id NOT IN (?)", rentals)
+		}
+	}
+	return query
+}
+
+// countGranted 统计批次已发放的用户优惠券数量
+func (s *CouponGrantService) countGranted(ctx context.Context, batchID int64) (int, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.UserCoupon{}).
+		Where("grant_batch_id = ?", batchID).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// validateGrantSegment 校验分群条件
+func validateGrantSegment(segment *models.CouponGrantSegment) error {
+	if segment.IsEmpty() {
+		return ErrGrantSegmentEmpty
+	}
+	if days := segment.LastOrderWithinDays; days != nil && (*days <= 0 || *days > MaxGrantOrderWithinDays) {
+		return ErrGrantSegmentInvalid
+	}
+	for _, id := range segment.MemberLevelIDs {
+		if id <= 0 {
+			return ErrGrantSegmentInvalid
+		}
+	}
+	return nil
+}
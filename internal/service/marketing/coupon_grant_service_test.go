@@ -0,0 +1,234 @@
+package marketing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+func setupCouponGrantService(t *testing.T) (*CouponGrantService, *gorm.DB) {
+	t.Helper()
+
+	db := setupMarketingTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Order{}, &models.Rental{}, &models.CouponGrantBatch{}))
+	return NewCouponGrantService(db, setupCouponService(db), repository.NewCouponGrantBatchRepository(db)), db
+}
+
+// createGrantTestOrder 创建已支付订单，finishedRental 为真时同时创建已完成的租借
+func createGrantTestOrder(t *testing.T, db *gorm.DB, userID int64, paidAt time.Time, finishedRental bool) {
+	t.Helper()
+
+	order := &models.Order{
+		OrderNo:        fmt.Sprintf("GT%d%d", userID, paidAt.UnixNano()),
+		UserID:         userID,
+		Type:           models.OrderTypeRental,
+		OriginalAmount: 10,
+		ActualAmount:   10,
+		Status:         models.OrderStatusCompleted,
+		PaidAt:         &paidAt,
+	}
+	require.NoError(t, db.Create(order).Error)
+	if !finishedRental {
+		return
+	}
+	require.NoError(t, db.Create(&models.Rental{
+		OrderID:       order.ID,
+		UserID:        userID,
+		DeviceID:      1,
+		DurationHours: 1,
+		RentalFee:     10,
+		Status:        models.RentalStatusCompleted,
+	}).Error)
+}
+
+func grantedUserIDs(t *testing.T, db *gorm.DB, batchID int64) []int64 {
+	t.Helper()
+
+	var ids []int64
+	require.NoError(t, db.Model(&models.UserCoupon{}).Where("grant_batch_id = ?", batchID).Order("user_id").Pluck("user_id", &ids).Error)
+	return ids
+}
+
+func TestCouponGrantService_GrantToSegment_SegmentResolution(t *testing.T) {
+	svc, db := setupCouponGrantService(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	recent := createMarketingTestUser(t, db, "13800000001")   // 近期完成租借
+	stale := createMarketingTestUser(t, db, "13800000002")    // 完成租借但订单已久
+	noRental := createMarketingTestUser(t, db, "13800000003") // 近期有商城订单，无租借
+	disabled := createMarketingTestUser(t, db, "13800000004") // 近期完成租借但账号已禁用
+	require.NoError(t, db.Model(disabled).Update("status", models.UserStatusDisabled).Error)
+	require.NoError(t, db.Model(noRental).Update("member_level_id", 2).Error)
+	require.NoError(t, db.Model(stale).Update("created_at", now.AddDate(-1, 0, 0)).Error)
+
+	createGrantTestOrder(t, db, recent.ID, now.AddDate(0, 0, -5), true)
+	createGrantTestOrder(t, db, stale.ID, now.AddDate(0, 0, -40), true)
+	createGrantTestOrder(t, db, noRental.ID, now.AddDate(0, 0, -1), false)
+	createGrantTestOrder(t, db, disabled.ID, now.AddDate(0, 0, -1), true)
+
+	days := 30
+	yes, no := true, false
+	registeredAfter := now.AddDate(0, -1, 0)
+	tests := []struct {
+		name    string
+		segment models.CouponGrantSegment
+		want    []int64
+	}{
+		{"近期完成租借", models.CouponGrantSegment{LastOrderWithinDays: &days, HasCompletedRental: &yes}, []int64{recent.ID}},
+		{"完成过租借", models.CouponGrantSegment{HasCompletedRental: &yes}, []int64{recent.ID, stale.ID}},
+		{"从未完成租借", models.CouponGrantSegment{HasCompletedRental: &no}, []int64{noRental.ID}},
+		{"会员等级", models.CouponGrantSegment{MemberLevelIDs: []int64{2}}, []int64{noRental.ID}},
+		{"注册时间", models.CouponGrantSegment{RegisteredAfter: &registeredAfter}, []int64{recent.ID, noRental.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coupon := createMarketingTestCoupon(t, db)
+			batch, err := svc.GrantToSegment(ctx, coupon.ID, tt.segment, "", 9)
+			require.NoError(t, err)
+			assert.Equal(t, models.CouponGrantBatchStatusCompleted, batch.Status)
+			assert.Equal(t, len(tt.want), batch.MatchedCount)
+			assert.Equal(t, len(tt.want), batch.GrantedCount)
+			assert.Equal(t, tt.want, grantedUserIDs(t, db, batch.ID))
+		})
+	}
+}
+
+func TestCouponGrantService_GrantToSegment_InvalidSegment(t *testing.T) {
+	svc, db := setupCouponGrantService(t)
+	ctx := context.Background()
+	coupon := createMarketingTestCoupon(t, db)
+
+	_, err := svc.GrantToSegment(ctx, coupon.ID, models.CouponGrantSegment{}, "", 9)
+	assert.ErrorIs(t, err, ErrGrantSegmentEmpty)
+
+	days := 0
+	_, err = svc.GrantToSegment(ctx, coupon.ID, models.CouponGrantSegment{LastOrderWithinDays: &days}, "", 9)
+	assert.ErrorIs(t, err, ErrGrantSegmentInvalid)
+
+	yes := true
+	_, err = svc.GrantToSegment(ctx, 999, models.CouponGrantSegment{HasCompletedRental: &yes}, "", 9)
+	assert.ErrorIs(t, err, ErrCouponNotFound)
+}
+
+func TestCouponGrantService_GrantToSegment_PartialGrantOnExhaustion(t *testing.T) {
+	svc, db := setupCouponGrantService(t)
+	ctx := context.Background()
+
+	coupon := createMarketingTestCoupon(t, db, func(c *models.Coupon) {
+		c.TotalCount = 3
+		c.PerUserLimit = 1
+	})
+
+	var users []*models.User
+	for i := 0; i < 5; i++ {
+		users = append(users, createMarketingTestUser(t, db, fmt.Sprintf("1390000000%d", i)))
+	}
+	// 第一个用户已领取过，达到每人上限
+	createMarketingTestUserCoupon(t, db, users[0].ID, coupon.ID, models.UserCouponStatusUnused)
+	require.NoError(t, db.Model(coupon).UpdateColumn("issued_count", 1).Error)
+
+	batch, err := svc.GrantToSegment(ctx, coupon.ID, models.CouponGrantSegment{MemberLevelIDs: []int64{1}}, "", 9)
+	require.NoError(t, err)
+	assert.Equal(t, 5, batch.MatchedCount)
+	assert.Equal(t, 2, batch.GrantedCount)
+	assert.Equal(t, 1, batch.SkippedCount)
+	assert.Equal(t, 2, batch.ShortfallCount)
+	assert.Equal(t, 0, batch.FailedCount)
+	assert.Equal(t, []int64{users[1].ID, users[2].ID}, grantedUserIDs(t, db, batch.ID))
+
+	var updated models.Coupon
+	require.NoError(t, db.First(&updated, coupon.ID).Error)
+	assert.Equal(t, 3, updated.ReceivedCount)
+
+	// 批次结果已持久化
+	stored, err := svc.batchRepo.GetByBatchNo(ctx, batch.BatchNo)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.ShortfallCount)
+	assert.NotNil(t, stored.CompletedAt)
+}
+
+func TestCouponGrantService_GrantToSegment_IdempotentBatchNo(t *testing.T) {
+	svc, db := setupCouponGrantService(t)
+	ctx := context.Background()
+
+	coupon := createMarketingTestCoupon(t, db, func(c *models.Coupon) { c.PerUserLimit = 5 })
+	for i := 0; i < 3; i++ {
+		createMarketingTestUser(t, db, fmt.Sprintf("1370000000%d", i))
+	}
+	segment := models.CouponGrantSegment{MemberLevelIDs: []int64{1}}
+
+	first, err := svc.GrantToSegment(ctx, coupon.ID, segment, "GRANT-001", 9)
+	require.NoError(t, err)
+	assert.Equal(t, 3, first.GrantedCount)
+
+	second, err := svc.GrantToSegment(ctx, coupon.ID, segment, "GRANT-001", 9)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, second.ID)
+	assert.Equal(t, 3, second.GrantedCount)
+
+	var count int64
+	require.NoError(t, db.Model(&models.UserCoupon{}).Where("coupon_id = ?", coupon.ID).Count(&count).Error)
+	assert.Equal(t, int64(3), count)
+
+	// 同一批次号不能用于其他优惠券
+	other := createMarketingTestCoupon(t, db)
+	_, err = svc.GrantToSegment(ctx, other.ID, segment, "GRANT-001", 9)
+	assert.ErrorIs(t, err, ErrGrantBatchConflict)
+}
+
+func TestCouponGrantService_GrantToSegment_ResumeRunningBatch(t *testing.T) {
+	svc, db := setupCouponGrantService(t)
+	ctx := context.Background()
+
+	coupon := createMarketingTestCoupon(t, db, func(c *models.Coupon) { c.PerUserLimit = 5 })
+	var users []*models.User
+	for i := 0; i < 3; i++ {
+		users = append(users, createMarketingTestUser(t, db, fmt.Sprintf("1360000000%d", i)))
+	}
+
+	// 模拟发放中断：批次仍为发放中，第一个用户已发放
+	segment := models.CouponGrantSegment{MemberLevelIDs: []int64{1}}
+	batch := &models.CouponGrantBatch{BatchNo: "GRANT-002", CouponID: coupon.ID, Segment: segment, AdminID: 9, Status: models.CouponGrantBatchStatusRunning}
+	require.NoError(t, db.Create(batch).Error)
+	uc := createMarketingTestUserCoupon(t, db, users[0].ID, coupon.ID, models.UserCouponStatusUnused)
+	require.NoError(t, db.Model(uc).Update("grant_batch_id", batch.ID).Error)
+
+	result, err := svc.GrantToSegment(ctx, coupon.ID, segment, "GRANT-002", 9)
+	require.NoError(t, err)
+	assert.Equal(t, batch.ID, result.ID)
+	assert.Equal(t, models.CouponGrantBatchStatusCompleted, result.Status)
+	assert.Equal(t, 3, result.MatchedCount)
+	assert.Equal(t, 3, result.GrantedCount)
+	assert.Equal(t, []int64{users[0].ID, users[1].ID, users[2].ID}, grantedUserIDs(t, db, batch.ID))
+}
+
+func TestCouponGrantService_ListGrants(t *testing.T) {
+	svc, db := setupCouponGrantService(t)
+	ctx := context.Background()
+
+	coupon := createMarketingTestCoupon(t, db)
+	createMarketingTestUser(t, db, "13500000000")
+	segment := models.CouponGrantSegment{MemberLevelIDs: []int64{1}}
+	for i := 0; i < 3; i++ {
+		_, err := svc.GrantToSegment(ctx, coupon.ID, segment, fmt.Sprintf("L%d", i), 9)
+		require.NoError(t, err)
+	}
+
+	list, total, err := svc.ListGrants(ctx, coupon.ID, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, list, 2)
+	assert.Equal(t, "L2", list[0].BatchNo)
+
+	_, _, err = svc.ListGrants(ctx, 999, 1, 10)
+	assert.ErrorIs(t, err, ErrCouponNotFound)
+}
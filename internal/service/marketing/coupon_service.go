@@ -4,6 +4,7 @@ package marketing
 import (
 	"context"
 	"errors"
+	"math"
 	"time"
 
 	"gorm.io/gorm"
@@ -42,23 +43,24 @@ type CouponListResponse struct {
 
 // CouponItem 优惠券项
 type CouponItem struct {
-	ID              int64      `json:"id"`
-	Name            string     `json:"name"`
-	Type            string     `json:"type"`
-	Value           float64    `json:"value"`
-	MinAmount       float64    `json:"min_amount"`
-	MaxDiscount     *float64   `json:"max_discount,omitempty"`
-	ApplicableScope string     `json:"applicable_scope"`
-	StartTime       time.Time  `json:"start_time"`
-	EndTime         time.Time  `json:"end_time"`
-	TotalCount      int        `json:"total_count"`
-	ReceivedCount   int        `json:"received_count"`
-	RemainCount     int        `json:"remain_count"`
-	PerUserLimit    int        `json:"per_user_limit"`
-	Description     *string    `json:"description,omitempty"`
-	Status          int8       `json:"status"`
-	CanReceive      bool       `json:"can_receive"`
-	ReceivedByUser  int64      `json:"received_by_user,omitempty"` // 当前用户已领取数量
+	ID              int64                         `json:"id"`
+	Name            string                        `json:"name"`
+	Type            string                        `json:"type"`
+	Value           float64                       `json:"value"`
+	MinAmount       float64                       `json:"min_amount"`
+	MaxDiscount     *float64                      `json:"max_discount,omitempty"`
+	ApplicableScope string                        `json:"applicable_scope"`
+	ApplicableItems *models.CouponApplicableItems `json:"applicable_items,omitempty"`
+	StartTime       time.Time                     `json:"start_time"`
+	EndTime         time.Time                     `json:"end_time"`
+	TotalCount      int                           `json:"total_count"`
+	ReceivedCount   int                           `json:"received_count"`
+	RemainCount     int                           `json:"remain_count"`
+	PerUserLimit    int                           `json:"per_user_limit"`
+	Description     *string                       `json:"description,omitempty"`
+	Status          int8                          `json:"status"`
+	CanReceive      bool                          `json:"can_receive"`
+	ReceivedByUser  int64                         `json:"received_by_user,omitempty"` // 当前用户已领取数量
 }
 
 // GetCouponList 获取可领取的优惠券列表（用户端）
@@ -84,6 +86,7 @@ func (s *CouponService) GetCouponList(ctx context.Context, req *CouponListReques
 			MinAmount:       c.MinAmount,
 			MaxDiscount:     c.MaxDiscount,
 			ApplicableScope: c.ApplicableScope,
+			ApplicableItems: c.ApplicableItems,
 			StartTime:       c.StartTime,
 			EndTime:         c.EndTime,
 			TotalCount:      c.TotalCount,
@@ -130,6 +133,7 @@ func (s *CouponService) GetCouponDetail(ctx context.Context, couponID int64, use
 		MinAmount:       coupon.MinAmount,
 		MaxDiscount:     coupon.MaxDiscount,
 		ApplicableScope: coupon.ApplicableScope,
+		ApplicableItems: coupon.ApplicableItems,
 		StartTime:       coupon.StartTime,
 		EndTime:         coupon.EndTime,
 		TotalCount:      coupon.TotalCount,
@@ -156,14 +160,8 @@ func (s *CouponService) ReceiveCoupon(ctx context.Context, couponID, userID int6
 
 		// 检查优惠券状态
 		now := time.Now()
-		if coupon.Status != models.CouponStatusActive {
-			return ErrCouponNotActive
-		}
-		if now.Before(coupon.StartTime) {
-			return ErrCouponNotStarted
-		}
-		if now.After(coupon.EndTime) {
-			return ErrCouponExpired
+		if err := checkCouponReceivable(&coupon, now); err != nil {
+			return err
 		}
 		if coupon.ReceivedCount >= coupon.TotalCount {
 			return ErrCouponSoldOut
@@ -180,24 +178,12 @@ func (s *CouponService) ReceiveCoupon(ctx context.Context, couponID, userID int6
 			return ErrCouponLimitExceeded
 		}
 
-		// 计算过期时间
-		var expireAt time.Time
-		if coupon.ValidDays != nil && *coupon.ValidDays > 0 {
-			expireAt = now.AddDate(0, 0, *coupon.ValidDays)
-			// 过期时间不能超过优惠券本身的结束时间
-			if expireAt.After(coupon.EndTime) {
-				expireAt = coupon.EndTime
-			}
-		} else {
-			expireAt = coupon.EndTime
-		}
-
 		// 创建用户优惠券
 		userCoupon = &models.UserCoupon{
 			UserID:     userID,
 			CouponID:   couponID,
 			Status:     models.UserCouponStatusUnused,
-			ExpiredAt:  expireAt,
+			ExpiredAt:  couponExpireAt(&coupon, now),
 			ReceivedAt: now,
 		}
 		if err := tx.Create(userCoupon).Error; err != nil {
@@ -225,9 +211,69 @@ func (s *CouponService) ReceiveCoupon(ctx context.Context, couponID, userID int6
 	return userCoupon, nil
 }
 
+// checkCouponReceivable 检查优惠券当前是否可领取（启用且在活动时间内）
+func checkCouponReceivable(coupon *models.Coupon, now time.Time) error {
+	if coupon.Status != models.CouponStatusActive {
+		return ErrCouponNotActive
+	}
+	if now.Before(coupon.StartTime) {
+		return ErrCouponNotStarted
+	}
+	if now.After(coupon.EndTime) {
+		return ErrCouponExpired
+	}
+	return nil
+}
+
+// couponExpireAt 计算用户优惠券的过期时间，不超过优惠券本身的结束时间
+func couponExpireAt(coupon *models.Coupon, now time.Time) time.Time {
+	if coupon.ValidDays != nil && *coupon.ValidDays > 0 {
+		expireAt := now.AddDate(0, 0, *coupon.ValidDays)
+		if expireAt.After(coupon.EndTime) {
+			return coupon.EndTime
+		}
+		return expireAt
+	}
+	return coupon.EndTime
+}
+
+// OrderLineItem 订单商品行，用于匹配限定商品/分类的优惠券
+type OrderLineItem struct {
+	ProductID  int64   `json:"product_id"`
+	CategoryID int64   `json:"category_id"`
+	Subtotal   float64 `json:"subtotal"` // 商品行小计
+}
+
+// eligibleAmount 计算优惠券在订单中的可优惠金额
+// 未限定商品的优惠券按订单金额计算；限定商品/分类的优惠券只计入命中商品行的小计，没有命中的商品时返回 false。
+// 订单金额已扣除会员折扣、满减等优惠（小于商品小计合计）时，按命中商品在合计中的占比折算
+func eligibleAmount(coupon *models.Coupon, orderAmount float64, items []*OrderLineItem) (float64, bool) {
+	if coupon.ApplicableItems.IsEmpty() {
+		return orderAmount, true
+	}
+
+	var total, matched float64
+	for _, item := range items {
+		total += item.Subtotal
+		if coupon.ApplicableItems.Matches(item.ProductID, item.CategoryID) {
+			matched += item.Subtotal
+		}
+	}
+	if matched <= 0 {
+		return 0, false
+	}
+
+	if orderAmount < total {
+		matched = math.Round(orderAmount*matched/total*100) / 100
+	}
+	return matched, true
+}
+
 // CalculateDiscount 计算优惠金额
-func (s *CouponService) CalculateDiscount(coupon *models.Coupon, orderAmount float64) float64 {
-	if orderAmount < coupon.MinAmount {
+// items 为订单商品组成，限定商品/分类的优惠券按命中商品的小计计算门槛、折扣和最大优惠
+func (s *CouponService) CalculateDiscount(coupon *models.Coupon, orderAmount float64, items []*OrderLineItem) float64 {
+	amount, ok := eligibleAmount(coupon, orderAmount, items)
+	if !ok || amount < coupon.MinAmount {
 		return 0
 	}
 
@@ -238,7 +284,7 @@ func (s *CouponService) CalculateDiscount(coupon *models.Coupon, orderAmount flo
 		discount = coupon.Value
 	case models.CouponTypePercent:
 		// 百分比折扣，value 是折扣比例（如 0.1 表示打9折，优惠10%）
-		discount = orderAmount * coupon.Value
+		discount = amount * coupon.Value
 	default:
 		return 0
 	}
@@ -248,16 +294,25 @@ func (s *CouponService) CalculateDiscount(coupon *models.Coupon, orderAmount flo
 		discount = *coupon.MaxDiscount
 	}
 
-	// 优惠金额不能超过订单金额
-	if discount > orderAmount {
-		discount = orderAmount
+	// 优惠金额不能超过可优惠金额
+	if discount > amount {
+		discount = amount
 	}
 
 	return discount
 }
 
+// isCouponApplicable 判断优惠券是否适用于指定订单范围
+// 全场通用券适用于所有订单，其他券仅适用于与其适用范围完全一致的订单
+func isCouponApplicable(coupon *models.Coupon, orderScope string) bool {
+	if coupon == nil {
+		return false
+	}
+	return coupon.ApplicableScope == models.CouponScopeAll || coupon.ApplicableScope == orderScope
+}
+
 // GetBestCouponForOrder 获取订单最优优惠券
-func (s *CouponService) GetBestCouponForOrder(ctx context.Context, userID int64, orderType string, orderAmount float64) (*models.UserCoupon, float64, error) {
+func (s *CouponService) GetBestCouponForOrder(ctx context.Context, userID int64, orderType string, orderAmount float64, items []*OrderLineItem) (*models.UserCoupon, float64, error) {
 	userCoupons, err := s.userCouponRepo.ListAvailableForOrder(ctx, userID, orderType, orderAmount)
 	if err != nil {
 		return nil, 0, err
@@ -271,10 +326,10 @@ func (s *CouponService) GetBestCouponForOrder(ctx context.Context, userID int64,
 	var maxDiscount float64
 
 	for _, uc := range userCoupons {
-		if uc.Coupon == nil {
+		if !isCouponApplicable(uc.Coupon, orderType) {
 			continue
 		}
-		discount := s.CalculateDiscount(uc.Coupon, orderAmount)
+		discount := s.CalculateDiscount(uc.Coupon, orderAmount, items)
 		if discount > maxDiscount {
 			maxDiscount = discount
 			bestCoupon = uc
@@ -286,7 +341,7 @@ func (s *CouponService) GetBestCouponForOrder(ctx context.Context, userID int64,
 
 // GetUserCouponForOrder 获取指定的用户优惠券并计算其对订单的优惠金额。
 // 若优惠券不可用/不匹配当前订单，则返回 (nil, 0, nil)。
-func (s *CouponService) GetUserCouponForOrder(ctx context.Context, userID int64, userCouponID int64, orderType string, orderAmount float64) (*models.UserCoupon, float64, error) {
+func (s *CouponService) GetUserCouponForOrder(ctx context.Context, userID int64, userCouponID int64, orderType string, orderAmount float64, items []*OrderLineItem) (*models.UserCoupon, float64, error) {
 	userCoupon, err := s.userCouponRepo.GetByIDWithCoupon(ctx, userCouponID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -321,11 +376,11 @@ func (s *CouponService) GetUserCouponForOrder(ctx context.Context, userID int64,
 	if orderAmount < coupon.MinAmount {
 		return nil, 0, nil
 	}
-	if coupon.ApplicableScope != models.CouponScopeAll && coupon.ApplicableScope != orderType {
+	if !isCouponApplicable(coupon, orderType) {
 		return nil, 0, nil
 	}
 
-	discount := s.CalculateDiscount(coupon, orderAmount)
+	discount := s.CalculateDiscount(coupon, orderAmount, items)
 	if discount <= 0 {
 		return nil, 0, nil
 	}
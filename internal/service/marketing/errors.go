@@ -16,15 +16,41 @@ var (
 	ErrCouponAlreadyUsed   = errors.New("优惠券已使用")
 	ErrCouponAmountNotMet  = errors.New("未达到使用门槛")
 
+	// 定向发放相关错误
+	ErrGrantSegmentEmpty   = errors.New("分群条件不能为空")
+	ErrGrantSegmentInvalid = errors.New("分群条件无效")
+	ErrGrantBatchConflict  = errors.New("批次号已用于其他优惠券")
+
 	// 用户优惠券相关错误
 	ErrUserCouponNotFound = errors.New("用户优惠券不存在")
 	ErrUserCouponExpired  = errors.New("用户优惠券已过期")
 	ErrUserCouponUsed     = errors.New("用户优惠券已使用")
 
+	// 优惠券转赠相关错误
+	ErrCouponTransferSelf       = errors.New("不能将优惠券转赠给自己")
+	ErrCouponTransferUserAbsent = errors.New("受赠用户不存在")
+
 	// 活动相关错误
 	ErrCampaignNotFound    = errors.New("活动不存在")
 	ErrCampaignNotActive   = errors.New("活动未启用")
 	ErrCampaignNotStarted  = errors.New("活动未开始")
 	ErrCampaignExpired     = errors.New("活动已结束")
 	ErrCampaignRuleInvalid = errors.New("活动规则无效")
+
+	// 满赠相关错误
+	ErrCampaignNotGift       = errors.New("非满赠活动")
+	ErrGiftProductNotFound   = errors.New("赠品不存在")
+	ErrGiftStockInsufficient = errors.New("赠品库存不足")
+	ErrGiftOrderExists       = errors.New("订单已有赠品")
+
+	// 秒杀相关错误
+	ErrCampaignNotFlashSale = errors.New("非秒杀活动")
+	ErrFlashSaleSoldOut     = errors.New("今日秒杀名额已抢完")
+	ErrFlashSaleUserLimit   = errors.New("已达到今日秒杀限购次数")
+
+	// 秒杀商品库存相关错误
+	ErrFlashSaleProductNotFound = errors.New("商品未参加该秒杀活动")
+	ErrFlashSaleQuantityInvalid = errors.New("秒杀购买数量必须大于0")
+	ErrFlashSaleStockSoldOut    = errors.New("秒杀库存不足")
+	ErrFlashSaleLimitExceeded   = errors.New("已达到秒杀每人限购数量")
 )
@@ -0,0 +1,264 @@
+package marketing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// 秒杀名额领取结果
+const (
+	flashSaleClaimed   = 0
+	flashSaleSoldOut   = 1
+	flashSaleUserLimit = 2
+)
+
+// claimFlashSaleScript 在每日名额和个人限购次数内领取秒杀名额
+// KEYS[1] 活动当日计数键，KEYS[2] 用户当日计数键；ARGV[1] 每日名额，ARGV[2] 个人限购次数（0 表示不限），ARGV[3] 计数过期时间(毫秒时间戳)，ARGV[4] 领取件数
+// 返回 0 领取成功，1 当日名额已抢完，2 已达个人限购次数
+var claimFlashSaleScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local userLimit = tonumber(ARGV[2])
+local quantity = tonumber(ARGV[4])
+if limit > 0 and tonumber(redis.call("GET", KEYS[1]) or "0") + quantity > limit then
+	return 1
+end
+if userLimit > 0 and tonumber(redis.call("GET", KEYS[2]) or "0") >= userLimit then
+	return 2
+end
+redis.call("INCRBY", KEYS[1], quantity)
+redis.call("PEXPIREAT", KEYS[1], ARGV[3])
+if userLimit > 0 then
+	redis.call("INCR", KEYS[2])
+	redis.call("PEXPIREAT", KEYS[2], ARGV[3])
+end
+return 0
+`)
+
+// releaseFlashSaleScript 归还已领取的秒杀名额，计数键已过期（次日）时不处理，避免生成不过期的负数计数
+// KEYS[1] 活动当日计数键，KEYS[2] 用户当日计数键；ARGV[1] 归还件数
+var releaseFlashSaleScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	redis.call("DECRBY", KEYS[1], ARGV[1])
+end
+if redis.call("EXISTS", KEYS[2]) == 1 then
+	redis.call("DECR", KEYS[2])
+end
+return 0
+`)
+
+// FlashSaleService 秒杀服务
+// 秒杀活动按自然日限量，当日已领取名额由当日创建且未释放的秒杀库存预占统计，次日自动重置；
+// 设置 Redis 后另以当日计数键 flash:campaign:{id}:{date} 预先拦截超出名额的请求，计数键在当天结束时过期，
+// 计数只作快速预检，名额以数据库中的预占为准
+type FlashSaleService struct {
+	campaignRepo        *repository.CampaignRepository
+	campaignProductRepo *repository.CampaignProductRepository
+	redis               redis.Cmdable
+	now                 func() time.Time
+}
+
+// NewFlashSaleService 创建秒杀服务
+func NewFlashSaleService(campaignRepo *repository.CampaignRepository, campaignProductRepo *repository.CampaignProductRepository) *FlashSaleService {
+	return &FlashSaleService{
+		campaignRepo:        campaignRepo,
+		campaignProductRepo: campaignProductRepo,
+		now:                 time.Now,
+	}
+}
+
+// SetRedis 设置 Redis 客户端，启用秒杀名额快速预检
+func (s *FlashSaleService) SetRedis(client redis.Cmdable) {
+	s.redis = client
+}
+
+// FlashSaleStatus 秒杀活动当日名额状态
+type FlashSaleStatus struct {
+	CampaignID     int64  `json:"campaign_id"`
+	Date           string `json:"date"`
+	DailyLimit     int    `json:"daily_limit"`      // 每日名额，0 表示不限
+	Claimed        int64  `json:"claimed"`          // 当日已领取名额
+	Remaining      int64  `json:"remaining"`        // 当日剩余名额，不限量时为 -1
+	UserDailyLimit int    `json:"user_daily_limit"` // 每人每日限购次数，0 表示不限
+	IsActive       bool   `json:"is_active"`        // 活动是否进行中
+}
+
+// ClaimFlashSaleSlot 领取秒杀活动的当日名额
+// 当日名额已抢完时返回 ErrFlashSaleSoldOut，超过个人每日限购次数时返回 ErrFlashSaleUserLimit；未设置 Redis 时不做预检
+func (s *FlashSaleService) ClaimFlashSaleSlot(ctx context.Context, campaignID, userID int64) error {
+	now := s.now()
+	campaign, err := s.getFlashSaleCampaign(ctx, campaignID)
+	if err != nil {
+		return err
+	}
+	if err := checkCampaignActive(campaign, now); err != nil {
+		return err
+	}
+	_, err = s.claimSlots(ctx, campaign, userID, 1, now)
+	return err
+}
+
+// ReleaseFlashSaleSlot 归还 ClaimFlashSaleSlot 领取的名额，用于领取后下单失败时回滚
+func (s *FlashSaleService) ReleaseFlashSaleSlot(ctx context.Context, campaignID, userID int64, quantity int) error {
+	return s.releaseSlots(ctx, campaignID, userID, quantity, s.now())
+}
+
+// claimSlots 在 Redis 当日计数中领取名额，返回是否已计入计数（未设置 Redis 或活动不限量时不计入）
+func (s *FlashSaleService) claimSlots(ctx context.Context, campaign *models.Campaign, userID int64, quantity int, now time.Time) (bool, error) {
+	if s.redis == nil || (campaign.DailyLimit <= 0 && campaign.UserDailyLimit <= 0) {
+		return false, nil
+	}
+
+	date := flashSaleDate(now)
+	result, err := claimFlashSaleScript.Run(ctx, s.redis,
+		[]string{flashSaleKey(campaign.ID, date), flashSaleUserKey(campaign.ID, date, userID)},
+		campaign.DailyLimit, campaign.UserDailyLimit, endOfDay(now).UnixMilli(), quantity,
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("领取秒杀名额失败: %w", err)
+	}
+
+	switch result {
+	case flashSaleClaimed:
+		return true, nil
+	case flashSaleSoldOut:
+		return false, ErrFlashSaleSoldOut
+	case flashSaleUserLimit:
+		return false, ErrFlashSaleUserLimit
+	default:
+		return false, fmt.Errorf("领取秒杀名额失败: 未知结果 %d", result)
+	}
+}
+
+// releaseSlots 归还 claimedAt 当日领取的名额
+func (s *FlashSaleService) releaseSlots(ctx context.Context, campaignID, userID int64, quantity int, claimedAt time.Time) error {
+	if s.redis == nil {
+		return nil
+	}
+	date := flashSaleDate(claimedAt)
+	err := releaseFlashSaleScript.Run(ctx, s.redis,
+		[]string{flashSaleKey(campaignID, date), flashSaleUserKey(campaignID, date, userID)},
+		quantity,
+	).Err()
+	if err != nil {
+		return fmt.Errorf("归还秒杀名额失败: %w", err)
+	}
+	return nil
+}
+
+// GetFlashSaleStatus 获取秒杀活动当日剩余名额
+func (s *FlashSaleService) GetFlashSaleStatus(ctx context.Context, campaignID int64) (*FlashSaleStatus, error) {
+	now := s.now()
+	campaign, err := s.getFlashSaleCampaign(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	claimed, err := s.campaignProductRepo.SumCampaignReservedQuantity(ctx, campaignID, startOfDay(now), endOfDay(now))
+	if err != nil {
+		return nil, fmt.Errorf("查询秒杀名额失败: %w", err)
+	}
+
+	status := &FlashSaleStatus{
+		CampaignID:     campaign.ID,
+		Date:           flashSaleDate(now),
+		DailyLimit:     campaign.DailyLimit,
+		Claimed:        claimed,
+		Remaining:      -1,
+		UserDailyLimit: campaign.UserDailyLimit,
+		IsActive:       checkCampaignActive(campaign, now) == nil,
+	}
+	if campaign.DailyLimit > 0 {
+		status.Remaining = int64(campaign.DailyLimit) - claimed
+		if status.Remaining < 0 {
+			status.Remaining = 0
+		}
+	}
+	return status, nil
+}
+
+// getFlashSaleCampaign 获取秒杀活动，非秒杀活动返回 ErrCampaignNotFlashSale
+func (s *FlashSaleService) getFlashSaleCampaign(ctx context.Context, campaignID int64) (*models.Campaign, error) {
+	campaign, err := s.campaignRepo.GetByID(ctx, campaignID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCampaignNotFound
+		}
+		return nil, err
+	}
+	if campaign.Type != models.CampaignTypeFlashSale {
+		return nil, ErrCampaignNotFlashSale
+	}
+	return campaign, nil
+}
+
+// checkCampaignActive 检查活动是否启用且在活动时间内
+func checkCampaignActive(campaign *models.Campaign, now time.Time) error {
+	if campaign.Status != models.CampaignStatusActive {
+		return ErrCampaignNotActive
+	}
+	if now.Before(campaign.StartTime) {
+		return ErrCampaignNotStarted
+	}
+	if now.After(campaign.EndTime) {
+		return ErrCampaignExpired
+	}
+	return nil
+}
+
+// checkFlashSaleDailyLimits 校验秒杀活动当日名额及用户当日限购次数，调用方需先锁定活动行以保证并发下不超额
+// 每日名额按件数统计，每人每日限购按预占次数（下单次数）统计，已释放的预占不计入
+func checkFlashSaleDailyLimits(ctx context.Context, productRepo *repository.CampaignProductRepository, campaign *models.Campaign, userID int64, quantity int, now time.Time) error {
+	since, until := startOfDay(now), endOfDay(now)
+	if campaign.DailyLimit > 0 {
+		claimed, err := productRepo.SumCampaignReservedQuantity(ctx, campaign.ID, since, until)
+		if err != nil {
+			return err
+		}
+		if claimed+int64(quantity) > int64(campaign.DailyLimit) {
+			return ErrFlashSaleSoldOut
+		}
+	}
+	if campaign.UserDailyLimit > 0 {
+		count, err := productRepo.CountUserReservations(ctx, campaign.ID, userID, since, until)
+		if err != nil {
+			return err
+		}
+		if count >= int64(campaign.UserDailyLimit) {
+			return ErrFlashSaleUserLimit
+		}
+	}
+	return nil
+}
+
+// flashSaleKey 活动当日名额计数键
+func flashSaleKey(campaignID int64, date string) string {
+	return fmt.Sprintf("flash:campaign:%d:%s", campaignID, date)
+}
+
+// flashSaleUserKey 用户当日领取次数计数键
+func flashSaleUserKey(campaignID int64, date string, userID int64) string {
+	return fmt.Sprintf("flash:campaign:%d:%s:user:%d", campaignID, date, userID)
+}
+
+// flashSaleDate 名额计数所属的自然日
+func flashSaleDate(now time.Time) string {
+	return now.Format("20060102")
+}
+
+// startOfDay 当日零点
+func startOfDay(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// endOfDay 次日零点，当日名额在此时重置，当日计数键在此时过期
+func endOfDay(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+}
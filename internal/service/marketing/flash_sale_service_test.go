@@ -0,0 +1,325 @@
+package marketing
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// setupFlashSaleService 创建秒杀服务及共用同一数据库的活动服务，名额通过活动服务预占秒杀库存领取，并以 Redis 计数预检
+func setupFlashSaleService(t *testing.T) (*FlashSaleService, *CampaignService, *gorm.DB, *miniredis.Miniredis) {
+	t.Helper()
+
+	campaignSvc, db := setupFlashSaleStockTest(t)
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	svc := NewFlashSaleService(repository.NewCampaignRepository(db), repository.NewCampaignProductRepository(db))
+	svc.SetRedis(client)
+	campaignSvc.SetFlashSaleService(svc)
+	return svc, campaignSvc, db, mr
+}
+
+// flashSaleCounter 活动当日 Redis 名额计数，计数键不存在时为 0
+func flashSaleCounter(t *testing.T, mr *miniredis.Miniredis, campaignID int64) int {
+	t.Helper()
+	value, err := mr.Get(flashSaleKey(campaignID, flashSaleDate(time.Now())))
+	if err == miniredis.ErrKeyNotFound {
+		return 0
+	}
+	require.NoError(t, err)
+	n, err := strconv.Atoi(value)
+	require.NoError(t, err)
+	return n
+}
+
+// createDailyFlashSaleProduct 创建设置了每日名额及每人每日限购次数的秒杀活动商品
+func createDailyFlashSaleProduct(t *testing.T, db *gorm.DB, dailyLimit, userDailyLimit int) (*models.Campaign, *models.Product) {
+	t.Helper()
+
+	campaign, product := createFlashSaleProduct(t, db, 1000, 0)
+	require.NoError(t, db.Model(campaign).Updates(map[string]interface{}{
+		"daily_limit":      dailyLimit,
+		"user_daily_limit": userDailyLimit,
+	}).Error)
+	return campaign, product
+}
+
+func TestFlashSaleService_DailyLimitConcurrent(t *testing.T) {
+	svc, campaignSvc, db, _ := setupFlashSaleService(t)
+	ctx := context.Background()
+	campaign, product := createDailyFlashSaleProduct(t, db, 50, 0)
+
+	const workers = 200
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(userID int64) {
+			defer wg.Done()
+			_, err := campaignSvc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, userID, 1)
+			errs <- err
+		}(int64(i + 1))
+	}
+	wg.Wait()
+	close(errs)
+
+	var claimed, soldOut int
+	for err := range errs {
+		switch err {
+		case nil:
+			claimed++
+		case ErrFlashSaleSoldOut:
+			soldOut++
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	assert.Equal(t, 50, claimed)
+	assert.Equal(t, workers-50, soldOut)
+	assert.Equal(t, 950, flashStockOf(t, db, campaign.ID, product.ID))
+
+	status, err := svc.GetFlashSaleStatus(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 50, status.DailyLimit)
+	assert.Equal(t, int64(50), status.Claimed)
+	assert.Equal(t, int64(0), status.Remaining)
+	assert.True(t, status.IsActive)
+}
+
+func TestFlashSaleService_UserDailyLimitConcurrent(t *testing.T) {
+	svc, campaignSvc, db, _ := setupFlashSaleService(t)
+	ctx := context.Background()
+	campaign, product := createDailyFlashSaleProduct(t, db, 50, 2)
+
+	const workers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := campaignSvc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, 1, 1)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var claimed, limited int
+	for err := range errs {
+		switch err {
+		case nil:
+			claimed++
+		case ErrFlashSaleUserLimit:
+			limited++
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	assert.Equal(t, 2, claimed)
+	assert.Equal(t, workers-2, limited)
+
+	// 其他用户不受影响，被拒绝的请求不占用名额
+	_, err := campaignSvc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, 2, 1)
+	require.NoError(t, err)
+	status, err := svc.GetFlashSaleStatus(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(47), status.Remaining)
+}
+
+func TestFlashSaleService_ReleasedReservationFreesSlot(t *testing.T) {
+	svc, campaignSvc, db, mr := setupFlashSaleService(t)
+	ctx := context.Background()
+	campaign, product := createDailyFlashSaleProduct(t, db, 2, 1)
+
+	orderID := int64(201)
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		_, err := campaignSvc.ReserveFlashSaleStockTx(ctx, tx, campaign.ID, product.ID, 1, &orderID, 2)
+		return err
+	}))
+	_, err := campaignSvc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, 2, 1)
+	assert.Equal(t, ErrFlashSaleSoldOut, err)
+	_, err = campaignSvc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, 1, 1)
+	assert.Equal(t, ErrFlashSaleSoldOut, err)
+
+	// 订单取消后释放预占，名额和用户限购次数一并恢复，Redis 计数同步归还
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		return campaignSvc.ReleaseFlashSaleReservationsTx(ctx, tx, orderID)
+	}))
+	assert.Equal(t, 0, flashSaleCounter(t, mr, campaign.ID))
+	status, err := svc.GetFlashSaleStatus(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), status.Claimed)
+	_, err = campaignSvc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, 1, 1)
+	require.NoError(t, err)
+}
+
+func TestFlashSaleService_ResetsDaily(t *testing.T) {
+	svc, campaignSvc, db, mr := setupFlashSaleService(t)
+	ctx := context.Background()
+	campaign, product := createDailyFlashSaleProduct(t, db, 1, 0)
+
+	_, err := campaignSvc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, 1, 1)
+	require.NoError(t, err)
+	_, err = campaignSvc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, 2, 1)
+	assert.Equal(t, ErrFlashSaleSoldOut, err)
+
+	// 计数键在当天结束时过期
+	ttl := mr.TTL(flashSaleKey(campaign.ID, flashSaleDate(time.Now())))
+	assert.True(t, ttl > 0 && ttl <= 24*time.Hour, "ttl=%v", ttl)
+
+	// 前一天的预占不占用当日名额，前一天的计数键已过期
+	require.NoError(t, db.Model(&models.FlashSaleReservation{}).
+		Where("campaign_id = ?", campaign.ID).
+		Update("created_at", time.Now().AddDate(0, 0, -1)).Error)
+	mr.FastForward(ttl)
+	status, err := svc.GetFlashSaleStatus(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), status.Remaining)
+	_, err = campaignSvc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, 2, 1)
+	require.NoError(t, err)
+}
+
+func TestFlashSaleService_Validation(t *testing.T) {
+	svc, campaignSvc, db, _ := setupFlashSaleService(t)
+	ctx := context.Background()
+
+	t.Run("活动不存在", func(t *testing.T) {
+		_, err := svc.GetFlashSaleStatus(ctx, 99999)
+		assert.Equal(t, ErrCampaignNotFound, err)
+	})
+
+	t.Run("非秒杀活动", func(t *testing.T) {
+		campaign := createMarketingTestCampaign(t, db)
+		_, err := svc.GetFlashSaleStatus(ctx, campaign.ID)
+		assert.Equal(t, ErrCampaignNotFlashSale, err)
+	})
+
+	t.Run("活动未开始", func(t *testing.T) {
+		campaign := createMarketingTestCampaign(t, db, func(c *models.Campaign) {
+			c.Type = models.CampaignTypeFlashSale
+			c.StartTime = time.Now().Add(time.Hour)
+			c.DailyLimit = 10
+		})
+
+		status, err := svc.GetFlashSaleStatus(ctx, campaign.ID)
+		require.NoError(t, err)
+		assert.False(t, status.IsActive)
+		assert.Equal(t, int64(10), status.Remaining)
+	})
+
+	t.Run("不限量活动", func(t *testing.T) {
+		campaign, product := createDailyFlashSaleProduct(t, db, 0, 0)
+		for i := 0; i < 3; i++ {
+			_, err := campaignSvc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, 1, 1)
+			require.NoError(t, err)
+		}
+		status, err := svc.GetFlashSaleStatus(ctx, campaign.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), status.Claimed)
+		assert.Equal(t, int64(-1), status.Remaining)
+	})
+}
+
+func TestFlashSaleService_ClaimFlashSaleSlotConcurrent(t *testing.T) {
+	svc, _, db, mr := setupFlashSaleService(t)
+	ctx := context.Background()
+	campaign, _ := createDailyFlashSaleProduct(t, db, 50, 0)
+
+	const workers = 200
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(userID int64) {
+			defer wg.Done()
+			errs <- svc.ClaimFlashSaleSlot(ctx, campaign.ID, userID)
+		}(int64(i + 1))
+	}
+	wg.Wait()
+	close(errs)
+
+	var claimed, soldOut int
+	for err := range errs {
+		switch err {
+		case nil:
+			claimed++
+		case ErrFlashSaleSoldOut:
+			soldOut++
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	assert.Equal(t, 50, claimed)
+	assert.Equal(t, workers-50, soldOut)
+	assert.Equal(t, 50, flashSaleCounter(t, mr, campaign.ID))
+
+	// 归还后名额重新可用
+	require.NoError(t, svc.ReleaseFlashSaleSlot(ctx, campaign.ID, 1, 1))
+	require.NoError(t, svc.ClaimFlashSaleSlot(ctx, campaign.ID, 1))
+	assert.Equal(t, ErrFlashSaleSoldOut, svc.ClaimFlashSaleSlot(ctx, campaign.ID, 2))
+}
+
+func TestCampaignService_ReserveFlashSaleStock_RedisPreCheck(t *testing.T) {
+	_, campaignSvc, db, mr := setupFlashSaleService(t)
+	ctx := context.Background()
+
+	t.Run("计数已满时不进入数据库预占", func(t *testing.T) {
+		campaign, product := createDailyFlashSaleProduct(t, db, 5, 0)
+		require.NoError(t, mr.Set(flashSaleKey(campaign.ID, flashSaleDate(time.Now())), "5"))
+
+		_, err := campaignSvc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, 1, 1)
+		assert.Equal(t, ErrFlashSaleSoldOut, err)
+		var count int64
+		require.NoError(t, db.Model(&models.FlashSaleReservation{}).Where("campaign_id = ?", campaign.ID).Count(&count).Error)
+		assert.Equal(t, int64(0), count)
+		assert.Equal(t, 1000, flashStockOf(t, db, campaign.ID, product.ID))
+	})
+
+	t.Run("数据库预占失败时归还计数", func(t *testing.T) {
+		campaign, product := createDailyFlashSaleProduct(t, db, 5, 0)
+		require.NoError(t, db.Model(&models.CampaignProduct{}).
+			Where("campaign_id = ? AND product_id = ?", campaign.ID, product.ID).
+			Update("flash_stock", 1).Error)
+
+		_, err := campaignSvc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, 1, 2)
+		assert.Equal(t, ErrFlashSaleStockSoldOut, err)
+		assert.Equal(t, 0, flashSaleCounter(t, mr, campaign.ID))
+	})
+
+	t.Run("调用方事务回滚后归还计数", func(t *testing.T) {
+		campaign, product := createDailyFlashSaleProduct(t, db, 5, 1)
+
+		var reservation *models.FlashSaleReservation
+		errRollback := errors.New("rollback")
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			reservation, err = campaignSvc.ReserveFlashSaleStockTx(ctx, tx, campaign.ID, product.ID, 1, nil, 3)
+			require.NoError(t, err)
+			return errRollback
+		})
+		require.ErrorIs(t, err, errRollback)
+		assert.Equal(t, 3, flashSaleCounter(t, mr, campaign.ID))
+
+		campaignSvc.ReleaseFlashSaleSlots(ctx, []*models.FlashSaleReservation{reservation})
+		assert.Equal(t, 0, flashSaleCounter(t, mr, campaign.ID))
+		// 用户当日次数一并归还，可再次下单
+		_, err = campaignSvc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, 1, 5)
+		require.NoError(t, err)
+		assert.Equal(t, 5, flashSaleCounter(t, mr, campaign.ID))
+	})
+}
@@ -0,0 +1,237 @@
+package marketing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// FlashSaleProductItem 秒杀活动商品（用户端）
+type FlashSaleProductItem struct {
+	ProductID      int64   `json:"product_id"`
+	Name           string  `json:"name"`
+	Image          string  `json:"image,omitempty"`
+	Price          float64 `json:"price"`       // 商品原价
+	FlashPrice     float64 `json:"flash_price"` // 秒杀价
+	RemainingStock int     `json:"remaining_stock"`
+	PerUserLimit   int     `json:"per_user_limit"` // 每人限购数量，0 表示不限
+	SoldOut        bool    `json:"sold_out"`
+}
+
+// ListFlashSaleProducts 获取秒杀活动的商品，已删除或已下架的商品不返回
+func (s *CampaignService) ListFlashSaleProducts(ctx context.Context, campaignID int64) ([]*FlashSaleProductItem, error) {
+	campaign, err := s.getCampaign(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Type != models.CampaignTypeFlashSale {
+		return nil, ErrCampaignNotFlashSale
+	}
+	if campaign.Status != models.CampaignStatusActive {
+		return nil, ErrCampaignNotActive
+	}
+
+	products, err := s.campaignProductRepo.ListByCampaign(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*FlashSaleProductItem, 0, len(products))
+	for _, p := range products {
+		if p.Product == nil || !p.Product.IsOnSale {
+			continue
+		}
+		item := &FlashSaleProductItem{
+			ProductID:      p.ProductID,
+			Name:           p.Product.Name,
+			Price:          p.Product.Price,
+			FlashPrice:     p.FlashPrice,
+			RemainingStock: p.FlashStock,
+			PerUserLimit:   p.PerUserLimit,
+			SoldOut:        p.FlashStock <= 0,
+		}
+		var images []string
+		if json.Unmarshal(p.Product.Images, &images) == nil && len(images) > 0 {
+			item.Image = images[0]
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+// GetActiveFlashSaleProductTx 在事务中获取商品当前进行中的秒杀，未参加进行中的秒杀时返回 nil
+func (s *CampaignService) GetActiveFlashSaleProductTx(ctx context.Context, tx *gorm.DB, productID int64) (*models.CampaignProduct, error) {
+	product, err := repository.NewCampaignProductRepository(tx).GetActiveByProduct(ctx, productID, time.Now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return product, err
+}
+
+// ReserveFlashSaleStock 预占秒杀库存，不关联订单
+func (s *CampaignService) ReserveFlashSaleStock(ctx context.Context, campaignID, productID, userID int64, quantity int) (*models.FlashSaleReservation, error) {
+	var reservation *models.FlashSaleReservation
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		reservation, err = s.ReserveFlashSaleStockTx(ctx, tx, campaignID, productID, userID, nil, quantity)
+		return err
+	})
+	if err != nil {
+		if reservation != nil {
+			s.ReleaseFlashSaleSlots(ctx, []*models.FlashSaleReservation{reservation})
+		}
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// ReserveFlashSaleStockTx 在事务中预占秒杀库存
+// 活动设置了每日名额或每人每日限购次数时先以 Redis 计数预检（见 SetFlashSaleService），再锁定活动并校验，
+// 当日名额已抢完返回 ErrFlashSaleSoldOut，超过每日限购次数返回 ErrFlashSaleUserLimit；
+// 以条件更新扣减剩余秒杀库存，库存不足时不扣减，并发下不会超卖；扣减同时锁定该秒杀商品，
+// 之后统计用户待支付及已支付的预占数量校验每人限购，超限时返回 ErrFlashSaleLimitExceeded，由调用方回滚事务恢复库存。
+// 本方法失败时已自行归还 Redis 名额；成功后若调用方事务回滚，需以返回的预占调用 ReleaseFlashSaleSlots 归还
+func (s *CampaignService) ReserveFlashSaleStockTx(ctx context.Context, tx *gorm.DB, campaignID, productID, userID int64, orderID *int64, quantity int) (_ *models.FlashSaleReservation, err error) {
+	if quantity <= 0 {
+		return nil, ErrFlashSaleQuantityInvalid
+	}
+
+	campaignRepo := repository.NewCampaignRepository(tx)
+	campaign, err := campaignRepo.GetByID(ctx, campaignID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCampaignNotFound
+		}
+		return nil, err
+	}
+	if campaign.Type != models.CampaignTypeFlashSale {
+		return nil, ErrCampaignNotFlashSale
+	}
+	now := time.Now()
+	if err := checkCampaignActive(campaign, now); err != nil {
+		return nil, err
+	}
+
+	productRepo := repository.NewCampaignProductRepository(tx)
+	product, err := productRepo.GetByCampaignAndProduct(ctx, campaignID, productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFlashSaleProductNotFound
+		}
+		return nil, err
+	}
+
+	if campaign.DailyLimit > 0 || campaign.UserDailyLimit > 0 {
+		// 先以 Redis 计数预检，名额已抢完的请求无需等待活动行锁；后续任一步失败时归还计数
+		claimed, claimErr := s.claimFlashSaleSlots(ctx, campaign, userID, quantity, now)
+		if claimErr != nil {
+			return nil, claimErr
+		}
+		if claimed {
+			defer func() {
+				if err != nil {
+					_ = s.flashSale.releaseSlots(ctx, campaignID, userID, quantity, now)
+				}
+			}()
+		}
+
+		// 每日名额按活动统计，锁定活动行使同一活动的预占串行校验
+		if campaign, err = campaignRepo.GetByIDForUpdate(ctx, campaignID); err != nil {
+			return nil, err
+		}
+		if err := checkFlashSaleDailyLimits(ctx, productRepo, campaign, userID, quantity, now); err != nil {
+			return nil, err
+		}
+	}
+
+	ok, err := productRepo.DecreaseFlashStock(ctx, product.ID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrFlashSaleStockSoldOut
+	}
+
+	if product.PerUserLimit > 0 {
+		reserved, err := productRepo.SumUserReservedQuantity(ctx, campaignID, productID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if reserved+quantity > product.PerUserLimit {
+			return nil, ErrFlashSaleLimitExceeded
+		}
+	}
+
+	reservation := &models.FlashSaleReservation{
+		CampaignID: campaignID,
+		ProductID:  productID,
+		UserID:     userID,
+		OrderID:    orderID,
+		Quantity:   quantity,
+		Status:     models.FlashSaleReservationReserved,
+		CreatedAt:  now, // 与名额计数使用同一自然日
+	}
+	if err := productRepo.CreateReservation(ctx, reservation); err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// ConfirmFlashSaleReservationsTx 订单支付后将其秒杀库存预占转为已支付
+func (s *CampaignService) ConfirmFlashSaleReservationsTx(ctx context.Context, tx *gorm.DB, orderID int64) error {
+	return repository.NewCampaignProductRepository(tx).MarkOrderReservationsPaid(ctx, orderID)
+}
+
+// ReleaseFlashSaleReservationsTx 订单取消或超时未支付时释放其待支付的秒杀库存预占，并恢复秒杀库存
+func (s *CampaignService) ReleaseFlashSaleReservationsTx(ctx context.Context, tx *gorm.DB, orderID int64) error {
+	productRepo := repository.NewCampaignProductRepository(tx)
+	reservations, err := productRepo.ListReservedByOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range reservations {
+		released, err := productRepo.UpdateReservationStatus(ctx, r.ID, models.FlashSaleReservationReleased)
+		if err != nil {
+			return err
+		}
+		if !released {
+			continue
+		}
+		if err := productRepo.IncreaseFlashStock(ctx, r.CampaignID, r.ProductID, r.Quantity); err != nil {
+			return err
+		}
+	}
+	// 计数只作预检，事务回滚时少计的名额由数据库校验兜底
+	s.ReleaseFlashSaleSlots(ctx, reservations)
+	return nil
+}
+
+// ReleaseFlashSaleSlots 归还预占占用的 Redis 当日名额计数，用于预占所在事务回滚或预占释放后
+func (s *CampaignService) ReleaseFlashSaleSlots(ctx context.Context, reservations []*models.FlashSaleReservation) {
+	if s.flashSale == nil {
+		return
+	}
+	for _, r := range reservations {
+		_ = s.flashSale.releaseSlots(ctx, r.CampaignID, r.UserID, r.Quantity, r.CreatedAt)
+	}
+}
+
+// claimFlashSaleSlots 以 Redis 计数预检当日名额，返回是否已计入计数
+// Redis 不可用时跳过预检，以数据库校验为准
+func (s *CampaignService) claimFlashSaleSlots(ctx context.Context, campaign *models.Campaign, userID int64, quantity int, now time.Time) (bool, error) {
+	if s.flashSale == nil {
+		return false, nil
+	}
+	claimed, err := s.flashSale.claimSlots(ctx, campaign, userID, quantity, now)
+	if errors.Is(err, ErrFlashSaleSoldOut) || errors.Is(err, ErrFlashSaleUserLimit) {
+		return false, err
+	}
+	return claimed, nil
+}
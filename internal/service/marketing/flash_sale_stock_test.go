@@ -0,0 +1,221 @@
+package marketing
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// setupFlashSaleStockTest 创建基于文件的 SQLite 数据库，允许多个连接并发预占库存
+func setupFlashSaleStockTest(t *testing.T) (*CampaignService, *gorm.DB) {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "flash_sale.db") + "?_journal_mode=WAL&_busy_timeout=10000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(20)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(
+		&models.Campaign{},
+		&models.Product{},
+		&models.CampaignProduct{},
+		&models.FlashSaleReservation{},
+	))
+	return NewCampaignService(db, repository.NewCampaignRepository(db)), db
+}
+
+// createFlashSaleProduct 创建进行中的秒杀活动及其秒杀商品
+func createFlashSaleProduct(t *testing.T, db *gorm.DB, flashStock, perUserLimit int) (*models.Campaign, *models.Product) {
+	t.Helper()
+
+	campaign := createMarketingTestCampaign(t, db, func(c *models.Campaign) {
+		c.Name = "限量秒杀"
+		c.Type = models.CampaignTypeFlashSale
+	})
+	product := &models.Product{CategoryID: 1, Name: "秒杀商品", Images: []byte(`["https://example.com/flash.jpg"]`), Price: 99, Stock: 1000, IsOnSale: true}
+	require.NoError(t, db.Create(product).Error)
+	require.NoError(t, db.Create(&models.CampaignProduct{
+		CampaignID:   campaign.ID,
+		ProductID:    product.ID,
+		FlashPrice:   9.9,
+		FlashStock:   flashStock,
+		PerUserLimit: perUserLimit,
+	}).Error)
+	return campaign, product
+}
+
+func flashStockOf(t *testing.T, db *gorm.DB, campaignID, productID int64) int {
+	t.Helper()
+	var p models.CampaignProduct
+	require.NoError(t, db.Where("campaign_id = ? AND product_id = ?", campaignID, productID).First(&p).Error)
+	return p.FlashStock
+}
+
+func TestCampaignService_ReserveFlashSaleStock_Concurrent(t *testing.T) {
+	svc, db := setupFlashSaleStockTest(t)
+	ctx := context.Background()
+	campaign, product := createFlashSaleProduct(t, db, 10, 1)
+
+	const buyers = 100
+	var wg sync.WaitGroup
+	errs := make(chan error, buyers)
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func(userID int64) {
+			defer wg.Done()
+			_, err := svc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, userID, 1)
+			errs <- err
+		}(int64(i + 1))
+	}
+	wg.Wait()
+	close(errs)
+
+	var reserved, soldOut int
+	for err := range errs {
+		switch {
+		case err == nil:
+			reserved++
+		case assert.ErrorIs(t, err, ErrFlashSaleStockSoldOut):
+			soldOut++
+		}
+	}
+	assert.Equal(t, 10, reserved)
+	assert.Equal(t, buyers-10, soldOut)
+	assert.Equal(t, 0, flashStockOf(t, db, campaign.ID, product.ID))
+
+	var count int64
+	require.NoError(t, db.Model(&models.FlashSaleReservation{}).
+		Where("status = ?", models.FlashSaleReservationReserved).Count(&count).Error)
+	assert.Equal(t, int64(10), count)
+}
+
+func TestCampaignService_ReserveFlashSaleStock(t *testing.T) {
+	svc, db := setupFlashSaleStockTest(t)
+	ctx := context.Background()
+	campaign, product := createFlashSaleProduct(t, db, 10, 3)
+	userID := int64(1)
+
+	t.Run("每人限购", func(t *testing.T) {
+		_, err := svc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, userID, 2)
+		require.NoError(t, err)
+		_, err = svc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, userID, 2)
+		assert.ErrorIs(t, err, ErrFlashSaleLimitExceeded)
+		// 超限时不扣减库存
+		assert.Equal(t, 8, flashStockOf(t, db, campaign.ID, product.ID))
+
+		_, err = svc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, userID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 7, flashStockOf(t, db, campaign.ID, product.ID))
+	})
+
+	t.Run("库存不足", func(t *testing.T) {
+		_, err := svc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, userID+1, 8)
+		assert.ErrorIs(t, err, ErrFlashSaleStockSoldOut)
+		assert.Equal(t, 7, flashStockOf(t, db, campaign.ID, product.ID))
+	})
+
+	t.Run("参数及活动校验", func(t *testing.T) {
+		_, err := svc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID, userID+1, 0)
+		assert.ErrorIs(t, err, ErrFlashSaleQuantityInvalid)
+		_, err = svc.ReserveFlashSaleStock(ctx, campaign.ID, product.ID+100, userID+1, 1)
+		assert.ErrorIs(t, err, ErrFlashSaleProductNotFound)
+		_, err = svc.ReserveFlashSaleStock(ctx, campaign.ID+100, product.ID, userID+1, 1)
+		assert.ErrorIs(t, err, ErrCampaignNotFound)
+
+		ended := createMarketingTestCampaign(t, db, func(c *models.Campaign) {
+			c.Type = models.CampaignTypeFlashSale
+			c.StartTime = time.Now().Add(-48 * time.Hour)
+			c.EndTime = time.Now().Add(-24 * time.Hour)
+		})
+		_, err = svc.ReserveFlashSaleStock(ctx, ended.ID, product.ID, userID+1, 1)
+		assert.ErrorIs(t, err, ErrCampaignExpired)
+	})
+}
+
+func TestCampaignService_FlashSaleReservationLifecycle(t *testing.T) {
+	svc, db := setupFlashSaleStockTest(t)
+	ctx := context.Background()
+	campaign, product := createFlashSaleProduct(t, db, 10, 0)
+
+	paidOrderID, cancelledOrderID := int64(101), int64(102)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := svc.ReserveFlashSaleStockTx(ctx, tx, campaign.ID, product.ID, 1, &paidOrderID, 2); err != nil {
+			return err
+		}
+		_, err := svc.ReserveFlashSaleStockTx(ctx, tx, campaign.ID, product.ID, 1, &cancelledOrderID, 3)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5, flashStockOf(t, db, campaign.ID, product.ID))
+
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		return svc.ConfirmFlashSaleReservationsTx(ctx, tx, paidOrderID)
+	}))
+	for i := 0; i < 2; i++ {
+		// 重复释放不重复恢复库存；已支付的预占不释放
+		require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+			if err := svc.ReleaseFlashSaleReservationsTx(ctx, tx, cancelledOrderID); err != nil {
+				return err
+			}
+			return svc.ReleaseFlashSaleReservationsTx(ctx, tx, paidOrderID)
+		}))
+	}
+	assert.Equal(t, 8, flashStockOf(t, db, campaign.ID, product.ID))
+
+	statuses := map[int64]string{}
+	var reservations []*models.FlashSaleReservation
+	require.NoError(t, db.Find(&reservations).Error)
+	for _, r := range reservations {
+		statuses[*r.OrderID] = r.Status
+	}
+	assert.Equal(t, models.FlashSaleReservationPaid, statuses[paidOrderID])
+	assert.Equal(t, models.FlashSaleReservationReleased, statuses[cancelledOrderID])
+}
+
+func TestCampaignService_ListFlashSaleProducts(t *testing.T) {
+	svc, db := setupFlashSaleStockTest(t)
+	ctx := context.Background()
+	campaign, product := createFlashSaleProduct(t, db, 0, 2)
+
+	offShelf := &models.Product{CategoryID: 1, Name: "已下架", Images: []byte(`[]`), Price: 50, IsOnSale: true}
+	require.NoError(t, db.Create(offShelf).Error)
+	require.NoError(t, db.Model(offShelf).Update("is_on_sale", false).Error)
+	require.NoError(t, db.Create(&models.CampaignProduct{CampaignID: campaign.ID, ProductID: offShelf.ID, FlashPrice: 5, FlashStock: 5}).Error)
+
+	items, err := svc.ListFlashSaleProducts(ctx, campaign.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, &FlashSaleProductItem{
+		ProductID:      product.ID,
+		Name:           "秒杀商品",
+		Image:          "https://example.com/flash.jpg",
+		Price:          99,
+		FlashPrice:     9.9,
+		RemainingStock: 0,
+		PerUserLimit:   2,
+		SoldOut:        true,
+	}, items[0])
+
+	discount := createMarketingTestCampaign(t, db)
+	_, err = svc.ListFlashSaleProducts(ctx, discount.ID)
+	assert.ErrorIs(t, err, ErrCampaignNotFlashSale)
+	_, err = svc.ListFlashSaleProducts(ctx, 99999)
+	assert.ErrorIs(t, err, ErrCampaignNotFound)
+}
@@ -0,0 +1,223 @@
+package marketing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// GiftItem 满赠规则，存储在满赠活动的 Rules 中：{"min_amount":200,"gift_product_id":42,"gift_quantity":1}
+type GiftItem struct {
+	MinAmount     float64 `json:"min_amount"`      // 满足金额
+	GiftProductID int64   `json:"gift_product_id"` // 赠品商品ID
+	GiftQuantity  int     `json:"gift_quantity"`   // 赠品数量，未设置时为 1
+}
+
+// ValidateGiftItem 校验满赠规则：门槛金额大于0，须指定赠品且数量大于0
+func ValidateGiftItem(item *GiftItem) error {
+	if item.MinAmount <= 0 {
+		return fmt.Errorf("%w: 门槛金额必须大于0", ErrCampaignRuleInvalid)
+	}
+	if item.GiftProductID <= 0 {
+		return fmt.Errorf("%w: 未指定赠品", ErrCampaignRuleInvalid)
+	}
+	if item.GiftQuantity <= 0 {
+		return fmt.Errorf("%w: 赠品数量必须大于0", ErrCampaignRuleInvalid)
+	}
+	return nil
+}
+
+// ParseGiftRules 解析并校验满赠规则，未设置赠品数量时按 1 件处理
+func ParseGiftRules(raw json.RawMessage) (*GiftItem, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, fmt.Errorf("%w: 未设置满赠规则", ErrCampaignRuleInvalid)
+	}
+
+	var item GiftItem
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCampaignRuleInvalid, err)
+	}
+	if item.GiftQuantity == 0 {
+		item.GiftQuantity = 1
+	}
+	if err := ValidateGiftItem(&item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// GiftCampaignService 满赠活动服务
+// 订单实付金额达到活动门槛时，为订单生成一笔零元赠品订单，并通过 GiftOrder 关联主订单
+type GiftCampaignService struct {
+	db           *gorm.DB
+	campaignRepo *repository.CampaignRepository
+}
+
+// NewGiftCampaignService 创建满赠活动服务
+func NewGiftCampaignService(db *gorm.DB, campaignRepo *repository.CampaignRepository) *GiftCampaignService {
+	return &GiftCampaignService{
+		db:           db,
+		campaignRepo: campaignRepo,
+	}
+}
+
+// CheckGiftEligibility 检查订单金额是否满足指定满赠活动，满足时返回赠品规则
+// 活动不存在、非满赠活动、未启用或不在活动时间内时返回对应错误
+func (s *GiftCampaignService) CheckGiftEligibility(ctx context.Context, orderAmount float64, campaignID int64) (*GiftItem, bool, error) {
+	campaign, err := s.campaignRepo.GetByID(ctx, campaignID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, ErrCampaignNotFound
+		}
+		return nil, false, err
+	}
+	if campaign.Type != models.CampaignTypeGift {
+		return nil, false, ErrCampaignNotGift
+	}
+
+	now := time.Now()
+	if campaign.Status != models.CampaignStatusActive {
+		return nil, false, ErrCampaignNotActive
+	}
+	if now.Before(campaign.StartTime) {
+		return nil, false, ErrCampaignNotStarted
+	}
+	if now.After(campaign.EndTime) {
+		return nil, false, ErrCampaignExpired
+	}
+
+	return giftFor(campaign, orderAmount)
+}
+
+// FindEligibleGift 查找进行中的满赠活动并检查订单金额是否满足，无进行中的活动或未达门槛时返回 nil
+func (s *GiftCampaignService) FindEligibleGift(ctx context.Context, orderAmount float64) (*models.Campaign, *GiftItem, error) {
+	campaign, err := s.campaignRepo.GetActiveByType(ctx, models.CampaignTypeGift)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	item, ok, err := giftFor(campaign, orderAmount)
+	if err != nil || !ok {
+		return nil, nil, err
+	}
+	return campaign, item, nil
+}
+
+// giftFor 按活动规则判断订单金额是否达到满赠门槛
+func giftFor(campaign *models.Campaign, orderAmount float64) (*GiftItem, bool, error) {
+	item, err := ParseGiftRules(campaign.Rules)
+	if err != nil {
+		return nil, false, err
+	}
+	if orderAmount < item.MinAmount {
+		return nil, false, nil
+	}
+	return item, true, nil
+}
+
+// CreateGiftOrder 为主订单创建零元赠品订单（如客服手动补发赠品），不关联满赠活动
+func (s *GiftCampaignService) CreateGiftOrder(ctx context.Context, mainOrderID, giftProductID int64, qty int) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var mainOrder models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&mainOrder, mainOrderID).Error; err != nil {
+			return err
+		}
+		_, err := CreateGiftOrderTx(tx, nil, &mainOrder, giftProductID, qty)
+		return err
+	})
+}
+
+// CreateGiftOrderTx 在事务中为主订单创建零元赠品订单并扣减赠品库存
+// 赠品订单沿用主订单的用户、收货地址和状态，随主订单一起支付、取消；
+// 赠品下架、不存在时返回 ErrGiftProductNotFound，库存不足时返回 ErrGiftStockInsufficient，主订单已有赠品时返回 ErrGiftOrderExists
+func CreateGiftOrderTx(tx *gorm.DB, campaignID *int64, mainOrder *models.Order, giftProductID int64, qty int) (*models.Order, error) {
+	if qty <= 0 {
+		return nil, fmt.Errorf("%w: 赠品数量必须大于0", ErrCampaignRuleInvalid)
+	}
+
+	var exists int64
+	if err := tx.Model(&models.GiftOrder{}).Where("main_order_id = ?", mainOrder.ID).Count(&exists).Error; err != nil {
+		return nil, err
+	}
+	if exists > 0 {
+		return nil, ErrGiftOrderExists
+	}
+
+	var product models.Product
+	if err := tx.First(&product, giftProductID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGiftProductNotFound
+		}
+		return nil, err
+	}
+	if !product.IsOnSale {
+		return nil, ErrGiftProductNotFound
+	}
+
+	result := tx.Model(&models.Product{}).
+		Where("id = ? AND stock >= ?", giftProductID, qty).
+		UpdateColumn("stock", gorm.Expr("stock - ?", qty))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrGiftStockInsufficient
+	}
+
+	remark := "满赠赠品，主订单 " + mainOrder.OrderNo
+	giftOrder := &models.Order{
+		OrderNo:         utils.GenerateOrderNo("MG"),
+		UserID:          mainOrder.UserID,
+		Type:            models.OrderTypeMall,
+		Status:          mainOrder.Status,
+		AddressID:       mainOrder.AddressID,
+		AddressSnapshot: mainOrder.AddressSnapshot,
+		Remark:          &remark,
+	}
+	if err := tx.Create(giftOrder).Error; err != nil {
+		return nil, err
+	}
+
+	var productImage string
+	if product.Images != nil {
+		var images []string
+		if json.Unmarshal(product.Images, &images) == nil && len(images) > 0 {
+			productImage = images[0]
+		}
+	}
+	productID := product.ID
+	if err := tx.Create(&models.OrderItem{
+		OrderID:      giftOrder.ID,
+		ProductID:    &productID,
+		ProductName:  product.Name,
+		ProductImage: &productImage,
+		Quantity:     qty,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Create(&models.GiftOrder{
+		CampaignID:  campaignID,
+		MainOrderID: mainOrder.ID,
+		GiftOrderID: giftOrder.ID,
+		ProductID:   productID,
+		Quantity:    qty,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return giftOrder, nil
+}
@@ -0,0 +1,180 @@
+package marketing
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+func setupGiftCampaignTest(t *testing.T) (*GiftCampaignService, *gorm.DB) {
+	t.Helper()
+
+	db := setupMarketingTestDB(t)
+	require.NoError(t, db.AutoMigrate(
+		&models.Product{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.GiftOrder{},
+	))
+	return NewGiftCampaignService(db, repository.NewCampaignRepository(db)), db
+}
+
+func createGiftTestCampaign(t *testing.T, db *gorm.DB, rules string, opts ...func(*models.Campaign)) *models.Campaign {
+	t.Helper()
+
+	return createMarketingTestCampaign(t, db, append([]func(*models.Campaign){func(c *models.Campaign) {
+		c.Name = "满赠活动"
+		c.Type = models.CampaignTypeGift
+		c.Rules = json.RawMessage(rules)
+	}}, opts...)...)
+}
+
+func TestParseGiftRules(t *testing.T) {
+	item, err := ParseGiftRules(json.RawMessage(`{"min_amount":200,"gift_product_id":42}`))
+	require.NoError(t, err)
+	assert.Equal(t, 200.0, item.MinAmount)
+	assert.Equal(t, int64(42), item.GiftProductID)
+	assert.Equal(t, 1, item.GiftQuantity, "未设置赠品数量时按 1 件")
+
+	invalid := []string{
+		``,
+		`[]`,
+		`{"min_amount":0,"gift_product_id":42}`,
+		`{"min_amount":200}`,
+		`{"min_amount":200,"gift_product_id":42,"gift_quantity":-1}`,
+	}
+	for _, raw := range invalid {
+		_, err := ParseGiftRules(json.RawMessage(raw))
+		assert.ErrorIs(t, err, ErrCampaignRuleInvalid, raw)
+	}
+}
+
+func TestGiftCampaignService_CheckGiftEligibility(t *testing.T) {
+	svc, db := setupGiftCampaignTest(t)
+	ctx := context.Background()
+	campaign := createGiftTestCampaign(t, db, `{"min_amount":200,"gift_product_id":42,"gift_quantity":1}`)
+
+	t.Run("达到门槛返回赠品", func(t *testing.T) {
+		item, ok, err := svc.CheckGiftEligibility(ctx, 200, campaign.ID)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		require.NotNil(t, item)
+		assert.Equal(t, int64(42), item.GiftProductID)
+		assert.Equal(t, 1, item.GiftQuantity)
+	})
+
+	t.Run("未达门槛不赠送", func(t *testing.T) {
+		item, ok, err := svc.CheckGiftEligibility(ctx, 199.99, campaign.ID)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Nil(t, item)
+	})
+
+	t.Run("活动不可用", func(t *testing.T) {
+		_, _, err := svc.CheckGiftEligibility(ctx, 500, 99999)
+		assert.ErrorIs(t, err, ErrCampaignNotFound)
+
+		discount := createMarketingTestCampaign(t, db)
+		_, _, err = svc.CheckGiftEligibility(ctx, 500, discount.ID)
+		assert.ErrorIs(t, err, ErrCampaignNotGift)
+
+		ended := createGiftTestCampaign(t, db, `{"min_amount":200,"gift_product_id":42}`, func(c *models.Campaign) {
+			c.StartTime = time.Now().Add(-48 * time.Hour)
+			c.EndTime = time.Now().Add(-24 * time.Hour)
+		})
+		_, _, err = svc.CheckGiftEligibility(ctx, 500, ended.ID)
+		assert.ErrorIs(t, err, ErrCampaignExpired)
+	})
+}
+
+func TestGiftCampaignService_FindEligibleGift(t *testing.T) {
+	svc, db := setupGiftCampaignTest(t)
+	ctx := context.Background()
+
+	campaign, item, err := svc.FindEligibleGift(ctx, 500)
+	require.NoError(t, err)
+	assert.Nil(t, campaign, "无进行中的满赠活动")
+	assert.Nil(t, item)
+
+	active := createGiftTestCampaign(t, db, `{"min_amount":200,"gift_product_id":42}`)
+	campaign, item, err = svc.FindEligibleGift(ctx, 500)
+	require.NoError(t, err)
+	require.NotNil(t, campaign)
+	assert.Equal(t, active.ID, campaign.ID)
+	assert.Equal(t, int64(42), item.GiftProductID)
+
+	campaign, _, err = svc.FindEligibleGift(ctx, 100)
+	require.NoError(t, err)
+	assert.Nil(t, campaign)
+}
+
+func TestGiftCampaignService_CreateGiftOrder(t *testing.T) {
+	svc, db := setupGiftCampaignTest(t)
+	ctx := context.Background()
+	user := createMarketingTestUser(t, db, "13800138301")
+
+	gift := &models.Product{CategoryID: 1, Name: "赠品水杯", Images: []byte(`["cup.jpg"]`), Price: 30, Stock: 3, IsOnSale: true}
+	require.NoError(t, db.Create(gift).Error)
+	addressID := int64(7)
+	mainOrder := &models.Order{
+		OrderNo:         "M_GIFT_MAIN",
+		UserID:          user.ID,
+		Type:            models.OrderTypeMall,
+		OriginalAmount:  250,
+		ActualAmount:    250,
+		Status:          models.OrderStatusPending,
+		AddressID:       &addressID,
+		AddressSnapshot: json.RawMessage(`{"receiver_name":"张三"}`),
+	}
+	require.NoError(t, db.Create(mainOrder).Error)
+
+	require.NoError(t, svc.CreateGiftOrder(ctx, mainOrder.ID, gift.ID, 2))
+
+	var link models.GiftOrder
+	require.NoError(t, db.Where("main_order_id = ?", mainOrder.ID).First(&link).Error)
+	assert.Nil(t, link.CampaignID)
+	assert.Equal(t, gift.ID, link.ProductID)
+	assert.Equal(t, 2, link.Quantity)
+
+	var giftOrder models.Order
+	require.NoError(t, db.Preload("Items").First(&giftOrder, link.GiftOrderID).Error)
+	assert.Equal(t, user.ID, giftOrder.UserID)
+	assert.Equal(t, models.OrderTypeMall, giftOrder.Type)
+	assert.Equal(t, models.OrderStatusPending, giftOrder.Status)
+	assert.Zero(t, giftOrder.ActualAmount)
+	require.NotNil(t, giftOrder.AddressID)
+	assert.Equal(t, addressID, *giftOrder.AddressID)
+	require.Len(t, giftOrder.Items, 1)
+	assert.Equal(t, "赠品水杯", giftOrder.Items[0].ProductName)
+	assert.Equal(t, 2, giftOrder.Items[0].Quantity)
+	assert.Zero(t, giftOrder.Items[0].Price)
+
+	var product models.Product
+	require.NoError(t, db.First(&product, gift.ID).Error)
+	assert.Equal(t, 1, product.Stock)
+
+	t.Run("同一主订单只赠送一次", func(t *testing.T) {
+		err := svc.CreateGiftOrder(ctx, mainOrder.ID, gift.ID, 1)
+		assert.ErrorIs(t, err, ErrGiftOrderExists)
+	})
+
+	t.Run("赠品库存不足", func(t *testing.T) {
+		other := &models.Order{OrderNo: "M_GIFT_OTHER", UserID: user.ID, Type: models.OrderTypeMall, Status: models.OrderStatusPending}
+		require.NoError(t, db.Create(other).Error)
+
+		err := svc.CreateGiftOrder(ctx, other.ID, gift.ID, 2)
+		assert.ErrorIs(t, err, ErrGiftStockInsufficient)
+
+		var count int64
+		require.NoError(t, db.Model(&models.GiftOrder{}).Where("main_order_id = ?", other.ID).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+}
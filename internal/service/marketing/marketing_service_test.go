@@ -3,6 +3,7 @@ package marketing
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -31,6 +32,7 @@ func setupMarketingTestDB(t *testing.T) *gorm.DB {
 		&models.MemberLevel{},
 		&models.Coupon{},
 		&models.UserCoupon{},
+		&models.CouponTransfer{},
 		&models.Campaign{},
 	))
 
@@ -132,7 +134,7 @@ func setupUserCouponService(db *gorm.DB) *UserCouponService {
 
 func setupCampaignService(db *gorm.DB) *CampaignService {
 	campaignRepo := repository.NewCampaignRepository(db)
-	return NewCampaignService(campaignRepo)
+	return NewCampaignService(db, campaignRepo)
 }
 
 // ================== CouponService Tests ==================
@@ -240,10 +242,10 @@ func TestCouponService_CalculateDiscount(t *testing.T) {
 			MinAmount: 50.0,
 		}
 
-		discount := svc.CalculateDiscount(coupon, 100.0)
+		discount := svc.CalculateDiscount(coupon, 100.0, nil)
 		assert.Equal(t, 10.0, discount)
 
-		discount = svc.CalculateDiscount(coupon, 30.0)
+		discount = svc.CalculateDiscount(coupon, 30.0, nil)
 		assert.Equal(t, 0.0, discount)
 	})
 
@@ -254,7 +256,7 @@ func TestCouponService_CalculateDiscount(t *testing.T) {
 			MinAmount: 50.0,
 		}
 
-		discount := svc.CalculateDiscount(coupon, 100.0)
+		discount := svc.CalculateDiscount(coupon, 100.0, nil)
 		assert.Equal(t, 10.0, discount)
 	})
 
@@ -267,7 +269,7 @@ func TestCouponService_CalculateDiscount(t *testing.T) {
 			MaxDiscount: &maxDiscount,
 		}
 
-		discount := svc.CalculateDiscount(coupon, 150.0)
+		discount := svc.CalculateDiscount(coupon, 150.0, nil)
 		assert.Equal(t, 20.0, discount)
 	})
 }
@@ -295,7 +297,7 @@ func TestCouponService_GetBestCouponForOrder(t *testing.T) {
 		svc.ReceiveCoupon(ctx, coupon1.ID, user.ID)
 		svc.ReceiveCoupon(ctx, coupon2.ID, user.ID)
 
-		bestCoupon, discount, err := svc.GetBestCouponForOrder(ctx, user.ID, models.CouponScopeAll, 150.0)
+		bestCoupon, discount, err := svc.GetBestCouponForOrder(ctx, user.ID, models.CouponScopeAll, 150.0, nil)
 		require.NoError(t, err)
 		assert.NotNil(t, bestCoupon)
 		assert.Equal(t, 20.0, discount)
@@ -305,7 +307,7 @@ func TestCouponService_GetBestCouponForOrder(t *testing.T) {
 	t.Run("无可用优惠券返回nil", func(t *testing.T) {
 		user := createMarketingTestUser(t, db, "13800138011")
 
-		bestCoupon, discount, err := svc.GetBestCouponForOrder(ctx, user.ID, models.CouponScopeAll, 100.0)
+		bestCoupon, discount, err := svc.GetBestCouponForOrder(ctx, user.ID, models.CouponScopeAll, 100.0, nil)
 		require.NoError(t, err)
 		assert.Nil(t, bestCoupon)
 		assert.Equal(t, 0.0, discount)
@@ -560,7 +562,7 @@ func TestCampaignService_CalculateDiscountCampaign(t *testing.T) {
 		createMarketingTestCampaign(t, db, func(c *models.Campaign) {
 			c.Name = "满减测试"
 			c.Type = models.CampaignTypeDiscount
-			c.Rules = rules
+			c.Rules, _ = json.Marshal(rules)
 		})
 
 		// 订单金额150，满足100减10
@@ -576,6 +578,98 @@ func TestCampaignService_CalculateDiscountCampaign(t *testing.T) {
 	})
 }
 
+func TestDiscountRules_EncodeAndDecode(t *testing.T) {
+	t.Run("编码为版本化格式", func(t *testing.T) {
+		raw, err := EncodeDiscountRules([]DiscountRule{{MinAmount: 100, Discount: 10}, {MinAmount: 200, Discount: 30}})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"version":1,"tiers":[{"min_amount":100,"discount":10},{"min_amount":200,"discount":30}]}`, string(raw))
+
+		rules, err := DecodeDiscountRules(raw)
+		require.NoError(t, err)
+		assert.Equal(t, []DiscountRule{{MinAmount: 100, Discount: 10}, {MinAmount: 200, Discount: 30}}, rules)
+	})
+
+	t.Run("校验失败", func(t *testing.T) {
+		invalid := [][]DiscountRule{
+			nil,
+			{{MinAmount: -1, Discount: 10}},
+			{{MinAmount: 100, Discount: 0}},
+			{{MinAmount: 100, Discount: 10}, {MinAmount: 100, Discount: 20}},
+			{{MinAmount: 200, Discount: 30}, {MinAmount: 100, Discount: 10}},
+		}
+		for _, rules := range invalid {
+			_, err := EncodeDiscountRules(rules)
+			assert.ErrorIs(t, err, ErrCampaignRuleInvalid, "%v", rules)
+		}
+	})
+
+	t.Run("兼容旧格式并按门槛排序", func(t *testing.T) {
+		for _, raw := range []string{
+			`{"rules":[{"min_amount":200,"discount":30},{"min_amount":100,"discount":10}]}`,
+			`[{"min_amount":200,"discount":30},{"min_amount":100,"discount":10}]`,
+		} {
+			rules, err := DecodeDiscountRules(json.RawMessage(raw))
+			require.NoError(t, err)
+			assert.Equal(t, []DiscountRule{{MinAmount: 100, Discount: 10}, {MinAmount: 200, Discount: 30}}, rules)
+		}
+
+		rules, err := DecodeDiscountRules(nil)
+		require.NoError(t, err)
+		assert.Empty(t, rules)
+	})
+
+	t.Run("不支持的版本或格式错误", func(t *testing.T) {
+		_, err := DecodeDiscountRules(json.RawMessage(`{"version":2,"tiers":[]}`))
+		assert.ErrorIs(t, err, ErrCampaignRuleInvalid)
+
+		_, err = DecodeDiscountRules(json.RawMessage(`{"rules":"满100减10"}`))
+		assert.ErrorIs(t, err, ErrCampaignRuleInvalid)
+	})
+}
+
+func TestCampaignService_SetDiscountRules(t *testing.T) {
+	db := setupMarketingTestDB(t)
+	svc := setupCampaignService(db)
+	ctx := context.Background()
+
+	campaign := createMarketingTestCampaign(t, db, func(c *models.Campaign) {
+		c.Type = models.CampaignTypeDiscount
+	})
+
+	t.Run("设置后读取档位", func(t *testing.T) {
+		tiers := []DiscountRule{{MinAmount: 100, Discount: 10}, {MinAmount: 300, Discount: 50}}
+		require.NoError(t, svc.SetDiscountRules(ctx, campaign.ID, tiers))
+
+		rules, err := svc.GetDiscountRules(ctx, campaign.ID)
+		require.NoError(t, err)
+		assert.Equal(t, tiers, rules)
+
+		discount, _, err := svc.CalculateDiscountCampaign(ctx, 350)
+		require.NoError(t, err)
+		assert.Equal(t, 50.0, discount)
+	})
+
+	t.Run("非法档位不保存", func(t *testing.T) {
+		err := svc.SetDiscountRules(ctx, campaign.ID, []DiscountRule{{MinAmount: 100, Discount: -5}})
+		assert.ErrorIs(t, err, ErrCampaignRuleInvalid)
+
+		rules, err := svc.GetDiscountRules(ctx, campaign.ID)
+		require.NoError(t, err)
+		assert.Len(t, rules, 2)
+	})
+
+	t.Run("非满减活动和不存在的活动", func(t *testing.T) {
+		other := createMarketingTestCampaign(t, db, func(c *models.Campaign) {
+			c.Type = models.CampaignTypeGift
+		})
+		err := svc.SetDiscountRules(ctx, other.ID, []DiscountRule{{MinAmount: 100, Discount: 10}})
+		assert.ErrorIs(t, err, ErrCampaignRuleInvalid)
+
+		_, err = svc.GetDiscountRules(ctx, 99999)
+		assert.ErrorIs(t, err, ErrCampaignNotFound)
+	})
+}
+
 func TestCampaignService_BuildCampaignItem(t *testing.T) {
 	db := setupMarketingTestDB(t)
 	svc := setupCampaignService(db)
@@ -728,7 +822,7 @@ func TestCouponService_GetUserCouponForOrder(t *testing.T) {
 	userCoupon := createMarketingTestUserCoupon(t, db, user.ID, coupon.ID, models.UserCouponStatusUnused)
 
 	t.Run("获取订单可用的用户优惠券成功", func(t *testing.T) {
-		uc, discount, err := svc.GetUserCouponForOrder(ctx, user.ID, userCoupon.ID, "mall", 100.0)
+		uc, discount, err := svc.GetUserCouponForOrder(ctx, user.ID, userCoupon.ID, "mall", 100.0, nil)
 		require.NoError(t, err)
 		assert.NotNil(t, uc)
 		assert.Equal(t, coupon.ID, uc.CouponID)
@@ -736,14 +830,14 @@ func TestCouponService_GetUserCouponForOrder(t *testing.T) {
 	})
 
 	t.Run("优惠券不存在返回nil", func(t *testing.T) {
-		uc, discount, err := svc.GetUserCouponForOrder(ctx, user.ID, 99999, "mall", 100.0)
+		uc, discount, err := svc.GetUserCouponForOrder(ctx, user.ID, 99999, "mall", 100.0, nil)
 		require.NoError(t, err)
 		assert.Nil(t, uc)
 		assert.Equal(t, 0.0, discount)
 	})
 
 	t.Run("金额未达到门槛返回nil", func(t *testing.T) {
-		uc, discount, err := svc.GetUserCouponForOrder(ctx, user.ID, userCoupon.ID, "mall", 30.0)
+		uc, discount, err := svc.GetUserCouponForOrder(ctx, user.ID, userCoupon.ID, "mall", 30.0, nil)
 		require.NoError(t, err)
 		assert.Nil(t, uc)
 		assert.Equal(t, 0.0, discount)
@@ -764,13 +858,13 @@ func TestUserCouponService_GetAvailableCouponsForOrder(t *testing.T) {
 	createMarketingTestUserCoupon(t, db, user.ID, coupon.ID, models.UserCouponStatusUnused)
 
 	t.Run("获取订单可用的优惠券列表", func(t *testing.T) {
-		list, err := svc.GetAvailableCouponsForOrder(ctx, user.ID, "mall", 100.0)
+		list, err := svc.GetAvailableCouponsForOrder(ctx, user.ID, "mall", 100.0, nil)
 		require.NoError(t, err)
 		assert.NotEmpty(t, list)
 	})
 
 	t.Run("订单金额太小无可用优惠券", func(t *testing.T) {
-		list, err := svc.GetAvailableCouponsForOrder(ctx, user.ID, "mall", 10.0)
+		list, err := svc.GetAvailableCouponsForOrder(ctx, user.ID, "mall", 10.0, nil)
 		require.NoError(t, err)
 		// 可能为空或有满足条件的
 		assert.NotNil(t, list)
@@ -995,3 +1089,338 @@ func TestUserCouponService_calculateDiscount_AllTypes(t *testing.T) {
 		assert.Equal(t, 0.0, discount)
 	})
 }
+
+func TestIsCouponApplicable(t *testing.T) {
+	scopes := []string{
+		models.CouponScopeAll,
+		models.CouponScopeRental,
+		models.CouponScopeMall,
+		models.CouponScopeHotel,
+		models.CouponScopeCategory,
+		models.CouponScopeProduct,
+	}
+	orderScopes := []string{models.OrderTypeRental, models.OrderTypeMall, models.OrderTypeHotel}
+
+	for _, scope := range scopes {
+		for _, orderScope := range orderScopes {
+			want := scope == models.CouponScopeAll || scope == orderScope
+			t.Run(fmt.Sprintf("%s券用于%s订单", scope, orderScope), func(t *testing.T) {
+				coupon := &models.Coupon{ApplicableScope: scope}
+				assert.Equal(t, want, isCouponApplicable(coupon, orderScope))
+			})
+		}
+	}
+
+	tests := []struct {
+		name       string
+		coupon     *models.Coupon
+		orderScope string
+		want       bool
+	}{
+		{"优惠券为空", nil, models.OrderTypeMall, false},
+		{"通用券用于空范围", &models.Coupon{ApplicableScope: models.CouponScopeAll}, "", true},
+		{"指定范围券用于空范围", &models.Coupon{ApplicableScope: models.CouponScopeMall}, "", false},
+		{"大小写不同不匹配", &models.Coupon{ApplicableScope: "Mall"}, models.OrderTypeMall, false},
+		{"包含关系不匹配", &models.Coupon{ApplicableScope: "all_mall"}, models.OrderTypeMall, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isCouponApplicable(tt.coupon, tt.orderScope))
+		})
+	}
+}
+
+func TestCoupon_ScopeFilteringForOrder(t *testing.T) {
+	db := setupMarketingTestDB(t)
+	couponSvc := setupCouponService(db)
+	userCouponSvc := setupUserCouponService(db)
+	ctx := context.Background()
+
+	user := createMarketingTestUser(t, db, "13800138099")
+	for _, tc := range []struct {
+		scope string
+		value float64
+	}{
+		{models.CouponScopeAll, 5.0},
+		{models.CouponScopeRental, 8.0},
+		{models.CouponScopeHotel, 20.0},
+	} {
+		coupon := createMarketingTestCoupon(t, db, func(c *models.Coupon) {
+			c.Name = "范围券-" + tc.scope
+			c.ApplicableScope = tc.scope
+			c.Value = tc.value
+			c.MinAmount = 0
+		})
+		createMarketingTestUserCoupon(t, db, user.ID, coupon.ID, models.UserCouponStatusUnused)
+	}
+
+	t.Run("租借订单只能用通用券和租借券", func(t *testing.T) {
+		best, discount, err := couponSvc.GetBestCouponForOrder(ctx, user.ID, models.OrderTypeRental, 100.0, nil)
+		require.NoError(t, err)
+		require.NotNil(t, best)
+		assert.Equal(t, models.CouponScopeRental, best.Coupon.ApplicableScope)
+		assert.Equal(t, 8.0, discount)
+
+		list, err := userCouponSvc.GetAvailableCouponsForOrder(ctx, user.ID, models.OrderTypeRental, 100.0, nil)
+		require.NoError(t, err)
+		assert.Len(t, list, 2)
+		for _, item := range list {
+			assert.NotEqual(t, models.CouponScopeHotel, item.ApplicableScope)
+		}
+	})
+
+	t.Run("商城订单只能用通用券", func(t *testing.T) {
+		best, discount, err := couponSvc.GetBestCouponForOrder(ctx, user.ID, models.OrderTypeMall, 100.0, nil)
+		require.NoError(t, err)
+		require.NotNil(t, best)
+		assert.Equal(t, models.CouponScopeAll, best.Coupon.ApplicableScope)
+		assert.Equal(t, 5.0, discount)
+
+		list, err := userCouponSvc.GetAvailableCouponsForOrder(ctx, user.ID, models.OrderTypeMall, 100.0, nil)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		assert.Equal(t, models.CouponScopeAll, list[0].ApplicableScope)
+	})
+
+	t.Run("酒店订单选择最优的酒店券", func(t *testing.T) {
+		best, discount, err := couponSvc.GetBestCouponForOrder(ctx, user.ID, models.OrderTypeHotel, 100.0, nil)
+		require.NoError(t, err)
+		require.NotNil(t, best)
+		assert.Equal(t, models.CouponScopeHotel, best.Coupon.ApplicableScope)
+		assert.Equal(t, 20.0, discount)
+	})
+}
+
+func TestCoupon_ApplicableItems(t *testing.T) {
+	db := setupMarketingTestDB(t)
+	couponSvc := setupCouponService(db)
+	userCouponSvc := setupUserCouponService(db)
+	ctx := context.Background()
+
+	// 购物车：充电宝（分类 1）60 元，数据线（分类 2）40 元
+	cart := []*OrderLineItem{
+		{ProductID: 11, CategoryID: 1, Subtotal: 60},
+		{ProductID: 21, CategoryID: 2, Subtotal: 40},
+	}
+	powerBanks := &models.CouponApplicableItems{CategoryIDs: []int64{1}}
+
+	t.Run("混合购物车只按命中商品小计计算门槛", func(t *testing.T) {
+		coupon := &models.Coupon{Type: models.CouponTypeFixed, Value: 20, MinAmount: 50, ApplicableItems: powerBanks}
+		assert.Equal(t, 20.0, couponSvc.CalculateDiscount(coupon, 100, cart))
+
+		coupon.MinAmount = 80
+		assert.Equal(t, 0.0, couponSvc.CalculateDiscount(coupon, 100, cart))
+	})
+
+	t.Run("折扣券按命中商品小计计算后再限制最大优惠", func(t *testing.T) {
+		coupon := &models.Coupon{Type: models.CouponTypePercent, Value: 0.1, ApplicableItems: powerBanks}
+		assert.InDelta(t, 6.0, couponSvc.CalculateDiscount(coupon, 100, cart), 0.001)
+
+		maxDiscount := 10.0
+		coupon.Value = 0.2
+		coupon.MaxDiscount = &maxDiscount
+		assert.InDelta(t, 10.0, couponSvc.CalculateDiscount(coupon, 100, cart), 0.001)
+	})
+
+	t.Run("按商品ID限定", func(t *testing.T) {
+		coupon := &models.Coupon{Type: models.CouponTypePercent, Value: 0.5,
+			ApplicableItems: &models.CouponApplicableItems{ProductIDs: []int64{21}}}
+		assert.InDelta(t, 20.0, couponSvc.CalculateDiscount(coupon, 100, cart), 0.001)
+	})
+
+	t.Run("订单金额已扣除其他优惠时按占比折算", func(t *testing.T) {
+		coupon := &models.Coupon{Type: models.CouponTypePercent, Value: 0.5, ApplicableItems: powerBanks}
+		assert.InDelta(t, 24.0, couponSvc.CalculateDiscount(coupon, 80, cart), 0.001)
+	})
+
+	t.Run("没有命中商品或未提供商品组成时不可用", func(t *testing.T) {
+		coupon := &models.Coupon{Type: models.CouponTypeFixed, Value: 5,
+			ApplicableItems: &models.CouponApplicableItems{CategoryIDs: []int64{9}}}
+		assert.Equal(t, 0.0, couponSvc.CalculateDiscount(coupon, 100, cart))
+
+		coupon.ApplicableItems = powerBanks
+		assert.Equal(t, 0.0, couponSvc.CalculateDiscount(coupon, 100, nil))
+	})
+
+	user := createMarketingTestUser(t, db, "13800138100")
+	general := createMarketingTestCoupon(t, db, func(c *models.Coupon) {
+		c.Name = "全场券"
+		c.Value = 8
+		c.MinAmount = 0
+	})
+	restricted := createMarketingTestCoupon(t, db, func(c *models.Coupon) {
+		c.Name = "充电宝满50减20"
+		c.Value = 20
+		c.MinAmount = 50
+		c.ApplicableItems = powerBanks
+	})
+	createMarketingTestUserCoupon(t, db, user.ID, general.ID, models.UserCouponStatusUnused)
+	createMarketingTestUserCoupon(t, db, user.ID, restricted.ID, models.UserCouponStatusUnused)
+
+	t.Run("限定商品保存后可读取", func(t *testing.T) {
+		var saved models.Coupon
+		require.NoError(t, db.First(&saved, restricted.ID).Error)
+		require.NotNil(t, saved.ApplicableItems)
+		assert.Equal(t, []int64{1}, saved.ApplicableItems.CategoryIDs)
+
+		var unrestricted models.Coupon
+		require.NoError(t, db.First(&unrestricted, general.ID).Error)
+		assert.True(t, unrestricted.ApplicableItems.IsEmpty())
+	})
+
+	t.Run("最优券考虑商品组成", func(t *testing.T) {
+		best, discount, err := couponSvc.GetBestCouponForOrder(ctx, user.ID, models.OrderTypeMall, 100, cart)
+		require.NoError(t, err)
+		require.NotNil(t, best)
+		assert.Equal(t, restricted.ID, best.CouponID)
+		assert.Equal(t, 20.0, discount)
+
+		onlyCables := []*OrderLineItem{{ProductID: 21, CategoryID: 2, Subtotal: 100}}
+		best, discount, err = couponSvc.GetBestCouponForOrder(ctx, user.ID, models.OrderTypeMall, 100, onlyCables)
+		require.NoError(t, err)
+		require.NotNil(t, best)
+		assert.Equal(t, general.ID, best.CouponID)
+		assert.Equal(t, 8.0, discount)
+	})
+
+	t.Run("可用券列表过滤不满足商品限定的券", func(t *testing.T) {
+		list, err := userCouponSvc.GetAvailableCouponsForOrder(ctx, user.ID, models.OrderTypeMall, 100, cart)
+		require.NoError(t, err)
+		assert.Len(t, list, 2)
+
+		smallCart := []*OrderLineItem{
+			{ProductID: 11, CategoryID: 1, Subtotal: 30},
+			{ProductID: 21, CategoryID: 2, Subtotal: 70},
+		}
+		list, err = userCouponSvc.GetAvailableCouponsForOrder(ctx, user.ID, models.OrderTypeMall, 100, smallCart)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		assert.Equal(t, general.ID, list[0].CouponID)
+	})
+}
+
+func TestUserCouponService_GetCartLineItems(t *testing.T) {
+	db := setupMarketingTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Product{}, &models.ProductSku{}, &models.CartItem{}))
+	svc := setupUserCouponService(db)
+
+	user := createMarketingTestUser(t, db, "13800138101")
+	product := &models.Product{CategoryID: 3, Name: "充电宝", Images: []byte(`[]`), Price: 50}
+	require.NoError(t, db.Create(product).Error)
+	sku := &models.ProductSku{ProductID: product.ID, SkuCode: "PB-20000", Attributes: []byte(`{}`), Price: 80}
+	require.NoError(t, db.Create(sku).Error)
+
+	require.NoError(t, db.Create(&models.CartItem{UserID: user.ID, ProductID: product.ID, Quantity: 2, Selected: true}).Error)
+	require.NoError(t, db.Create(&models.CartItem{UserID: user.ID, ProductID: product.ID, SkuID: &sku.ID, Quantity: 1, Selected: true}).Error)
+	unselected := &models.CartItem{UserID: user.ID, ProductID: product.ID, Quantity: 5, Selected: true}
+	require.NoError(t, db.Create(unselected).Error)
+	require.NoError(t, db.Model(unselected).Update("selected", false).Error)
+
+	items, err := svc.GetCartLineItems(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	var total float64
+	for _, item := range items {
+		assert.Equal(t, product.ID, item.ProductID)
+		assert.Equal(t, int64(3), item.CategoryID)
+		total += item.Subtotal
+	}
+	assert.Equal(t, 180.0, total)
+}
+
+func TestCouponService_BulkDistribute_PerUserLimit(t *testing.T) {
+	db := setupMarketingTestDB(t)
+	svc := setupCouponService(db)
+	ctx := context.Background()
+
+	coupon := createMarketingTestCoupon(t, db, func(c *models.Coupon) {
+		c.PerUserLimit = 2
+	})
+	user1 := createMarketingTestUser(t, db, "13800138201")
+	user2 := createMarketingTestUser(t, db, "13800138202")
+	user3 := createMarketingTestUser(t, db, "13800138203")
+
+	// user1 已领取 2 张，达到上限
+	createMarketingTestUserCoupon(t, db, user1.ID, coupon.ID, models.UserCouponStatusUnused)
+	createMarketingTestUserCoupon(t, db, user1.ID, coupon.ID, models.UserCouponStatusUnused)
+	// user2 已领取 1 张，列表中出现两次，只有一次成功
+	createMarketingTestUserCoupon(t, db, user2.ID, coupon.ID, models.UserCouponStatusUnused)
+	db.Model(&models.Coupon{}).Where("id = ?", coupon.ID).Update("issued_count", 3)
+
+	succeeded, failed, errs := svc.BulkDistribute(ctx, coupon.ID, []int64{user1.ID, user2.ID, user2.ID, user3.ID}, 9)
+	assert.Equal(t, 2, succeeded)
+	assert.Equal(t, 2, failed)
+	require.Len(t, errs, 2)
+	for _, err := range errs {
+		var userErr *BulkDistributeError
+		require.ErrorAs(t, err, &userErr)
+		assert.ErrorIs(t, err, ErrCouponLimitExceeded)
+	}
+
+	var updated models.Coupon
+	db.First(&updated, coupon.ID)
+	assert.Equal(t, 5, updated.ReceivedCount)
+
+	var issued []models.UserCoupon
+	db.Where("coupon_id = ? AND issued_by = ?", coupon.ID, 9).Find(&issued)
+	require.Len(t, issued, 2)
+	for _, uc := range issued {
+		assert.Contains(t, []int64{user2.ID, user3.ID}, uc.UserID)
+	}
+}
+
+func TestCouponService_BulkDistribute_StockExhaustedMidBatch(t *testing.T) {
+	db := setupMarketingTestDB(t)
+	svc := setupCouponService(db)
+	ctx := context.Background()
+
+	coupon := createMarketingTestCoupon(t, db, func(c *models.Coupon) {
+		c.TotalCount = 150
+		c.PerUserLimit = 1
+	})
+
+	userIDs := make([]int64, 0, 250)
+	for i := int64(1); i <= 250; i++ {
+		userIDs = append(userIDs, 1000+i)
+	}
+
+	succeeded, failed, errs := svc.BulkDistribute(ctx, coupon.ID, userIDs, 9)
+	assert.Equal(t, 150, succeeded)
+	assert.Equal(t, 100, failed)
+	require.Len(t, errs, 100)
+	for _, err := range errs {
+		assert.ErrorIs(t, err, ErrCouponSoldOut)
+	}
+	// 第二批中前 50 个用户成功，之后的用户失败
+	var firstErr *BulkDistributeError
+	require.ErrorAs(t, errs[0], &firstErr)
+	assert.Equal(t, userIDs[150], firstErr.UserID)
+
+	var updated models.Coupon
+	db.First(&updated, coupon.ID)
+	assert.Equal(t, 150, updated.ReceivedCount)
+
+	var count int64
+	db.Model(&models.UserCoupon{}).Where("coupon_id = ?", coupon.ID).Count(&count)
+	assert.Equal(t, int64(150), count)
+}
+
+func TestCouponService_BulkDistribute_CouponNotReceivable(t *testing.T) {
+	db := setupMarketingTestDB(t)
+	svc := setupCouponService(db)
+	ctx := context.Background()
+
+	succeeded, failed, errs := svc.BulkDistribute(ctx, 99999, []int64{1, 2}, 9)
+	assert.Equal(t, 0, succeeded)
+	assert.Equal(t, 2, failed)
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], ErrCouponNotFound)
+
+	coupon := createMarketingTestCoupon(t, db)
+	db.Model(coupon).Update("status", models.CouponStatusDisabled)
+	_, failed, errs = svc.BulkDistribute(ctx, coupon.ID, []int64{1, 2}, 9)
+	assert.Equal(t, 2, failed)
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], ErrCouponNotActive)
+}
@@ -42,23 +42,24 @@ type UserCouponListResponse struct {
 
 // UserCouponItem 用户优惠券项
 type UserCouponItem struct {
-	ID              int64      `json:"id"`
-	CouponID        int64      `json:"coupon_id"`
-	CouponName      string     `json:"coupon_name"`
-	CouponType      string     `json:"coupon_type"`
-	Value           float64    `json:"value"`
-	MinAmount       float64    `json:"min_amount"`
-	MaxDiscount     *float64   `json:"max_discount,omitempty"`
-	ApplicableScope string     `json:"applicable_scope"`
-	Description     *string    `json:"description,omitempty"`
-	Status          int8       `json:"status"`
-	StatusText      string     `json:"status_text"`
-	ExpiredAt       time.Time  `json:"expired_at"`
-	ReceivedAt      time.Time  `json:"received_at"`
-	UsedAt          *time.Time `json:"used_at,omitempty"`
-	OrderID         *int64     `json:"order_id,omitempty"`
-	IsAvailable     bool       `json:"is_available"`
-	DaysRemaining   int        `json:"days_remaining"` // 剩余天数
+	ID              int64                         `json:"id"`
+	CouponID        int64                         `json:"coupon_id"`
+	CouponName      string                        `json:"coupon_name"`
+	CouponType      string                        `json:"coupon_type"`
+	Value           float64                       `json:"value"`
+	MinAmount       float64                       `json:"min_amount"`
+	MaxDiscount     *float64                      `json:"max_discount,omitempty"`
+	ApplicableScope string                        `json:"applicable_scope"`
+	ApplicableItems *models.CouponApplicableItems `json:"applicable_items,omitempty"`
+	Description     *string                       `json:"description,omitempty"`
+	Status          int8                          `json:"status"`
+	StatusText      string                        `json:"status_text"`
+	ExpiredAt       time.Time                     `json:"expired_at"`
+	ReceivedAt      time.Time                     `json:"received_at"`
+	UsedAt          *time.Time                    `json:"used_at,omitempty"`
+	OrderID         *int64                        `json:"order_id,omitempty"`
+	IsAvailable     bool                          `json:"is_available"`
+	DaysRemaining   int                           `json:"days_remaining"` // 剩余天数
 }
 
 // GetUserCoupons 获取用户优惠券列表
@@ -113,7 +114,8 @@ func (s *UserCouponService) GetAvailableCoupons(ctx context.Context, userID int6
 }
 
 // GetAvailableCouponsForOrder 获取用户可用于订单的优惠券列表
-func (s *UserCouponService) GetAvailableCouponsForOrder(ctx context.Context, userID int64, orderType string, orderAmount float64) ([]*UserCouponItem, error) {
+// items 为订单商品组成，限定商品/分类的优惠券在命中商品的小计未达到使用门槛时不返回
+func (s *UserCouponService) GetAvailableCouponsForOrder(ctx context.Context, userID int64, orderType string, orderAmount float64, items []*OrderLineItem) ([]*UserCouponItem, error) {
 	userCoupons, err := s.userCouponRepo.ListAvailableForOrder(ctx, userID, orderType, orderAmount)
 	if err != nil {
 		return nil, err
@@ -122,17 +124,51 @@ func (s *UserCouponService) GetAvailableCouponsForOrder(ctx context.Context, use
 	now := time.Now()
 	list := make([]*UserCouponItem, 0, len(userCoupons))
 	for _, uc := range userCoupons {
-		item := s.buildUserCouponItem(uc, now)
-		if uc.Coupon != nil {
-			// 计算可优惠金额
-			item.Value = s.calculateDiscount(uc.Coupon, orderAmount)
+		if !isCouponApplicable(uc.Coupon, orderType) {
+			continue
+		}
+		amount, ok := eligibleAmount(uc.Coupon, orderAmount, items)
+		if !ok || amount < uc.Coupon.MinAmount {
+			continue
 		}
+		item := s.buildUserCouponItem(uc, now)
+		// 计算可优惠金额
+		item.Value = s.calculateDiscount(uc.Coupon, amount)
 		list = append(list, item)
 	}
 
 	return list, nil
 }
 
+// GetCartLineItems 获取用户购物车中已选中商品的组成，用于匹配限定商品/分类的优惠券
+func (s *UserCouponService) GetCartLineItems(ctx context.Context, userID int64) ([]*OrderLineItem, error) {
+	var cartItems []*models.CartItem
+	if err := s.db.WithContext(ctx).
+		Preload("Product").
+		Preload("Sku").
+		Where("user_id = ? AND selected = ?", userID, true).
+		Find(&cartItems).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]*OrderLineItem, 0, len(cartItems))
+	for _, ci := range cartItems {
+		if ci.Product == nil {
+			continue
+		}
+		price := ci.Product.Price
+		if ci.Sku != nil {
+			price = ci.Sku.Price // SKU 价格覆盖商品价格
+		}
+		items = append(items, &OrderLineItem{
+			ProductID:  ci.ProductID,
+			CategoryID: ci.Product.CategoryID,
+			Subtotal:   price * float64(ci.Quantity),
+		})
+	}
+	return items, nil
+}
+
 // GetUserCouponDetail 获取用户优惠券详情
 func (s *UserCouponService) GetUserCouponDetail(ctx context.Context, userID, userCouponID int64) (*UserCouponItem, error) {
 	userCoupon, err := s.userCouponRepo.GetByIDWithCoupon(ctx, userCouponID)
@@ -244,6 +280,76 @@ func (s *UserCouponService) UnuseCoupon(ctx context.Context, userCouponID int64)
 	})
 }
 
+// TransferCoupon 将未使用且未过期的用户优惠券转赠给其他用户
+// 受赠用户持有该优惠券的数量不能超过每人限领数量，转赠成功后记录转赠流水
+func (s *UserCouponService) TransferCoupon(ctx context.Context, fromUserID, toUserID, userCouponID int64) error {
+	if fromUserID == toUserID {
+		return ErrCouponTransferSelf
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var uc models.UserCoupon
+		if err := tx.Preload("Coupon").First(&uc, userCouponID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrUserCouponNotFound
+			}
+			return err
+		}
+		if uc.UserID != fromUserID {
+			return ErrUserCouponNotFound
+		}
+
+		// 检查状态
+		if uc.Status == models.UserCouponStatusUsed {
+			return ErrUserCouponUsed
+		}
+		if uc.Status != models.UserCouponStatusUnused || time.Now().After(uc.ExpiredAt) {
+			return ErrUserCouponExpired
+		}
+		if uc.Coupon == nil {
+			return ErrCouponNotFound
+		}
+
+		// 检查受赠用户
+		var target int64
+		if err := tx.Model(&models.User{}).Where("id = ?", toUserID).Count(&target).Error; err != nil {
+			return err
+		}
+		if target == 0 {
+			return ErrCouponTransferUserAbsent
+		}
+
+		// 检查受赠用户领取数量
+		var receivedCount int64
+		if err := tx.Model(&models.UserCoupon{}).
+			Where("user_id = ? AND coupon_id = ?", toUserID, uc.CouponID).
+			Count(&receivedCount).Error; err != nil {
+			return err
+		}
+		if receivedCount >= int64(uc.Coupon.PerUserLimit) {
+			return ErrCouponLimitExceeded
+		}
+
+		// 转移归属，条件更新防止并发使用或重复转赠
+		result := tx.Model(&models.UserCoupon{}).
+			Where("id = ? AND user_id = ? AND status = ?", uc.ID, fromUserID, models.UserCouponStatusUnused).
+			Update("user_id", toUserID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrUserCouponUsed
+		}
+
+		return tx.Create(&models.CouponTransfer{
+			UserCouponID: uc.ID,
+			CouponID:     uc.CouponID,
+			FromUserID:   fromUserID,
+			ToUserID:     toUserID,
+		}).Error
+	})
+}
+
 // ExpireUserCoupons 过期处理用户优惠券
 func (s *UserCouponService) ExpireUserCoupons(ctx context.Context) (int64, error) {
 	return s.userCouponRepo.BatchMarkAsExpired(ctx)
@@ -338,6 +444,7 @@ func (s *UserCouponService) buildUserCouponItem(uc *models.UserCoupon, now time.
 		item.MinAmount = uc.Coupon.MinAmount
 		item.MaxDiscount = uc.Coupon.MaxDiscount
 		item.ApplicableScope = uc.Coupon.ApplicableScope
+		item.ApplicableItems = uc.Coupon.ApplicableItems
 		item.Description = uc.Coupon.Description
 	}
 
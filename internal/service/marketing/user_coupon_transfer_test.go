@@ -0,0 +1,107 @@
+package marketing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+func TestUserCouponService_TransferCoupon(t *testing.T) {
+	db := setupMarketingTestDB(t)
+	svc := setupUserCouponService(db)
+	ctx := context.Background()
+
+	from := createMarketingTestUser(t, db, "13800138501")
+	to := createMarketingTestUser(t, db, "13800138502")
+	coupon := createMarketingTestCoupon(t, db)
+	uc := createMarketingTestUserCoupon(t, db, from.ID, coupon.ID, models.UserCouponStatusUnused)
+
+	require.NoError(t, svc.TransferCoupon(ctx, from.ID, to.ID, uc.ID))
+
+	var transferred models.UserCoupon
+	require.NoError(t, db.First(&transferred, uc.ID).Error)
+	assert.Equal(t, to.ID, transferred.UserID)
+	assert.Equal(t, int8(models.UserCouponStatusUnused), transferred.Status)
+
+	var record models.CouponTransfer
+	require.NoError(t, db.Where("user_coupon_id = ?", uc.ID).First(&record).Error)
+	assert.Equal(t, coupon.ID, record.CouponID)
+	assert.Equal(t, from.ID, record.FromUserID)
+	assert.Equal(t, to.ID, record.ToUserID)
+
+	// 转出后原用户不再持有
+	err := svc.TransferCoupon(ctx, from.ID, to.ID, uc.ID)
+	assert.ErrorIs(t, err, ErrUserCouponNotFound)
+}
+
+func TestUserCouponService_TransferCoupon_LimitExceeded(t *testing.T) {
+	db := setupMarketingTestDB(t)
+	svc := setupUserCouponService(db)
+	ctx := context.Background()
+
+	from := createMarketingTestUser(t, db, "13800138511")
+	to := createMarketingTestUser(t, db, "13800138512")
+	coupon := createMarketingTestCoupon(t, db, func(c *models.Coupon) { c.PerUserLimit = 2 })
+	uc := createMarketingTestUserCoupon(t, db, from.ID, coupon.ID, models.UserCouponStatusUnused)
+
+	// 受赠用户已持有 2 张（含已使用），再转入会超过每人限领数量
+	createMarketingTestUserCoupon(t, db, to.ID, coupon.ID, models.UserCouponStatusUnused)
+	createMarketingTestUserCoupon(t, db, to.ID, coupon.ID, models.UserCouponStatusUsed)
+
+	err := svc.TransferCoupon(ctx, from.ID, to.ID, uc.ID)
+	assert.ErrorIs(t, err, ErrCouponLimitExceeded)
+
+	var unchanged models.UserCoupon
+	require.NoError(t, db.First(&unchanged, uc.ID).Error)
+	assert.Equal(t, from.ID, unchanged.UserID)
+
+	var count int64
+	require.NoError(t, db.Model(&models.CouponTransfer{}).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestUserCouponService_TransferCoupon_Invalid(t *testing.T) {
+	db := setupMarketingTestDB(t)
+	svc := setupUserCouponService(db)
+	ctx := context.Background()
+
+	from := createMarketingTestUser(t, db, "13800138521")
+	to := createMarketingTestUser(t, db, "13800138522")
+	coupon := createMarketingTestCoupon(t, db)
+
+	t.Run("已使用", func(t *testing.T) {
+		used := createMarketingTestUserCoupon(t, db, from.ID, coupon.ID, models.UserCouponStatusUsed)
+		err := svc.TransferCoupon(ctx, from.ID, to.ID, used.ID)
+		assert.ErrorIs(t, err, ErrUserCouponUsed)
+	})
+
+	t.Run("已过期", func(t *testing.T) {
+		expired := createMarketingTestUserCoupon(t, db, from.ID, coupon.ID, models.UserCouponStatusUnused)
+		require.NoError(t, db.Model(expired).Update("expired_at", time.Now().Add(-time.Hour)).Error)
+		err := svc.TransferCoupon(ctx, from.ID, to.ID, expired.ID)
+		assert.ErrorIs(t, err, ErrUserCouponExpired)
+	})
+
+	t.Run("非本人优惠券", func(t *testing.T) {
+		others := createMarketingTestUserCoupon(t, db, to.ID, coupon.ID, models.UserCouponStatusUnused)
+		err := svc.TransferCoupon(ctx, from.ID, to.ID, others.ID)
+		assert.ErrorIs(t, err, ErrUserCouponNotFound)
+	})
+
+	t.Run("转赠给自己", func(t *testing.T) {
+		own := createMarketingTestUserCoupon(t, db, from.ID, coupon.ID, models.UserCouponStatusUnused)
+		err := svc.TransferCoupon(ctx, from.ID, from.ID, own.ID)
+		assert.ErrorIs(t, err, ErrCouponTransferSelf)
+	})
+
+	t.Run("受赠用户不存在", func(t *testing.T) {
+		own := createMarketingTestUserCoupon(t, db, from.ID, coupon.ID, models.UserCouponStatusUnused)
+		err := svc.TransferCoupon(ctx, from.ID, 999999, own.ID)
+		assert.ErrorIs(t, err, ErrCouponTransferUserAbsent)
+	})
+}
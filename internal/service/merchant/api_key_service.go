@@ -0,0 +1,224 @@
+// Package merchant 提供商户自助门户相关服务
+package merchant
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/crypto"
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// 密钥长度（随机字节数）
+const (
+	apiKeyIDBytes     = 12
+	apiKeySecretBytes = 32
+	apiKeyIDPrefix    = "mk_"
+)
+
+// errEncryptorMissing 未配置 AES 密钥时无法保存或还原签名密钥
+var errEncryptorMissing = appErrors.ErrInternalError.WithMessage("未配置加密密钥，商户 API 密钥不可用")
+
+// MerchantAPIKeyService 商户开放接口密钥服务
+// 负责密钥的签发、轮换和吊销，签名密钥仅在签发时返回一次，库中以 AES 加密保存
+type MerchantAPIKeyService struct {
+	keyRepo      *repository.MerchantAPIKeyRepository
+	merchantRepo *repository.MerchantRepository
+	aes          *crypto.AES
+	now          func() time.Time
+}
+
+// NewMerchantAPIKeyService 创建商户开放接口密钥服务
+func NewMerchantAPIKeyService(keyRepo *repository.MerchantAPIKeyRepository, merchantRepo *repository.MerchantRepository, aes *crypto.AES) *MerchantAPIKeyService {
+	return &MerchantAPIKeyService{
+		keyRepo:      keyRepo,
+		merchantRepo: merchantRepo,
+		aes:          aes,
+		now:          time.Now,
+	}
+}
+
+// IssueAPIKeyRequest 签发密钥请求
+type IssueAPIKeyRequest struct {
+	Scopes []string `json:"scopes"` // 为空时授予全部范围
+}
+
+// IssuedAPIKey 新签发的密钥，Secret 仅返回这一次
+type IssuedAPIKey struct {
+	ID         int64     `json:"id"`
+	MerchantID int64     `json:"merchant_id"`
+	KeyID      string    `json:"key_id"`
+	Secret     string    `json:"secret"`
+	Scopes     []string  `json:"scopes"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// IssueKey 为商户签发新密钥
+func (s *MerchantAPIKeyService) IssueKey(ctx context.Context, merchantID int64, req *IssueAPIKeyRequest) (*IssuedAPIKey, error) {
+	if _, err := s.merchantRepo.GetByID(ctx, merchantID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrMerchantNotFound
+		}
+		return nil, err
+	}
+
+	var scopes []string
+	if req != nil {
+		scopes = req.Scopes
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(ctx, merchantID, scopes)
+}
+
+// RotateKey 轮换密钥：签发相同授权范围的新密钥并立即吊销旧密钥
+func (s *MerchantAPIKeyService) RotateKey(ctx context.Context, merchantID, id int64) (*IssuedAPIKey, error) {
+	old, err := s.getMerchantKey(ctx, merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !old.IsActive() {
+		return nil, appErrors.ErrMerchantAPIKeyRevoked
+	}
+
+	issued, err := s.issue(ctx, merchantID, old.ScopeList())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.keyRepo.Revoke(ctx, old.ID, s.now()); err != nil {
+		return nil, err
+	}
+	return issued, nil
+}
+
+// RevokeKey 吊销密钥，吊销后使用该密钥的请求立即失效
+func (s *MerchantAPIKeyService) RevokeKey(ctx context.Context, merchantID, id int64) error {
+	key, err := s.getMerchantKey(ctx, merchantID, id)
+	if err != nil {
+		return err
+	}
+	if !key.IsActive() {
+		return nil
+	}
+	return s.keyRepo.Revoke(ctx, key.ID, s.now())
+}
+
+// ListKeys 获取商户的密钥列表（不含签名密钥）
+func (s *MerchantAPIKeyService) ListKeys(ctx context.Context, merchantID int64) ([]*models.MerchantAPIKey, error) {
+	return s.keyRepo.ListByMerchant(ctx, merchantID)
+}
+
+// ResolveAPIKey 根据密钥标识返回密钥及签名密钥原文，密钥不存在时返回 nil
+func (s *MerchantAPIKeyService) ResolveAPIKey(ctx context.Context, keyID string) (*models.MerchantAPIKey, string, error) {
+	key, err := s.keyRepo.GetByKeyID(ctx, keyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+	if s.aes == nil {
+		return nil, "", errEncryptorMissing
+	}
+
+	secret, err := s.aes.Decrypt(key.SecretEncrypted)
+	if err != nil {
+		return nil, "", err
+	}
+	return key, secret, nil
+}
+
+// MarkAPIKeyUsed 记录密钥最近使用时间，失败不影响请求
+func (s *MerchantAPIKeyService) MarkAPIKeyUsed(ctx context.Context, key *models.MerchantAPIKey) {
+	_ = s.keyRepo.TouchLastUsed(ctx, key.ID, s.now())
+}
+
+// issue 生成并保存密钥
+func (s *MerchantAPIKeyService) issue(ctx context.Context, merchantID int64, scopes []string) (*IssuedAPIKey, error) {
+	if s.aes == nil {
+		return nil, errEncryptorMissing
+	}
+
+	idBytes, err := crypto.GenerateRandomBytes(apiKeyIDBytes)
+	if err != nil {
+		return nil, err
+	}
+	secretBytes, err := crypto.GenerateRandomBytes(apiKeySecretBytes)
+	if err != nil {
+		return nil, err
+	}
+	secret := hex.EncodeToString(secretBytes)
+
+	encrypted, err := s.aes.Encrypt(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.MerchantAPIKey{
+		MerchantID:      merchantID,
+		KeyID:           apiKeyIDPrefix + hex.EncodeToString(idBytes),
+		SecretEncrypted: encrypted,
+		Scopes:          strings.Join(scopes, ","),
+		Status:          models.MerchantAPIKeyStatusActive,
+	}
+	if err := s.keyRepo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+
+	return &IssuedAPIKey{
+		ID:         key.ID,
+		MerchantID: key.MerchantID,
+		KeyID:      key.KeyID,
+		Secret:     secret,
+		Scopes:     scopes,
+		CreatedAt:  key.CreatedAt,
+	}, nil
+}
+
+// getMerchantKey 获取属于指定商户的密钥
+func (s *MerchantAPIKeyService) getMerchantKey(ctx context.Context, merchantID, id int64) (*models.MerchantAPIKey, error) {
+	key, err := s.keyRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrMerchantAPIKeyNotFound
+		}
+		return nil, err
+	}
+	if key.MerchantID != merchantID {
+		return nil, appErrors.ErrMerchantAPIKeyNotFound
+	}
+	return key, nil
+}
+
+// normalizeScopes 校验并去重授权范围，为空时授予全部范围
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return append([]string{}, models.MerchantAPIScopes...), nil
+	}
+
+	requested := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		requested[scope] = true
+	}
+
+	result := make([]string, 0, len(requested))
+	for _, scope := range models.MerchantAPIScopes {
+		if requested[scope] {
+			result = append(result, scope)
+			delete(requested, scope)
+		}
+	}
+	for scope := range requested {
+		return nil, appErrors.ErrInvalidParams.WithMessage("不支持的授权范围: " + scope)
+	}
+	return result, nil
+}
@@ -0,0 +1,153 @@
+package merchant
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/crypto"
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+func setupMerchantTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	require.NoError(t, db.AutoMigrate(
+		&models.Merchant{},
+		&models.Venue{},
+		&models.Device{},
+		&models.Rental{},
+		&models.Settlement{},
+		&models.MerchantAPIKey{},
+		&models.MerchantApplication{},
+	))
+	return db
+}
+
+func createTestMerchant(t *testing.T, db *gorm.DB, name string) *models.Merchant {
+	t.Helper()
+	merchant := &models.Merchant{Name: name, ContactName: "联系人", ContactPhone: "13800138000", Status: models.MerchantStatusActive}
+	require.NoError(t, db.Create(merchant).Error)
+	return merchant
+}
+
+func setupAPIKeyService(t *testing.T) (*MerchantAPIKeyService, *gorm.DB) {
+	t.Helper()
+
+	db := setupMerchantTestDB(t)
+	aes, err := crypto.NewAES("0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+
+	svc := NewMerchantAPIKeyService(repository.NewMerchantAPIKeyRepository(db), repository.NewMerchantRepository(db), aes)
+	return svc, db
+}
+
+func TestMerchantAPIKeyService_IssueKey(t *testing.T) {
+	svc, db := setupAPIKeyService(t)
+	ctx := context.Background()
+	merchant := createTestMerchant(t, db, "商户A")
+
+	t.Run("默认授予全部范围", func(t *testing.T) {
+		issued, err := svc.IssueKey(ctx, merchant.ID, nil)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(issued.KeyID, apiKeyIDPrefix))
+		assert.Len(t, issued.Secret, apiKeySecretBytes*2)
+		assert.Equal(t, models.MerchantAPIScopes, issued.Scopes)
+
+		// 库中只保存加密后的密钥，解析后还原原文
+		var stored models.MerchantAPIKey
+		require.NoError(t, db.First(&stored, issued.ID).Error)
+		assert.NotContains(t, stored.SecretEncrypted, issued.Secret)
+
+		key, secret, err := svc.ResolveAPIKey(ctx, issued.KeyID)
+		require.NoError(t, err)
+		assert.Equal(t, merchant.ID, key.MerchantID)
+		assert.Equal(t, issued.Secret, secret)
+	})
+
+	t.Run("指定授权范围", func(t *testing.T) {
+		issued, err := svc.IssueKey(ctx, merchant.ID, &IssueAPIKeyRequest{Scopes: []string{"rentals", "venues", "venues"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{models.MerchantAPIScopeVenues, models.MerchantAPIScopeRentals}, issued.Scopes)
+	})
+
+	t.Run("不支持的授权范围", func(t *testing.T) {
+		_, err := svc.IssueKey(ctx, merchant.ID, &IssueAPIKeyRequest{Scopes: []string{"orders"}})
+		require.Error(t, err)
+		assert.Equal(t, appErrors.ErrInvalidParams.Code, err.(*appErrors.AppError).Code)
+	})
+
+	t.Run("商户不存在", func(t *testing.T) {
+		_, err := svc.IssueKey(ctx, 99999, nil)
+		assert.Equal(t, appErrors.ErrMerchantNotFound, err)
+	})
+
+	t.Run("未配置加密密钥", func(t *testing.T) {
+		noAES := NewMerchantAPIKeyService(svc.keyRepo, svc.merchantRepo, nil)
+		_, err := noAES.IssueKey(ctx, merchant.ID, nil)
+		assert.Equal(t, errEncryptorMissing, err)
+	})
+}
+
+func TestMerchantAPIKeyService_RotateAndRevoke(t *testing.T) {
+	svc, db := setupAPIKeyService(t)
+	ctx := context.Background()
+	merchantA := createTestMerchant(t, db, "商户A")
+	merchantB := createTestMerchant(t, db, "商户B")
+
+	old, err := svc.IssueKey(ctx, merchantA.ID, &IssueAPIKeyRequest{Scopes: []string{"devices"}})
+	require.NoError(t, err)
+
+	t.Run("其他商户不能操作", func(t *testing.T) {
+		_, err := svc.RotateKey(ctx, merchantB.ID, old.ID)
+		assert.Equal(t, appErrors.ErrMerchantAPIKeyNotFound, err)
+		assert.Equal(t, appErrors.ErrMerchantAPIKeyNotFound, svc.RevokeKey(ctx, merchantB.ID, old.ID))
+	})
+
+	rotated, err := svc.RotateKey(ctx, merchantA.ID, old.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, old.KeyID, rotated.KeyID)
+	assert.NotEqual(t, old.Secret, rotated.Secret)
+	assert.Equal(t, []string{models.MerchantAPIScopeDevices}, rotated.Scopes)
+
+	oldKey, _, err := svc.ResolveAPIKey(ctx, old.KeyID)
+	require.NoError(t, err)
+	assert.False(t, oldKey.IsActive())
+
+	// 已吊销的密钥不能再轮换
+	_, err = svc.RotateKey(ctx, merchantA.ID, old.ID)
+	assert.Equal(t, appErrors.ErrMerchantAPIKeyRevoked, err)
+
+	require.NoError(t, svc.RevokeKey(ctx, merchantA.ID, rotated.ID))
+	require.NoError(t, svc.RevokeKey(ctx, merchantA.ID, rotated.ID))
+	newKey, _, err := svc.ResolveAPIKey(ctx, rotated.KeyID)
+	require.NoError(t, err)
+	assert.False(t, newKey.IsActive())
+
+	keys, err := svc.ListKeys(ctx, merchantA.ID)
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	missing, _, err := svc.ResolveAPIKey(ctx, "mk_missing")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
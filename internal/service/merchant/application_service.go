@@ -0,0 +1,452 @@
+package merchant
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/crypto"
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// merchantApplicationTransitions 入驻申请允许的状态流转
+var merchantApplicationTransitions = map[string][]string{
+	models.MerchantApplicationStatusDraft:       {models.MerchantApplicationStatusSubmitted},
+	models.MerchantApplicationStatusSubmitted:   {models.MerchantApplicationStatusUnderReview},
+	models.MerchantApplicationStatusUnderReview: {models.MerchantApplicationStatusApproved, models.MerchantApplicationStatusRejected},
+	models.MerchantApplicationStatusRejected:    {models.MerchantApplicationStatusDraft},
+}
+
+// canTransition 判断申请能否从 from 状态流转到 to 状态
+func canTransition(from, to string) bool {
+	for _, next := range merchantApplicationTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// MerchantApplicationService 商户入驻申请服务
+// 用户填写资料并提交申请，管理员受理审核；审核通过时在同一事务中按申请资料创建商户
+type MerchantApplicationService struct {
+	db           *gorm.DB
+	appRepo      *repository.MerchantApplicationRepository
+	aes          *crypto.AES
+	allowedHosts map[string]struct{}
+	now          func() time.Time
+}
+
+// NewMerchantApplicationService 创建商户入驻申请服务
+// documentHosts 为资料图片允许的存储域名，申请中的文件地址必须指向这些域名
+func NewMerchantApplicationService(db *gorm.DB, appRepo *repository.MerchantApplicationRepository, aes *crypto.AES, documentHosts []string) *MerchantApplicationService {
+	allowed := make(map[string]struct{}, len(documentHosts))
+	for _, host := range documentHosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host != "" {
+			allowed[host] = struct{}{}
+		}
+	}
+	return &MerchantApplicationService{
+		db:           db,
+		appRepo:      appRepo,
+		aes:          aes,
+		allowedHosts: allowed,
+		now:          time.Now,
+	}
+}
+
+// MerchantApplicationRequest 填写入驻申请请求
+type MerchantApplicationRequest struct {
+	MerchantName       string  `json:"merchant_name" binding:"required,max=100"`
+	ContactName        string  `json:"contact_name" binding:"required,max=50"`
+	ContactPhone       string  `json:"contact_phone" binding:"required,max=20"`
+	Address            *string `json:"address" binding:"omitempty,max=255"`
+	BusinessLicenseURL string  `json:"business_license_url" binding:"required,max=500"`
+	BankName           *string `json:"bank_name" binding:"omitempty,max=100"`
+	BankAccount        *string `json:"bank_account"`
+	BankHolder         *string `json:"bank_holder"`
+}
+
+// ApproveMerchantApplicationRequest 审核通过请求，未填写时使用商户默认分成比例和结算周期
+type ApproveMerchantApplicationRequest struct {
+	CommissionRate float64 `json:"commission_rate" binding:"min=0,max=1"`
+	SettlementType string  `json:"settlement_type" binding:"omitempty,oneof=weekly monthly"`
+}
+
+// MerchantApplicationInfo 入驻申请信息
+type MerchantApplicationInfo struct {
+	*models.MerchantApplication
+	BankAccount *string `json:"bank_account,omitempty"` // 脱敏后的账号
+	BankHolder  *string `json:"bank_holder,omitempty"`  // 脱敏后的开户人
+}
+
+// Create 创建入驻申请草稿，每个用户仅能有一份申请
+func (s *MerchantApplicationService) Create(ctx context.Context, userID int64, req *MerchantApplicationRequest) (*MerchantApplicationInfo, error) {
+	if _, err := s.appRepo.GetByUserID(ctx, userID); err == nil {
+		return nil, appErrors.ErrMerchantApplicationExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	app := &models.MerchantApplication{
+		UserID: userID,
+		Status: models.MerchantApplicationStatusDraft,
+	}
+	if err := s.fill(app, req); err != nil {
+		return nil, err
+	}
+	if err := s.appRepo.Create(ctx, app); err != nil {
+		return nil, err
+	}
+	return s.toInfo(app), nil
+}
+
+// Update 修改入驻申请资料，仅草稿或已驳回的申请可修改；已驳回的申请修改后回到草稿
+func (s *MerchantApplicationService) Update(ctx context.Context, userID int64, req *MerchantApplicationRequest) (*MerchantApplicationInfo, error) {
+	app, err := s.getByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case app.Status == models.MerchantApplicationStatusDraft:
+	case canTransition(app.Status, models.MerchantApplicationStatusDraft):
+		app.Status = models.MerchantApplicationStatusDraft
+		app.RejectReason = nil
+	default:
+		return nil, appErrors.ErrMerchantApplicationStatus.WithMessage("申请已提交，无法修改")
+	}
+
+	if err := s.fill(app, req); err != nil {
+		return nil, err
+	}
+	if err := s.appRepo.Update(ctx, app); err != nil {
+		return nil, err
+	}
+	return s.toInfo(app), nil
+}
+
+// Submit 提交入驻申请等待审核
+func (s *MerchantApplicationService) Submit(ctx context.Context, userID int64) (*MerchantApplicationInfo, error) {
+	app, err := s.getByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if err := s.transition(s.db.WithContext(ctx), app, models.MerchantApplicationStatusSubmitted, map[string]interface{}{
+		"submitted_at": now,
+	}); err != nil {
+		return nil, err
+	}
+	app.SubmittedAt = &now
+	return s.toInfo(app), nil
+}
+
+// GetMine 获取用户自己的入驻申请
+func (s *MerchantApplicationService) GetMine(ctx context.Context, userID int64) (*MerchantApplicationInfo, error) {
+	app, err := s.getByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toInfo(app), nil
+}
+
+// List 获取入驻申请列表
+func (s *MerchantApplicationService) List(ctx context.Context, offset, limit int, filters *repository.MerchantApplicationListFilters) ([]*MerchantApplicationInfo, int64, error) {
+	apps, total, err := s.appRepo.List(ctx, offset, limit, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	list := make([]*MerchantApplicationInfo, len(apps))
+	for i, app := range apps {
+		list[i] = s.toInfo(app)
+	}
+	return list, total, nil
+}
+
+// Get 获取入驻申请详情
+func (s *MerchantApplicationService) Get(ctx context.Context, id int64) (*MerchantApplicationInfo, error) {
+	app, err := s.getByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toInfo(app), nil
+}
+
+// StartReview 受理已提交的申请，进入审核中
+func (s *MerchantApplicationService) StartReview(ctx context.Context, id, adminID int64) (*MerchantApplicationInfo, error) {
+	app, err := s.getByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transition(s.db.WithContext(ctx), app, models.MerchantApplicationStatusUnderReview, map[string]interface{}{
+		"reviewed_by": adminID,
+	}); err != nil {
+		return nil, err
+	}
+	app.ReviewedBy = &adminID
+	return s.toInfo(app), nil
+}
+
+// Reject 驳回审核中的申请，必须填写驳回原因
+func (s *MerchantApplicationService) Reject(ctx context.Context, id, adminID int64, reason string) (*MerchantApplicationInfo, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, appErrors.ErrInvalidParams.WithMessage("请填写驳回原因")
+	}
+
+	app, err := s.getByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if err := s.transition(s.db.WithContext(ctx), app, models.MerchantApplicationStatusRejected, map[string]interface{}{
+		"reject_reason": reason,
+		"reviewed_by":   adminID,
+		"reviewed_at":   now,
+	}); err != nil {
+		return nil, err
+	}
+	app.RejectReason = &reason
+	app.ReviewedBy = &adminID
+	app.ReviewedAt = &now
+	return s.toInfo(app), nil
+}
+
+// Approve 审核通过申请，并按申请资料创建商户
+// 重复调用时直接返回已创建的商户，保证每份申请只创建一个商户
+func (s *MerchantApplicationService) Approve(ctx context.Context, id, adminID int64, req *ApproveMerchantApplicationRequest) (*models.Merchant, error) {
+	var merchant *models.Merchant
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var app models.MerchantApplication
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&app, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return appErrors.ErrMerchantApplicationNotFound
+			}
+			return err
+		}
+
+		if app.Status == models.MerchantApplicationStatusApproved {
+			existing, err := approvedMerchant(tx, &app)
+			merchant = existing
+			return err
+		}
+		if !canTransition(app.Status, models.MerchantApplicationStatusApproved) {
+			return appErrors.ErrMerchantApplicationStatus
+		}
+
+		var count int64
+		if err := tx.Model(&models.Merchant{}).Where("name = ?", app.MerchantName).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return appErrors.ErrDuplicateRecord.WithMessage("商户名称已存在")
+		}
+
+		// 先按原状态条件更新为已通过，并发审核时只有一个请求能继续创建商户
+		if err := s.transition(tx, &app, models.MerchantApplicationStatusApproved, map[string]interface{}{
+			"reject_reason": nil,
+			"reviewed_by":   adminID,
+			"reviewed_at":   s.now(),
+		}); err != nil {
+			return err
+		}
+
+		merchant = newMerchantFromApplication(&app, req)
+		if err := tx.Create(merchant).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.MerchantApplication{}).Where("id = ?", app.ID).
+			Update("merchant_id", merchant.ID).Error
+	})
+	if err == nil {
+		return merchant, nil
+	}
+
+	// 并发审核时另一请求已先通过，返回其创建的商户
+	if errors.Is(err, appErrors.ErrMerchantApplicationStatus) {
+		if app, getErr := s.getByID(ctx, id); getErr == nil && app.Status == models.MerchantApplicationStatusApproved {
+			return approvedMerchant(s.db.WithContext(ctx), app)
+		}
+	}
+	return nil, err
+}
+
+// newMerchantFromApplication 按审核通过的申请资料构建商户，银行信息沿用申请中的密文
+func newMerchantFromApplication(app *models.MerchantApplication, req *ApproveMerchantApplicationRequest) *models.Merchant {
+	commissionRate := 0.2 // 默认 20% 分成
+	settlementType := models.SettlementTypeMonthly
+	if req != nil {
+		if req.CommissionRate > 0 {
+			commissionRate = req.CommissionRate
+		}
+		if req.SettlementType != "" {
+			settlementType = req.SettlementType
+		}
+	}
+
+	businessLicense := app.BusinessLicenseURL
+	return &models.Merchant{
+		Name:                 app.MerchantName,
+		ContactName:          app.ContactName,
+		ContactPhone:         app.ContactPhone,
+		Address:              app.Address,
+		BusinessLicense:      &businessLicense,
+		CommissionRate:       commissionRate,
+		SettlementType:       settlementType,
+		BankName:             app.BankName,
+		BankAccountEncrypted: app.BankAccountEncrypted,
+		BankHolderEncrypted:  app.BankHolderEncrypted,
+		Status:               models.MerchantStatusActive,
+	}
+}
+
+// approvedMerchant 获取已通过申请创建的商户
+func approvedMerchant(db *gorm.DB, app *models.MerchantApplication) (*models.Merchant, error) {
+	if app.MerchantID == nil {
+		return nil, appErrors.ErrMerchantNotFound
+	}
+	var merchant models.Merchant
+	if err := db.First(&merchant, *app.MerchantID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrMerchantNotFound
+		}
+		return nil, err
+	}
+	return &merchant, nil
+}
+
+// transition 按当前状态条件更新申请状态，状态不允许流转或已被并发修改时返回 ErrMerchantApplicationStatus
+func (s *MerchantApplicationService) transition(db *gorm.DB, app *models.MerchantApplication, to string, fields map[string]interface{}) error {
+	if !canTransition(app.Status, to) {
+		return appErrors.ErrMerchantApplicationStatus
+	}
+
+	fields["status"] = to
+	result := db.Model(&models.MerchantApplication{}).
+		Where("id = ? AND status = ?", app.ID, app.Status).
+		Updates(fields)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.ErrMerchantApplicationStatus
+	}
+	app.Status = to
+	return nil
+}
+
+// fill 校验并填充申请资料，银行账号和开户人加密保存
+func (s *MerchantApplicationService) fill(app *models.MerchantApplication, req *MerchantApplicationRequest) error {
+	if err := s.validateDocumentURL(req.BusinessLicenseURL); err != nil {
+		return err
+	}
+
+	bankAccount, err := s.encryptOptional(req.BankAccount)
+	if err != nil {
+		return err
+	}
+	bankHolder, err := s.encryptOptional(req.BankHolder)
+	if err != nil {
+		return err
+	}
+
+	app.MerchantName = strings.TrimSpace(req.MerchantName)
+	app.ContactName = req.ContactName
+	app.ContactPhone = req.ContactPhone
+	app.Address = req.Address
+	app.BusinessLicenseURL = req.BusinessLicenseURL
+	app.BankName = req.BankName
+	app.BankAccountEncrypted = bankAccount
+	app.BankHolderEncrypted = bankHolder
+	return nil
+}
+
+// validateDocumentURL 校验资料文件地址为允许的存储域名下的 http(s) 地址
+func (s *MerchantApplicationService) validateDocumentURL(raw string) error {
+	u, err := url.ParseRequestURI(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return appErrors.ErrInvalidParams.WithMessage("资料文件地址无效")
+	}
+	if _, ok := s.allowedHosts[strings.ToLower(u.Hostname())]; !ok {
+		return appErrors.ErrInvalidParams.WithMessage("资料文件须通过上传接口上传")
+	}
+	return nil
+}
+
+// encryptOptional 加密可选的敏感字段，为空时返回 nil
+func (s *MerchantApplicationService) encryptOptional(value *string) (*string, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	if s.aes == nil {
+		return nil, errEncryptorMissing
+	}
+	encrypted, err := s.aes.Encrypt(*value)
+	if err != nil {
+		return nil, err
+	}
+	return &encrypted, nil
+}
+
+// toInfo 转换为申请信息，银行信息解密后脱敏
+func (s *MerchantApplicationService) toInfo(app *models.MerchantApplication) *MerchantApplicationInfo {
+	info := &MerchantApplicationInfo{MerchantApplication: app}
+	if s.aes == nil {
+		return info
+	}
+
+	if app.BankAccountEncrypted != nil {
+		if decrypted, err := s.aes.Decrypt(*app.BankAccountEncrypted); err == nil {
+			masked := crypto.MaskBankCard(decrypted)
+			info.BankAccount = &masked
+		}
+	}
+	if app.BankHolderEncrypted != nil {
+		if decrypted, err := s.aes.Decrypt(*app.BankHolderEncrypted); err == nil {
+			// 姓名脱敏：保留第一个字，其余用*
+			runes := []rune(decrypted)
+			if len(runes) > 1 {
+				decrypted = string(runes[0]) + strings.Repeat("*", len(runes)-1)
+			}
+			info.BankHolder = &decrypted
+		}
+	}
+	return info
+}
+
+// getByUser 获取用户的申请
+func (s *MerchantApplicationService) getByUser(ctx context.Context, userID int64) (*models.MerchantApplication, error) {
+	app, err := s.appRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrMerchantApplicationNotFound
+		}
+		return nil, err
+	}
+	return app, nil
+}
+
+// getByID 根据 ID 获取申请
+func (s *MerchantApplicationService) getByID(ctx context.Context, id int64) (*models.MerchantApplication, error) {
+	app, err := s.appRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrMerchantApplicationNotFound
+		}
+		return nil, err
+	}
+	return app, nil
+}
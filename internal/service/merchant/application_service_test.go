@@ -0,0 +1,298 @@
+package merchant
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/crypto"
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+const testDocumentHost = "mock-oss.example.com"
+
+func setupApplicationService(t *testing.T) (*MerchantApplicationService, *gorm.DB) {
+	t.Helper()
+
+	db := setupMerchantTestDB(t)
+	aes, err := crypto.NewAES("0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+
+	svc := NewMerchantApplicationService(db, repository.NewMerchantApplicationRepository(db), aes, []string{testDocumentHost})
+	return svc, db
+}
+
+func newApplicationRequest(name string) *MerchantApplicationRequest {
+	bankName := "招商银行"
+	bankAccount := "6225880112345678"
+	bankHolder := "张三"
+	return &MerchantApplicationRequest{
+		MerchantName:       name,
+		ContactName:        "张三",
+		ContactPhone:       "13800138000",
+		BusinessLicenseURL: "https://" + testDocumentHost + "/licenses/1.jpg",
+		BankName:           &bankName,
+		BankAccount:        &bankAccount,
+		BankHolder:         &bankHolder,
+	}
+}
+
+// createUnderReviewApplication 创建并提交申请，管理员受理后进入审核中
+func createUnderReviewApplication(t *testing.T, svc *MerchantApplicationService, userID int64, name string) *MerchantApplicationInfo {
+	t.Helper()
+	ctx := context.Background()
+
+	info, err := svc.Create(ctx, userID, newApplicationRequest(name))
+	require.NoError(t, err)
+	_, err = svc.Submit(ctx, userID)
+	require.NoError(t, err)
+	info, err = svc.StartReview(ctx, info.ID, 1)
+	require.NoError(t, err)
+	require.Equal(t, models.MerchantApplicationStatusUnderReview, info.Status)
+	return info
+}
+
+func assertAppErrorCode(t *testing.T, expected *appErrors.AppError, err error) {
+	t.Helper()
+	require.Error(t, err)
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok, "expected AppError, got %v", err)
+	assert.Equal(t, expected.Code, appErr.Code)
+}
+
+func TestMerchantApplicationService_CreateAndSubmit(t *testing.T) {
+	svc, db := setupApplicationService(t)
+	ctx := context.Background()
+
+	info, err := svc.Create(ctx, 10, newApplicationRequest("新商户"))
+	require.NoError(t, err)
+	assert.Equal(t, models.MerchantApplicationStatusDraft, info.Status)
+	require.NotNil(t, info.BankAccount)
+	assert.Equal(t, "6225 **** **** 5678", *info.BankAccount)
+	assert.Equal(t, "张*", *info.BankHolder)
+
+	// 银行信息加密保存
+	var stored models.MerchantApplication
+	require.NoError(t, db.First(&stored, info.ID).Error)
+	require.NotNil(t, stored.BankAccountEncrypted)
+	assert.NotContains(t, *stored.BankAccountEncrypted, "6225880112345678")
+
+	_, err = svc.Create(ctx, 10, newApplicationRequest("另一个商户"))
+	assert.Equal(t, appErrors.ErrMerchantApplicationExists, err)
+
+	submitted, err := svc.Submit(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, models.MerchantApplicationStatusSubmitted, submitted.Status)
+	assert.NotNil(t, submitted.SubmittedAt)
+
+	// 已提交的申请不能修改或重复提交
+	_, err = svc.Update(ctx, 10, newApplicationRequest("新商户"))
+	assertAppErrorCode(t, appErrors.ErrMerchantApplicationStatus, err)
+	_, err = svc.Submit(ctx, 10)
+	assertAppErrorCode(t, appErrors.ErrMerchantApplicationStatus, err)
+
+	_, err = svc.GetMine(ctx, 11)
+	assert.Equal(t, appErrors.ErrMerchantApplicationNotFound, err)
+}
+
+func TestMerchantApplicationService_DocumentURL(t *testing.T) {
+	svc, _ := setupApplicationService(t)
+	ctx := context.Background()
+
+	for _, raw := range []string{
+		"https://evil.example.com/licenses/1.jpg",
+		"ftp://" + testDocumentHost + "/licenses/1.jpg",
+		"licenses/1.jpg",
+		"https://" + testDocumentHost + ".evil.com/1.jpg",
+	} {
+		req := newApplicationRequest("新商户")
+		req.BusinessLicenseURL = raw
+		_, err := svc.Create(ctx, 10, req)
+		assertAppErrorCode(t, appErrors.ErrInvalidParams, err)
+	}
+
+	req := newApplicationRequest("新商户")
+	req.BusinessLicenseURL = "https://MOCK-OSS.example.com/licenses/1.jpg"
+	_, err := svc.Create(ctx, 10, req)
+	assert.NoError(t, err)
+}
+
+func TestMerchantApplicationService_InvalidTransition(t *testing.T) {
+	svc, db := setupApplicationService(t)
+	ctx := context.Background()
+
+	draft, err := svc.Create(ctx, 10, newApplicationRequest("新商户"))
+	require.NoError(t, err)
+
+	// 草稿不能直接审核通过、驳回或受理
+	_, err = svc.Approve(ctx, draft.ID, 1, nil)
+	assert.Equal(t, appErrors.ErrMerchantApplicationStatus, err)
+	_, err = svc.Reject(ctx, draft.ID, 1, "资料不全")
+	assert.Equal(t, appErrors.ErrMerchantApplicationStatus, err)
+	_, err = svc.StartReview(ctx, draft.ID, 1)
+	assert.Equal(t, appErrors.ErrMerchantApplicationStatus, err)
+
+	// 已提交但未受理的申请也不能直接通过
+	_, err = svc.Submit(ctx, 10)
+	require.NoError(t, err)
+	_, err = svc.Approve(ctx, draft.ID, 1, nil)
+	assert.Equal(t, appErrors.ErrMerchantApplicationStatus, err)
+
+	var count int64
+	db.Model(&models.Merchant{}).Count(&count)
+	assert.Zero(t, count)
+
+	_, err = svc.Approve(ctx, 99999, 1, nil)
+	assert.Equal(t, appErrors.ErrMerchantApplicationNotFound, err)
+}
+
+func TestMerchantApplicationService_Reject(t *testing.T) {
+	svc, _ := setupApplicationService(t)
+	ctx := context.Background()
+	app := createUnderReviewApplication(t, svc, 10, "新商户")
+
+	// 驳回必须填写原因
+	_, err := svc.Reject(ctx, app.ID, 1, "  ")
+	assertAppErrorCode(t, appErrors.ErrInvalidParams, err)
+
+	info, err := svc.GetMine(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, models.MerchantApplicationStatusUnderReview, info.Status)
+
+	rejected, err := svc.Reject(ctx, app.ID, 1, "营业执照不清晰")
+	require.NoError(t, err)
+	assert.Equal(t, models.MerchantApplicationStatusRejected, rejected.Status)
+	require.NotNil(t, rejected.RejectReason)
+	assert.Equal(t, "营业执照不清晰", *rejected.RejectReason)
+
+	// 驳回后修改资料回到草稿，可重新提交
+	updated, err := svc.Update(ctx, 10, newApplicationRequest("新商户（更正）"))
+	require.NoError(t, err)
+	assert.Equal(t, models.MerchantApplicationStatusDraft, updated.Status)
+	assert.Nil(t, updated.RejectReason)
+
+	resubmitted, err := svc.Submit(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, models.MerchantApplicationStatusSubmitted, resubmitted.Status)
+}
+
+func TestMerchantApplicationService_Approve(t *testing.T) {
+	svc, db := setupApplicationService(t)
+	ctx := context.Background()
+	app := createUnderReviewApplication(t, svc, 10, "新商户")
+
+	merchant, err := svc.Approve(ctx, app.ID, 2, &ApproveMerchantApplicationRequest{
+		CommissionRate: 0.15,
+		SettlementType: models.SettlementTypeWeekly,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "新商户", merchant.Name)
+	assert.Equal(t, "张三", merchant.ContactName)
+	assert.Equal(t, 0.15, merchant.CommissionRate)
+	assert.Equal(t, models.SettlementTypeWeekly, merchant.SettlementType)
+	assert.Equal(t, int8(models.MerchantStatusActive), merchant.Status)
+	require.NotNil(t, merchant.BusinessLicense)
+	assert.Equal(t, app.BusinessLicenseURL, *merchant.BusinessLicense)
+
+	// 银行信息沿用申请中的密文
+	var stored models.MerchantApplication
+	require.NoError(t, db.First(&stored, app.ID).Error)
+	assert.Equal(t, stored.BankAccountEncrypted, merchant.BankAccountEncrypted)
+	assert.Equal(t, models.MerchantApplicationStatusApproved, stored.Status)
+	require.NotNil(t, stored.MerchantID)
+	assert.Equal(t, merchant.ID, *stored.MerchantID)
+	assert.Equal(t, int64(2), *stored.ReviewedBy)
+
+	// 重复审核通过返回同一商户，不重复创建
+	again, err := svc.Approve(ctx, app.ID, 2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, merchant.ID, again.ID)
+
+	var count int64
+	db.Model(&models.Merchant{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// 已通过的申请不能再驳回或修改
+	_, err = svc.Reject(ctx, app.ID, 2, "重复")
+	assert.Equal(t, appErrors.ErrMerchantApplicationStatus, err)
+	_, err = svc.Update(ctx, 10, newApplicationRequest("新商户"))
+	assertAppErrorCode(t, appErrors.ErrMerchantApplicationStatus, err)
+}
+
+func TestMerchantApplicationService_ApproveConcurrentRetries(t *testing.T) {
+	svc, db := setupApplicationService(t)
+	ctx := context.Background()
+	app := createUnderReviewApplication(t, svc, 10, "新商户")
+
+	const retries = 5
+	ids := make([]int64, retries)
+	errs := make([]error, retries)
+	var wg sync.WaitGroup
+	for i := 0; i < retries; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			merchant, err := svc.Approve(ctx, app.ID, 2, nil)
+			errs[i] = err
+			if merchant != nil {
+				ids[i] = merchant.ID
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < retries; i++ {
+		require.NoError(t, errs[i])
+		assert.Equal(t, ids[0], ids[i])
+	}
+
+	var count int64
+	db.Model(&models.Merchant{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestMerchantApplicationService_ApproveStaleRow(t *testing.T) {
+	svc, db := setupApplicationService(t)
+	ctx := context.Background()
+	app := createUnderReviewApplication(t, svc, 10, "新商户")
+
+	// 读取申请后模拟另一审核已将其驳回（SQLite 无行锁，在同一事务内改写以模拟读到旧数据）
+	var fired bool
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:concurrent_review", func(tx *gorm.DB) {
+		if fired || tx.Statement.Table != "merchant_applications" {
+			return
+		}
+		fired = true
+		require.NoError(t, tx.Session(&gorm.Session{NewDB: true}).
+			Exec("UPDATE merchant_applications SET status = ? WHERE id = ?", models.MerchantApplicationStatusRejected, app.ID).Error)
+	}))
+
+	_, err := svc.Approve(ctx, app.ID, 2, nil)
+	require.True(t, fired)
+	assert.Equal(t, appErrors.ErrMerchantApplicationStatus, err)
+
+	// 条件更新未命中时不创建商户
+	var count int64
+	db.Model(&models.Merchant{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestMerchantApplicationService_ApproveDuplicateName(t *testing.T) {
+	svc, db := setupApplicationService(t)
+	ctx := context.Background()
+	createTestMerchant(t, db, "已有商户")
+	app := createUnderReviewApplication(t, svc, 10, "已有商户")
+
+	_, err := svc.Approve(ctx, app.ID, 2, nil)
+	assertAppErrorCode(t, appErrors.ErrDuplicateRecord, err)
+
+	info, err := svc.Get(ctx, app.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.MerchantApplicationStatusUnderReview, info.Status)
+}
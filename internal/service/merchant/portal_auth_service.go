@@ -0,0 +1,62 @@
+package merchant
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// MerchantPortalAuthService 商户门户登录服务
+// 入驻申请已通过的用户以用户令牌换取商户令牌，商户令牌的 UserID 为商户 ID，仅能访问商户门户接口
+type MerchantPortalAuthService struct {
+	appRepo      *repository.MerchantApplicationRepository
+	merchantRepo *repository.MerchantRepository
+	jwtManager   *jwt.Manager
+}
+
+// NewMerchantPortalAuthService 创建商户门户登录服务
+func NewMerchantPortalAuthService(appRepo *repository.MerchantApplicationRepository, merchantRepo *repository.MerchantRepository, jwtManager *jwt.Manager) *MerchantPortalAuthService {
+	return &MerchantPortalAuthService{
+		appRepo:      appRepo,
+		merchantRepo: merchantRepo,
+		jwtManager:   jwtManager,
+	}
+}
+
+// Login 为用户入驻申请通过后创建的商户签发商户令牌
+// 未提交申请、申请未通过或商户已禁用时拒绝签发
+func (s *MerchantPortalAuthService) Login(ctx context.Context, userID int64) (*jwt.TokenPair, error) {
+	app, err := s.appRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrPermissionDenied.WithMessage("未入驻商户，无法登录商户门户")
+		}
+		return nil, appErrors.ErrDatabaseError.WithError(err)
+	}
+	if app.Status != models.MerchantApplicationStatusApproved || app.MerchantID == nil {
+		return nil, appErrors.ErrPermissionDenied.WithMessage("商户入驻申请尚未通过，无法登录商户门户")
+	}
+
+	merchant, err := s.merchantRepo.GetByID(ctx, *app.MerchantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrMerchantNotFound
+		}
+		return nil, appErrors.ErrDatabaseError.WithError(err)
+	}
+	if merchant.Status != models.MerchantStatusActive {
+		return nil, appErrors.ErrPermissionDenied.WithMessage("商户已被禁用")
+	}
+
+	pair, err := s.jwtManager.IssueTokenPair(ctx, merchant.ID, jwt.UserTypeMerchant, "")
+	if err != nil {
+		return nil, appErrors.ErrInternalError.WithError(err)
+	}
+	return pair, nil
+}
@@ -0,0 +1,61 @@
+package merchant
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+func TestMerchantPortalAuthService_Login(t *testing.T) {
+	appSvc, db := setupApplicationService(t)
+	ctx := context.Background()
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: 24 * time.Hour,
+		Issuer:            "test",
+	})
+	svc := NewMerchantPortalAuthService(repository.NewMerchantApplicationRepository(db), repository.NewMerchantRepository(db), jwtManager)
+
+	t.Run("未提交申请", func(t *testing.T) {
+		_, err := svc.Login(ctx, 20)
+		assertAppErrorCode(t, appErrors.ErrPermissionDenied, err)
+	})
+
+	app := createUnderReviewApplication(t, appSvc, 21, "门户商户")
+
+	t.Run("申请未通过", func(t *testing.T) {
+		_, err := svc.Login(ctx, 21)
+		assertAppErrorCode(t, appErrors.ErrPermissionDenied, err)
+	})
+
+	merchant, err := appSvc.Approve(ctx, app.ID, 2, nil)
+	require.NoError(t, err)
+
+	t.Run("签发商户令牌", func(t *testing.T) {
+		pair, err := svc.Login(ctx, 21)
+		require.NoError(t, err)
+
+		claims, err := jwtManager.ParseToken(pair.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, merchant.ID, claims.UserID)
+		assert.Equal(t, jwt.UserTypeMerchant, claims.UserType)
+		assert.Equal(t, jwt.TokenTypeAccess, claims.TokenType)
+	})
+
+	t.Run("商户已禁用", func(t *testing.T) {
+		require.NoError(t, db.Model(&models.Merchant{}).Where("id = ?", merchant.ID).
+			Update("status", models.MerchantStatusDisabled).Error)
+
+		_, err := svc.Login(ctx, 21)
+		assertAppErrorCode(t, appErrors.ErrPermissionDenied, err)
+	})
+}
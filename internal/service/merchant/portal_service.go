@@ -0,0 +1,113 @@
+// Package merchant 提供商户自助门户相关服务
+package merchant
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// MerchantPortalService 商户自助门户只读查询服务
+// 所有查询都以已认证的商户 ID 作为必选条件，商户只能看到自己场地下的数据
+type MerchantPortalService struct {
+	db *gorm.DB
+}
+
+// NewMerchantPortalService 创建商户自助门户服务
+func NewMerchantPortalService(db *gorm.DB) *MerchantPortalService {
+	return &MerchantPortalService{db: db}
+}
+
+// PortalDeviceFilters 设备列表筛选条件
+type PortalDeviceFilters struct {
+	VenueID int64
+}
+
+// PortalRentalFilters 租借列表筛选条件
+type PortalRentalFilters struct {
+	Status   string
+	DeviceID int64
+}
+
+// ListSettlements 获取商户结算单列表
+func (s *MerchantPortalService) ListSettlements(ctx context.Context, merchantID int64, page, pageSize int, status string) ([]*models.Settlement, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.Settlement{}).
+		Where("type = ? AND target_id = ?", models.SettlementTypeMerchant, merchantID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var settlements []*models.Settlement
+	err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&settlements).Error
+	return settlements, total, err
+}
+
+// ListVenues 获取商户场地列表
+func (s *MerchantPortalService) ListVenues(ctx context.Context, merchantID int64, page, pageSize int) ([]*models.Venue, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.Venue{}).Where("merchant_id = ?", merchantID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var venues []*models.Venue
+	err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&venues).Error
+	return venues, total, err
+}
+
+// ListDevices 获取商户场地下的设备列表
+func (s *MerchantPortalService) ListDevices(ctx context.Context, merchantID int64, page, pageSize int, filters *PortalDeviceFilters) ([]*models.Device, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.Device{}).
+		Where("venue_id IN (?)", s.merchantVenueIDs(ctx, merchantID))
+	if filters != nil && filters.VenueID > 0 {
+		query = query.Where("venue_id = ?", filters.VenueID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var devices []*models.Device
+	err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&devices).Error
+	return devices, total, err
+}
+
+// ListRentals 获取商户设备上的租借记录，已删除设备的历史租借仍归属原商户
+func (s *MerchantPortalService) ListRentals(ctx context.Context, merchantID int64, page, pageSize int, filters *PortalRentalFilters) ([]*models.Rental, int64, error) {
+	deviceIDs := s.db.WithContext(ctx).Unscoped().Model(&models.Device{}).
+		Select("id").
+		Where("venue_id IN (?)", s.merchantVenueIDs(ctx, merchantID))
+
+	query := s.db.WithContext(ctx).Model(&models.Rental{}).Where("device_id IN (?)", deviceIDs)
+	if filters != nil {
+		if filters.Status != "" {
+			query = query.Where("status = ?", filters.Status)
+		}
+		if filters.DeviceID > 0 {
+			query = query.Where("device_id = ?", filters.DeviceID)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rentals []*models.Rental
+	err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&rentals).Error
+	return rentals, total, err
+}
+
+// merchantVenueIDs 商户场地 ID 子查询
+func (s *MerchantPortalService) merchantVenueIDs(ctx context.Context, merchantID int64) *gorm.DB {
+	return s.db.WithContext(ctx).Model(&models.Venue{}).Select("id").Where("merchant_id = ?", merchantID)
+}
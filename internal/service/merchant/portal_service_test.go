@@ -0,0 +1,94 @@
+package merchant
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// portalFixture 一个商户名下的场地、设备、租借和结算数据
+type portalFixture struct {
+	merchant *models.Merchant
+	venue    *models.Venue
+	device   *models.Device
+	rental   *models.Rental
+}
+
+func createPortalFixture(t *testing.T, db *gorm.DB, name string) *portalFixture {
+	t.Helper()
+
+	merchant := createTestMerchant(t, db, name)
+	venue := &models.Venue{MerchantID: merchant.ID, Name: name + "场地", Type: "mall", Province: "广东省", City: "深圳市", District: "南山区", Address: "科技园"}
+	require.NoError(t, db.Create(venue).Error)
+	device := &models.Device{DeviceNo: name + "-D1", Name: name + "设备", Type: "standard", VenueID: venue.ID, QRCode: name + "-qr", ProductName: "充电宝"}
+	require.NoError(t, db.Create(device).Error)
+	rental := &models.Rental{OrderID: device.ID, UserID: 1, DeviceID: device.ID, DurationHours: 1, RentalFee: 5, Deposit: 99, Status: models.RentalStatusInUse}
+	require.NoError(t, db.Create(rental).Error)
+	settlement := &models.Settlement{
+		SettlementNo: name + "-S1", Type: models.SettlementTypeMerchant, TargetID: merchant.ID,
+		PeriodStart: time.Now().AddDate(0, 0, -7), PeriodEnd: time.Now(),
+		TotalAmount: 100, ActualAmount: 80, OrderCount: 3, Status: "pending",
+	}
+	require.NoError(t, db.Create(settlement).Error)
+
+	return &portalFixture{merchant: merchant, venue: venue, device: device, rental: rental}
+}
+
+func TestMerchantPortalService_Isolation(t *testing.T) {
+	db := setupMerchantTestDB(t)
+	svc := NewMerchantPortalService(db)
+	ctx := context.Background()
+
+	a := createPortalFixture(t, db, "A")
+	b := createPortalFixture(t, db, "B")
+
+	// 同 ID 的分销商结算不属于商户
+	require.NoError(t, db.Create(&models.Settlement{
+		SettlementNo: "DIST-S1", Type: models.SettlementTypeDistributor, TargetID: a.merchant.ID,
+		PeriodStart: time.Now(), PeriodEnd: time.Now(), Status: "pending",
+	}).Error)
+
+	settlements, total, err := svc.ListSettlements(ctx, a.merchant.ID, 1, 20, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "A-S1", settlements[0].SettlementNo)
+
+	venues, total, err := svc.ListVenues(ctx, a.merchant.ID, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, a.venue.ID, venues[0].ID)
+
+	devices, total, err := svc.ListDevices(ctx, a.merchant.ID, 1, 20, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, a.device.ID, devices[0].ID)
+
+	// 按其他商户的场地筛选得不到数据
+	_, total, err = svc.ListDevices(ctx, a.merchant.ID, 1, 20, &PortalDeviceFilters{VenueID: b.venue.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+
+	rentals, total, err := svc.ListRentals(ctx, a.merchant.ID, 1, 20, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, a.rental.ID, rentals[0].ID)
+
+	_, total, err = svc.ListRentals(ctx, a.merchant.ID, 1, 20, &PortalRentalFilters{DeviceID: b.device.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+
+	// 设备删除后历史租借仍可查询
+	require.NoError(t, db.Delete(a.device).Error)
+	_, total, err = svc.ListDevices(ctx, a.merchant.ID, 1, 20, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	_, total, err = svc.ListRentals(ctx, a.merchant.ID, 1, 20, &PortalRentalFilters{Status: models.RentalStatusInUse})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+}
@@ -0,0 +1,317 @@
+package order
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/pkg/wechatpay"
+)
+
+// adminRefundIdempotencyKeyMaxLen 管理员退款幂等键最大长度
+const adminRefundIdempotencyKeyMaxLen = 128
+
+// adminRefundableStatuses 允许管理员发起退款的订单状态
+var adminRefundableStatuses = map[string]bool{
+	models.OrderStatusPaid:            true,
+	models.OrderStatusPendingShip:     true,
+	models.OrderStatusShipping:        true,
+	models.OrderStatusShipped:         true,
+	models.OrderStatusDelivered:       true,
+	models.OrderStatusCompleted:       true,
+	models.OrderStatusPartialRefunded: true,
+}
+
+// walletRefunder 钱包退款接口
+type walletRefunder interface {
+	RefundTx(ctx context.Context, tx *gorm.DB, userID int64, amount float64, orderNo string) error
+}
+
+// refundGateway 第三方支付渠道退款接口
+type refundGateway interface {
+	Refund(ctx context.Context, req *wechatpay.RefundRequest) (*wechatpay.RefundResponse, error)
+}
+
+// commissionRecalculator 佣金重算接口
+type commissionRecalculator interface {
+	RecalculateCommission(ctx context.Context, orderID int64, newActualAmount float64) error
+}
+
+// financeOverviewInvalidator 财务概览失效接口
+type financeOverviewInvalidator interface {
+	InvalidateOrderOverview(ctx context.Context, orderID int64) error
+}
+
+// SetWalletRefunder 设置钱包退款服务，余额支付的订单退款时退回钱包余额
+func (s *RefundService) SetWalletRefunder(wallet walletRefunder) {
+	s.wallet = wallet
+}
+
+// SetRefundGateway 设置微信支付退款渠道，未设置时微信支付订单的退款停留在已批准状态等待人工处理
+func (s *RefundService) SetRefundGateway(gateway refundGateway) {
+	s.gateway = gateway
+}
+
+// SetCommissionRecalculator 设置佣金重算服务，退款后按订单剩余实付金额重算佣金
+func (s *RefundService) SetCommissionRecalculator(recalculator commissionRecalculator) {
+	s.commissions = recalculator
+}
+
+// SetFinanceOverviewInvalidator 设置财务概览失效服务，退款后按订单重新计算财务日汇总并清除概览缓存
+func (s *RefundService) SetFinanceOverviewInvalidator(invalidator financeOverviewInvalidator) {
+	s.overview = invalidator
+}
+
+// CreateAdminRefund 管理员发起订单退款，支持部分退款
+// 退款金额不能超过订单实付金额减去已申请及已退款的金额；余额支付的订单直接退回钱包，
+// 微信支付的订单先记录已批准的退款再在事务外调用支付渠道退款，避免渠道已退款而事务回滚导致退款无记录。
+// 退完后订单变更为已退款，否则变更为部分退款，并按剩余实付金额重算佣金。
+// 携带幂等键时，相同幂等键的重复提交返回首次创建的退款，不会重复退款
+func (s *RefundService) CreateAdminRefund(ctx context.Context, orderID int64, amount float64, reason, idempotencyKey string, adminID int64) (*models.Refund, error) {
+	amount = roundRefundAmount(amount)
+	if amount <= 0 {
+		return nil, errors.ErrInvalidParams.WithMessage("退款金额必须大于0")
+	}
+	if len(idempotencyKey) > adminRefundIdempotencyKeyMaxLen {
+		return nil, errors.ErrInvalidParams.WithMessage("幂等键过长")
+	}
+
+	var (
+		refund     *models.Refund
+		payment    models.Payment
+		remaining  float64
+		duplicated bool
+	)
+	viaGateway := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.ErrOrderNotFound
+			}
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		if idempotencyKey != "" {
+			existing, err := findAdminRefundByIdempotencyKey(tx, idempotencyKey)
+			if err != nil {
+				return errors.ErrDatabaseError.WithError(err)
+			}
+			if existing != nil {
+				if existing.OrderID != orderID || existing.Amount != amount || existing.Reason != reason {
+					return errors.ErrIdempotencyConflict
+				}
+				refund = existing
+				duplicated = true
+				return nil
+			}
+		}
+
+		if !adminRefundableStatuses[order.Status] {
+			return errors.ErrOrderStatusError.WithMessage("订单状态不允许退款")
+		}
+
+		if err := tx.Where("order_id = ? AND status = ?", orderID, models.PaymentStatusSuccess).
+			First(&payment).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.ErrPaymentNotFound
+			}
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		refunded, err := occupiedRefundAmount(tx, orderID)
+		if err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		if amount > roundRefundAmount(order.ActualAmount-refunded) {
+			return errors.ErrRefundAmountExceed
+		}
+
+		operatorType := models.RefundOperatorAdmin
+		refund = &models.Refund{
+			RefundNo:     utils.GenerateOrderNo("R"),
+			OrderID:      order.ID,
+			OrderNo:      order.OrderNo,
+			PaymentID:    payment.ID,
+			PaymentNo:    payment.PaymentNo,
+			UserID:       order.UserID,
+			Amount:       amount,
+			Reason:       reason,
+			Status:       models.RefundStatusApproved,
+			OperatorID:   &adminID,
+			OperatorType: &operatorType,
+		}
+		if idempotencyKey != "" {
+			refund.IdempotencyKey = &idempotencyKey
+		}
+		if err := tx.Create(refund).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		// 微信支付退款在事务提交后调用渠道，已批准的退款记录先占用可退额度
+		if payment.PaymentMethod == models.PaymentMethodWechat && s.gateway != nil {
+			viaGateway = true
+			return nil
+		}
+
+		if payment.PaymentMethod == models.PaymentMethodBalance && s.wallet != nil {
+			if err := s.wallet.RefundTx(ctx, tx, refund.UserID, refund.Amount, refund.OrderNo); err != nil {
+				return err
+			}
+			now := time.Now()
+			refund.Status = models.RefundStatusSuccess
+			refund.RefundedAt = &now
+			if err := tx.Model(&models.Refund{}).Where("id = ?", refund.ID).Updates(map[string]interface{}{
+				"status":      refund.Status,
+				"refunded_at": now,
+			}).Error; err != nil {
+				return errors.ErrDatabaseError.WithError(err)
+			}
+		}
+
+		remaining, err = finishAdminRefundTx(tx, &order, &payment, refund)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if duplicated {
+		return refund, nil
+	}
+
+	if viaGateway {
+		if remaining, err = s.submitGatewayRefund(ctx, refund, &payment, reason); err != nil {
+			return nil, err
+		}
+	}
+
+	// 佣金重算失败不影响退款结果
+	if s.commissions != nil {
+		_ = s.commissions.RecalculateCommission(ctx, orderID, remaining)
+	}
+	// 全额退款变更历史支付状态、佣金重算调整历史佣金，需在佣金重算之后刷新财务概览
+	if s.overview != nil {
+		_ = s.overview.InvalidateOrderOverview(ctx, orderID)
+	}
+	return refund, nil
+}
+
+// submitGatewayRefund 调用微信支付渠道退款并更新退款及订单状态，返回订单剩余可退金额
+// 渠道调用失败时退款标记为失败并释放可退额度；渠道已受理但状态更新失败时退款保持已批准状态，
+// 可使用相同的退款单号重新提交（渠道按退款单号去重）
+func (s *RefundService) submitGatewayRefund(ctx context.Context, refund *models.Refund, payment *models.Payment, reason string) (float64, error) {
+	resp, err := s.gateway.Refund(ctx, &wechatpay.RefundRequest{
+		OutTradeNo:  payment.PaymentNo,
+		OutRefundNo: refund.RefundNo,
+		Reason:      reason,
+		Total:       int64(math.Round(payment.Amount * 100)),
+		Refund:      int64(math.Round(refund.Amount * 100)),
+	})
+	if err != nil {
+		if dbErr := s.db.WithContext(ctx).Model(&models.Refund{}).
+			Where("id = ? AND status = ?", refund.ID, models.RefundStatusApproved).
+			Update("status", models.RefundStatusFailed).Error; dbErr != nil {
+			return 0, errors.ErrDatabaseError.WithError(dbErr)
+		}
+		return 0, errors.ErrRefundFailed.WithError(err)
+	}
+
+	var remaining float64
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, refund.OrderID).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		transactionID := resp.RefundID
+		result := tx.Model(&models.Refund{}).
+			Where("id = ? AND status = ?", refund.ID, models.RefundStatusApproved).
+			Updates(map[string]interface{}{
+				"status":         models.RefundStatusProcessing,
+				"transaction_id": transactionID,
+			})
+		if result.Error != nil {
+			return errors.ErrDatabaseError.WithError(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrOperationFailed.WithMessage("退款状态已变更")
+		}
+		refund.Status = models.RefundStatusProcessing
+		refund.TransactionID = &transactionID
+
+		var err error
+		remaining, err = finishAdminRefundTx(tx, &order, payment, refund)
+		return err
+	})
+	return remaining, err
+}
+
+// finishAdminRefundTx 记录退款备注并按剩余可退金额更新订单状态，退完时支付变更为已退款，返回剩余可退金额
+func finishAdminRefundTx(tx *gorm.DB, order *models.Order, payment *models.Payment, refund *models.Refund) (float64, error) {
+	if err := tx.Create(NewSystemNote(order.ID, RefundApprovedNote(refund.Amount), true)).Error; err != nil {
+		return 0, errors.ErrDatabaseError.WithError(err)
+	}
+
+	refunded, err := occupiedRefundAmount(tx, order.ID)
+	if err != nil {
+		return 0, errors.ErrDatabaseError.WithError(err)
+	}
+	remaining := roundRefundAmount(order.ActualAmount - refunded)
+
+	orderStatus := models.OrderStatusPartialRefunded
+	if remaining <= 0 {
+		orderStatus = models.OrderStatusRefunded
+		if err := tx.Model(&models.Payment{}).Where("id = ?", payment.ID).
+			Update("status", models.PaymentStatusRefunded).Error; err != nil {
+			return 0, errors.ErrDatabaseError.WithError(err)
+		}
+		if err := CancelGiftOrdersTx(tx, order.ID, GiftOrderRefundCancelReason); err != nil {
+			return 0, errors.ErrDatabaseError.WithError(err)
+		}
+	}
+	if err := tx.Model(&models.Order{}).Where("id = ?", order.ID).
+		Update("status", orderStatus).Error; err != nil {
+		return 0, errors.ErrDatabaseError.WithError(err)
+	}
+	return remaining, nil
+}
+
+// occupiedRefundAmount 统计订单已申请及已退款的金额，已申请的退款同样占用可退额度
+func occupiedRefundAmount(tx *gorm.DB, orderID int64) (float64, error) {
+	var refunded float64
+	err := tx.Model(&models.Refund{}).
+		Where("order_id = ?", orderID).
+		Where("status IN ?", []int8{
+			models.RefundStatusPending,
+			models.RefundStatusApproved,
+			models.RefundStatusProcessing,
+			models.RefundStatusSuccess,
+		}).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&refunded).Error
+	return refunded, err
+}
+
+// findAdminRefundByIdempotencyKey 根据幂等键查找管理员发起的退款
+func findAdminRefundByIdempotencyKey(tx *gorm.DB, idempotencyKey string) (*models.Refund, error) {
+	var refund models.Refund
+	err := tx.Where("idempotency_key = ?", idempotencyKey).First(&refund).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// roundRefundAmount 金额保留两位小数
+func roundRefundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,259 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	userService "github.com/dumeirei/smart-locker-backend/internal/service/user"
+	"github.com/dumeirei/smart-locker-backend/pkg/wechatpay"
+)
+
+// stubCommissionRecalculator 记录佣金重算调用
+type stubCommissionRecalculator struct {
+	amounts []float64
+}
+
+func (s *stubCommissionRecalculator) RecalculateCommission(_ context.Context, _ int64, newActualAmount float64) error {
+	s.amounts = append(s.amounts, newActualAmount)
+	return nil
+}
+
+func setupAdminRefundService(t *testing.T) (*gorm.DB, *RefundService, *stubCommissionRecalculator) {
+	t.Helper()
+
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.UserWallet{}, &models.WalletTransaction{}))
+
+	svc := setupRefundService(db)
+	svc.SetWalletRefunder(userService.NewWalletService(db, repository.NewUserRepository(db), nil))
+	recalculator := &stubCommissionRecalculator{}
+	svc.SetCommissionRecalculator(recalculator)
+	return db, svc, recalculator
+}
+
+func walletBalance(t *testing.T, db *gorm.DB, userID int64) float64 {
+	t.Helper()
+
+	var wallet models.UserWallet
+	require.NoError(t, db.Where("user_id = ?", userID).First(&wallet).Error)
+	return wallet.Balance
+}
+
+func TestRefundService_CreateAdminRefund_Full(t *testing.T) {
+	db, svc, recalculator := setupAdminRefundService(t)
+	ctx := context.Background()
+
+	user := createTestUser(t, db, "13800138100")
+	require.NoError(t, db.Create(&models.UserWallet{UserID: user.ID, Balance: 10}).Error)
+	order := createPaidOrder(t, db, user.ID, models.OrderStatusCompleted, 100.0)
+	payment := createPayment(t, db, user.ID, order.ID, order.OrderNo, 100.0, models.PaymentStatusSuccess)
+
+	refund, err := svc.CreateAdminRefund(ctx, order.ID, 100.0, "商品质量问题", "", 9)
+	require.NoError(t, err)
+	assert.EqualValues(t, models.RefundStatusSuccess, refund.Status)
+	assert.Equal(t, "商品质量问题", refund.Reason)
+	require.NotNil(t, refund.OperatorID)
+	assert.Equal(t, int64(9), *refund.OperatorID)
+	assert.NotNil(t, refund.RefundedAt)
+
+	assert.Equal(t, 110.0, walletBalance(t, db, user.ID))
+
+	var updated models.Order
+	require.NoError(t, db.First(&updated, order.ID).Error)
+	assert.Equal(t, models.OrderStatusRefunded, updated.Status)
+
+	var updatedPayment models.Payment
+	require.NoError(t, db.First(&updatedPayment, payment.ID).Error)
+	assert.EqualValues(t, models.PaymentStatusRefunded, updatedPayment.Status)
+
+	assert.Equal(t, []float64{0}, recalculator.amounts)
+}
+
+func TestRefundService_CreateAdminRefund_Partial(t *testing.T) {
+	db, svc, recalculator := setupAdminRefundService(t)
+	ctx := context.Background()
+
+	user := createTestUser(t, db, "13800138101")
+	require.NoError(t, db.Create(&models.UserWallet{UserID: user.ID}).Error)
+	order := createPaidOrder(t, db, user.ID, models.OrderStatusPaid, 100.0)
+	createPayment(t, db, user.ID, order.ID, order.OrderNo, 100.0, models.PaymentStatusSuccess)
+
+	_, err := svc.CreateAdminRefund(ctx, order.ID, 30.0, "少发一件", "", 9)
+	require.NoError(t, err)
+
+	var updated models.Order
+	require.NoError(t, db.First(&updated, order.ID).Error)
+	assert.Equal(t, models.OrderStatusPartialRefunded, updated.Status)
+	assert.Equal(t, 30.0, walletBalance(t, db, user.ID))
+
+	// 部分退款后可以继续退剩余金额
+	_, err = svc.CreateAdminRefund(ctx, order.ID, 70.0, "整单退货", "", 9)
+	require.NoError(t, err)
+
+	require.NoError(t, db.First(&updated, order.ID).Error)
+	assert.Equal(t, models.OrderStatusRefunded, updated.Status)
+	assert.Equal(t, 100.0, walletBalance(t, db, user.ID))
+	assert.Equal(t, []float64{70, 0}, recalculator.amounts)
+}
+
+func TestRefundService_CreateAdminRefund_OverRefund(t *testing.T) {
+	db, svc, recalculator := setupAdminRefundService(t)
+	ctx := context.Background()
+
+	user := createTestUser(t, db, "13800138102")
+	require.NoError(t, db.Create(&models.UserWallet{UserID: user.ID}).Error)
+	order := createPaidOrder(t, db, user.ID, models.OrderStatusPaid, 100.0)
+	createPayment(t, db, user.ID, order.ID, order.OrderNo, 100.0, models.PaymentStatusSuccess)
+
+	t.Run("超过实付金额", func(t *testing.T) {
+		_, err := svc.CreateAdminRefund(ctx, order.ID, 100.01, "退款", "", 9)
+		assert.ErrorIs(t, err, appErrors.ErrRefundAmountExceed)
+	})
+
+	t.Run("超过剩余可退金额", func(t *testing.T) {
+		// 用户已申请的退款占用可退额度
+		require.NoError(t, db.Create(&models.Refund{
+			RefundNo:  "RPENDING001",
+			OrderID:   order.ID,
+			OrderNo:   order.OrderNo,
+			PaymentNo: "P-PENDING",
+			UserID:    user.ID,
+			Amount:    60.0,
+			Reason:    "用户申请",
+			Status:    models.RefundStatusPending,
+		}).Error)
+
+		_, err := svc.CreateAdminRefund(ctx, order.ID, 50.0, "退款", "", 9)
+		assert.ErrorIs(t, err, appErrors.ErrRefundAmountExceed)
+	})
+
+	t.Run("金额无效", func(t *testing.T) {
+		_, err := svc.CreateAdminRefund(ctx, order.ID, 0, "退款", "", 9)
+		require.Error(t, err)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrInvalidParams.Code, appErr.Code)
+	})
+
+	t.Run("订单不存在", func(t *testing.T) {
+		_, err := svc.CreateAdminRefund(ctx, 99999, 10.0, "退款", "", 9)
+		assert.ErrorIs(t, err, appErrors.ErrOrderNotFound)
+	})
+
+	t.Run("待支付订单不能退款", func(t *testing.T) {
+		pending := createPaidOrder(t, db, user.ID, models.OrderStatusPending, 50.0)
+		_, err := svc.CreateAdminRefund(ctx, pending.ID, 10.0, "退款", "", 9)
+		require.Error(t, err)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrOrderStatusError.Code, appErr.Code)
+	})
+
+	assert.Equal(t, 0.0, walletBalance(t, db, user.ID))
+	assert.Empty(t, recalculator.amounts)
+}
+
+func TestRefundService_CreateAdminRefund_IdempotencyKey(t *testing.T) {
+	db, svc, recalculator := setupAdminRefundService(t)
+	ctx := context.Background()
+
+	user := createTestUser(t, db, "13800138103")
+	require.NoError(t, db.Create(&models.UserWallet{UserID: user.ID}).Error)
+	order := createPaidOrder(t, db, user.ID, models.OrderStatusPaid, 100.0)
+	createPayment(t, db, user.ID, order.ID, order.OrderNo, 100.0, models.PaymentStatusSuccess)
+
+	first, err := svc.CreateAdminRefund(ctx, order.ID, 40.0, "重复提交", "refund-key-1", 9)
+	require.NoError(t, err)
+	second, err := svc.CreateAdminRefund(ctx, order.ID, 40.0, "重复提交", "refund-key-1", 9)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, second.ID)
+	assert.Equal(t, first.RefundNo, second.RefundNo)
+
+	var count int64
+	db.Model(&models.Refund{}).Where("order_id = ?", order.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, 40.0, walletBalance(t, db, user.ID))
+	assert.Equal(t, []float64{60}, recalculator.amounts)
+
+	t.Run("相同幂等键用于不同退款", func(t *testing.T) {
+		_, err := svc.CreateAdminRefund(ctx, order.ID, 30.0, "重复提交", "refund-key-1", 9)
+		assert.ErrorIs(t, err, appErrors.ErrIdempotencyConflict)
+	})
+
+	t.Run("不同幂等键的相同金额退款视为新的部分退款", func(t *testing.T) {
+		_, err := svc.CreateAdminRefund(ctx, order.ID, 40.0, "重复提交", "refund-key-2", 9)
+		require.NoError(t, err)
+		_, err = svc.CreateAdminRefund(ctx, order.ID, 10.0, "补退", "", 9)
+		require.NoError(t, err)
+		_, err = svc.CreateAdminRefund(ctx, order.ID, 10.0, "补退", "", 9)
+		require.NoError(t, err)
+		assert.Equal(t, 100.0, walletBalance(t, db, user.ID))
+	})
+}
+
+// stubRefundGateway 模拟微信支付退款渠道
+type stubRefundGateway struct {
+	err      error
+	requests []*wechatpay.RefundRequest
+}
+
+func (g *stubRefundGateway) Refund(_ context.Context, req *wechatpay.RefundRequest) (*wechatpay.RefundResponse, error) {
+	g.requests = append(g.requests, req)
+	if g.err != nil {
+		return nil, g.err
+	}
+	return &wechatpay.RefundResponse{RefundID: "WX-" + req.OutRefundNo}, nil
+}
+
+func TestRefundService_CreateAdminRefund_WechatGateway(t *testing.T) {
+	db, svc, recalculator := setupAdminRefundService(t)
+	ctx := context.Background()
+	gateway := &stubRefundGateway{}
+	svc.SetRefundGateway(gateway)
+
+	user := createTestUser(t, db, "13800138104")
+	order := createPaidOrder(t, db, user.ID, models.OrderStatusPaid, 100.0)
+	payment := createPayment(t, db, user.ID, order.ID, order.OrderNo, 100.0, models.PaymentStatusSuccess)
+	require.NoError(t, db.Model(payment).Update("payment_method", models.PaymentMethodWechat).Error)
+
+	t.Run("渠道调用失败时退款标记为失败且订单状态不变", func(t *testing.T) {
+		gateway.err = fmt.Errorf("network error")
+		_, err := svc.CreateAdminRefund(ctx, order.ID, 100.0, "退款", "", 9)
+		require.Error(t, err)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrRefundFailed.Code, appErr.Code)
+
+		var refund models.Refund
+		require.NoError(t, db.Where("order_id = ?", order.ID).First(&refund).Error)
+		assert.EqualValues(t, models.RefundStatusFailed, refund.Status)
+
+		var updated models.Order
+		require.NoError(t, db.First(&updated, order.ID).Error)
+		assert.Equal(t, models.OrderStatusPaid, updated.Status)
+		assert.Empty(t, recalculator.amounts)
+	})
+
+	t.Run("渠道受理后退款处理中并更新订单状态", func(t *testing.T) {
+		gateway.err = nil
+		refund, err := svc.CreateAdminRefund(ctx, order.ID, 100.0, "退款", "", 9)
+		require.NoError(t, err)
+		assert.EqualValues(t, models.RefundStatusProcessing, refund.Status)
+		require.NotNil(t, refund.TransactionID)
+		assert.Equal(t, "WX-"+refund.RefundNo, *refund.TransactionID)
+		assert.Equal(t, int64(10000), gateway.requests[len(gateway.requests)-1].Refund)
+
+		var updated models.Order
+		require.NoError(t, db.First(&updated, order.ID).Error)
+		assert.Equal(t, models.OrderStatusRefunded, updated.Status)
+		assert.Equal(t, []float64{0}, recalculator.amounts)
+	})
+}
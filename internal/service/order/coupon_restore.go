@@ -0,0 +1,34 @@
+// Package order 提供订单相关服务
+package order
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// RestoreOrderCouponTx 在事务中恢复订单使用的优惠券（订单取消或全部退款时调用）
+// 用户优惠券恢复为未使用，优惠券已使用数量减一；订单未使用优惠券时不做处理
+func RestoreOrderCouponTx(tx *gorm.DB, orderID int64) error {
+	var userCoupons []*models.UserCoupon
+	if err := tx.Where("order_id = ? AND status = ?", orderID, models.UserCouponStatusUsed).
+		Find(&userCoupons).Error; err != nil {
+		return err
+	}
+
+	for _, uc := range userCoupons {
+		if err := tx.Model(&models.UserCoupon{}).Where("id = ?", uc.ID).Updates(map[string]interface{}{
+			"status":   models.UserCouponStatusUnused,
+			"order_id": nil,
+			"used_at":  nil,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Coupon{}).
+			Where("id = ? AND used_count > 0", uc.CouponID).
+			UpdateColumn("used_count", gorm.Expr("used_count - 1")).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
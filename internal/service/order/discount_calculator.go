@@ -10,8 +10,10 @@ import (
 
 // DiscountCalculator 订单优惠计算器
 type DiscountCalculator struct {
-	couponService   *marketingService.CouponService
-	campaignService *marketingService.CampaignService
+	couponService         *marketingService.CouponService
+	campaignService       *marketingService.CampaignService
+	memberDiscountService *MemberDiscountService
+	giftCampaignService   *marketingService.GiftCampaignService
 }
 
 // NewDiscountCalculator 创建订单优惠计算器
@@ -22,33 +24,140 @@ func NewDiscountCalculator(couponSvc *marketingService.CouponService, campaignSv
 	}
 }
 
+// SetMemberDiscountService 设置会员折扣服务，设置后计算订单优惠时先按会员等级折扣打折，再计算活动和优惠券
+func (c *DiscountCalculator) SetMemberDiscountService(memberDiscountService *MemberDiscountService) {
+	c.memberDiscountService = memberDiscountService
+}
+
+// SetGiftCampaignService 设置满赠活动服务，设置后计算订单优惠时按实付金额匹配满赠活动
+func (c *DiscountCalculator) SetGiftCampaignService(giftCampaignService *marketingService.GiftCampaignService) {
+	c.giftCampaignService = giftCampaignService
+}
+
 // DiscountResult 优惠结果
 type DiscountResult struct {
-	OriginalAmount   float64                  `json:"original_amount"`    // 原始金额
-	FinalAmount      float64                  `json:"final_amount"`       // 最终金额
-	TotalDiscount    float64                  `json:"total_discount"`     // 总优惠金额
-	CouponDiscount   float64                  `json:"coupon_discount"`    // 优惠券优惠金额
-	CampaignDiscount float64                  `json:"campaign_discount"`  // 活动优惠金额
-	UserCoupon       *models.UserCoupon       `json:"user_coupon,omitempty"`
-	Campaign         *models.Campaign         `json:"campaign,omitempty"`
-	DiscountDetails  []*DiscountDetail        `json:"discount_details"`   // 优惠明细
+	OriginalAmount   float64            `json:"original_amount"`   // 原始金额
+	FinalAmount      float64            `json:"final_amount"`      // 最终金额
+	TotalDiscount    float64            `json:"total_discount"`    // 总优惠金额
+	MemberDiscount   float64            `json:"member_discount"`   // 会员折扣金额
+	CouponDiscount   float64            `json:"coupon_discount"`   // 优惠券优惠金额
+	CampaignDiscount float64            `json:"campaign_discount"` // 活动优惠金额
+	MemberLevel      *MemberLevelInfo   `json:"member_level,omitempty"`
+	UserCoupon       *models.UserCoupon `json:"user_coupon,omitempty"`
+	Campaign         *models.Campaign   `json:"campaign,omitempty"`
+	Gift             *GiftResult        `json:"gift,omitempty"`   // 满足的满赠活动
+	DiscountDetails  []*DiscountDetail  `json:"discount_details"` // 优惠明细
+}
+
+// GiftResult 满赠结果
+type GiftResult struct {
+	Campaign *models.Campaign           `json:"campaign"`
+	Item     *marketingService.GiftItem `json:"item"`
 }
 
 // DiscountDetail 优惠明细
 type DiscountDetail struct {
-	Type        string  `json:"type"`        // 优惠类型：coupon/campaign
+	Type        string  `json:"type"`        // 优惠类型：member/coupon/campaign
 	Name        string  `json:"name"`        // 优惠名称
 	Amount      float64 `json:"amount"`      // 优惠金额
 	Description string  `json:"description"` // 优惠描述
 }
 
 // CalculateOrderDiscount 计算订单优惠
+// 优惠计算顺序：会员折扣（设置了会员折扣服务时）-> 活动优惠 -> 优惠券，最后按实付金额匹配满赠活动
 // 参数:
 //   - userID: 用户ID
 //   - orderType: 订单类型 (rental/mall/hotel)
 //   - orderAmount: 订单金额
+//   - items: 订单商品组成（可选），用于限定商品/分类的优惠券
 //   - userCouponID: 用户选择的优惠券ID（可选）
-func (c *DiscountCalculator) CalculateOrderDiscount(ctx context.Context, userID int64, orderType string, orderAmount float64, userCouponID *int64) (*DiscountResult, error) {
+func (c *DiscountCalculator) CalculateOrderDiscount(ctx context.Context, userID int64, orderType string, orderAmount float64, items []*marketingService.OrderLineItem, userCouponID *int64) (*DiscountResult, error) {
+	result := &DiscountResult{
+		OriginalAmount:  orderAmount,
+		FinalAmount:     orderAmount,
+		DiscountDetails: make([]*DiscountDetail, 0),
+	}
+
+	// 1. 计算会员折扣（基于原始金额）
+	afterMemberAmount := orderAmount
+	if c.memberDiscountService != nil {
+		memberResult, err := c.memberDiscountService.CalculateMemberDiscount(ctx, userID, orderAmount)
+		if err != nil {
+			return nil, err
+		}
+		if memberResult.HasMemberDiscount {
+			result.MemberDiscount = memberResult.DiscountAmount
+			result.MemberLevel = &MemberLevelInfo{
+				ID:       memberResult.MemberLevelID,
+				Name:     memberResult.MemberLevelName,
+				Discount: memberResult.DiscountRate,
+			}
+			result.DiscountDetails = append(result.DiscountDetails, &DiscountDetail{
+				Type:        "member",
+				Name:        memberResult.MemberLevelName,
+				Amount:      memberResult.DiscountAmount,
+				Description: c.memberDiscountService.GetDiscountDescription(memberResult.DiscountRate, memberResult.MemberLevelName),
+			})
+			afterMemberAmount = memberResult.FinalAmount
+		}
+	}
+
+	// 2. 基于会员折后金额计算活动和优惠券优惠
+	promotion, err := c.calculatePromotionDiscount(ctx, userID, orderType, afterMemberAmount, items, userCouponID)
+	if err != nil {
+		return nil, err
+	}
+	result.CampaignDiscount = promotion.CampaignDiscount
+	result.CouponDiscount = promotion.CouponDiscount
+	result.Campaign = promotion.Campaign
+	result.UserCoupon = promotion.UserCoupon
+	result.DiscountDetails = append(result.DiscountDetails, promotion.DiscountDetails...)
+
+	// 3. 计算最终金额
+	result.TotalDiscount = result.MemberDiscount + result.CampaignDiscount + result.CouponDiscount
+	result.FinalAmount = orderAmount - result.TotalDiscount
+
+	// 确保最终金额不为负
+	if result.FinalAmount < 0 {
+		result.FinalAmount = 0
+	}
+
+	// 4. 按实付金额匹配满赠活动
+	gift, err := c.CheckGift(ctx, result.FinalAmount)
+	if err != nil {
+		return nil, err
+	}
+	result.Gift = gift
+
+	return result, nil
+}
+
+// CalculateMemberDiscountAmount 按用户会员等级折扣计算优惠金额，未设置会员折扣服务或无折扣时返回 0
+func (c *DiscountCalculator) CalculateMemberDiscountAmount(ctx context.Context, userID int64, amount float64) (float64, error) {
+	if c.memberDiscountService == nil {
+		return 0, nil
+	}
+	memberResult, err := c.memberDiscountService.CalculateMemberDiscount(ctx, userID, amount)
+	if err != nil {
+		return 0, err
+	}
+	return memberResult.DiscountAmount, nil
+}
+
+// CheckGift 按实付金额匹配进行中的满赠活动，未设置满赠活动服务或未达门槛时返回 nil
+func (c *DiscountCalculator) CheckGift(ctx context.Context, payAmount float64) (*GiftResult, error) {
+	if c.giftCampaignService == nil {
+		return nil, nil
+	}
+	campaign, item, err := c.giftCampaignService.FindEligibleGift(ctx, payAmount)
+	if err != nil || campaign == nil {
+		return nil, err
+	}
+	return &GiftResult{Campaign: campaign, Item: item}, nil
+}
+
+// calculatePromotionDiscount 计算活动优惠和优惠券优惠（不含会员折扣）
+func (c *DiscountCalculator) calculatePromotionDiscount(ctx context.Context, userID int64, orderType string, orderAmount float64, items []*marketingService.OrderLineItem, userCouponID *int64) (*DiscountResult, error) {
 	result := &DiscountResult{
 		OriginalAmount:  orderAmount,
 		FinalAmount:     orderAmount,
@@ -77,7 +186,7 @@ func (c *DiscountCalculator) CalculateOrderDiscount(ctx context.Context, userID
 
 	if userCouponID != nil {
 		// 用户指定了优惠券：若该券对当前订单可用，则按该券计算；否则不使用优惠券且不报错。
-		userCoupon, couponDiscount, err := c.couponService.GetUserCouponForOrder(ctx, userID, *userCouponID, orderType, afterCampaignAmount)
+		userCoupon, couponDiscount, err := c.couponService.GetUserCouponForOrder(ctx, userID, *userCouponID, orderType, afterCampaignAmount, items)
 		if err != nil {
 			return nil, err
 		}
@@ -95,7 +204,7 @@ func (c *DiscountCalculator) CalculateOrderDiscount(ctx context.Context, userID
 		}
 	} else {
 		// 自动选择最优优惠券
-		bestCoupon, couponDiscount, err := c.couponService.GetBestCouponForOrder(ctx, userID, orderType, afterCampaignAmount)
+		bestCoupon, couponDiscount, err := c.couponService.GetBestCouponForOrder(ctx, userID, orderType, afterCampaignAmount, items)
 		if err != nil {
 			return nil, err
 		}
@@ -126,13 +235,13 @@ func (c *DiscountCalculator) CalculateOrderDiscount(ctx context.Context, userID
 }
 
 // CalculateWithSpecificCoupon 使用指定优惠券计算优惠
-func (c *DiscountCalculator) CalculateWithSpecificCoupon(ctx context.Context, userID int64, orderType string, orderAmount float64, userCouponID int64) (*DiscountResult, error) {
-	return c.CalculateOrderDiscount(ctx, userID, orderType, orderAmount, &userCouponID)
+func (c *DiscountCalculator) CalculateWithSpecificCoupon(ctx context.Context, userID int64, orderType string, orderAmount float64, items []*marketingService.OrderLineItem, userCouponID int64) (*DiscountResult, error) {
+	return c.CalculateOrderDiscount(ctx, userID, orderType, orderAmount, items, &userCouponID)
 }
 
 // GetBestCoupon 获取最优优惠券
-func (c *DiscountCalculator) GetBestCoupon(ctx context.Context, userID int64, orderType string, orderAmount float64) (*models.UserCoupon, float64, error) {
-	return c.couponService.GetBestCouponForOrder(ctx, userID, orderType, orderAmount)
+func (c *DiscountCalculator) GetBestCoupon(ctx context.Context, userID int64, orderType string, orderAmount float64, items []*marketingService.OrderLineItem) (*models.UserCoupon, float64, error) {
+	return c.couponService.GetBestCouponForOrder(ctx, userID, orderType, orderAmount, items)
 }
 
 // PreviewDiscount 预览订单优惠（不使用优惠券）
@@ -0,0 +1,130 @@
+// Package order 会员折扣参与订单优惠计算单元测试
+package order
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	userService "github.com/dumeirei/smart-locker-backend/internal/service/user"
+)
+
+func TestDiscountCalculator_CalculateOrderDiscount_MemberDiscount(t *testing.T) {
+	db := setupEnhancedMemberDiscountTestDB(t)
+	ctx := context.Background()
+
+	userRepo := repository.NewUserRepository(db)
+	levelRepo := repository.NewMemberLevelRepository(db)
+	calc := setupDiscountCalculator(db)
+	calc.SetMemberDiscountService(NewMemberDiscountService(db, userRepo, levelRepo))
+
+	t.Run("普通会员无会员折扣", func(t *testing.T) {
+		user := createEnhancedMemberDiscountTestUser(t, db, 1)
+
+		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 100.0, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, result.MemberDiscount)
+		assert.Nil(t, result.MemberLevel)
+		assert.Equal(t, 100.0, result.FinalAmount)
+	})
+
+	t.Run("会员折扣先于优惠券计算", func(t *testing.T) {
+		user := createEnhancedMemberDiscountTestUser(t, db, 2)
+		coupon := createTestCouponForDiscount(t, db, func(c *models.Coupon) {
+			c.Value = 10.0
+			c.MinAmount = 80.0
+		})
+		require.NoError(t, db.Create(&models.UserCoupon{
+			UserID:     user.ID,
+			CouponID:   coupon.ID,
+			Status:     models.UserCouponStatusUnused,
+			ExpiredAt:  time.Now().Add(24 * time.Hour),
+			ReceivedAt: time.Now(),
+		}).Error)
+
+		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 100.0, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 10.0, result.MemberDiscount)
+		require.NotNil(t, result.MemberLevel)
+		assert.Equal(t, 0.9, result.MemberLevel.Discount)
+		assert.Equal(t, 10.0, result.CouponDiscount)
+		assert.InDelta(t, 20.0, result.TotalDiscount, 0.001)
+		assert.InDelta(t, 80.0, result.FinalAmount, 0.001)
+		require.Len(t, result.DiscountDetails, 2)
+		assert.Equal(t, "member", result.DiscountDetails[0].Type)
+		assert.Equal(t, "coupon", result.DiscountDetails[1].Type)
+	})
+
+	t.Run("会员折后金额不满足优惠券门槛", func(t *testing.T) {
+		user := createEnhancedMemberDiscountTestUser(t, db, 2)
+		coupon := createTestCouponForDiscount(t, db, func(c *models.Coupon) {
+			c.Value = 10.0
+			c.MinAmount = 100.0
+		})
+		require.NoError(t, db.Create(&models.UserCoupon{
+			UserID:     user.ID,
+			CouponID:   coupon.ID,
+			Status:     models.UserCouponStatusUnused,
+			ExpiredAt:  time.Now().Add(24 * time.Hour),
+			ReceivedAt: time.Now(),
+		}).Error)
+
+		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 100.0, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 10.0, result.MemberDiscount)
+		assert.Equal(t, 0.0, result.CouponDiscount)
+		assert.Nil(t, result.UserCoupon)
+		assert.InDelta(t, 90.0, result.FinalAmount, 0.001)
+	})
+}
+
+func TestDiscountCalculator_MemberUpgradeAfterOrderCompleted(t *testing.T) {
+	db := setupEnhancedMemberDiscountTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.UserPointsLog{}, &models.Order{}))
+	ctx := context.Background()
+
+	userRepo := repository.NewUserRepository(db)
+	levelRepo := repository.NewMemberLevelRepository(db)
+	calc := setupDiscountCalculator(db)
+	calc.SetMemberDiscountService(NewMemberDiscountService(db, userRepo, levelRepo))
+	hook := NewPointsHook(db, userService.NewPointsService(db, userRepo, levelRepo))
+
+	user := createEnhancedMemberDiscountTestUser(t, db, 1)
+	require.NoError(t, db.Model(user).Update("points", 90).Error)
+
+	// 未达到黄金会员门槛，不享受会员折扣
+	before, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeRental, 200.0, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, before.MemberDiscount)
+	assert.Equal(t, 200.0, before.FinalAmount)
+
+	// 订单完成获得积分，跨过黄金会员门槛
+	order := &models.Order{
+		OrderNo:        "O_MEMBER_UPGRADE",
+		UserID:         user.ID,
+		Type:           models.OrderTypeRental,
+		OriginalAmount: 20,
+		ActualAmount:   20,
+		Status:         models.OrderStatusCompleted,
+	}
+	require.NoError(t, db.Create(order).Error)
+	require.NoError(t, hook.OnOrderCompleted(ctx, order))
+
+	var refreshed models.User
+	require.NoError(t, db.First(&refreshed, user.ID).Error)
+	assert.Equal(t, 110, refreshed.Points)
+	assert.Equal(t, int64(2), refreshed.MemberLevelID)
+
+	// 下一笔订单按新等级折扣计算
+	after, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeRental, 200.0, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 20.0, after.MemberDiscount)
+	require.NotNil(t, after.MemberLevel)
+	assert.Equal(t, int64(2), after.MemberLevel.ID)
+	assert.InDelta(t, 180.0, after.FinalAmount, 0.001)
+}
@@ -3,6 +3,7 @@ package order
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -111,7 +112,7 @@ func setupDiscountCalculator(db *gorm.DB) *DiscountCalculator {
 	campaignRepo := repository.NewCampaignRepository(db)
 
 	couponSvc := marketing.NewCouponService(db, couponRepo, userCouponRepo)
-	campaignSvc := marketing.NewCampaignService(campaignRepo)
+	campaignSvc := marketing.NewCampaignService(db, campaignRepo)
 
 	return NewDiscountCalculator(couponSvc, campaignSvc)
 }
@@ -124,7 +125,7 @@ func TestDiscountCalculator_CalculateOrderDiscount(t *testing.T) {
 	t.Run("无优惠情况", func(t *testing.T) {
 		user := createDiscountTestUser(t, db, "13800138000")
 
-		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 100.0, nil)
+		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 100.0, nil, nil)
 		require.NoError(t, err)
 		assert.Equal(t, 100.0, result.OriginalAmount)
 		assert.Equal(t, 100.0, result.FinalAmount)
@@ -149,7 +150,7 @@ func TestDiscountCalculator_CalculateOrderDiscount(t *testing.T) {
 		}
 		require.NoError(t, db.Create(userCoupon).Error)
 
-		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 100.0, nil)
+		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 100.0, nil, nil)
 		require.NoError(t, err)
 		assert.Equal(t, 100.0, result.OriginalAmount)
 		assert.Equal(t, 15.0, result.CouponDiscount)
@@ -192,7 +193,7 @@ func TestDiscountCalculator_CalculateOrderDiscount(t *testing.T) {
 		require.NoError(t, db.Create(userCoupon2).Error)
 
 		// 指定使用第一张优惠券
-		result, err := calc.CalculateWithSpecificCoupon(ctx, user.ID, models.OrderTypeMall, 150.0, userCoupon1.ID)
+		result, err := calc.CalculateWithSpecificCoupon(ctx, user.ID, models.OrderTypeMall, 150.0, nil, userCoupon1.ID)
 		require.NoError(t, err)
 
 		assert.Equal(t, 150.0, result.OriginalAmount)
@@ -218,12 +219,44 @@ func TestDiscountCalculator_CalculateOrderDiscount(t *testing.T) {
 		}
 		require.NoError(t, db.Create(userCoupon).Error)
 
-		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 30.0, nil)
+		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 30.0, nil, nil)
 		require.NoError(t, err)
 		assert.Equal(t, 30.0, result.OriginalAmount)
 		// 优惠金额不能超过订单金额
 		assert.True(t, result.FinalAmount >= 0)
 	})
+
+	t.Run("限定分类优惠券按命中商品计算", func(t *testing.T) {
+		user := createDiscountTestUser(t, db, "13800138004")
+		coupon := createTestCouponForDiscount(t, db, func(c *models.Coupon) {
+			c.Type = models.CouponTypePercent
+			c.Value = 0.5
+			c.MinAmount = 0
+			c.ApplicableItems = &models.CouponApplicableItems{CategoryIDs: []int64{7}}
+		})
+		userCoupon := &models.UserCoupon{
+			UserID:     user.ID,
+			CouponID:   coupon.ID,
+			Status:     models.UserCouponStatusUnused,
+			ExpiredAt:  time.Now().Add(24 * time.Hour),
+			ReceivedAt: time.Now(),
+		}
+		require.NoError(t, db.Create(userCoupon).Error)
+
+		items := []*marketing.OrderLineItem{
+			{ProductID: 1, CategoryID: 7, Subtotal: 40},
+			{ProductID: 2, CategoryID: 8, Subtotal: 60},
+		}
+		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 100.0, items, &userCoupon.ID)
+		require.NoError(t, err)
+		assert.InDelta(t, 20.0, result.CouponDiscount, 0.001)
+		assert.InDelta(t, 80.0, result.FinalAmount, 0.001)
+
+		// 未提供商品组成时限定券不可用
+		result, err = calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 100.0, nil, &userCoupon.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, result.CouponDiscount)
+	})
 }
 
 func TestDiscountCalculator_PreviewDiscount(t *testing.T) {
@@ -250,7 +283,7 @@ func TestDiscountCalculator_PreviewDiscount(t *testing.T) {
 		createTestCampaign(t, db, func(c *models.Campaign) {
 			c.Name = "满100减10，满200减30"
 			c.Type = models.CampaignTypeDiscount
-			c.Rules = rules
+			c.Rules, _ = json.Marshal(rules)
 		})
 
 		result, err := calc.PreviewDiscount(ctx, 150.0)
@@ -294,7 +327,7 @@ func TestDiscountCalculator_GetBestCoupon(t *testing.T) {
 			require.NoError(t, db.Create(userCoupon).Error)
 		}
 
-		bestCoupon, discount, err := calc.GetBestCoupon(ctx, user.ID, models.CouponScopeAll, 150.0)
+		bestCoupon, discount, err := calc.GetBestCoupon(ctx, user.ID, models.CouponScopeAll, 150.0, nil)
 		require.NoError(t, err)
 		assert.NotNil(t, bestCoupon)
 		assert.Equal(t, 20.0, discount)
@@ -304,7 +337,7 @@ func TestDiscountCalculator_GetBestCoupon(t *testing.T) {
 	t.Run("无可用优惠券返回nil", func(t *testing.T) {
 		user := createDiscountTestUser(t, db, "13800138011")
 
-		bestCoupon, discount, err := calc.GetBestCoupon(ctx, user.ID, models.CouponScopeAll, 100.0)
+		bestCoupon, discount, err := calc.GetBestCoupon(ctx, user.ID, models.CouponScopeAll, 100.0, nil)
 		require.NoError(t, err)
 		assert.Nil(t, bestCoupon)
 		assert.Equal(t, 0.0, discount)
@@ -427,7 +460,7 @@ func TestDiscountCalculator_EdgeCases(t *testing.T) {
 	t.Run("订单金额为0", func(t *testing.T) {
 		user := createDiscountTestUser(t, db, fmt.Sprintf("138%08d", time.Now().UnixNano()%100000000))
 
-		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 0, nil)
+		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 0, nil, nil)
 		require.NoError(t, err)
 		assert.Equal(t, 0.0, result.OriginalAmount)
 		assert.Equal(t, 0.0, result.FinalAmount)
@@ -437,7 +470,7 @@ func TestDiscountCalculator_EdgeCases(t *testing.T) {
 	t.Run("非常小的订单金额", func(t *testing.T) {
 		user := createDiscountTestUser(t, db, fmt.Sprintf("138%08d", time.Now().UnixNano()%100000000))
 
-		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 0.01, nil)
+		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 0.01, nil, nil)
 		require.NoError(t, err)
 		assert.Equal(t, 0.01, result.OriginalAmount)
 		assert.True(t, result.FinalAmount >= 0)
@@ -446,8 +479,43 @@ func TestDiscountCalculator_EdgeCases(t *testing.T) {
 	t.Run("非常大的订单金额", func(t *testing.T) {
 		user := createDiscountTestUser(t, db, fmt.Sprintf("138%08d", time.Now().UnixNano()%100000000))
 
-		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 1000000.0, nil)
+		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 1000000.0, nil, nil)
 		require.NoError(t, err)
 		assert.Equal(t, 1000000.0, result.OriginalAmount)
 	})
 }
+
+func TestDiscountCalculator_Gift(t *testing.T) {
+	db := setupDiscountTestDB(t)
+	calc := setupDiscountCalculator(db)
+	ctx := context.Background()
+	user := createDiscountTestUser(t, db, "13800138201")
+
+	t.Run("未设置满赠服务时不匹配满赠", func(t *testing.T) {
+		gift, err := calc.CheckGift(ctx, 500)
+		require.NoError(t, err)
+		assert.Nil(t, gift)
+	})
+
+	calc.SetGiftCampaignService(marketing.NewGiftCampaignService(db, repository.NewCampaignRepository(db)))
+	campaign := createTestCampaign(t, db, func(c *models.Campaign) {
+		c.Name = "满200赠"
+		c.Type = models.CampaignTypeGift
+		c.Rules = json.RawMessage(`{"min_amount":200,"gift_product_id":42,"gift_quantity":2}`)
+	})
+
+	t.Run("实付金额达到门槛", func(t *testing.T) {
+		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 250, nil, nil)
+		require.NoError(t, err)
+		require.NotNil(t, result.Gift)
+		assert.Equal(t, campaign.ID, result.Gift.Campaign.ID)
+		assert.Equal(t, int64(42), result.Gift.Item.GiftProductID)
+		assert.Equal(t, 2, result.Gift.Item.GiftQuantity)
+	})
+
+	t.Run("实付金额未达门槛", func(t *testing.T) {
+		result, err := calc.CalculateOrderDiscount(ctx, user.ID, models.OrderTypeMall, 199.99, nil, nil)
+		require.NoError(t, err)
+		assert.Nil(t, result.Gift)
+	})
+}
@@ -0,0 +1,59 @@
+package order
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// GiftOrderRefundCancelReason 主订单全额退款时赠品订单记录的取消原因
+const GiftOrderRefundCancelReason = "主订单已全额退款，赠品随之取消"
+
+// giftOrderCancellableStatuses 可随主订单取消的赠品订单状态，已发货的赠品不再取消
+var giftOrderCancellableStatuses = []string{
+	models.OrderStatusPending,
+	models.OrderStatusPaid,
+	models.OrderStatusPendingShip,
+}
+
+// CancelGiftOrdersTx 在事务中取消主订单关联的未发货赠品订单并恢复赠品库存（主订单取消或全额退款时调用）
+func CancelGiftOrdersTx(tx *gorm.DB, mainOrderID int64, reason string) error {
+	var ids []int64
+	if err := tx.Model(&models.GiftOrder{}).Where("main_order_id = ?", mainOrderID).
+		Pluck("gift_order_id", &ids).Error; err != nil || len(ids) == 0 {
+		return err
+	}
+
+	for _, id := range ids {
+		result := tx.Model(&models.Order{}).
+			Where("id = ? AND status IN ?", id, giftOrderCancellableStatuses).
+			Updates(map[string]interface{}{
+				"status":        models.OrderStatusCancelled,
+				"cancelled_at":  time.Now(),
+				"cancel_reason": reason,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		var items []*models.OrderItem
+		if err := tx.Where("order_id = ?", id).Find(&items).Error; err != nil {
+			return err
+		}
+		for _, item := range items {
+			if item.ProductID == nil {
+				continue
+			}
+			if err := tx.Model(&models.Product{}).Where("id = ?", *item.ProductID).
+				UpdateColumn("stock", gorm.Expr("stock + ?", item.Quantity)).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
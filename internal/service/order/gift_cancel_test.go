@@ -0,0 +1,115 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// createTestGiftOrder 为主订单创建指定状态的赠品订单，赠品商品库存为 stock
+func createTestGiftOrder(t *testing.T, db *gorm.DB, mainOrder *models.Order, status string, stock int) (*models.Order, *models.Product) {
+	t.Helper()
+
+	product := &models.Product{CategoryID: 1, Name: "赠品", Images: []byte(`[]`), Price: 10, Stock: stock}
+	require.NoError(t, db.Create(product).Error)
+	gift := &models.Order{
+		OrderNo: fmt.Sprintf("G%d", time.Now().UnixNano()),
+		UserID:  mainOrder.UserID,
+		Type:    models.OrderTypeMall,
+		Status:  status,
+	}
+	require.NoError(t, db.Create(gift).Error)
+	require.NoError(t, db.Create(&models.OrderItem{
+		OrderID: gift.ID, ProductID: &product.ID, ProductName: product.Name, Quantity: 1,
+	}).Error)
+	require.NoError(t, db.Create(&models.GiftOrder{
+		MainOrderID: mainOrder.ID, GiftOrderID: gift.ID, ProductID: product.ID, Quantity: 1,
+	}).Error)
+	return gift, product
+}
+
+func TestCancelGiftOrdersTx(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db, "13800138000")
+
+	tests := []struct {
+		status    string
+		cancelled bool
+	}{
+		{models.OrderStatusPending, true},
+		{models.OrderStatusPendingShip, true},
+		{models.OrderStatusShipped, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			main := createPaidOrder(t, db, user.ID, models.OrderStatusRefunded, 100)
+			gift, product := createTestGiftOrder(t, db, main, tt.status, 5)
+
+			require.NoError(t, CancelGiftOrdersTx(db, main.ID, GiftOrderRefundCancelReason))
+
+			var updated models.Order
+			require.NoError(t, db.First(&updated, gift.ID).Error)
+			var restored models.Product
+			require.NoError(t, db.First(&restored, product.ID).Error)
+			if tt.cancelled {
+				assert.Equal(t, models.OrderStatusCancelled, updated.Status)
+				assert.Equal(t, 6, restored.Stock)
+			} else {
+				assert.Equal(t, tt.status, updated.Status)
+				assert.Equal(t, 5, restored.Stock)
+			}
+		})
+	}
+}
+
+func TestRefundService_ApproveRefund_CancelsGiftOrders(t *testing.T) {
+	db := setupTestDB(t)
+	svc := setupRefundService(db)
+	ctx := context.Background()
+	user := createTestUser(t, db, "13800138001")
+
+	approve := func(order *models.Order, amount float64) {
+		payment := createPayment(t, db, user.ID, order.ID, order.OrderNo, order.ActualAmount, models.PaymentStatusSuccess)
+		refund := &models.Refund{
+			RefundNo: fmt.Sprintf("R%d", time.Now().UnixNano()), OrderID: order.ID, OrderNo: order.OrderNo,
+			PaymentID: payment.ID, PaymentNo: payment.PaymentNo, UserID: user.ID, Amount: amount,
+			Reason: "测试", Status: models.RefundStatusPending,
+		}
+		require.NoError(t, db.Create(refund).Error)
+		require.NoError(t, svc.ApproveRefund(ctx, 99, refund.ID))
+	}
+
+	t.Run("部分退款保留赠品订单", func(t *testing.T) {
+		main := createPaidOrder(t, db, user.ID, models.OrderStatusRefunding, 100)
+		gift, _ := createTestGiftOrder(t, db, main, models.OrderStatusPendingShip, 5)
+
+		approve(main, 40)
+
+		var updated models.Order
+		require.NoError(t, db.First(&updated, gift.ID).Error)
+		assert.Equal(t, models.OrderStatusPendingShip, updated.Status)
+	})
+
+	t.Run("全额退款取消待发货的赠品订单", func(t *testing.T) {
+		main := createPaidOrder(t, db, user.ID, models.OrderStatusRefunding, 100)
+		gift, product := createTestGiftOrder(t, db, main, models.OrderStatusPendingShip, 5)
+
+		approve(main, 100)
+
+		var updated models.Order
+		require.NoError(t, db.First(&updated, gift.ID).Error)
+		assert.Equal(t, models.OrderStatusCancelled, updated.Status)
+		require.NotNil(t, updated.CancelReason)
+		assert.Equal(t, GiftOrderRefundCancelReason, *updated.CancelReason)
+		var restored models.Product
+		require.NoError(t, db.First(&restored, product.ID).Error)
+		assert.Equal(t, 6, restored.Stock)
+	})
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	marketingService "github.com/dumeirei/smart-locker-backend/internal/service/marketing"
 )
 
 // MemberDiscountService 会员折扣服务
@@ -170,6 +171,7 @@ func (s *MemberDiscountService) CalculateWithMemberDiscount(
 	ctx context.Context,
 	userID int64,
 	amount float64,
+	items []*marketingService.OrderLineItem,
 	discountCalc *DiscountCalculator,
 	orderType string,
 	userCouponID *int64,
@@ -205,7 +207,7 @@ func (s *MemberDiscountService) CalculateWithMemberDiscount(
 
 	// 2. 计算活动和优惠券优惠（如果有 DiscountCalculator）
 	if discountCalc != nil {
-		discountResult, err := discountCalc.CalculateOrderDiscount(ctx, userID, orderType, afterMemberAmount, userCouponID)
+		discountResult, err := discountCalc.calculatePromotionDiscount(ctx, userID, orderType, afterMemberAmount, items, userCouponID)
 		if err != nil {
 			return nil, err
 		}
@@ -3,6 +3,7 @@ package order
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
@@ -153,7 +154,7 @@ func TestMemberDiscountService_CalculateWithMemberDiscount(t *testing.T) {
 
 	t.Run("无 DiscountCalculator 时仅计算会员折扣", func(t *testing.T) {
 		user := createEnhancedMemberDiscountTestUser(t, db, 2) // 0.9
-		result, err := svc.CalculateWithMemberDiscount(ctx, user.ID, 200.0, nil, models.OrderTypeMall, nil)
+		result, err := svc.CalculateWithMemberDiscount(ctx, user.ID, 200.0, nil, nil, models.OrderTypeMall, nil)
 		require.NoError(t, err)
 
 		assert.Equal(t, 200.0, result.OriginalAmount)
@@ -180,7 +181,7 @@ func TestMemberDiscountService_CalculateWithMemberDiscount(t *testing.T) {
 		createTestCampaign(t, db, func(c *models.Campaign) {
 			c.Name = "满100减10，满180减20"
 			c.Type = models.CampaignTypeDiscount
-			c.Rules = rules
+			c.Rules, _ = json.Marshal(rules)
 		})
 
 		coupon := createTestCouponForDiscount(t, db, func(c *models.Coupon) {
@@ -197,7 +198,7 @@ func TestMemberDiscountService_CalculateWithMemberDiscount(t *testing.T) {
 		}
 		require.NoError(t, db.Create(userCoupon).Error)
 
-		result, err := svc.CalculateWithMemberDiscount(ctx, user.ID, 200.0, discountCalc, models.OrderTypeMall, &userCoupon.ID)
+		result, err := svc.CalculateWithMemberDiscount(ctx, user.ID, 200.0, nil, discountCalc, models.OrderTypeMall, &userCoupon.ID)
 		require.NoError(t, err)
 
 		// 原始 200 -> 会员折扣 0.9 后 180 -> 活动 20 -> 优惠券 5 => 155
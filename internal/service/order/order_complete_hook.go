@@ -16,7 +16,7 @@ type OrderCompleteHook struct {
 }
 
 type commissionService interface {
-	Calculate(ctx context.Context, req *distribution.CalculateRequest) (*distribution.CalculateResponse, error)
+	OnOrderCompleted(ctx context.Context, orderID int64) (*distribution.CalculateResponse, error)
 	CancelByOrderID(ctx context.Context, orderID int64) error
 }
 
@@ -39,14 +39,8 @@ func (h *OrderCompleteHook) OnOrderCompleted(ctx context.Context, order *models.
 		return nil
 	}
 
-	// 计算佣金
-	req := &distribution.CalculateRequest{
-		OrderID:     order.ID,
-		UserID:      order.UserID,
-		OrderAmount: order.ActualAmount, // 使用实付金额计算佣金
-	}
-
-	result, err := h.commissionService.Calculate(ctx, req)
+	// 按实付金额为邀请链上的分销商计算佣金
+	result, err := h.commissionService.OnOrderCompleted(ctx, order.ID)
 	if err != nil {
 		// 佣金计算失败不应该影响订单完成
 		// 记录错误日志，后续可以通过定时任务补偿
@@ -12,15 +12,15 @@ import (
 )
 
 type stubCommissionService struct {
-	calculateFn func(ctx context.Context, req *distribution.CalculateRequest) (*distribution.CalculateResponse, error)
-	cancelFn    func(ctx context.Context, orderID int64) error
+	completeFn func(ctx context.Context, orderID int64) (*distribution.CalculateResponse, error)
+	cancelFn   func(ctx context.Context, orderID int64) error
 }
 
-func (s *stubCommissionService) Calculate(ctx context.Context, req *distribution.CalculateRequest) (*distribution.CalculateResponse, error) {
-	if s.calculateFn == nil {
+func (s *stubCommissionService) OnOrderCompleted(ctx context.Context, orderID int64) (*distribution.CalculateResponse, error) {
+	if s.completeFn == nil {
 		return &distribution.CalculateResponse{}, nil
 	}
-	return s.calculateFn(ctx, req)
+	return s.completeFn(ctx, orderID)
 }
 
 func (s *stubCommissionService) CancelByOrderID(ctx context.Context, orderID int64) error {
@@ -35,7 +35,7 @@ func TestOrderCompleteHook_OnOrderCompleted(t *testing.T) {
 
 	t.Run("非已完成订单不触发", func(t *testing.T) {
 		h := NewOrderCompleteHook(&stubCommissionService{
-			calculateFn: func(ctx context.Context, req *distribution.CalculateRequest) (*distribution.CalculateResponse, error) {
+			completeFn: func(ctx context.Context, orderID int64) (*distribution.CalculateResponse, error) {
 				t.Fatalf("should not be called")
 				return nil, nil
 			},
@@ -53,7 +53,7 @@ func TestOrderCompleteHook_OnOrderCompleted(t *testing.T) {
 
 	t.Run("佣金计算失败不影响订单完成", func(t *testing.T) {
 		h := NewOrderCompleteHook(&stubCommissionService{
-			calculateFn: func(ctx context.Context, req *distribution.CalculateRequest) (*distribution.CalculateResponse, error) {
+			completeFn: func(ctx context.Context, orderID int64) (*distribution.CalculateResponse, error) {
 				return nil, errors.New("boom")
 			},
 		})
@@ -63,7 +63,7 @@ func TestOrderCompleteHook_OnOrderCompleted(t *testing.T) {
 
 	t.Run("佣金计算成功", func(t *testing.T) {
 		h := NewOrderCompleteHook(&stubCommissionService{
-			calculateFn: func(ctx context.Context, req *distribution.CalculateRequest) (*distribution.CalculateResponse, error) {
+			completeFn: func(ctx context.Context, orderID int64) (*distribution.CalculateResponse, error) {
 				return &distribution.CalculateResponse{
 					TotalAmount:       1,
 					DirectCommission:  &models.Commission{Amount: 0.5},
@@ -0,0 +1,198 @@
+// Package order 提供订单相关服务
+package order
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+const (
+	DefaultOrderPaymentTTLMinutes = 15             // 默认待支付订单超时时间（分钟）
+	expireOrderBatchSize          = 100            // 每次最多处理的超时订单数
+	OrderExpiredCancelReason      = "超时未支付，系统自动取消" // 超时取消时记录的取消原因
+)
+
+// ExpiredOrderHandler 超时订单处理器，按订单类型注册，负责释放订单占用的业务资源
+type ExpiredOrderHandler interface {
+	// ReleaseExpiredOrderTx 在取消订单的事务中释放订单占用的资源（设备槽位、库存等）
+	// 返回的 afterCommit 不为 nil 时在事务提交后执行，用于推送状态变更、调用第三方渠道等不能放在事务中的操作
+	ReleaseExpiredOrderTx(ctx context.Context, tx *gorm.DB, order *models.Order) (afterCommit func(), err error)
+}
+
+// OrderExpiryService 待支付订单超时取消服务
+// 酒店预订由本服务直接释放（注册的处理器在此之后执行，如返还抵扣积分），其他订单类型通过 SetExpiredOrderHandler 注册处理器后参与超时取消
+type OrderExpiryService struct {
+	db       *gorm.DB
+	ttl      time.Duration
+	typeTTLs map[string]time.Duration       // 订单类型 -> 单独配置的超时时间
+	handlers map[string]ExpiredOrderHandler // 订单类型 -> 超时订单处理器
+	now      func() time.Time
+}
+
+// NewOrderExpiryService 创建待支付订单超时取消服务
+func NewOrderExpiryService(db *gorm.DB) *OrderExpiryService {
+	return &OrderExpiryService{
+		db:       db,
+		ttl:      DefaultOrderPaymentTTLMinutes * time.Minute,
+		typeTTLs: make(map[string]time.Duration),
+		handlers: make(map[string]ExpiredOrderHandler),
+		now:      time.Now,
+	}
+}
+
+// SetPaymentTTL 设置待支付订单的超时时间（分钟），不大于0时忽略
+func (s *OrderExpiryService) SetPaymentTTL(minutes int) {
+	if minutes > 0 {
+		s.ttl = time.Duration(minutes) * time.Minute
+	}
+}
+
+// SetOrderTypePaymentTTL 单独设置某类订单的超时时间（分钟），不大于0时使用默认超时时间
+func (s *OrderExpiryService) SetOrderTypePaymentTTL(orderType string, minutes int) {
+	if minutes > 0 {
+		s.typeTTLs[orderType] = time.Duration(minutes) * time.Minute
+	} else {
+		delete(s.typeTTLs, orderType)
+	}
+}
+
+// SetExpiredOrderHandler 注册订单类型的超时订单处理器，未注册的订单类型（酒店预订除外）不自动取消
+func (s *OrderExpiryService) SetExpiredOrderHandler(orderType string, handler ExpiredOrderHandler) {
+	s.handlers[orderType] = handler
+}
+
+// Run 定时任务入口，取消超时未支付的订单
+func (s *OrderExpiryService) Run(ctx context.Context) error {
+	_, err := s.ProcessExpiredOrders(ctx)
+	return err
+}
+
+// expirableOrderTypes 参与超时自动取消的订单类型：酒店预订及已注册处理器的订单类型
+func (s *OrderExpiryService) expirableOrderTypes() []string {
+	types := []string{models.OrderTypeHotel}
+	for orderType := range s.handlers {
+		if orderType != models.OrderTypeHotel {
+			types = append(types, orderType)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// paymentTTL 订单类型的超时时间
+func (s *OrderExpiryService) paymentTTL(orderType string) time.Duration {
+	if ttl, ok := s.typeTTLs[orderType]; ok {
+		return ttl
+	}
+	return s.ttl
+}
+
+// ProcessExpiredOrders 取消超时未支付的订单
+// 创建时间早于超时时间的待支付订单将被取消：释放订单占用的资源（设备槽位、房间时段、商品库存等）、恢复使用的优惠券。
+// 每类订单每次最多处理 expireOrderBatchSize 条，返回实际取消的数量；单条失败不影响其余订单
+func (s *OrderExpiryService) ProcessExpiredOrders(ctx context.Context) (int, error) {
+	var expired int
+	var errs []error
+	for _, orderType := range s.expirableOrderTypes() {
+		expiredBefore := s.now().Add(-s.paymentTTL(orderType))
+
+		var orders []*models.Order
+		err := s.db.WithContext(ctx).
+			Where("type = ?", orderType).
+			Where("status = ? AND paid_at IS NULL", models.OrderStatusPending).
+			Where("created_at < ?", expiredBefore).
+			// 赠品订单随主订单取消，不单独超时
+			Where("id NOT IN (?)", s.db.Model(&models.GiftOrder{}).Select("gift_order_id")).
+			Order("id ASC").
+			Limit(expireOrderBatchSize).
+			Find(&orders).Error
+		if err != nil {
+			errs = append(errs, errors.ErrDatabaseError.WithError(err))
+			continue
+		}
+
+		for _, o := range orders {
+			ok, err := s.expireOrder(ctx, o.ID, expiredBefore)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("order %d: %w", o.ID, err))
+				continue
+			}
+			if ok {
+				expired++
+			}
+		}
+	}
+
+	return expired, stderrors.Join(errs...)
+}
+
+// expireOrder 取消单个超时订单，订单已支付或已取消时返回 false
+// 以待支付且未支付为条件更新订单状态，与支付回调的状态更新互斥，避免取消刚完成支付的订单
+func (s *OrderExpiryService) expireOrder(ctx context.Context, orderID int64, expiredBefore time.Time) (bool, error) {
+	var expired bool
+	var afterCommit func()
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Order{}).
+			Where("id = ? AND status = ? AND paid_at IS NULL AND created_at < ?",
+				orderID, models.OrderStatusPending, expiredBefore).
+			Updates(map[string]interface{}{
+				"status":        models.OrderStatusCancelled,
+				"cancelled_at":  s.now(),
+				"cancel_reason": OrderExpiredCancelReason,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		// 查询后可能已完成支付或被用户取消
+		if res.RowsAffected == 0 {
+			return nil
+		}
+
+		var order models.Order
+		if err := tx.First(&order, orderID).Error; err != nil {
+			return err
+		}
+
+		if order.Type == models.OrderTypeHotel {
+			if err := s.releaseBookingTx(tx, order.ID); err != nil {
+				return err
+			}
+		}
+		if handler, ok := s.handlers[order.Type]; ok && handler != nil {
+			var err error
+			if afterCommit, err = handler.ReleaseExpiredOrderTx(ctx, tx, &order); err != nil {
+				return err
+			}
+		}
+
+		if err := RestoreOrderCouponTx(tx, order.ID); err != nil {
+			return err
+		}
+
+		if err := tx.Create(NewSystemNote(order.ID, OrderExpiredCancelReason, true)).Error; err != nil {
+			return err
+		}
+
+		expired = true
+		return nil
+	})
+	if err == nil && afterCommit != nil {
+		afterCommit()
+	}
+	return expired, err
+}
+
+// releaseBookingTx 取消订单关联的待支付预订，释放房间时段
+func (s *OrderExpiryService) releaseBookingTx(tx *gorm.DB, orderID int64) error {
+	return tx.Model(&models.Booking{}).
+		Where("order_id = ? AND status = ?", orderID, models.BookingStatusPending).
+		Update("status", models.BookingStatusCancelled).Error
+}
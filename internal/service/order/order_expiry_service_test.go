@@ -0,0 +1,302 @@
+// Package order 待支付订单超时取消单元测试
+package order
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+func setupOrderExpiryTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(
+		&models.Booking{},
+		&models.GiftOrder{},
+		&models.Coupon{},
+		&models.UserCoupon{},
+	))
+	return db
+}
+
+// stubExpiredOrderHandler 记录被取消的订单，并统计事务提交后回调的执行次数
+type stubExpiredOrderHandler struct {
+	released    []int64
+	afterCommit int
+	err         error
+}
+
+func (h *stubExpiredOrderHandler) ReleaseExpiredOrderTx(ctx context.Context, tx *gorm.DB, order *models.Order) (func(), error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	h.released = append(h.released, order.ID)
+	return func() { h.afterCommit++ }, nil
+}
+
+// newRentalExpiryTestService 创建注册了租借桩处理器的订单超时取消服务
+func newRentalExpiryTestService(db *gorm.DB, now time.Time) (*OrderExpiryService, *stubExpiredOrderHandler) {
+	svc := NewOrderExpiryService(db)
+	svc.now = func() time.Time { return now }
+	handler := &stubExpiredOrderHandler{}
+	svc.SetExpiredOrderHandler(models.OrderTypeRental, handler)
+	return svc, handler
+}
+
+// createExpiryTestOrder 创建指定类型、状态和创建时间的订单
+func createExpiryTestOrder(t *testing.T, db *gorm.DB, orderType, status string, createdAt time.Time) *models.Order {
+	t.Helper()
+
+	order := &models.Order{
+		OrderNo:        fmt.Sprintf("O_EXPIRY_%d", time.Now().UnixNano()),
+		UserID:         1,
+		Type:           orderType,
+		OriginalAmount: 20,
+		ActualAmount:   20,
+		Status:         status,
+	}
+	require.NoError(t, db.Create(order).Error)
+	require.NoError(t, db.Model(order).Update("created_at", createdAt).Error)
+	order.CreatedAt = createdAt
+	return order
+}
+
+// createExpiryTestCoupon 创建已被订单使用的用户优惠券
+func createExpiryTestCoupon(t *testing.T, db *gorm.DB, order *models.Order) (*models.Coupon, *models.UserCoupon) {
+	t.Helper()
+
+	now := time.Now()
+	coupon := &models.Coupon{
+		Name:       "满20减5",
+		Type:       models.CouponTypeFixed,
+		Value:      5,
+		TotalCount: 100,
+		UsedCount:  1,
+		StartTime:  now.Add(-24 * time.Hour),
+		EndTime:    now.Add(24 * time.Hour),
+		Status:     1,
+	}
+	require.NoError(t, db.Create(coupon).Error)
+
+	userCoupon := &models.UserCoupon{
+		UserID:    order.UserID,
+		CouponID:  coupon.ID,
+		OrderID:   &order.ID,
+		Status:    models.UserCouponStatusUsed,
+		ExpiredAt: now.Add(24 * time.Hour),
+		UsedAt:    &now,
+	}
+	require.NoError(t, db.Create(userCoupon).Error)
+	return coupon, userCoupon
+}
+
+func TestOrderExpiryService_ProcessExpiredOrders_Handler(t *testing.T) {
+	db := setupOrderExpiryTestDB(t)
+	ctx := context.Background()
+	now := time.Now()
+	svc, handler := newRentalExpiryTestService(db, now)
+
+	order := createExpiryTestOrder(t, db, models.OrderTypeRental, models.OrderStatusPending, now.Add(-20*time.Minute))
+	coupon, userCoupon := createExpiryTestCoupon(t, db, order)
+
+	expired, err := svc.ProcessExpiredOrders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	var updatedOrder models.Order
+	require.NoError(t, db.First(&updatedOrder, order.ID).Error)
+	assert.Equal(t, models.OrderStatusCancelled, updatedOrder.Status)
+	assert.NotNil(t, updatedOrder.CancelledAt)
+	require.NotNil(t, updatedOrder.CancelReason)
+	assert.Equal(t, OrderExpiredCancelReason, *updatedOrder.CancelReason)
+
+	// 按订单类型分发给处理器，提交后执行回调
+	assert.Equal(t, []int64{order.ID}, handler.released)
+	assert.Equal(t, 1, handler.afterCommit)
+
+	// 优惠券恢复为未使用
+	var updatedUserCoupon models.UserCoupon
+	require.NoError(t, db.First(&updatedUserCoupon, userCoupon.ID).Error)
+	assert.Equal(t, int8(models.UserCouponStatusUnused), updatedUserCoupon.Status)
+	assert.Nil(t, updatedUserCoupon.OrderID)
+	assert.Nil(t, updatedUserCoupon.UsedAt)
+
+	var updatedCoupon models.Coupon
+	require.NoError(t, db.First(&updatedCoupon, coupon.ID).Error)
+	assert.Equal(t, 0, updatedCoupon.UsedCount)
+
+	// 重复执行不会再次释放
+	expired, err = svc.ProcessExpiredOrders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, expired)
+	assert.Len(t, handler.released, 1)
+}
+
+func TestOrderExpiryService_ProcessExpiredOrders_HandlerError(t *testing.T) {
+	db := setupOrderExpiryTestDB(t)
+	ctx := context.Background()
+	now := time.Now()
+	svc, handler := newRentalExpiryTestService(db, now)
+	handler.err = fmt.Errorf("release failed")
+
+	order := createExpiryTestOrder(t, db, models.OrderTypeRental, models.OrderStatusPending, now.Add(-20*time.Minute))
+
+	expired, err := svc.ProcessExpiredOrders(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 0, expired)
+	assert.Equal(t, 0, handler.afterCommit)
+
+	// 释放失败时订单状态回滚，下次重试
+	var updatedOrder models.Order
+	require.NoError(t, db.First(&updatedOrder, order.ID).Error)
+	assert.Equal(t, models.OrderStatusPending, updatedOrder.Status)
+}
+
+func TestOrderExpiryService_ProcessExpiredOrders_Booking(t *testing.T) {
+	db := setupOrderExpiryTestDB(t)
+	svc := NewOrderExpiryService(db)
+	ctx := context.Background()
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+
+	order := createExpiryTestOrder(t, db, models.OrderTypeHotel, models.OrderStatusPending, now.Add(-30*time.Minute))
+	booking := &models.Booking{
+		BookingNo:        "B_EXPIRY",
+		OrderID:          order.ID,
+		UserID:           order.UserID,
+		HotelID:          1,
+		RoomID:           1,
+		CheckInTime:      now.Add(time.Hour),
+		CheckOutTime:     now.Add(3 * time.Hour),
+		DurationHours:    2,
+		Amount:           order.ActualAmount,
+		VerificationCode: "V_EXPIRY",
+		UnlockCode:       "123456",
+		QRCode:           "qr",
+		Status:           models.BookingStatusPending,
+	}
+	require.NoError(t, db.Create(booking).Error)
+	_, userCoupon := createExpiryTestCoupon(t, db, order)
+
+	require.NoError(t, svc.Run(ctx))
+
+	var updatedOrder models.Order
+	require.NoError(t, db.First(&updatedOrder, order.ID).Error)
+	assert.Equal(t, models.OrderStatusCancelled, updatedOrder.Status)
+
+	var updatedBooking models.Booking
+	require.NoError(t, db.First(&updatedBooking, booking.ID).Error)
+	assert.Equal(t, models.BookingStatusCancelled, updatedBooking.Status)
+
+	var updatedUserCoupon models.UserCoupon
+	require.NoError(t, db.First(&updatedUserCoupon, userCoupon.ID).Error)
+	assert.Equal(t, int8(models.UserCouponStatusUnused), updatedUserCoupon.Status)
+}
+
+func TestOrderExpiryService_ProcessExpiredOrders_Skipped(t *testing.T) {
+	db := setupOrderExpiryTestDB(t)
+	ctx := context.Background()
+	now := time.Now()
+	svc, handler := newRentalExpiryTestService(db, now)
+
+	// 已支付订单即使支付时间在超时窗口内也不处理
+	paidOrder := createExpiryTestOrder(t, db, models.OrderTypeRental, models.OrderStatusPaid, now.Add(-time.Hour))
+	paidAt := now.Add(-5 * time.Minute)
+	require.NoError(t, db.Model(paidOrder).Update("paid_at", paidAt).Error)
+	_, paidCoupon := createExpiryTestCoupon(t, db, paidOrder)
+
+	// 支付回调已写入支付时间但状态仍为待支付时不处理
+	payingOrder := createExpiryTestOrder(t, db, models.OrderTypeRental, models.OrderStatusPending, now.Add(-time.Hour))
+	require.NoError(t, db.Model(payingOrder).Update("paid_at", paidAt).Error)
+
+	// 未超时的待支付订单不处理
+	freshOrder := createExpiryTestOrder(t, db, models.OrderTypeRental, models.OrderStatusPending, now.Add(-5*time.Minute))
+
+	// 未注册处理器的订单类型不在自动取消范围内
+	mallOrder := createExpiryTestOrder(t, db, models.OrderTypeMall, models.OrderStatusPending, now.Add(-time.Hour))
+
+	expired, err := svc.ProcessExpiredOrders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, expired)
+	assert.Empty(t, handler.released)
+
+	var order models.Order
+	require.NoError(t, db.First(&order, paidOrder.ID).Error)
+	assert.Equal(t, models.OrderStatusPaid, order.Status)
+	assert.Nil(t, order.CancelReason)
+
+	var userCoupon models.UserCoupon
+	require.NoError(t, db.First(&userCoupon, paidCoupon.ID).Error)
+	assert.Equal(t, int8(models.UserCouponStatusUsed), userCoupon.Status)
+
+	for _, id := range []int64{payingOrder.ID, freshOrder.ID, mallOrder.ID} {
+		var pending models.Order
+		require.NoError(t, db.First(&pending, id).Error)
+		assert.Equal(t, models.OrderStatusPending, pending.Status, "order %d", id)
+	}
+}
+
+func TestOrderExpiryService_ExpireOrder_PaidConcurrently(t *testing.T) {
+	db := setupOrderExpiryTestDB(t)
+	ctx := context.Background()
+	now := time.Now()
+	svc, handler := newRentalExpiryTestService(db, now)
+
+	// 查询出超时订单后、取消前支付回调已将订单更新为已支付
+	order := createExpiryTestOrder(t, db, models.OrderTypeRental, models.OrderStatusPending, now.Add(-20*time.Minute))
+	require.NoError(t, db.Model(order).Updates(map[string]interface{}{
+		"status":  models.OrderStatusPaid,
+		"paid_at": now,
+	}).Error)
+
+	ok, err := svc.expireOrder(ctx, order.ID, now.Add(-svc.ttl))
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Empty(t, handler.released)
+
+	var updatedOrder models.Order
+	require.NoError(t, db.First(&updatedOrder, order.ID).Error)
+	assert.Equal(t, models.OrderStatusPaid, updatedOrder.Status)
+
+	var noteCount int64
+	require.NoError(t, db.Model(&models.OrderNote{}).Where("order_id = ?", order.ID).Count(&noteCount).Error)
+	assert.Equal(t, int64(0), noteCount)
+}
+
+func TestOrderExpiryService_SetPaymentTTL(t *testing.T) {
+	db := setupOrderExpiryTestDB(t)
+	ctx := context.Background()
+	now := time.Now()
+	svc, _ := newRentalExpiryTestService(db, now)
+
+	svc.SetPaymentTTL(0)
+	assert.Equal(t, DefaultOrderPaymentTTLMinutes*time.Minute, svc.ttl)
+
+	svc.SetPaymentTTL(60)
+	order := createExpiryTestOrder(t, db, models.OrderTypeRental, models.OrderStatusPending, now.Add(-30*time.Minute))
+
+	expired, err := svc.ProcessExpiredOrders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, expired)
+
+	var updatedOrder models.Order
+	require.NoError(t, db.First(&updatedOrder, order.ID).Error)
+	assert.Equal(t, models.OrderStatusPending, updatedOrder.Status)
+
+	// 按订单类型单独设置的超时时间优先
+	svc.SetOrderTypePaymentTTL(models.OrderTypeRental, 20)
+	expired, err = svc.ProcessExpiredOrders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	svc.SetOrderTypePaymentTTL(models.OrderTypeRental, 0)
+	assert.Equal(t, 60*time.Minute, svc.paymentTTL(models.OrderTypeRental))
+}
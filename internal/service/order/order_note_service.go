@@ -0,0 +1,186 @@
+package order
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// maxOrderNoteLength 订单备注最大字符数
+const maxOrderNoteLength = 500
+
+// OrderNoteService 订单备注时间线服务
+// 用户只能查看和添加自己订单上用户可见的备注；管理员可查看全部备注并决定新增备注是否对用户可见。
+// 超时取消、退款通过、计入商户结算等节点由对应业务在事务内写入系统备注
+type OrderNoteService struct {
+	db       *gorm.DB
+	noteRepo *repository.OrderNoteRepository
+}
+
+// NewOrderNoteService 创建订单备注服务
+func NewOrderNoteService(db *gorm.DB, noteRepo *repository.OrderNoteRepository) *OrderNoteService {
+	return &OrderNoteService{db: db, noteRepo: noteRepo}
+}
+
+// AddUserOrderNoteRequest 用户添加订单备注请求
+type AddUserOrderNoteRequest struct {
+	Content string `json:"content" binding:"required,max=500"`
+}
+
+// AddAdminOrderNoteRequest 管理员添加订单备注请求
+type AddAdminOrderNoteRequest struct {
+	Content       string `json:"content" binding:"required,max=500"`
+	VisibleToUser bool   `json:"visible_to_user"` // 默认仅管理员可见
+}
+
+// OrderNoteInfo 用户可见的订单备注
+type OrderNoteInfo struct {
+	ID         int64     `json:"id"`
+	AuthorType string    `json:"author_type"`
+	Content    string    `json:"content"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AddUserNote 用户为自己的订单添加备注，备注对用户可见
+func (s *OrderNoteService) AddUserNote(ctx context.Context, userID, orderID int64, content string) (*OrderNoteInfo, error) {
+	if err := s.checkUserOrder(ctx, userID, orderID); err != nil {
+		return nil, err
+	}
+	note, err := s.addNote(ctx, orderID, models.OrderNoteAuthorUser, userID, content, true)
+	if err != nil {
+		return nil, err
+	}
+	return toOrderNoteInfo(note), nil
+}
+
+// ListUserNotes 按时间倒序分页获取用户订单上用户可见的备注
+func (s *OrderNoteService) ListUserNotes(ctx context.Context, userID, orderID int64, page, pageSize int) ([]*OrderNoteInfo, int64, error) {
+	if err := s.checkUserOrder(ctx, userID, orderID); err != nil {
+		return nil, 0, err
+	}
+	notes, total, err := s.noteRepo.ListByOrder(ctx, orderID, true, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errors.ErrDatabaseError.WithError(err)
+	}
+	list := make([]*OrderNoteInfo, 0, len(notes))
+	for _, note := range notes {
+		list = append(list, toOrderNoteInfo(note))
+	}
+	return list, total, nil
+}
+
+// GetLatestUserNote 获取订单最新一条用户可见的备注，没有时返回 nil
+func (s *OrderNoteService) GetLatestUserNote(ctx context.Context, orderID int64) (*OrderNoteInfo, error) {
+	note, err := s.noteRepo.GetLatest(ctx, orderID, true)
+	if err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	if note == nil {
+		return nil, nil
+	}
+	return toOrderNoteInfo(note), nil
+}
+
+// AddAdminNote 管理员为订单添加备注
+func (s *OrderNoteService) AddAdminNote(ctx context.Context, adminID, orderID int64, req *AddAdminOrderNoteRequest) (*models.OrderNote, error) {
+	if err := s.checkOrder(ctx, orderID); err != nil {
+		return nil, err
+	}
+	return s.addNote(ctx, orderID, models.OrderNoteAuthorAdmin, adminID, req.Content, req.VisibleToUser)
+}
+
+// ListAdminNotes 按时间倒序分页获取订单的全部备注
+func (s *OrderNoteService) ListAdminNotes(ctx context.Context, orderID int64, page, pageSize int) ([]*models.OrderNote, int64, error) {
+	if err := s.checkOrder(ctx, orderID); err != nil {
+		return nil, 0, err
+	}
+	notes, total, err := s.noteRepo.ListByOrder(ctx, orderID, false, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errors.ErrDatabaseError.WithError(err)
+	}
+	return notes, total, nil
+}
+
+// GetLatestNote 获取订单最新一条备注（含仅管理员可见的备注），没有时返回 nil
+func (s *OrderNoteService) GetLatestNote(ctx context.Context, orderID int64) (*models.OrderNote, error) {
+	note, err := s.noteRepo.GetLatest(ctx, orderID, false)
+	if err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	return note, nil
+}
+
+// addNote 校验内容并创建备注
+func (s *OrderNoteService) addNote(ctx context.Context, orderID int64, authorType string, authorID int64, content string, visibleToUser bool) (*models.OrderNote, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, errors.ErrInvalidParams.WithMessage("备注内容不能为空")
+	}
+	if len([]rune(content)) > maxOrderNoteLength {
+		return nil, errors.ErrInvalidParams.WithMessage("备注内容不能超过500字")
+	}
+
+	note := &models.OrderNote{
+		OrderID:       orderID,
+		AuthorType:    authorType,
+		AuthorID:      &authorID,
+		Content:       content,
+		VisibleToUser: visibleToUser,
+	}
+	if err := s.noteRepo.Create(ctx, note); err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	return note, nil
+}
+
+// checkUserOrder 校验订单存在且属于该用户，不属于时按不存在处理
+func (s *OrderNoteService) checkUserOrder(ctx context.Context, userID, orderID int64) error {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.Order{}).
+		Where("id = ? AND user_id = ?", orderID, userID).
+		Count(&count).Error; err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	if count == 0 {
+		return errors.ErrOrderNotFound
+	}
+	return nil
+}
+
+// checkOrder 校验订单存在
+func (s *OrderNoteService) checkOrder(ctx context.Context, orderID int64) error {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.Order{}).Where("id = ?", orderID).Count(&count).Error; err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	if count == 0 {
+		return errors.ErrOrderNotFound
+	}
+	return nil
+}
+
+// toOrderNoteInfo 转换为用户可见的备注信息，不返回作者 ID
+func toOrderNoteInfo(note *models.OrderNote) *OrderNoteInfo {
+	return &OrderNoteInfo{
+		ID:         note.ID,
+		AuthorType: note.AuthorType,
+		Content:    note.Content,
+		CreatedAt:  note.CreatedAt,
+	}
+}
+
+// NewSystemNote 构建系统自动写入的订单备注，由业务在状态变更的事务内创建
+func NewSystemNote(orderID int64, content string, visibleToUser bool) *models.OrderNote {
+	return &models.OrderNote{
+		OrderID:       orderID,
+		AuthorType:    models.OrderNoteAuthorSystem,
+		Content:       content,
+		VisibleToUser: visibleToUser,
+	}
+}
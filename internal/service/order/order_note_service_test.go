@@ -0,0 +1,137 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+func TestOrderNoteService_Visibility(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewOrderNoteService(db, repository.NewOrderNoteRepository(db))
+	ctx := context.Background()
+
+	user := createTestUser(t, db, "13800138101")
+	other := createTestUser(t, db, "13800138102")
+	order := createPaidOrder(t, db, user.ID, models.OrderStatusPaid, 100)
+
+	userNote, err := svc.AddUserNote(ctx, user.ID, order.ID, "  请尽快发货  ")
+	require.NoError(t, err)
+	assert.Equal(t, "请尽快发货", userNote.Content)
+	assert.Equal(t, models.OrderNoteAuthorUser, userNote.AuthorType)
+
+	_, err = svc.AddAdminNote(ctx, 1, order.ID, &AddAdminOrderNoteRequest{Content: "用户多次催单，注意安抚"})
+	require.NoError(t, err)
+	_, err = svc.AddAdminNote(ctx, 1, order.ID, &AddAdminOrderNoteRequest{Content: "已联系仓库加急", VisibleToUser: true})
+	require.NoError(t, err)
+
+	t.Run("用户看不到仅管理员可见的备注", func(t *testing.T) {
+		notes, total, err := svc.ListUserNotes(ctx, user.ID, order.ID, 1, 20)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		require.Len(t, notes, 2)
+		assert.Equal(t, "已联系仓库加急", notes[0].Content)
+		assert.Equal(t, "请尽快发货", notes[1].Content)
+
+		latest, err := svc.GetLatestUserNote(ctx, order.ID)
+		require.NoError(t, err)
+		require.NotNil(t, latest)
+		assert.Equal(t, "已联系仓库加急", latest.Content)
+	})
+
+	t.Run("管理员可看到全部备注", func(t *testing.T) {
+		notes, total, err := svc.ListAdminNotes(ctx, order.ID, 1, 20)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		require.Len(t, notes, 3)
+		assert.False(t, notes[1].VisibleToUser)
+		require.NotNil(t, notes[1].AuthorID)
+		assert.Equal(t, int64(1), *notes[1].AuthorID)
+	})
+
+	t.Run("不能访问他人订单的备注", func(t *testing.T) {
+		_, _, err := svc.ListUserNotes(ctx, other.ID, order.ID, 1, 20)
+		assert.ErrorIs(t, err, appErrors.ErrOrderNotFound)
+
+		_, err = svc.AddUserNote(ctx, other.ID, order.ID, "备注")
+		assert.ErrorIs(t, err, appErrors.ErrOrderNotFound)
+	})
+
+	t.Run("备注内容校验", func(t *testing.T) {
+		_, err := svc.AddUserNote(ctx, user.ID, order.ID, "   ")
+		assert.Error(t, err)
+
+		_, err = svc.AddUserNote(ctx, user.ID, order.ID, strings.Repeat("长", maxOrderNoteLength+1))
+		assert.Error(t, err)
+	})
+
+	t.Run("没有备注时最新备注为空", func(t *testing.T) {
+		empty := createPaidOrder(t, db, user.ID, models.OrderStatusPaid, 50)
+		latest, err := svc.GetLatestUserNote(ctx, empty.ID)
+		require.NoError(t, err)
+		assert.Nil(t, latest)
+	})
+}
+
+func TestOrderNoteService_ListPaginatesLongTimeline(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewOrderNoteService(db, repository.NewOrderNoteRepository(db))
+	ctx := context.Background()
+
+	user := createTestUser(t, db, "13800138103")
+	order := createPaidOrder(t, db, user.ID, models.OrderStatusPaid, 100)
+	for i := 1; i <= 25; i++ {
+		_, err := svc.AddUserNote(ctx, user.ID, order.ID, fmt.Sprintf("备注%d", i))
+		require.NoError(t, err)
+	}
+
+	page1, total, err := svc.ListUserNotes(ctx, user.ID, order.ID, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(25), total)
+	require.Len(t, page1, 10)
+	assert.Equal(t, "备注25", page1[0].Content)
+	assert.Equal(t, "备注16", page1[9].Content)
+
+	page3, _, err := svc.ListUserNotes(ctx, user.ID, order.ID, 3, 10)
+	require.NoError(t, err)
+	require.Len(t, page3, 5)
+	assert.Equal(t, "备注5", page3[0].Content)
+	assert.Equal(t, "备注1", page3[4].Content)
+}
+
+func TestOrderNoteService_ExpiryWritesSystemNote(t *testing.T) {
+	db := setupOrderExpiryTestDB(t)
+	noteSvc := NewOrderNoteService(db, repository.NewOrderNoteRepository(db))
+	ctx := context.Background()
+	now := time.Now()
+	expirySvc, _ := newRentalExpiryTestService(db, now)
+
+	order := createExpiryTestOrder(t, db, models.OrderTypeRental, models.OrderStatusPending, now.Add(-20*time.Minute))
+
+	expired, err := expirySvc.ProcessExpiredOrders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	notes, total, err := noteSvc.ListUserNotes(ctx, order.UserID, order.ID, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, notes, 1)
+	assert.Equal(t, models.OrderNoteAuthorSystem, notes[0].AuthorType)
+	assert.Equal(t, OrderExpiredCancelReason, notes[0].Content)
+
+	// 重复执行不会重复写入
+	_, err = expirySvc.ProcessExpiredOrders(ctx)
+	require.NoError(t, err)
+	_, total, err = noteSvc.ListUserNotes(ctx, order.UserID, order.ID, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+}
@@ -65,7 +65,7 @@ func (h *PointsHook) OnOrderCompleted(ctx context.Context, order *models.Order)
 		return nil
 	}
 
-	log.Printf("订单 %s 完成，用户 %d 获得 %d 积分", order.OrderNo, order.UserID, int(amount))
+	log.Printf("订单 %s 完成，用户 %d 消费积分已发放", order.OrderNo, order.UserID)
 	return nil
 }
 
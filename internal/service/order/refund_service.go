@@ -20,6 +20,10 @@ type RefundService struct {
 	refundRepo  *repository.RefundRepository
 	orderRepo   *repository.OrderRepository
 	paymentRepo *repository.PaymentRepository
+	wallet      walletRefunder
+	gateway     refundGateway
+	commissions commissionRecalculator
+	overview    financeOverviewInvalidator
 }
 
 // NewRefundService 创建退款服务
@@ -251,15 +255,74 @@ func (s *RefundService) ApproveRefund(ctx context.Context, operatorID int64, ref
 	if refund.Status != models.RefundStatusPending {
 		return errors.ErrOperationFailed.WithMessage("退款申请状态不允许审批")
 	}
+	if err := s.checkNotMallPartialRefund(ctx, refundID); err != nil {
+		return err
+	}
 
 	operatorType := models.RefundOperatorAdmin
-	return s.refundRepo.UpdateFields(ctx, refundID, map[string]interface{}{
-		"status":        models.RefundStatusApproved,
-		"operator_id":   operatorID,
-		"operator_type": operatorType,
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 仅更新仍为待处理的退款，并发审批同一退款时只有一次生效
+		result := tx.Model(&models.Refund{}).
+			Where("id = ? AND status = ?", refundID, models.RefundStatusPending).
+			Updates(map[string]interface{}{
+				"status":        models.RefundStatusApproved,
+				"operator_id":   operatorID,
+				"operator_type": operatorType,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrOperationFailed.WithMessage("退款申请状态不允许审批")
+		}
+		if err := tx.Create(NewSystemNote(refund.OrderID, RefundApprovedNote(refund.Amount), true)).Error; err != nil {
+			return err
+		}
+		return cancelGiftOrdersIfFullyRefundedTx(tx, refund.OrderID)
 	})
 }
 
+// cancelGiftOrdersIfFullyRefundedTx 订单已审批的退款金额达到实付金额时，取消其未发货的赠品订单
+func cancelGiftOrdersIfFullyRefundedTx(tx *gorm.DB, orderID int64) error {
+	var order models.Order
+	if err := tx.Select("id", "actual_amount").First(&order, orderID).Error; err != nil {
+		return err
+	}
+
+	var approved float64
+	if err := tx.Model(&models.Refund{}).
+		Where("order_id = ? AND status IN ?", orderID, []int8{
+			models.RefundStatusApproved,
+			models.RefundStatusProcessing,
+			models.RefundStatusSuccess,
+		}).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&approved).Error; err != nil {
+		return err
+	}
+	if roundRefundAmount(order.ActualAmount-approved) > 0 {
+		return nil
+	}
+	return CancelGiftOrdersTx(tx, orderID, GiftOrderRefundCancelReason)
+}
+
+// checkNotMallPartialRefund 商城订单部分退款（含退款明细）需通过商城退款审批处理库存及优惠券，不能在通用退款中审批
+func (s *RefundService) checkNotMallPartialRefund(ctx context.Context, refundID int64) error {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.RefundItem{}).Where("refund_id = ?", refundID).Count(&count).Error; err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	if count > 0 {
+		return errors.ErrOperationFailed.WithMessage("商城订单部分退款请在商城退款中审批")
+	}
+	return nil
+}
+
+// RefundApprovedNote 退款审核通过时写入订单时间线的系统备注内容
+func RefundApprovedNote(amount float64) string {
+	return fmt.Sprintf("退款申请已通过，退款金额 %.2f 元", amount)
+}
+
 // RejectRefund 拒绝退款（管理端）
 func (s *RefundService) RejectRefund(ctx context.Context, operatorID int64, refundID int64, reason string) error {
 	refund, err := s.refundRepo.GetByID(ctx, refundID)
@@ -273,6 +336,9 @@ func (s *RefundService) RejectRefund(ctx context.Context, operatorID int64, refu
 	if refund.Status != models.RefundStatusPending {
 		return errors.ErrOperationFailed.WithMessage("退款申请状态不允许拒绝")
 	}
+	if err := s.checkNotMallPartialRefund(ctx, refundID); err != nil {
+		return err
+	}
 
 	now := time.Now()
 	operatorType := models.RefundOperatorAdmin
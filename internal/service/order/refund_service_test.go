@@ -33,6 +33,10 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		&models.OrderItem{},
 		&models.Payment{},
 		&models.Refund{},
+		&models.RefundItem{},
+		&models.OrderNote{},
+		&models.GiftOrder{},
+		&models.Product{},
 	))
 
 	db.Create(&models.MemberLevel{ID: 1, Name: "普通会员", Level: 1, MinPoints: 0, Discount: 1.0})
@@ -437,6 +441,13 @@ func TestRefundService_ApproveRefund(t *testing.T) {
 		assert.Equal(t, adminID, *updated.OperatorID)
 		require.NotNil(t, updated.OperatorType)
 		assert.Equal(t, models.RefundOperatorAdmin, *updated.OperatorType)
+
+		// 订单时间线记录退款通过
+		var note models.OrderNote
+		require.NoError(t, db.Where("order_id = ?", order.ID).First(&note).Error)
+		assert.Equal(t, models.OrderNoteAuthorSystem, note.AuthorType)
+		assert.Equal(t, RefundApprovedNote(10.0), note.Content)
+		assert.True(t, note.VisibleToUser)
 	})
 
 	t.Run("退款不存在", func(t *testing.T) {
@@ -447,6 +458,35 @@ func TestRefundService_ApproveRefund(t *testing.T) {
 		assert.Equal(t, appErrors.ErrRefundNotFound.Code, appErr.Code)
 	})
 
+	t.Run("商城订单部分退款不能在通用退款中审批", func(t *testing.T) {
+		partial := &models.Refund{
+			RefundNo:  fmt.Sprintf("R%d", time.Now().UnixNano()),
+			OrderID:   order.ID,
+			OrderNo:   order.OrderNo,
+			PaymentID: payment.ID,
+			PaymentNo: payment.PaymentNo,
+			UserID:    user.ID,
+			Amount:    5.0,
+			Reason:    "部分退款",
+			Status:    models.RefundStatusPending,
+		}
+		require.NoError(t, db.Create(partial).Error)
+		require.NoError(t, db.Create(&models.RefundItem{RefundID: partial.ID, OrderID: order.ID, OrderItemID: 1, Quantity: 1, Amount: 5.0}).Error)
+
+		for _, err := range []error{
+			svc.ApproveRefund(ctx, adminID, partial.ID),
+			svc.RejectRefund(ctx, adminID, partial.ID, "拒绝"),
+		} {
+			appErr, ok := err.(*appErrors.AppError)
+			require.True(t, ok)
+			assert.Equal(t, appErrors.ErrOperationFailed.Code, appErr.Code)
+		}
+
+		var unchanged models.Refund
+		require.NoError(t, db.First(&unchanged, partial.ID).Error)
+		assert.EqualValues(t, models.RefundStatusPending, unchanged.Status)
+	})
+
 	t.Run("非待处理状态不允许审批", func(t *testing.T) {
 		require.NoError(t, db.Model(&models.Refund{}).Where("id = ?", refund.ID).
 			UpdateColumn("status", models.RefundStatusRejected).Error)
@@ -0,0 +1,128 @@
+// Package payment 提供支付服务
+package payment
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// 幂等键相关常量
+const (
+	IdempotencyKeyHeader  = "Idempotency-Key" // 请求头名称
+	IdempotencyKeyMaxLen  = 128               // 幂等键最大长度
+	DefaultIdempotencyTTL = 24 * time.Hour    // 幂等键默认有效期
+)
+
+// IdempotencyService 幂等键服务
+// 用于支付、钱包扣款等需要防止客户端重试导致重复执行的写操作
+type IdempotencyService struct {
+	repo *repository.IdempotencyRepository
+	ttl  time.Duration
+}
+
+// NewIdempotencyService 创建幂等键服务
+func NewIdempotencyService(repo *repository.IdempotencyRepository) *IdempotencyService {
+	return &IdempotencyService{
+		repo: repo,
+		ttl:  DefaultIdempotencyTTL,
+	}
+}
+
+// ReserveTx 在已有事务中预留幂等键
+// key 为空时返回 (nil, false, nil)，表示调用方未启用幂等
+// 若键已被同一请求使用过，返回 replayed=true，调用方应直接返回记录中的响应快照
+// 若键已被不同请求使用过，返回 ErrIdempotencyConflict
+func (s *IdempotencyService) ReserveTx(ctx context.Context, tx *gorm.DB, userID int64, key, scope string, payload interface{}) (*models.IdempotencyKey, bool, error) {
+	if key == "" {
+		return nil, false, nil
+	}
+	if len(key) > IdempotencyKeyMaxLen {
+		return nil, false, errors.ErrInvalidParams.WithMessage("幂等键过长")
+	}
+
+	requestHash, err := hashRequest(scope, payload)
+	if err != nil {
+		return nil, false, errors.ErrInternalError.WithError(err)
+	}
+
+	now := time.Now()
+	record := &models.IdempotencyKey{
+		Key:         key,
+		UserID:      userID,
+		Scope:       scope,
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+
+	created, err := s.repo.CreateIfAbsent(ctx, tx, record)
+	if err != nil {
+		return nil, false, errors.ErrDatabaseError.WithError(err)
+	}
+	if created {
+		return record, false, nil
+	}
+
+	existing, err := s.repo.GetByUserAndKey(ctx, tx, userID, key)
+	if err != nil {
+		return nil, false, errors.ErrDatabaseError.WithError(err)
+	}
+
+	// 已过期的键视为未使用，重新占用
+	if existing.ExpiresAt.Before(now) {
+		if err := s.repo.Delete(ctx, tx, existing.ID); err != nil {
+			return nil, false, errors.ErrDatabaseError.WithError(err)
+		}
+		record.ID = 0
+		if _, err := s.repo.CreateIfAbsent(ctx, tx, record); err != nil {
+			return nil, false, errors.ErrDatabaseError.WithError(err)
+		}
+		return record, false, nil
+	}
+
+	if existing.RequestHash != requestHash {
+		return nil, false, errors.ErrIdempotencyConflict
+	}
+
+	return existing, true, nil
+}
+
+// CompleteTx 在已有事务中保存响应快照
+func (s *IdempotencyService) CompleteTx(ctx context.Context, tx *gorm.DB, record *models.IdempotencyKey, resp interface{}) error {
+	if record == nil {
+		return nil
+	}
+
+	snapshot, err := json.Marshal(resp)
+	if err != nil {
+		return errors.ErrInternalError.WithError(err)
+	}
+	if err := s.repo.SaveResponse(ctx, tx, record.ID, snapshot); err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	record.ResponseSnapshot = snapshot
+	return nil
+}
+
+// CleanExpired 清理过期的幂等键
+func (s *IdempotencyService) CleanExpired(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpired(ctx, time.Now())
+}
+
+// hashRequest 计算请求摘要
+func hashRequest(scope string, payload interface{}) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(scope+":"), data...))
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,243 @@
+package payment
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	"github.com/dumeirei/smart-locker-backend/pkg/wechatpay"
+)
+
+// setupIdempotentPaymentService 创建启用幂等键的支付服务
+func setupIdempotentPaymentService(t *testing.T) *testPaymentService {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.IdempotencyKey{}))
+
+	// 内存 SQLite 每个连接是独立的数据库，并发测试需要共享单连接
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	idempotencySvc := NewIdempotencyService(repository.NewIdempotencyRepository(db))
+	service := NewPaymentService(db,
+		repository.NewPaymentRepository(db),
+		repository.NewRefundRepository(db),
+		repository.NewRentalRepository(db),
+		nil,
+		idempotencySvc,
+	)
+
+	return &testPaymentService{
+		PaymentService: service,
+		db:             db,
+	}
+}
+
+func newIdempotentPaymentRequest() *CreatePaymentRequest {
+	return &CreatePaymentRequest{
+		OrderID:        1,
+		OrderNo:        "M20240101001",
+		OrderType:      models.OrderTypeMall,
+		Amount:         88.0,
+		PaymentMethod:  models.PaymentMethodBalance,
+		PaymentChannel: models.PaymentChannelMiniProgram,
+	}
+}
+
+func TestIdempotencyService_ReserveTx(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.IdempotencyKey{}))
+	svc := NewIdempotencyService(repository.NewIdempotencyRepository(db))
+	ctx := context.Background()
+	payload := map[string]int64{"rental_id": 1}
+
+	t.Run("未提供幂等键", func(t *testing.T) {
+		record, replayed, err := svc.ReserveTx(ctx, db, 1, "", models.IdempotencyScopeRentalPay, payload)
+		require.NoError(t, err)
+		assert.Nil(t, record)
+		assert.False(t, replayed)
+	})
+
+	t.Run("幂等键过长", func(t *testing.T) {
+		longKey := string(make([]byte, IdempotencyKeyMaxLen+1))
+		_, _, err := svc.ReserveTx(ctx, db, 1, longKey, models.IdempotencyScopeRentalPay, payload)
+		require.Error(t, err)
+		assert.Equal(t, appErrors.ErrInvalidParams.Code, err.(*appErrors.AppError).Code)
+	})
+
+	t.Run("首次请求与重放", func(t *testing.T) {
+		record, replayed, err := svc.ReserveTx(ctx, db, 1, "k1", models.IdempotencyScopeRentalPay, payload)
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.False(t, replayed)
+		require.NoError(t, svc.CompleteTx(ctx, db, record, map[string]string{"ok": "1"}))
+
+		again, replayed, err := svc.ReserveTx(ctx, db, 1, "k1", models.IdempotencyScopeRentalPay, payload)
+		require.NoError(t, err)
+		assert.True(t, replayed)
+		assert.JSONEq(t, `{"ok":"1"}`, string(again.ResponseSnapshot))
+	})
+
+	t.Run("同键不同请求返回冲突", func(t *testing.T) {
+		_, _, err := svc.ReserveTx(ctx, db, 1, "k1", models.IdempotencyScopeRentalPay, map[string]int64{"rental_id": 2})
+		require.Error(t, err)
+		assert.Equal(t, appErrors.ErrIdempotencyConflict.Code, err.(*appErrors.AppError).Code)
+	})
+
+	t.Run("同键不同作用域返回冲突", func(t *testing.T) {
+		_, _, err := svc.ReserveTx(ctx, db, 1, "k1", models.IdempotencyScopePaymentCreate, payload)
+		require.Error(t, err)
+		assert.Equal(t, appErrors.ErrIdempotencyConflict.Code, err.(*appErrors.AppError).Code)
+	})
+
+	t.Run("过期的键可被重新使用", func(t *testing.T) {
+		require.NoError(t, db.Create(&models.IdempotencyKey{
+			Key:         "expired",
+			UserID:      1,
+			Scope:       models.IdempotencyScopeRentalPay,
+			RequestHash: "old",
+			ExpiresAt:   time.Now().Add(-time.Minute),
+		}).Error)
+
+		record, replayed, err := svc.ReserveTx(ctx, db, 1, "expired", models.IdempotencyScopeRentalPay, payload)
+		require.NoError(t, err)
+		assert.False(t, replayed)
+		assert.True(t, record.ExpiresAt.After(time.Now()))
+	})
+}
+
+func TestPaymentService_CreatePayment_Idempotent(t *testing.T) {
+	svc := setupIdempotentPaymentService(t)
+	ctx := context.Background()
+	user := createTestUser(t, svc.db)
+
+	t.Run("重放返回首次创建的支付单", func(t *testing.T) {
+		first, err := svc.CreatePayment(ctx, user.ID, newIdempotentPaymentRequest(), "pay-key-1")
+		require.NoError(t, err)
+
+		second, err := svc.CreatePayment(ctx, user.ID, newIdempotentPaymentRequest(), "pay-key-1")
+		require.NoError(t, err)
+		assert.Equal(t, first.PaymentNo, second.PaymentNo)
+
+		var count int64
+		svc.db.Model(&models.Payment{}).Where("order_no = ?", "M20240101001").Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("同键不同金额返回冲突", func(t *testing.T) {
+		req := newIdempotentPaymentRequest()
+		req.Amount = 1.0
+
+		_, err := svc.CreatePayment(ctx, user.ID, req, "pay-key-1")
+		require.Error(t, err)
+		assert.Equal(t, appErrors.ErrIdempotencyConflict.Code, err.(*appErrors.AppError).Code)
+	})
+
+	t.Run("不带幂等键每次创建新支付单", func(t *testing.T) {
+		req := newIdempotentPaymentRequest()
+		req.OrderNo = "M20240101002"
+
+		first, err := svc.CreatePayment(ctx, user.ID, req, "")
+		require.NoError(t, err)
+		second, err := svc.CreatePayment(ctx, user.ID, req, "")
+		require.NoError(t, err)
+		assert.NotEqual(t, first.PaymentNo, second.PaymentNo)
+	})
+}
+
+func TestPaymentService_CreatePayment_IdempotentWechat(t *testing.T) {
+	svc := setupIdempotentPaymentService(t)
+	wp, err := wechatpay.NewClient(&wechatpay.Config{})
+	require.NoError(t, err)
+	svc.wechatPay = wp
+	ctx := context.Background()
+	user := createTestUser(t, svc.db)
+
+	req := newIdempotentPaymentRequest()
+	req.PaymentMethod = models.PaymentMethodWechat
+	req.OpenID = "oXXXX12345"
+
+	first, err := svc.CreatePayment(ctx, user.ID, req, "wechat-key")
+	require.NoError(t, err)
+	require.NotNil(t, first.PayParams)
+
+	t.Run("重放返回首次的支付参数", func(t *testing.T) {
+		second, err := svc.CreatePayment(ctx, user.ID, req, "wechat-key")
+		require.NoError(t, err)
+		assert.Equal(t, first.PaymentNo, second.PaymentNo)
+		require.NotNil(t, second.PayParams)
+		assert.Equal(t, first.PayParams.PrepayID, second.PayParams.PrepayID)
+	})
+
+	t.Run("下单未完成时重放沿用支付单重新下单", func(t *testing.T) {
+		// 模拟支付单已落库但微信下单失败，快照中没有支付参数
+		require.NoError(t, svc.db.Model(&models.IdempotencyKey{}).Where("idempotency_key = ?", "wechat-key").
+			Update("response_snapshot", []byte(`{"payment_no":"`+first.PaymentNo+`"}`)).Error)
+
+		retried, err := svc.CreatePayment(ctx, user.ID, req, "wechat-key")
+		require.NoError(t, err)
+		assert.Equal(t, first.PaymentNo, retried.PaymentNo)
+		require.NotNil(t, retried.PayParams)
+
+		var record models.IdempotencyKey
+		require.NoError(t, svc.db.Where("idempotency_key = ?", "wechat-key").First(&record).Error)
+		assert.Contains(t, string(record.ResponseSnapshot), retried.PayParams.PrepayID)
+
+		var count int64
+		require.NoError(t, svc.db.Model(&models.Payment{}).Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+}
+
+func TestPaymentService_CreatePayment_ConcurrentDuplicates(t *testing.T) {
+	svc := setupIdempotentPaymentService(t)
+	ctx := context.Background()
+	user := createTestUser(t, svc.db)
+
+	const workers = 10
+	var wg sync.WaitGroup
+	paymentNos := make(chan string, workers)
+	errs := make(chan error, workers)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := svc.CreatePayment(ctx, user.ID, newIdempotentPaymentRequest(), "concurrent-key")
+			if err != nil {
+				errs <- err
+				return
+			}
+			paymentNos <- resp.PaymentNo
+		}()
+	}
+	wg.Wait()
+	close(paymentNos)
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	unique := make(map[string]struct{})
+	for no := range paymentNos {
+		unique[no] = struct{}{}
+	}
+	assert.Len(t, unique, 1)
+
+	var count int64
+	require.NoError(t, svc.db.Model(&models.Payment{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	var keys []models.IdempotencyKey
+	require.NoError(t, svc.db.Find(&keys).Error)
+	require.Len(t, keys, 1)
+	assert.NotEmpty(t, keys[0].ResponseSnapshot)
+}
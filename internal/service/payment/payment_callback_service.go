@@ -0,0 +1,171 @@
+package payment
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/pkg/wechatpay"
+)
+
+// WechatNotifyParser 微信支付回调验签解密（由 wechatpay.NotifyVerifier 实现）
+type WechatNotifyParser interface {
+	ParseVerifiedNotify(body []byte, header http.Header) (*wechatpay.NotifyResource, error)
+}
+
+// RechargeCallbackHandler 钱包充值回调处理
+type RechargeCallbackHandler interface {
+	HandleRechargeCallback(ctx context.Context, paymentNo string, externalOrderNo string, paidAmount float64) error
+}
+
+// PaymentSuccessHandler 订单支付成功后的业务处理
+// 实现需保证幂等：重复调用不会产生副作用
+type PaymentSuccessHandler interface {
+	OnPaymentSuccess(ctx context.Context, orderID int64) error
+}
+
+// PaymentCallbackService 支付回调服务
+type PaymentCallbackService struct {
+	db              *gorm.DB
+	notifyParser    WechatNotifyParser
+	successHandlers map[string]PaymentSuccessHandler // 订单类型 -> 支付成功处理
+	recharge        RechargeCallbackHandler
+}
+
+// NewPaymentCallbackService 创建支付回调服务
+// successHandlers 按订单类型分发支付成功事件；rechargeHandler 可为 nil，此时不处理钱包充值支付单
+func NewPaymentCallbackService(
+	db *gorm.DB,
+	notifyParser WechatNotifyParser,
+	successHandlers map[string]PaymentSuccessHandler,
+	rechargeHandler RechargeCallbackHandler,
+) *PaymentCallbackService {
+	return &PaymentCallbackService{
+		db:              db,
+		notifyParser:    notifyParser,
+		successHandlers: successHandlers,
+		recharge:        rechargeHandler,
+	}
+}
+
+// VerifyAndProcessWechatNotify 验签并处理微信支付回调
+// 支付单按 out_trade_no 匹配，待支付时更新为成功并按订单类型分发支付成功事件；
+// 已成功的支付单重复通知时仅重新分发（业务处理幂等），以便上次分发失败时借助微信重试补偿；
+// 已退款、已关闭或已失败的支付单直接确认，不做任何处理
+func (s *PaymentCallbackService) VerifyAndProcessWechatNotify(ctx context.Context, body []byte, header http.Header) error {
+	resource, err := s.notifyParser.ParseVerifiedNotify(body, header)
+	if err != nil {
+		return errors.ErrPaymentCallbackError.WithError(err)
+	}
+
+	var payment models.Payment
+	if err := s.db.WithContext(ctx).Preload("Order").
+		Where("payment_no = ?", resource.OutTradeNo).First(&payment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.ErrPaymentNotFound
+		}
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	if payment.Order == nil {
+		return errors.ErrOrderNotFound
+	}
+
+	// 钱包充值支付单交由钱包服务入账（自身幂等）
+	if payment.Order.Type == models.OrderTypeRecharge && s.recharge != nil {
+		if payment.Status != models.PaymentStatusPending || resource.TradeState != wechatpay.TradeStateSuccess {
+			return nil
+		}
+		return s.recharge.HandleRechargeCallback(ctx, payment.PaymentNo, resource.TransactionID,
+			float64(resource.Amount.Total)/100)
+	}
+
+	switch payment.Status {
+	case models.PaymentStatusPending:
+	case models.PaymentStatusSuccess:
+		return s.dispatchPaymentSuccess(ctx, payment.Order)
+	default:
+		return nil
+	}
+
+	if resource.TradeState != wechatpay.TradeStateSuccess {
+		return s.markPaymentFailed(ctx, &payment, resource.TradeState)
+	}
+
+	// 按分比较金额，避免浮点误差
+	if int64(math.Round(payment.Amount*100)) != resource.Amount.Total {
+		return errors.ErrPaymentCallbackError.WithMessage("金额不匹配")
+	}
+
+	processed, err := s.markPaymentSuccess(ctx, &payment, resource.TransactionID)
+	if err != nil {
+		return err
+	}
+	if !processed {
+		// 并发通知已由另一请求处理
+		return nil
+	}
+
+	return s.dispatchPaymentSuccess(ctx, payment.Order)
+}
+
+// markPaymentSuccess 将待支付的支付单更新为成功，并将待支付订单标记为已支付
+// 返回 false 表示支付单已被并发处理
+func (s *PaymentCallbackService) markPaymentSuccess(ctx context.Context, payment *models.Payment, transactionID string) (bool, error) {
+	processed := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		res := tx.Model(&models.Payment{}).
+			Where("id = ? AND status = ?", payment.ID, models.PaymentStatusPending).
+			Updates(map[string]interface{}{
+				"status":         models.PaymentStatusSuccess,
+				"transaction_id": transactionID,
+				"pay_time":       now,
+			})
+		if res.Error != nil {
+			return errors.ErrDatabaseError.WithError(res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&models.Order{}).
+			Where("id = ? AND status = ?", payment.OrderID, models.OrderStatusPending).
+			Updates(map[string]interface{}{
+				"status":  models.OrderStatusPaid,
+				"paid_at": now,
+			}).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		processed = true
+		return nil
+	})
+	return processed, err
+}
+
+// markPaymentFailed 将待支付的支付单标记为失败
+func (s *PaymentCallbackService) markPaymentFailed(ctx context.Context, payment *models.Payment, tradeState string) error {
+	if err := s.db.WithContext(ctx).Model(&models.Payment{}).
+		Where("id = ? AND status = ?", payment.ID, models.PaymentStatusPending).
+		Updates(map[string]interface{}{
+			"status":        models.PaymentStatusFailed,
+			"error_message": tradeState,
+		}).Error; err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	return nil
+}
+
+// dispatchPaymentSuccess 按订单类型分发支付成功事件，未注册处理器的订单类型忽略
+func (s *PaymentCallbackService) dispatchPaymentSuccess(ctx context.Context, order *models.Order) error {
+	handler, ok := s.successHandlers[order.Type]
+	if !ok || handler == nil {
+		return nil
+	}
+	return handler.OnPaymentSuccess(ctx, order.ID)
+}
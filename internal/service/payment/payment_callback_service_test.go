@@ -0,0 +1,296 @@
+package payment
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/pkg/wechatpay"
+)
+
+// recordingSuccessHandler 记录支付成功分发的订单
+type recordingSuccessHandler struct {
+	orderIDs []int64
+	err      error
+}
+
+func (h *recordingSuccessHandler) OnPaymentSuccess(ctx context.Context, orderID int64) error {
+	h.orderIDs = append(h.orderIDs, orderID)
+	return h.err
+}
+
+// recordingRechargeHandler 记录钱包充值回调
+type recordingRechargeHandler struct {
+	paymentNos []string
+}
+
+func (h *recordingRechargeHandler) HandleRechargeCallback(ctx context.Context, paymentNo string, externalOrderNo string, paidAmount float64) error {
+	h.paymentNos = append(h.paymentNos, paymentNo)
+	return nil
+}
+
+// callbackTestEnv 支付回调测试环境
+type callbackTestEnv struct {
+	svc      *PaymentCallbackService
+	db       *gorm.DB
+	platform *wechatpay.FakePlatform
+	handlers map[string]*recordingSuccessHandler
+	recharge *recordingRechargeHandler
+	user     *models.User
+}
+
+// setupCallbackTest 创建使用模拟微信支付平台的回调服务
+func setupCallbackTest(t *testing.T) *callbackTestEnv {
+	t.Helper()
+
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Order{}))
+
+	platform, err := wechatpay.NewFakePlatform()
+	require.NoError(t, err)
+
+	env := &callbackTestEnv{
+		db:       db,
+		platform: platform,
+		handlers: map[string]*recordingSuccessHandler{
+			models.OrderTypeRental: {},
+			models.OrderTypeMall:   {},
+			models.OrderTypeHotel:  {},
+		},
+		recharge: &recordingRechargeHandler{},
+		user:     createTestUser(t, db),
+	}
+
+	successHandlers := make(map[string]PaymentSuccessHandler, len(env.handlers))
+	for orderType, h := range env.handlers {
+		successHandlers[orderType] = h
+	}
+	env.svc = NewPaymentCallbackService(db, platform.Verifier(), successHandlers, env.recharge)
+	return env
+}
+
+// createPaymentWithOrder 创建订单及对应的微信支付单
+func (env *callbackTestEnv) createPaymentWithOrder(t *testing.T, paymentNo, orderType string, amount float64, status int8) (*models.Order, *models.Payment) {
+	t.Helper()
+
+	order := &models.Order{
+		OrderNo:        "O" + paymentNo,
+		UserID:         env.user.ID,
+		Type:           orderType,
+		OriginalAmount: amount,
+		ActualAmount:   amount,
+		Status:         models.OrderStatusPending,
+	}
+	require.NoError(t, env.db.Create(order).Error)
+
+	payment := &models.Payment{
+		PaymentNo:      paymentNo,
+		OrderID:        order.ID,
+		OrderNo:        order.OrderNo,
+		UserID:         env.user.ID,
+		Amount:         amount,
+		PaymentMethod:  models.PaymentMethodWechat,
+		PaymentChannel: models.PaymentChannelMiniProgram,
+		Status:         status,
+	}
+	require.NoError(t, env.db.Create(payment).Error)
+	return order, payment
+}
+
+// buildNotify 构造已签名的支付结果通知
+func (env *callbackTestEnv) buildNotify(t *testing.T, paymentNo, tradeState string, totalCents int64) ([]byte, http.Header) {
+	t.Helper()
+
+	resource := &wechatpay.NotifyResource{
+		OutTradeNo:    paymentNo,
+		TransactionID: "4200" + paymentNo,
+		TradeType:     "JSAPI",
+		TradeState:    tradeState,
+		SuccessTime:   time.Now().Format(time.RFC3339),
+	}
+	resource.Amount.Total = totalCents
+	resource.Amount.PayerTotal = totalCents
+	resource.Amount.Currency = "CNY"
+
+	body, header, err := env.platform.BuildNotify(resource)
+	require.NoError(t, err)
+	return body, header
+}
+
+func TestPaymentCallbackService_SuccessDispatchesByOrderType(t *testing.T) {
+	env := setupCallbackTest(t)
+	ctx := context.Background()
+
+	for _, orderType := range []string{models.OrderTypeRental, models.OrderTypeMall, models.OrderTypeHotel} {
+		paymentNo := "P_CB_" + orderType
+		order, _ := env.createPaymentWithOrder(t, paymentNo, orderType, 60, models.PaymentStatusPending)
+
+		body, header := env.buildNotify(t, paymentNo, wechatpay.TradeStateSuccess, 6000)
+		require.NoError(t, env.svc.VerifyAndProcessWechatNotify(ctx, body, header))
+
+		var payment models.Payment
+		require.NoError(t, env.db.Where("payment_no = ?", paymentNo).First(&payment).Error)
+		assert.EqualValues(t, models.PaymentStatusSuccess, payment.Status)
+		require.NotNil(t, payment.TransactionID)
+		assert.Equal(t, "4200"+paymentNo, *payment.TransactionID)
+		assert.NotNil(t, payment.PaidAt)
+
+		var updatedOrder models.Order
+		require.NoError(t, env.db.First(&updatedOrder, order.ID).Error)
+		assert.Equal(t, models.OrderStatusPaid, updatedOrder.Status)
+		assert.NotNil(t, updatedOrder.PaidAt)
+
+		assert.Equal(t, []int64{order.ID}, env.handlers[orderType].orderIDs, orderType)
+	}
+}
+
+func TestPaymentCallbackService_DuplicateNotifyIsIdempotent(t *testing.T) {
+	env := setupCallbackTest(t)
+	ctx := context.Background()
+
+	order, _ := env.createPaymentWithOrder(t, "P_CB_DUP", models.OrderTypeHotel, 60, models.PaymentStatusPending)
+	body, header := env.buildNotify(t, "P_CB_DUP", wechatpay.TradeStateSuccess, 6000)
+
+	require.NoError(t, env.svc.VerifyAndProcessWechatNotify(ctx, body, header))
+
+	var first models.Payment
+	require.NoError(t, env.db.Where("payment_no = ?", "P_CB_DUP").First(&first).Error)
+
+	require.NoError(t, env.svc.VerifyAndProcessWechatNotify(ctx, body, header))
+
+	var second models.Payment
+	require.NoError(t, env.db.Where("payment_no = ?", "P_CB_DUP").First(&second).Error)
+	assert.EqualValues(t, models.PaymentStatusSuccess, second.Status)
+	assert.True(t, first.PaidAt.Equal(*second.PaidAt), "重复通知不应更新支付时间")
+
+	// 重复通知重新分发，由业务处理的幂等性保证无副作用
+	assert.Equal(t, []int64{order.ID, order.ID}, env.handlers[models.OrderTypeHotel].orderIDs)
+}
+
+func TestPaymentCallbackService_FailedDispatchRetriedByDuplicateNotify(t *testing.T) {
+	env := setupCallbackTest(t)
+	ctx := context.Background()
+
+	order, _ := env.createPaymentWithOrder(t, "P_CB_RETRY", models.OrderTypeRental, 60, models.PaymentStatusPending)
+	body, header := env.buildNotify(t, "P_CB_RETRY", wechatpay.TradeStateSuccess, 6000)
+
+	env.handlers[models.OrderTypeRental].err = stderrors.New("rental service unavailable")
+	require.Error(t, env.svc.VerifyAndProcessWechatNotify(ctx, body, header))
+
+	// 支付单已记为成功，微信重试时补偿分发
+	env.handlers[models.OrderTypeRental].err = nil
+	require.NoError(t, env.svc.VerifyAndProcessWechatNotify(ctx, body, header))
+	assert.Equal(t, []int64{order.ID, order.ID}, env.handlers[models.OrderTypeRental].orderIDs)
+}
+
+func TestPaymentCallbackService_RefundedPaymentHasNoSideEffects(t *testing.T) {
+	env := setupCallbackTest(t)
+	ctx := context.Background()
+
+	order, _ := env.createPaymentWithOrder(t, "P_CB_REFUNDED", models.OrderTypeMall, 60, models.PaymentStatusRefunded)
+	require.NoError(t, env.db.Model(order).Update("status", models.OrderStatusRefunded).Error)
+
+	body, header := env.buildNotify(t, "P_CB_REFUNDED", wechatpay.TradeStateSuccess, 6000)
+	require.NoError(t, env.svc.VerifyAndProcessWechatNotify(ctx, body, header))
+
+	var payment models.Payment
+	require.NoError(t, env.db.Where("payment_no = ?", "P_CB_REFUNDED").First(&payment).Error)
+	assert.EqualValues(t, models.PaymentStatusRefunded, payment.Status)
+	assert.Nil(t, payment.TransactionID)
+
+	var updatedOrder models.Order
+	require.NoError(t, env.db.First(&updatedOrder, order.ID).Error)
+	assert.Equal(t, models.OrderStatusRefunded, updatedOrder.Status)
+	assert.Empty(t, env.handlers[models.OrderTypeMall].orderIDs)
+}
+
+func TestPaymentCallbackService_RejectsInvalidSignature(t *testing.T) {
+	env := setupCallbackTest(t)
+	ctx := context.Background()
+
+	env.createPaymentWithOrder(t, "P_CB_FORGED", models.OrderTypeRental, 60, models.PaymentStatusPending)
+	body, _ := env.buildNotify(t, "P_CB_FORGED", wechatpay.TradeStateSuccess, 6000)
+
+	// 使用其他平台私钥签名的伪造通知
+	forger, err := wechatpay.NewFakePlatform()
+	require.NoError(t, err)
+	header, err := forger.Sign(body, time.Now())
+	require.NoError(t, err)
+
+	err = env.svc.VerifyAndProcessWechatNotify(ctx, body, header)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, wechatpay.ErrInvalidSignature)
+	var appErr *appErrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, appErrors.ErrPaymentCallbackError.Code, appErr.Code)
+
+	var payment models.Payment
+	require.NoError(t, env.db.Where("payment_no = ?", "P_CB_FORGED").First(&payment).Error)
+	assert.EqualValues(t, models.PaymentStatusPending, payment.Status)
+	assert.Empty(t, env.handlers[models.OrderTypeRental].orderIDs)
+}
+
+func TestPaymentCallbackService_AmountMismatch(t *testing.T) {
+	env := setupCallbackTest(t)
+	ctx := context.Background()
+
+	env.createPaymentWithOrder(t, "P_CB_AMOUNT", models.OrderTypeRental, 60, models.PaymentStatusPending)
+	body, header := env.buildNotify(t, "P_CB_AMOUNT", wechatpay.TradeStateSuccess, 1)
+
+	err := env.svc.VerifyAndProcessWechatNotify(ctx, body, header)
+	require.Error(t, err)
+	var appErr *appErrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, appErrors.ErrPaymentCallbackError.Code, appErr.Code)
+
+	var payment models.Payment
+	require.NoError(t, env.db.Where("payment_no = ?", "P_CB_AMOUNT").First(&payment).Error)
+	assert.EqualValues(t, models.PaymentStatusPending, payment.Status)
+}
+
+func TestPaymentCallbackService_PaymentNotFound(t *testing.T) {
+	env := setupCallbackTest(t)
+
+	body, header := env.buildNotify(t, "P_CB_MISSING", wechatpay.TradeStateSuccess, 6000)
+	err := env.svc.VerifyAndProcessWechatNotify(context.Background(), body, header)
+	assert.ErrorIs(t, err, appErrors.ErrPaymentNotFound)
+}
+
+func TestPaymentCallbackService_NonSuccessTradeStateMarksFailed(t *testing.T) {
+	env := setupCallbackTest(t)
+	ctx := context.Background()
+
+	order, _ := env.createPaymentWithOrder(t, "P_CB_PAYERROR", models.OrderTypeRental, 60, models.PaymentStatusPending)
+	body, header := env.buildNotify(t, "P_CB_PAYERROR", wechatpay.TradeStatePayError, 6000)
+	require.NoError(t, env.svc.VerifyAndProcessWechatNotify(ctx, body, header))
+
+	var payment models.Payment
+	require.NoError(t, env.db.Where("payment_no = ?", "P_CB_PAYERROR").First(&payment).Error)
+	assert.EqualValues(t, models.PaymentStatusFailed, payment.Status)
+	require.NotNil(t, payment.ErrorMessage)
+	assert.Equal(t, wechatpay.TradeStatePayError, *payment.ErrorMessage)
+
+	var updatedOrder models.Order
+	require.NoError(t, env.db.First(&updatedOrder, order.ID).Error)
+	assert.Equal(t, models.OrderStatusPending, updatedOrder.Status)
+	assert.Empty(t, env.handlers[models.OrderTypeRental].orderIDs)
+}
+
+func TestPaymentCallbackService_RechargeDelegatesToWallet(t *testing.T) {
+	env := setupCallbackTest(t)
+	ctx := context.Background()
+
+	env.createPaymentWithOrder(t, "P_CB_RECHARGE", models.OrderTypeRecharge, 100, models.PaymentStatusPending)
+	body, header := env.buildNotify(t, "P_CB_RECHARGE", wechatpay.TradeStateSuccess, 10000)
+
+	require.NoError(t, env.svc.VerifyAndProcessWechatNotify(ctx, body, header))
+	assert.Equal(t, []string{"P_CB_RECHARGE"}, env.recharge.paymentNos)
+}
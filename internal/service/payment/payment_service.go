@@ -3,6 +3,7 @@ package payment
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -22,15 +23,18 @@ type PaymentService struct {
 	refundRepo  *repository.RefundRepository
 	rentalRepo  *repository.RentalRepository
 	wechatPay   *wechatpay.Client
+	idempotency *IdempotencyService
 }
 
 // NewPaymentService 创建支付服务
+// 微信支付结果通知由 PaymentCallbackService 验签后统一处理
 func NewPaymentService(
 	db *gorm.DB,
 	paymentRepo *repository.PaymentRepository,
 	refundRepo *repository.RefundRepository,
 	rentalRepo *repository.RentalRepository,
 	wechatPay *wechatpay.Client,
+	idempotencySvc *IdempotencyService,
 ) *PaymentService {
 	return &PaymentService{
 		db:          db,
@@ -38,6 +42,7 @@ func NewPaymentService(
 		refundRepo:  refundRepo,
 		rentalRepo:  rentalRepo,
 		wechatPay:   wechatPay,
+		idempotency: idempotencySvc,
 	}
 }
 
@@ -61,7 +66,66 @@ type CreatePaymentResponse struct {
 }
 
 // CreatePayment 创建支付
-func (s *PaymentService) CreatePayment(ctx context.Context, userID int64, req *CreatePaymentRequest) (*CreatePaymentResponse, error) {
+// 支付记录与幂等键在事务中先行落库，第三方下单在事务提交后进行，避免外部调用期间占用数据库连接和锁；
+// 第三方下单失败时支付记录保持待支付，同一幂等键重试时沿用该支付单重新下单，未重试的由过期关闭任务处理
+func (s *PaymentService) CreatePayment(ctx context.Context, userID int64, req *CreatePaymentRequest, idempotencyKey string) (*CreatePaymentResponse, error) {
+	var resp *CreatePaymentResponse
+	var idemRecord *models.IdempotencyKey
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if s.idempotency != nil {
+			record, replayed, err := s.idempotency.ReserveTx(ctx, tx, userID, idempotencyKey,
+				models.IdempotencyScopePaymentCreate, req)
+			if err != nil {
+				return err
+			}
+			idemRecord = record
+			if replayed {
+				var cached CreatePaymentResponse
+				if err := json.Unmarshal(record.ResponseSnapshot, &cached); err != nil {
+					return errors.ErrInternalError.WithError(err)
+				}
+				resp = &cached
+				return nil
+			}
+		}
+
+		result, err := s.createPaymentRecordTx(ctx, tx, userID, req)
+		if err != nil {
+			return err
+		}
+		resp = result
+
+		// 先保存不含支付参数的响应快照，第三方下单成功后补充
+		if s.idempotency != nil {
+			return s.idempotency.CompleteTx(ctx, tx, idemRecord, resp)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.PayParams != nil || req.PaymentMethod != models.PaymentMethodWechat || s.wechatPay == nil {
+		return resp, nil
+	}
+
+	payParams, err := s.createWechatOrder(ctx, resp.PaymentNo, req)
+	if err != nil {
+		return nil, errors.ErrPaymentFailed.WithError(err)
+	}
+	resp.PayParams = payParams
+
+	if s.idempotency != nil {
+		if err := s.idempotency.CompleteTx(ctx, s.db.WithContext(ctx), idemRecord, resp); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// createPaymentRecordTx 在已有事务中创建待支付的支付记录
+func (s *PaymentService) createPaymentRecordTx(ctx context.Context, tx *gorm.DB, userID int64, req *CreatePaymentRequest) (*CreatePaymentResponse, error) {
 	paymentNo := utils.GenerateOrderNo("P")
 	expiredAt := time.Now().Add(30 * time.Minute)
 
@@ -77,115 +141,40 @@ func (s *PaymentService) CreatePayment(ctx context.Context, userID int64, req *C
 		ExpiredAt:      &expiredAt,
 	}
 
-	if err := s.paymentRepo.Create(ctx, payment); err != nil {
+	if err := tx.WithContext(ctx).Create(payment).Error; err != nil {
 		return nil, errors.ErrDatabaseError.WithError(err)
 	}
 
-	resp := &CreatePaymentResponse{
+	return &CreatePaymentResponse{
 		PaymentNo: paymentNo,
 		ExpiredAt: expiredAt,
-	}
-
-	// 调用微信支付创建订单
-	if req.PaymentMethod == models.PaymentMethodWechat && s.wechatPay != nil {
-		amount := int64(req.Amount * 100) // 转换为分
-		description := req.Description
-		if description == "" {
-			description = fmt.Sprintf("订单支付-%s", req.OrderNo)
-		}
-
-		wechatReq := &wechatpay.UnifiedOrderRequest{
-			OutTradeNo:  paymentNo,
-			Description: description,
-			Amount:      amount,
-			OpenID:      req.OpenID,
-		}
-
-		var payParams *wechatpay.UnifiedOrderResponse
-		var err error
-
-		switch req.PaymentChannel {
-		case models.PaymentChannelMiniProgram:
-			payParams, err = s.wechatPay.CreateOrder(ctx, wechatReq)
-		case models.PaymentChannelNative:
-			payParams, err = s.wechatPay.CreateNativeOrder(ctx, wechatReq)
-		case models.PaymentChannelH5:
-			payParams, err = s.wechatPay.CreateH5Order(ctx, wechatReq)
-		default:
-			payParams, err = s.wechatPay.CreateOrder(ctx, wechatReq)
-		}
-
-		if err != nil {
-			return nil, errors.ErrPaymentFailed.WithError(err)
-		}
-
-		resp.PayParams = payParams
-	}
-
-	return resp, nil
+	}, nil
 }
 
-// HandlePaymentCallback 处理支付回调
-func (s *PaymentService) HandlePaymentCallback(ctx context.Context, payload []byte) error {
-	if s.wechatPay == nil {
-		return errors.ErrPaymentCallbackError.WithMessage("微信支付客户端未初始化")
+// createWechatOrder 调用微信支付下单，商户订单号为支付单号，重复下单时微信返回同一预支付交易
+func (s *PaymentService) createWechatOrder(ctx context.Context, paymentNo string, req *CreatePaymentRequest) (*wechatpay.UnifiedOrderResponse, error) {
+	description := req.Description
+	if description == "" {
+		description = fmt.Sprintf("订单支付-%s", req.OrderNo)
 	}
 
-	resource, err := s.wechatPay.ParseNotify(payload)
-	if err != nil {
-		return errors.ErrPaymentCallbackError.WithError(err)
+	wechatReq := &wechatpay.UnifiedOrderRequest{
+		OutTradeNo:  paymentNo,
+		Description: description,
+		Amount:      int64(req.Amount * 100), // 转换为分
+		OpenID:      req.OpenID,
 	}
 
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 获取支付记录（在事务内使用 tx，确保一致性）
-		var payment models.Payment
-		if err := tx.WithContext(ctx).Where("payment_no = ?", resource.OutTradeNo).First(&payment).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return errors.ErrPaymentNotFound
-			}
-			return errors.ErrDatabaseError.WithError(err)
-		}
-
-		// 检查是否已处理
-		if payment.Status != models.PaymentStatusPending {
-			return nil
-		}
-
-		// 验证金额
-		callbackAmount := float64(resource.Amount.Total) / 100
-		if callbackAmount != payment.Amount {
-			return errors.ErrPaymentCallbackError.WithMessage("金额不匹配")
-		}
-
-		// 更新支付状态
-		now := time.Now()
-		transactionID := resource.TransactionID
-		if resource.TradeState == wechatpay.TradeStateSuccess {
-			payment.Status = models.PaymentStatusSuccess
-			payment.TransactionID = &transactionID
-			payment.PaidAt = &now
-		} else {
-			payment.Status = models.PaymentStatusFailed
-			errMsg := resource.TradeState
-			payment.ErrorMessage = &errMsg
-		}
-
-		if err := tx.Save(&payment).Error; err != nil {
-			return errors.ErrDatabaseError.WithError(err)
-		}
-
-		// 如果支付成功，更新订单状态
-		if payment.Status == models.PaymentStatusSuccess {
-			// 更新租借订单状态
-			if err := tx.Model(&models.Rental{}).
-				Where("order_id = ?", payment.OrderID).
-				Update("status", models.RentalStatusPaid).Error; err != nil {
-				return errors.ErrDatabaseError.WithError(err)
-			}
-		}
-
-		return nil
-	})
+	switch req.PaymentChannel {
+	case models.PaymentChannelMiniProgram:
+		return s.wechatPay.CreateOrder(ctx, wechatReq)
+	case models.PaymentChannelNative:
+		return s.wechatPay.CreateNativeOrder(ctx, wechatReq)
+	case models.PaymentChannelH5:
+		return s.wechatPay.CreateH5Order(ctx, wechatReq)
+	default:
+		return s.wechatPay.CreateOrder(ctx, wechatReq)
+	}
 }
 
 // QueryPayment 查询支付状态
@@ -0,0 +1,320 @@
+package payment
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// PreauthProvider 第三方支付预授权渠道
+// 授权时冻结用户支付账户中的押金，结束时按实际费用扣款或全额解冻
+type PreauthProvider interface {
+	// Method 预授权使用的支付方式，如 wechat
+	Method() string
+	// Authorize 发起预授权冻结金额，返回渠道授权单号
+	Authorize(ctx context.Context, paymentNo string, userID int64, amount float64) (string, error)
+	// Capture 从已授权金额中扣款，未扣款部分由渠道同时解冻
+	Capture(ctx context.Context, authorizationID string, amount float64) error
+	// Release 全额解冻已授权金额
+	Release(ctx context.Context, authorizationID string) error
+}
+
+// PreauthService 押金预授权服务
+// 预授权支付单状态：已授权 → 扣款中/解冻中 → 已扣款（扣除超时费，其余解冻）/ 已解冻（全额退还）
+// 渠道调用均不在数据库事务中进行：授权在下单事务前完成，事务失败时解冻；
+// 结算在业务事务中只将支付单标记为扣款中/解冻中，提交后再调用渠道，失败的由 CompletePendingSettlements 重试
+type PreauthService struct {
+	provider PreauthProvider
+}
+
+// NewPreauthService 创建押金预授权服务
+func NewPreauthService(provider PreauthProvider) *PreauthService {
+	return &PreauthService{provider: provider}
+}
+
+// PreauthAuthorization 渠道已完成、尚未记录支付单的预授权
+type PreauthAuthorization struct {
+	PaymentNo       string
+	AuthorizationID string
+	Amount          float64
+}
+
+// Authorize 向渠道发起预授权冻结押金，须在下单事务之外调用
+// 随后在下单事务中调用 RecordAuthorizationTx 记录支付单，事务失败时调用 Void 解冻
+func (s *PreauthService) Authorize(ctx context.Context, userID int64, amount float64) (*PreauthAuthorization, error) {
+	if amount <= 0 {
+		return nil, errors.ErrInvalidParams.WithMessage("预授权金额必须大于0")
+	}
+
+	paymentNo := utils.GenerateOrderNo("PA")
+	authorizationID, err := s.provider.Authorize(ctx, paymentNo, userID, amount)
+	if err != nil {
+		return nil, errors.ErrPaymentFailed.WithError(err)
+	}
+	return &PreauthAuthorization{PaymentNo: paymentNo, AuthorizationID: authorizationID, Amount: amount}, nil
+}
+
+// RecordAuthorizationTx 在下单事务中为订单创建已授权的预授权支付单
+func (s *PreauthService) RecordAuthorizationTx(ctx context.Context, tx *gorm.DB, order *models.Order, auth *PreauthAuthorization) (*models.Payment, error) {
+	authorizationID := auth.AuthorizationID
+	payment := &models.Payment{
+		PaymentNo:      auth.PaymentNo,
+		Type:           models.PaymentTypePreauth,
+		OrderID:        order.ID,
+		OrderNo:        order.OrderNo,
+		UserID:         order.UserID,
+		Amount:         auth.Amount,
+		PaymentMethod:  s.provider.Method(),
+		PaymentChannel: models.PaymentChannelMiniProgram,
+		TransactionID:  &authorizationID,
+		Status:         models.PaymentStatusAuthorized,
+	}
+	if err := tx.WithContext(ctx).Create(payment).Error; err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	return payment, nil
+}
+
+// Void 下单事务失败时全额解冻尚未记录支付单的预授权
+func (s *PreauthService) Void(ctx context.Context, auth *PreauthAuthorization) error {
+	if err := s.provider.Release(ctx, auth.AuthorizationID); err != nil {
+		return errors.ErrPaymentFailed.WithError(err)
+	}
+	return nil
+}
+
+// SettleTx 在事务中结算订单押金预授权：扣除 captureAmount（不超过授权金额），其余金额解冻
+// captureAmount 不大于 0 时全额解冻。只将支付单标记为扣款中/解冻中，事务提交后须调用 Complete 提交渠道
+func (s *PreauthService) SettleTx(ctx context.Context, tx *gorm.DB, orderID int64, captureAmount float64) error {
+	payment, err := s.getAuthorizedTx(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+	if captureAmount <= 0 {
+		return s.transition(tx, payment, models.PaymentStatusAuthorized, models.PaymentStatusReleasing, 0)
+	}
+	if captureAmount > payment.Amount {
+		captureAmount = payment.Amount
+	}
+	return s.transition(tx, payment, models.PaymentStatusAuthorized, models.PaymentStatusCapturing, captureAmount)
+}
+
+// ReleaseTx 在事务中将订单押金预授权标记为待全额解冻，事务提交后须调用 Complete 提交渠道
+func (s *PreauthService) ReleaseTx(ctx context.Context, tx *gorm.DB, orderID int64) error {
+	payment, err := s.getAuthorizedTx(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+	return s.transition(tx, payment, models.PaymentStatusAuthorized, models.PaymentStatusReleasing, 0)
+}
+
+// Complete 向渠道提交订单扣款中/解冻中的预授权，没有待处理的预授权时直接返回
+// 渠道调用失败时支付单保持原状态，由 CompletePendingSettlements 重试
+func (s *PreauthService) Complete(ctx context.Context, db *gorm.DB, orderID int64) error {
+	var payment models.Payment
+	err := db.WithContext(ctx).
+		Where("order_id = ? AND type = ? AND status IN ?", orderID, models.PaymentTypePreauth, pendingSettlementStatuses).
+		First(&payment).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	return s.complete(ctx, db, &payment)
+}
+
+// CompletePendingSettlements 重试提交扣款中/解冻中的预授权，每次最多处理 limit 条
+// 返回成功提交的数量；单条失败不影响其余支付单
+func (s *PreauthService) CompletePendingSettlements(ctx context.Context, db *gorm.DB, limit int) (int, error) {
+	var payments []*models.Payment
+	err := db.WithContext(ctx).
+		Where("type = ? AND status IN ?", models.PaymentTypePreauth, pendingSettlementStatuses).
+		Order("id ASC").
+		Limit(limit).
+		Find(&payments).Error
+	if err != nil {
+		return 0, errors.ErrDatabaseError.WithError(err)
+	}
+
+	var completed int
+	var errs []error
+	for _, payment := range payments {
+		if err := s.complete(ctx, db, payment); err != nil {
+			errs = append(errs, fmt.Errorf("payment %d: %w", payment.ID, err))
+			continue
+		}
+		completed++
+	}
+	return completed, stderrors.Join(errs...)
+}
+
+// pendingSettlementStatuses 已结算、待提交渠道的预授权状态
+var pendingSettlementStatuses = []int8{models.PaymentStatusCapturing, models.PaymentStatusReleasing}
+
+// complete 调用渠道扣款或解冻并更新支付单为终态
+func (s *PreauthService) complete(ctx context.Context, db *gorm.DB, payment *models.Payment) error {
+	switch payment.Status {
+	case models.PaymentStatusCapturing:
+		if err := s.provider.Capture(ctx, authorizationID(payment), payment.CapturedAmount); err != nil {
+			return errors.ErrPaymentFailed.WithError(err)
+		}
+		return s.transition(db.WithContext(ctx), payment, models.PaymentStatusCapturing, models.PaymentStatusCaptured, payment.CapturedAmount)
+	case models.PaymentStatusReleasing:
+		if err := s.provider.Release(ctx, authorizationID(payment)); err != nil {
+			return errors.ErrPaymentFailed.WithError(err)
+		}
+		return s.transition(db.WithContext(ctx), payment, models.PaymentStatusReleasing, models.PaymentStatusReleased, 0)
+	}
+	return nil
+}
+
+// getAuthorizedTx 锁定订单处于已授权状态的预授权支付单
+func (s *PreauthService) getAuthorizedTx(ctx context.Context, tx *gorm.DB, orderID int64) (*models.Payment, error) {
+	var payment models.Payment
+	err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND type = ? AND status = ?", orderID, models.PaymentTypePreauth, models.PaymentStatusAuthorized).
+		First(&payment).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrPaymentNotFound.WithMessage("押金预授权不存在或已结算")
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	return &payment, nil
+}
+
+// transition 按状态条件更新预授权支付单，防止重复扣款或解冻
+func (s *PreauthService) transition(tx *gorm.DB, payment *models.Payment, from, to int8, capturedAmount float64) error {
+	result := tx.Model(&models.Payment{}).
+		Where("id = ? AND status = ?", payment.ID, from).
+		Updates(map[string]interface{}{
+			"status":          to,
+			"captured_amount": capturedAmount,
+		})
+	if result.Error != nil {
+		return errors.ErrDatabaseError.WithError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrPaymentNotFound.WithMessage("押金预授权不存在或已结算")
+	}
+	payment.Status = to
+	payment.CapturedAmount = capturedAmount
+	return nil
+}
+
+// authorizationID 预授权支付单的渠道授权单号
+func authorizationID(payment *models.Payment) string {
+	if payment.TransactionID == nil {
+		return ""
+	}
+	return *payment.TransactionID
+}
+
+// FakePreauthProvider 内存实现的预授权渠道，用于测试及未接入真实渠道的环境
+type FakePreauthProvider struct {
+	mu             sync.Mutex
+	seq            int
+	authorizations map[string]*FakePreauthorization
+	// AuthorizeErr 非空时 Authorize 返回该错误，用于模拟授权失败
+	AuthorizeErr error
+	// SettleErr 非空时 Capture 及 Release 返回该错误，用于模拟渠道结算失败
+	SettleErr error
+}
+
+// FakePreauthorization 模拟渠道中的预授权记录
+type FakePreauthorization struct {
+	UserID   int64
+	Amount   float64
+	Captured float64
+	Released bool
+}
+
+// NewFakePreauthProvider 创建内存预授权渠道
+func NewFakePreauthProvider() *FakePreauthProvider {
+	return &FakePreauthProvider{authorizations: make(map[string]*FakePreauthorization)}
+}
+
+// Method 返回模拟渠道的支付方式
+func (p *FakePreauthProvider) Method() string {
+	return models.PaymentMethodWechat
+}
+
+// Authorize 记录一笔预授权
+func (p *FakePreauthProvider) Authorize(ctx context.Context, paymentNo string, userID int64, amount float64) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.AuthorizeErr != nil {
+		return "", p.AuthorizeErr
+	}
+	p.seq++
+	id := fmt.Sprintf("FAKE-AUTH-%d", p.seq)
+	p.authorizations[id] = &FakePreauthorization{UserID: userID, Amount: amount}
+	return id, nil
+}
+
+// Capture 从预授权中扣款，其余金额视为已解冻
+func (p *FakePreauthProvider) Capture(ctx context.Context, authorizationID string, amount float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.SettleErr != nil {
+		return p.SettleErr
+	}
+	auth, err := p.openAuthorization(authorizationID)
+	if err != nil {
+		return err
+	}
+	if amount > auth.Amount {
+		return fmt.Errorf("capture amount %.2f exceeds authorized %.2f", amount, auth.Amount)
+	}
+	auth.Captured = amount
+	auth.Released = true
+	return nil
+}
+
+// Release 全额解冻预授权
+func (p *FakePreauthProvider) Release(ctx context.Context, authorizationID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.SettleErr != nil {
+		return p.SettleErr
+	}
+	auth, err := p.openAuthorization(authorizationID)
+	if err != nil {
+		return err
+	}
+	auth.Released = true
+	return nil
+}
+
+// Authorization 获取预授权记录副本，不存在时返回 nil
+func (p *FakePreauthProvider) Authorization(authorizationID string) *FakePreauthorization {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	auth, ok := p.authorizations[authorizationID]
+	if !ok {
+		return nil
+	}
+	copied := *auth
+	return &copied
+}
+
+// openAuthorization 获取未结束的预授权记录
+func (p *FakePreauthProvider) openAuthorization(authorizationID string) (*FakePreauthorization, error) {
+	auth, ok := p.authorizations[authorizationID]
+	if !ok {
+		return nil, fmt.Errorf("authorization %s not found", authorizationID)
+	}
+	if auth.Released {
+		return nil, fmt.Errorf("authorization %s already settled", authorizationID)
+	}
+	return auth, nil
+}
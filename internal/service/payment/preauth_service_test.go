@@ -0,0 +1,110 @@
+package payment
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+func TestPreauthService(t *testing.T) {
+	db := setupTestDB(t)
+	provider := NewFakePreauthProvider()
+	svc := NewPreauthService(provider)
+	ctx := context.Background()
+
+	authorize := func(orderID int64, amount float64) *models.Payment {
+		auth, err := svc.Authorize(ctx, 9, amount)
+		require.NoError(t, err)
+		var payment *models.Payment
+		require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			payment, err = svc.RecordAuthorizationTx(ctx, tx, &models.Order{ID: orderID, OrderNo: "O-PREAUTH", UserID: 9}, auth)
+			return err
+		}))
+		return payment
+	}
+	reload := func(id int64) *models.Payment {
+		var payment models.Payment
+		require.NoError(t, db.First(&payment, id).Error)
+		return &payment
+	}
+
+	t.Run("扣款金额不超过授权金额", func(t *testing.T) {
+		payment := authorize(101, 50)
+		assert.Equal(t, models.PaymentTypePreauth, payment.Type)
+		assert.Equal(t, int8(models.PaymentStatusAuthorized), payment.Status)
+
+		require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+			return svc.SettleTx(ctx, tx, 101, 80)
+		}))
+
+		// 事务中只标记为扣款中，提交后才调用渠道
+		reloaded := reload(payment.ID)
+		assert.Equal(t, int8(models.PaymentStatusCapturing), reloaded.Status)
+		assert.Equal(t, 50.0, reloaded.CapturedAmount)
+		assert.Zero(t, provider.Authorization(*payment.TransactionID).Captured)
+
+		require.NoError(t, svc.Complete(ctx, db, 101))
+		reloaded = reload(payment.ID)
+		assert.Equal(t, int8(models.PaymentStatusCaptured), reloaded.Status)
+		assert.Equal(t, 50.0, reloaded.CapturedAmount)
+		assert.Equal(t, 50.0, provider.Authorization(*payment.TransactionID).Captured)
+	})
+
+	t.Run("已结算的预授权不能再次解冻", func(t *testing.T) {
+		payment := authorize(102, 30)
+		require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+			return svc.ReleaseTx(ctx, tx, 102)
+		}))
+		require.NoError(t, svc.Complete(ctx, db, 102))
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return svc.ReleaseTx(ctx, tx, 102)
+		})
+		appErr, ok := err.(*errors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, errors.ErrPaymentNotFound.Code, appErr.Code)
+
+		reloaded := reload(payment.ID)
+		assert.Equal(t, int8(models.PaymentStatusReleased), reloaded.Status)
+		assert.Zero(t, reloaded.CapturedAmount)
+		assert.True(t, provider.Authorization(*payment.TransactionID).Released)
+	})
+
+	t.Run("渠道结算失败后重试", func(t *testing.T) {
+		payment := authorize(104, 20)
+		require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+			return svc.ReleaseTx(ctx, tx, 104)
+		}))
+
+		provider.SettleErr = stderrors.New("channel unavailable")
+		assert.Error(t, svc.Complete(ctx, db, 104))
+		assert.Equal(t, int8(models.PaymentStatusReleasing), reload(payment.ID).Status)
+
+		provider.SettleErr = nil
+		completed, err := svc.CompletePendingSettlements(ctx, db, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, completed)
+		assert.Equal(t, int8(models.PaymentStatusReleased), reload(payment.ID).Status)
+		assert.True(t, provider.Authorization(*payment.TransactionID).Released)
+	})
+
+	t.Run("下单失败时解冻预授权", func(t *testing.T) {
+		auth, err := svc.Authorize(ctx, 9, 10)
+		require.NoError(t, err)
+		require.NoError(t, svc.Void(ctx, auth))
+		assert.True(t, provider.Authorization(auth.AuthorizationID).Released)
+	})
+
+	t.Run("授权金额必须大于0", func(t *testing.T) {
+		_, err := svc.Authorize(ctx, 9, 0)
+		assert.Error(t, err)
+	})
+}
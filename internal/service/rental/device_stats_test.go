@@ -0,0 +1,119 @@
+package rental
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// statsDay 统计测试使用的日期（场地默认时区，保证按小时分组结果稳定）
+var statsDay = time.Date(2026, 3, 10, 0, 0, 0, 0, scheduleLocation)
+
+// createStatsTestRental 创建统计用租借记录，unlockedAt 为 nil 表示未开始使用
+func createStatsTestRental(t *testing.T, db *gorm.DB, deviceID, orderID int64, status string, unlockedAt *time.Time, usedHours, overtimeFee float64) {
+	t.Helper()
+	rental := &models.Rental{
+		OrderID:       orderID,
+		UserID:        1,
+		DeviceID:      deviceID,
+		DurationHours: 1,
+		RentalFee:     10,
+		Deposit:       50,
+		OvertimeRate:  1.5,
+		OvertimeFee:   overtimeFee,
+		Status:        status,
+		UnlockedAt:    unlockedAt,
+		CreatedAt:     statsDay.Add(time.Hour),
+	}
+	if unlockedAt != nil {
+		rental.CreatedAt = unlockedAt.Add(-5 * time.Minute)
+		if usedHours > 0 {
+			returnedAt := unlockedAt.Add(time.Duration(usedHours * float64(time.Hour)))
+			rental.ReturnedAt = &returnedAt
+		}
+	}
+	require.NoError(t, db.Create(rental).Error)
+}
+
+func statsAt(hour, minute int) *time.Time {
+	t := statsDay.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	return &t
+}
+
+func TestRentalService_GetRentalStatsByDevice(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	_, device, _ := createTestData(t, svc.db)
+	otherDevice := createLimitTestDevice(t, svc.db, device.VenueID, 1)
+	dayEnd := statsDay.Add(24*time.Hour - time.Second)
+
+	createStatsTestRental(t, svc.db, device.ID, 1, models.RentalStatusCompleted, statsAt(9, 10), 2, 0)
+	createStatsTestRental(t, svc.db, device.ID, 2, models.RentalStatusCompleted, statsAt(9, 40), 3, 4.5)
+	createStatsTestRental(t, svc.db, device.ID, 3, models.RentalStatusCompleted, statsAt(14, 0), 1, 1.5)
+	createStatsTestRental(t, svc.db, device.ID, 4, models.RentalStatusInUse, statsAt(9, 55), 0, 0)
+	createStatsTestRental(t, svc.db, device.ID, 5, models.RentalStatusCancelled, nil, 0, 0)
+	// 其他设备和统计范围外的租借不计入
+	createStatsTestRental(t, svc.db, otherDevice.ID, 6, models.RentalStatusCompleted, statsAt(14, 10), 1, 0)
+	createStatsTestRental(t, svc.db, device.ID, 7, models.RentalStatusCompleted, statsAt(14+24, 10), 1, 0)
+
+	stats, err := svc.GetRentalStatsByDevice(ctx, device.ID, statsDay, dayEnd)
+	require.NoError(t, err)
+	assert.Equal(t, device.ID, stats.DeviceID)
+	assert.Equal(t, int64(5), stats.TotalRentals)
+	assert.Equal(t, int64(3), stats.CompletedRentals)
+	assert.Equal(t, int64(1), stats.CancelledRentals)
+	assert.Equal(t, 36.0, stats.TotalRevenue)
+	assert.Equal(t, 2.0, stats.AverageDurationHours)
+	assert.Equal(t, 2.0, stats.AverageOvertimeFee)
+	assert.Equal(t, 9, stats.PeakHour)
+
+	t.Run("开始次数相同时取较早的小时", func(t *testing.T) {
+		createStatsTestRental(t, svc.db, device.ID, 8, models.RentalStatusInUse, statsAt(14, 20), 0, 0)
+		createStatsTestRental(t, svc.db, device.ID, 9, models.RentalStatusInUse, statsAt(14, 30), 0, 0)
+
+		stats, err := svc.GetRentalStatsByDevice(ctx, device.ID, statsDay, dayEnd)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), stats.TotalRentals)
+		assert.Equal(t, 9, stats.PeakHour)
+
+		createStatsTestRental(t, svc.db, device.ID, 10, models.RentalStatusInUse, statsAt(14, 40), 0, 0)
+		stats, err = svc.GetRentalStatsByDevice(ctx, device.ID, statsDay, dayEnd)
+		require.NoError(t, err)
+		assert.Equal(t, 14, stats.PeakHour)
+	})
+
+	t.Run("按场地时区统计高峰时段", func(t *testing.T) {
+		require.NoError(t, svc.db.Model(&models.Venue{}).Where("id = ?", device.VenueID).Update("timezone", "UTC").Error)
+		defer svc.db.Model(&models.Venue{}).Where("id = ?", device.VenueID).Update("timezone", models.DefaultVenueTimezone)
+
+		// 北京时间 14 点为 UTC 6 点
+		stats, err := svc.GetRentalStatsByDevice(ctx, device.ID, statsDay, dayEnd)
+		require.NoError(t, err)
+		assert.Equal(t, 6, stats.PeakHour)
+	})
+
+	t.Run("无租借", func(t *testing.T) {
+		empty := createLimitTestDevice(t, svc.db, device.VenueID, 2)
+		stats, err := svc.GetRentalStatsByDevice(ctx, empty.ID, statsDay, dayEnd)
+		require.NoError(t, err)
+		assert.Equal(t, &DeviceRentalStats{DeviceID: empty.ID, PeakHour: -1}, stats)
+	})
+
+	t.Run("设备不存在", func(t *testing.T) {
+		_, err := svc.GetRentalStatsByDevice(ctx, 99999, statsDay, dayEnd)
+		assert.Equal(t, errors.ErrDeviceNotFound, err)
+	})
+
+	t.Run("结束日期早于开始日期", func(t *testing.T) {
+		_, err := svc.GetRentalStatsByDevice(ctx, device.ID, dayEnd, statsDay)
+		require.Error(t, err)
+		assert.Equal(t, errors.ErrInvalidParams.Code, err.(*errors.AppError).Code)
+	})
+}
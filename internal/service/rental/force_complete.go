@@ -0,0 +1,132 @@
+// Package rental 提供租借服务
+package rental
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// 强制完成租借的操作日志
+const (
+	forceCompleteLogModule     = "rental"
+	forceCompleteLogAction     = "force_complete"
+	forceCompleteLogTargetType = "rental"
+)
+
+// forceCompletableStatuses 允许管理员强制完成的租借状态
+var forceCompletableStatuses = map[string]bool{
+	models.RentalStatusInUse:    true,
+	models.RentalStatusOverdue:  true,
+	models.RentalStatusReturned: true,
+}
+
+// ForceCompleteRental 管理员强制完成租借（用户弃用未归还等场景）
+// 未归还的租借按当前时间视为归还并释放设备槽位；waiveOvertimeFee 为 true 时免除超时费，押金全额退还。
+// 结算后记录管理员操作日志
+func (s *RentalService) ForceCompleteRental(ctx context.Context, rentalID, adminID int64, waiveOvertimeFee bool, note string) error {
+	var order models.Order
+	var rental *models.Rental
+	var releasedDeviceID int64
+	var userID int64
+	var oldStatus string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		rental, err = s.rentalRepo.GetForUpdate(ctx, tx, rentalID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.ErrRentalNotFound
+			}
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		if !forceCompletableStatuses[rental.Status] {
+			return errors.ErrRentalStatusError.WithMessage("只有使用中、超时或已归还的租借可以强制完成")
+		}
+
+		userID = rental.UserID
+		oldStatus = rental.Status
+		before := models.JSON{
+			"status":       rental.Status,
+			"overtime_fee": rental.OvertimeFee,
+		}
+
+		now := time.Now()
+		if rental.Status != models.RentalStatusReturned {
+			rental.ReturnedAt = &now
+			rental.OvertimeFee = calculateOvertimeFee(rental, now)
+
+			// 更新设备状态，释放格口
+			if err := tx.Model(&models.Device{}).Where("id = ?", rental.DeviceID).Updates(map[string]interface{}{
+				"rental_status":     models.DeviceRentalFree,
+				"current_rental_id": nil,
+			}).Error; err != nil {
+				return errors.ErrDatabaseError.WithError(err)
+			}
+			if err := s.slotRepo.ReleaseTx(ctx, tx, rental); err != nil {
+				return errors.ErrDatabaseError.WithError(err)
+			}
+			releasedDeviceID = rental.DeviceID
+		}
+		if waiveOvertimeFee {
+			rental.OvertimeFee = 0
+		}
+
+		// 仅当状态未被并发的归还、结算修改时更新，避免重复释放格口和重复结算
+		result := tx.Model(&models.Rental{}).
+			Where("id = ? AND status = ?", rental.ID, oldStatus).
+			Updates(map[string]interface{}{
+				"returned_at":  rental.ReturnedAt,
+				"overtime_fee": rental.OvertimeFee,
+			})
+		if result.Error != nil {
+			return errors.ErrDatabaseError.WithError(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrRentalStatusError.WithMessage("租借状态已变更，请刷新后重试")
+		}
+
+		if err := s.settleRentalTx(ctx, tx, rental, &order); err != nil {
+			return err
+		}
+
+		targetType := forceCompleteLogTargetType
+		targetID := rental.ID
+		log := &models.OperationLog{
+			AdminID:    adminID,
+			Module:     forceCompleteLogModule,
+			Action:     forceCompleteLogAction,
+			TargetType: &targetType,
+			TargetID:   &targetID,
+			BeforeData: before,
+			AfterData: models.JSON{
+				"status":             models.RentalStatusCompleted,
+				"overtime_fee":       rental.OvertimeFee,
+				"waive_overtime_fee": waiveOvertimeFee,
+				"admin_note":         note,
+			},
+		}
+		if err := tx.Create(log).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.completePreauth(ctx, rental)
+
+	// 订单完成事件处理失败不影响结算结果
+	if s.orderEvents != nil {
+		_ = s.orderEvents.OnOrderCompleted(ctx, &order)
+	}
+	if releasedDeviceID != 0 {
+		s.publishDeviceRentalStatus(ctx, releasedDeviceID, models.DeviceRentalInUse, models.DeviceRentalFree)
+	}
+	s.publishRentalStatus(ctx, rentalID, userID, oldStatus, models.RentalStatusCompleted)
+	return nil
+}
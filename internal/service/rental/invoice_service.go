@@ -0,0 +1,209 @@
+package rental
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/receipt"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	"github.com/dumeirei/smart-locker-backend/pkg/oss"
+)
+
+// 发票相关常量
+const (
+	invoiceNoPrefix          = "INV"
+	DefaultInvoiceStorageDir = "invoices"
+	invoiceCacheTTL          = 24 * time.Hour
+)
+
+// InvoiceConfig 发票配置
+type InvoiceConfig struct {
+	CompanyName string  // 开票公司名称
+	LogoPath    string  // 公司 Logo 图片路径（png/jpg），为空时不显示
+	FontPath    string  // UTF-8 字体文件路径（ttf），为空时使用内置字体，无法显示中文
+	TaxRate     float64 // 税率，租借金额为含税价
+	StorageDir  string  // 发票在对象存储中的目录
+}
+
+// InvoiceService 租借发票服务
+type InvoiceService struct {
+	db         *gorm.DB
+	rentalRepo *repository.RentalRepository
+	uploader   oss.Uploader
+	renderer   *receipt.Renderer
+	cache      *redis.Client
+	config     InvoiceConfig
+}
+
+// NewInvoiceService 创建租借发票服务，uploader 为空时不保存发票文件
+func NewInvoiceService(db *gorm.DB, rentalRepo *repository.RentalRepository, uploader oss.Uploader, config InvoiceConfig) *InvoiceService {
+	if config.StorageDir == "" {
+		config.StorageDir = DefaultInvoiceStorageDir
+	}
+	renderer := receipt.NewRenderer(config.FontPath)
+	renderer.SetLogo(config.LogoPath)
+	return &InvoiceService{
+		db:         db,
+		rentalRepo: rentalRepo,
+		uploader:   uploader,
+		renderer:   renderer,
+		config:     config,
+	}
+}
+
+// SetCache 设置发票缓存，已生成的发票PDF缓存 24 小时，重复下载时不再渲染；未设置时每次下载重新渲染
+func (s *InvoiceService) SetCache(client *redis.Client) {
+	s.cache = client
+}
+
+// invoiceCacheKey 发票PDF缓存键
+func invoiceCacheKey(invoiceNo string) string {
+	return "cache:rental_invoice:" + invoiceNo
+}
+
+// invoiceData 发票内容
+type invoiceData struct {
+	InvoiceNo    string
+	IssuedAt     time.Time
+	OrderNo      string
+	Rental       *models.Rental
+	RentalFee    float64 // 租金（含税）
+	OvertimeFee  float64 // 实际扣除的超时费（含税）
+	Subtotal     float64 // 不含税金额
+	Tax          float64 // 税额
+	Total        float64 // 价税合计
+	DeviceName   string
+	VenueName    string
+	VenueAddress string
+}
+
+// GenerateRentalInvoice 生成已完成租借的发票PDF
+// 发票号由订单号派生，开票时间为订单完成时间，重复下载得到相同内容；首次生成时保存到对象存储并记录路径
+func (s *InvoiceService) GenerateRentalInvoice(ctx context.Context, rentalID, userID int64) ([]byte, error) {
+	rental, err := s.rentalRepo.GetByIDWithRelations(ctx, rentalID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrRentalNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	if rental.UserID != userID {
+		return nil, errors.ErrPermissionDenied
+	}
+	if rental.Status != models.RentalStatusCompleted {
+		return nil, errors.ErrRentalStatusError.WithMessage("租借完成后才能开具发票")
+	}
+
+	var order models.Order
+	if err := s.db.WithContext(ctx).First(&order, rental.OrderID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrOrderNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	data := s.buildInvoiceData(rental, &order)
+	cacheKey := invoiceCacheKey(data.InvoiceNo)
+	if s.cache != nil {
+		if cached, err := s.cache.Get(ctx, cacheKey).Bytes(); err == nil {
+			return cached, nil
+		}
+	}
+
+	content, err := s.renderInvoice(data)
+	if err != nil {
+		return nil, errors.ErrOperationFailed.WithMessage("生成发票失败").WithError(err)
+	}
+
+	if rental.InvoicePath == nil && s.uploader != nil {
+		objectKey := path.Join(s.config.StorageDir, data.IssuedAt.Format("200601"), data.InvoiceNo+".pdf")
+		if _, err := s.uploader.Upload(ctx, objectKey, bytes.NewReader(content)); err != nil {
+			return nil, errors.ErrOperationFailed.WithMessage("保存发票失败").WithError(err)
+		}
+		if err := s.rentalRepo.UpdateFields(ctx, rental.ID, map[string]interface{}{"invoice_path": objectKey}); err != nil {
+			return nil, errors.ErrDatabaseError.WithError(err)
+		}
+	}
+
+	if s.cache != nil {
+		// 缓存失败不影响下载，下次请求重新渲染
+		_ = s.cache.Set(ctx, cacheKey, content, invoiceCacheTTL).Err()
+	}
+	return content, nil
+}
+
+// buildInvoiceData 计算发票金额：租金与实际扣除的超时费均为含税价，按税率拆分不含税金额与税额
+func (s *InvoiceService) buildInvoiceData(rental *models.Rental, order *models.Order) *invoiceData {
+	issuedAt := rental.UpdatedAt
+	if order.CompletedAt != nil {
+		issuedAt = *order.CompletedAt
+	}
+
+	data := &invoiceData{
+		InvoiceNo:   invoiceNoPrefix + order.OrderNo,
+		IssuedAt:    issuedAt,
+		OrderNo:     order.OrderNo,
+		Rental:      rental,
+		RentalFee:   roundToCent(rental.RentalFee),
+		OvertimeFee: roundToCent(chargedOvertimeFee(rental)),
+	}
+	data.Total = roundToCent(data.RentalFee + data.OvertimeFee)
+	data.Subtotal = roundToCent(data.Total / (1 + s.config.TaxRate))
+	data.Tax = roundToCent(data.Total - data.Subtotal)
+
+	if rental.Device != nil {
+		data.DeviceName = rental.Device.Name
+		if rental.Device.Venue != nil {
+			venue := rental.Device.Venue
+			data.VenueName = venue.Name
+			data.VenueAddress = venue.Province + venue.City + venue.District + venue.Address
+		}
+	}
+	return data
+}
+
+// renderInvoice 按统一单据版式渲染发票PDF
+func (s *InvoiceService) renderInvoice(data *invoiceData) ([]byte, error) {
+	rentalLabel := fmt.Sprintf("Rental fee (%d hours)", data.Rental.DurationHours)
+	if data.Rental.DiscountRate > 0 && data.Rental.DiscountRate < 1 {
+		rentalLabel += fmt.Sprintf(", member discount %.0f%%", data.Rental.DiscountRate*100)
+	}
+
+	doc := &receipt.Receipt{
+		Issuer:    s.config.CompanyName,
+		Title:     "INVOICE",
+		NoLabel:   "Invoice No.",
+		ReceiptNo: data.InvoiceNo,
+		OrderNo:   data.OrderNo,
+		IssuedAt:  data.IssuedAt,
+		Details: []receipt.Detail{
+			{Label: "Rental ID", Value: fmt.Sprintf("%d", data.Rental.ID)},
+			{Label: "Device", Value: data.DeviceName},
+			{Label: "Venue", Value: data.VenueName},
+			{Label: "Address", Value: data.VenueAddress},
+		},
+		Items: []receipt.Item{
+			{Label: rentalLabel, Amount: data.RentalFee},
+			{Label: "Overtime fee", Amount: data.OvertimeFee},
+			{Label: "Subtotal (excl. tax)", Amount: data.Subtotal},
+			{Label: fmt.Sprintf("Tax (%.2f%%)", s.config.TaxRate*100), Amount: data.Tax},
+		},
+		Total: data.Total,
+		Notes: []string{fmt.Sprintf("Deposit of %.2f CNY is refundable and not included in the total.", data.Rental.Deposit)},
+	}
+	if data.Rental.UnlockedAt != nil {
+		doc.Details = append(doc.Details, receipt.Detail{Label: "Start Time", Value: data.Rental.UnlockedAt.Format("2006-01-02 15:04")})
+	}
+	if data.Rental.ReturnedAt != nil {
+		doc.Details = append(doc.Details, receipt.Detail{Label: "Return Time", Value: data.Rental.ReturnedAt.Format("2006-01-02 15:04")})
+	}
+	return s.renderer.Render(doc)
+}
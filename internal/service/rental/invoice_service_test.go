@@ -0,0 +1,128 @@
+package rental
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	"github.com/dumeirei/smart-locker-backend/pkg/oss"
+)
+
+func TestInvoiceService_GenerateRentalInvoice(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	uploader := oss.NewMockUploader()
+	invoiceSvc := NewInvoiceService(svc.db, repository.NewRentalRepository(svc.db), uploader, InvoiceConfig{
+		CompanyName: "Smart Locker Co., Ltd.",
+		TaxRate:     0.06,
+	})
+
+	user, device, pricing := createTestData(t, svc.db)
+	rentalInfo, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.NoError(t, err)
+	require.NoError(t, svc.PayRental(ctx, user.ID, rentalInfo.ID, ""))
+
+	t.Run("未完成的租借不能开具发票", func(t *testing.T) {
+		_, err := invoiceSvc.GenerateRentalInvoice(ctx, rentalInfo.ID, user.ID)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrRentalStatusError.Code, appErr.Code)
+	})
+
+	require.NoError(t, svc.StartRental(ctx, user.ID, rentalInfo.ID))
+	require.NoError(t, svc.ReturnRental(ctx, user.ID, rentalInfo.ID))
+	require.NoError(t, svc.CompleteRental(ctx, rentalInfo.ID))
+
+	t.Run("生成PDF并保存到对象存储", func(t *testing.T) {
+		data, err := invoiceSvc.GenerateRentalInvoice(ctx, rentalInfo.ID, user.ID)
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(data, []byte("%PDF")))
+
+		var rental models.Rental
+		require.NoError(t, svc.db.First(&rental, rentalInfo.ID).Error)
+		require.NotNil(t, rental.InvoicePath)
+		assert.Contains(t, *rental.InvoicePath, DefaultInvoiceStorageDir+"/")
+		assert.Contains(t, *rental.InvoicePath, "INV"+rentalInfo.OrderNo+".pdf")
+		assert.Equal(t, data, uploader.Files[*rental.InvoicePath])
+
+		// 再次下载内容不变，且不重复保存
+		delete(uploader.Files, *rental.InvoicePath)
+		again, err := invoiceSvc.GenerateRentalInvoice(ctx, rentalInfo.ID, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, data, again)
+		assert.Empty(t, uploader.Files)
+	})
+
+	t.Run("不能下载他人的发票", func(t *testing.T) {
+		_, err := invoiceSvc.GenerateRentalInvoice(ctx, rentalInfo.ID, user.ID+1)
+		assert.ErrorIs(t, err, appErrors.ErrPermissionDenied)
+	})
+
+	t.Run("租借不存在", func(t *testing.T) {
+		_, err := invoiceSvc.GenerateRentalInvoice(ctx, 99999, user.ID)
+		assert.ErrorIs(t, err, appErrors.ErrRentalNotFound)
+	})
+}
+
+func TestInvoiceService_GenerateRentalInvoice_Cache(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	invoiceSvc := NewInvoiceService(svc.db, repository.NewRentalRepository(svc.db), nil, InvoiceConfig{
+		CompanyName: "Smart Locker Co., Ltd.",
+		TaxRate:     0.06,
+	})
+	invoiceSvc.SetCache(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	user, device, pricing := createTestData(t, svc.db)
+	rentalInfo, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.NoError(t, err)
+	require.NoError(t, svc.PayRental(ctx, user.ID, rentalInfo.ID, ""))
+	require.NoError(t, svc.StartRental(ctx, user.ID, rentalInfo.ID))
+	require.NoError(t, svc.ReturnRental(ctx, user.ID, rentalInfo.ID))
+	require.NoError(t, svc.CompleteRental(ctx, rentalInfo.ID))
+
+	data, err := invoiceSvc.GenerateRentalInvoice(ctx, rentalInfo.ID, user.ID)
+	require.NoError(t, err)
+
+	key := invoiceCacheKey(invoiceNoPrefix + rentalInfo.OrderNo)
+	cached, err := mr.Get(key)
+	require.NoError(t, err)
+	assert.Equal(t, data, []byte(cached))
+	assert.Positive(t, mr.TTL(key))
+
+	// 命中缓存时直接返回缓存内容
+	require.NoError(t, mr.Set(key, "%PDF-cached"))
+	again, err := invoiceSvc.GenerateRentalInvoice(ctx, rentalInfo.ID, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("%PDF-cached"), again)
+
+	// 缓存不绕过权限校验
+	_, err = invoiceSvc.GenerateRentalInvoice(ctx, rentalInfo.ID, user.ID+1)
+	assert.ErrorIs(t, err, appErrors.ErrPermissionDenied)
+}
+
+func TestInvoiceService_BuildInvoiceData(t *testing.T) {
+	invoiceSvc := NewInvoiceService(nil, nil, nil, InvoiceConfig{TaxRate: 0.06})
+
+	rental := &models.Rental{RentalFee: 100, OvertimeFee: 20, Deposit: 50}
+	data := invoiceSvc.buildInvoiceData(rental, &models.Order{OrderNo: "R20261017001"})
+	assert.Equal(t, "INVR20261017001", data.InvoiceNo)
+	assert.Equal(t, 120.0, data.Total)
+	assert.Equal(t, 113.21, data.Subtotal)
+	assert.Equal(t, 6.79, data.Tax)
+
+	// 超时费超过押金时按押金扣除
+	rental.OvertimeFee = 80
+	data = invoiceSvc.buildInvoiceData(rental, &models.Order{OrderNo: "R20261017001"})
+	assert.Equal(t, 50.0, data.OvertimeFee)
+	assert.Equal(t, 150.0, data.Total)
+}
@@ -0,0 +1,65 @@
+package rental
+
+import (
+	"context"
+	"math"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// noDiscount 无折扣
+const noDiscount = 1.0
+
+// getMemberLevel 获取用户会员等级，用户未关联会员等级时返回 nil
+func (s *RentalService) getMemberLevel(ctx context.Context, userID int64) (*models.MemberLevel, error) {
+	if s.levelCache != nil {
+		return s.getCachedMemberLevel(ctx, userID)
+	}
+
+	var user models.User
+	if err := s.db.WithContext(ctx).Preload("MemberLevel").First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	return user.MemberLevel, nil
+}
+
+// getCachedMemberLevel 查询用户会员等级 ID 后从缓存读取会员等级，会员等级不存在时返回 nil
+func (s *RentalService) getCachedMemberLevel(ctx context.Context, userID int64) (*models.MemberLevel, error) {
+	var user models.User
+	if err := s.db.WithContext(ctx).Select("id", "member_level_id").First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	level, err := s.levelCache.GetByID(ctx, user.MemberLevelID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	return level, nil
+}
+
+// applyMemberDiscount 按会员等级折扣计算租金，返回折后租金（保留两位小数）和实际折扣率
+// 会员等级为空或折扣率不在 (0, 1] 范围内时不打折
+func applyMemberDiscount(price float64, level *models.MemberLevel) (float64, float64) {
+	rate := noDiscount
+	if level != nil && level.Discount > 0 && level.Discount < noDiscount {
+		rate = level.Discount
+	}
+	return roundToCent(price * rate), rate
+}
+
+// roundToCent 金额保留两位小数
+func roundToCent(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
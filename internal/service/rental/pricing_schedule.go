@@ -0,0 +1,92 @@
+package rental
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// defaultPriceMultiplier 无匹配分时定价时的租金倍率
+const defaultPriceMultiplier = 1.0
+
+// SetPricingScheduleRepository 设置场地分时定价仓储，未设置时租金不做分时调整
+func (s *RentalService) SetPricingScheduleRepository(repo *repository.PricingScheduleRepository) {
+	s.scheduleRepo = repo
+}
+
+// GetApplicableMultiplier 获取场地在租借开始时间适用的租金倍率
+// 按场地当地时间匹配星期和小时；多条分时定价同时覆盖时取最具体的一条：指定星期优先于每天，
+// 其次时段较短者优先，再次后创建者优先；无匹配时按原价（倍率 1.0）计费
+func (s *RentalService) GetApplicableMultiplier(ctx context.Context, venueID int64, rentalStartTime time.Time) (float64, error) {
+	if s.scheduleRepo == nil {
+		return defaultPriceMultiplier, nil
+	}
+
+	schedules, err := s.scheduleRepo.ListActiveByVenue(ctx, venueID)
+	if err != nil {
+		return 0, errors.ErrDatabaseError.WithError(err)
+	}
+	if len(schedules) == 0 {
+		return defaultPriceMultiplier, nil
+	}
+
+	venue, err := s.venueRepo.GetByID(ctx, venueID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, errors.ErrVenueNotFound
+		}
+		return 0, errors.ErrDatabaseError.WithError(err)
+	}
+	localStart := rentalStartTime.In(venue.Location())
+
+	var best *models.PricingSchedule
+	for _, schedule := range schedules {
+		if !schedule.Matches(localStart) {
+			continue
+		}
+		if best == nil || moreSpecificSchedule(schedule, best) {
+			best = schedule
+		}
+	}
+	if best == nil || best.Multiplier <= 0 {
+		return defaultPriceMultiplier, nil
+	}
+	return best.Multiplier, nil
+}
+
+// moreSpecificSchedule 判断分时定价 a 是否比 b 更具体
+func moreSpecificSchedule(a, b *models.PricingSchedule) bool {
+	if (a.DayOfWeek != nil) != (b.DayOfWeek != nil) {
+		return a.DayOfWeek != nil
+	}
+	aSpan, bSpan := a.EndHour-a.StartHour, b.EndHour-b.StartHour
+	if aSpan != bSpan {
+		return aSpan < bSpan
+	}
+	return a.ID > b.ID
+}
+
+// scheduledPrice 按设备所在场地的分时定价计算租借开始时的租金（会员折扣前）
+func (s *RentalService) scheduledPrice(ctx context.Context, pricing *models.RentalPricing, deviceID int64, at time.Time) (float64, error) {
+	if s.scheduleRepo == nil {
+		return pricing.Price, nil
+	}
+
+	device, err := s.deviceRepo.GetByID(ctx, deviceID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, errors.ErrDeviceNotFound
+		}
+		return 0, errors.ErrDatabaseError.WithError(err)
+	}
+	multiplier, err := s.GetApplicableMultiplier(ctx, device.VenueID, at)
+	if err != nil {
+		return 0, err
+	}
+	return roundToCent(pricing.Price * multiplier), nil
+}
@@ -0,0 +1,219 @@
+package rental
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/receipt"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	"github.com/dumeirei/smart-locker-backend/pkg/oss"
+)
+
+// 收据相关常量
+const (
+	receiptNoPrefix          = "RCT"
+	DefaultReceiptStorageDir = "receipts"
+)
+
+// receiptPaymentMethods 收据上显示的支付方式
+var receiptPaymentMethods = map[string]string{
+	models.PaymentMethodWechat:  "WeChat Pay",
+	models.PaymentMethodAlipay:  "Alipay",
+	models.PaymentMethodBalance: "Wallet balance",
+}
+
+// ReceiptConfig 收据配置
+type ReceiptConfig struct {
+	CompanyName string // 开具方名称
+	FontPath    string // UTF-8 字体文件路径（ttf），为空时使用内置字体，无法显示中文
+	StorageDir  string // 收据在对象存储中的目录
+}
+
+// ReceiptService 订单收据服务
+// 各业务负责组装收据内容，渲染、保存及缓存地址由本服务统一处理
+type ReceiptService struct {
+	db         *gorm.DB
+	rentalRepo *repository.RentalRepository
+	uploader   oss.Uploader
+	renderer   *receipt.Renderer
+	config     ReceiptConfig
+}
+
+// NewReceiptService 创建订单收据服务
+func NewReceiptService(db *gorm.DB, rentalRepo *repository.RentalRepository, uploader oss.Uploader, config ReceiptConfig) *ReceiptService {
+	if config.StorageDir == "" {
+		config.StorageDir = DefaultReceiptStorageDir
+	}
+	return &ReceiptService{
+		db:         db,
+		rentalRepo: rentalRepo,
+		uploader:   uploader,
+		renderer:   receipt.NewRenderer(config.FontPath),
+		config:     config,
+	}
+}
+
+// GenerateRentalReceipt 获取租借收据地址，仅已完成或已退款的租借可开具
+// 首次请求时生成PDF并保存到对象存储，地址记录在订单上，之后直接返回已保存的地址
+func (s *ReceiptService) GenerateRentalReceipt(ctx context.Context, userID, rentalID int64) (string, error) {
+	rental, err := s.rentalRepo.GetByIDWithRelations(ctx, rentalID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", errors.ErrRentalNotFound
+		}
+		return "", errors.ErrDatabaseError.WithError(err)
+	}
+	if rental.UserID != userID {
+		return "", errors.ErrPermissionDenied
+	}
+	if rental.Status != models.RentalStatusCompleted && rental.Status != models.RentalStatusRefunded {
+		return "", errors.ErrRentalStatusError.WithMessage("租借完成后才能开具收据")
+	}
+
+	order, err := s.getOrder(ctx, rental.OrderID)
+	if err != nil {
+		return "", err
+	}
+	if order.ReceiptURL != nil {
+		return *order.ReceiptURL, nil
+	}
+
+	paymentMethod, err := s.paymentMethod(ctx, order.ID)
+	if err != nil {
+		return "", err
+	}
+	return s.store(ctx, order, buildRentalReceipt(rental, order, paymentMethod))
+}
+
+// getOrder 获取订单
+func (s *ReceiptService) getOrder(ctx context.Context, orderID int64) (*models.Order, error) {
+	var order models.Order
+	if err := s.db.WithContext(ctx).First(&order, orderID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrOrderNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	return &order, nil
+}
+
+// paymentMethod 订单的支付方式，取最近一笔成功的普通支付，没有时为余额支付
+func (s *ReceiptService) paymentMethod(ctx context.Context, orderID int64) (string, error) {
+	var payment models.Payment
+	err := s.db.WithContext(ctx).
+		Where("order_id = ? AND type = ? AND status = ?", orderID, models.PaymentTypePay, models.PaymentStatusSuccess).
+		Order("id DESC").
+		First(&payment).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.PaymentMethodBalance, nil
+	}
+	if err != nil {
+		return "", errors.ErrDatabaseError.WithError(err)
+	}
+	return payment.PaymentMethod, nil
+}
+
+// store 渲染收据并保存到对象存储，将地址记录到订单
+// 并发生成同一订单的收据时以先记录的地址为准
+func (s *ReceiptService) store(ctx context.Context, order *models.Order, doc *receipt.Receipt) (string, error) {
+	if s.uploader == nil {
+		return "", errors.ErrOperationFailed.WithMessage("收据存储未配置")
+	}
+
+	doc.Issuer = s.config.CompanyName
+	content, err := s.renderer.Render(doc)
+	if err != nil {
+		return "", errors.ErrOperationFailed.WithMessage("生成收据失败").WithError(err)
+	}
+
+	objectKey := path.Join(s.config.StorageDir, doc.IssuedAt.Format("200601"), doc.ReceiptNo+".pdf")
+	url, err := s.uploader.Upload(ctx, objectKey, bytes.NewReader(content))
+	if err != nil {
+		return "", errors.ErrOperationFailed.WithMessage("保存收据失败").WithError(err)
+	}
+
+	result := s.db.WithContext(ctx).Model(&models.Order{}).
+		Where("id = ? AND receipt_url IS NULL", order.ID).
+		Update("receipt_url", url)
+	if result.Error != nil {
+		return "", errors.ErrDatabaseError.WithError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		stored, err := s.getOrder(ctx, order.ID)
+		if err != nil {
+			return "", err
+		}
+		if stored.ReceiptURL != nil {
+			return *stored.ReceiptURL, nil
+		}
+	}
+	return url, nil
+}
+
+// buildRentalReceipt 组装租借收据内容
+// 租金按订单金额拆分（原价、会员折扣），超时费为结算时实际从押金扣除的金额，合计为实际收取的费用，不含退还的押金
+func buildRentalReceipt(rental *models.Rental, order *models.Order, paymentMethod string) *receipt.Receipt {
+	issuedAt := rental.UpdatedAt
+	if order.CompletedAt != nil {
+		issuedAt = *order.CompletedAt
+	}
+
+	rentalFee := roundToCent(order.ActualAmount - order.DepositAmount)
+	overtimeFee := roundToCent(chargedOvertimeFee(rental))
+	doc := &receipt.Receipt{
+		Title:     "RECEIPT",
+		ReceiptNo: receiptNoPrefix + order.OrderNo,
+		OrderNo:   order.OrderNo,
+		IssuedAt:  issuedAt,
+		Items: []receipt.Item{
+			{Label: fmt.Sprintf("Rental fee (%d hours)", rental.DurationHours), Amount: roundToCent(rentalFee + order.DiscountAmount)},
+		},
+		Total:  roundToCent(rentalFee + overtimeFee),
+		PaidAt: order.PaidAt,
+	}
+	if order.DiscountAmount > 0 {
+		doc.Items = append(doc.Items, receipt.Item{Label: "Member discount", Amount: -roundToCent(order.DiscountAmount)})
+	}
+	doc.Items = append(doc.Items, receipt.Item{Label: "Overtime fee", Amount: overtimeFee})
+
+	if label, ok := receiptPaymentMethods[paymentMethod]; ok {
+		doc.PaymentMethod = label
+	} else {
+		doc.PaymentMethod = paymentMethod
+	}
+
+	if rental.Device != nil {
+		doc.Details = append(doc.Details, receipt.Detail{Label: "Device", Value: rental.Device.Name})
+		if rental.Device.Venue != nil {
+			venue := rental.Device.Venue
+			doc.Details = append(doc.Details,
+				receipt.Detail{Label: "Venue", Value: venue.Name},
+				receipt.Detail{Label: "Address", Value: venue.Province + venue.City + venue.District + venue.Address},
+			)
+		}
+	}
+	doc.Details = append(doc.Details, receipt.Detail{Label: "Duration", Value: fmt.Sprintf("%d hours", rental.DurationHours)})
+	if rental.UnlockedAt != nil {
+		doc.Details = append(doc.Details, receipt.Detail{Label: "Start Time", Value: rental.UnlockedAt.Format("2006-01-02 15:04")})
+	}
+	if rental.ReturnedAt != nil {
+		doc.Details = append(doc.Details, receipt.Detail{Label: "Return Time", Value: rental.ReturnedAt.Format("2006-01-02 15:04")})
+	}
+	deposit := roundToCent(order.DepositAmount)
+	doc.Details = append(doc.Details,
+		receipt.Detail{Label: "Amount Paid", Value: fmt.Sprintf("%.2f", order.ActualAmount)},
+		receipt.Detail{Label: "Deposit", Value: fmt.Sprintf("%.2f", deposit)},
+		receipt.Detail{Label: "Deposit Returned", Value: fmt.Sprintf("%.2f", roundToCent(deposit-overtimeFee))},
+	)
+
+	if rental.Status == models.RentalStatusRefunded {
+		doc.Notes = append(doc.Notes, "This rental has been refunded.")
+	}
+	return doc
+}
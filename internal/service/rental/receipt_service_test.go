@@ -0,0 +1,163 @@
+package rental
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/receipt"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	"github.com/dumeirei/smart-locker-backend/pkg/oss"
+)
+
+func TestReceiptService_GenerateRentalReceipt(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	uploader := oss.NewMockUploader()
+	receiptSvc := NewReceiptService(svc.db, repository.NewRentalRepository(svc.db), uploader, ReceiptConfig{
+		CompanyName: "Smart Locker Co., Ltd.",
+	})
+
+	user, device, pricing := createTestData(t, svc.db)
+	rentalInfo, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.NoError(t, err)
+	require.NoError(t, svc.PayRental(ctx, user.ID, rentalInfo.ID, ""))
+
+	t.Run("未完成的租借不能开具收据", func(t *testing.T) {
+		_, err := receiptSvc.GenerateRentalReceipt(ctx, user.ID, rentalInfo.ID)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrRentalStatusError.Code, appErr.Code)
+	})
+
+	require.NoError(t, svc.StartRental(ctx, user.ID, rentalInfo.ID))
+	require.NoError(t, svc.ReturnRental(ctx, user.ID, rentalInfo.ID))
+	require.NoError(t, svc.CompleteRental(ctx, rentalInfo.ID))
+
+	t.Run("不能获取他人的收据", func(t *testing.T) {
+		_, err := receiptSvc.GenerateRentalReceipt(ctx, user.ID+1, rentalInfo.ID)
+		assert.ErrorIs(t, err, appErrors.ErrPermissionDenied)
+		assert.Empty(t, uploader.Files)
+	})
+
+	t.Run("首次生成并保存，之后返回同一地址", func(t *testing.T) {
+		url, err := receiptSvc.GenerateRentalReceipt(ctx, user.ID, rentalInfo.ID)
+		require.NoError(t, err)
+		assert.Contains(t, url, DefaultReceiptStorageDir+"/")
+		assert.Contains(t, url, "RCT"+rentalInfo.OrderNo+".pdf")
+		require.Len(t, uploader.Files, 1)
+		for _, content := range uploader.Files {
+			assert.True(t, bytes.HasPrefix(content, []byte("%PDF")))
+		}
+
+		var order models.Order
+		require.NoError(t, svc.db.First(&order, rentalInfo.OrderID).Error)
+		require.NotNil(t, order.ReceiptURL)
+		assert.Equal(t, url, *order.ReceiptURL)
+
+		// 再次请求不重新生成
+		uploader.Files = make(map[string][]byte)
+		again, err := receiptSvc.GenerateRentalReceipt(ctx, user.ID, rentalInfo.ID)
+		require.NoError(t, err)
+		assert.Equal(t, url, again)
+		assert.Empty(t, uploader.Files)
+	})
+
+	t.Run("租借不存在", func(t *testing.T) {
+		_, err := receiptSvc.GenerateRentalReceipt(ctx, user.ID, 99999)
+		assert.ErrorIs(t, err, appErrors.ErrRentalNotFound)
+	})
+}
+
+func TestReceiptService_RefundedRental(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	receiptSvc := NewReceiptService(svc.db, repository.NewRentalRepository(svc.db), oss.NewMockUploader(), ReceiptConfig{})
+
+	user, device, pricing := createTestData(t, svc.db)
+	rentalInfo, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.NoError(t, err)
+	require.NoError(t, svc.db.Model(&models.Rental{}).Where("id = ?", rentalInfo.ID).
+		Update("status", models.RentalStatusRefunded).Error)
+
+	url, err := receiptSvc.GenerateRentalReceipt(ctx, user.ID, rentalInfo.ID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, url)
+}
+
+func TestBuildRentalReceipt(t *testing.T) {
+	paidAt := time.Date(2026, 10, 17, 9, 0, 0, 0, time.Local)
+	completedAt := time.Date(2026, 10, 17, 12, 30, 0, 0, time.Local)
+	rental := &models.Rental{
+		DurationHours: 3,
+		RentalFee:     26.73,
+		Deposit:       50,
+		OvertimeFee:   7.35,
+		Status:        models.RentalStatusCompleted,
+		Device:        &models.Device{Name: "1号柜", Venue: &models.Venue{Name: "万达广场", City: "上海市", Address: "中山路1号"}},
+	}
+	// 原价 29.70，会员 9 折优惠 2.97，订单实付 = 租金 26.73 + 押金 50
+	order := &models.Order{
+		OrderNo:        "R20261017001",
+		OriginalAmount: 79.70,
+		DiscountAmount: 2.97,
+		ActualAmount:   76.73,
+		DepositAmount:  50,
+		PaidAt:         &paidAt,
+		CompletedAt:    &completedAt,
+	}
+
+	doc := buildRentalReceipt(rental, order, models.PaymentMethodWechat)
+	assert.Equal(t, "RCTR20261017001", doc.ReceiptNo)
+	assert.Equal(t, completedAt, doc.IssuedAt)
+	assert.Equal(t, "WeChat Pay", doc.PaymentMethod)
+	assert.Equal(t, &paidAt, doc.PaidAt)
+	assert.Equal(t, []receipt.Item{
+		{Label: "Rental fee (3 hours)", Amount: 29.70},
+		{Label: "Member discount", Amount: -2.97},
+		{Label: "Overtime fee", Amount: 7.35},
+	}, doc.Items)
+
+	// 费用明细与订单金额一致到分：租金原价 = 订单原价 - 押金，合计 = 实付 - 押金 + 超时费
+	cents := func(amount float64) int64 { return int64(math.Round(amount * 100)) }
+	var sum int64
+	for _, item := range doc.Items {
+		sum += cents(item.Amount)
+	}
+	assert.Equal(t, cents(order.OriginalAmount)-cents(order.DepositAmount), cents(doc.Items[0].Amount))
+	assert.Equal(t, cents(order.ActualAmount)-cents(order.DepositAmount)+cents(rental.OvertimeFee), sum)
+	assert.Equal(t, sum, cents(doc.Total))
+
+	details := map[string]string{}
+	for _, detail := range doc.Details {
+		details[detail.Label] = detail.Value
+	}
+	assert.Equal(t, "1号柜", details["Device"])
+	assert.Equal(t, "万达广场", details["Venue"])
+	assert.Equal(t, "3 hours", details["Duration"])
+	assert.Equal(t, "76.73", details["Amount Paid"])
+	assert.Equal(t, "50.00", details["Deposit"])
+	assert.Equal(t, "42.65", details["Deposit Returned"])
+	assert.Empty(t, doc.Notes)
+
+	// 超时费超过押金时按押金扣除，押金不退
+	rental.OvertimeFee = 80
+	rental.Status = models.RentalStatusRefunded
+	doc = buildRentalReceipt(rental, order, models.PaymentMethodBalance)
+	assert.Equal(t, 50.0, doc.Items[2].Amount)
+	assert.Equal(t, 76.73, doc.Total)
+	assert.Equal(t, "Wallet balance", doc.PaymentMethod)
+	assert.Equal(t, []string{"This rental has been refunded."}, doc.Notes)
+	for _, detail := range doc.Details {
+		if detail.Label == "Deposit Returned" {
+			assert.Equal(t, "0.00", detail.Value)
+		}
+	}
+}
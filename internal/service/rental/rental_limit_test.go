@@ -0,0 +1,135 @@
+package rental
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// createLimitTestDevice 在同一场地下创建空闲设备
+func createLimitTestDevice(t *testing.T, db *gorm.DB, venueID int64, no int) *models.Device {
+	t.Helper()
+
+	deviceNo := fmt.Sprintf("D_LIMIT_%03d", no)
+	device := &models.Device{
+		DeviceNo:       deviceNo,
+		Name:           "限额测试设备",
+		Type:           models.DeviceTypeStandard,
+		VenueID:        venueID,
+		QRCode:         "https://qr.example.com/" + deviceNo,
+		ProductName:    "测试产品",
+		SlotCount:      1,
+		AvailableSlots: 1,
+		OnlineStatus:   models.DeviceOnline,
+		LockStatus:     models.DeviceLocked,
+		RentalStatus:   models.DeviceRentalFree,
+		NetworkType:    "WiFi",
+		Status:         models.DeviceStatusActive,
+	}
+	require.NoError(t, db.Create(device).Error)
+	return device
+}
+
+// createRentals 为用户连续创建 n 个租借，返回最后一次的错误
+func createRentals(t *testing.T, svc *testRentalService, userID, venueID, pricingID int64, start, n int) error {
+	t.Helper()
+
+	var err error
+	for i := 0; i < n; i++ {
+		device := createLimitTestDevice(t, svc.db, venueID, start+i)
+		_, err = svc.CreateRental(context.Background(), userID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricingID})
+	}
+	return err
+}
+
+func assertMaxRentalsExceeded(t *testing.T, err error, limit int) {
+	t.Helper()
+
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok, "expected AppError, got %v", err)
+	assert.Equal(t, appErrors.ErrMaxRentalsExceeded.Code, appErr.Code)
+	assert.Contains(t, appErr.Message, fmt.Sprintf("%d", limit))
+}
+
+func TestRentalService_CreateRental_MaxConcurrentRentals(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		allowed int
+	}{
+		{"上限1", 1, 1},
+		{"上限2", 2, 2},
+		{"不限制", 0, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := setupTestRentalService(t)
+			user, device, pricing := createTestData(t, svc.db)
+			svc.db.Model(&models.UserWallet{}).Where("user_id = ?", user.ID).Update("balance", 1000.0)
+			svc.SetMaxConcurrentRentals(tt.limit)
+
+			require.NoError(t, createRentals(t, svc, user.ID, device.VenueID, pricing.ID, 0, tt.allowed))
+			if tt.limit == 0 {
+				return
+			}
+
+			err := createRentals(t, svc, user.ID, device.VenueID, pricing.ID, tt.allowed, 1)
+			assertMaxRentalsExceeded(t, err, tt.limit)
+		})
+	}
+}
+
+func TestRentalService_CreateRental_CompletedRentalsNotCounted(t *testing.T) {
+	svc := setupTestRentalService(t)
+	user, device, pricing := createTestData(t, svc.db)
+
+	require.NoError(t, createRentals(t, svc, user.ID, device.VenueID, pricing.ID, 0, 1))
+	err := createRentals(t, svc, user.ID, device.VenueID, pricing.ID, 1, 1)
+	assertMaxRentalsExceeded(t, err, DefaultMaxConcurrentRentals)
+
+	require.NoError(t, svc.db.Model(&models.Rental{}).Where("user_id = ?", user.ID).
+		Update("status", models.RentalStatusCompleted).Error)
+	assert.NoError(t, createRentals(t, svc, user.ID, device.VenueID, pricing.ID, 2, 1))
+}
+
+func TestRentalService_UpdateMaxConcurrentRentals(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+
+	svc := setupTestRentalService(t)
+	svc.SetMaxConcurrentRentals(1)
+	svc.SetRentalLimitStore(NewRentalLimitStore(client))
+	assert.Equal(t, 1, svc.MaxConcurrentRentals(ctx))
+
+	err := svc.UpdateMaxConcurrentRentals(ctx, -1)
+	assert.Error(t, err)
+
+	// 运行时修改对共享同一存储的其他实例立即生效
+	require.NoError(t, svc.UpdateMaxConcurrentRentals(ctx, 3))
+	peer := setupTestRentalService(t)
+	peer.SetRentalLimitStore(NewRentalLimitStore(client))
+	assert.Equal(t, 3, peer.MaxConcurrentRentals(ctx))
+
+	require.NoError(t, svc.UpdateMaxConcurrentRentals(ctx, 0))
+	assert.Equal(t, 0, peer.MaxConcurrentRentals(ctx))
+
+	// 存储不可用时使用本实例的配置值
+	mr.Close()
+	assert.Equal(t, DefaultMaxConcurrentRentals, peer.MaxConcurrentRentals(ctx))
+	err = svc.UpdateMaxConcurrentRentals(ctx, 2)
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, appErrors.ErrCacheError.Code, appErr.Code)
+}
@@ -3,16 +3,24 @@ package rental
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"math"
+	"sync/atomic"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"github.com/dumeirei/smart-locker-backend/internal/common/cache"
 	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
 	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 	deviceService "github.com/dumeirei/smart-locker-backend/internal/service/device"
+	eventService "github.com/dumeirei/smart-locker-backend/internal/service/event"
+	orderService "github.com/dumeirei/smart-locker-backend/internal/service/order"
+	paymentService "github.com/dumeirei/smart-locker-backend/internal/service/payment"
 	userService "github.com/dumeirei/smart-locker-backend/internal/service/user"
 )
 
@@ -21,9 +29,24 @@ type RentalService struct {
 	db            *gorm.DB
 	rentalRepo    *repository.RentalRepository
 	deviceRepo    *repository.DeviceRepository
+	venueRepo     *repository.VenueRepository
+	slotRepo      *repository.DeviceSlotRepository
 	deviceService *deviceService.DeviceService
 	walletService *userService.WalletService
 	mqttService   *deviceService.MQTTService
+	idempotency   *paymentService.IdempotencyService
+	locker        *cache.Locker
+	orderEvents   orderService.OrderEventHandler
+	statusEvents  eventService.Publisher
+	pricingCache  *repository.CachedRentalPricingRepository
+	levelCache    *repository.CachedMemberLevelRepository
+	preauth       *paymentService.PreauthService
+	scheduleRepo  *repository.PricingScheduleRepository
+	pointsService *userService.PointsService
+	orderNotes    *orderService.OrderNoteService
+
+	maxConcurrentRentals atomic.Int64      // 每用户同时进行中租借数上限，0 表示不限制
+	limitStore           *RentalLimitStore // 运行时上限存储，覆盖 maxConcurrentRentals
 }
 
 // NewRentalService 创建租借服务
@@ -34,52 +57,127 @@ func NewRentalService(
 	deviceSvc *deviceService.DeviceService,
 	walletSvc *userService.WalletService,
 	mqttSvc *deviceService.MQTTService,
+	idempotencySvc *paymentService.IdempotencyService,
+	locker *cache.Locker,
 ) *RentalService {
-	return &RentalService{
+	s := &RentalService{
 		db:            db,
 		rentalRepo:    rentalRepo,
 		deviceRepo:    deviceRepo,
+		venueRepo:     repository.NewVenueRepository(db),
+		slotRepo:      repository.NewDeviceSlotRepository(db),
 		deviceService: deviceSvc,
 		walletService: walletSvc,
 		mqttService:   mqttSvc,
+		idempotency:   idempotencySvc,
+		locker:        locker,
 	}
+	s.maxConcurrentRentals.Store(DefaultMaxConcurrentRentals)
+	return s
+}
+
+// SetOrderEventHandler 设置订单事件处理器，租借结算完成后触发（如发放消费积分）
+func (s *RentalService) SetOrderEventHandler(handler orderService.OrderEventHandler) {
+	s.orderEvents = handler
+}
+
+// SetStatusEventPublisher 设置租借状态变更事件发布者，用于向客户端实时推送状态，未设置时不发布
+func (s *RentalService) SetStatusEventPublisher(publisher eventService.Publisher) {
+	s.statusEvents = publisher
+}
+
+// SetPricingCache 设置带缓存的租借定价仓储，创建租借时优先从缓存读取定价，未设置时直接查库
+func (s *RentalService) SetPricingCache(repo *repository.CachedRentalPricingRepository) {
+	s.pricingCache = repo
+}
+
+// SetMemberLevelCache 设置带缓存的会员等级仓储，创建租借时优先从缓存读取会员等级，未设置时直接查库
+func (s *RentalService) SetMemberLevelCache(repo *repository.CachedMemberLevelRepository) {
+	s.levelCache = repo
+}
+
+// SetPreauthService 设置押金预授权服务，未设置时不支持预授权方式缴纳押金
+func (s *RentalService) SetPreauthService(svc *paymentService.PreauthService) {
+	s.preauth = svc
+}
+
+// SetPointsService 设置积分服务，用于下单时积分抵扣租金及取消订单时返还抵扣积分
+func (s *RentalService) SetPointsService(svc *userService.PointsService) {
+	s.pointsService = svc
+}
+
+// SetOrderNoteService 设置订单备注服务，租借详情附带最新一条用户可见的备注
+func (s *RentalService) SetOrderNoteService(orderNotes *orderService.OrderNoteService) {
+	s.orderNotes = orderNotes
+}
+
+// publishRentalStatus 发布租借状态变更事件，发布失败不影响业务流程
+func (s *RentalService) publishRentalStatus(ctx context.Context, rentalID, userID int64, oldStatus, newStatus string) {
+	if s.statusEvents == nil || oldStatus == newStatus {
+		return
+	}
+	_ = s.statusEvents.Publish(ctx, &eventService.StatusEvent{
+		Subject:   eventService.SubjectRental,
+		ID:        rentalID,
+		UserID:    userID,
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+		Timestamp: time.Now(),
+	})
 }
 
 // CreateRentalRequest 创建租借请求
 type CreateRentalRequest struct {
-	DeviceID  int64 `json:"device_id" binding:"required"`
-	PricingID int64 `json:"pricing_id" binding:"required"`
+	DeviceID      int64  `json:"device_id" binding:"required"`
+	PricingID     int64  `json:"pricing_id" binding:"required"`
+	DepositMethod string `json:"deposit_method" binding:"omitempty,oneof=wallet_freeze preauth"` // 押金方式，默认冻结钱包余额
+	UsePoints     int    `json:"use_points" binding:"omitempty,min=0"`                           // 使用积分抵扣租金（押金不可抵扣）
+}
+
+// ExtendRentalRequest 续租请求，additional_hours（按小时续租）与 pricing_id（按续租套餐续租）二选一
+type ExtendRentalRequest struct {
+	AdditionalHours int   `json:"additional_hours" binding:"omitempty,min=1,max=24"`
+	PricingID       int64 `json:"pricing_id" binding:"omitempty,min=1"`
 }
 
 // RentalInfo 租借信息
 type RentalInfo struct {
-	ID               int64                     `json:"id"`
-	OrderID          int64                     `json:"order_id"`
-	OrderNo          string                    `json:"order_no"`
-	Status           string                    `json:"status"`
-	StatusName       string                    `json:"status_name"`
-	Device           *deviceService.DeviceInfo  `json:"device,omitempty"`
-	DurationHours    int                       `json:"duration_hours"`
-	RentalFee        float64                   `json:"rental_fee"`
-	Deposit          float64                   `json:"deposit"`
-	OvertimeRate     float64                   `json:"overtime_rate"`
-	OvertimeFee      float64                   `json:"overtime_fee"`
-	UnlockedAt       *time.Time                `json:"unlocked_at,omitempty"`
-	ExpectedReturnAt *time.Time                `json:"expected_return_at,omitempty"`
-	ReturnedAt       *time.Time                `json:"returned_at,omitempty"`
-	IsPurchased      bool                      `json:"is_purchased"`
-	CreatedAt        time.Time                 `json:"created_at"`
+	ID               int64                       `json:"id"`
+	OrderID          int64                       `json:"order_id"`
+	OrderNo          string                      `json:"order_no"`
+	Status           string                      `json:"status"`
+	StatusName       string                      `json:"status_name"`
+	Device           *deviceService.DeviceInfo   `json:"device,omitempty"`
+	SlotNo           *int                        `json:"slot_no,omitempty"` // 分配的格口编号
+	DurationHours    int                         `json:"duration_hours"`
+	OriginalFee      float64                     `json:"original_fee"`
+	DiscountRate     float64                     `json:"discount_rate"`
+	RentalFee        float64                     `json:"rental_fee"`
+	Deposit          float64                     `json:"deposit"`
+	DepositMethod    string                      `json:"deposit_method"`
+	OvertimeRate     float64                     `json:"overtime_rate"`
+	OvertimeFee      float64                     `json:"overtime_fee"`
+	UnlockedAt       *time.Time                  `json:"unlocked_at,omitempty"`
+	ExpectedReturnAt *time.Time                  `json:"expected_return_at,omitempty"`
+	ReturnedAt       *time.Time                  `json:"returned_at,omitempty"`
+	IsPurchased      bool                        `json:"is_purchased"`
+	CreatedAt        time.Time                   `json:"created_at"`
+	LatestNote       *orderService.OrderNoteInfo `json:"latest_note,omitempty"` // 仅租借详情返回
 }
 
 // CreateRental 创建租借订单
 func (s *RentalService) CreateRental(ctx context.Context, userID int64, req *CreateRentalRequest) (*RentalInfo, error) {
-	// 检查用户是否有进行中的租借
-	hasActive, err := s.rentalRepo.HasActiveRental(ctx, userID)
-	if err != nil {
-		return nil, errors.ErrDatabaseError.WithError(err)
+	// 检查用户进行中的租借数是否已达上限
+	if err := s.checkConcurrentRentals(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	depositMethod := req.DepositMethod
+	if depositMethod == "" {
+		depositMethod = models.DepositMethodWalletFreeze
 	}
-	if hasActive {
-		return nil, errors.ErrRentalInProgress
+	if depositMethod == models.DepositMethodPreauth && s.preauth == nil {
+		return nil, errPreauthUnavailable
 	}
 
 	// 检查设备是否可用
@@ -87,25 +185,53 @@ func (s *RentalService) CreateRental(ctx context.Context, userID int64, req *Cre
 		return nil, err
 	}
 
-	// 获取定价信息
-	pricing, err := s.deviceRepo.GetPricingByID(ctx, req.PricingID)
+	// 校验所选定价对设备生效（设备专属 > 场地 > 全局）
+	pricing, err := s.GetEffectivePricing(ctx, req.DeviceID, req.PricingID)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, errors.ErrPricingNotFound
-		}
-		return nil, errors.ErrDatabaseError.WithError(err)
+		return nil, err
 	}
 
-	if !pricing.IsActive {
-		return nil, errors.ErrPricingNotFound
+	// 确定超时宽限期（定价未配置时使用场地默认值）
+	gracePeriod, err := s.resolveGracePeriod(ctx, pricing, req.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按场地分时定价调整租金（高峰/周末倍率）
+	price, err := s.scheduledPrice(ctx, pricing, req.DeviceID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// 按会员等级折扣计算租金（押金不打折）
+	memberLevel, err := s.getMemberLevel(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	rentalFee, discountRate := applyMemberDiscount(price, memberLevel)
+
+	// 积分抵扣租金（押金不可抵扣），积分在创建订单的事务中扣减
+	if req.UsePoints > 0 {
+		if s.pointsService == nil {
+			return nil, errors.ErrOperationFailed.WithMessage("暂不支持积分抵扣")
+		}
+		pointsDiscount := userService.CalculateRedeemDiscount(req.UsePoints)
+		if pointsDiscount > rentalFee {
+			return nil, errors.ErrInvalidParams.WithMessage("抵扣金额不能超过租金")
+		}
+		rentalFee = roundToCent(rentalFee - pointsDiscount)
 	}
 
 	// 计算总金额
-	totalAmount := pricing.Price + pricing.Deposit
+	totalAmount := rentalFee + pricing.Deposit
 
-	// 检查余额是否足够（租金 + 押金）
-	if s.walletService != nil && totalAmount > 0 {
-		ok, err := s.walletService.CheckBalance(ctx, userID, totalAmount)
+	// 检查余额是否足够（租金 + 押金，预授权押金不占用余额）
+	walletAmount := totalAmount
+	if depositMethod == models.DepositMethodPreauth {
+		walletAmount = rentalFee
+	}
+	if s.walletService != nil && walletAmount > 0 {
+		ok, err := s.walletService.CheckBalance(ctx, userID, walletAmount)
 		if err != nil {
 			return nil, err
 		}
@@ -114,19 +240,54 @@ func (s *RentalService) CreateRental(ctx context.Context, userID int64, req *Cre
 		}
 	}
 
+	// 预授权方式在下单前向渠道冻结押金（不在事务中调用渠道），下单失败时解冻；取消或结算时解冻
+	// 渠道调用耗时不可控，须在加设备槽位锁之前完成，避免持锁期间锁过期
+	var preauth *paymentService.PreauthAuthorization
+	if depositMethod == models.DepositMethodPreauth && pricing.Deposit > 0 {
+		preauth, err = s.preauth.Authorize(ctx, userID, pricing.Deposit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 加设备槽位锁，防止并发下重复预占同一槽位
+	if s.locker != nil {
+		lock, err := s.locker.Acquire(ctx, deviceSlotLockKey(req.DeviceID))
+		if err != nil {
+			if preauth != nil {
+				_ = s.preauth.Void(context.WithoutCancel(ctx), preauth)
+			}
+			if stderrors.Is(err, cache.ErrLockNotAcquired) {
+				return nil, errors.ErrDeviceBusy
+			}
+			return nil, errors.ErrCacheError.WithError(err)
+		}
+		defer lock.Release(context.WithoutCancel(ctx))
+	}
+
 	// 使用事务创建Order和Rental
 	var rental *models.Rental
 	var order *models.Order
 
 	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 格口库存上线前注册的设备先补建格口记录
+		if err := s.slotRepo.EnsureTx(ctx, tx, req.DeviceID); err != nil {
+			return err
+		}
+
 		// 1. 创建Order记录
 		orderNo := utils.GenerateOrderNo("O")
+		if req.UsePoints > 0 {
+			if _, err := s.pointsService.RedeemPointsTx(ctx, tx, userID, req.UsePoints, orderNo); err != nil {
+				return err
+			}
+		}
 		order = &models.Order{
 			OrderNo:        orderNo,
 			UserID:         userID,
 			Type:           models.OrderTypeRental,
-			OriginalAmount: totalAmount,
-			DiscountAmount: 0,
+			OriginalAmount: price + pricing.Deposit,
+			DiscountAmount: roundToCent(price - rentalFee),
 			ActualAmount:   totalAmount,
 			DepositAmount:  pricing.Deposit,
 			Status:         models.OrderStatusPending,
@@ -139,37 +300,55 @@ func (s *RentalService) CreateRental(ctx context.Context, userID int64, req *Cre
 		// 2. 创建Rental记录
 		expectedReturn := time.Now().Add(time.Duration(pricing.DurationHours) * time.Hour)
 		rental = &models.Rental{
-			OrderID:          order.ID,
-			UserID:           userID,
-			DeviceID:         req.DeviceID,
-			DurationHours:    pricing.DurationHours,
-			RentalFee:        pricing.Price,
-			Deposit:          pricing.Deposit,
-			OvertimeRate:     pricing.OvertimeRate,
-			OvertimeFee:      0,
-			Status:           models.RentalStatusPending,
-			ExpectedReturnAt: &expectedReturn,
+			OrderID:            order.ID,
+			UserID:             userID,
+			DeviceID:           req.DeviceID,
+			PricingID:          &pricing.ID,
+			DurationHours:      pricing.DurationHours,
+			OriginalFee:        price,
+			HourlyPrice:        hourlyPrice(price, pricing.DurationHours),
+			DiscountRate:       discountRate,
+			RentalFee:          rentalFee,
+			Deposit:            pricing.Deposit,
+			OvertimeRate:       pricing.OvertimeRate,
+			OvertimeFee:        0,
+			GracePeriodMinutes: gracePeriod,
+			DepositMethod:      depositMethod,
+			Status:             models.RentalStatusPending,
+			ExpectedReturnAt:   &expectedReturn,
 		}
 
 		if err := tx.Create(rental).Error; err != nil {
 			return err
 		}
 
-		// 3. 减少设备可用槽位（预占）
-		result := tx.Model(&models.Device{}).
-			Where("id = ? AND available_slots > 0", req.DeviceID).
-			UpdateColumn("available_slots", gorm.Expr("available_slots - 1"))
-		if result.Error != nil {
-			return result.Error
+		// 3. 预占空闲格口并减少设备可用槽位
+		slotNo, err := s.slotRepo.AllocateTx(ctx, tx, req.DeviceID, rental.ID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.ErrDeviceNoSlot
+			}
+			return err
 		}
-		if result.RowsAffected == 0 {
-			return errors.ErrDeviceNoSlot
+		rental.SlotNo = &slotNo
+
+		if err := tx.Model(rental).Update("slot_no", slotNo).Error; err != nil {
+			return err
 		}
 
+		// 4. 记录已冻结押金的预授权支付单
+		if preauth != nil {
+			if _, err := s.preauth.RecordAuthorizationTx(ctx, tx, order, preauth); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 
 	if err != nil {
+		if preauth != nil {
+			_ = s.preauth.Void(context.WithoutCancel(ctx), preauth)
+		}
 		if appErr, ok := err.(*errors.AppError); ok {
 			return nil, appErr
 		}
@@ -184,9 +363,72 @@ func (s *RentalService) CreateRental(ctx context.Context, userID int64, req *Cre
 	return info, nil
 }
 
+// GetEffectivePricing 校验所选定价对设备生效并返回该定价
+// 所选定价须启用且适用于该设备；同一时长存在更高优先级（设备专属 > 场地 > 全局）的定价时，
+// 所选定价已被覆盖，返回 ErrPricingNotApplicable，客户端需按设备定价列表重新选择
+func (s *RentalService) GetEffectivePricing(ctx context.Context, deviceID int64, pricingID int64) (*models.RentalPricing, error) {
+	selected, err := s.getPricingByID(ctx, pricingID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrPricingNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	if !selected.IsActive {
+		return nil, errors.ErrPricingNotFound
+	}
+
+	device, err := s.deviceRepo.GetByID(ctx, deviceID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrDeviceNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	if !selected.AppliesTo(device.ID, device.VenueID) {
+		return nil, errors.ErrPricingNotFound
+	}
+
+	pricings, err := s.deviceRepo.ListApplicablePricings(ctx, device.ID, device.VenueID)
+	if err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	for _, p := range pricings {
+		if p.DurationHours == selected.DurationHours && p.Priority() > selected.Priority() {
+			return nil, errors.ErrPricingNotApplicable
+		}
+	}
+	return selected, nil
+}
+
+// getPricingByID 获取租借定价，设置了定价缓存时优先读取缓存
+func (s *RentalService) getPricingByID(ctx context.Context, pricingID int64) (*models.RentalPricing, error) {
+	if s.pricingCache != nil {
+		return s.pricingCache.GetByID(ctx, pricingID)
+	}
+	return s.deviceRepo.GetPricingByID(ctx, pricingID)
+}
+
 // PayRental 支付租借订单
-func (s *RentalService) PayRental(ctx context.Context, userID int64, rentalID int64) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+// idempotencyKey 非空时，相同键的重复请求将直接返回首次结果，不会重复扣款
+func (s *RentalService) PayRental(ctx context.Context, userID int64, rentalID int64, idempotencyKey string) error {
+	var paid bool
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 预留幂等键（与扣款在同一事务中，失败时一并回滚以便客户端重试）
+		var idemRecord *models.IdempotencyKey
+		if s.idempotency != nil {
+			record, replayed, err := s.idempotency.ReserveTx(ctx, tx, userID, idempotencyKey,
+				models.IdempotencyScopeRentalPay, map[string]int64{"rental_id": rentalID})
+			if err != nil {
+				return err
+			}
+			if replayed {
+				return nil
+			}
+			idemRecord = record
+		}
+
 		// 获取并锁定租借订单
 		rental, err := s.rentalRepo.GetForUpdate(ctx, tx, rentalID)
 		if err != nil {
@@ -214,9 +456,10 @@ func (s *RentalService) PayRental(ctx context.Context, userID int64, rentalID in
 		}
 
 		// 对接钱包服务 - 冻结押金 + 扣除租金（余额支付）
-		if s.walletService != nil {
+		// 免费租借（租金与押金均为 0）不触达钱包，也不产生任何钱包流水；预授权押金已在下单时冻结
+		if s.walletService != nil && rental.RentalFee+rental.Deposit > 0 {
 			orderNo := order.OrderNo
-			if rental.Deposit > 0 {
+			if rental.Deposit > 0 && !usesPreauth(rental) {
 				if err := s.walletService.FreezeDepositTx(ctx, tx, userID, rental.Deposit, orderNo); err != nil {
 					return err
 				}
@@ -246,13 +489,51 @@ func (s *RentalService) PayRental(ctx context.Context, userID int64, rentalID in
 			return errors.ErrDatabaseError.WithError(err)
 		}
 
+		paid = true
+		if s.idempotency != nil {
+			return s.idempotency.CompleteTx(ctx, tx, idemRecord, nil)
+		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	if paid {
+		s.publishRentalStatus(ctx, rentalID, userID, models.RentalStatusPending, models.RentalStatusPaid)
+	}
+	return nil
+}
+
+// OnPaymentSuccess 第三方支付成功回调
+// 将待支付的租借更新为已支付；租借已不是待支付状态时视为已处理
+func (s *RentalService) OnPaymentSuccess(ctx context.Context, orderID int64) error {
+	var rental models.Rental
+	if err := s.db.WithContext(ctx).Select("id", "user_id").
+		Where("order_id = ? AND status = ?", orderID, models.RentalStatusPending).
+		First(&rental).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return errors.ErrDatabaseError.WithError(err)
+	}
+
+	res := s.db.WithContext(ctx).Model(&models.Rental{}).
+		Where("id = ? AND status = ?", rental.ID, models.RentalStatusPending).
+		Update("status", models.RentalStatusPaid)
+	if res.Error != nil {
+		return errors.ErrDatabaseError.WithError(res.Error)
+	}
+	if res.RowsAffected > 0 {
+		s.publishRentalStatus(ctx, rental.ID, rental.UserID, models.RentalStatusPending, models.RentalStatusPaid)
+	}
+	return nil
 }
 
 // StartRental 开始租借（开锁取货）
 func (s *RentalService) StartRental(ctx context.Context, userID int64, rentalID int64) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	var device models.Device
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		rental, err := s.rentalRepo.GetForUpdate(ctx, tx, rentalID)
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
@@ -270,7 +551,6 @@ func (s *RentalService) StartRental(ctx context.Context, userID int64, rentalID
 		}
 
 		// 获取设备信息(用于后续MQTT命令)，并确保设备仍可用
-		var device models.Device
 		if err := tx.WithContext(ctx).First(&device, rental.DeviceID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				return errors.ErrDeviceNotFound
@@ -287,12 +567,12 @@ func (s *RentalService) StartRental(ctx context.Context, userID int64, rentalID
 		// TODO: 发送开锁命令 (MQTT服务集成)
 		// 临时注释,等MQTT服务完善后启用
 		/*
-		if s.mqttService != nil {
-			_, err := s.mqttService.SendUnlockCommand(ctx, device.DeviceNo, nil)
-			if err != nil {
-				return errors.ErrUnlockFailed.WithError(err)
+			if s.mqttService != nil {
+				_, err := s.mqttService.SendUnlockCommand(ctx, device.DeviceNo, nil)
+				if err != nil {
+					return errors.ErrUnlockFailed.WithError(err)
+				}
 			}
-		}
 		*/
 
 		now := time.Now()
@@ -316,13 +596,219 @@ func (s *RentalService) StartRental(ctx context.Context, userID int64, rentalID
 			return errors.ErrDatabaseError.WithError(err)
 		}
 
+		// 预占的格口转为使用中
+		if err := s.slotRepo.OccupyTx(ctx, tx, rental); err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	s.publishDeviceRentalStatus(ctx, device.ID, device.RentalStatus, models.DeviceRentalInUse)
+	s.publishRentalStatus(ctx, rentalID, userID, models.RentalStatusPaid, models.RentalStatusInUse)
+	return nil
+}
+
+// publishDeviceRentalStatus 发布设备租借状态变更事件
+func (s *RentalService) publishDeviceRentalStatus(ctx context.Context, deviceID int64, oldStatus, newStatus int8) {
+	if s.deviceService != nil {
+		s.deviceService.PublishStatusChange(ctx, deviceID, deviceService.StatusEventRentalStatus, oldStatus, newStatus)
+	}
+}
+
+// maxExtendHours 单次按小时续租的最大时长
+const maxExtendHours = 24
+
+// extendQuote 计算续租时长和费用，在锁定租借订单的事务内调用
+type extendQuote func(tx *gorm.DB, rental *models.Rental, device *models.Device) (hours int, fee float64, err error)
+
+// ExtendRental 按小时续租
+// 按下单时记录的小时单价计费（沿用租借时的会员折扣率，不再收取押金），不受之后定价或分时倍率调整影响，
+// 从余额扣除并顺延预计归还时间
+func (s *RentalService) ExtendRental(ctx context.Context, userID int64, rentalID int64, additionalHours int) (*RentalInfo, error) {
+	if additionalHours <= 0 || additionalHours > maxExtendHours {
+		return nil, errors.ErrInvalidParams.WithMessage(fmt.Sprintf("续租时长须为1-%d小时", maxExtendHours))
+	}
+
+	return s.extendRental(ctx, userID, rentalID, func(tx *gorm.DB, rental *models.Rental, _ *models.Device) (int, float64, error) {
+		price := rental.HourlyPrice
+		if price <= 0 {
+			// 历史租借未记录小时单价，按所选定价计算；未记录定价的只能按续租套餐续租
+			if rental.PricingID == nil {
+				return 0, 0, errors.ErrPricingNotFound
+			}
+
+			var pricing models.RentalPricing
+			if err := tx.WithContext(ctx).First(&pricing, *rental.PricingID).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return 0, 0, errors.ErrPricingNotFound
+				}
+				return 0, 0, errors.ErrDatabaseError.WithError(err)
+			}
+			if pricing.DurationHours <= 0 {
+				return 0, 0, errors.ErrPricingNotFound
+			}
+			price = hourlyPrice(pricing.Price, pricing.DurationHours)
+		}
+
+		discountRate := rental.DiscountRate
+		if discountRate <= 0 || discountRate > noDiscount {
+			discountRate = noDiscount
+		}
+
+		return additionalHours, roundToCent(price * float64(additionalHours) * discountRate), nil
+	})
+}
+
+// hourlyPrice 按定价时长折算小时单价，保留四位小数
+func hourlyPrice(price float64, durationHours int) float64 {
+	if durationHours <= 0 {
+		return 0
+	}
+	return math.Round(price/float64(durationHours)*10000) / 10000
+}
+
+// ExtendRentalWithPricing 按续租套餐续租
+// 按所选定价扣除续租费用并顺延预计归还时间
+func (s *RentalService) ExtendRentalWithPricing(ctx context.Context, userID int64, rentalID int64, additionalPricingID int64) (*RentalInfo, error) {
+	// 获取续租定价
+	pricing, err := s.deviceRepo.GetPricingByID(ctx, additionalPricingID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrPricingNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	if !pricing.IsActive {
+		return nil, errors.ErrPricingNotFound
+	}
+
+	return s.extendRental(ctx, userID, rentalID, func(_ *gorm.DB, _ *models.Rental, device *models.Device) (int, float64, error) {
+		// 续租定价须适用于当前设备
+		if !pricing.AppliesTo(device.ID, device.VenueID) {
+			return 0, 0, errors.ErrPricingNotFound
+		}
+		return pricing.DurationHours, pricing.Price, nil
+	})
+}
+
+// extendRental 续租，已进入超时的租借不允许续租
+// 续租费用从余额扣除，续租记录作为订单项保存并累加订单金额
+func (s *RentalService) extendRental(ctx context.Context, userID int64, rentalID int64, quote extendQuote) (*RentalInfo, error) {
+	var rental *models.Rental
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 获取并锁定租借订单，并发续租时串行执行（SQLite 不支持行锁，由下方条件更新兜底）
+		var err error
+		rental, err = s.rentalRepo.GetForUpdate(ctx, tx, rentalID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.ErrRentalNotFound
+			}
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		if rental.UserID != userID {
+			return errors.ErrPermissionDenied
+		}
+
+		if rental.Status != models.RentalStatusInUse {
+			return errors.ErrRentalStatusError
+		}
+
+		// 订阅租借按周期自动续订，不支持单独续租
+		if rental.SubscriptionID != nil {
+			return errors.ErrRentalStatusError.WithMessage("订阅中的租借按周期自动续订，不能单独续租")
+		}
+
+		now := time.Now()
+		if rental.ExpectedReturnAt == nil || now.After(*rental.ExpectedReturnAt) {
+			return errors.ErrRentalOverdue
+		}
+
+		var device models.Device
+		if err := tx.WithContext(ctx).First(&device, rental.DeviceID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.ErrDeviceNotFound
+			}
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		hours, fee, err := quote(tx, rental, &device)
+		if err != nil {
+			return err
+		}
+
+		var order models.Order
+		if err := tx.WithContext(ctx).First(&order, rental.OrderID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.ErrOrderNotFound
+			}
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		// 扣除续租费用（余额支付）
+		if s.walletService != nil && fee > 0 {
+			if err := s.walletService.ConsumeTx(ctx, tx, userID, fee, order.OrderNo); err != nil {
+				return err
+			}
+		}
+
+		// 顺延预计归还时间；仅当租借仍为使用中且未被其他续租修改时更新，避免并发续租丢失更新
+		expectedReturn := rental.ExpectedReturnAt.Add(time.Duration(hours) * time.Hour)
+		durationHours := rental.DurationHours + hours
+		rentalFee := roundToCent(rental.RentalFee + fee)
+		result := tx.Model(&models.Rental{}).
+			Where("id = ? AND status = ? AND expected_return_at = ?", rental.ID, models.RentalStatusInUse, *rental.ExpectedReturnAt).
+			Updates(map[string]interface{}{
+				"expected_return_at": expectedReturn,
+				"duration_hours":     durationHours,
+				"rental_fee":         rentalFee,
+			})
+		if result.Error != nil {
+			return errors.ErrDatabaseError.WithError(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrRentalStatusError.WithMessage("租借状态已变更，请重试")
+		}
+		rental.ExpectedReturnAt = &expectedReturn
+		rental.DurationHours = durationHours
+		rental.RentalFee = rentalFee
+
+		// 续租记录作为订单项保存，并累加订单金额
+		item := &models.OrderItem{
+			OrderID:     order.ID,
+			ProductName: fmt.Sprintf("续租%d小时", hours),
+			Price:       fee,
+			Quantity:    1,
+			Subtotal:    fee,
+		}
+		if err := tx.Create(item).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		if err := tx.Model(&models.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+			"original_amount": gorm.Expr("original_amount + ?", fee),
+			"actual_amount":   gorm.Expr("actual_amount + ?", fee),
+		}).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toRentalInfo(rental, nil, nil), nil
 }
 
 // ReturnRental 归还租借
 func (s *RentalService) ReturnRental(ctx context.Context, userID int64, rentalID int64) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	var deviceID int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		rental, err := s.rentalRepo.GetForUpdate(ctx, tx, rentalID)
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
@@ -343,16 +829,7 @@ func (s *RentalService) ReturnRental(ctx context.Context, userID int64, rentalID
 		now := time.Now()
 
 		// 计算超时费用
-		var overtimeFee float64
-		if rental.ExpectedReturnAt != nil && now.After(*rental.ExpectedReturnAt) {
-			// 超时,计算超时费用
-			overtimeHours := int(now.Sub(*rental.ExpectedReturnAt).Hours()) + 1
-			overtimeFee = float64(overtimeHours) * rental.OvertimeRate
-			// 超时费用不能超过押金
-			if overtimeFee > rental.Deposit {
-				overtimeFee = rental.Deposit
-			}
-		}
+		overtimeFee := calculateOvertimeFee(rental, now)
 
 		// 更新租借状态
 		updates := map[string]interface{}{
@@ -364,25 +841,43 @@ func (s *RentalService) ReturnRental(ctx context.Context, userID int64, rentalID
 			return errors.ErrDatabaseError.WithError(err)
 		}
 
-		// 更新设备状态
+		// 更新设备状态，释放格口
 		if err := tx.Model(&models.Device{}).Where("id = ?", rental.DeviceID).Updates(map[string]interface{}{
 			"rental_status":     models.DeviceRentalFree,
 			"current_rental_id": nil,
-			"available_slots":   gorm.Expr("available_slots + 1"),
 		}).Error; err != nil {
 			return errors.ErrDatabaseError.WithError(err)
 		}
+		if err := s.slotRepo.ReleaseTx(ctx, tx, rental); err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		// 订阅租借归还后格口已释放，所属订阅随之终止
+		if err := endSubscriptionTx(tx, rental); err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
 
 		// TODO: 钱包服务 - 退还押金或扣除超时费
 
+		deviceID = rental.DeviceID
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	s.publishDeviceRentalStatus(ctx, deviceID, models.DeviceRentalInUse, models.DeviceRentalFree)
+	s.publishRentalStatus(ctx, rentalID, userID, models.RentalStatusInUse, models.RentalStatusReturned)
+	return nil
 }
 
 // CompleteRental 完成租借（结算）
 func (s *RentalService) CompleteRental(ctx context.Context, rentalID int64) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		rental, err := s.rentalRepo.GetForUpdate(ctx, tx, rentalID)
+	var order models.Order
+	var rental *models.Rental
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		rental, err = s.rentalRepo.GetForUpdate(ctx, tx, rentalID)
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
 				return errors.ErrRentalNotFound
@@ -394,64 +889,136 @@ func (s *RentalService) CompleteRental(ctx context.Context, rentalID int64) erro
 			return errors.ErrRentalStatusError
 		}
 
-		var order models.Order
-		if err := tx.WithContext(ctx).First(&order, rental.OrderID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return errors.ErrOrderNotFound
-			}
-			return errors.ErrDatabaseError.WithError(err)
-		}
-
-		// 结算逻辑：超时费用从押金扣除，其余押金退还
-		if s.walletService != nil && rental.Deposit > 0 {
-			overtimeFee := rental.OvertimeFee
-			if overtimeFee < 0 {
-				overtimeFee = 0
-			}
-			if overtimeFee > rental.Deposit {
-				overtimeFee = rental.Deposit
-			}
+		return s.settleRentalTx(ctx, tx, rental, &order)
+	})
+	if err != nil {
+		return err
+	}
+	s.completePreauth(ctx, rental)
 
-			if overtimeFee > 0 {
-				if err := s.walletService.DeductFrozenToConsumeTx(ctx, tx, rental.UserID, overtimeFee, order.OrderNo, "租借超时费"); err != nil {
-					return err
-				}
-			}
+	// 订单完成事件处理失败不影响结算结果
+	if s.orderEvents != nil {
+		_ = s.orderEvents.OnOrderCompleted(ctx, &order)
+	}
+	s.publishRentalStatus(ctx, rentalID, rental.UserID, models.RentalStatusReturned, models.RentalStatusCompleted)
+	return nil
+}
 
-			refundAmount := rental.Deposit - overtimeFee
-			if refundAmount > 0 {
-				if err := s.walletService.UnfreezeDepositTx(ctx, tx, rental.UserID, refundAmount, order.OrderNo); err != nil {
-					return err
-				}
-			}
+// settleRentalTx 在事务中结算租借：超时费用从押金扣除，其余押金退还，并将租借及订单标记为已完成
+// 结算完成后 order 为更新后的订单；预授权押金在事务提交后须调用 completePreauth 提交渠道
+func (s *RentalService) settleRentalTx(ctx context.Context, tx *gorm.DB, rental *models.Rental, order *models.Order) error {
+	if err := tx.WithContext(ctx).First(order, rental.OrderID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.ErrOrderNotFound
 		}
+		return errors.ErrDatabaseError.WithError(err)
+	}
 
-		// 更新订单状态
-		updates := map[string]interface{}{
-			"status": models.RentalStatusCompleted,
+	// 结算逻辑：超时费用从押金扣除，其余押金退还
+	if usesPreauth(rental) && rental.Deposit > 0 {
+		if s.preauth == nil {
+			return errPreauthUnavailable
 		}
-		if err := tx.Model(rental).Updates(updates).Error; err != nil {
-			return errors.ErrDatabaseError.WithError(err)
+		if err := s.preauth.SettleTx(ctx, tx, rental.OrderID, chargedOvertimeFee(rental)); err != nil {
+			return err
+		}
+	} else if s.walletService != nil && rental.Deposit > 0 {
+		overtimeFee := chargedOvertimeFee(rental)
+		if overtimeFee > 0 {
+			if err := s.walletService.DeductFrozenToConsumeTx(ctx, tx, rental.UserID, overtimeFee, order.OrderNo, "租借超时费"); err != nil {
+				return err
+			}
 		}
 
-		// 更新Order状态
-		now := time.Now()
-		if err := tx.Model(&models.Order{}).Where("id = ?", rental.OrderID).
-			Updates(map[string]interface{}{
-				"status":       models.OrderStatusCompleted,
-				"completed_at": now,
-			}).Error; err != nil {
-			return errors.ErrDatabaseError.WithError(err)
+		refundAmount := rental.Deposit - overtimeFee
+		if refundAmount > 0 {
+			if err := s.walletService.UnfreezeDepositTx(ctx, tx, rental.UserID, refundAmount, order.OrderNo); err != nil {
+				return err
+			}
 		}
+	}
+
+	// 更新订单状态
+	updates := map[string]interface{}{
+		"status": models.RentalStatusCompleted,
+	}
+	if err := tx.Model(rental).Updates(updates).Error; err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+
+	// 更新Order状态
+	now := time.Now()
+	if err := tx.Model(&models.Order{}).Where("id = ?", rental.OrderID).
+		Updates(map[string]interface{}{
+			"status":       models.OrderStatusCompleted,
+			"completed_at": now,
+		}).Error; err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	order.Status = models.OrderStatusCompleted
+	order.CompletedAt = &now
+
+	return nil
+}
+
+// completePreauthBatchSize 每次最多重试提交的预授权数
+const completePreauthBatchSize = 100
+
+// errPreauthUnavailable 未配置押金预授权服务
+var errPreauthUnavailable = errors.ErrPaymentMethodError.WithMessage("暂不支持押金预授权")
+
+// usesPreauth 租借押金是否通过第三方支付预授权缴纳
+func usesPreauth(rental *models.Rental) bool {
+	return rental.DepositMethod == models.DepositMethodPreauth
+}
 
+// releasePreauthTx 在事务中将预授权押金标记为待全额解冻，非预授权租借不做处理
+// 事务提交后须调用 completePreauth 提交渠道
+func (s *RentalService) releasePreauthTx(ctx context.Context, tx *gorm.DB, rental *models.Rental) error {
+	if !usesPreauth(rental) || rental.Deposit <= 0 {
 		return nil
-	})
+	}
+	if s.preauth == nil {
+		return errPreauthUnavailable
+	}
+	return s.preauth.ReleaseTx(ctx, tx, rental.OrderID)
+}
+
+// completePreauth 事务提交后向渠道提交预授权押金的扣款或解冻
+// 渠道调用失败时支付单保持扣款中/解冻中，由 CompletePendingPreauth 重试，不影响已提交的业务结果
+func (s *RentalService) completePreauth(ctx context.Context, rental *models.Rental) {
+	if s.preauth == nil || rental == nil || !usesPreauth(rental) || rental.Deposit <= 0 {
+		return
+	}
+	_ = s.preauth.Complete(context.WithoutCancel(ctx), s.db, rental.OrderID)
+}
+
+// CompletePendingPreauth 重试提交扣款中/解冻中的押金预授权，返回成功提交的数量；未配置预授权服务时不做处理
+func (s *RentalService) CompletePendingPreauth(ctx context.Context) (int, error) {
+	if s.preauth == nil {
+		return 0, nil
+	}
+	return s.preauth.CompletePendingSettlements(ctx, s.db, completePreauthBatchSize)
+}
+
+// chargedOvertimeFee 结算时实际从押金扣除的超时费用，不超过押金
+func chargedOvertimeFee(rental *models.Rental) float64 {
+	overtimeFee := rental.OvertimeFee
+	if overtimeFee < 0 {
+		overtimeFee = 0
+	}
+	if overtimeFee > rental.Deposit {
+		overtimeFee = rental.Deposit
+	}
+	return overtimeFee
 }
 
 // CancelRental 取消租借
 func (s *RentalService) CancelRental(ctx context.Context, userID int64, rentalID int64) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		rental, err := s.rentalRepo.GetForUpdate(ctx, tx, rentalID)
+	var rental *models.Rental
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		rental, err = s.rentalRepo.GetForUpdate(ctx, tx, rentalID)
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
 				return errors.ErrRentalNotFound
@@ -472,15 +1039,94 @@ func (s *RentalService) CancelRental(ctx context.Context, userID int64, rentalID
 			return errors.ErrDatabaseError.WithError(err)
 		}
 
-		// 恢复设备可用槽位
-		if err := tx.Model(&models.Device{}).
-			Where("id = ?", rental.DeviceID).
-			UpdateColumn("available_slots", gorm.Expr("available_slots + 1")).Error; err != nil {
+		// 解冻预授权押金
+		if err := s.releasePreauthTx(ctx, tx, rental); err != nil {
+			return err
+		}
+
+		// 释放预占的格口，恢复设备可用槽位
+		if err := s.slotRepo.ReleaseTx(ctx, tx, rental); err != nil {
 			return errors.ErrDatabaseError.WithError(err)
 		}
 
-		return nil
+		// 返还下单时抵扣的积分
+		return s.returnRedeemedPointsTx(ctx, tx, rental)
 	})
+	if err != nil {
+		return err
+	}
+
+	s.completePreauth(ctx, rental)
+	s.publishRentalStatus(ctx, rentalID, userID, models.RentalStatusPending, models.RentalStatusCancelled)
+	return nil
+}
+
+// ReleaseExpiredOrderTx 超时未支付的租借订单被取消时，在同一事务中取消待支付租借，
+// 释放押金预授权及预占的设备槽位、返还抵扣积分，并记录系统设备日志；提交后向渠道解冻预授权并推送租借状态变更
+// 租借已被取消（如用户先行取消）时不重复释放
+func (s *RentalService) ReleaseExpiredOrderTx(ctx context.Context, tx *gorm.DB, order *models.Order) (func(), error) {
+	var rental models.Rental
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_id = ?", order.ID).First(&rental).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	res := tx.Model(&models.Rental{}).
+		Where("id = ? AND status = ?", rental.ID, models.RentalStatusPending).
+		Update("status", models.RentalStatusCancelled)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	if err := s.releasePreauthTx(ctx, tx, &rental); err != nil {
+		return nil, err
+	}
+
+	// 释放预占的格口，恢复设备可用槽位
+	if err := s.slotRepo.ReleaseTx(ctx, tx, &rental); err != nil {
+		return nil, err
+	}
+
+	// 返还下单时抵扣的积分
+	if s.pointsService != nil {
+		if err := s.pointsService.ReturnRedeemedPointsTx(ctx, tx, rental.UserID, order.OrderNo); err != nil {
+			return nil, err
+		}
+	}
+
+	content := fmt.Sprintf("租借 %d 超时未支付，自动取消并释放槽位", rental.ID)
+	operatorType := models.DeviceLogOperatorSystem
+	if err := tx.Create(&models.DeviceLog{
+		DeviceID:     rental.DeviceID,
+		Type:         models.DeviceLogTypeSlotRelease,
+		Content:      &content,
+		OperatorType: &operatorType,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return func() {
+		s.completePreauth(ctx, &rental)
+		s.publishRentalStatus(ctx, rental.ID, rental.UserID, models.RentalStatusPending, models.RentalStatusCancelled)
+	}, nil
+}
+
+// returnRedeemedPointsTx 租借取消时在事务中返还下单时抵扣的积分
+func (s *RentalService) returnRedeemedPointsTx(ctx context.Context, tx *gorm.DB, rental *models.Rental) error {
+	if s.pointsService == nil {
+		return nil
+	}
+	var order models.Order
+	if err := tx.Select("id, order_no").First(&order, rental.OrderID).Error; err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	return s.pointsService.ReturnRedeemedPointsTx(ctx, tx, rental.UserID, order.OrderNo)
 }
 
 // GetRental 获取租借详情
@@ -497,7 +1143,13 @@ func (s *RentalService) GetRental(ctx context.Context, userID int64, rentalID in
 		return nil, errors.ErrPermissionDenied
 	}
 
-	return s.toRentalInfo(rental, rental.Device, nil), nil
+	info := s.toRentalInfo(rental, rental.Device, nil)
+	if s.orderNotes != nil {
+		if info.LatestNote, err = s.orderNotes.GetLatestUserNote(ctx, rental.OrderID); err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
 }
 
 // ListRentals 获取用户租借列表
@@ -515,6 +1167,53 @@ func (s *RentalService) ListRentals(ctx context.Context, userID int64, offset, l
 	return result, total, nil
 }
 
+// ListRentalsFilter 用户租借列表筛选条件
+type ListRentalsFilter struct {
+	Status    string
+	StartDate *time.Time
+	EndDate   *time.Time
+}
+
+// ListRentalsByCursor 游标分页获取租借列表
+// cursor 为空时返回第一页；还有更多记录时返回下一页游标，否则返回空字符串
+func (s *RentalService) ListRentalsByCursor(ctx context.Context, userID int64, cursor string, limit int, filter *ListRentalsFilter) ([]*RentalInfo, string, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	after, err := utils.DecodeCursor(cursor)
+	if err != nil {
+		return nil, "", errors.ErrInvalidParams.WithMessage("无效的游标")
+	}
+
+	filters := map[string]interface{}{}
+	if filter != nil {
+		filters["status"] = filter.Status
+		filters["start_date"] = filter.StartDate
+		filters["end_date"] = filter.EndDate
+	}
+
+	// 多取一条用于判断是否还有下一页
+	rentals, err := s.rentalRepo.ListByUserCursor(ctx, userID, after, limit+1, filters)
+	if err != nil {
+		return nil, "", errors.ErrDatabaseError.WithError(err)
+	}
+
+	nextCursor := ""
+	if len(rentals) > limit {
+		rentals = rentals[:limit]
+		last := rentals[limit-1]
+		nextCursor = utils.EncodeCursor(&utils.Cursor{ID: last.ID, CreatedAt: last.CreatedAt})
+	}
+
+	result := make([]*RentalInfo, len(rentals))
+	for i, r := range rentals {
+		result[i] = s.toRentalInfo(r, r.Device, nil)
+	}
+
+	return result, nextCursor, nil
+}
+
 // toRentalInfo 转换为租借信息
 func (s *RentalService) toRentalInfo(rental *models.Rental, device *models.Device, _ *models.RentalPricing) *RentalInfo {
 	// 获取Order信息
@@ -528,9 +1227,13 @@ func (s *RentalService) toRentalInfo(rental *models.Rental, device *models.Devic
 		OrderID:          rental.OrderID,
 		Status:           rental.Status,
 		StatusName:       s.getStatusName(rental.Status),
+		SlotNo:           rental.SlotNo,
 		DurationHours:    rental.DurationHours,
+		OriginalFee:      rental.OriginalFee,
+		DiscountRate:     rental.DiscountRate,
 		RentalFee:        rental.RentalFee,
 		Deposit:          rental.Deposit,
+		DepositMethod:    rental.DepositMethod,
 		OvertimeRate:     rental.OvertimeRate,
 		OvertimeFee:      rental.OvertimeFee,
 		UnlockedAt:       rental.UnlockedAt,
@@ -559,6 +1262,56 @@ func (s *RentalService) toRentalInfo(rental *models.Rental, device *models.Devic
 	return info
 }
 
+// deviceSlotLockKey 设备槽位锁的缓存键
+func deviceSlotLockKey(deviceID int64) string {
+	return fmt.Sprintf("%s%d:slot_lock", cache.KeyPrefixDevice, deviceID)
+}
+
+// overtimeUnit 超时计费单位
+const overtimeUnit = time.Hour
+
+// resolveGracePeriod 获取租借适用的超时宽限期(分钟)
+// 优先使用定价上的配置，未配置时使用设备所在场地的默认值
+func (s *RentalService) resolveGracePeriod(ctx context.Context, pricing *models.RentalPricing, deviceID int64) (int, error) {
+	if pricing.GracePeriodMinutes != nil {
+		return *pricing.GracePeriodMinutes, nil
+	}
+
+	device, err := s.deviceRepo.GetByIDWithVenue(ctx, deviceID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, errors.ErrDeviceNotFound
+		}
+		return 0, errors.ErrDatabaseError.WithError(err)
+	}
+	if device.Venue == nil {
+		return 0, nil
+	}
+	return device.Venue.DefaultGracePeriodMinutes, nil
+}
+
+// calculateOvertimeFee 计算超时费用
+// 超时时长 = max(0, 归还时间 - 预计归还时间 - 宽限期)，按超时计费单位向上取整
+func calculateOvertimeFee(rental *models.Rental, returnedAt time.Time) float64 {
+	if rental.ExpectedReturnAt == nil {
+		return 0
+	}
+
+	deadline := rental.ExpectedReturnAt.Add(time.Duration(rental.GracePeriodMinutes) * time.Minute)
+	if !returnedAt.After(deadline) {
+		return 0
+	}
+
+	overtime := returnedAt.Sub(deadline)
+	overtimeUnits := int64((overtime + overtimeUnit - 1) / overtimeUnit)
+	overtimeFee := float64(overtimeUnits) * rental.OvertimeRate
+	// 超时费用不能超过押金
+	if overtimeFee > rental.Deposit {
+		overtimeFee = rental.Deposit
+	}
+	return overtimeFee
+}
+
 // getStatusName 获取状态名称
 func (s *RentalService) getStatusName(status string) string {
 	switch status {
@@ -0,0 +1,105 @@
+package rental
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// createCursorTestRental 创建指定创建时间的租借记录
+func createCursorTestRental(t *testing.T, db *gorm.DB, userID, deviceID, orderID int64, status string, createdAt time.Time) *models.Rental {
+	t.Helper()
+	rental := &models.Rental{
+		OrderID:       orderID,
+		UserID:        userID,
+		DeviceID:      deviceID,
+		DurationHours: 1,
+		RentalFee:     10,
+		Deposit:       50,
+		OvertimeRate:  1.5,
+		Status:        status,
+		CreatedAt:     createdAt,
+	}
+	require.NoError(t, db.Create(rental).Error)
+	return rental
+}
+
+func rentalIDsOf(rentals []*RentalInfo) []int64 {
+	ids := make([]int64, len(rentals))
+	for i, r := range rentals {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func TestRentalService_ListRentalsByCursor_StableWhenInserted(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+
+	user, device, _ := createTestData(t, svc.db)
+	base := time.Now().Add(-time.Hour)
+
+	var ids []int64
+	for i := 1; i <= 4; i++ {
+		r := createCursorTestRental(t, svc.db, user.ID, device.ID, int64(100+i), models.RentalStatusCompleted, base.Add(time.Duration(i)*time.Minute))
+		ids = append(ids, r.ID)
+	}
+
+	page1, next, err := svc.ListRentalsByCursor(ctx, user.ID, "", 3, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{ids[3], ids[2], ids[1]}, rentalIDsOf(page1))
+	require.NotEmpty(t, next)
+	assert.NotNil(t, page1[0].Device)
+
+	// 翻页期间产生新租借，下一页仍从上一页末尾继续
+	createCursorTestRental(t, svc.db, user.ID, device.ID, 200, models.RentalStatusPending, time.Now())
+
+	page2, next, err := svc.ListRentalsByCursor(ctx, user.ID, next, 3, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{ids[0]}, rentalIDsOf(page2))
+	assert.Empty(t, next)
+}
+
+func TestRentalService_ListRentalsByCursor_Filters(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+
+	user, device, _ := createTestData(t, svc.db)
+	base := time.Date(2025, 6, 1, 9, 0, 0, 0, time.Local)
+
+	r1 := createCursorTestRental(t, svc.db, user.ID, device.ID, 301, models.RentalStatusCompleted, base)
+	r2 := createCursorTestRental(t, svc.db, user.ID, device.ID, 302, models.RentalStatusCompleted, base.AddDate(0, 0, 1))
+	createCursorTestRental(t, svc.db, user.ID, device.ID, 303, models.RentalStatusCancelled, base.AddDate(0, 0, 2))
+	r4 := createCursorTestRental(t, svc.db, user.ID, device.ID, 304, models.RentalStatusCompleted, base.AddDate(0, 0, 3))
+	createCursorTestRental(t, svc.db, user.ID, device.ID, 305, models.RentalStatusCompleted, base.AddDate(0, 0, 5))
+
+	start := base
+	end := base.AddDate(0, 0, 3)
+	filter := &ListRentalsFilter{Status: models.RentalStatusCompleted, StartDate: &start, EndDate: &end}
+
+	var all []int64
+	cursor := ""
+	for {
+		rentals, next, err := svc.ListRentalsByCursor(ctx, user.ID, cursor, 2, filter)
+		require.NoError(t, err)
+		all = append(all, rentalIDsOf(rentals)...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	assert.Equal(t, []int64{r4.ID, r2.ID, r1.ID}, all)
+}
+
+func TestRentalService_ListRentalsByCursor_InvalidCursor(t *testing.T) {
+	svc := setupTestRentalService(t)
+
+	_, _, err := svc.ListRentalsByCursor(context.Background(), 1, "!!", 10, nil)
+	assert.Error(t, err)
+}
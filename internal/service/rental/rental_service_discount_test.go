@@ -0,0 +1,187 @@
+package rental
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	userService "github.com/dumeirei/smart-locker-backend/internal/service/user"
+)
+
+func TestApplyMemberDiscount(t *testing.T) {
+	tests := []struct {
+		name     string
+		price    float64
+		level    *models.MemberLevel
+		wantFee  float64
+		wantRate float64
+	}{
+		{"无会员等级不打折", 10.0, nil, 10.0, 1.0},
+		{"折扣率1.0不打折", 10.0, &models.MemberLevel{Discount: 1.0}, 10.0, 1.0},
+		{"八折", 10.0, &models.MemberLevel{Discount: 0.8}, 8.0, 0.8},
+		{"折后保留两位小数", 9.99, &models.MemberLevel{Discount: 0.85}, 8.49, 0.85},
+		{"非法折扣率不打折", 10.0, &models.MemberLevel{Discount: 0}, 10.0, 1.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee, rate := applyMemberDiscount(tt.price, tt.level)
+			assert.Equal(t, tt.wantFee, fee)
+			assert.Equal(t, tt.wantRate, rate)
+		})
+	}
+}
+
+func TestRentalService_CreateRental_MemberDiscount(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("折扣率1.0按原价计费", func(t *testing.T) {
+		svc := setupTestRentalService(t)
+		user, device, pricing := createTestData(t, svc.db)
+
+		info, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+		require.NoError(t, err)
+		assert.Equal(t, 10.0, info.OriginalFee)
+		assert.Equal(t, 1.0, info.DiscountRate)
+		assert.Equal(t, 10.0, info.RentalFee)
+		assert.Equal(t, 50.0, info.Deposit)
+
+		var order models.Order
+		require.NoError(t, svc.db.First(&order, info.OrderID).Error)
+		assert.Equal(t, 60.0, order.OriginalAmount)
+		assert.Equal(t, 0.0, order.DiscountAmount)
+		assert.Equal(t, 60.0, order.ActualAmount)
+	})
+
+	t.Run("八折会员按折后价计费且押金不打折", func(t *testing.T) {
+		svc := setupTestRentalService(t)
+		user, device, pricing := createTestData(t, svc.db)
+
+		gold := &models.MemberLevel{ID: 2, Name: "黄金会员", Level: 2, MinPoints: 1000, Discount: 0.8}
+		require.NoError(t, svc.db.Create(gold).Error)
+		require.NoError(t, svc.db.Model(user).Update("member_level_id", gold.ID).Error)
+
+		info, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+		require.NoError(t, err)
+		assert.Equal(t, 10.0, info.OriginalFee)
+		assert.Equal(t, 0.8, info.DiscountRate)
+		assert.Equal(t, 8.0, info.RentalFee)
+		assert.Equal(t, 50.0, info.Deposit)
+
+		var rental models.Rental
+		require.NoError(t, svc.db.First(&rental, info.ID).Error)
+		assert.Equal(t, 10.0, rental.OriginalFee)
+		assert.Equal(t, 0.8, rental.DiscountRate)
+		assert.Equal(t, 8.0, rental.RentalFee)
+
+		var order models.Order
+		require.NoError(t, svc.db.First(&order, info.OrderID).Error)
+		assert.Equal(t, 60.0, order.OriginalAmount)
+		assert.Equal(t, 2.0, order.DiscountAmount)
+		assert.Equal(t, 58.0, order.ActualAmount)
+		assert.Equal(t, 50.0, order.DepositAmount)
+	})
+
+	t.Run("会员等级不存在时不打折", func(t *testing.T) {
+		svc := setupTestRentalService(t)
+		user, device, pricing := createTestData(t, svc.db)
+
+		require.NoError(t, svc.db.Model(user).Update("member_level_id", 999).Error)
+
+		info, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, info.DiscountRate)
+		assert.Equal(t, 10.0, info.RentalFee)
+	})
+	t.Run("设置缓存仓储后从缓存读取会员等级和定价", func(t *testing.T) {
+		svc := setupTestRentalService(t)
+		user, device, pricing := createTestData(t, svc.db)
+
+		gold := &models.MemberLevel{ID: 2, Name: "黄金会员", Level: 2, MinPoints: 1000, Discount: 0.8}
+		require.NoError(t, svc.db.Create(gold).Error)
+		require.NoError(t, svc.db.Model(user).Update("member_level_id", gold.ID).Error)
+
+		client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+		svc.SetMemberLevelCache(repository.NewCachedMemberLevelRepository(repository.NewMemberLevelRepository(svc.db), client))
+		svc.SetPricingCache(repository.NewCachedRentalPricingRepository(repository.NewDeviceRepository(svc.db), client))
+
+		info, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+		require.NoError(t, err)
+		assert.Equal(t, 8.0, info.RentalFee)
+		require.NoError(t, svc.CancelRental(ctx, user.ID, info.ID))
+
+		// 绕过仓储修改的数据在缓存有效期内不生效
+		require.NoError(t, svc.db.Model(gold).Update("discount", 0.5).Error)
+		require.NoError(t, svc.db.Model(pricing).Update("price", 20).Error)
+		info, err = svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+		require.NoError(t, err)
+		assert.Equal(t, 10.0, info.OriginalFee)
+		assert.Equal(t, 0.8, info.DiscountRate)
+	})
+}
+
+func TestRentalService_CreateRental_UsePoints(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*testRentalService, *models.User, *models.Device, *models.RentalPricing) {
+		svc := setupTestRentalService(t)
+		require.NoError(t, svc.db.AutoMigrate(&models.UserPointsLog{}))
+		userRepo := repository.NewUserRepository(svc.db)
+		svc.SetPointsService(userService.NewPointsService(svc.db, userRepo, repository.NewMemberLevelRepository(svc.db)))
+		user, device, pricing := createTestData(t, svc.db)
+		require.NoError(t, svc.db.Model(user).Update("points", 500).Error)
+		return svc, user, device, pricing
+	}
+
+	t.Run("积分抵扣租金，押金不抵扣", func(t *testing.T) {
+		svc, user, device, pricing := setup(t)
+
+		info, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID, UsePoints: 300})
+		require.NoError(t, err)
+		assert.Equal(t, 7.0, info.RentalFee)
+		assert.Equal(t, 50.0, info.Deposit)
+
+		var order models.Order
+		require.NoError(t, svc.db.First(&order, info.OrderID).Error)
+		assert.Equal(t, 60.0, order.OriginalAmount)
+		assert.Equal(t, 3.0, order.DiscountAmount)
+		assert.Equal(t, 57.0, order.ActualAmount)
+
+		var refreshed models.User
+		require.NoError(t, svc.db.First(&refreshed, user.ID).Error)
+		assert.Equal(t, 200, refreshed.Points)
+
+		// 取消租借返还抵扣的积分
+		require.NoError(t, svc.CancelRental(ctx, user.ID, info.ID))
+		require.NoError(t, svc.db.First(&refreshed, user.ID).Error)
+		assert.Equal(t, 500, refreshed.Points)
+	})
+
+	t.Run("抵扣金额超过租金", func(t *testing.T) {
+		svc, user, device, pricing := setup(t)
+
+		_, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID, UsePoints: 1100})
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrInvalidParams.Code, appErr.Code)
+	})
+
+	t.Run("积分不足不创建订单", func(t *testing.T) {
+		svc, user, device, pricing := setup(t)
+		require.NoError(t, svc.db.Model(user).Update("points", 100).Error)
+
+		_, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID, UsePoints: 300})
+		assert.ErrorIs(t, err, appErrors.ErrPointsInsufficient)
+
+		var count int64
+		require.NoError(t, svc.db.Model(&models.Rental{}).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+}
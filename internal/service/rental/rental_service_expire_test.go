@@ -0,0 +1,149 @@
+package rental
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	eventService "github.com/dumeirei/smart-locker-backend/internal/service/event"
+	orderService "github.com/dumeirei/smart-locker-backend/internal/service/order"
+)
+
+// newRentalExpiryService 创建注册了租借处理器的订单超时取消服务，ttlMinutes 为租借订单的支付超时时间
+func newRentalExpiryService(t *testing.T, svc *testRentalService, ttlMinutes int) *orderService.OrderExpiryService {
+	t.Helper()
+	require.NoError(t, svc.db.AutoMigrate(&models.DeviceLog{}, &models.Coupon{}, &models.UserCoupon{}, &models.OrderNote{}, &models.GiftOrder{}))
+
+	expirySvc := orderService.NewOrderExpiryService(svc.db)
+	expirySvc.SetExpiredOrderHandler(models.OrderTypeRental, svc.RentalService)
+	expirySvc.SetOrderTypePaymentTTL(models.OrderTypeRental, ttlMinutes)
+	return expirySvc
+}
+
+// backdateRentalOrder 将租借订单的创建时间提前 age
+func backdateRentalOrder(t *testing.T, svc *testRentalService, orderID int64, age time.Duration) {
+	t.Helper()
+	require.NoError(t, svc.db.Model(&models.Order{}).Where("id = ?", orderID).
+		UpdateColumn("created_at", time.Now().Add(-age)).Error)
+}
+
+// recordingStatusPublisher 记录发布的状态事件
+type recordingStatusPublisher struct {
+	events []*eventService.StatusEvent
+}
+
+func (p *recordingStatusPublisher) Publish(ctx context.Context, event *eventService.StatusEvent) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func TestRentalService_ReleaseExpiredOrder(t *testing.T) {
+	svc := setupTestRentalService(t)
+	expirySvc := newRentalExpiryService(t, svc, 15)
+	ctx := context.Background()
+
+	user, device, pricing := createTestData(t, svc.db)
+
+	t.Run("超时未支付的租借被取消并释放槽位", func(t *testing.T) {
+		info, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+		require.NoError(t, err)
+		backdateRentalOrder(t, svc, info.OrderID, 20*time.Minute)
+
+		expired, err := expirySvc.ProcessExpiredOrders(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, expired)
+
+		var order models.Order
+		require.NoError(t, svc.db.First(&order, info.OrderID).Error)
+		assert.Equal(t, models.OrderStatusCancelled, order.Status)
+
+		var rental models.Rental
+		require.NoError(t, svc.db.First(&rental, info.ID).Error)
+		assert.Equal(t, models.RentalStatusCancelled, rental.Status)
+
+		var updatedDevice models.Device
+		require.NoError(t, svc.db.First(&updatedDevice, device.ID).Error)
+		assert.Equal(t, 1, updatedDevice.AvailableSlots)
+
+		var logs []models.DeviceLog
+		require.NoError(t, svc.db.Where("device_id = ?", device.ID).Find(&logs).Error)
+		require.Len(t, logs, 1)
+		assert.Equal(t, models.DeviceLogTypeSlotRelease, logs[0].Type)
+		require.NotNil(t, logs[0].OperatorType)
+		assert.Equal(t, models.DeviceLogOperatorSystem, *logs[0].OperatorType)
+
+		// 再次执行不会重复处理
+		expired, err = expirySvc.ProcessExpiredOrders(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, expired)
+	})
+
+	t.Run("未超时的租借保持待支付", func(t *testing.T) {
+		longTTL := newRentalExpiryService(t, svc, 60)
+
+		info, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+		require.NoError(t, err)
+		backdateRentalOrder(t, svc, info.OrderID, 30*time.Minute)
+
+		expired, err := longTTL.ProcessExpiredOrders(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, expired)
+
+		var rental models.Rental
+		require.NoError(t, svc.db.First(&rental, info.ID).Error)
+		assert.Equal(t, models.RentalStatusPending, rental.Status)
+		require.NoError(t, svc.CancelRental(ctx, user.ID, info.ID))
+		require.NoError(t, svc.db.Model(&models.Order{}).Where("id = ?", info.OrderID).
+			Update("status", models.OrderStatusCancelled).Error)
+	})
+
+	t.Run("已支付的订单不取消", func(t *testing.T) {
+		info, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+		require.NoError(t, err)
+		backdateRentalOrder(t, svc, info.OrderID, 20*time.Minute)
+		require.NoError(t, svc.db.Model(&models.Order{}).Where("id = ?", info.OrderID).
+			Updates(map[string]interface{}{"status": models.OrderStatusPaid, "paid_at": time.Now()}).Error)
+
+		expired, err := expirySvc.ProcessExpiredOrders(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, expired)
+
+		var rental models.Rental
+		require.NoError(t, svc.db.First(&rental, info.ID).Error)
+		assert.Equal(t, models.RentalStatusPending, rental.Status)
+	})
+}
+
+func TestRentalService_ReleaseExpiredOrder_PublishesStatus(t *testing.T) {
+	svc := setupTestRentalService(t)
+	publisher := &recordingStatusPublisher{}
+	svc.SetStatusEventPublisher(publisher)
+	expirySvc := newRentalExpiryService(t, svc, 15)
+	ctx := context.Background()
+
+	user, device, pricing := createTestData(t, svc.db)
+	info, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.NoError(t, err)
+	backdateRentalOrder(t, svc, info.OrderID, 20*time.Minute)
+
+	expired, err := expirySvc.ProcessExpiredOrders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	require.Len(t, publisher.events, 1)
+	event := publisher.events[0]
+	assert.Equal(t, eventService.SubjectRental, event.Subject)
+	assert.Equal(t, info.ID, event.ID)
+	assert.Equal(t, user.ID, event.UserID)
+	assert.Equal(t, models.RentalStatusPending, event.OldStatus)
+	assert.Equal(t, models.RentalStatusCancelled, event.NewStatus)
+
+	// 重复执行不会重复推送
+	_, err = expirySvc.ProcessExpiredOrders(ctx)
+	require.NoError(t, err)
+	assert.Len(t, publisher.events, 1)
+}
@@ -0,0 +1,365 @@
+package rental
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// startTestRental 创建并开始一个使用中的租借
+func startTestRental(t *testing.T, svc *testRentalService, userID int64, device *models.Device, pricing *models.RentalPricing) *models.Rental {
+	ctx := context.Background()
+	rentalInfo, err := svc.CreateRental(ctx, userID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.NoError(t, err)
+	require.NoError(t, svc.PayRental(ctx, userID, rentalInfo.ID, ""))
+	require.NoError(t, svc.StartRental(ctx, userID, rentalInfo.ID))
+
+	var rental models.Rental
+	require.NoError(t, svc.db.First(&rental, rentalInfo.ID).Error)
+	return &rental
+}
+
+// createExtendPricing 创建续租定价
+func createExtendPricing(t *testing.T, svc *testRentalService, venueID int64, hours int, price float64) *models.RentalPricing {
+	pricing := &models.RentalPricing{
+		VenueID:       &venueID,
+		DurationHours: hours,
+		Price:         price,
+		Deposit:       50.0,
+		OvertimeRate:  1.5,
+		IsActive:      true,
+	}
+	require.NoError(t, svc.db.Create(pricing).Error)
+	return pricing
+}
+
+func TestRentalService_ExtendRental(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+
+	user, device, pricing := createTestData(t, svc.db)
+	extendPricing := createExtendPricing(t, svc, device.VenueID, 2, 18.0)
+	rental := startTestRental(t, svc, user.ID, device, pricing)
+
+	info, err := svc.ExtendRentalWithPricing(ctx, user.ID, rental.ID, extendPricing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, info.DurationHours)
+	assert.Equal(t, 28.0, info.RentalFee)
+
+	var updated models.Rental
+	require.NoError(t, svc.db.First(&updated, rental.ID).Error)
+	assert.WithinDuration(t, rental.ExpectedReturnAt.Add(2*time.Hour), *updated.ExpectedReturnAt, time.Second)
+
+	// 续租费用从余额扣除：200 - 10(租金) - 50(押金) - 18(续租)
+	var wallet models.UserWallet
+	require.NoError(t, svc.db.Where("user_id = ?", user.ID).First(&wallet).Error)
+	assert.Equal(t, 122.0, wallet.Balance)
+
+	var order models.Order
+	require.NoError(t, svc.db.First(&order, rental.OrderID).Error)
+	var consume models.WalletTransaction
+	require.NoError(t, svc.db.Where("user_id = ? AND order_no = ? AND type = ?", user.ID, order.OrderNo, models.WalletTxTypeConsume).
+		Order("id DESC").First(&consume).Error)
+	assert.Equal(t, -18.0, consume.Amount)
+	assert.Equal(t, 122.0, consume.BalanceAfter)
+
+	// 续租记录保存为订单项
+	var items []models.OrderItem
+	require.NoError(t, svc.db.Where("order_id = ?", rental.OrderID).Find(&items).Error)
+	require.Len(t, items, 1)
+	assert.Equal(t, 18.0, items[0].Subtotal)
+
+	require.NoError(t, svc.db.First(&order, rental.OrderID).Error)
+	assert.Equal(t, 78.0, order.ActualAmount) // 租金 + 押金 + 续租
+}
+
+func TestRentalService_ExtendRental_Errors(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+
+	user, device, pricing := createTestData(t, svc.db)
+	extendPricing := createExtendPricing(t, svc, device.VenueID, 1, 10.0)
+	rental := startTestRental(t, svc, user.ID, device, pricing)
+
+	t.Run("余额不足", func(t *testing.T) {
+		svc.db.Model(&models.UserWallet{}).Where("user_id = ?", user.ID).Update("balance", 5.0)
+		defer svc.db.Model(&models.UserWallet{}).Where("user_id = ?", user.ID).Update("balance", 140.0)
+
+		_, err := svc.ExtendRentalWithPricing(ctx, user.ID, rental.ID, extendPricing.ID)
+		assert.Equal(t, appErrors.ErrBalanceInsufficient, err)
+
+		var unchanged models.Rental
+		require.NoError(t, svc.db.First(&unchanged, rental.ID).Error)
+		assert.WithinDuration(t, *rental.ExpectedReturnAt, *unchanged.ExpectedReturnAt, time.Second)
+
+		var itemCount int64
+		svc.db.Model(&models.OrderItem{}).Where("order_id = ?", rental.OrderID).Count(&itemCount)
+		assert.Equal(t, int64(0), itemCount)
+	})
+
+	t.Run("非本人租借", func(t *testing.T) {
+		_, err := svc.ExtendRentalWithPricing(ctx, user.ID+100, rental.ID, extendPricing.ID)
+		assert.Equal(t, appErrors.ErrPermissionDenied, err)
+	})
+
+	t.Run("定价不存在", func(t *testing.T) {
+		_, err := svc.ExtendRentalWithPricing(ctx, user.ID, rental.ID, 99999)
+		assert.Equal(t, appErrors.ErrPricingNotFound, err)
+	})
+
+	t.Run("已超时不允许续租", func(t *testing.T) {
+		svc.db.Model(&models.Rental{}).Where("id = ?", rental.ID).Update("expected_return_at", time.Now().Add(-time.Minute))
+
+		_, err := svc.ExtendRentalWithPricing(ctx, user.ID, rental.ID, extendPricing.ID)
+		assert.Equal(t, appErrors.ErrRentalOverdue, err)
+	})
+
+	t.Run("非使用中状态不允许续租", func(t *testing.T) {
+		svc.db.Model(&models.Rental{}).Where("id = ?", rental.ID).Update("status", models.RentalStatusReturned)
+
+		_, err := svc.ExtendRentalWithPricing(ctx, user.ID, rental.ID, extendPricing.ID)
+		assert.Equal(t, appErrors.ErrRentalStatusError, err)
+	})
+}
+
+func TestRentalService_ReturnRental_UsesExtendedDeadline(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+
+	user, device, pricing := createTestData(t, svc.db)
+	extendPricing := createExtendPricing(t, svc, device.VenueID, 1, 10.0)
+	rental := startTestRental(t, svc, user.ID, device, pricing)
+
+	// 原定1分钟后到期，续租1小时后当前归还不应计超时费
+	svc.db.Model(&models.Rental{}).Where("id = ?", rental.ID).Update("expected_return_at", time.Now().Add(time.Minute))
+	_, err := svc.ExtendRentalWithPricing(ctx, user.ID, rental.ID, extendPricing.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.ReturnRental(ctx, user.ID, rental.ID))
+
+	var returned models.Rental
+	require.NoError(t, svc.db.First(&returned, rental.ID).Error)
+	assert.Equal(t, float64(0), returned.OvertimeFee)
+	assert.True(t, returned.ExpectedReturnAt.After(time.Now().Add(time.Hour)))
+}
+
+func TestRentalService_ExtendRental_StaleRowRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		extend func(svc *testRentalService, userID, rentalID, pricingID int64) error
+	}{
+		{"按续租套餐", func(svc *testRentalService, userID, rentalID, pricingID int64) error {
+			_, err := svc.ExtendRentalWithPricing(context.Background(), userID, rentalID, pricingID)
+			return err
+		}},
+		{"按小时", func(svc *testRentalService, userID, rentalID, _ int64) error {
+			_, err := svc.ExtendRental(context.Background(), userID, rentalID, 1)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := setupTestRentalService(t)
+
+			user, device, pricing := createTestData(t, svc.db)
+			extendPricing := createExtendPricing(t, svc, device.VenueID, 1, 10.0)
+			rental := startTestRental(t, svc, user.ID, device, pricing)
+
+			// 读取租借后模拟另一笔续租顺延了预计归还时间（SQLite 无行锁，在同一事务内改写以模拟读到旧数据）
+			concurrentReturn := rental.ExpectedReturnAt.Add(time.Hour)
+			var fired bool
+			require.NoError(t, svc.db.Callback().Query().After("gorm:query").Register("test:concurrent_extend", func(tx *gorm.DB) {
+				if fired || tx.Statement.Table != "rentals" {
+					return
+				}
+				fired = true
+				require.NoError(t, tx.Session(&gorm.Session{NewDB: true}).
+					Exec("UPDATE rentals SET expected_return_at = ? WHERE id = ?", concurrentReturn, rental.ID).Error)
+			}))
+
+			err := tt.extend(svc, user.ID, rental.ID, extendPricing.ID)
+			require.True(t, fired)
+			var appErr *appErrors.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, appErrors.ErrRentalStatusError.Code, appErr.Code)
+
+			// 条件更新未命中，整个续租回滚：不扣费、不按旧数据顺延、不新增订单项
+			var updated models.Rental
+			require.NoError(t, svc.db.First(&updated, rental.ID).Error)
+			assert.WithinDuration(t, *rental.ExpectedReturnAt, *updated.ExpectedReturnAt, time.Second)
+			assert.Equal(t, rental.DurationHours, updated.DurationHours)
+
+			var wallet models.UserWallet
+			require.NoError(t, svc.db.Where("user_id = ?", user.ID).First(&wallet).Error)
+			assert.Equal(t, 140.0, wallet.Balance)
+
+			var itemCount int64
+			svc.db.Model(&models.OrderItem{}).Where("order_id = ?", rental.OrderID).Count(&itemCount)
+			assert.Equal(t, int64(0), itemCount)
+		})
+	}
+}
+
+func TestRentalService_ExtendRental_ByHours(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+
+	user, device, pricing := createTestData(t, svc.db)
+	rental := startTestRental(t, svc, user.ID, device, pricing)
+	require.NotNil(t, rental.PricingID)
+	assert.Equal(t, pricing.ID, *rental.PricingID)
+
+	info, err := svc.ExtendRental(ctx, user.ID, rental.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, info.DurationHours)
+	assert.Equal(t, 30.0, info.RentalFee)
+
+	var updated models.Rental
+	require.NoError(t, svc.db.First(&updated, rental.ID).Error)
+	assert.WithinDuration(t, rental.ExpectedReturnAt.Add(2*time.Hour), *updated.ExpectedReturnAt, time.Second)
+	assert.Equal(t, 50.0, updated.Deposit)
+
+	// 按原定价小时单价扣费且不再收取押金：200 - 10(租金) - 50(押金) - 20(续租2小时)
+	var wallet models.UserWallet
+	require.NoError(t, svc.db.Where("user_id = ?", user.ID).First(&wallet).Error)
+	assert.Equal(t, 120.0, wallet.Balance)
+
+	var order models.Order
+	require.NoError(t, svc.db.First(&order, rental.OrderID).Error)
+	assert.Equal(t, 80.0, order.ActualAmount)
+
+	var txs []models.WalletTransaction
+	require.NoError(t, svc.db.Where("user_id = ? AND order_no = ?", user.ID, order.OrderNo).
+		Order("id ASC").Find(&txs).Error)
+	require.NotEmpty(t, txs)
+	last := txs[len(txs)-1]
+	assert.Equal(t, models.WalletTxTypeConsume, last.Type)
+	assert.Equal(t, -20.0, last.Amount)
+	assert.Equal(t, 120.0, last.BalanceAfter)
+
+	var items []models.OrderItem
+	require.NoError(t, svc.db.Where("order_id = ?", rental.OrderID).Find(&items).Error)
+	require.Len(t, items, 1)
+	assert.Equal(t, "续租2小时", items[0].ProductName)
+	assert.Equal(t, 20.0, items[0].Subtotal)
+}
+
+func TestRentalService_ExtendRental_ByHoursKeepsMemberDiscount(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+
+	user, device, _ := createTestData(t, svc.db)
+	pricing := createExtendPricing(t, svc, device.VenueID, 4, 36.0)
+	rental := startTestRental(t, svc, user.ID, device, pricing)
+
+	// 租借时享受 8 折，续租沿用租借时的折扣率：36 / 4 * 3 * 0.8
+	require.NoError(t, svc.db.Model(rental).Update("discount_rate", 0.8).Error)
+
+	info, err := svc.ExtendRental(ctx, user.ID, rental.ID, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 7, info.DurationHours)
+
+	var items []models.OrderItem
+	require.NoError(t, svc.db.Where("order_id = ?", rental.OrderID).Find(&items).Error)
+	require.Len(t, items, 1)
+	assert.Equal(t, 21.6, items[0].Subtotal)
+}
+
+func TestRentalService_ExtendRental_ByHoursUsesSnapshotPrice(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+
+	user, device, pricing := createTestData(t, svc.db)
+	rental := startTestRental(t, svc, user.ID, device, pricing)
+	assert.Equal(t, 10.0, rental.HourlyPrice)
+
+	// 下单后定价调价，续租仍按下单时的小时单价
+	require.NoError(t, svc.db.Model(pricing).Update("price", 30.0).Error)
+
+	_, err := svc.ExtendRental(ctx, user.ID, rental.ID, 2)
+	require.NoError(t, err)
+
+	var items []models.OrderItem
+	require.NoError(t, svc.db.Where("order_id = ?", rental.OrderID).Order("id ASC").Find(&items).Error)
+	require.Len(t, items, 1)
+	assert.Equal(t, 20.0, items[0].Subtotal)
+
+	// 续租后时长已累加，单价保持不变
+	_, err = svc.ExtendRental(ctx, user.ID, rental.ID, 1)
+	require.NoError(t, err)
+	require.NoError(t, svc.db.Where("order_id = ?", rental.OrderID).Order("id ASC").Find(&items).Error)
+	require.Len(t, items, 2)
+	assert.Equal(t, 10.0, items[1].Subtotal)
+
+	t.Run("历史租借未记录小时单价按所选定价计算", func(t *testing.T) {
+		require.NoError(t, svc.db.Model(&models.Rental{}).Where("id = ?", rental.ID).Update("hourly_price", 0).Error)
+
+		_, err := svc.ExtendRental(ctx, user.ID, rental.ID, 1)
+		require.NoError(t, err)
+		require.NoError(t, svc.db.Where("order_id = ?", rental.OrderID).Order("id ASC").Find(&items).Error)
+		require.Len(t, items, 3)
+		assert.Equal(t, 30.0, items[2].Subtotal)
+	})
+}
+
+func TestRentalService_ExtendRental_ByHoursErrors(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+
+	user, device, pricing := createTestData(t, svc.db)
+	rental := startTestRental(t, svc, user.ID, device, pricing)
+
+	t.Run("续租时长无效", func(t *testing.T) {
+		for _, hours := range []int{0, -1, maxExtendHours + 1} {
+			_, err := svc.ExtendRental(ctx, user.ID, rental.ID, hours)
+			appErr, ok := err.(*appErrors.AppError)
+			require.True(t, ok)
+			assert.Equal(t, appErrors.ErrInvalidParams.Code, appErr.Code)
+		}
+	})
+
+	t.Run("余额不足", func(t *testing.T) {
+		svc.db.Model(&models.UserWallet{}).Where("user_id = ?", user.ID).Update("balance", 5.0)
+		defer svc.db.Model(&models.UserWallet{}).Where("user_id = ?", user.ID).Update("balance", 140.0)
+
+		_, err := svc.ExtendRental(ctx, user.ID, rental.ID, 1)
+		assert.Equal(t, appErrors.ErrBalanceInsufficient, err)
+
+		var unchanged models.Rental
+		require.NoError(t, svc.db.First(&unchanged, rental.ID).Error)
+		assert.Equal(t, rental.DurationHours, unchanged.DurationHours)
+		assert.WithinDuration(t, *rental.ExpectedReturnAt, *unchanged.ExpectedReturnAt, time.Second)
+
+		var txCount int64
+		svc.db.Model(&models.WalletTransaction{}).Where("user_id = ? AND amount = ?", user.ID, -10.0).Count(&txCount)
+		assert.Equal(t, int64(1), txCount) // 仅有支付租金时的消费记录
+	})
+
+	t.Run("历史租借未记录定价", func(t *testing.T) {
+		svc.db.Model(&models.Rental{}).Where("id = ?", rental.ID).Updates(map[string]interface{}{"pricing_id": nil, "hourly_price": 0})
+		defer svc.db.Model(&models.Rental{}).Where("id = ?", rental.ID).Updates(map[string]interface{}{"pricing_id": pricing.ID, "hourly_price": rental.HourlyPrice})
+
+		_, err := svc.ExtendRental(ctx, user.ID, rental.ID, 1)
+		assert.Equal(t, appErrors.ErrPricingNotFound, err)
+	})
+
+	t.Run("非本人租借", func(t *testing.T) {
+		_, err := svc.ExtendRental(ctx, user.ID+100, rental.ID, 1)
+		assert.Equal(t, appErrors.ErrPermissionDenied, err)
+	})
+
+	t.Run("非使用中状态不允许续租", func(t *testing.T) {
+		svc.db.Model(&models.Rental{}).Where("id = ?", rental.ID).Update("status", models.RentalStatusPaid)
+
+		_, err := svc.ExtendRental(ctx, user.ID, rental.ID, 1)
+		assert.Equal(t, appErrors.ErrRentalStatusError, err)
+	})
+}
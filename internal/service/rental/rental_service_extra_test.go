@@ -11,6 +11,8 @@ import (
 
 	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	orderService "github.com/dumeirei/smart-locker-backend/internal/service/order"
 )
 
 func TestRentalService_CancelRental(t *testing.T) {
@@ -65,6 +67,37 @@ func TestRentalService_CancelRental_PermissionDenied(t *testing.T) {
 	assert.Equal(t, appErrors.ErrPermissionDenied.Code, appErr.Code)
 }
 
+func TestRentalService_GetRental_LatestNote(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	require.NoError(t, svc.db.AutoMigrate(&models.OrderNote{}))
+	noteSvc := orderService.NewOrderNoteService(svc.db, repository.NewOrderNoteRepository(svc.db))
+	svc.SetOrderNoteService(noteSvc)
+	user, device, pricing := createTestData(t, svc.db)
+
+	created, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{
+		DeviceID:  device.ID,
+		PricingID: pricing.ID,
+	})
+	require.NoError(t, err)
+
+	info, err := svc.GetRental(ctx, user.ID, created.ID)
+	require.NoError(t, err)
+	assert.Nil(t, info.LatestNote)
+
+	_, err = noteSvc.AddUserNote(ctx, user.ID, created.OrderID, "明天来取")
+	require.NoError(t, err)
+	// 仅管理员可见的备注不返回给用户
+	_, err = noteSvc.AddAdminNote(ctx, 1, created.OrderID, &orderService.AddAdminOrderNoteRequest{Content: "用户来电催单"})
+	require.NoError(t, err)
+
+	info, err = svc.GetRental(ctx, user.ID, created.ID)
+	require.NoError(t, err)
+	require.NotNil(t, info.LatestNote)
+	assert.Equal(t, "明天来取", info.LatestNote.Content)
+	assert.Equal(t, models.OrderNoteAuthorUser, info.LatestNote.AuthorType)
+}
+
 func TestRentalService_GetRental_And_ListRentals(t *testing.T) {
 	svc := setupTestRentalService(t)
 	ctx := context.Background()
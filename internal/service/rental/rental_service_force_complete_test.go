@@ -0,0 +1,187 @@
+// Package rental 管理员强制完成租借单元测试
+package rental
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// startOverdueRental 创建并开始租借，预计归还时间设为 2.5 小时前
+func startOverdueRental(t *testing.T, svc *testRentalService, userID int64, device *models.Device, pricing *models.RentalPricing) *RentalInfo {
+	t.Helper()
+	ctx := context.Background()
+
+	rentalInfo, err := svc.CreateRental(ctx, userID, &CreateRentalRequest{
+		DeviceID:  device.ID,
+		PricingID: pricing.ID,
+	})
+	require.NoError(t, err)
+	require.NoError(t, svc.PayRental(ctx, userID, rentalInfo.ID, ""))
+	require.NoError(t, svc.StartRental(ctx, userID, rentalInfo.ID))
+
+	expectedReturnAt := time.Now().Add(-150 * time.Minute)
+	require.NoError(t, svc.db.Model(&models.Rental{}).Where("id = ?", rentalInfo.ID).
+		Update("expected_return_at", expectedReturnAt).Error)
+	return rentalInfo
+}
+
+func TestRentalService_ForceCompleteRental(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("未归还租借按当前时间结算超时费", func(t *testing.T) {
+		svc := setupTestRentalService(t)
+		require.NoError(t, svc.db.AutoMigrate(&models.OperationLog{}))
+		user, device, pricing := createTestData(t, svc.db)
+		rentalInfo := startOverdueRental(t, svc, user.ID, device, pricing)
+
+		require.NoError(t, svc.ForceCompleteRental(ctx, rentalInfo.ID, 9, false, "用户弃用"))
+
+		var rental models.Rental
+		require.NoError(t, svc.db.First(&rental, rentalInfo.ID).Error)
+		assert.Equal(t, models.RentalStatusCompleted, rental.Status)
+		assert.NotNil(t, rental.ReturnedAt)
+		assert.Equal(t, 4.5, rental.OvertimeFee) // 超时 2.5 小时按 3 小时计 × 1.5
+
+		var order models.Order
+		require.NoError(t, svc.db.First(&order, rentalInfo.OrderID).Error)
+		assert.Equal(t, models.OrderStatusCompleted, order.Status)
+
+		// 超时费从押金扣除，其余押金退还
+		var wallet models.UserWallet
+		require.NoError(t, svc.db.Where("user_id = ?", user.ID).First(&wallet).Error)
+		assert.Equal(t, 200.0-pricing.Price-4.5, wallet.Balance)
+		assert.Equal(t, 0.0, wallet.FrozenBalance)
+
+		// 设备槽位释放
+		var updatedDevice models.Device
+		require.NoError(t, svc.db.First(&updatedDevice, device.ID).Error)
+		assert.Equal(t, 1, updatedDevice.AvailableSlots)
+		assert.Equal(t, int8(models.DeviceRentalFree), updatedDevice.RentalStatus)
+		assert.Nil(t, updatedDevice.CurrentRentalID)
+
+		var log models.OperationLog
+		require.NoError(t, svc.db.Where("target_type = ? AND target_id = ?", "rental", rentalInfo.ID).First(&log).Error)
+		assert.Equal(t, int64(9), log.AdminID)
+		assert.Equal(t, "rental", log.Module)
+		assert.Equal(t, "force_complete", log.Action)
+		assert.Equal(t, models.RentalStatusInUse, log.BeforeData["status"])
+		assert.Equal(t, "用户弃用", log.AfterData["admin_note"])
+		assert.Equal(t, false, log.AfterData["waive_overtime_fee"])
+	})
+
+	t.Run("读取后已被并发归还时拒绝重复结算", func(t *testing.T) {
+		svc := setupTestRentalService(t)
+		require.NoError(t, svc.db.AutoMigrate(&models.OperationLog{}))
+		user, device, pricing := createTestData(t, svc.db)
+		rentalInfo := startOverdueRental(t, svc, user.ID, device, pricing)
+
+		// SQLite 无行锁，在同一事务内改写以模拟读到旧数据
+		var fired bool
+		require.NoError(t, svc.db.Callback().Query().After("gorm:query").Register("test:concurrent_return", func(tx *gorm.DB) {
+			if fired || tx.Statement.Table != "rentals" {
+				return
+			}
+			fired = true
+			require.NoError(t, tx.Session(&gorm.Session{NewDB: true}).
+				Exec("UPDATE rentals SET status = ? WHERE id = ?", models.RentalStatusReturned, rentalInfo.ID).Error)
+		}))
+
+		err := svc.ForceCompleteRental(ctx, rentalInfo.ID, 9, false, "用户弃用")
+		require.True(t, fired)
+		var appErr *appErrors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, appErrors.ErrRentalStatusError.Code, appErr.Code)
+
+		// 整个强制完成回滚：不结算、不释放格口、不记录日志
+		var rental models.Rental
+		require.NoError(t, svc.db.First(&rental, rentalInfo.ID).Error)
+		assert.Equal(t, models.RentalStatusInUse, rental.Status)
+		var updatedDevice models.Device
+		require.NoError(t, svc.db.First(&updatedDevice, device.ID).Error)
+		assert.Equal(t, 0, updatedDevice.AvailableSlots)
+		var logCount int64
+		svc.db.Model(&models.OperationLog{}).Count(&logCount)
+		assert.Zero(t, logCount)
+	})
+
+	t.Run("免除超时费时押金全额退还", func(t *testing.T) {
+		svc := setupTestRentalService(t)
+		require.NoError(t, svc.db.AutoMigrate(&models.OperationLog{}))
+		user, device, pricing := createTestData(t, svc.db)
+		rentalInfo := startOverdueRental(t, svc, user.ID, device, pricing)
+
+		require.NoError(t, svc.ForceCompleteRental(ctx, rentalInfo.ID, 9, true, "customer lost item"))
+
+		var rental models.Rental
+		require.NoError(t, svc.db.First(&rental, rentalInfo.ID).Error)
+		assert.Equal(t, models.RentalStatusCompleted, rental.Status)
+		assert.Equal(t, 0.0, rental.OvertimeFee)
+
+		var wallet models.UserWallet
+		require.NoError(t, svc.db.Where("user_id = ?", user.ID).First(&wallet).Error)
+		assert.Equal(t, 200.0-pricing.Price, wallet.Balance)
+		assert.Equal(t, 0.0, wallet.FrozenBalance)
+
+		var log models.OperationLog
+		require.NoError(t, svc.db.Where("target_id = ? AND action = ?", rentalInfo.ID, "force_complete").First(&log).Error)
+		assert.Equal(t, true, log.AfterData["waive_overtime_fee"])
+		assert.Equal(t, 0.0, log.AfterData["overtime_fee"])
+	})
+
+	t.Run("已归还租借保留已计算的超时费", func(t *testing.T) {
+		svc := setupTestRentalService(t)
+		require.NoError(t, svc.db.AutoMigrate(&models.OperationLog{}))
+		user, device, pricing := createTestData(t, svc.db)
+		rentalInfo := startOverdueRental(t, svc, user.ID, device, pricing)
+		require.NoError(t, svc.ReturnRental(ctx, user.ID, rentalInfo.ID))
+
+		var returned models.Rental
+		require.NoError(t, svc.db.First(&returned, rentalInfo.ID).Error)
+
+		require.NoError(t, svc.ForceCompleteRental(ctx, rentalInfo.ID, 9, false, ""))
+
+		var rental models.Rental
+		require.NoError(t, svc.db.First(&rental, rentalInfo.ID).Error)
+		assert.Equal(t, models.RentalStatusCompleted, rental.Status)
+		assert.Equal(t, returned.OvertimeFee, rental.OvertimeFee)
+
+		// 槽位已在归还时释放，不重复释放
+		var updatedDevice models.Device
+		require.NoError(t, svc.db.First(&updatedDevice, device.ID).Error)
+		assert.Equal(t, 1, updatedDevice.AvailableSlots)
+	})
+
+	t.Run("待支付租借不能强制完成", func(t *testing.T) {
+		svc := setupTestRentalService(t)
+		require.NoError(t, svc.db.AutoMigrate(&models.OperationLog{}))
+		user, device, pricing := createTestData(t, svc.db)
+		rentalInfo, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{
+			DeviceID:  device.ID,
+			PricingID: pricing.ID,
+		})
+		require.NoError(t, err)
+
+		err = svc.ForceCompleteRental(ctx, rentalInfo.ID, 9, true, "")
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok, "expected AppError, got %v", err)
+		assert.Equal(t, appErrors.ErrRentalStatusError.Code, appErr.Code)
+
+		var count int64
+		require.NoError(t, svc.db.Model(&models.OperationLog{}).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+
+	t.Run("租借不存在", func(t *testing.T) {
+		svc := setupTestRentalService(t)
+		err := svc.ForceCompleteRental(ctx, 99999, 9, true, "")
+		assert.Equal(t, appErrors.ErrRentalNotFound, err)
+	})
+}
@@ -0,0 +1,128 @@
+package rental
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	paymentService "github.com/dumeirei/smart-locker-backend/internal/service/payment"
+)
+
+// setupIdempotentRentalService 创建启用幂等键的租借服务
+func setupIdempotentRentalService(t *testing.T) *testRentalService {
+	svc := setupTestRentalService(t)
+	require.NoError(t, svc.db.AutoMigrate(&models.IdempotencyKey{}))
+
+	// 内存 SQLite 每个连接是独立的数据库，并发测试需要共享单连接
+	sqlDB, err := svc.db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	svc.idempotency = paymentService.NewIdempotencyService(repository.NewIdempotencyRepository(svc.db))
+	return svc
+}
+
+func TestRentalService_PayRental_IdempotentReplay(t *testing.T) {
+	svc := setupIdempotentRentalService(t)
+	ctx := context.Background()
+	user, device, pricing := createTestData(t, svc.db)
+
+	rentalInfo, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.PayRental(ctx, user.ID, rentalInfo.ID, "rental-pay-1"))
+	// 重放同一幂等键：直接返回成功，不再校验状态或扣款
+	require.NoError(t, svc.PayRental(ctx, user.ID, rentalInfo.ID, "rental-pay-1"))
+
+	var wallet models.UserWallet
+	require.NoError(t, svc.db.Where("user_id = ?", user.ID).First(&wallet).Error)
+	assert.Equal(t, 200.0-(pricing.Price+pricing.Deposit), wallet.Balance)
+
+	var txCount int64
+	svc.db.Model(&models.WalletTransaction{}).Where("user_id = ?", user.ID).Count(&txCount)
+	assert.Equal(t, int64(2), txCount) // 押金冻结 + 租金消费
+
+	// 不带幂等键的重复支付仍按状态校验拒绝
+	err = svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
+	require.Error(t, err)
+	assert.Equal(t, appErrors.ErrRentalStatusError.Code, err.(*appErrors.AppError).Code)
+}
+
+func TestRentalService_PayRental_IdempotencyConflict(t *testing.T) {
+	svc := setupIdempotentRentalService(t)
+	ctx := context.Background()
+	user, device, pricing := createTestData(t, svc.db)
+
+	rentalInfo, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.NoError(t, err)
+	require.NoError(t, svc.PayRental(ctx, user.ID, rentalInfo.ID, "rental-pay-1"))
+
+	err = svc.PayRental(ctx, user.ID, rentalInfo.ID+1, "rental-pay-1")
+	require.Error(t, err)
+	assert.Equal(t, appErrors.ErrIdempotencyConflict.Code, err.(*appErrors.AppError).Code)
+}
+
+func TestRentalService_PayRental_FailedAttemptReleasesKey(t *testing.T) {
+	svc := setupIdempotentRentalService(t)
+	ctx := context.Background()
+	user, device, pricing := createTestData(t, svc.db)
+
+	rentalInfo, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.NoError(t, err)
+
+	// 余额被清空导致扣款失败，幂等键随事务回滚
+	require.NoError(t, svc.db.Model(&models.UserWallet{}).Where("user_id = ?", user.ID).Update("balance", 0).Error)
+	err = svc.PayRental(ctx, user.ID, rentalInfo.ID, "rental-pay-retry")
+	require.Error(t, err)
+
+	var keyCount int64
+	svc.db.Model(&models.IdempotencyKey{}).Count(&keyCount)
+	assert.Equal(t, int64(0), keyCount)
+
+	// 充值后使用同一幂等键重试成功
+	require.NoError(t, svc.db.Model(&models.UserWallet{}).Where("user_id = ?", user.ID).Update("balance", 200).Error)
+	require.NoError(t, svc.PayRental(ctx, user.ID, rentalInfo.ID, "rental-pay-retry"))
+}
+
+func TestRentalService_PayRental_ConcurrentDuplicates(t *testing.T) {
+	svc := setupIdempotentRentalService(t)
+	ctx := context.Background()
+	user, device, pricing := createTestData(t, svc.db)
+
+	rentalInfo, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.NoError(t, err)
+
+	const workers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- svc.PayRental(ctx, user.ID, rentalInfo.ID, "concurrent-pay")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	var wallet models.UserWallet
+	require.NoError(t, svc.db.Where("user_id = ?", user.ID).First(&wallet).Error)
+	assert.Equal(t, 200.0-(pricing.Price+pricing.Deposit), wallet.Balance)
+	assert.Equal(t, pricing.Deposit, wallet.FrozenBalance)
+
+	var consumeCount int64
+	svc.db.Model(&models.WalletTransaction{}).
+		Where("user_id = ? AND type = ?", user.ID, models.WalletTxTypeConsume).
+		Count(&consumeCount)
+	assert.Equal(t, int64(1), consumeCount)
+}
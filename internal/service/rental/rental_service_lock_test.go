@@ -0,0 +1,121 @@
+package rental
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/cache"
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// setupLockedRentalService 创建启用设备槽位锁的租借服务
+func setupLockedRentalService(t *testing.T) (*testRentalService, *miniredis.Miniredis) {
+	svc := setupTestRentalService(t)
+
+	// 内存 SQLite 每个连接是独立的数据库，并发测试需要共享单连接
+	sqlDB, err := svc.db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+		mr.Close()
+	})
+
+	svc.locker = cache.NewLocker(client)
+	return svc, mr
+}
+
+// createTestUsers 批量创建带余额的测试用户
+func createTestUsers(t *testing.T, svc *testRentalService, n int) []*models.User {
+	users := make([]*models.User, 0, n)
+	for i := 0; i < n; i++ {
+		phone := fmt.Sprintf("1390000%04d", i)
+		user := &models.User{
+			Phone:         &phone,
+			Nickname:      fmt.Sprintf("并发用户%d", i),
+			MemberLevelID: 1,
+			Status:        models.UserStatusActive,
+		}
+		require.NoError(t, svc.db.Create(user).Error)
+		require.NoError(t, svc.db.Create(&models.UserWallet{UserID: user.ID, Balance: 200.0}).Error)
+		users = append(users, user)
+	}
+	return users
+}
+
+func TestRentalService_CreateRental_ConcurrentSameDevice(t *testing.T) {
+	svc, _ := setupLockedRentalService(t)
+	ctx := context.Background()
+	_, device, pricing := createTestData(t, svc.db)
+	users := createTestUsers(t, svc, 10)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make([]error, len(users))
+	for i, user := range users {
+		wg.Add(1)
+		go func(i int, userID int64) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = svc.CreateRental(ctx, userID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+		}(i, user.ID)
+	}
+	close(start)
+	wg.Wait()
+
+	successCount := 0
+	for _, err := range errs {
+		if err == nil {
+			successCount++
+			continue
+		}
+		_, ok := err.(*appErrors.AppError)
+		assert.True(t, ok, "并发失败应返回业务错误: %v", err)
+	}
+	assert.Equal(t, 1, successCount)
+
+	var rentalCount int64
+	svc.db.Model(&models.Rental{}).Where("device_id = ?", device.ID).Count(&rentalCount)
+	assert.Equal(t, int64(1), rentalCount)
+
+	var updated models.Device
+	require.NoError(t, svc.db.First(&updated, device.ID).Error)
+	assert.Equal(t, 0, updated.AvailableSlots)
+}
+
+func TestRentalService_CreateRental_DeviceLocked(t *testing.T) {
+	svc, mr := setupLockedRentalService(t)
+	ctx := context.Background()
+	user, device, pricing := createTestData(t, svc.db)
+
+	t.Run("设备锁被占用时返回设备繁忙", func(t *testing.T) {
+		require.NoError(t, mr.Set(deviceSlotLockKey(device.ID), "other"))
+
+		_, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+		assert.Equal(t, appErrors.ErrDeviceBusy, err)
+
+		var rentalCount int64
+		svc.db.Model(&models.Rental{}).Count(&rentalCount)
+		assert.Equal(t, int64(0), rentalCount)
+
+		mr.Del(deviceSlotLockKey(device.ID))
+	})
+
+	t.Run("创建成功后释放设备锁", func(t *testing.T) {
+		_, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+		require.NoError(t, err)
+		assert.False(t, mr.Exists(deviceSlotLockKey(device.ID)))
+	})
+}
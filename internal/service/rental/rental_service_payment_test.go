@@ -0,0 +1,32 @@
+package rental
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+func TestRentalService_OnPaymentSuccess(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+
+	user, device, pricing := createTestData(t, svc.db)
+	info, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.OnPaymentSuccess(ctx, info.OrderID))
+
+	var rental models.Rental
+	require.NoError(t, svc.db.First(&rental, info.ID).Error)
+	assert.Equal(t, models.RentalStatusPaid, rental.Status)
+
+	// 重复回调不改变已推进的状态
+	require.NoError(t, svc.db.Model(&rental).Update("status", models.RentalStatusInUse).Error)
+	require.NoError(t, svc.OnPaymentSuccess(ctx, info.OrderID))
+	require.NoError(t, svc.db.First(&rental, info.ID).Error)
+	assert.Equal(t, models.RentalStatusInUse, rental.Status)
+}
@@ -0,0 +1,289 @@
+package rental
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	paymentService "github.com/dumeirei/smart-locker-backend/internal/service/payment"
+)
+
+// setupPreauthRentalService 创建配置了内存预授权渠道的租借服务
+func setupPreauthRentalService(t *testing.T) (*testRentalService, *paymentService.FakePreauthProvider) {
+	svc := setupTestRentalService(t)
+	provider := paymentService.NewFakePreauthProvider()
+	svc.SetPreauthService(paymentService.NewPreauthService(provider))
+	return svc, provider
+}
+
+// getPreauthPayment 获取订单的预授权支付单
+func getPreauthPayment(t *testing.T, svc *testRentalService, orderID int64) *models.Payment {
+	t.Helper()
+	var payment models.Payment
+	require.NoError(t, svc.db.Where("order_id = ? AND type = ?", orderID, models.PaymentTypePreauth).First(&payment).Error)
+	return &payment
+}
+
+func TestRentalService_Preauth_CaptureOvertimeFee(t *testing.T) {
+	svc, provider := setupPreauthRentalService(t)
+	ctx := context.Background()
+	user, device, pricing := createTestData(t, svc.db)
+
+	info, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{
+		DeviceID:      device.ID,
+		PricingID:     pricing.ID,
+		DepositMethod: models.DepositMethodPreauth,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.DepositMethodPreauth, info.DepositMethod)
+
+	payment := getPreauthPayment(t, svc, info.OrderID)
+	assert.Equal(t, int8(models.PaymentStatusAuthorized), payment.Status)
+	assert.Equal(t, 50.0, payment.Amount)
+	require.NotNil(t, payment.TransactionID)
+
+	// 支付只扣除租金，押金不冻结钱包余额
+	require.NoError(t, svc.PayRental(ctx, user.ID, info.ID, ""))
+	wallet := getTestWallet(t, svc, user.ID)
+	assert.Equal(t, 190.0, wallet.Balance)
+	assert.Equal(t, 0.0, wallet.FrozenBalance)
+
+	require.NoError(t, svc.StartRental(ctx, user.ID, info.ID))
+	require.NoError(t, svc.db.Model(&models.Rental{}).Where("id = ?", info.ID).
+		Update("expected_return_at", time.Now().Add(-2*time.Hour)).Error)
+	require.NoError(t, svc.ReturnRental(ctx, user.ID, info.ID))
+	require.NoError(t, svc.CompleteRental(ctx, info.ID))
+
+	var rental models.Rental
+	require.NoError(t, svc.db.First(&rental, info.ID).Error)
+	require.Greater(t, rental.OvertimeFee, 0.0)
+	require.Less(t, rental.OvertimeFee, rental.Deposit)
+
+	payment = getPreauthPayment(t, svc, info.OrderID)
+	assert.Equal(t, int8(models.PaymentStatusCaptured), payment.Status)
+	assert.Equal(t, rental.OvertimeFee, payment.CapturedAmount)
+
+	auth := provider.Authorization(*payment.TransactionID)
+	require.NotNil(t, auth)
+	assert.Equal(t, rental.OvertimeFee, auth.Captured)
+	assert.True(t, auth.Released)
+
+	// 钱包不受押金结算影响
+	wallet = getTestWallet(t, svc, user.ID)
+	assert.Equal(t, 190.0, wallet.Balance)
+	assert.Equal(t, 0.0, wallet.FrozenBalance)
+}
+
+func TestRentalService_Preauth_ReleaseOnCancel(t *testing.T) {
+	svc, provider := setupPreauthRentalService(t)
+	ctx := context.Background()
+	user, device, pricing := createTestData(t, svc.db)
+
+	info, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{
+		DeviceID:      device.ID,
+		PricingID:     pricing.ID,
+		DepositMethod: models.DepositMethodPreauth,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.CancelRental(ctx, user.ID, info.ID))
+
+	payment := getPreauthPayment(t, svc, info.OrderID)
+	assert.Equal(t, int8(models.PaymentStatusReleased), payment.Status)
+	assert.Equal(t, 0.0, payment.CapturedAmount)
+	auth := provider.Authorization(*payment.TransactionID)
+	require.NotNil(t, auth)
+	assert.Equal(t, 0.0, auth.Captured)
+	assert.True(t, auth.Released)
+}
+
+func TestRentalService_Preauth_ReleaseOnExpire(t *testing.T) {
+	svc, provider := setupPreauthRentalService(t)
+	expirySvc := newRentalExpiryService(t, svc, 15)
+	ctx := context.Background()
+	user, device, pricing := createTestData(t, svc.db)
+
+	info, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{
+		DeviceID:      device.ID,
+		PricingID:     pricing.ID,
+		DepositMethod: models.DepositMethodPreauth,
+	})
+	require.NoError(t, err)
+	backdateRentalOrder(t, svc, info.OrderID, time.Hour)
+
+	expired, err := expirySvc.ProcessExpiredOrders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	payment := getPreauthPayment(t, svc, info.OrderID)
+	assert.Equal(t, int8(models.PaymentStatusReleased), payment.Status)
+	assert.True(t, provider.Authorization(*payment.TransactionID).Released)
+}
+
+func TestRentalService_Preauth_MixedMethodsForSameUser(t *testing.T) {
+	svc, provider := setupPreauthRentalService(t)
+	svc.SetMaxConcurrentRentals(2)
+	ctx := context.Background()
+	user, device, pricing := createTestData(t, svc.db)
+	device2 := createLimitTestDevice(t, svc.db, device.VenueID, 1)
+
+	walletRental, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.NoError(t, err)
+	assert.Equal(t, models.DepositMethodWalletFreeze, walletRental.DepositMethod)
+
+	preauthRental, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{
+		DeviceID:      device2.ID,
+		PricingID:     pricing.ID,
+		DepositMethod: models.DepositMethodPreauth,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.PayRental(ctx, user.ID, walletRental.ID, ""))
+	require.NoError(t, svc.PayRental(ctx, user.ID, preauthRental.ID, ""))
+
+	// 只有钱包方式的租借冻结押金
+	wallet := getTestWallet(t, svc, user.ID)
+	assert.Equal(t, 130.0, wallet.Balance)
+	assert.Equal(t, 50.0, wallet.FrozenBalance)
+
+	var walletPreauths int64
+	svc.db.Model(&models.Payment{}).Where("order_id = ?", walletRental.OrderID).Count(&walletPreauths)
+	assert.Zero(t, walletPreauths)
+
+	for _, id := range []int64{walletRental.ID, preauthRental.ID} {
+		require.NoError(t, svc.StartRental(ctx, user.ID, id))
+		require.NoError(t, svc.ReturnRental(ctx, user.ID, id))
+		require.NoError(t, svc.CompleteRental(ctx, id))
+	}
+
+	// 钱包押金退还，预授权押金无超时费时全额解冻
+	wallet = getTestWallet(t, svc, user.ID)
+	assert.Equal(t, 180.0, wallet.Balance)
+	assert.Equal(t, 0.0, wallet.FrozenBalance)
+
+	payment := getPreauthPayment(t, svc, preauthRental.OrderID)
+	assert.Equal(t, int8(models.PaymentStatusReleased), payment.Status)
+	auth := provider.Authorization(*payment.TransactionID)
+	assert.Equal(t, 0.0, auth.Captured)
+	assert.True(t, auth.Released)
+}
+
+func TestRentalService_Preauth_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("未配置预授权服务", func(t *testing.T) {
+		svc := setupTestRentalService(t)
+		user, device, pricing := createTestData(t, svc.db)
+
+		_, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{
+			DeviceID:      device.ID,
+			PricingID:     pricing.ID,
+			DepositMethod: models.DepositMethodPreauth,
+		})
+		var appErr *errors.AppError
+		require.True(t, stderrors.As(err, &appErr))
+		assert.Equal(t, errors.ErrPaymentMethodError.Code, appErr.Code)
+	})
+
+	t.Run("授权失败时不创建租借", func(t *testing.T) {
+		svc, provider := setupPreauthRentalService(t)
+		provider.AuthorizeErr = stderrors.New("card declined")
+		user, device, pricing := createTestData(t, svc.db)
+
+		_, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{
+			DeviceID:      device.ID,
+			PricingID:     pricing.ID,
+			DepositMethod: models.DepositMethodPreauth,
+		})
+		var appErr *errors.AppError
+		require.True(t, stderrors.As(err, &appErr))
+		assert.Equal(t, errors.ErrPaymentFailed.Code, appErr.Code)
+
+		var count int64
+		svc.db.Model(&models.Rental{}).Count(&count)
+		assert.Zero(t, count)
+		var reloaded models.Device
+		require.NoError(t, svc.db.First(&reloaded, device.ID).Error)
+		assert.Equal(t, 1, reloaded.AvailableSlots)
+	})
+	t.Run("下单事务失败时解冻已授权押金", func(t *testing.T) {
+		svc, provider := setupPreauthRentalService(t)
+		user, device, pricing := createTestData(t, svc.db)
+		require.NoError(t, svc.db.Migrator().DropTable(&models.Payment{}))
+
+		_, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{
+			DeviceID:      device.ID,
+			PricingID:     pricing.ID,
+			DepositMethod: models.DepositMethodPreauth,
+		})
+		require.Error(t, err)
+
+		auth := provider.Authorization("FAKE-AUTH-1")
+		require.NotNil(t, auth)
+		assert.True(t, auth.Released)
+	})
+
+	t.Run("设备槽位锁被占用时解冻已授权押金", func(t *testing.T) {
+		svc, mr := setupLockedRentalService(t)
+		provider := paymentService.NewFakePreauthProvider()
+		svc.SetPreauthService(paymentService.NewPreauthService(provider))
+		user, device, pricing := createTestData(t, svc.db)
+		require.NoError(t, mr.Set(deviceSlotLockKey(device.ID), "other"))
+
+		_, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{
+			DeviceID:      device.ID,
+			PricingID:     pricing.ID,
+			DepositMethod: models.DepositMethodPreauth,
+		})
+		var appErr *errors.AppError
+		require.True(t, stderrors.As(err, &appErr))
+		assert.Equal(t, errors.ErrDeviceBusy.Code, appErr.Code)
+
+		auth := provider.Authorization("FAKE-AUTH-1")
+		require.NotNil(t, auth)
+		assert.True(t, auth.Released)
+
+		var count int64
+		svc.db.Model(&models.Rental{}).Count(&count)
+		assert.Zero(t, count)
+	})
+}
+
+func TestRentalService_Preauth_RetryFailedSettlement(t *testing.T) {
+	svc, provider := setupPreauthRentalService(t)
+	ctx := context.Background()
+	user, device, pricing := createTestData(t, svc.db)
+
+	info, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{
+		DeviceID:      device.ID,
+		PricingID:     pricing.ID,
+		DepositMethod: models.DepositMethodPreauth,
+	})
+	require.NoError(t, err)
+
+	// 渠道解冻失败不影响取消结果，支付单保持解冻中
+	provider.SettleErr = stderrors.New("channel unavailable")
+	require.NoError(t, svc.CancelRental(ctx, user.ID, info.ID))
+
+	var rental models.Rental
+	require.NoError(t, svc.db.First(&rental, info.ID).Error)
+	assert.Equal(t, models.RentalStatusCancelled, rental.Status)
+	payment := getPreauthPayment(t, svc, info.OrderID)
+	assert.Equal(t, int8(models.PaymentStatusReleasing), payment.Status)
+	assert.False(t, provider.Authorization(*payment.TransactionID).Released)
+
+	provider.SettleErr = nil
+	completed, err := svc.CompletePendingPreauth(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, completed)
+
+	payment = getPreauthPayment(t, svc, info.OrderID)
+	assert.Equal(t, int8(models.PaymentStatusReleased), payment.Status)
+	assert.True(t, provider.Authorization(*payment.TransactionID).Released)
+}
@@ -0,0 +1,178 @@
+package rental
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// 场地默认时区下 2026-03-07 为周六，2026-03-10 为周二
+var (
+	scheduleLocation = mustLoadLocation(models.DefaultVenueTimezone)
+	scheduleSaturday = time.Date(2026, 3, 7, 0, 0, 0, 0, scheduleLocation)
+	scheduleTuesday  = time.Date(2026, 3, 10, 0, 0, 0, 0, scheduleLocation)
+)
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// multiplierAt 获取场地在指定时间的租金倍率
+func multiplierAt(t *testing.T, svc *testRentalService, venueID int64, at time.Time) float64 {
+	t.Helper()
+	multiplier, err := svc.GetApplicableMultiplier(context.Background(), venueID, at)
+	require.NoError(t, err)
+	return multiplier
+}
+
+// createPricingSchedule 创建场地分时定价，dayOfWeek 小于 0 表示每天
+func createPricingSchedule(t *testing.T, db *gorm.DB, venueID int64, dayOfWeek, startHour, endHour int, multiplier float64) *models.PricingSchedule {
+	t.Helper()
+	schedule := &models.PricingSchedule{
+		VenueID:    venueID,
+		StartHour:  startHour,
+		EndHour:    endHour,
+		Multiplier: multiplier,
+		IsActive:   true,
+	}
+	if dayOfWeek >= 0 {
+		schedule.DayOfWeek = &dayOfWeek
+	}
+	require.NoError(t, db.Create(schedule).Error)
+	return schedule
+}
+
+func setupScheduleRentalService(t *testing.T) *testRentalService {
+	svc := setupTestRentalService(t)
+	svc.SetPricingScheduleRepository(repository.NewPricingScheduleRepository(svc.db))
+	return svc
+}
+
+func TestRentalService_GetApplicableMultiplier(t *testing.T) {
+	svc := setupScheduleRentalService(t)
+	_, device, _ := createTestData(t, svc.db)
+	venueID := device.VenueID
+
+	// 周末全天高峰 1.5 倍，每天凌晨低谷 0.8 倍
+	createPricingSchedule(t, svc.db, venueID, int(time.Saturday), 0, 24, 1.5)
+	createPricingSchedule(t, svc.db, venueID, int(time.Sunday), 0, 24, 1.5)
+	createPricingSchedule(t, svc.db, venueID, -1, 0, 6, 0.8)
+
+	t.Run("周末高峰", func(t *testing.T) {
+		assert.Equal(t, 1.5, multiplierAt(t, svc, venueID, scheduleSaturday.Add(15*time.Hour)))
+	})
+
+	t.Run("工作日低谷", func(t *testing.T) {
+		assert.Equal(t, 0.8, multiplierAt(t, svc, venueID, scheduleTuesday.Add(3*time.Hour)))
+	})
+
+	t.Run("重叠时取指定星期的定价", func(t *testing.T) {
+		// 周六凌晨同时命中每天低谷和周六高峰
+		assert.Equal(t, 1.5, multiplierAt(t, svc, venueID, scheduleSaturday.Add(3*time.Hour)))
+	})
+
+	t.Run("无匹配时为原价", func(t *testing.T) {
+		assert.Equal(t, 1.0, multiplierAt(t, svc, venueID, scheduleTuesday.Add(6*time.Hour)))
+		assert.Equal(t, 1.0, multiplierAt(t, svc, venueID+100, scheduleSaturday.Add(15*time.Hour)))
+	})
+
+	t.Run("停用的定价不生效", func(t *testing.T) {
+		disabled := createPricingSchedule(t, svc.db, venueID, int(time.Tuesday), 10, 12, 2.0)
+		assert.Equal(t, 2.0, multiplierAt(t, svc, venueID, scheduleTuesday.Add(11*time.Hour)))
+
+		require.NoError(t, svc.db.Model(disabled).Update("is_active", false).Error)
+		assert.Equal(t, 1.0, multiplierAt(t, svc, venueID, scheduleTuesday.Add(11*time.Hour)))
+	})
+
+	t.Run("未配置分时定价仓储", func(t *testing.T) {
+		plain := setupTestRentalService(t)
+		assert.Equal(t, 1.0, multiplierAt(t, plain, venueID, scheduleSaturday.Add(15*time.Hour)))
+	})
+}
+
+func TestRentalService_GetApplicableMultiplier_VenueTimezone(t *testing.T) {
+	svc := setupScheduleRentalService(t)
+	_, device, _ := createTestData(t, svc.db)
+	venueID := device.VenueID
+
+	// 每天 18-22 点晚高峰
+	createPricingSchedule(t, svc.db, venueID, -1, 18, 22, 1.3)
+
+	// UTC 10:00 为北京时间 18:00
+	at := time.Date(2026, 3, 10, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, 1.3, multiplierAt(t, svc, venueID, at))
+
+	require.NoError(t, svc.db.Model(&models.Venue{}).Where("id = ?", venueID).Update("timezone", "UTC").Error)
+	assert.Equal(t, 1.0, multiplierAt(t, svc, venueID, at))
+	assert.Equal(t, 1.3, multiplierAt(t, svc, venueID, at.Add(8*time.Hour)))
+}
+
+func TestRentalService_GetApplicableMultiplier_DatabaseError(t *testing.T) {
+	svc := setupScheduleRentalService(t)
+	ctx := context.Background()
+	user, device, pricing := createTestData(t, svc.db)
+
+	require.NoError(t, svc.db.Migrator().DropTable(&models.PricingSchedule{}))
+
+	_, err := svc.GetApplicableMultiplier(ctx, device.VenueID, scheduleSaturday)
+	require.Error(t, err)
+
+	// 查询失败时不应按原价下单
+	_, err = svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.Error(t, err)
+}
+
+func TestRentalService_GetApplicableMultiplier_Overlapping(t *testing.T) {
+	svc := setupScheduleRentalService(t)
+	_, device, _ := createTestData(t, svc.db)
+	venueID := device.VenueID
+	at := scheduleTuesday.Add(18 * time.Hour)
+
+	createPricingSchedule(t, svc.db, venueID, -1, 8, 22, 1.2)
+	assert.Equal(t, 1.2, multiplierAt(t, svc, venueID, at))
+
+	// 同为每天生效时，时段较短者更具体
+	createPricingSchedule(t, svc.db, venueID, -1, 17, 20, 1.4)
+	assert.Equal(t, 1.4, multiplierAt(t, svc, venueID, at))
+
+	// 指定星期优先于每天，即使时段更长
+	createPricingSchedule(t, svc.db, venueID, int(time.Tuesday), 0, 24, 0.9)
+	assert.Equal(t, 0.9, multiplierAt(t, svc, venueID, at))
+
+	// 其他星期不受影响
+	assert.Equal(t, 1.4, multiplierAt(t, svc, venueID, at.Add(24*time.Hour)))
+}
+
+func TestRentalService_CreateRental_AppliesPricingSchedule(t *testing.T) {
+	svc := setupScheduleRentalService(t)
+	ctx := context.Background()
+	user, device, pricing := createTestData(t, svc.db)
+
+	// 全天 1.5 倍，保证与测试运行时间无关
+	createPricingSchedule(t, svc.db, device.VenueID, -1, 0, 24, 1.5)
+
+	info, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.NoError(t, err)
+
+	var rental models.Rental
+	require.NoError(t, svc.db.First(&rental, info.ID).Error)
+	assert.Equal(t, pricing.Price*1.5, rental.OriginalFee)
+	assert.Equal(t, pricing.Price*1.5, rental.RentalFee)
+
+	var order models.Order
+	require.NoError(t, svc.db.First(&order, info.OrderID).Error)
+	assert.Equal(t, pricing.Price*1.5+pricing.Deposit, order.OriginalAmount)
+	assert.Equal(t, pricing.Price*1.5+pricing.Deposit, order.ActualAmount)
+	assert.Equal(t, 0.0, order.DiscountAmount)
+}
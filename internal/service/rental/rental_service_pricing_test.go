@@ -0,0 +1,176 @@
+// Package rental 租借定价优先级单元测试
+package rental
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// createPricing 创建指定层级的定价
+func createPricing(t *testing.T, db *gorm.DB, venueID, deviceID *int64, hours int, price float64) *models.RentalPricing {
+	t.Helper()
+
+	pricing := &models.RentalPricing{
+		VenueID:       venueID,
+		DeviceID:      deviceID,
+		DurationHours: hours,
+		Price:         price,
+		Deposit:       50.0,
+		OvertimeRate:  1.5,
+		IsActive:      true,
+	}
+	require.NoError(t, db.Create(pricing).Error)
+	return pricing
+}
+
+// createPricingDevice 在指定场地下创建设备
+func createPricingDevice(t *testing.T, db *gorm.DB, venueID int64, deviceNo string) *models.Device {
+	t.Helper()
+
+	device := &models.Device{
+		DeviceNo:       deviceNo,
+		Name:           "定价测试设备",
+		Type:           models.DeviceTypeStandard,
+		VenueID:        venueID,
+		QRCode:         "https://qr.example.com/" + deviceNo,
+		ProductName:    "测试产品",
+		SlotCount:      1,
+		AvailableSlots: 1,
+		OnlineStatus:   models.DeviceOnline,
+		LockStatus:     models.DeviceLocked,
+		RentalStatus:   models.DeviceRentalFree,
+		NetworkType:    "WiFi",
+		Status:         models.DeviceStatusActive,
+	}
+	require.NoError(t, db.Create(device).Error)
+	return device
+}
+
+// assertPricingErrorCode 断言错误为指定错误码的 AppError
+func assertPricingErrorCode(t *testing.T, err error, code int) {
+	t.Helper()
+
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok, "expected AppError, got %v", err)
+	assert.Equal(t, code, appErr.Code)
+}
+
+func TestRentalService_GetEffectivePricing(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+
+	_, device, venuePricing := createTestData(t, svc.db)
+	venueID := device.VenueID
+	deviceID := device.ID
+	other := createPricingDevice(t, svc.db, venueID, "D20240101099")
+	otherID := other.ID
+
+	global := createPricing(t, svc.db, nil, nil, 1, 8.0)
+	globalOnly := createPricing(t, svc.db, nil, nil, 2, 15.0)
+
+	t.Run("仅全局定价", func(t *testing.T) {
+		pricing, err := svc.GetEffectivePricing(ctx, device.ID, globalOnly.ID)
+		require.NoError(t, err)
+		assert.Equal(t, globalOnly.ID, pricing.ID)
+	})
+
+	t.Run("场地定价覆盖同时长的全局定价", func(t *testing.T) {
+		pricing, err := svc.GetEffectivePricing(ctx, device.ID, venuePricing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, venuePricing.ID, pricing.ID)
+
+		_, err = svc.GetEffectivePricing(ctx, device.ID, global.ID)
+		assertPricingErrorCode(t, err, appErrors.ErrPricingNotApplicable.Code)
+	})
+
+	devicePricing := createPricing(t, svc.db, &venueID, &deviceID, 1, 6.0)
+
+	t.Run("设备专属定价覆盖同时长的场地及全局定价", func(t *testing.T) {
+		pricing, err := svc.GetEffectivePricing(ctx, device.ID, devicePricing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, devicePricing.ID, pricing.ID)
+		assert.Equal(t, 6.0, pricing.Price)
+
+		for _, selected := range []int64{global.ID, venuePricing.ID} {
+			_, err := svc.GetEffectivePricing(ctx, device.ID, selected)
+			assertPricingErrorCode(t, err, appErrors.ErrPricingNotApplicable.Code)
+		}
+
+		// 同场地其他设备不受设备专属定价影响
+		pricing, err = svc.GetEffectivePricing(ctx, other.ID, venuePricing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, venuePricing.ID, pricing.ID)
+	})
+
+	t.Run("其他设备的专属定价不可用", func(t *testing.T) {
+		otherPricing := createPricing(t, svc.db, &venueID, &otherID, 3, 20.0)
+		_, err := svc.GetEffectivePricing(ctx, device.ID, otherPricing.ID)
+		assertPricingErrorCode(t, err, appErrors.ErrPricingNotFound.Code)
+	})
+
+	t.Run("其他场地的定价不可用", func(t *testing.T) {
+		otherVenueID := venueID + 100
+		otherVenuePricing := createPricing(t, svc.db, &otherVenueID, nil, 4, 30.0)
+		_, err := svc.GetEffectivePricing(ctx, device.ID, otherVenuePricing.ID)
+		assertPricingErrorCode(t, err, appErrors.ErrPricingNotFound.Code)
+	})
+
+	t.Run("停用的定价不可用", func(t *testing.T) {
+		inactive := createPricing(t, svc.db, nil, nil, 5, 40.0)
+		require.NoError(t, svc.db.Model(inactive).Update("is_active", false).Error)
+		_, err := svc.GetEffectivePricing(ctx, device.ID, inactive.ID)
+		assertPricingErrorCode(t, err, appErrors.ErrPricingNotFound.Code)
+	})
+
+	t.Run("定价不存在", func(t *testing.T) {
+		_, err := svc.GetEffectivePricing(ctx, device.ID, 99999)
+		assertPricingErrorCode(t, err, appErrors.ErrPricingNotFound.Code)
+	})
+
+	t.Run("停用设备专属定价后回退场地定价", func(t *testing.T) {
+		require.NoError(t, svc.db.Model(devicePricing).Update("is_active", false).Error)
+
+		pricing, err := svc.GetEffectivePricing(ctx, device.ID, venuePricing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, venuePricing.ID, pricing.ID)
+
+		_, err = svc.GetEffectivePricing(ctx, device.ID, devicePricing.ID)
+		assertPricingErrorCode(t, err, appErrors.ErrPricingNotFound.Code)
+	})
+}
+
+func TestRentalService_CreateRental_DevicePricing(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+
+	user, device, venuePricing := createTestData(t, svc.db)
+	venueID := device.VenueID
+	deviceID := device.ID
+	devicePricing := createPricing(t, svc.db, &venueID, &deviceID, 1, 6.0)
+
+	// 同时长存在设备专属定价时不能选择场地定价下单
+	_, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{
+		DeviceID:  device.ID,
+		PricingID: venuePricing.ID,
+	})
+	assertPricingErrorCode(t, err, appErrors.ErrPricingNotApplicable.Code)
+
+	info, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{
+		DeviceID:  device.ID,
+		PricingID: devicePricing.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, devicePricing.Price, info.RentalFee)
+
+	var rental models.Rental
+	require.NoError(t, svc.db.First(&rental, info.ID).Error)
+	require.NotNil(t, rental.PricingID)
+	assert.Equal(t, devicePricing.ID, *rental.PricingID)
+}
@@ -3,6 +3,7 @@ package rental
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -12,6 +13,8 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 	deviceService "github.com/dumeirei/smart-locker-backend/internal/service/device"
@@ -20,7 +23,24 @@ import (
 
 // setupTestDB 创建测试数据库
 func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+	return openTestDB(t, ":memory:")
+}
+
+// setupConcurrentTestDB 创建基于文件的 SQLite 测试数据库，允许多个连接并发执行事务
+func setupConcurrentTestDB(t *testing.T) *gorm.DB {
+	dsn := filepath.Join(t.TempDir(), "rental.db") + "?_journal_mode=WAL&_busy_timeout=10000&_txlock=immediate"
+	db := openTestDB(t, dsn)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(20)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// openTestDB 打开测试数据库并迁移租借相关表
+func openTestDB(t *testing.T, dsn string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
 	require.NoError(t, err)
@@ -32,10 +52,16 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		&models.Merchant{},
 		&models.Venue{},
 		&models.Device{},
+		&models.DeviceSlot{},
 		&models.RentalPricing{},
+		&models.PricingSchedule{},
 		&models.Order{},
+		&models.OrderItem{},
 		&models.Rental{},
+		&models.RentalTransfer{},
+		&models.RentalSubscription{},
 		&models.WalletTransaction{},
+		&models.Payment{},
 	)
 	require.NoError(t, err)
 
@@ -60,15 +86,24 @@ type testRentalService struct {
 
 // setupTestRentalService 创建测试用的 RentalService
 func setupTestRentalService(t *testing.T) *testRentalService {
-	db := setupTestDB(t)
+	return newTestRentalService(setupTestDB(t))
+}
+
+// setupConcurrentTestRentalService 创建基于文件数据库、可并发调用的 RentalService
+func setupConcurrentTestRentalService(t *testing.T) *testRentalService {
+	return newTestRentalService(setupConcurrentTestDB(t))
+}
+
+// newTestRentalService 基于 db 创建测试用的 RentalService
+func newTestRentalService(db *gorm.DB) *testRentalService {
 	rentalRepo := repository.NewRentalRepository(db)
 	deviceRepo := repository.NewDeviceRepository(db)
 	venueRepo := repository.NewVenueRepository(db)
 	userRepo := repository.NewUserRepository(db)
 	deviceSvc := deviceService.NewDeviceService(db, deviceRepo, venueRepo)
-	walletSvc := userService.NewWalletService(db, userRepo)
+	walletSvc := userService.NewWalletService(db, userRepo, nil)
 
-	service := NewRentalService(db, rentalRepo, deviceRepo, deviceSvc, walletSvc, nil)
+	service := NewRentalService(db, rentalRepo, deviceRepo, deviceSvc, walletSvc, nil, nil, nil)
 
 	return &testRentalService{
 		RentalService: service,
@@ -261,7 +296,7 @@ func TestRentalService_PayStartReturnComplete_FullWalletFlow(t *testing.T) {
 	require.NoError(t, err)
 
 	// 2) 支付
-	err = svc.PayRental(ctx, user.ID, rentalInfo.ID)
+	err = svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 	require.NoError(t, err)
 
 	// 验证订单状态与钱包变化
@@ -303,7 +338,7 @@ func TestRentalService_PayRental_Errors(t *testing.T) {
 	user, device, pricing := createTestData(t, svc.db)
 
 	t.Run("租借不存在", func(t *testing.T) {
-		err := svc.PayRental(ctx, user.ID, 999999)
+		err := svc.PayRental(ctx, user.ID, 999999, "")
 		assert.Error(t, err)
 	})
 
@@ -316,11 +351,11 @@ func TestRentalService_PayRental_Errors(t *testing.T) {
 		require.NoError(t, err)
 
 		// 另一个用户尝试支付
-		err = svc.PayRental(ctx, 999999, rentalInfo.ID)
+		err = svc.PayRental(ctx, 999999, rentalInfo.ID, "")
 		assert.Error(t, err)
 
 		// 完成当前租借，以便后续测试
-		svc.PayRental(ctx, user.ID, rentalInfo.ID)
+		svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 		svc.StartRental(ctx, user.ID, rentalInfo.ID)
 		svc.ReturnRental(ctx, user.ID, rentalInfo.ID)
 		svc.CompleteRental(ctx, rentalInfo.ID)
@@ -352,11 +387,11 @@ func TestRentalService_PayRental_Errors(t *testing.T) {
 		require.NoError(t, err)
 
 		// 先支付
-		err = svc.PayRental(ctx, user.ID, rentalInfo.ID)
+		err = svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 		require.NoError(t, err)
 
 		// 再次支付应失败
-		err = svc.PayRental(ctx, user.ID, rentalInfo.ID)
+		err = svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 		assert.Error(t, err)
 	})
 }
@@ -395,7 +430,7 @@ func TestRentalService_StartRental_Errors(t *testing.T) {
 			PricingID: pricing.ID,
 		})
 		require.NoError(t, err)
-		svc.PayRental(ctx, user.ID, rentalInfo.ID)
+		svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 
 		err = svc.StartRental(ctx, 999999, rentalInfo.ID)
 		assert.Error(t, err)
@@ -460,7 +495,7 @@ func TestRentalService_StartRental_Errors(t *testing.T) {
 			PricingID: pricing.ID,
 		})
 		require.NoError(t, err)
-		svc.PayRental(ctx, user.ID, rentalInfo.ID)
+		svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 
 		// 禁用设备
 		svc.db.Model(&models.Device{}).Where("id = ?", device4.ID).Update("status", models.DeviceStatusDisabled)
@@ -498,7 +533,7 @@ func TestRentalService_StartRental_Errors(t *testing.T) {
 			PricingID: pricing.ID,
 		})
 		require.NoError(t, err)
-		svc.PayRental(ctx, user.ID, rentalInfo.ID)
+		svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 
 		// 设备离线
 		svc.db.Model(&models.Device{}).Where("id = ?", device5.ID).Update("online_status", models.DeviceOffline)
@@ -542,7 +577,7 @@ func TestRentalService_ReturnRental_Errors(t *testing.T) {
 			PricingID: pricing.ID,
 		})
 		require.NoError(t, err)
-		svc.PayRental(ctx, user.ID, rentalInfo.ID)
+		svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 		svc.StartRental(ctx, user.ID, rentalInfo.ID)
 
 		err = svc.ReturnRental(ctx, 999999, rentalInfo.ID)
@@ -576,7 +611,7 @@ func TestRentalService_ReturnRental_Errors(t *testing.T) {
 			PricingID: pricing.ID,
 		})
 		require.NoError(t, err)
-		svc.PayRental(ctx, user.ID, rentalInfo.ID)
+		svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 
 		// 未开始就尝试归还
 		err = svc.ReturnRental(ctx, user.ID, rentalInfo.ID)
@@ -618,7 +653,7 @@ func TestRentalService_CompleteRental_Errors(t *testing.T) {
 			PricingID: pricing.ID,
 		})
 		require.NoError(t, err)
-		svc.PayRental(ctx, user.ID, rentalInfo.ID)
+		svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 		svc.StartRental(ctx, user.ID, rentalInfo.ID)
 
 		// 未归还就尝试完成
@@ -736,7 +771,7 @@ func TestRentalService_ReturnRental_OvertimeFee(t *testing.T) {
 			PricingID: pricing.ID,
 		})
 		require.NoError(t, err)
-		svc.PayRental(ctx, user.ID, rentalInfo.ID)
+		svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 		svc.StartRental(ctx, user.ID, rentalInfo.ID)
 
 		err = svc.ReturnRental(ctx, user.ID, rentalInfo.ID)
@@ -797,7 +832,7 @@ func TestRentalService_PayRental_DBError(t *testing.T) {
 	sqlDB, _ := svc.db.DB()
 	sqlDB.Close()
 
-	err := svc.PayRental(ctx, 1, 1)
+	err := svc.PayRental(ctx, 1, 1, "")
 	assert.Error(t, err)
 }
 
@@ -864,6 +899,20 @@ func TestRentalService_CreateRental_DeviceCheckFail(t *testing.T) {
 	})
 }
 
+func TestRentalService_CreateRental_DeviceUnderMaintenance(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+
+	user, device, pricing := createTestData(t, svc.db)
+	svc.db.Model(&models.Device{}).Where("id = ?", device.ID).Update("maintenance_mode", true)
+
+	_, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{
+		DeviceID:  device.ID,
+		PricingID: pricing.ID,
+	})
+	assert.ErrorIs(t, err, appErrors.ErrDeviceMaintenance)
+}
+
 func TestRentalService_CreateRental_NoSlot(t *testing.T) {
 	svc := setupTestRentalService(t)
 	ctx := context.Background()
@@ -894,7 +943,7 @@ func TestRentalService_CancelRental_StatusError(t *testing.T) {
 	require.NoError(t, err)
 
 	// 支付后尝试取消
-	svc.PayRental(ctx, user.ID, rentalInfo.ID)
+	svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 	err = svc.CancelRental(ctx, user.ID, rentalInfo.ID)
 	assert.Error(t, err)
 }
@@ -922,7 +971,7 @@ func TestRentalService_CompleteRental_OrderNotFound(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	svc.PayRental(ctx, user.ID, rentalInfo.ID)
+	svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 	svc.StartRental(ctx, user.ID, rentalInfo.ID)
 	svc.ReturnRental(ctx, user.ID, rentalInfo.ID)
 
@@ -946,7 +995,7 @@ func TestRentalService_ReturnRental_WithOvertimeFee(t *testing.T) {
 			PricingID: pricing.ID,
 		})
 		require.NoError(t, err)
-		svc.PayRental(ctx, user.ID, rentalInfo.ID)
+		svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 		svc.StartRental(ctx, user.ID, rentalInfo.ID)
 
 		// 手动设置过期时间为过去（模拟超时2小时）
@@ -997,7 +1046,7 @@ func TestRentalService_ReturnRental_WithOvertimeFee(t *testing.T) {
 			PricingID: highOvertimePricing.ID,
 		})
 		require.NoError(t, err)
-		svc.PayRental(ctx, user.ID, rentalInfo.ID)
+		svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 		svc.StartRental(ctx, user.ID, rentalInfo.ID)
 
 		// 手动设置过期时间为过去（模拟超时10小时）
@@ -1014,6 +1063,133 @@ func TestRentalService_ReturnRental_WithOvertimeFee(t *testing.T) {
 	})
 }
 
+func TestCalculateOvertimeFee_GracePeriod(t *testing.T) {
+	expectedReturn := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	rental := &models.Rental{
+		Deposit:            50.0,
+		OvertimeRate:       1.5,
+		GracePeriodMinutes: 15,
+		ExpectedReturnAt:   &expectedReturn,
+	}
+
+	t.Run("宽限期内归还不计超时费", func(t *testing.T) {
+		assert.Equal(t, float64(0), calculateOvertimeFee(rental, expectedReturn.Add(10*time.Minute)))
+	})
+
+	t.Run("恰好在宽限期结束时归还不计超时费", func(t *testing.T) {
+		assert.Equal(t, float64(0), calculateOvertimeFee(rental, expectedReturn.Add(15*time.Minute)))
+	})
+
+	t.Run("超出宽限期1秒按1小时计费", func(t *testing.T) {
+		assert.Equal(t, 1.5, calculateOvertimeFee(rental, expectedReturn.Add(15*time.Minute+time.Second)))
+	})
+
+	t.Run("超出宽限期1分钟按1个计费单位计费", func(t *testing.T) {
+		assert.Equal(t, 1.5, calculateOvertimeFee(rental, expectedReturn.Add(16*time.Minute)))
+	})
+
+	t.Run("超时时长从宽限期结束开始计算并向上取整", func(t *testing.T) {
+		// 超出宽限期1小时整，按1小时计
+		assert.Equal(t, 1.5, calculateOvertimeFee(rental, expectedReturn.Add(75*time.Minute)))
+		// 超出宽限期1小时1分钟，按2小时计
+		assert.Equal(t, 3.0, calculateOvertimeFee(rental, expectedReturn.Add(76*time.Minute)))
+	})
+
+	t.Run("超时费不超过押金", func(t *testing.T) {
+		assert.Equal(t, 50.0, calculateOvertimeFee(rental, expectedReturn.Add(100*time.Hour)))
+	})
+
+	t.Run("宽限期为0时与原计费一致", func(t *testing.T) {
+		noGrace := &models.Rental{Deposit: 50.0, OvertimeRate: 1.5, ExpectedReturnAt: &expectedReturn}
+		assert.Equal(t, float64(0), calculateOvertimeFee(noGrace, expectedReturn))
+		assert.Equal(t, 1.5, calculateOvertimeFee(noGrace, expectedReturn.Add(time.Second)))
+		assert.Equal(t, 1.5, calculateOvertimeFee(noGrace, expectedReturn.Add(3*time.Minute)))
+		assert.Equal(t, 3.0, calculateOvertimeFee(noGrace, expectedReturn.Add(90*time.Minute)))
+	})
+
+	t.Run("无预计归还时间", func(t *testing.T) {
+		assert.Equal(t, float64(0), calculateOvertimeFee(&models.Rental{OvertimeRate: 1.5}, expectedReturn))
+	})
+}
+
+func TestRentalService_ReturnRental_WithinGracePeriod(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+
+	user, device, _ := createTestData(t, svc.db)
+
+	gracePricing := &models.RentalPricing{
+		VenueID:            &device.VenueID,
+		DurationHours:      1,
+		Price:              10.0,
+		Deposit:            50.0,
+		OvertimeRate:       1.5,
+		GracePeriodMinutes: utils.IntPtr(15),
+		IsActive:           true,
+	}
+	require.NoError(t, svc.db.Create(gracePricing).Error)
+
+	rentalInfo, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{
+		DeviceID:  device.ID,
+		PricingID: gracePricing.ID,
+	})
+	require.NoError(t, err)
+	require.NoError(t, svc.PayRental(ctx, user.ID, rentalInfo.ID, ""))
+	require.NoError(t, svc.StartRental(ctx, user.ID, rentalInfo.ID))
+
+	// 预计归还时间已过10分钟，仍在15分钟宽限期内
+	pastTime := time.Now().Add(-10 * time.Minute)
+	svc.db.Model(&models.Rental{}).Where("id = ?", rentalInfo.ID).Update("expected_return_at", pastTime)
+
+	err = svc.ReturnRental(ctx, user.ID, rentalInfo.ID)
+	require.NoError(t, err)
+
+	var rental models.Rental
+	svc.db.First(&rental, rentalInfo.ID)
+	assert.Equal(t, 15, rental.GracePeriodMinutes)
+	assert.Equal(t, float64(0), rental.OvertimeFee)
+}
+
+func TestRentalService_CreateRental_VenueDefaultGracePeriod(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+
+	user, device, pricing := createTestData(t, svc.db)
+	require.NoError(t, svc.db.Model(&models.Venue{}).Where("id = ?", device.VenueID).
+		Update("default_grace_period_minutes", 10).Error)
+
+	t.Run("定价未配置宽限期时使用场地默认值", func(t *testing.T) {
+		rentalInfo, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+		require.NoError(t, err)
+
+		var rental models.Rental
+		require.NoError(t, svc.db.First(&rental, rentalInfo.ID).Error)
+		assert.Equal(t, 10, rental.GracePeriodMinutes)
+
+		require.NoError(t, svc.CancelRental(ctx, user.ID, rentalInfo.ID))
+	})
+
+	t.Run("定价配置的宽限期优先于场地默认值", func(t *testing.T) {
+		zeroGrace := &models.RentalPricing{
+			VenueID:            &device.VenueID,
+			DurationHours:      2,
+			Price:              18.0,
+			Deposit:            50.0,
+			OvertimeRate:       1.5,
+			GracePeriodMinutes: utils.IntPtr(0),
+			IsActive:           true,
+		}
+		require.NoError(t, svc.db.Create(zeroGrace).Error)
+
+		rentalInfo, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: zeroGrace.ID})
+		require.NoError(t, err)
+
+		var rental models.Rental
+		require.NoError(t, svc.db.First(&rental, rentalInfo.ID).Error)
+		assert.Equal(t, 0, rental.GracePeriodMinutes)
+	})
+}
+
 func TestRentalService_CompleteRental_WithOvertimeFee(t *testing.T) {
 	svc := setupTestRentalService(t)
 	ctx := context.Background()
@@ -1026,7 +1202,7 @@ func TestRentalService_CompleteRental_WithOvertimeFee(t *testing.T) {
 			PricingID: pricing.ID,
 		})
 		require.NoError(t, err)
-		svc.PayRental(ctx, user.ID, rentalInfo.ID)
+		svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 		svc.StartRental(ctx, user.ID, rentalInfo.ID)
 
 		// 手动设置超时费
@@ -1074,7 +1250,7 @@ func TestRentalService_CompleteRental_WithOvertimeFee(t *testing.T) {
 			PricingID: pricing.ID,
 		})
 		require.NoError(t, err)
-		svc.PayRental(ctx, user.ID, rentalInfo.ID)
+		svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 		svc.StartRental(ctx, user.ID, rentalInfo.ID)
 		svc.ReturnRental(ctx, user.ID, rentalInfo.ID)
 
@@ -1100,7 +1276,7 @@ func TestRentalService_CompleteRental_NegativeOvertimeFee(t *testing.T) {
 		PricingID: pricing.ID,
 	})
 	require.NoError(t, err)
-	svc.PayRental(ctx, user.ID, rentalInfo.ID)
+	svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 	svc.StartRental(ctx, user.ID, rentalInfo.ID)
 
 	// 手动设置负超时费（异常数据）
@@ -1130,7 +1306,7 @@ func TestRentalService_CompleteRental_OvertimeExceedsDeposit(t *testing.T) {
 		PricingID: pricing.ID,
 	})
 	require.NoError(t, err)
-	svc.PayRental(ctx, user.ID, rentalInfo.ID)
+	svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 	svc.StartRental(ctx, user.ID, rentalInfo.ID)
 
 	// 手动设置超时费大于押金（异常数据，应该被处理）
@@ -1225,7 +1401,7 @@ func TestRentalService_PayRental_OrderNotFound(t *testing.T) {
 	svc.db.Delete(&models.Order{}, "id = ?", rentalInfo.OrderID)
 
 	// 尝试支付
-	err = svc.PayRental(ctx, user.ID, rentalInfo.ID)
+	err = svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 	assert.Error(t, err)
 }
 
@@ -1241,7 +1417,7 @@ func TestRentalService_StartRental_DeviceNotFound(t *testing.T) {
 		PricingID: pricing.ID,
 	})
 	require.NoError(t, err)
-	svc.PayRental(ctx, user.ID, rentalInfo.ID)
+	svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 
 	// 删除设备
 	svc.db.Delete(&models.Device{}, "id = ?", device.ID)
@@ -1340,13 +1516,23 @@ func TestRentalService_PayRental_ZeroAmounts(t *testing.T) {
 	require.NoError(t, err)
 
 	// 支付免费租借
-	err = svc.PayRental(ctx, user.ID, rentalInfo.ID)
+	err = svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 	require.NoError(t, err)
 
 	// 验证订单状态
 	var order models.Order
 	svc.db.First(&order, rentalInfo.OrderID)
 	assert.Equal(t, models.OrderStatusPaid, order.Status)
+	assert.NotNil(t, order.PaidAt)
+
+	var rental models.Rental
+	svc.db.First(&rental, rentalInfo.ID)
+	assert.Equal(t, models.RentalStatusPaid, rental.Status)
+
+	// 免费租借不应产生任何钱包流水
+	var txCount int64
+	svc.db.Model(&models.WalletTransaction{}).Where("user_id = ?", user.ID).Count(&txCount)
+	assert.Equal(t, int64(0), txCount)
 }
 
 func TestRentalService_CompleteRental_ZeroDeposit(t *testing.T) {
@@ -1392,7 +1578,7 @@ func TestRentalService_CompleteRental_ZeroDeposit(t *testing.T) {
 	require.NoError(t, err)
 
 	// 完整流程
-	svc.PayRental(ctx, user.ID, rentalInfo.ID)
+	svc.PayRental(ctx, user.ID, rentalInfo.ID, "")
 	svc.StartRental(ctx, user.ID, rentalInfo.ID)
 	svc.ReturnRental(ctx, user.ID, rentalInfo.ID)
 
@@ -1435,3 +1621,47 @@ func TestRentalService_toRentalInfo_WithOrderID(t *testing.T) {
 	assert.NotEmpty(t, info.OrderNo)
 	assert.Equal(t, rentalInfo.OrderID, info.OrderID)
 }
+
+// recordingOrderEvents 记录订单完成事件的测试桩
+type recordingOrderEvents struct {
+	completed []*models.Order
+}
+
+func (r *recordingOrderEvents) OnOrderCompleted(ctx context.Context, order *models.Order) error {
+	r.completed = append(r.completed, order)
+	return nil
+}
+
+func (r *recordingOrderEvents) OnOrderRefunded(ctx context.Context, order *models.Order) error {
+	return nil
+}
+
+func TestRentalService_CompleteRental_TriggersOrderCompleted(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	events := &recordingOrderEvents{}
+	svc.SetOrderEventHandler(events)
+
+	user, device, pricing := createTestData(t, svc.db)
+
+	rentalInfo, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{
+		DeviceID:  device.ID,
+		PricingID: pricing.ID,
+	})
+	require.NoError(t, err)
+	require.NoError(t, svc.PayRental(ctx, user.ID, rentalInfo.ID, ""))
+	require.NoError(t, svc.StartRental(ctx, user.ID, rentalInfo.ID))
+	require.NoError(t, svc.ReturnRental(ctx, user.ID, rentalInfo.ID))
+
+	require.NoError(t, svc.CompleteRental(ctx, rentalInfo.ID))
+
+	require.Len(t, events.completed, 1)
+	completed := events.completed[0]
+	assert.Equal(t, rentalInfo.OrderNo, completed.OrderNo)
+	assert.Equal(t, models.OrderStatusCompleted, completed.Status)
+	assert.NotNil(t, completed.CompletedAt)
+
+	// 重复结算失败且不再触发事件
+	require.Error(t, svc.CompleteRental(ctx, rentalInfo.ID))
+	assert.Len(t, events.completed, 1)
+}
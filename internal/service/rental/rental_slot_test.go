@@ -0,0 +1,208 @@
+package rental
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+)
+
+// createMultiSlotDevice 通过设备仓储注册多格口设备（同时创建格口）
+func createMultiSlotDevice(t *testing.T, db *gorm.DB, venueID int64, slotCount int) *models.Device {
+	t.Helper()
+
+	device := &models.Device{
+		DeviceNo:       "D_SLOT_001",
+		Name:           "多格口设备",
+		Type:           models.DeviceTypeStandard,
+		VenueID:        venueID,
+		QRCode:         "https://qr.example.com/D_SLOT_001",
+		ProductName:    "测试产品",
+		SlotCount:      slotCount,
+		AvailableSlots: slotCount,
+		OnlineStatus:   models.DeviceOnline,
+		LockStatus:     models.DeviceLocked,
+		RentalStatus:   models.DeviceRentalFree,
+		NetworkType:    "WiFi",
+		Status:         models.DeviceStatusActive,
+	}
+	require.NoError(t, repository.NewDeviceRepository(db).Create(context.Background(), device))
+	return device
+}
+
+// assertSlotsInSync 断言设备可用槽位等于空闲格口数，返回各格口状态（按格口编号）
+func assertSlotsInSync(t *testing.T, db *gorm.DB, deviceID int64) []int8 {
+	t.Helper()
+
+	var device models.Device
+	require.NoError(t, db.First(&device, deviceID).Error)
+
+	var slots []*models.DeviceSlot
+	require.NoError(t, db.Where("device_id = ?", deviceID).Order("slot_no").Find(&slots).Error)
+	require.Len(t, slots, device.SlotCount)
+
+	statuses := make([]int8, len(slots))
+	free := 0
+	for i, slot := range slots {
+		statuses[i] = slot.Status
+		if slot.Status == models.DeviceSlotFree {
+			free++
+		}
+	}
+	assert.Equal(t, free, device.AvailableSlots, "可用槽位应等于空闲格口数")
+	return statuses
+}
+
+func TestRentalService_SlotLifecycle(t *testing.T) {
+	svc := setupTestRentalService(t)
+	require.NoError(t, svc.db.AutoMigrate(&models.DeviceLog{}, &models.OperationLog{}))
+	ctx := context.Background()
+	user, legacy, pricing := createTestData(t, svc.db)
+	svc.db.Model(&models.UserWallet{}).Where("user_id = ?", user.ID).Update("balance", 1000.0)
+	svc.SetMaxConcurrentRentals(0)
+	device := createMultiSlotDevice(t, svc.db, legacy.VenueID, 3)
+
+	create := func() (*RentalInfo, error) {
+		return svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	}
+
+	// 按编号从小到大分配格口
+	r1, err := create()
+	require.NoError(t, err)
+	require.NotNil(t, r1.SlotNo)
+	assert.Equal(t, 1, *r1.SlotNo)
+	r2, err := create()
+	require.NoError(t, err)
+	assert.Equal(t, 2, *r2.SlotNo)
+	r3, err := create()
+	require.NoError(t, err)
+	assert.Equal(t, 3, *r3.SlotNo)
+	assert.Equal(t, []int8{models.DeviceSlotReserved, models.DeviceSlotReserved, models.DeviceSlotReserved},
+		assertSlotsInSync(t, svc.db, device.ID))
+
+	_, err = create()
+	assert.Equal(t, appErrors.ErrDeviceNoSlot, err)
+
+	// 取消释放格口
+	require.NoError(t, svc.CancelRental(ctx, user.ID, r2.ID))
+	assert.Equal(t, []int8{models.DeviceSlotReserved, models.DeviceSlotFree, models.DeviceSlotReserved},
+		assertSlotsInSync(t, svc.db, device.ID))
+
+	// 开锁取货后格口使用中，归还后释放
+	require.NoError(t, svc.PayRental(ctx, user.ID, r1.ID, ""))
+	require.NoError(t, svc.StartRental(ctx, user.ID, r1.ID))
+	assert.Equal(t, []int8{models.DeviceSlotInUse, models.DeviceSlotFree, models.DeviceSlotReserved},
+		assertSlotsInSync(t, svc.db, device.ID))
+
+	require.NoError(t, svc.ReturnRental(ctx, user.ID, r1.ID))
+	assert.Equal(t, []int8{models.DeviceSlotFree, models.DeviceSlotFree, models.DeviceSlotReserved},
+		assertSlotsInSync(t, svc.db, device.ID))
+
+	// 超时未支付自动取消释放格口
+	backdateRentalOrder(t, svc, r3.OrderID, time.Hour)
+	expired, err := newRentalExpiryService(t, svc, 15).ProcessExpiredOrders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	assert.Equal(t, []int8{models.DeviceSlotFree, models.DeviceSlotFree, models.DeviceSlotFree},
+		assertSlotsInSync(t, svc.db, device.ID))
+
+	// 强制完成使用中的租借释放格口
+	r4, err := create()
+	require.NoError(t, err)
+	assert.Equal(t, 1, *r4.SlotNo)
+	require.NoError(t, svc.PayRental(ctx, user.ID, r4.ID, ""))
+	require.NoError(t, svc.StartRental(ctx, user.ID, r4.ID))
+	require.NoError(t, svc.ForceCompleteRental(ctx, r4.ID, 1, true, "格口测试"))
+	assert.Equal(t, []int8{models.DeviceSlotFree, models.DeviceSlotFree, models.DeviceSlotFree},
+		assertSlotsInSync(t, svc.db, device.ID))
+
+	var rental models.Rental
+	require.NoError(t, svc.db.First(&rental, r4.ID).Error)
+	require.NotNil(t, rental.SlotNo)
+	assert.Equal(t, 1, *rental.SlotNo)
+}
+
+func TestRentalService_CreateRental_ConcurrentLastSlot(t *testing.T) {
+	// 基于文件的数据库，各请求使用独立连接并发执行
+	svc := setupConcurrentTestRentalService(t)
+	ctx := context.Background()
+
+	owner, legacy, pricing := createTestData(t, svc.db)
+	device := createMultiSlotDevice(t, svc.db, legacy.VenueID, 2)
+
+	// 先占用 1 号格口，仅剩最后一个空闲格口
+	_, err := svc.CreateRental(ctx, owner.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.NoError(t, err)
+
+	users := createTestUsers(t, svc, 10)
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	results := make([]*RentalInfo, len(users))
+	errs := make([]error, len(users))
+	for i, user := range users {
+		wg.Add(1)
+		go func(i int, userID int64) {
+			defer wg.Done()
+			<-start
+			results[i], errs[i] = svc.CreateRental(ctx, userID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+		}(i, user.ID)
+	}
+	close(start)
+	wg.Wait()
+
+	successCount := 0
+	for i, err := range errs {
+		if err == nil {
+			successCount++
+			require.NotNil(t, results[i].SlotNo)
+			assert.Equal(t, 2, *results[i].SlotNo)
+			continue
+		}
+		assert.Equal(t, appErrors.ErrDeviceNoSlot, err)
+	}
+	assert.Equal(t, 1, successCount)
+
+	var rentalCount int64
+	svc.db.Model(&models.Rental{}).Where("device_id = ?", device.ID).Count(&rentalCount)
+	assert.Equal(t, int64(2), rentalCount)
+	assert.Equal(t, []int8{models.DeviceSlotReserved, models.DeviceSlotReserved}, assertSlotsInSync(t, svc.db, device.ID))
+}
+
+func TestRentalService_CreateRental_LegacyDeviceSlots(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	user, device, pricing := createTestData(t, svc.db)
+	svc.db.Model(&models.UserWallet{}).Where("user_id = ?", user.ID).Update("balance", 1000.0)
+	svc.SetMaxConcurrentRentals(0)
+
+	// 格口库存上线前的设备：有 3 个格口，已有一个未分配格口的进行中租借
+	svc.db.Model(&models.Device{}).Where("id = ?", device.ID).
+		Updates(map[string]interface{}{"slot_count": 3, "available_slots": 2})
+	legacy := &models.Rental{
+		OrderID:       999,
+		UserID:        user.ID,
+		DeviceID:      device.ID,
+		DurationHours: 1,
+		Status:        models.RentalStatusInUse,
+	}
+	require.NoError(t, svc.db.Create(legacy).Error)
+
+	info, err := svc.CreateRental(ctx, user.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 2, *info.SlotNo)
+	assert.Equal(t, []int8{models.DeviceSlotInUse, models.DeviceSlotReserved, models.DeviceSlotFree},
+		assertSlotsInSync(t, svc.db, device.ID))
+
+	var updated models.Rental
+	require.NoError(t, svc.db.First(&updated, legacy.ID).Error)
+	require.NotNil(t, updated.SlotNo)
+	assert.Equal(t, 1, *updated.SlotNo)
+}
@@ -0,0 +1,194 @@
+package rental
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// 租借转让相关常量
+const (
+	RentalTransferTTL      = 10 * time.Minute // 转让码有效期
+	rentalTransferCodeSize = 8                // 转让码长度
+)
+
+// TransferInfo 租借转让信息
+type TransferInfo struct {
+	RentalID  int64     `json:"rental_id"`
+	Code      string    `json:"code"`
+	Deposit   float64   `json:"deposit"` // 接收方需冻结的押金
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// AcceptTransferRequest 接受转让请求
+type AcceptTransferRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// InitiateTransfer 发起租借转让，生成转让码交给接收方
+// 仅使用中的租借可以转让，每个租借最多转让一次；重新发起时之前未使用的转让码失效
+func (s *RentalService) InitiateTransfer(ctx context.Context, ownerID, rentalID int64) (*TransferInfo, error) {
+	rental, err := s.rentalRepo.GetByID(ctx, rentalID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrRentalNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	if rental.UserID != ownerID {
+		return nil, errors.ErrPermissionDenied
+	}
+	if rental.Status != models.RentalStatusInUse {
+		return nil, errors.ErrRentalStatusError.WithMessage("仅使用中的租借可以转让")
+	}
+	if rental.SubscriptionID != nil {
+		return nil, errors.ErrRentalStatusError.WithMessage("订阅中的租借不能转让")
+	}
+	if usesPreauth(rental) {
+		return nil, errors.ErrRentalStatusError.WithMessage("押金预授权的租借不能转让")
+	}
+
+	var transfer *models.RentalTransfer
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var accepted int64
+		if err := tx.Model(&models.RentalTransfer{}).
+			Where("rental_id = ? AND status = ?", rentalID, models.RentalTransferStatusAccepted).
+			Count(&accepted).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		if accepted > 0 {
+			return errors.ErrRentalTransferred
+		}
+
+		if err := tx.Model(&models.RentalTransfer{}).
+			Where("rental_id = ? AND status = ?", rentalID, models.RentalTransferStatusPending).
+			Update("status", models.RentalTransferStatusCancelled).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		transfer = &models.RentalTransfer{
+			RentalID:   rental.ID,
+			OrderID:    rental.OrderID,
+			FromUserID: ownerID,
+			Code:       utils.GenerateInviteCode(rentalTransferCodeSize),
+			Deposit:    rental.Deposit,
+			Status:     models.RentalTransferStatusPending,
+			ExpiresAt:  time.Now().Add(RentalTransferTTL),
+		}
+		if err := tx.Create(transfer).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TransferInfo{
+		RentalID:  transfer.RentalID,
+		Code:      transfer.Code,
+		Deposit:   transfer.Deposit,
+		ExpiresAt: transfer.ExpiresAt,
+	}, nil
+}
+
+// AcceptTransfer 接受租借转让
+// 在同一事务中退还原用户冻结的押金、冻结接收方等额押金，并将租借及订单转到接收方名下；
+// 接收方余额不足时整体回滚，同一转让码只能被接受一次
+func (s *RentalService) AcceptTransfer(ctx context.Context, newUserID int64, code string) (*RentalInfo, error) {
+	if err := s.checkConcurrentRentals(ctx, newUserID); err != nil {
+		return nil, err
+	}
+
+	var rentalID int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var transfer models.RentalTransfer
+		if err := tx.Where("code = ?", code).First(&transfer).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.ErrTransferCodeInvalid
+			}
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		switch {
+		case transfer.Status == models.RentalTransferStatusAccepted:
+			return errors.ErrTransferCodeInvalid.WithMessage("转让码已被使用")
+		case transfer.Status != models.RentalTransferStatusPending:
+			return errors.ErrTransferCodeInvalid
+		case time.Now().After(transfer.ExpiresAt):
+			return errors.ErrTransferExpired
+		case transfer.FromUserID == newUserID:
+			return errors.ErrInvalidParams.WithMessage("不能接受自己发起的转让")
+		}
+
+		rental, err := s.rentalRepo.GetForUpdate(ctx, tx, transfer.RentalID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.ErrRentalNotFound
+			}
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		if rental.Status != models.RentalStatusInUse || rental.UserID != transfer.FromUserID {
+			return errors.ErrRentalStatusError.WithMessage("租借已结束，无法转让")
+		}
+
+		// 按状态条件更新占用转让码，并发接受时只有一方成功
+		now := time.Now()
+		result := tx.Model(&models.RentalTransfer{}).
+			Where("id = ? AND status = ?", transfer.ID, models.RentalTransferStatusPending).
+			Updates(map[string]interface{}{
+				"status":      models.RentalTransferStatusAccepted,
+				"to_user_id":  newUserID,
+				"deposit":     rental.Deposit,
+				"accepted_at": now,
+			})
+		if result.Error != nil {
+			return errors.ErrDatabaseError.WithError(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrTransferCodeInvalid.WithMessage("转让码已被使用")
+		}
+
+		// 押金随租借转移：退还原用户冻结的押金，冻结接收方等额押金
+		if s.walletService != nil && rental.Deposit > 0 {
+			var order models.Order
+			if err := tx.First(&order, rental.OrderID).Error; err != nil {
+				return errors.ErrDatabaseError.WithError(err)
+			}
+			if err := s.walletService.UnfreezeDepositTx(ctx, tx, transfer.FromUserID, rental.Deposit, order.OrderNo); err != nil {
+				return err
+			}
+			if err := s.walletService.FreezeDepositTx(ctx, tx, newUserID, rental.Deposit, order.OrderNo); err != nil {
+				return err
+			}
+		}
+
+		// 仅当租借仍由原用户使用中时转移，避免与并发的归还、续租交错
+		rentalResult := tx.Model(&models.Rental{}).
+			Where("id = ? AND user_id = ? AND status = ?", rental.ID, transfer.FromUserID, models.RentalStatusInUse).
+			Update("user_id", newUserID)
+		if rentalResult.Error != nil {
+			return errors.ErrDatabaseError.WithError(rentalResult.Error)
+		}
+		if rentalResult.RowsAffected == 0 {
+			return errors.ErrRentalStatusError.WithMessage("租借已结束，无法转让")
+		}
+		if err := tx.Model(&models.Order{}).Where("id = ?", rental.OrderID).Update("user_id", newUserID).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		rentalID = rental.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetRental(ctx, newUserID, rentalID)
+}
@@ -0,0 +1,261 @@
+package rental
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// createInUseRental 创建使用中的租借
+func createInUseRental(t *testing.T, svc *testRentalService) (*models.User, *RentalInfo, *models.RentalPricing) {
+	ctx := context.Background()
+	owner, device, pricing := createTestData(t, svc.db)
+
+	rentalInfo, err := svc.CreateRental(ctx, owner.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.NoError(t, err)
+	require.NoError(t, svc.PayRental(ctx, owner.ID, rentalInfo.ID, ""))
+	require.NoError(t, svc.StartRental(ctx, owner.ID, rentalInfo.ID))
+	return owner, rentalInfo, pricing
+}
+
+// getTestWallet 获取用户钱包
+func getTestWallet(t *testing.T, svc *testRentalService, userID int64) models.UserWallet {
+	var wallet models.UserWallet
+	require.NoError(t, svc.db.Where("user_id = ?", userID).First(&wallet).Error)
+	return wallet
+}
+
+func TestRentalService_Transfer(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	owner, rentalInfo, pricing := createInUseRental(t, svc)
+	friend := createTestUsers(t, svc, 1)[0]
+
+	transfer, err := svc.InitiateTransfer(ctx, owner.ID, rentalInfo.ID)
+	require.NoError(t, err)
+	assert.Len(t, transfer.Code, rentalTransferCodeSize)
+	assert.Equal(t, pricing.Deposit, transfer.Deposit)
+	assert.WithinDuration(t, time.Now().Add(RentalTransferTTL), transfer.ExpiresAt, time.Minute)
+
+	accepted, err := svc.AcceptTransfer(ctx, friend.ID, transfer.Code)
+	require.NoError(t, err)
+	assert.Equal(t, rentalInfo.ID, accepted.ID)
+	assert.Equal(t, models.RentalStatusInUse, accepted.Status)
+
+	// 租借及订单转到接收方名下
+	var order models.Order
+	require.NoError(t, svc.db.First(&order, rentalInfo.OrderID).Error)
+	assert.Equal(t, friend.ID, order.UserID)
+
+	// 原用户押金退还，接收方冻结等额押金
+	ownerWallet := getTestWallet(t, svc, owner.ID)
+	assert.Equal(t, 200.0-pricing.Price, ownerWallet.Balance)
+	assert.Equal(t, 0.0, ownerWallet.FrozenBalance)
+	friendWallet := getTestWallet(t, svc, friend.ID)
+	assert.Equal(t, 200.0-pricing.Deposit, friendWallet.Balance)
+	assert.Equal(t, pricing.Deposit, friendWallet.FrozenBalance)
+
+	// 转让记录
+	var record models.RentalTransfer
+	require.NoError(t, svc.db.Where("code = ?", transfer.Code).First(&record).Error)
+	assert.Equal(t, models.RentalTransferStatusAccepted, record.Status)
+	assert.Equal(t, owner.ID, record.FromUserID)
+	require.NotNil(t, record.ToUserID)
+	assert.Equal(t, friend.ID, *record.ToUserID)
+	assert.NotNil(t, record.AcceptedAt)
+
+	t.Run("原用户不能再操作租借", func(t *testing.T) {
+		_, err := svc.GetRental(ctx, owner.ID, rentalInfo.ID)
+		assert.ErrorIs(t, err, appErrors.ErrPermissionDenied)
+	})
+
+	t.Run("每个租借最多转让一次", func(t *testing.T) {
+		_, err := svc.InitiateTransfer(ctx, friend.ID, rentalInfo.ID)
+		assert.ErrorIs(t, err, appErrors.ErrRentalTransferred)
+	})
+
+	t.Run("接收方归还后押金退还给接收方", func(t *testing.T) {
+		require.NoError(t, svc.ReturnRental(ctx, friend.ID, rentalInfo.ID))
+		require.NoError(t, svc.CompleteRental(ctx, rentalInfo.ID))
+
+		friendWallet := getTestWallet(t, svc, friend.ID)
+		assert.Equal(t, 200.0, friendWallet.Balance)
+		assert.Equal(t, 0.0, friendWallet.FrozenBalance)
+	})
+}
+
+func TestRentalService_AcceptTransfer_InsufficientBalance(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	owner, rentalInfo, pricing := createInUseRental(t, svc)
+	friend := createTestUsers(t, svc, 1)[0]
+	require.NoError(t, svc.db.Model(&models.UserWallet{}).Where("user_id = ?", friend.ID).Update("balance", 10.0).Error)
+
+	transfer, err := svc.InitiateTransfer(ctx, owner.ID, rentalInfo.ID)
+	require.NoError(t, err)
+
+	_, err = svc.AcceptTransfer(ctx, friend.ID, transfer.Code)
+	assert.ErrorIs(t, err, appErrors.ErrBalanceInsufficient)
+
+	// 整体回滚：租借、押金及转让码均不变
+	var rental models.Rental
+	require.NoError(t, svc.db.First(&rental, rentalInfo.ID).Error)
+	assert.Equal(t, owner.ID, rental.UserID)
+	assert.Equal(t, pricing.Deposit, getTestWallet(t, svc, owner.ID).FrozenBalance)
+	assert.Equal(t, 10.0, getTestWallet(t, svc, friend.ID).Balance)
+
+	var record models.RentalTransfer
+	require.NoError(t, svc.db.Where("code = ?", transfer.Code).First(&record).Error)
+	assert.Equal(t, models.RentalTransferStatusPending, record.Status)
+}
+
+func TestRentalService_AcceptTransfer_RentalReturnedAfterLock(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	owner, rentalInfo, pricing := createInUseRental(t, svc)
+	friend := createTestUsers(t, svc, 1)[0]
+
+	transfer, err := svc.InitiateTransfer(ctx, owner.ID, rentalInfo.ID)
+	require.NoError(t, err)
+
+	// 加锁读取租借后模拟并发归还（SQLite 无行锁，在同一事务内改写以模拟读到旧数据）
+	var fired bool
+	require.NoError(t, svc.db.Callback().Query().After("gorm:query").Register("test:concurrent_return", func(tx *gorm.DB) {
+		if _, locked := tx.Statement.Clauses["FOR"]; fired || !locked || tx.Statement.Table != "rentals" {
+			return
+		}
+		fired = true
+		require.NoError(t, tx.Session(&gorm.Session{NewDB: true}).
+			Exec("UPDATE rentals SET status = ? WHERE id = ?", models.RentalStatusReturned, rentalInfo.ID).Error)
+	}))
+
+	_, err = svc.AcceptTransfer(ctx, friend.ID, transfer.Code)
+	require.True(t, fired)
+	var appErr *appErrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, appErrors.ErrRentalStatusError.Code, appErr.Code)
+
+	// 整体回滚：租借、押金及转让码均不变
+	var rental models.Rental
+	require.NoError(t, svc.db.First(&rental, rentalInfo.ID).Error)
+	assert.Equal(t, owner.ID, rental.UserID)
+	assert.Equal(t, pricing.Deposit, getTestWallet(t, svc, owner.ID).FrozenBalance)
+	assert.Equal(t, 0.0, getTestWallet(t, svc, friend.ID).FrozenBalance)
+
+	var record models.RentalTransfer
+	require.NoError(t, svc.db.Where("code = ?", transfer.Code).First(&record).Error)
+	assert.Equal(t, models.RentalTransferStatusPending, record.Status)
+}
+
+func TestRentalService_AcceptTransfer_Invalid(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	owner, rentalInfo, _ := createInUseRental(t, svc)
+	friend := createTestUsers(t, svc, 1)[0]
+
+	t.Run("转让码已过期", func(t *testing.T) {
+		transfer, err := svc.InitiateTransfer(ctx, owner.ID, rentalInfo.ID)
+		require.NoError(t, err)
+		require.NoError(t, svc.db.Model(&models.RentalTransfer{}).Where("code = ?", transfer.Code).
+			Update("expires_at", time.Now().Add(-time.Second)).Error)
+
+		_, err = svc.AcceptTransfer(ctx, friend.ID, transfer.Code)
+		assert.ErrorIs(t, err, appErrors.ErrTransferExpired)
+	})
+
+	t.Run("重新发起后旧转让码失效", func(t *testing.T) {
+		first, err := svc.InitiateTransfer(ctx, owner.ID, rentalInfo.ID)
+		require.NoError(t, err)
+		_, err = svc.InitiateTransfer(ctx, owner.ID, rentalInfo.ID)
+		require.NoError(t, err)
+
+		_, err = svc.AcceptTransfer(ctx, friend.ID, first.Code)
+		assert.ErrorIs(t, err, appErrors.ErrTransferCodeInvalid)
+	})
+
+	t.Run("转让码不存在", func(t *testing.T) {
+		_, err := svc.AcceptTransfer(ctx, friend.ID, "NOTEXIST")
+		assert.ErrorIs(t, err, appErrors.ErrTransferCodeInvalid)
+	})
+
+	t.Run("不能接受自己发起的转让", func(t *testing.T) {
+		transfer, err := svc.InitiateTransfer(ctx, owner.ID, rentalInfo.ID)
+		require.NoError(t, err)
+		_, err = svc.AcceptTransfer(ctx, owner.ID, transfer.Code)
+		assert.Error(t, err)
+	})
+
+	t.Run("只有租借本人可以发起转让", func(t *testing.T) {
+		_, err := svc.InitiateTransfer(ctx, friend.ID, rentalInfo.ID)
+		assert.ErrorIs(t, err, appErrors.ErrPermissionDenied)
+	})
+
+	t.Run("仅使用中的租借可以转让", func(t *testing.T) {
+		require.NoError(t, svc.ReturnRental(ctx, owner.ID, rentalInfo.ID))
+		_, err := svc.InitiateTransfer(ctx, owner.ID, rentalInfo.ID)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrRentalStatusError.Code, appErr.Code)
+	})
+}
+
+func TestRentalService_AcceptTransfer_ConcurrentAccept(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+
+	// 内存 SQLite 每个连接是独立的数据库，并发测试需要共享单连接
+	sqlDB, err := svc.db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	owner, rentalInfo, pricing := createInUseRental(t, svc)
+	friends := createTestUsers(t, svc, 5)
+
+	transfer, err := svc.InitiateTransfer(ctx, owner.ID, rentalInfo.ID)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make([]error, len(friends))
+	for i, friend := range friends {
+		wg.Add(1)
+		go func(i int, userID int64) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = svc.AcceptTransfer(ctx, userID, transfer.Code)
+		}(i, friend.ID)
+	}
+	close(start)
+	wg.Wait()
+
+	var winner int64
+	succeeded := 0
+	for i, err := range errs {
+		if err == nil {
+			succeeded++
+			winner = friends[i].ID
+			continue
+		}
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrTransferCodeInvalid.Code, appErr.Code)
+	}
+	require.Equal(t, 1, succeeded)
+
+	var rental models.Rental
+	require.NoError(t, svc.db.First(&rental, rentalInfo.ID).Error)
+	assert.Equal(t, winner, rental.UserID)
+
+	// 只有一位接收方冻结了押金
+	var frozen float64
+	require.NoError(t, svc.db.Model(&models.UserWallet{}).Select("SUM(frozen_balance)").Scan(&frozen).Error)
+	assert.Equal(t, pricing.Deposit, frozen)
+}
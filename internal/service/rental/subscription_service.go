@@ -0,0 +1,472 @@
+package rental
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/cache"
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// 租借订阅相关常量
+const (
+	SubscriptionRenewalWindow = 24 * time.Hour // 周期结束前多久开始续订
+	renewSubscriptionBatch    = 100            // 每次最多续订的订阅数
+)
+
+// subscriptionPeriodHours 订阅周期时长（小时），订阅所选定价的时长须与之一致
+var subscriptionPeriodHours = map[string]int{
+	models.SubscriptionPeriodWeekly:  7 * 24,
+	models.SubscriptionPeriodMonthly: 30 * 24,
+}
+
+// SubscriptionService 租借订阅服务
+// 订阅按周期预先从余额扣费，每个周期生成一条已支付的租借，续订时格口直接交接给新周期的租借，
+// 订阅期间格口不会被其他用户预占
+type SubscriptionService struct {
+	db      *gorm.DB
+	rentals *RentalService
+}
+
+// NewSubscriptionService 创建租借订阅服务
+func NewSubscriptionService(db *gorm.DB, rentalSvc *RentalService) *SubscriptionService {
+	return &SubscriptionService{
+		db:      db,
+		rentals: rentalSvc,
+	}
+}
+
+// CreateSubscriptionRequest 创建订阅请求
+type CreateSubscriptionRequest struct {
+	DeviceID  int64  `json:"device_id" binding:"required"`
+	PricingID int64  `json:"pricing_id" binding:"required"`                  // 时长与订阅周期一致的定价
+	Period    string `json:"period" binding:"required,oneof=weekly monthly"` // weekly / monthly
+}
+
+// SubscriptionInfo 订阅信息
+type SubscriptionInfo struct {
+	ID              int64      `json:"id"`
+	DeviceID        int64      `json:"device_id"`
+	Period          string     `json:"period"`
+	PricePerPeriod  float64    `json:"price_per_period"`
+	StartDate       time.Time  `json:"start_date"`
+	EndDate         time.Time  `json:"end_date"`
+	Status          string     `json:"status"`
+	CurrentRentalID *int64     `json:"current_rental_id,omitempty"`
+	SlotNo          *int       `json:"slot_no,omitempty"`
+	PausedAt        *time.Time `json:"paused_at,omitempty"`
+	CancelledAt     *time.Time `json:"cancelled_at,omitempty"`
+}
+
+// RenewResult 续订任务结果
+type RenewResult struct {
+	Renewed   int `json:"renewed"`   // 续订成功数
+	Paused    int `json:"paused"`    // 余额不足暂停数
+	Cancelled int `json:"cancelled"` // 当前租借已结束而终止的订阅数
+}
+
+// CreateSubscription 创建订阅并支付首个周期
+// 首个周期的租借创建后即为已支付状态，用户按正常流程开锁取货；订阅费用按定价收取，不享受会员折扣，不收取押金
+func (s *SubscriptionService) CreateSubscription(ctx context.Context, userID int64, req *CreateSubscriptionRequest) (*SubscriptionInfo, error) {
+	periodHours, ok := subscriptionPeriodHours[req.Period]
+	if !ok {
+		return nil, errors.ErrInvalidParams.WithMessage("订阅周期须为 weekly 或 monthly")
+	}
+
+	pricing, err := s.rentals.GetEffectivePricing(ctx, req.DeviceID, req.PricingID)
+	if err != nil {
+		return nil, err
+	}
+	if pricing.DurationHours != periodHours {
+		return nil, errors.ErrInvalidParams.WithMessage("所选定价的时长与订阅周期不一致")
+	}
+	if pricing.Price <= 0 {
+		return nil, errors.ErrInvalidParams.WithMessage("订阅费用必须大于0")
+	}
+
+	if err := s.rentals.checkConcurrentRentals(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.rentals.deviceService.CheckDeviceAvailable(ctx, req.DeviceID); err != nil {
+		return nil, err
+	}
+
+	// 加设备槽位锁，与按次租借共用，防止并发下重复预占同一槽位
+	if s.rentals.locker != nil {
+		lock, err := s.rentals.locker.Acquire(ctx, deviceSlotLockKey(req.DeviceID))
+		if err != nil {
+			if stderrors.Is(err, cache.ErrLockNotAcquired) {
+				return nil, errors.ErrDeviceBusy
+			}
+			return nil, errors.ErrCacheError.WithError(err)
+		}
+		defer lock.Release(context.WithoutCancel(ctx))
+	}
+
+	var sub *models.RentalSubscription
+	var rental *models.Rental
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.rentals.slotRepo.EnsureTx(ctx, tx, req.DeviceID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		sub = &models.RentalSubscription{
+			UserID:         userID,
+			DeviceID:       req.DeviceID,
+			PricingID:      pricing.ID,
+			Period:         req.Period,
+			PricePerPeriod: pricing.Price,
+			StartDate:      now,
+			EndDate:        now.Add(time.Duration(periodHours) * time.Hour),
+			Status:         models.SubscriptionStatusActive,
+		}
+		if err := tx.Create(sub).Error; err != nil {
+			return err
+		}
+
+		rental, err = s.createPeriodRentalTx(ctx, tx, sub, pricing.OvertimeRate, models.RentalStatusPaid, nil)
+		if err != nil {
+			return err
+		}
+
+		// 预占空闲格口并减少设备可用槽位
+		slotNo, err := s.rentals.slotRepo.AllocateTx(ctx, tx, req.DeviceID, rental.ID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.ErrDeviceNoSlot
+			}
+			return err
+		}
+		rental.SlotNo = &slotNo
+		if err := tx.Model(rental).Update("slot_no", slotNo).Error; err != nil {
+			return err
+		}
+
+		sub.CurrentRentalID = &rental.ID
+		return tx.Model(sub).Update("current_rental_id", rental.ID).Error
+	})
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return nil, appErr
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	s.rentals.publishRentalStatus(ctx, rental.ID, userID, models.RentalStatusPending, models.RentalStatusPaid)
+	return toSubscriptionInfo(sub, rental), nil
+}
+
+// createPeriodRentalTx 创建订阅周期的订单和租借，并从余额扣除周期费用
+// 租借覆盖订阅当前周期（截止 sub.EndDate），status 为已支付或使用中；unlockedAt 为上一周期的开锁时间
+func (s *SubscriptionService) createPeriodRentalTx(ctx context.Context, tx *gorm.DB, sub *models.RentalSubscription, overtimeRate float64, status string, unlockedAt *time.Time) (*models.Rental, error) {
+	now := time.Now()
+	order := &models.Order{
+		OrderNo:        utils.GenerateOrderNo("O"),
+		UserID:         sub.UserID,
+		Type:           models.OrderTypeRental,
+		OriginalAmount: sub.PricePerPeriod,
+		ActualAmount:   sub.PricePerPeriod,
+		Status:         models.OrderStatusPaid,
+		PaidAt:         &now,
+	}
+	if err := tx.Create(order).Error; err != nil {
+		return nil, err
+	}
+
+	if s.rentals.walletService != nil {
+		if err := s.rentals.walletService.ConsumeTx(ctx, tx, sub.UserID, sub.PricePerPeriod, order.OrderNo); err != nil {
+			return nil, err
+		}
+	}
+
+	expectedReturn := sub.EndDate
+	pricingID := sub.PricingID
+	rental := &models.Rental{
+		OrderID:          order.ID,
+		UserID:           sub.UserID,
+		DeviceID:         sub.DeviceID,
+		PricingID:        &pricingID,
+		DurationHours:    subscriptionPeriodHours[sub.Period],
+		OriginalFee:      sub.PricePerPeriod,
+		DiscountRate:     noDiscount,
+		RentalFee:        sub.PricePerPeriod,
+		OvertimeRate:     overtimeRate,
+		Status:           status,
+		UnlockedAt:       unlockedAt,
+		ExpectedReturnAt: &expectedReturn,
+		SubscriptionID:   &sub.ID,
+	}
+	if err := tx.Create(rental).Error; err != nil {
+		return nil, err
+	}
+	return rental, nil
+}
+
+// CancelSubscription 取消订阅
+// 取消后不再续订，当前周期的租借继续有效，用户在周期结束前按正常流程归还
+func (s *SubscriptionService) CancelSubscription(ctx context.Context, userID, subscriptionID int64) (*SubscriptionInfo, error) {
+	var sub models.RentalSubscription
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sub, subscriptionID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.ErrSubscriptionNotFound
+			}
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		if sub.UserID != userID {
+			return errors.ErrPermissionDenied
+		}
+		if sub.Status == models.SubscriptionStatusCancelled {
+			return errors.ErrSubscriptionStatusError.WithMessage("订阅已取消")
+		}
+
+		now := time.Now()
+		result := tx.Model(&models.RentalSubscription{}).
+			Where("id = ? AND status = ?", sub.ID, sub.Status).
+			Updates(map[string]interface{}{
+				"status":       models.SubscriptionStatusCancelled,
+				"cancelled_at": now,
+			})
+		if result.Error != nil {
+			return errors.ErrDatabaseError.WithError(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrSubscriptionStatusError.WithMessage("订阅状态已变更，请重试")
+		}
+		sub.Status = models.SubscriptionStatusCancelled
+		sub.CancelledAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toSubscriptionInfo(&sub, nil), nil
+}
+
+// ListSubscriptions 获取用户的订阅列表，按创建时间倒序
+func (s *SubscriptionService) ListSubscriptions(ctx context.Context, userID int64) ([]*SubscriptionInfo, error) {
+	var subs []*models.RentalSubscription
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("created_at DESC").Order("id DESC").
+		Find(&subs).Error; err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	list := make([]*SubscriptionInfo, len(subs))
+	for i, sub := range subs {
+		list[i] = toSubscriptionInfo(sub, nil)
+	}
+	return list, nil
+}
+
+// RenewSubscriptions 续订即将到期的订阅，由每日任务调用
+// 周期在 SubscriptionRenewalWindow 内结束的生效中订阅从余额扣除下一周期费用并生成新周期的租借，
+// 上一周期的租借随之结算完成；余额不足时订阅暂停，当前租借到期后按超时处理。
+// 每次最多处理 renewSubscriptionBatch 条，单条失败不影响其余订阅
+func (s *SubscriptionService) RenewSubscriptions(ctx context.Context) (*RenewResult, error) {
+	var ids []int64
+	if err := s.db.WithContext(ctx).Model(&models.RentalSubscription{}).
+		Where("status = ? AND end_date <= ?", models.SubscriptionStatusActive, time.Now().Add(SubscriptionRenewalWindow)).
+		Order("end_date ASC").
+		Limit(renewSubscriptionBatch).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	result := &RenewResult{}
+	var errs []error
+	for _, id := range ids {
+		status, err := s.renewSubscription(ctx, id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("subscription %d: %w", id, err))
+			continue
+		}
+		switch status {
+		case models.SubscriptionStatusActive:
+			result.Renewed++
+		case models.SubscriptionStatusPaused:
+			result.Paused++
+		case models.SubscriptionStatusCancelled:
+			result.Cancelled++
+		}
+	}
+
+	return result, stderrors.Join(errs...)
+}
+
+// errSubscriptionChanged 续订过程中订阅已被取消或由其他实例续订
+var errSubscriptionChanged = stderrors.New("subscription changed during renewal")
+
+// updateActiveSubscriptionTx 仅当订阅仍生效且周期结束时间未变化时更新，否则返回 errSubscriptionChanged
+func updateActiveSubscriptionTx(tx *gorm.DB, sub *models.RentalSubscription, endDate time.Time, updates map[string]interface{}) error {
+	result := tx.Model(&models.RentalSubscription{}).
+		Where("id = ? AND status = ? AND end_date = ?", sub.ID, models.SubscriptionStatusActive, endDate).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errSubscriptionChanged
+	}
+	return nil
+}
+
+// renewSubscription 锁定并续订单个订阅，返回续订后的订阅状态；订阅已不需要续订时返回空字符串
+func (s *SubscriptionService) renewSubscription(ctx context.Context, subscriptionID int64) (string, error) {
+	var sub models.RentalSubscription
+	var previous, next *models.Rental
+	var previousStatus string
+	var settled models.Order
+	var status string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sub, subscriptionID).Error; err != nil {
+			return err
+		}
+
+		// 查询后可能已被取消或由其他实例续订
+		if sub.Status != models.SubscriptionStatusActive || sub.EndDate.After(time.Now().Add(SubscriptionRenewalWindow)) {
+			return nil
+		}
+
+		var err error
+		if sub.CurrentRentalID != nil {
+			previous, err = s.rentals.rentalRepo.GetForUpdate(ctx, tx, *sub.CurrentRentalID)
+			if err != nil && err != gorm.ErrRecordNotFound {
+				return err
+			}
+		}
+
+		// 当前租借已结束（如管理员强制结束）时格口已释放，订阅随之终止
+		if previous == nil || !isSubscriptionRentalActive(previous.Status) {
+			status = models.SubscriptionStatusCancelled
+			return updateActiveSubscriptionTx(tx, &sub, sub.EndDate, map[string]interface{}{
+				"status":       models.SubscriptionStatusCancelled,
+				"cancelled_at": time.Now(),
+			})
+		}
+
+		previousStatus = previous.Status
+		nextStatus := models.RentalStatusPaid
+		if previous.Status != models.RentalStatusPaid {
+			nextStatus = models.RentalStatusInUse
+		}
+
+		previousEndDate := sub.EndDate
+		sub.EndDate = sub.EndDate.Add(time.Duration(subscriptionPeriodHours[sub.Period]) * time.Hour)
+		next, err = s.createPeriodRentalTx(ctx, tx, &sub, previous.OvertimeRate, nextStatus, previous.UnlockedAt)
+		if err != nil {
+			return err
+		}
+
+		// 格口直接交接给新周期的租借，期间不会被其他用户预占
+		if err := s.rentals.slotRepo.HandOverTx(ctx, tx, previous, next.ID); err != nil {
+			return err
+		}
+		next.SlotNo = previous.SlotNo
+		if err := tx.Model(next).Update("slot_no", *next.SlotNo).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Device{}).
+			Where("id = ? AND current_rental_id = ?", sub.DeviceID, previous.ID).
+			Update("current_rental_id", next.ID).Error; err != nil {
+			return err
+		}
+
+		// 上一周期的租借结算完成
+		if err := s.rentals.settleRentalTx(ctx, tx, previous, &settled); err != nil {
+			return err
+		}
+
+		status = models.SubscriptionStatusActive
+		return updateActiveSubscriptionTx(tx, &sub, previousEndDate, map[string]interface{}{
+			"end_date":          sub.EndDate,
+			"current_rental_id": next.ID,
+		})
+	})
+	if stderrors.Is(err, errSubscriptionChanged) {
+		// 续订期间订阅已被取消或续订，本次扣费及新周期租借随事务回滚
+		return "", nil
+	}
+	if err != nil {
+		// 余额不足时暂停订阅，不再自动续订
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrBalanceInsufficient.Code {
+			return s.pauseSubscription(ctx, subscriptionID)
+		}
+		return "", err
+	}
+
+	if status == models.SubscriptionStatusActive {
+		s.rentals.completePreauth(ctx, previous)
+		if s.rentals.orderEvents != nil {
+			_ = s.rentals.orderEvents.OnOrderCompleted(ctx, &settled)
+		}
+		s.rentals.publishRentalStatus(ctx, previous.ID, sub.UserID, previousStatus, models.RentalStatusCompleted)
+		s.rentals.publishRentalStatus(ctx, next.ID, sub.UserID, models.RentalStatusPending, next.Status)
+	}
+	return status, nil
+}
+
+// pauseSubscription 暂停生效中的订阅
+func (s *SubscriptionService) pauseSubscription(ctx context.Context, subscriptionID int64) (string, error) {
+	result := s.db.WithContext(ctx).Model(&models.RentalSubscription{}).
+		Where("id = ? AND status = ?", subscriptionID, models.SubscriptionStatusActive).
+		Updates(map[string]interface{}{
+			"status":    models.SubscriptionStatusPaused,
+			"paused_at": time.Now(),
+		})
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return "", nil
+	}
+	return models.SubscriptionStatusPaused, nil
+}
+
+// endSubscriptionTx 订阅租借归还时终止所属订阅，格口已释放，不再续订
+func endSubscriptionTx(tx *gorm.DB, rental *models.Rental) error {
+	if rental.SubscriptionID == nil {
+		return nil
+	}
+	return tx.Model(&models.RentalSubscription{}).
+		Where("id = ? AND status <> ?", *rental.SubscriptionID, models.SubscriptionStatusCancelled).
+		Updates(map[string]interface{}{
+			"status":       models.SubscriptionStatusCancelled,
+			"cancelled_at": time.Now(),
+		}).Error
+}
+
+// isSubscriptionRentalActive 订阅当前周期的租借是否仍占用格口
+func isSubscriptionRentalActive(status string) bool {
+	return status == models.RentalStatusPaid || status == models.RentalStatusInUse || status == models.RentalStatusOverdue
+}
+
+// toSubscriptionInfo 转换订阅信息，rental 为当前周期的租借，可为空
+func toSubscriptionInfo(sub *models.RentalSubscription, rental *models.Rental) *SubscriptionInfo {
+	info := &SubscriptionInfo{
+		ID:              sub.ID,
+		DeviceID:        sub.DeviceID,
+		Period:          sub.Period,
+		PricePerPeriod:  sub.PricePerPeriod,
+		StartDate:       sub.StartDate,
+		EndDate:         sub.EndDate,
+		Status:          sub.Status,
+		CurrentRentalID: sub.CurrentRentalID,
+		PausedAt:        sub.PausedAt,
+		CancelledAt:     sub.CancelledAt,
+	}
+	if rental != nil {
+		info.SlotNo = rental.SlotNo
+	}
+	return info
+}
@@ -0,0 +1,285 @@
+package rental
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// createTestSubscription 创建按周订阅，返回订阅用户、设备、周定价及订阅信息
+func createTestSubscription(t *testing.T, svc *testRentalService) (*SubscriptionService, *models.User, *models.Device, *SubscriptionInfo) {
+	user, device, pricing := createTestData(t, svc.db)
+	weekly := &models.RentalPricing{
+		VenueID:       pricing.VenueID,
+		DurationHours: 7 * 24,
+		Price:         70.0,
+		OvertimeRate:  1.5,
+		IsActive:      true,
+	}
+	require.NoError(t, svc.db.Create(weekly).Error)
+
+	subSvc := NewSubscriptionService(svc.db, svc.RentalService)
+	sub, err := subSvc.CreateSubscription(context.Background(), user.ID, &CreateSubscriptionRequest{
+		DeviceID:  device.ID,
+		PricingID: weekly.ID,
+		Period:    models.SubscriptionPeriodWeekly,
+	})
+	require.NoError(t, err)
+	return subSvc, user, device, sub
+}
+
+// makeSubscriptionDue 将订阅当前周期的结束时间提前到续订窗口内
+func makeSubscriptionDue(t *testing.T, svc *testRentalService, subscriptionID int64) time.Time {
+	endDate := time.Now().Add(time.Hour)
+	require.NoError(t, svc.db.Model(&models.RentalSubscription{}).Where("id = ?", subscriptionID).
+		Update("end_date", endDate).Error)
+	return endDate
+}
+
+func TestSubscriptionService_CreateSubscription(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	_, user, device, sub := createTestSubscription(t, svc)
+
+	assert.Equal(t, models.SubscriptionStatusActive, sub.Status)
+	assert.Equal(t, 70.0, sub.PricePerPeriod)
+	assert.WithinDuration(t, time.Now().Add(7*24*time.Hour), sub.EndDate, time.Minute)
+	require.NotNil(t, sub.CurrentRentalID)
+	require.NotNil(t, sub.SlotNo)
+
+	// 首个周期费用已扣除，租借为已支付状态，不收取押金
+	assert.Equal(t, 130.0, getTestWallet(t, svc, user.ID).Balance)
+	var rental models.Rental
+	require.NoError(t, svc.db.First(&rental, *sub.CurrentRentalID).Error)
+	assert.Equal(t, models.RentalStatusPaid, rental.Status)
+	assert.Equal(t, 0.0, rental.Deposit)
+	require.NotNil(t, rental.SubscriptionID)
+	assert.Equal(t, sub.ID, *rental.SubscriptionID)
+
+	other := createTestUsers(t, svc, 1)[0]
+
+	t.Run("订阅的格口不会被其他用户预占", func(t *testing.T) {
+		var pricing models.RentalPricing
+		require.NoError(t, svc.db.Where("duration_hours = ?", 1).First(&pricing).Error)
+
+		_, err := svc.CreateRental(ctx, other.ID, &CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+		assert.ErrorIs(t, err, appErrors.ErrDeviceNoSlot)
+	})
+
+	t.Run("定价时长与订阅周期不一致", func(t *testing.T) {
+		subSvc := NewSubscriptionService(svc.db, svc.RentalService)
+		_, err := subSvc.CreateSubscription(ctx, other.ID, &CreateSubscriptionRequest{
+			DeviceID:  device.ID,
+			PricingID: *rental.PricingID,
+			Period:    models.SubscriptionPeriodMonthly,
+		})
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrInvalidParams.Code, appErr.Code)
+	})
+}
+
+func TestSubscriptionService_RenewSubscriptions(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	subSvc, user, device, sub := createTestSubscription(t, svc)
+	require.NoError(t, svc.StartRental(ctx, user.ID, *sub.CurrentRentalID))
+	endDate := makeSubscriptionDue(t, svc, sub.ID)
+
+	result, err := subSvc.RenewSubscriptions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &RenewResult{Renewed: 1}, result)
+
+	// 扣除下一周期费用，周期顺延
+	assert.Equal(t, 60.0, getTestWallet(t, svc, user.ID).Balance)
+	var renewed models.RentalSubscription
+	require.NoError(t, svc.db.First(&renewed, sub.ID).Error)
+	assert.Equal(t, models.SubscriptionStatusActive, renewed.Status)
+	assert.WithinDuration(t, endDate.Add(7*24*time.Hour), renewed.EndDate, time.Second)
+	require.NotNil(t, renewed.CurrentRentalID)
+	assert.NotEqual(t, *sub.CurrentRentalID, *renewed.CurrentRentalID)
+
+	// 上一周期的租借结算完成
+	var previous models.Rental
+	require.NoError(t, svc.db.First(&previous, *sub.CurrentRentalID).Error)
+	assert.Equal(t, models.RentalStatusCompleted, previous.Status)
+
+	// 新周期的租借沿用格口并保持使用中
+	var next models.Rental
+	require.NoError(t, svc.db.First(&next, *renewed.CurrentRentalID).Error)
+	assert.Equal(t, models.RentalStatusInUse, next.Status)
+	assert.Equal(t, *sub.SlotNo, *next.SlotNo)
+	require.NotNil(t, next.ExpectedReturnAt)
+	assert.WithinDuration(t, renewed.EndDate, *next.ExpectedReturnAt, time.Second)
+
+	var slot models.DeviceSlot
+	require.NoError(t, svc.db.Where("device_id = ? AND slot_no = ?", device.ID, *sub.SlotNo).First(&slot).Error)
+	assert.Equal(t, int8(models.DeviceSlotInUse), slot.Status)
+	require.NotNil(t, slot.CurrentRentalID)
+	assert.Equal(t, next.ID, *slot.CurrentRentalID)
+
+	var refreshed models.Device
+	require.NoError(t, svc.db.First(&refreshed, device.ID).Error)
+	assert.Equal(t, 0, refreshed.AvailableSlots)
+	require.NotNil(t, refreshed.CurrentRentalID)
+	assert.Equal(t, next.ID, *refreshed.CurrentRentalID)
+
+	t.Run("未到续订窗口的订阅不会重复续订", func(t *testing.T) {
+		result, err := subSvc.RenewSubscriptions(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, &RenewResult{}, result)
+		assert.Equal(t, 60.0, getTestWallet(t, svc, user.ID).Balance)
+	})
+
+	t.Run("订阅租借不能单独续租或转让", func(t *testing.T) {
+		_, err := svc.ExtendRental(ctx, user.ID, next.ID, 1)
+		assert.Error(t, err)
+		_, err = svc.InitiateTransfer(ctx, user.ID, next.ID)
+		assert.Error(t, err)
+	})
+}
+
+func TestSubscriptionService_RenewSubscriptions_InsufficientBalance(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	subSvc, user, device, sub := createTestSubscription(t, svc)
+	require.NoError(t, svc.db.Model(&models.UserWallet{}).Where("user_id = ?", user.ID).Update("balance", 10.0).Error)
+	endDate := makeSubscriptionDue(t, svc, sub.ID)
+
+	result, err := subSvc.RenewSubscriptions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &RenewResult{Paused: 1}, result)
+
+	// 订阅暂停，余额及当前租借不变
+	var paused models.RentalSubscription
+	require.NoError(t, svc.db.First(&paused, sub.ID).Error)
+	assert.Equal(t, models.SubscriptionStatusPaused, paused.Status)
+	assert.NotNil(t, paused.PausedAt)
+	assert.WithinDuration(t, endDate, paused.EndDate, time.Second)
+	assert.Equal(t, *sub.CurrentRentalID, *paused.CurrentRentalID)
+	assert.Equal(t, 10.0, getTestWallet(t, svc, user.ID).Balance)
+
+	var rental models.Rental
+	require.NoError(t, svc.db.First(&rental, *sub.CurrentRentalID).Error)
+	assert.Equal(t, models.RentalStatusPaid, rental.Status)
+
+	var rentals int64
+	require.NoError(t, svc.db.Model(&models.Rental{}).Where("device_id = ?", device.ID).Count(&rentals).Error)
+	assert.Equal(t, int64(1), rentals)
+
+	t.Run("暂停的订阅不再自动续订", func(t *testing.T) {
+		require.NoError(t, svc.db.Model(&models.UserWallet{}).Where("user_id = ?", user.ID).Update("balance", 200.0).Error)
+		result, err := subSvc.RenewSubscriptions(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, &RenewResult{}, result)
+		assert.Equal(t, 200.0, getTestWallet(t, svc, user.ID).Balance)
+	})
+}
+
+func TestSubscriptionService_RenewSubscriptions_CancelledDuringRenewal(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	subSvc, user, _, sub := createTestSubscription(t, svc)
+	require.NoError(t, svc.StartRental(ctx, user.ID, *sub.CurrentRentalID))
+	makeSubscriptionDue(t, svc, sub.ID)
+
+	var rentalCount int64
+	require.NoError(t, svc.db.Model(&models.Rental{}).Count(&rentalCount).Error)
+
+	// 续订读取订阅后模拟用户取消（SQLite 无行锁，在同一事务内改写以模拟读到旧数据）
+	var fired bool
+	require.NoError(t, svc.db.Callback().Query().After("gorm:query").Register("test:cancel_during_renewal", func(tx *gorm.DB) {
+		if fired || tx.Statement.Table != "rental_subscriptions" {
+			return
+		}
+		fired = true
+		require.NoError(t, tx.Session(&gorm.Session{NewDB: true}).
+			Exec("UPDATE rental_subscriptions SET status = ? WHERE id = ?", models.SubscriptionStatusCancelled, sub.ID).Error)
+	}))
+
+	status, err := subSvc.renewSubscription(ctx, sub.ID)
+	require.True(t, fired)
+	require.NoError(t, err)
+	assert.Empty(t, status)
+
+	// 条件更新未命中，扣费和新周期租借随事务回滚
+	assert.Equal(t, 130.0, getTestWallet(t, svc, user.ID).Balance)
+	var afterCount int64
+	require.NoError(t, svc.db.Model(&models.Rental{}).Count(&afterCount).Error)
+	assert.Equal(t, rentalCount, afterCount)
+
+	var previous models.Rental
+	require.NoError(t, svc.db.First(&previous, *sub.CurrentRentalID).Error)
+	assert.Equal(t, models.RentalStatusInUse, previous.Status)
+}
+
+func TestSubscriptionService_CancelSubscription(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	subSvc, user, device, sub := createTestSubscription(t, svc)
+	other := createTestUsers(t, svc, 1)[0]
+
+	t.Run("只有订阅本人可以取消", func(t *testing.T) {
+		_, err := subSvc.CancelSubscription(ctx, other.ID, sub.ID)
+		assert.ErrorIs(t, err, appErrors.ErrPermissionDenied)
+	})
+
+	cancelled, err := subSvc.CancelSubscription(ctx, user.ID, sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SubscriptionStatusCancelled, cancelled.Status)
+	assert.NotNil(t, cancelled.CancelledAt)
+
+	t.Run("取消后不再续订，当前租借继续有效", func(t *testing.T) {
+		makeSubscriptionDue(t, svc, sub.ID)
+		result, err := subSvc.RenewSubscriptions(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, &RenewResult{}, result)
+		assert.Equal(t, 130.0, getTestWallet(t, svc, user.ID).Balance)
+
+		var rental models.Rental
+		require.NoError(t, svc.db.First(&rental, *sub.CurrentRentalID).Error)
+		assert.Equal(t, models.RentalStatusPaid, rental.Status)
+	})
+
+	t.Run("不能重复取消", func(t *testing.T) {
+		_, err := subSvc.CancelSubscription(ctx, user.ID, sub.ID)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrSubscriptionStatusError.Code, appErr.Code)
+	})
+
+	t.Run("订阅不存在", func(t *testing.T) {
+		_, err := subSvc.CancelSubscription(ctx, user.ID, 99999)
+		assert.ErrorIs(t, err, appErrors.ErrSubscriptionNotFound)
+	})
+
+	t.Run("归还后释放格口", func(t *testing.T) {
+		require.NoError(t, svc.StartRental(ctx, user.ID, *sub.CurrentRentalID))
+		require.NoError(t, svc.ReturnRental(ctx, user.ID, *sub.CurrentRentalID))
+
+		var refreshed models.Device
+		require.NoError(t, svc.db.First(&refreshed, device.ID).Error)
+		assert.Equal(t, 1, refreshed.AvailableSlots)
+	})
+}
+
+func TestSubscriptionService_ReturnRentalEndsSubscription(t *testing.T) {
+	svc := setupTestRentalService(t)
+	ctx := context.Background()
+	subSvc, user, _, sub := createTestSubscription(t, svc)
+	require.NoError(t, svc.StartRental(ctx, user.ID, *sub.CurrentRentalID))
+	require.NoError(t, svc.ReturnRental(ctx, user.ID, *sub.CurrentRentalID))
+
+	subs, err := subSvc.ListSubscriptions(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, models.SubscriptionStatusCancelled, subs[0].Status)
+	assert.NotNil(t, subs[0].CancelledAt)
+}
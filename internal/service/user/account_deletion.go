@@ -0,0 +1,277 @@
+package user
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// DeletionGracePeriod 注销冷静期，冷静期内可撤销注销申请
+const DeletionGracePeriod = 7 * 24 * time.Hour
+
+// deletedUserNickname 注销后匿名化的昵称
+const deletedUserNickname = "已注销用户"
+
+// processDeletionBatch 每次执行的注销申请数上限
+const processDeletionBatch = 100
+
+// 注销阻断原因类型
+const (
+	DeletionBlockerRental     = "rental"     // 进行中的租借
+	DeletionBlockerBooking    = "booking"    // 未完成的酒店预订
+	DeletionBlockerFrozen     = "frozen"     // 钱包冻结金额（押金）
+	DeletionBlockerWithdrawal = "withdrawal" // 处理中的提现
+)
+
+// TokenRevoker 令牌吊销接口，注销完成后吊销用户全部登录会话
+type TokenRevoker interface {
+	// RevokeUser 吊销用户全部未过期的令牌，返回吊销的会话数
+	RevokeUser(ctx context.Context, userType string, userID int64) (int, error)
+}
+
+// SetTokenRevoker 设置令牌吊销器
+func (s *UserService) SetTokenRevoker(revoker TokenRevoker) {
+	s.tokenRevoker = revoker
+}
+
+// DeletionBlocker 阻止注销的未完成业务
+type DeletionBlocker struct {
+	Type    string  `json:"type"`
+	Count   int64   `json:"count,omitempty"`
+	Amount  float64 `json:"amount,omitempty"`
+	Message string  `json:"message"`
+}
+
+// DeletionRequestInfo 注销申请信息
+type DeletionRequestInfo struct {
+	ID          int64     `json:"id"`
+	Status      string    `json:"status"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// DeletionResult 提交注销申请的结果，存在未完成业务时 Blockers 非空
+type DeletionResult struct {
+	Request  *DeletionRequestInfo `json:"request,omitempty"`
+	Blockers []*DeletionBlocker   `json:"blockers,omitempty"`
+}
+
+// RequestDeletion 提交账号注销申请
+// 存在进行中的租借、未完成的预订、冻结金额或处理中的提现时返回 ErrDeletionBlocked，
+// 并在结果中列出全部阻断原因；否则创建注销申请，冷静期结束后由 ProcessDueDeletions 执行注销
+func (s *UserService) RequestDeletion(ctx context.Context, userID int64) (*DeletionResult, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	if user.Status == models.UserStatusDeleted {
+		return nil, errors.ErrAccountDeleted
+	}
+
+	var existing int64
+	if err := s.db.WithContext(ctx).Model(&models.UserDeletionRequest{}).
+		Where("user_id = ? AND status = ?", userID, models.UserDeletionStatusPending).
+		Count(&existing).Error; err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	if existing > 0 {
+		return nil, errors.ErrDeletionRequested
+	}
+
+	blockers, err := s.deletionBlockers(s.db.WithContext(ctx), userID)
+	if err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+	if len(blockers) > 0 {
+		return &DeletionResult{Blockers: blockers}, errors.ErrDeletionBlocked
+	}
+
+	request := &models.UserDeletionRequest{
+		UserID:      userID,
+		Status:      models.UserDeletionStatusPending,
+		ScheduledAt: time.Now().Add(DeletionGracePeriod),
+	}
+	if err := s.db.WithContext(ctx).Create(request).Error; err != nil {
+		return nil, errors.ErrDatabaseError.WithError(err)
+	}
+
+	return &DeletionResult{Request: toDeletionRequestInfo(request)}, nil
+}
+
+// CancelDeletion 在冷静期内撤销注销申请
+func (s *UserService) CancelDeletion(ctx context.Context, userID int64) error {
+	now := time.Now()
+	result := s.db.WithContext(ctx).Model(&models.UserDeletionRequest{}).
+		Where("user_id = ? AND status = ?", userID, models.UserDeletionStatusPending).
+		Updates(map[string]interface{}{
+			"status":       models.UserDeletionStatusCancelled,
+			"cancelled_at": now,
+		})
+	if result.Error != nil {
+		return errors.ErrDatabaseError.WithError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrDeletionNotFound
+	}
+	return nil
+}
+
+// ProcessDueDeletions 执行冷静期已结束的注销申请，返回完成注销的用户数
+// 冷静期内新产生未完成业务的申请本次跳过，待业务结束后再次执行；
+// 单个申请失败不影响其他申请，全部错误合并返回
+func (s *UserService) ProcessDueDeletions(ctx context.Context) (int, error) {
+	var requests []*models.UserDeletionRequest
+	if err := s.db.WithContext(ctx).
+		Where("status = ? AND scheduled_at <= ?", models.UserDeletionStatusPending, time.Now()).
+		Order("scheduled_at ASC").
+		Limit(processDeletionBatch).
+		Find(&requests).Error; err != nil {
+		return 0, errors.ErrDatabaseError.WithError(err)
+	}
+
+	completed := 0
+	var errs []error
+	for _, request := range requests {
+		done, err := s.completeDeletion(ctx, request)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %d: %w", request.UserID, err))
+			continue
+		}
+		if !done {
+			continue
+		}
+		completed++
+
+		if s.tokenRevoker != nil {
+			if _, err := s.tokenRevoker.RevokeUser(ctx, jwt.UserTypeUser, request.UserID); err != nil {
+				errs = append(errs, fmt.Errorf("user %d revoke tokens: %w", request.UserID, err))
+			}
+		}
+	}
+	return completed, stderrors.Join(errs...)
+}
+
+// completeDeletion 匿名化用户个人信息并删除收货地址，返回是否完成注销
+// 订单、支付、退款、钱包流水等财务记录保留并继续关联匿名化后的用户，财务统计不受影响
+func (s *UserService) completeDeletion(ctx context.Context, request *models.UserDeletionRequest) (bool, error) {
+	done := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		blockers, err := s.deletionBlockers(tx, request.UserID)
+		if err != nil {
+			return err
+		}
+		if len(blockers) > 0 {
+			return nil
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", request.UserID).Updates(map[string]interface{}{
+			"phone":               nil,
+			"openid":              nil,
+			"unionid":             nil,
+			"nickname":            deletedUserNickname,
+			"avatar":              nil,
+			"gender":              models.GenderUnknown,
+			"birthday":            nil,
+			"is_verified":         false,
+			"real_name_encrypted": nil,
+			"id_card_encrypted":   nil,
+			"status":              models.UserStatusDeleted,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", request.UserID).Delete(&models.Address{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.UserDeletionRequest{}).
+			Where("id = ? AND status = ?", request.ID, models.UserDeletionStatusPending).
+			Updates(map[string]interface{}{
+				"status":       models.UserDeletionStatusCompleted,
+				"completed_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// 申请已被撤销，回滚匿名化
+			return errors.ErrDeletionNotFound
+		}
+		done = true
+		return nil
+	})
+	if err == errors.ErrDeletionNotFound {
+		return false, nil
+	}
+	return done, err
+}
+
+// deletionBlockers 查询阻止注销的未完成业务
+func (s *UserService) deletionBlockers(db *gorm.DB, userID int64) ([]*DeletionBlocker, error) {
+	var blockers []*DeletionBlocker
+
+	var rentals int64
+	if err := db.Model(&models.Rental{}).
+		Where("user_id = ? AND status IN ?", userID, []string{
+			models.RentalStatusPending, models.RentalStatusPaid, models.RentalStatusInUse, models.RentalStatusOverdue,
+		}).
+		Count(&rentals).Error; err != nil {
+		return nil, err
+	}
+	if rentals > 0 {
+		blockers = append(blockers, &DeletionBlocker{Type: DeletionBlockerRental, Count: rentals, Message: "存在进行中的租借"})
+	}
+
+	var bookings int64
+	if err := db.Model(&models.Booking{}).
+		Where("user_id = ? AND status IN ?", userID, []string{
+			models.BookingStatusPending, models.BookingStatusPaid, models.BookingStatusVerified, models.BookingStatusInUse,
+		}).
+		Count(&bookings).Error; err != nil {
+		return nil, err
+	}
+	if bookings > 0 {
+		blockers = append(blockers, &DeletionBlocker{Type: DeletionBlockerBooking, Count: bookings, Message: "存在未完成的酒店预订"})
+	}
+
+	var wallets []*models.UserWallet
+	if err := db.Where("user_id = ?", userID).Limit(1).Find(&wallets).Error; err != nil {
+		return nil, err
+	}
+	if len(wallets) > 0 && wallets[0].FrozenBalance > 0 {
+		blockers = append(blockers, &DeletionBlocker{Type: DeletionBlockerFrozen, Amount: wallets[0].FrozenBalance, Message: "钱包存在冻结金额（押金）"})
+	}
+
+	var withdrawals int64
+	if err := db.Model(&models.Withdrawal{}).
+		Where("user_id = ? AND status IN ?", userID, []string{
+			models.WithdrawalStatusPending, models.WithdrawalStatusApproved, models.WithdrawalStatusProcessing,
+		}).
+		Count(&withdrawals).Error; err != nil {
+		return nil, err
+	}
+	if withdrawals > 0 {
+		blockers = append(blockers, &DeletionBlocker{Type: DeletionBlockerWithdrawal, Count: withdrawals, Message: "存在处理中的提现"})
+	}
+
+	return blockers, nil
+}
+
+// toDeletionRequestInfo 转换注销申请信息
+func toDeletionRequestInfo(request *models.UserDeletionRequest) *DeletionRequestInfo {
+	return &DeletionRequestInfo{
+		ID:          request.ID,
+		Status:      request.Status,
+		ScheduledAt: request.ScheduledAt,
+		CreatedAt:   request.CreatedAt,
+	}
+}
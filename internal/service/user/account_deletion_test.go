@@ -0,0 +1,244 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	authService "github.com/dumeirei/smart-locker-backend/internal/service/auth"
+)
+
+func setupAccountDeletionTest(t *testing.T) (*gorm.DB, *UserService) {
+	db := setupUserServiceTestDB(t)
+	require.NoError(t, db.AutoMigrate(
+		&models.UserDeletionRequest{},
+		&models.UserWallet{},
+		&models.Address{},
+		&models.Order{},
+		&models.Rental{},
+		&models.Booking{},
+		&models.Withdrawal{},
+		&models.AuthToken{},
+	))
+	return db, setupUserService(db)
+}
+
+// expireDeletionRequest 将用户的注销申请置为冷静期已结束
+func expireDeletionRequest(t *testing.T, db *gorm.DB, userID int64) {
+	require.NoError(t, db.Model(&models.UserDeletionRequest{}).
+		Where("user_id = ?", userID).
+		Update("scheduled_at", time.Now().Add(-time.Minute)).Error)
+}
+
+func TestUserService_RequestDeletion_BlockedByFrozenDeposit(t *testing.T) {
+	db, svc := setupAccountDeletionTest(t)
+	ctx := context.Background()
+
+	u := createUserServiceTestUser(t, db)
+	require.NoError(t, db.Create(&models.UserWallet{UserID: u.ID, Balance: 50, FrozenBalance: 99}).Error)
+
+	result, err := svc.RequestDeletion(ctx, u.ID)
+	require.Error(t, err)
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, appErrors.ErrDeletionBlocked.Code, appErr.Code)
+	require.NotNil(t, result)
+	require.Len(t, result.Blockers, 1)
+	assert.Equal(t, DeletionBlockerFrozen, result.Blockers[0].Type)
+	assert.Equal(t, 99.0, result.Blockers[0].Amount)
+
+	var count int64
+	db.Model(&models.UserDeletionRequest{}).Where("user_id = ?", u.ID).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestUserService_RequestDeletion_ListsAllBlockers(t *testing.T) {
+	db, svc := setupAccountDeletionTest(t)
+	ctx := context.Background()
+
+	u := createUserServiceTestUser(t, db)
+	require.NoError(t, db.Create(&models.Rental{OrderID: 1, UserID: u.ID, DeviceID: 1, Status: models.RentalStatusInUse}).Error)
+	require.NoError(t, db.Create(&models.Withdrawal{
+		WithdrawalNo: "W1", UserID: u.ID, Type: "wallet", Amount: 10, ActualAmount: 10,
+		WithdrawTo: "wechat", Status: models.WithdrawalStatusPending,
+	}).Error)
+
+	result, err := svc.RequestDeletion(ctx, u.ID)
+	require.Error(t, err)
+	require.NotNil(t, result)
+	types := make([]string, 0, len(result.Blockers))
+	for _, b := range result.Blockers {
+		types = append(types, b.Type)
+	}
+	assert.Equal(t, []string{DeletionBlockerRental, DeletionBlockerWithdrawal}, types)
+}
+
+func TestUserService_CancelDeletion(t *testing.T) {
+	db, svc := setupAccountDeletionTest(t)
+	ctx := context.Background()
+
+	u := createUserServiceTestUser(t, db)
+	result, err := svc.RequestDeletion(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.UserDeletionStatusPending, result.Request.Status)
+	assert.WithinDuration(t, time.Now().Add(DeletionGracePeriod), result.Request.ScheduledAt, time.Minute)
+
+	_, err = svc.RequestDeletion(ctx, u.ID)
+	require.Error(t, err)
+	assert.Equal(t, appErrors.ErrDeletionRequested.Code, err.(*appErrors.AppError).Code)
+
+	require.NoError(t, svc.CancelDeletion(ctx, u.ID))
+
+	// 撤销后到期也不执行
+	expireDeletionRequest(t, db, u.ID)
+	completed, err := svc.ProcessDueDeletions(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, completed)
+
+	var user models.User
+	require.NoError(t, db.First(&user, u.ID).Error)
+	assert.Equal(t, int8(models.UserStatusActive), user.Status)
+
+	err = svc.CancelDeletion(ctx, u.ID)
+	require.Error(t, err)
+	assert.Equal(t, appErrors.ErrDeletionNotFound.Code, err.(*appErrors.AppError).Code)
+}
+
+func TestUserService_ProcessDueDeletions_SkipsNewBlockers(t *testing.T) {
+	db, svc := setupAccountDeletionTest(t)
+	ctx := context.Background()
+
+	u := createUserServiceTestUser(t, db)
+	_, err := svc.RequestDeletion(ctx, u.ID)
+	require.NoError(t, err)
+
+	// 冷静期内产生了押金
+	require.NoError(t, db.Create(&models.UserWallet{UserID: u.ID, FrozenBalance: 99}).Error)
+	expireDeletionRequest(t, db, u.ID)
+
+	completed, err := svc.ProcessDueDeletions(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, completed)
+
+	var request models.UserDeletionRequest
+	require.NoError(t, db.Where("user_id = ?", u.ID).First(&request).Error)
+	assert.Equal(t, models.UserDeletionStatusPending, request.Status)
+}
+
+func TestUserService_ProcessDueDeletions_AnonymizesAndRevokes(t *testing.T) {
+	db, svc := setupAccountDeletionTest(t)
+	ctx := context.Background()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: 24 * time.Hour,
+		Issuer:            "test",
+	})
+	jwtManager.SetRevocationStore(authService.NewTokenStore(db, client))
+	svc.SetTokenRevoker(jwtManager)
+	authSvc := authService.NewAuthService(db, svc.userRepo, jwtManager,
+		authService.NewCodeService(client, nil, &authService.CodeServiceConfig{CodeLength: 6, ExpireIn: 5 * time.Minute, DebugMode: true}))
+
+	phone := "13912345678"
+	u := createUserServiceTestUser(t, db, func(u *models.User) {
+		u.Phone = &phone
+		u.Nickname = "张三"
+	})
+	require.NoError(t, db.Model(u).Updates(map[string]interface{}{"avatar": "https://cdn/a.png", "gender": models.GenderMale}).Error)
+	require.NoError(t, db.Create(&models.Address{
+		UserID: u.ID, ReceiverName: "张三", ReceiverPhone: phone,
+		Province: "广东省", City: "深圳市", District: "南山区", Detail: "科技园",
+	}).Error)
+	for i, amount := range []float64{30, 45.5} {
+		require.NoError(t, db.Create(&models.Order{
+			OrderNo:        "ODEL" + string(rune('A'+i)),
+			UserID:         u.ID,
+			Type:           models.OrderTypeRental,
+			OriginalAmount: amount,
+			ActualAmount:   amount,
+			Status:         models.OrderStatusCompleted,
+		}).Error)
+	}
+	sumOrders := func() float64 {
+		var sum float64
+		require.NoError(t, db.Model(&models.Order{}).Select("COALESCE(SUM(actual_amount), 0)").Scan(&sum).Error)
+		return sum
+	}
+	before := sumOrders()
+
+	login, err := authSvc.SmsLogin(ctx, &authService.SmsLoginRequest{Phone: phone, Code: "123456"})
+	require.NoError(t, err)
+	require.Equal(t, u.ID, login.User.ID)
+
+	_, err = svc.RequestDeletion(ctx, u.ID)
+	require.NoError(t, err)
+
+	// 冷静期未结束不执行
+	completed, err := svc.ProcessDueDeletions(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, completed)
+
+	expireDeletionRequest(t, db, u.ID)
+	completed, err = svc.ProcessDueDeletions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, completed)
+
+	t.Run("个人信息匿名化", func(t *testing.T) {
+		var user models.User
+		require.NoError(t, db.First(&user, u.ID).Error)
+		assert.Nil(t, user.Phone)
+		assert.Nil(t, user.Avatar)
+		assert.Equal(t, deletedUserNickname, user.Nickname)
+		assert.Equal(t, int8(models.GenderUnknown), user.Gender)
+		assert.Equal(t, int8(models.UserStatusDeleted), user.Status)
+
+		var addresses int64
+		db.Model(&models.Address{}).Where("user_id = ?", u.ID).Count(&addresses)
+		assert.Zero(t, addresses)
+	})
+
+	t.Run("订单保留且金额不变", func(t *testing.T) {
+		assert.Equal(t, before, sumOrders())
+		var orders int64
+		db.Model(&models.Order{}).Where("user_id = ?", u.ID).Count(&orders)
+		assert.Equal(t, int64(2), orders)
+	})
+
+	t.Run("原登录会话失效", func(t *testing.T) {
+		_, err := authSvc.RefreshToken(ctx, login.TokenPair.RefreshToken)
+		require.Error(t, err)
+		assert.Equal(t, appErrors.ErrAccountDeleted.Code, err.(*appErrors.AppError).Code)
+
+		claims, err := jwtManager.ParseToken(login.TokenPair.AccessToken)
+		require.NoError(t, err)
+		revoked, err := jwtManager.IsRevoked(ctx, claims)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+	})
+
+	t.Run("原手机号无法登录已注销账号", func(t *testing.T) {
+		relogin, err := authSvc.SmsLogin(ctx, &authService.SmsLoginRequest{Phone: phone, Code: "123456"})
+		require.NoError(t, err)
+		assert.True(t, relogin.IsNewUser)
+		assert.NotEqual(t, u.ID, relogin.User.ID)
+	})
+
+	t.Run("已注销用户不能再次申请", func(t *testing.T) {
+		_, err := svc.RequestDeletion(ctx, u.ID)
+		require.Error(t, err)
+		assert.Equal(t, appErrors.ErrAccountDeleted.Code, err.(*appErrors.AppError).Code)
+	})
+}
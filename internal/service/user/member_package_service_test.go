@@ -37,6 +37,7 @@ func setupMemberPackageServiceTestDB(t *testing.T) *gorm.DB {
 		&models.Order{},
 		&models.OrderItem{},
 		&models.WalletTransaction{},
+		&models.UserPointsLog{},
 	))
 
 	db.Create(&models.MemberLevel{ID: 1, Name: "普通会员", Level: 1, MinPoints: 0, Discount: 1.0})
@@ -153,7 +154,7 @@ func TestMemberPackageService_PurchasePackage_Success(t *testing.T) {
 	assert.Equal(t, 100, refreshed.Points)
 
 	var txCount int64
-	require.NoError(t, db.Model(&models.WalletTransaction{}).Where("user_id = ? AND type = ?", user.ID, "points_package_purchase").Count(&txCount).Error)
+	require.NoError(t, db.Model(&models.UserPointsLog{}).Where("user_id = ? AND type = ?", user.ID, "package_purchase").Count(&txCount).Error)
 	assert.Equal(t, int64(1), txCount)
 }
 
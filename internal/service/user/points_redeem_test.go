@@ -0,0 +1,235 @@
+// Package user 积分抵扣及等级积分倍率单元测试
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// assertPointsErrorCode 断言错误为指定错误码的 AppError
+func assertPointsErrorCode(t *testing.T, err error, code int) {
+	t.Helper()
+
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok, "expected AppError, got %v", err)
+	assert.Equal(t, code, appErr.Code)
+}
+
+func createPendingOrderForPoints(t *testing.T, db *gorm.DB, userID int64, orderNo string, amount float64) *models.Order {
+	t.Helper()
+
+	order := &models.Order{
+		OrderNo:        orderNo,
+		UserID:         userID,
+		Type:           models.OrderTypeMall,
+		OriginalAmount: amount,
+		ActualAmount:   amount,
+		Status:         models.OrderStatusPending,
+	}
+	require.NoError(t, db.Create(order).Error)
+	return order
+}
+
+func TestPointsService_AddConsumePoints_MemberLevelRate(t *testing.T) {
+	db := setupPointsServiceTestDB(t)
+	require.NoError(t, db.Create(&models.MemberLevel{ID: 3, Name: "钻石会员", Level: 3, MinPoints: 1000, Discount: 0.8, PointsRate: 1.5}).Error)
+	svc, _, _ := newPointsServiceForTest(db)
+	ctx := context.Background()
+
+	t.Run("默认等级倍率为1", func(t *testing.T) {
+		user := createTestUserForPoints(db, 0, 1)
+		require.NoError(t, svc.AddConsumePoints(ctx, user.ID, 50, "O_RATE_DEFAULT"))
+
+		var refreshed models.User
+		require.NoError(t, db.First(&refreshed, user.ID).Error)
+		assert.Equal(t, 50, refreshed.Points)
+	})
+
+	t.Run("按会员等级倍率发放", func(t *testing.T) {
+		user := createTestUserForPoints(db, 1000, 3)
+		require.NoError(t, svc.AddConsumePoints(ctx, user.ID, 80.5, "O_RATE_DIAMOND"))
+
+		var refreshed models.User
+		require.NoError(t, db.First(&refreshed, user.ID).Error)
+		assert.Equal(t, 1120, refreshed.Points) // int(80.5 × 1.5) = 120
+
+		// 退款按相同倍率扣减
+		require.NoError(t, svc.RefundPoints(ctx, user.ID, 80.5, "O_RATE_DIAMOND"))
+		require.NoError(t, db.First(&refreshed, user.ID).Error)
+		assert.Equal(t, 1000, refreshed.Points)
+	})
+}
+
+func TestPointsService_RefundPoints_UsesEarnedRate(t *testing.T) {
+	db := setupPointsServiceTestDB(t)
+	require.NoError(t, db.Create(&models.MemberLevel{ID: 3, Name: "钻石会员", Level: 3, MinPoints: 1000, Discount: 0.8, PointsRate: 1.5}).Error)
+	svc, _, _ := newPointsServiceForTest(db)
+	ctx := context.Background()
+
+	user := createTestUserForPoints(db, 1000, 3)
+	order := createPendingOrderForPoints(t, db, user.ID, "O_REFUND_SNAPSHOT", 80)
+	require.NoError(t, svc.AddConsumePoints(ctx, user.ID, order.ActualAmount, order.OrderNo))
+
+	// 发放积分后等级倍率变化，退款仍按发放时的比例扣减
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", user.ID).Update("member_level_id", 1).Error)
+
+	var refreshed models.User
+	require.NoError(t, svc.RefundPoints(ctx, user.ID, 20, order.OrderNo))
+	require.NoError(t, db.First(&refreshed, user.ID).Error)
+	assert.Equal(t, 1090, refreshed.Points) // 1000 + 120 - 120×20/80
+
+	// 累计扣减不超过该订单发放的积分
+	require.NoError(t, svc.RefundPoints(ctx, user.ID, 80, order.OrderNo))
+	require.NoError(t, db.First(&refreshed, user.ID).Error)
+	assert.Equal(t, 1000, refreshed.Points)
+
+	require.NoError(t, svc.RefundPoints(ctx, user.ID, 80, order.OrderNo))
+	require.NoError(t, db.First(&refreshed, user.ID).Error)
+	assert.Equal(t, 1000, refreshed.Points)
+
+	t.Run("未发放消费积分的订单不扣减", func(t *testing.T) {
+		other := createTestUserForPoints(db, 100, 1)
+		require.NoError(t, svc.RefundPoints(ctx, other.ID, 50, "O_REFUND_NO_EARN"))
+
+		var unchanged models.User
+		require.NoError(t, db.First(&unchanged, other.ID).Error)
+		assert.Equal(t, 100, unchanged.Points)
+	})
+}
+
+func TestPointsService_RedeemPoints(t *testing.T) {
+	db := setupPointsServiceTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Order{}))
+	svc, _, _ := newPointsServiceForTest(db)
+	ctx := context.Background()
+
+	t.Run("抵扣待支付订单金额", func(t *testing.T) {
+		user := createTestUserForPoints(db, 500, 1)
+		order := createPendingOrderForPoints(t, db, user.ID, "O_REDEEM_OK", 20)
+
+		discount, err := svc.RedeemPoints(ctx, user.ID, 250, order.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2.5, discount)
+
+		var refreshed models.User
+		require.NoError(t, db.First(&refreshed, user.ID).Error)
+		assert.Equal(t, 250, refreshed.Points)
+
+		var updated models.Order
+		require.NoError(t, db.First(&updated, order.ID).Error)
+		assert.InDelta(t, 2.5, updated.DiscountAmount, 0.001)
+		assert.InDelta(t, 17.5, updated.ActualAmount, 0.001)
+
+		var log models.UserPointsLog
+		require.NoError(t, db.Where("user_id = ? AND type = ?", user.ID, PointsTypeRedeem).First(&log).Error)
+		assert.Equal(t, -250, log.Points)
+		require.NotNil(t, log.OrderNo)
+		assert.Equal(t, "O_REDEEM_OK", *log.OrderNo)
+	})
+
+	t.Run("积分不足", func(t *testing.T) {
+		user := createTestUserForPoints(db, 50, 1)
+		order := createPendingOrderForPoints(t, db, user.ID, "O_REDEEM_SHORT", 20)
+
+		_, err := svc.RedeemPoints(ctx, user.ID, 100, order.ID)
+		assertPointsErrorCode(t, err, appErrors.ErrPointsInsufficient.Code)
+
+		var refreshed models.User
+		require.NoError(t, db.First(&refreshed, user.ID).Error)
+		assert.Equal(t, 50, refreshed.Points)
+
+		var unchanged models.Order
+		require.NoError(t, db.First(&unchanged, order.ID).Error)
+		assert.Equal(t, 20.0, unchanged.ActualAmount)
+	})
+
+	t.Run("抵扣金额超过订单金额", func(t *testing.T) {
+		user := createTestUserForPoints(db, 5000, 1)
+		order := createPendingOrderForPoints(t, db, user.ID, "O_REDEEM_OVER", 20)
+
+		_, err := svc.RedeemPoints(ctx, user.ID, 2001, order.ID)
+		assertPointsErrorCode(t, err, appErrors.ErrInvalidParams.Code)
+	})
+
+	t.Run("非待支付订单", func(t *testing.T) {
+		user := createTestUserForPoints(db, 500, 1)
+		order := createPendingOrderForPoints(t, db, user.ID, "O_REDEEM_PAID", 20)
+		require.NoError(t, db.Model(order).Update("status", models.OrderStatusPaid).Error)
+
+		_, err := svc.RedeemPoints(ctx, user.ID, 100, order.ID)
+		assertPointsErrorCode(t, err, appErrors.ErrOrderStatusError.Code)
+	})
+
+	t.Run("非本人订单", func(t *testing.T) {
+		user := createTestUserForPoints(db, 500, 1)
+		order := createPendingOrderForPoints(t, db, user.ID, "O_REDEEM_OTHER", 20)
+
+		_, err := svc.RedeemPoints(ctx, user.ID+1000, 100, order.ID)
+		assertPointsErrorCode(t, err, appErrors.ErrOrderNotFound.Code)
+	})
+
+	t.Run("积分必须大于0", func(t *testing.T) {
+		_, err := svc.RedeemPoints(ctx, 1, 0, 1)
+		assertPointsErrorCode(t, err, appErrors.ErrInvalidParams.Code)
+	})
+}
+
+func TestPointsService_ReturnRedeemedPointsTx(t *testing.T) {
+	db := setupPointsServiceTestDB(t)
+	svc, _, _ := newPointsServiceForTest(db)
+	ctx := context.Background()
+
+	user := createTestUserForPoints(db, 300, 1)
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		_, err := svc.RedeemPointsTx(ctx, tx, user.ID, 200, "O_RETURN")
+		return err
+	}))
+
+	// 重复返还只生效一次
+	for i := 0; i < 2; i++ {
+		require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+			return svc.ReturnRedeemedPointsTx(ctx, tx, user.ID, "O_RETURN")
+		}))
+	}
+
+	var refreshed models.User
+	require.NoError(t, db.First(&refreshed, user.ID).Error)
+	assert.Equal(t, 300, refreshed.Points)
+
+	var returnCount int64
+	require.NoError(t, db.Model(&models.UserPointsLog{}).
+		Where("user_id = ? AND type = ?", user.ID, PointsTypeRedeemReturn).Count(&returnCount).Error)
+	assert.Equal(t, int64(1), returnCount)
+}
+
+func TestPointsService_GetPointsInfo_LifetimePoints(t *testing.T) {
+	db := setupPointsServiceTestDB(t)
+	svc, _, _ := newPointsServiceForTest(db)
+	ctx := context.Background()
+
+	user := createTestUserForPoints(db, 0, 1)
+	require.NoError(t, svc.AddConsumePoints(ctx, user.ID, 60, "O_LIFETIME_1"))
+	require.NoError(t, svc.AddPoints(ctx, user.ID, 40, PointsTypeActivity, "活动赠送", nil))
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		if _, err := svc.RedeemPointsTx(ctx, tx, user.ID, 80, "O_LIFETIME_2"); err != nil {
+			return err
+		}
+		return svc.ReturnRedeemedPointsTx(ctx, tx, user.ID, "O_LIFETIME_2")
+	}))
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		_, err := svc.RedeemPointsTx(ctx, tx, user.ID, 30, "O_LIFETIME_3")
+		return err
+	}))
+
+	info, err := svc.GetPointsInfo(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 70, info.Points)
+	assert.Equal(t, 100, info.LifetimePoints)
+}
@@ -4,9 +4,11 @@ package user
 import (
 	"context"
 	"fmt"
+	"math"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
@@ -18,14 +20,29 @@ type PointsService struct {
 	db              *gorm.DB
 	userRepo        *repository.UserRepository
 	memberLevelRepo *repository.MemberLevelRepository
+	pointsRate      int
 }
 
+// DefaultPointsRate 默认积分比例：每消费1元获得1积分
+const DefaultPointsRate = 1
+
+// PointsRedeemRate 积分抵扣比例：每100积分抵扣1元
+const PointsRedeemRate = 100
+
 // NewPointsService 创建积分服务
 func NewPointsService(db *gorm.DB, userRepo *repository.UserRepository, memberLevelRepo *repository.MemberLevelRepository) *PointsService {
 	return &PointsService{
 		db:              db,
 		userRepo:        userRepo,
 		memberLevelRepo: memberLevelRepo,
+		pointsRate:      DefaultPointsRate,
+	}
+}
+
+// SetPointsRate 设置积分比例（每消费1元获得的积分数），非正数时保持默认比例
+func (s *PointsService) SetPointsRate(rate int) {
+	if rate > 0 {
+		s.pointsRate = rate
 	}
 }
 
@@ -44,6 +61,7 @@ type PointsRecord struct {
 // PointsInfo 积分信息
 type PointsInfo struct {
 	Points           int     `json:"points"`
+	LifetimePoints   int     `json:"lifetime_points"` // 累计获得积分
 	MemberLevelID    int64   `json:"member_level_id"`
 	MemberLevelName  string  `json:"member_level_name"`
 	Discount         float64 `json:"discount"`
@@ -62,6 +80,8 @@ const (
 	PointsTypeExpired         = "expired"          // 积分过期
 	PointsTypeExchange        = "exchange"         // 积分兑换
 	PointsTypeAdmin           = "admin"            // 管理员调整
+	PointsTypeRedeem          = "redeem"           // 下单抵扣
+	PointsTypeRedeemReturn    = "redeem_return"    // 抵扣返还
 )
 
 // GetPointsInfo 获取积分信息
@@ -74,8 +94,14 @@ func (s *PointsService) GetPointsInfo(ctx context.Context, userID int64) (*Point
 		return nil, errors.ErrDatabaseError.WithError(err)
 	}
 
+	lifetimePoints, err := s.GetLifetimePoints(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	info := &PointsInfo{
 		Points:          user.Points,
+		LifetimePoints:  lifetimePoints,
 		MemberLevelID:   user.MemberLevelID,
 		MemberLevelName: "普通会员",
 		Discount:        1.0,
@@ -100,6 +126,19 @@ func (s *PointsService) GetPointsInfo(ctx context.Context, userID int64) (*Point
 	return info, nil
 }
 
+// GetLifetimePoints 获取用户累计获得的积分，抵扣返还的积分不计入
+func (s *PointsService) GetLifetimePoints(ctx context.Context, userID int64) (int, error) {
+	var total int
+	err := s.db.WithContext(ctx).Model(&models.UserPointsLog{}).
+		Where("user_id = ? AND points > 0 AND type <> ?", userID, PointsTypeRedeemReturn).
+		Select("COALESCE(SUM(points), 0)").
+		Row().Scan(&total)
+	if err != nil {
+		return 0, errors.ErrDatabaseError.WithError(err)
+	}
+	return total, nil
+}
+
 // AddPoints 增加积分
 func (s *PointsService) AddPoints(ctx context.Context, userID int64, points int, pointsType, description string, orderNo *string) error {
 	if points <= 0 {
@@ -124,20 +163,12 @@ func (s *PointsService) AddPointsTx(ctx context.Context, tx *gorm.DB, userID int
 		return errors.ErrDatabaseError.WithError(err)
 	}
 
-	// 记录积分变动（使用钱包交易表记录，或者创建专门的积分记录表）
-	// 这里使用备注记录积分变动信息
-	record := &models.WalletTransaction{
-		UserID:  userID,
-		Type:    fmt.Sprintf("points_%s", pointsType),
-		Amount:  float64(points),
-		OrderNo: orderNo,
-		Remark:  &description,
-	}
-	if err := tx.WithContext(ctx).Create(record).Error; err != nil {
-		return errors.ErrDatabaseError.WithError(err)
+	// 记录积分变动
+	if err := s.createLogTx(ctx, tx, userID, pointsType, points, description, orderNo); err != nil {
+		return err
 	}
 
-	// 检查并更新会员等级
+	// 检查并升级会员等级
 	return s.checkAndUpgradeLevelTx(ctx, tx, userID)
 }
 
@@ -166,25 +197,30 @@ func (s *PointsService) DeductPointsTx(ctx context.Context, tx *gorm.DB, userID
 		return errors.ErrDatabaseError.WithError(result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return errors.New(errors.ErrOperationFailed.Code, "积分不足")
+		return errors.ErrPointsInsufficient
 	}
 
-	// 记录积分变动
-	record := &models.WalletTransaction{
+	// 记录积分变动（扣减积分不触发会员降级）
+	return s.createLogTx(ctx, tx, userID, pointsType, -points, description, orderNo)
+}
+
+// createLogTx 记录积分变动
+func (s *PointsService) createLogTx(ctx context.Context, tx *gorm.DB, userID int64, pointsType string, points int, description string, orderNo *string) error {
+	record := &models.UserPointsLog{
 		UserID:  userID,
-		Type:    fmt.Sprintf("points_%s", pointsType),
-		Amount:  float64(-points),
+		Type:    pointsType,
+		Points:  points,
 		OrderNo: orderNo,
 		Remark:  &description,
 	}
 	if err := tx.WithContext(ctx).Create(record).Error; err != nil {
 		return errors.ErrDatabaseError.WithError(err)
 	}
-
 	return nil
 }
 
 // checkAndUpgradeLevelTx 检查并升级会员等级
+// 会员等级只升不降，降级不在此处处理
 func (s *PointsService) checkAndUpgradeLevelTx(ctx context.Context, tx *gorm.DB, userID int64) error {
 	// 获取用户当前积分
 	var user models.User
@@ -205,49 +241,57 @@ func (s *PointsService) checkAndUpgradeLevelTx(ctx context.Context, tx *gorm.DB,
 		return err
 	}
 
-	// 如果需要升级
-	if targetLevel.ID != user.MemberLevelID {
-		if err := tx.WithContext(ctx).Model(&models.User{}).
-			Where("id = ?", userID).
-			Update("member_level_id", targetLevel.ID).Error; err != nil {
-			return err
-		}
+	if targetLevel.ID == user.MemberLevelID {
+		return nil
 	}
 
-	return nil
+	// 当前等级不低于目标等级时（如购买会员套餐获得的等级）保持不变
+	var currentLevel models.MemberLevel
+	err := tx.WithContext(ctx).First(&currentLevel, user.MemberLevelID).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	if err == nil && currentLevel.Level >= targetLevel.Level {
+		return nil
+	}
+
+	// 升级会员等级
+	return tx.WithContext(ctx).Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("member_level_id", targetLevel.ID).Error
 }
 
 // GetPointsHistory 获取积分历史记录
 func (s *PointsService) GetPointsHistory(ctx context.Context, userID int64, offset, limit int, pointsType string) ([]*PointsRecord, int64, error) {
-	var transactions []*models.WalletTransaction
+	var logs []*models.UserPointsLog
 	var total int64
 
-	query := s.db.WithContext(ctx).Model(&models.WalletTransaction{}).
-		Where("user_id = ? AND type LIKE 'points_%'", userID)
+	query := s.db.WithContext(ctx).Model(&models.UserPointsLog{}).
+		Where("user_id = ?", userID)
 
 	if pointsType != "" {
-		query = query.Where("type = ?", fmt.Sprintf("points_%s", pointsType))
+		query = query.Where("type = ?", pointsType)
 	}
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, errors.ErrDatabaseError.WithError(err)
 	}
 
-	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&transactions).Error; err != nil {
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&logs).Error; err != nil {
 		return nil, 0, errors.ErrDatabaseError.WithError(err)
 	}
 
-	records := make([]*PointsRecord, len(transactions))
-	for i, tx := range transactions {
+	records := make([]*PointsRecord, len(logs))
+	for i, log := range logs {
 		records[i] = &PointsRecord{
-			ID:          tx.ID,
-			UserID:      tx.UserID,
-			Type:        tx.Type,
-			TypeName:    s.getPointsTypeName(tx.Type),
-			Points:      int(tx.Amount),
-			OrderNo:     tx.OrderNo,
-			Description: s.getDescription(tx.Remark),
-			CreatedAt:   tx.CreatedAt,
+			ID:          log.ID,
+			UserID:      log.UserID,
+			Type:        log.Type,
+			TypeName:    s.getPointsTypeName(log.Type),
+			Points:      log.Points,
+			OrderNo:     log.OrderNo,
+			Description: s.getDescription(log.Remark),
+			CreatedAt:   log.CreatedAt,
 		}
 	}
 
@@ -255,24 +299,28 @@ func (s *PointsService) GetPointsHistory(ctx context.Context, userID int64, offs
 }
 
 // getPointsTypeName 获取积分类型名称
-func (s *PointsService) getPointsTypeName(txType string) string {
-	switch txType {
-	case "points_consume":
+func (s *PointsService) getPointsTypeName(pointsType string) string {
+	switch pointsType {
+	case PointsTypeConsume:
 		return "消费获取"
-	case "points_package_purchase":
+	case PointsTypePackagePurchase:
 		return "套餐购买"
-	case "points_sign_in":
+	case PointsTypeSignIn:
 		return "签到"
-	case "points_activity":
+	case PointsTypeActivity:
 		return "活动赠送"
-	case "points_refund":
+	case PointsTypeRefund:
 		return "退款扣减"
-	case "points_expired":
+	case PointsTypeExpired:
 		return "积分过期"
-	case "points_exchange":
+	case PointsTypeExchange:
 		return "积分兑换"
-	case "points_admin":
+	case PointsTypeAdmin:
 		return "管理员调整"
+	case PointsTypeRedeem:
+		return "下单抵扣"
+	case PointsTypeRedeemReturn:
+		return "抵扣返还"
 	default:
 		return "其他"
 	}
@@ -286,51 +334,229 @@ func (s *PointsService) getDescription(remark *string) string {
 	return ""
 }
 
-// CalculatePointsByAmount 根据消费金额计算积分（每消费1元获得1积分）
+// CalculatePointsByAmount 根据消费金额按积分比例计算积分（不足1积分的部分舍去）
 func (s *PointsService) CalculatePointsByAmount(amount float64) int {
-	return int(amount)
+	return int(amount * float64(s.pointsRate))
 }
 
-// AddConsumePoints 添加消费积分
-func (s *PointsService) AddConsumePoints(ctx context.Context, userID int64, amount float64, orderNo string) error {
-	points := s.CalculatePointsByAmount(amount)
-	if points <= 0 {
-		return nil
+// calculateConsumePointsTx 按积分比例和用户会员等级的积分倍率计算消费积分
+func (s *PointsService) calculateConsumePointsTx(ctx context.Context, tx *gorm.DB, userID int64, amount float64) (int, error) {
+	if amount <= 0 {
+		return 0, nil
 	}
 
-	description := fmt.Sprintf("消费%.2f元获得积分", amount)
-	return s.AddPoints(ctx, userID, points, PointsTypeConsume, description, &orderNo)
+	var user models.User
+	if err := tx.WithContext(ctx).Select("id, member_level_id").First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, errors.ErrUserNotFound
+		}
+		return 0, errors.ErrDatabaseError.WithError(err)
+	}
+
+	levelRate := 1.0
+	var level models.MemberLevel
+	err := tx.WithContext(ctx).Select("id, points_rate").First(&level, user.MemberLevelID).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return 0, errors.ErrDatabaseError.WithError(err)
+	}
+	if err == nil && level.PointsRate > 0 {
+		levelRate = level.PointsRate
+	}
+
+	return int(math.Round(amount*float64(s.pointsRate)*levelRate*100) / 100), nil
+}
+
+// AddConsumePoints 添加消费积分
+func (s *PointsService) AddConsumePoints(ctx context.Context, userID int64, amount float64, orderNo string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.AddConsumePointsTx(ctx, tx, userID, amount, orderNo)
+	})
 }
 
-// AddConsumePointsTx 在事务中添加消费积分
+// AddConsumePointsTx 在事务中添加消费积分，同一订单只发放一次
 func (s *PointsService) AddConsumePointsTx(ctx context.Context, tx *gorm.DB, userID int64, amount float64, orderNo string) error {
-	points := s.CalculatePointsByAmount(amount)
+	points, err := s.calculateConsumePointsTx(ctx, tx, userID, amount)
+	if err != nil {
+		return err
+	}
 	if points <= 0 {
 		return nil
 	}
 
+	var count int64
+	if err := tx.WithContext(ctx).Model(&models.UserPointsLog{}).
+		Where("user_id = ? AND type = ? AND order_no = ?", userID, PointsTypeConsume, orderNo).
+		Count(&count).Error; err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	if count > 0 {
+		return nil
+	}
+
 	description := fmt.Sprintf("消费%.2f元获得积分", amount)
 	return s.AddPointsTx(ctx, tx, userID, points, PointsTypeConsume, description, &orderNo)
 }
 
 // RefundPoints 退款扣减积分
 func (s *PointsService) RefundPoints(ctx context.Context, userID int64, amount float64, orderNo string) error {
-	points := s.CalculatePointsByAmount(amount)
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.RefundPointsTx(ctx, tx, userID, amount, orderNo)
+	})
+}
+
+// RefundPointsTx 在事务中退款扣减积分
+// 按订单发放消费积分时的实际比例（含当时的会员等级倍率）折算，累计扣减不超过该订单发放的积分；
+// 订单未发放消费积分时不扣减
+func (s *PointsService) RefundPointsTx(ctx context.Context, tx *gorm.DB, userID int64, amount float64, orderNo string) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	earned, err := s.sumOrderPointsTx(ctx, tx, userID, orderNo, PointsTypeConsume)
+	if err != nil {
+		return err
+	}
+	refunded, err := s.sumOrderPointsTx(ctx, tx, userID, orderNo, PointsTypeRefund)
+	if err != nil {
+		return err
+	}
+	remaining := earned + refunded
+	if earned <= 0 || remaining <= 0 {
+		return nil
+	}
+
+	points := remaining
+	var order models.Order
+	err = tx.WithContext(ctx).Select("id, actual_amount").Where("order_no = ?", orderNo).First(&order).Error
+	switch {
+	case err == nil:
+		if amount < order.ActualAmount {
+			points = int(math.Round(float64(earned) * amount / order.ActualAmount))
+		}
+	case err == gorm.ErrRecordNotFound:
+		// 订单记录缺失时无法得知发放时的比例，按当前比例折算
+		if points, err = s.calculateConsumePointsTx(ctx, tx, userID, amount); err != nil {
+			return err
+		}
+	default:
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	if points > remaining {
+		points = remaining
+	}
 	if points <= 0 {
 		return nil
 	}
 
-	description := fmt.Sprintf("订单退款扣减积分")
-	return s.DeductPoints(ctx, userID, points, PointsTypeRefund, description, &orderNo)
+	description := "订单退款扣减积分"
+	return s.DeductPointsTx(ctx, tx, userID, points, PointsTypeRefund, description, &orderNo)
 }
 
-// RefundPointsTx 在事务中退款扣减积分
-func (s *PointsService) RefundPointsTx(ctx context.Context, tx *gorm.DB, userID int64, amount float64, orderNo string) error {
-	points := s.CalculatePointsByAmount(amount)
+// sumOrderPointsTx 汇总用户某订单指定类型的积分变动
+func (s *PointsService) sumOrderPointsTx(ctx context.Context, tx *gorm.DB, userID int64, orderNo, pointsType string) (int, error) {
+	var sum int
+	err := tx.WithContext(ctx).Model(&models.UserPointsLog{}).
+		Where("user_id = ? AND order_no = ? AND type = ?", userID, orderNo, pointsType).
+		Select("COALESCE(SUM(points), 0)").
+		Row().Scan(&sum)
+	if err != nil {
+		return 0, errors.ErrDatabaseError.WithError(err)
+	}
+	return sum, nil
+}
+
+// CalculateRedeemDiscount 计算积分可抵扣的金额
+func CalculateRedeemDiscount(points int) float64 {
+	return math.Round(float64(points)/PointsRedeemRate*100) / 100
+}
+
+// RedeemPoints 使用积分抵扣待支付订单的金额，返回抵扣金额
+func (s *PointsService) RedeemPoints(ctx context.Context, userID int64, points int, orderID int64) (float64, error) {
+	if points <= 0 {
+		return 0, errors.ErrInvalidParams.WithMessage("积分必须大于0")
+	}
+
+	var discount float64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.ErrOrderNotFound
+			}
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		if order.UserID != userID {
+			return errors.ErrOrderNotFound
+		}
+		if order.Status != models.OrderStatusPending {
+			return errors.ErrOrderStatusError.WithMessage("只有待支付订单可以使用积分抵扣")
+		}
+		if CalculateRedeemDiscount(points) > order.ActualAmount {
+			return errors.ErrInvalidParams.WithMessage("抵扣金额不能超过订单应付金额")
+		}
+
+		var err error
+		discount, err = s.RedeemPointsTx(ctx, tx, userID, points, order.OrderNo)
+		if err != nil {
+			return err
+		}
+
+		// 条件更新防止并发支付或抵扣后金额被扣成负数（不支持行锁的数据库上同样生效）
+		res := tx.Model(&models.Order{}).
+			Where("id = ? AND status = ? AND actual_amount >= ?", order.ID, models.OrderStatusPending, discount).
+			Updates(map[string]interface{}{
+				"discount_amount": gorm.Expr("discount_amount + ?", discount),
+				"actual_amount":   gorm.Expr("actual_amount - ?", discount),
+			})
+		if res.Error != nil {
+			return errors.ErrDatabaseError.WithError(res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return errors.ErrOrderStatusError.WithMessage("订单状态已变更，请刷新后重试")
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return discount, nil
+}
+
+// RedeemPointsTx 在事务中扣减用于抵扣订单的积分，返回抵扣金额，订单金额由调用方更新
+func (s *PointsService) RedeemPointsTx(ctx context.Context, tx *gorm.DB, userID int64, points int, orderNo string) (float64, error) {
 	if points <= 0 {
+		return 0, errors.ErrInvalidParams.WithMessage("积分必须大于0")
+	}
+
+	discount := CalculateRedeemDiscount(points)
+	description := fmt.Sprintf("%d积分抵扣%.2f元", points, discount)
+	if err := s.DeductPointsTx(ctx, tx, userID, points, PointsTypeRedeem, description, &orderNo); err != nil {
+		return 0, err
+	}
+	return discount, nil
+}
+
+// ReturnRedeemedPointsTx 在事务中返还订单抵扣的积分（如订单取消），已返还的部分不重复返还
+func (s *PointsService) ReturnRedeemedPointsTx(ctx context.Context, tx *gorm.DB, userID int64, orderNo string) error {
+	var net int
+	err := tx.WithContext(ctx).Model(&models.UserPointsLog{}).
+		Where("user_id = ? AND order_no = ? AND type IN ?", userID, orderNo,
+			[]string{PointsTypeRedeem, PointsTypeRedeemReturn}).
+		Select("COALESCE(SUM(points), 0)").
+		Row().Scan(&net)
+	if err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	if net >= 0 {
 		return nil
 	}
 
-	description := fmt.Sprintf("订单退款扣减积分")
-	return s.DeductPointsTx(ctx, tx, userID, points, PointsTypeRefund, description, &orderNo)
+	// 返还抵扣的积分不触发会员升级，也不计入累计获得积分
+	points := -net
+	if err := tx.WithContext(ctx).Model(&models.User{}).
+		Where("id = ?", userID).
+		UpdateColumn("points", gorm.Expr("points + ?", points)).Error; err != nil {
+		return errors.ErrDatabaseError.WithError(err)
+	}
+	return s.createLogTx(ctx, tx, userID, PointsTypeRedeemReturn, points, "订单取消返还抵扣积分", &orderNo)
 }
@@ -34,6 +34,8 @@ func setupPointsServiceTestDB(t *testing.T) *gorm.DB {
 		&models.User{},
 		&models.MemberLevel{},
 		&models.WalletTransaction{},
+		&models.UserPointsLog{},
+		&models.Order{},
 	))
 
 	db.Create(&models.MemberLevel{ID: 1, Name: "普通会员", Level: 1, MinPoints: 0, Discount: 1.0})
@@ -99,7 +101,7 @@ func TestPointsService_AddConsumePoints_UpgradesLevelAndWritesHistory(t *testing
 	assert.Equal(t, int64(2), refreshed.MemberLevelID) // 触发升级
 
 	var txCount int64
-	require.NoError(t, db.Model(&models.WalletTransaction{}).Where("user_id = ? AND type = ?", user.ID, "points_consume").Count(&txCount).Error)
+	require.NoError(t, db.Model(&models.UserPointsLog{}).Where("user_id = ? AND type = ?", user.ID, "consume").Count(&txCount).Error)
 	assert.Equal(t, int64(1), txCount)
 }
 
@@ -145,7 +147,7 @@ func TestPointsService_GetPointsHistory_FilterAndOrder(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, int64(1), filteredTotal)
 	require.Len(t, filtered, 1)
-	assert.Equal(t, "points_admin", filtered[0].Type)
+	assert.Equal(t, PointsTypeAdmin, filtered[0].Type)
 	assert.Equal(t, 10, filtered[0].Points)
 }
 
@@ -155,18 +157,19 @@ func TestPointsService_RefundPoints(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("退款扣减积分成功", func(t *testing.T) {
-		user := createTestUserForPoints(db, 100, 1)
+		user := createTestUserForPoints(db, 50, 1)
+		require.NoError(t, svc.AddConsumePoints(ctx, user.ID, 50.5, "O202401010002"))
 
 		err := svc.RefundPoints(ctx, user.ID, 50.5, "O202401010002")
 		require.NoError(t, err)
 
 		var refreshed models.User
 		require.NoError(t, db.First(&refreshed, user.ID).Error)
-		assert.Equal(t, 50, refreshed.Points) // 100 - 50 = 50
+		assert.Equal(t, 50, refreshed.Points) // 50 + 50 - 50 = 50
 
 		// 验证积分记录
 		var txCount int64
-		require.NoError(t, db.Model(&models.WalletTransaction{}).Where("user_id = ? AND type = ?", user.ID, "points_refund").Count(&txCount).Error)
+		require.NoError(t, db.Model(&models.UserPointsLog{}).Where("user_id = ? AND type = ?", user.ID, "refund").Count(&txCount).Error)
 		assert.Equal(t, int64(1), txCount)
 	})
 
@@ -188,7 +191,8 @@ func TestPointsService_RefundPointsTx(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("事务中退款扣减积分成功", func(t *testing.T) {
-		user := createTestUserForPoints(db, 100, 1)
+		user := createTestUserForPoints(db, 70, 1)
+		require.NoError(t, svc.AddConsumePoints(ctx, user.ID, 30.5, "O202401010004"))
 
 		err := db.Transaction(func(tx *gorm.DB) error {
 			return svc.RefundPointsTx(ctx, tx, user.ID, 30.5, "O202401010004")
@@ -254,14 +258,14 @@ func TestPointsService_getPointsTypeName(t *testing.T) {
 		txType   string
 		expected string
 	}{
-		{"points_consume", "消费获取"},
-		{"points_package_purchase", "套餐购买"},
-		{"points_sign_in", "签到"},
-		{"points_activity", "活动赠送"},
-		{"points_refund", "退款扣减"},
-		{"points_expired", "积分过期"},
-		{"points_exchange", "积分兑换"},
-		{"points_admin", "管理员调整"},
+		{"consume", "消费获取"},
+		{"package_purchase", "套餐购买"},
+		{"sign_in", "签到"},
+		{"activity", "活动赠送"},
+		{"refund", "退款扣减"},
+		{"expired", "积分过期"},
+		{"exchange", "积分兑换"},
+		{"admin", "管理员调整"},
 		{"unknown", "其他"},
 	}
 
@@ -287,3 +291,71 @@ func TestPointsService_getDescription(t *testing.T) {
 	})
 }
 
+
+func TestPointsService_SetPointsRate(t *testing.T) {
+	db := setupPointsServiceTestDB(t)
+	svc, _, _ := newPointsServiceForTest(db)
+	ctx := context.Background()
+
+	assert.Equal(t, 10, svc.CalculatePointsByAmount(10.9))
+
+	svc.SetPointsRate(0) // 非正数忽略
+	assert.Equal(t, 10, svc.CalculatePointsByAmount(10.9))
+
+	svc.SetPointsRate(2)
+	assert.Equal(t, 21, svc.CalculatePointsByAmount(10.9))
+
+	user := createTestUserForPoints(db, 0, 1)
+	require.NoError(t, svc.AddConsumePoints(ctx, user.ID, 50, "O202401010008"))
+
+	var refreshed models.User
+	require.NoError(t, db.First(&refreshed, user.ID).Error)
+	assert.Equal(t, 100, refreshed.Points)
+	assert.Equal(t, int64(2), refreshed.MemberLevelID)
+}
+
+func TestPointsService_AddConsumePoints_OncePerOrder(t *testing.T) {
+	db := setupPointsServiceTestDB(t)
+	svc, _, _ := newPointsServiceForTest(db)
+	ctx := context.Background()
+
+	user := createTestUserForPoints(db, 0, 1)
+	require.NoError(t, svc.AddConsumePoints(ctx, user.ID, 30, "O202401010009"))
+	require.NoError(t, svc.AddConsumePoints(ctx, user.ID, 30, "O202401010009"))
+
+	var refreshed models.User
+	require.NoError(t, db.First(&refreshed, user.ID).Error)
+	assert.Equal(t, 30, refreshed.Points)
+
+	var logCount int64
+	require.NoError(t, db.Model(&models.UserPointsLog{}).Where("user_id = ? AND order_no = ?", user.ID, "O202401010009").Count(&logCount).Error)
+	assert.Equal(t, int64(1), logCount)
+}
+
+func TestPointsService_AddPoints_DoesNotDowngradeLevel(t *testing.T) {
+	db := setupPointsServiceTestDB(t)
+	db.Create(&models.MemberLevel{ID: 3, Name: "钻石会员", Level: 3, MinPoints: 1000, Discount: 0.8})
+	svc, _, _ := newPointsServiceForTest(db)
+	ctx := context.Background()
+
+	// 购买套餐获得的高等级不因积分不足被降级
+	user := createTestUserForPoints(db, 0, 3)
+	require.NoError(t, svc.AddConsumePoints(ctx, user.ID, 150, "O202401010010"))
+
+	var refreshed models.User
+	require.NoError(t, db.First(&refreshed, user.ID).Error)
+	assert.Equal(t, 150, refreshed.Points)
+	assert.Equal(t, int64(3), refreshed.MemberLevelID)
+
+	// 扣减积分后低于当前等级门槛，等级保持不变
+	phone := "13900000011"
+	upgraded := &models.User{Phone: &phone, Nickname: "测试用户", MemberLevelID: 1, Status: models.UserStatusActive}
+	require.NoError(t, db.Create(upgraded).Error)
+	require.NoError(t, svc.AddConsumePoints(ctx, upgraded.ID, 120, "O202401010011"))
+	require.NoError(t, svc.RefundPoints(ctx, upgraded.ID, 100, "O202401010011"))
+
+	var afterRefund models.User
+	require.NoError(t, db.First(&afterRefund, upgraded.ID).Error)
+	assert.Equal(t, 20, afterRefund.Points)
+	assert.Equal(t, int64(2), afterRefund.MemberLevelID)
+}
@@ -57,8 +57,9 @@ func (nt *NullableTime) UnmarshalJSON(data []byte) error {
 
 // UserService 用户服务
 type UserService struct {
-	db       *gorm.DB
-	userRepo *repository.UserRepository
+	db           *gorm.DB
+	userRepo     *repository.UserRepository
+	tokenRevoker TokenRevoker
 }
 
 // NewUserService 创建用户服务
@@ -132,7 +132,7 @@ func TestUserService_UpdateProfile(t *testing.T) {
 			Nickname: &nickname,
 			Avatar:   &avatar,
 			Gender:   &gender,
-			Birthday: &birthday,
+			Birthday: NullableTime{Time: &birthday, Valid: true},
 		}
 		require.NoError(t, svc.UpdateProfile(ctx, u.ID, req))
 
@@ -0,0 +1,138 @@
+// Package user 钱包乐观锁并发单元测试
+package user
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// setupWalletFileDB 创建基于文件的 SQLite 数据库，允许多个连接并发访问
+func setupWalletFileDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "wallet.db") + "?_journal_mode=WAL&_busy_timeout=10000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(20)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(
+		&models.User{},
+		&models.UserWallet{},
+		&models.WalletTransaction{},
+		&models.MemberLevel{},
+	))
+	db.Create(&models.MemberLevel{ID: 1, Name: "普通会员", Level: 1, MinPoints: 0, Discount: 1.0})
+
+	return db
+}
+
+// bumpWalletVersionOnUpdate 在钱包条件更新前抢先递增 version，模拟其他事务并发修改；times 为模拟次数
+func bumpWalletVersionOnUpdate(t *testing.T, db *gorm.DB, times int) {
+	t.Helper()
+
+	remaining := times
+	require.NoError(t, db.Callback().Update().Before("gorm:update").Register("test:bump_wallet_version", func(tx *gorm.DB) {
+		if tx.Statement.Table != "user_wallets" || remaining == 0 {
+			return
+		}
+		remaining--
+		_, err := tx.Statement.ConnPool.ExecContext(tx.Statement.Context, "UPDATE user_wallets SET version = version + 1")
+		require.NoError(t, err)
+	}))
+}
+
+func TestWalletService_ConcurrentConsume(t *testing.T) {
+	db := setupWalletFileDB(t)
+	svc := setupWalletService(db)
+	ctx := context.Background()
+
+	user, _ := createWalletTestUser(t, db, "13800138100", 100.0)
+
+	const workers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- svc.Consume(ctx, user.ID, 2.5, fmt.Sprintf("CONCURRENT%02d", i))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	var wallet models.UserWallet
+	require.NoError(t, db.Where("user_id = ?", user.ID).First(&wallet).Error)
+	assert.Equal(t, 50.0, wallet.Balance)
+	assert.Equal(t, 50.0, wallet.TotalConsumed)
+	assert.Equal(t, workers, wallet.Version)
+
+	var count int64
+	require.NoError(t, db.Model(&models.WalletTransaction{}).Where("user_id = ?", user.ID).Count(&count).Error)
+	assert.Equal(t, int64(workers), count)
+}
+
+func TestWalletService_OptimisticLockConflict(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("版本冲突后重试成功", func(t *testing.T) {
+		db := setupWalletTestDB(t)
+		svc := setupWalletService(db)
+		user, _ := createWalletTestUser(t, db, "13800138101", 100.0)
+		bumpWalletVersionOnUpdate(t, db, 2)
+
+		require.NoError(t, svc.FreezeDeposit(ctx, user.ID, 30.0, "DEPOSIT001"))
+
+		var wallet models.UserWallet
+		require.NoError(t, db.Where("user_id = ?", user.ID).First(&wallet).Error)
+		assert.Equal(t, 70.0, wallet.Balance)
+		assert.Equal(t, 30.0, wallet.FrozenBalance)
+		assert.Equal(t, 3, wallet.Version)
+
+		var count int64
+		require.NoError(t, db.Model(&models.WalletTransaction{}).Where("user_id = ?", user.ID).Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("重试次数耗尽返回冲突错误且不写流水", func(t *testing.T) {
+		db := setupWalletTestDB(t)
+		svc := setupWalletService(db)
+		user, _ := createWalletTestUser(t, db, "13800138102", 100.0)
+		bumpWalletVersionOnUpdate(t, db, walletUpdateMaxAttempts)
+
+		err := svc.Consume(ctx, user.ID, 30.0, "ORDER101")
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok, "expected AppError, got %v", err)
+		assert.Equal(t, appErrors.ErrWalletConflict.Code, appErr.Code)
+
+		var wallet models.UserWallet
+		require.NoError(t, db.Where("user_id = ?", user.ID).First(&wallet).Error)
+		assert.Equal(t, 100.0, wallet.Balance)
+
+		var count int64
+		require.NoError(t, db.Model(&models.WalletTransaction{}).Where("user_id = ?", user.ID).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+}
@@ -0,0 +1,204 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/pkg/wechatpay"
+)
+
+// 充值相关常量
+const (
+	MinRechargeAmount     = 0.01             // 单笔最低充值金额
+	MaxRechargeAmount     = 5000.0           // 单笔最高充值金额
+	rechargePaymentTTL    = 30 * time.Minute // 充值支付单有效期
+	rechargeOrderPrefix   = "RC"
+	rechargePaymentPrefix = "P"
+)
+
+// RechargeRequest 充值请求
+type RechargeRequest struct {
+	Amount  float64 `json:"amount" binding:"required,gt=0"`
+	Channel string  `json:"channel" binding:"required,oneof=wechat alipay"`
+}
+
+// RechargeResult 充值下单结果
+type RechargeResult struct {
+	OrderNo   string                          `json:"order_no"`
+	PaymentNo string                          `json:"payment_no"`
+	Amount    float64                         `json:"amount"`
+	Channel   string                          `json:"channel"`
+	PayURL    string                          `json:"pay_url,omitempty"` // 扫码支付链接，可直接生成二维码
+	PayParams *wechatpay.UnifiedOrderResponse `json:"pay_params,omitempty"`
+	ExpiredAt time.Time                       `json:"expired_at"`
+}
+
+// InitiateRecharge 发起钱包充值
+// 创建充值订单及待支付的支付单，返回第三方支付链接/二维码内容
+func (s *WalletService) InitiateRecharge(ctx context.Context, userID int64, amount float64, channel string) (*RechargeResult, error) {
+	if channel != models.PaymentMethodWechat && channel != models.PaymentMethodAlipay {
+		return nil, errors.ErrPaymentMethodError
+	}
+	amount = roundToCent(amount)
+	if amount < MinRechargeAmount {
+		return nil, errors.ErrInvalidParams.WithMessage("充值金额必须大于0")
+	}
+	if amount > MaxRechargeAmount {
+		return nil, errors.ErrInvalidParams.WithMessage(fmt.Sprintf("单笔充值金额不能超过%.2f元", MaxRechargeAmount))
+	}
+
+	orderNo := utils.GenerateOrderNo(rechargeOrderPrefix)
+	paymentNo := utils.GenerateOrderNo(rechargePaymentPrefix)
+	expiredAt := time.Now().Add(rechargePaymentTTL)
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order := &models.Order{
+			OrderNo:        orderNo,
+			UserID:         userID,
+			Type:           models.OrderTypeRecharge,
+			OriginalAmount: amount,
+			ActualAmount:   amount,
+			Status:         models.OrderStatusPending,
+		}
+		if err := tx.Create(order).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		payment := &models.Payment{
+			PaymentNo:      paymentNo,
+			OrderID:        order.ID,
+			OrderNo:        orderNo,
+			UserID:         userID,
+			Amount:         amount,
+			PaymentMethod:  channel,
+			PaymentChannel: models.PaymentChannelNative,
+			Status:         models.PaymentStatusPending,
+			ExpiredAt:      &expiredAt,
+		}
+		if err := tx.Create(payment).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RechargeResult{
+		OrderNo:   orderNo,
+		PaymentNo: paymentNo,
+		Amount:    amount,
+		Channel:   channel,
+		ExpiredAt: expiredAt,
+	}
+
+	// 调用微信支付创建扫码订单
+	if channel == models.PaymentMethodWechat && s.wechatPay != nil {
+		payParams, err := s.wechatPay.CreateNativeOrder(ctx, &wechatpay.UnifiedOrderRequest{
+			OutTradeNo:  paymentNo,
+			Description: fmt.Sprintf("钱包充值-%s", orderNo),
+			Amount:      int64(math.Round(amount * 100)), // 转换为分
+		})
+		if err != nil {
+			return nil, errors.ErrPaymentFailed.WithError(err)
+		}
+		result.PayParams = payParams
+		result.PayURL = payParams.CodeURL
+	}
+
+	return result, nil
+}
+
+// HandleRechargeCallback 处理充值支付回调
+// 校验金额后为用户钱包入账，并将支付单标记为成功；重复回调不会重复入账
+func (s *WalletService) HandleRechargeCallback(ctx context.Context, paymentNo string, externalOrderNo string, paidAmount float64) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var payment models.Payment
+		if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("payment_no = ?", paymentNo).First(&payment).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.ErrPaymentNotFound
+			}
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		// 已处理过的回调直接返回成功
+		if payment.Status == models.PaymentStatusSuccess {
+			return nil
+		}
+		if payment.Status != models.PaymentStatusPending {
+			return errors.ErrPaymentCallbackError.WithMessage("支付单状态异常")
+		}
+
+		// 校验金额（按分比较，避免浮点误差）
+		if roundToCent(paidAmount) != roundToCent(payment.Amount) {
+			return errors.ErrPaymentCallbackError.WithMessage("金额不匹配")
+		}
+
+		var order models.Order
+		if err := tx.WithContext(ctx).Where("id = ?", payment.OrderID).First(&order).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		if order.Type != models.OrderTypeRecharge {
+			return errors.ErrPaymentCallbackError.WithMessage("非充值订单")
+		}
+
+		// 先将支付单由待支付条件更新为成功，并发回调时只有一个请求能继续入账
+		now := time.Now()
+		result := tx.WithContext(ctx).Model(&models.Payment{}).
+			Where("id = ? AND status = ?", payment.ID, models.PaymentStatusPending).
+			Updates(map[string]interface{}{
+				"status":         models.PaymentStatusSuccess,
+				"transaction_id": externalOrderNo,
+				"pay_time":       now,
+			})
+		if result.Error != nil {
+			return errors.ErrDatabaseError.WithError(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := s.rechargeTx(ctx, tx, payment.UserID, payment.Amount, payment.OrderNo, &payment.PaymentNo); err != nil {
+			return err
+		}
+
+		if err := tx.WithContext(ctx).Model(&order).Updates(map[string]interface{}{
+			"status":       models.OrderStatusCompleted,
+			"paid_at":      now,
+			"completed_at": now,
+		}).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		return nil
+	})
+	if err != nil && s.isRechargeCredited(ctx, paymentNo) {
+		// 并发回调时另一请求已完成入账（payment_no 唯一约束冲突），视为成功
+		return nil
+	}
+	return err
+}
+
+// isRechargeCredited 判断支付单是否已入账
+func (s *WalletService) isRechargeCredited(ctx context.Context, paymentNo string) bool {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.WalletTransaction{}).
+		Where("payment_no = ?", paymentNo).Count(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
+
+// roundToCent 金额保留两位小数
+func roundToCent(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
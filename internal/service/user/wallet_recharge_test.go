@@ -0,0 +1,169 @@
+// Package user 钱包充值单元测试
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/errors"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	"github.com/dumeirei/smart-locker-backend/pkg/wechatpay"
+)
+
+func TestWalletService_InitiateRecharge(t *testing.T) {
+	db := setupWalletTestDB(t)
+	svc := setupWalletService(db)
+	ctx := context.Background()
+	user, _ := createWalletTestUser(t, db, "13800138100", 0)
+
+	t.Run("创建待支付充值单", func(t *testing.T) {
+		result, err := svc.InitiateRecharge(ctx, user.ID, 100, models.PaymentMethodAlipay)
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.OrderNo)
+		assert.NotEmpty(t, result.PaymentNo)
+		assert.Equal(t, 100.0, result.Amount)
+
+		var payment models.Payment
+		require.NoError(t, db.Where("payment_no = ?", result.PaymentNo).First(&payment).Error)
+		assert.Equal(t, int8(models.PaymentStatusPending), payment.Status)
+		assert.Equal(t, models.PaymentMethodAlipay, payment.PaymentMethod)
+		assert.Equal(t, user.ID, payment.UserID)
+
+		var order models.Order
+		require.NoError(t, db.First(&order, payment.OrderID).Error)
+		assert.Equal(t, models.OrderTypeRecharge, order.Type)
+		assert.Equal(t, models.OrderStatusPending, order.Status)
+	})
+
+	t.Run("微信充值返回扫码链接", func(t *testing.T) {
+		wp, err := wechatpay.NewClient(&wechatpay.Config{})
+		require.NoError(t, err)
+		wechatSvc := NewWalletService(db, repository.NewUserRepository(db), wp)
+
+		result, err := wechatSvc.InitiateRecharge(ctx, user.ID, 50, models.PaymentMethodWechat)
+		require.NoError(t, err)
+		require.NotNil(t, result.PayParams)
+		assert.Equal(t, result.PayParams.CodeURL, result.PayURL)
+		assert.NotEmpty(t, result.PayURL)
+	})
+
+	t.Run("不支持的支付方式", func(t *testing.T) {
+		_, err := svc.InitiateRecharge(ctx, user.ID, 100, models.PaymentMethodBalance)
+		assert.ErrorIs(t, err, errors.ErrPaymentMethodError)
+	})
+
+	t.Run("充值金额无效", func(t *testing.T) {
+		_, err := svc.InitiateRecharge(ctx, user.ID, 0, models.PaymentMethodWechat)
+		assert.Error(t, err)
+
+		_, err = svc.InitiateRecharge(ctx, user.ID, MaxRechargeAmount+1, models.PaymentMethodWechat)
+		assert.Error(t, err)
+	})
+}
+
+func TestWalletService_HandleRechargeCallback(t *testing.T) {
+	db := setupWalletTestDB(t)
+	svc := setupWalletService(db)
+	ctx := context.Background()
+
+	t.Run("重复回调只入账一次", func(t *testing.T) {
+		user, _ := createWalletTestUser(t, db, "13800138101", 10)
+		result, err := svc.InitiateRecharge(ctx, user.ID, 100, models.PaymentMethodWechat)
+		require.NoError(t, err)
+
+		require.NoError(t, svc.HandleRechargeCallback(ctx, result.PaymentNo, "wx_tx_001", 100))
+		require.NoError(t, svc.HandleRechargeCallback(ctx, result.PaymentNo, "wx_tx_001", 100))
+
+		var wallet models.UserWallet
+		require.NoError(t, db.Where("user_id = ?", user.ID).First(&wallet).Error)
+		assert.Equal(t, 110.0, wallet.Balance)
+		assert.Equal(t, 100.0, wallet.TotalRecharged)
+
+		var txs []models.WalletTransaction
+		require.NoError(t, db.Where("user_id = ?", user.ID).Find(&txs).Error)
+		require.Len(t, txs, 1)
+		assert.Equal(t, models.WalletTxTypeRecharge, txs[0].Type)
+		require.NotNil(t, txs[0].PaymentNo)
+		assert.Equal(t, result.PaymentNo, *txs[0].PaymentNo)
+
+		var payment models.Payment
+		require.NoError(t, db.Where("payment_no = ?", result.PaymentNo).First(&payment).Error)
+		assert.Equal(t, int8(models.PaymentStatusSuccess), payment.Status)
+		require.NotNil(t, payment.TransactionID)
+		assert.Equal(t, "wx_tx_001", *payment.TransactionID)
+		assert.NotNil(t, payment.PaidAt)
+
+		var order models.Order
+		require.NoError(t, db.First(&order, payment.OrderID).Error)
+		assert.Equal(t, models.OrderStatusCompleted, order.Status)
+	})
+
+	t.Run("同一支付单号的流水受唯一约束保护", func(t *testing.T) {
+		user, _ := createWalletTestUser(t, db, "13800138102", 0)
+		paymentNo := "P_DUP_001"
+		first := &models.WalletTransaction{UserID: user.ID, Type: models.WalletTxTypeRecharge, Amount: 1, BalanceAfter: 1, PaymentNo: &paymentNo}
+		require.NoError(t, db.Create(first).Error)
+
+		dup := &models.WalletTransaction{UserID: user.ID, Type: models.WalletTxTypeRecharge, Amount: 1, BalanceAfter: 2, PaymentNo: &paymentNo}
+		assert.Error(t, db.Create(dup).Error)
+	})
+
+	t.Run("金额不匹配不入账", func(t *testing.T) {
+		user, _ := createWalletTestUser(t, db, "13800138103", 0)
+		result, err := svc.InitiateRecharge(ctx, user.ID, 100, models.PaymentMethodWechat)
+		require.NoError(t, err)
+
+		err = svc.HandleRechargeCallback(ctx, result.PaymentNo, "wx_tx_002", 99.99)
+		require.Error(t, err)
+		appErr, ok := err.(*errors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, errors.ErrPaymentCallbackError.Code, appErr.Code)
+
+		var wallet models.UserWallet
+		require.NoError(t, db.Where("user_id = ?", user.ID).First(&wallet).Error)
+		assert.Equal(t, 0.0, wallet.Balance)
+
+		var payment models.Payment
+		require.NoError(t, db.Where("payment_no = ?", result.PaymentNo).First(&payment).Error)
+		assert.Equal(t, int8(models.PaymentStatusPending), payment.Status)
+	})
+
+	t.Run("读取后支付单已被其他回调处理时不重复入账", func(t *testing.T) {
+		user, _ := createWalletTestUser(t, db, "13800138104", 10)
+		result, err := svc.InitiateRecharge(ctx, user.ID, 100, models.PaymentMethodWechat)
+		require.NoError(t, err)
+
+		// SQLite 无行锁，在同一事务内改写以模拟读到旧数据
+		var fired bool
+		require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:concurrent_recharge", func(tx *gorm.DB) {
+			if fired || tx.Statement.Table != "payments" {
+				return
+			}
+			fired = true
+			require.NoError(t, tx.Session(&gorm.Session{NewDB: true}).
+				Exec("UPDATE payments SET status = ? WHERE payment_no = ?", models.PaymentStatusSuccess, result.PaymentNo).Error)
+		}))
+		defer db.Callback().Query().Remove("test:concurrent_recharge")
+
+		require.NoError(t, svc.HandleRechargeCallback(ctx, result.PaymentNo, "wx_tx_004", 100))
+		require.True(t, fired)
+
+		var wallet models.UserWallet
+		require.NoError(t, db.Where("user_id = ?", user.ID).First(&wallet).Error)
+		assert.Equal(t, 10.0, wallet.Balance)
+
+		var count int64
+		db.Model(&models.WalletTransaction{}).Where("payment_no = ?", result.PaymentNo).Count(&count)
+		assert.Zero(t, count)
+	})
+
+	t.Run("支付单不存在", func(t *testing.T) {
+		err := svc.HandleRechargeCallback(ctx, "P_NOT_EXIST", "wx_tx_003", 100)
+		assert.ErrorIs(t, err, errors.ErrPaymentNotFound)
+	})
+}
@@ -12,19 +12,23 @@ import (
 	"github.com/dumeirei/smart-locker-backend/internal/common/utils"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	"github.com/dumeirei/smart-locker-backend/pkg/wechatpay"
 )
 
 // WalletService 钱包服务
 type WalletService struct {
-	db       *gorm.DB
-	userRepo *repository.UserRepository
+	db        *gorm.DB
+	userRepo  *repository.UserRepository
+	wechatPay *wechatpay.Client
 }
 
 // NewWalletService 创建钱包服务
-func NewWalletService(db *gorm.DB, userRepo *repository.UserRepository) *WalletService {
+// wechatPay 可为 nil，此时充值只创建支付单，不调用第三方下单
+func NewWalletService(db *gorm.DB, userRepo *repository.UserRepository, wechatPay *wechatpay.Client) *WalletService {
 	return &WalletService{
-		db:       db,
-		userRepo: userRepo,
+		db:        db,
+		userRepo:  userRepo,
+		wechatPay: wechatPay,
 	}
 }
 
@@ -46,6 +50,7 @@ type TransactionRecord struct {
 	BalanceAfter  float64   `json:"balance_after"`
 	OrderNo       *string   `json:"order_no,omitempty"`
 	Remark        *string   `json:"remark,omitempty"`
+	OperatorID    *int64    `json:"operator_id,omitempty"`
 	CreatedAt     time.Time `json:"created_at"`
 }
 
@@ -103,6 +108,7 @@ func (s *WalletService) GetTransactions(ctx context.Context, userID int64, offse
 			BalanceAfter:  tx.BalanceAfter,
 			OrderNo:       tx.OrderNo,
 			Remark:        tx.Remark,
+			OperatorID:    tx.OperatorID,
 			CreatedAt:     tx.CreatedAt,
 		}
 	}
@@ -125,6 +131,12 @@ func (s *WalletService) getTypeName(txType string) string {
 		return "押金冻结"
 	case models.WalletTxTypeReturnDeposit:
 		return "押金退还"
+	case models.WalletTxTypeCommissionClawback:
+		return "佣金追回"
+	case models.WalletTxTypeAdjustment:
+		return "人工调整"
+	case models.WalletTxTypeFrozenAdjustment:
+		return "冻结余额调整"
 	default:
 		return "其他"
 	}
@@ -143,24 +155,25 @@ func (s *WalletService) Recharge(ctx context.Context, userID int64, amount float
 
 // RechargeTx 在已有事务中充值（增加余额）
 func (s *WalletService) RechargeTx(ctx context.Context, tx *gorm.DB, userID int64, amount float64, orderNo string) error {
+	return s.rechargeTx(ctx, tx, userID, amount, orderNo, nil)
+}
+
+// rechargeTx 在已有事务中充值，paymentNo 非空时记录到流水用于防重复入账
+func (s *WalletService) rechargeTx(ctx context.Context, tx *gorm.DB, userID int64, amount float64, orderNo string, paymentNo *string) error {
 	if amount <= 0 {
 		return errors.ErrInvalidParams.WithMessage("充值金额必须大于0")
 	}
 
-	var wallet models.UserWallet
-	if err := tx.WithContext(ctx).Set("gorm:query_option", "FOR UPDATE").
-		Where("user_id = ?", userID).First(&wallet).Error; err != nil {
-		return errors.ErrDatabaseError.WithError(err)
-	}
-
-	balanceBefore := wallet.Balance
-	balanceAfter := balanceBefore + amount
-
-	if err := tx.WithContext(ctx).Model(&wallet).Updates(map[string]interface{}{
-		"balance":         balanceAfter,
-		"total_recharged": gorm.Expr("total_recharged + ?", amount),
-	}).Error; err != nil {
-		return errors.ErrDatabaseError.WithError(err)
+	var balanceBefore, balanceAfter float64
+	if _, err := s.updateWalletTx(ctx, tx, userID, func(wallet *models.UserWallet) (map[string]interface{}, error) {
+		balanceBefore = wallet.Balance
+		balanceAfter = balanceBefore + amount
+		return map[string]interface{}{
+			"balance":         balanceAfter,
+			"total_recharged": gorm.Expr("total_recharged + ?", amount),
+		}, nil
+	}); err != nil {
+		return err
 	}
 
 	transaction := &models.WalletTransaction{
@@ -170,6 +183,7 @@ func (s *WalletService) RechargeTx(ctx context.Context, tx *gorm.DB, userID int6
 		BalanceBefore: balanceBefore,
 		BalanceAfter:  balanceAfter,
 		OrderNo:       &orderNo,
+		PaymentNo:     paymentNo,
 		Remark:        utils.StringPtr("余额充值"),
 	}
 	if err := tx.WithContext(ctx).Create(transaction).Error; err != nil {
@@ -196,24 +210,19 @@ func (s *WalletService) ConsumeTx(ctx context.Context, tx *gorm.DB, userID int64
 		return errors.ErrInvalidParams.WithMessage("消费金额必须大于0")
 	}
 
-	var wallet models.UserWallet
-	if err := tx.WithContext(ctx).Set("gorm:query_option", "FOR UPDATE").
-		Where("user_id = ?", userID).First(&wallet).Error; err != nil {
-		return errors.ErrDatabaseError.WithError(err)
-	}
-
-	if wallet.Balance < amount {
-		return errors.ErrBalanceInsufficient
-	}
-
-	balanceBefore := wallet.Balance
-	balanceAfter := balanceBefore - amount
-
-	if err := tx.WithContext(ctx).Model(&wallet).Updates(map[string]interface{}{
-		"balance":        balanceAfter,
-		"total_consumed": gorm.Expr("total_consumed + ?", amount),
-	}).Error; err != nil {
-		return errors.ErrDatabaseError.WithError(err)
+	var balanceBefore, balanceAfter float64
+	if _, err := s.updateWalletTx(ctx, tx, userID, func(wallet *models.UserWallet) (map[string]interface{}, error) {
+		if wallet.Balance < amount {
+			return nil, errors.ErrBalanceInsufficient
+		}
+		balanceBefore = wallet.Balance
+		balanceAfter = balanceBefore - amount
+		return map[string]interface{}{
+			"balance":        balanceAfter,
+			"total_consumed": gorm.Expr("total_consumed + ?", amount),
+		}, nil
+	}); err != nil {
+		return err
 	}
 
 	transaction := &models.WalletTransaction{
@@ -249,17 +258,13 @@ func (s *WalletService) RefundTx(ctx context.Context, tx *gorm.DB, userID int64,
 		return errors.ErrInvalidParams.WithMessage("退款金额必须大于0")
 	}
 
-	var wallet models.UserWallet
-	if err := tx.WithContext(ctx).Set("gorm:query_option", "FOR UPDATE").
-		Where("user_id = ?", userID).First(&wallet).Error; err != nil {
-		return errors.ErrDatabaseError.WithError(err)
-	}
-
-	balanceBefore := wallet.Balance
-	balanceAfter := balanceBefore + amount
-
-	if err := tx.WithContext(ctx).Model(&wallet).Update("balance", balanceAfter).Error; err != nil {
-		return errors.ErrDatabaseError.WithError(err)
+	var balanceBefore, balanceAfter float64
+	if _, err := s.updateWalletTx(ctx, tx, userID, func(wallet *models.UserWallet) (map[string]interface{}, error) {
+		balanceBefore = wallet.Balance
+		balanceAfter = balanceBefore + amount
+		return map[string]interface{}{"balance": balanceAfter}, nil
+	}); err != nil {
+		return err
 	}
 
 	transaction := &models.WalletTransaction{
@@ -295,24 +300,19 @@ func (s *WalletService) FreezeDepositTx(ctx context.Context, tx *gorm.DB, userID
 		return errors.ErrInvalidParams.WithMessage("押金金额必须大于0")
 	}
 
-	var wallet models.UserWallet
-	if err := tx.WithContext(ctx).Set("gorm:query_option", "FOR UPDATE").
-		Where("user_id = ?", userID).First(&wallet).Error; err != nil {
-		return errors.ErrDatabaseError.WithError(err)
-	}
-
-	if wallet.Balance < amount {
-		return errors.ErrBalanceInsufficient
-	}
-
-	balanceBefore := wallet.Balance
-	balanceAfter := balanceBefore - amount
-
-	if err := tx.WithContext(ctx).Model(&wallet).Updates(map[string]interface{}{
-		"balance":        balanceAfter,
-		"frozen_balance": gorm.Expr("frozen_balance + ?", amount),
-	}).Error; err != nil {
-		return errors.ErrDatabaseError.WithError(err)
+	var balanceBefore, balanceAfter float64
+	if _, err := s.updateWalletTx(ctx, tx, userID, func(wallet *models.UserWallet) (map[string]interface{}, error) {
+		if wallet.Balance < amount {
+			return nil, errors.ErrBalanceInsufficient
+		}
+		balanceBefore = wallet.Balance
+		balanceAfter = balanceBefore - amount
+		return map[string]interface{}{
+			"balance":        balanceAfter,
+			"frozen_balance": gorm.Expr("frozen_balance + ?", amount),
+		}, nil
+	}); err != nil {
+		return err
 	}
 
 	transaction := &models.WalletTransaction{
@@ -348,24 +348,19 @@ func (s *WalletService) UnfreezeDepositTx(ctx context.Context, tx *gorm.DB, user
 		return errors.ErrInvalidParams.WithMessage("押金金额必须大于0")
 	}
 
-	var wallet models.UserWallet
-	if err := tx.WithContext(ctx).Set("gorm:query_option", "FOR UPDATE").
-		Where("user_id = ?", userID).First(&wallet).Error; err != nil {
-		return errors.ErrDatabaseError.WithError(err)
-	}
-
-	if wallet.FrozenBalance < amount {
-		return errors.New(errors.ErrOperationFailed.Code, "冻结余额不足")
-	}
-
-	balanceBefore := wallet.Balance
-	balanceAfter := balanceBefore + amount
-
-	if err := tx.WithContext(ctx).Model(&wallet).Updates(map[string]interface{}{
-		"balance":        balanceAfter,
-		"frozen_balance": gorm.Expr("frozen_balance - ?", amount),
-	}).Error; err != nil {
-		return errors.ErrDatabaseError.WithError(err)
+	var balanceBefore, balanceAfter float64
+	if _, err := s.updateWalletTx(ctx, tx, userID, func(wallet *models.UserWallet) (map[string]interface{}, error) {
+		if wallet.FrozenBalance < amount {
+			return nil, errors.New(errors.ErrOperationFailed.Code, "冻结余额不足")
+		}
+		balanceBefore = wallet.Balance
+		balanceAfter = balanceBefore + amount
+		return map[string]interface{}{
+			"balance":        balanceAfter,
+			"frozen_balance": gorm.Expr("frozen_balance - ?", amount),
+		}, nil
+	}); err != nil {
+		return err
 	}
 
 	transaction := &models.WalletTransaction{
@@ -384,6 +379,86 @@ func (s *WalletService) UnfreezeDepositTx(ctx context.Context, tx *gorm.DB, user
 	return nil
 }
 
+// Adjust 人工调整可用余额，amount 为正时增加、为负时扣减
+// 调整后余额为负时拒绝调整；流水记录调整原因和操作管理员
+func (s *WalletService) Adjust(ctx context.Context, userID int64, amount float64, operatorID int64, reason string) (*TransactionRecord, error) {
+	return s.adjust(ctx, userID, amount, operatorID, reason, false)
+}
+
+// AdjustFrozen 人工调整冻结余额（如修正押金冻结异常），amount 为正时增加、为负时扣减
+// 调整后冻结余额为负时拒绝调整；可用余额不变，流水的变动前后余额相同
+func (s *WalletService) AdjustFrozen(ctx context.Context, userID int64, amount float64, operatorID int64, reason string) (*TransactionRecord, error) {
+	return s.adjust(ctx, userID, amount, operatorID, reason, true)
+}
+
+// adjust 人工调整可用余额或冻结余额并记录调整流水
+func (s *WalletService) adjust(ctx context.Context, userID int64, amount float64, operatorID int64, reason string, frozen bool) (*TransactionRecord, error) {
+	if amount == 0 {
+		return nil, errors.ErrInvalidParams.WithMessage("调整金额不能为0")
+	}
+
+	var transaction *models.WalletTransaction
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 钱包不存在时先创建
+		if err := tx.Where(models.UserWallet{UserID: userID}).FirstOrCreate(&models.UserWallet{}).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+
+		var balanceBefore, balanceAfter float64
+		if _, err := s.updateWalletTx(ctx, tx, userID, func(wallet *models.UserWallet) (map[string]interface{}, error) {
+			balanceBefore = wallet.Balance
+			if frozen {
+				if wallet.FrozenBalance+amount < 0 {
+					return nil, errors.New(errors.ErrOperationFailed.Code, "调整后冻结余额不能为负")
+				}
+				balanceAfter = balanceBefore
+				return map[string]interface{}{"frozen_balance": wallet.FrozenBalance + amount}, nil
+			}
+
+			if wallet.Balance+amount < 0 {
+				return nil, errors.New(errors.ErrOperationFailed.Code, "调整后余额不能为负")
+			}
+			balanceAfter = balanceBefore + amount
+			return map[string]interface{}{"balance": balanceAfter}, nil
+		}); err != nil {
+			return err
+		}
+
+		txType := models.WalletTxTypeAdjustment
+		if frozen {
+			txType = models.WalletTxTypeFrozenAdjustment
+		}
+		transaction = &models.WalletTransaction{
+			UserID:        userID,
+			Type:          txType,
+			Amount:        amount,
+			BalanceBefore: balanceBefore,
+			BalanceAfter:  balanceAfter,
+			Remark:        &reason,
+			OperatorID:    &operatorID,
+		}
+		if err := tx.WithContext(ctx).Create(transaction).Error; err != nil {
+			return errors.ErrDatabaseError.WithError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TransactionRecord{
+		ID:            transaction.ID,
+		Type:          transaction.Type,
+		TypeName:      s.getTypeName(transaction.Type),
+		Amount:        transaction.Amount,
+		BalanceBefore: transaction.BalanceBefore,
+		BalanceAfter:  transaction.BalanceAfter,
+		Remark:        transaction.Remark,
+		OperatorID:    transaction.OperatorID,
+		CreatedAt:     transaction.CreatedAt,
+	}, nil
+}
+
 // CheckBalance 检查余额是否充足
 func (s *WalletService) CheckBalance(ctx context.Context, userID int64, amount float64) (bool, error) {
 	var wallet models.UserWallet
@@ -429,21 +504,17 @@ func (s *WalletService) DeductFrozenToConsumeTx(ctx context.Context, tx *gorm.DB
 		return nil
 	}
 
-	var wallet models.UserWallet
-	if err := tx.WithContext(ctx).Set("gorm:query_option", "FOR UPDATE").
-		Where("user_id = ?", userID).First(&wallet).Error; err != nil {
-		return errors.ErrDatabaseError.WithError(err)
-	}
-
-	if wallet.FrozenBalance < amount {
-		return errors.New(errors.ErrOperationFailed.Code, "冻结余额不足")
-	}
-
-	if err := tx.WithContext(ctx).Model(&wallet).Updates(map[string]interface{}{
-		"frozen_balance": gorm.Expr("frozen_balance - ?", amount),
-		"total_consumed": gorm.Expr("total_consumed + ?", amount),
-	}).Error; err != nil {
-		return errors.ErrDatabaseError.WithError(err)
+	wallet, err := s.updateWalletTx(ctx, tx, userID, func(wallet *models.UserWallet) (map[string]interface{}, error) {
+		if wallet.FrozenBalance < amount {
+			return nil, errors.New(errors.ErrOperationFailed.Code, "冻结余额不足")
+		}
+		return map[string]interface{}{
+			"frozen_balance": gorm.Expr("frozen_balance - ?", amount),
+			"total_consumed": gorm.Expr("total_consumed + ?", amount),
+		}, nil
+	})
+	if err != nil {
+		return err
 	}
 
 	transaction := &models.WalletTransaction{
@@ -461,3 +532,40 @@ func (s *WalletService) DeductFrozenToConsumeTx(ctx context.Context, tx *gorm.DB
 
 	return nil
 }
+
+// walletUpdateMaxAttempts 钱包乐观锁更新的最大尝试次数
+const walletUpdateMaxAttempts = 5
+
+// walletMutation 根据当前钱包计算待更新字段，返回错误时放弃更新
+type walletMutation func(wallet *models.UserWallet) (map[string]interface{}, error)
+
+// updateWalletTx 在已有事务中以乐观锁方式更新钱包
+// 读取最新钱包后由 mutate 计算更新字段，仅当 version 未变化时更新并递增 version；
+// 版本冲突时重新读取重试，超过最大次数返回 ErrWalletConflict。成功时返回本次更新前的钱包
+func (s *WalletService) updateWalletTx(ctx context.Context, tx *gorm.DB, userID int64, mutate walletMutation) (*models.UserWallet, error) {
+	for attempt := 0; attempt < walletUpdateMaxAttempts; attempt++ {
+		var wallet models.UserWallet
+		if err := tx.WithContext(ctx).Set("gorm:query_option", "FOR UPDATE").
+			Where("user_id = ?", userID).First(&wallet).Error; err != nil {
+			return nil, errors.ErrDatabaseError.WithError(err)
+		}
+
+		updates, err := mutate(&wallet)
+		if err != nil {
+			return nil, err
+		}
+		updates["version"] = gorm.Expr("version + 1")
+
+		result := tx.WithContext(ctx).Model(&models.UserWallet{}).
+			Where("id = ? AND version = ?", wallet.ID, wallet.Version).
+			Updates(updates)
+		if result.Error != nil {
+			return nil, errors.ErrDatabaseError.WithError(result.Error)
+		}
+		if result.RowsAffected == 1 {
+			return &wallet, nil
+		}
+	}
+
+	return nil, errors.ErrWalletConflict
+}
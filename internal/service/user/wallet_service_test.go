@@ -28,6 +28,8 @@ func setupWalletTestDB(t *testing.T) *gorm.DB {
 		&models.UserWallet{},
 		&models.WalletTransaction{},
 		&models.MemberLevel{},
+		&models.Order{},
+		&models.Payment{},
 	))
 
 	// 创建默认会员等级
@@ -58,7 +60,7 @@ func createWalletTestUser(t *testing.T, db *gorm.DB, phone string, balance float
 
 func setupWalletService(db *gorm.DB) *WalletService {
 	userRepo := repository.NewUserRepository(db)
-	return NewWalletService(db, userRepo)
+	return NewWalletService(db, userRepo, nil)
 }
 
 func TestWalletService_GetWallet(t *testing.T) {
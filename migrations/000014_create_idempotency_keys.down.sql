@@ -0,0 +1,2 @@
+-- 回滚幂等键表
+DROP TABLE IF EXISTS idempotency_keys;
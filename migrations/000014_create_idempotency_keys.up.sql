@@ -0,0 +1,19 @@
+-- 幂等键表（防止支付/扣款请求重放导致重复扣费）
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id BIGSERIAL PRIMARY KEY,
+    idempotency_key VARCHAR(128) NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    scope VARCHAR(50) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    response_snapshot JSONB,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_idempotency_user_key UNIQUE (user_id, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+COMMENT ON TABLE idempotency_keys IS '幂等键表';
+COMMENT ON COLUMN idempotency_keys.scope IS '幂等作用域: rental_pay, payment_create';
+COMMENT ON COLUMN idempotency_keys.request_hash IS '请求参数摘要(SHA-256)，用于识别同键不同请求';
+COMMENT ON COLUMN idempotency_keys.response_snapshot IS '首次请求的响应快照';
@@ -0,0 +1,5 @@
+-- 移除租借超时宽限期字段
+ALTER TABLE rentals DROP COLUMN IF EXISTS grace_period_minutes;
+
+ALTER TABLE rental_pricings DROP CONSTRAINT IF EXISTS chk_rental_pricings_grace_period;
+ALTER TABLE rental_pricings DROP COLUMN IF EXISTS grace_period_minutes;
@@ -0,0 +1,11 @@
+-- 添加租借超时宽限期字段
+ALTER TABLE rental_pricings ADD COLUMN grace_period_minutes INT NOT NULL DEFAULT 0;
+ALTER TABLE rental_pricings ADD CONSTRAINT chk_rental_pricings_grace_period
+    CHECK (grace_period_minutes >= 0 AND grace_period_minutes <= 60);
+
+-- 租借记录保存下单时的宽限期快照
+ALTER TABLE rentals ADD COLUMN grace_period_minutes INT NOT NULL DEFAULT 0;
+
+-- 添加注释
+COMMENT ON COLUMN rental_pricings.grace_period_minutes IS '超时宽限期(分钟)，超过预计归还时间后在宽限期内归还不计超时费';
+COMMENT ON COLUMN rentals.grace_period_minutes IS '超时宽限期快照(分钟)';
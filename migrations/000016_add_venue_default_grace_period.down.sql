@@ -0,0 +1,9 @@
+-- 恢复定价宽限期为必填
+UPDATE rental_pricings SET grace_period_minutes = 0 WHERE grace_period_minutes IS NULL;
+ALTER TABLE rental_pricings ALTER COLUMN grace_period_minutes SET DEFAULT 0;
+ALTER TABLE rental_pricings ALTER COLUMN grace_period_minutes SET NOT NULL;
+COMMENT ON COLUMN rental_pricings.grace_period_minutes IS '超时宽限期(分钟)，超过预计归还时间后在宽限期内归还不计超时费';
+
+-- 移除场地默认超时宽限期
+ALTER TABLE venues DROP CONSTRAINT IF EXISTS chk_venues_default_grace_period;
+ALTER TABLE venues DROP COLUMN IF EXISTS default_grace_period_minutes;
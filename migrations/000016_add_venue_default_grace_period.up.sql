@@ -0,0 +1,12 @@
+-- 场地级默认超时宽限期，定价未单独配置宽限期时使用
+ALTER TABLE venues ADD COLUMN default_grace_period_minutes INT NOT NULL DEFAULT 0;
+ALTER TABLE venues ADD CONSTRAINT chk_venues_default_grace_period
+    CHECK (default_grace_period_minutes >= 0 AND default_grace_period_minutes <= 60);
+
+-- 定价宽限期允许为空，为空表示沿用场地默认值
+ALTER TABLE rental_pricings ALTER COLUMN grace_period_minutes DROP NOT NULL;
+ALTER TABLE rental_pricings ALTER COLUMN grace_period_minutes DROP DEFAULT;
+
+-- 添加注释
+COMMENT ON COLUMN venues.default_grace_period_minutes IS '默认超时宽限期(分钟)';
+COMMENT ON COLUMN rental_pricings.grace_period_minutes IS '超时宽限期(分钟)，为空时使用场地默认值';
@@ -0,0 +1,3 @@
+-- 移除钱包流水支付单号
+DROP INDEX IF EXISTS idx_wallet_transactions_payment_no;
+ALTER TABLE wallet_transactions DROP COLUMN IF EXISTS payment_no;
@@ -0,0 +1,6 @@
+-- 钱包流水关联支付单号，唯一约束防止同一笔充值回调重复入账
+ALTER TABLE wallet_transactions ADD COLUMN payment_no VARCHAR(64);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wallet_transactions_payment_no ON wallet_transactions(payment_no);
+
+-- 添加注释
+COMMENT ON COLUMN wallet_transactions.payment_no IS '支付单号(充值入账时记录)';
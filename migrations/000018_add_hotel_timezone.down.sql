@@ -0,0 +1,2 @@
+-- 移除酒店时区
+ALTER TABLE hotels DROP COLUMN IF EXISTS timezone;
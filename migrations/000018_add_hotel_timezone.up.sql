@@ -0,0 +1,5 @@
+-- 酒店时区，入住/退房时间及"过去时间"校验均按酒店当地时间计算
+ALTER TABLE hotels ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Shanghai';
+
+-- 添加注释
+COMMENT ON COLUMN hotels.timezone IS '酒店所在时区(IANA 名称，如 Asia/Shanghai)';
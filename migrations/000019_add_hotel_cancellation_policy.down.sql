@@ -0,0 +1,5 @@
+-- 移除酒店取消政策
+ALTER TABLE hotels DROP CONSTRAINT IF EXISTS chk_hotels_cancellation_fee_rate;
+ALTER TABLE hotels DROP CONSTRAINT IF EXISTS chk_hotels_free_cancel_hours;
+ALTER TABLE hotels DROP COLUMN IF EXISTS cancellation_fee_rate;
+ALTER TABLE hotels DROP COLUMN IF EXISTS free_cancel_hours;
@@ -0,0 +1,10 @@
+-- 酒店取消政策：入住前 free_cancel_hours 小时之前可免费取消，之后按 cancellation_fee_rate 收取手续费
+ALTER TABLE hotels ADD COLUMN free_cancel_hours INT NOT NULL DEFAULT 0;
+ALTER TABLE hotels ADD COLUMN cancellation_fee_rate DECIMAL(5,4) NOT NULL DEFAULT 0;
+ALTER TABLE hotels ADD CONSTRAINT chk_hotels_free_cancel_hours CHECK (free_cancel_hours >= 0);
+ALTER TABLE hotels ADD CONSTRAINT chk_hotels_cancellation_fee_rate
+    CHECK (cancellation_fee_rate >= 0 AND cancellation_fee_rate <= 1);
+
+-- 添加注释
+COMMENT ON COLUMN hotels.free_cancel_hours IS '免费取消时限(入住前小时数)';
+COMMENT ON COLUMN hotels.cancellation_fee_rate IS '超过免费取消时限后的取消手续费比例(0-1)';
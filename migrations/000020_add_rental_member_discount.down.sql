@@ -0,0 +1,3 @@
+-- 移除租借会员折扣字段
+ALTER TABLE rentals DROP COLUMN IF EXISTS discount_rate;
+ALTER TABLE rentals DROP COLUMN IF EXISTS original_fee;
@@ -0,0 +1,11 @@
+-- 租借会员折扣：记录折扣前租金与折扣率，rental_fee 为折后租金，便于财务对账
+ALTER TABLE rentals ADD COLUMN original_fee DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE rentals ADD COLUMN discount_rate DECIMAL(3,2) NOT NULL DEFAULT 1.00;
+
+-- 历史数据未打折，折扣前租金等于实收租金
+UPDATE rentals SET original_fee = rental_fee;
+
+-- 添加注释
+COMMENT ON COLUMN rentals.original_fee IS '会员折扣前租金';
+COMMENT ON COLUMN rentals.discount_rate IS '会员折扣率(1.00表示无折扣)';
+COMMENT ON COLUMN rentals.rental_fee IS '折后租金';
@@ -0,0 +1,2 @@
+-- 删除提现审核日志表
+DROP TABLE IF EXISTS withdrawal_audit_logs;
@@ -0,0 +1,22 @@
+-- 提现审核日志表：记录提现每次状态流转的操作人及前后状态，只追加不修改
+CREATE TABLE IF NOT EXISTS withdrawal_audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    withdrawal_id BIGINT NOT NULL REFERENCES withdrawals(id),
+    operator_id BIGINT NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    reason VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_withdrawal_audit_log_withdrawal ON withdrawal_audit_logs(withdrawal_id);
+
+-- 添加注释
+COMMENT ON TABLE withdrawal_audit_logs IS '提现审核日志表';
+COMMENT ON COLUMN withdrawal_audit_logs.withdrawal_id IS '提现ID';
+COMMENT ON COLUMN withdrawal_audit_logs.operator_id IS '操作管理员ID';
+COMMENT ON COLUMN withdrawal_audit_logs.action IS '操作: approve/reject/process/complete';
+COMMENT ON COLUMN withdrawal_audit_logs.from_status IS '变更前状态';
+COMMENT ON COLUMN withdrawal_audit_logs.to_status IS '变更后状态';
+COMMENT ON COLUMN withdrawal_audit_logs.reason IS '操作原因(拒绝原因等)';
@@ -0,0 +1,3 @@
+-- 移除佣金所属结算ID
+DROP INDEX IF EXISTS idx_commission_settlement;
+ALTER TABLE commissions DROP COLUMN IF EXISTS settlement_id;
@@ -0,0 +1,20 @@
+-- 佣金锁定到结算单：生成分销商结算时记录佣金所属结算ID，防止重叠周期重复结算
+ALTER TABLE commissions ADD COLUMN settlement_id BIGINT REFERENCES settlements(id);
+
+CREATE INDEX IF NOT EXISTS idx_commission_settlement ON commissions(settlement_id);
+
+-- 将已生成但未处理的分销商结算所覆盖的待结算佣金归属到最早的结算单
+UPDATE commissions c SET settlement_id = (
+    SELECT s.id FROM settlements s
+    WHERE s.type = 'distributor'
+      AND s.target_id = c.distributor_id
+      AND s.status IN ('pending', 'processing')
+      AND c.created_at >= s.period_start
+      AND c.created_at <= s.period_end
+    ORDER BY s.id
+    LIMIT 1
+)
+WHERE c.status = 0 AND c.settlement_id IS NULL;
+
+-- 添加注释
+COMMENT ON COLUMN commissions.settlement_id IS '所属结算ID(生成结算单时锁定)';
@@ -0,0 +1,7 @@
+-- 移除设备软删除
+-- 注意：若存在已软删除且编号被复用的设备，需先处理重复编号才能恢复唯一约束
+DROP INDEX IF EXISTS idx_devices_device_no_active;
+ALTER TABLE devices ADD CONSTRAINT devices_device_no_key UNIQUE (device_no);
+
+DROP INDEX IF EXISTS idx_devices_deleted_at;
+ALTER TABLE devices DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,12 @@
+-- 设备软删除：保留已删除设备以维持历史租借记录的引用完整性
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_devices_deleted_at ON devices(deleted_at);
+
+-- 设备编号仅在未删除的设备中唯一，软删除后可重新使用
+-- 使用部分唯一索引：(device_no, deleted_at) 联合唯一索引中 NULL 互不相等，无法约束未删除的设备
+ALTER TABLE devices DROP CONSTRAINT IF EXISTS devices_device_no_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_devices_device_no_active ON devices(device_no) WHERE deleted_at IS NULL;
+
+-- 添加注释
+COMMENT ON COLUMN devices.deleted_at IS '删除时间(软删除)';
@@ -0,0 +1,3 @@
+-- 移除租借定价ID
+DROP INDEX IF EXISTS idx_rental_pricing;
+ALTER TABLE rentals DROP COLUMN IF EXISTS pricing_id;
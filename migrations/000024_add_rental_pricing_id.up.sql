@@ -0,0 +1,7 @@
+-- 租借记录所选定价：按小时续租时沿用原定价的小时单价
+ALTER TABLE rentals ADD COLUMN pricing_id BIGINT REFERENCES rental_pricings(id);
+
+CREATE INDEX IF NOT EXISTS idx_rental_pricing ON rentals(pricing_id);
+
+-- 添加注释
+COMMENT ON COLUMN rentals.pricing_id IS '租借时所选定价ID(历史数据为空)';
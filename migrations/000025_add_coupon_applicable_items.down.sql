@@ -0,0 +1,2 @@
+-- 移除优惠券限定商品/分类
+ALTER TABLE coupons DROP COLUMN IF EXISTS applicable_items;
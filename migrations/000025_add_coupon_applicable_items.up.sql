@@ -0,0 +1,5 @@
+-- 优惠券限定商品/分类：{"product_ids": [...], "category_ids": [...]}，为空表示不限
+ALTER TABLE coupons ADD COLUMN applicable_items JSONB;
+
+-- 添加注释
+COMMENT ON COLUMN coupons.applicable_items IS '限定商品ID/分类ID，优惠只按命中商品的小计计算';
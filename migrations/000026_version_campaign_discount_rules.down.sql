@@ -0,0 +1,6 @@
+-- 满减活动规则恢复为旧格式 {"rules": [...]}
+UPDATE campaigns
+SET rules = jsonb_build_object('rules', COALESCE(rules->'tiers', '[]'::jsonb))
+WHERE type = 'discount'
+  AND jsonb_typeof(rules) = 'object'
+  AND rules ? 'version';
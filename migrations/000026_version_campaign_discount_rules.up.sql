@@ -0,0 +1,18 @@
+-- 满减活动规则改为版本化结构：{"version": 1, "tiers": [{"min_amount": 100, "discount": 10}]}
+-- 旧数据为 {"rules": [...]} 或直接存储的数组，档位按门槛金额升序重排
+
+UPDATE campaigns
+SET rules = jsonb_build_object(
+        'version', 1,
+        'tiers', COALESCE((
+            SELECT jsonb_agg(tier ORDER BY (tier->>'min_amount')::numeric)
+            FROM jsonb_array_elements(
+                CASE WHEN jsonb_typeof(campaigns.rules) = 'array' THEN campaigns.rules ELSE campaigns.rules->'rules' END
+            ) AS tier
+        ), '[]'::jsonb)
+    )
+WHERE type = 'discount'
+  AND (
+        jsonb_typeof(rules) = 'array'
+        OR (jsonb_typeof(rules) = 'object' AND NOT rules ? 'version' AND jsonb_typeof(rules->'rules') = 'array')
+      );
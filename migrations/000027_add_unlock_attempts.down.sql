@@ -0,0 +1,3 @@
+-- 移除开锁尝试记录及8位开锁码设置
+DROP TABLE IF EXISTS unlock_attempts;
+ALTER TABLE hotels DROP COLUMN IF EXISTS long_unlock_code;
@@ -0,0 +1,17 @@
+-- 酒店可选8位开锁码
+ALTER TABLE hotels ADD COLUMN long_unlock_code BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN hotels.long_unlock_code IS '是否生成8位开锁码';
+
+-- 设备开锁码失败尝试记录（未配置 Redis 时使用）
+CREATE TABLE IF NOT EXISTS unlock_attempts (
+    device_id BIGINT PRIMARY KEY REFERENCES devices(id),
+    failed_count INT NOT NULL DEFAULT 0,
+    first_failed_at TIMESTAMP WITH TIME ZONE,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE unlock_attempts IS '设备开锁码失败尝试记录';
+COMMENT ON COLUMN unlock_attempts.first_failed_at IS '本轮计数的首次失败时间';
+COMMENT ON COLUMN unlock_attempts.locked_until IS '开锁锁定截止时间';
@@ -0,0 +1,2 @@
+-- 000028_create_settlement_items.down.sql
+DROP TABLE IF EXISTS settlement_items;
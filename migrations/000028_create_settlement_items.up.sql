@@ -0,0 +1,23 @@
+-- 000028_create_settlement_items.up.sql
+-- 结算明细表：记录生成商户结算时计入的订单，明细金额之和等于结算总额
+
+CREATE TABLE IF NOT EXISTS settlement_items (
+    id BIGSERIAL PRIMARY KEY,
+    settlement_id BIGINT NOT NULL REFERENCES settlements(id) ON DELETE CASCADE,
+    order_id BIGINT NOT NULL REFERENCES orders(id),
+    rental_id BIGINT REFERENCES rentals(id),
+    order_no VARCHAR(64) NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    amount DECIMAL(12,2) NOT NULL,
+    commission_rate DECIMAL(5,4) NOT NULL,
+    merchant_share DECIMAL(12,2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_settlement_item_order UNIQUE (settlement_id, order_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_settlement_item_order ON settlement_items(order_id);
+
+COMMENT ON TABLE settlement_items IS '结算明细';
+COMMENT ON COLUMN settlement_items.amount IS '订单实付金额';
+COMMENT ON COLUMN settlement_items.commission_rate IS '平台分成比例';
+COMMENT ON COLUMN settlement_items.merchant_share IS '商户应得金额';
@@ -0,0 +1,7 @@
+-- 000029_add_rental_pricing_device.down.sql
+DROP INDEX IF EXISTS uk_pricing_venue_duration;
+DROP INDEX IF EXISTS uk_pricing_device_duration;
+DELETE FROM rental_pricings WHERE device_id IS NOT NULL;
+ALTER TABLE rental_pricings ADD CONSTRAINT rental_pricings_venue_id_duration_hours_key UNIQUE (venue_id, duration_hours);
+DROP INDEX IF EXISTS idx_pricing_device;
+ALTER TABLE rental_pricings DROP COLUMN IF EXISTS device_id;
@@ -0,0 +1,16 @@
+-- 000029_add_rental_pricing_device.up.sql
+-- 租借定价支持设备级覆盖：定价优先级 设备专属 > 场地 > 全局
+
+ALTER TABLE rental_pricings ADD COLUMN IF NOT EXISTS device_id BIGINT REFERENCES devices(id);
+
+CREATE INDEX IF NOT EXISTS idx_pricing_device ON rental_pricings(device_id);
+
+-- 原 (venue_id, duration_hours) 唯一约束不区分设备专属定价，改为按层级的部分唯一索引
+ALTER TABLE rental_pricings DROP CONSTRAINT IF EXISTS rental_pricings_venue_id_duration_hours_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uk_pricing_device_duration
+    ON rental_pricings(device_id, duration_hours) WHERE device_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uk_pricing_venue_duration
+    ON rental_pricings(venue_id, duration_hours) WHERE device_id IS NULL AND venue_id IS NOT NULL;
+
+COMMENT ON COLUMN rental_pricings.device_id IS '设备ID（设备专属定价，优先于场地及全局定价）';
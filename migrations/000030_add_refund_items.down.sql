@@ -0,0 +1,3 @@
+-- 000030_add_refund_items.down.sql
+DROP TABLE IF EXISTS refund_items;
+ALTER TABLE order_items DROP COLUMN IF EXISTS sku_id;
@@ -0,0 +1,25 @@
+-- 000030_add_refund_items.up.sql
+-- 商城订单部分退款：订单项记录 SKU 以便退款时恢复规格库存，退款明细记录每个订单项的退款数量及金额
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS sku_id BIGINT;
+
+COMMENT ON COLUMN order_items.sku_id IS '商品规格ID';
+
+CREATE TABLE IF NOT EXISTS refund_items (
+    id BIGSERIAL PRIMARY KEY,
+    refund_id BIGINT NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+    order_id BIGINT NOT NULL REFERENCES orders(id),
+    order_item_id BIGINT NOT NULL REFERENCES order_items(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    amount DECIMAL(12,2) NOT NULL,
+    reason VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_refund_item_refund ON refund_items(refund_id);
+CREATE INDEX IF NOT EXISTS idx_refund_item_order ON refund_items(order_id);
+CREATE INDEX IF NOT EXISTS idx_refund_item_order_item ON refund_items(order_item_id);
+
+COMMENT ON TABLE refund_items IS '退款明细';
+COMMENT ON COLUMN refund_items.quantity IS '退款数量';
+COMMENT ON COLUMN refund_items.amount IS '退款金额（已按比例分摊优惠）';
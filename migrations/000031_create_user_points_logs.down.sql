@@ -0,0 +1,2 @@
+-- 000031_create_user_points_logs.down.sql
+DROP TABLE IF EXISTS user_points_logs;
@@ -0,0 +1,26 @@
+-- 000031_create_user_points_logs.up.sql
+-- 积分变动记录独立成表，不再借用钱包交易记录表
+
+CREATE TABLE IF NOT EXISTS user_points_logs (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    type VARCHAR(32) NOT NULL,
+    points INT NOT NULL,
+    order_no VARCHAR(64),
+    remark VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 迁移历史积分记录（原以 points_ 前缀类型存放在 wallet_transactions 中）
+INSERT INTO user_points_logs (user_id, type, points, order_no, remark, created_at)
+SELECT user_id, SUBSTRING(type FROM 8), amount::INT, order_no, remark, created_at
+FROM wallet_transactions
+WHERE type LIKE 'points\_%';
+
+CREATE INDEX IF NOT EXISTS idx_user_points_log_user ON user_points_logs(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_points_log_order ON user_points_logs(order_no);
+CREATE INDEX IF NOT EXISTS idx_user_points_log_type ON user_points_logs(type);
+
+COMMENT ON TABLE user_points_logs IS '用户积分变动记录';
+COMMENT ON COLUMN user_points_logs.type IS '变动类型: consume/package_purchase/sign_in/activity/refund/expired/exchange/admin';
+COMMENT ON COLUMN user_points_logs.points IS '变动积分（正数增加，负数扣减）';
@@ -0,0 +1,2 @@
+-- 000032_add_member_level_points_rate.down.sql
+ALTER TABLE member_levels DROP COLUMN IF EXISTS points_rate;
@@ -0,0 +1,7 @@
+-- 000032_add_member_level_points_rate.up.sql
+-- 会员等级增加消费积分倍率，消费积分 = 金额 × 全局积分比例 × 等级倍率
+
+ALTER TABLE member_levels ADD COLUMN IF NOT EXISTS points_rate DECIMAL(4,2) NOT NULL DEFAULT 1.00;
+
+COMMENT ON COLUMN member_levels.points_rate IS '消费积分倍率';
+COMMENT ON COLUMN user_points_logs.type IS '变动类型: consume/package_purchase/sign_in/activity/refund/expired/exchange/admin/redeem/redeem_return';
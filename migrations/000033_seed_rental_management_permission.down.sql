@@ -0,0 +1,5 @@
+-- 000033_seed_rental_management_permission.down.sql
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE code = 'rental_management');
+
+DELETE FROM permissions WHERE code = 'rental_management';
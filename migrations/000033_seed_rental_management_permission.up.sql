@@ -0,0 +1,13 @@
+-- 000033_seed_rental_management_permission.up.sql
+-- 租借管理权限（强制完成租借等运维操作），授予平台管理员与运营管理员
+
+INSERT INTO permissions (code, name, type, path, method, sort) VALUES
+    ('rental_management', '租借管理', 'api', '/api/v1/admin/rentals/:id/force-complete', 'POST', 0)
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.code IN ('platform_admin', 'operation_admin')
+  AND p.code = 'rental_management'
+ON CONFLICT DO NOTHING;
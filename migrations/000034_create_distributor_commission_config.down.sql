@@ -0,0 +1,2 @@
+-- 000034_create_distributor_commission_config.down.sql
+DROP TABLE IF EXISTS distributor_commission_config;
@@ -0,0 +1,19 @@
+-- 000034_create_distributor_commission_config.up.sql
+-- 分销佣金比例按推荐层级配置，替代代码中的固定比例
+
+CREATE TABLE IF NOT EXISTS distributor_commission_config (
+    id BIGSERIAL PRIMARY KEY,
+    level SMALLINT NOT NULL UNIQUE,
+    rate DECIMAL(5,4) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO distributor_commission_config (level, rate) VALUES
+    (1, 0.1000),
+    (2, 0.0500)
+ON CONFLICT (level) DO NOTHING;
+
+COMMENT ON TABLE distributor_commission_config IS '分销佣金层级比例配置';
+COMMENT ON COLUMN distributor_commission_config.level IS '推荐层级: 1直推 2间推';
+COMMENT ON COLUMN distributor_commission_config.rate IS '佣金比例（按订单实付金额计算）';
@@ -0,0 +1,2 @@
+-- 000035_create_product_search_terms.down.sql
+DROP TABLE IF EXISTS product_search_terms;
@@ -0,0 +1,5 @@
+-- 000036_add_wallet_transaction_operator.down.sql
+-- 移除钱包流水操作管理员
+
+DROP INDEX IF EXISTS idx_wallet_transactions_operator_id;
+ALTER TABLE wallet_transactions DROP COLUMN IF EXISTS operator_id;
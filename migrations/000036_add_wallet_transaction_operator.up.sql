@@ -0,0 +1,8 @@
+-- 000036_add_wallet_transaction_operator.up.sql
+-- 钱包流水记录操作管理员，人工调整余额时用于审计
+
+ALTER TABLE wallet_transactions ADD COLUMN IF NOT EXISTS operator_id BIGINT;
+CREATE INDEX IF NOT EXISTS idx_wallet_transactions_operator_id ON wallet_transactions(operator_id);
+
+-- 添加注释
+COMMENT ON COLUMN wallet_transactions.operator_id IS '操作管理员ID(人工调整时记录)';
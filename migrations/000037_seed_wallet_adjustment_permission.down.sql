@@ -0,0 +1,5 @@
+-- 000037_seed_wallet_adjustment_permission.down.sql
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE code = 'wallet_adjustment');
+
+DELETE FROM permissions WHERE code = 'wallet_adjustment';
@@ -0,0 +1,13 @@
+-- 000037_seed_wallet_adjustment_permission.up.sql
+-- 钱包余额人工调整权限，授予平台管理员与财务管理员
+
+INSERT INTO permissions (code, name, type, path, method, sort) VALUES
+    ('wallet_adjustment', '钱包余额调整', 'api', '/api/v1/admin/users/:id/wallet/adjust', 'POST', 0)
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.code IN ('platform_admin', 'finance_admin')
+  AND p.code = 'wallet_adjustment'
+ON CONFLICT DO NOTHING;
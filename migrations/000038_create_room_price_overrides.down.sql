@@ -0,0 +1,3 @@
+-- 000038_create_room_price_overrides.down.sql
+DROP TRIGGER IF EXISTS update_room_price_overrides_updated_at ON room_price_overrides;
+DROP TABLE IF EXISTS room_price_overrides;
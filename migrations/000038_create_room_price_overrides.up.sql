@@ -0,0 +1,32 @@
+-- 000038_create_room_price_overrides.up.sql
+-- 房间日历价格覆盖表：按日期或星期几覆盖时段价格
+CREATE TABLE IF NOT EXISTS room_price_overrides (
+    id BIGSERIAL PRIMARY KEY,
+    room_id BIGINT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    date DATE,
+    weekday_mask INT NOT NULL DEFAULT 0,
+    duration_hours INT,
+    price DECIMAL(10,2) NOT NULL,
+    priority INT NOT NULL DEFAULT 0,
+    start_date DATE,
+    end_date DATE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_room_price_overrides_rule CHECK (date IS NOT NULL OR weekday_mask > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_price_overrides_room ON room_price_overrides(room_id);
+
+CREATE TRIGGER update_room_price_overrides_updated_at
+    BEFORE UPDATE ON room_price_overrides
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- 添加注释
+COMMENT ON TABLE room_price_overrides IS '房间日历价格覆盖';
+COMMENT ON COLUMN room_price_overrides.date IS '指定日期(优先于星期规则)';
+COMMENT ON COLUMN room_price_overrides.weekday_mask IS '星期掩码(bit0=周日...bit6=周六)';
+COMMENT ON COLUMN room_price_overrides.duration_hours IS '适用时长(为空适用所有时段)';
+COMMENT ON COLUMN room_price_overrides.priority IS '优先级(越大越优先)';
+COMMENT ON COLUMN room_price_overrides.end_date IS '生效结束日期(含)';
@@ -0,0 +1,3 @@
+-- 000039_add_user_coupon_issued_by.down.sql
+DROP INDEX IF EXISTS idx_user_coupons_issued_by;
+ALTER TABLE user_coupons DROP COLUMN IF EXISTS issued_by;
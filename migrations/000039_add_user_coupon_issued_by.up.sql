@@ -0,0 +1,7 @@
+-- 000039_add_user_coupon_issued_by.up.sql
+-- 用户优惠券记录发放管理员，管理员批量发放时用于审计
+ALTER TABLE user_coupons ADD COLUMN issued_by BIGINT;
+CREATE INDEX IF NOT EXISTS idx_user_coupons_issued_by ON user_coupons(issued_by);
+
+-- 添加注释
+COMMENT ON COLUMN user_coupons.issued_by IS '发放管理员ID(管理员批量发放时记录)';
@@ -0,0 +1,4 @@
+-- 000040_add_settlement_review.down.sql
+ALTER TABLE settlements DROP COLUMN IF EXISTS reject_reason;
+ALTER TABLE settlements DROP COLUMN IF EXISTS reviewed_at;
+ALTER TABLE settlements DROP COLUMN IF EXISTS reviewer_id;
@@ -0,0 +1,10 @@
+-- 000040_add_settlement_review.up.sql
+-- 结算审核：记录审核人、审核时间及驳回原因
+ALTER TABLE settlements ADD COLUMN reviewer_id BIGINT;
+ALTER TABLE settlements ADD COLUMN reviewed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE settlements ADD COLUMN reject_reason VARCHAR(255);
+
+-- 添加注释
+COMMENT ON COLUMN settlements.reviewer_id IS '审核管理员ID';
+COMMENT ON COLUMN settlements.reviewed_at IS '审核时间';
+COMMENT ON COLUMN settlements.reject_reason IS '驳回原因';
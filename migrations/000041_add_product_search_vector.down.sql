@@ -0,0 +1,3 @@
+-- 000041_add_product_search_vector.down.sql
+DROP INDEX IF EXISTS idx_products_search_vector;
+ALTER TABLE products DROP COLUMN IF EXISTS search_vector;
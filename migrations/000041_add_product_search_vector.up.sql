@@ -0,0 +1,7 @@
+-- 000041_add_product_search_vector.up.sql
+-- 商品全文检索向量，由商品名称和描述分词后生成，写入后需通过管理端重建索引填充存量商品
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN(search_vector);
+
+-- 添加注释
+COMMENT ON COLUMN products.search_vector IS '全文检索向量(名称词项权重A，描述词项权重B)';
@@ -0,0 +1,2 @@
+-- 000042_add_distributor_second_level_rate.down.sql
+ALTER TABLE distributors DROP COLUMN IF EXISTS second_level_rate;
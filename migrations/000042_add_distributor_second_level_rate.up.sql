@@ -0,0 +1,6 @@
+-- 000042_add_distributor_second_level_rate.up.sql
+-- 分销商单独设置的间推佣金比例，下级分销商邀请的用户消费时按此比例计算间推佣金
+ALTER TABLE distributors ADD COLUMN IF NOT EXISTS second_level_rate DECIMAL(5,4) NOT NULL DEFAULT 0;
+
+-- 添加注释
+COMMENT ON COLUMN distributors.second_level_rate IS '间推佣金比例(0表示使用全局间推比例)';
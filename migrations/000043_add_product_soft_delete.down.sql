@@ -0,0 +1,9 @@
+-- 移除商品软删除
+-- 注意：若存在已软删除且编码被复用的 SKU，需先处理重复编码才能恢复唯一约束
+DROP INDEX IF EXISTS idx_product_skus_sku_code_active;
+ALTER TABLE product_skus ADD CONSTRAINT product_skus_sku_code_key UNIQUE (sku_code);
+
+DROP INDEX IF EXISTS idx_product_skus_deleted_at;
+DROP INDEX IF EXISTS idx_products_deleted_at;
+ALTER TABLE product_skus DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE products DROP COLUMN IF EXISTS deleted_at;
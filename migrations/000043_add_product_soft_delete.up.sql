@@ -0,0 +1,14 @@
+-- 商品软删除：删除后保留商品及 SKU，历史订单和购物车仍可引用，并支持恢复
+ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE product_skus ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_product_skus_deleted_at ON product_skus(deleted_at);
+
+-- SKU 编码仅在未删除的 SKU 中唯一，软删除后可重新使用
+ALTER TABLE product_skus DROP CONSTRAINT IF EXISTS product_skus_sku_code_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_skus_sku_code_active ON product_skus(sku_code) WHERE deleted_at IS NULL;
+
+-- 添加注释
+COMMENT ON COLUMN products.deleted_at IS '删除时间(软删除)';
+COMMENT ON COLUMN product_skus.deleted_at IS '删除时间(软删除)';
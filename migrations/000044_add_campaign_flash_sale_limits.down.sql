@@ -0,0 +1,3 @@
+-- 移除秒杀活动每日名额
+ALTER TABLE campaigns DROP COLUMN IF EXISTS user_daily_limit;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS daily_limit;
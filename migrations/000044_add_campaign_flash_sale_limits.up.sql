@@ -0,0 +1,7 @@
+-- 秒杀活动每日名额：名额按自然日在 Redis 中计数，0 表示不限
+ALTER TABLE campaigns ADD COLUMN IF NOT EXISTS daily_limit INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE campaigns ADD COLUMN IF NOT EXISTS user_daily_limit INTEGER NOT NULL DEFAULT 0;
+
+-- 添加注释
+COMMENT ON COLUMN campaigns.daily_limit IS '秒杀每日名额(0不限)';
+COMMENT ON COLUMN campaigns.user_daily_limit IS '秒杀每人每日限购次数(0不限)';
@@ -0,0 +1,3 @@
+-- 000045_create_merchant_api_keys.down.sql
+DROP TRIGGER IF EXISTS update_merchant_api_keys_updated_at ON merchant_api_keys;
+DROP TABLE IF EXISTS merchant_api_keys;
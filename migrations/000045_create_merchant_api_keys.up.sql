@@ -0,0 +1,30 @@
+-- 000045_create_merchant_api_keys.up.sql
+-- 商户开放接口密钥：商户自助门户使用 HMAC 签名调用只读接口
+
+CREATE TABLE IF NOT EXISTS merchant_api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    merchant_id BIGINT NOT NULL REFERENCES merchants(id),
+    key_id VARCHAR(64) NOT NULL,
+    secret_encrypted TEXT NOT NULL,
+    scopes VARCHAR(255) NOT NULL DEFAULT '',
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    status SMALLINT NOT NULL DEFAULT 1,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_merchant_api_keys_key_id UNIQUE (key_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_merchant_api_keys_merchant ON merchant_api_keys(merchant_id);
+
+CREATE TRIGGER update_merchant_api_keys_updated_at
+    BEFORE UPDATE ON merchant_api_keys
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- 添加注释
+COMMENT ON TABLE merchant_api_keys IS '商户开放接口密钥';
+COMMENT ON COLUMN merchant_api_keys.key_id IS '密钥标识(请求头 X-Api-Key)';
+COMMENT ON COLUMN merchant_api_keys.secret_encrypted IS '签名密钥(AES加密，签名校验需还原原文)';
+COMMENT ON COLUMN merchant_api_keys.scopes IS '授权范围(逗号分隔)';
+COMMENT ON COLUMN merchant_api_keys.status IS '状态(0已吊销 1正常)';
@@ -0,0 +1,5 @@
+-- 000046_create_coupon_grant_batches.down.sql
+DROP INDEX IF EXISTS idx_user_coupons_grant_batch_id;
+ALTER TABLE user_coupons DROP COLUMN IF EXISTS grant_batch_id;
+DROP TRIGGER IF EXISTS update_coupon_grant_batches_updated_at ON coupon_grant_batches;
+DROP TABLE IF EXISTS coupon_grant_batches;
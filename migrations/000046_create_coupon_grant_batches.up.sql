@@ -0,0 +1,39 @@
+-- 000046_create_coupon_grant_batches.up.sql
+-- 优惠券定向发放批次：按用户分群批量发放优惠券并记录发放结果
+
+CREATE TABLE IF NOT EXISTS coupon_grant_batches (
+    id BIGSERIAL PRIMARY KEY,
+    batch_no VARCHAR(64) NOT NULL,
+    coupon_id BIGINT NOT NULL REFERENCES coupons(id),
+    segment JSONB NOT NULL,
+    admin_id BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    matched_count INT NOT NULL DEFAULT 0,
+    granted_count INT NOT NULL DEFAULT 0,
+    skipped_count INT NOT NULL DEFAULT 0,
+    shortfall_count INT NOT NULL DEFAULT 0,
+    failed_count INT NOT NULL DEFAULT 0,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_coupon_grant_batches_batch_no UNIQUE (batch_no)
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupon_grant_batches_coupon ON coupon_grant_batches(coupon_id);
+
+CREATE TRIGGER update_coupon_grant_batches_updated_at
+    BEFORE UPDATE ON coupon_grant_batches
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE user_coupons ADD COLUMN grant_batch_id BIGINT;
+CREATE INDEX IF NOT EXISTS idx_user_coupons_grant_batch_id ON user_coupons(grant_batch_id);
+
+-- 添加注释
+COMMENT ON TABLE coupon_grant_batches IS '优惠券定向发放批次';
+COMMENT ON COLUMN coupon_grant_batches.batch_no IS '批次号(重复提交同一批次号不会重复发放)';
+COMMENT ON COLUMN coupon_grant_batches.segment IS '用户分群条件';
+COMMENT ON COLUMN coupon_grant_batches.status IS '状态(running发放中 completed已完成)';
+COMMENT ON COLUMN coupon_grant_batches.skipped_count IS '因领取上限跳过的用户数';
+COMMENT ON COLUMN coupon_grant_batches.shortfall_count IS '因库存不足未发放的用户数';
+COMMENT ON COLUMN user_coupons.grant_batch_id IS '定向发放批次ID(按分群发放时记录)';
@@ -0,0 +1,2 @@
+-- 000047_create_auth_tokens.down.sql
+DROP TABLE IF EXISTS auth_tokens;
@@ -0,0 +1,30 @@
+-- 000047_create_auth_tokens.up.sql
+-- 已签发的 JWT 令牌记录：刷新令牌轮换、重用检测及吊销
+
+CREATE TABLE IF NOT EXISTS auth_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    jti VARCHAR(64) NOT NULL,
+    family_id VARCHAR(64) NOT NULL,
+    user_type VARCHAR(20) NOT NULL,
+    user_id BIGINT NOT NULL,
+    token_type VARCHAR(20) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_auth_tokens_jti UNIQUE (jti)
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_tokens_family ON auth_tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_auth_tokens_user ON auth_tokens(user_type, user_id);
+CREATE INDEX IF NOT EXISTS idx_auth_tokens_expires_at ON auth_tokens(expires_at);
+CREATE INDEX IF NOT EXISTS idx_auth_tokens_revoked_at ON auth_tokens(revoked_at) WHERE revoked_at IS NOT NULL;
+
+-- 添加注释
+COMMENT ON TABLE auth_tokens IS '已签发的JWT令牌';
+COMMENT ON COLUMN auth_tokens.jti IS '令牌ID(JWT jti)';
+COMMENT ON COLUMN auth_tokens.family_id IS '令牌族ID(同一登录会话轮换产生的令牌相同)';
+COMMENT ON COLUMN auth_tokens.user_type IS '用户类型(user/admin)';
+COMMENT ON COLUMN auth_tokens.token_type IS '令牌类型(access/refresh)';
+COMMENT ON COLUMN auth_tokens.used_at IS '刷新令牌被轮换的时间';
+COMMENT ON COLUMN auth_tokens.revoked_at IS '吊销时间';
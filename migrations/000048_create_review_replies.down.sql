@@ -0,0 +1,3 @@
+-- 000048_create_review_replies.down.sql
+DROP INDEX IF EXISTS idx_review_product_has_images;
+DROP TABLE IF EXISTS review_replies;
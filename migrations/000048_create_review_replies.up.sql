@@ -0,0 +1,23 @@
+-- 000048_create_review_replies.up.sql
+-- 评价回复：管理员或商户可对评价进行多次回复，删除评价时级联删除回复
+
+CREATE TABLE IF NOT EXISTS review_replies (
+    id BIGSERIAL PRIMARY KEY,
+    review_id BIGINT NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+    admin_id BIGINT,
+    merchant_id BIGINT,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_review_replies_replier CHECK (admin_id IS NOT NULL OR merchant_id IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_replies_review ON review_replies(review_id, created_at);
+
+-- 有图评价筛选
+CREATE INDEX IF NOT EXISTS idx_review_product_has_images ON reviews(product_id) WHERE images IS NOT NULL;
+
+-- 添加注释
+COMMENT ON TABLE review_replies IS '评价回复';
+COMMENT ON COLUMN review_replies.admin_id IS '回复的管理员ID';
+COMMENT ON COLUMN review_replies.merchant_id IS '回复的商户ID';
+COMMENT ON COLUMN reviews.images IS '评价图片URL列表(最多6张)';
@@ -0,0 +1,2 @@
+-- 000049_create_reconciliation_issues.down.sql
+DROP TABLE IF EXISTS reconciliation_issues;
@@ -0,0 +1,27 @@
+-- 000049_create_reconciliation_issues.up.sql
+-- 对账差异：每日核对支付记录、订单与钱包流水，按业务日期重新生成
+
+CREATE TABLE IF NOT EXISTS reconciliation_issues (
+    id BIGSERIAL PRIMARY KEY,
+    biz_date DATE NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    user_id BIGINT,
+    order_id BIGINT,
+    order_no VARCHAR(64),
+    payment_id BIGINT,
+    payment_no VARCHAR(64),
+    wallet_transaction_id BIGINT,
+    expected_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    actual_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    detail VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_issue_date ON reconciliation_issues(biz_date, type);
+
+-- 添加注释
+COMMENT ON TABLE reconciliation_issues IS '对账差异';
+COMMENT ON COLUMN reconciliation_issues.biz_date IS '对账业务日期';
+COMMENT ON COLUMN reconciliation_issues.type IS '差异类型: order_missing/order_not_paid/amount_mismatch/payment_missing/duplicate_payment/wallet_chain_break';
+COMMENT ON COLUMN reconciliation_issues.expected_amount IS '应有金额';
+COMMENT ON COLUMN reconciliation_issues.actual_amount IS '实际金额';
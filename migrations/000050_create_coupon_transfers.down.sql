@@ -0,0 +1,2 @@
+-- 000050_create_coupon_transfers.down.sql
+DROP TABLE IF EXISTS coupon_transfers;
@@ -0,0 +1,21 @@
+-- 000050_create_coupon_transfers.up.sql
+-- 优惠券转赠记录：用户将未使用的优惠券转赠给其他用户时记录
+
+CREATE TABLE IF NOT EXISTS coupon_transfers (
+    id BIGSERIAL PRIMARY KEY,
+    user_coupon_id BIGINT NOT NULL REFERENCES user_coupons(id) ON DELETE CASCADE,
+    coupon_id BIGINT NOT NULL REFERENCES coupons(id),
+    from_user_id BIGINT NOT NULL REFERENCES users(id),
+    to_user_id BIGINT NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupon_transfers_user_coupon ON coupon_transfers(user_coupon_id);
+CREATE INDEX IF NOT EXISTS idx_coupon_transfers_coupon ON coupon_transfers(coupon_id);
+CREATE INDEX IF NOT EXISTS idx_coupon_transfers_from_user ON coupon_transfers(from_user_id);
+CREATE INDEX IF NOT EXISTS idx_coupon_transfers_to_user ON coupon_transfers(to_user_id);
+
+-- 添加注释
+COMMENT ON TABLE coupon_transfers IS '优惠券转赠记录';
+COMMENT ON COLUMN coupon_transfers.from_user_id IS '转赠人';
+COMMENT ON COLUMN coupon_transfers.to_user_id IS '受赠人';
@@ -0,0 +1,4 @@
+-- 000051_create_device_slots.down.sql
+ALTER TABLE rentals DROP COLUMN IF EXISTS slot_no;
+DROP TRIGGER IF EXISTS update_device_slots_updated_at ON device_slots;
+DROP TABLE IF EXISTS device_slots;
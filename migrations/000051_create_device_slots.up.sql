@@ -0,0 +1,62 @@
+-- 000051_create_device_slots.up.sql
+-- 设备格口库存：记录多格口设备每个物理格口的占用情况，租借时分配具体格口
+
+CREATE TABLE IF NOT EXISTS device_slots (
+    id BIGSERIAL PRIMARY KEY,
+    device_id BIGINT NOT NULL REFERENCES devices(id),
+    slot_no INT NOT NULL,
+    status SMALLINT NOT NULL DEFAULT 0,
+    current_rental_id BIGINT,
+    product_name VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT idx_device_slots_device_slot UNIQUE (device_id, slot_no)
+);
+
+CREATE TRIGGER update_device_slots_updated_at
+    BEFORE UPDATE ON device_slots
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE rentals ADD COLUMN slot_no INT;
+
+-- 为已有设备按格口数补建格口
+INSERT INTO device_slots (device_id, slot_no)
+SELECT d.id, s.slot_no
+FROM devices d
+CROSS JOIN LATERAL generate_series(1, d.slot_count) AS s(slot_no)
+WHERE d.deleted_at IS NULL;
+
+-- 进行中的租借按创建顺序依次分配格口
+WITH active AS (
+    SELECT r.id, ROW_NUMBER() OVER (PARTITION BY r.device_id ORDER BY r.id) AS slot_no
+    FROM rentals r
+    WHERE r.status IN ('pending', 'paid', 'in_use', 'overdue')
+)
+UPDATE rentals r
+SET slot_no = a.slot_no
+FROM active a, devices d
+WHERE r.id = a.id AND d.id = r.device_id AND a.slot_no <= d.slot_count;
+
+UPDATE device_slots ds
+SET status = CASE WHEN r.status IN ('in_use', 'overdue') THEN 2 ELSE 1 END,
+    current_rental_id = r.id
+FROM rentals r
+WHERE r.device_id = ds.device_id
+  AND r.slot_no = ds.slot_no
+  AND r.status IN ('pending', 'paid', 'in_use', 'overdue');
+
+-- 设备可用槽位与空闲格口数保持一致
+UPDATE devices d
+SET available_slots = (
+    SELECT COUNT(*) FROM device_slots ds WHERE ds.device_id = d.id AND ds.status = 0
+)
+WHERE d.deleted_at IS NULL;
+
+-- 添加注释
+COMMENT ON TABLE device_slots IS '设备格口';
+COMMENT ON COLUMN device_slots.slot_no IS '格口编号(从1开始)';
+COMMENT ON COLUMN device_slots.status IS '状态(0空闲 1已预占 2使用中)';
+COMMENT ON COLUMN device_slots.current_rental_id IS '当前占用格口的租借ID';
+COMMENT ON COLUMN device_slots.product_name IS '格口商品名称(为空时使用设备商品名称)';
+COMMENT ON COLUMN rentals.slot_no IS '分配的格口编号';
@@ -0,0 +1,7 @@
+-- 000052_create_finance_daily_summaries.down.sql
+DROP INDEX IF EXISTS idx_settlements_status_settled_at;
+DROP INDEX IF EXISTS idx_commissions_status_settled_at;
+DROP INDEX IF EXISTS idx_refunds_status_refunded_at;
+DROP INDEX IF EXISTS idx_payments_status_pay_time;
+DROP TRIGGER IF EXISTS update_finance_daily_summaries_updated_at ON finance_daily_summaries;
+DROP TABLE IF EXISTS finance_daily_summaries;
@@ -0,0 +1,33 @@
+-- 000052_create_finance_daily_summaries.up.sql
+-- 财务日汇总：按天汇总收入、退款、佣金与结算金额，财务概览读取汇总加当日增量，避免全表聚合
+
+CREATE TABLE IF NOT EXISTS finance_daily_summaries (
+    id BIGSERIAL PRIMARY KEY,
+    biz_date DATE NOT NULL,
+    revenue DECIMAL(14,2) NOT NULL DEFAULT 0,
+    refund DECIMAL(14,2) NOT NULL DEFAULT 0,
+    commission DECIMAL(14,2) NOT NULL DEFAULT 0,
+    settlement DECIMAL(14,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT idx_finance_daily_summaries_date UNIQUE (biz_date)
+);
+
+CREATE TRIGGER update_finance_daily_summaries_updated_at
+    BEFORE UPDATE ON finance_daily_summaries
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- 日汇总与增量查询按各金额的入账时间筛选
+CREATE INDEX IF NOT EXISTS idx_payments_status_pay_time ON payments(status, pay_time);
+CREATE INDEX IF NOT EXISTS idx_refunds_status_refunded_at ON refunds(status, refunded_at);
+CREATE INDEX IF NOT EXISTS idx_commissions_status_settled_at ON commissions(status, settled_at);
+CREATE INDEX IF NOT EXISTS idx_settlements_status_settled_at ON settlements(status, settled_at);
+
+-- 添加注释
+COMMENT ON TABLE finance_daily_summaries IS '财务日汇总';
+COMMENT ON COLUMN finance_daily_summaries.biz_date IS '汇总日期';
+COMMENT ON COLUMN finance_daily_summaries.revenue IS '当日支付成功金额（按支付时间）';
+COMMENT ON COLUMN finance_daily_summaries.refund IS '当日退款成功金额（按退款时间）';
+COMMENT ON COLUMN finance_daily_summaries.commission IS '当日已结算佣金金额（按结算时间）';
+COMMENT ON COLUMN finance_daily_summaries.settlement IS '当日已完成结算的实际金额（按结算时间）';
@@ -0,0 +1,5 @@
+-- 000053_seed_finance_export_full_permission.down.sql
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE code = 'finance:export:full');
+
+DELETE FROM permissions WHERE code = 'finance:export:full';
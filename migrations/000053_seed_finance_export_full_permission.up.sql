@@ -0,0 +1,13 @@
+-- 000053_seed_finance_export_full_permission.up.sql
+-- 财务导出完整收款账户和手机号权限（默认导出脱敏至后4位），授予平台管理员与财务管理员
+
+INSERT INTO permissions (code, name, type, path, method, sort) VALUES
+    ('finance:export:full', '财务导出完整账户信息', 'api', '/api/v1/admin/finance/export/withdrawals', 'GET', 0)
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.code IN ('platform_admin', 'finance_admin')
+  AND p.code = 'finance:export:full'
+ON CONFLICT DO NOTHING;
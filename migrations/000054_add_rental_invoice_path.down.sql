@@ -0,0 +1,2 @@
+-- 移除租借发票路径
+ALTER TABLE rentals DROP COLUMN IF EXISTS invoice_path;
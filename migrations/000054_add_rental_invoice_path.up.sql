@@ -0,0 +1,5 @@
+-- 租借发票：记录完成租借后生成的发票PDF在对象存储中的路径
+ALTER TABLE rentals ADD COLUMN invoice_path VARCHAR(255);
+
+-- 添加注释
+COMMENT ON COLUMN rentals.invoice_path IS '发票PDF在对象存储中的路径(未生成时为空)';
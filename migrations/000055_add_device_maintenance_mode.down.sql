@@ -0,0 +1,5 @@
+-- 移除管理员通知表及设备维护模式
+DROP TABLE IF EXISTS admin_notifications;
+ALTER TABLE devices DROP COLUMN IF EXISTS maintenance_restore_at;
+ALTER TABLE devices DROP COLUMN IF EXISTS maintenance_reason;
+ALTER TABLE devices DROP COLUMN IF EXISTS maintenance_mode;
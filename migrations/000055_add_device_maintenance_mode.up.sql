@@ -0,0 +1,25 @@
+-- 设备维护模式：故障设备暂停新的租借但不删除，并通知所有管理员
+ALTER TABLE devices ADD COLUMN maintenance_mode BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE devices ADD COLUMN maintenance_reason VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE devices ADD COLUMN maintenance_restore_at TIMESTAMP WITH TIME ZONE;
+
+-- 管理员通知表
+CREATE TABLE IF NOT EXISTS admin_notifications (
+    id BIGSERIAL PRIMARY KEY,
+    admin_id BIGINT NOT NULL REFERENCES admins(id),
+    type VARCHAR(20) NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    content TEXT NOT NULL,
+    link VARCHAR(255),
+    is_read BOOLEAN NOT NULL DEFAULT FALSE,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_notification_admin ON admin_notifications(admin_id);
+
+-- 添加注释
+COMMENT ON COLUMN devices.maintenance_mode IS '维护模式(开启后暂停新的租借)';
+COMMENT ON COLUMN devices.maintenance_reason IS '维护原因';
+COMMENT ON COLUMN devices.maintenance_restore_at IS '预计恢复时间';
+COMMENT ON TABLE admin_notifications IS '管理员通知表';
@@ -0,0 +1,2 @@
+-- 移除租借转让记录表
+DROP TABLE IF EXISTS rental_transfers;
@@ -0,0 +1,27 @@
+-- 租借转让：用户可将使用中的租借转让给同行者，记录租借及订单归属的变更
+CREATE TABLE IF NOT EXISTS rental_transfers (
+    id BIGSERIAL PRIMARY KEY,
+    rental_id BIGINT NOT NULL REFERENCES rentals(id),
+    order_id BIGINT NOT NULL REFERENCES orders(id),
+    from_user_id BIGINT NOT NULL REFERENCES users(id),
+    to_user_id BIGINT REFERENCES users(id),
+    code VARCHAR(16) NOT NULL UNIQUE,
+    deposit DECIMAL(10,2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_rental_transfer_rental ON rental_transfers(rental_id);
+CREATE INDEX IF NOT EXISTS idx_rental_transfer_from_user ON rental_transfers(from_user_id);
+CREATE INDEX IF NOT EXISTS idx_rental_transfer_to_user ON rental_transfers(to_user_id);
+
+-- 每个租借最多转让一次
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rental_transfer_accepted ON rental_transfers(rental_id) WHERE status = 'accepted';
+
+-- 添加注释
+COMMENT ON TABLE rental_transfers IS '租借转让记录表';
+COMMENT ON COLUMN rental_transfers.code IS '转让码(10分钟内有效)';
+COMMENT ON COLUMN rental_transfers.deposit IS '接收方冻结的押金';
+COMMENT ON COLUMN rental_transfers.status IS '状态(pending待接受 accepted已接受 cancelled已失效)';
@@ -0,0 +1,9 @@
+-- 000057_seed_finance_route_permissions.down.sql
+DELETE FROM role_permissions
+WHERE permission_id IN (
+    SELECT id FROM permissions
+    WHERE code IN ('finance:withdrawal:approve', 'finance:settlement:process', 'finance:export', 'system:role')
+);
+
+DELETE FROM permissions
+WHERE code IN ('finance:withdrawal:approve', 'finance:settlement:process', 'finance:export', 'system:role');
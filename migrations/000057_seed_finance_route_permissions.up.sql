@@ -0,0 +1,17 @@
+-- 000057_seed_finance_route_permissions.up.sql
+-- 财务接口权限：提现审核、结算处理、财务导出授予平台管理员与财务管理员；
+-- 角色权限管理默认仅超级管理员可用（超级管理员不受权限限制）
+
+INSERT INTO permissions (code, name, type, path, method, sort) VALUES
+    ('finance:withdrawal:approve', '提现审核', 'api', '/api/admin/finance/withdrawals/:id/handle', 'POST', 0),
+    ('finance:settlement:process', '结算处理', 'api', '/api/admin/finance/settlements/:id/process', 'POST', 0),
+    ('finance:export', '财务导出', 'api', NULL, NULL, 0),
+    ('system:role', '角色权限管理', 'api', '/api/admin/roles/:id/permissions', 'PUT', 0)
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.code IN ('platform_admin', 'finance_admin')
+  AND p.code IN ('finance:withdrawal:approve', 'finance:settlement:process', 'finance:export')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+-- 移除租借订阅
+DROP INDEX IF EXISTS idx_rental_subscription_id;
+ALTER TABLE rentals DROP COLUMN IF EXISTS subscription_id;
+DROP TABLE IF EXISTS rental_subscriptions;
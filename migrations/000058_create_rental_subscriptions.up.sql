@@ -0,0 +1,34 @@
+-- 租借订阅：按周或按月固定费用连续租用同一设备的格口，每个周期生成一条租借记录
+CREATE TABLE IF NOT EXISTS rental_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    device_id BIGINT NOT NULL REFERENCES devices(id),
+    pricing_id BIGINT NOT NULL REFERENCES rental_pricings(id),
+    period VARCHAR(20) NOT NULL,
+    price_per_period DECIMAL(10,2) NOT NULL,
+    start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    current_rental_id BIGINT REFERENCES rentals(id),
+    paused_at TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_rental_subscription_user ON rental_subscriptions(user_id);
+CREATE INDEX IF NOT EXISTS idx_rental_subscription_device ON rental_subscriptions(device_id);
+-- 续订任务按状态和周期结束时间查找待续订的订阅
+CREATE INDEX IF NOT EXISTS idx_rental_subscription_renewal ON rental_subscriptions(status, end_date);
+
+-- 租借关联所属订阅
+ALTER TABLE rentals ADD COLUMN IF NOT EXISTS subscription_id BIGINT REFERENCES rental_subscriptions(id);
+CREATE INDEX IF NOT EXISTS idx_rental_subscription_id ON rentals(subscription_id);
+
+-- 添加注释
+COMMENT ON TABLE rental_subscriptions IS '租借订阅表';
+COMMENT ON COLUMN rental_subscriptions.period IS '订阅周期(weekly按周 monthly按月)';
+COMMENT ON COLUMN rental_subscriptions.price_per_period IS '每周期费用';
+COMMENT ON COLUMN rental_subscriptions.end_date IS '当前已付费周期的结束时间';
+COMMENT ON COLUMN rental_subscriptions.status IS '状态(active生效中 paused已暂停 cancelled已取消)';
+COMMENT ON COLUMN rentals.subscription_id IS '所属订阅(按次租借为空)';
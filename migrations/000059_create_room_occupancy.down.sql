@@ -0,0 +1,2 @@
+-- 移除房间占用位图
+DROP TABLE IF EXISTS room_occupancy;
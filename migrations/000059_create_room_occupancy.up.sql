@@ -0,0 +1,15 @@
+-- 房间按日占用位图：预订支付、取消、过期、完成或改期时在同一事务中刷新，房间可用性查询只读此表
+CREATE TABLE IF NOT EXISTS room_occupancy (
+    room_id BIGINT NOT NULL REFERENCES rooms(id),
+    date VARCHAR(10) NOT NULL,
+    hour_mask INT NOT NULL DEFAULT 0,
+    ranges TEXT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (room_id, date)
+);
+
+-- 添加注释
+COMMENT ON TABLE room_occupancy IS '房间占用位图表(已有预订由服务启动时自动回填，也可调用 POST /admin/rooms/:id/occupancy/rebuild 重建)';
+COMMENT ON COLUMN room_occupancy.date IS 'UTC 日期(YYYY-MM-DD)';
+COMMENT ON COLUMN room_occupancy.hour_mask IS '24 位小时掩码(bit0=00:00-01:00)';
+COMMENT ON COLUMN room_occupancy.ranges IS '当日被占用的分钟区间(左闭右开)，如 630-750,900-1020';
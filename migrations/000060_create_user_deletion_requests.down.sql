@@ -0,0 +1,2 @@
+-- 移除用户注销申请
+DROP TABLE IF EXISTS user_deletion_requests;
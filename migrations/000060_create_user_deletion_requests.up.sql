@@ -0,0 +1,23 @@
+-- 用户注销申请：冷静期结束后匿名化个人信息，订单、支付等财务记录保留并指向匿名化的用户
+CREATE TABLE IF NOT EXISTS user_deletion_requests (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    status VARCHAR(20) NOT NULL,
+    scheduled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_deletion_request_user ON user_deletion_requests(user_id);
+-- 注销任务按状态和执行时间查找到期的申请
+CREATE INDEX IF NOT EXISTS idx_user_deletion_request_due ON user_deletion_requests(status, scheduled_at);
+-- 每个用户最多一条冷静期中的申请
+CREATE UNIQUE INDEX IF NOT EXISTS uk_user_deletion_request_pending ON user_deletion_requests(user_id) WHERE status = 'pending';
+
+-- 添加注释
+COMMENT ON TABLE user_deletion_requests IS '用户注销申请表';
+COMMENT ON COLUMN user_deletion_requests.status IS '状态(pending冷静期中 cancelled已撤销 completed已注销)';
+COMMENT ON COLUMN user_deletion_requests.scheduled_at IS '冷静期结束、执行注销的时间';
+COMMENT ON COLUMN users.status IS '状态(0禁用 1正常 2已注销)';
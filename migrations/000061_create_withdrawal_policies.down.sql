@@ -0,0 +1,3 @@
+-- 移除提现规则
+ALTER TABLE withdrawals DROP COLUMN IF EXISTS policy_snapshot;
+DROP TABLE IF EXISTS withdrawal_policies;
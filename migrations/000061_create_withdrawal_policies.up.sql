@@ -0,0 +1,32 @@
+-- 提现规则：按提现类型配置最低金额、手续费和每日限额，提现记录保存申请时的规则快照
+CREATE TABLE IF NOT EXISTS withdrawal_policies (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(20) NOT NULL,
+    min_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    fee_fixed DECIMAL(10,2) NOT NULL DEFAULT 0,
+    fee_percent DECIMAL(5,4) NOT NULL DEFAULT 0,
+    daily_count_limit INT NOT NULL DEFAULT 0,
+    daily_amount_limit DECIMAL(12,2) NOT NULL DEFAULT 0,
+    updated_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uk_withdrawal_policy_type ON withdrawal_policies(type);
+
+-- 默认规则与原有配置一致：最低 10 元、手续费 0.6%、每日 3 次
+INSERT INTO withdrawal_policies (type, min_amount, fee_fixed, fee_percent, daily_count_limit, daily_amount_limit)
+VALUES ('wallet', 10, 0, 0.006, 3, 0),
+       ('commission', 10, 0, 0.006, 3, 0)
+ON CONFLICT (type) DO NOTHING;
+
+ALTER TABLE withdrawals ADD COLUMN policy_snapshot JSONB;
+
+-- 添加注释
+COMMENT ON TABLE withdrawal_policies IS '提现规则表';
+COMMENT ON COLUMN withdrawal_policies.type IS '提现类型(wallet/commission)';
+COMMENT ON COLUMN withdrawal_policies.fee_fixed IS '每笔固定手续费';
+COMMENT ON COLUMN withdrawal_policies.fee_percent IS '按金额收取的手续费比例';
+COMMENT ON COLUMN withdrawal_policies.daily_count_limit IS '每日提现次数上限(0不限)';
+COMMENT ON COLUMN withdrawal_policies.daily_amount_limit IS '每日提现金额上限(0不限)';
+COMMENT ON COLUMN withdrawals.policy_snapshot IS '申请时适用的提现规则快照';
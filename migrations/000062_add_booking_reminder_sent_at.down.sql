@@ -0,0 +1,3 @@
+-- 移除预订入住提醒
+DROP INDEX IF EXISTS idx_booking_reminder_pending;
+ALTER TABLE bookings DROP COLUMN IF EXISTS reminder_sent_at;
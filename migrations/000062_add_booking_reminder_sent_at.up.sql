@@ -0,0 +1,8 @@
+-- 预订入住提醒：入住前 30 分钟推送提醒，记录发送时间避免重复推送
+ALTER TABLE bookings ADD COLUMN reminder_sent_at TIMESTAMP WITH TIME ZONE;
+
+-- 提醒任务按入住时间查找尚未提醒的已支付预订
+CREATE INDEX IF NOT EXISTS idx_booking_reminder_pending ON bookings(check_in_time) WHERE status = 'paid' AND reminder_sent_at IS NULL;
+
+-- 添加注释
+COMMENT ON COLUMN bookings.reminder_sent_at IS '入住提醒发送时间';
@@ -0,0 +1,9 @@
+-- 000063_seed_audit_log_permission.down.sql
+DROP INDEX IF EXISTS idx_oplog_target;
+
+DELETE FROM role_permissions
+WHERE permission_id IN (
+    SELECT id FROM permissions WHERE code = 'system:audit_log'
+);
+
+DELETE FROM permissions WHERE code = 'system:audit_log';
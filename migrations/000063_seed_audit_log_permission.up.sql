@@ -0,0 +1,16 @@
+-- 000063_seed_audit_log_permission.up.sql
+-- 管理员操作日志查看权限：授予平台管理员（超级管理员不受权限限制）
+
+INSERT INTO permissions (code, name, type, path, method, sort) VALUES
+    ('system:audit_log', '操作日志查看', 'api', '/api/admin/audit-logs', 'GET', 0)
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.code = 'platform_admin'
+  AND p.code = 'system:audit_log'
+ON CONFLICT DO NOTHING;
+
+-- 按操作对象筛选操作日志
+CREATE INDEX IF NOT EXISTS idx_oplog_target ON operation_logs(target_type, target_id);
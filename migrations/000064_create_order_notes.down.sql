@@ -0,0 +1,2 @@
+-- 移除订单备注时间线
+DROP TABLE IF EXISTS order_notes;
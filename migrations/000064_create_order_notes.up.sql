@@ -0,0 +1,19 @@
+-- 订单备注时间线：客服、用户的跟进备注及系统在超时取消、退款通过、计入结算时自动写入的备注
+CREATE TABLE IF NOT EXISTS order_notes (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id),
+    author_type VARCHAR(20) NOT NULL,
+    author_id BIGINT,
+    content VARCHAR(500) NOT NULL,
+    visible_to_user BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 按订单倒序分页查询时间线
+CREATE INDEX IF NOT EXISTS idx_order_note_order ON order_notes(order_id, id DESC);
+
+-- 添加注释
+COMMENT ON TABLE order_notes IS '订单备注时间线表';
+COMMENT ON COLUMN order_notes.author_type IS '作者类型(user用户 admin管理员 system系统)';
+COMMENT ON COLUMN order_notes.author_id IS '作者ID(系统备注为空)';
+COMMENT ON COLUMN order_notes.visible_to_user IS '用户是否可见';
@@ -0,0 +1,2 @@
+-- 移除满赠活动赠品订单
+DROP TABLE IF EXISTS gift_orders;
@@ -0,0 +1,19 @@
+-- 满赠活动赠品订单：记录主订单触发满赠后生成的零元赠品订单
+CREATE TABLE IF NOT EXISTS gift_orders (
+    id BIGSERIAL PRIMARY KEY,
+    campaign_id BIGINT REFERENCES campaigns(id),
+    main_order_id BIGINT NOT NULL REFERENCES orders(id),
+    gift_order_id BIGINT NOT NULL REFERENCES orders(id),
+    product_id BIGINT NOT NULL,
+    quantity INT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_gift_order_main ON gift_orders(main_order_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_gift_order_gift ON gift_orders(gift_order_id);
+CREATE INDEX IF NOT EXISTS idx_gift_order_campaign ON gift_orders(campaign_id);
+
+-- 添加注释
+COMMENT ON TABLE gift_orders IS '满赠活动赠品订单表';
+COMMENT ON COLUMN gift_orders.main_order_id IS '触发满赠的主订单ID';
+COMMENT ON COLUMN gift_orders.gift_order_id IS '零元赠品订单ID';
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_operation_logs_route;
+
+ALTER TABLE operation_logs DROP COLUMN IF EXISTS latency_ms;
+ALTER TABLE operation_logs DROP COLUMN IF EXISTS status_code;
+ALTER TABLE operation_logs DROP COLUMN IF EXISTS route;
+ALTER TABLE operation_logs DROP COLUMN IF EXISTS method;
@@ -0,0 +1,13 @@
+-- 操作日志补充请求信息：请求方法、路由模板、响应状态码和耗时，用于按路由检索审计日志
+ALTER TABLE operation_logs ADD COLUMN IF NOT EXISTS method VARCHAR(10) NOT NULL DEFAULT '';
+ALTER TABLE operation_logs ADD COLUMN IF NOT EXISTS route VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE operation_logs ADD COLUMN IF NOT EXISTS status_code INT NOT NULL DEFAULT 0;
+ALTER TABLE operation_logs ADD COLUMN IF NOT EXISTS latency_ms BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_operation_logs_route ON operation_logs(route varchar_pattern_ops);
+
+-- 添加注释
+COMMENT ON COLUMN operation_logs.method IS '请求方法';
+COMMENT ON COLUMN operation_logs.route IS '路由模板，如 /api/admin/devices/:id';
+COMMENT ON COLUMN operation_logs.status_code IS '响应状态码';
+COMMENT ON COLUMN operation_logs.latency_ms IS '请求耗时（毫秒）';
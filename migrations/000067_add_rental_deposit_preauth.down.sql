@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_payments_order_type;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS captured_amount;
+ALTER TABLE payments DROP COLUMN IF EXISTS type;
+
+ALTER TABLE rentals DROP COLUMN IF EXISTS deposit_method;
@@ -0,0 +1,12 @@
+-- 租借押金支持第三方支付预授权：租借记录押金方式，支付单区分普通支付与押金预授权
+ALTER TABLE rentals ADD COLUMN IF NOT EXISTS deposit_method VARCHAR(20) NOT NULL DEFAULT 'wallet_freeze';
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS type VARCHAR(20) NOT NULL DEFAULT 'pay';
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS captured_amount DECIMAL(12,2) NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_payments_order_type ON payments(order_id, type);
+
+-- 添加注释
+COMMENT ON COLUMN rentals.deposit_method IS '押金方式: wallet_freeze-冻结钱包余额, preauth-第三方支付预授权';
+COMMENT ON COLUMN payments.type IS '支付单类型: pay-普通支付, preauth-押金预授权';
+COMMENT ON COLUMN payments.captured_amount IS '预授权实际扣款金额';
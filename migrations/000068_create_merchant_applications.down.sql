@@ -0,0 +1,3 @@
+-- 000068_create_merchant_applications.down.sql
+DROP TRIGGER IF EXISTS update_merchant_applications_updated_at ON merchant_applications;
+DROP TABLE IF EXISTS merchant_applications;
@@ -0,0 +1,40 @@
+-- 000068_create_merchant_applications.up.sql
+-- 商户入驻申请：用户自助提交资料，管理员审核通过后自动创建商户
+
+CREATE TABLE IF NOT EXISTS merchant_applications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    merchant_name VARCHAR(100) NOT NULL,
+    contact_name VARCHAR(50) NOT NULL,
+    contact_phone VARCHAR(20) NOT NULL,
+    address VARCHAR(255),
+    business_license_url VARCHAR(500) NOT NULL,
+    bank_name VARCHAR(100),
+    bank_account_encrypted TEXT,
+    bank_holder_encrypted TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    reject_reason VARCHAR(255),
+    merchant_id BIGINT REFERENCES merchants(id),
+    submitted_at TIMESTAMP WITH TIME ZONE,
+    reviewed_by BIGINT,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_merchant_applications_user UNIQUE (user_id),
+    CONSTRAINT uk_merchant_applications_merchant UNIQUE (merchant_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_merchant_applications_status ON merchant_applications(status);
+
+CREATE TRIGGER update_merchant_applications_updated_at
+    BEFORE UPDATE ON merchant_applications
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- 添加注释
+COMMENT ON TABLE merchant_applications IS '商户入驻申请';
+COMMENT ON COLUMN merchant_applications.business_license_url IS '营业执照图片地址(仅允许对象存储域名)';
+COMMENT ON COLUMN merchant_applications.bank_account_encrypted IS '结算银行账号(AES加密)';
+COMMENT ON COLUMN merchant_applications.bank_holder_encrypted IS '开户人姓名(AES加密)';
+COMMENT ON COLUMN merchant_applications.status IS '状态: draft-草稿, submitted-已提交, under_review-审核中, approved-已通过, rejected-已驳回';
+COMMENT ON COLUMN merchant_applications.merchant_id IS '审核通过后创建的商户ID';
@@ -0,0 +1,3 @@
+-- 000069_create_pricing_schedules.down.sql
+DROP TRIGGER IF EXISTS update_pricing_schedules_updated_at ON pricing_schedules;
+DROP TABLE IF EXISTS pricing_schedules;
@@ -0,0 +1,31 @@
+-- 000069_create_pricing_schedules.up.sql
+-- 场地分时定价：高峰时段或周末按倍率调整租金
+
+CREATE TABLE IF NOT EXISTS pricing_schedules (
+    id BIGSERIAL PRIMARY KEY,
+    venue_id BIGINT NOT NULL REFERENCES venues(id),
+    day_of_week SMALLINT,
+    start_hour SMALLINT NOT NULL,
+    end_hour SMALLINT NOT NULL,
+    multiplier DECIMAL(4,2) NOT NULL DEFAULT 1,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_pricing_schedules_day CHECK (day_of_week IS NULL OR day_of_week BETWEEN 0 AND 6),
+    CONSTRAINT chk_pricing_schedules_hours CHECK (start_hour >= 0 AND start_hour < end_hour AND end_hour <= 24),
+    CONSTRAINT chk_pricing_schedules_multiplier CHECK (multiplier > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pricing_schedules_venue ON pricing_schedules(venue_id);
+
+CREATE TRIGGER update_pricing_schedules_updated_at
+    BEFORE UPDATE ON pricing_schedules
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- 添加注释
+COMMENT ON TABLE pricing_schedules IS '场地分时定价';
+COMMENT ON COLUMN pricing_schedules.day_of_week IS '星期(0周日-6周六)，为空表示每天';
+COMMENT ON COLUMN pricing_schedules.start_hour IS '开始小时(含)';
+COMMENT ON COLUMN pricing_schedules.end_hour IS '结束小时(不含)';
+COMMENT ON COLUMN pricing_schedules.multiplier IS '租金倍率';
@@ -0,0 +1,8 @@
+-- 移除游客购物车
+DROP TABLE IF EXISTS guest_cart_tokens;
+
+DELETE FROM cart_items WHERE user_id IS NULL;
+DROP INDEX IF EXISTS uk_cart_guest_product_sku;
+ALTER TABLE cart_items DROP CONSTRAINT IF EXISTS chk_cart_items_owner;
+ALTER TABLE cart_items DROP COLUMN IF EXISTS guest_token;
+ALTER TABLE cart_items ALTER COLUMN user_id SET NOT NULL;
@@ -0,0 +1,21 @@
+-- 游客购物车：未登录时按客户端生成的购物车令牌保存购物车项，登录后合并到用户购物车
+ALTER TABLE cart_items ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS guest_token VARCHAR(64);
+ALTER TABLE cart_items ADD CONSTRAINT chk_cart_items_owner CHECK ((user_id IS NULL) <> (guest_token IS NULL));
+
+CREATE UNIQUE INDEX IF NOT EXISTS uk_cart_guest_product_sku ON cart_items(guest_token, product_id, sku_id) WHERE guest_token IS NOT NULL;
+
+-- 已合并的游客购物车令牌，合并后令牌不可再用于游客购物车
+CREATE TABLE IF NOT EXISTS guest_cart_tokens (
+    token VARCHAR(64) PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    merged_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_guest_cart_tokens_merged_at ON guest_cart_tokens(merged_at);
+
+-- 添加注释
+COMMENT ON COLUMN cart_items.user_id IS '用户ID，游客购物车为空';
+COMMENT ON COLUMN cart_items.guest_token IS '游客购物车令牌，登录用户为空';
+COMMENT ON TABLE guest_cart_tokens IS '已合并的游客购物车令牌';
+COMMENT ON COLUMN guest_cart_tokens.user_id IS '合并到的用户ID';
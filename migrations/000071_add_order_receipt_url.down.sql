@@ -0,0 +1,2 @@
+-- 移除订单收据地址
+ALTER TABLE orders DROP COLUMN IF EXISTS receipt_url;
@@ -0,0 +1,5 @@
+-- 订单收据：记录首次下载时生成的收据PDF地址
+ALTER TABLE orders ADD COLUMN receipt_url VARCHAR(500);
+
+-- 添加注释
+COMMENT ON COLUMN orders.receipt_url IS '收据PDF地址(未生成时为空)';
@@ -0,0 +1,3 @@
+-- 移除秒杀活动商品
+DROP TABLE IF EXISTS flash_sale_reservations;
+DROP TABLE IF EXISTS campaign_products;
@@ -0,0 +1,37 @@
+-- 秒杀活动商品：秒杀价、剩余秒杀库存及每人限购数量
+CREATE TABLE IF NOT EXISTS campaign_products (
+    id BIGSERIAL PRIMARY KEY,
+    campaign_id BIGINT NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    product_id BIGINT NOT NULL REFERENCES products(id),
+    flash_price DECIMAL(10,2) NOT NULL,
+    flash_stock INT NOT NULL DEFAULT 0 CHECK (flash_stock >= 0),
+    per_user_limit INT NOT NULL DEFAULT 0 CHECK (per_user_limit >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_campaign_products_campaign_product UNIQUE (campaign_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaign_products_product_id ON campaign_products(product_id);
+
+-- 秒杀库存预占记录：下单时预占，支付后为已支付，订单取消或超时时释放
+CREATE TABLE IF NOT EXISTS flash_sale_reservations (
+    id BIGSERIAL PRIMARY KEY,
+    campaign_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    order_id BIGINT,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_flash_sale_reservations_user ON flash_sale_reservations(campaign_id, product_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_flash_sale_reservations_order_id ON flash_sale_reservations(order_id);
+
+-- 添加注释
+COMMENT ON TABLE campaign_products IS '秒杀活动商品';
+COMMENT ON COLUMN campaign_products.flash_stock IS '剩余秒杀库存';
+COMMENT ON COLUMN campaign_products.per_user_limit IS '每人限购数量(0表示不限)';
+COMMENT ON TABLE flash_sale_reservations IS '秒杀库存预占记录';
+COMMENT ON COLUMN flash_sale_reservations.status IS '状态: reserved-已预占, paid-已支付, released-已释放';
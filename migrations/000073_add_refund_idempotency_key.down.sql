@@ -0,0 +1,3 @@
+-- 移除管理员退款幂等键
+DROP INDEX IF EXISTS idx_refunds_idempotency_key;
+ALTER TABLE refunds DROP COLUMN IF EXISTS idempotency_key;
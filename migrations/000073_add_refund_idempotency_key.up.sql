@@ -0,0 +1,6 @@
+-- 管理员退款幂等键：客户端重复提交同一退款时返回首次创建的退款
+ALTER TABLE refunds ADD COLUMN idempotency_key VARCHAR(128);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refunds_idempotency_key ON refunds(idempotency_key);
+
+-- 添加注释
+COMMENT ON COLUMN refunds.idempotency_key IS '管理员发起退款时客户端提供的幂等键';
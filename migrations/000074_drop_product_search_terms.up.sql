@@ -0,0 +1,4 @@
+-- 000074_drop_product_search_terms.up.sql
+-- 商品检索改由 products.search_vector 全文检索（PostgreSQL）或 LIKE 检索，移除不再使用的倒排索引表
+
+DROP TABLE IF EXISTS product_search_terms;
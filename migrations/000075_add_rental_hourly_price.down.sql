@@ -0,0 +1,4 @@
+-- 000075_add_rental_hourly_price.down.sql
+-- 移除租借小时单价快照
+
+ALTER TABLE rentals DROP COLUMN IF EXISTS hourly_price;
@@ -0,0 +1,12 @@
+-- 000075_add_rental_hourly_price.up.sql
+-- 租借记录下单时的小时单价（含分时定价倍率、会员折扣前），按小时续租沿用该单价，不受之后定价调整影响
+
+ALTER TABLE rentals ADD COLUMN IF NOT EXISTS hourly_price DECIMAL(10,4) NOT NULL DEFAULT 0;
+
+-- 未续租过的历史租借按折扣前租金折算；已续租的租借时长已累加，无法还原，续租时仍按所选定价计算
+UPDATE rentals r
+SET hourly_price = ROUND(r.original_fee / r.duration_hours, 4)
+WHERE r.duration_hours > 0
+  AND NOT EXISTS (SELECT 1 FROM order_items oi WHERE oi.order_id = r.order_id);
+
+COMMENT ON COLUMN rentals.hourly_price IS '下单时小时单价（会员折扣前），0 表示未记录';
@@ -0,0 +1,3 @@
+-- 000076_add_venue_timezone.down.sql
+-- 移除场地时区
+ALTER TABLE venues DROP COLUMN IF EXISTS timezone;
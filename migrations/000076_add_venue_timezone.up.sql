@@ -0,0 +1,6 @@
+-- 000076_add_venue_timezone.up.sql
+-- 场地时区，分时定价按场地当地时间匹配星期和小时
+ALTER TABLE venues ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Shanghai';
+
+-- 添加注释
+COMMENT ON COLUMN venues.timezone IS '场地所在时区(IANA 名称，如 Asia/Shanghai)';
@@ -0,0 +1,4 @@
+-- 000077_recreate_product_search_terms.down.sql
+-- 移除商品搜索倒排索引表
+
+DROP TABLE IF EXISTS product_search_terms;
@@ -0,0 +1,17 @@
+-- 000077_recreate_product_search_terms.up.sql
+-- 恢复商品搜索倒排索引，非 PostgreSQL 部署使用倒排索引检索，写入后需通过管理端重建索引填充存量商品
+
+CREATE TABLE IF NOT EXISTS product_search_terms (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    term VARCHAR(64) NOT NULL,
+    frequency INT NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_product_search_terms_product_term UNIQUE (product_id, term)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_search_terms_term ON product_search_terms(term);
+
+COMMENT ON TABLE product_search_terms IS '商品搜索倒排索引';
+COMMENT ON COLUMN product_search_terms.term IS '分词词项（英文小写单词、数字或中文二元组）';
+COMMENT ON COLUMN product_search_terms.frequency IS '词频（名称中的出现按权重计入）';
@@ -0,0 +1,9 @@
+-- 000078_seed_order_refund_permission.down.sql
+-- 移除订单退款权限
+
+DELETE FROM role_permissions
+WHERE permission_id IN (
+    SELECT id FROM permissions WHERE code = 'order:refund'
+);
+
+DELETE FROM permissions WHERE code = 'order:refund';
@@ -0,0 +1,13 @@
+-- 000078_seed_order_refund_permission.up.sql
+-- 管理员发起订单退款权限：授予平台管理员与财务管理员（超级管理员不受权限限制）
+
+INSERT INTO permissions (code, name, type, path, method, sort) VALUES
+    ('order:refund', '订单退款', 'api', '/api/admin/orders/:id/refund', 'POST', 0)
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.code IN ('platform_admin', 'finance_admin')
+  AND p.code = 'order:refund'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+-- 000079_add_settlement_legacy_pending.down.sql
+-- 回滚结算审核兼容标记
+
+ALTER TABLE settlements DROP COLUMN IF EXISTS legacy_pending;
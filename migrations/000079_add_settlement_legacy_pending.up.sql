@@ -0,0 +1,9 @@
+-- 000079_add_settlement_legacy_pending.up.sql
+-- 结算审核兼容：标记审核流程上线前生成的待结算记录，允许其按原流程直接处理入账
+
+ALTER TABLE settlements ADD COLUMN legacy_pending BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- 审核流程上线前未审核的待结算记录
+UPDATE settlements SET legacy_pending = TRUE WHERE status = 'pending' AND reviewer_id IS NULL;
+
+COMMENT ON COLUMN settlements.legacy_pending IS '审核流程上线前生成的待结算记录';
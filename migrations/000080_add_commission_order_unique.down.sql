@@ -0,0 +1,4 @@
+-- 000080_add_commission_order_unique.down.sql
+-- 移除佣金唯一约束
+
+DROP INDEX IF EXISTS uk_commissions_order_type_distributor;
@@ -0,0 +1,12 @@
+-- 000080_add_commission_order_unique.up.sql
+-- 佣金唯一约束：同一订单同一类型只为同一分销商生成一条佣金，防止并发处理订单完成时重复计佣
+
+-- 清理已存在的重复佣金，保留最早生成的一条
+DELETE FROM commissions c
+USING commissions d
+WHERE c.order_id = d.order_id
+  AND c.type = d.type
+  AND c.distributor_id = d.distributor_id
+  AND c.id > d.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uk_commissions_order_type_distributor ON commissions(order_id, type, distributor_id);
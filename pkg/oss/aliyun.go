@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"path"
 	"strings"
 	"time"
@@ -24,6 +25,15 @@ type Uploader interface {
 	GetSignedURL(objectKey string, expires time.Duration) (string, error)
 }
 
+// URLHost 返回上传器生成的文件地址的主机名，用于校验客户端提交的文件地址是否来自本存储
+func URLHost(u Uploader) string {
+	parsed, err := url.Parse(u.GetURL(""))
+	if err != nil {
+		return ""
+	}
+	return parsed.Hostname()
+}
+
 // AliyunConfig 阿里云 OSS 配置
 type AliyunConfig struct {
 	Endpoint        string
@@ -289,6 +289,17 @@ func TestAliyunUploader_GetURL(t *testing.T) {
 	})
 }
 
+func TestURLHost(t *testing.T) {
+	assert.Equal(t, "mock-oss.example.com", URLHost(NewMockUploader()))
+
+	assert.Equal(t, "my-bucket.oss-cn-hangzhou.aliyuncs.com", URLHost(&AliyunUploader{
+		config: &AliyunConfig{BucketName: "my-bucket", Endpoint: "oss-cn-hangzhou.aliyuncs.com", BasePath: "uploads"},
+	}))
+	assert.Equal(t, "cdn.example.com", URLHost(&AliyunUploader{
+		config: &AliyunConfig{Domain: "https://cdn.example.com/"},
+	}))
+}
+
 // 辅助函数测试
 func TestReadAll(t *testing.T) {
 	content := "test content for upload"
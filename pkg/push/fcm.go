@@ -0,0 +1,38 @@
+// Package push 提供移动端推送服务
+package push
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotConfigured 推送服务未配置
+var ErrNotConfigured = errors.New("push: fcm server key not configured")
+
+// FCMConfig FCM 推送配置
+type FCMConfig struct {
+	ServerKey string
+}
+
+// FCMNotifier FCM 推送发送器
+// 占位实现：用户设备令牌尚未接入，Send 只校验配置，不实际调用 FCM 接口
+type FCMNotifier struct {
+	serverKey string
+}
+
+// NewFCMNotifier 创建 FCM 推送发送器
+func NewFCMNotifier(cfg *FCMConfig) *FCMNotifier {
+	return &FCMNotifier{serverKey: cfg.ServerKey}
+}
+
+// Send 向用户推送通知
+func (n *FCMNotifier) Send(ctx context.Context, userID int64, title, body string) error {
+	if n.serverKey == "" {
+		return ErrNotConfigured
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// TODO: 按用户查询设备令牌并调用 FCM HTTP v1 接口
+	return nil
+}
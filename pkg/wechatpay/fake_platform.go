@@ -0,0 +1,125 @@
+package wechatpay
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// FakePlatform 模拟微信支付平台（用于测试）
+// 持有平台私钥和 APIv3 密钥，可生成与真实回调格式一致的签名加密通知，无需调用微信
+type FakePlatform struct {
+	SerialNo   string
+	APIv3Key   string
+	privateKey *rsa.PrivateKey
+}
+
+// NewFakePlatform 创建模拟微信支付平台
+func NewFakePlatform() (*FakePlatform, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("generate platform key error: %w", err)
+	}
+	return &FakePlatform{
+		SerialNo:   "FAKE_PLATFORM_SERIAL",
+		APIv3Key:   "fake-wechatpay-api-v3-key-32byte",
+		privateKey: privateKey,
+	}, nil
+}
+
+// Verifier 返回信任该模拟平台证书的验签解密器
+func (p *FakePlatform) Verifier() *NotifyVerifier {
+	return NewNotifyVerifier(p.APIv3Key, map[string]*rsa.PublicKey{
+		p.SerialNo: &p.privateKey.PublicKey,
+	})
+}
+
+// BuildNotify 构造支付成功类回调通知，返回请求体及签名请求头
+func (p *FakePlatform) BuildNotify(resource *NotifyResource) ([]byte, http.Header, error) {
+	plaintext, err := json.Marshal(resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encrypted, err := p.encrypt(plaintext, "transaction")
+	if err != nil {
+		return nil, nil, err
+	}
+	rawResource, err := json.Marshal(encrypted)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, err := json.Marshal(&NotifyPayload{
+		ID:           "EV-" + strconv.FormatInt(time.Now().UnixNano(), 10),
+		CreateTime:   time.Now().Format(time.RFC3339),
+		ResourceType: "encrypt-resource",
+		EventType:    "TRANSACTION.SUCCESS",
+		Summary:      "支付成功",
+		Resource:     rawResource,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header, err := p.Sign(body, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, header, nil
+}
+
+// Sign 按平台规则对请求体签名，返回回调请求头
+func (p *FakePlatform) Sign(body []byte, at time.Time) (http.Header, error) {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	nonce := generateNonceStr()
+
+	hashed := sha256.Sum256([]byte(buildSignMessage(timestamp, nonce, body)))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.privateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return nil, fmt.Errorf("sign notify error: %w", err)
+	}
+
+	header := make(http.Header)
+	header.Set(HeaderSignature, base64.StdEncoding.EncodeToString(sig))
+	header.Set(HeaderTimestamp, timestamp)
+	header.Set(HeaderNonce, nonce)
+	header.Set(HeaderSerial, p.SerialNo)
+	return header, nil
+}
+
+// encrypt 使用 APIv3 密钥加密资源（AEAD_AES_256_GCM）
+func (p *FakePlatform) encrypt(plaintext []byte, associatedData string) (*EncryptedResource, error) {
+	block, err := aes.NewCipher([]byte(p.APIv3Key))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceBytes := make([]byte, 6)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return nil, err
+	}
+	nonce := fmt.Sprintf("%x", nonceBytes) // 12 字节随机串
+
+	ciphertext := gcm.Seal(nil, []byte(nonce), plaintext, []byte(associatedData))
+	return &EncryptedResource{
+		Algorithm:      AlgorithmAEADAES256GCM,
+		Ciphertext:     base64.StdEncoding.EncodeToString(ciphertext),
+		AssociatedData: associatedData,
+		OriginalType:   "transaction",
+		Nonce:          nonce,
+	}, nil
+}
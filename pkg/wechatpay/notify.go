@@ -0,0 +1,192 @@
+package wechatpay
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 回调通知请求头
+const (
+	HeaderSignature = "Wechatpay-Signature"
+	HeaderTimestamp = "Wechatpay-Timestamp"
+	HeaderNonce     = "Wechatpay-Nonce"
+	HeaderSerial    = "Wechatpay-Serial"
+)
+
+// AlgorithmAEADAES256GCM 回调资源加密算法
+const AlgorithmAEADAES256GCM = "AEAD_AES_256_GCM"
+
+// notifyTimestampTolerance 回调时间戳允许的最大偏差，超出视为重放
+const notifyTimestampTolerance = 5 * time.Minute
+
+// 回调验签错误
+var (
+	ErrMissingSignatureHeader = errors.New("wechatpay: missing signature header")
+	ErrUnknownPlatformSerial  = errors.New("wechatpay: unknown platform certificate serial")
+	ErrInvalidSignature       = errors.New("wechatpay: invalid signature")
+	ErrNotifyExpired          = errors.New("wechatpay: notify timestamp expired")
+)
+
+// EncryptedResource 回调中的加密资源
+type EncryptedResource struct {
+	Algorithm      string `json:"algorithm"`
+	Ciphertext     string `json:"ciphertext"`
+	AssociatedData string `json:"associated_data"`
+	OriginalType   string `json:"original_type"`
+	Nonce          string `json:"nonce"`
+}
+
+// NotifyVerifier 回调通知验签解密器
+// 使用微信支付平台证书公钥验证签名，使用 APIv3 密钥解密资源
+type NotifyVerifier struct {
+	apiV3Key     []byte
+	platformKeys map[string]*rsa.PublicKey // 平台证书序列号 -> 公钥
+	now          func() time.Time
+}
+
+// NewNotifyVerifier 创建回调通知验签解密器
+func NewNotifyVerifier(apiV3Key string, platformKeys map[string]*rsa.PublicKey) *NotifyVerifier {
+	if platformKeys == nil {
+		platformKeys = make(map[string]*rsa.PublicKey)
+	}
+	return &NotifyVerifier{
+		apiV3Key:     []byte(apiV3Key),
+		platformKeys: platformKeys,
+		now:          time.Now,
+	}
+}
+
+// ParseVerifiedNotify 验证签名并解密支付回调，返回解密后的资源
+func (v *NotifyVerifier) ParseVerifiedNotify(body []byte, header http.Header) (*NotifyResource, error) {
+	if err := v.VerifySignature(header, body); err != nil {
+		return nil, err
+	}
+
+	var notify NotifyPayload
+	if err := json.Unmarshal(body, &notify); err != nil {
+		return nil, fmt.Errorf("parse notify payload error: %w", err)
+	}
+
+	var encrypted EncryptedResource
+	if err := json.Unmarshal(notify.Resource, &encrypted); err != nil {
+		return nil, fmt.Errorf("parse notify resource error: %w", err)
+	}
+
+	plaintext, err := DecryptResource(v.apiV3Key, &encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	var resource NotifyResource
+	if err := json.Unmarshal(plaintext, &resource); err != nil {
+		return nil, fmt.Errorf("parse decrypted resource error: %w", err)
+	}
+	return &resource, nil
+}
+
+// VerifySignature 验证回调签名
+// 签名串为 "时间戳\n随机串\n请求体\n"，使用 SHA256-RSA 验证
+func (v *NotifyVerifier) VerifySignature(header http.Header, body []byte) error {
+	signature := header.Get(HeaderSignature)
+	timestamp := header.Get(HeaderTimestamp)
+	nonce := header.Get(HeaderNonce)
+	serial := header.Get(HeaderSerial)
+	if signature == "" || timestamp == "" || nonce == "" || serial == "" {
+		return ErrMissingSignatureHeader
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if d := v.now().Sub(time.Unix(ts, 0)); d > notifyTimestampTolerance || d < -notifyTimestampTolerance {
+		return ErrNotifyExpired
+	}
+
+	publicKey, ok := v.platformKeys[serial]
+	if !ok {
+		return ErrUnknownPlatformSerial
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	hashed := sha256.Sum256([]byte(buildSignMessage(timestamp, nonce, body)))
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hashed[:], sig); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// DecryptResource 使用 APIv3 密钥解密回调资源（AEAD_AES_256_GCM）
+func DecryptResource(apiV3Key []byte, resource *EncryptedResource) ([]byte, error) {
+	if resource.Algorithm != AlgorithmAEADAES256GCM {
+		return nil, fmt.Errorf("unsupported resource algorithm: %s", resource.Algorithm)
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(resource.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("decode resource ciphertext error: %w", err)
+	}
+
+	block, err := aes.NewCipher(apiV3Key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher error: %w", err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(resource.Nonce))
+	if err != nil {
+		return nil, fmt.Errorf("create gcm error: %w", err)
+	}
+
+	plaintext, err := gcm.Open(nil, []byte(resource.Nonce), ciphertext, []byte(resource.AssociatedData))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt resource error: %w", err)
+	}
+	return plaintext, nil
+}
+
+// LoadPlatformCertificate 从 PEM 文件加载平台证书，返回证书序列号和公钥
+func LoadPlatformCertificate(path string) (string, *rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("read platform certificate error: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return "", nil, errors.New("invalid platform certificate pem")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", nil, fmt.Errorf("parse platform certificate error: %w", err)
+	}
+
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return "", nil, errors.New("platform certificate is not rsa")
+	}
+
+	return strings.ToUpper(cert.SerialNumber.Text(16)), publicKey, nil
+}
+
+// buildSignMessage 构造签名串
+func buildSignMessage(timestamp, nonce string, body []byte) string {
+	return timestamp + "\n" + nonce + "\n" + string(body) + "\n"
+}
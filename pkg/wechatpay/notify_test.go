@@ -0,0 +1,97 @@
+package wechatpay
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestNotify(t *testing.T) (*FakePlatform, []byte, http.Header) {
+	t.Helper()
+
+	platform, err := NewFakePlatform()
+	require.NoError(t, err)
+
+	resource := &NotifyResource{
+		OutTradeNo:    "P20260101000001",
+		TransactionID: "4200000000000001",
+		TradeType:     "JSAPI",
+		TradeState:    TradeStateSuccess,
+	}
+	resource.Amount.Total = 6000
+
+	body, header, err := platform.BuildNotify(resource)
+	require.NoError(t, err)
+	return platform, body, header
+}
+
+func TestNotifyVerifier_ParseVerifiedNotify(t *testing.T) {
+	platform, body, header := newTestNotify(t)
+
+	resource, err := platform.Verifier().ParseVerifiedNotify(body, header)
+	require.NoError(t, err)
+	assert.Equal(t, "P20260101000001", resource.OutTradeNo)
+	assert.Equal(t, "4200000000000001", resource.TransactionID)
+	assert.Equal(t, TradeStateSuccess, resource.TradeState)
+	assert.Equal(t, int64(6000), resource.Amount.Total)
+}
+
+func TestNotifyVerifier_RejectsTamperedBody(t *testing.T) {
+	platform, body, header := newTestNotify(t)
+
+	tampered := append([]byte{}, body...)
+	tampered[len(tampered)-2] = ' '
+
+	_, err := platform.Verifier().ParseVerifiedNotify(tampered, header)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestNotifyVerifier_RejectsUnknownSerial(t *testing.T) {
+	_, body, header := newTestNotify(t)
+
+	// 其他平台密钥签名的通知不被信任
+	other, err := NewFakePlatform()
+	require.NoError(t, err)
+
+	_, err = other.Verifier().ParseVerifiedNotify(body, header)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	other.SerialNo = "OTHER_SERIAL"
+	_, err = other.Verifier().ParseVerifiedNotify(body, header)
+	assert.ErrorIs(t, err, ErrUnknownPlatformSerial)
+}
+
+func TestNotifyVerifier_RejectsMissingHeaders(t *testing.T) {
+	platform, body, header := newTestNotify(t)
+
+	delete(header, HeaderSignature)
+	_, err := platform.Verifier().ParseVerifiedNotify(body, header)
+	assert.ErrorIs(t, err, ErrMissingSignatureHeader)
+}
+
+func TestNotifyVerifier_RejectsExpiredTimestamp(t *testing.T) {
+	platform, body, _ := newTestNotify(t)
+
+	header, err := platform.Sign(body, time.Now().Add(-10*time.Minute))
+	require.NoError(t, err)
+
+	_, err = platform.Verifier().ParseVerifiedNotify(body, header)
+	assert.ErrorIs(t, err, ErrNotifyExpired)
+}
+
+func TestDecryptResource_WrongKey(t *testing.T) {
+	platform, _, _ := newTestNotify(t)
+
+	encrypted, err := platform.encrypt([]byte(`{"out_trade_no":"P1"}`), "transaction")
+	require.NoError(t, err)
+
+	plaintext, err := DecryptResource([]byte(platform.APIv3Key), encrypted)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"out_trade_no":"P1"}`, string(plaintext))
+
+	_, err = DecryptResource([]byte("another-api-v3-key-with-32-bytes"), encrypted)
+	assert.Error(t, err)
+}
@@ -49,6 +49,7 @@ func setupDeviceAPITestDB(t *testing.T) *gorm.DB {
 		&models.Permission{},
 		&models.RolePermission{},
 		&models.Device{},
+		&models.DeviceSlot{},
 		&models.DeviceLog{},
 		&models.DeviceMaintenance{},
 		&models.Venue{},
@@ -74,11 +75,12 @@ func setupDeviceAPIRouter(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager) {
 
 	// 创建设备服务和处理器
 	deviceRepo := repository.NewDeviceRepository(db)
+	deviceSlotRepo := repository.NewDeviceSlotRepository(db)
 	deviceLogRepo := repository.NewDeviceLogRepository(db)
 	deviceMaintenanceRepo := repository.NewDeviceMaintenanceRepository(db)
 	venueRepo := repository.NewVenueRepository(db)
 
-	deviceService := adminService.NewDeviceAdminService(deviceRepo, deviceLogRepo, deviceMaintenanceRepo, venueRepo, nil)
+	deviceService := adminService.NewDeviceAdminService(deviceRepo, deviceSlotRepo, deviceLogRepo, deviceMaintenanceRepo, venueRepo, nil)
 	deviceHandler := adminHandler.NewDeviceHandler(deviceService)
 
 	api := r.Group("/api/v1/admin")
@@ -850,3 +852,47 @@ func TestDeviceAPI_RemoteLock_Success(t *testing.T) {
 	assert.Len(t, logs, 1)
 }
 
+
+func TestDeviceAPI_ListAndReleaseSlots(t *testing.T) {
+	router, db, jwtManager := setupDeviceAPIRouter(t)
+
+	token := createDeviceAPITestAdmin(t, db, jwtManager, "slot_admin")
+	venue := createDeviceAPITestVenue(t, db)
+	device := createDeviceAPITestDevice(t, db, "DEV_API_SLOT", venue.ID)
+	devicePath := "/api/v1/admin/devices/" + strconv.FormatInt(device.ID, 10)
+
+	req, _ := http.NewRequest("GET", devicePath+"/slots", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp["data"], 10)
+
+	// 已完成租借遗留占用的格口
+	slotNo := 1
+	rental := &models.Rental{OrderID: 1, UserID: 1, DeviceID: device.ID, SlotNo: &slotNo, DurationHours: 1, Status: models.RentalStatusCompleted}
+	require.NoError(t, db.Create(rental).Error)
+	require.NoError(t, db.Model(&models.DeviceSlot{}).Where("device_id = ? AND slot_no = ?", device.ID, slotNo).
+		Updates(map[string]interface{}{"status": models.DeviceSlotInUse, "current_rental_id": rental.ID}).Error)
+	require.NoError(t, db.Model(device).Update("available_slots", 9).Error)
+
+	req, _ = http.NewRequest("POST", devicePath+"/slots/1/release", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var updated models.Device
+	require.NoError(t, db.First(&updated, device.ID).Error)
+	assert.Equal(t, 10, updated.AvailableSlots)
+
+	req, _ = http.NewRequest("POST", devicePath+"/slots/99/release", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,116 @@
+//go:build api
+// +build api
+
+// Package api 设备批量导入 API 测试
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+)
+
+// doDeviceImportRequest 以 multipart 表单上传设备导入文件
+func doDeviceImportRequest(t *testing.T, router *gin.Engine, venueID int64, token, csv, strict string) (int, map[string]interface{}) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "devices.csv")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(csv))
+	require.NoError(t, err)
+	if strict != "" {
+		require.NoError(t, writer.WriteField("strict", strict))
+	}
+	require.NoError(t, writer.Close())
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/admin/venues/%d/devices/import", venueID), body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestDeviceAPI_Import_PartialSuccess(t *testing.T) {
+	router, db, jwtManager := setupDeviceAPIRouter(t)
+	token := createDeviceAPITestAdmin(t, db, jwtManager, "import_device_admin")
+	venue := createDeviceAPITestVenue(t, db)
+	createDeviceAPITestDevice(t, db, "API-IMP-EXIST", venue.ID)
+
+	csv := "device_no,name,type,slot_count,network_type,product_name\n" +
+		"API-IMP-001,一号柜,standard,10,WiFi,充电宝\n" +
+		"API-IMP-001,一号柜,standard,10,WiFi,充电宝\n" +
+		"API-IMP-EXIST,已存在柜,standard,10,WiFi,充电宝\n" +
+		"API-IMP-002,二号柜,mini,-1,WiFi,充电宝\n"
+
+	code, resp := doDeviceImportRequest(t, router, venue.ID, token, csv, "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(0), resp["code"])
+
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, float64(4), data["total"])
+	assert.Equal(t, float64(1), data["created"])
+	assert.Equal(t, float64(3), data["failed"])
+	errs := data["errors"].([]interface{})
+	require.Len(t, errs, 3)
+	assert.Equal(t, float64(3), errs[0].(map[string]interface{})["row"])
+	assert.Equal(t, "API-IMP-EXIST", errs[1].(map[string]interface{})["device_no"])
+
+	var count int64
+	db.Model(&models.Device{}).Where("venue_id = ?", venue.ID).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestDeviceAPI_Import_Strict(t *testing.T) {
+	router, db, jwtManager := setupDeviceAPIRouter(t)
+	token := createDeviceAPITestAdmin(t, db, jwtManager, "import_strict_admin")
+	venue := createDeviceAPITestVenue(t, db)
+
+	csv := "device_no,name,type,slot_count,network_type,product_name\n" +
+		"API-STRICT-001,一号柜,standard,10,WiFi,充电宝\n" +
+		"API-STRICT-002,二号柜,bogus,10,WiFi,充电宝\n"
+
+	code, resp := doDeviceImportRequest(t, router, venue.ID, token, csv, "true")
+	assert.Equal(t, http.StatusBadRequest, code)
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, float64(0), data["created"])
+	assert.Len(t, data["errors"].([]interface{}), 1)
+
+	var count int64
+	db.Model(&models.Device{}).Where("venue_id = ?", venue.ID).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestDeviceAPI_Import_BadRequest(t *testing.T) {
+	router, db, jwtManager := setupDeviceAPIRouter(t)
+	token := createDeviceAPITestAdmin(t, db, jwtManager, "import_bad_admin")
+	venue := createDeviceAPITestVenue(t, db)
+
+	// 缺少必需列
+	code, _ := doDeviceImportRequest(t, router, venue.ID, token, "device_no,name\nA,B\n", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 场地不存在
+	code, _ = doDeviceImportRequest(t, router, 99999, token, "device_no,name,type,slot_count,network_type,product_name\n", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// 未登录
+	code, _ = doDeviceImportRequest(t, router, venue.ID, "", "device_no\n", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
@@ -0,0 +1,153 @@
+//go:build api
+// +build api
+
+// Package api 设备状态实时推送 WebSocket API 测试
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
+	adminHandler "github.com/dumeirei/smart-locker-backend/internal/handler/admin"
+	"github.com/dumeirei/smart-locker-backend/internal/middleware"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	deviceService "github.com/dumeirei/smart-locker-backend/internal/service/device"
+)
+
+// deviceWSAllowedOrigin 测试中允许的管理后台来源
+const deviceWSAllowedOrigin = "https://admin.example.com"
+
+// setupDeviceWSAPIServer 启动带设备状态推送路由的测试服务器
+func setupDeviceWSAPIServer(t *testing.T) (*httptest.Server, *deviceService.DeviceService, string, int64) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupDeviceAPITestDB(t)
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret-key-for-device-ws-api",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: 2 * time.Hour,
+		Issuer:            "test",
+	})
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hub := deviceService.NewStatusHub(redisClient, nil)
+	require.NoError(t, hub.Start(ctx))
+
+	deviceSvc := deviceService.NewDeviceService(db, repository.NewDeviceRepository(db), repository.NewVenueRepository(db))
+	deviceSvc.SetStatusPublisher(deviceService.NewRedisStatusPublisher(redisClient))
+
+	r := gin.New()
+	admin := r.Group("/api/admin")
+	admin.Use(middleware.AdminAuth(jwtManager))
+	adminHandler.NewDeviceStatusWSHandler(hub, []string{deviceWSAllowedOrigin}).RegisterRoutes(admin)
+
+	server := httptest.NewServer(r)
+	t.Cleanup(func() {
+		server.Close()
+		cancel()
+		hub.Wait()
+		_ = redisClient.Close()
+		mr.Close()
+	})
+
+	token := createDeviceAPITestAdmin(t, db, jwtManager, "ws_admin")
+	venue := createDeviceAPITestVenue(t, db)
+	device := createDeviceAPITestDevice(t, db, "DEV_WS_001", venue.ID)
+	require.NoError(t, db.Model(device).Update("online_status", models.DeviceOnline).Error)
+
+	return server, deviceSvc, token, device.ID
+}
+
+// deviceWSURL 设备状态推送地址
+func deviceWSURL(server *httptest.Server, token string) string {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/admin/ws/devices"
+	if token != "" {
+		url += "?token=" + token
+	}
+	return url
+}
+
+func TestDeviceStatusWS_ReceivesStatusChange(t *testing.T) {
+	server, deviceSvc, token, deviceID := setupDeviceWSAPIServer(t)
+
+	conn, resp, err := websocket.DefaultDialer.Dial(deviceWSURL(server, token), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	require.NoError(t, deviceSvc.SetDeviceOffline(context.Background(), deviceID))
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(3*time.Second)))
+	_, payload, err := conn.ReadMessage()
+	require.NoError(t, err)
+
+	var event map[string]interface{}
+	require.NoError(t, json.Unmarshal(payload, &event))
+	assert.Equal(t, float64(deviceID), event["device_id"])
+	assert.Equal(t, deviceService.StatusEventOffline, event["event_type"])
+	assert.Equal(t, float64(models.DeviceOnline), event["old_status"])
+	assert.Equal(t, float64(models.DeviceOffline), event["new_status"])
+	assert.NotEmpty(t, event["timestamp"])
+}
+
+func TestDeviceStatusWS_Unauthorized(t *testing.T) {
+	server, _, _, _ := setupDeviceWSAPIServer(t)
+
+	_, resp, err := websocket.DefaultDialer.Dial(deviceWSURL(server, ""), nil)
+	require.ErrorIs(t, err, websocket.ErrBadHandshake)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestDeviceStatusWS_CheckOrigin(t *testing.T) {
+	server, _, token, _ := setupDeviceWSAPIServer(t)
+
+	dial := func(origin string) (*http.Response, error) {
+		header := http.Header{}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		conn, resp, err := websocket.DefaultDialer.Dial(deviceWSURL(server, token), header)
+		if err == nil {
+			conn.Close()
+		}
+		return resp, err
+	}
+
+	t.Run("未配置的跨域来源被拒绝", func(t *testing.T) {
+		resp, err := dial("https://evil.example.com")
+		require.ErrorIs(t, err, websocket.ErrBadHandshake)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("配置的来源允许连接", func(t *testing.T) {
+		_, err := dial(deviceWSAllowedOrigin)
+		require.NoError(t, err)
+	})
+
+	t.Run("同源及非浏览器客户端允许连接", func(t *testing.T) {
+		_, err := dial(server.URL)
+		require.NoError(t, err)
+		_, err = dial("")
+		require.NoError(t, err)
+	})
+}
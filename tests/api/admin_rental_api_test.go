@@ -0,0 +1,405 @@
+//go:build api
+// +build api
+
+// Package api 租借管理 API 测试
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
+	adminHandler "github.com/dumeirei/smart-locker-backend/internal/handler/admin"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	adminService "github.com/dumeirei/smart-locker-backend/internal/service/admin"
+	rentalService "github.com/dumeirei/smart-locker-backend/internal/service/rental"
+)
+
+// setupRentalAPIRouter 创建租借管理测试路由
+func setupRentalAPIRouter(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+
+	require.NoError(t, db.AutoMigrate(
+		&models.Admin{},
+		&models.Role{},
+		&models.User{},
+		&models.Merchant{},
+		&models.Venue{},
+		&models.Device{},
+		&models.DeviceSlot{},
+		&models.Order{},
+		&models.Rental{},
+		&models.Permission{},
+		&models.RolePermission{},
+		&models.OperationLog{},
+	))
+
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret-key-for-rental-api",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: 2 * time.Hour,
+		Issuer:            "test",
+	})
+
+	permissionSvc := adminService.NewPermissionService(
+		repository.NewRoleRepository(db),
+		repository.NewPermissionRepository(db),
+		repository.NewAdminRepository(db),
+	)
+	rentalSvc := rentalService.NewRentalService(db, repository.NewRentalRepository(db), repository.NewDeviceRepository(db), nil, nil, nil, nil, nil)
+	rentalHandler := adminHandler.NewRentalHandler(adminService.NewRentalAdminService(db), rentalSvc, permissionSvc)
+
+	api := r.Group("/api/v1/admin")
+
+	// 模拟认证中间件
+	api.Use(func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		claims, err := jwtManager.ParseToken(token)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		c.Set("user_id", claims.UserID)
+		c.Set("user_type", claims.UserType)
+		c.Set("role", claims.Role)
+		c.Next()
+	})
+
+	rentalHandler.RegisterRoutes(api)
+
+	return r, db, jwtManager
+}
+
+// createRentalAPITestData 创建两个场地下各一台设备，以及使用中、已超时和已完成的租借
+func createRentalAPITestData(t *testing.T, db *gorm.DB) (*models.Venue, *models.Device) {
+	phone := "13900139000"
+	user := &models.User{Phone: &phone, Nickname: "租借API用户", Status: models.UserStatusActive}
+	require.NoError(t, db.Create(user).Error)
+
+	merchant := &models.Merchant{Name: "租借商户", Status: models.MerchantStatusActive}
+	require.NoError(t, db.Create(merchant).Error)
+
+	var venues []*models.Venue
+	var devices []*models.Device
+	for i := 1; i <= 2; i++ {
+		venue := &models.Venue{
+			MerchantID: merchant.ID,
+			Name:       fmt.Sprintf("租借场地%d", i),
+			Type:       "mall",
+			Province:   "广东省",
+			City:       "深圳市",
+			District:   "南山区",
+			Address:    "科技园路1号",
+			Status:     models.VenueStatusActive,
+		}
+		require.NoError(t, db.Create(venue).Error)
+
+		device := &models.Device{
+			DeviceNo:    fmt.Sprintf("RENTAL_API_%03d", i),
+			Name:        "租借设备",
+			Type:        "standard",
+			VenueID:     venue.ID,
+			ProductName: "测试产品",
+			Status:      models.DeviceStatusActive,
+		}
+		require.NoError(t, db.Create(device).Error)
+
+		venues = append(venues, venue)
+		devices = append(devices, device)
+	}
+
+	now := time.Now()
+	rentals := []struct {
+		deviceID    int64
+		status      string
+		unlockedAgo time.Duration
+	}{
+		{devices[0].ID, models.RentalStatusInUse, 20 * time.Minute},
+		{devices[1].ID, models.RentalStatusInUse, 3 * time.Hour},
+		{devices[0].ID, models.RentalStatusCompleted, 5 * time.Hour},
+	}
+	for i, rt := range rentals {
+		unlockedAt := now.Add(-rt.unlockedAgo)
+		expectedReturnAt := unlockedAt.Add(time.Hour)
+		rental := &models.Rental{
+			OrderID:          int64(i + 1),
+			UserID:           user.ID,
+			DeviceID:         rt.deviceID,
+			DurationHours:    1,
+			RentalFee:        10,
+			Deposit:          50,
+			OvertimeRate:     5,
+			Status:           rt.status,
+			UnlockedAt:       &unlockedAt,
+			ExpectedReturnAt: &expectedReturnAt,
+		}
+		if rt.status == models.RentalStatusCompleted {
+			returnedAt := expectedReturnAt
+			rental.ReturnedAt = &returnedAt
+		}
+		require.NoError(t, db.Create(rental).Error)
+	}
+
+	return venues[1], devices[0]
+}
+
+// getRentalList 请求租借列表
+func getRentalList(t *testing.T, router *gin.Engine, token, query string) (int, map[string]interface{}) {
+	req, _ := http.NewRequest("GET", "/api/v1/admin/rentals"+query, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestRentalAPI_List_InUse(t *testing.T) {
+	router, db, jwtManager := setupRentalAPIRouter(t)
+	token := createDeviceAPITestAdmin(t, db, jwtManager, "rental_list_admin")
+	createRentalAPITestData(t, db)
+
+	code, resp := getRentalList(t, router, token, "?status=in_use&page=1&page_size=20")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(0), resp["code"])
+
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, float64(2), data["total"])
+	assert.Equal(t, float64(1), data["page"])
+	assert.Equal(t, float64(20), data["page_size"])
+
+	list := data["list"].([]interface{})
+	require.Len(t, list, 2)
+
+	// 按 ID 倒序，先返回 3 小时前开锁的超时租借
+	overtime := list[0].(map[string]interface{})
+	assert.Equal(t, "RENTAL_API_002", overtime["device_no"])
+	assert.Equal(t, "租借场地2", overtime["venue_name"])
+	assert.Equal(t, "租借商户", overtime["merchant_name"])
+	assert.Equal(t, "13900139000", overtime["user_phone"])
+	assert.Equal(t, true, overtime["is_overtime"])
+	assert.NotEmpty(t, overtime["expected_return_at"])
+	assert.InDelta(t, 180, overtime["used_minutes"], 1)
+
+	inUse := list[1].(map[string]interface{})
+	assert.Equal(t, "RENTAL_API_001", inUse["device_no"])
+	assert.Equal(t, false, inUse["is_overtime"])
+	assert.InDelta(t, 20, inUse["used_minutes"], 1)
+}
+
+func TestRentalAPI_List_Filters(t *testing.T) {
+	router, db, jwtManager := setupRentalAPIRouter(t)
+	token := createDeviceAPITestAdmin(t, db, jwtManager, "rental_filter_admin")
+	venue, device := createRentalAPITestData(t, db)
+
+	code, resp := getRentalList(t, router, token, fmt.Sprintf("?device_id=%d", device.ID))
+	assert.Equal(t, http.StatusOK, code)
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, float64(2), data["total"])
+
+	code, resp = getRentalList(t, router, token, fmt.Sprintf("?venue_id=%d", venue.ID))
+	assert.Equal(t, http.StatusOK, code)
+	data = resp["data"].(map[string]interface{})
+	assert.Equal(t, float64(1), data["total"])
+
+	code, resp = getRentalList(t, router, token, "?page=2&page_size=2")
+	assert.Equal(t, http.StatusOK, code)
+	data = resp["data"].(map[string]interface{})
+	assert.Equal(t, float64(3), data["total"])
+	assert.Len(t, data["list"].([]interface{}), 1)
+}
+
+func TestRentalAPI_List_Unauthorized(t *testing.T) {
+	router, _, _ := setupRentalAPIRouter(t)
+
+	code, _ := getRentalList(t, router, "", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+// grantRentalManagement 为测试管理员的角色授予租借管理权限
+func grantRentalManagement(t *testing.T, db *gorm.DB, username string) {
+	perm := &models.Permission{Code: models.PermissionCodeRentalManagement, Name: "租借管理", Type: models.PermissionTypeAPI}
+	require.NoError(t, db.Where("code = ?", perm.Code).FirstOrCreate(perm).Error)
+
+	var admin models.Admin
+	require.NoError(t, db.Where("username = ?", username).First(&admin).Error)
+	require.NoError(t, db.Create(&models.RolePermission{RoleID: admin.RoleID, PermissionID: perm.ID}).Error)
+}
+
+// createForceCompleteTestRental 创建已超时的使用中租借及其订单
+func createForceCompleteTestRental(t *testing.T, db *gorm.DB, device *models.Device) *models.Rental {
+	order := &models.Order{
+		OrderNo:        fmt.Sprintf("RFC%d", time.Now().UnixNano()),
+		UserID:         1,
+		Type:           models.OrderTypeRental,
+		OriginalAmount: 10,
+		ActualAmount:   10,
+		Status:         models.OrderStatusPaid,
+	}
+	require.NoError(t, db.Create(order).Error)
+
+	unlockedAt := time.Now().Add(-3 * time.Hour)
+	expectedReturnAt := unlockedAt.Add(time.Hour)
+	rental := &models.Rental{
+		OrderID:          order.ID,
+		UserID:           1,
+		DeviceID:         device.ID,
+		DurationHours:    1,
+		RentalFee:        10,
+		Deposit:          50,
+		OvertimeRate:     5,
+		Status:           models.RentalStatusInUse,
+		UnlockedAt:       &unlockedAt,
+		ExpectedReturnAt: &expectedReturnAt,
+	}
+	require.NoError(t, db.Create(rental).Error)
+	return rental
+}
+
+// postForceComplete 请求强制完成租借
+func postForceComplete(t *testing.T, router *gin.Engine, token string, rentalID int64, body string) (int, map[string]interface{}) {
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/admin/rentals/%d/force-complete", rentalID), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestRentalAPI_ForceComplete(t *testing.T) {
+	router, db, jwtManager := setupRentalAPIRouter(t)
+	token := createDeviceAPITestAdmin(t, db, jwtManager, "rental_force_admin")
+	grantRentalManagement(t, db, "rental_force_admin")
+	venue := createDeviceAPITestVenue(t, db)
+	device := createDeviceAPITestDevice(t, db, "RENTAL_FORCE_001", venue.ID)
+	rental := createForceCompleteTestRental(t, db, device)
+
+	code, resp := postForceComplete(t, router, token, rental.ID, `{"waive_overtime_fee": true, "admin_note": "customer lost item"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(0), resp["code"])
+
+	var updated models.Rental
+	require.NoError(t, db.First(&updated, rental.ID).Error)
+	assert.Equal(t, models.RentalStatusCompleted, updated.Status)
+	assert.Equal(t, 0.0, updated.OvertimeFee)
+	assert.NotNil(t, updated.ReturnedAt)
+
+	var order models.Order
+	require.NoError(t, db.First(&order, rental.OrderID).Error)
+	assert.Equal(t, models.OrderStatusCompleted, order.Status)
+
+	var log models.OperationLog
+	require.NoError(t, db.Where("action = ? AND target_id = ?", "force_complete", rental.ID).First(&log).Error)
+	assert.Equal(t, "customer lost item", log.AfterData["admin_note"])
+
+	// 已完成的租借不能再次强制完成
+	code, _ = postForceComplete(t, router, token, rental.ID, `{}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestRentalAPI_ForceComplete_PermissionDenied(t *testing.T) {
+	router, db, jwtManager := setupRentalAPIRouter(t)
+	token := createDeviceAPITestAdmin(t, db, jwtManager, "rental_force_no_perm")
+	venue := createDeviceAPITestVenue(t, db)
+	device := createDeviceAPITestDevice(t, db, "RENTAL_FORCE_001", venue.ID)
+	rental := createForceCompleteTestRental(t, db, device)
+
+	code, _ := postForceComplete(t, router, token, rental.ID, `{"waive_overtime_fee": true}`)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	var unchanged models.Rental
+	require.NoError(t, db.First(&unchanged, rental.ID).Error)
+	assert.Equal(t, models.RentalStatusInUse, unchanged.Status)
+
+	code, _ = postForceComplete(t, router, "", rental.ID, `{}`)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+// patchRentalConfig 请求更新租借配置
+func patchRentalConfig(t *testing.T, router *gin.Engine, token, body string) (int, map[string]interface{}) {
+	req, _ := http.NewRequest("PATCH", "/api/v1/admin/config/rental", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestRentalAPI_UpdateConfig(t *testing.T) {
+	router, db, jwtManager := setupRentalAPIRouter(t)
+	token := createDeviceAPITestAdmin(t, db, jwtManager, "rental_config_admin")
+	grantRentalManagement(t, db, "rental_config_admin")
+
+	code, resp := patchRentalConfig(t, router, token, `{"max_concurrent_rentals": 3}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(0), resp["code"])
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, float64(3), data["max_concurrent_rentals"])
+
+	// 0 表示不限制
+	code, _ = patchRentalConfig(t, router, token, `{"max_concurrent_rentals": 0}`)
+	assert.Equal(t, http.StatusOK, code)
+
+	code, _ = patchRentalConfig(t, router, token, `{"max_concurrent_rentals": -1}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = patchRentalConfig(t, router, token, `{}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestRentalAPI_UpdateConfig_PermissionDenied(t *testing.T) {
+	router, db, jwtManager := setupRentalAPIRouter(t)
+	token := createDeviceAPITestAdmin(t, db, jwtManager, "rental_config_no_perm")
+
+	code, _ := patchRentalConfig(t, router, token, `{"max_concurrent_rentals": 3}`)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, _ = patchRentalConfig(t, router, "", `{"max_concurrent_rentals": 3}`)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
@@ -0,0 +1,271 @@
+//go:build api
+// +build api
+
+// Package api 角色权限 API 测试
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
+	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	adminHandler "github.com/dumeirei/smart-locker-backend/internal/handler/admin"
+	"github.com/dumeirei/smart-locker-backend/internal/middleware"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	adminService "github.com/dumeirei/smart-locker-backend/internal/service/admin"
+)
+
+// setupRolePermissionAPIRouter 创建角色权限测试路由
+// 提现审核、结算审批接口与网关一致地挂载权限中间件，处理器仅返回成功，用于验证权限拦截；
+// 订单退款接口使用真实处理器（请求体为空时在调用服务前返回 400）
+func setupRolePermissionAPIRouter(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+
+	require.NoError(t, db.AutoMigrate(
+		&models.Admin{},
+		&models.Role{},
+		&models.Permission{},
+		&models.RolePermission{},
+	))
+
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret-key-for-role-permission-api",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: 2 * time.Hour,
+		Issuer:            "test",
+	})
+
+	permissionSvc := adminService.NewPermissionService(
+		repository.NewRoleRepository(db),
+		repository.NewPermissionRepository(db),
+		repository.NewAdminRepository(db),
+	)
+
+	api := r.Group("/api/admin")
+
+	// 模拟认证中间件
+	api.Use(func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		claims, err := jwtManager.ParseToken(token)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		c.Set("user_id", claims.UserID)
+		c.Set("user_type", claims.UserType)
+		c.Set("role", claims.Role)
+		c.Next()
+	})
+
+	adminHandler.NewRoleHandler(permissionSvc).RegisterRoutes(api)
+	api.POST("/finance/withdrawals/:id/handle",
+		middleware.RequirePermission(permissionSvc, models.PermissionCodeWithdrawalApprove),
+		func(c *gin.Context) { response.Success(c, nil) })
+	requireSettlementProcess := middleware.RequirePermission(permissionSvc, models.PermissionCodeSettlementProcess)
+	api.POST("/finance/settlements/:id/approve", requireSettlementProcess, func(c *gin.Context) { response.Success(c, nil) })
+	api.POST("/finance/settlements/:id/reject", requireSettlementProcess, func(c *gin.Context) { response.Success(c, nil) })
+	adminHandler.NewOrderRefundHandler(nil, permissionSvc).RegisterRoutes(api)
+
+	return r, db, jwtManager
+}
+
+// createRolePermissionTestAdmin 创建指定角色的管理员并返回访问令牌
+func createRolePermissionTestAdmin(t *testing.T, db *gorm.DB, jwtManager *jwt.Manager, roleCode, username string) (*models.Role, string) {
+	role := &models.Role{Code: roleCode, Name: roleCode, IsSystem: true}
+	require.NoError(t, db.Create(role).Error)
+
+	admin := &models.Admin{
+		Username:     username,
+		PasswordHash: "hash",
+		Name:         username,
+		RoleID:       role.ID,
+		Status:       models.AdminStatusActive,
+	}
+	require.NoError(t, db.Create(admin).Error)
+
+	tokenPair, err := jwtManager.GenerateTokenPair(admin.ID, jwt.UserTypeAdmin, role.Code)
+	require.NoError(t, err)
+	return role, tokenPair.AccessToken
+}
+
+// doRolePermissionRequest 发送角色权限测试请求
+func doRolePermissionRequest(t *testing.T, router *gin.Engine, method, path, token, body string) (int, map[string]interface{}) {
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestRolePermissionAPI_WithdrawalApprove(t *testing.T) {
+	router, db, jwtManager := setupRolePermissionAPIRouter(t)
+	financeRole, financeToken := createRolePermissionTestAdmin(t, db, jwtManager, models.RoleCodeFinanceAdmin, "finance_admin")
+	_, superToken := createRolePermissionTestAdmin(t, db, jwtManager, models.RoleCodeSuperAdmin, "super_admin")
+
+	approvePerm := &models.Permission{Code: models.PermissionCodeWithdrawalApprove, Name: "提现审核", Type: models.PermissionTypeAPI}
+	require.NoError(t, db.Create(approvePerm).Error)
+
+	handlePath := "/api/admin/finance/withdrawals/1/handle"
+	rolePermissionsPath := fmt.Sprintf("/api/admin/roles/%d/permissions", financeRole.ID)
+
+	t.Run("未授权的角色返回403及缺少的权限", func(t *testing.T) {
+		code, resp := doRolePermissionRequest(t, router, "POST", handlePath, financeToken, `{}`)
+		assert.Equal(t, http.StatusForbidden, code)
+		assert.Equal(t, float64(http.StatusForbidden), resp["code"])
+
+		data, ok := resp["data"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, models.RoleCodeFinanceAdmin, data["role"])
+		assert.Equal(t, []interface{}{models.PermissionCodeWithdrawalApprove}, data["required_permissions"])
+
+		code, _ = doRolePermissionRequest(t, router, "POST", handlePath, "", `{}`)
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("超级管理员不受权限限制", func(t *testing.T) {
+		code, _ := doRolePermissionRequest(t, router, "POST", handlePath, superToken, `{}`)
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("角色权限管理接口仅限有权限的管理员", func(t *testing.T) {
+		code, _ := doRolePermissionRequest(t, router, "GET", "/api/admin/roles", financeToken, "")
+		assert.Equal(t, http.StatusForbidden, code)
+		code, _ = doRolePermissionRequest(t, router, "PUT", rolePermissionsPath, financeToken,
+			fmt.Sprintf(`{"permission_ids": [%d]}`, approvePerm.ID))
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("分配权限后立即生效", func(t *testing.T) {
+		code, resp := doRolePermissionRequest(t, router, "PUT", rolePermissionsPath, superToken,
+			fmt.Sprintf(`{"permission_ids": [%d]}`, approvePerm.ID))
+		require.Equal(t, http.StatusOK, code)
+		data := resp["data"].(map[string]interface{})
+		permissions := data["permissions"].([]interface{})
+		require.Len(t, permissions, 1)
+		assert.Equal(t, models.PermissionCodeWithdrawalApprove, permissions[0].(map[string]interface{})["code"])
+
+		code, _ = doRolePermissionRequest(t, router, "POST", handlePath, financeToken, `{}`)
+		assert.Equal(t, http.StatusOK, code)
+
+		code, resp = doRolePermissionRequest(t, router, "GET", "/api/admin/roles", superToken, "")
+		require.Equal(t, http.StatusOK, code)
+		var financePermissions []interface{}
+		for _, item := range resp["data"].([]interface{}) {
+			role := item.(map[string]interface{})
+			if role["code"] == models.RoleCodeFinanceAdmin {
+				financePermissions = role["permissions"].([]interface{})
+			}
+		}
+		assert.Equal(t, []interface{}{models.PermissionCodeWithdrawalApprove}, financePermissions)
+	})
+
+	t.Run("收回权限后立即生效", func(t *testing.T) {
+		code, _ := doRolePermissionRequest(t, router, "PUT", rolePermissionsPath, superToken, `{"permission_ids": []}`)
+		require.Equal(t, http.StatusOK, code)
+
+		code, _ = doRolePermissionRequest(t, router, "POST", handlePath, financeToken, `{}`)
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("分配不存在的权限或角色", func(t *testing.T) {
+		code, _ := doRolePermissionRequest(t, router, "PUT", rolePermissionsPath, superToken, `{"permission_ids": [99999]}`)
+		assert.Equal(t, http.StatusNotFound, code)
+		code, _ = doRolePermissionRequest(t, router, "PUT", "/api/admin/roles/99999/permissions", superToken,
+			fmt.Sprintf(`{"permission_ids": [%d]}`, approvePerm.ID))
+		assert.Equal(t, http.StatusNotFound, code)
+		code, _ = doRolePermissionRequest(t, router, "PUT", rolePermissionsPath, superToken, `{}`)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("获取权限列表", func(t *testing.T) {
+		code, resp := doRolePermissionRequest(t, router, "GET", "/api/admin/permissions?type=api", superToken, "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Len(t, resp["data"].([]interface{}), 1)
+	})
+}
+
+func TestRolePermissionAPI_SettlementReviewAndOrderRefund(t *testing.T) {
+	router, db, jwtManager := setupRolePermissionAPIRouter(t)
+	financeRole, financeToken := createRolePermissionTestAdmin(t, db, jwtManager, models.RoleCodeFinanceAdmin, "finance_admin")
+	_, superToken := createRolePermissionTestAdmin(t, db, jwtManager, models.RoleCodeSuperAdmin, "super_admin")
+
+	settlementPerm := &models.Permission{Code: models.PermissionCodeSettlementProcess, Name: "结算处理", Type: models.PermissionTypeAPI}
+	refundPerm := &models.Permission{Code: models.PermissionCodeOrderRefund, Name: "订单退款", Type: models.PermissionTypeAPI}
+	require.NoError(t, db.Create(settlementPerm).Error)
+	require.NoError(t, db.Create(refundPerm).Error)
+
+	settlementPaths := []string{"/api/admin/finance/settlements/1/approve", "/api/admin/finance/settlements/1/reject"}
+	refundPath := "/api/admin/orders/1/refund"
+
+	t.Run("未授权的角色不能审批结算或发起退款", func(t *testing.T) {
+		for _, path := range settlementPaths {
+			code, resp := doRolePermissionRequest(t, router, "POST", path, financeToken, `{}`)
+			assert.Equal(t, http.StatusForbidden, code, path)
+			data := resp["data"].(map[string]interface{})
+			assert.Equal(t, []interface{}{models.PermissionCodeSettlementProcess}, data["required_permissions"])
+		}
+
+		code, resp := doRolePermissionRequest(t, router, "POST", refundPath, financeToken, `{}`)
+		assert.Equal(t, http.StatusForbidden, code)
+		data := resp["data"].(map[string]interface{})
+		assert.Equal(t, []interface{}{models.PermissionCodeOrderRefund}, data["required_permissions"])
+	})
+
+	t.Run("授权后放行", func(t *testing.T) {
+		code, _ := doRolePermissionRequest(t, router, "PUT", fmt.Sprintf("/api/admin/roles/%d/permissions", financeRole.ID), superToken,
+			fmt.Sprintf(`{"permission_ids": [%d, %d]}`, settlementPerm.ID, refundPerm.ID))
+		require.Equal(t, http.StatusOK, code)
+
+		for _, path := range settlementPaths {
+			code, _ := doRolePermissionRequest(t, router, "POST", path, financeToken, `{}`)
+			assert.Equal(t, http.StatusOK, code, path)
+		}
+		// 通过权限校验后由处理器校验请求体
+		code, _ = doRolePermissionRequest(t, router, "POST", refundPath, financeToken, `{}`)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("超级管理员不受权限限制", func(t *testing.T) {
+		code, _ := doRolePermissionRequest(t, router, "POST", refundPath, superToken, `{}`)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
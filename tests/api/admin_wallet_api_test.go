@@ -0,0 +1,203 @@
+//go:build api
+// +build api
+
+// Package api 用户钱包管理 API 测试
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
+	adminHandler "github.com/dumeirei/smart-locker-backend/internal/handler/admin"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	adminService "github.com/dumeirei/smart-locker-backend/internal/service/admin"
+	userService "github.com/dumeirei/smart-locker-backend/internal/service/user"
+)
+
+// setupWalletAdminAPIRouter 创建用户钱包管理测试路由
+func setupWalletAdminAPIRouter(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+
+	require.NoError(t, db.AutoMigrate(
+		&models.Admin{},
+		&models.Role{},
+		&models.Permission{},
+		&models.RolePermission{},
+		&models.User{},
+		&models.UserWallet{},
+		&models.WalletTransaction{},
+	))
+
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret-key-for-wallet-api",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: 2 * time.Hour,
+		Issuer:            "test",
+	})
+
+	permissionSvc := adminService.NewPermissionService(
+		repository.NewRoleRepository(db),
+		repository.NewPermissionRepository(db),
+		repository.NewAdminRepository(db),
+	)
+	userRepo := repository.NewUserRepository(db)
+	walletSvc := userService.NewWalletService(db, userRepo, nil)
+	walletHandler := adminHandler.NewWalletHandler(adminService.NewWalletAdminService(userRepo, walletSvc), permissionSvc)
+
+	api := r.Group("/api/v1/admin")
+
+	// 模拟认证中间件
+	api.Use(func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		claims, err := jwtManager.ParseToken(token)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		c.Set("user_id", claims.UserID)
+		c.Set("user_type", claims.UserType)
+		c.Set("role", claims.Role)
+		c.Next()
+	})
+
+	walletHandler.RegisterRoutes(api)
+
+	return r, db, jwtManager
+}
+
+// grantWalletAdjustment 为测试管理员的角色授予钱包调整权限
+func grantWalletAdjustment(t *testing.T, db *gorm.DB, username string) {
+	perm := &models.Permission{Code: models.PermissionCodeWalletAdjustment, Name: "钱包余额调整", Type: models.PermissionTypeAPI}
+	require.NoError(t, db.Where("code = ?", perm.Code).FirstOrCreate(perm).Error)
+
+	var admin models.Admin
+	require.NoError(t, db.Where("username = ?", username).First(&admin).Error)
+	require.NoError(t, db.Create(&models.RolePermission{RoleID: admin.RoleID, PermissionID: perm.ID}).Error)
+}
+
+// createWalletAPITestUser 创建带钱包的测试用户
+func createWalletAPITestUser(t *testing.T, db *gorm.DB, balance float64) *models.User {
+	phone := "13900139100"
+	user := &models.User{Phone: &phone, Nickname: "钱包API用户", Status: models.UserStatusActive}
+	require.NoError(t, db.Create(user).Error)
+	require.NoError(t, db.Create(&models.UserWallet{UserID: user.ID, Balance: balance}).Error)
+	return user
+}
+
+// doWalletAdminRequest 请求用户钱包管理接口
+func doWalletAdminRequest(t *testing.T, router *gin.Engine, method, path, token, body string) (int, map[string]interface{}) {
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestWalletAdminAPI_AdjustAndListTransactions(t *testing.T) {
+	router, db, jwtManager := setupWalletAdminAPIRouter(t)
+	token := createDeviceAPITestAdmin(t, db, jwtManager, "wallet_adjust_admin")
+	grantWalletAdjustment(t, db, "wallet_adjust_admin")
+	user := createWalletAPITestUser(t, db, 30)
+
+	var admin models.Admin
+	require.NoError(t, db.Where("username = ?", "wallet_adjust_admin").First(&admin).Error)
+
+	adjustPath := fmt.Sprintf("/api/v1/admin/users/%d/wallet/adjust", user.ID)
+	code, resp := doWalletAdminRequest(t, router, "POST", adjustPath, token, `{"amount": 12.5, "direction": "increase", "reason": "设备故障补偿"}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(0), resp["code"])
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, models.WalletTxTypeAdjustment, data["type"])
+	assert.Equal(t, 42.5, data["balance_after"])
+
+	// 扣减后余额为负被拒绝
+	code, _ = doWalletAdminRequest(t, router, "POST", adjustPath, token, `{"amount": 50, "direction": "decrease", "reason": "误操作扣回"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 缺少原因
+	code, _ = doWalletAdminRequest(t, router, "POST", adjustPath, token, `{"amount": 1, "direction": "decrease"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 冻结余额为 0 时扣减冻结余额被拒绝，可用余额不受影响
+	code, _ = doWalletAdminRequest(t, router, "POST", adjustPath, token, `{"amount": 1, "direction": "decrease", "reason": "押金异常", "target": "frozen_balance"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 无效的调整对象
+	code, _ = doWalletAdminRequest(t, router, "POST", adjustPath, token, `{"amount": 1, "direction": "increase", "reason": "补偿", "target": "points"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	var wallet models.UserWallet
+	require.NoError(t, db.Where("user_id = ?", user.ID).First(&wallet).Error)
+	assert.Equal(t, 42.5, wallet.Balance)
+
+	code, resp = doWalletAdminRequest(t, router, "GET", fmt.Sprintf("/api/v1/admin/users/%d/wallet/transactions", user.ID), token, "")
+	require.Equal(t, http.StatusOK, code)
+	page := resp["data"].(map[string]interface{})
+	assert.Equal(t, float64(1), page["total"])
+	list := page["list"].([]interface{})
+	require.Len(t, list, 1)
+	record := list[0].(map[string]interface{})
+	assert.Equal(t, float64(admin.ID), record["operator_id"])
+	assert.Equal(t, "设备故障补偿", record["remark"])
+	assert.Equal(t, 30.0, record["balance_before"])
+}
+
+func TestWalletAdminAPI_Adjust_PermissionDenied(t *testing.T) {
+	router, db, jwtManager := setupWalletAdminAPIRouter(t)
+	token := createDeviceAPITestAdmin(t, db, jwtManager, "wallet_adjust_no_perm")
+	user := createWalletAPITestUser(t, db, 30)
+
+	adjustPath := fmt.Sprintf("/api/v1/admin/users/%d/wallet/adjust", user.ID)
+	code, _ := doWalletAdminRequest(t, router, "POST", adjustPath, token, `{"amount": 10, "direction": "increase", "reason": "补偿"}`)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, _ = doWalletAdminRequest(t, router, "POST", adjustPath, "", `{"amount": 10, "direction": "increase", "reason": "补偿"}`)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	var wallet models.UserWallet
+	require.NoError(t, db.Where("user_id = ?", user.ID).First(&wallet).Error)
+	assert.Equal(t, 30.0, wallet.Balance)
+
+	// 查看流水无需调整权限
+	code, _ = doWalletAdminRequest(t, router, "GET", fmt.Sprintf("/api/v1/admin/users/%d/wallet/transactions", user.ID), token, "")
+	assert.Equal(t, http.StatusOK, code)
+}
@@ -0,0 +1,183 @@
+//go:build api
+// +build api
+
+// Package api 设备心跳 API 测试
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	deviceHandler "github.com/dumeirei/smart-locker-backend/internal/handler/device"
+	"github.com/dumeirei/smart-locker-backend/internal/middleware"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	deviceService "github.com/dumeirei/smart-locker-backend/internal/service/device"
+)
+
+const (
+	heartbeatAPITestInterval = 10 * time.Second
+	heartbeatAPITestSecret   = "test-device-secret"
+)
+
+// setupDeviceHeartbeatAPIRouter 创建设备心跳测试路由和离线检测任务
+func setupDeviceHeartbeatAPIRouter(t *testing.T) (*gin.Engine, *gorm.DB, *deviceService.OfflineDetectionJob) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	db := setupDeviceAPITestDB(t)
+	deviceRepo := repository.NewDeviceRepository(db)
+	venueRepo := repository.NewVenueRepository(db)
+	deviceSvc := deviceService.NewDeviceService(db, deviceRepo, venueRepo)
+	venueSvc := deviceService.NewVenueService(db, venueRepo, deviceRepo)
+	deviceH := deviceHandler.NewHandler(deviceSvc, venueSvc)
+
+	device := r.Group("/api/v1/device")
+	device.Use(middleware.DeviceAuth(heartbeatAPITestSecret, middleware.DefaultDeviceAuthMaxSkew))
+	device.POST("/heartbeat", deviceH.Heartbeat)
+
+	job := deviceService.NewOfflineDetectionJob(deviceSvc, deviceRepo, heartbeatAPITestInterval, deviceService.DefaultOfflineDetectionPoll, nil)
+	return r, db, job
+}
+
+// postDeviceHeartbeat 以请求体中的设备编号签名上报心跳
+func postDeviceHeartbeat(router *gin.Engine, body map[string]interface{}) *httptest.ResponseRecorder {
+	deviceNo, _ := body["device_no"].(string)
+	if deviceNo == "" {
+		deviceNo = "DEV_UNKNOWN"
+	}
+	return postSignedDeviceHeartbeat(router, deviceNo, middleware.DeriveDeviceKey(heartbeatAPITestSecret, deviceNo), time.Now(), body)
+}
+
+// postSignedDeviceHeartbeat 以指定设备编号、签名密钥和时间戳上报心跳
+func postSignedDeviceHeartbeat(router *gin.Engine, deviceNo, deviceKey string, at time.Time, body map[string]interface{}) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", "/api/v1/device/heartbeat", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	req.Header.Set(middleware.DeviceNoHeader, deviceNo)
+	req.Header.Set(middleware.DeviceTimestampHeader, timestamp)
+	req.Header.Set(middleware.DeviceSignatureHeader, middleware.SignDeviceRequest(deviceKey, "POST", "/api/v1/device/heartbeat", timestamp, jsonBody))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestDeviceHeartbeatAPI_HeartbeatThenSilence_GoesOffline(t *testing.T) {
+	router, db, job := setupDeviceHeartbeatAPIRouter(t)
+	venue := createDeviceAPITestVenue(t, db)
+	device := createDeviceAPITestDevice(t, db, "DEV_HB_001", venue.ID)
+	require.NoError(t, db.Model(device).Update("online_status", models.DeviceOffline).Error)
+
+	for i := 0; i < 3; i++ {
+		w := postDeviceHeartbeat(router, map[string]interface{}{
+			"device_no":        device.DeviceNo,
+			"signal_strength":  -60,
+			"battery_level":    90 - i,
+			"temperature":      26.5,
+			"humidity":         55.0,
+			"firmware_version": "1.2.0",
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, float64(0), resp["code"])
+	}
+
+	var updated models.Device
+	require.NoError(t, db.First(&updated, device.ID).Error)
+	assert.Equal(t, int8(models.DeviceOnline), updated.OnlineStatus)
+	require.NotNil(t, updated.LastHeartbeatAt)
+	require.NotNil(t, updated.BatteryLevel)
+	assert.Equal(t, 88, *updated.BatteryLevel)
+	require.NotNil(t, updated.FirmwareVersion)
+	assert.Equal(t, "1.2.0", *updated.FirmwareVersion)
+
+	ctx := context.Background()
+	lastHeartbeat := *updated.LastHeartbeatAt
+
+	// 静默 2 个心跳间隔，仍在线
+	offline, err := job.RunOnce(ctx, lastHeartbeat.Add(2*heartbeatAPITestInterval))
+	require.NoError(t, err)
+	assert.Equal(t, 0, offline)
+	require.NoError(t, db.First(&updated, device.ID).Error)
+	assert.Equal(t, int8(models.DeviceOnline), updated.OnlineStatus)
+
+	// 静默超过 3 个心跳间隔，离线
+	offline, err = job.RunOnce(ctx, lastHeartbeat.Add(3*heartbeatAPITestInterval+time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, offline)
+	require.NoError(t, db.First(&updated, device.ID).Error)
+	assert.Equal(t, int8(models.DeviceOffline), updated.OnlineStatus)
+}
+
+func TestDeviceHeartbeatAPI_DeviceNotFound(t *testing.T) {
+	router, _, _ := setupDeviceHeartbeatAPIRouter(t)
+
+	w := postDeviceHeartbeat(router, map[string]interface{}{"device_no": "DEV_NOT_EXIST"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDeviceHeartbeatAPI_MissingDeviceNo(t *testing.T) {
+	router, _, _ := setupDeviceHeartbeatAPIRouter(t)
+
+	w := postDeviceHeartbeat(router, map[string]interface{}{"battery_level": 80})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDeviceHeartbeatAPI_RequiresDeviceSignature(t *testing.T) {
+	router, db, _ := setupDeviceHeartbeatAPIRouter(t)
+	venue := createDeviceAPITestVenue(t, db)
+	device := createDeviceAPITestDevice(t, db, "DEV_HB_AUTH", venue.ID)
+	other := createDeviceAPITestDevice(t, db, "DEV_HB_OTHER", venue.ID)
+	body := map[string]interface{}{"device_no": device.DeviceNo}
+	key := middleware.DeriveDeviceKey(heartbeatAPITestSecret, device.DeviceNo)
+
+	t.Run("未签名", func(t *testing.T) {
+		jsonBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/api/v1/device/heartbeat", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("签名密钥错误", func(t *testing.T) {
+		w := postSignedDeviceHeartbeat(router, device.DeviceNo, middleware.DeriveDeviceKey("wrong-secret", device.DeviceNo), time.Now(), body)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("时间戳过期", func(t *testing.T) {
+		w := postSignedDeviceHeartbeat(router, device.DeviceNo, key, time.Now().Add(-time.Hour), body)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("不能代其他设备上报心跳", func(t *testing.T) {
+		w := postSignedDeviceHeartbeat(router, device.DeviceNo, key, time.Now(), map[string]interface{}{"device_no": other.DeviceNo})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	for _, id := range []int64{device.ID, other.ID} {
+		var unchanged models.Device
+		require.NoError(t, db.First(&unchanged, id).Error)
+		assert.Nil(t, unchanged.LastHeartbeatAt)
+	}
+
+	t.Run("签名正确", func(t *testing.T) {
+		w := postSignedDeviceHeartbeat(router, device.DeviceNo, key, time.Now(), body)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -0,0 +1,140 @@
+//go:build api
+
+// Package api 财务加密导出与脱敏 API 测试
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
+	adminHandler "github.com/dumeirei/smart-locker-backend/internal/handler/admin"
+	"github.com/dumeirei/smart-locker-backend/internal/middleware"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	financeService "github.com/dumeirei/smart-locker-backend/internal/service/finance"
+)
+
+// exportPermissionChecker 按角色授予权限的测试权限检查器
+type exportPermissionChecker map[string][]string
+
+func (c exportPermissionChecker) HasPermission(roleCode, permissionCode string) bool {
+	for _, code := range c[roleCode] {
+		if code == permissionCode {
+			return true
+		}
+	}
+	return false
+}
+
+func (c exportPermissionChecker) HasAnyPermission(roleCode string, permissionCodes []string) bool {
+	for _, code := range permissionCodes {
+		if c.HasPermission(roleCode, code) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c exportPermissionChecker) HasAllPermissions(roleCode string, permissionCodes []string) bool {
+	for _, code := range permissionCodes {
+		if !c.HasPermission(roleCode, code) {
+			return false
+		}
+	}
+	return true
+}
+
+// setupFinanceExportAPITestRouter 创建财务导出测试路由，finance_admin 拥有完整导出权限
+func setupFinanceExportAPITestRouter(db *gorm.DB, jwtManager *jwt.Manager) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	settlementRepo := repository.NewSettlementRepository(db)
+	exportSvc := financeService.NewExportService(db, settlementRepo, repository.NewTransactionRepository(db),
+		repository.NewOrderRepository(db), repository.NewWithdrawalRepository(db))
+	financeH := adminHandler.NewFinanceHandler(nil, nil, nil, exportSvc)
+	financeH.SetPermissionChecker(exportPermissionChecker{
+		models.RoleCodeFinanceAdmin: {models.PermissionCodeFinanceExportFull},
+	})
+
+	finance := r.Group("/api/admin/finance")
+	finance.Use(middleware.AdminAuth(jwtManager))
+	finance.GET("/export/settlements", financeH.ExportSettlements)
+	finance.GET("/export/withdrawals", financeH.ExportWithdrawals)
+	return r
+}
+
+// TestFinanceAPI_ExportProtected 测试导出默认脱敏、有权限导出完整信息及加密导出
+func TestFinanceAPI_ExportProtected(t *testing.T) {
+	db := setupFinanceAPITestDB(t)
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret-key-for-finance-export",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: time.Hour * 24,
+		Issuer:            "test",
+	})
+	router := setupFinanceExportAPITestRouter(db, jwtManager)
+
+	user := createFinanceTestUser(t, db)
+	withdrawal := createFinanceTestWithdrawal(t, db, user.ID)
+	require.NoError(t, db.Model(withdrawal).Update("account_info_encrypted", `{"account":"6222020200112233"}`).Error)
+
+	operatorToken, _, err := jwtManager.GenerateAccessToken(1, jwt.UserTypeAdmin, models.RoleCodeOperationAdmin)
+	require.NoError(t, err)
+	financeToken, _, err := jwtManager.GenerateAccessToken(2, jwt.UserTypeAdmin, models.RoleCodeFinanceAdmin)
+	require.NoError(t, err)
+
+	get := func(url, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", url, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("无权限时收款账户脱敏", func(t *testing.T) {
+		w := get("/api/admin/finance/export/withdrawals", operatorToken)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "****2233")
+		assert.NotContains(t, w.Body.String(), "6222020200112233")
+	})
+
+	t.Run("有权限时导出完整收款账户", func(t *testing.T) {
+		w := get("/api/admin/finance/export/withdrawals", financeToken)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "6222020200112233")
+	})
+
+	t.Run("设置密码时导出加密XLSX", func(t *testing.T) {
+		for _, url := range []string{
+			"/api/admin/finance/export/withdrawals?password=secret-pass",
+			"/api/admin/finance/export/settlements?password=secret-pass",
+		} {
+			w := get(url, financeToken)
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Header().Get("Content-Type"), "spreadsheetml")
+			assert.Contains(t, w.Header().Get("Content-Disposition"), ".xlsx")
+
+			_, err := excelize.OpenReader(bytes.NewReader(w.Body.Bytes()))
+			assert.Error(t, err)
+			f, err := excelize.OpenReader(bytes.NewReader(w.Body.Bytes()), excelize.Options{Password: "secret-pass"})
+			require.NoError(t, err)
+			f.Close()
+		}
+	})
+
+	t.Run("CSV不支持加密", func(t *testing.T) {
+		w := get("/api/admin/finance/export/withdrawals?format=csv&password=secret-pass", financeToken)
+		assert.NotEqual(t, http.StatusOK, w.Code)
+	})
+}
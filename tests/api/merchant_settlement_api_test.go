@@ -0,0 +1,162 @@
+//go:build api
+
+// Package api 商户门户结算单 API 测试
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
+	merchantHandler "github.com/dumeirei/smart-locker-backend/internal/handler/merchant"
+	"github.com/dumeirei/smart-locker-backend/internal/middleware"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	financeService "github.com/dumeirei/smart-locker-backend/internal/service/finance"
+	merchantService "github.com/dumeirei/smart-locker-backend/internal/service/merchant"
+)
+
+// setupMerchantSettlementAPITestRouter 创建商户门户结算单测试路由
+func setupMerchantSettlementAPITestRouter(db *gorm.DB, jwtManager *jwt.Manager) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	settlementH := merchantHandler.NewSettlementHandler(financeService.NewMerchantSettlementService(repository.NewSettlementRepository(db)))
+	authH := merchantHandler.NewAuthHandler(merchantService.NewMerchantPortalAuthService(
+		repository.NewMerchantApplicationRepository(db), repository.NewMerchantRepository(db), jwtManager))
+
+	user := r.Group("/api/v1")
+	user.Use(middleware.UserAuth(jwtManager))
+	authH.RegisterRoutes(user)
+
+	merchantPortal := r.Group("/api/merchant")
+	merchantPortal.Use(middleware.MerchantAuth(jwtManager))
+	settlementH.RegisterRoutes(merchantPortal)
+
+	return r
+}
+
+// TestMerchantSettlementAPI_ListOwnSettlements 测试商户只能查询和下载自己的结算单
+func TestMerchantSettlementAPI_ListOwnSettlements(t *testing.T) {
+	db := setupFinanceAPITestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.MerchantApplication{}))
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret-key-for-merchant-api",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: time.Hour * 24,
+		Issuer:            "test",
+	})
+	router := setupMerchantSettlementAPITestRouter(db, jwtManager)
+
+	merchantA := createFinanceTestMerchant(t, db)
+	merchantB := createFinanceTestMerchant(t, db)
+	own := createFinanceTestSettlement(t, db, merchantA.ID)
+	other := createFinanceTestSettlement(t, db, merchantB.ID)
+	require.NoError(t, db.Create(&models.SettlementItem{
+		SettlementID:   own.ID,
+		OrderID:        1,
+		OrderNo:        "ORD_OWN_1",
+		CompletedAt:    time.Now(),
+		Amount:         100,
+		CommissionRate: 0.1,
+		MerchantShare:  90,
+	}).Error)
+
+	do := func(method, url, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	get := func(url, token string) *httptest.ResponseRecorder {
+		return do("GET", url, token)
+	}
+
+	// 入驻申请通过的用户登录商户门户换取商户令牌
+	owner := createFinanceTestUser(t, db)
+	require.NoError(t, db.Create(&models.MerchantApplication{
+		UserID:             owner.ID,
+		MerchantName:       merchantA.Name,
+		ContactName:        merchantA.ContactName,
+		ContactPhone:       merchantA.ContactPhone,
+		BusinessLicenseURL: "https://oss.example.com/license.jpg",
+		Status:             models.MerchantApplicationStatusApproved,
+		MerchantID:         &merchantA.ID,
+	}).Error)
+	ownerToken, _, err := jwtManager.GenerateAccessToken(owner.ID, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+
+	w := do("POST", "/api/v1/merchant/login", ownerToken)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var loginResp struct {
+		Data jwt.TokenPair `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &loginResp))
+	merchantToken := loginResp.Data.AccessToken
+	require.NotEmpty(t, merchantToken)
+
+	t.Run("未入驻的用户不能登录商户门户", func(t *testing.T) {
+		stranger := createFinanceTestUser(t, db)
+		strangerToken, _, err := jwtManager.GenerateAccessToken(stranger.ID, jwt.UserTypeUser, "")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, do("POST", "/api/v1/merchant/login", strangerToken).Code)
+	})
+
+	t.Run("只返回自己的结算单", func(t *testing.T) {
+		w := get("/api/merchant/settlements?target_id="+strconv.FormatInt(merchantB.ID, 10), merchantToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Code int `json:"code"`
+			Data struct {
+				List  []*models.Settlement `json:"list"`
+				Total int64                `json:"total"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, 0, resp.Code)
+		assert.Equal(t, int64(1), resp.Data.Total)
+		require.Len(t, resp.Data.List, 1)
+		assert.Equal(t, own.ID, resp.Data.List[0].ID)
+	})
+
+	t.Run("下载CSV包含订单明细且不含其他商户数据", func(t *testing.T) {
+		w := get("/api/merchant/settlements?format=csv", merchantToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+
+		body := w.Body.String()
+		assert.Contains(t, body, own.SettlementNo)
+		assert.Contains(t, body, "ORD_OWN_1")
+		assert.NotContains(t, body, other.SettlementNo)
+	})
+
+	t.Run("不支持的下载格式", func(t *testing.T) {
+		w := get("/api/merchant/settlements?format=pdf", merchantToken)
+		assert.NotEqual(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("用户和管理员令牌无权访问", func(t *testing.T) {
+		userToken, _, err := jwtManager.GenerateAccessToken(merchantA.ID, jwt.UserTypeUser, "")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, get("/api/merchant/settlements", userToken).Code)
+		assert.Equal(t, http.StatusForbidden, get("/api/merchant/settlements", generateAdminTestToken(jwtManager, 1)).Code)
+	})
+
+	t.Run("未登录", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, get("/api/merchant/settlements", "").Code)
+	})
+}
@@ -0,0 +1,179 @@
+//go:build api
+// +build api
+
+// Package api 微信支付通知 API 测试
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	paymentHandler "github.com/dumeirei/smart-locker-backend/internal/handler/payment"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	hotelService "github.com/dumeirei/smart-locker-backend/internal/service/hotel"
+	paymentService "github.com/dumeirei/smart-locker-backend/internal/service/payment"
+	"github.com/dumeirei/smart-locker-backend/pkg/wechatpay"
+)
+
+// setupPaymentNotifyAPI 创建使用模拟微信支付平台的通知路由
+func setupPaymentNotifyAPI(t *testing.T) (*gin.Engine, *gorm.DB, *wechatpay.FakePlatform) {
+	gin.SetMode(gin.TestMode)
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Order{}, &models.Payment{}, &models.Booking{}, &models.RoomOccupancy{}))
+
+	platform, err := wechatpay.NewFakePlatform()
+	require.NoError(t, err)
+
+	bookingSvc := hotelService.NewBookingService(db, repository.NewBookingRepository(db), nil, nil, nil, nil, nil, nil, nil)
+	callbackSvc := paymentService.NewPaymentCallbackService(db, platform.Verifier(),
+		map[string]paymentService.PaymentSuccessHandler{
+			models.OrderTypeHotel: bookingSvc,
+		}, nil)
+
+	r := gin.New()
+	paymentHandler.NewNotifyHandler(callbackSvc).RegisterRoutes(r.Group("/api/v1"))
+
+	return r, db, platform
+}
+
+// createPaymentNotifyTestBooking 创建待支付的酒店预订及微信支付单
+func createPaymentNotifyTestBooking(t *testing.T, db *gorm.DB) (*models.Booking, *models.Payment) {
+	user := &models.User{Nickname: "通知用户", Status: models.UserStatusActive}
+	require.NoError(t, db.Create(user).Error)
+
+	order := &models.Order{
+		OrderNo:        "O_NOTIFY_001",
+		UserID:         user.ID,
+		Type:           models.OrderTypeHotel,
+		OriginalAmount: 88,
+		ActualAmount:   88,
+		Status:         models.OrderStatusPending,
+	}
+	require.NoError(t, db.Create(order).Error)
+
+	checkIn := time.Now().Add(24 * time.Hour)
+	booking := &models.Booking{
+		BookingNo:        "B_NOTIFY_001",
+		OrderID:          order.ID,
+		UserID:           user.ID,
+		HotelID:          1,
+		RoomID:           1,
+		CheckInTime:      checkIn,
+		CheckOutTime:     checkIn.Add(2 * time.Hour),
+		DurationHours:    2,
+		Amount:           88,
+		VerificationCode: "V_NOTIFY_001",
+		UnlockCode:       "112233",
+		QRCode:           "/qr/notify-001",
+		Status:           models.BookingStatusPending,
+	}
+	require.NoError(t, db.Create(booking).Error)
+
+	payment := &models.Payment{
+		PaymentNo:      "P_NOTIFY_001",
+		OrderID:        order.ID,
+		OrderNo:        order.OrderNo,
+		UserID:         user.ID,
+		Amount:         88,
+		PaymentMethod:  models.PaymentMethodWechat,
+		PaymentChannel: models.PaymentChannelMiniProgram,
+		Status:         models.PaymentStatusPending,
+	}
+	require.NoError(t, db.Create(payment).Error)
+
+	return booking, payment
+}
+
+// postWechatNotify 发送微信支付通知
+func postWechatNotify(router *gin.Engine, body []byte, header http.Header) (*httptest.ResponseRecorder, map[string]interface{}) {
+	req, _ := http.NewRequest("POST", "/api/v1/payments/notify/wechat", bytes.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func TestPaymentNotifyAPI_WechatNotify_Success(t *testing.T) {
+	router, db, platform := setupPaymentNotifyAPI(t)
+	booking, payment := createPaymentNotifyTestBooking(t, db)
+
+	resource := &wechatpay.NotifyResource{
+		OutTradeNo:    payment.PaymentNo,
+		TransactionID: "4200000000000088",
+		TradeState:    wechatpay.TradeStateSuccess,
+	}
+	resource.Amount.Total = 8800
+	body, header, err := platform.BuildNotify(resource)
+	require.NoError(t, err)
+
+	w, resp := postWechatNotify(router, body, header)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "SUCCESS", resp["code"])
+
+	var updatedPayment models.Payment
+	require.NoError(t, db.First(&updatedPayment, payment.ID).Error)
+	assert.EqualValues(t, models.PaymentStatusSuccess, updatedPayment.Status)
+
+	var updatedBooking models.Booking
+	require.NoError(t, db.First(&updatedBooking, booking.ID).Error)
+	assert.Equal(t, models.BookingStatusPaid, updatedBooking.Status)
+
+	// 重复通知同样应答成功
+	w, resp = postWechatNotify(router, body, header)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "SUCCESS", resp["code"])
+}
+
+func TestPaymentNotifyAPI_WechatNotify_InvalidSignature(t *testing.T) {
+	router, db, platform := setupPaymentNotifyAPI(t)
+	_, payment := createPaymentNotifyTestBooking(t, db)
+
+	resource := &wechatpay.NotifyResource{
+		OutTradeNo: payment.PaymentNo,
+		TradeState: wechatpay.TradeStateSuccess,
+	}
+	resource.Amount.Total = 8800
+	body, header, err := platform.BuildNotify(resource)
+	require.NoError(t, err)
+	header.Set(wechatpay.HeaderSignature, "Zm9yZ2Vk")
+
+	w, resp := postWechatNotify(router, body, header)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "FAIL", resp["code"])
+
+	var updatedPayment models.Payment
+	require.NoError(t, db.First(&updatedPayment, payment.ID).Error)
+	assert.EqualValues(t, models.PaymentStatusPending, updatedPayment.Status)
+}
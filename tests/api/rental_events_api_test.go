@@ -0,0 +1,234 @@
+//go:build api
+// +build api
+
+// Package api 租借状态实时推送 SSE API 测试
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
+	rentalHandler "github.com/dumeirei/smart-locker-backend/internal/handler/rental"
+	userMiddleware "github.com/dumeirei/smart-locker-backend/internal/middleware"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	deviceService "github.com/dumeirei/smart-locker-backend/internal/service/device"
+	eventService "github.com/dumeirei/smart-locker-backend/internal/service/event"
+	rentalService "github.com/dumeirei/smart-locker-backend/internal/service/rental"
+	userService "github.com/dumeirei/smart-locker-backend/internal/service/user"
+)
+
+// setupRentalEventsAPIServer 启动带租借状态推送路由的测试服务器
+func setupRentalEventsAPIServer(t *testing.T) (*httptest.Server, *gorm.DB, *jwt.Manager, *rentalService.RentalService) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	require.NoError(t, db.AutoMigrate(
+		&models.User{},
+		&models.UserWallet{},
+		&models.MemberLevel{},
+		&models.Merchant{},
+		&models.Venue{},
+		&models.Device{},
+		&models.DeviceSlot{},
+		&models.RentalPricing{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.Rental{},
+		&models.WalletTransaction{},
+	))
+	db.Create(&models.MemberLevel{ID: 1, Name: "普通会员", Level: 1, MinPoints: 0, Discount: 1.0})
+
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret-key-rental-events-api",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: 2 * time.Hour,
+		Issuer:            "test",
+	})
+
+	deviceRepo := repository.NewDeviceRepository(db)
+	deviceSvc := deviceService.NewDeviceService(db, deviceRepo, repository.NewVenueRepository(db))
+	walletSvc := userService.NewWalletService(db, repository.NewUserRepository(db), nil)
+	rentalSvc := rentalService.NewRentalService(db, repository.NewRentalRepository(db), deviceRepo, deviceSvc, walletSvc, nil, nil, nil)
+
+	bus := eventService.NewStatusBus(nil, nil)
+	rentalSvc.SetStatusEventPublisher(bus)
+
+	r := gin.New()
+	user := r.Group("/api/v1")
+	user.Use(userMiddleware.UserAuth(jwtManager))
+	rentalHandler.NewHandler(rentalSvc).RegisterRoutes(user)
+	rentalHandler.NewEventsHandler(rentalSvc, bus).RegisterRoutes(user)
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server, db, jwtManager, rentalSvc
+}
+
+// readStatusEvents 读取 SSE 流中的状态事件直到流结束，忽略心跳注释
+func readStatusEvents(t *testing.T, resp *http.Response, events chan<- *eventService.StatusEvent) {
+	t.Helper()
+	defer close(events)
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var event eventService.StatusEvent
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+			return
+		}
+		events <- &event
+	}
+}
+
+// nextStatusEvent 读取下一个状态事件，超时则失败
+func nextStatusEvent(t *testing.T, events <-chan *eventService.StatusEvent) *eventService.StatusEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "事件流已提前结束")
+		return event
+	case <-time.After(3 * time.Second):
+		t.Fatal("未收到租借状态事件")
+		return nil
+	}
+}
+
+func TestRentalEventsAPI_FullLifecycle(t *testing.T) {
+	server, db, jwtManager, rentalSvc := setupRentalEventsAPIServer(t)
+	user, device, pricing := seedUS1DeviceAndUser(t, db)
+
+	tokenPair, err := jwtManager.GenerateTokenPair(user.ID, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+	authz := "Bearer " + tokenPair.AccessToken
+
+	post := func(path string, body interface{}) {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req, err := http.NewRequest(http.MethodPost, server.URL+path, &buf)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", authz)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result struct {
+			Code int             `json:"code"`
+			Data json.RawMessage `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		require.Equal(t, 0, result.Code, path)
+	}
+
+	post("/api/v1/rental", map[string]interface{}{"device_id": device.ID, "pricing_id": pricing.ID})
+	var rental models.Rental
+	require.NoError(t, db.Where("user_id = ?", user.ID).First(&rental).Error)
+	idStr := fmt.Sprintf("%d", rental.ID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/rental/"+idStr+"/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", authz)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := make(chan *eventService.StatusEvent, 16)
+	go readStatusEvents(t, resp, events)
+
+	// 连接建立后先推送当前状态
+	first := nextStatusEvent(t, events)
+	assert.Equal(t, models.RentalStatusPending, first.NewStatus)
+
+	post("/api/v1/rental/"+idStr+"/pay", nil)
+	post("/api/v1/rental/"+idStr+"/start", nil)
+	post("/api/v1/rental/"+idStr+"/return", nil)
+	require.NoError(t, rentalSvc.CompleteRental(context.Background(), rental.ID))
+
+	expected := [][2]string{
+		{models.RentalStatusPending, models.RentalStatusPaid},
+		{models.RentalStatusPaid, models.RentalStatusInUse},
+		{models.RentalStatusInUse, models.RentalStatusReturned},
+		{models.RentalStatusReturned, models.RentalStatusCompleted},
+	}
+	for _, transition := range expected {
+		event := nextStatusEvent(t, events)
+		assert.Equal(t, eventService.SubjectRental, event.Subject)
+		assert.Equal(t, rental.ID, event.ID)
+		assert.Equal(t, user.ID, event.UserID)
+		assert.Equal(t, transition[0], event.OldStatus)
+		assert.Equal(t, transition[1], event.NewStatus)
+	}
+
+	// 进入终态后服务端结束事件流
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok, "终态之后不应再有事件")
+	case <-time.After(3 * time.Second):
+		t.Fatal("租借完成后事件流未结束")
+	}
+}
+
+func TestRentalEventsAPI_OtherUserForbidden(t *testing.T) {
+	server, db, jwtManager, rentalSvc := setupRentalEventsAPIServer(t)
+	user, device, pricing := seedUS1DeviceAndUser(t, db)
+
+	rental, err := rentalSvc.CreateRental(context.Background(), user.ID, &rentalService.CreateRentalRequest{DeviceID: device.ID, PricingID: pricing.ID})
+	require.NoError(t, err)
+
+	otherPhone := "13800138999"
+	other := &models.User{Phone: &otherPhone, Nickname: "其他用户", MemberLevelID: 1, Status: models.UserStatusActive}
+	require.NoError(t, db.Create(other).Error)
+	tokenPair, err := jwtManager.GenerateTokenPair(other.ID, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/v1/rental/%d/events", server.URL, rental.ID), nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+tokenPair.AccessToken)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.NotEqual(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	var result struct {
+		Code int `json:"code"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.NotEqual(t, 0, result.Code)
+}
@@ -0,0 +1,212 @@
+//go:build api
+// +build api
+
+// Package api 令牌轮换与吊销 API 测试
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
+	adminHandler "github.com/dumeirei/smart-locker-backend/internal/handler/admin"
+	authHandler "github.com/dumeirei/smart-locker-backend/internal/handler/auth"
+	"github.com/dumeirei/smart-locker-backend/internal/middleware"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	adminService "github.com/dumeirei/smart-locker-backend/internal/service/admin"
+	authService "github.com/dumeirei/smart-locker-backend/internal/service/auth"
+)
+
+type tokenRevocationTestEnv struct {
+	router     *gin.Engine
+	db         *gorm.DB
+	mr         *miniredis.Miniredis
+	jwtManager *jwt.Manager
+}
+
+func setupTokenRevocationTest(t *testing.T) *tokenRevocationTestEnv {
+	gin.SetMode(gin.TestMode)
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&models.MemberLevel{}, &models.User{}, &models.UserWallet{}, &models.AuthToken{}))
+	db.Create(&models.MemberLevel{ID: 1, Name: "普通会员", Level: 1, Discount: 1.0})
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = redisClient.Close() })
+
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret-key",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: 24 * time.Hour,
+		Issuer:            "test",
+	})
+	jwtManager.SetRevocationStore(authService.NewTokenStore(db, redisClient))
+
+	userRepo := repository.NewUserRepository(db)
+	authH := authHandler.NewHandler(authService.NewAuthService(db, userRepo, jwtManager, nil), nil, nil)
+	userAdminSvc := adminService.NewUserAdminService(db, userRepo)
+	userAdminSvc.SetTokenRevoker(jwtManager)
+	userAdminH := adminHandler.NewUserHandler(userAdminSvc)
+
+	r := gin.New()
+	v1 := r.Group("/api/v1")
+	v1.POST("/auth/refresh", authH.RefreshToken)
+	user := v1.Group("")
+	user.Use(middleware.UserAuth(jwtManager))
+	user.GET("/auth/me", authH.GetCurrentUser)
+	user.POST("/auth/logout", authH.Logout)
+	admin := v1.Group("/admin")
+	admin.Use(middleware.AdminAuth(jwtManager))
+	admin.POST("/users/:id/force-logout", userAdminH.ForceLogout)
+
+	return &tokenRevocationTestEnv{router: r, db: db, mr: mr, jwtManager: jwtManager}
+}
+
+func (env *tokenRevocationTestEnv) createUser(t *testing.T, phone string) *models.User {
+	user := &models.User{Phone: &phone, Nickname: "用户" + phone, MemberLevelID: 1, Status: models.UserStatusActive}
+	require.NoError(t, env.db.Create(user).Error)
+	return user
+}
+
+func (env *tokenRevocationTestEnv) login(t *testing.T, userID int64, userType string) *jwt.TokenPair {
+	pair, err := env.jwtManager.IssueTokenPair(context.Background(), userID, userType, "")
+	require.NoError(t, err)
+	return pair
+}
+
+func (env *tokenRevocationTestEnv) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	return w
+}
+
+func (env *tokenRevocationTestEnv) refresh(t *testing.T, refreshToken string) (*jwt.TokenPair, int) {
+	w := env.do(http.MethodPost, "/api/v1/auth/refresh", "", map[string]string{"refresh_token": refreshToken})
+	if w.Code != http.StatusOK {
+		return nil, w.Code
+	}
+	var resp struct {
+		Data jwt.TokenPair `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return &resp.Data, w.Code
+}
+
+func TestTokenRevocationAPI_RefreshReuseRevokesFamily(t *testing.T) {
+	env := setupTokenRevocationTest(t)
+	user := env.createUser(t, "13800138001")
+	first := env.login(t, user.ID, jwt.UserTypeUser)
+
+	second, code := env.refresh(t, first.RefreshToken)
+	require.Equal(t, http.StatusOK, code)
+
+	// 轮换后旧访问令牌在过期前仍可使用
+	assert.Equal(t, http.StatusOK, env.do(http.MethodGet, "/api/v1/auth/me", first.AccessToken, nil).Code)
+
+	// 重放已轮换的刷新令牌：整个令牌族失效
+	_, code = env.refresh(t, first.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	_, code = env.refresh(t, second.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, http.StatusUnauthorized, env.do(http.MethodGet, "/api/v1/auth/me", first.AccessToken, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, env.do(http.MethodGet, "/api/v1/auth/me", second.AccessToken, nil).Code)
+}
+
+func TestTokenRevocationAPI_LogoutAndForceLogout(t *testing.T) {
+	env := setupTokenRevocationTest(t)
+	user := env.createUser(t, "13800138002")
+	phone := env.login(t, user.ID, jwt.UserTypeUser)
+	tablet := env.login(t, user.ID, jwt.UserTypeUser)
+	adminPair := env.login(t, 1, jwt.UserTypeAdmin)
+
+	// 退出登录只影响当前会话
+	require.Equal(t, http.StatusOK, env.do(http.MethodPost, "/api/v1/auth/logout", phone.AccessToken, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, env.do(http.MethodGet, "/api/v1/auth/me", phone.AccessToken, nil).Code)
+	_, code := env.refresh(t, phone.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, http.StatusOK, env.do(http.MethodGet, "/api/v1/auth/me", tablet.AccessToken, nil).Code)
+
+	// 强制下线吊销用户全部会话
+	w := env.do(http.MethodPost, fmt.Sprintf("/api/v1/admin/users/%d/force-logout", user.ID), adminPair.AccessToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"revoked_sessions":1`)
+	assert.Equal(t, http.StatusUnauthorized, env.do(http.MethodGet, "/api/v1/auth/me", tablet.AccessToken, nil).Code)
+	_, code = env.refresh(t, tablet.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// 重新登录后正常使用
+	fresh := env.login(t, user.ID, jwt.UserTypeUser)
+	assert.Equal(t, http.StatusOK, env.do(http.MethodGet, "/api/v1/auth/me", fresh.AccessToken, nil).Code)
+
+	w = env.do(http.MethodPost, "/api/v1/admin/users/999/force-logout", adminPair.AccessToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestTokenRevocationAPI_NoRedisRoundTripWithoutMarker(t *testing.T) {
+	env := setupTokenRevocationTest(t)
+	clean := env.createUser(t, "13800138003")
+	revoked := env.createUser(t, "13800138004")
+	cleanPair := env.login(t, clean.ID, jwt.UserTypeUser)
+	revokedPair := env.login(t, revoked.ID, jwt.UserTypeUser)
+	require.Equal(t, http.StatusOK, env.do(http.MethodPost, "/api/v1/auth/logout", revokedPair.AccessToken, nil).Code)
+
+	before := env.mr.CommandCount()
+	for i := 0; i < 5; i++ {
+		require.Equal(t, http.StatusOK, env.do(http.MethodGet, "/api/v1/auth/me", cleanPair.AccessToken, nil).Code)
+	}
+	assert.Equal(t, before, env.mr.CommandCount())
+
+	assert.Equal(t, http.StatusUnauthorized, env.do(http.MethodGet, "/api/v1/auth/me", revokedPair.AccessToken, nil).Code)
+	assert.Greater(t, env.mr.CommandCount(), before)
+}
+
+func TestTokenRevocationAPI_RefreshTokenCannotAccessAPI(t *testing.T) {
+	env := setupTokenRevocationTest(t)
+	user := env.createUser(t, "13800138005")
+	pair := env.login(t, user.ID, jwt.UserTypeUser)
+	adminPair := env.login(t, 1, jwt.UserTypeAdmin)
+
+	// 刷新令牌不能作为访问令牌使用
+	assert.Equal(t, http.StatusUnauthorized, env.do(http.MethodGet, "/api/v1/auth/me", pair.RefreshToken, nil).Code)
+	w := env.do(http.MethodPost, fmt.Sprintf("/api/v1/admin/users/%d/force-logout", user.ID), adminPair.RefreshToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	assert.Equal(t, http.StatusOK, env.do(http.MethodGet, "/api/v1/auth/me", pair.AccessToken, nil).Code)
+}
@@ -21,6 +21,7 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
 	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
 	deviceHandler "github.com/dumeirei/smart-locker-backend/internal/handler/device"
 	paymentHandler "github.com/dumeirei/smart-locker-backend/internal/handler/payment"
@@ -32,6 +33,7 @@ import (
 	paymentService "github.com/dumeirei/smart-locker-backend/internal/service/payment"
 	rentalService "github.com/dumeirei/smart-locker-backend/internal/service/rental"
 	userService "github.com/dumeirei/smart-locker-backend/internal/service/user"
+	"github.com/dumeirei/smart-locker-backend/pkg/oss"
 )
 
 func setupUS1APIRouter(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager) {
@@ -58,12 +60,15 @@ func setupUS1APIRouter(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager) {
 		&models.Merchant{},
 		&models.Venue{},
 		&models.Device{},
+		&models.DeviceSlot{},
 		&models.RentalPricing{},
 		&models.Order{},
+		&models.OrderItem{},
 		&models.Rental{},
 		&models.WalletTransaction{},
 		&models.Payment{},
 		&models.Refund{},
+		&models.OrderNote{},
 	)
 	require.NoError(t, err)
 
@@ -92,12 +97,16 @@ func setupUS1APIRouter(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager) {
 
 	deviceSvc := deviceService.NewDeviceService(db, deviceRepo, venueRepo)
 	venueSvc := deviceService.NewVenueService(db, venueRepo, deviceRepo)
-	walletSvc := userService.NewWalletService(db, userRepo)
-	rentalSvc := rentalService.NewRentalService(db, rentalRepo, deviceRepo, deviceSvc, walletSvc, nil)
-	paymentSvc := paymentService.NewPaymentService(db, paymentRepo, refundRepo, rentalRepo, nil)
+	walletSvc := userService.NewWalletService(db, userRepo, nil)
+	rentalSvc := rentalService.NewRentalService(db, rentalRepo, deviceRepo, deviceSvc, walletSvc, nil, nil, nil)
+	paymentSvc := paymentService.NewPaymentService(db, paymentRepo, refundRepo, rentalRepo, nil, nil)
 
 	deviceH := deviceHandler.NewHandler(deviceSvc, venueSvc)
 	rentalH := rentalHandler.NewHandler(rentalSvc)
+	rentalH.SetInvoiceService(rentalService.NewInvoiceService(db, rentalRepo, oss.NewMockUploader(), rentalService.InvoiceConfig{
+		CompanyName: "Smart Locker Co., Ltd.",
+		TaxRate:     0.06,
+	}))
 	paymentH := paymentHandler.NewHandler(paymentSvc)
 
 	v1 := r.Group("/api/v1")
@@ -264,3 +273,180 @@ func TestUS1API_RentalLifecycle_ScanToReturn(t *testing.T) {
 	assert.Equal(t, float64(0), getResp["code"])
 	assert.Equal(t, models.RentalStatusReturned, getResp["data"].(map[string]interface{})["status"])
 }
+
+func TestUS1API_ListRentals_Cursor(t *testing.T) {
+	router, db, jwtManager := setupUS1APIRouter(t)
+	user, device, _ := seedUS1DeviceAndUser(t, db)
+
+	base := time.Now().Add(-time.Hour)
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, db.Create(&models.Rental{
+			OrderID:       int64(i),
+			UserID:        user.ID,
+			DeviceID:      device.ID,
+			DurationHours: 1,
+			RentalFee:     10,
+			Deposit:       50,
+			OvertimeRate:  1.5,
+			Status:        models.RentalStatusCompleted,
+			CreatedAt:     base.Add(time.Duration(i) * time.Minute),
+		}).Error)
+	}
+
+	tokenPair, err := jwtManager.GenerateTokenPair(user.ID, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+
+	list := func(query string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest("GET", "/api/v1/rental?"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+tokenPair.AccessToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	code, resp := list("cursor=&limit=2")
+	require.Equal(t, http.StatusOK, code)
+	data := resp["data"].(map[string]interface{})
+	assert.Len(t, data["list"].([]interface{}), 2)
+	assert.Equal(t, true, data["has_more"])
+	next := data["next_cursor"].(string)
+	require.NotEmpty(t, next)
+
+	code, resp = list("cursor=" + next + "&limit=2")
+	require.Equal(t, http.StatusOK, code)
+	data = resp["data"].(map[string]interface{})
+	assert.Len(t, data["list"].([]interface{}), 1)
+	assert.Equal(t, false, data["has_more"])
+	assert.Equal(t, "", data["next_cursor"])
+
+	code, _ = list("cursor=invalid!!")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 未传 cursor 时保持页码分页
+	code, resp = list("page=1&page_size=2")
+	require.Equal(t, http.StatusOK, code)
+	data = resp["data"].(map[string]interface{})
+	assert.Equal(t, float64(3), data["total"])
+}
+
+func TestUS1API_ExtendRental_ByHours(t *testing.T) {
+	router, db, jwtManager := setupUS1APIRouter(t)
+	user, device, pricing := seedUS1DeviceAndUser(t, db)
+
+	tokenPair, err := jwtManager.GenerateTokenPair(user.ID, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+	authz := "Bearer " + tokenPair.AccessToken
+
+	post := func(path string, body interface{}) (int, map[string]interface{}) {
+		var reader *bytes.Buffer
+		if body != nil {
+			data, _ := json.Marshal(body)
+			reader = bytes.NewBuffer(data)
+		} else {
+			reader = bytes.NewBuffer(nil)
+		}
+		req, _ := http.NewRequest("POST", path, reader)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", authz)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	// 创建、支付并开始租借
+	code, resp := post("/api/v1/rental", map[string]interface{}{"device_id": device.ID, "pricing_id": pricing.ID})
+	require.Equal(t, http.StatusOK, code)
+	idStr := strconv.FormatInt(int64(resp["data"].(map[string]interface{})["id"].(float64)), 10)
+	code, _ = post("/api/v1/rental/"+idStr+"/pay", nil)
+	require.Equal(t, http.StatusOK, code)
+	code, _ = post("/api/v1/rental/"+idStr+"/start", nil)
+	require.Equal(t, http.StatusOK, code)
+
+	var before models.Rental
+	require.NoError(t, db.First(&before, idStr).Error)
+
+	code, resp = post("/api/v1/rental/"+idStr+"/extend", map[string]interface{}{"additional_hours": 2})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(0), resp["code"])
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, float64(before.DurationHours+2), data["duration_hours"])
+
+	var after models.Rental
+	require.NoError(t, db.First(&after, idStr).Error)
+	assert.WithinDuration(t, before.ExpectedReturnAt.Add(2*time.Hour), *after.ExpectedReturnAt, time.Second)
+
+	// 续租时长和续租套餐须二选一
+	code, _ = post("/api/v1/rental/"+idStr+"/extend", map[string]interface{}{})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = post("/api/v1/rental/"+idStr+"/extend", map[string]interface{}{"additional_hours": 1, "pricing_id": pricing.ID})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = post("/api/v1/rental/"+idStr+"/extend", map[string]interface{}{"additional_hours": 100})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 余额不足
+	require.NoError(t, db.Model(&models.UserWallet{}).Where("user_id = ?", user.ID).Update("balance", 0).Error)
+	code, resp = post("/api/v1/rental/"+idStr+"/extend", map[string]interface{}{"additional_hours": 1})
+	assert.NotEqual(t, http.StatusOK, code)
+	assert.Equal(t, float64(appErrors.ErrBalanceInsufficient.Code), resp["code"])
+
+	var unchanged models.Rental
+	require.NoError(t, db.First(&unchanged, idStr).Error)
+	assert.Equal(t, after.DurationHours, unchanged.DurationHours)
+}
+
+func TestUS1API_DownloadInvoice(t *testing.T) {
+	router, db, jwtManager := setupUS1APIRouter(t)
+	user, device, pricing := seedUS1DeviceAndUser(t, db)
+
+	tokenPair, err := jwtManager.GenerateTokenPair(user.ID, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+	authz := "Bearer " + tokenPair.AccessToken
+
+	createBody, _ := json.Marshal(map[string]interface{}{
+		"device_id":  device.ID,
+		"pricing_id": pricing.ID,
+	})
+	createReq, _ := http.NewRequest("POST", "/api/v1/rental", bytes.NewBuffer(createBody))
+	createReq.Header.Set("Content-Type", "application/json")
+	createReq.Header.Set("Authorization", authz)
+	createW := httptest.NewRecorder()
+	router.ServeHTTP(createW, createReq)
+	require.Equal(t, http.StatusOK, createW.Code)
+
+	var createResp map[string]interface{}
+	require.NoError(t, json.Unmarshal(createW.Body.Bytes(), &createResp))
+	rentalID := int64(createResp["data"].(map[string]interface{})["id"].(float64))
+	invoiceURL := "/api/v1/rental/" + strconv.FormatInt(rentalID, 10) + "/invoice"
+
+	// 未完成的租借不能下载发票
+	req, _ := http.NewRequest("GET", invoiceURL, nil)
+	req.Header.Set("Authorization", authz)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.NotEqual(t, http.StatusOK, w.Code)
+
+	var rental models.Rental
+	require.NoError(t, db.First(&rental, rentalID).Error)
+	now := time.Now()
+	require.NoError(t, db.Model(&rental).Update("status", models.RentalStatusCompleted).Error)
+	require.NoError(t, db.Model(&models.Order{}).Where("id = ?", rental.OrderID).
+		Updates(map[string]interface{}{"status": models.OrderStatusCompleted, "completed_at": now}).Error)
+
+	req, _ = http.NewRequest("GET", invoiceURL, nil)
+	req.Header.Set("Authorization", authz)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".pdf")
+	assert.True(t, bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF")))
+
+	require.NoError(t, db.First(&rental, rentalID).Error)
+	assert.NotNil(t, rental.InvoicePath)
+}
@@ -22,6 +22,7 @@ import (
 	"gorm.io/gorm/logger"
 
 	"github.com/dumeirei/smart-locker-backend/internal/common/jwt"
+	adminHandler "github.com/dumeirei/smart-locker-backend/internal/handler/admin"
 	mallHandler "github.com/dumeirei/smart-locker-backend/internal/handler/mall"
 	userMiddleware "github.com/dumeirei/smart-locker-backend/internal/middleware"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
@@ -55,10 +56,19 @@ func setupUS3APIRouter(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager) {
 		&models.Category{},
 		&models.Product{},
 		&models.ProductSku{},
+		&models.ProductSearchTerm{},
 		&models.CartItem{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.GiftOrder{},
 		&models.Review{},
+		&models.ReviewReply{},
+		&models.Payment{},
+		&models.Refund{},
+		&models.OrderNote{},
+		&models.RefundItem{},
+		&models.Coupon{},
+		&models.UserCoupon{},
 	)
 	require.NoError(t, err)
 
@@ -84,7 +94,7 @@ func setupUS3APIRouter(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager) {
 	productSvc := mallService.NewProductService(db, productRepo, categoryRepo, skuRepo)
 	searchSvc := mallService.NewSearchService(db, productRepo)
 	cartSvc := mallService.NewCartService(db, cartRepo, productRepo, skuRepo)
-	orderSvc := mallService.NewMallOrderService(db, orderRepo, cartRepo, productRepo, skuRepo, productSvc)
+	orderSvc := mallService.NewMallOrderService(db, orderRepo, cartRepo, productRepo, skuRepo, productSvc, repository.NewRefundRepository(db), repository.NewPaymentRepository(db))
 	reviewSvc := mallService.NewReviewService(db, reviewRepo, orderRepo)
 
 	// 创建 handlers
@@ -92,6 +102,7 @@ func setupUS3APIRouter(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager) {
 	cartH := mallHandler.NewCartHandler(cartSvc)
 	orderH := mallHandler.NewOrderHandler(orderSvc)
 	reviewH := mallHandler.NewReviewHandler(reviewSvc)
+	mallRefundAdminH := adminHandler.NewMallRefundHandler(orderSvc)
 
 	v1 := r.Group("/api/v1")
 	{
@@ -122,12 +133,18 @@ func setupUS3APIRouter(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager) {
 			user.GET("/orders/:id", orderH.GetOrderDetail)
 			user.POST("/orders/:id/cancel", orderH.CancelOrder)
 			user.POST("/orders/:id/confirm", orderH.ConfirmReceive)
+			user.POST("/orders/:id/refund", orderH.RequestRefund)
 
 			// 评价
 			user.POST("/reviews", reviewH.CreateReview)
 			user.GET("/user/reviews", reviewH.GetUserReviews)
 			user.DELETE("/reviews/:id", reviewH.DeleteReview)
 		}
+
+		adminAuth := v1.Group("/admin")
+		adminAuth.Use(userMiddleware.AdminAuth(jwtManager))
+		mallRefundAdminH.RegisterRoutes(adminAuth)
+		adminAuth.POST("/reviews/:id/reply", reviewH.ReplyReview)
 	}
 
 	return r, db, jwtManager
@@ -805,6 +822,97 @@ func TestUS3API_Order_Cancel(t *testing.T) {
 	assert.Equal(t, models.OrderStatusCancelled, order.Status)
 }
 
+func TestUS3API_Order_PartialRefund(t *testing.T) {
+	router, db, jwtManager := setupUS3APIRouter(t)
+	user, _, product, sku, address := seedUS3TestData(t, db)
+
+	tokenPair, err := jwtManager.GenerateTokenPair(user.ID, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+	authz := "Bearer " + tokenPair.AccessToken
+	adminToken, _, err := jwtManager.GenerateAccessToken(1, jwt.UserTypeAdmin, "")
+	require.NoError(t, err)
+
+	post := func(path, authorization string, payload interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", authorization)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w, resp
+	}
+
+	// 创建规格商品订单并模拟支付
+	w, resp := post("/api/v1/orders", authz, map[string]interface{}{
+		"items":      []map[string]interface{}{{"product_id": product.ID, "sku_id": sku.ID, "quantity": 2}},
+		"address_id": address.ID,
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	orderID := int64(resp["data"].(map[string]interface{})["id"].(float64))
+
+	var order models.Order
+	require.NoError(t, db.First(&order, orderID).Error)
+	require.NoError(t, db.Model(&order).Update("status", models.OrderStatusPendingShip).Error)
+	require.NoError(t, db.Create(&models.Payment{
+		PaymentNo:      "P" + order.OrderNo,
+		OrderID:        order.ID,
+		OrderNo:        order.OrderNo,
+		UserID:         user.ID,
+		Amount:         order.ActualAmount,
+		PaymentMethod:  "wechat",
+		PaymentChannel: "miniapp",
+		Status:         models.PaymentStatusSuccess,
+	}).Error)
+
+	var item models.OrderItem
+	require.NoError(t, db.Where("order_id = ?", orderID).First(&item).Error)
+	require.NotNil(t, item.SkuID)
+	assert.Equal(t, sku.ID, *item.SkuID)
+
+	refundPath := "/api/v1/orders/" + strconv.FormatInt(orderID, 10) + "/refund"
+
+	// 退 1 件
+	w, resp = post(refundPath, authz, map[string]interface{}{
+		"items": []map[string]interface{}{{"order_item_id": item.ID, "quantity": 1, "reason": "不想要了"}},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	refundData := resp["data"].(map[string]interface{})
+	assert.Equal(t, 85.0, refundData["amount"])
+	firstRefundID := int64(refundData["id"].(float64))
+
+	// 超过剩余可退数量
+	w, _ = post(refundPath, authz, map[string]interface{}{
+		"items": []map[string]interface{}{{"order_item_id": item.ID, "quantity": 2}},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 退剩余 1 件
+	w, resp = post(refundPath, authz, map[string]interface{}{
+		"items": []map[string]interface{}{{"order_item_id": item.ID, "quantity": 1}},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	lastRefundID := int64(resp["data"].(map[string]interface{})["id"].(float64))
+
+	// 用户无权审批
+	w, _ = post("/api/v1/admin/refunds/"+strconv.FormatInt(firstRefundID, 10)+"/approve", authz, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	for _, id := range []int64{firstRefundID, lastRefundID} {
+		w, _ = post("/api/v1/admin/refunds/"+strconv.FormatInt(id, 10)+"/approve", "Bearer "+adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	require.NoError(t, db.First(&order, orderID).Error)
+	assert.Equal(t, models.OrderStatusRefunded, order.Status)
+
+	var restored models.ProductSku
+	require.NoError(t, db.First(&restored, sku.ID).Error)
+	assert.Equal(t, sku.Stock, restored.Stock)
+}
+
 // ==================== 评价 API 测试 ====================
 
 func TestUS3API_Review_Create(t *testing.T) {
@@ -935,6 +1043,68 @@ func TestUS3API_Review_Delete(t *testing.T) {
 	assert.Equal(t, int64(0), count)
 }
 
+func TestUS3API_Review_AdminReply(t *testing.T) {
+	router, db, jwtManager := setupUS3APIRouter(t)
+	user, _, product, _, _ := seedUS3TestData(t, db)
+
+	order := &models.Order{
+		OrderNo:        "M20240101002",
+		UserID:         user.ID,
+		Type:           models.OrderTypeMall,
+		OriginalAmount: 80.0,
+		ActualAmount:   80.0,
+		Status:         models.OrderStatusCompleted,
+	}
+	require.NoError(t, db.Create(order).Error)
+	review := &models.Review{
+		OrderID:   order.ID,
+		ProductID: product.ID,
+		UserID:    user.ID,
+		Rating:    5,
+		Status:    int16(models.ReviewStatusVisible),
+	}
+	require.NoError(t, db.Create(review).Error)
+
+	adminToken, _, err := jwtManager.GenerateAccessToken(1, jwt.UserTypeAdmin, "")
+	require.NoError(t, err)
+	reply := func(reviewID int64, token string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"content": "感谢您的评价"})
+		req, _ := http.NewRequest("POST", "/api/v1/admin/reviews/"+strconv.FormatInt(reviewID, 10)+"/reply", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 普通用户无权回复
+	userToken, _, err := jwtManager.GenerateAccessToken(user.ID, jwt.UserTypeUser, "")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, reply(review.ID, userToken).Code)
+
+	require.Equal(t, http.StatusOK, reply(review.ID, adminToken).Code)
+	assert.Equal(t, http.StatusNotFound, reply(review.ID+100, adminToken).Code)
+
+	req, _ := http.NewRequest("GET", "/api/v1/products/"+strconv.FormatInt(product.ID, 10)+"/reviews", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data mallService.ReviewListResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data.List, 1)
+	require.Len(t, resp.Data.List[0].Replies, 1)
+	assert.Equal(t, "感谢您的评价", resp.Data.List[0].Replies[0].Content)
+	assert.Equal(t, mallService.ReviewReplierAdmin, resp.Data.List[0].Replies[0].ReplierType)
+
+	req, _ = http.NewRequest("GET", "/api/v1/products/"+strconv.FormatInt(product.ID, 10)+"/reviews?has_images=maybe", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 // ==================== 完整购物流程测试 ====================
 
 func TestUS3API_FullShoppingFlow(t *testing.T) {
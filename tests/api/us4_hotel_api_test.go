@@ -57,7 +57,9 @@ func setupUS4APIRouter(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager) {
 		&models.Hotel{},
 		&models.Room{},
 		&models.RoomTimeSlot{},
+		&models.RoomPriceOverride{},
 		&models.Booking{},
+		&models.RoomOccupancy{},
 	)
 	require.NoError(t, err)
 
@@ -80,8 +82,11 @@ func setupUS4APIRouter(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager) {
 
 	// 创建 services
 	codeService := hotelService.NewCodeService()
+	pricing := hotelService.NewPricingResolver(repository.NewRoomPriceOverrideRepository(db))
 	hotelSvc := hotelService.NewHotelService(db, hotelRepo, roomRepo, timeSlotRepo)
+	hotelSvc.SetPricingResolver(pricing)
 	bookingSvc := hotelService.NewBookingService(db, bookingRepo, roomRepo, hotelRepo, orderRepo, timeSlotRepo, codeService, nil, nil)
+	bookingSvc.SetPricingResolver(pricing)
 
 	// 创建 handlers
 	hotelH := hotelHandler.NewHandler(hotelSvc)
@@ -431,13 +436,52 @@ func TestUS4API_GetRoomTimeSlots(t *testing.T) {
 	assert.Len(t, data, 3)
 }
 
+func TestUS4API_GetRoomTimeSlots_WithDate(t *testing.T) {
+	router, db, _ := setupUS4APIRouter(t)
+	_, _, room, slot := seedUS4TestData(t, db)
+
+	// 周五、周六加价，2026-10-16 为周五
+	require.NoError(t, db.Create(&models.RoomPriceOverride{
+		RoomID:      room.ID,
+		WeekdayMask: models.WeekdayBit(time.Friday) | models.WeekdayBit(time.Saturday),
+		Price:       slot.Price + 50,
+		IsActive:    true,
+	}).Error)
+
+	priceOn := func(date string) (int, float64) {
+		req, _ := http.NewRequest("GET", "/api/v1/rooms/"+strconv.FormatInt(room.ID, 10)+"/time-slots?date="+date, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			return w.Code, 0
+		}
+
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		data := resp["data"].([]interface{})
+		require.Len(t, data, 1)
+		return w.Code, data[0].(map[string]interface{})["price"].(float64)
+	}
+
+	code, price := priceOn("2026-10-16")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, slot.Price+50, price)
+
+	code, price = priceOn("2026-10-15")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, slot.Price, price)
+
+	code, _ = priceOn("2026/10/16")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
 func TestUS4API_CheckRoomAvailability(t *testing.T) {
 	router, db, _ := setupUS4APIRouter(t)
 	_, _, room, _ := seedUS4TestData(t, db)
 
 	t.Run("房间可用", func(t *testing.T) {
-		checkIn := time.Now().Add(2 * time.Hour).Format("2006-01-02 15:04")
-		checkOut := time.Now().Add(4 * time.Hour).Format("2006-01-02 15:04")
+		checkIn := us4HotelNow(t).Add(2 * time.Hour).Format("2006-01-02 15:04")
+		checkOut := us4HotelNow(t).Add(4 * time.Hour).Format("2006-01-02 15:04")
 
 		req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/rooms/%d/availability?check_in=%s&check_out=%s",
 			room.ID, checkIn, checkOut), nil)
@@ -475,7 +519,7 @@ func TestUS4API_CreateBooking(t *testing.T) {
 	authz := "Bearer " + tokenPair.AccessToken
 
 	t.Run("创建预订成功", func(t *testing.T) {
-		checkInTime := time.Now().Add(2 * time.Hour).Format("2006-01-02 15:04:05")
+		checkInTime := us4HotelNow(t).Add(2 * time.Hour).Format("2006-01-02 15:04:05")
 		body, _ := json.Marshal(map[string]interface{}{
 			"room_id":        room.ID,
 			"duration_hours": 2,
@@ -502,7 +546,7 @@ func TestUS4API_CreateBooking(t *testing.T) {
 	})
 
 	t.Run("未登录创建预订失败", func(t *testing.T) {
-		checkInTime := time.Now().Add(3 * time.Hour).Format("2006-01-02 15:04:05")
+		checkInTime := us4HotelNow(t).Add(3 * time.Hour).Format("2006-01-02 15:04:05")
 		body, _ := json.Marshal(map[string]interface{}{
 			"room_id":        room.ID,
 			"duration_hours": 2,
@@ -518,7 +562,7 @@ func TestUS4API_CreateBooking(t *testing.T) {
 	})
 
 	t.Run("房间不存在创建失败", func(t *testing.T) {
-		checkInTime := time.Now().Add(4 * time.Hour).Format("2006-01-02 15:04:05")
+		checkInTime := us4HotelNow(t).Add(4 * time.Hour).Format("2006-01-02 15:04:05")
 		body, _ := json.Marshal(map[string]interface{}{
 			"room_id":        99999,
 			"duration_hours": 2,
@@ -965,7 +1009,7 @@ func TestUS4API_FullBookingFlow(t *testing.T) {
 	t.Log("Step 5: 获取房间详情成功")
 
 	// 6. 检查房间可用性
-	checkInTime := time.Now().Add(2 * time.Hour)
+	checkInTime := us4HotelNow(t).Add(2 * time.Hour)
 	checkOutTime := checkInTime.Add(2 * time.Hour)
 	availReq, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/rooms/%d/availability?check_in=%s&check_out=%s",
 		room.ID, checkInTime.Format("2006-01-02 15:04"), checkOutTime.Format("2006-01-02 15:04")), nil)
@@ -1028,3 +1072,11 @@ func TestUS4API_FullBookingFlow(t *testing.T) {
 	assert.Equal(t, models.BookingStatusCancelled, cancelledBooking.Status)
 	t.Log("完整酒店预订流程测试通过！")
 }
+
+// us4HotelNow 返回酒店当地的当前时间（未带时区的时间按酒店时区解析）
+func us4HotelNow(t *testing.T) time.Time {
+	t.Helper()
+	loc, err := time.LoadLocation(models.DefaultHotelTimezone)
+	require.NoError(t, err)
+	return time.Now().In(loc)
+}
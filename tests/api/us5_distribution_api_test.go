@@ -49,7 +49,9 @@ func setupDistributionAPITestDB(t *testing.T) *gorm.DB {
 		&models.Order{},
 		&models.Distributor{},
 		&models.Commission{},
+		&models.DistributorCommissionConfig{},
 		&models.Withdrawal{},
+		&models.WithdrawalPolicy{},
 		&models.Admin{},
 	)
 	require.NoError(t, err)
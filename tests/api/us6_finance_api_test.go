@@ -5,6 +5,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -53,10 +54,15 @@ func setupFinanceAPITestDB(t *testing.T) *gorm.DB {
 		&models.Rental{},
 		&models.Payment{},
 		&models.Refund{},
+		&models.OrderNote{},
 		&models.Settlement{},
+		&models.SettlementItem{},
 		&models.WalletTransaction{},
 		&models.Withdrawal{},
+		&models.WithdrawalAuditLog{},
 		&models.Commission{},
+		&models.ReconciliationIssue{},
+		&models.FinanceDailySummary{},
 	)
 	require.NoError(t, err)
 
@@ -79,13 +85,14 @@ func setupFinanceAPITestRouter(db *gorm.DB, jwtManager *jwt.Manager) *gin.Engine
 	withdrawalRepo := repository.NewWithdrawalRepository(db)
 
 	// 初始化服务
-	settlementSvc := financeService.NewSettlementService(db, settlementRepo, orderRepo, merchantRepo, commissionRepo, distributorRepo)
+	settlementSvc := financeService.NewSettlementService(db, settlementRepo, orderRepo, merchantRepo, commissionRepo, distributorRepo, nil)
 	statisticsSvc := financeService.NewStatisticsService(db, settlementRepo, transactionRepo, orderRepo, paymentRepo, commissionRepo, withdrawalRepo)
 	withdrawalSvc := financeService.NewWithdrawalAuditService(db, withdrawalRepo, distributorRepo)
 	exportSvc := financeService.NewExportService(db, settlementRepo, transactionRepo, orderRepo, withdrawalRepo)
 
 	// 初始化处理器
 	financeH := adminHandler.NewFinanceHandler(settlementSvc, statisticsSvc, withdrawalSvc, exportSvc)
+	financeH.SetReconciliationService(financeService.NewReconciliationService(db))
 
 	// 注册路由
 	admin := r.Group("/api/admin")
@@ -100,13 +107,17 @@ func setupFinanceAPITestRouter(db *gorm.DB, jwtManager *jwt.Manager) *gin.Engine
 			finance.GET("/revenue/daily", financeH.GetDailyRevenueReport)
 			finance.GET("/revenue/by-type", financeH.GetOrderRevenueByType)
 			finance.GET("/transactions/statistics", financeH.GetTransactionStatistics)
+			finance.GET("/statistics/top-devices", financeH.GetTopDevicesByRevenue)
 
 			// 结算管理
 			finance.GET("/settlements", financeH.ListSettlements)
 			finance.POST("/settlements", financeH.CreateSettlement)
 			finance.GET("/settlements/summary", financeH.GetSettlementSummary)
+			finance.GET("/settlements/preview", financeH.PreviewSettlement)
 			finance.POST("/settlements/generate", financeH.GenerateSettlements)
+			finance.POST("/settlements/generate-all", financeH.GenerateAllSettlements)
 			finance.GET("/settlements/:id", financeH.GetSettlement)
+			finance.GET("/settlements/:id/items", financeH.ListSettlementItems)
 			finance.POST("/settlements/:id/process", financeH.ProcessSettlement)
 
 			// 提现管理
@@ -125,7 +136,12 @@ func setupFinanceAPITestRouter(db *gorm.DB, jwtManager *jwt.Manager) *gin.Engine
 			finance.GET("/export/daily-revenue", financeH.ExportDailyRevenue)
 			finance.GET("/export/merchant-settlement", financeH.ExportMerchantSettlement)
 			finance.GET("/export/transactions", financeH.ExportTransactions)
+
+			// 对账
+			finance.GET("/reconciliation", financeH.ListReconciliationIssues)
 		}
+
+		adminAuth.GET("/analytics/churned-users", financeH.GetChurnedUsers)
 	}
 
 	return r
@@ -320,6 +336,147 @@ func TestFinanceAPI_GetRevenueStatistics_MissingParams(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+// TestFinanceAPI_GetTopDevicesByRevenue 测试设备收入排行
+func TestFinanceAPI_GetTopDevicesByRevenue(t *testing.T) {
+	db := setupFinanceAPITestDB(t)
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret-key-for-finance-api",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: time.Hour * 24,
+		Issuer:            "test",
+	})
+	router := setupFinanceAPITestRouter(db, jwtManager)
+
+	admin := createFinanceTestAdmin(t, db)
+	token := generateAdminTestToken(jwtManager, admin.ID)
+
+	merchant := createFinanceTestMerchant(t, db)
+	user := createFinanceTestUser(t, db)
+	venue := &models.Venue{MerchantID: merchant.ID, Name: "收入排行场地", Status: models.VenueStatusActive}
+	require.NoError(t, db.Create(venue).Error)
+
+	amounts := map[string][]float64{"TOP_DEV_1": {10, 15}, "TOP_DEV_2": {40}, "TOP_DEV_3": {5}}
+	for _, deviceNo := range []string{"TOP_DEV_1", "TOP_DEV_2", "TOP_DEV_3"} {
+		device := &models.Device{DeviceNo: deviceNo, Name: "收入排行设备", Type: "standard", VenueID: venue.ID, ProductName: "测试产品", Status: models.DeviceStatusActive}
+		require.NoError(t, db.Create(device).Error)
+		for _, amount := range amounts[deviceNo] {
+			order := createFinanceTestOrder(t, db, user.ID, merchant.ID, amount, models.OrderTypeRental)
+			require.NoError(t, db.Create(&models.Rental{OrderID: order.ID, UserID: user.ID, DeviceID: device.ID, Status: models.RentalStatusCompleted}).Error)
+		}
+	}
+
+	today := time.Now().Format("2006-01-02")
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/admin/finance/statistics/top-devices?start=%s&end=%s&limit=2", today, today), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	items := resp["data"].([]interface{})
+	require.Len(t, items, 2)
+
+	first := items[0].(map[string]interface{})
+	assert.Equal(t, "TOP_DEV_2", first["device_no"])
+	assert.Equal(t, 40.0, first["total_revenue"])
+	assert.Equal(t, "收入排行场地", first["venue_name"])
+	assert.Equal(t, merchant.Name, first["merchant_name"])
+
+	second := items[1].(map[string]interface{})
+	assert.Equal(t, "TOP_DEV_1", second["device_no"])
+	assert.Equal(t, 25.0, second["total_revenue"])
+	assert.Equal(t, float64(2), second["order_count"])
+}
+
+// TestFinanceAPI_GetTopDevicesByRevenue_InvalidParams 测试设备收入排行参数校验
+func TestFinanceAPI_GetTopDevicesByRevenue_InvalidParams(t *testing.T) {
+	db := setupFinanceAPITestDB(t)
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret-key-for-finance-api",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: time.Hour * 24,
+		Issuer:            "test",
+	})
+	router := setupFinanceAPITestRouter(db, jwtManager)
+
+	admin := createFinanceTestAdmin(t, db)
+	token := generateAdminTestToken(jwtManager, admin.ID)
+
+	for _, query := range []string{
+		"",
+		"?start=2026-03-01",
+		"?start=bad&end=2026-03-31",
+		"?start=2026-03-01&end=2026-03-31&limit=0",
+		"?start=2026-03-31&end=2026-03-01",
+	} {
+		req, _ := http.NewRequest("GET", "/api/admin/finance/statistics/top-devices"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+// TestFinanceAPI_GetChurnedUsers 测试流失用户列表
+func TestFinanceAPI_GetChurnedUsers(t *testing.T) {
+	db := setupFinanceAPITestDB(t)
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret-key-for-finance-api",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: time.Hour * 24,
+		Issuer:            "test",
+	})
+	router := setupFinanceAPITestRouter(db, jwtManager)
+
+	admin := createFinanceTestAdmin(t, db)
+	token := generateAdminTestToken(jwtManager, admin.ID)
+
+	merchant := createFinanceTestMerchant(t, db)
+	churned := createFinanceTestUser(t, db)
+	active := createFinanceTestUser(t, db)
+	for _, rental := range []struct {
+		user    *models.User
+		daysAgo int
+	}{{churned, 45}, {active, 3}} {
+		order := createFinanceTestOrder(t, db, rental.user.ID, merchant.ID, 12.5, models.OrderTypeRental)
+		returnedAt := time.Now().AddDate(0, 0, -rental.daysAgo)
+		require.NoError(t, db.Create(&models.Rental{
+			OrderID: order.ID, UserID: rental.user.ID, DeviceID: 1, Status: models.RentalStatusCompleted,
+			ReturnedAt: &returnedAt, CreatedAt: returnedAt.Add(-time.Hour),
+		}).Error)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/admin/analytics/churned-users?inactive_days=30&page=1&page_size=50", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, float64(1), data["total"])
+	list := data["list"].([]interface{})
+	require.Len(t, list, 1)
+	item := list[0].(map[string]interface{})
+	assert.Equal(t, float64(churned.ID), item["user_id"])
+	assert.Contains(t, item["phone"], "****")
+	assert.Equal(t, 12.5, item["total_spend"])
+
+	for _, query := range []string{"?inactive_days=0", "?inactive_days=abc"} {
+		req, _ := http.NewRequest("GET", "/api/admin/analytics/churned-users"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
 // TestFinanceAPI_ListSettlements 测试获取结算列表
 func TestFinanceAPI_ListSettlements(t *testing.T) {
 	db := setupFinanceAPITestDB(t)
@@ -377,6 +534,76 @@ func TestFinanceAPI_GetSettlement(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+// TestFinanceAPI_ListSettlementItems 测试获取结算明细
+func TestFinanceAPI_ListSettlementItems(t *testing.T) {
+	db := setupFinanceAPITestDB(t)
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret-key-for-finance-api",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: time.Hour * 24,
+		Issuer:            "test",
+	})
+	router := setupFinanceAPITestRouter(db, jwtManager)
+
+	admin := createFinanceTestAdmin(t, db)
+	token := generateAdminTestToken(jwtManager, admin.ID)
+
+	merchant := createFinanceTestMerchant(t, db)
+	settlement := createFinanceTestSettlement(t, db, merchant.ID)
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, db.Create(&models.SettlementItem{
+			SettlementID:   settlement.ID,
+			OrderID:        int64(i),
+			OrderNo:        fmt.Sprintf("ORD_ITEM_%d", i),
+			CompletedAt:    time.Now().Add(time.Duration(i) * time.Minute),
+			Amount:         100,
+			CommissionRate: 0.1,
+			MerchantShare:  90,
+		}).Error)
+	}
+
+	t.Run("分页获取明细", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/api/admin/finance/settlements/%d/items?page=1&page_size=2", settlement.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Code int `json:"code"`
+			Data struct {
+				List []struct {
+					OrderNo        string  `json:"order_no"`
+					CompletedAt    string  `json:"completed_at"`
+					Amount         float64 `json:"amount"`
+					CommissionRate float64 `json:"commission_rate"`
+					MerchantShare  float64 `json:"merchant_share"`
+				} `json:"list"`
+				Total int64 `json:"total"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, 0, resp.Code)
+		assert.Equal(t, int64(3), resp.Data.Total)
+		require.Len(t, resp.Data.List, 2)
+		assert.Equal(t, "ORD_ITEM_1", resp.Data.List[0].OrderNo)
+		assert.NotEmpty(t, resp.Data.List[0].CompletedAt)
+		assert.Equal(t, 100.0, resp.Data.List[0].Amount)
+		assert.Equal(t, 0.1, resp.Data.List[0].CommissionRate)
+		assert.Equal(t, 90.0, resp.Data.List[0].MerchantShare)
+	})
+
+	t.Run("结算不存在", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/admin/finance/settlements/99999/items", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
 // TestFinanceAPI_CreateSettlement 测试创建结算
 func TestFinanceAPI_CreateSettlement(t *testing.T) {
 	db := setupFinanceAPITestDB(t)
@@ -411,8 +638,8 @@ func TestFinanceAPI_CreateSettlement(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
-// TestFinanceAPI_ProcessSettlement 测试处理结算
-func TestFinanceAPI_ProcessSettlement(t *testing.T) {
+// TestFinanceAPI_GenerateAllSettlements 测试一键生成结算，部分商户失败不影响分销商结算
+func TestFinanceAPI_GenerateAllSettlements(t *testing.T) {
 	db := setupFinanceAPITestDB(t)
 	jwtManager := jwt.NewManager(&jwt.Config{
 		Secret:            "test-secret-key-for-finance-api",
@@ -425,16 +652,208 @@ func TestFinanceAPI_ProcessSettlement(t *testing.T) {
 	admin := createFinanceTestAdmin(t, db)
 	token := generateAdminTestToken(jwtManager, admin.ID)
 
+	periodStart := time.Now().AddDate(0, 0, -7).Truncate(24 * time.Hour)
+	periodEnd := time.Now().AddDate(0, 0, 1).Truncate(24 * time.Hour)
+
+	// 商户在同一周期已有结算记录
 	merchant := createFinanceTestMerchant(t, db)
-	settlement := createFinanceTestSettlement(t, db, merchant.ID)
+	require.NoError(t, db.Create(&models.Settlement{
+		SettlementNo: fmt.Sprintf("SET%d", time.Now().UnixNano()),
+		Type:         models.SettlementTypeMerchant,
+		TargetID:     merchant.ID,
+		PeriodStart:  periodStart,
+		PeriodEnd:    periodEnd,
+		Status:       models.SettlementStatusPending,
+	}).Error)
+
+	user := createFinanceTestUser(t, db)
+	distributor := &models.Distributor{UserID: user.ID, InviteCode: fmt.Sprintf("INV%d", user.ID), Level: 1, Status: 1}
+	require.NoError(t, db.Create(distributor).Error)
+	order := createFinanceTestOrder(t, db, user.ID, merchant.ID, 100.0, models.OrderTypeRental)
+	require.NoError(t, db.Create(&models.Commission{
+		DistributorID: distributor.ID,
+		OrderID:       order.ID,
+		FromUserID:    user.ID,
+		Type:          models.CommissionTypeDirect,
+		OrderAmount:   100.0,
+		Rate:          0.1,
+		Amount:        10.0,
+		Status:        models.CommissionStatusPending,
+	}).Error)
 
-	req, _ := http.NewRequest("POST", fmt.Sprintf("/api/admin/finance/settlements/%d/process", settlement.ID), nil)
+	reqBody := map[string]interface{}{
+		"period_start": periodStart.Format("2006-01-02"),
+		"period_end":   periodEnd.Format("2006-01-02"),
+	}
+	bodyBytes, _ := json.Marshal(reqBody)
+
+	req, _ := http.NewRequest("POST", "/api/admin/finance/settlements/generate-all", bytes.NewBuffer(bodyBytes))
 	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Code int                              `json:"code"`
+		Data financeService.GenerateAllResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 0, resp.Code)
+	assert.Empty(t, resp.Data.MerchantSettlements)
+	require.Len(t, resp.Data.DistributorSettlements, 1)
+	assert.Equal(t, distributor.ID, resp.Data.DistributorSettlements[0].TargetID)
+	require.Len(t, resp.Data.Errors, 1)
+	assert.Equal(t, merchant.ID, resp.Data.Errors[0].TargetID)
+	assert.Equal(t, models.SettlementTypeMerchant, resp.Data.Errors[0].Type)
+}
+
+func TestFinanceAPI_ListReconciliationIssues(t *testing.T) {
+	db := setupFinanceAPITestDB(t)
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret-key-for-finance-api",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: time.Hour * 24,
+		Issuer:            "test",
+	})
+	router := setupFinanceAPITestRouter(db, jwtManager)
+
+	admin := createFinanceTestAdmin(t, db)
+	token := generateAdminTestToken(jwtManager, admin.ID)
+
+	// 已支付但无支付记录的商城订单
+	day := time.Date(2026, 3, 12, 0, 0, 0, 0, time.Local)
+	paidAt := day.Add(10 * time.Hour)
+	user := createFinanceTestUser(t, db)
+	order := &models.Order{
+		OrderNo:        fmt.Sprintf("RCAPI%d", time.Now().UnixNano()),
+		UserID:         user.ID,
+		Type:           models.OrderTypeMall,
+		OriginalAmount: 66,
+		ActualAmount:   66,
+		Status:         models.OrderStatusPaid,
+		PaidAt:         &paidAt,
+	}
+	require.NoError(t, db.Create(order).Error)
+
+	_, err := financeService.NewReconciliationService(db).RunDaily(context.Background(), day)
+	require.NoError(t, err)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/admin/finance/reconciliation"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("?date=2026-03-12")
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Code int `json:"code"`
+		Data struct {
+			List  []models.ReconciliationIssue `json:"list"`
+			Total int64                        `json:"total"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, int64(1), resp.Data.Total)
+	require.Len(t, resp.Data.List, 1)
+	assert.Equal(t, models.ReconciliationIssuePaymentMissing, resp.Data.List[0].Type)
+	assert.Equal(t, order.OrderNo, *resp.Data.List[0].OrderNo)
+	assert.Equal(t, 66.0, resp.Data.List[0].ExpectedAmount)
+
+	w = get("?date=2026-03-12&type=" + models.ReconciliationIssueDuplicatePayment)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, int64(0), resp.Data.Total)
+
+	assert.Equal(t, http.StatusBadRequest, get("").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?date=2026/03/12").Code)
+}
+
+// TestFinanceAPI_PreviewSettlement 测试预览结算
+func TestFinanceAPI_PreviewSettlement(t *testing.T) {
+	db := setupFinanceAPITestDB(t)
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret-key-for-finance-api",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: time.Hour * 24,
+		Issuer:            "test",
+	})
+	router := setupFinanceAPITestRouter(db, jwtManager)
+
+	admin := createFinanceTestAdmin(t, db)
+	token := generateAdminTestToken(jwtManager, admin.ID)
+
+	merchant := createFinanceTestMerchant(t, db)
+
+	t.Run("预览不生成结算记录", func(t *testing.T) {
+		url := fmt.Sprintf("/api/admin/finance/settlements/preview?type=merchant&target_id=%d&period_start=%s&period_end=%s",
+			merchant.ID, time.Now().AddDate(0, 0, -7).Format("2006-01-02"), time.Now().Format("2006-01-02"))
+		req, _ := http.NewRequest("GET", url, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, float64(0), resp["code"])
+		data := resp["data"].(map[string]interface{})
+		assert.Equal(t, "merchant", data["type"])
+		assert.Contains(t, data, "order_ids")
+
+		var count int64
+		db.Model(&models.Settlement{}).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("无效的结算类型", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/admin/finance/settlements/preview?type=unknown&target_id=1&period_start=2024-01-01&period_end=2024-01-31", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+// TestFinanceAPI_ProcessSettlement 测试处理结算
+func TestFinanceAPI_ProcessSettlement(t *testing.T) {
+	db := setupFinanceAPITestDB(t)
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret-key-for-finance-api",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: time.Hour * 24,
+		Issuer:            "test",
+	})
+	router := setupFinanceAPITestRouter(db, jwtManager)
+
+	admin := createFinanceTestAdmin(t, db)
+	token := generateAdminTestToken(jwtManager, admin.ID)
+
+	merchant := createFinanceTestMerchant(t, db)
+	settlement := createFinanceTestSettlement(t, db, merchant.ID)
+
+	process := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/api/admin/finance/settlements/%d/process", settlement.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 未审核通过的结算不能入账
+	assert.Equal(t, http.StatusBadRequest, process().Code)
+
+	require.NoError(t, db.Model(settlement).Update("status", models.SettlementStatusApproved).Error)
+	assert.Equal(t, http.StatusOK, process().Code)
 }
 
 // TestFinanceAPI_ListWithdrawals 测试获取提现列表
@@ -696,6 +1115,99 @@ func TestFinanceAPI_ExportSettlements(t *testing.T) {
 	assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
 }
 
+// TestFinanceAPI_ExportFormats 测试按导出格式切换 Content-Type
+func TestFinanceAPI_ExportFormats(t *testing.T) {
+	db := setupFinanceAPITestDB(t)
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret-key-for-finance-api",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: time.Hour * 24,
+		Issuer:            "test",
+	})
+	router := setupFinanceAPITestRouter(db, jwtManager)
+
+	admin := createFinanceTestAdmin(t, db)
+	token := generateAdminTestToken(jwtManager, admin.ID)
+
+	merchant := createFinanceTestMerchant(t, db)
+	createFinanceTestSettlement(t, db, merchant.ID)
+
+	xlsxContentType := "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	paths := []string{
+		"/api/admin/finance/export/settlements",
+		"/api/admin/finance/export/withdrawals",
+		"/api/admin/finance/export/transactions",
+		"/api/admin/finance/export/daily-revenue?start_date=2024-01-01&end_date=2024-01-03",
+	}
+	withFormat := func(path, format string) string {
+		if strings.Contains(path, "?") {
+			return path + "&format=" + format
+		}
+		return path + "?format=" + format
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", withFormat(path, "xlsx"), nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, xlsxContentType, w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Header().Get("Content-Disposition"), ".xlsx")
+			// XLSX 为 zip 格式
+			assert.True(t, bytes.HasPrefix(w.Body.Bytes(), []byte("PK")))
+
+			req, _ = http.NewRequest("GET", withFormat(path, "pdf"), nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Empty(t, w.Header().Get("Content-Disposition"))
+		})
+	}
+}
+
+// TestFinanceAPI_ExportTransactions 测试流式导出交易记录
+func TestFinanceAPI_ExportTransactions(t *testing.T) {
+	db := setupFinanceAPITestDB(t)
+	jwtManager := jwt.NewManager(&jwt.Config{
+		Secret:            "test-secret-key-for-finance-api",
+		AccessExpireTime:  time.Hour,
+		RefreshExpireTime: time.Hour * 24,
+		Issuer:            "test",
+	})
+	router := setupFinanceAPITestRouter(db, jwtManager)
+
+	admin := createFinanceTestAdmin(t, db)
+	token := generateAdminTestToken(jwtManager, admin.ID)
+
+	user := createFinanceTestUser(t, db)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, db.Create(&models.WalletTransaction{
+			UserID:        user.ID,
+			Type:          models.WalletTxTypeRecharge,
+			Amount:        10,
+			BalanceBefore: float64(i * 10),
+			BalanceAfter:  float64((i + 1) * 10),
+		}).Error)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/admin/finance/export/transactions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "transactions_")
+	assert.True(t, w.Flushed)
+	assert.Equal(t, 4, strings.Count(w.Body.String(), "\n"))
+}
+
 // TestFinanceAPI_ExportWithdrawals 测试导出提现记录
 func TestFinanceAPI_ExportWithdrawals(t *testing.T) {
 	db := setupFinanceAPITestDB(t)
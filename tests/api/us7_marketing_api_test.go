@@ -10,11 +10,14 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
@@ -66,8 +69,8 @@ func setupMarketingAPITestDB(t *testing.T) *gorm.DB {
 	return db
 }
 
-// setupMarketingAPITestRouter 创建测试路由
-func setupMarketingAPITestRouter(db *gorm.DB, jwtManager *jwt.Manager) *gin.Engine {
+// setupMarketingAPITestRouter 创建测试路由，receiveMiddleware 挂载在领取优惠券接口上
+func setupMarketingAPITestRouter(db *gorm.DB, jwtManager *jwt.Manager, receiveMiddleware ...gin.HandlerFunc) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 
@@ -89,7 +92,7 @@ func setupMarketingAPITestRouter(db *gorm.DB, jwtManager *jwt.Manager) *gin.Engi
 			// 可领取的优惠券
 			marketing.GET("/coupons", handler.GetCouponList)
 			marketing.GET("/coupons/:id", handler.GetCouponDetail)
-			marketing.POST("/coupons/:id/receive", handler.ReceiveCoupon)
+			marketing.POST("/coupons/:id/receive", append(receiveMiddleware, handler.ReceiveCoupon)...)
 
 			// 用户优惠券
 			marketing.GET("/user-coupons", handler.GetUserCoupons)
@@ -365,6 +368,53 @@ func TestMarketingAPI_ReceiveCoupon(t *testing.T) {
 	})
 }
 
+func TestMarketingAPI_ReceiveCoupon_RateLimit(t *testing.T) {
+	db := setupMarketingAPITestDB(t)
+	jwtManager := createMarketingAPITestJWTManager()
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer redisClient.Close()
+
+	router := setupMarketingAPITestRouter(db, jwtManager, middleware.CouponReceiveRateLimit(redisClient))
+
+	user := createMarketingAPITestUser(db)
+	token := generateMarketingTestToken(jwtManager, user.ID)
+	coupon := createMarketingAPITestCoupon(db)
+
+	receive := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/marketing/coupons/%d/receive", coupon.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 前 10 次尝试均会到达业务处理（超过每人限领数量的返回业务错误）
+	for i := 0; i < middleware.CouponReceiveLimit; i++ {
+		w := receive(token)
+		assert.NotEqual(t, http.StatusTooManyRequests, w.Code, "第 %d 次请求", i+1)
+	}
+
+	// 第 11 次被限流
+	w := receive(token)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	assert.True(t, retryAfter >= 1 && retryAfter <= 60, "Retry-After=%d", retryAfter)
+	assert.True(t, mr.Exists(fmt.Sprintf("rate:coupon:receive:%d", user.ID)))
+
+	// 被限流的请求不会领取优惠券
+	var updatedCoupon models.Coupon
+	require.NoError(t, db.First(&updatedCoupon, coupon.ID).Error)
+	assert.Equal(t, 3, updatedCoupon.ReceivedCount)
+
+	// 其他用户不受影响
+	other := createMarketingAPITestUser(db)
+	w = receive(generateMarketingTestToken(jwtManager, other.ID))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
 func TestMarketingAPI_GetUserCoupons(t *testing.T) {
 	t.Run("正常获取用户优惠券列表", func(t *testing.T) {
 		db := setupMarketingAPITestDB(t)
@@ -49,6 +49,7 @@ func setupMemberAPITestDB(t *testing.T) *gorm.DB {
 		&models.Order{},
 		&models.OrderItem{},
 		&models.WalletTransaction{},
+		&models.UserPointsLog{},
 	))
 
 	db.Create(&models.MemberLevel{
@@ -66,11 +66,13 @@ func setupUS1E2E(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager, *rentalServ
 		&models.Merchant{},
 		&models.Venue{},
 		&models.Device{},
+		&models.DeviceSlot{},
 		&models.RentalPricing{},
 		&models.Order{},
 		&models.Rental{},
 		&models.Payment{},
 		&models.Refund{},
+		&models.OrderNote{},
 	))
 
 	db.Create(&models.MemberLevel{ID: 1, Name: "普通会员", Level: 1, MinPoints: 0, Discount: 1.0})
@@ -91,9 +93,9 @@ func setupUS1E2E(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager, *rentalServ
 
 	deviceSvc := deviceService.NewDeviceService(db, deviceRepo, venueRepo)
 	venueSvc := deviceService.NewVenueService(db, venueRepo, deviceRepo)
-	walletSvc := userService.NewWalletService(db, userRepo)
-	rentalSvc := rentalService.NewRentalService(db, rentalRepo, deviceRepo, deviceSvc, walletSvc, nil)
-	paymentSvc := paymentService.NewPaymentService(db, paymentRepo, refundRepo, rentalRepo, nil)
+	walletSvc := userService.NewWalletService(db, userRepo, nil)
+	rentalSvc := rentalService.NewRentalService(db, rentalRepo, deviceRepo, deviceSvc, walletSvc, nil, nil, nil)
+	paymentSvc := paymentService.NewPaymentService(db, paymentRepo, refundRepo, rentalRepo, nil, nil)
 
 	deviceH := deviceHandler.NewHandler(deviceSvc, venueSvc)
 	rentalH := rentalHandler.NewHandler(rentalSvc)
@@ -62,11 +62,13 @@ func setupUS2AdminDeviceE2E(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager,
 	jwtManager := jwt.NewManager(&jwt.Config{Secret: "test-secret-key-us2-e2e", AccessExpireTime: time.Hour, RefreshExpireTime: 2 * time.Hour, Issuer: "test"})
 
 	deviceRepo := repository.NewDeviceRepository(db)
+
+	deviceSlotRepo := repository.NewDeviceSlotRepository(db)
 	deviceLogRepo := repository.NewDeviceLogRepository(db)
 	deviceMaintenanceRepo := repository.NewDeviceMaintenanceRepository(db)
 	venueRepo := repository.NewVenueRepository(db)
 
-	adminDeviceSvc := adminService.NewDeviceAdminService(deviceRepo, deviceLogRepo, deviceMaintenanceRepo, venueRepo, nil)
+	adminDeviceSvc := adminService.NewDeviceAdminService(deviceRepo, deviceSlotRepo, deviceLogRepo, deviceMaintenanceRepo, venueRepo, nil)
 	adminDeviceH := adminHandler.NewDeviceHandler(adminDeviceSvc)
 
 	deviceSvc := deviceService.NewDeviceService(db, deviceRepo, venueRepo)
@@ -61,10 +61,13 @@ func setupUS3E2E(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager) {
 		&models.Category{},
 		&models.Product{},
 		&models.ProductSku{},
+		&models.ProductSearchTerm{},
 		&models.CartItem{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.GiftOrder{},
 		&models.Review{},
+		&models.ReviewReply{},
 	))
 
 	db.Create(&models.MemberLevel{ID: 1, Name: "普通会员", Level: 1, MinPoints: 0, Discount: 1.0})
@@ -88,7 +91,7 @@ func setupUS3E2E(t *testing.T) (*gin.Engine, *gorm.DB, *jwt.Manager) {
 	productSvc := mallService.NewProductService(db, productRepo, categoryRepo, skuRepo)
 	searchSvc := mallService.NewSearchService(db, productRepo)
 	cartSvc := mallService.NewCartService(db, cartRepo, productRepo, skuRepo)
-	orderSvc := mallService.NewMallOrderService(db, orderRepo, cartRepo, productRepo, skuRepo, productSvc)
+	orderSvc := mallService.NewMallOrderService(db, orderRepo, cartRepo, productRepo, skuRepo, productSvc, repository.NewRefundRepository(db), repository.NewPaymentRepository(db))
 	reviewSvc := mallService.NewReviewService(db, reviewRepo, orderRepo)
 
 	// 创建 handlers
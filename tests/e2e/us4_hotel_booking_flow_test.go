@@ -43,6 +43,7 @@ func setupHotelE2ETestDB(t *testing.T) *gorm.DB {
 		&models.Room{},
 		&models.RoomTimeSlot{},
 		&models.Booking{},
+		&models.RoomOccupancy{},
 	)
 	require.NoError(t, err)
 
@@ -45,7 +45,9 @@ func setupDistributionE2ETestDB(t *testing.T) *gorm.DB {
 		&models.Order{},
 		&models.Distributor{},
 		&models.Commission{},
+		&models.DistributorCommissionConfig{},
 		&models.Withdrawal{},
+		&models.WithdrawalPolicy{},
 	)
 	require.NoError(t, err)
 
@@ -324,7 +326,8 @@ func TestE2E_DistributionCompleteFlow(t *testing.T) {
 		t.Logf("分销商已有可用佣金: 50.00 元")
 
 		// Step 1: 获取提现配置
-		config := tc.withdrawSvc.GetConfig()
+		config, err := tc.withdrawSvc.GetConfig(ctx, models.WithdrawalTypeCommission)
+		require.NoError(t, err)
 		t.Logf("Step 1: 提现配置 - 最低金额: %.2f, 手续费率: %.4f",
 			config["min_withdraw"], config["withdraw_fee"])
 
@@ -53,10 +53,14 @@ func setupFinanceE2ETestDB(t *testing.T) *gorm.DB {
 		&models.Rental{},
 		&models.Payment{},
 		&models.Refund{},
+		&models.OrderNote{},
 		&models.Settlement{},
+		&models.SettlementItem{},
 		&models.WalletTransaction{},
 		&models.Withdrawal{},
+		&models.WithdrawalAuditLog{},
 		&models.Commission{},
+		&models.FinanceDailySummary{},
 	)
 	require.NoError(t, err)
 
@@ -79,7 +83,7 @@ func setupFinanceE2ERouter(db *gorm.DB, jwtManager *jwt.Manager) *gin.Engine {
 	withdrawalRepo := repository.NewWithdrawalRepository(db)
 
 	// 初始化服务
-	settlementSvc := financeService.NewSettlementService(db, settlementRepo, orderRepo, merchantRepo, commissionRepo, distributorRepo)
+	settlementSvc := financeService.NewSettlementService(db, settlementRepo, orderRepo, merchantRepo, commissionRepo, distributorRepo, nil)
 	statisticsSvc := financeService.NewStatisticsService(db, settlementRepo, transactionRepo, orderRepo, paymentRepo, commissionRepo, withdrawalRepo)
 	withdrawalSvc := financeService.NewWithdrawalAuditService(db, withdrawalRepo, distributorRepo)
 	exportSvc := financeService.NewExportService(db, settlementRepo, transactionRepo, orderRepo, withdrawalRepo)
@@ -251,7 +255,12 @@ func TestE2E_MerchantSettlementCompleteFlow(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, float64(0), detailResp["code"])
 
-	// Step 5: 处理结算
+	// Step 5: 未审核的结算不能处理，审核通过后处理结算
+	w = ctx.makeRequest("POST", fmt.Sprintf("/api/admin/finance/settlements/%d/process", settlementID), nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	require.NoError(t, ctx.DB.Model(&models.Settlement{}).Where("id = ?", settlementID).
+		Update("status", models.SettlementStatusApproved).Error)
 	w = ctx.makeRequest("POST", fmt.Sprintf("/api/admin/finance/settlements/%d/process", settlementID), nil)
 	assert.Equal(t, http.StatusOK, w.Code)
 
@@ -639,7 +648,9 @@ func TestE2E_DistributorSettlementFlow(t *testing.T) {
 	data := createResp["data"].(map[string]interface{})
 	settlementID := int64(data["id"].(float64))
 
-	// Step 4: 处理结算
+	// Step 4: 审核通过后处理结算
+	require.NoError(t, ctx.DB.Model(&models.Settlement{}).Where("id = ?", settlementID).
+		Update("status", models.SettlementStatusApproved).Error)
 	w = ctx.makeRequest("POST", fmt.Sprintf("/api/admin/finance/settlements/%d/process", settlementID), nil)
 	assert.Equal(t, http.StatusOK, w.Code)
 
@@ -3,7 +3,6 @@ package e2e
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -64,7 +63,7 @@ func setupMarketingE2ETestContext(t *testing.T) *marketingE2ETestContext {
 
 	couponSvc := marketingService.NewCouponService(db, couponRepo, userCouponRepo)
 	userCouponSvc := marketingService.NewUserCouponService(db, couponRepo, userCouponRepo)
-	campaignSvc := marketingService.NewCampaignService(campaignRepo)
+	campaignSvc := marketingService.NewCampaignService(db, campaignRepo)
 	discountCalc := orderService.NewDiscountCalculator(couponSvc, campaignSvc)
 
 	return &marketingE2ETestContext{
@@ -117,9 +116,8 @@ func createE2EMarketingCoupon(t *testing.T, db *gorm.DB, name string, couponType
 
 // createE2EMarketingCampaign 创建E2E测试满减活动
 func createE2EMarketingCampaign(t *testing.T, db *gorm.DB, name string, rules []marketingService.DiscountRule) *models.Campaign {
-	rulesJSON, _ := json.Marshal(rules)
-	rulesMap := make(models.JSON)
-	json.Unmarshal(rulesJSON, &rulesMap)
+	rulesJSON, err := marketingService.EncodeDiscountRules(rules)
+	require.NoError(t, err)
 
 	campaign := &models.Campaign{
 		Name:      name,
@@ -127,7 +125,7 @@ func createE2EMarketingCampaign(t *testing.T, db *gorm.DB, name string, rules []
 		StartTime: time.Now().Add(-time.Hour),
 		EndTime:   time.Now().Add(24 * time.Hour),
 		Status:    models.CampaignStatusActive,
-		Rules:     rulesMap,
+		Rules:     rulesJSON,
 	}
 
 	// 保存原始状态值（GORM 会跳过零值）
@@ -210,13 +208,13 @@ func TestE2E_MarketingCompleteFlow(t *testing.T) {
 
 		// Step 7: 用户准备下单，查询订单可用优惠券
 		orderAmount := 150.0
-		availableCoupons, err := tc.userCouponSvc.GetAvailableCouponsForOrder(ctx, user.ID, models.CouponScopeAll, orderAmount)
+		availableCoupons, err := tc.userCouponSvc.GetAvailableCouponsForOrder(ctx, user.ID, models.CouponScopeAll, orderAmount, nil)
 		require.NoError(t, err)
 		assert.Len(t, availableCoupons, 1)
 		t.Logf("Step 7: 订单金额 %.2f，可用优惠券数量: %d", orderAmount, len(availableCoupons))
 
 		// Step 8: 计算订单优惠
-		discountResult, err := tc.discountCalculator.CalculateOrderDiscount(ctx, user.ID, models.CouponScopeAll, orderAmount, &userCoupon.ID)
+		discountResult, err := tc.discountCalculator.CalculateOrderDiscount(ctx, user.ID, models.CouponScopeAll, orderAmount, nil, &userCoupon.ID)
 		require.NoError(t, err)
 		assert.Equal(t, 20.0, discountResult.CouponDiscount)
 		assert.Equal(t, 130.0, discountResult.FinalAmount)
@@ -286,17 +284,13 @@ func TestE2E_MarketingCompleteFlow(t *testing.T) {
 		t.Logf("优惠券状态: 未使用（已恢复）")
 
 		// 验证优惠券可再次使用
-		available, err := tc.userCouponSvc.GetAvailableCouponsForOrder(ctx, user.ID, models.CouponScopeAll, 100.0)
+		available, err := tc.userCouponSvc.GetAvailableCouponsForOrder(ctx, user.ID, models.CouponScopeAll, 100.0, nil)
 		require.NoError(t, err)
 		assert.Len(t, available, 1)
 		t.Logf("优惠券可再次使用: %v", len(available) > 0)
 	})
 
 	t.Run("场景3: 优惠券与满减活动叠加使用", func(t *testing.T) {
-		// 跳过：活动规则存储为 map[string]interface{}，无法正确解析为 []DiscountRule
-		// 这是服务层已知限制，需要修改 models.JSON 的存储方式才能支持
-		t.Skip("Skip: Campaign rules JSON parsing has known limitation")
-
 		// 创建用户
 		user := createE2EMarketingUser(t, tc.db, "13800138003", 1000.0)
 		t.Logf("用户创建成功，用户ID: %d", user.ID)
@@ -319,7 +313,7 @@ func TestE2E_MarketingCompleteFlow(t *testing.T) {
 		orderAmount := 250.0
 
 		// 计算优惠（使用优惠券）
-		result, err := tc.discountCalculator.CalculateOrderDiscount(ctx, user.ID, models.CouponScopeAll, orderAmount, &userCoupon.ID)
+		result, err := tc.discountCalculator.CalculateOrderDiscount(ctx, user.ID, models.CouponScopeAll, orderAmount, nil, &userCoupon.ID)
 		require.NoError(t, err)
 
 		t.Logf("订单优惠计算结果:")
@@ -341,10 +335,6 @@ func TestE2E_MarketingCompleteFlow(t *testing.T) {
 	})
 
 	t.Run("场景4: 自动选择最优优惠券", func(t *testing.T) {
-		// 跳过：CalculateOrderDiscount 内部调用 CalculateDiscountCampaign，会触发活动规则解析错误
-		// 这是服务层已知限制，需要修改 models.JSON 的存储方式才能支持
-		t.Skip("Skip: Campaign rules JSON parsing has known limitation")
-
 		// 创建用户
 		user := createE2EMarketingUser(t, tc.db, "13800138004", 1000.0)
 		t.Logf("用户创建成功，用户ID: %d", user.ID)
@@ -362,7 +352,7 @@ func TestE2E_MarketingCompleteFlow(t *testing.T) {
 
 		// 订单金额150，应该自动选择满100减25
 		orderAmount := 150.0
-		result, err := tc.discountCalculator.CalculateOrderDiscount(ctx, user.ID, models.CouponScopeAll, orderAmount, nil)
+		result, err := tc.discountCalculator.CalculateOrderDiscount(ctx, user.ID, models.CouponScopeAll, orderAmount, nil, nil)
 		require.NoError(t, err)
 
 		assert.Equal(t, 25.0, result.CouponDiscount)
@@ -372,7 +362,7 @@ func TestE2E_MarketingCompleteFlow(t *testing.T) {
 
 		// 订单金额250，应该自动选择满200减50
 		orderAmount2 := 250.0
-		result2, err := tc.discountCalculator.CalculateOrderDiscount(ctx, user.ID, models.CouponScopeAll, orderAmount2, nil)
+		result2, err := tc.discountCalculator.CalculateOrderDiscount(ctx, user.ID, models.CouponScopeAll, orderAmount2, nil, nil)
 		require.NoError(t, err)
 
 		assert.Equal(t, 50.0, result2.CouponDiscount)
@@ -574,10 +564,6 @@ func TestE2E_MarketingCampaign(t *testing.T) {
 	})
 
 	t.Run("场景2: 多档满减活动计算", func(t *testing.T) {
-		// 跳过：活动规则存储为 map[string]interface{}，无法正确解析为 []DiscountRule
-		// 这是服务层已知限制，需要修改 models.JSON 的存储方式才能支持
-		t.Skip("Skip: Campaign rules JSON parsing has known limitation")
-
 		// 创建多档满减活动
 		rules := []marketingService.DiscountRule{
 			{MinAmount: 50, Discount: 5},
@@ -667,7 +653,7 @@ func TestE2E_MarketingPercentCoupon(t *testing.T) {
 
 		// 计算折扣
 		orderAmount := 200.0
-		result, err := tc.discountCalculator.CalculateOrderDiscount(ctx, user.ID, models.CouponScopeAll, orderAmount, &userCoupon.ID)
+		result, err := tc.discountCalculator.CalculateOrderDiscount(ctx, user.ID, models.CouponScopeAll, orderAmount, nil, &userCoupon.ID)
 		require.NoError(t, err)
 
 		expectedDiscount := 20.0 // 200 * 10%
@@ -701,7 +687,7 @@ func TestE2E_MarketingPercentCoupon(t *testing.T) {
 
 		// 订单金额500，20%折扣应为100，但最高只能50
 		orderAmount := 500.0
-		result, err := tc.discountCalculator.CalculateOrderDiscount(ctx, user.ID, models.CouponScopeAll, orderAmount, &userCoupon.ID)
+		result, err := tc.discountCalculator.CalculateOrderDiscount(ctx, user.ID, models.CouponScopeAll, orderAmount, nil, &userCoupon.ID)
 		require.NoError(t, err)
 
 		assert.Equal(t, 50.0, result.CouponDiscount) // 受最高优惠限制
@@ -770,19 +756,19 @@ func TestE2E_MarketingCouponScope(t *testing.T) {
 		t.Logf("用户领取了3张优惠券: 全场通用、商城专用、租借专用")
 
 		// 商城订单应该能使用: 全场通用 + 商城专用
-		mallAvailable, err := tc.userCouponSvc.GetAvailableCouponsForOrder(ctx, user.ID, "mall", 100.0)
+		mallAvailable, err := tc.userCouponSvc.GetAvailableCouponsForOrder(ctx, user.ID, "mall", 100.0, nil)
 		require.NoError(t, err)
 		assert.Len(t, mallAvailable, 2)
 		t.Logf("商城订单可用优惠券: %d 张", len(mallAvailable))
 
 		// 租借订单应该能使用: 全场通用 + 租借专用
-		rentalAvailable, err := tc.userCouponSvc.GetAvailableCouponsForOrder(ctx, user.ID, "rental", 100.0)
+		rentalAvailable, err := tc.userCouponSvc.GetAvailableCouponsForOrder(ctx, user.ID, "rental", 100.0, nil)
 		require.NoError(t, err)
 		assert.Len(t, rentalAvailable, 2)
 		t.Logf("租借订单可用优惠券: %d 张", len(rentalAvailable))
 
 		// 全场订单（scope=all）仅能使用全场通用券
-		allAvailable, err := tc.userCouponSvc.GetAvailableCouponsForOrder(ctx, user.ID, models.CouponScopeAll, 100.0)
+		allAvailable, err := tc.userCouponSvc.GetAvailableCouponsForOrder(ctx, user.ID, models.CouponScopeAll, 100.0, nil)
 		require.NoError(t, err)
 		assert.Len(t, allAvailable, 1)
 		t.Logf("全场订单可用优惠券: %d 张", len(allAvailable))
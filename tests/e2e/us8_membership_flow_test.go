@@ -40,6 +40,7 @@ func setupMembershipE2ETestDB(t *testing.T) *gorm.DB {
 		&models.User{},
 		&models.MemberLevel{},
 		&models.WalletTransaction{},
+		&models.UserPointsLog{},
 		&models.Order{},
 	))
 
@@ -45,6 +45,7 @@ func setupAdminIntegrationDB(t *testing.T) *gorm.DB {
 		&models.Merchant{},
 		&models.Venue{},
 		&models.Device{},
+		&models.DeviceSlot{},
 		&models.DeviceLog{},
 		&models.DeviceMaintenance{},
 	)
@@ -70,13 +71,14 @@ func setupAdminTestEnvironment(t *testing.T, db *gorm.DB) (
 
 	adminRepo := repository.NewAdminRepository(db)
 	deviceRepo := repository.NewDeviceRepository(db)
+	deviceSlotRepo := repository.NewDeviceSlotRepository(db)
 	deviceLogRepo := repository.NewDeviceLogRepository(db)
 	deviceMaintenanceRepo := repository.NewDeviceMaintenanceRepository(db)
 	venueRepo := repository.NewVenueRepository(db)
 	merchantRepo := repository.NewMerchantRepository(db)
 
 	authService := adminService.NewAdminAuthService(adminRepo, jwtManager)
-	deviceService := adminService.NewDeviceAdminService(deviceRepo, deviceLogRepo, deviceMaintenanceRepo, venueRepo, nil)
+	deviceService := adminService.NewDeviceAdminService(deviceRepo, deviceSlotRepo, deviceLogRepo, deviceMaintenanceRepo, venueRepo, nil)
 	venueService := adminService.NewVenueAdminService(venueRepo, merchantRepo, deviceRepo)
 	merchantService := adminService.NewMerchantAdminService(merchantRepo, nil)
 
@@ -43,7 +43,9 @@ func setupDistributionIntegrationDB(t *testing.T) *gorm.DB {
 		&models.Order{},
 		&models.Distributor{},
 		&models.Commission{},
+		&models.DistributorCommissionConfig{},
 		&models.Withdrawal{},
+		&models.WithdrawalPolicy{},
 		&models.Admin{},
 	)
 	require.NoError(t, err)
@@ -450,6 +452,98 @@ func TestDistributionFlow_RefundCancelCommission(t *testing.T) {
 	})
 }
 
+// TestDistributionFlow_InviteBindingCommission 测试绑定邀请人后两级佣金生成及退款撤销
+func TestDistributionFlow_InviteBindingCommission(t *testing.T) {
+	db := setupDistributionIntegrationDB(t)
+	distributorSvc, commissionSvc, _, orderHook := setupDistributionIntegrationServices(db)
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&[]models.DistributorCommissionConfig{
+		{Level: models.DistributorLevelDirect, Rate: 0.15},
+		{Level: models.DistributorLevelIndirect, Rate: 0.05},
+	}).Error)
+
+	// approvedDistributor 申请并审核通过分销商
+	approvedDistributor := func(user *models.User, inviteCode *string) *models.Distributor {
+		resp, err := distributorSvc.Apply(ctx, &distributionService.ApplyRequest{UserID: user.ID, InviteCode: inviteCode})
+		require.NoError(t, err)
+		require.NoError(t, distributorSvc.Approve(ctx, &distributionService.ApproveRequest{
+			DistributorID: resp.Distributor.ID,
+			OperatorID:    1,
+			Approved:      true,
+		}))
+		distributor, err := distributorSvc.GetByID(ctx, resp.Distributor.ID)
+		require.NoError(t, err)
+		return distributor
+	}
+
+	// completedOrder 创建已完成订单
+	completedOrder := func(userID int64, amount float64) *models.Order {
+		now := time.Now()
+		order := &models.Order{
+			OrderNo:        fmt.Sprintf("O%d", time.Now().UnixNano()),
+			UserID:         userID,
+			Type:           models.OrderTypeMall,
+			OriginalAmount: amount,
+			ActualAmount:   amount,
+			Status:         models.OrderStatusCompleted,
+			PaidAt:         &now,
+			CompletedAt:    &now,
+		}
+		require.NoError(t, db.Create(order).Error)
+		return order
+	}
+
+	t.Run("两级邀请链生成佣金并在退款后撤销", func(t *testing.T) {
+		grandUser := createIntegrationTestUser(db, nil)
+		grand := approvedDistributor(grandUser, nil)
+		parentUser := createIntegrationTestUser(db, nil)
+		parent := approvedDistributor(parentUser, &grand.InviteCode)
+		require.NotNil(t, parent.ParentID)
+
+		buyer := createIntegrationTestUser(db, nil)
+		require.NoError(t, distributorSvc.BindInviter(ctx, buyer.ID, parent.InviteCode))
+
+		order := completedOrder(buyer.ID, 200.0)
+		require.NoError(t, orderHook.OnOrderCompleted(ctx, order))
+
+		commissions, err := commissionSvc.GetByOrderID(ctx, order.ID)
+		require.NoError(t, err)
+		require.Len(t, commissions, 2)
+		byType := map[string]*models.Commission{}
+		for _, c := range commissions {
+			byType[c.Type] = c
+			assert.Equal(t, models.CommissionStatusPending, c.Status)
+		}
+		require.Contains(t, byType, models.CommissionTypeDirect)
+		require.Contains(t, byType, models.CommissionTypeIndirect)
+		assert.Equal(t, parent.ID, byType[models.CommissionTypeDirect].DistributorID)
+		assert.InDelta(t, 30.0, byType[models.CommissionTypeDirect].Amount, 0.001)
+		assert.Equal(t, grand.ID, byType[models.CommissionTypeIndirect].DistributorID)
+		assert.InDelta(t, 10.0, byType[models.CommissionTypeIndirect].Amount, 0.001)
+
+		order.Status = models.OrderStatusRefunded
+		require.NoError(t, orderHook.OnOrderRefunded(ctx, order))
+
+		reversed, err := commissionSvc.GetByOrderID(ctx, order.ID)
+		require.NoError(t, err)
+		require.Len(t, reversed, 2)
+		for _, c := range reversed {
+			assert.Equal(t, models.CommissionStatusCancelled, c.Status)
+		}
+	})
+
+	t.Run("未绑定邀请人不生成佣金", func(t *testing.T) {
+		buyer := createIntegrationTestUser(db, nil)
+		order := completedOrder(buyer.ID, 200.0)
+		require.NoError(t, orderHook.OnOrderCompleted(ctx, order))
+
+		commissions, err := commissionSvc.GetByOrderID(ctx, order.ID)
+		require.NoError(t, err)
+		assert.Empty(t, commissions)
+	})
+}
+
 // TestDistributionFlow_WithdrawRejection 测试提现拒绝流程
 func TestDistributionFlow_WithdrawRejection(t *testing.T) {
 	db := setupDistributionIntegrationDB(t)
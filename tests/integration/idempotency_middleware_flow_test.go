@@ -0,0 +1,228 @@
+//go:build integration
+// +build integration
+
+// Package integration 幂等键中间件集成测试
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/response"
+	userMiddleware "github.com/dumeirei/smart-locker-backend/internal/middleware"
+)
+
+// idempotencyTestEnv 幂等中间件测试环境
+type idempotencyTestEnv struct {
+	router *gin.Engine
+	mr     *miniredis.Miniredis
+	calls  atomic.Int64
+	status int
+	panic  bool
+}
+
+// setupIdempotencyTest 创建挂载幂等中间件的路由，POST /orders 每次执行生成新的订单号
+func setupIdempotencyTest(t *testing.T) *idempotencyTestEnv {
+	gin.SetMode(gin.TestMode)
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	env := &idempotencyTestEnv{mr: mr, status: http.StatusOK}
+
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(func(c *gin.Context) {
+		if uid := c.GetHeader("X-Test-User"); uid != "" {
+			c.Set("user_id", int64(len(uid)))
+		}
+		c.Next()
+	})
+	r.POST("/orders", userMiddleware.IdempotencyMiddleware(client, time.Hour), func(c *gin.Context) {
+		n := env.calls.Add(1)
+		if env.panic {
+			panic("handler panic")
+		}
+		if env.status != http.StatusOK {
+			response.InternalError(c, "下单失败")
+			return
+		}
+		response.Success(c, gin.H{"order_no": "O" + strings.Repeat("1", int(n))})
+	})
+
+	env.router = r
+	return env
+}
+
+// post 发送创建订单请求
+func (env *idempotencyTestEnv) post(key, user string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/orders", strings.NewReader(`{"product_id":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(userMiddleware.IdempotencyKeyHeader, key)
+	}
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+	}
+
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddleware_ReplaySameKey(t *testing.T) {
+	env := setupIdempotencyTest(t)
+
+	first := env.post("order-key-001", "u1")
+	require.Equal(t, http.StatusOK, first.Code)
+	assert.Empty(t, first.Header().Get(userMiddleware.IdempotencyReplayedHeader))
+
+	for i := 0; i < 3; i++ {
+		replay := env.post("order-key-001", "u1")
+		assert.Equal(t, http.StatusOK, replay.Code)
+		assert.Equal(t, first.Body.String(), replay.Body.String())
+		assert.Equal(t, "true", replay.Header().Get(userMiddleware.IdempotencyReplayedHeader))
+		assert.Contains(t, replay.Header().Get("Content-Type"), "application/json")
+	}
+
+	assert.Equal(t, int64(1), env.calls.Load(), "相同幂等键只应执行一次")
+}
+
+func TestIdempotencyMiddleware_DifferentKeysAndUsers(t *testing.T) {
+	env := setupIdempotencyTest(t)
+
+	a := env.post("order-key-a", "u1")
+	b := env.post("order-key-b", "u1")
+	assert.NotEqual(t, a.Body.String(), b.Body.String())
+
+	// 不同用户使用相同的键互不影响
+	c := env.post("order-key-a", "user2")
+	assert.NotEqual(t, a.Body.String(), c.Body.String())
+
+	assert.Equal(t, int64(3), env.calls.Load())
+}
+
+func TestIdempotencyMiddleware_WithoutHeader(t *testing.T) {
+	env := setupIdempotencyTest(t)
+
+	env.post("", "u1")
+	env.post("", "u1")
+
+	assert.Equal(t, int64(2), env.calls.Load())
+}
+
+func TestIdempotencyMiddleware_MalformedKey(t *testing.T) {
+	env := setupIdempotencyTest(t)
+
+	for _, key := range []string{"含中文的键", "has space", strings.Repeat("k", 129)} {
+		w := env.post(key, "u1")
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, key)
+	}
+
+	// 请求头存在但为空同样视为格式错误
+	req, _ := http.NewRequest("POST", "/orders", nil)
+	req.Header[userMiddleware.IdempotencyKeyHeader] = []string{""}
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	assert.Equal(t, int64(0), env.calls.Load())
+}
+
+func TestIdempotencyMiddleware_ServerErrorNotCached(t *testing.T) {
+	env := setupIdempotencyTest(t)
+
+	env.status = http.StatusInternalServerError
+	w := env.post("order-key-retry", "u1")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// 失败后客户端使用相同键重试可以重新执行
+	env.status = http.StatusOK
+	w = env.post("order-key-retry", "u1")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = env.post("order-key-retry", "u1")
+	assert.Equal(t, "true", w.Header().Get(userMiddleware.IdempotencyReplayedHeader))
+	assert.Equal(t, int64(2), env.calls.Load())
+}
+
+func TestIdempotencyMiddleware_PanicReleasesKey(t *testing.T) {
+	env := setupIdempotencyTest(t)
+
+	env.panic = true
+	first := env.post("order-key-panic", "u1")
+	assert.Equal(t, http.StatusInternalServerError, first.Code)
+	assert.Empty(t, env.mr.Keys(), "处理函数 panic 后应释放幂等键")
+
+	// 重试不会因键仍处于处理中而返回 409
+	env.panic = false
+	retry := env.post("order-key-panic", "u1")
+	assert.Equal(t, http.StatusOK, retry.Code)
+	assert.Empty(t, retry.Header().Get(userMiddleware.IdempotencyReplayedHeader))
+	assert.Equal(t, int64(2), env.calls.Load())
+}
+
+func TestIdempotencyMiddleware_InFlightRequestConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var calls atomic.Int64
+
+	r := gin.New()
+	r.POST("/bookings", userMiddleware.IdempotencyMiddleware(client, time.Hour), func(c *gin.Context) {
+		calls.Add(1)
+		close(started)
+		<-release
+		response.Success(c, gin.H{"booking_no": "B1"})
+	})
+
+	send := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/bookings", nil)
+		req.Header.Set(userMiddleware.IdempotencyKeyHeader, "booking-key-1")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- send() }()
+	<-started
+
+	// 首个请求处理中，重复请求返回 409
+	w := send()
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	close(release)
+	first := <-done
+	assert.Equal(t, http.StatusOK, first.Code)
+
+	replay := send()
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+	assert.Equal(t, int64(1), calls.Load())
+}
+
+func TestIdempotencyMiddleware_KeyExpires(t *testing.T) {
+	env := setupIdempotencyTest(t)
+
+	env.post("order-key-ttl", "u1")
+	env.mr.FastForward(2 * time.Hour)
+	env.post("order-key-ttl", "u1")
+
+	assert.Equal(t, int64(2), env.calls.Load())
+}
@@ -0,0 +1,310 @@
+//go:build integration
+// +build integration
+
+// Package integration 商户开放接口签名认证集成测试
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dumeirei/smart-locker-backend/internal/common/crypto"
+	merchantHandler "github.com/dumeirei/smart-locker-backend/internal/handler/merchant"
+	userMiddleware "github.com/dumeirei/smart-locker-backend/internal/middleware"
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	merchantService "github.com/dumeirei/smart-locker-backend/internal/service/merchant"
+)
+
+// merchantAPITestEnv 商户开放接口测试环境
+type merchantAPITestEnv struct {
+	router *gin.Engine
+	db     *gorm.DB
+	mr     *miniredis.Miniredis
+	keySvc *merchantService.MerchantAPIKeyService
+}
+
+// setupMerchantAPITest 创建挂载签名认证中间件的商户开放接口路由
+func setupMerchantAPITest(t *testing.T) *merchantAPITestEnv {
+	gin.SetMode(gin.TestMode)
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+
+	require.NoError(t, db.AutoMigrate(
+		&models.Merchant{},
+		&models.Venue{},
+		&models.Device{},
+		&models.DeviceSlot{},
+		&models.Rental{},
+		&models.Settlement{},
+		&models.MerchantAPIKey{},
+	))
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	aes, err := crypto.NewAES("0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+	keySvc := merchantService.NewMerchantAPIKeyService(repository.NewMerchantAPIKeyRepository(db), repository.NewMerchantRepository(db), aes)
+
+	r := gin.New()
+	api := r.Group("/api/merchant/v1")
+	api.Use(userMiddleware.MerchantAPIAuth(keySvc, client, userMiddleware.DefaultMerchantAPIMaxSkew))
+	merchantHandler.NewPortalHandler(merchantService.NewMerchantPortalService(db)).RegisterRoutes(api)
+
+	return &merchantAPITestEnv{router: r, db: db, mr: mr, keySvc: keySvc}
+}
+
+// createMerchantWithVenue 创建商户及其名下的一个场地和设备
+func (env *merchantAPITestEnv) createMerchantWithVenue(t *testing.T, name string) (*models.Merchant, *models.Venue, *models.Device) {
+	merchant := &models.Merchant{Name: name, ContactName: "联系人", ContactPhone: "13800138000", Status: models.MerchantStatusActive}
+	require.NoError(t, env.db.Create(merchant).Error)
+	venue := &models.Venue{MerchantID: merchant.ID, Name: name + "场地", Type: "mall", Province: "广东省", City: "深圳市", District: "南山区", Address: "科技园"}
+	require.NoError(t, env.db.Create(venue).Error)
+	device := &models.Device{DeviceNo: name + "-D1", Name: name + "设备", Type: "standard", VenueID: venue.ID, QRCode: name + "-qr", ProductName: "充电宝"}
+	require.NoError(t, env.db.Create(device).Error)
+	return merchant, venue, device
+}
+
+// signedRequest 签名请求参数
+type signedRequest struct {
+	uri       string
+	keyID     string
+	secret    string
+	timestamp time.Time
+	nonce     string
+	signature string // 非空时覆盖计算出的签名
+}
+
+// do 发送签名请求
+func (env *merchantAPITestEnv) do(req signedRequest) *httptest.ResponseRecorder {
+	if req.timestamp.IsZero() {
+		req.timestamp = time.Now()
+	}
+	if req.nonce == "" {
+		req.nonce = strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	ts := strconv.FormatInt(req.timestamp.Unix(), 10)
+	signature := req.signature
+	if signature == "" {
+		signature = userMiddleware.SignMerchantAPIRequest(req.secret, http.MethodGet, req.uri, ts, req.nonce, nil)
+	}
+
+	httpReq, _ := http.NewRequest(http.MethodGet, req.uri, nil)
+	httpReq.Header.Set(userMiddleware.MerchantAPIKeyHeader, req.keyID)
+	httpReq.Header.Set(userMiddleware.MerchantAPITimestampHeader, ts)
+	httpReq.Header.Set(userMiddleware.MerchantAPINonceHeader, req.nonce)
+	httpReq.Header.Set(userMiddleware.MerchantAPISignatureHeader, signature)
+
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, httpReq)
+	return w
+}
+
+// pageIDs 解析分页响应中的记录 ID
+func pageIDs(t *testing.T, w *httptest.ResponseRecorder) []int64 {
+	var resp struct {
+		Code int `json:"code"`
+		Data struct {
+			List []struct {
+				ID int64 `json:"id"`
+			} `json:"list"`
+			Total int64 `json:"total"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	ids := make([]int64, 0, len(resp.Data.List))
+	for _, item := range resp.Data.List {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func TestMerchantAPIAuth_ValidSignature(t *testing.T) {
+	env := setupMerchantAPITest(t)
+	ctx := context.Background()
+	merchant, venue, _ := env.createMerchantWithVenue(t, "A")
+
+	key, err := env.keySvc.IssueKey(ctx, merchant.ID, nil)
+	require.NoError(t, err)
+
+	w := env.do(signedRequest{uri: "/api/merchant/v1/venues?page=1", keyID: key.KeyID, secret: key.Secret})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []int64{venue.ID}, pageIDs(t, w))
+
+	// 记录最近使用时间
+	stored, _, err := env.keySvc.ResolveAPIKey(ctx, key.KeyID)
+	require.NoError(t, err)
+	assert.NotNil(t, stored.LastUsedAt)
+}
+
+func TestMerchantAPIAuth_SignatureMismatch(t *testing.T) {
+	env := setupMerchantAPITest(t)
+	merchant, _, _ := env.createMerchantWithVenue(t, "A")
+	key, err := env.keySvc.IssueKey(context.Background(), merchant.ID, nil)
+	require.NoError(t, err)
+
+	t.Run("错误的密钥", func(t *testing.T) {
+		w := env.do(signedRequest{uri: "/api/merchant/v1/venues", keyID: key.KeyID, secret: "wrong-secret"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "签名错误")
+	})
+
+	t.Run("篡改查询参数", func(t *testing.T) {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		signature := userMiddleware.SignMerchantAPIRequest(key.Secret, http.MethodGet, "/api/merchant/v1/venues?page=1", ts, "n1", nil)
+		w := env.do(signedRequest{uri: "/api/merchant/v1/venues?page=2", keyID: key.KeyID, nonce: "n1", signature: signature})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("缺少签名头", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/api/merchant/v1/venues", nil)
+		req.Header.Set(userMiddleware.MerchantAPIKeyHeader, key.KeyID)
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("未知密钥", func(t *testing.T) {
+		w := env.do(signedRequest{uri: "/api/merchant/v1/venues", keyID: "mk_unknown", secret: key.Secret})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestMerchantAPIAuth_ExpiredTimestamp(t *testing.T) {
+	env := setupMerchantAPITest(t)
+	merchant, _, _ := env.createMerchantWithVenue(t, "A")
+	key, err := env.keySvc.IssueKey(context.Background(), merchant.ID, nil)
+	require.NoError(t, err)
+
+	for name, ts := range map[string]time.Time{
+		"过期时间戳": time.Now().Add(-userMiddleware.DefaultMerchantAPIMaxSkew - time.Minute),
+		"未来时间戳": time.Now().Add(userMiddleware.DefaultMerchantAPIMaxSkew + time.Minute),
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := env.do(signedRequest{uri: "/api/merchant/v1/venues", keyID: key.KeyID, secret: key.Secret, timestamp: ts})
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Contains(t, w.Body.String(), "请求已过期")
+		})
+	}
+}
+
+func TestMerchantAPIAuth_NonceReplay(t *testing.T) {
+	env := setupMerchantAPITest(t)
+	merchant, _, _ := env.createMerchantWithVenue(t, "A")
+	key, err := env.keySvc.IssueKey(context.Background(), merchant.ID, nil)
+	require.NoError(t, err)
+
+	req := signedRequest{uri: "/api/merchant/v1/venues", keyID: key.KeyID, secret: key.Secret, timestamp: time.Now(), nonce: "replay-nonce"}
+	require.Equal(t, http.StatusOK, env.do(req).Code)
+
+	w := env.do(req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "重复的请求")
+
+	// nonce 缓存覆盖整个时间窗口
+	ttl := env.mr.TTL("merchant_api:nonce:" + key.KeyID + ":replay-nonce")
+	assert.Equal(t, 2*userMiddleware.DefaultMerchantAPIMaxSkew, ttl)
+}
+
+func TestMerchantAPIAuth_RevokedKey(t *testing.T) {
+	env := setupMerchantAPITest(t)
+	ctx := context.Background()
+	merchant, _, _ := env.createMerchantWithVenue(t, "A")
+	key, err := env.keySvc.IssueKey(ctx, merchant.ID, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusOK, env.do(signedRequest{uri: "/api/merchant/v1/venues", keyID: key.KeyID, secret: key.Secret}).Code)
+
+	require.NoError(t, env.keySvc.RevokeKey(ctx, merchant.ID, key.ID))
+	w := env.do(signedRequest{uri: "/api/merchant/v1/venues", keyID: key.KeyID, secret: key.Secret})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "API 密钥已吊销")
+
+	// 轮换后旧密钥失效，新密钥可用
+	fresh, err := env.keySvc.IssueKey(ctx, merchant.ID, nil)
+	require.NoError(t, err)
+	rotated, err := env.keySvc.RotateKey(ctx, merchant.ID, fresh.ID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, env.do(signedRequest{uri: "/api/merchant/v1/venues", keyID: fresh.KeyID, secret: fresh.Secret}).Code)
+	assert.Equal(t, http.StatusOK, env.do(signedRequest{uri: "/api/merchant/v1/venues", keyID: rotated.KeyID, secret: rotated.Secret}).Code)
+}
+
+func TestMerchantAPIAuth_ScopeRequired(t *testing.T) {
+	env := setupMerchantAPITest(t)
+	merchant, _, _ := env.createMerchantWithVenue(t, "A")
+	key, err := env.keySvc.IssueKey(context.Background(), merchant.ID, &merchantService.IssueAPIKeyRequest{Scopes: []string{models.MerchantAPIScopeVenues}})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, env.do(signedRequest{uri: "/api/merchant/v1/venues", keyID: key.KeyID, secret: key.Secret}).Code)
+	assert.Equal(t, http.StatusForbidden, env.do(signedRequest{uri: "/api/merchant/v1/rentals", keyID: key.KeyID, secret: key.Secret}).Code)
+}
+
+func TestMerchantAPI_CrossMerchantIsolation(t *testing.T) {
+	env := setupMerchantAPITest(t)
+	ctx := context.Background()
+	merchantA, venueA, deviceA := env.createMerchantWithVenue(t, "A")
+	merchantB, venueB, deviceB := env.createMerchantWithVenue(t, "B")
+
+	rentalA := &models.Rental{OrderID: 1, UserID: 1, DeviceID: deviceA.ID, DurationHours: 1, RentalFee: 5, Deposit: 99, Status: models.RentalStatusInUse}
+	rentalB := &models.Rental{OrderID: 2, UserID: 2, DeviceID: deviceB.ID, DurationHours: 1, RentalFee: 5, Deposit: 99, Status: models.RentalStatusInUse}
+	require.NoError(t, env.db.Create(rentalA).Error)
+	require.NoError(t, env.db.Create(rentalB).Error)
+	for i, m := range []*models.Merchant{merchantA, merchantB} {
+		require.NoError(t, env.db.Create(&models.Settlement{
+			SettlementNo: fmt.Sprintf("S%d", i), Type: models.SettlementTypeMerchant, TargetID: m.ID,
+			PeriodStart: time.Now(), PeriodEnd: time.Now(), Status: "pending",
+		}).Error)
+	}
+
+	keyA, err := env.keySvc.IssueKey(ctx, merchantA.ID, nil)
+	require.NoError(t, err)
+
+	var settlementA models.Settlement
+	require.NoError(t, env.db.Where("target_id = ?", merchantA.ID).First(&settlementA).Error)
+
+	cases := map[string]struct {
+		uri  string
+		want []int64
+	}{
+		"结算单":      {"/api/merchant/v1/settlements", []int64{settlementA.ID}},
+		"场地":       {"/api/merchant/v1/venues", []int64{venueA.ID}},
+		"设备":       {"/api/merchant/v1/devices", []int64{deviceA.ID}},
+		"租借":       {"/api/merchant/v1/rentals", []int64{rentalA.ID}},
+		"筛选其他商户场地": {fmt.Sprintf("/api/merchant/v1/devices?venue_id=%d", venueB.ID), []int64{}},
+		"筛选其他商户设备": {fmt.Sprintf("/api/merchant/v1/rentals?device_id=%d", deviceB.ID), []int64{}},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := env.do(signedRequest{uri: tc.uri, keyID: keyA.KeyID, secret: keyA.Secret})
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			assert.Equal(t, tc.want, pageIDs(t, w))
+		})
+	}
+}
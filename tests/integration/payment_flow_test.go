@@ -43,6 +43,7 @@ func setupPaymentIntegrationDB(t *testing.T) *gorm.DB {
 		&models.Rental{},
 		&models.Payment{},
 		&models.Refund{},
+		&models.OrderNote{},
 	)
 	require.NoError(t, err)
 
@@ -83,7 +84,7 @@ func setupPaymentTestEnvironment(t *testing.T, db *gorm.DB) (*paymentService.Pay
 	refundRepo := repository.NewRefundRepository(db)
 	rentalRepo := repository.NewRentalRepository(db)
 
-	svc := paymentService.NewPaymentService(db, paymentRepo, refundRepo, rentalRepo, nil)
+	svc := paymentService.NewPaymentService(db, paymentRepo, refundRepo, rentalRepo, nil, nil)
 
 	return svc, user
 }
@@ -131,7 +132,7 @@ func TestPaymentFlow_BalancePayment(t *testing.T) {
 			Description:    "租借订单支付",
 		}
 
-		resp, err := svc.CreatePayment(ctx, user.ID, req)
+		resp, err := svc.CreatePayment(ctx, user.ID, req, "")
 		require.NoError(t, err)
 		assert.NotEmpty(t, resp.PaymentNo)
 		assert.False(t, resp.ExpiredAt.IsZero())
@@ -295,7 +296,7 @@ func TestPaymentFlow_MultiplePayments(t *testing.T) {
 			PaymentChannel: models.PaymentChannelMiniProgram,
 		}
 
-		resp, err := svc.CreatePayment(ctx, user.ID, req)
+		resp, err := svc.CreatePayment(ctx, user.ID, req, "")
 		require.NoError(t, err)
 		assert.NotEmpty(t, resp.PaymentNo)
 	}
@@ -0,0 +1,139 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/dumeirei/smart-locker-backend/internal/models"
+	"github.com/dumeirei/smart-locker-backend/internal/repository"
+	mallService "github.com/dumeirei/smart-locker-backend/internal/service/mall"
+)
+
+// setupProductFTSDB 启动 Postgres 容器并执行商品全文检索迁移
+func setupProductFTSDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping postgres integration test in short mode")
+	}
+
+	tc := NewTestContainers(context.Background())
+	require.NoError(t, tc.StartPostgres(DefaultPostgresConfig()))
+	t.Cleanup(func() {
+		_ = tc.Cleanup()
+	})
+
+	db, err := tc.GetPostgresDB()
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Category{}, &models.Product{}))
+
+	migration, err := os.ReadFile("../../migrations/000041_add_product_search_vector.up.sql")
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(string(migration)).Error)
+	return db
+}
+
+func createFTSProduct(t *testing.T, db *gorm.DB, name, description string, price float64, isOnSale bool) *models.Product {
+	t.Helper()
+	images, _ := json.Marshal([]string{"https://example.com/img.jpg"})
+	product := &models.Product{
+		CategoryID: 1,
+		Name:       name,
+		Images:     images,
+		Price:      price,
+		Stock:      10,
+		Unit:       "件",
+		IsOnSale:   true,
+	}
+	if description != "" {
+		product.Description = &description
+	}
+	require.NoError(t, db.Create(product).Error)
+	if !isOnSale {
+		require.NoError(t, db.Model(product).Update("is_on_sale", false).Error)
+	}
+	return product
+}
+
+func ftsProductNames(products []*models.Product) []string {
+	names := make([]string, len(products))
+	for i, p := range products {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// TestProductFTS_Postgres PostgreSQL 全文检索按名称、描述权重排序并支持筛选与回退
+func TestProductFTS_Postgres(t *testing.T) {
+	db := setupProductFTSDB(t)
+	ctx := context.Background()
+
+	backend := mallService.NewSearchBackendForDialect(db, repository.NewProductRepository(db))
+	require.IsType(t, &mallService.PostgresFTSBackend{}, backend)
+
+	createFTSProduct(t, db, "蕾丝睡衣", "舒适透气", 129, true)
+	createFTSProduct(t, db, "真丝眼罩", "蕾丝花边设计", 39, true)
+	createFTSProduct(t, db, "蕾丝内衣", "", 89, false)
+	createFTSProduct(t, db, "Durex 安全套", "超薄 12只装", 59, true)
+
+	t.Run("未建立检索向量时回退到 LIKE 检索", func(t *testing.T) {
+		products, total, err := backend.Search(ctx, &mallService.ProductSearchParams{Keyword: "蕾丝睡衣", Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, []string{"蕾丝睡衣"}, ftsProductNames(products))
+	})
+
+	indexed, err := backend.ReindexAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, indexed)
+
+	t.Run("名称命中排在描述命中之前，不返回下架商品", func(t *testing.T) {
+		products, total, err := backend.Search(ctx, &mallService.ProductSearchParams{
+			Keyword: "蕾丝",
+			SortBy:  mallService.SearchSortRelevance,
+			Limit:   10,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Equal(t, []string{"蕾丝睡衣", "真丝眼罩"}, ftsProductNames(products))
+	})
+
+	t.Run("多个关键词需全部命中", func(t *testing.T) {
+		products, _, err := backend.Search(ctx, &mallService.ProductSearchParams{Keyword: "durex 超薄", Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Durex 安全套"}, ftsProductNames(products))
+	})
+
+	t.Run("按价格排序并筛选价格区间", func(t *testing.T) {
+		maxPrice := 100.0
+		products, total, err := backend.Search(ctx, &mallService.ProductSearchParams{
+			Keyword:  "蕾丝",
+			SortBy:   "price_asc",
+			MaxPrice: &maxPrice,
+			Limit:    10,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, []string{"真丝眼罩"}, ftsProductNames(products))
+	})
+
+	t.Run("商品更新后刷新检索向量", func(t *testing.T) {
+		var product models.Product
+		require.NoError(t, db.Where("name = ?", "真丝眼罩").First(&product).Error)
+		product.Description = nil
+		require.NoError(t, db.Model(&product).Update("description", nil).Error)
+		require.NoError(t, backend.IndexProduct(ctx, &product))
+
+		products, _, err := backend.Search(ctx, &mallService.ProductSearchParams{Keyword: "蕾丝", Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"蕾丝睡衣"}, ftsProductNames(products))
+	})
+}
@@ -44,12 +44,14 @@ func setupRentalIntegrationDB(t *testing.T) *gorm.DB {
 		&models.Merchant{},
 		&models.Venue{},
 		&models.Device{},
+		&models.DeviceSlot{},
 		&models.DeviceLog{},
 		&models.RentalPricing{},
 		&models.Order{},
 		&models.Rental{},
 		&models.Payment{},
 		&models.Refund{},
+		&models.OrderNote{},
 		&models.WalletTransaction{},
 	)
 	require.NoError(t, err)
@@ -145,9 +147,9 @@ func setupRentalTestEnvironment(t *testing.T, db *gorm.DB) (*rentalService.Renta
 	venueRepo := repository.NewVenueRepository(db)
 	userRepo := repository.NewUserRepository(db)
 	deviceSvc := deviceService.NewDeviceService(db, deviceRepo, venueRepo)
-	walletSvc := userService.NewWalletService(db, userRepo)
+	walletSvc := userService.NewWalletService(db, userRepo, nil)
 
-	svc := rentalService.NewRentalService(db, rentalRepo, deviceRepo, deviceSvc, walletSvc, nil)
+	svc := rentalService.NewRentalService(db, rentalRepo, deviceRepo, deviceSvc, walletSvc, nil, nil, nil)
 
 	return svc, user, device, pricing
 }
@@ -186,7 +188,7 @@ func TestRentalFlow_CompleteProcess(t *testing.T) {
 
 	// 2. 支付订单
 	t.Run("步骤2: 支付订单", func(t *testing.T) {
-		err := svc.PayRental(ctx, user.ID, rental.ID)
+		err := svc.PayRental(ctx, user.ID, rental.ID, "")
 		require.NoError(t, err)
 
 		// 验证订单状态
@@ -408,8 +410,8 @@ func TestRentalFlow_InsufficientBalance(t *testing.T) {
 	venueRepo := repository.NewVenueRepository(db)
 	userRepo := repository.NewUserRepository(db)
 	deviceSvc := deviceService.NewDeviceService(db, deviceRepo, venueRepo)
-	walletSvc := userService.NewWalletService(db, userRepo)
-	svc := rentalService.NewRentalService(db, rentalRepo, deviceRepo, deviceSvc, walletSvc, nil)
+	walletSvc := userService.NewWalletService(db, userRepo, nil)
+	svc := rentalService.NewRentalService(db, rentalRepo, deviceRepo, deviceSvc, walletSvc, nil, nil, nil)
 
 	ctx := context.Background()
 
@@ -530,8 +532,8 @@ func TestRentalFlow_OverdueRental(t *testing.T) {
 	venueRepo := repository.NewVenueRepository(db)
 	userRepo := repository.NewUserRepository(db)
 	deviceSvc := deviceService.NewDeviceService(db, deviceRepo, venueRepo)
-	walletSvc := userService.NewWalletService(db, userRepo)
-	svc := rentalService.NewRentalService(db, rentalRepo, deviceRepo, deviceSvc, walletSvc, nil)
+	walletSvc := userService.NewWalletService(db, userRepo, nil)
+	svc := rentalService.NewRentalService(db, rentalRepo, deviceRepo, deviceSvc, walletSvc, nil, nil, nil)
 
 	// 完成租借（结算）
 	err := svc.CompleteRental(ctx, rental.ID)
@@ -48,11 +48,13 @@ func TestUS2DeviceMonitoringFlow_HeartbeatThenAdminManage(t *testing.T) {
 	ctx := context.Background()
 
 	deviceRepo := repository.NewDeviceRepository(db)
+
+	deviceSlotRepo := repository.NewDeviceSlotRepository(db)
 	deviceLogRepo := repository.NewDeviceLogRepository(db)
 	deviceMaintenanceRepo := repository.NewDeviceMaintenanceRepository(db)
 	venueRepo := repository.NewVenueRepository(db)
 
-	adminSvc := adminService.NewDeviceAdminService(deviceRepo, deviceLogRepo, deviceMaintenanceRepo, venueRepo, nil)
+	adminSvc := adminService.NewDeviceAdminService(deviceRepo, deviceSlotRepo, deviceLogRepo, deviceMaintenanceRepo, venueRepo, nil)
 	deviceSvc := deviceService.NewDeviceService(db, deviceRepo, venueRepo)
 
 	merchant := &models.Merchant{Name: "测试商户", Status: models.MerchantStatusActive}
@@ -9,16 +9,23 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
+	appErrors "github.com/dumeirei/smart-locker-backend/internal/common/errors"
 	"github.com/dumeirei/smart-locker-backend/internal/models"
 	"github.com/dumeirei/smart-locker-backend/internal/repository"
 	mallService "github.com/dumeirei/smart-locker-backend/internal/service/mall"
+	marketingService "github.com/dumeirei/smart-locker-backend/internal/service/marketing"
+	orderService "github.com/dumeirei/smart-locker-backend/internal/service/order"
+	userService "github.com/dumeirei/smart-locker-backend/internal/service/user"
 )
 
 func setupUS3IntegrationDB(t *testing.T) *gorm.DB {
@@ -48,7 +55,10 @@ func setupUS3IntegrationDB(t *testing.T) *gorm.DB {
 		&models.CartItem{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.GiftOrder{},
 		&models.Review{},
+		&models.ReviewReply{},
+		&models.UserPointsLog{},
 	)
 	require.NoError(t, err)
 
@@ -68,7 +78,7 @@ func setupUS3Services(db *gorm.DB) (*mallService.ProductService, *mallService.Ca
 
 	productSvc := mallService.NewProductService(db, productRepo, categoryRepo, skuRepo)
 	cartSvc := mallService.NewCartService(db, cartRepo, productRepo, skuRepo)
-	orderSvc := mallService.NewMallOrderService(db, orderRepo, cartRepo, productRepo, skuRepo, productSvc)
+	orderSvc := mallService.NewMallOrderService(db, orderRepo, cartRepo, productRepo, skuRepo, productSvc, repository.NewRefundRepository(db), repository.NewPaymentRepository(db))
 	reviewSvc := mallService.NewReviewService(db, reviewRepo, orderRepo)
 
 	return productSvc, cartSvc, orderSvc, reviewSvc
@@ -231,20 +241,20 @@ func TestUS3Integration_MallOrderFlow_CartPurchase(t *testing.T) {
 	assert.Equal(t, "商品2", p2.Name)
 
 	// 2. 添加商品到购物车
-	_, err = cartSvc.AddItem(ctx, user.ID, &mallService.AddCartItemRequest{
+	_, err = cartSvc.AddItem(ctx, repository.UserCartOwner(user.ID), &mallService.AddCartItemRequest{
 		ProductID: product1.ID,
 		Quantity:  2,
 	})
 	require.NoError(t, err)
 
-	_, err = cartSvc.AddItem(ctx, user.ID, &mallService.AddCartItemRequest{
+	_, err = cartSvc.AddItem(ctx, repository.UserCartOwner(user.ID), &mallService.AddCartItemRequest{
 		ProductID: product2.ID,
 		Quantity:  1,
 	})
 	require.NoError(t, err)
 
 	// 3. 查看购物车
-	cart, err := cartSvc.GetCart(ctx, user.ID)
+	cart, err := cartSvc.GetCart(ctx, repository.UserCartOwner(user.ID))
 	require.NoError(t, err)
 	assert.Len(t, cart.Items, 2)
 	assert.Equal(t, 3, cart.TotalCount)                  // 2 + 1
@@ -260,7 +270,7 @@ func TestUS3Integration_MallOrderFlow_CartPurchase(t *testing.T) {
 	assert.Len(t, order.Items, 2)
 
 	// 5. 验证购物车已清空选中项
-	cart, err = cartSvc.GetCart(ctx, user.ID)
+	cart, err = cartSvc.GetCart(ctx, repository.UserCartOwner(user.ID))
 	require.NoError(t, err)
 	assert.Empty(t, cart.Items)
 
@@ -292,7 +302,7 @@ func TestUS3Integration_MallOrderFlow_WithSku(t *testing.T) {
 
 	// 3. 添加带 SKU 的商品到购物车
 	skuID := sku.ID
-	_, err = cartSvc.AddItem(ctx, user.ID, &mallService.AddCartItemRequest{
+	_, err = cartSvc.AddItem(ctx, repository.UserCartOwner(user.ID), &mallService.AddCartItemRequest{
 		ProductID: product.ID,
 		SkuID:     &skuID,
 		Quantity:  3,
@@ -358,6 +368,378 @@ func TestUS3Integration_MallOrderFlow_CancelAndRestoreStock(t *testing.T) {
 	assert.Equal(t, models.OrderStatusCancelled, orderDetail.Status)
 }
 
+func TestUS3Integration_MallOrderFlow_RedeemPoints(t *testing.T) {
+	db := setupUS3IntegrationDB(t)
+	_, _, orderSvc, _ := setupUS3Services(db)
+	pointsSvc := userService.NewPointsService(db, repository.NewUserRepository(db), repository.NewMemberLevelRepository(db))
+	orderSvc.SetPointsService(pointsSvc)
+	ctx := context.Background()
+
+	user, _, product, _, address := seedUS3IntegrationData(t, db)
+	require.NoError(t, db.Model(user).Update("points", 1500).Error)
+
+	// 1. 下单使用1000积分抵扣10元
+	order, err := orderSvc.CreateOrder(ctx, user.ID, &mallService.CreateMallOrderRequest{
+		Items: []mallService.OrderItemRequest{
+			{ProductID: product.ID, Quantity: 1},
+		},
+		AddressID: address.ID,
+		UsePoints: 1000,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 80.0, order.OriginalAmount)
+	assert.Equal(t, 10.0, order.DiscountAmount)
+	assert.Equal(t, 70.0, order.ActualAmount)
+
+	var afterOrder models.User
+	require.NoError(t, db.First(&afterOrder, user.ID).Error)
+	assert.Equal(t, 500, afterOrder.Points)
+
+	// 2. 积分不足时下单失败
+	_, err = orderSvc.CreateOrder(ctx, user.ID, &mallService.CreateMallOrderRequest{
+		Items: []mallService.OrderItemRequest{
+			{ProductID: product.ID, Quantity: 1},
+		},
+		AddressID: address.ID,
+		UsePoints: 600,
+	})
+	require.Error(t, err)
+	appErr, ok := err.(*appErrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, appErrors.ErrPointsInsufficient.Code, appErr.Code)
+
+	// 3. 抵扣金额不能超过订单金额
+	require.NoError(t, db.Model(user).Update("points", 10000).Error)
+	_, err = orderSvc.CreateOrder(ctx, user.ID, &mallService.CreateMallOrderRequest{
+		Items: []mallService.OrderItemRequest{
+			{ProductID: product.ID, Quantity: 1},
+		},
+		AddressID: address.ID,
+		UsePoints: 8001,
+	})
+	require.Error(t, err)
+	require.NoError(t, db.Model(user).Update("points", 500).Error)
+
+	// 4. 取消订单返还抵扣积分
+	require.NoError(t, orderSvc.CancelOrder(ctx, user.ID, order.ID, "不想要了"))
+
+	var afterCancel models.User
+	require.NoError(t, db.First(&afterCancel, user.ID).Error)
+	assert.Equal(t, 1500, afterCancel.Points)
+}
+
+// TestUS3Integration_MallOrderFlow_GiftCampaign 满赠活动：达到门槛的订单生成赠品订单，随主订单支付和取消
+func TestUS3Integration_MallOrderFlow_MemberDiscount(t *testing.T) {
+	db := setupUS3IntegrationDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Campaign{}, &models.Coupon{}, &models.UserCoupon{}))
+	_, _, orderSvc, _ := setupUS3Services(db)
+
+	calc := orderService.NewDiscountCalculator(
+		marketingService.NewCouponService(db, repository.NewCouponRepository(db), repository.NewUserCouponRepository(db)),
+		marketingService.NewCampaignService(db, repository.NewCampaignRepository(db)))
+	calc.SetMemberDiscountService(orderService.NewMemberDiscountService(db,
+		repository.NewUserRepository(db), repository.NewMemberLevelRepository(db)))
+	orderSvc.SetDiscountCalculator(calc)
+	ctx := context.Background()
+
+	user, _, product, _, address := seedUS3IntegrationData(t, db)
+	require.NoError(t, db.Create(&models.MemberLevel{ID: 2, Name: "黄金会员", Level: 2, MinPoints: 1000, Discount: 0.9}).Error)
+	require.NoError(t, db.Model(user).Update("member_level_id", 2).Error)
+
+	order, err := orderSvc.CreateOrder(ctx, user.ID, &mallService.CreateMallOrderRequest{
+		Items:     []mallService.OrderItemRequest{{ProductID: product.ID, Quantity: 2}},
+		AddressID: address.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 160.0, order.OriginalAmount)
+	assert.Equal(t, 16.0, order.DiscountAmount)
+	assert.Equal(t, 144.0, order.ActualAmount)
+}
+
+func TestUS3Integration_MallOrderFlow_GiftCampaign(t *testing.T) {
+	db := setupUS3IntegrationDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Campaign{}, &models.Coupon{}, &models.UserCoupon{}))
+	_, _, orderSvc, _ := setupUS3Services(db)
+
+	campaignRepo := repository.NewCampaignRepository(db)
+	calc := orderService.NewDiscountCalculator(
+		marketingService.NewCouponService(db, repository.NewCouponRepository(db), repository.NewUserCouponRepository(db)),
+		marketingService.NewCampaignService(db, campaignRepo))
+	calc.SetGiftCampaignService(marketingService.NewGiftCampaignService(db, campaignRepo))
+	orderSvc.SetDiscountCalculator(calc)
+	ctx := context.Background()
+
+	user, category, product, _, address := seedUS3IntegrationData(t, db)
+	gift := &models.Product{CategoryID: category.ID, Name: "赠品", Images: []byte(`[]`), Price: 20, Stock: 5, Unit: "件", IsOnSale: true}
+	require.NoError(t, db.Create(gift).Error)
+	campaign := &models.Campaign{
+		Name:      "满200赠",
+		Type:      models.CampaignTypeGift,
+		Rules:     json.RawMessage(fmt.Sprintf(`{"min_amount":200,"gift_product_id":%d,"gift_quantity":1}`, gift.ID)),
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(24 * time.Hour),
+		Status:    models.CampaignStatusActive,
+	}
+	require.NoError(t, db.Create(campaign).Error)
+
+	giftOrderOf := func(mainOrderID int64) *models.GiftOrder {
+		var link models.GiftOrder
+		if err := db.Where("main_order_id = ?", mainOrderID).First(&link).Error; err != nil {
+			return nil
+		}
+		return &link
+	}
+	giftStock := func() int {
+		var p models.Product
+		require.NoError(t, db.First(&p, gift.ID).Error)
+		return p.Stock
+	}
+
+	t.Run("未达门槛不赠送", func(t *testing.T) {
+		order, err := orderSvc.CreateOrder(ctx, user.ID, &mallService.CreateMallOrderRequest{
+			Items:     []mallService.OrderItemRequest{{ProductID: product.ID, Quantity: 2}},
+			AddressID: address.ID,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 160.0, order.ActualAmount)
+		assert.Nil(t, giftOrderOf(order.ID))
+		assert.Equal(t, 5, giftStock())
+	})
+
+	t.Run("达到门槛生成赠品订单并随主订单支付", func(t *testing.T) {
+		order, err := orderSvc.CreateOrder(ctx, user.ID, &mallService.CreateMallOrderRequest{
+			Items:     []mallService.OrderItemRequest{{ProductID: product.ID, Quantity: 3}},
+			AddressID: address.ID,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 240.0, order.ActualAmount)
+
+		link := giftOrderOf(order.ID)
+		require.NotNil(t, link)
+		require.NotNil(t, link.CampaignID)
+		assert.Equal(t, campaign.ID, *link.CampaignID)
+		assert.Equal(t, gift.ID, link.ProductID)
+		assert.Equal(t, 4, giftStock())
+
+		var giftOrder models.Order
+		require.NoError(t, db.First(&giftOrder, link.GiftOrderID).Error)
+		assert.Equal(t, user.ID, giftOrder.UserID)
+		assert.Zero(t, giftOrder.ActualAmount)
+		assert.Equal(t, models.OrderStatusPending, giftOrder.Status)
+
+		require.NoError(t, orderSvc.OnPaymentSuccess(ctx, order.ID))
+		require.NoError(t, db.First(&giftOrder, link.GiftOrderID).Error)
+		assert.Equal(t, models.OrderStatusPendingShip, giftOrder.Status)
+	})
+
+	t.Run("取消主订单同时取消赠品订单并恢复赠品库存", func(t *testing.T) {
+		order, err := orderSvc.CreateOrder(ctx, user.ID, &mallService.CreateMallOrderRequest{
+			Items:     []mallService.OrderItemRequest{{ProductID: product.ID, Quantity: 3}},
+			AddressID: address.ID,
+		})
+		require.NoError(t, err)
+		link := giftOrderOf(order.ID)
+		require.NotNil(t, link)
+		assert.Equal(t, 3, giftStock())
+
+		require.NoError(t, orderSvc.CancelOrder(ctx, user.ID, order.ID, "不想要了"))
+
+		var giftOrder models.Order
+		require.NoError(t, db.First(&giftOrder, link.GiftOrderID).Error)
+		assert.Equal(t, models.OrderStatusCancelled, giftOrder.Status)
+		assert.Equal(t, 4, giftStock())
+	})
+
+	t.Run("赠品库存不足时正常下单不赠送", func(t *testing.T) {
+		require.NoError(t, db.Model(gift).Update("stock", 0).Error)
+
+		order, err := orderSvc.CreateOrder(ctx, user.ID, &mallService.CreateMallOrderRequest{
+			Items:     []mallService.OrderItemRequest{{ProductID: product.ID, Quantity: 3}},
+			AddressID: address.ID,
+		})
+		require.NoError(t, err)
+		assert.Nil(t, giftOrderOf(order.ID))
+	})
+}
+
+func TestUS3Integration_MallOrderFlow_FlashSale(t *testing.T) {
+	db := setupUS3IntegrationDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Campaign{}, &models.CampaignProduct{}, &models.FlashSaleReservation{}, &models.OrderNote{},
+		&models.Coupon{}, &models.UserCoupon{}))
+	_, _, orderSvc, _ := setupUS3Services(db)
+	orderSvc.SetCampaignService(marketingService.NewCampaignService(db, repository.NewCampaignRepository(db)))
+	ctx := context.Background()
+
+	user, _, product, _, address := seedUS3IntegrationData(t, db)
+	campaign := &models.Campaign{
+		Name:      "限时秒杀",
+		Type:      models.CampaignTypeFlashSale,
+		Rules:     json.RawMessage(`{}`),
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(24 * time.Hour),
+		Status:    models.CampaignStatusActive,
+	}
+	require.NoError(t, db.Create(campaign).Error)
+	require.NoError(t, db.Create(&models.CampaignProduct{
+		CampaignID:   campaign.ID,
+		ProductID:    product.ID,
+		FlashPrice:   9.9,
+		FlashStock:   10,
+		PerUserLimit: 3,
+	}).Error)
+
+	flashStock := func() int {
+		var p models.CampaignProduct
+		require.NoError(t, db.Where("campaign_id = ? AND product_id = ?", campaign.ID, product.ID).First(&p).Error)
+		return p.FlashStock
+	}
+	productStock := func() int {
+		var p models.Product
+		require.NoError(t, db.First(&p, product.ID).Error)
+		return p.Stock
+	}
+	reservationStatus := func(orderID int64) string {
+		var r models.FlashSaleReservation
+		require.NoError(t, db.Where("order_id = ?", orderID).First(&r).Error)
+		return r.Status
+	}
+	createOrder := func(quantity int) (*mallService.MallOrderInfo, error) {
+		return orderSvc.CreateOrder(ctx, user.ID, &mallService.CreateMallOrderRequest{
+			Items:     []mallService.OrderItemRequest{{ProductID: product.ID, Quantity: quantity}},
+			AddressID: address.ID,
+		})
+	}
+
+	var paidOrder *mallService.MallOrderInfo
+	t.Run("按秒杀价下单并预占秒杀库存", func(t *testing.T) {
+		var err error
+		paidOrder, err = createOrder(2)
+		require.NoError(t, err)
+		assert.Equal(t, 19.8, paidOrder.OriginalAmount)
+		assert.Equal(t, 8, flashStock())
+		assert.Equal(t, 48, productStock())
+		assert.Equal(t, models.FlashSaleReservationReserved, reservationStatus(paidOrder.ID))
+
+		require.NoError(t, orderSvc.OnPaymentSuccess(ctx, paidOrder.ID))
+		assert.Equal(t, models.FlashSaleReservationPaid, reservationStatus(paidOrder.ID))
+	})
+
+	t.Run("每人限购跨订单累计", func(t *testing.T) {
+		_, err := createOrder(2)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrFlashSaleLimitExceeded.Code, appErr.Code)
+		// 下单失败整体回滚
+		assert.Equal(t, 8, flashStock())
+		assert.Equal(t, 48, productStock())
+	})
+
+	t.Run("超时未支付取消订单并恢复库存", func(t *testing.T) {
+		order, err := createOrder(1)
+		require.NoError(t, err)
+		assert.Equal(t, 7, flashStock())
+		assert.Equal(t, 47, productStock())
+
+		require.NoError(t, db.Model(&models.Order{}).Where("id = ?", order.ID).
+			UpdateColumn("created_at", time.Now().Add(-time.Hour)).Error)
+		expirySvc := orderService.NewOrderExpiryService(db)
+		expirySvc.SetExpiredOrderHandler(models.OrderTypeMall, orderSvc)
+		expired, err := expirySvc.ProcessExpiredOrders(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, expired)
+
+		var cancelled models.Order
+		require.NoError(t, db.First(&cancelled, order.ID).Error)
+		assert.Equal(t, models.OrderStatusCancelled, cancelled.Status)
+		assert.Equal(t, 8, flashStock())
+		assert.Equal(t, 48, productStock())
+		assert.Equal(t, models.FlashSaleReservationReleased, reservationStatus(order.ID))
+
+		// 已支付订单不受影响，释放的名额可以再次购买
+		assert.Equal(t, models.FlashSaleReservationPaid, reservationStatus(paidOrder.ID))
+
+		// 超时取消后才到达的支付回调不会恢复订单
+		err = orderSvc.OnPaymentSuccess(ctx, order.ID)
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok)
+		assert.Equal(t, appErrors.ErrOrderStatusError.Code, appErr.Code)
+		require.NoError(t, db.First(&cancelled, order.ID).Error)
+		assert.Equal(t, models.OrderStatusCancelled, cancelled.Status)
+		assert.Equal(t, models.FlashSaleReservationReleased, reservationStatus(order.ID))
+		_, err = createOrder(1)
+		require.NoError(t, err)
+	})
+}
+
+func TestUS3Integration_MallOrderFlow_FlashSaleDailyLimits(t *testing.T) {
+	db := setupUS3IntegrationDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Campaign{}, &models.CampaignProduct{}, &models.FlashSaleReservation{}, &models.OrderNote{},
+		&models.Coupon{}, &models.UserCoupon{}))
+	_, _, orderSvc, _ := setupUS3Services(db)
+	campaignSvc := marketingService.NewCampaignService(db, repository.NewCampaignRepository(db))
+	mr := miniredis.RunT(t)
+	flashSaleSvc := marketingService.NewFlashSaleService(repository.NewCampaignRepository(db), repository.NewCampaignProductRepository(db))
+	flashSaleSvc.SetRedis(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	campaignSvc.SetFlashSaleService(flashSaleSvc)
+	orderSvc.SetCampaignService(campaignSvc)
+	ctx := context.Background()
+
+	user, _, product, _, address := seedUS3IntegrationData(t, db)
+	campaign := &models.Campaign{
+		Name:           "每日秒杀",
+		Type:           models.CampaignTypeFlashSale,
+		Rules:          json.RawMessage(`{}`),
+		StartTime:      time.Now().Add(-time.Hour),
+		EndTime:        time.Now().Add(24 * time.Hour),
+		Status:         models.CampaignStatusActive,
+		DailyLimit:     5,
+		UserDailyLimit: 2,
+	}
+	require.NoError(t, db.Create(campaign).Error)
+	require.NoError(t, db.Create(&models.CampaignProduct{
+		CampaignID: campaign.ID,
+		ProductID:  product.ID,
+		FlashPrice: 9.9,
+		FlashStock: 10,
+	}).Error)
+
+	createOrder := func(quantity int) error {
+		_, err := orderSvc.CreateOrder(ctx, user.ID, &mallService.CreateMallOrderRequest{
+			Items:     []mallService.OrderItemRequest{{ProductID: product.ID, Quantity: quantity}},
+			AddressID: address.ID,
+		})
+		return err
+	}
+	assertCode := func(err error, code int) {
+		appErr, ok := err.(*appErrors.AppError)
+		require.True(t, ok, "err=%v", err)
+		assert.Equal(t, code, appErr.Code)
+	}
+
+	// 同一订单中后一项超出名额时整单失败，前一项已领取的名额随之归还
+	_, err := orderSvc.CreateOrder(ctx, user.ID, &mallService.CreateMallOrderRequest{
+		Items:     []mallService.OrderItemRequest{{ProductID: product.ID, Quantity: 2}, {ProductID: product.ID, Quantity: 4}},
+		AddressID: address.ID,
+	})
+	assertCode(err, appErrors.ErrFlashSaleSoldOut.Code)
+
+	require.NoError(t, createOrder(3))
+	// 当日名额按件数统计
+	assertCode(createOrder(3), appErrors.ErrFlashSaleSoldOut.Code)
+	require.NoError(t, createOrder(1))
+	// 每人每日限购按下单次数统计
+	assertCode(createOrder(1), appErrors.ErrFlashSaleLimitExceeded.Code)
+
+	var reserved int64
+	require.NoError(t, db.Model(&models.FlashSaleReservation{}).Where("campaign_id = ?", campaign.ID).Count(&reserved).Error)
+	assert.Equal(t, int64(2), reserved)
+
+	// Redis 名额计数只计入成功的下单
+	counter, err := mr.Get(fmt.Sprintf("flash:campaign:%d:%s", campaign.ID, time.Now().Format("20060102")))
+	require.NoError(t, err)
+	assert.Equal(t, "4", counter)
+}
+
 func TestUS3Integration_MallOrderFlow_MultipleOrdersFromSameProduct(t *testing.T) {
 	db := setupUS3IntegrationDB(t)
 	_, _, orderSvc, _ := setupUS3Services(db)
@@ -443,7 +825,7 @@ func TestUS3Integration_ReviewFlow(t *testing.T) {
 	assert.Equal(t, int(5), review.Rating)
 
 	// 3. 获取商品评价
-	reviews, err := reviewSvc.GetProductReviews(ctx, product.ID, 1, 10)
+	reviews, err := reviewSvc.GetProductReviews(ctx, product.ID, nil, 1, 10)
 	require.NoError(t, err)
 	assert.Len(t, reviews.List, 1)
 
@@ -504,9 +886,9 @@ func TestUS3Integration_CartToOrderConsistency(t *testing.T) {
 	db.Create(product3)
 
 	// 添加多个商品到购物车
-	cartSvc.AddItem(ctx, user.ID, &mallService.AddCartItemRequest{ProductID: product1.ID, Quantity: 2})
-	cartSvc.AddItem(ctx, user.ID, &mallService.AddCartItemRequest{ProductID: product2.ID, Quantity: 3})
-	cartSvc.AddItem(ctx, user.ID, &mallService.AddCartItemRequest{ProductID: product3.ID, Quantity: 1})
+	cartSvc.AddItem(ctx, repository.UserCartOwner(user.ID), &mallService.AddCartItemRequest{ProductID: product1.ID, Quantity: 2})
+	cartSvc.AddItem(ctx, repository.UserCartOwner(user.ID), &mallService.AddCartItemRequest{ProductID: product2.ID, Quantity: 3})
+	cartSvc.AddItem(ctx, repository.UserCartOwner(user.ID), &mallService.AddCartItemRequest{ProductID: product3.ID, Quantity: 1})
 
 	// 取消选中商品3
 	var cartItems []models.CartItem
@@ -531,7 +913,7 @@ func TestUS3Integration_CartToOrderConsistency(t *testing.T) {
 	assert.Len(t, order.Items, 2)
 
 	// 验证未选中商品还在购物车中
-	cart, err := cartSvc.GetCart(ctx, user.ID)
+	cart, err := cartSvc.GetCart(ctx, repository.UserCartOwner(user.ID))
 	require.NoError(t, err)
 	assert.Len(t, cart.Items, 1)
 	assert.Equal(t, product3.ID, cart.Items[0].ProductID)
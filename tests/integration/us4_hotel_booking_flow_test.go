@@ -35,6 +35,7 @@ func setupHotelTestDB(t *testing.T) *gorm.DB {
 		&models.Room{},
 		&models.RoomTimeSlot{},
 		&models.Booking{},
+		&models.RoomOccupancy{},
 	)
 	require.NoError(t, err)
 
@@ -45,10 +45,14 @@ func setupFinanceIntegrationDB(t *testing.T) *gorm.DB {
 		&models.Rental{},
 		&models.Payment{},
 		&models.Refund{},
+		&models.OrderNote{},
 		&models.Settlement{},
+		&models.SettlementItem{},
 		&models.WalletTransaction{},
 		&models.Withdrawal{},
+		&models.WithdrawalAuditLog{},
 		&models.Commission{},
+		&models.FinanceDailySummary{},
 	)
 	require.NoError(t, err)
 
@@ -66,7 +70,7 @@ func setupFinanceServices(db *gorm.DB) (*financeService.SettlementService, *fina
 	distributorRepo := repository.NewDistributorRepository(db)
 	withdrawalRepo := repository.NewWithdrawalRepository(db)
 
-	settlementSvc := financeService.NewSettlementService(db, settlementRepo, orderRepo, merchantRepo, commissionRepo, distributorRepo)
+	settlementSvc := financeService.NewSettlementService(db, settlementRepo, orderRepo, merchantRepo, commissionRepo, distributorRepo, nil)
 	statisticsSvc := financeService.NewStatisticsService(db, settlementRepo, transactionRepo, orderRepo, paymentRepo, commissionRepo, withdrawalRepo)
 	withdrawalAuditSvc := financeService.NewWithdrawalAuditService(db, withdrawalRepo, distributorRepo)
 	exportSvc := financeService.NewExportService(db, settlementRepo, transactionRepo, orderRepo, withdrawalRepo)
@@ -236,9 +240,13 @@ func TestSettlementFlow_MerchantSettlement(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, settlement.SettlementNo, detail.SettlementNo)
 
-	// 3. 处理结算
+	// 3. 未审核的结算不能直接处理
 	err = settlementSvc.ProcessSettlement(ctx, settlement.ID, 1)
-	require.NoError(t, err)
+	require.Error(t, err)
+
+	// 4. 提交审核并由他人审核通过后完成入账
+	require.NoError(t, settlementSvc.SubmitForReview(ctx, settlement.ID, 1))
+	require.NoError(t, settlementSvc.ApproveSettlement(ctx, settlement.ID, 2))
 
 	// 验证状态更新
 	var updatedSettlement models.Settlement
@@ -289,9 +297,9 @@ func TestSettlementFlow_DistributorSettlement(t *testing.T) {
 	assert.NotNil(t, settlement)
 	assert.Equal(t, models.SettlementTypeDistributor, settlement.Type)
 
-	// 2. 处理结算
-	err = settlementSvc.ProcessSettlement(ctx, settlement.ID, 1)
-	require.NoError(t, err)
+	// 2. 提交审核并由他人审核通过后完成入账
+	require.NoError(t, settlementSvc.SubmitForReview(ctx, settlement.ID, 1))
+	require.NoError(t, settlementSvc.ApproveSettlement(ctx, settlement.ID, 2))
 
 	// 验证状态
 	var updatedSettlement models.Settlement
@@ -656,7 +664,7 @@ func TestExportFlow_ExportDailyRevenue(t *testing.T) {
 	endDate := time.Now()
 
 	// 导出
-	data, filename, err := exportSvc.ExportDailyRevenue(ctx, startDate, endDate)
+	data, filename, err := exportSvc.ExportDailyRevenue(ctx, &financeService.ExportDailyRevenueRequest{StartDate: startDate, EndDate: endDate})
 	require.NoError(t, err)
 	assert.NotEmpty(t, data)
 	assert.Contains(t, filename, "daily_revenue_")
@@ -77,7 +77,7 @@ func setupMarketingTestServices(db *gorm.DB) *MarketingTestServices {
 
 	couponSvc := marketing.NewCouponService(db, couponRepo, userCouponRepo)
 	userCouponSvc := marketing.NewUserCouponService(db, couponRepo, userCouponRepo)
-	campaignSvc := marketing.NewCampaignService(campaignRepo)
+	campaignSvc := marketing.NewCampaignService(db, campaignRepo)
 	discountCalc := order.NewDiscountCalculator(couponSvc, campaignSvc)
 
 	return &MarketingTestServices{
@@ -159,7 +159,7 @@ func TestMarketingIntegration_CouponReceiveAndUse(t *testing.T) {
 		assert.Equal(t, 1, updatedCoupon.ReceivedCount)
 
 		// 6. 验证可用优惠券
-		availableCoupons, err := services.UserCouponService.GetAvailableCouponsForOrder(ctx, user.ID, models.CouponScopeAll, 150.0)
+		availableCoupons, err := services.UserCouponService.GetAvailableCouponsForOrder(ctx, user.ID, models.CouponScopeAll, 150.0, nil)
 		require.NoError(t, err)
 		assert.Len(t, availableCoupons, 1)
 
@@ -236,7 +236,7 @@ func TestMarketingIntegration_CouponRefund(t *testing.T) {
 		assert.Equal(t, 0, updatedCoupon.UsedCount)
 
 		// 6. 验证优惠券可再次使用
-		availableCoupons, err := services.UserCouponService.GetAvailableCouponsForOrder(ctx, user.ID, models.CouponScopeAll, 100.0)
+		availableCoupons, err := services.UserCouponService.GetAvailableCouponsForOrder(ctx, user.ID, models.CouponScopeAll, 100.0, nil)
 		require.NoError(t, err)
 		assert.Len(t, availableCoupons, 1)
 	})
@@ -251,18 +251,18 @@ func TestMarketingIntegration_DiscountCalculation(t *testing.T) {
 		ctx := context.Background()
 
 		// 1. 创建满减活动
+			rules, err := marketing.EncodeDiscountRules([]marketing.DiscountRule{
+				{MinAmount: 100, Discount: 10},
+				{MinAmount: 200, Discount: 25},
+			})
+			require.NoError(t, err)
 			campaign := &models.Campaign{
 				Name:      "满减活动",
 				Type:      models.CampaignTypeDiscount,
 				StartTime: time.Now().Add(-time.Hour),
 				EndTime:   time.Now().Add(24 * time.Hour),
 				Status:    models.CampaignStatusActive,
-				Rules: models.JSON{
-					"rules": []marketing.DiscountRule{
-						{MinAmount: 100, Discount: 10},
-						{MinAmount: 200, Discount: 25},
-					},
-				},
+				Rules:     rules,
 			}
 			db.Create(campaign)
 
@@ -285,7 +285,7 @@ func TestMarketingIntegration_DiscountCalculation(t *testing.T) {
 		require.NoError(t, err)
 
 		// 3. 计算订单优惠（订单金额250）
-		result, err := services.DiscountCalculator.CalculateOrderDiscount(ctx, user.ID, models.CouponScopeAll, 250.0, &userCoupon.ID)
+		result, err := services.DiscountCalculator.CalculateOrderDiscount(ctx, user.ID, models.CouponScopeAll, 250.0, nil, &userCoupon.ID)
 		require.NoError(t, err)
 
 		// 验证结果
@@ -351,7 +351,7 @@ func TestMarketingIntegration_DiscountCalculation(t *testing.T) {
 		}
 
 		// 订单金额150，应自动选择满100减20
-		result, err := services.DiscountCalculator.CalculateOrderDiscount(ctx, user.ID, models.CouponScopeAll, 150.0, nil)
+		result, err := services.DiscountCalculator.CalculateOrderDiscount(ctx, user.ID, models.CouponScopeAll, 150.0, nil, nil)
 		require.NoError(t, err)
 		assert.Equal(t, 20.0, result.CouponDiscount)
 		assert.NotNil(t, result.UserCoupon)
@@ -39,6 +39,7 @@ func setupMembershipIntegrationDB(t *testing.T) *gorm.DB {
 		&models.User{},
 		&models.MemberLevel{},
 		&models.WalletTransaction{},
+		&models.UserPointsLog{},
 		&models.Order{},
 	))
 
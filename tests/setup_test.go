@@ -44,17 +44,21 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 		&models.OrderItem{},
 		&models.Payment{},
 		&models.Refund{},
+		&models.OrderNote{},
 		// 商城模块 - US3
 		&models.Category{},
 		&models.Product{},
 		&models.ProductSku{},
+		&models.ProductSearchTerm{},
 		&models.CartItem{},
 		&models.Review{},
+		&models.ReviewReply{},
 		// 酒店模块 - US4
 		&models.Hotel{},
 		&models.Room{},
 		&models.RoomTimeSlot{},
 		&models.Booking{},
+		&models.RoomOccupancy{},
 	)
 	require.NoError(t, err, "failed to migrate test database")
 
@@ -46,7 +46,7 @@ func setupCampaignServiceTestDB(t *testing.T) *gorm.DB {
 // createCampaignTestService 创建测试服务
 func createCampaignTestService(db *gorm.DB) *marketing.CampaignService {
 	campaignRepo := repository.NewCampaignRepository(db)
-	return marketing.NewCampaignService(campaignRepo)
+	return marketing.NewCampaignService(db, campaignRepo)
 }
 
 // createTestCampaign 创建测试活动
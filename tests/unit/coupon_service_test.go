@@ -452,11 +452,11 @@ func TestCouponService_CalculateDiscount(t *testing.T) {
 		}
 
 		// 订单金额满足门槛
-		discount := svc.CalculateDiscount(coupon, 100.0)
+		discount := svc.CalculateDiscount(coupon, 100.0, nil)
 		assert.Equal(t, 10.0, discount)
 
 		// 订单金额不满足门槛
-		discount = svc.CalculateDiscount(coupon, 30.0)
+		discount = svc.CalculateDiscount(coupon, 30.0, nil)
 		assert.Equal(t, 0.0, discount)
 	})
 
@@ -471,11 +471,11 @@ func TestCouponService_CalculateDiscount(t *testing.T) {
 		}
 
 		// 订单金额满足门槛
-		discount := svc.CalculateDiscount(coupon, 100.0)
+		discount := svc.CalculateDiscount(coupon, 100.0, nil)
 		assert.Equal(t, 10.0, discount)
 
 		// 订单金额不满足门槛
-		discount = svc.CalculateDiscount(coupon, 30.0)
+		discount = svc.CalculateDiscount(coupon, 30.0, nil)
 		assert.Equal(t, 0.0, discount)
 	})
 
@@ -492,7 +492,7 @@ func TestCouponService_CalculateDiscount(t *testing.T) {
 		}
 
 		// 计算优惠为30，但最大只能20
-		discount := svc.CalculateDiscount(coupon, 150.0)
+		discount := svc.CalculateDiscount(coupon, 150.0, nil)
 		assert.Equal(t, 20.0, discount)
 	})
 
@@ -507,7 +507,7 @@ func TestCouponService_CalculateDiscount(t *testing.T) {
 		}
 
 		// 订单金额50，优惠最多50
-		discount := svc.CalculateDiscount(coupon, 50.0)
+		discount := svc.CalculateDiscount(coupon, 50.0, nil)
 		assert.Equal(t, 50.0, discount)
 	})
 }
@@ -545,7 +545,7 @@ func TestCouponService_GetBestCouponForOrder(t *testing.T) {
 		svc.ReceiveCoupon(context.Background(), coupon3.ID, user.ID)
 
 		// 订单金额150，应该选择减20的
-		bestCoupon, discount, err := svc.GetBestCouponForOrder(context.Background(), user.ID, models.CouponScopeAll, 150.0)
+		bestCoupon, discount, err := svc.GetBestCouponForOrder(context.Background(), user.ID, models.CouponScopeAll, 150.0, nil)
 		require.NoError(t, err)
 		assert.NotNil(t, bestCoupon)
 		assert.Equal(t, 20.0, discount)
@@ -557,7 +557,7 @@ func TestCouponService_GetBestCouponForOrder(t *testing.T) {
 		svc := createTestCouponService(db)
 		user := createTestUser(db)
 
-		bestCoupon, discount, err := svc.GetBestCouponForOrder(context.Background(), user.ID, models.CouponScopeAll, 100.0)
+		bestCoupon, discount, err := svc.GetBestCouponForOrder(context.Background(), user.ID, models.CouponScopeAll, 100.0, nil)
 		require.NoError(t, err)
 		assert.Nil(t, bestCoupon)
 		assert.Equal(t, 0.0, discount)
@@ -577,7 +577,7 @@ func TestCouponService_GetBestCouponForOrder(t *testing.T) {
 		svc.ReceiveCoupon(context.Background(), coupon.ID, user.ID)
 
 		// 订单金额50，不满足门槛
-		bestCoupon, discount, err := svc.GetBestCouponForOrder(context.Background(), user.ID, models.CouponScopeAll, 50.0)
+		bestCoupon, discount, err := svc.GetBestCouponForOrder(context.Background(), user.ID, models.CouponScopeAll, 50.0, nil)
 		require.NoError(t, err)
 		assert.Nil(t, bestCoupon)
 		assert.Equal(t, 0.0, discount)
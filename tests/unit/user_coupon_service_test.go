@@ -289,7 +289,7 @@ func TestUserCouponService_GetAvailableCouponsForOrder(t *testing.T) {
 		createUCTestUserCoupon(db, user.ID, coupon2.ID)
 
 		// 订单金额100
-		result, err := svc.GetAvailableCouponsForOrder(context.Background(), user.ID, models.CouponScopeAll, 100.0)
+		result, err := svc.GetAvailableCouponsForOrder(context.Background(), user.ID, models.CouponScopeAll, 100.0, nil)
 		require.NoError(t, err)
 		assert.Len(t, result, 1)
 		assert.Equal(t, "满50减10", result[0].CouponName)
@@ -325,7 +325,7 @@ func TestUserCouponService_GetAvailableCouponsForOrder(t *testing.T) {
 		createUCTestUserCoupon(db, user.ID, coupon3.ID)
 
 		// 商城订单应该能使用全场通用和商城专用
-		result, err := svc.GetAvailableCouponsForOrder(context.Background(), user.ID, "mall", 100.0)
+		result, err := svc.GetAvailableCouponsForOrder(context.Background(), user.ID, "mall", 100.0, nil)
 		require.NoError(t, err)
 		assert.Len(t, result, 2)
 	})